@@ -166,7 +166,7 @@ impl TypeConverterTable {
                                 let mut buf = itoa::Buffer::new();
                                 Ok(buf.format(*i).as_bytes().to_vec())
                             },
-                            rusqlite::types::Value::Real(r) => Ok(r.to_string().as_bytes().to_vec()),
+                            rusqlite::types::Value::Real(r) => Ok(crate::types::numeric_utils::format_float(*r).into_bytes()),
                             rusqlite::types::Value::Null => Ok(Vec::new()),
                             rusqlite::types::Value::Blob(b) => Ok(b.clone()),
                         }
@@ -181,7 +181,7 @@ impl TypeConverterTable {
                         let mut buf = itoa::Buffer::new();
                         Ok(buf.format(*i).as_bytes().to_vec())
                     },
-                    rusqlite::types::Value::Real(r) => Ok(r.to_string().as_bytes().to_vec()),
+                    rusqlite::types::Value::Real(r) => Ok(crate::types::numeric_utils::format_float(*r).into_bytes()),
                     rusqlite::types::Value::Null => Ok(Vec::new()),
                     rusqlite::types::Value::Blob(b) => {
                         // SQLite may return BLOB for some text values, convert to string
@@ -249,7 +249,7 @@ impl TypeConverterTable {
                 },
                 // 3: Float converter
                 |value| match value {
-                    rusqlite::types::Value::Real(r) => Ok(r.to_string().as_bytes().to_vec()),
+                    rusqlite::types::Value::Real(r) => Ok(crate::types::numeric_utils::format_float(*r).into_bytes()),
                     rusqlite::types::Value::Integer(i) => Ok((*i as f64).to_string().as_bytes().to_vec()),
                     rusqlite::types::Value::Text(s) => Ok(s.as_bytes().to_vec()),
                     rusqlite::types::Value::Null => Ok(Vec::new()),
@@ -327,7 +327,7 @@ impl TypeConverterTable {
                     let mut buf = itoa::Buffer::new();
                     Ok(buf.format(*i).as_bytes().to_vec())
                 },
-                rusqlite::types::Value::Real(r) => Ok(r.to_string().as_bytes().to_vec()),
+                rusqlite::types::Value::Real(r) => Ok(crate::types::numeric_utils::format_float(*r).into_bytes()),
                 rusqlite::types::Value::Null => Ok(Vec::new()),
                 _ => Ok("".as_bytes().to_vec()),
             }
@@ -227,6 +227,7 @@ impl SchemaCache {
                     crate::types::PgType::Macaddr8 => "macaddr8",
                     crate::types::PgType::Bit => "bit",
                     crate::types::PgType::Varbit => "varbit",
+                    crate::types::PgType::Xml => "xml",
                     crate::types::PgType::Varchar => "varchar",
                     crate::types::PgType::Char => "char",
                     crate::types::PgType::Time => "time",
@@ -142,7 +142,7 @@ impl StatementPool {
                         }
                     },
                     rusqlite::types::ValueRef::Real(f) => {
-                        row_data.push(Some(f.to_string().into_bytes()));
+                        row_data.push(Some(crate::types::numeric_utils::format_float(f).into_bytes()));
                     },
                     rusqlite::types::ValueRef::Text(s) => {
                         row_data.push(Some(s.to_vec()));
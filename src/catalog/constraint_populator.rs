@@ -3,6 +3,7 @@ use anyhow::Result;
 use tracing::{debug, info};
 use once_cell::sync::Lazy;
 use regex::Regex;
+use crate::translator::sql_scan::matching_paren;
 
 // Pre-compiled regex patterns for constraint parsing
 static PK_REGEX: Lazy<Regex> = Lazy::new(|| {
@@ -22,17 +23,13 @@ static TABLE_UNIQUE_REGEX: Lazy<Regex> = Lazy::new(|| {
 });
 
 static CHECK_REGEX: Lazy<Regex> = Lazy::new(|| {
-    Regex::new(r"(?i)CHECK\s*\(\s*([^)]+)\s*\)").unwrap()
+    Regex::new(r"(?i)\bCHECK\s*\(").unwrap()
 });
 
 static NOT_NULL_REGEX: Lazy<Regex> = Lazy::new(|| {
     Regex::new(r"(?i)\b(\w+)\s+[^,\)]*\bNOT\s+NULL\b").unwrap()
 });
 
-static DEFAULT_REGEX: Lazy<Regex> = Lazy::new(|| {
-    Regex::new(r"(?i)\b(\w+)\s+[^,\)]*\bDEFAULT\s+([^,\)]+)").unwrap()
-});
-
 static FOREIGN_KEY_REGEX: Lazy<Regex> = Lazy::new(|| {
     Regex::new(r"(?i)FOREIGN\s+KEY\s*\(\s*([^)]+)\s*\)\s+REFERENCES\s+(\w+)\s*\(\s*([^)]+)\s*\)").unwrap()
 });
@@ -60,11 +57,8 @@ pub fn populate_constraints_for_table(conn: &Connection, table_name: &str) -> Re
     // Parse and populate constraints
     populate_table_constraints(conn, table_name, &create_sql, &table_oid)?;
 
-    // Parse and populate column defaults
-    populate_column_defaults(conn, table_name, &create_sql, &table_oid)?;
-
-    // Populate indexes (including those created by UNIQUE constraints)
-    populate_table_indexes(conn, table_name, &table_oid)?;
+    // pg_attrdef and pg_index are views over the live schema, so defaults and
+    // indexes (including ones created later) need no population here
 
     // Populate dependencies (for Rails sequence ownership detection)
     populate_table_dependencies(conn, table_name, &table_oid)?;
@@ -81,7 +75,13 @@ fn get_create_table_sql(conn: &Connection, table_name: &str) -> Result<String> {
 }
 
 /// Generate table OID using the same algorithm as the pg_class view
-fn generate_table_oid(name: &str) -> String {
+pub(crate) fn generate_table_oid(name: &str) -> String {
+    table_oid(name).to_string()
+}
+
+/// The OID of a table or index, as the pg_class view reports it. Everything that hands out
+/// or looks up relation OIDs (comments, regclass, catalog functions) uses this one formula
+pub(crate) fn table_oid(name: &str) -> u32 {
     // Must match the formula in pg_class view for JOIN compatibility:
     // (unicode(substr(name, 1, 1)) * 1000000) +
     // (unicode(substr(name || ' ', 2, 1)) * 10000) +
@@ -89,17 +89,17 @@ fn generate_table_oid(name: &str) -> String {
     // (length(name) * 7)
     let name_with_padding = format!("{}  ", name);
     let chars: Vec<char> = name_with_padding.chars().collect();
-    let char1 = chars.get(0).copied().unwrap_or(' ') as u32;
-    let char2 = chars.get(1).copied().unwrap_or(' ') as u32;
-    let char3 = chars.get(2).copied().unwrap_or(' ') as u32;
-    let length = name.len() as u32;
+    // SQLite computes this in 64-bit integers
+    let char1 = chars.get(0).copied().unwrap_or(' ') as u64;
+    let char2 = chars.get(1).copied().unwrap_or(' ') as u64;
+    let char3 = chars.get(2).copied().unwrap_or(' ') as u64;
+    let length = name.chars().count() as u64;
 
-    let oid = ((char1 * 1000000) + (char2 * 10000) + (char3 * 100) + (length * 7)) % 1000000 + 16384;
-    oid.to_string()
+    (((char1 * 1000000) + (char2 * 10000) + (char3 * 100) + (length * 7)) % 1000000 + 16384) as u32
 }
 
 /// Generate constraint OID with better collision avoidance
-fn generate_constraint_oid(name: &str, contype: &str) -> String {
+pub(crate) fn generate_constraint_oid(name: &str, contype: &str) -> String {
     use crate::utils::generate_oid;
     // Add the constraint type to the name to avoid collisions between different constraint types
     let unique_name = format!("{}_{}", name, contype);
@@ -146,6 +146,10 @@ fn populate_table_constraints(conn: &Connection, table_name: &str, create_sql: &
                 .map(|n| n.to_string())
                 .collect();
 
+            let (update_action, delete_action) = constraint.columns.first()
+                .map(|col_name| get_foreign_key_actions(conn, table_name, col_name))
+                .unwrap_or_else(|| ("a".to_string(), "a".to_string()));
+
             // Check if this OID already exists
             let existing: Result<String, _> = conn.query_row(
                 "SELECT conname FROM pg_constraint WHERE oid = ?1",
@@ -171,8 +175,8 @@ fn populate_table_constraints(conn: &Connection, table_name: &str, create_sql: &
                     ref_table_oid,                           // confrelid as TEXT (to match pg_class.oid)
                     col_nums.join(","),    // Column numbers as comma-separated list (no braces for LIKE pattern)
                     "1".to_string(),       // Default to column 1 of referenced table
-                    "a".to_string(),   // NO ACTION (default)
-                    "a".to_string(),   // NO ACTION (default)
+                    update_action,
+                    delete_action,
                     "s".to_string(),   // SIMPLE (default)
                     true,              // conislocal as boolean
                     true,              // convalidated as boolean
@@ -214,123 +218,6 @@ fn populate_table_constraints(conn: &Connection, table_name: &str, create_sql: &
     Ok(())
 }
 
-/// Populate pg_attrdef table with column default information
-fn populate_column_defaults(conn: &Connection, table_name: &str, create_sql: &str, table_oid: &str) -> Result<()> {
-    let defaults = parse_column_defaults(table_name, create_sql);
-    
-    for default in defaults {
-        conn.execute(
-            "INSERT OR IGNORE INTO pg_attrdef (
-                oid, adrelid, adnum, adsrc
-            ) VALUES (?1, ?2, ?3, ?4)",
-            [
-                &default.oid,
-                table_oid,
-                &default.column_num.to_string(),
-                &default.default_expr,
-            ]
-        )?;
-        
-        debug!("Inserted default: column {} = '{}' for table: {}", 
-               default.column_num, default.default_expr, table_name);
-    }
-    
-    Ok(())
-}
-
-/// Populate pg_index table with index information
-fn populate_table_indexes(conn: &Connection, table_name: &str, table_oid: &str) -> Result<()> {
-    // First, get table columns to map column names to numbers (1-based like PostgreSQL)
-    let mut column_map = std::collections::HashMap::new();
-    let query = format!("PRAGMA table_info({})", table_name);
-    let mut column_stmt = conn.prepare(&query)?;
-    let column_rows = column_stmt.query_map([], |row| {
-        let cid: i32 = row.get(0)?;
-        let name: String = row.get(1)?;
-        Ok((name, cid + 1)) // Convert to 1-based like PostgreSQL attnum
-    })?;
-
-    for column_result in column_rows {
-        let (name, attnum) = column_result?;
-        column_map.insert(name, attnum);
-    }
-
-    // Get indexes using PRAGMA index_list
-    let query = format!("PRAGMA index_list({})", table_name);
-    let mut index_stmt = conn.prepare(&query)?;
-    let index_rows = index_stmt.query_map([], |row| {
-        let seq: i32 = row.get(0)?;
-        let name: String = row.get(1)?;
-        let is_unique: bool = row.get(2)?;
-        let origin: String = row.get(3)?;
-        let partial: bool = row.get(4)?;
-        Ok((seq, name, is_unique, origin, partial))
-    })?;
-
-    for index_result in index_rows {
-        let (_seq, index_name, is_unique, origin, _partial) = index_result?;
-        let index_oid = generate_constraint_oid(&index_name, "i");
-
-        // Skip auto-indexes created by SQLite for unique constraints
-        if index_name.starts_with("sqlite_") {
-            continue;
-        }
-
-        // Get column information for this index using PRAGMA index_info
-        let query = format!("PRAGMA index_info({})", index_name);
-        let mut info_stmt = conn.prepare(&query)?;
-        let info_rows = info_stmt.query_map([], |row| {
-            let seqno: i32 = row.get(0)?;
-            let cid: i32 = row.get(1)?;
-            let name: Option<String> = row.get(2)?;
-            Ok((seqno, cid, name))
-        })?;
-
-        let mut column_numbers = Vec::new();
-        let mut column_count = 0;
-
-        for info_result in info_rows {
-            let (_seqno, _cid, col_name_opt) = info_result?;
-            if let Some(col_name) = col_name_opt
-                && let Some(&attnum) = column_map.get(&col_name) {
-                column_numbers.push(attnum.to_string());
-                column_count += 1;
-            }
-        }
-
-        // Build indkey field (space-separated column numbers, PostgreSQL format)
-        let indkey = column_numbers.join(" ");
-
-        // Determine if this is a primary key index
-        let is_primary = origin == "pk" || index_name.contains("primary") || index_name.contains("pkey");
-
-        conn.execute(
-            "INSERT OR IGNORE INTO pg_index (
-                indexrelid, indrelid, indnatts, indnkeyatts,
-                indisunique, indisprimary, indkey,
-                indisexclusion, indimmediate, indisclustered,
-                indisvalid, indcheckxmin, indisready, indislive,
-                indisreplident, indcollation, indclass, indoption,
-                indexprs, indpred
-            ) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, 0, 1, 0, 1, 0, 1, 1, 0, '', '', '', '', '')",
-            [
-                &index_oid,
-                table_oid,
-                &column_count.to_string(),
-                &column_count.to_string(), // For regular indexes, indnkeyatts = indnatts
-                &(is_unique as i32).to_string(),
-                &(is_primary as i32).to_string(),
-                &indkey,
-            ]
-        )?;
-
-        debug!("Inserted index: {} (unique: {}, primary: {}, columns: {}) for table: {}",
-               index_name, is_unique, is_primary, indkey, table_name);
-    }
-
-    Ok(())
-}
-
 /// Information about a constraint
 #[derive(Debug)]
 struct ConstraintInfo {
@@ -341,12 +228,35 @@ struct ConstraintInfo {
     definition: String,
 }
 
-/// Information about a column default
-#[derive(Debug)]
-struct DefaultInfo {
-    oid: String,
-    column_num: i16,
-    default_expr: String,
+/// Get the ON UPDATE / ON DELETE action codes for a foreign key column as pg_constraint
+/// confupdtype/confdeltype values, read from SQLite's enforced foreign key definition
+fn get_foreign_key_actions(conn: &Connection, table_name: &str, column_name: &str) -> (String, String) {
+    let actions: Option<(String, String)> = conn
+        .prepare(&format!("PRAGMA foreign_key_list(\"{table_name}\")"))
+        .ok()
+        .and_then(|mut stmt| {
+            stmt.query_map([], |row| Ok((row.get::<_, String>(3)?, row.get::<_, String>(5)?, row.get::<_, String>(6)?)))
+                .ok()?
+                .filter_map(|r| r.ok())
+                .find(|(from, _, _)| from.eq_ignore_ascii_case(column_name))
+                .map(|(_, on_update, on_delete)| (on_update, on_delete))
+        });
+
+    match actions {
+        Some((on_update, on_delete)) => (fk_action_code(&on_update).to_string(), fk_action_code(&on_delete).to_string()),
+        None => ("a".to_string(), "a".to_string()),
+    }
+}
+
+/// Map a SQLite foreign key action to its pg_constraint action code
+fn fk_action_code(action: &str) -> &'static str {
+    match action.to_uppercase().as_str() {
+        "CASCADE" => "c",
+        "SET NULL" => "n",
+        "SET DEFAULT" => "d",
+        "RESTRICT" => "r",
+        _ => "a", // NO ACTION
+    }
 }
 
 /// Parse table constraints from CREATE TABLE statement
@@ -417,17 +327,15 @@ fn parse_table_constraints(table_name: &str, create_sql: &str) -> Vec<Constraint
     }
     
     // Parse CHECK constraints
-    for (i, cap) in CHECK_REGEX.captures_iter(create_sql).enumerate() {
-        if let Some(check_expr) = cap.get(1) {
-            let constraint_name = format!("{}_check{}", table_name, i + 1);
-            constraints.push(ConstraintInfo {
-                oid: generate_constraint_oid(&constraint_name, "c"),
-                name: constraint_name,
-                contype: "c".to_string(),
-                columns: vec![], // CHECK constraints don't have specific columns
-                definition: format!("CHECK ({})", check_expr.as_str()),
-            });
-        }
+    for (i, check_expr) in check_expressions(create_sql).into_iter().enumerate() {
+        let constraint_name = format!("{}_check{}", table_name, i + 1);
+        constraints.push(ConstraintInfo {
+            oid: generate_constraint_oid(&constraint_name, "c"),
+            name: constraint_name,
+            contype: "c".to_string(),
+            columns: vec![], // CHECK constraints don't have specific columns
+            definition: format!("CHECK ({check_expr})"),
+        });
     }
     
     // Parse NOT NULL constraints (treated as check constraints in PostgreSQL)
@@ -490,25 +398,12 @@ fn parse_table_constraints(table_name: &str, create_sql: &str) -> Vec<Constraint
     constraints
 }
 
-/// Parse column defaults from CREATE TABLE statement
-fn parse_column_defaults(table_name: &str, create_sql: &str) -> Vec<DefaultInfo> {
-    let mut defaults = Vec::new();
-    
-    // Parse DEFAULT clauses - look for column definitions with DEFAULT
-    for cap in DEFAULT_REGEX.captures_iter(create_sql) {
-        if let (Some(column_name), Some(default_value)) = (cap.get(1), cap.get(2)) {
-            // Get column number by counting columns before this one
-            let column_num = get_column_number(create_sql, column_name.as_str()).unwrap_or(1);
-            
-            defaults.push(DefaultInfo {
-                oid: generate_constraint_oid(&format!("{}_{}_default", table_name, column_name.as_str()), "d"),
-                column_num,
-                default_expr: default_value.as_str().trim().to_string(),
-            });
-        }
-    }
-    
-    defaults
+/// Expressions of the CHECK constraints in a CREATE TABLE statement, matching nested
+/// parentheses so calls like length(name) stay inside the expression
+fn check_expressions(create_sql: &str) -> Vec<&str> {
+    CHECK_REGEX.find_iter(create_sql)
+        .filter_map(|m| matching_paren(create_sql, m.end() - 1).map(|close| create_sql[m.end()..close].trim()))
+        .collect()
 }
 
 /// Get the column number (1-based) for a given column name in a CREATE TABLE statement
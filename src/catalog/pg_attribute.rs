@@ -155,13 +155,13 @@ async fn add_table_attributes(
     selected_indices: &[usize],
     already_filtered_by_table: bool,
 ) -> Result<(), PgSqliteError> {
-    let table_oid = generate_oid_from_name(table_name);
+    let table_oid = super::constraint_populator::table_oid(table_name);
 
     debug!("Getting column info for table: {}", table_name);
     println!("PG_ATTRIBUTE DEBUG: Getting column info for table: {}", table_name);
 
-    // Get column information from PRAGMA
-    let col_info_query = format!("PRAGMA table_info({table_name})");
+    // Get column information from PRAGMA, table_xinfo also lists generated columns
+    let col_info_query = format!("PRAGMA table_xinfo({table_name})");
     println!("PG_ATTRIBUTE DEBUG: Running PRAGMA query: {}", col_info_query);
     let col_info = db.query(&col_info_query).await?;
     println!("PG_ATTRIBUTE DEBUG: PRAGMA query returned {} rows", col_info.rows.len());
@@ -170,24 +170,53 @@ async fn add_table_attributes(
     
     // Also check if we have type info in __pgsqlite_schema
     let schema_query = format!(
-        "SELECT column_name, pg_type FROM __pgsqlite_schema WHERE table_name = '{table_name}'"
+        "SELECT column_name, pg_type, type_modifier FROM __pgsqlite_schema WHERE table_name = '{table_name}'"
     );
     let schema_info = db.query(&schema_query).await.ok();
     
     // Build a map of column name to pg_type
     let mut type_map = std::collections::HashMap::new();
+    // The fractional seconds precision of time(p) and timestamp(p) columns
+    let mut precision_map = std::collections::HashMap::new();
     if let Some(schema) = schema_info {
         for row in &schema.rows {
             if let (Some(Some(col_bytes)), Some(Some(type_bytes))) = (row.first(), row.get(1)) {
                 let col_name = String::from_utf8_lossy(col_bytes);
                 let pg_type = String::from_utf8_lossy(type_bytes);
+                if crate::validator::DatetimePrecisionTriggers::is_datetime_type(&pg_type)
+                    && let Some(Some(modifier_bytes)) = row.get(2)
+                    && let Ok(precision) = String::from_utf8_lossy(modifier_bytes).parse::<i32>() {
+                        precision_map.insert(col_name.to_string(), precision);
+                    }
                 type_map.insert(col_name.to_string(), pg_type.to_string());
             }
         }
     }
     
+    // GENERATED ALWAYS ('a') and BY DEFAULT ('d') identity columns recorded at CREATE TABLE
+    let identity_query = format!(
+        "SELECT column_name, generation FROM __pgsqlite_identity_columns WHERE table_name = '{table_name}'"
+    );
+    let mut identity_map = std::collections::HashMap::new();
+    if let Ok(identity) = db.query(&identity_query).await {
+        for row in &identity.rows {
+            if let (Some(Some(col_bytes)), Some(Some(generation_bytes))) = (row.first(), row.get(1)) {
+                let generation = if generation_bytes.as_slice() == b"a" { "a" } else { "d" };
+                identity_map.insert(String::from_utf8_lossy(col_bytes).to_string(), generation);
+            }
+        }
+    }
+    
     for (idx, col_row) in col_info.rows.iter().enumerate() {
-        // PRAGMA table_info returns: cid, name, type, notnull, dflt_value, pk
+        // PRAGMA table_xinfo returns: cid, name, type, notnull, dflt_value, pk, hidden
+        // (1 for the hidden columns of virtual tables, 2 and 3 for VIRTUAL and STORED generated columns)
+        let hidden = col_row.get(6)
+            .and_then(|v| v.as_ref())
+            .map(|v| String::from_utf8_lossy(v).to_string())
+            .unwrap_or_default();
+        if hidden == "1" {
+            continue;
+        }
         if let Some(Some(col_name_bytes)) = col_row.get(1) {
             let col_name = String::from_utf8_lossy(col_name_bytes);
             let sqlite_type = col_row.get(2)
@@ -200,12 +229,24 @@ async fn add_table_attributes(
                 .map(|v| String::from_utf8_lossy(v) == "1")
                 .unwrap_or(false);
                 
-            let has_default = col_row.get(4).and_then(|v| v.as_ref()).is_some();
+            // PostgreSQL keeps the expression of a generated column as its default
+            let has_default = col_row.get(4).and_then(|v| v.as_ref()).is_some() || hidden == "2" || hidden == "3";
 
             // Get the actual default expression from PRAGMA table_info
             let default_expr = col_row.get(4)
                 .and_then(|v| v.as_ref())
-                .map(|v| String::from_utf8_lossy(v).to_string());
+                .map(|v| String::from_utf8_lossy(v).to_string())
+                .map(|expr| if expr == "datetime('now')" { "now()".to_string() } else { expr });
+
+            // SERIAL columns become AUTOINCREMENT keys without a SQLite default, PostgreSQL
+            // reports the nextval() of their sequence, as pg_attrdef does
+            let is_serial = type_map.get(col_name.as_ref()).is_some_and(|pg_type| {
+                matches!(pg_type.to_uppercase().as_str(), "SERIAL" | "SERIAL4" | "BIGSERIAL" | "SERIAL8" | "SMALLSERIAL" | "SERIAL2")
+            });
+            let (has_default, default_expr) = match default_expr {
+                None if is_serial => (true, Some(format!("nextval('{table_name}_{col_name}_seq'::regclass)"))),
+                default_expr => (has_default, default_expr),
+            };
             
             // Check if this column is a primary key (pk flag is at index 5)
             let is_primary_key = col_row.get(5)
@@ -217,16 +258,15 @@ async fn add_table_attributes(
             let notnull = notnull || is_primary_key;
 
             // Determine if this is an identity/serial column
-            let (attidentity, attgenerated) = if is_primary_key && sqlite_type.to_uppercase().contains("INTEGER") {
+            let (attidentity, attgenerated) = if let Some(generation) = identity_map.get(col_name.as_ref()) {
+                (*generation, "")
+            } else if is_primary_key && sqlite_type.to_uppercase().contains("INTEGER") {
                 // INTEGER PRIMARY KEY in SQLite behaves like PostgreSQL SERIAL
                 ("d", "") // 'd' = GENERATED BY DEFAULT (like SERIAL)
-            } else if let Some(ref default_val) = default_expr {
-                // Check for generated column patterns
-                if default_val.to_uppercase().contains("GENERATED") {
-                    ("", "s") // 's' = stored generated column
-                } else {
-                    ("", "")
-                }
+            } else if hidden == "3" {
+                ("", "s") // 's' = stored generated column
+            } else if hidden == "2" {
+                ("", "v") // 'v' = virtual generated column
             } else {
                 ("", "")
             };
@@ -290,6 +330,7 @@ async fn add_table_attributes(
             } else {
                 map_sqlite_to_pg_type(&sqlite_type)
             };
+            let atttypmod = precision_map.get(col_name.as_ref()).copied().unwrap_or(atttypmod);
             
             // Build row data for WHERE evaluation
             let mut row_data = HashMap::new();
@@ -316,7 +357,7 @@ async fn add_table_attributes(
             row_data.insert("attcollation".to_string(), "0".to_string());
 
             // Add the default expression if available (non-standard pg_attribute extension)
-            row_data.insert("adsrc".to_string(), default_expr.unwrap_or_else(|| "".to_string()));
+            row_data.insert("adsrc".to_string(), default_expr.clone().unwrap_or_default());
             
             // Evaluate WHERE clause if present, but skip if we already filtered by table
             // Since we extracted the table filter, we don't need to evaluate the complex WHERE clause again
@@ -364,6 +405,7 @@ async fn add_table_attributes(
                     None,                                                  // 22: attoptions
                     None,                                                  // 23: attfdwoptions
                     None,                                                  // 24: attmissingval
+                    default_expr.map(String::into_bytes),                  // 25: adsrc
                 ];
                 
                 // Project only the selected columns
@@ -499,10 +541,33 @@ fn extract_table_from_expr(expr: &Expr) -> Option<String> {
     None
 }
 
+/// The atttypid and atttypmod of a column, from the type recorded in __pgsqlite_schema
+/// or else its SQLite declared type. Used by the pg_attribute view, which looks up
+/// ENUM types itself.
+pub(crate) fn column_type(pg_type: Option<&str>, sqlite_type: &str, type_modifier: Option<i32>) -> (i32, i32) {
+    let Some(pg_type) = pg_type else {
+        let (oid, _, atttypmod) = map_sqlite_to_pg_type(sqlite_type);
+        return (oid, atttypmod);
+    };
+    let (oid, _, atttypmod) = parse_pg_type(pg_type);
+    // time(p) and timestamp(p) keep their precision as the type modifier
+    match type_modifier {
+        Some(precision) if crate::validator::DatetimePrecisionTriggers::is_datetime_type(pg_type) => (oid, precision),
+        _ => (oid, atttypmod),
+    }
+}
+
 fn parse_pg_type(pg_type_str: &str) -> (i32, i16, i32) {
     // Parse PostgreSQL type string and return (oid, attlen, atttypmod)
     let type_upper = pg_type_str.to_uppercase();
     
+    // Arrays have their own OID and keep the element type's modifier
+    if let Some(element) = type_upper.trim().strip_suffix("[]") {
+        let (oid, _, atttypmod) = parse_pg_type(element);
+        let array_oid = PgType::from_oid(oid).and_then(|pg_type| pg_type.array_type()).map_or(PgType::TextArray.to_oid(), |array| array.to_oid());
+        return (array_oid, -1, atttypmod);
+    }
+    
     // Extract base type and modifiers
     let (base_type, type_mod) = if let Some(paren_pos) = type_upper.find('(') {
         let base = &type_upper[..paren_pos].trim();
@@ -522,8 +587,8 @@ fn parse_pg_type(pg_type_str: &str) -> (i32, i16, i32) {
         "FLOAT4" | "REAL" => (PgType::Float4.to_oid(), 4),
         "FLOAT8" | "DOUBLE PRECISION" => (PgType::Float8.to_oid(), 8),
         "TEXT" => (PgType::Text.to_oid(), -1),
-        "VARCHAR" => (PgType::Varchar.to_oid(), -1),
-        "CHAR" => (PgType::Char.to_oid(), -1),
+        "VARCHAR" | "CHARACTER VARYING" => (PgType::Varchar.to_oid(), -1),
+        "CHAR" | "CHARACTER" | "BPCHAR" => (PgType::Char.to_oid(), -1),
         "BYTEA" => (PgType::Bytea.to_oid(), -1),
         "DATE" => (PgType::Date.to_oid(), 4),
         "TIME" => (PgType::Time.to_oid(), 8),
@@ -543,7 +608,7 @@ fn parse_pg_type(pg_type_str: &str) -> (i32, i16, i32) {
     
     // Calculate atttypmod
     let atttypmod = match base_type.as_str() {
-        "VARCHAR" | "CHAR" => {
+        "VARCHAR" | "CHARACTER VARYING" | "CHAR" | "CHARACTER" | "BPCHAR" => {
             if let Some(mods) = type_mod {
                 if let Ok(len) = mods[0].parse::<i32>() {
                     len + 4 // PostgreSQL adds 4 to the length
@@ -622,13 +687,3 @@ fn map_sqlite_to_pg_type(sqlite_type: &str) -> (i32, i16, i32) {
     
     (oid, attlen, -1) // atttypmod = -1 for no modifier
 }
-
-fn generate_oid_from_name(name: &str) -> u32 {
-    // Generate a stable OID from name using a simple hash
-    // Start at 16384 to avoid conflicts with system OIDs
-    let mut hash = 0u32;
-    for byte in name.bytes() {
-        hash = hash.wrapping_mul(31).wrapping_add(byte as u32);
-    }
-    16384 + (hash % 1000000)
-}
\ No newline at end of file
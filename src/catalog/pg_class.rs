@@ -14,8 +14,8 @@ impl PgClassHandler {
     ) -> Result<DbResponse, PgSqliteError> {
         debug!("Handling pg_class query");
         
-        // Get list of tables from SQLite
-        let tables_response = db.query("SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '__pgsqlite_%'").await?;
+        // Get list of tables and views from SQLite
+        let tables_response = db.query("SELECT name, type FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '__pgsqlite_%'").await?;
         
         // Define all available columns - PostgreSQL has 33 columns in pg_class
         let all_columns = vec![
@@ -70,6 +70,8 @@ impl PgClassHandler {
         for table_row in &tables_response.rows {
             if let Some(Some(table_name_bytes)) = table_row.first() {
                 let table_name = String::from_utf8_lossy(table_name_bytes);
+                let is_view = matches!(table_row.get(1), Some(Some(kind)) if kind == b"view");
+                let relkind = if is_view { "v" } else { "r" };
                 
                 // Get column count for this table
                 let col_count_query = format!("PRAGMA table_info({table_name})");
@@ -77,13 +79,15 @@ impl PgClassHandler {
                 let relnatts = col_info.rows.len() as i16;
                 
                 // Generate a stable OID from table name
-                let oid = generate_oid_from_name(&table_name);
+                let oid = super::constraint_populator::table_oid(&table_name);
                 
                 // Check if table has indexes
                 let index_query = format!("PRAGMA index_list({table_name})");
                 let index_info = db.query(&index_query).await?;
                 let relhasindex = !index_info.rows.is_empty();
                 
+                let (relpages, reltuples) = Self::relation_statistics(db, &table_name).await;
+                
                 // Build row data for WHERE evaluation
                 let mut row_data = HashMap::new();
                 row_data.insert("oid".to_string(), oid.to_string());
@@ -95,14 +99,14 @@ impl PgClassHandler {
                 row_data.insert("relam".to_string(), "0".to_string());
                 row_data.insert("relfilenode".to_string(), oid.to_string());
                 row_data.insert("reltablespace".to_string(), "0".to_string());
-                row_data.insert("relpages".to_string(), "0".to_string());
-                row_data.insert("reltuples".to_string(), "-1".to_string());
+                row_data.insert("relpages".to_string(), relpages.clone());
+                row_data.insert("reltuples".to_string(), reltuples.clone());
                 row_data.insert("relallvisible".to_string(), "0".to_string());
                 row_data.insert("reltoastrelid".to_string(), "0".to_string());
                 row_data.insert("relhasindex".to_string(), if relhasindex { "t" } else { "f" }.to_string());
                 row_data.insert("relisshared".to_string(), "f".to_string());
                 row_data.insert("relpersistence".to_string(), "p".to_string());
-                row_data.insert("relkind".to_string(), "r".to_string());
+                row_data.insert("relkind".to_string(), relkind.to_string());
                 row_data.insert("relnatts".to_string(), relnatts.to_string());
                 row_data.insert("relchecks".to_string(), "0".to_string());
                 row_data.insert("relhasrules".to_string(), "f".to_string());
@@ -141,14 +145,14 @@ impl PgClassHandler {
                         Some("0".to_string().into_bytes()),                    // relam (0 for tables)
                         Some(oid.to_string().into_bytes()),                    // relfilenode
                         Some("0".to_string().into_bytes()),                    // reltablespace
-                        Some("0".to_string().into_bytes()),                    // relpages
-                        Some("-1".to_string().into_bytes()),                   // reltuples
+                        Some(relpages.into_bytes()),                           // relpages
+                        Some(reltuples.into_bytes()),                          // reltuples
                         Some("0".to_string().into_bytes()),                    // relallvisible
                         Some("0".to_string().into_bytes()),                    // reltoastrelid
                         Some(if relhasindex { b"t".to_vec() } else { b"f".to_vec() }), // relhasindex
                         Some(b"f".to_vec()),                                // relisshared
                         Some(b"p".to_vec()),                                // relpersistence (permanent)
-                        Some(b"r".to_vec()),                                // relkind (regular table)
+                        Some(relkind.as_bytes().to_vec()),                  // relkind (regular table or view)
                         Some(relnatts.to_string().into_bytes()),              // relnatts
                         Some("0".to_string().into_bytes()),                    // relchecks
                         Some(b"f".to_vec()),                                // relhasrules
@@ -186,8 +190,10 @@ impl PgClassHandler {
                 let index_name = String::from_utf8_lossy(index_name_bytes);
                 let table_name = String::from_utf8_lossy(table_name_bytes);
                 
-                let index_oid = generate_oid_from_name(&index_name);
-                let _table_oid = generate_oid_from_name(&table_name);
+                let index_oid = super::constraint_populator::table_oid(&index_name);
+                let _table_oid = super::constraint_populator::table_oid(&table_name);
+                
+                let (relpages, reltuples) = Self::relation_statistics(db, &index_name).await;
                 
                 // Build row data for WHERE evaluation
                 let mut row_data = HashMap::new();
@@ -200,8 +206,8 @@ impl PgClassHandler {
                 row_data.insert("relam".to_string(), "403".to_string());
                 row_data.insert("relfilenode".to_string(), index_oid.to_string());
                 row_data.insert("reltablespace".to_string(), "0".to_string());
-                row_data.insert("relpages".to_string(), "0".to_string());
-                row_data.insert("reltuples".to_string(), "0".to_string());
+                row_data.insert("relpages".to_string(), relpages.clone());
+                row_data.insert("reltuples".to_string(), reltuples.clone());
                 row_data.insert("relallvisible".to_string(), "0".to_string());
                 row_data.insert("reltoastrelid".to_string(), "0".to_string());
                 row_data.insert("relhasindex".to_string(), "f".to_string());
@@ -246,8 +252,8 @@ impl PgClassHandler {
                         Some("403".to_string().into_bytes()),                  // relam (btree)
                         Some(index_oid.to_string().into_bytes()),              // relfilenode
                         Some("0".to_string().into_bytes()),                    // reltablespace
-                        Some("0".to_string().into_bytes()),                    // relpages
-                        Some("0".to_string().into_bytes()),                    // reltuples
+                        Some(relpages.into_bytes()),                           // relpages
+                        Some(reltuples.into_bytes()),                          // reltuples
                         Some("0".to_string().into_bytes()),                    // relallvisible
                         Some("0".to_string().into_bytes()),                    // reltoastrelid
                         Some(b"f".to_vec()),                                // relhasindex
@@ -291,6 +297,25 @@ impl PgClassHandler {
         })
     }
     
+    /// relpages and reltuples of a relation, from the page count and the row estimate
+    /// that ANALYZE keeps in sqlite_stat1 (see __pgsqlite_relpages/__pgsqlite_reltuples)
+    async fn relation_statistics(db: &DbHandler, name: &str) -> (String, String) {
+        let name = name.replace('\'', "''");
+        let query = format!("SELECT __pgsqlite_relpages('{name}'), CAST(__pgsqlite_reltuples('{name}') AS INTEGER)");
+        let value = |row: &Vec<Option<Vec<u8>>>, idx: usize| {
+            row.get(idx).cloned().flatten().map(|bytes| String::from_utf8_lossy(&bytes).to_string())
+        };
+        match db.query(&query).await {
+            Ok(response) => response.rows.first()
+                .and_then(|row| Some((value(row, 0)?, value(row, 1)?)))
+                .unwrap_or_else(|| ("0".to_string(), "-1".to_string())),
+            Err(e) => {
+                debug!("Could not read size estimates for '{}': {}", name, e);
+                ("0".to_string(), "-1".to_string())
+            }
+        }
+    }
+    
     /// Determine which columns to return based on the SELECT projection
     fn get_projected_columns(select: &Select, all_columns: &[String]) -> (Vec<String>, Vec<usize>) {
         let mut columns = Vec::new();
@@ -361,13 +386,3 @@ impl PgClassHandler {
         }
     }
 }
-
-fn generate_oid_from_name(name: &str) -> u32 {
-    // Generate a stable OID from name using a simple hash
-    // Start at 16384 to avoid conflicts with system OIDs
-    let mut hash = 0u32;
-    for byte in name.bytes() {
-        hash = hash.wrapping_mul(31).wrapping_add(byte as u32);
-    }
-    16384 + (hash % 1000000)
-}
\ No newline at end of file
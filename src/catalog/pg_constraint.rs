@@ -168,7 +168,7 @@ impl PgConstraintHandler {
                         condeferrable: false,
                         condeferred: false,
                         convalidated: true,
-                        conrelid: Self::generate_table_oid(&table_name),
+                        conrelid: super::constraint_populator::table_oid(&table_name),
                         contypid: 0,
                         conindid: constraint_id + 1000, // Arbitrary index OID
                         conparentid: 0,
@@ -212,11 +212,11 @@ impl PgConstraintHandler {
                                 condeferrable: false,
                                 condeferred: false,
                                 convalidated: true,
-                                conrelid: Self::generate_table_oid(&table_name),
+                                conrelid: super::constraint_populator::table_oid(&table_name),
                                 contypid: 0,
                                 conindid: 0,
                                 conparentid: 0,
-                                confrelid: Self::generate_table_oid(&ref_table),
+                                confrelid: super::constraint_populator::table_oid(&ref_table),
                                 confupdtype: 'a', // NO ACTION (default)
                                 confdeltype: 'a', // NO ACTION (default)
                                 confmatchtype: 's', // SIMPLE (default)
@@ -253,17 +253,6 @@ impl PgConstraintHandler {
         Ok(1) // Default fallback
     }
 
-    fn generate_table_oid(table_name: &str) -> u32 {
-        // Generate deterministic OID from table name (same as pg_class handler)
-        use std::collections::hash_map::DefaultHasher;
-        use std::hash::{Hash, Hasher};
-
-        let mut hasher = DefaultHasher::new();
-        table_name.hash(&mut hasher);
-        let hash = hasher.finish();
-        16384 + ((hash % 65536) as u32) // Keep OIDs in reasonable range
-    }
-
     fn constraint_to_row(constraint: &ConstraintInfo) -> Vec<Option<Vec<u8>>> {
         vec![
             Some(constraint.oid.to_string().into_bytes()),                    // oid
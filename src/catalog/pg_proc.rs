@@ -125,6 +125,12 @@ impl PgProcHandler {
             ("upper", "11", "25", "f", "i", true, false),   // upper(text) -> text
             ("substr", "11", "25", "f", "i", true, false),  // substr(text, int, int) -> text
             ("replace", "11", "25", "f", "i", true, false), // replace(text, text, text) -> text
+            ("strpos", "11", "23", "f", "i", true, false),  // strpos(text, text) -> int4
+            ("starts_with", "11", "16", "f", "i", true, false), // starts_with(text, text) -> bool
+            ("get_bit", "11", "23", "f", "i", true, false), // get_bit(bit, int) -> int4
+            ("set_bit", "11", "1560", "f", "i", true, false), // set_bit(bit, int, int) -> bit
+            ("xpath", "11", "1009", "f", "i", true, false), // xpath(text, xml) -> text[]
+            ("xml_is_well_formed", "11", "16", "f", "i", true, false), // xml_is_well_formed(text) -> bool
 
             // Math functions
             ("abs", "11", "23", "f", "i", true, false),     // abs(int) -> int
@@ -142,6 +148,9 @@ impl PgProcHandler {
             // Date/time functions
             ("now", "11", "1184", "f", "v", false, false),  // now() -> timestamptz
             ("date", "11", "1082", "f", "i", true, false),  // date(text) -> date
+            ("make_date", "11", "1082", "f", "i", true, false), // make_date(int4, int4, int4) -> date
+            ("make_timestamp", "11", "1114", "f", "i", true, false), // make_timestamp(...) -> timestamp
+            ("make_interval", "11", "1186", "f", "i", true, false), // make_interval(...) -> interval
 
             // JSON functions
             ("json_agg", "11", "114", "f", "v", false, true),     // json_agg(any) -> json
@@ -154,6 +163,8 @@ impl PgProcHandler {
 
             // UUID functions
             ("uuid_generate_v4", "11", "2950", "f", "v", false, false), // uuid_generate_v4() -> uuid
+            ("uuid_generate_v1", "11", "2950", "f", "v", false, false), // uuid_generate_v1() -> uuid
+            ("gen_random_bytes", "11", "17", "f", "v", false, false), // gen_random_bytes(int4) -> bytea
 
             // System functions
             ("version", "11", "25", "f", "s", false, false),      // version() -> text
@@ -96,6 +96,12 @@ impl PgRolesHandler {
         selected
     }
 
+    /// Whether pg_roles lists a role with this name
+    pub fn role_exists(name: &str) -> bool {
+        Self::get_default_roles().iter()
+            .any(|role| role.get("rolname").is_some_and(|rolname| rolname == name.as_bytes()))
+    }
+
     fn get_default_roles() -> Vec<HashMap<String, Vec<u8>>> {
         let mut roles = Vec::new();
 
@@ -118,7 +118,7 @@ impl PgSequenceHandler {
         for row_result in rows.flatten() {
             let (table_name, current_value) = row_result;
 
-            let table_oid = Self::generate_table_oid(&table_name);
+            let table_oid = super::constraint_populator::table_oid(&table_name);
 
             let mut sequence = HashMap::new();
 
@@ -146,14 +146,6 @@ impl PgSequenceHandler {
         Ok(sequences)
     }
 
-    fn generate_table_oid(table_name: &str) -> u32 {
-        let mut hash = 0u32;
-        for byte in table_name.bytes() {
-            hash = hash.wrapping_mul(31).wrapping_add(byte as u32);
-        }
-        16384 + (hash % 65536)
-    }
-
     fn apply_where_filter(
         sequences: &[HashMap<String, Vec<u8>>],
         where_clause: &Expr,
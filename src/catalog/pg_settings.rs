@@ -99,8 +99,8 @@ impl PgSettingsHandler {
 
     fn get_all_settings() -> Vec<HashMap<String, Vec<u8>>> {
         let settings_data: Vec<(&str, &str, &str, &str, &str, &str, &str)> = vec![
-            ("server_version", "16.0", "", "Preset Options", "PostgreSQL version string", "internal", "string"),
-            ("server_version_num", "160000", "", "Preset Options", "PostgreSQL version number", "internal", "integer"),
+            ("server_version", crate::PG_SERVER_VERSION, "", "Preset Options", "PostgreSQL version string", "internal", "string"),
+            ("server_version_num", crate::PG_SERVER_VERSION_NUM, "", "Preset Options", "PostgreSQL version number", "internal", "integer"),
             ("server_encoding", "UTF8", "", "Client Connection Defaults", "Server encoding", "internal", "string"),
             ("client_encoding", "UTF8", "", "Client Connection Defaults", "Client encoding", "user", "string"),
             ("DateStyle", "ISO, MDY", "", "Client Connection Defaults", "Date display format", "user", "string"),
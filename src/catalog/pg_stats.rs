@@ -119,6 +119,7 @@ impl PgStatsHandler {
             // Get columns for each table
             let columns = Self::get_table_columns(db, &table_name).await?;
             debug!("Found {} columns for table {}", columns.len(), table_name);
+            let analyzed = Self::get_analyzed_statistics(db, &table_name);
 
             for column_info in columns {
                 let mut stat = HashMap::new();
@@ -129,8 +130,12 @@ impl PgStatsHandler {
                 stat.insert("attname".to_string(), column_info.name.as_bytes().to_vec());
                 stat.insert("inherited".to_string(), b"f".to_vec()); // false
 
-                // Generate realistic statistics based on column type and name
-                let column_stats = Self::generate_column_statistics(&table_name, &column_info);
+                // Use what ANALYZE measured, otherwise generate realistic statistics based on
+                // column type and name
+                let column_stats = match analyzed.get(&column_info.name) {
+                    Some(measured) => measured.clone(),
+                    None => Self::generate_column_statistics(&table_name, &column_info),
+                };
 
                 stat.insert("null_frac".to_string(), column_stats.null_frac.as_bytes().to_vec());
                 stat.insert("n_distinct".to_string(), column_stats.n_distinct.as_bytes().to_vec());
@@ -195,6 +200,32 @@ impl PgStatsHandler {
         Ok(columns)
     }
 
+    /// Statistics stored by ANALYZE for the columns of a table, see AnalyzeHandler
+    fn get_analyzed_statistics(db: &DbHandler, table_name: &str) -> HashMap<String, ColumnStats> {
+        let read = || -> rusqlite::Result<HashMap<String, ColumnStats>> {
+            let conn = rusqlite::Connection::open(&db.db_path)?;
+            let mut stmt = conn.prepare(
+                "SELECT column_name, null_frac, n_distinct, most_common_vals, most_common_freqs \
+                 FROM __pgsqlite_stats WHERE table_name = ?1"
+            )?;
+            let rows = stmt.query_map([table_name], |row| {
+                Ok((row.get::<_, String>(0)?, ColumnStats {
+                    null_frac: row.get(1)?,
+                    n_distinct: row.get(2)?,
+                    most_common_vals: row.get::<_, Option<String>>(3)?.unwrap_or_default(),
+                    most_common_freqs: row.get::<_, Option<String>>(4)?.unwrap_or_default(),
+                    histogram_bounds: String::new(),
+                    correlation: String::new(),
+                }))
+            })?;
+            rows.collect()
+        };
+        read().unwrap_or_else(|e| {
+            debug!("No analyzed statistics for table {}: {}", table_name, e);
+            HashMap::new()
+        })
+    }
+
     fn generate_column_statistics(_table_name: &str, column_info: &ColumnInfo) -> ColumnStats {
         let column_name = &column_info.name.to_lowercase();
         let data_type = &column_info.data_type.to_uppercase();
@@ -349,7 +380,7 @@ struct ColumnInfo {
     data_type: String,
 }
 
-#[derive(Debug)]
+#[derive(Debug, Clone)]
 struct ColumnStats {
     null_frac: String,
     n_distinct: String,
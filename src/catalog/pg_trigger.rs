@@ -130,7 +130,7 @@ impl PgTriggerHandler {
             let (timing, event, _orientation) = Self::parse_trigger_sql(&trigger_sql);
 
             let trigger_oid = Self::generate_trigger_oid(&trigger_name);
-            let table_oid = Self::generate_table_oid(&table_name);
+            let table_oid = super::constraint_populator::table_oid(&table_name);
             let tgtype = Self::calculate_tgtype(&timing, &event);
 
             let mut trigger = HashMap::new();
@@ -230,14 +230,6 @@ impl PgTriggerHandler {
         16384 + (hash % 65536)
     }
 
-    fn generate_table_oid(table_name: &str) -> u32 {
-        let mut hash = 0u32;
-        for byte in table_name.bytes() {
-            hash = hash.wrapping_mul(31).wrapping_add(byte as u32);
-        }
-        16384 + (hash % 65536)
-    }
-
     fn apply_where_filter(
         triggers: &[HashMap<String, Vec<u8>>],
         where_clause: &Expr,
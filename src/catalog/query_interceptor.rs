@@ -13,6 +13,27 @@ use std::sync::Arc;
 use std::pin::Pin;
 use std::future::Future;
 use std::collections::HashMap;
+use once_cell::sync::Lazy;
+use regex::Regex;
+use rusqlite::types::ValueRef;
+
+/// Catalog functions taking catalog columns, which only the SQLite catalog views can feed
+const CATALOG_VIEW_FUNCTIONS: &[&str] = &[
+    "format_type", "pg_get_expr", "pg_get_constraintdef", "pg_get_indexdef", "regclass_name", "regtype_name",
+];
+
+/// A boolean catalog column used as a condition, as in `AND NOT a.attisdropped`
+static CATALOG_BOOLEAN_CONDITION: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(concat!(
+        r"(?i)\b(WHERE|AND|OR|NOT|ON|WHEN|HAVING)(\s+|\s*\(\s*)",
+        r"((?:\w+\.)?(?:attnotnull|atthasdef|atthasmissing|attisdropped|attislocal|",
+        r"relhasindex|relisshared|relhasrules|relhastriggers|relhassubclass|relrowsecurity|",
+        r"relforcerowsecurity|relispopulated|relispartition|indisunique|indisprimary|",
+        r"indisexclusion|indimmediate|indisclustered|indisvalid|indisreplident|",
+        r"condeferrable|condeferred|convalidated|conislocal|connoinherit|inhdetachpending))",
+        r"(\s*(?:\)|;|$)|\s+(?:AND|OR|THEN|ORDER|GROUP|LIMIT|HAVING|UNION)\b)",
+    )).unwrap()
+});
 
 /// Type alias for the complex Future type returned by process_expression
 type ProcessExpressionFuture<'a> = Pin<Box<dyn Future<Output = Result<(), Box<dyn std::error::Error + Send + Sync>>> + Send + 'a>>;
@@ -183,6 +204,14 @@ impl CatalogInterceptor {
                             }
                         }
                         
+                        // psql's \d queries the handlers can't serve run on the SQLite catalog views
+                        if let Some(ref session) = session
+                            && Self::needs_catalog_views(query_stmt) {
+                                let sql = Self::catalog_view_sql(query_stmt);
+                                debug!("Running catalog query on the catalog views: {}", sql);
+                                return Some(Self::query_catalog_views(&sql, &db, &session.id));
+                            }
+
                         // Normal catalog table handling
                         println!("INTERCEPT: About to call handle_catalog_query");
                         if let Some(response) = Self::handle_catalog_query(query_stmt, db.clone(), session.clone()).await {
@@ -207,6 +236,98 @@ impl CatalogInterceptor {
         None
     }
 
+    /// Whether a catalog query needs the SQLite catalog views. The handlers serve the plain
+    /// columns of one catalog; psql's \d lists catalogs with commas, selects columns of
+    /// joined catalogs and passes catalog columns to subqueries and catalog functions.
+    fn needs_catalog_views(query: &sqlparser::ast::Query) -> bool {
+        let SetExpr::Select(select) = &*query.body else {
+            return false;
+        };
+        let relations: Vec<(String, String)> = select.from.iter()
+            .flat_map(|table| std::iter::once(&table.relation).chain(table.joins.iter().map(|join| &join.relation)))
+            .filter_map(|relation| match relation {
+                TableFactor::Table { name, alias, .. } => {
+                    let name = name.to_string().to_lowercase();
+                    let alias = alias.as_ref().map_or_else(|| name.clone(), |alias| alias.name.value.to_lowercase());
+                    Some((name, alias))
+                }
+                _ => None,
+            })
+            .collect();
+        // information_schema and pg_type joins have their own handling
+        if relations.first().is_none_or(|(name, _)| name.ends_with("pg_type"))
+            || relations.iter().any(|(name, _)| name.contains("information_schema")) {
+            return false;
+        }
+        if select.from.len() > 1 {
+            return true;
+        }
+
+        let joined: Vec<&str> = relations.iter().skip(1).map(|(_, alias)| alias.as_str()).collect();
+        select.projection.iter().any(|item| match item {
+            SelectItem::UnnamedExpr(expr) | SelectItem::ExprWithAlias { expr, .. } => match expr {
+                Expr::Subquery(_) => true,
+                Expr::CompoundIdentifier(parts) => parts.len() == 2 && joined.contains(&parts[0].value.to_lowercase().as_str()),
+                Expr::Function(func) => {
+                    let name = func.name.to_string().to_lowercase();
+                    CATALOG_VIEW_FUNCTIONS.contains(&name.rsplit('.').next().unwrap_or(&name))
+                }
+                _ => false,
+            },
+            _ => false,
+        })
+    }
+
+    /// The SQL of a catalog query for the SQLite catalog views. Boolean catalog columns
+    /// hold 't'/'f' there, which SQLite takes for false, so the ones used as conditions are
+    /// compared instead, and selected true/false constants print as PostgreSQL booleans.
+    fn catalog_view_sql(query: &sqlparser::ast::Query) -> String {
+        let mut query = query.clone();
+        if let SetExpr::Select(select) = &mut *query.body {
+            for item in &mut select.projection {
+                if let SelectItem::UnnamedExpr(expr) | SelectItem::ExprWithAlias { expr, .. } = item
+                    && let Expr::Value(value) = expr
+                    && let sqlparser::ast::Value::Boolean(b) = value.value {
+                        value.value = sqlparser::ast::Value::SingleQuotedString(if b { "t" } else { "f" }.to_string());
+                    }
+            }
+        }
+
+        let mut sql = query.to_string();
+        // A replaced column is parenthesized, so it doesn't match again
+        loop {
+            let replaced = CATALOG_BOOLEAN_CONDITION.replace_all(&sql, "${1}${2}(${3} IN (1, 't'))${4}").into_owned();
+            if replaced == sql {
+                return sql;
+            }
+            sql = replaced;
+        }
+    }
+
+    /// Run a catalog query on the SQLite catalog views with the session's connection
+    fn query_catalog_views(sql: &str, db: &DbHandler, session_id: &Uuid) -> Result<DbResponse, PgSqliteError> {
+        db.connection_manager().execute_with_session(session_id, |conn| {
+            let mut stmt = conn.prepare(sql)?;
+            let columns: Vec<String> = stmt.column_names().into_iter().map(str::to_string).collect();
+            let rows = stmt.query_map([], |row| {
+                (0..columns.len()).map(|i| {
+                    Ok(match row.get_ref(i)? {
+                        ValueRef::Null => None,
+                        ValueRef::Integer(value) => Some(value.to_string().into_bytes()),
+                        ValueRef::Real(value) => Some(crate::types::numeric_utils::format_float(value).into_bytes()),
+                        ValueRef::Text(bytes) | ValueRef::Blob(bytes) => Some(bytes.to_vec()),
+                    })
+                }).collect::<rusqlite::Result<Vec<Option<Vec<u8>>>>>()
+            })?.collect::<rusqlite::Result<Vec<_>>>()?;
+            let rows_affected = rows.len();
+            Ok(DbResponse {
+                columns,
+                rows,
+                rows_affected,
+            })
+        })
+    }
+
     async fn handle_catalog_query(query: &sqlparser::ast::Query, db: Arc<DbHandler>, session: Option<Arc<SessionState>>) -> Option<DbResponse> {
         debug!("handle_catalog_query called");
         println!("HANDLE_CATALOG_QUERY: called with query");
@@ -321,11 +442,11 @@ impl CatalogInterceptor {
                 }
 
                 // Check if the query contains system functions that need special handling
+                // (pg_get_expr is a SQLite function, so those JOINs can run as-is)
                 let query_str = query.to_string();
                 let contains_system_functions = query_str.contains("pg_table_is_visible") ||
                                               query_str.contains("pg_get_constraintdef") ||
-                                              query_str.contains("format_type") ||
-                                              query_str.contains("pg_get_expr");
+                                              query_str.contains("format_type");
 
                 if contains_system_functions {
                     // This query contains system functions that need special handling
@@ -567,6 +688,16 @@ impl CatalogInterceptor {
 
             // Handle pg_constraint queries
             if table_name.contains("pg_constraint") || table_name.contains("pg_catalog.pg_constraint") {
+                // The handler only projects plain columns; function calls such as
+                // pg_get_constraintdef(oid) run against the pg_constraint table instead
+                let has_function_projection = select.projection.iter().any(|item| matches!(
+                    item,
+                    SelectItem::UnnamedExpr(Expr::Function(_)) | SelectItem::ExprWithAlias { expr: Expr::Function(_), .. }
+                ));
+                if has_function_projection {
+                    return None;
+                }
+
                 info!("Routing to PgConstraintHandler for table: {}", table_name);
                 return match PgConstraintHandler::handle_query(select, &db).await {
                     Ok(response) => {
@@ -2073,7 +2204,13 @@ impl CatalogInterceptor {
                             let is_nullable = if not_null || is_primary_key { "NO" } else { "YES" };
 
                             // Handle default value
-                            let column_default = if default_value.is_empty() || default_value == "NULL" {
+                            let is_serial = matches!(
+                                pg_type.to_uppercase().as_str(),
+                                "SERIAL" | "SERIAL4" | "BIGSERIAL" | "SERIAL8" | "SMALLSERIAL" | "SERIAL2"
+                            );
+                            let column_default = if default_value.is_empty() && is_serial {
+                                Some(format!("nextval('{table_name}_{column_name}_seq'::regclass)").into_bytes())
+                            } else if default_value.is_empty() || default_value == "NULL" {
                                 None
                             } else {
                                 Some(default_value.to_string().into_bytes())
@@ -2798,6 +2935,12 @@ impl CatalogInterceptor {
             ("upper", "FUNCTION", "text", "text", "SQL", "CONTAINS_SQL"),
             ("substr", "FUNCTION", "text", "text", "SQL", "CONTAINS_SQL"),
             ("replace", "FUNCTION", "text", "text", "SQL", "CONTAINS_SQL"),
+            ("strpos", "FUNCTION", "text", "integer", "SQL", "CONTAINS_SQL"),
+            ("starts_with", "FUNCTION", "text", "boolean", "SQL", "CONTAINS_SQL"),
+            ("get_bit", "FUNCTION", "bit", "integer", "SQL", "CONTAINS_SQL"),
+            ("set_bit", "FUNCTION", "bit", "bit", "SQL", "CONTAINS_SQL"),
+            ("xpath", "FUNCTION", "xml", "ARRAY", "SQL", "CONTAINS_SQL"),
+            ("xml_is_well_formed", "FUNCTION", "text", "boolean", "SQL", "CONTAINS_SQL"),
             ("trim", "FUNCTION", "text", "text", "SQL", "CONTAINS_SQL"),
             ("ltrim", "FUNCTION", "text", "text", "SQL", "CONTAINS_SQL"),
             ("rtrim", "FUNCTION", "text", "text", "SQL", "CONTAINS_SQL"),
@@ -2824,6 +2967,9 @@ impl CatalogInterceptor {
             ("current_time", "FUNCTION", "time with time zone", "time with time zone", "SQL", "CONTAINS_SQL"),
             ("date_trunc", "FUNCTION", "timestamp with time zone", "timestamp with time zone", "SQL", "CONTAINS_SQL"),
             ("extract", "FUNCTION", "numeric", "double precision", "SQL", "CONTAINS_SQL"),
+            ("make_date", "FUNCTION", "integer", "date", "SQL", "CONTAINS_SQL"),
+            ("make_timestamp", "FUNCTION", "integer", "timestamp without time zone", "SQL", "CONTAINS_SQL"),
+            ("make_interval", "FUNCTION", "integer", "interval", "SQL", "CONTAINS_SQL"),
 
             // JSON functions
             ("json_agg", "FUNCTION", "json", "json", "SQL", "CONTAINS_SQL"),
@@ -2840,6 +2986,8 @@ impl CatalogInterceptor {
 
             // UUID functions
             ("uuid_generate_v4", "FUNCTION", "uuid", "uuid", "SQL", "CONTAINS_SQL"),
+            ("uuid_generate_v1", "FUNCTION", "uuid", "uuid", "SQL", "CONTAINS_SQL"),
+            ("gen_random_bytes", "FUNCTION", "bytea", "bytea", "SQL", "CONTAINS_SQL"),
 
             // System functions
             ("version", "FUNCTION", "text", "text", "SQL", "CONTAINS_SQL"),
@@ -2852,6 +3000,9 @@ impl CatalogInterceptor {
             ("to_tsquery", "FUNCTION", "tsquery", "tsquery", "SQL", "CONTAINS_SQL"),
             ("plainto_tsquery", "FUNCTION", "tsquery", "tsquery", "SQL", "CONTAINS_SQL"),
             ("ts_rank", "FUNCTION", "real", "real", "SQL", "CONTAINS_SQL"),
+            ("ts_rank_cd", "FUNCTION", "real", "real", "SQL", "CONTAINS_SQL"),
+            ("setweight", "FUNCTION", "tsvector", "tsvector", "SQL", "CONTAINS_SQL"),
+            ("ts_headline", "FUNCTION", "text", "text", "SQL", "CONTAINS_SQL"),
         ];
 
         for (name, routine_type, _param_type, return_type, language, data_access) in function_data {
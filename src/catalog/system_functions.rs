@@ -2,8 +2,6 @@ use crate::session::db_handler::DbHandler;
 use crate::types::PgType;
 use sqlparser::ast::Expr;
 use std::sync::Arc;
-use std::collections::hash_map::DefaultHasher;
-use std::hash::{Hash, Hasher};
 
 /// Handles PostgreSQL system function calls within catalog queries
 pub struct SystemFunctions;
@@ -29,78 +27,12 @@ impl SystemFunctions {
     }
 
     /// pg_get_constraintdef(constraint_oid) - Returns the definition of a constraint
-    /// For full implementation, we would need a pg_constraint table that maps OIDs to constraints
     async fn pg_get_constraintdef(
-        args: &[Expr],
-        db: Arc<DbHandler>,
+        _args: &[Expr],
+        _db: Arc<DbHandler>,
     ) -> Result<Option<String>, Box<dyn std::error::Error + Send + Sync>> {
-        if args.is_empty() {
-            return Ok(Some("".to_string()));
-        }
-
-        // Extract the constraint OID from the first argument
-        let constraint_oid = match &args[0] {
-            Expr::Value(sqlparser::ast::ValueWithSpan { value: sqlparser::ast::Value::Number(n, _), .. }) => n.parse::<i64>().ok(),
-            _ => None,
-        };
-
-        if let Some(oid) = constraint_oid {
-            // Since we don't have a pg_constraint table yet, we'll do a simple implementation
-            // that tries to match common constraint OIDs that psql might be looking for
-            
-            // For demonstration, let's handle some special cases:
-            // 1. Primary key constraints typically have predictable OIDs
-            // 2. Foreign key constraints 
-            // 3. Check constraints
-            
-            // Query all tables to find constraints
-            let tables_query = "SELECT name, sql FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '__pgsqlite_%'";
-            let tables_response = db.query(tables_query).await?;
-            
-            if !tables_response.rows.is_empty() {
-                let rows = &tables_response.rows;
-                for row in rows {
-                    if row.len() >= 2 {
-                        let table_name = row[0].as_ref().and_then(|v| std::str::from_utf8(v).ok()).unwrap_or("");
-                        let create_sql = row[1].as_ref().and_then(|v| std::str::from_utf8(v).ok()).unwrap_or("");
-                        
-                        // Try to extract constraints from the CREATE TABLE statement
-                        // This is a simplified approach - a full implementation would parse the SQL
-                        if let Some(constraint_def) = extract_constraint_by_oid(create_sql, oid, table_name) {
-                            return Ok(Some(constraint_def));
-                        }
-                    }
-                }
-            }
-            
-            // Also check indexes (for UNIQUE constraints)
-            let index_query = "SELECT name, sql FROM sqlite_master WHERE type = 'index' AND sql IS NOT NULL";
-            let index_response = db.query(index_query).await?;
-            
-            if !index_response.rows.is_empty() {
-                let rows = &index_response.rows;
-                for row in rows {
-                    if row.len() >= 2 {
-                        let _index_name = row[0].as_ref().and_then(|v| std::str::from_utf8(v).ok()).unwrap_or("");
-                        let create_sql = row[1].as_ref().and_then(|v| std::str::from_utf8(v).ok()).unwrap_or("");
-                        
-                        // Check if this might be a UNIQUE constraint with matching OID
-                        if create_sql.to_uppercase().contains("UNIQUE") {
-                            // For now, return a generic UNIQUE constraint definition
-                            // A full implementation would parse and match OIDs properly
-                            if oid % 1000 == 500 { // Arbitrary check for demonstration
-                                return Ok(Some("UNIQUE (column_name)".to_string()));
-                            }
-                        }
-                    }
-                }
-            }
-            
-            // Return empty string for unknown constraint OID
-            Ok(Some("".to_string()))
-        } else {
-            Ok(Some("".to_string()))
-        }
+        // Evaluated by the SQLite pg_get_constraintdef function, which reads pg_constraint
+        Ok(None)
     }
 
     /// pg_table_is_visible(table_oid) - Returns true if table is in search path
@@ -121,124 +53,159 @@ impl SystemFunctions {
         args: &[Expr],
         _db: Arc<DbHandler>,
     ) -> Result<Option<String>, Box<dyn std::error::Error + Send + Sync>> {
-        if args.is_empty() {
-            return Ok(Some("".to_string()));
-        }
+        // Only literal arguments are folded here; column references such as
+        // format_type(a.atttypid, a.atttypmod) are left for the SQLite format_type function
+        let literal = |expr: &Expr| expr.to_string().parse::<i32>().ok();
+        let is_null = |expr: &Expr| matches!(expr, Expr::Value(sqlparser::ast::ValueWithSpan { value: sqlparser::ast::Value::Null, .. }));
 
-        // Extract type OID from first argument
-        let type_oid = match &args[0] {
-            Expr::Value(sqlparser::ast::ValueWithSpan { value: sqlparser::ast::Value::Number(n, _), .. }) => n.parse::<i32>().ok(),
-            _ => None,
+        let Some(type_oid) = args.first().and_then(literal) else {
+            return Ok(None);
         };
-
-        // Extract typemod from second argument if present
-        let typemod = if args.len() > 1 {
-            match &args[1] {
-                Expr::Value(sqlparser::ast::ValueWithSpan { value: sqlparser::ast::Value::Number(n, _), .. }) => n.parse::<i32>().ok(),
-                _ => None,
-            }
-        } else {
-            None
+        let typemod = match args.get(1) {
+            None => None,
+            Some(arg) if is_null(arg) => None,
+            Some(arg) => match literal(arg) {
+                Some(typemod) => Some(typemod),
+                None => return Ok(None),
+            },
         };
+        Ok(Some(Self::format_type_name(type_oid, typemod)))
+    }
 
-        if let Some(oid) = type_oid {
-            // Convert OID to PgType and format
-            let type_name = match oid {
-                t if t == PgType::Bool.to_oid() => "boolean".to_string(),
-                t if t == PgType::Bytea.to_oid() => "bytea".to_string(),
-                t if t == PgType::Char.to_oid() => "\"char\"".to_string(),
-                19 => "name".to_string(), // PostgreSQL name type OID
-                t if t == PgType::Int8.to_oid() => "bigint".to_string(),
-                t if t == PgType::Int2.to_oid() => "smallint".to_string(),
-                t if t == PgType::Int4.to_oid() => "integer".to_string(),
-                t if t == PgType::Text.to_oid() => "text".to_string(),
-                26 => "oid".to_string(), // PostgreSQL OID type
-                27 => "tid".to_string(), // PostgreSQL TID type
-                28 => "xid".to_string(), // PostgreSQL XID type
-                29 => "cid".to_string(), // PostgreSQL CID type
-                t if t == PgType::Float4.to_oid() => "real".to_string(),
-                t if t == PgType::Float8.to_oid() => "double precision".to_string(),
-                t if t == PgType::Money.to_oid() => "money".to_string(),
-                t if t == PgType::Varchar.to_oid() => {
-                    // Handle varchar with length modifier
-                    if let Some(mod_val) = typemod {
-                        if mod_val > 4 {
-                            format!("character varying({})", mod_val - 4)
-                        } else {
-                            "character varying".to_string()
-                        }
-                    } else {
-                        "character varying".to_string()
-                    }
-                },
-                1042 => {
-                    // Handle char with length modifier (bpchar)
-                    if let Some(mod_val) = typemod {
-                        if mod_val > 4 {
-                            format!("character({})", mod_val - 4)
-                        } else {
-                            "character".to_string()
-                        }
-                    } else {
-                        "character".to_string()
-                    }
-                },
-                t if t == PgType::Numeric.to_oid() => {
-                    // Handle numeric with precision and scale
-                    if let Some(mod_val) = typemod {
-                        if mod_val > 4 {
-                            let precision = (mod_val - 4) >> 16;
-                            let scale = (mod_val - 4) & 0xFFFF;
-                            if scale > 0 {
-                                format!("numeric({precision},{scale})")
-                            } else {
-                                format!("numeric({precision})")
-                            }
-                        } else {
-                            "numeric".to_string()
-                        }
-                    } else {
-                        "numeric".to_string()
-                    }
-                },
-                t if t == PgType::Date.to_oid() => "date".to_string(),
-                t if t == PgType::Time.to_oid() => "time without time zone".to_string(),
-                t if t == PgType::Timestamp.to_oid() => "timestamp without time zone".to_string(),
-                t if t == PgType::Timestamptz.to_oid() => "timestamp with time zone".to_string(),
-                1186 => "interval".to_string(), // PostgreSQL interval type
-                1266 => "time with time zone".to_string(), // PostgreSQL timetz type
-                t if t == PgType::Bit.to_oid() => "bit".to_string(),
-                t if t == PgType::Varbit.to_oid() => "bit varying".to_string(),
-                603 => "box".to_string(), // PostgreSQL box type
-                718 => "circle".to_string(), // PostgreSQL circle type
-                628 => "line".to_string(), // PostgreSQL line type
-                601 => "lseg".to_string(), // PostgreSQL lseg type
-                602 => "path".to_string(), // PostgreSQL path type
-                600 => "point".to_string(), // PostgreSQL point type
-                604 => "polygon".to_string(), // PostgreSQL polygon type
-                t if t == PgType::Inet.to_oid() => "inet".to_string(),
-                t if t == PgType::Cidr.to_oid() => "cidr".to_string(),
-                t if t == PgType::Macaddr.to_oid() => "macaddr".to_string(),
-                t if t == PgType::Uuid.to_oid() => "uuid".to_string(),
-                t if t == PgType::Json.to_oid() => "json".to_string(),
-                t if t == PgType::Jsonb.to_oid() => "jsonb".to_string(),
-                _ => format!("unknown({oid})"),
-            };
-
-            Ok(Some(type_name))
-        } else {
-            Ok(Some("".to_string()))
+    /// Format a type OID and typmod as PostgreSQL's SQL-standard type name.
+    ///
+    /// A typmod of None means no modifier was passed, while a negative one was passed but
+    /// has no value; like PostgreSQL, `character` and `bit` then print as `bpchar` and
+    /// `"bit"`, since their bare names imply a length of 1. Arrays format their element
+    /// type, modifier included, followed by `[]`.
+    pub fn format_type_name(oid: i32, typemod: Option<i32>) -> String {
+        if let Some(element) = PgType::from_oid(oid).and_then(|pg_type| pg_type.element_type()) {
+            return format!("{}[]", Self::format_type_name(element.to_oid(), typemod));
+        }
+
+        // Variable-length types store the length plus the 4-byte header size
+        let length = typemod.filter(|m| *m >= 4).map(|m| m - 4);
+        let precision = typemod.filter(|m| *m >= 0);
+        let no_modifier = typemod.is_some_and(|m| m < 0);
+        match oid {
+            t if t == PgType::Bool.to_oid() => "boolean".to_string(),
+            t if t == PgType::Bytea.to_oid() => "bytea".to_string(),
+            18 => "\"char\"".to_string(), // PostgreSQL single-byte char type
+            19 => "name".to_string(), // PostgreSQL name type OID
+            t if t == PgType::Int8.to_oid() => "bigint".to_string(),
+            t if t == PgType::Int2.to_oid() => "smallint".to_string(),
+            t if t == PgType::Int4.to_oid() => "integer".to_string(),
+            t if t == PgType::Text.to_oid() => "text".to_string(),
+            26 => "oid".to_string(), // PostgreSQL OID type
+            27 => "tid".to_string(), // PostgreSQL TID type
+            28 => "xid".to_string(), // PostgreSQL XID type
+            29 => "cid".to_string(), // PostgreSQL CID type
+            t if t == PgType::Float4.to_oid() => "real".to_string(),
+            t if t == PgType::Float8.to_oid() => "double precision".to_string(),
+            t if t == PgType::Money.to_oid() => "money".to_string(),
+            t if t == PgType::Varchar.to_oid() => match length {
+                Some(length) => format!("character varying({length})"),
+                None => "character varying".to_string(),
+            },
+            t if t == PgType::Char.to_oid() => match length {
+                Some(length) => format!("character({length})"),
+                None if no_modifier => "bpchar".to_string(),
+                None => "character".to_string(),
+            },
+            t if t == PgType::Numeric.to_oid() => match length {
+                // Precision in the high 16 bits, scale in the low ones
+                Some(modifier) => format!("numeric({},{})", (modifier >> 16) & 0xFFFF, modifier & 0xFFFF),
+                None => "numeric".to_string(),
+            },
+            t if t == PgType::Date.to_oid() => "date".to_string(),
+            t if t == PgType::Time.to_oid() => Self::with_precision("time", precision, " without time zone"),
+            t if t == PgType::Timestamp.to_oid() => Self::with_precision("timestamp", precision, " without time zone"),
+            t if t == PgType::Timestamptz.to_oid() => Self::with_precision("timestamp", precision, " with time zone"),
+            t if t == PgType::Timetz.to_oid() => Self::with_precision("time", precision, " with time zone"),
+            t if t == PgType::Interval.to_oid() => Self::interval_type_name(precision),
+            t if t == PgType::Bit.to_oid() => match precision {
+                Some(length) => format!("bit({length})"),
+                None if no_modifier => "\"bit\"".to_string(),
+                None => "bit".to_string(),
+            },
+            t if t == PgType::Varbit.to_oid() => Self::with_precision("bit varying", precision, ""),
+            603 => "box".to_string(), // PostgreSQL box type
+            718 => "circle".to_string(), // PostgreSQL circle type
+            628 => "line".to_string(), // PostgreSQL line type
+            601 => "lseg".to_string(), // PostgreSQL lseg type
+            602 => "path".to_string(), // PostgreSQL path type
+            600 => "point".to_string(), // PostgreSQL point type
+            604 => "polygon".to_string(), // PostgreSQL polygon type
+            t if t == PgType::Inet.to_oid() => "inet".to_string(),
+            t if t == PgType::Cidr.to_oid() => "cidr".to_string(),
+            t if t == PgType::Macaddr.to_oid() => "macaddr".to_string(),
+            t if t == PgType::Macaddr8.to_oid() => "macaddr8".to_string(),
+            t if t == PgType::Uuid.to_oid() => "uuid".to_string(),
+            t if t == PgType::Json.to_oid() => "json".to_string(),
+            t if t == PgType::Jsonb.to_oid() => "jsonb".to_string(),
+            t if t == PgType::Unknown.to_oid() => "unknown".to_string(),
+            _ => match PgType::from_oid(oid) {
+                // Range and text search types print as their type names
+                Some(pg_type) => pg_type.name().to_string(),
+                None => format!("unknown({oid})"),
+            },
+        }
+    }
+
+    /// `name(precision)` followed by the suffix, or just the name and suffix
+    fn with_precision(name: &str, precision: Option<i32>, suffix: &str) -> String {
+        match precision {
+            Some(precision) => format!("{name}({precision}){suffix}"),
+            None => format!("{name}{suffix}"),
+        }
+    }
+
+    /// An interval typmod keeps the field restriction in its high 16 bits and the seconds
+    /// precision, 0xFFFF when unspecified, in the low ones
+    fn interval_type_name(typemod: Option<i32>) -> String {
+        const MONTH: i32 = 1 << 1;
+        const YEAR: i32 = 1 << 2;
+        const DAY: i32 = 1 << 3;
+        const HOUR: i32 = 1 << 10;
+        const MINUTE: i32 = 1 << 11;
+        const SECOND: i32 = 1 << 12;
+
+        let Some(typemod) = typemod else {
+            return "interval".to_string();
+        };
+        let fields = match (typemod >> 16) & 0x7FFF {
+            YEAR => " year",
+            MONTH => " month",
+            DAY => " day",
+            HOUR => " hour",
+            MINUTE => " minute",
+            SECOND => " second",
+            f if f == YEAR | MONTH => " year to month",
+            f if f == DAY | HOUR => " day to hour",
+            f if f == DAY | HOUR | MINUTE => " day to minute",
+            f if f == DAY | HOUR | MINUTE | SECOND => " day to second",
+            f if f == HOUR | MINUTE => " hour to minute",
+            f if f == HOUR | MINUTE | SECOND => " hour to second",
+            f if f == MINUTE | SECOND => " minute to second",
+            _ => "",
+        };
+        match typemod & 0xFFFF {
+            0xFFFF => format!("interval{fields}"),
+            precision => format!("interval{fields}({precision})"),
         }
     }
 
     /// pg_get_expr(node_tree, relation_oid) - Returns the expression from a node tree
     async fn pg_get_expr(
-        _args: &[Expr],
+        args: &[Expr],
         _db: Arc<DbHandler>,
     ) -> Result<Option<String>, Box<dyn std::error::Error + Send + Sync>> {
-        // SQLite doesn't have expression trees like PostgreSQL
-        // Return empty string for now
-        Ok(Some("".to_string()))
+        // pg_attrdef keeps defaults as SQL text, so a literal node tree is its own expression.
+        // Column references (d.adbin) are left for the SQLite pg_get_expr function.
+        match args.first() {
+            Some(Expr::Value(sqlparser::ast::ValueWithSpan { value: sqlparser::ast::Value::SingleQuotedString(s), .. })) => Ok(Some(s.clone())),
+            _ => Ok(None),
+        }
     }
 
     /// pg_get_userbyid(user_oid) - Returns username for an OID
@@ -255,46 +222,17 @@ impl SystemFunctions {
 
     /// pg_get_indexdef(index_oid) - Returns CREATE INDEX statement
     async fn pg_get_indexdef(
-        args: &[Expr],
+        _args: &[Expr],
         _db: Arc<DbHandler>,
     ) -> Result<Option<String>, Box<dyn std::error::Error + Send + Sync>> {
-        if args.is_empty() {
-            return Ok(Some("".to_string()));
-        }
-
-        // Extract the index OID from the first argument
-        let index_oid = match &args[0] {
-            Expr::Value(sqlparser::ast::ValueWithSpan { value: sqlparser::ast::Value::Number(n, _), .. }) => n.parse::<i64>().ok(),
-            _ => None,
-        };
-
-        if let Some(_oid) = index_oid {
-            // Query SQLite metadata to find index information
-            // For now, return empty string since we don't have OID -> index mapping
-            Ok(Some("".to_string()))
-        } else {
-            Ok(Some("".to_string()))
-        }
+        // Evaluated by the SQLite pg_get_indexdef function, which can see the index schema
+        Ok(None)
     }
 
-    /// to_regtype(type_name) - Converts type name to OID, returns NULL if type doesn't exist
-    async fn to_regtype(
-        args: &[Expr],
-        _db: Arc<DbHandler>,
-    ) -> Result<Option<String>, Box<dyn std::error::Error + Send + Sync>> {
-        if args.is_empty() {
-            return Ok(Some("NULL".to_string()));
-        }
-
-        // Extract the type name from the first argument
-        let type_name = match &args[0] {
-            Expr::Value(sqlparser::ast::ValueWithSpan { value: sqlparser::ast::Value::SingleQuotedString(s), .. }) => s.clone(),
-            Expr::Value(sqlparser::ast::ValueWithSpan { value: sqlparser::ast::Value::DoubleQuotedString(s), .. }) => s.clone(),
-            _ => return Ok(Some("NULL".to_string())),
-        };
-
-        // Define type name to OID mapping - same as in handle_pg_type_query
-        let type_oid = match type_name.to_lowercase().as_str() {
+    /// Type OID for a type name, as resolved by to_regtype() and ::regtype casts.
+    /// Same mapping as in handle_pg_type_query; None when the type doesn't exist
+    pub fn type_oid_from_name(type_name: &str) -> Option<i32> {
+        match type_name.trim().to_lowercase().as_str() {
             // Basic types
             "bool" | "boolean" => Some(16),
             "bytea" => Some(17),
@@ -355,12 +293,36 @@ impl SystemFunctions {
             "tstzrange" => Some(3910),
             "daterange" => Some(3912),
             
+            // Geometric types
+            "point" => Some(600),
+            
+            "xml" => Some(142),
+            
             // Types that don't exist in our system (should return NULL)
             "hstore" | "ltree" | "cube" | "seg" | "isn" | "lo" => None,
             
             _ => None, // Unknown type
+        }
+    }
+
+    /// to_regtype(type_name) - Converts type name to OID, returns NULL if type doesn't exist
+    async fn to_regtype(
+        args: &[Expr],
+        _db: Arc<DbHandler>,
+    ) -> Result<Option<String>, Box<dyn std::error::Error + Send + Sync>> {
+        if args.is_empty() {
+            return Ok(Some("NULL".to_string()));
+        }
+
+        // Extract the type name from the first argument
+        let type_name = match &args[0] {
+            Expr::Value(sqlparser::ast::ValueWithSpan { value: sqlparser::ast::Value::SingleQuotedString(s), .. }) => s.clone(),
+            Expr::Value(sqlparser::ast::ValueWithSpan { value: sqlparser::ast::Value::DoubleQuotedString(s), .. }) => s.clone(),
+            _ => return Ok(Some("NULL".to_string())),
         };
 
+        let type_oid = Self::type_oid_from_name(&type_name);
+
         match type_oid {
             Some(oid) => Ok(Some(oid.to_string())),
             None => Ok(Some("NULL".to_string())),
@@ -388,7 +350,8 @@ impl SystemFunctions {
                 // Handle column references - try to parse as number
                 ident.value.parse::<i64>().ok()
             }
-            _ => None,
+            // Expressions such as pg_total_relation_size('t') are left to the SQLite function
+            _ => return Ok(None),
         };
 
         match size_bytes {
@@ -446,124 +409,34 @@ impl SystemFunctions {
     }
 }
 
-/// Helper function to extract constraint definition by OID from CREATE TABLE SQL
-/// This is a simplified implementation - a full version would properly parse the SQL
-fn extract_constraint_by_oid(create_sql: &str, target_oid: i64, table_name: &str) -> Option<String> {
-    let sql_upper = create_sql.to_uppercase();
-    
-    // Generate OIDs for common constraint patterns
-    // We use a hash of table_name + constraint_type + some identifier
-    
-    // Check for PRIMARY KEY
-    if sql_upper.contains("PRIMARY KEY") {
-        let mut hasher = DefaultHasher::new();
-        format!("{table_name}_pkey").hash(&mut hasher);
-        let pkey_oid = hasher.finish() as i64 & 0x7FFFFFFF; // Keep it positive
-        
-        if pkey_oid == target_oid {
-            // Extract PRIMARY KEY definition
-            if let Some(start) = sql_upper.find("PRIMARY KEY") {
-                // Simple extraction - look for column names in parentheses
-                let remaining = &create_sql[start..];
-                if let Some(paren_start) = remaining.find('(')
-                    && let Some(paren_end) = remaining.find(')') {
-                        let columns = &remaining[paren_start..=paren_end];
-                        return Some(format!("PRIMARY KEY {columns}"));
-                    }
-            }
-        }
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_format_type_name() {
+        assert_eq!(SystemFunctions::format_type_name(1043, Some(504)), "character varying(500)");
+        assert_eq!(SystemFunctions::format_type_name(1043, Some(-1)), "character varying");
+        assert_eq!(SystemFunctions::format_type_name(1042, Some(14)), "character(10)");
+        assert_eq!(SystemFunctions::format_type_name(1042, None), "character");
+        assert_eq!(SystemFunctions::format_type_name(1042, Some(-1)), "bpchar");
+        assert_eq!(SystemFunctions::format_type_name(18, None), "\"char\"");
+        assert_eq!(SystemFunctions::format_type_name(1700, Some(655366)), "numeric(10,2)");
+        assert_eq!(SystemFunctions::format_type_name(1700, Some(655364)), "numeric(10,0)");
+        assert_eq!(SystemFunctions::format_type_name(1114, Some(3)), "timestamp(3) without time zone");
+        assert_eq!(SystemFunctions::format_type_name(1184, Some(-1)), "timestamp with time zone");
+        assert_eq!(SystemFunctions::format_type_name(1560, Some(8)), "bit(8)");
+        assert_eq!(SystemFunctions::format_type_name(1186, Some(0x7FFF0000 | 0xFFFF)), "interval");
+        assert_eq!(SystemFunctions::format_type_name(1186, Some(((1 << 3 | 1 << 10 | 1 << 11 | 1 << 12) << 16) | 3)), "interval day to second(3)");
+        assert_eq!(SystemFunctions::format_type_name(2950, Some(-1)), "uuid");
+        assert_eq!(SystemFunctions::format_type_name(3904, None), "int4range");
     }
-    
-    // Check for FOREIGN KEY constraints
-    if sql_upper.contains("FOREIGN KEY") {
-        let mut fkey_count = 0;
-        let mut search_start = 0;
-        
-        while let Some(fkey_pos) = sql_upper[search_start..].find("FOREIGN KEY") {
-            let actual_pos = search_start + fkey_pos;
-            fkey_count += 1;
-            
-            let mut hasher = DefaultHasher::new();
-            format!("{table_name}_fkey_{fkey_count}").hash(&mut hasher);
-            let fkey_oid = hasher.finish() as i64 & 0x7FFFFFFF;
-            
-            if fkey_oid == target_oid {
-                // Extract FOREIGN KEY definition
-                let remaining = &create_sql[actual_pos..];
-                // Look for pattern: FOREIGN KEY (columns) REFERENCES table (columns)
-                if let Some(ref_pos) = remaining.to_uppercase().find("REFERENCES") {
-                    // Find the end of the constraint (next comma or closing paren)
-                    let mut depth = 0;
-                    let mut end_pos = ref_pos;
-                    
-                    for (i, ch) in remaining[ref_pos..].chars().enumerate() {
-                        match ch {
-                            '(' => depth += 1,
-                            ')' => {
-                                depth -= 1;
-                                if depth <= 0 {
-                                    end_pos = ref_pos + i + 1;
-                                    break;
-                                }
-                            },
-                            ',' if depth == 0 => {
-                                end_pos = ref_pos + i;
-                                break;
-                            },
-                            _ => {}
-                        }
-                    }
-                    
-                    return Some(remaining[..end_pos].trim().to_string());
-                }
-            }
-            
-            search_start = actual_pos + 11; // Move past "FOREIGN KEY"
-        }
-    }
-    
-    // Check for CHECK constraints
-    if sql_upper.contains("CHECK") {
-        let mut check_count = 0;
-        let mut search_start = 0;
-        
-        while let Some(check_pos) = sql_upper[search_start..].find("CHECK") {
-            let actual_pos = search_start + check_pos;
-            check_count += 1;
-            
-            let mut hasher = DefaultHasher::new();
-            format!("{table_name}_check_{check_count}").hash(&mut hasher);
-            let check_oid = hasher.finish() as i64 & 0x7FFFFFFF;
-            
-            if check_oid == target_oid {
-                // Extract CHECK definition
-                let remaining = &create_sql[actual_pos..];
-                if let Some(paren_start) = remaining.find('(') {
-                    // Find matching closing parenthesis
-                    let mut depth = 0;
-                    let mut end_pos = paren_start;
-                    
-                    for (i, ch) in remaining[paren_start..].chars().enumerate() {
-                        match ch {
-                            '(' => depth += 1,
-                            ')' => {
-                                depth -= 1;
-                                if depth == 0 {
-                                    end_pos = paren_start + i + 1;
-                                    break;
-                                }
-                            },
-                            _ => {}
-                        }
-                    }
-                    
-                    return Some(remaining[..end_pos].to_string());
-                }
-            }
-            
-            search_start = actual_pos + 5; // Move past "CHECK"
-        }
+
+    #[test]
+    fn test_format_array_type_name() {
+        assert_eq!(SystemFunctions::format_type_name(1009, Some(-1)), "text[]");
+        assert_eq!(SystemFunctions::format_type_name(1007, None), "integer[]");
+        assert_eq!(SystemFunctions::format_type_name(1015, Some(104)), "character varying(100)[]");
+        assert_eq!(SystemFunctions::format_type_name(1231, Some(327686)), "numeric(5,2)[]");
     }
-    
-    None
-}
\ No newline at end of file
+}
@@ -30,11 +30,13 @@ impl WhereEvaluator {
                 let value = Self::get_column_value(expr, row_data);
                 value.is_some()
             }
-            Expr::Like { expr, pattern, negated, .. } => {
-                Self::evaluate_like(expr, pattern, *negated, row_data, column_mapping)
+            Expr::Like { expr, pattern, negated, escape_char, .. } => {
+                let escape = escape_char.as_ref().map(|escape| Self::escape_string(&escape.to_string()));
+                Self::evaluate_like(expr, pattern, *negated, escape.as_deref(), row_data, column_mapping)
             }
-            Expr::ILike { expr, pattern, negated, .. } => {
-                Self::evaluate_ilike(expr, pattern, *negated, row_data, column_mapping)
+            Expr::ILike { expr, pattern, negated, escape_char, .. } => {
+                let escape = escape_char.as_ref().map(|escape| Self::escape_string(&escape.to_string()));
+                Self::evaluate_ilike(expr, pattern, *negated, escape.as_deref(), row_data, column_mapping)
             }
             Expr::Value(val) => {
                 // A literal value evaluates to its boolean interpretation
@@ -189,6 +191,7 @@ impl WhereEvaluator {
         expr: &Expr,
         pattern: &Expr,
         negated: bool,
+        escape: Option<&str>,
         row_data: &HashMap<String, String>,
         _column_mapping: &HashMap<String, usize>,
     ) -> bool {
@@ -196,7 +199,7 @@ impl WhereEvaluator {
             Self::get_expression_value(expr, row_data),
             Self::get_expression_value(pattern, row_data),
         ) {
-            let matches = Self::like_match(&value, &pattern_str);
+            let matches = Self::like_match(&value, &pattern_str, escape);
             debug!("LIKE evaluation: '{}' LIKE '{}' = {}", value, pattern_str, matches);
             if negated {
                 !matches
@@ -213,6 +216,7 @@ impl WhereEvaluator {
         expr: &Expr,
         pattern: &Expr,
         negated: bool,
+        escape: Option<&str>,
         row_data: &HashMap<String, String>,
         _column_mapping: &HashMap<String, usize>,
     ) -> bool {
@@ -220,7 +224,7 @@ impl WhereEvaluator {
             Self::get_expression_value(expr, row_data),
             Self::get_expression_value(pattern, row_data),
         ) {
-            let matches = Self::like_match(&value.to_lowercase(), &pattern_str.to_lowercase());
+            let matches = Self::like_match(&value.to_lowercase(), &pattern_str.to_lowercase(), escape);
             if negated {
                 !matches
             } else {
@@ -320,7 +324,14 @@ impl WhereEvaluator {
         }
     }
 
-    fn like_match(value: &str, pattern: &str) -> bool {
+    /// The content of an ESCAPE clause, which prints as a quoted literal
+    fn escape_string(escape: &str) -> String {
+        escape.strip_prefix('\'').and_then(|e| e.strip_suffix('\'')).unwrap_or(escape).to_string()
+    }
+
+    /// Match a LIKE pattern, where a character following the ESCAPE character (if any)
+    /// is matched literally
+    fn like_match(value: &str, pattern: &str, escape: Option<&str>) -> bool {
         // Convert SQL LIKE pattern to simple pattern matching
         // % matches any sequence of characters
         // _ matches any single character
@@ -331,7 +342,18 @@ impl WhereEvaluator {
             return true;
         }
 
-        let pattern_chars: Vec<char> = pattern.chars().collect();
+        let escape = escape.and_then(|escape| escape.chars().next());
+        let mut pattern_chars = Vec::with_capacity(pattern.len());
+        let mut chars = pattern.chars();
+        while let Some(c) = chars.next() {
+            if Some(c) == escape {
+                if let Some(escaped) = chars.next() {
+                    pattern_chars.push((escaped, true));
+                }
+            } else {
+                pattern_chars.push((c, false));
+            }
+        }
         let value_chars: Vec<char> = value.chars().collect();
         
         Self::like_match_recursive(&value_chars, &pattern_chars, 0, 0)
@@ -339,7 +361,7 @@ impl WhereEvaluator {
 
     fn like_match_recursive(
         value: &[char],
-        pattern: &[char],
+        pattern: &[(char, bool)],
         val_idx: usize,
         pat_idx: usize,
     ) -> bool {
@@ -349,11 +371,11 @@ impl WhereEvaluator {
 
         if val_idx >= value.len() {
             // Only match if remaining pattern is all %
-            return pattern[pat_idx..].iter().all(|&c| c == '%');
+            return pattern[pat_idx..].iter().all(|&c| c == ('%', false));
         }
 
         match pattern[pat_idx] {
-            '%' => {
+            ('%', false) => {
                 // Try matching 0 or more characters
                 for i in val_idx..=value.len() {
                     if Self::like_match_recursive(value, pattern, i, pat_idx + 1) {
@@ -362,11 +384,11 @@ impl WhereEvaluator {
                 }
                 false
             }
-            '_' => {
+            ('_', false) => {
                 // Match exactly one character
                 Self::like_match_recursive(value, pattern, val_idx + 1, pat_idx + 1)
             }
-            c => {
+            (c, _) => {
                 // Match literal character
                 if value[val_idx] == c {
                     Self::like_match_recursive(value, pattern, val_idx + 1, pat_idx + 1)
@@ -437,13 +459,20 @@ mod tests {
     #[test]
     fn test_like_match_function() {
         // Test basic like patterns
-        assert!(WhereEvaluator::like_match("pgclass_test_table1", "pgclass_test_%"));
-        assert!(WhereEvaluator::like_match("pgclass_test_table2", "pgclass_test_%"));
-        assert!(!WhereEvaluator::like_match("other_table", "pgclass_test_%"));
-        assert!(WhereEvaluator::like_match("test", "test"));
-        assert!(WhereEvaluator::like_match("test", "te%"));
-        assert!(WhereEvaluator::like_match("test", "%st"));
-        assert!(WhereEvaluator::like_match("test", "t_st"));
+        assert!(WhereEvaluator::like_match("pgclass_test_table1", "pgclass_test_%", None));
+        assert!(WhereEvaluator::like_match("pgclass_test_table2", "pgclass_test_%", None));
+        assert!(!WhereEvaluator::like_match("other_table", "pgclass_test_%", None));
+        assert!(WhereEvaluator::like_match("test", "test", None));
+        assert!(WhereEvaluator::like_match("test", "te%", None));
+        assert!(WhereEvaluator::like_match("test", "%st", None));
+        assert!(WhereEvaluator::like_match("test", "t_st", None));
+
+        // ESCAPE makes a wildcard literal
+        assert!(WhereEvaluator::like_match("100%", "100\\%", Some("\\")));
+        assert!(!WhereEvaluator::like_match("1000", "100\\%", Some("\\")));
+        assert!(WhereEvaluator::like_match("a_b", "a!_b", Some("!")));
+        assert!(!WhereEvaluator::like_match("axb", "a!_b", Some("!")));
+        assert!(WhereEvaluator::like_match("a\\b", "a\\b", None));
     }
     
     #[test]
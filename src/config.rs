@@ -155,6 +155,10 @@ pub struct Config {
     #[arg(long, env = "PGSQLITE_SSL_EPHEMERAL", help = "Generate ephemeral SSL certificates on startup")]
     pub ssl_ephemeral: bool,
 
+    // Role configuration
+    #[arg(long, env = "PGSQLITE_PERMISSIVE_ROLES", help = "Accept SET ROLE for roles that don't exist in pg_roles")]
+    pub permissive_roles: bool,
+
     // Migration configuration
     #[arg(long, help = "Run pending database migrations and exit")]
     pub migrate: bool,
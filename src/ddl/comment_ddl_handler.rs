@@ -5,13 +5,14 @@ use tracing::{debug, info};
 use once_cell::sync::Lazy;
 use regex::Regex;
 
-// Pre-compiled regex patterns for COMMENT ON statements
+// Pre-compiled regex patterns for COMMENT ON statements. Table and column names
+// may be double-quoted and the table may carry a schema qualifier.
 static COMMENT_ON_TABLE_REGEX: Lazy<Regex> = Lazy::new(|| {
-    Regex::new(r"(?i)COMMENT\s+ON\s+TABLE\s+(\w+)\s+IS\s+(?:'((?:''|[^'])*)'|NULL)").unwrap()
+    Regex::new(r#"(?i)COMMENT\s+ON\s+TABLE\s+(?:(?:"(?:[^"]|"")+"|\w+)\.)?("(?:[^"]|"")+"|\w+)\s+IS\s+(?:'((?:''|[^'])*)'|NULL)"#).unwrap()
 });
 
 static COMMENT_ON_COLUMN_REGEX: Lazy<Regex> = Lazy::new(|| {
-    Regex::new(r"(?i)COMMENT\s+ON\s+COLUMN\s+(\w+)\.(\w+)\s+IS\s+(?:'((?:''|[^'])*)'|NULL)").unwrap()
+    Regex::new(r#"(?i)COMMENT\s+ON\s+COLUMN\s+(?:(?:"(?:[^"]|"")+"|\w+)\.)?("(?:[^"]|"")+"|\w+)\.("(?:[^"]|"")+"|\w+)\s+IS\s+(?:'((?:''|[^'])*)'|NULL)"#).unwrap()
 });
 
 static COMMENT_ON_FUNCTION_REGEX: Lazy<Regex> = Lazy::new(|| {
@@ -58,24 +59,16 @@ impl CommentDdlHandler {
             .captures(query)
             .ok_or_else(|| PgSqliteError::Protocol("Invalid COMMENT ON TABLE syntax".to_string()))?;
             
-        let table_name = captures.get(1).unwrap().as_str();
-        let comment_text = captures.get(2).map(|m| m.as_str());
+        let table_name = Self::resolve_table_name(conn, captures.get(1).unwrap().as_str())?;
+        let comment_text = captures.get(2).map(|m| Self::unescape_literal(m.as_str()));
         
         info!("Setting comment on table '{}': {:?}", table_name, comment_text);
         
-        // Validate table exists
-        if !ObjectResolver::table_exists(conn, table_name)? {
-            return Err(PgSqliteError::Protocol(format!(
-                "Table '{}' does not exist", 
-                table_name
-            )));
-        }
-        
-        let table_oid = ObjectResolver::resolve_table_oid(table_name);
+        let table_oid = ObjectResolver::resolve_table_oid(&table_name);
         
         if let Some(comment) = comment_text {
             // Set or update comment
-            Self::set_comment(conn, table_oid, "pg_class", 0, Some(comment))?;
+            Self::set_comment(conn, table_oid, "pg_class", 0, Some(&comment))?;
         } else {
             // Remove comment (IS NULL)
             Self::set_comment(conn, table_oid, "pg_class", 0, None)?;
@@ -95,18 +88,18 @@ impl CommentDdlHandler {
             .captures(query)
             .ok_or_else(|| PgSqliteError::Protocol("Invalid COMMENT ON COLUMN syntax".to_string()))?;
             
-        let table_name = captures.get(1).unwrap().as_str();
-        let column_name = captures.get(2).unwrap().as_str();
-        let comment_text = captures.get(3).map(|m| m.as_str());
+        let table_name = Self::resolve_table_name(conn, captures.get(1).unwrap().as_str())?;
+        let column_name = Self::unquote_identifier(captures.get(2).unwrap().as_str());
+        let comment_text = captures.get(3).map(|m| Self::unescape_literal(m.as_str()));
         
         info!("Setting comment on column '{}.{}': {:?}", table_name, column_name, comment_text);
         
         // Validate table and column exist
-        let (table_oid, column_number) = ObjectResolver::resolve_column_oid(conn, table_name, column_name)?;
+        let (table_oid, column_number) = ObjectResolver::resolve_column_oid(conn, &table_name, &column_name)?;
         
         if let Some(comment) = comment_text {
             // Set or update comment
-            Self::set_comment(conn, table_oid, "pg_class", column_number, Some(comment))?;
+            Self::set_comment(conn, table_oid, "pg_class", column_number, Some(&comment))?;
         } else {
             // Remove comment (IS NULL)
             Self::set_comment(conn, table_oid, "pg_class", column_number, None)?;
@@ -127,7 +120,7 @@ impl CommentDdlHandler {
             .ok_or_else(|| PgSqliteError::Protocol("Invalid COMMENT ON FUNCTION syntax".to_string()))?;
             
         let function_name = captures.get(1).unwrap().as_str();
-        let comment_text = captures.get(2).map(|m| m.as_str());
+        let comment_text = captures.get(2).map(|m| Self::unescape_literal(m.as_str()));
         
         info!("Setting comment on function '{}': {:?}", function_name, comment_text);
         
@@ -136,7 +129,7 @@ impl CommentDdlHandler {
         
         if let Some(comment) = comment_text {
             // Set or update comment
-            Self::set_comment(conn, function_oid, "pg_proc", 0, Some(comment))?;
+            Self::set_comment(conn, function_oid, "pg_proc", 0, Some(&comment))?;
         } else {
             // Remove comment (IS NULL)  
             Self::set_comment(conn, function_oid, "pg_proc", 0, None)?;
@@ -145,6 +138,32 @@ impl CommentDdlHandler {
         Ok(())
     }
     
+    /// Map a possibly quoted table name to the table's stored name, which is
+    /// what its catalog OID is derived from
+    fn resolve_table_name(conn: &Connection, identifier: &str) -> Result<String, PgSqliteError> {
+        let table_name = Self::unquote_identifier(identifier);
+        conn.query_row(
+            "SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?1 COLLATE NOCASE",
+            [&table_name],
+            |row| row.get(0),
+        ).optional()?
+            .ok_or_else(|| PgSqliteError::Protocol(format!("Table '{}' does not exist", table_name)))
+    }
+    
+    /// Strip double quotes from a quoted identifier
+    fn unquote_identifier(identifier: &str) -> String {
+        if identifier.len() >= 2 && identifier.starts_with('"') && identifier.ends_with('"') {
+            identifier[1..identifier.len() - 1].replace("\"\"", "\"")
+        } else {
+            identifier.to_string()
+        }
+    }
+    
+    /// Turn the body of a string literal into the text it denotes
+    fn unescape_literal(literal: &str) -> String {
+        literal.replace("''", "'")
+    }
+    
     /// Set or remove a comment in the database
     pub fn set_comment(
         conn: &mut Connection,
@@ -280,6 +299,42 @@ mod tests {
         assert_eq!(comment, Some("Updated email comment".to_string()));
     }
     
+    #[test]
+    fn test_comment_on_quoted_and_qualified_names() {
+        let mut conn = setup_test_db();
+        
+        CommentDdlHandler::handle_comment_ddl(
+            &mut conn,
+            "COMMENT ON TABLE public.\"users\" IS 'People''s accounts'"
+        ).unwrap();
+        CommentDdlHandler::handle_comment_ddl(
+            &mut conn,
+            "COMMENT ON COLUMN public.users.\"name\" IS 'Display name'"
+        ).unwrap();
+        CommentDdlHandler::handle_comment_ddl(
+            &mut conn,
+            "COMMENT ON COLUMN \"users\".email IS 'Contact address'"
+        ).unwrap();
+        
+        // Quotes are stored unescaped, as PostgreSQL does
+        let table_oid = ObjectResolver::resolve_table_oid("users");
+        let comment = CommentDdlHandler::get_comment(&conn, table_oid, "pg_class", 0).unwrap();
+        assert_eq!(comment, Some("People's accounts".to_string()));
+        
+        let comment = CommentDdlHandler::get_comment(&conn, table_oid, "pg_class", 2).unwrap();
+        assert_eq!(comment, Some("Display name".to_string()));
+        let comment = CommentDdlHandler::get_comment(&conn, table_oid, "pg_class", 3).unwrap();
+        assert_eq!(comment, Some("Contact address".to_string()));
+        
+        // Unquoted names match the stored table name case-insensitively
+        CommentDdlHandler::handle_comment_ddl(
+            &mut conn,
+            "COMMENT ON TABLE USERS IS 'Accounts'"
+        ).unwrap();
+        let comment = CommentDdlHandler::get_comment(&conn, table_oid, "pg_class", 0).unwrap();
+        assert_eq!(comment, Some("Accounts".to_string()));
+    }
+    
     #[test]
     fn test_comment_on_function() {
         let mut conn = setup_test_db();
@@ -24,6 +24,15 @@ pub enum PgError {
     },
     /// 23503: Foreign key violation
     ForeignKeyViolation {
+        /// Table the failed statement modified
+        table_name: String,
+        constraint_name: String,
+        /// Set when a referenced row was deleted or updated; names the table still referencing it
+        referencing_table: Option<String>,
+        detail: String,
+    },
+    /// 23P01: Exclusion constraint violation
+    ExclusionViolation {
         constraint_name: String,
         detail: String,
     },
@@ -119,11 +128,39 @@ impl PgError {
                     routine: None,
                 }
             }
-            PgError::ForeignKeyViolation { constraint_name, detail } => {
+            PgError::ForeignKeyViolation { table_name, constraint_name, referencing_table, detail } => {
                 ErrorResponse {
                     severity: "ERROR".to_string(),
                     code: "23503".to_string(),
-                    message: format!("insert or update on table violates foreign key constraint \"{constraint_name}\""),
+                    message: match referencing_table {
+                        Some(referencing) => format!(
+                            "update or delete on table \"{table_name}\" violates foreign key constraint \"{constraint_name}\" on table \"{referencing}\""
+                        ),
+                        None => format!(
+                            "insert or update on table \"{table_name}\" violates foreign key constraint \"{constraint_name}\""
+                        ),
+                    },
+                    detail: Some(detail.clone()),
+                    hint: None,
+                    position: None,
+                    internal_position: None,
+                    internal_query: None,
+                    where_: None,
+                    schema: None,
+                    table: Some(referencing_table.clone().unwrap_or_else(|| table_name.clone())),
+                    column: None,
+                    datatype: None,
+                    constraint: Some(constraint_name.clone()),
+                    file: None,
+                    line: None,
+                    routine: None,
+                }
+            }
+            PgError::ExclusionViolation { constraint_name, detail } => {
+                ErrorResponse {
+                    severity: "ERROR".to_string(),
+                    code: "23P01".to_string(),
+                    message: format!("conflicting key value violates exclusion constraint \"{constraint_name}\""),
                     detail: Some(detail.clone()),
                     hint: None,
                     position: None,
@@ -198,8 +235,11 @@ impl fmt::Display for PgError {
             PgError::UniqueViolation { constraint_name, detail } => {
                 write!(f, "duplicate key value violates unique constraint \"{constraint_name}\": {detail}")
             }
-            PgError::ForeignKeyViolation { constraint_name, detail } => {
-                write!(f, "foreign key constraint \"{constraint_name}\" violation: {detail}")
+            PgError::ForeignKeyViolation { table_name, constraint_name, detail, .. } => {
+                write!(f, "foreign key constraint \"{constraint_name}\" on table \"{table_name}\" violation: {detail}")
+            }
+            PgError::ExclusionViolation { constraint_name, detail } => {
+                write!(f, "conflicting key value violates exclusion constraint \"{constraint_name}\": {detail}")
             }
             PgError::SyntaxError { message, position } => {
                 if let Some(pos) = position {
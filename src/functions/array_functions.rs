@@ -8,6 +8,7 @@ pub fn register_array_functions(conn: &Connection) -> Result<()> {
     register_array_upper(conn)?;
     register_array_lower(conn)?;
     register_array_ndims(conn)?;
+    register_cardinality(conn)?;
     
     // Array manipulation functions
     register_array_append(conn)?;
@@ -148,10 +149,9 @@ fn register_array_ndims(conn: &Connection) -> Result<()> {
         1,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let array_json: String = ctx.get(0)?;
-            
-            match serde_json::from_str::<JsonValue>(&array_json) {
-                Ok(json) => Ok(Some(count_dimensions(&json))),
+            // PostgreSQL reports no dimensions for an empty array, so it returns NULL
+            match array_argument(ctx.get_raw(0)) {
+                Some(arr) if !arr.is_empty() => Ok(Some(count_dimensions(&JsonValue::Array(arr)))),
                 _ => Ok(None),
             }
         },
@@ -160,6 +160,20 @@ fn register_array_ndims(conn: &Connection) -> Result<()> {
     Ok(())
 }
 
+/// cardinality(array) - Get total number of elements across all dimensions
+fn register_cardinality(conn: &Connection) -> Result<()> {
+    conn.create_scalar_function(
+        "cardinality",
+        1,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            Ok(array_argument(ctx.get_raw(0)).map(|arr| count_elements(&arr)))
+        },
+    )?;
+    
+    Ok(())
+}
+
 /// array_append(array, element) - Append element to array
 fn register_array_append(conn: &Connection) -> Result<()> {
     conn.create_scalar_function(
@@ -167,31 +181,12 @@ fn register_array_append(conn: &Connection) -> Result<()> {
         2,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let array_json: String = ctx.get(0)?;
-            
-            // Handle element parameter of different types
-            let elem_value = match ctx.get_raw(1) {
-                rusqlite::types::ValueRef::Text(s) => {
-                    let text = std::str::from_utf8(s).unwrap_or("");
-                    serde_json::from_str::<JsonValue>(text)
-                        .unwrap_or_else(|_| JsonValue::String(text.to_string()))
-                }
-                rusqlite::types::ValueRef::Integer(i) => JsonValue::Number(serde_json::Number::from(i)),
-                rusqlite::types::ValueRef::Real(f) => {
-                    JsonValue::Number(serde_json::Number::from_f64(f).unwrap_or_else(|| serde_json::Number::from(0)))
-                }
-                rusqlite::types::ValueRef::Null => JsonValue::Null,
-                rusqlite::types::ValueRef::Blob(b) => {
-                    JsonValue::String(format!("\\x{}", hex::encode(b)))
-                }
-            };
-            
-            match serde_json::from_str::<JsonValue>(&array_json) {
-                Ok(JsonValue::Array(mut arr)) => {
-                    arr.push(elem_value);
+            match array_argument(ctx.get_raw(0)) {
+                Some(mut arr) => {
+                    arr.push(element_json(ctx.get_raw(1)));
                     Ok(serde_json::to_string(&arr).ok())
                 }
-                _ => Ok(None),
+                None => Ok(None),
             }
         },
     )?;
@@ -206,31 +201,12 @@ fn register_array_prepend(conn: &Connection) -> Result<()> {
         2,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            // Handle element parameter of different types
-            let elem_value = match ctx.get_raw(0) {
-                rusqlite::types::ValueRef::Text(s) => {
-                    let text = std::str::from_utf8(s).unwrap_or("");
-                    serde_json::from_str::<JsonValue>(text)
-                        .unwrap_or_else(|_| JsonValue::String(text.to_string()))
-                }
-                rusqlite::types::ValueRef::Integer(i) => JsonValue::Number(serde_json::Number::from(i)),
-                rusqlite::types::ValueRef::Real(f) => {
-                    JsonValue::Number(serde_json::Number::from_f64(f).unwrap_or_else(|| serde_json::Number::from(0)))
-                }
-                rusqlite::types::ValueRef::Null => JsonValue::Null,
-                rusqlite::types::ValueRef::Blob(b) => {
-                    JsonValue::String(format!("\\x{}", hex::encode(b)))
-                }
-            };
-            
-            let array_json: String = ctx.get(1)?;
-            
-            match serde_json::from_str::<JsonValue>(&array_json) {
-                Ok(JsonValue::Array(mut arr)) => {
-                    arr.insert(0, elem_value);
+            match array_argument(ctx.get_raw(1)) {
+                Some(mut arr) => {
+                    arr.insert(0, element_json(ctx.get_raw(0)));
                     Ok(serde_json::to_string(&arr).ok())
                 }
-                _ => Ok(None),
+                None => Ok(None),
             }
         },
     )?;
@@ -238,32 +214,80 @@ fn register_array_prepend(conn: &Connection) -> Result<()> {
     Ok(())
 }
 
-/// array_cat(array1, array2) - Concatenate two arrays
+/// array_cat(array1, array2) - Concatenate two arrays. It also backs the || operator,
+/// so when only one argument is an array the other is appended or prepended to it.
 fn register_array_cat(conn: &Connection) -> Result<()> {
     conn.create_scalar_function(
         "array_cat",
         2,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let array1_json: String = ctx.get(0)?;
-            let array2_json: String = ctx.get(1)?;
-            
-            match (
-                serde_json::from_str::<JsonValue>(&array1_json),
-                serde_json::from_str::<JsonValue>(&array2_json),
-            ) {
-                (Ok(JsonValue::Array(mut arr1)), Ok(JsonValue::Array(arr2))) => {
+            let merged = match (array_argument(ctx.get_raw(0)), array_argument(ctx.get_raw(1))) {
+                (Some(mut arr1), Some(arr2)) => {
                     arr1.extend(arr2);
-                    Ok(serde_json::to_string(&arr1).ok())
+                    arr1
                 }
-                _ => Ok(None),
-            }
+                (Some(mut arr), None) => {
+                    arr.push(element_json(ctx.get_raw(1)));
+                    arr
+                }
+                (None, Some(mut arr)) => {
+                    arr.insert(0, element_json(ctx.get_raw(0)));
+                    arr
+                }
+                (None, None) => return Ok(None),
+            };
+            Ok(serde_json::to_string(&merged).ok())
         },
     )?;
     
     Ok(())
 }
 
+/// Elements of an array argument, stored as a JSON array or written as a PostgreSQL
+/// array literal such as '{a,b}'; None for NULL and non-array values
+fn array_argument(value: rusqlite::types::ValueRef) -> Option<Vec<JsonValue>> {
+    match crate::functions::json_functions::array_json(value.as_str().ok()?) {
+        Ok(JsonValue::Array(elements)) => Some(elements),
+        _ => None,
+    }
+}
+
+/// Compare an array element with a searched value. Text arguments are read as JSON, so
+/// '5' searches for the number 5; scalars also match on their text form, so it still
+/// finds the element of a text array stored as "5".
+fn same_element(element: &JsonValue, value: &JsonValue) -> bool {
+    fn scalar_text(value: &JsonValue) -> Option<String> {
+        match value {
+            JsonValue::String(s) => Some(s.clone()),
+            JsonValue::Number(n) => Some(n.to_string()),
+            JsonValue::Bool(b) => Some(b.to_string()),
+            _ => None,
+        }
+    }
+    
+    element == value || scalar_text(element).is_some_and(|text| scalar_text(value).as_ref() == Some(&text))
+}
+
+/// JSON form of an element argument; text holding JSON keeps its JSON type
+fn element_json(value: rusqlite::types::ValueRef) -> JsonValue {
+    match value {
+        rusqlite::types::ValueRef::Text(s) => {
+            let text = std::str::from_utf8(s).unwrap_or("");
+            serde_json::from_str::<JsonValue>(text)
+                .unwrap_or_else(|_| JsonValue::String(text.to_string()))
+        }
+        rusqlite::types::ValueRef::Integer(i) => JsonValue::Number(serde_json::Number::from(i)),
+        rusqlite::types::ValueRef::Real(f) => {
+            JsonValue::Number(serde_json::Number::from_f64(f).unwrap_or_else(|| serde_json::Number::from(0)))
+        }
+        rusqlite::types::ValueRef::Null => JsonValue::Null,
+        rusqlite::types::ValueRef::Blob(b) => {
+            JsonValue::String(format!("\\x{}", hex::encode(b)))
+        }
+    }
+}
+
 /// array_remove(array, element) - Remove all occurrences of element
 fn register_array_remove(conn: &Connection) -> Result<()> {
     conn.create_scalar_function(
@@ -271,34 +295,17 @@ fn register_array_remove(conn: &Connection) -> Result<()> {
         2,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let array_json: String = ctx.get(0)?;
+            let elem_value = element_json(ctx.get_raw(1));
             
-            // Handle element parameter of different types
-            let elem_value = match ctx.get_raw(1) {
-                rusqlite::types::ValueRef::Text(s) => {
-                    let text = std::str::from_utf8(s).unwrap_or("");
-                    serde_json::from_str::<JsonValue>(text)
-                        .unwrap_or_else(|_| JsonValue::String(text.to_string()))
-                }
-                rusqlite::types::ValueRef::Integer(i) => JsonValue::Number(serde_json::Number::from(i)),
-                rusqlite::types::ValueRef::Real(f) => {
-                    JsonValue::Number(serde_json::Number::from_f64(f).unwrap_or_else(|| serde_json::Number::from(0)))
-                }
-                rusqlite::types::ValueRef::Null => JsonValue::Null,
-                rusqlite::types::ValueRef::Blob(b) => {
-                    JsonValue::String(format!("\\x{}", hex::encode(b)))
-                }
-            };
-            
-            match serde_json::from_str::<JsonValue>(&array_json) {
-                Ok(JsonValue::Array(arr)) => {
+            match array_argument(ctx.get_raw(0)) {
+                Some(arr) => {
                     let filtered: Vec<JsonValue> = arr.into_iter()
-                        .filter(|v| v != &elem_value)
+                        .filter(|v| !same_element(v, &elem_value))
                         .collect();
                     
                     Ok(serde_json::to_string(&filtered).ok())
                 }
-                _ => Ok(None),
+                None => Ok(None),
             }
         },
     )?;
@@ -313,51 +320,18 @@ fn register_array_replace(conn: &Connection) -> Result<()> {
         3,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let array_json: String = ctx.get(0)?;
+            let old_value = element_json(ctx.get_raw(1));
+            let new_value = element_json(ctx.get_raw(2));
             
-            // Handle old_element parameter of different types
-            let old_value = match ctx.get_raw(1) {
-                rusqlite::types::ValueRef::Text(s) => {
-                    let text = std::str::from_utf8(s).unwrap_or("");
-                    serde_json::from_str::<JsonValue>(text)
-                        .unwrap_or_else(|_| JsonValue::String(text.to_string()))
-                }
-                rusqlite::types::ValueRef::Integer(i) => JsonValue::Number(serde_json::Number::from(i)),
-                rusqlite::types::ValueRef::Real(f) => {
-                    JsonValue::Number(serde_json::Number::from_f64(f).unwrap_or_else(|| serde_json::Number::from(0)))
-                }
-                rusqlite::types::ValueRef::Null => JsonValue::Null,
-                rusqlite::types::ValueRef::Blob(b) => {
-                    JsonValue::String(format!("\\x{}", hex::encode(b)))
-                }
-            };
-            
-            // Handle new_element parameter of different types
-            let new_value = match ctx.get_raw(2) {
-                rusqlite::types::ValueRef::Text(s) => {
-                    let text = std::str::from_utf8(s).unwrap_or("");
-                    serde_json::from_str::<JsonValue>(text)
-                        .unwrap_or_else(|_| JsonValue::String(text.to_string()))
-                }
-                rusqlite::types::ValueRef::Integer(i) => JsonValue::Number(serde_json::Number::from(i)),
-                rusqlite::types::ValueRef::Real(f) => {
-                    JsonValue::Number(serde_json::Number::from_f64(f).unwrap_or_else(|| serde_json::Number::from(0)))
-                }
-                rusqlite::types::ValueRef::Null => JsonValue::Null,
-                rusqlite::types::ValueRef::Blob(b) => {
-                    JsonValue::String(format!("\\x{}", hex::encode(b)))
-                }
-            };
-            
-            match serde_json::from_str::<JsonValue>(&array_json) {
-                Ok(JsonValue::Array(arr)) => {
+            match array_argument(ctx.get_raw(0)) {
+                Some(arr) => {
                     let replaced: Vec<JsonValue> = arr.into_iter()
-                        .map(|v| if v == old_value { new_value.clone() } else { v })
+                        .map(|v| if same_element(&v, &old_value) { new_value.clone() } else { v })
                         .collect();
                     
                     Ok(serde_json::to_string(&replaced).ok())
                 }
-                _ => Ok(None),
+                None => Ok(None),
             }
         },
     )?;
@@ -478,46 +452,36 @@ fn register_array_slice(conn: &Connection) -> Result<()> {
     Ok(())
 }
 
-/// array_position(array, element) - Find position of element (1-based)
+/// array_position(array, element [, start]) - Find position of element (1-based),
+/// searching from the start position when one is given
 fn register_array_position(conn: &Connection) -> Result<()> {
-    conn.create_scalar_function(
-        "array_position",
-        2,
-        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let array_json: String = ctx.get(0)?;
-            
-            // Handle element parameter of different types
-            let elem_value = match ctx.get_raw(1) {
-                rusqlite::types::ValueRef::Text(s) => {
-                    let text = std::str::from_utf8(s).unwrap_or("");
-                    serde_json::from_str::<JsonValue>(text)
-                        .unwrap_or_else(|_| JsonValue::String(text.to_string()))
-                }
-                rusqlite::types::ValueRef::Integer(i) => JsonValue::Number(serde_json::Number::from(i)),
-                rusqlite::types::ValueRef::Real(f) => {
-                    JsonValue::Number(serde_json::Number::from_f64(f).unwrap_or_else(|| serde_json::Number::from(0)))
-                }
-                rusqlite::types::ValueRef::Null => JsonValue::Null,
-                rusqlite::types::ValueRef::Blob(b) => {
-                    JsonValue::String(format!("\\x{}", hex::encode(b)))
-                }
-            };
-            
-            match serde_json::from_str::<JsonValue>(&array_json) {
-                Ok(JsonValue::Array(arr)) => {
-                    // Find first occurrence (1-based index)
-                    for (i, val) in arr.iter().enumerate() {
-                        if val == &elem_value {
-                            return Ok(Some((i + 1) as i32));
-                        }
+    for arity in [2, 3] {
+        conn.create_scalar_function(
+            "array_position",
+            arity,
+            FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| {
+                let elem_value = element_json(ctx.get_raw(1));
+                let start = if arity == 3 {
+                    match ctx.get::<Option<i64>>(2)? {
+                        Some(start) => start.max(1) as usize,
+                        None => return Ok(None),
                     }
-                    Ok(None)
-                }
-                _ => Ok(None),
-            }
-        },
-    )?;
+                } else {
+                    1
+                };
+                
+                Ok(array_argument(ctx.get_raw(0)).and_then(|arr| {
+                    // Find first occurrence (1-based index)
+                    arr.iter()
+                        .enumerate()
+                        .skip(start - 1)
+                        .find(|(_, val)| same_element(val, &elem_value))
+                        .map(|(i, _)| (i + 1) as i32)
+                }))
+            },
+        )?;
+    }
     
     Ok(())
 }
@@ -529,42 +493,20 @@ fn register_array_positions(conn: &Connection) -> Result<()> {
         2,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let array_json: String = ctx.get(0)?;
-            
-            // Handle element parameter of different types
-            let elem_value = match ctx.get_raw(1) {
-                rusqlite::types::ValueRef::Text(s) => {
-                    let text = std::str::from_utf8(s).unwrap_or("");
-                    serde_json::from_str::<JsonValue>(text)
-                        .unwrap_or_else(|_| JsonValue::String(text.to_string()))
-                }
-                rusqlite::types::ValueRef::Integer(i) => JsonValue::Number(serde_json::Number::from(i)),
-                rusqlite::types::ValueRef::Real(f) => {
-                    JsonValue::Number(serde_json::Number::from_f64(f).unwrap_or_else(|| serde_json::Number::from(0)))
-                }
-                rusqlite::types::ValueRef::Null => JsonValue::Null,
-                rusqlite::types::ValueRef::Blob(b) => {
-                    JsonValue::String(format!("\\x{}", hex::encode(b)))
-                }
-            };
+            let elem_value = element_json(ctx.get_raw(1));
             
-            match serde_json::from_str::<JsonValue>(&array_json) {
-                Ok(JsonValue::Array(arr)) => {
+            match array_argument(ctx.get_raw(0)) {
+                Some(arr) => {
                     // Find all occurrences (1-based indices)
                     let positions: Vec<i32> = arr.iter()
                         .enumerate()
-                        .filter_map(|(i, val)| {
-                            if val == &elem_value {
-                                Some((i + 1) as i32)
-                            } else {
-                                None
-                            }
-                        })
+                        .filter(|(_, val)| same_element(val, &elem_value))
+                        .map(|(i, _)| (i + 1) as i32)
                         .collect();
                     
                     Ok(serde_json::to_string(&positions).ok())
                 }
-                _ => Ok(Some("[]".to_string())),
+                None => Ok(None),
             }
         },
     )?;
@@ -572,12 +514,16 @@ fn register_array_positions(conn: &Connection) -> Result<()> {
     Ok(())
 }
 
-/// array_agg aggregate function
+/// array_agg aggregate function, plus array_agg_distinct for array_agg(DISTINCT ...)
 fn register_array_agg(conn: &Connection) -> Result<()> {
     use rusqlite::functions::Aggregate;
+    use std::collections::HashSet;
     
     #[derive(Default)]
-    struct ArrayAgg;
+    struct ArrayAgg {
+        /// Drop repeated values, keeping the first occurrence in aggregation order
+        distinct: bool,
+    }
     
     impl Aggregate<Vec<JsonValue>, Option<String>> for ArrayAgg {
         fn init(&self, _: &mut rusqlite::functions::Context<'_>) -> Result<Vec<JsonValue>> {
@@ -607,7 +553,14 @@ fn register_array_agg(conn: &Connection) -> Result<()> {
         }
         
         fn finalize(&self, _: &mut rusqlite::functions::Context<'_>, agg: Option<Vec<JsonValue>>) -> Result<Option<String>> {
-            Ok(agg.map(|values| serde_json::to_string(&values).unwrap_or_else(|_| "[]".to_string())))
+            Ok(agg.map(|mut values| {
+                if self.distinct {
+                    // Values that serialize the same are the same array element
+                    let mut seen = HashSet::new();
+                    values.retain(|value| seen.insert(value.to_string()));
+                }
+                serde_json::to_string(&values).unwrap_or_else(|_| "[]".to_string())
+            }))
         }
     }
     
@@ -616,164 +569,117 @@ fn register_array_agg(conn: &Connection) -> Result<()> {
         "array_agg",
         1,
         FunctionFlags::SQLITE_UTF8,
-        ArrayAgg,
+        ArrayAgg::default(),
     )?;
     
-    // Enhanced array_agg_distinct function for DISTINCT support
-    register_array_agg_distinct(conn)?;
-    
-    Ok(())
-}
-
-/// array_agg_distinct aggregate function - supports DISTINCT functionality
-fn register_array_agg_distinct(conn: &Connection) -> Result<()> {
-    use rusqlite::functions::Aggregate;
-    use std::collections::HashSet;
-    
-    #[derive(Default)]
-    struct ArrayAggDistinct;
-    
-    impl Aggregate<HashSet<String>, Option<String>> for ArrayAggDistinct {
-        fn init(&self, _: &mut rusqlite::functions::Context<'_>) -> Result<HashSet<String>> {
-            Ok(HashSet::new())
-        }
-        
-        fn step(&self, ctx: &mut rusqlite::functions::Context<'_>, agg: &mut HashSet<String>) -> Result<()> {
-            let value = ctx.get_raw(0);
-            
-            let json_value = match value {
-                rusqlite::types::ValueRef::Null => JsonValue::Null,
-                rusqlite::types::ValueRef::Integer(i) => json!(i),
-                rusqlite::types::ValueRef::Real(f) => json!(f),
-                rusqlite::types::ValueRef::Text(s) => {
-                    let text = std::str::from_utf8(s).unwrap_or("");
-                    serde_json::from_str(text)
-                        .unwrap_or_else(|_| JsonValue::String(text.to_string()))
-                }
-                rusqlite::types::ValueRef::Blob(b) => {
-                    JsonValue::String(format!("\\x{}", hex::encode(b)))
-                }
-            };
-            
-            // Use string representation for uniqueness check
-            let key = match &json_value {
-                JsonValue::String(s) => s.clone(),
-                JsonValue::Number(n) => n.to_string(),
-                JsonValue::Bool(b) => b.to_string(),
-                JsonValue::Null => "null".to_string(),
-                _ => serde_json::to_string(&json_value).unwrap_or_default(),
-            };
-            
-            agg.insert(key);
-            Ok(())
-        }
-        
-        fn finalize(&self, _: &mut rusqlite::functions::Context<'_>, agg: Option<HashSet<String>>) -> Result<Option<String>> {
-            if let Some(values) = agg {
-                let mut sorted_values: Vec<String> = values.into_iter().collect();
-                sorted_values.sort();
-                
-                let json_values: Vec<JsonValue> = sorted_values.into_iter()
-                    .map(|s| {
-                        // Try to parse back to appropriate JSON type
-                        if s == "null" {
-                            JsonValue::Null
-                        } else if s == "true" {
-                            JsonValue::Bool(true)
-                        } else if s == "false" {
-                            JsonValue::Bool(false)
-                        } else if let Ok(num) = s.parse::<i64>() {
-                            json!(num)
-                        } else if let Ok(num) = s.parse::<f64>() {
-                            json!(num)
-                        } else {
-                            JsonValue::String(s)
-                        }
-                    })
-                    .collect();
-                
-                Ok(Some(serde_json::to_string(&json_values).unwrap_or_else(|_| "[]".to_string())))
-            } else {
-                Ok(Some("[]".to_string()))
-            }
-        }
-    }
-    
+    // array_agg(DISTINCT ...) is translated to array_agg_distinct, with the
+    // translator supplying the ORDER BY that decides which values come first
     conn.create_aggregate_function(
         "array_agg_distinct",
         1,
         FunctionFlags::SQLITE_UTF8,
-        ArrayAggDistinct,
+        ArrayAgg { distinct: true },
     )?;
     
     Ok(())
 }
 
 
-/// string_to_array(string, delimiter) - Split string into array
+/// string_to_array(string, delimiter [, null_string]) - Split string into array
 fn register_string_to_array(conn: &Connection) -> Result<()> {
-    conn.create_scalar_function(
-        "string_to_array",
-        2,
-        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let input_string: String = ctx.get(0)?;
-            let delimiter: String = ctx.get(1)?;
-            
-            if input_string.is_empty() {
-                return Ok(Some("[]".to_string()));
-            }
-            
-            let parts: Vec<JsonValue> = if delimiter.is_empty() {
-                // Split into individual characters
-                input_string.chars()
-                    .map(|c| JsonValue::String(c.to_string()))
-                    .collect()
-            } else {
-                // Split by delimiter
-                input_string.split(&delimiter)
-                    .map(|s| JsonValue::String(s.to_string()))
-                    .collect()
-            };
-            
-            Ok(serde_json::to_string(&parts).ok())
-        },
-    )?;
+    for n_args in [2, 3] {
+        conn.create_scalar_function(
+            "string_to_array",
+            n_args,
+            FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+            |ctx| {
+                let input_string: Option<String> = ctx.get(0)?;
+                let delimiter: Option<String> = ctx.get(1)?;
+                let null_string: Option<String> = if ctx.len() > 2 { ctx.get(2)? } else { None };
+                
+                let Some(input_string) = input_string else {
+                    return Ok(None);
+                };
+                if input_string.is_empty() {
+                    return Ok(Some("[]".to_string()));
+                }
+                
+                let parts: Vec<&str> = match delimiter.as_deref() {
+                    // A NULL delimiter splits into individual characters
+                    None => input_string.char_indices()
+                        .map(|(i, c)| &input_string[i..i + c.len_utf8()])
+                        .collect(),
+                    // An empty delimiter keeps the whole string as one element
+                    Some("") => vec![input_string.as_str()],
+                    Some(delimiter) => input_string.split(delimiter).collect(),
+                };
+                
+                let elements: Vec<JsonValue> = parts.into_iter()
+                    .map(|part| match null_string.as_deref() {
+                        Some(null_string) if part == null_string => JsonValue::Null,
+                        _ => JsonValue::String(part.to_string()),
+                    })
+                    .collect();
+                
+                Ok(serde_json::to_string(&elements).ok())
+            },
+        )?;
+    }
     
     Ok(())
 }
 
-/// array_to_string(array, delimiter) - Join array elements into string
+/// array_to_string(array, delimiter [, null_string]) - Join array elements into string
 fn register_array_to_string(conn: &Connection) -> Result<()> {
-    conn.create_scalar_function(
-        "array_to_string",
-        2,
-        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let array_json: String = ctx.get(0)?;
-            let delimiter: String = ctx.get(1)?;
-            
-            match serde_json::from_str::<JsonValue>(&array_json) {
-                Ok(JsonValue::Array(arr)) => {
-                    let elements: Vec<String> = arr.iter()
-                        .filter_map(|v| match v {
-                            JsonValue::String(s) => Some(s.clone()),
-                            JsonValue::Number(n) => Some(n.to_string()),
-                            JsonValue::Bool(b) => Some(b.to_string()),
-                            JsonValue::Null => None, // Skip NULL values like PostgreSQL
-                            _ => Some(serde_json::to_string(v).unwrap_or_default()),
-                        })
-                        .collect();
-                    Ok(Some(elements.join(&delimiter)))
-                }
-                _ => Ok(None),
-            }
-        },
-    )?;
+    for n_args in [2, 3] {
+        conn.create_scalar_function(
+            "array_to_string",
+            n_args,
+            FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+            |ctx| {
+                let delimiter: Option<String> = ctx.get(1)?;
+                let null_string: Option<String> = if ctx.len() > 2 { ctx.get(2)? } else { None };
+                
+                let (Some(arr), Some(delimiter)) = (array_argument(ctx.get_raw(0)), delimiter) else {
+                    return Ok(None);
+                };
+                
+                // Multi-dimensional arrays are joined in storage order, like PostgreSQL
+                let mut elements = Vec::new();
+                join_elements(&arr, null_string.as_deref(), &mut elements);
+                Ok(Some(elements.join(&delimiter)))
+            },
+        )?;
+    }
     
     Ok(())
 }
 
+/// Text forms of the leaf elements of an array. NULLs are skipped unless a
+/// null_string is given to render them with.
+fn join_elements(arr: &[JsonValue], null_string: Option<&str>, out: &mut Vec<String>) {
+    for v in arr {
+        match v {
+            JsonValue::String(s) => out.push(s.clone()),
+            JsonValue::Number(n) => out.push(n.to_string()),
+            JsonValue::Bool(b) => out.push(b.to_string()),
+            JsonValue::Null => out.extend(null_string.map(str::to_string)),
+            JsonValue::Array(inner) => join_elements(inner, null_string, out),
+            _ => out.push(serde_json::to_string(v).unwrap_or_default()),
+        }
+    }
+}
+
+/// Count the leaf elements of a possibly nested array
+fn count_elements(elements: &[JsonValue]) -> i32 {
+    elements.iter()
+        .map(|element| match element {
+            JsonValue::Array(inner) => count_elements(inner),
+            _ => 1,
+        })
+        .sum()
+}
+
 /// Helper function to count array dimensions
 fn count_dimensions(value: &JsonValue) -> i32 {
     match value {
@@ -847,4 +753,76 @@ mod tests {
         ).unwrap();
         assert!(overlap);
     }
-}
\ No newline at end of file
+    
+    #[test]
+    fn test_array_cat_elements_and_literals() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_array_functions(&conn).unwrap();
+        
+        let cat = |sql: &str| -> Option<String> { conn.query_row(sql, [], |row| row.get(0)).unwrap() };
+        
+        assert_eq!(cat("SELECT array_cat('[1,2]', '{3,4}')").as_deref(), Some("[1,2,3,4]"));
+        assert_eq!(cat("SELECT array_cat('[\"a\"]', 'b')").as_deref(), Some("[\"a\",\"b\"]"));
+        assert_eq!(cat("SELECT array_cat(0, '[1,2]')").as_deref(), Some("[0,1,2]"));
+        assert_eq!(cat("SELECT array_append('{x,\"y z\"}', 'w')").as_deref(), Some("[\"x\",\"y z\",\"w\"]"));
+        assert_eq!(cat("SELECT array_prepend(1.5, '{2}')").as_deref(), Some("[1.5,2]"));
+        assert_eq!(cat("SELECT array_cat('a', 'b')"), None);
+    }
+    
+    #[test]
+    fn test_array_search_functions() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_array_functions(&conn).unwrap();
+        
+        let text = |sql: &str| -> Option<String> { conn.query_row(sql, [], |row| row.get(0)).unwrap() };
+        let position = |sql: &str| -> Option<i32> { conn.query_row(sql, [], |row| row.get(0)).unwrap() };
+        
+        assert_eq!(text("SELECT array_remove('{old,\"new tag\",old}', 'old')").as_deref(), Some("[\"new tag\"]"));
+        assert_eq!(text("SELECT array_remove('[\"5\",6]', '5')").as_deref(), Some("[6]"));
+        assert_eq!(text("SELECT array_remove('[1,null,2]', NULL)").as_deref(), Some("[1,2]"));
+        assert_eq!(text("SELECT array_replace('{a,b,a}', 'a', 'x, y')").as_deref(), Some("[\"x, y\",\"b\",\"x, y\"]"));
+        assert_eq!(text("SELECT array_remove(NULL, 'a')"), None);
+        
+        assert_eq!(position("SELECT array_position('{a,b,c,b}', 'b')"), Some(2));
+        assert_eq!(position("SELECT array_position('{a,b,c,b}', 'b', 3)"), Some(4));
+        assert_eq!(position("SELECT array_position('{a,b,c}', 'z')"), None);
+        assert_eq!(text("SELECT array_positions('{a,b,c,b}', 'b')").as_deref(), Some("[2,4]"));
+        assert_eq!(text("SELECT array_positions('{a,b}', 'z')").as_deref(), Some("[]"));
+    }
+    
+    #[test]
+    fn test_cardinality_and_ndims() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_array_functions(&conn).unwrap();
+        
+        let int = |sql: &str| -> Option<i32> { conn.query_row(sql, [], |row| row.get(0)).unwrap() };
+        
+        assert_eq!(int("SELECT cardinality('{}')"), Some(0));
+        assert_eq!(int("SELECT cardinality('[1,2,3]')"), Some(3));
+        assert_eq!(int("SELECT cardinality('{{1,2,3},{4,5,6}}')"), Some(6));
+        assert_eq!(int("SELECT cardinality(NULL)"), None);
+        
+        assert_eq!(int("SELECT array_ndims('{a,b}')"), Some(1));
+        assert_eq!(int("SELECT array_ndims('{{1,2},{3,4}}')"), Some(2));
+        assert_eq!(int("SELECT array_ndims('[]')"), None);
+    }
+    
+    #[test]
+    fn test_string_array_conversion() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_array_functions(&conn).unwrap();
+        
+        let text = |sql: &str| -> Option<String> { conn.query_row(sql, [], |row| row.get(0)).unwrap() };
+        
+        assert_eq!(text("SELECT string_to_array('a,b,c', ',')").as_deref(), Some(r#"["a","b","c"]"#));
+        assert_eq!(text("SELECT string_to_array('a,*,c', ',', '*')").as_deref(), Some(r#"["a",null,"c"]"#));
+        assert_eq!(text("SELECT string_to_array('abc', NULL)").as_deref(), Some(r#"["a","b","c"]"#));
+        assert_eq!(text("SELECT string_to_array('abc', '')").as_deref(), Some(r#"["abc"]"#));
+        assert_eq!(text("SELECT string_to_array(NULL, ',')"), None);
+        
+        assert_eq!(text("SELECT array_to_string('[\"a\",null,\"c\"]', ', ')").as_deref(), Some("a, c"));
+        assert_eq!(text("SELECT array_to_string('{a,NULL,c}', ',', '*')").as_deref(), Some("a,*,c"));
+        assert_eq!(text("SELECT array_to_string('{{1,2},{3,4}}', '-')").as_deref(), Some("1-2-3-4"));
+        assert_eq!(text("SELECT array_to_string(string_to_array('x|y|z', '|'), '|')").as_deref(), Some("x|y|z"));
+    }
+}
@@ -0,0 +1,330 @@
+use rusqlite::{Connection, Result, functions::FunctionFlags};
+use rusqlite::types::ValueRef;
+use tracing::debug;
+
+/// Register functions for the bit and bit varying types, which are stored as their text of
+/// 0s and 1s. The bitwise operators on bit strings are translated to bitand(), bitor(),
+/// bitxor(), bitnot(), bitshiftleft() and bitshiftright(), the names PostgreSQL gives them.
+pub fn register_bit_functions(conn: &Connection) -> Result<()> {
+    debug!("Registering bit string functions");
+
+    // varbit(text) - validate a bit string literal (B'0101' is translated to varbit('0101'))
+    conn.create_scalar_function(
+        "varbit",
+        1,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let Some(bits) = ctx.get::<Option<String>>(0)? else {
+                return Ok(None);
+            };
+            validate_bits(&bits)?;
+            Ok(Some(bits))
+        },
+    )?;
+
+    // varbit(text, n) - the ::bit varying(n) cast, which truncates to n bits
+    conn.create_scalar_function(
+        "varbit",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let Some(bits) = ctx.get::<Option<String>>(0)? else {
+                return Ok(None);
+            };
+            validate_bits(&bits)?;
+            let length = ctx.get::<i64>(1)?.max(0) as usize;
+            Ok(Some(bits.chars().take(length).collect::<String>()))
+        },
+    )?;
+
+    // bit(value, n) - the ::bit(n) cast: an integer becomes its low n bits, a bit string is
+    // padded with zeros or truncated to n bits
+    conn.create_scalar_function(
+        "bit",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let length = ctx.get::<i64>(1)?.max(0) as usize;
+            match ctx.get_raw(0) {
+                ValueRef::Null => Ok(None),
+                ValueRef::Integer(value) => Ok(Some(bits_from_integer(value, length))),
+                _ => {
+                    let bits = ctx.get::<String>(0)?;
+                    validate_bits(&bits)?;
+                    Ok(Some(format!("{:0<length$}", bits.chars().take(length).collect::<String>())))
+                }
+            }
+        },
+    )?;
+
+    for (name, operator) in [("bitand", "AND"), ("bitor", "OR"), ("bitxor", "XOR")] {
+        conn.create_scalar_function(
+            name,
+            2,
+            FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| {
+                let (Some(a), Some(b)) = (ctx.get::<Option<String>>(0)?, ctx.get::<Option<String>>(1)?) else {
+                    return Ok(None);
+                };
+                Ok(Some(bitwise(&a, &b, operator)?))
+            },
+        )?;
+    }
+
+    // bitnot(bits) - the ~ operator, flipping every bit
+    conn.create_scalar_function(
+        "bitnot",
+        1,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let Some(bits) = ctx.get::<Option<String>>(0)? else {
+                return Ok(None);
+            };
+            validate_bits(&bits)?;
+            Ok(Some(bits.chars().map(|c| if c == '0' { '1' } else { '0' }).collect::<String>()))
+        },
+    )?;
+
+    // bitshiftleft(bits, n) / bitshiftright(bits, n) - the << and >> operators, which keep
+    // the length and shift in zeros
+    for (name, direction) in [("bitshiftleft", 1), ("bitshiftright", -1)] {
+        conn.create_scalar_function(
+            name,
+            2,
+            FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| {
+                let Some(bits) = ctx.get::<Option<String>>(0)? else {
+                    return Ok(None);
+                };
+                let Some(count) = ctx.get::<Option<i64>>(1)? else {
+                    return Ok(None);
+                };
+                validate_bits(&bits)?;
+                Ok(Some(shift_left(&bits, count.saturating_mul(direction))))
+            },
+        )?;
+    }
+
+    // get_bit(bits, n) - the bit at position n, counting from 0 at the left
+    conn.create_scalar_function(
+        "get_bit",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            if matches!(ctx.get_raw(0), ValueRef::Null) || matches!(ctx.get_raw(1), ValueRef::Null) {
+                return Ok(None);
+            }
+            let index = ctx.get::<i64>(1)?;
+            if let ValueRef::Blob(bytes) = ctx.get_raw(0) {
+                // bytea numbers the bits of each byte from the least significant
+                let position = byte_bit_index(index, bytes.len())?;
+                return Ok(Some(((bytes[position / 8] >> (position % 8)) & 1) as i64));
+            }
+            let bits = ctx.get::<String>(0)?;
+            validate_bits(&bits)?;
+            let position = bit_index(index, bits.len())?;
+            Ok(Some((bits.as_bytes()[position] - b'0') as i64))
+        },
+    )?;
+
+    // set_bit(bits, n, value) - a copy with the bit at position n set to 0 or 1
+    conn.create_scalar_function(
+        "set_bit",
+        3,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            if (0..3).any(|i| matches!(ctx.get_raw(i), ValueRef::Null)) {
+                return Ok(None);
+            }
+            let index = ctx.get::<i64>(1)?;
+            let new_bit = ctx.get::<i64>(2)?;
+            if new_bit != 0 && new_bit != 1 {
+                return Err(rusqlite::Error::UserFunctionError("new bit must be 0 or 1".into()));
+            }
+            if let ValueRef::Blob(bytes) = ctx.get_raw(0) {
+                let position = byte_bit_index(index, bytes.len())?;
+                let mut bytes = bytes.to_vec();
+                bytes[position / 8] = (bytes[position / 8] & !(1 << (position % 8))) | ((new_bit as u8) << (position % 8));
+                return Ok(Some(rusqlite::types::Value::Blob(bytes)));
+            }
+            let bits = ctx.get::<String>(0)?;
+            validate_bits(&bits)?;
+            let position = bit_index(index, bits.len())?;
+            let mut bits = bits.into_bytes();
+            bits[position] = b'0' + new_bit as u8;
+            Ok(Some(rusqlite::types::Value::Text(String::from_utf8(bits).unwrap_or_default())))
+        },
+    )?;
+
+    // bit_typmod_check(bits, n, varying) - used by the column triggers: a bit(n) value must
+    // have exactly n bits and a bit varying(n) value at most n
+    conn.create_scalar_function(
+        "bit_typmod_check",
+        3,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let Some(bits) = ctx.get::<Option<String>>(0)? else {
+                return Ok(None);
+            };
+            validate_bits(&bits)?;
+            let length = ctx.get::<i64>(1)? as usize;
+            let varying = ctx.get::<bool>(2)?;
+            if !varying && bits.len() != length {
+                return Err(rusqlite::Error::UserFunctionError(
+                    format!("bit string length {} does not match type bit({length})", bits.len()).into(),
+                ));
+            }
+            if varying && bits.len() > length {
+                return Err(rusqlite::Error::UserFunctionError(
+                    format!("bit string too long for type bit varying({length})").into(),
+                ));
+            }
+            Ok(Some(bits))
+        },
+    )?;
+
+    Ok(())
+}
+
+/// Reject characters other than 0 and 1, as PostgreSQL's bit input does
+fn validate_bits(bits: &str) -> Result<()> {
+    match bits.chars().find(|c| *c != '0' && *c != '1') {
+        Some(c) => Err(rusqlite::Error::UserFunctionError(format!("\"{c}\" is not a valid binary digit").into())),
+        None => Ok(()),
+    }
+}
+
+/// The low `length` bits of an integer, sign-extended past its 64 bits
+fn bits_from_integer(value: i64, length: usize) -> String {
+    (0..length)
+        .rev()
+        .map(|bit| {
+            let set = if bit < 64 { (value >> bit) & 1 == 1 } else { value < 0 };
+            if set { '1' } else { '0' }
+        })
+        .collect()
+}
+
+/// Apply AND, OR or XOR to two bit strings of the same length
+fn bitwise(a: &str, b: &str, operator: &str) -> Result<String> {
+    validate_bits(a)?;
+    validate_bits(b)?;
+    if a.len() != b.len() {
+        return Err(rusqlite::Error::UserFunctionError(
+            format!("cannot {operator} bit strings of different sizes").into(),
+        ));
+    }
+    Ok(a.bytes()
+        .zip(b.bytes())
+        .map(|(x, y)| {
+            let (x, y) = (x == b'1', y == b'1');
+            let set = match operator {
+                "AND" => x && y,
+                "OR" => x || y,
+                _ => x != y,
+            };
+            if set { '1' } else { '0' }
+        })
+        .collect())
+}
+
+/// Shift left by `count` bits (right when negative), keeping the length
+fn shift_left(bits: &str, count: i64) -> String {
+    let length = bits.len();
+    let shift = count.unsigned_abs().min(length as u64) as usize;
+    if count >= 0 {
+        format!("{}{}", &bits[shift..], "0".repeat(shift))
+    } else {
+        format!("{}{}", "0".repeat(shift), &bits[..length - shift])
+    }
+}
+
+/// Check a bit position against the length of a bit string
+fn bit_index(index: i64, length: usize) -> Result<usize> {
+    if index < 0 || index as usize >= length {
+        return Err(rusqlite::Error::UserFunctionError(
+            format!("bit index {index} out of valid range (0..{})", length as i64 - 1).into(),
+        ));
+    }
+    Ok(index as usize)
+}
+
+/// Check a bit position against the length of a bytea value
+fn byte_bit_index(index: i64, bytes: usize) -> Result<usize> {
+    if index < 0 || index as usize >= bytes * 8 {
+        return Err(rusqlite::Error::UserFunctionError(
+            format!("index {index} out of valid range, 0..{}", (bytes * 8) as i64 - 1).into(),
+        ));
+    }
+    Ok(index as usize)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn query(conn: &Connection, sql: &str) -> Option<String> {
+        conn.query_row(sql, [], |row| row.get(0)).unwrap()
+    }
+
+    #[test]
+    fn test_bitwise_functions() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_bit_functions(&conn).unwrap();
+
+        assert_eq!(query(&conn, "SELECT bitand('1100', '1010')").as_deref(), Some("1000"));
+        assert_eq!(query(&conn, "SELECT bitor('1100', '1010')").as_deref(), Some("1110"));
+        assert_eq!(query(&conn, "SELECT bitxor('1100', '1010')").as_deref(), Some("0110"));
+        assert_eq!(query(&conn, "SELECT bitnot('1100')").as_deref(), Some("0011"));
+        assert_eq!(query(&conn, "SELECT bitshiftleft('10011', 2)").as_deref(), Some("01100"));
+        assert_eq!(query(&conn, "SELECT bitshiftright('10011', 2)").as_deref(), Some("00100"));
+        assert_eq!(query(&conn, "SELECT bitand(NULL, '1')"), None);
+
+        let err = conn.query_row("SELECT bitand('1', '10')", [], |row| row.get::<_, String>(0)).unwrap_err();
+        assert!(err.to_string().contains("cannot AND bit strings of different sizes"));
+        let err = conn.query_row("SELECT varbit('102')", [], |row| row.get::<_, String>(0)).unwrap_err();
+        assert!(err.to_string().contains("\"2\" is not a valid binary digit"));
+    }
+
+    #[test]
+    fn test_casts() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_bit_functions(&conn).unwrap();
+
+        assert_eq!(query(&conn, "SELECT bit(5, 4)").as_deref(), Some("0101"));
+        assert_eq!(query(&conn, "SELECT bit(-1, 4)").as_deref(), Some("1111"));
+        assert_eq!(query(&conn, "SELECT bit('101', 5)").as_deref(), Some("10100"));
+        assert_eq!(query(&conn, "SELECT bit('10110', 3)").as_deref(), Some("101"));
+        assert_eq!(query(&conn, "SELECT varbit('10110', 2)").as_deref(), Some("10"));
+    }
+
+    #[test]
+    fn test_get_and_set_bit() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_bit_functions(&conn).unwrap();
+
+        let bit: i64 = conn.query_row("SELECT get_bit('0010', 2)", [], |row| row.get(0)).unwrap();
+        assert_eq!(bit, 1);
+        assert_eq!(query(&conn, "SELECT set_bit('0010', 0, 1)").as_deref(), Some("1010"));
+        let bit: i64 = conn.query_row("SELECT get_bit(X'0102', 9)", [], |row| row.get(0)).unwrap();
+        assert_eq!(bit, 1);
+
+        let err = conn.query_row("SELECT get_bit('0010', 4)", [], |row| row.get::<_, i64>(0)).unwrap_err();
+        assert!(err.to_string().contains("bit index 4 out of valid range (0..3)"));
+        let err = conn.query_row("SELECT set_bit('0010', 1, 2)", [], |row| row.get::<_, String>(0)).unwrap_err();
+        assert!(err.to_string().contains("new bit must be 0 or 1"));
+    }
+
+    #[test]
+    fn test_typmod_check() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_bit_functions(&conn).unwrap();
+
+        assert_eq!(query(&conn, "SELECT bit_typmod_check('0101', 4, 0)").as_deref(), Some("0101"));
+        assert_eq!(query(&conn, "SELECT bit_typmod_check('01', 4, 1)").as_deref(), Some("01"));
+        let err = conn.query_row("SELECT bit_typmod_check('010', 4, 0)", [], |row| row.get::<_, String>(0)).unwrap_err();
+        assert!(err.to_string().contains("bit string length 3 does not match type bit(4)"));
+        let err = conn.query_row("SELECT bit_typmod_check('01010', 4, 1)", [], |row| row.get::<_, String>(0)).unwrap_err();
+        assert!(err.to_string().contains("bit string too long for type bit varying(4)"));
+    }
+}
@@ -1,5 +1,6 @@
 use rusqlite::{Connection, Result, functions::FunctionFlags};
 use tracing::debug;
+use crate::translator::sql_scan::{matching_paren, split_top_level};
 
 /// Register PostgreSQL catalog-related functions
 pub fn register_catalog_functions(conn: &Connection) -> Result<()> {
@@ -35,29 +36,481 @@ pub fn register_catalog_functions(conn: &Connection) -> Result<()> {
     conn.create_scalar_function(
         "regclass",
         1,
+        FunctionFlags::SQLITE_UTF8,
+        |ctx| {
+            // A regclass is already an OID; casting an OID or a numeric string keeps it
+            let table_name = match ctx.get_raw(0) {
+                rusqlite::types::ValueRef::Null => return Ok(None),
+                rusqlite::types::ValueRef::Integer(oid) => return Ok(Some(oid)),
+                _ => ctx.get::<String>(0)?,
+            };
+            if let Ok(oid) = table_name.trim().parse::<i64>() {
+                return Ok(Some(oid));
+            }
+            
+            // SAFETY: the connection is only used for a read-only lookup while the
+            // calling statement runs, and is never closed from here
+            let conn = unsafe { ctx.get_connection()? };
+            let name = table_name.rsplit('.').next().unwrap_or(&table_name).trim();
+            let Some(relation) = find_relation(&conn, name)? else {
+                return Err(rusqlite::Error::UserFunctionError(
+                    format!("relation \"{}\" does not exist", table_name.trim()).into()
+                ));
+            };
+            // Same OID the pg_class catalog reports for the relation
+            Ok(Some(crate::catalog::constraint_populator::table_oid(&relation) as i64))
+        },
+    )?;
+    
+    // regclass_name(oid) - output form of a regclass: the table name, or the OID itself
+    // when no table has it, as PostgreSQL prints dangling regclass values
+    conn.create_scalar_function(
+        "regclass_name",
+        1,
+        FunctionFlags::SQLITE_UTF8,
+        |ctx| {
+            let oid = match ctx.get_raw(0) {
+                rusqlite::types::ValueRef::Null => return Ok(None),
+                rusqlite::types::ValueRef::Integer(oid) => oid,
+                _ => {
+                    let text = ctx.get::<String>(0)?;
+                    match text.trim().parse::<i64>() {
+                        Ok(oid) => oid,
+                        Err(_) => return Ok(Some(text)),
+                    }
+                }
+            };
+            // SAFETY: the connection is only used for a read-only lookup while the
+            // calling statement runs, and is never closed from here
+            let conn = unsafe { ctx.get_connection()? };
+            Ok(Some(relation_name(&conn, oid).unwrap_or_else(|| oid.to_string())))
+        },
+    )?;
+
+    // pg_partition_ancestors(regclass) - SQLite has no partitioned tables, so a relation is
+    // its own only ancestor. psql's \d looks up the foreign keys referencing a table with it.
+    conn.create_scalar_function(
+        "pg_partition_ancestors",
+        1,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| ctx.get::<rusqlite::types::Value>(0),
+    )?;
+
+    // regtype type cast function - type name to type OID
+    conn.create_scalar_function(
+        "regtype",
+        1,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let table_name: String = ctx.get(0)?;
+            let type_name = match ctx.get_raw(0) {
+                rusqlite::types::ValueRef::Null => return Ok(None),
+                rusqlite::types::ValueRef::Integer(oid) => return Ok(Some(oid)),
+                _ => ctx.get::<String>(0)?,
+            };
+            if let Ok(oid) = type_name.trim().parse::<i64>() {
+                return Ok(Some(oid));
+            }
             
-            // Look up table OID from pg_class view
-            // For now, just generate a consistent OID
-            let oid = generate_table_oid(&table_name);
-            Ok(oid)
+            let unqualified = type_name.trim().strip_prefix("pg_catalog.").unwrap_or(type_name.trim());
+            match crate::catalog::system_functions::SystemFunctions::type_oid_from_name(unqualified.trim_matches('"')) {
+                Some(oid) => Ok(Some(oid as i64)),
+                None => Err(rusqlite::Error::UserFunctionError(
+                    format!("type \"{type_name}\" does not exist").into()
+                )),
+            }
         },
     )?;
     
+    // regtype_name(oid) - output form of a regtype: the SQL name of the type
+    conn.create_scalar_function(
+        "regtype_name",
+        1,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let Some(oid) = ctx.get::<Option<i64>>(0)? else {
+                return Ok(None);
+            };
+            Ok(Some(crate::catalog::system_functions::SystemFunctions::format_type_name(oid as i32, None)))
+        },
+    )?;
+    
+    // format_type(type_oid, typmod) - SQL name of a column type, modifier included, for
+    // catalog queries passing atttypid and atttypmod. User-defined types such as enums
+    // print as their pg_type name.
+    conn.create_scalar_function(
+        "format_type",
+        2,
+        FunctionFlags::SQLITE_UTF8,
+        |ctx| {
+            let Some(oid) = ctx.get::<Option<i64>>(0)? else {
+                return Ok(None);
+            };
+            let typemod = ctx.get::<Option<i64>>(1)?;
+            let formatted = crate::catalog::system_functions::SystemFunctions::format_type_name(oid as i32, typemod.map(|m| m as i32));
+            if !formatted.starts_with("unknown(") {
+                return Ok(Some(formatted));
+            }
+            // SAFETY: the connection is only used for a read-only lookup while the
+            // calling statement runs, and is never closed from here
+            let conn = unsafe { ctx.get_connection()? };
+            let type_name: Option<String> = conn.query_row(
+                "SELECT typname FROM pg_type WHERE oid = ?1",
+                [oid],
+                |row| row.get(0),
+            ).ok();
+            Ok(Some(type_name.unwrap_or_else(|| "???".to_string())))
+        },
+    )?;
+    
+    // pg_get_expr(adbin, relid [, pretty]) - pg_attrdef stores the SQLite default text, which
+    // is shown the way PostgreSQL prints it, typed by the column it belongs to
+    conn.create_scalar_function(
+        "pg_get_expr",
+        -1,
+        FunctionFlags::SQLITE_UTF8,
+        |ctx| {
+            if ctx.is_empty() {
+                return Ok(None);
+            }
+            let Some(expr) = ctx.get::<Option<String>>(0)? else {
+                return Ok(None);
+            };
+            let relid = if ctx.len() > 1 {
+                match ctx.get_raw(1) {
+                    rusqlite::types::ValueRef::Integer(oid) => Some(oid),
+                    rusqlite::types::ValueRef::Text(oid) => std::str::from_utf8(oid).ok().and_then(|oid| oid.trim().parse().ok()),
+                    _ => None,
+                }
+            } else {
+                None
+            };
+            // SAFETY: the connection is only used for read-only lookups while the
+            // calling statement runs, and is never closed from here
+            let conn = unsafe { ctx.get_connection()? };
+            let column_type = relid.and_then(|oid| default_column_type(&conn, oid, &expr));
+            Ok(Some(default_expression(&expr, column_type.as_deref())))
+        },
+    )?;
+
+    // pg_get_constraintdef(constraint_oid [, pretty]) - constraint clause for a pg_constraint row
+    conn.create_scalar_function(
+        "pg_get_constraintdef",
+        -1,
+        FunctionFlags::SQLITE_UTF8,
+        |ctx| {
+            if ctx.is_empty() {
+                return Ok(None);
+            }
+            let constraint_oid = match ctx.get_raw(0) {
+                rusqlite::types::ValueRef::Null => return Ok(None),
+                rusqlite::types::ValueRef::Integer(oid) => oid.to_string(),
+                _ => ctx.get::<String>(0)?,
+            };
+            // SAFETY: the connection is only used for read-only lookups while the
+            // calling statement runs, and is never closed from here
+            let conn = unsafe { ctx.get_connection()? };
+            Ok(constraint_definition(&conn, constraint_oid.trim()))
+        },
+    )?;
+
+    // pg_get_indexdef(index_oid [, column, pretty]) - CREATE INDEX statement for a pg_index row
+    conn.create_scalar_function(
+        "pg_get_indexdef",
+        -1,
+        FunctionFlags::SQLITE_UTF8,
+        |ctx| {
+            if ctx.is_empty() {
+                return Ok(None);
+            }
+            let index_oid = match ctx.get::<i64>(0) {
+                Ok(oid) => oid.to_string(),
+                Err(_) => ctx.get::<String>(0)?,
+            };
+            let column = if ctx.len() > 1 { ctx.get::<i64>(1).unwrap_or(0) } else { 0 };
+            // SAFETY: the connection is only used for read-only lookups while the
+            // calling statement runs, and is never closed from here
+            let conn = unsafe { ctx.get_connection()? };
+            Ok(index_definition(&conn, &index_oid, column))
+        },
+    )?;
+
     debug!("Catalog functions registered successfully");
     Ok(())
 }
 
-// Generate a stable OID from table name
-fn generate_table_oid(name: &str) -> i32 {
-    use std::collections::hash_map::DefaultHasher;
-    use std::hash::{Hash, Hasher};
-    
-    let mut hasher = DefaultHasher::new();
-    name.hash(&mut hasher);
-    ((hasher.finish() & 0x7FFFFFFF) % 1000000 + 16384) as i32
+/// The stored name of the table, view or index a regclass names. Unquoted names are
+/// matched regardless of case, as PostgreSQL folds them; quoted ones must match exactly.
+fn find_relation(conn: &Connection, name: &str) -> rusqlite::Result<Option<String>> {
+    let (name, collation) = match name.strip_prefix('"').and_then(|n| n.strip_suffix('"')) {
+        Some(quoted) => (quoted, "BINARY"),
+        None => (name, "NOCASE"),
+    };
+    let result = conn.query_row(
+        &format!(
+            "SELECT name FROM sqlite_master WHERE type IN ('table', 'view', 'index') \
+             AND name = ?1 COLLATE {collation} \
+             AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '__pgsqlite_%'"
+        ),
+        [name],
+        |row| row.get(0),
+    );
+    match result {
+        Ok(found) => Ok(Some(found)),
+        Err(rusqlite::Error::QueryReturnedNoRows) => Ok(None),
+        Err(e) => Err(e),
+    }
+}
+
+/// Find the table or view whose pg_class OID is `oid`
+fn relation_name(conn: &Connection, oid: i64) -> Option<String> {
+    let mut stmt = conn.prepare(
+        "SELECT name FROM sqlite_master WHERE type IN ('table', 'view') \
+         AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '__pgsqlite_%'"
+    ).ok()?;
+    stmt.query_map([], |row| row.get::<_, String>(0))
+        .ok()?
+        .flatten()
+        .find(|name| crate::catalog::constraint_populator::table_oid(name) as i64 == oid)
+}
+
+/// Build PostgreSQL's constraint clause for the pg_constraint row whose OID is `constraint_oid`
+fn constraint_definition(conn: &Connection, constraint_oid: &str) -> Option<String> {
+    let (contype, conrelid, conkey, consrc): (String, String, Option<String>, Option<String>) = conn.query_row(
+        "SELECT contype, conrelid, conkey, consrc FROM pg_constraint WHERE oid = ?1",
+        [constraint_oid],
+        |row| Ok((row.get(0)?, row.get(1)?, row.get(2)?, row.get(3)?)),
+    ).ok()?;
+    let table_name = relation_name(conn, conrelid.parse().ok()?)?;
+    let columns = column_names(conn, &table_name, conkey.as_deref().unwrap_or(""));
+
+    match contype.as_str() {
+        "p" => Some(format!("PRIMARY KEY ({})", columns.join(", "))),
+        "u" => Some(format!("UNIQUE ({})", columns.join(", "))),
+        "c" => {
+            let consrc = consrc?;
+            let expr = consrc.trim()
+                .strip_prefix("CHECK (")
+                .and_then(|expr| expr.strip_suffix(')'))
+                .unwrap_or(&consrc);
+            Some(format!("CHECK (({expr}))"))
+        }
+        "f" => foreign_key_definition(conn, &table_name, &columns),
+        _ => None,
+    }
+}
+
+/// FOREIGN KEY clause for the key of `table_name` on `columns`, read from the enforced
+/// SQLite definition so the referenced columns and actions are exact
+fn foreign_key_definition(conn: &Connection, table_name: &str, columns: &[String]) -> Option<String> {
+    let mut stmt = conn.prepare(
+        "SELECT id, \"table\", \"from\", \"to\", on_update, on_delete FROM pragma_foreign_key_list(?1) ORDER BY id, seq"
+    ).ok()?;
+    let rows: Vec<(i64, String, String, Option<String>, String, String)> = stmt
+        .query_map([table_name], |row| Ok((row.get(0)?, row.get(1)?, row.get(2)?, row.get(3)?, row.get(4)?, row.get(5)?)))
+        .ok()?
+        .flatten()
+        .collect();
+
+    let id = rows.iter()
+        .find(|(_, _, from, _, _, _)| columns.first().is_some_and(|c| c.eq_ignore_ascii_case(from)))?
+        .0;
+    let key: Vec<_> = rows.iter().filter(|row| row.0 == id).collect();
+    let (_, ref_table, _, _, on_update, on_delete) = key[0];
+
+    let from: Vec<&str> = key.iter().map(|row| row.2.as_str()).collect();
+    // A REFERENCES clause without columns points at the referenced table's primary key
+    let to: Vec<String> = if key.iter().all(|row| row.3.is_some()) {
+        key.iter().filter_map(|row| row.3.clone()).collect()
+    } else {
+        let mut stmt = conn.prepare("SELECT name FROM pragma_table_info(?1) WHERE pk > 0 ORDER BY pk").ok()?;
+        stmt.query_map([ref_table], |row| row.get(0)).ok()?.flatten().collect()
+    };
+
+    let mut definition = format!("FOREIGN KEY ({}) REFERENCES {}({})", from.join(", "), ref_table, to.join(", "));
+    for (clause, action) in [("ON UPDATE", on_update), ("ON DELETE", on_delete)] {
+        if !action.eq_ignore_ascii_case("NO ACTION") {
+            definition.push_str(&format!(" {clause} {}", action.to_uppercase()));
+        }
+    }
+    Some(definition)
+}
+
+/// Names of the columns numbered (1-based, comma-separated) in a conkey list
+fn column_names(conn: &Connection, table_name: &str, conkey: &str) -> Vec<String> {
+    let columns: Vec<String> = conn.prepare("SELECT name FROM pragma_table_info(?1) ORDER BY cid")
+        .and_then(|mut stmt| {
+            let columns = stmt.query_map([table_name], |row| row.get(0))?.collect();
+            columns
+        })
+        .unwrap_or_default();
+
+    conkey.trim_matches(|c| c == '{' || c == '}')
+        .split(',')
+        .filter_map(|n| n.trim().parse::<usize>().ok())
+        .filter_map(|n| n.checked_sub(1).and_then(|i| columns.get(i)).cloned())
+        .collect()
+}
+
+/// PostgreSQL type of the column of relation `relid` whose default is `expr`
+fn default_column_type(conn: &Connection, relid: i64, expr: &str) -> Option<String> {
+    let table_name = relation_name(conn, relid)?;
+    let column_name: String = conn.query_row(
+        "SELECT name FROM pragma_table_info(?1) WHERE dflt_value = ?2",
+        rusqlite::params![table_name, expr],
+        |row| row.get(0),
+    ).ok()?;
+    conn.query_row(
+        "SELECT pg_type FROM __pgsqlite_schema WHERE table_name = ?1 AND column_name = ?2",
+        [&table_name, &column_name],
+        |row| row.get(0),
+    ).ok()
+}
+
+/// Show a SQLite column default in PostgreSQL syntax: string literals carry a cast to the
+/// column type and booleans print as true/false
+fn default_expression(expr: &str, column_type: Option<&str>) -> String {
+    use crate::catalog::system_functions::SystemFunctions;
+
+    let expr = expr.trim();
+    if expr.eq_ignore_ascii_case("datetime('now')") {
+        return "now()".to_string();
+    }
+
+    let Some(column_type) = column_type else {
+        return expr.to_string();
+    };
+    let base_type = column_type.split('(').next().unwrap_or(column_type).trim();
+    let Some(type_oid) = SystemFunctions::type_oid_from_name(base_type) else {
+        return expr.to_string();
+    };
+
+    if type_oid == crate::types::PgType::Bool.to_oid() {
+        match expr.trim_matches('\'').to_lowercase().as_str() {
+            "1" | "t" | "true" => return "true".to_string(),
+            "0" | "f" | "false" => return "false".to_string(),
+            _ => {}
+        }
+    }
+
+    if expr.len() >= 2 && expr.starts_with('\'') && expr.ends_with('\'') {
+        return format!("{}::{}", expr, SystemFunctions::format_type_name(type_oid, None));
+    }
+    expr.to_string()
+}
+
+/// Build PostgreSQL's CREATE INDEX text for the index whose pg_index OID is `index_oid`, or
+/// just the definition of key `column` when it isn't 0
+fn index_definition(conn: &Connection, index_oid: &str, column: i64) -> Option<String> {
+    use crate::catalog::constraint_populator::generate_table_oid;
+
+    let mut stmt = conn.prepare(
+        "SELECT name, tbl_name, sql FROM sqlite_master WHERE type = 'index' AND name NOT LIKE 'sqlite_%'"
+    ).ok()?;
+    let (index_name, table_name, sql) = stmt
+        .query_map([], |row| Ok((row.get::<_, String>(0)?, row.get::<_, String>(1)?, row.get::<_, Option<String>>(2)?)))
+        .ok()?
+        .flatten()
+        .find(|(name, _, _)| generate_table_oid(name) == index_oid)?;
+
+    let unique: bool = conn.query_row(
+        "SELECT \"unique\" FROM pragma_index_list(?1) WHERE name = ?2",
+        [&table_name, &index_name],
+        |row| row.get(0),
+    ).ok()?;
+
+    // Key columns in index order; expressions have no name and are only found in the SQL
+    let mut stmt = conn.prepare(
+        "SELECT cid, name, \"desc\", coll FROM pragma_index_xinfo(?1) WHERE key = 1 ORDER BY seqno"
+    ).ok()?;
+    let keys: Vec<(i64, Option<String>, bool, Option<String>)> = stmt
+        .query_map([&index_name], |row| Ok((row.get(0)?, row.get(1)?, row.get(2)?, row.get(3)?)))
+        .ok()?
+        .flatten()
+        .collect();
+    let (key_sql, predicate) = sql.as_deref().map(index_sql_parts).unwrap_or_default();
+
+    let items: Vec<String> = keys.iter().enumerate().map(|(i, (cid, name, desc, collation))| {
+        let mut item = match name {
+            Some(name) if *cid >= 0 => quote_identifier(name),
+            _ => {
+                let expr = key_sql.get(i).map(|key| strip_key_options(key)).unwrap_or_default();
+                // Like PostgreSQL, function calls print bare and other expressions in parentheses
+                if is_function_call(&expr) { expr } else { format!("({expr})") }
+            }
+        };
+        if let Some(collation) = collation.as_deref().filter(|c| !c.eq_ignore_ascii_case("BINARY")) {
+            item.push_str(&format!(" COLLATE {}", quote_identifier(collation)));
+        }
+        if *desc {
+            item.push_str(" DESC");
+        }
+        item
+    }).collect();
+
+    if column > 0 {
+        return items.get(column as usize - 1).cloned();
+    }
+    let nulls_not_distinct = crate::metadata::NullsNotDistinctUnique::is_recorded(conn, &index_name);
+    Some(format!(
+        "CREATE {}INDEX {} ON public.{} USING btree ({}){}{}",
+        if unique { "UNIQUE " } else { "" },
+        quote_identifier(&index_name),
+        quote_identifier(&table_name),
+        items.join(", "),
+        if nulls_not_distinct { " NULLS NOT DISTINCT" } else { "" },
+        predicate.map(|predicate| format!(" WHERE ({predicate})")).unwrap_or_default()
+    ))
+}
+
+/// The key list items and the WHERE predicate of a CREATE INDEX statement
+fn index_sql_parts(sql: &str) -> (Vec<String>, Option<String>) {
+    // The key list is the first parenthesized part after ON
+    let upper = sql.to_uppercase();
+    let Some(on) = upper.find(" ON ") else {
+        return (vec![], None);
+    };
+    let Some(open) = sql[on..].find('(').map(|i| on + i) else {
+        return (vec![], None);
+    };
+
+    let close = matching_paren(sql, open).unwrap_or(sql.len());
+    let keys = split_top_level(&sql[open + 1..close]).into_iter().map(str::to_string).collect();
+
+    let rest = sql.get(close + 1..).unwrap_or("").trim();
+    let predicate = rest.get(..5)
+        .filter(|word| word.eq_ignore_ascii_case("WHERE"))
+        .map(|_| rest[5..].trim().trim_end_matches(';').trim().to_string());
+    (keys, predicate)
+}
+
+/// A key list item without its COLLATE and ASC/DESC options, which the pragmas report
+fn strip_key_options(key: &str) -> String {
+    static KEY_OPTIONS: once_cell::sync::Lazy<regex::Regex> = once_cell::sync::Lazy::new(|| {
+        regex::Regex::new(r#"(?i)(?:\s+COLLATE\s+(?:"[^"]+"|\w+))?(?:\s+(?:ASC|DESC))?\s*$"#).unwrap()
+    });
+    KEY_OPTIONS.replace(key.trim(), "").to_string()
+}
+
+/// Whether `expr` is a single function call like lower(name)
+fn is_function_call(expr: &str) -> bool {
+    let Some(open) = expr.find('(') else {
+        return false;
+    };
+    if open == 0 || !expr[..open].trim_end().chars().all(|c| c.is_alphanumeric() || c == '_' || c == '.') {
+        return false;
+    }
+    matching_paren(expr, open) == Some(expr.len() - 1)
+}
+
+/// Quote an identifier the way PostgreSQL prints it: only when it isn't a plain lowercase name
+fn quote_identifier(name: &str) -> String {
+    let plain = name.chars().next().is_some_and(|c| c.is_ascii_lowercase() || c == '_')
+        && name.chars().all(|c| c.is_ascii_lowercase() || c.is_ascii_digit() || c == '_');
+    if plain {
+        name.to_string()
+    } else {
+        format!("\"{}\"", name.replace('"', "\"\""))
+    }
 }
 
 #[cfg(test)]
@@ -92,5 +545,94 @@ mod tests {
             .query_row("SELECT regclass('test_table')", [], |row| row.get(0))
             .unwrap();
         assert_eq!(oid, oid2);
+        
+        // Casting an OID to regclass keeps it, and the output form names the table
+        conn.execute("CREATE TABLE books (id INTEGER PRIMARY KEY)", []).unwrap();
+        let (oid, recast, name): (i64, i64, String) = conn
+            .query_row(
+                "SELECT regclass('books'), regclass(regclass('books')), regclass_name(regclass('public.books'))",
+                [],
+                |row| Ok((row.get(0)?, row.get(1)?, row.get(2)?)),
+            )
+            .unwrap();
+        assert_eq!(oid, recast);
+        assert_eq!(name, "books");
+        
+        let name: String = conn.query_row("SELECT regclass_name(1)", [], |row| row.get(0)).unwrap();
+        assert_eq!(name, "1");
     }
-}
\ No newline at end of file
+    
+    #[test]
+    fn test_format_type() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_catalog_functions(&conn).unwrap();
+        conn.execute("CREATE TABLE pg_type (oid INTEGER, typname TEXT)", []).unwrap();
+        conn.execute("INSERT INTO pg_type VALUES (16390, 'mood')", []).unwrap();
+        
+        let format = |oid: &str, typemod: &str| -> Option<String> {
+            conn.query_row(&format!("SELECT format_type({oid}, {typemod})"), [], |row| row.get(0)).unwrap()
+        };
+        assert_eq!(format("1043", "504").as_deref(), Some("character varying(500)"));
+        assert_eq!(format("1700", "655366").as_deref(), Some("numeric(10,2)"));
+        assert_eq!(format("1015", "36").as_deref(), Some("character varying(32)[]"));
+        assert_eq!(format("16390", "-1").as_deref(), Some("mood"));
+        assert_eq!(format("99999", "-1").as_deref(), Some("???"));
+        assert_eq!(format("NULL", "-1"), None);
+    }
+    
+    #[test]
+    fn test_regtype_cast() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_catalog_functions(&conn).unwrap();
+        
+        let (oid, name): (i32, String) = conn
+            .query_row("SELECT regtype('int4'), regtype_name(regtype('integer'))", [], |row| Ok((row.get(0)?, row.get(1)?)))
+            .unwrap();
+        assert_eq!(oid, 23);
+        assert_eq!(name, "integer");
+        
+        assert!(conn.query_row("SELECT regtype('no_such_type')", [], |row| row.get::<_, i32>(0)).is_err());
+    }
+    
+    #[test]
+    fn test_pg_get_constraintdef() {
+        use crate::catalog::constraint_populator::generate_table_oid;
+
+        let conn = Connection::open_in_memory().unwrap();
+        register_catalog_functions(&conn).unwrap();
+        conn.execute_batch(
+            "CREATE TABLE pg_constraint (oid TEXT PRIMARY KEY, conname TEXT, contype TEXT, conrelid TEXT, conkey TEXT, consrc TEXT);
+             CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT);
+             CREATE TABLE books (id INTEGER PRIMARY KEY, author_id INTEGER REFERENCES authors(id) ON DELETE CASCADE,
+                                 stock INTEGER CHECK (stock >= 0));"
+        ).unwrap();
+        let books_oid = generate_table_oid("books");
+        for (oid, contype, conkey, consrc) in [
+            ("1", "p", "1", None),
+            ("2", "f", "2", None),
+            ("3", "c", "", Some("CHECK (stock >= 0)")),
+        ] {
+            conn.execute(
+                "INSERT INTO pg_constraint VALUES (?1, 'c', ?2, ?3, ?4, ?5)",
+                rusqlite::params![oid, contype, books_oid, conkey, consrc],
+            ).unwrap();
+        }
+
+        let definition = |oid: i64| -> Option<String> {
+            conn.query_row("SELECT pg_get_constraintdef(?1)", [oid], |row| row.get(0)).unwrap()
+        };
+        assert_eq!(definition(1).as_deref(), Some("PRIMARY KEY (id)"));
+        assert_eq!(definition(2).as_deref(), Some("FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE CASCADE"));
+        assert_eq!(definition(3).as_deref(), Some("CHECK ((stock >= 0))"));
+        assert_eq!(definition(4), None);
+    }
+    
+    #[test]
+    fn test_default_expression() {
+        assert_eq!(default_expression("'draft'", Some("TEXT")), "'draft'::text");
+        assert_eq!(default_expression("'x'", Some("VARCHAR(10)")), "'x'::character varying");
+        assert_eq!(default_expression("1", Some("BOOLEAN")), "true");
+        assert_eq!(default_expression("0", Some("INTEGER")), "0");
+        assert_eq!(default_expression("datetime('now')", None), "now()");
+    }
+}
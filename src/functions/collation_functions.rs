@@ -0,0 +1,69 @@
+use rusqlite::{Connection, Result};
+use std::cmp::Ordering;
+use tracing::debug;
+
+/// The collation locale names like "en_US" or "de-DE-x-icu" are translated to
+pub const LOCALE_COLLATION: &str = "pg_locale";
+
+/// Base letter of each character from U+00C0 to U+017F (Latin-1 letters and Latin Extended-A),
+/// '*' where the character is not a letter
+const LATIN_BASE_LETTERS: &[u8] =
+    b"AAAAAAACEEEEIIIIDNOOOOO*OUUUUYTsaaaaaaaceeeeiiiidnooooo*ouuuuytyAaAaAaCcCcCcCcDdDdEeEeEeEeEeGgGgGgGgHhHhIiIiIiIiIiIiJjKkkLlLlLlLlLlNnNnNnnNnOoOoOoOoRrRrRrSsSsSsSsTtTtTtUuUuUuUuUuUuWwYyYZzZzZzs";
+
+/// Register the collations PostgreSQL collation names are mapped to
+pub fn register_collations(conn: &Connection) -> Result<()> {
+    debug!("Registering collations");
+    conn.create_collation(LOCALE_COLLATION, compare_locale)?;
+    Ok(())
+}
+
+/// Compare strings the way a linguistic locale sorts them: by letter ignoring accents and
+/// case first, then by accents, then by case with lowercase first
+pub fn compare_locale(a: &str, b: &str) -> Ordering {
+    let letters = |s: &str| s.chars().map(base_letter).flat_map(char::to_lowercase).collect::<Vec<_>>();
+    let accents = |s: &str| s.chars().flat_map(char::to_lowercase).collect::<Vec<_>>();
+    let cases = |s: &str| s.chars().map(char::is_uppercase).collect::<Vec<_>>();
+
+    letters(a).cmp(&letters(b))
+        .then_with(|| accents(a).cmp(&accents(b)))
+        .then_with(|| cases(a).cmp(&cases(b)))
+        .then_with(|| a.cmp(b))
+}
+
+/// The letter without its diacritics
+fn base_letter(c: char) -> char {
+    match (c as usize).checked_sub(0xC0).and_then(|i| LATIN_BASE_LETTERS.get(i)) {
+        Some(b'*') | None => c,
+        Some(&base) => base as char,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_compare_locale() {
+        let mut words = vec!["zebra", "Émile", "eagle", "apple", "Eve", "éclair", "Łódź", "lodge"];
+        words.sort_by(|a, b| compare_locale(a, b));
+        assert_eq!(words, vec!["apple", "eagle", "éclair", "Émile", "Eve", "lodge", "Łódź", "zebra"]);
+
+        assert_eq!(compare_locale("résumé", "resume"), Ordering::Greater);
+        assert_eq!(compare_locale("apple", "Apple"), Ordering::Less);
+        assert_eq!(compare_locale("same", "same"), Ordering::Equal);
+    }
+
+    #[test]
+    fn test_locale_collation_in_sql() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_collations(&conn).unwrap();
+        let sorted: Vec<String> = conn
+            .prepare("SELECT column1 FROM (VALUES ('Zoë'), ('zoo'), ('Ángel'), ('ant')) ORDER BY column1 COLLATE pg_locale")
+            .unwrap()
+            .query_map([], |row| row.get(0))
+            .unwrap()
+            .map(|r| r.unwrap())
+            .collect();
+        assert_eq!(sorted, vec!["Ángel", "ant", "Zoë", "zoo"]);
+    }
+}
@@ -64,10 +64,7 @@ impl CommentFunctionHandler {
             if let Ok(oid) = oid_str.parse::<i32>() {
                 let comment = CommentDdlHandler::get_comment(conn, oid, catalog_name, 0)?;
                 let replacement = match comment {
-                    Some(text) => {
-                        // Text from database already has proper escaping, just wrap in quotes
-                        format!("'{}'", text)
-                    },
+                    Some(text) => format!("'{}'", text.replace('\'', "''")),
                     None => "NULL".to_string(),
                 };
                 
@@ -97,10 +94,7 @@ impl CommentFunctionHandler {
                     .or_else(|| CommentDdlHandler::get_comment(conn, oid, "pg_proc", 0).unwrap_or(None));
                     
                 let replacement = match comment {
-                    Some(text) => {
-                        // Text from database already has proper escaping, just wrap in quotes
-                        format!("'{}'", text)
-                    },
+                    Some(text) => format!("'{}'", text.replace('\'', "''")),
                     None => "NULL".to_string(),
                 };
                 
@@ -128,10 +122,7 @@ impl CommentFunctionHandler {
             if let (Ok(table_oid), Ok(column_num)) = (table_oid_str.parse::<i32>(), column_num_str.parse::<i32>()) {
                 let comment = CommentDdlHandler::get_comment(conn, table_oid, "pg_class", column_num)?;
                 let replacement = match comment {
-                    Some(text) => {
-                        // Text from database already has proper escaping, just wrap in quotes
-                        format!("'{}'", text)
-                    },
+                    Some(text) => format!("'{}'", text.replace('\'', "''")),
                     None => "NULL".to_string(),
                 };
                 
@@ -265,6 +256,6 @@ mod tests {
         
         let query = format!("SELECT obj_description({}, 'pg_class')", table_oid);
         let result = CommentFunctionHandler::process_comment_functions(&conn, &query).unwrap();
-        assert_eq!(result, "SELECT 'User''s information table'"); // Quote escaping preserved from storage
+        assert_eq!(result, "SELECT 'User''s information table'"); // Quote re-escaped for the literal
     }
 }
\ No newline at end of file
@@ -1,23 +1,64 @@
 use rusqlite::{Connection, Result, Error};
 use rusqlite::functions::FunctionFlags;
-use chrono::{DateTime, NaiveDate, NaiveTime, Utc, Datelike, Timelike};
+use chrono::{DateTime, NaiveDate, NaiveDateTime, NaiveTime, Utc, Datelike, Timelike};
+use parking_lot::Mutex;
+use std::sync::Arc;
+
+/// Start of the explicit transaction open on a connection, if any
+type TransactionClock = Arc<Mutex<Option<DateTime<Utc>>>>;
+
+/// The instant now() stands for: the start of the open transaction, otherwise the current one
+fn transaction_now(clock: &TransactionClock) -> DateTime<Utc> {
+    (*clock.lock()).unwrap_or_else(Utc::now)
+}
+
+/// Fix now() to the current instant until the transaction started on `conn` ends
+pub fn start_transaction_clock(conn: &Connection) -> Result<()> {
+    conn.query_row("SELECT __pgsqlite_transaction_clock(1)", [], |_| Ok(()))
+}
+
+/// Let now() follow the clock again after the transaction on `conn` ended
+pub fn stop_transaction_clock(conn: &Connection) -> Result<()> {
+    conn.query_row("SELECT __pgsqlite_transaction_clock(0)", [], |_| Ok(()))
+}
 
 /// Register datetime-related functions in SQLite
 pub fn register_datetime_functions(conn: &Connection) -> Result<()> {
-    // now() / current_timestamp - Return current timestamp as formatted string
-    // PostgreSQL clients expect NOW() to return formatted timestamp strings
-    conn.create_scalar_function(
-        "now",
-        0,
-        FunctionFlags::SQLITE_UTF8,
-        |_ctx| {
-            let now = Utc::now();
-            Ok(now.format("%Y-%m-%d %H:%M:%S%.6f").to_string())
-        },
-    )?;
-    
+    // PostgreSQL's now() is the start time of the current transaction, so every statement
+    // of a transaction sees the same value. BEGIN sets the clock and COMMIT/ROLLBACK clear
+    // it (see DbHandler), outside a transaction each call reads the current time.
+    let clock = TransactionClock::default();
+    {
+        let clock = clock.clone();
+        conn.create_scalar_function(
+            "__pgsqlite_transaction_clock",
+            1,
+            FunctionFlags::SQLITE_UTF8,
+            move |ctx| {
+                let active: bool = ctx.get(0)?;
+                *clock.lock() = active.then(Utc::now);
+                Ok(active)
+            },
+        )?;
+    }
+
+    // now() / current_timestamp() / transaction_timestamp() - Return the transaction timestamp
+    // as a formatted string, PostgreSQL clients expect NOW() to return formatted timestamp strings
+    for name in ["now", "current_timestamp", "transaction_timestamp"] {
+        let clock = clock.clone();
+        conn.create_scalar_function(
+            name,
+            0,
+            FunctionFlags::SQLITE_UTF8,
+            move |_ctx| {
+                Ok(transaction_now(&clock).format("%Y-%m-%d %H:%M:%S%.6f").to_string())
+            },
+        )?;
+    }
+
+    // clock_timestamp() - The actual current time, which changes even within a statement
     conn.create_scalar_function(
-        "current_timestamp",
+        "clock_timestamp",
         0,
         FunctionFlags::SQLITE_UTF8,
         |_ctx| {
@@ -30,12 +71,13 @@ pub fn register_datetime_functions(conn: &Connection) -> Result<()> {
     // SQLite's CURRENT_DATE returns text in YYYY-MM-DD format
     
     // current_time - Return microseconds since midnight
+    let current_time_clock = clock.clone();
     conn.create_scalar_function(
         "current_time",
         0,
         FunctionFlags::SQLITE_UTF8,
-        |_ctx| {
-            let now = Utc::now();
+        move |_ctx| {
+            let now = transaction_now(&current_time_clock);
             let time = now.time();
             let micros = time.num_seconds_from_midnight() as i64 * 1_000_000 
                 + (time.nanosecond() / 1000) as i64;
@@ -111,27 +153,30 @@ pub fn register_datetime_functions(conn: &Connection) -> Result<()> {
         },
     )?;
     
-    // age(timestamp1, timestamp2) - Calculate interval between timestamps
+    // age(timestamp1, timestamp2) - Calendar-aware interval between timestamps
     conn.create_scalar_function(
         "age",
         2,
-        FunctionFlags::SQLITE_UTF8,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let ts1: i64 = ctx.get(0)?;
-            let ts2: i64 = ctx.get(1)?;
-            Ok(ts1 - ts2) // Return difference in microseconds
+            let end = value_to_datetime(ctx.get_raw(0))?;
+            let start = value_to_datetime(ctx.get_raw(1))?;
+            Ok(match (end, start) {
+                (Some(end), Some(start)) => Some(interval_age(&end, &start)),
+                _ => None,
+            })
         },
     )?;
     
-    // age(timestamp) - Calculate interval from current time
+    // age(timestamp) - Calendar-aware interval from midnight of the current date
     conn.create_scalar_function(
         "age",
         1,
         FunctionFlags::SQLITE_UTF8,
-        |ctx| {
-            let ts: i64 = ctx.get(0)?;
-            let now = Utc::now().timestamp_micros();
-            Ok(now - ts) // Return difference in microseconds
+        move |ctx| {
+            let start = value_to_datetime(ctx.get_raw(0))?;
+            let today = transaction_now(&clock).date_naive().and_time(NaiveTime::default());
+            Ok(start.map(|start| interval_age(&today, &start)))
         },
     )?;
     
@@ -191,51 +236,85 @@ pub fn register_datetime_functions(conn: &Connection) -> Result<()> {
         },
     )?;
     
-    // make_date(year, month, day) - Create date from components
+    // make_date(year, month, day) - Create date from components, as days since epoch
     conn.create_scalar_function(
         "make_date",
         3,
-        FunctionFlags::SQLITE_UTF8,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let year: i32 = ctx.get(0)?;
-            let month: u32 = ctx.get::<i32>(1)? as u32;
-            let day: u32 = ctx.get::<i32>(2)? as u32;
-            
-            match NaiveDate::from_ymd_opt(year, month, day) {
-                Some(date) => {
-                    // Return days since epoch
-                    Ok(date.num_days_from_ce() as i64 - 719163)
-                }
-                None => Err(Error::UserFunctionError(
-                    format!("Invalid date: {year}-{month}-{day}").into()
-                ))
-            }
+            let (Some(year), Some(month), Some(day)) = (ctx.get::<Option<i32>>(0)?, ctx.get::<Option<i32>>(1)?, ctx.get::<Option<i32>>(2)?) else {
+                return Ok(None);
+            };
+            let date = make_date(year, month, day)?;
+            Ok(Some(date.num_days_from_ce() as i64 - 719163))
         },
     )?;
     
-    // make_time(hour, min, sec) - Create time from components
+    // make_time(hour, min, sec) - Create time from components, as microseconds since midnight
     conn.create_scalar_function(
         "make_time",
         3,
-        FunctionFlags::SQLITE_UTF8,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let hour: u32 = ctx.get::<i32>(0)? as u32;
-            let min: u32 = ctx.get::<i32>(1)? as u32;
-            let sec: f64 = ctx.get(2)?;
-            
-            let secs = sec.trunc() as u32;
-            let nanos = ((sec.fract() * 1_000_000_000.0).round() as u32).min(999_999_999);
-            
-            match NaiveTime::from_hms_nano_opt(hour, min, secs, nanos) {
-                Some(time) => {
-                    let micros = time.num_seconds_from_midnight() as i64 * 1_000_000 
-                        + (time.nanosecond() / 1000) as i64;
-                    Ok(micros)
+            let (Some(hour), Some(min), Some(sec)) = (ctx.get::<Option<i32>>(0)?, ctx.get::<Option<i32>>(1)?, ctx.get::<Option<f64>>(2)?) else {
+                return Ok(None);
+            };
+            let time = make_time(hour, min, sec)?;
+            Ok(Some(time.num_seconds_from_midnight() as i64 * 1_000_000 + (time.nanosecond() / 1000) as i64))
+        },
+    )?;
+    
+    // make_timestamp(year, month, mday, hour, min, sec) - Create timestamp from components,
+    // as microseconds since epoch
+    conn.create_scalar_function(
+        "make_timestamp",
+        6,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let mut fields = [0; 5];
+            for (i, field) in fields.iter_mut().enumerate() {
+                match ctx.get::<Option<i32>>(i)? {
+                    Some(value) => *field = value,
+                    None => return Ok(None),
                 }
-                None => Err(Error::UserFunctionError(
-                    format!("Invalid time: {hour}:{min}:{sec}").into()
-                ))
             }
+            let Some(sec) = ctx.get::<Option<f64>>(5)? else {
+                return Ok(None);
+            };
+            let [year, month, day, hour, min] = fields;
+            let timestamp = make_date(year, month, day)?.and_time(make_time(hour, min, sec)?);
+            Ok(Some(crate::types::datetime_utils::datetime_to_microseconds(&timestamp)))
+        },
+    )?;
+    
+    // make_interval(years, months, weeks, days, hours, mins, secs) - Create interval from
+    // components, all optional. Calls naming their fields (days => 5) are made positional
+    // by MakeIntervalTranslator.
+    conn.create_scalar_function(
+        "make_interval",
+        -1,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            if ctx.len() > 7 {
+                return Err(Error::UserFunctionError("function make_interval takes at most 7 arguments".into()));
+            }
+            let mut fields = [0i64; 6];
+            for (i, field) in fields.iter_mut().enumerate().take(ctx.len()) {
+                match ctx.get::<Option<i64>>(i)? {
+                    Some(value) => *field = value,
+                    None => return Ok(None),
+                }
+            }
+            let secs = if ctx.len() == 7 {
+                match ctx.get::<Option<f64>>(6)? {
+                    Some(secs) => secs,
+                    None => return Ok(None),
+                }
+            } else {
+                0.0
+            };
+            let [years, months, weeks, days, hours, mins] = fields;
+            make_interval(years, months, weeks, days, hours, mins, secs).map(Some)
         },
     )?;
     
@@ -397,6 +476,107 @@ pub fn register_datetime_functions(conn: &Connection) -> Result<()> {
     Ok(())
 }
 
+/// The date make_date() and make_timestamp() build, PostgreSQL counting years BC as negative
+fn make_date(year: i32, month: i32, day: i32) -> Result<NaiveDate> {
+    // There is no year 0, year -1 is 1 BC
+    let ce_year = if year < 0 { Some(year + 1) } else if year > 0 { Some(year) } else { None };
+    ce_year
+        .zip(u32::try_from(month).ok())
+        .zip(u32::try_from(day).ok())
+        .and_then(|((year, month), day)| NaiveDate::from_ymd_opt(year, month, day))
+        .ok_or_else(|| Error::UserFunctionError(format!("date field value out of range: {year}-{month:02}-{day:02}").into()))
+}
+
+/// The time make_time() and make_timestamp() build
+fn make_time(hour: i32, min: i32, sec: f64) -> Result<NaiveTime> {
+    let micros = (sec * 1_000_000.0).round();
+    u32::try_from(hour).ok()
+        .zip(u32::try_from(min).ok())
+        .filter(|_| (0.0..60_000_000.0).contains(&micros))
+        .and_then(|(hour, min)| {
+            let micros = micros as u32;
+            NaiveTime::from_hms_micro_opt(hour, min, micros / 1_000_000, micros % 1_000_000)
+        })
+        .ok_or_else(|| Error::UserFunctionError(format!("time field value out of range: {hour:02}:{min:02}:{sec:02}").into()))
+}
+
+/// The interval make_interval() builds, in PostgreSQL's text format
+fn make_interval(years: i64, months: i64, weeks: i64, days: i64, hours: i64, mins: i64, secs: f64) -> Result<String> {
+    use crate::types::datetime_utils::format_interval;
+    let out_of_range = || Error::UserFunctionError("interval out of range".into());
+    let total_months = years.checked_mul(12).and_then(|m| m.checked_add(months))
+        .and_then(|m| i32::try_from(m).ok())
+        .ok_or_else(out_of_range)?;
+    let total_days = weeks.checked_mul(7).and_then(|d| d.checked_add(days))
+        .and_then(|d| i32::try_from(d).ok())
+        .ok_or_else(out_of_range)?;
+    let secs_micros = (secs * 1_000_000.0).round();
+    if !secs_micros.is_finite() || secs_micros.abs() >= i64::MAX as f64 {
+        return Err(out_of_range());
+    }
+    let micros = hours.checked_mul(3_600_000_000)
+        .zip(mins.checked_mul(60_000_000))
+        .and_then(|(h, m)| h.checked_add(m))
+        .and_then(|hm| hm.checked_add(secs_micros as i64))
+        .ok_or_else(out_of_range)?;
+    Ok(format_interval(total_months, total_days, micros))
+}
+
+/// Compute age(end, start) and format it as PostgreSQL interval text
+fn interval_age(end: &NaiveDateTime, start: &NaiveDateTime) -> String {
+    use crate::types::datetime_utils::{timestamp_age, format_interval};
+    let (months, days, micros) = timestamp_age(end, start);
+    format_interval(months, days, micros)
+}
+
+/// Interpret a SQLite value as a timestamp. Dates are stored as INTEGER days and
+/// timestamps as INTEGER microseconds since the epoch; text is parsed as a date or timestamp.
+fn value_to_datetime(value: rusqlite::types::ValueRef<'_>) -> Result<Option<NaiveDateTime>> {
+    use crate::types::datetime_utils::{microseconds_to_datetime, parse_timestamp_to_microseconds, parse_date_to_days};
+    use rusqlite::types::ValueRef;
+    
+    // Anything this small is a day count rather than microseconds (which would be within seconds of 1970)
+    const MAX_EPOCH_DAYS: i64 = 3_000_000;
+    let from_integer = |value: i64| {
+        if value.abs() <= MAX_EPOCH_DAYS {
+            microseconds_to_datetime(value * 86_400_000_000)
+        } else {
+            microseconds_to_datetime(value)
+        }
+    };
+    
+    match value {
+        ValueRef::Null => Ok(None),
+        ValueRef::Integer(i) => Ok(from_integer(i)),
+        ValueRef::Real(f) => Ok(from_integer(f as i64)),
+        ValueRef::Text(bytes) => {
+            let text = std::str::from_utf8(bytes)
+                .map_err(|e| Error::UserFunctionError(e.to_string().into()))?
+                .trim();
+            
+            if let Some(micros) = parse_timestamp_to_microseconds(text) {
+                return Ok(microseconds_to_datetime(micros));
+            }
+            if let Some(days) = parse_date_to_days(text) {
+                return Ok(microseconds_to_datetime(days * 86_400_000_000));
+            }
+            // Timestamps with a UTC offset, e.g. "2024-01-01 10:00:00+02:00"
+            for format in ["%Y-%m-%d %H:%M:%S%.f%#z", "%Y-%m-%dT%H:%M:%S%.f%#z"] {
+                if let Ok(dt) = DateTime::parse_from_str(text, format) {
+                    return Ok(Some(dt.naive_utc()));
+                }
+            }
+            // Plain integers stored as text
+            if let Ok(i) = text.parse::<i64>() {
+                return Ok(from_integer(i));
+            }
+            
+            Err(Error::UserFunctionError(format!("invalid input syntax for type timestamp: \"{text}\"").into()))
+        }
+        ValueRef::Blob(_) => Err(Error::UserFunctionError("Expected a date or timestamp value".into())),
+    }
+}
+
 /// Extract a date part from microseconds since epoch
 fn extract_date_part(field: &str, timestamp: i64) -> Result<f64> {
     let secs = timestamp / 1_000_000;
@@ -576,4 +756,74 @@ mod tests {
             assert_eq!(result, expected, "Failed for input: {}", input);
         }
     }
-}
\ No newline at end of file
+    
+    #[test]
+    fn test_age() {
+        use rusqlite::Connection;
+        
+        let conn = Connection::open_in_memory().unwrap();
+        register_datetime_functions(&conn).unwrap();
+        
+        let age = |sql: &str| -> Option<String> { conn.query_row(sql, [], |row| row.get(0)).unwrap() };
+        
+        assert_eq!(age("SELECT age('2024-02-10', '1990-06-15')").as_deref(), Some("33 years 7 mons 25 days"));
+        assert_eq!(age("SELECT age('2024-01-02 03:00:00', '2023-12-31 23:30:00')").as_deref(), Some("1 day 03:30:00"));
+        assert_eq!(age("SELECT age('2023-12-31', '2024-01-01')").as_deref(), Some("-1 days"));
+        // Dates stored as INTEGER days since the epoch (2000-01-01 and 1999-12-25)
+        assert_eq!(age("SELECT age(10957, 10950)").as_deref(), Some("7 days"));
+        assert_eq!(age("SELECT age(NULL, '2024-01-01')"), None);
+    }
+    
+    #[test]
+    fn test_make_constructors() {
+        use rusqlite::Connection;
+        
+        let conn = Connection::open_in_memory().unwrap();
+        register_datetime_functions(&conn).unwrap();
+        
+        let days: i64 = conn.query_row("SELECT make_date(2024, 1, 15)", [], |row| row.get(0)).unwrap();
+        assert_eq!(days, 19737);
+        let micros: i64 = conn.query_row("SELECT make_timestamp(2024, 1, 15, 10, 30, 45.5)", [], |row| row.get(0)).unwrap();
+        assert_eq!(micros, 1705314645500000);
+        let interval: String = conn.query_row("SELECT make_interval(0, 0, 1, 5, 2, 0, 1.5)", [], |row| row.get(0)).unwrap();
+        assert_eq!(interval, "12 days 02:00:01.5");
+        let interval: String = conn.query_row("SELECT make_interval(1, 2)", [], |row| row.get(0)).unwrap();
+        assert_eq!(interval, "1 year 2 mons");
+        let nothing: Option<i64> = conn.query_row("SELECT make_date(2024, NULL, 15)", [], |row| row.get(0)).unwrap();
+        assert_eq!(nothing, None);
+        
+        for (sql, message) in [
+            ("SELECT make_date(2024, 2, 30)", "date field value out of range: 2024-02-30"),
+            ("SELECT make_date(0, 1, 1)", "date field value out of range: 0-01-01"),
+            ("SELECT make_timestamp(2024, 13, 1, 0, 0, 0)", "date field value out of range: 2024-13-01"),
+            ("SELECT make_time(25, 0, 0)", "time field value out of range: 25:00:00"),
+        ] {
+            let err = conn.query_row(sql, [], |row| row.get::<_, i64>(0)).unwrap_err();
+            assert!(err.to_string().contains(message), "{sql}: {err}");
+        }
+    }
+    
+    #[test]
+    fn test_transaction_clock() {
+        use rusqlite::Connection;
+        
+        let conn = Connection::open_in_memory().unwrap();
+        register_datetime_functions(&conn).unwrap();
+        
+        let now = || -> String { conn.query_row("SELECT now()", [], |row| row.get(0)).unwrap() };
+        
+        conn.execute_batch("BEGIN").unwrap();
+        start_transaction_clock(&conn).unwrap();
+        let first = now();
+        std::thread::sleep(std::time::Duration::from_millis(5));
+        assert_eq!(now(), first);
+        let transaction: String = conn.query_row("SELECT transaction_timestamp()", [], |row| row.get(0)).unwrap();
+        assert_eq!(transaction, first);
+        let clock: String = conn.query_row("SELECT clock_timestamp()", [], |row| row.get(0)).unwrap();
+        assert!(clock > first);
+        
+        stop_transaction_clock(&conn).unwrap();
+        conn.execute_batch("COMMIT").unwrap();
+        assert!(now() > first);
+    }
+}
@@ -1,5 +1,5 @@
 use rusqlite::{Connection, Result, functions::{FunctionFlags, Context}};
-use rust_decimal::Decimal;
+use rust_decimal::{Decimal, RoundingStrategy};
 use std::str::FromStr;
 use std::panic::AssertUnwindSafe;
 
@@ -106,6 +106,48 @@ pub fn register_decimal_functions(conn: &Connection) -> Result<()> {
         decimal_abs,
     )?;
     
+    conn.create_scalar_function(
+        "decimal_trunc",
+        2,
+        FunctionFlags::SQLITE_DETERMINISTIC | FunctionFlags::SQLITE_INNOCUOUS,
+        decimal_trunc,
+    )?;
+    
+    conn.create_scalar_function(
+        "decimal_ceil",
+        1,
+        FunctionFlags::SQLITE_DETERMINISTIC | FunctionFlags::SQLITE_INNOCUOUS,
+        decimal_ceil,
+    )?;
+    
+    conn.create_scalar_function(
+        "decimal_floor",
+        1,
+        FunctionFlags::SQLITE_DETERMINISTIC | FunctionFlags::SQLITE_INNOCUOUS,
+        decimal_floor,
+    )?;
+    
+    conn.create_scalar_function(
+        "decimal_mod",
+        2,
+        FunctionFlags::SQLITE_DETERMINISTIC | FunctionFlags::SQLITE_INNOCUOUS,
+        decimal_mod,
+    )?;
+    
+    conn.create_scalar_function(
+        "decimal_power",
+        2,
+        FunctionFlags::SQLITE_DETERMINISTIC | FunctionFlags::SQLITE_INNOCUOUS,
+        decimal_power,
+    )?;
+    
+    conn.create_scalar_function(
+        "decimal_sqrt",
+        1,
+        FunctionFlags::SQLITE_DETERMINISTIC | FunctionFlags::SQLITE_INNOCUOUS,
+        decimal_sqrt,
+    )?;
+    
     Ok(())
 }
 
@@ -221,6 +263,11 @@ fn get_decimal(ctx: &Context<'_>, idx: usize) -> Result<Option<Decimal>> {
                 return Err(rusqlite::Error::UserFunctionError("Cannot convert infinity to decimal".into()));
             }
             
+            // Prefer the float's shortest decimal form, so 2.675 doesn't become 2.67499999...
+            if let Ok(decimal) = Decimal::from_str(&f.to_string()) {
+                return Ok(Some(decimal));
+            }
+            
             // Try conversion and provide detailed error information
             Decimal::try_from(f)
                 .map(Some)
@@ -265,7 +312,7 @@ fn decimal_div(ctx: &Context<'_>) -> Result<Option<String>> {
     match (get_decimal(ctx, 0)?, get_decimal(ctx, 1)?) {
         (Some(a), Some(b)) => {
             if b.is_zero() {
-                Err(rusqlite::Error::UserFunctionError("Division by zero".into()))
+                Err(rusqlite::Error::UserFunctionError("division by zero".into()))
             } else {
                 let result = a / b;
                 Ok(Some(result.to_string()))
@@ -375,13 +422,15 @@ fn numeric_format(ctx: &Context<'_>) -> Result<Option<String>> {
     }
 }
 
-/// numeric_cast function that validates and formats numeric values with constraints
+/// numeric_cast function implementing CAST(value AS NUMERIC(p,s)): the value is
+/// rounded half away from zero to the scale, then checked against the precision
 fn numeric_cast(ctx: &Context<'_>) -> Result<Option<String>> {
     let value = match ctx.get_raw(0) {
         rusqlite::types::ValueRef::Null => return Ok(None),
         rusqlite::types::ValueRef::Text(s) => {
             std::str::from_utf8(s)
                 .map_err(|e| rusqlite::Error::UserFunctionError(Box::new(e)))?
+                .trim()
                 .to_string()
         }
         rusqlite::types::ValueRef::Integer(i) => i.to_string(),
@@ -392,13 +441,19 @@ fn numeric_cast(ctx: &Context<'_>) -> Result<Option<String>> {
     let precision = ctx.get::<i32>(1)?;
     let scale = ctx.get::<i32>(2)?;
     
+    // Round to the target scale before validating, as PostgreSQL does for casts
+    let value = match Decimal::from_str(&value).or_else(|_| Decimal::from_scientific(&value)) {
+        Ok(decimal) => {
+            let rounded = decimal.round_dp_with_strategy(scale.max(0) as u32, RoundingStrategy::MidpointAwayFromZero);
+            format!("{:.prec$}", rounded, prec = scale.max(0) as usize)
+        }
+        Err(_) => value,
+    };
+    
     // Validate the value against numeric constraints
     use crate::validator::NumericValidator;
     match NumericValidator::validate_value(&value, precision, scale) {
-        Ok(()) => {
-            // Value is valid, now format it to the correct scale
-            numeric_format(ctx)
-        }
+        Ok(()) => Ok(Some(value)),
         Err(e) => {
             // Return an error that will be caught by SQLite
             Err(rusqlite::Error::SqliteFailure(
@@ -415,14 +470,163 @@ fn decimal_round(ctx: &Context<'_>) -> Result<Option<String>> {
     let scale = ctx.get::<i32>(1)?;
     
     match decimal_opt {
-        Some(decimal) => {
-            let rounded = decimal.round_dp(scale as u32);
-            Ok(Some(rounded.to_string()))
-        }
+        Some(decimal) => Ok(Some(round_numeric(decimal, scale))),
         None => Ok(None)
     }
 }
 
+/// Round like PostgreSQL's round(numeric, int): halves go away from zero and the result
+/// keeps `scale` fractional digits. A negative scale rounds to the left of the decimal point.
+pub(crate) fn round_numeric(value: Decimal, scale: i32) -> String {
+    rescale_numeric(value, scale, RoundingStrategy::MidpointAwayFromZero)
+}
+
+/// Truncate like PostgreSQL's trunc(numeric, int), keeping `scale` fractional digits
+pub(crate) fn trunc_numeric(value: Decimal, scale: i32) -> String {
+    rescale_numeric(value, scale, RoundingStrategy::ToZero)
+}
+
+fn rescale_numeric(value: Decimal, scale: i32, strategy: RoundingStrategy) -> String {
+    let rescaled = if scale >= 0 {
+        value.round_dp_with_strategy(scale as u32, strategy)
+    } else {
+        // Decimal can't hold 10^29, and every representable value rounds to zero at that point
+        match 10i128.checked_pow(scale.unsigned_abs()).filter(|_| scale >= -28) {
+            Some(factor) => {
+                let factor = Decimal::from_i128_with_scale(factor, 0);
+                (value / factor).round_dp_with_strategy(0, strategy) * factor
+            }
+            None => Decimal::ZERO,
+        }
+    };
+    
+    format!("{:.prec$}", without_negative_zero(rescaled), prec = scale.max(0) as usize)
+}
+
+/// A negative value that rounds to zero prints as 0, not -0
+fn without_negative_zero(value: Decimal) -> Decimal {
+    if value.is_zero() { value.abs() } else { value }
+}
+
+/// mod(numeric, numeric): the remainder takes the sign of the dividend
+pub(crate) fn numeric_mod(dividend: Decimal, divisor: Decimal) -> Result<Decimal> {
+    if divisor.is_zero() {
+        return Err(rusqlite::Error::UserFunctionError("division by zero".into()));
+    }
+    dividend.checked_rem(divisor)
+        .ok_or_else(|| rusqlite::Error::UserFunctionError("numeric field overflow".into()))
+}
+
+/// power(numeric, numeric): whole exponents are computed exactly by repeated squaring,
+/// fractional ones (or results too large for an exact decimal) through f64
+pub(crate) fn numeric_power(base: Decimal, exponent: Decimal) -> Result<Decimal> {
+    if base.is_zero() && exponent.is_sign_negative() {
+        return Err(rusqlite::Error::UserFunctionError("zero raised to a negative power is undefined".into()));
+    }
+    if base.is_sign_negative() && !exponent.fract().is_zero() {
+        return Err(rusqlite::Error::UserFunctionError(
+            "a negative number raised to a non-integer power yields a complex result".into()
+        ));
+    }
+    
+    let exact = i64::try_from(exponent.trunc()).ok()
+        .filter(|_| exponent.fract().is_zero())
+        .and_then(|n| {
+            let mut result = Decimal::ONE;
+            let mut square = base;
+            let mut remaining = n.unsigned_abs();
+            while remaining > 0 {
+                if remaining & 1 == 1 {
+                    result = result.checked_mul(square)?;
+                }
+                remaining >>= 1;
+                if remaining > 0 {
+                    square = square.checked_mul(square)?;
+                }
+            }
+            if n < 0 { Decimal::ONE.checked_div(result) } else { Some(result) }
+        });
+    if let Some(result) = exact {
+        return Ok(result.normalize());
+    }
+    
+    let (base, exponent) = (decimal_to_f64(base)?, decimal_to_f64(exponent)?);
+    Decimal::from_str(&base.powf(exponent).to_string())
+        .map(Decimal::normalize)
+        .map_err(|_| rusqlite::Error::UserFunctionError("value overflows numeric format".into()))
+}
+
+/// sqrt(numeric) by Newton's method, to the full precision of the decimal type
+pub(crate) fn numeric_sqrt(value: Decimal) -> Result<Decimal> {
+    if value.is_sign_negative() && !value.is_zero() {
+        return Err(rusqlite::Error::UserFunctionError("cannot take square root of a negative number".into()));
+    }
+    if value.is_zero() {
+        return Ok(Decimal::ZERO);
+    }
+    
+    // Start from the float estimate, which is already close
+    let mut root = Decimal::from_str(&decimal_to_f64(value)?.sqrt().to_string()).unwrap_or(value);
+    for _ in 0..20 {
+        let next = (root + value / root) / Decimal::TWO;
+        if next == root {
+            break;
+        }
+        root = next;
+    }
+    
+    // Newton's method approaches exact roots from above, so settle on a whole root when there is one
+    let whole = root.round();
+    if whole * whole == value {
+        return Ok(whole);
+    }
+    Ok(root.normalize())
+}
+
+fn decimal_to_f64(value: Decimal) -> Result<f64> {
+    f64::from_str(&value.to_string())
+        .map_err(|e| rusqlite::Error::UserFunctionError(Box::new(e)))
+}
+
+/// decimal_trunc function that truncates a decimal value to specified decimal places
+fn decimal_trunc(ctx: &Context<'_>) -> Result<Option<String>> {
+    let decimal_opt = get_decimal(ctx, 0)?;
+    let scale = ctx.get::<i32>(1)?;
+    
+    Ok(decimal_opt.map(|decimal| trunc_numeric(decimal, scale)))
+}
+
+/// decimal_ceil function that returns the smallest integer not less than a decimal
+fn decimal_ceil(ctx: &Context<'_>) -> Result<Option<String>> {
+    Ok(get_decimal(ctx, 0)?.map(|decimal| without_negative_zero(decimal.ceil()).to_string()))
+}
+
+/// decimal_floor function that returns the largest integer not greater than a decimal
+fn decimal_floor(ctx: &Context<'_>) -> Result<Option<String>> {
+    Ok(get_decimal(ctx, 0)?.map(|decimal| without_negative_zero(decimal.floor()).to_string()))
+}
+
+/// decimal_mod function that returns the remainder of dividing two decimals
+fn decimal_mod(ctx: &Context<'_>) -> Result<Option<String>> {
+    match (get_decimal(ctx, 0)?, get_decimal(ctx, 1)?) {
+        (Some(a), Some(b)) => Ok(Some(numeric_mod(a, b)?.to_string())),
+        _ => Ok(None)
+    }
+}
+
+/// decimal_power function that raises a decimal to a power
+fn decimal_power(ctx: &Context<'_>) -> Result<Option<String>> {
+    match (get_decimal(ctx, 0)?, get_decimal(ctx, 1)?) {
+        (Some(a), Some(b)) => Ok(Some(numeric_power(a, b)?.to_string())),
+        _ => Ok(None)
+    }
+}
+
+/// decimal_sqrt function that returns the square root of a decimal
+fn decimal_sqrt(ctx: &Context<'_>) -> Result<Option<String>> {
+    Ok(get_decimal(ctx, 0)?.map(numeric_sqrt).transpose()?.map(|root| root.to_string()))
+}
+
 /// decimal_abs function that returns the absolute value of a decimal
 fn decimal_abs(ctx: &Context<'_>) -> Result<Option<String>> {
     let decimal_opt = get_decimal(ctx, 0)?;
@@ -479,4 +683,66 @@ mod tests {
         Ok(())
     }
 
-}
\ No newline at end of file
+    #[test]
+    fn test_numeric_cast_rounds_to_scale() -> Result<()> {
+        let conn = Connection::open_in_memory()?;
+        register_decimal_functions(&conn)?;
+
+        let result: String = conn.query_row("SELECT numeric_cast(1234.56789, 10, 2)", [], |row| row.get(0))?;
+        assert_eq!(result, "1234.57");
+
+        // Halves round away from zero
+        let result: String = conn.query_row("SELECT numeric_cast('2.345', 10, 2)", [], |row| row.get(0))?;
+        assert_eq!(result, "2.35");
+        let result: String = conn.query_row("SELECT numeric_cast(-2.5, 5, 0)", [], |row| row.get(0))?;
+        assert_eq!(result, "-3");
+
+        let result: String = conn.query_row("SELECT numeric_cast(7, 10, 2)", [], |row| row.get(0))?;
+        assert_eq!(result, "7.00");
+
+        // Rounding up can push the value past the precision
+        assert!(conn.query_row("SELECT numeric_cast('99999999.999', 10, 2)", [], |row| row.get::<_, String>(0)).is_err());
+
+        Ok(())
+    }
+
+    #[test]
+    fn test_round_numeric() {
+        let value = |s: &str| Decimal::from_str(s).unwrap();
+
+        assert_eq!(round_numeric(value("2.5"), 0), "3");
+        assert_eq!(round_numeric(value("-2.5"), 0), "-3");
+        assert_eq!(round_numeric(value("0.125"), 2), "0.13");
+        assert_eq!(round_numeric(value("2.5"), 3), "2.500");
+        assert_eq!(round_numeric(value("1250"), -2), "1300");
+        assert_eq!(round_numeric(value("-1249.99"), -2), "-1200");
+        assert_eq!(round_numeric(value("12345"), -30), "0");
+        
+        assert_eq!(trunc_numeric(value("2.679"), 2), "2.67");
+        assert_eq!(trunc_numeric(value("-0.009"), 2), "0.00");
+        assert_eq!(trunc_numeric(value("1299"), -2), "1200");
+    }
+
+    #[test]
+    fn test_decimal_math_precision() -> Result<()> {
+        let conn = Connection::open_in_memory()?;
+        register_decimal_functions(&conn)?;
+        let text = |sql: &str| conn.query_row(sql, [], |row| row.get::<_, String>(0));
+
+        // Float math would give 0.09999999999999998 and 1.2100000000000002
+        assert_eq!(text("SELECT decimal_mod('0.3', '0.1')")?, "0.0");
+        assert_eq!(text("SELECT decimal_mod('-7.5', '2')")?, "-1.5");
+        assert_eq!(text("SELECT decimal_power('1.1', 2)")?, "1.21");
+        assert_eq!(text("SELECT decimal_power(2, -2)")?, "0.25");
+        assert!(text("SELECT decimal_sqrt('2')")?.starts_with("1.41421356237309504880168872"));
+        assert_eq!(text("SELECT decimal_sqrt('1.44')")?, "1.2");
+        assert_eq!(text("SELECT decimal_ceil('-0.5')")?, "0");
+        assert_eq!(text("SELECT decimal_floor('19.99')")?, "19");
+        assert_eq!(text("SELECT decimal_trunc('19.999', 2)")?, "19.99");
+
+        assert!(text("SELECT decimal_mod(1, 0)").is_err());
+        assert!(text("SELECT decimal_sqrt(-4)").is_err());
+
+        Ok(())
+    }
+}
@@ -0,0 +1,338 @@
+use rusqlite::{Connection, Result, functions::{Context, FunctionFlags}};
+use rusqlite::types::ValueRef;
+use rand::Rng;
+use sha2::{Digest, Sha224, Sha256, Sha384, Sha512};
+use tracing::debug;
+
+/// The most bytes gen_random_bytes() returns at once, as in pgcrypto
+const MAX_RANDOM_BYTES: i64 = 1024;
+
+/// A hash algorithm of pgcrypto's digest() and hmac()
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum Algorithm {
+    Md5,
+    Sha1,
+    Sha224,
+    Sha256,
+    Sha384,
+    Sha512,
+}
+
+impl Algorithm {
+    /// Look up an algorithm by name; pgcrypto matches names case-insensitively
+    fn from_name(name: &str) -> std::result::Result<Self, String> {
+        match name.to_lowercase().as_str() {
+            "md5" => Ok(Self::Md5),
+            "sha1" => Ok(Self::Sha1),
+            "sha224" => Ok(Self::Sha224),
+            "sha256" => Ok(Self::Sha256),
+            "sha384" => Ok(Self::Sha384),
+            "sha512" => Ok(Self::Sha512),
+            _ => Err(format!("Cannot use \"{name}\": No such hash algorithm")),
+        }
+    }
+
+    fn digest(self, data: &[u8]) -> Vec<u8> {
+        match self {
+            Self::Md5 => md5(data).to_vec(),
+            Self::Sha1 => sha1(data).to_vec(),
+            Self::Sha224 => Sha224::digest(data).to_vec(),
+            Self::Sha256 => Sha256::digest(data).to_vec(),
+            Self::Sha384 => Sha384::digest(data).to_vec(),
+            Self::Sha512 => Sha512::digest(data).to_vec(),
+        }
+    }
+
+    /// The input block size, which HMAC pads keys to
+    fn block_size(self) -> usize {
+        match self {
+            Self::Md5 | Self::Sha1 | Self::Sha224 | Self::Sha256 => 64,
+            Self::Sha384 | Self::Sha512 => 128,
+        }
+    }
+
+    /// HMAC as defined by RFC 2104
+    fn hmac(self, data: &[u8], key: &[u8]) -> Vec<u8> {
+        let mut block_key = if key.len() > self.block_size() { self.digest(key) } else { key.to_vec() };
+        block_key.resize(self.block_size(), 0);
+
+        let mut inner: Vec<u8> = block_key.iter().map(|byte| byte ^ 0x36).collect();
+        inner.extend_from_slice(data);
+        let mut outer: Vec<u8> = block_key.iter().map(|byte| byte ^ 0x5c).collect();
+        outer.extend(self.digest(&inner));
+        self.digest(&outer)
+    }
+}
+
+/// Register md5(), PostgreSQL's sha224() to sha512(), and pgcrypto's digest(), hmac() and
+/// gen_random_bytes()
+pub fn register_digest_functions(conn: &Connection) -> Result<()> {
+    debug!("Registering digest functions");
+
+    // md5(data) - the MD5 hash as 32 hex digits
+    conn.create_scalar_function(
+        "md5",
+        1,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| Ok(data_arg(ctx, 0).map(|data| hex::encode(md5(&data)))),
+    )?;
+
+    // sha224(data) .. sha512(data) - the hash as bytea
+    for (name, algorithm) in [
+        ("sha224", Algorithm::Sha224),
+        ("sha256", Algorithm::Sha256),
+        ("sha384", Algorithm::Sha384),
+        ("sha512", Algorithm::Sha512),
+    ] {
+        conn.create_scalar_function(
+            name,
+            1,
+            FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| Ok(data_arg(ctx, 0).map(|data| algorithm.digest(&data))),
+        )?;
+    }
+
+    // digest(data, algorithm) - the hash as bytea
+    conn.create_scalar_function(
+        "digest",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let (Some(data), Some(name)) = (data_arg(ctx, 0), ctx.get::<Option<String>>(1)?) else {
+                return Ok(None);
+            };
+            let algorithm = Algorithm::from_name(&name).map_err(digest_error)?;
+            Ok(Some(algorithm.digest(&data)))
+        },
+    )?;
+
+    // hmac(data, key, algorithm) - the keyed hash as bytea
+    conn.create_scalar_function(
+        "hmac",
+        3,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let (Some(data), Some(key), Some(name)) = (data_arg(ctx, 0), data_arg(ctx, 1), ctx.get::<Option<String>>(2)?) else {
+                return Ok(None);
+            };
+            let algorithm = Algorithm::from_name(&name).map_err(digest_error)?;
+            Ok(Some(algorithm.hmac(&data, &key)))
+        },
+    )?;
+
+    // gen_random_bytes(count) - count random bytes as bytea
+    conn.create_scalar_function(
+        "gen_random_bytes",
+        1,
+        FunctionFlags::SQLITE_UTF8,
+        |ctx| {
+            let Some(count) = ctx.get::<Option<i64>>(0)? else {
+                return Ok(None);
+            };
+            if !(1..=MAX_RANDOM_BYTES).contains(&count) {
+                return Err(digest_error("Length not in range".to_string()));
+            }
+            let mut bytes = vec![0u8; count as usize];
+            rand::rng().fill(&mut bytes[..]);
+            Ok(Some(bytes))
+        },
+    )?;
+
+    debug!("Digest functions registered successfully");
+    Ok(())
+}
+
+/// The bytes to hash: a blob as it is, anything else as its text
+fn data_arg(ctx: &Context<'_>, i: usize) -> Option<Vec<u8>> {
+    match ctx.get_raw(i) {
+        ValueRef::Null => None,
+        ValueRef::Blob(bytes) | ValueRef::Text(bytes) => Some(bytes.to_vec()),
+        ValueRef::Integer(value) => Some(value.to_string().into_bytes()),
+        ValueRef::Real(value) => Some(value.to_string().into_bytes()),
+    }
+}
+
+fn digest_error(message: String) -> rusqlite::Error {
+    rusqlite::Error::UserFunctionError(message.into())
+}
+
+/// Pad a message into 64-byte blocks ending with its bit length, the way MD5 and SHA-1 do
+fn pad_message(data: &[u8], big_endian: bool) -> Vec<u8> {
+    let bit_length = (data.len() as u64).wrapping_mul(8);
+    let mut message = data.to_vec();
+    message.push(0x80);
+    while message.len() % 64 != 56 {
+        message.push(0);
+    }
+    message.extend(if big_endian { bit_length.to_be_bytes() } else { bit_length.to_le_bytes() });
+    message
+}
+
+const MD5_SHIFTS: [u32; 64] = [
+    7, 12, 17, 22, 7, 12, 17, 22, 7, 12, 17, 22, 7, 12, 17, 22,
+    5, 9, 14, 20, 5, 9, 14, 20, 5, 9, 14, 20, 5, 9, 14, 20,
+    4, 11, 16, 23, 4, 11, 16, 23, 4, 11, 16, 23, 4, 11, 16, 23,
+    6, 10, 15, 21, 6, 10, 15, 21, 6, 10, 15, 21, 6, 10, 15, 21,
+];
+
+const MD5_CONSTANTS: [u32; 64] = [
+    0xd76aa478, 0xe8c7b756, 0x242070db, 0xc1bdceee, 0xf57c0faf, 0x4787c62a, 0xa8304613, 0xfd469501,
+    0x698098d8, 0x8b44f7af, 0xffff5bb1, 0x895cd7be, 0x6b901122, 0xfd987193, 0xa679438e, 0x49b40821,
+    0xf61e2562, 0xc040b340, 0x265e5a51, 0xe9b6c7aa, 0xd62f105d, 0x02441453, 0xd8a1e681, 0xe7d3fbc8,
+    0x21e1cde6, 0xc33707d6, 0xf4d50d87, 0x455a14ed, 0xa9e3e905, 0xfcefa3f8, 0x676f02d9, 0x8d2a4c8a,
+    0xfffa3942, 0x8771f681, 0x6d9d6122, 0xfde5380c, 0xa4beea44, 0x4bdecfa9, 0xf6bb4b60, 0xbebfbc70,
+    0x289b7ec6, 0xeaa127fa, 0xd4ef3085, 0x04881d05, 0xd9d4d039, 0xe6db99e5, 0x1fa27cf8, 0xc4ac5665,
+    0xf4292244, 0x432aff97, 0xab9423a7, 0xfc93a039, 0x655b59c3, 0x8f0ccc92, 0xffeff47d, 0x85845dd1,
+    0x6fa87e4f, 0xfe2ce6e0, 0xa3014314, 0x4e0811a1, 0xf7537e82, 0xbd3af235, 0x2ad7d2bb, 0xeb86d391,
+];
+
+/// MD5 as defined by RFC 1321
+fn md5(data: &[u8]) -> [u8; 16] {
+    let mut state: [u32; 4] = [0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476];
+    for block in pad_message(data, false).chunks(64) {
+        let words: Vec<u32> = block.chunks(4).map(|word| u32::from_le_bytes([word[0], word[1], word[2], word[3]])).collect();
+        let [mut a, mut b, mut c, mut d] = state;
+        for i in 0..64 {
+            let (f, g) = match i / 16 {
+                0 => ((b & c) | (!b & d), i),
+                1 => ((d & b) | (!d & c), (5 * i + 1) % 16),
+                2 => (b ^ c ^ d, (3 * i + 5) % 16),
+                _ => (c ^ (b | !d), (7 * i) % 16),
+            };
+            let rotated = a.wrapping_add(f).wrapping_add(MD5_CONSTANTS[i]).wrapping_add(words[g])
+                .rotate_left(MD5_SHIFTS[i]);
+            (a, d, c) = (d, c, b);
+            b = b.wrapping_add(rotated);
+        }
+        for (word, value) in state.iter_mut().zip([a, b, c, d]) {
+            *word = word.wrapping_add(value);
+        }
+    }
+
+    let mut digest = [0u8; 16];
+    for (bytes, word) in digest.chunks_mut(4).zip(state) {
+        bytes.copy_from_slice(&word.to_le_bytes());
+    }
+    digest
+}
+
+/// SHA-1 as defined by RFC 3174
+fn sha1(data: &[u8]) -> [u8; 20] {
+    let mut state: [u32; 5] = [0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476, 0xc3d2e1f0];
+    for block in pad_message(data, true).chunks(64) {
+        let mut words = [0u32; 80];
+        for (i, word) in block.chunks(4).enumerate() {
+            words[i] = u32::from_be_bytes([word[0], word[1], word[2], word[3]]);
+        }
+        for i in 16..80 {
+            words[i] = (words[i - 3] ^ words[i - 8] ^ words[i - 14] ^ words[i - 16]).rotate_left(1);
+        }
+
+        let [mut a, mut b, mut c, mut d, mut e] = state;
+        for (i, word) in words.iter().enumerate() {
+            let (f, k) = match i / 20 {
+                0 => ((b & c) | (!b & d), 0x5a827999),
+                1 => (b ^ c ^ d, 0x6ed9eba1),
+                2 => ((b & c) | (b & d) | (c & d), 0x8f1bbcdc),
+                _ => (b ^ c ^ d, 0xca62c1d6),
+            };
+            let temp = a.rotate_left(5).wrapping_add(f).wrapping_add(e).wrapping_add(k).wrapping_add(*word);
+            (e, d, c, b, a) = (d, c, b.rotate_left(30), a, temp);
+        }
+        for (word, value) in state.iter_mut().zip([a, b, c, d, e]) {
+            *word = word.wrapping_add(value);
+        }
+    }
+
+    let mut digest = [0u8; 20];
+    for (bytes, word) in digest.chunks_mut(4).zip(state) {
+        bytes.copy_from_slice(&word.to_be_bytes());
+    }
+    digest
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn setup() -> Connection {
+        let conn = Connection::open_in_memory().unwrap();
+        register_digest_functions(&conn).unwrap();
+        conn
+    }
+
+    fn hex_query(conn: &Connection, query: &str) -> String {
+        conn.query_row(&format!("SELECT hex({query})"), [], |row| row.get::<_, String>(0)).unwrap().to_lowercase()
+    }
+
+    #[test]
+    fn test_md5() {
+        let conn = setup();
+        let hash: String = conn.query_row("SELECT md5('')", [], |row| row.get(0)).unwrap();
+        assert_eq!(hash, "d41d8cd98f00b204e9800998ecf8427e");
+        let hash: String = conn.query_row("SELECT md5('The quick brown fox jumps over the lazy dog')", [], |row| row.get(0)).unwrap();
+        assert_eq!(hash, "9e107d9d372bb6826bd81d3542a419d6");
+
+        // Inputs spanning several blocks
+        let hash: String = conn.query_row("SELECT md5(?1)", [&"a".repeat(1000)], |row| row.get(0)).unwrap();
+        assert_eq!(hash, "cabe45dcc9ae5b66ba86600cca6b8ba8");
+
+        let hash: Option<String> = conn.query_row("SELECT md5(NULL)", [], |row| row.get(0)).unwrap();
+        assert!(hash.is_none());
+    }
+
+    #[test]
+    fn test_digest() {
+        let conn = setup();
+        assert_eq!(hex_query(&conn, "digest('abc', 'sha1')"), "a9993e364706816aba3e25717850c26c9cd0d89d");
+        assert_eq!(hex_query(&conn, "digest('abc', 'SHA256')"), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad");
+        assert_eq!(hex_query(&conn, "sha256('abc')"), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad");
+        assert_eq!(
+            hex_query(&conn, "digest('abc', 'sha512')"),
+            "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"
+        );
+        assert_eq!(hex_query(&conn, "digest(X'00ff', 'md5')"), hex::encode(md5(&[0x00, 0xff])));
+
+        let err = conn.query_row("SELECT digest('abc', 'whirlpool')", [], |row| row.get::<_, Vec<u8>>(0)).unwrap_err();
+        assert!(err.to_string().contains("Cannot use \"whirlpool\": No such hash algorithm"));
+    }
+
+    #[test]
+    fn test_gen_random_bytes() {
+        let conn = setup();
+        let (first, second): (Vec<u8>, Vec<u8>) = conn.query_row(
+            "SELECT gen_random_bytes(16), gen_random_bytes(16)", [], |row| Ok((row.get(0)?, row.get(1)?))
+        ).unwrap();
+        assert_eq!(first.len(), 16);
+        assert_ne!(first, second);
+
+        let bytes: Vec<u8> = conn.query_row("SELECT gen_random_bytes(1024)", [], |row| row.get(0)).unwrap();
+        assert_eq!(bytes.len(), 1024);
+        for query in ["SELECT gen_random_bytes(0)", "SELECT gen_random_bytes(1025)"] {
+            let err = conn.query_row(query, [], |row| row.get::<_, Vec<u8>>(0)).unwrap_err();
+            assert!(err.to_string().contains("Length not in range"), "{query}");
+        }
+    }
+
+    #[test]
+    fn test_hmac() {
+        let conn = setup();
+        let message = "The quick brown fox jumps over the lazy dog";
+        assert_eq!(
+            hex_query(&conn, &format!("hmac('{message}', 'key', 'md5')")),
+            "80070713463e7749b90c2dc24911e275"
+        );
+        assert_eq!(
+            hex_query(&conn, &format!("hmac('{message}', 'key', 'sha1')")),
+            "de7c9b85b8b78aa6bc8a7a36f70a90701c9db4d9"
+        );
+        assert_eq!(
+            hex_query(&conn, &format!("hmac('{message}', 'key', 'sha256')")),
+            "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+        );
+
+        // Keys longer than a block are hashed first
+        let long_key = "k".repeat(200);
+        assert_eq!(Algorithm::Sha1.hmac(b"data", long_key.as_bytes()), Algorithm::Sha1.hmac(b"data", &sha1(long_key.as_bytes())));
+    }
+}
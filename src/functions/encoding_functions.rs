@@ -0,0 +1,272 @@
+use rusqlite::{Connection, Result, functions::{Context, FunctionFlags}};
+use rusqlite::types::ValueRef;
+use tracing::debug;
+
+const BASE64_ALPHABET: &[u8; 64] = b"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/";
+
+/// PostgreSQL breaks base64 output into lines of this many characters
+const BASE64_LINE_LENGTH: usize = 76;
+
+/// Register encode(bytea, format) and decode(text, format) for the hex, base64 and escape
+/// formats. Binary data is stored as BLOBs, so decode() returns a BLOB.
+pub fn register_encoding_functions(conn: &Connection) -> Result<()> {
+    debug!("Registering encoding functions");
+
+    // encode(data, format) - the textual representation of binary data
+    conn.create_scalar_function(
+        "encode",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            if matches!(ctx.get_raw(0), ValueRef::Null) || matches!(ctx.get_raw(1), ValueRef::Null) {
+                return Ok(None);
+            }
+            let data = bytea_arg(ctx, 0)?;
+            let encoded = match format_arg(ctx)?.as_str() {
+                "hex" => hex::encode(&data),
+                "base64" => encode_base64(&data),
+                "escape" => encode_escape(&data),
+                format => return Err(unrecognized_encoding(format)),
+            };
+            Ok(Some(encoded))
+        },
+    )?;
+
+    // decode(text, format) - binary data from its textual representation
+    conn.create_scalar_function(
+        "decode",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let (Some(text), Some(_)) = (ctx.get::<Option<String>>(0)?, ctx.get::<Option<String>>(1)?) else {
+                return Ok(None);
+            };
+            let decoded = match format_arg(ctx)?.as_str() {
+                "hex" => decode_hex(&text),
+                "base64" => decode_base64(&text),
+                "escape" => decode_escape(&text),
+                format => return Err(unrecognized_encoding(format)),
+            };
+            decoded.map(Some).map_err(encoding_error)
+        },
+    )?;
+
+    debug!("Encoding functions registered successfully");
+    Ok(())
+}
+
+/// A bytea argument: a blob, or text in bytea's input syntax, `\x...` hex or escape format
+fn bytea_arg(ctx: &Context<'_>, i: usize) -> Result<Vec<u8>> {
+    match ctx.get_raw(i) {
+        ValueRef::Blob(bytes) => Ok(bytes.to_vec()),
+        ValueRef::Text(text) => {
+            let text = String::from_utf8_lossy(text);
+            match text.strip_prefix("\\x") {
+                Some(digits) => decode_hex(digits),
+                None => decode_escape(&text),
+            }.map_err(encoding_error)
+        }
+        ValueRef::Integer(value) => Ok(value.to_string().into_bytes()),
+        ValueRef::Real(value) => Ok(value.to_string().into_bytes()),
+        ValueRef::Null => Ok(Vec::new()),
+    }
+}
+
+/// The format argument; PostgreSQL matches format names case-insensitively
+fn format_arg(ctx: &Context<'_>) -> Result<String> {
+    Ok(ctx.get::<String>(1)?.to_lowercase())
+}
+
+fn encoding_error(message: String) -> rusqlite::Error {
+    rusqlite::Error::UserFunctionError(message.into())
+}
+
+fn unrecognized_encoding(format: &str) -> rusqlite::Error {
+    encoding_error(format!("unrecognized encoding: \"{format}\""))
+}
+
+/// Encode base64 the way PostgreSQL does, with a line break after every 76 characters
+/// of complete groups
+fn encode_base64(data: &[u8]) -> String {
+    let mut encoded = String::with_capacity(data.len().div_ceil(3) * 4);
+    let mut line_length = 0;
+    for chunk in data.chunks(3) {
+        let buf = chunk.iter().fold(0u32, |buf, &byte| buf << 8 | byte as u32) << (8 * (3 - chunk.len()));
+        for i in 0..4 {
+            if i <= chunk.len() {
+                encoded.push(BASE64_ALPHABET[(buf >> (18 - 6 * i) & 0x3f) as usize] as char);
+            } else {
+                encoded.push('=');
+            }
+        }
+        line_length += 4;
+        if chunk.len() == 3 && line_length >= BASE64_LINE_LENGTH {
+            encoded.push('\n');
+            line_length = 0;
+        }
+    }
+    encoded
+}
+
+/// Decode base64 the way PostgreSQL does: whitespace is skipped, and `=` may only pad
+/// the last group
+fn decode_base64(text: &str) -> std::result::Result<Vec<u8>, String> {
+    let mut decoded = Vec::with_capacity(text.len() / 4 * 3);
+    let (mut buf, mut pos, mut end) = (0u32, 0, 0);
+    for c in text.chars() {
+        if matches!(c, ' ' | '\t' | '\n' | '\r') {
+            continue;
+        }
+        let bits = if c == '=' {
+            if end == 0 {
+                end = match pos {
+                    2 => 1,
+                    3 => 2,
+                    _ => return Err("unexpected \"=\" while decoding base64 sequence".to_string()),
+                };
+            }
+            0
+        } else {
+            BASE64_ALPHABET.iter().position(|&symbol| symbol as char == c)
+                .ok_or_else(|| format!("invalid symbol \"{c}\" found while decoding base64 sequence"))? as u32
+        };
+        buf = buf << 6 | bits;
+        pos += 1;
+        if pos == 4 {
+            decoded.push((buf >> 16) as u8);
+            if end == 0 || end > 1 {
+                decoded.push((buf >> 8) as u8);
+            }
+            if end == 0 || end > 2 {
+                decoded.push(buf as u8);
+            }
+            buf = 0;
+            pos = 0;
+        }
+    }
+    if pos != 0 {
+        return Err("invalid base64 end sequence".to_string());
+    }
+    Ok(decoded)
+}
+
+/// Decode hex digit pairs, skipping whitespace between them
+fn decode_hex(text: &str) -> std::result::Result<Vec<u8>, String> {
+    let mut decoded = Vec::with_capacity(text.len() / 2);
+    let mut chars = text.chars().filter(|c| !matches!(c, ' ' | '\t' | '\n' | '\r'));
+    while let Some(high) = chars.next() {
+        let high = high.to_digit(16).ok_or_else(|| format!("invalid hexadecimal digit: \"{high}\""))?;
+        let low = chars.next().ok_or("invalid hexadecimal data: odd number of digits")?;
+        let low = low.to_digit(16).ok_or_else(|| format!("invalid hexadecimal digit: \"{low}\""))?;
+        decoded.push((high << 4 | low) as u8);
+    }
+    Ok(decoded)
+}
+
+/// The escape format: zero bytes and bytes with the high bit set become `\nnn` octal,
+/// backslashes are doubled
+fn encode_escape(data: &[u8]) -> String {
+    let mut encoded = String::with_capacity(data.len());
+    for &byte in data {
+        match byte {
+            b'\\' => encoded.push_str("\\\\"),
+            0 | 0x80.. => encoded.push_str(&format!("\\{byte:03o}")),
+            _ => encoded.push(byte as char),
+        }
+    }
+    encoded
+}
+
+fn decode_escape(text: &str) -> std::result::Result<Vec<u8>, String> {
+    let bytes = text.as_bytes();
+    let mut decoded = Vec::with_capacity(bytes.len());
+    let mut i = 0;
+    while i < bytes.len() {
+        if bytes[i] != b'\\' {
+            decoded.push(bytes[i]);
+            i += 1;
+        } else if bytes.get(i + 1) == Some(&b'\\') {
+            decoded.push(b'\\');
+            i += 2;
+        } else if let [b'0'..=b'3', b'0'..=b'7', b'0'..=b'7'] = bytes.get(i + 1..i + 4).unwrap_or_default() {
+            decoded.push((bytes[i + 1] - b'0') << 6 | (bytes[i + 2] - b'0') << 3 | (bytes[i + 3] - b'0'));
+            i += 4;
+        } else {
+            return Err("invalid input syntax for type bytea".to_string());
+        }
+    }
+    Ok(decoded)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn setup() -> Connection {
+        let conn = Connection::open_in_memory().unwrap();
+        register_encoding_functions(&conn).unwrap();
+        conn
+    }
+
+    #[test]
+    fn test_base64_round_trip() {
+        let conn = setup();
+        for len in 0..8 {
+            let data: Vec<u8> = (0..len).map(|i| (i * 97 + 200) as u8).collect();
+            let decoded: Vec<u8> = conn.query_row(
+                "SELECT decode(encode(?1, 'base64'), 'base64')", [&data], |row| row.get(0)
+            ).unwrap();
+            assert_eq!(decoded, data);
+        }
+
+        let encoded: String = conn.query_row("SELECT encode(CAST('hello' AS BLOB), 'BASE64')", [], |row| row.get(0)).unwrap();
+        assert_eq!(encoded, "aGVsbG8=");
+
+        // Long output is wrapped at 76 characters, and decoding skips the line breaks
+        let data = vec![0xffu8; 60];
+        let encoded: String = conn.query_row("SELECT encode(?1, 'base64')", [&data], |row| row.get(0)).unwrap();
+        assert_eq!(encoded.lines().map(str::len).collect::<Vec<_>>(), vec![76, 4]);
+        let decoded: Vec<u8> = conn.query_row("SELECT decode(?1, 'base64')", [&encoded], |row| row.get(0)).unwrap();
+        assert_eq!(decoded, data);
+    }
+
+    #[test]
+    fn test_hex_and_escape() {
+        let conn = setup();
+        let encoded: String = conn.query_row("SELECT encode(X'00ff10', 'hex')", [], |row| row.get(0)).unwrap();
+        assert_eq!(encoded, "00ff10");
+        let decoded: Vec<u8> = conn.query_row("SELECT decode('00FF 10', 'hex')", [], |row| row.get(0)).unwrap();
+        assert_eq!(decoded, vec![0x00, 0xff, 0x10]);
+
+        // Text arguments use bytea's input syntax
+        let encoded: String = conn.query_row("SELECT encode('\\x6869', 'escape')", [], |row| row.get(0)).unwrap();
+        assert_eq!(encoded, "hi");
+
+        let encoded: String = conn.query_row("SELECT encode(X'610062ff5c', 'escape')", [], |row| row.get(0)).unwrap();
+        assert_eq!(encoded, "a\\000b\\377\\\\");
+        let decoded: Vec<u8> = conn.query_row("SELECT decode(?1, 'escape')", [&encoded], |row| row.get(0)).unwrap();
+        assert_eq!(decoded, vec![b'a', 0, b'b', 0xff, b'\\']);
+    }
+
+    #[test]
+    fn test_nulls_and_errors() {
+        let conn = setup();
+        let result: Option<String> = conn.query_row("SELECT encode(NULL, 'hex')", [], |row| row.get(0)).unwrap();
+        assert!(result.is_none());
+        let result: Option<Vec<u8>> = conn.query_row("SELECT decode('00', NULL)", [], |row| row.get(0)).unwrap();
+        assert!(result.is_none());
+
+        for (query, message) in [
+            ("SELECT decode('aGk!', 'base64')", "invalid symbol \"!\" found while decoding base64 sequence"),
+            ("SELECT decode('aGVsbG8', 'base64')", "invalid base64 end sequence"),
+            ("SELECT decode('a===', 'base64')", "unexpected \"=\" while decoding base64 sequence"),
+            ("SELECT decode('abc', 'hex')", "invalid hexadecimal data: odd number of digits"),
+            ("SELECT decode('zz', 'hex')", "invalid hexadecimal digit: \"z\""),
+            ("SELECT decode('\\9', 'escape')", "invalid input syntax for type bytea"),
+            ("SELECT encode(X'00', 'base32')", "unrecognized encoding: \"base32\""),
+        ] {
+            let err = conn.query_row(query, [], |row| row.get::<_, Option<Vec<u8>>>(0)).unwrap_err();
+            assert!(err.to_string().contains(message), "{query}: {err}");
+        }
+    }
+}
@@ -1,142 +1,218 @@
 use rusqlite::{Connection, Result};
-use serde_json::json;
+use rusqlite::functions::Context;
+use serde_json::{json, Value};
+use std::collections::{BTreeMap, HashSet};
+use super::text_search_config::TextSearchConfig;
+
+/// Lexemes of a tsvector with the positions and weight labels of their occurrences
+type Lexemes = BTreeMap<String, Vec<(u64, char)>>;
+
+/// A position of the document matching one of the query terms
+struct Occurrence {
+    position: u64,
+    weight: f64,
+    term: usize,
+}
+
+/// The configuration given as the first of two arguments, english when it is left out like
+/// PostgreSQL's default_text_search_config
+fn config_arg(ctx: &Context, arity: i32) -> Result<TextSearchConfig> {
+    if arity == 1 {
+        return Ok(TextSearchConfig::English);
+    }
+    TextSearchConfig::from_name(&ctx.get::<String>(0)?)
+        .map_err(|e| rusqlite::Error::UserFunctionError(e.into()))
+}
 
 /// Register PostgreSQL Full-Text Search functions with SQLite
 pub fn register_fts_functions(conn: &Connection) -> Result<()> {
-    // Register to_tsvector function
-    conn.create_scalar_function(
-        "to_tsvector",
-        2, // config_name, text
-        rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let config = ctx.get::<String>(0).unwrap_or_else(|_| "english".to_string());
-            let text = ctx.get::<String>(1)?;
-            
-            // Simple tokenization - split by whitespace and create JSON metadata
-            let tokens: Vec<&str> = text.split_whitespace().collect();
-            let mut lexemes = serde_json::Map::new();
-            
-            for (pos, token) in tokens.iter().enumerate() {
-                let token_clean = token.to_lowercase()
-                    .trim_matches(|c: char| !c.is_alphabetic())
-                    .to_string();
+    for arity in [1, 2] {
+        // Register to_tsvector function: [config,] text
+        conn.create_scalar_function(
+            "to_tsvector",
+            arity,
+            rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| {
+                let config = config_arg(ctx, arity)?;
+                let text = ctx.get::<String>(arity as usize - 1)?;
                 
-                if !token_clean.is_empty() {
-                    lexemes.insert(token_clean, json!({
-                        "pos": [pos + 1],
-                        "weight": "D"
-                    }));
+                // Simple tokenization - split by whitespace and create JSON metadata. Stop words
+                // are dropped but keep their position.
+                let tokens: Vec<&str> = text.split_whitespace().collect();
+                let mut lexemes = serde_json::Map::new();
+                
+                for (pos, token) in tokens.iter().enumerate() {
+                    let token_clean = token.trim_matches(|c: char| !c.is_alphabetic());
+                    
+                    if let Some(lexeme) = config.lexize(token_clean).filter(|lexeme| !lexeme.is_empty()) {
+                        let entry = lexemes.entry(lexeme).or_insert_with(|| json!({
+                            "pos": [],
+                            "weight": "D"
+                        }));
+                        if let Some(positions) = entry["pos"].as_array_mut() {
+                            positions.push(json!(pos + 1));
+                        }
+                    }
                 }
-            }
-            
-            // Return JSON metadata for tsvector
-            let result = json!({
-                "fts_ref": "__pgsqlite_fts_table_column", // Will be replaced by actual table/column
-                "config": config,
-                "lexemes": lexemes
-            });
-            
-            Ok(result.to_string())
-        },
-    )?;
-    
-    // Register to_tsquery function
-    conn.create_scalar_function(
-        "to_tsquery",
-        2, // config_name, query_text
-        rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let _config = ctx.get::<String>(0).unwrap_or_else(|_| "english".to_string());
-            let query_text = ctx.get::<String>(1)?;
-            
-            // Convert PostgreSQL tsquery syntax to FTS5 MATCH syntax
-            let fts5_query = query_text
-                .replace(" & ", " AND ")
-                .replace("&", " AND ")
-                .replace(" | ", " OR ")
-                .replace("|", " OR ")
-                .replace("!", "NOT ")
-                .replace(":*", "*");
-            
-            Ok(fts5_query)
-        },
-    )?;
+                
+                // Return JSON metadata for tsvector
+                let result = json!({
+                    "fts_ref": "__pgsqlite_fts_table_column", // Will be replaced by actual table/column
+                    "config": config.name(),
+                    "lexemes": lexemes
+                });
+                
+                Ok(result.to_string())
+            },
+        )?;
+        
+        // Register to_tsquery function: [config,] query_text
+        conn.create_scalar_function(
+            "to_tsquery",
+            arity,
+            rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| {
+                let config = config_arg(ctx, arity)?;
+                let query_text = ctx.get::<String>(arity as usize - 1)?;
+                
+                Ok(to_tsquery(config, &query_text))
+            },
+        )?;
+        
+        // Register plainto_tsquery function: [config,] text
+        conn.create_scalar_function(
+            "plainto_tsquery",
+            arity,
+            rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| {
+                let config = config_arg(ctx, arity)?;
+                let text = ctx.get::<String>(arity as usize - 1)?;
+                
+                // Convert plain text to AND-separated terms
+                Ok(lexize_words(config, &text).join(" AND "))
+            },
+        )?;
+        
+        // Register phraseto_tsquery function: [config,] text
+        conn.create_scalar_function(
+            "phraseto_tsquery",
+            arity,
+            rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| {
+                let config = config_arg(ctx, arity)?;
+                let text = ctx.get::<String>(arity as usize - 1)?;
+                
+                // Return quoted phrase for exact match
+                Ok(format!("\"{}\"", lexize_words(config, &text).join(" ")))
+            },
+        )?;
+        
+        // Register websearch_to_tsquery function: [config,] text
+        conn.create_scalar_function(
+            "websearch_to_tsquery",
+            arity,
+            rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| {
+                let config = config_arg(ctx, arity)?;
+                let text = ctx.get::<String>(arity as usize - 1)?;
+                Ok(websearch_to_tsquery(config, &text))
+            },
+        )?;
+    }
     
-    // Register plainto_tsquery function
+    // Register setweight function
     conn.create_scalar_function(
-        "plainto_tsquery",
-        2, // config_name, text
+        "setweight",
+        2, // tsvector, weight
         rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let _config = ctx.get::<String>(0).unwrap_or_else(|_| "english".to_string());
-            let text = ctx.get::<String>(1)?;
-            
-            // Convert plain text to AND-separated terms
-            let terms: Vec<&str> = text.split_whitespace().collect();
-            Ok(terms.join(" AND "))
+            let tsvector = ctx.get::<Option<String>>(0)?;
+            let weight = ctx.get::<String>(1)?.trim().to_uppercase();
+            if !matches!(weight.as_str(), "A" | "B" | "C" | "D") {
+                return Err(rusqlite::Error::UserFunctionError(format!("unrecognized weight: \"{weight}\"").into()));
+            }
+            Ok(tsvector.map(|tsvector| set_weight(&tsvector, &weight)))
         },
     )?;
     
-    // Register phraseto_tsquery function
-    conn.create_scalar_function(
-        "phraseto_tsquery",
-        2, // config_name, text
-        rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let _config = ctx.get::<String>(0).unwrap_or_else(|_| "english".to_string());
-            let text = ctx.get::<String>(1)?;
-            
-            // Return quoted phrase for exact match
-            Ok(format!("\"{text}\""))
-        },
-    )?;
+    // Register ts_rank and ts_rank_cd, with and without the normalization argument
+    let rank_functions: [(&str, fn(&str, &str, i64) -> f64); 2] = [("ts_rank", rank), ("ts_rank_cd", cover_density_rank)];
+    for (name, rank_function) in rank_functions {
+        for arity in [2, 3] {
+            conn.create_scalar_function(
+                name,
+                arity, // tsvector, tsquery [, normalization]
+                rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
+                move |ctx| {
+                    let tsvector = ctx.get::<Option<String>>(0)?;
+                    let tsquery = ctx.get::<Option<String>>(1)?;
+                    let normalization = if arity == 3 { ctx.get::<i64>(2)? } else { 0 };
+                    Ok(tsvector.zip(tsquery).map(|(tsvector, tsquery)| rank_function(&tsvector, &tsquery, normalization)))
+                },
+            )?;
+        }
+    }
     
-    // Register websearch_to_tsquery function
-    conn.create_scalar_function(
-        "websearch_to_tsquery",
-        2, // config_name, text
-        rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let _config = ctx.get::<String>(0).unwrap_or_else(|_| "english".to_string());
-            let text = ctx.get::<String>(1)?;
-            
-            // Simple web search syntax conversion (can be enhanced)
-            let processed = text
-                .replace(" OR ", " | ")
-                .replace(" AND ", " & ")
-                .replace("-", "!");
-            
-            Ok(processed)
-        },
-    )?;
+    // Register ts_headline function: [config,] document, query [, options]
+    for arity in [2, 3, 4] {
+        conn.create_scalar_function(
+            "ts_headline",
+            arity,
+            rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| {
+                let args = (0..arity as usize)
+                    .map(|i| ctx.get::<Option<String>>(i))
+                    .collect::<Result<Vec<_>>>()?;
+                // With three arguments the last one is either the query or the options
+                let (config, document, tsquery, options) = match args.as_slice() {
+                    [document, tsquery] => (None, document, tsquery, None),
+                    [document, tsquery, options] if options.as_deref().is_some_and(|o| o.contains('=')) => (None, document, tsquery, options.as_deref()),
+                    [config, document, tsquery] => (config.as_deref(), document, tsquery, None),
+                    [config, document, tsquery, options] => (config.as_deref(), document, tsquery, options.as_deref()),
+                    _ => unreachable!(),
+                };
+                let config = match config {
+                    Some(name) => TextSearchConfig::from_name(name)
+                        .map_err(|e| rusqlite::Error::UserFunctionError(e.into()))?,
+                    None => TextSearchConfig::English,
+                };
+                let (Some(document), Some(tsquery)) = (document, tsquery) else {
+                    return Ok(None);
+                };
+                let options = HeadlineOptions::parse(options.unwrap_or(""))
+                    .map_err(|e| rusqlite::Error::UserFunctionError(e.into()))?;
+                Ok(Some(headline(document, tsquery, config, &options)))
+            },
+        )?;
+    }
     
-    // Register ts_rank function (simplified version)
+    // Register pgsqlite_ts_match function - tsvector @@ tsquery
     conn.create_scalar_function(
-        "ts_rank",
+        "pgsqlite_ts_match",
         2, // tsvector, tsquery
         rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let _tsvector = ctx.get::<String>(0)?;
-            let _tsquery = ctx.get::<String>(1)?;
-            
-            // Simple rank calculation (could be enhanced)
-            // For now, return a default rank of 0.1
-            Ok(0.1_f64)
+            let tsvector = ctx.get::<Option<String>>(0)?;
+            let tsquery = ctx.get::<Option<String>>(1)?;
+            Ok(tsvector.zip(tsquery).map(|(tsvector, tsquery)| ts_match(&tsvector, &tsquery)))
         },
     )?;
     
-    // Register ts_rank_cd function (simplified version)
+    // Register pgsqlite_text_to_tsquery function - text on the query side of @@
     conn.create_scalar_function(
-        "ts_rank_cd",
-        2, // tsvector, tsquery
+        "pgsqlite_text_to_tsquery",
+        1, // text
         rusqlite::functions::FunctionFlags::SQLITE_UTF8 | rusqlite::functions::FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let _tsvector = ctx.get::<String>(0)?;
-            let _tsquery = ctx.get::<String>(1)?;
-            
-            // Simple cover density rank calculation (could be enhanced)
-            // For now, return a default rank of 0.05
-            Ok(0.05_f64)
+            let text = ctx.get::<Option<String>>(0)?;
+            Ok(text.map(|text| {
+                // Text with tsquery operators is a tsquery, anything else a list of words
+                if text.contains(|c: char| "&|!()<:".contains(c)) {
+                    to_tsquery(TextSearchConfig::English, &text)
+                } else {
+                    lexize_words(TextSearchConfig::English, &text).join(" AND ")
+                }
+            }))
         },
     )?;
     
@@ -171,4 +247,664 @@ pub fn register_fts_functions(conn: &Connection) -> Result<()> {
     )?;
     
     Ok(())
-}
\ No newline at end of file
+}
+
+/// to_tsquery: the lexized query converted to FTS5 MATCH syntax
+fn to_tsquery(config: TextSearchConfig, query_text: &str) -> String {
+    lexize_tsquery(config, query_text)
+        .replace(" & ", " AND ")
+        .replace("&", " AND ")
+        .replace(" | ", " OR ")
+        .replace("|", " OR ")
+        .replace("!", "NOT ")
+        .replace(":*", "*")
+}
+
+/// The lexemes of the words of a text, without stop words
+pub fn lexize_words(config: TextSearchConfig, text: &str) -> Vec<String> {
+    text.split(|c: char| !c.is_alphanumeric() && c != '_')
+        .filter(|word| !word.is_empty())
+        .filter_map(|word| config.lexize(word))
+        .collect()
+}
+
+/// Replace the words of a tsquery by their lexemes. Stop words are removed together with the
+/// operators they leave without an operand, so 'the & cat' becomes 'cat' as in PostgreSQL.
+pub fn lexize_tsquery(config: TextSearchConfig, query: &str) -> String {
+    let is_binary = |token: &str| token == "&" || token == "|" || token.starts_with('<');
+    let is_operand = |token: &str| token.starts_with(|c: char| c.is_alphanumeric() || c == '_');
+    let follows_operand = |tokens: &Vec<String>| tokens.last().is_some_and(|last| last == ")" || is_operand(last));
+    let mut tokens: Vec<String> = Vec::new();
+    let mut chars = query.chars().peekable();
+    while let Some(c) = chars.next() {
+        if c.is_alphanumeric() || c == '_' {
+            let mut word = c.to_string();
+            while let Some(&c) = chars.peek().filter(|c| c.is_alphanumeric() || **c == '_') {
+                word.push(c);
+                chars.next();
+            }
+            // Weight labels and the prefix marker stay with the word
+            let mut label = String::new();
+            if chars.peek() == Some(&':') {
+                while let Some(&c) = chars.peek().filter(|c| **c == ':' || **c == '*' || "ABCDabcd".contains(**c)) {
+                    label.push(c);
+                    chars.next();
+                }
+            }
+            match config.lexize(&word) {
+                Some(lexeme) => {
+                    // Words next to each other are all required
+                    if follows_operand(&tokens) {
+                        tokens.push("&".to_string());
+                    }
+                    tokens.push(format!("{lexeme}{label}"));
+                }
+                None => {
+                    if tokens.last().is_some_and(|last| last == "!") {
+                        tokens.pop();
+                    }
+                }
+            }
+            continue;
+        }
+        let token = match c {
+            c if c.is_whitespace() => continue,
+            '<' => {
+                let mut distance = c.to_string();
+                for c in chars.by_ref() {
+                    distance.push(c);
+                    if c == '>' {
+                        break;
+                    }
+                }
+                distance
+            }
+            c => c.to_string(),
+        };
+        if is_binary(&token) {
+            if follows_operand(&tokens) {
+                tokens.push(token);
+            }
+        } else if token == ")" {
+            while tokens.last().is_some_and(|last| is_binary(last) || last == "!") {
+                tokens.pop();
+            }
+            if tokens.last().is_some_and(|last| last == "(") {
+                tokens.pop();
+            } else {
+                tokens.push(token);
+            }
+        } else {
+            if (token == "!" || token == "(") && follows_operand(&tokens) {
+                tokens.push("&".to_string());
+            }
+            tokens.push(token);
+        }
+    }
+    while tokens.last().is_some_and(|last| is_binary(last) || last == "!" || last == "(") {
+        tokens.pop();
+    }
+
+    let mut result = String::new();
+    for token in tokens {
+        if is_binary(&token) {
+            result.push_str(&format!(" {token} "));
+        } else {
+            result.push_str(&token);
+        }
+    }
+    result
+}
+
+/// websearch_to_tsquery: OR, AND and -word in tsquery syntax
+pub fn websearch_to_tsquery(config: TextSearchConfig, text: &str) -> String {
+    // Simple web search syntax conversion (can be enhanced)
+    let processed = text
+        .replace(" OR ", " | ")
+        .replace(" AND ", " & ")
+        .replace("-", "!");
+    lexize_tsquery(config, &processed)
+}
+
+/// Label weights, PostgreSQL's default {D, C, B, A} = {0.1, 0.2, 0.4, 1.0}
+fn weight_value(label: char) -> f64 {
+    match label {
+        'A' => 1.0,
+        'B' => 0.4,
+        'C' => 0.2,
+        _ => 0.1,
+    }
+}
+
+/// Read the lexemes of a tsvector value. Concatenated vectors (`a || b` gives adjacent JSON
+/// objects) are merged, shifting the positions of each vector past the previous one like
+/// PostgreSQL does.
+fn parse_tsvector(value: &str) -> Lexemes {
+    let mut lexemes = Lexemes::new();
+    let mut offset = 0;
+    for vector in serde_json::Deserializer::from_str(value).into_iter::<Value>().map_while(|v| v.ok()) {
+        let mut last_position = 0;
+        for (lexeme, entry) in vector.get("lexemes").and_then(Value::as_object).into_iter().flatten() {
+            let weight = entry.get("weight").and_then(Value::as_str).and_then(|w| w.chars().next()).unwrap_or('D');
+            let positions = lexemes.entry(lexeme.clone()).or_default();
+            for position in entry.get("pos").and_then(Value::as_array).into_iter().flatten().filter_map(Value::as_u64) {
+                positions.push((position + offset, weight));
+                last_position = last_position.max(position);
+            }
+        }
+        offset += last_position;
+    }
+    for positions in lexemes.values_mut() {
+        positions.sort_unstable();
+    }
+    lexemes
+}
+
+/// setweight: label every lexeme of the vector with the given weight
+fn set_weight(tsvector: &str, weight: &str) -> String {
+    let mut result = match serde_json::Deserializer::from_str(tsvector).into_iter::<Value>().next() {
+        Some(Ok(Value::Object(vector))) => vector,
+        _ => serde_json::Map::new(),
+    };
+    let lexemes = parse_tsvector(tsvector).into_iter()
+        .map(|(lexeme, positions)| {
+            let positions: Vec<u64> = positions.iter().map(|(position, _)| *position).collect();
+            (lexeme, json!({ "pos": positions, "weight": weight }))
+        })
+        .collect();
+    result.insert("lexemes".to_string(), Value::Object(lexemes));
+    Value::Object(result).to_string()
+}
+
+/// Terms of a query, either as produced by to_tsquery (FTS5 syntax) or written in tsquery
+/// syntax. Negated terms are left out and prefix terms keep a trailing `*`.
+fn query_terms(tsquery: &str) -> Vec<String> {
+    let mut terms = Vec::new();
+    let mut negated = false;
+    for token in tsquery.split(|c: char| c.is_whitespace() || "&|()\"".contains(c)).filter(|t| !t.is_empty()) {
+        match token {
+            "AND" | "OR" => continue,
+            "NOT" | "!" => {
+                negated = true;
+                continue;
+            }
+            _ => {}
+        }
+        let (token, negate) = match token.strip_prefix('!') {
+            Some(rest) => (rest, true),
+            None => (token, negated),
+        };
+        negated = false;
+        let (word, label) = token.split_once(':').unwrap_or((token, ""));
+        let prefix = word.ends_with('*') || label.contains('*');
+        let word = word.to_lowercase().trim_matches(|c: char| !c.is_alphabetic()).to_string();
+        if negate || word.is_empty() {
+            continue;
+        }
+        let term = if prefix { format!("{word}*") } else { word };
+        if !terms.contains(&term) {
+            terms.push(term);
+        }
+    }
+    terms
+}
+
+/// Whether a lexeme matches a query term, prefix terms ending in `*`
+fn term_matches(term: &str, lexeme: &str) -> bool {
+    match term.strip_suffix('*') {
+        Some(prefix) => lexeme.starts_with(prefix),
+        None => lexeme == term,
+    }
+}
+
+/// A token of a tsquery evaluated by ts_match
+#[derive(Debug, PartialEq)]
+enum MatchToken {
+    And,
+    Or,
+    Not,
+    Open,
+    Close,
+    Term(String),
+    Phrase(Vec<String>),
+}
+
+fn match_tokens(tsquery: &str) -> Vec<MatchToken> {
+    let mut tokens = Vec::new();
+    let mut chars = tsquery.chars().peekable();
+    while let Some(c) = chars.next() {
+        let token = match c {
+            c if c.is_whitespace() => continue,
+            '(' => MatchToken::Open,
+            ')' => MatchToken::Close,
+            '&' => MatchToken::And,
+            '|' => MatchToken::Or,
+            '!' => MatchToken::Not,
+            '"' => {
+                let phrase: String = chars.by_ref().take_while(|&c| c != '"').collect();
+                MatchToken::Phrase(phrase.split(|c: char| !c.is_alphanumeric() && c != '_')
+                    .filter(|word| !word.is_empty())
+                    .map(str::to_lowercase)
+                    .collect())
+            }
+            c => {
+                let mut word = c.to_string();
+                while let Some(&c) = chars.peek().filter(|c| !c.is_whitespace() && !"()&|!\"".contains(**c)) {
+                    word.push(c);
+                    chars.next();
+                }
+                match word.as_str() {
+                    "AND" => MatchToken::And,
+                    "OR" => MatchToken::Or,
+                    "NOT" => MatchToken::Not,
+                    // Phrase distance operators only require both sides
+                    w if w.starts_with('<') && w.ends_with('>') => MatchToken::And,
+                    _ => MatchToken::Term(word),
+                }
+            }
+        };
+        tokens.push(token);
+    }
+    tokens
+}
+
+/// Recursive descent over the tokens of a tsquery: NOT binds tighter than AND, which binds
+/// tighter than OR, and terms next to each other are ANDed
+struct MatchParser<'a> {
+    tokens: &'a [MatchToken],
+    next: usize,
+    lexemes: &'a Lexemes,
+}
+
+impl MatchParser<'_> {
+    fn peek(&self) -> Option<&MatchToken> {
+        self.tokens.get(self.next)
+    }
+
+    fn or(&mut self) -> bool {
+        let mut matched = self.and();
+        while self.peek() == Some(&MatchToken::Or) {
+            self.next += 1;
+            matched |= self.and();
+        }
+        matched
+    }
+
+    fn and(&mut self) -> bool {
+        let mut matched = self.not();
+        loop {
+            match self.peek() {
+                Some(MatchToken::And) => self.next += 1,
+                Some(MatchToken::Not | MatchToken::Open | MatchToken::Term(_) | MatchToken::Phrase(_)) => {}
+                _ => return matched,
+            }
+            matched &= self.not();
+        }
+    }
+
+    fn not(&mut self) -> bool {
+        if self.peek() == Some(&MatchToken::Not) {
+            self.next += 1;
+            return !self.not();
+        }
+        self.primary()
+    }
+
+    fn primary(&mut self) -> bool {
+        let tokens = self.tokens;
+        let Some(token) = tokens.get(self.next) else {
+            return false;
+        };
+        self.next += 1;
+        match token {
+            MatchToken::Open => {
+                let matched = self.or();
+                if self.peek() == Some(&MatchToken::Close) {
+                    self.next += 1;
+                }
+                matched
+            }
+            MatchToken::Term(term) => self.term(term),
+            MatchToken::Phrase(words) => self.phrase(words),
+            _ => false,
+        }
+    }
+
+    /// A lexeme, `word*` or `word:*` for a prefix and `word:AB` for a lexeme with one of the
+    /// weights
+    fn term(&self, term: &str) -> bool {
+        let (word, label) = term.split_once(':').unwrap_or((term, ""));
+        let prefix = word.ends_with('*') || label.contains('*');
+        let word = word.trim_end_matches('*').to_lowercase();
+        let weights: Vec<char> = label.chars().filter(|c| c.is_ascii_alphabetic()).map(|c| c.to_ascii_uppercase()).collect();
+        self.lexemes.iter().any(|(lexeme, positions)| {
+            (if prefix { lexeme.starts_with(&word) } else { *lexeme == word })
+                && (weights.is_empty() || positions.iter().any(|(_, weight)| weights.contains(weight)))
+        })
+    }
+
+    /// Words at consecutive positions
+    fn phrase(&self, words: &[String]) -> bool {
+        let Some(first) = words.first().and_then(|word| self.lexemes.get(word)) else {
+            return words.is_empty();
+        };
+        first.iter().any(|&(position, _)| {
+            words.iter().enumerate().skip(1).all(|(i, word)| {
+                self.lexemes.get(word).is_some_and(|positions| positions.iter().any(|&(p, _)| p == position + i as u64))
+            })
+        })
+    }
+}
+
+/// tsvector @@ tsquery: evaluate the AND, OR and NOT of the query, written in FTS5 syntax as
+/// to_tsquery() returns it or in tsquery syntax, against the lexemes of the vector
+fn ts_match(tsvector: &str, tsquery: &str) -> bool {
+    let lexemes = parse_tsvector(tsvector);
+    let tokens = match_tokens(tsquery);
+    if tokens.is_empty() {
+        return false;
+    }
+    MatchParser { tokens: &tokens, next: 0, lexemes: &lexemes }.or()
+}
+
+/// Positions of the vector matching the query terms, in document order
+fn occurrences(lexemes: &Lexemes, terms: &[String]) -> Vec<Occurrence> {
+    let mut occurrences = Vec::new();
+    for (term_index, term) in terms.iter().enumerate() {
+        for (lexeme, positions) in lexemes {
+            if term_matches(term, lexeme) {
+                occurrences.extend(positions.iter().map(|&(position, label)| Occurrence {
+                    position,
+                    weight: weight_value(label),
+                    term: term_index,
+                }));
+            }
+        }
+    }
+    occurrences.sort_by_key(|occurrence| occurrence.position);
+    occurrences
+}
+
+/// ts_rank: each term scores the weights of its occurrences with decreasing importance (the
+/// n-th occurrence counts 1/n²), and the scores are averaged over the query terms
+fn rank(tsvector: &str, tsquery: &str, normalization: i64) -> f64 {
+    let lexemes = parse_tsvector(tsvector);
+    let terms = query_terms(tsquery);
+    if terms.is_empty() {
+        return 0.0;
+    }
+    let occurrences = occurrences(&lexemes, &terms);
+
+    let mut score = 0.0;
+    for term in 0..terms.len() {
+        let (mut sum, mut max_weight, mut max_index) = (0.0, 0.0, 0);
+        for (i, weight) in occurrences.iter().filter(|o| o.term == term).map(|o| o.weight).enumerate() {
+            sum += weight / ((i + 1) * (i + 1)) as f64;
+            if weight > max_weight {
+                max_weight = weight;
+                max_index = i;
+            }
+        }
+        // The sum of 1/n² converges to pi²/6
+        score += (max_weight + sum - max_weight / ((max_index + 1) * (max_index + 1)) as f64) / 1.64493406685;
+    }
+    normalize(score / terms.len() as f64, &lexemes, normalization)
+}
+
+/// ts_rank_cd: sums over the covers, the shortest spans of the document satisfying the query,
+/// the harmonic mean of their weights divided by the number of other words in the span
+fn cover_density_rank(tsvector: &str, tsquery: &str, normalization: i64) -> f64 {
+    let lexemes = parse_tsvector(tsvector);
+    let terms = query_terms(tsquery);
+    if terms.is_empty() {
+        return 0.0;
+    }
+    let occurrences = occurrences(&lexemes, &terms);
+    // Any one term satisfies an OR query, an AND query needs all of them
+    let needed = if tsquery.contains(" OR ") || tsquery.contains('|') { 1 } else { terms.len() };
+
+    let mut score = 0.0;
+    let mut start = 0;
+    while let Some(end) = cover_bound(&occurrences, start..occurrences.len(), needed) {
+        let begin = cover_bound(&occurrences, (start..=end).rev(), needed).unwrap_or(start);
+        let cover = &occurrences[begin..=end];
+        let inverse_sum: f64 = cover.iter().map(|o| 1.0 / o.weight).sum();
+        let noise = (cover[cover.len() - 1].position - cover[0].position).saturating_sub(cover.len() as u64 - 1);
+        score += cover.len() as f64 / inverse_sum / (1.0 + noise as f64);
+        start = begin + 1;
+    }
+    normalize(score, &lexemes, normalization)
+}
+
+/// First index, walking `indexes`, by which `needed` distinct terms have been seen
+fn cover_bound(occurrences: &[Occurrence], mut indexes: impl Iterator<Item = usize>, needed: usize) -> Option<usize> {
+    let mut seen = HashSet::new();
+    indexes.find(|&i| {
+        seen.insert(occurrences[i].term);
+        seen.len() == needed
+    })
+}
+
+/// The ts_headline options that are supported, with PostgreSQL's defaults
+struct HeadlineOptions {
+    start_sel: String,
+    stop_sel: String,
+    max_words: usize,
+    min_words: usize,
+    highlight_all: bool,
+}
+
+impl HeadlineOptions {
+    /// Parse an options string such as `StartSel=<em>, StopSel=</em>, MaxWords=10`
+    fn parse(options: &str) -> std::result::Result<Self, String> {
+        let mut parsed = HeadlineOptions {
+            start_sel: "<b>".to_string(),
+            stop_sel: "</b>".to_string(),
+            max_words: 35,
+            min_words: 15,
+            highlight_all: false,
+        };
+        for option in options.split(',').filter(|o| !o.trim().is_empty()) {
+            let (name, value) = option.split_once('=')
+                .ok_or_else(|| format!("invalid ts_headline option \"{}\"", option.trim()))?;
+            let value = value.trim().trim_matches('"');
+            let number = || value.parse::<usize>().map_err(|_| format!("invalid value for {}: \"{value}\"", name.trim()));
+            match name.trim().to_lowercase().as_str() {
+                "startsel" => parsed.start_sel = value.to_string(),
+                "stopsel" => parsed.stop_sel = value.to_string(),
+                "maxwords" => parsed.max_words = number()?,
+                "minwords" => parsed.min_words = number()?,
+                "highlightall" => parsed.highlight_all = matches!(value.to_lowercase().as_str(), "true" | "t" | "yes" | "y" | "on" | "1"),
+                // ShortWord, MaxFragments and FragmentDelimiter are accepted but not applied
+                _ => {}
+            }
+        }
+        if !parsed.highlight_all {
+            if parsed.min_words == 0 {
+                return Err("MinWords should be positive".to_string());
+            }
+            if parsed.min_words >= parsed.max_words {
+                return Err("MinWords should be less than MaxWords".to_string());
+            }
+        }
+        Ok(parsed)
+    }
+}
+
+/// A word of a ts_headline document
+struct HeadlineWord {
+    start: usize,
+    end: usize,
+    core_start: usize,
+    core_end: usize,
+    matched: bool,
+}
+
+/// ts_headline: the part of the document around the first match, at most MaxWords words (or
+/// MinWords from the start when nothing matches), with the matching words wrapped in
+/// StartSel/StopSel
+fn headline(document: &str, tsquery: &str, config: TextSearchConfig, options: &HeadlineOptions) -> String {
+    let terms = query_terms(tsquery);
+    // Words split like to_tsvector does: the byte range of each word in the document and of
+    // the alphabetic part that gets highlighted
+    let not_alphabetic = |c: char| !c.is_alphabetic();
+    let mut words = Vec::new();
+    let mut offset = 0;
+    for token in document.split_whitespace() {
+        let start = offset + document[offset..].find(token).unwrap_or(0);
+        offset = start + token.len();
+        let trimmed = token.trim_start_matches(not_alphabetic);
+        let core_start = offset - trimmed.len();
+        let core_end = core_start + trimmed.trim_end_matches(not_alphabetic).len();
+        let matched = config.lexize(&document[core_start..core_end])
+            .is_some_and(|lexeme| !lexeme.is_empty() && terms.iter().any(|term| term_matches(term, &lexeme)));
+        words.push(HeadlineWord { start, end: offset, core_start, core_end, matched });
+    }
+    if words.is_empty() {
+        return String::new();
+    }
+
+    let (first, last) = if options.highlight_all || words.len() <= options.max_words {
+        (0, words.len())
+    } else if let Some(matched) = words.iter().position(|w| w.matched) {
+        let last = (matched + options.max_words).min(words.len());
+        (last - options.max_words, last)
+    } else {
+        (0, options.min_words)
+    };
+
+    let mut result = String::new();
+    let mut copied = words[first].start;
+    for word in &words[first..last] {
+        if word.matched {
+            result.push_str(&document[copied..word.core_start]);
+            result.push_str(&options.start_sel);
+            result.push_str(&document[word.core_start..word.core_end]);
+            result.push_str(&options.stop_sel);
+            copied = word.core_end;
+        }
+        result.push_str(&document[copied..word.end]);
+        copied = word.end;
+    }
+    result
+}
+
+/// Apply the normalization bit mask of ts_rank: 1 and 2 divide by the logarithm of the document
+/// length or by the length, 8 and 16 by the number of unique words or its logarithm, and 32
+/// scales the rank to rank / (rank + 1)
+fn normalize(mut rank: f64, lexemes: &Lexemes, normalization: i64) -> f64 {
+    let length = lexemes.values().map(Vec::len).sum::<usize>() as f64;
+    let unique = lexemes.len() as f64;
+    if normalization & 1 != 0 && length > 0.0 {
+        rank /= (length + 1.0).log2();
+    }
+    if normalization & 2 != 0 && length > 0.0 {
+        rank /= length;
+    }
+    if normalization & 8 != 0 && unique > 0.0 {
+        rank /= unique;
+    }
+    if normalization & 16 != 0 && unique > 0.0 {
+        rank /= (unique + 1.0).log2();
+    }
+    if normalization & 32 != 0 {
+        rank /= rank + 1.0;
+    }
+    rank
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn tsvector(conn: &Connection, text: &str) -> String {
+        conn.query_row("SELECT to_tsvector('english', ?1)", [text], |row| row.get(0)).unwrap()
+    }
+
+    #[test]
+    fn test_rank() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_fts_functions(&conn).unwrap();
+
+        // Values PostgreSQL returns for the same vectors
+        let cat = tsvector(&conn, "cat");
+        assert!((rank(&cat, "cat", 0) - 0.0607927).abs() < 1e-6);
+        assert!((cover_density_rank(&cat, "cat", 0) - 0.1).abs() < 1e-9);
+        assert_eq!(rank(&cat, "dog", 0), 0.0);
+        assert_eq!(rank(&cat, "NOT cat", 0), 0.0);
+
+        let pets = tsvector(&conn, "cat sat with the dog");
+        assert!((cover_density_rank(&pets, "cat AND dog", 0) - 0.1 / 4.0).abs() < 1e-9);
+        assert!((cover_density_rank(&pets, "cat OR dog", 0) - 0.2).abs() < 1e-9);
+        assert!(rank(&tsvector(&conn, "cat and cat"), "cat", 0) > rank(&cat, "cat", 0));
+        assert!(rank(&pets, "ca*", 0) > 0.0);
+        assert!(rank(&pets, "cat", 32) < rank(&pets, "cat", 0));
+    }
+
+    #[test]
+    fn test_setweight() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_fts_functions(&conn).unwrap();
+
+        let weighted: String = conn.query_row(
+            "SELECT setweight(to_tsvector('english', 'cat'), 'a')", [], |row| row.get(0)
+        ).unwrap();
+        assert_eq!(parse_tsvector(&weighted)["cat"], vec![(1, 'A')]);
+        assert!((rank(&weighted, "cat", 0) - 0.6079271).abs() < 1e-6);
+
+        // Concatenated vectors keep their weights and shift positions
+        let title_and_body = format!("{weighted}{}", tsvector(&conn, "dog cat"));
+        assert_eq!(parse_tsvector(&title_and_body)["cat"], vec![(1, 'A'), (3, 'D')]);
+
+        assert!(conn.query_row("SELECT setweight(to_tsvector('english', 'cat'), 'E')", [], |row| row.get::<_, String>(0)).is_err());
+    }
+
+    #[test]
+    fn test_headline() {
+        let defaults = HeadlineOptions::parse("").unwrap();
+        assert_eq!(
+            headline("The quick brown fox, the lazy dog.", "fox OR dog", TextSearchConfig::English, &defaults),
+            "The quick brown <b>fox</b>, the lazy <b>dog</b>."
+        );
+
+        let options = HeadlineOptions::parse("StartSel=<em>, StopSel=</em>, MaxWords=4, MinWords=2").unwrap();
+        let document = "one two three four five six seven eight";
+        assert_eq!(headline(document, "five", TextSearchConfig::English, &options), "<em>five</em> six seven eight");
+        assert_eq!(headline(document, "seven", TextSearchConfig::English, &options), "five six <em>seven</em> eight");
+        assert_eq!(headline(document, "nine", TextSearchConfig::English, &options), "one two");
+        assert_eq!(headline(document, "t*", TextSearchConfig::English, &options), "<em>two</em> <em>three</em> four five");
+
+        assert!(HeadlineOptions::parse("MaxWords=5, MinWords=5").is_err());
+        assert!(HeadlineOptions::parse("HighlightAll=true, MinWords=0").unwrap().highlight_all);
+
+        // Words match by lexeme, so stemmed forms are highlighted with english only
+        let english = HeadlineOptions::parse("").unwrap();
+        assert_eq!(headline("Runners running", "run", TextSearchConfig::English, &english), "Runners <b>running</b>");
+        assert_eq!(headline("Runners running", "run", TextSearchConfig::Simple, &english), "Runners running");
+    }
+
+    #[test]
+    fn test_text_search_configs() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_fts_functions(&conn).unwrap();
+        let lexemes = |config: &str, text: &str| -> Vec<String> {
+            let tsvector: String = conn.query_row("SELECT to_tsvector(?1, ?2)", [config, text], |row| row.get(0)).unwrap();
+            parse_tsvector(&tsvector).into_keys().collect()
+        };
+
+        // english stems and drops stop words, simple only lowercases
+        assert_eq!(lexemes("english", "The runner is running"), vec!["run", "runner"]);
+        assert_eq!(lexemes("simple", "The runner is running"), vec!["is", "runner", "running", "the"]);
+        assert_eq!(parse_tsvector(&tsvector(&conn, "the cat sat"))["sat"], vec![(3, 'D')]);
+
+        let query = |sql: &str| -> Result<String> { conn.query_row(sql, [], |row| row.get(0)) };
+        assert_eq!(query("SELECT to_tsquery('english', 'Running & !the & (cats | the)')").unwrap(), "run AND (cat)");
+        assert_eq!(query("SELECT to_tsquery('simple', 'Running & cats')").unwrap(), "running AND cats");
+        assert_eq!(query("SELECT to_tsquery('supernovae:*')").unwrap(), "supernova*");
+        assert_eq!(query("SELECT plainto_tsquery('english', 'The fat rats')").unwrap(), "fat AND rat");
+        assert_eq!(query("SELECT phraseto_tsquery('pg_catalog.simple', 'The fat rats')").unwrap(), "\"the fat rats\"");
+
+        let err = query("SELECT to_tsvector('klingon', 'Qapla')").unwrap_err();
+        assert!(err.to_string().contains("text search configuration \"klingon\" does not exist"), "{err}");
+    }
+}
@@ -0,0 +1,102 @@
+use rusqlite::{Connection, Result, functions::FunctionFlags};
+use rusqlite::types::ValueRef;
+use tracing::debug;
+
+/// Register functions for the point type, which is stored as its text form `(x,y)`
+pub fn register_geometry_functions(conn: &Connection) -> Result<()> {
+    debug!("Registering geometry functions");
+
+    // point(x, y) - build a point from its coordinates
+    conn.create_scalar_function(
+        "point",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            if matches!(ctx.get_raw(0), ValueRef::Null) || matches!(ctx.get_raw(1), ValueRef::Null) {
+                return Ok(None);
+            }
+            let x = ctx.get::<f64>(0)?;
+            let y = ctx.get::<f64>(1)?;
+            Ok(Some(format_point(x, y)))
+        },
+    )?;
+
+    // point(text) - normalize a point literal (used for '...'::point casts)
+    conn.create_scalar_function(
+        "point",
+        1,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            if matches!(ctx.get_raw(0), ValueRef::Null) {
+                return Ok(None);
+            }
+            let text = ctx.get::<String>(0)?;
+            let (x, y) = parse_point(&text).ok_or_else(|| invalid_point(&text))?;
+            Ok(Some(format_point(x, y)))
+        },
+    )?;
+
+    // point_distance(a, b) - the <-> operator, Euclidean distance between two points
+    conn.create_scalar_function(
+        "point_distance",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            if matches!(ctx.get_raw(0), ValueRef::Null) || matches!(ctx.get_raw(1), ValueRef::Null) {
+                return Ok(None);
+            }
+            let a = ctx.get::<String>(0)?;
+            let b = ctx.get::<String>(1)?;
+            let (ax, ay) = parse_point(&a).ok_or_else(|| invalid_point(&a))?;
+            let (bx, by) = parse_point(&b).ok_or_else(|| invalid_point(&b))?;
+            Ok(Some((ax - bx).hypot(ay - by)))
+        },
+    )?;
+
+    Ok(())
+}
+
+fn invalid_point(text: &str) -> rusqlite::Error {
+    rusqlite::Error::UserFunctionError(format!("invalid input syntax for type point: \"{text}\"").into())
+}
+
+/// Parse `(x,y)` or `x,y`
+fn parse_point(text: &str) -> Option<(f64, f64)> {
+    let text = text.trim();
+    let inner = match text.strip_prefix('(') {
+        Some(rest) => rest.strip_suffix(')')?,
+        None => text,
+    };
+    let (x, y) = inner.split_once(',')?;
+    let x = x.trim().parse::<f64>().ok().filter(|v| v.is_finite())?;
+    let y = y.trim().parse::<f64>().ok().filter(|v| v.is_finite())?;
+    Some((x, y))
+}
+
+/// PostgreSQL's output form; whole coordinates print without a fractional part, as `(1,2)`
+fn format_point(x: f64, y: f64) -> String {
+    format!("({x},{y})")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_point_functions() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_geometry_functions(&conn).unwrap();
+
+        let point: String = conn.query_row("SELECT point(1, 2.5)", [], |row| row.get(0)).unwrap();
+        assert_eq!(point, "(1,2.5)");
+        let point: String = conn.query_row("SELECT point(' ( 3 , -4 ) ')", [], |row| row.get(0)).unwrap();
+        assert_eq!(point, "(3,-4)");
+
+        let distance: f64 = conn.query_row("SELECT point_distance('(0,0)', point(3, 4))", [], |row| row.get(0)).unwrap();
+        assert_eq!(distance, 5.0);
+        let distance: Option<f64> = conn.query_row("SELECT point_distance(NULL, '(1,1)')", [], |row| row.get(0)).unwrap();
+        assert_eq!(distance, None);
+
+        assert!(conn.query_row("SELECT point('1;2')", [], |row| row.get::<_, String>(0)).is_err());
+    }
+}
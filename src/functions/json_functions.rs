@@ -35,14 +35,7 @@ pub fn register_json_functions(conn: &Connection) -> Result<()> {
         "jsonb_array_length",
         1,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let value: String = ctx.get(0)?;
-            match serde_json::from_str::<JsonValue>(&value) {
-                Ok(JsonValue::Array(arr)) => Ok(Some(arr.len() as i64)),
-                Ok(_) => Ok(None),
-                Err(_) => Ok(None),
-            }
-        },
+        json_array_length,
     )?;
     
     // json_array_length(json) - Alias
@@ -50,14 +43,7 @@ pub fn register_json_functions(conn: &Connection) -> Result<()> {
         "json_array_length",
         1,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let value: String = ctx.get(0)?;
-            match serde_json::from_str::<JsonValue>(&value) {
-                Ok(JsonValue::Array(arr)) => Ok(Some(arr.len() as i64)),
-                Ok(_) => Ok(None),
-                Err(_) => Ok(None),
-            }
-        },
+        json_array_length,
     )?;
     
     // jsonb_object_keys(jsonb) - Get object keys (returns them as comma-separated for now)
@@ -276,39 +262,24 @@ pub fn register_json_functions(conn: &Connection) -> Result<()> {
         },
     )?;
     
-    // json_strip_nulls(json) - Remove all null values from JSON
-    conn.create_scalar_function(
-        "json_strip_nulls",
-        1,
-        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let json_str: String = ctx.get(0)?;
-            match serde_json::from_str::<JsonValue>(&json_str) {
-                Ok(json) => {
-                    let stripped = strip_nulls(&json);
-                    Ok(serde_json::to_string(&stripped).ok())
-                }
-                Err(_) => Ok(None),
-            }
-        },
-    )?;
-    
-    // jsonb_strip_nulls(jsonb) - Alias for json_strip_nulls
-    conn.create_scalar_function(
-        "jsonb_strip_nulls",
-        1,
-        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let json_str: String = ctx.get(0)?;
-            match serde_json::from_str::<JsonValue>(&json_str) {
-                Ok(json) => {
-                    let stripped = strip_nulls(&json);
-                    Ok(serde_json::to_string(&stripped).ok())
+    // json_strip_nulls(json) / jsonb_strip_nulls(jsonb) - Remove null-valued object fields
+    for name in ["json_strip_nulls", "jsonb_strip_nulls"] {
+        conn.create_scalar_function(
+            name,
+            1,
+            FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+            |ctx| {
+                let json_str: Option<String> = ctx.get(0)?;
+                match json_str.as_deref().map(serde_json::from_str::<JsonValue>) {
+                    Some(Ok(json)) => {
+                        let stripped = strip_nulls(&json);
+                        Ok(serde_json::to_string(&stripped).ok())
+                    }
+                    _ => Ok(None),
                 }
-                Err(_) => Ok(None),
-            }
-        },
-    )?;
+            },
+        )?;
+    }
     
     // jsonb_set(jsonb, text[], jsonb, boolean) - Set value at path
     // For simplicity, implement a 3-arg version without create_missing flag
@@ -760,13 +731,18 @@ pub fn register_json_functions(conn: &Connection) -> Result<()> {
         1,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let json_str: String = ctx.get(0)?;
+            let Some(json_str) = ctx.get::<Option<String>>(0)? else {
+                return Ok(None);
+            };
             
             match serde_json::from_str::<JsonValue>(&json_str) {
                 Ok(json) => {
-                    // Pretty print with 2-space indentation
-                    match serde_json::to_string_pretty(&json) {
-                        Ok(pretty) => Ok(Some(pretty)),
+                    // Pretty print with PostgreSQL's 4-space indentation
+                    let mut pretty = Vec::new();
+                    let formatter = serde_json::ser::PrettyFormatter::with_indent(b"    ");
+                    let mut serializer = serde_json::Serializer::with_formatter(&mut pretty, formatter);
+                    match serde::Serialize::serialize(&json, &mut serializer) {
+                        Ok(()) => Ok(String::from_utf8(pretty).ok()),
                         Err(_) => Ok(Some(json_str)), // Return original if pretty-print fails
                     }
                 }
@@ -873,6 +849,7 @@ pub fn register_json_functions(conn: &Connection) -> Result<()> {
     
     // Row conversion functions
     register_row_to_json(conn)?;
+    register_array_to_json(conn)?;
     
     // Record conversion functions
     register_json_populate_record(conn)?;
@@ -1019,7 +996,11 @@ fn register_json_object_agg(conn: &Connection) -> Result<()> {
                 rusqlite::types::ValueRef::Text(s) => std::str::from_utf8(s).unwrap_or("").to_string(),
                 rusqlite::types::ValueRef::Integer(i) => i.to_string(),
                 rusqlite::types::ValueRef::Real(f) => f.to_string(),
-                rusqlite::types::ValueRef::Null => "null".to_string(),
+                rusqlite::types::ValueRef::Null => {
+                    return Err(rusqlite::Error::UserFunctionError(
+                        "null value not allowed for object key".into()
+                    ));
+                }
                 rusqlite::types::ValueRef::Blob(_) => return Ok(()), // Skip blob keys
             };
             
@@ -1092,7 +1073,11 @@ fn register_jsonb_object_agg(conn: &Connection) -> Result<()> {
                 rusqlite::types::ValueRef::Text(s) => std::str::from_utf8(s).unwrap_or("").to_string(),
                 rusqlite::types::ValueRef::Integer(i) => i.to_string(),
                 rusqlite::types::ValueRef::Real(f) => f.to_string(),
-                rusqlite::types::ValueRef::Null => "null".to_string(),
+                rusqlite::types::ValueRef::Null => {
+                    return Err(rusqlite::Error::UserFunctionError(
+                        "null value not allowed for object key".into()
+                    ));
+                }
                 rusqlite::types::ValueRef::Blob(_) => return Ok(()), // Skip blob keys
             };
             
@@ -1148,12 +1133,9 @@ fn register_jsonb_object_agg(conn: &Connection) -> Result<()> {
 
 /// row_to_json(record [, pretty_bool]) - Convert row to JSON object
 fn register_row_to_json(conn: &Connection) -> Result<()> {
-    // This function will need to be implemented as a query translator
-    // rather than a simple SQLite function, because PostgreSQL's row_to_json
-    // works with composite types and subqueries.
-    
-    // For now, implement a basic version that handles simple JSON conversion
-    // The real implementation would need to be in the query translator layer
+    // Whole-row references like row_to_json(t) are expanded into json_object()
+    // calls by RowToJsonTranslator, so by the time these run the record is
+    // usually already a JSON object in column order.
     
     // Single parameter version: row_to_json(record)
     conn.create_scalar_function(
@@ -1162,6 +1144,9 @@ fn register_row_to_json(conn: &Connection) -> Result<()> {
         FunctionFlags::SQLITE_UTF8,
         |ctx| {
             let input = ctx.get_raw(0);
+            if let Some(object) = json_object_text(input) {
+                return Ok(Some(object.to_string()));
+            }
             convert_value_to_json(input, false)
         },
     )?;
@@ -1174,13 +1159,225 @@ fn register_row_to_json(conn: &Connection) -> Result<()> {
         |ctx| {
             let input = ctx.get_raw(0);
             let pretty: bool = ctx.get(1)?;
+            if let Some(object) = json_object_text(input) {
+                return Ok(Some(if pretty { pretty_row_json(object) } else { object.to_string() }));
+            }
             convert_value_to_json(input, pretty)
         },
     )?;
     
+    // pgsqlite_json_typed_value(value, type_oid) - JSON encoding of a stored column
+    // value according to its declared PostgreSQL type, used for whole-row conversion
+    conn.create_scalar_function(
+        "pgsqlite_json_typed_value",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let type_oid: i32 = ctx.get(1)?;
+            Ok(json_typed_value(ctx.get_raw(0), type_oid).to_string())
+        },
+    )?;
+    
     Ok(())
 }
 
+/// array_to_json(anyarray [, pretty_bool]) - Convert an array to a JSON array
+fn register_array_to_json(conn: &Connection) -> Result<()> {
+    for arity in [1, 2] {
+        conn.create_scalar_function(
+            "array_to_json",
+            arity,
+            FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| {
+                let Some(array) = ctx.get::<Option<String>>(0)? else {
+                    return Ok(None);
+                };
+                let pretty = arity == 2 && ctx.get::<bool>(1)?;
+                let json = array_json(&array)
+                    .map_err(|e| rusqlite::Error::UserFunctionError(e.into()))?
+                    .to_string();
+                Ok(Some(if pretty { pretty_row_json(&json) } else { json }))
+            },
+        )?;
+    }
+    
+    Ok(())
+}
+
+/// Read an array value, stored as a JSON array or written as a PostgreSQL array literal
+pub(crate) fn array_json(array: &str) -> std::result::Result<JsonValue, String> {
+    let trimmed = array.trim();
+    if trimmed.starts_with('[') {
+        return serde_json::from_str::<JsonValue>(trimmed)
+            .map_err(|_| format!("malformed array literal: \"{array}\""));
+    }
+    let mut chars = trimmed.chars().peekable();
+    match parse_pg_array(&mut chars) {
+        Some(value) if chars.next().is_none() => Ok(value),
+        _ => Err(format!("malformed array literal: \"{array}\"")),
+    }
+}
+
+/// Parse a PostgreSQL array literal such as {a,"b c",NULL} or {{1,2},{3,4}}. Unquoted
+/// elements that read as numbers or booleans are typed like they are on INSERT.
+fn parse_pg_array(chars: &mut std::iter::Peekable<std::str::Chars>) -> Option<JsonValue> {
+    fn skip_whitespace(chars: &mut std::iter::Peekable<std::str::Chars>) {
+        while chars.next_if(|c| c.is_whitespace()).is_some() {}
+    }
+    
+    if chars.next()? != '{' {
+        return None;
+    }
+    let mut elements = Vec::new();
+    skip_whitespace(chars);
+    if chars.next_if_eq(&'}').is_some() {
+        return Some(JsonValue::Array(elements));
+    }
+    loop {
+        skip_whitespace(chars);
+        let element = match chars.peek()? {
+            '{' => parse_pg_array(chars)?,
+            '"' => {
+                chars.next();
+                let mut text = String::new();
+                loop {
+                    match chars.next()? {
+                        '\\' => text.push(chars.next()?),
+                        '"' => break,
+                        c => text.push(c),
+                    }
+                }
+                JsonValue::String(text)
+            }
+            _ => {
+                let mut text = String::new();
+                while let Some(c) = chars.next_if(|c| *c != ',' && *c != '}') {
+                    text.push(c);
+                }
+                let text = text.trim();
+                if text.eq_ignore_ascii_case("NULL") {
+                    JsonValue::Null
+                } else if let Ok(number) = text.parse::<i64>() {
+                    JsonValue::from(number)
+                } else if let Some(number) = text.parse::<f64>().ok().and_then(serde_json::Number::from_f64) {
+                    JsonValue::Number(number)
+                } else if text.eq_ignore_ascii_case("true") || text.eq_ignore_ascii_case("false") {
+                    JsonValue::Bool(text.eq_ignore_ascii_case("true"))
+                } else {
+                    JsonValue::String(text.to_string())
+                }
+            }
+        };
+        elements.push(element);
+        skip_whitespace(chars);
+        match chars.next()? {
+            ',' => continue,
+            '}' => return Some(JsonValue::Array(elements)),
+            _ => return None,
+        }
+    }
+}
+
+/// Return the text of a value that already holds a JSON object
+fn json_object_text<'a>(value: ValueRef<'a>) -> Option<&'a str> {
+    match value {
+        ValueRef::Text(s) => {
+            let text = std::str::from_utf8(s).ok()?;
+            (text.trim_start().starts_with('{') && serde_json::from_str::<JsonValue>(text).is_ok()).then_some(text)
+        }
+        _ => None,
+    }
+}
+
+/// Format a row object the way PostgreSQL's pretty row_to_json does:
+/// a line feed between top-level fields, nested values untouched
+fn pretty_row_json(object: &str) -> String {
+    let mut result = String::with_capacity(object.len() + 16);
+    let mut depth = 0;
+    let mut in_string = false;
+    let mut escaped = false;
+    
+    for ch in object.chars() {
+        result.push(ch);
+        if in_string {
+            match ch {
+                _ if escaped => escaped = false,
+                '\\' => escaped = true,
+                '"' => in_string = false,
+                _ => {}
+            }
+            continue;
+        }
+        match ch {
+            '"' => in_string = true,
+            '{' | '[' => depth += 1,
+            '}' | ']' => depth -= 1,
+            ',' if depth == 1 => result.push_str("\n "),
+            _ => {}
+        }
+    }
+    
+    result
+}
+
+/// Encode a stored value as JSON following its PostgreSQL type: numbers and
+/// booleans unquoted, dates and timestamps as ISO 8601 strings, json and array
+/// columns embedded as JSON
+fn json_typed_value(value: ValueRef, type_oid: i32) -> JsonValue {
+    use crate::types::{PgType, datetime_utils};
+    
+    let text = match value {
+        ValueRef::Null => return JsonValue::Null,
+        ValueRef::Text(s) => std::str::from_utf8(s).ok(),
+        _ => None,
+    };
+    
+    match PgType::from_oid(type_oid) {
+        Some(PgType::Bool) => match value {
+            ValueRef::Integer(i) => JsonValue::Bool(i != 0),
+            ValueRef::Real(f) => JsonValue::Bool(f != 0.0),
+            _ => JsonValue::Bool(matches!(text.map(str::to_lowercase).as_deref(), Some("t" | "true" | "1" | "yes" | "on"))),
+        },
+        Some(PgType::Int2 | PgType::Int4 | PgType::Int8 | PgType::Float4 | PgType::Float8 | PgType::Numeric) => match value {
+            ValueRef::Integer(i) => JsonValue::from(i),
+            ValueRef::Real(f) => serde_json::Number::from_f64(f).map_or(JsonValue::Null, JsonValue::Number),
+            // Numeric text keeps its exact digits
+            _ => text
+                .and_then(|t| serde_json::from_str::<serde_json::Number>(t.trim()).ok())
+                .map_or_else(|| JsonValue::String(text.unwrap_or_default().to_string()), JsonValue::Number),
+        },
+        Some(PgType::Date) => match value {
+            ValueRef::Integer(days) => JsonValue::String(datetime_utils::format_days_to_date(days)),
+            _ => JsonValue::String(text.unwrap_or_default().to_string()),
+        },
+        Some(PgType::Time) => match value {
+            ValueRef::Integer(micros) => JsonValue::String(datetime_utils::format_microseconds_to_time(micros)),
+            _ => JsonValue::String(text.unwrap_or_default().to_string()),
+        },
+        Some(pg_type @ (PgType::Timestamp | PgType::Timestamptz)) => {
+            let formatted = match value {
+                ValueRef::Integer(micros) => datetime_utils::format_microseconds_to_timestamp(micros),
+                _ => text.unwrap_or_default().to_string(),
+            };
+            let iso = formatted.replacen(' ', "T", 1);
+            if pg_type == PgType::Timestamptz && !iso.contains('+') && iso.contains('T') {
+                JsonValue::String(format!("{iso}+00:00"))
+            } else {
+                JsonValue::String(iso)
+            }
+        }
+        Some(pg_type) if matches!(pg_type, PgType::Json | PgType::Jsonb) || pg_type.is_array() => text
+            .and_then(|t| serde_json::from_str(t).ok())
+            .unwrap_or_else(|| JsonValue::String(text.unwrap_or_default().to_string())),
+        _ => match value {
+            ValueRef::Integer(i) => JsonValue::from(i),
+            ValueRef::Real(f) => serde_json::Number::from_f64(f).map_or(JsonValue::Null, JsonValue::Number),
+            ValueRef::Blob(b) => JsonValue::String(format!("\\x{}", hex::encode(b))),
+            _ => JsonValue::String(text.unwrap_or_default().to_string()),
+        },
+    }
+}
+
 /// Convert a SQLite value to JSON format
 fn convert_value_to_json(value: rusqlite::types::ValueRef, pretty: bool) -> Result<Option<String>> {
     use rusqlite::types::ValueRef;
@@ -1410,6 +1607,20 @@ fn insert_json_value(json: &mut JsonValue, path: &[String], new_value: JsonValue
     }
 }
 
+/// Get the number of elements of a JSON array; scalars and objects are an error
+fn json_array_length(ctx: &rusqlite::functions::Context) -> Result<Option<i64>> {
+    let Some(value) = ctx.get::<Option<String>>(0)? else {
+        return Ok(None);
+    };
+    let message = match serde_json::from_str::<JsonValue>(&value) {
+        Ok(JsonValue::Array(arr)) => return Ok(Some(arr.len() as i64)),
+        Ok(JsonValue::Object(_)) => "cannot get array length of a non-array",
+        Ok(_) => "cannot get array length of a scalar",
+        Err(_) => "invalid input syntax for type json",
+    };
+    Err(rusqlite::Error::UserFunctionError(message.into()))
+}
+
 /// Get the type of a JSON value
 fn json_typeof(ctx: &rusqlite::functions::Context) -> Result<Option<String>> {
     let value: String = ctx.get(0)?;
@@ -1505,7 +1716,8 @@ fn extract_json_path(json: &JsonValue, path: &str) -> Option<JsonValue> {
     Some(current.clone())
 }
 
-/// Remove null values from JSON
+/// Remove null-valued object fields, recursively. Nulls inside arrays are kept, as in
+/// PostgreSQL.
 fn strip_nulls(json: &JsonValue) -> JsonValue {
     match json {
         JsonValue::Object(map) => {
@@ -1660,6 +1872,19 @@ mod tests {
         // Test json_array_length
         let len: i64 = conn.query_row("SELECT json_array_length(?)", ["[1,2,3,4,5]"], |row| row.get(0)).unwrap();
         assert_eq!(len, 5);
+        let err = conn.query_row("SELECT jsonb_array_length(?)", ["{\"a\": 1}"], |row| row.get::<_, i64>(0)).unwrap_err();
+        assert_eq!(err.to_string(), "cannot get array length of a non-array");
+        let err = conn.query_row("SELECT json_array_length(?)", ["5"], |row| row.get::<_, i64>(0)).unwrap_err();
+        assert_eq!(err.to_string(), "cannot get array length of a scalar");
+        
+        // Test array_to_json on stored and literal arrays
+        let json: String = conn.query_row("SELECT array_to_json(?)", ["[\"a\", \"b\"]"], |row| row.get(0)).unwrap();
+        assert_eq!(json, "[\"a\",\"b\"]");
+        let json: String = conn.query_row("SELECT array_to_json(?)", ["{x,\"y z\",NULL,3}"], |row| row.get(0)).unwrap();
+        assert_eq!(json, "[\"x\",\"y z\",null,3]");
+        let json: String = conn.query_row("SELECT array_to_json(?, 1)", ["{{1,5},{99,100}}"], |row| row.get(0)).unwrap();
+        assert_eq!(json, "[[1,5],\n [99,100]]");
+        assert!(conn.query_row("SELECT array_to_json(?)", ["{1,2"], |row| row.get::<_, String>(0)).is_err());
         
         // Test json_extract_scalar
         let value: Option<String> = conn.query_row(
@@ -1906,6 +2131,64 @@ mod tests {
         ).unwrap();
         
         assert_eq!(result, Some("[]".to_string()));
+        
+        // ORDER BY inside the aggregate call
+        let result: String = conn.query_row(
+            "SELECT jsonb_agg(score ORDER BY score DESC) FROM test_agg",
+            [],
+            |row| row.get(0)
+        ).unwrap();
+        assert_eq!(result, "[95,92,88,87]");
+    }
+    
+    #[test]
+    fn test_row_to_json_typed_values() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_json_functions(&conn).unwrap();
+        
+        // 2024-01-15 10:30:00 as microseconds since the epoch, 1965-08-01 as days
+        let result: String = conn.query_row(
+            "SELECT json_object('id', json(pgsqlite_json_typed_value(1, 23)), 'price', json(pgsqlite_json_typed_value('9.99', 1700)), \
+             'active', json(pgsqlite_json_typed_value(1, 16)), 'at', json(pgsqlite_json_typed_value(1705314600000000, 1184)), \
+             'day', json(pgsqlite_json_typed_value(-1614, 1082)), 'tags', json(pgsqlite_json_typed_value('[\"a\"]', 1009)), \
+             'none', json(pgsqlite_json_typed_value(NULL, 25)))",
+            [],
+            |row| row.get(0)
+        ).unwrap();
+        assert_eq!(
+            result,
+            r#"{"id":1,"price":9.99,"active":true,"at":"2024-01-15T10:30:00+00:00","day":"1965-08-01","tags":["a"],"none":null}"#
+        );
+        
+        // Pretty output breaks lines between top-level fields only
+        let result: String = conn.query_row(
+            r#"SELECT row_to_json('{"a":1,"b":{"c":2,"d":"x,y"}}', true)"#,
+            [],
+            |row| row.get(0)
+        ).unwrap();
+        assert_eq!(result, "{\"a\":1,\n \"b\":{\"c\":2,\"d\":\"x,y\"}}");
+    }
+    
+    #[test]
+    fn test_json_object_agg_null_key() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_json_functions(&conn).unwrap();
+        
+        let result: String = conn.query_row(
+            "SELECT json_object_agg(k, v) FROM (SELECT 'a' AS k, 1 AS v UNION ALL SELECT 'b', 2)",
+            [],
+            |row| row.get(0)
+        ).unwrap();
+        assert_eq!(result, r#"{"a":1,"b":2}"#);
+        
+        for function in ["json_object_agg", "jsonb_object_agg"] {
+            let err = conn.query_row(
+                &format!("SELECT {function}(k, v) FROM (SELECT 'a' AS k, 1 AS v UNION ALL SELECT NULL, 2)"),
+                [],
+                |row| row.get::<_, String>(0)
+            ).unwrap_err();
+            assert!(err.to_string().contains("null value not allowed for object key"));
+        }
     }
     
     #[test]
@@ -2284,6 +2567,27 @@ mod tests {
         assert_eq!(result, Some("[]".to_string()));
     }
     
+    #[test]
+    fn test_strip_nulls_and_pretty_indent() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_json_functions(&conn).unwrap();
+        
+        let text = |sql: &str| -> Option<String> { conn.query_row(sql, [], |row| row.get(0)).unwrap() };
+        
+        assert_eq!(
+            text(r#"SELECT jsonb_strip_nulls('{"a":1,"b":null,"c":{"d":null,"e":[1,null]}}')"#).as_deref(),
+            Some(r#"{"a":1,"c":{"e":[1,null]}}"#)
+        );
+        assert_eq!(text("SELECT json_strip_nulls('[null,{\"x\":null}]')").as_deref(), Some("[null,{}]"));
+        assert_eq!(text("SELECT jsonb_strip_nulls(NULL)"), None);
+        
+        assert_eq!(
+            text(r#"SELECT jsonb_pretty('{"a":1,"b":[true]}')"#).as_deref(),
+            Some("{\n    \"a\": 1,\n    \"b\": [\n        true\n    ]\n}")
+        );
+        assert_eq!(text("SELECT jsonb_pretty(NULL)"), None);
+    }
+    
     #[test]
     fn test_json_populate_record_function() {
         let conn = Connection::open_in_memory().unwrap();
@@ -0,0 +1,524 @@
+use std::sync::Arc;
+use parking_lot::Mutex;
+use rusqlite::{Connection, OptionalExtension, Result, params, functions::{Context, FunctionFlags}};
+use rusqlite::types::{Null, ValueRef};
+use tracing::debug;
+
+/// Large objects are stored in pages of this many bytes, like PostgreSQL's LOBLKSIZE
+const PAGE_SIZE: i64 = 2048;
+
+/// The lo_open() mode bit asking for write access
+const INV_WRITE: i64 = 0x20000;
+
+/// The oid of the first large object created with lo_create(0)
+const FIRST_OID: i64 = 16384;
+
+/// The functions that write large objects or move descriptors when they run
+const LARGE_OBJECT_FUNCTIONS: &[&str] = &[
+    "lo_creat", "lo_create", "lo_open", "lo_close", "loread", "lowrite", "lo_lseek", "lo_lseek64",
+    "lo_tell", "lo_tell64", "lo_truncate", "lo_truncate64", "lo_unlink", "lo_put", "lo_from_bytea",
+];
+
+/// A large object opened with lo_open(), which returns its index in the connection's table
+struct Descriptor {
+    oid: i64,
+    offset: i64,
+    writable: bool,
+}
+
+type Descriptors = Arc<Mutex<Vec<Option<Descriptor>>>>;
+
+/// Register the large object functions. Objects live in `__pgsqlite_largeobject_metadata` and
+/// their data in 2 kB pages in `__pgsqlite_largeobject`; descriptors belong to the connection
+/// and stay open until lo_close() or the end of the session.
+pub fn register_large_object_functions(conn: &Connection) -> Result<()> {
+    debug!("Registering large object functions");
+    let descriptors: Descriptors = Arc::new(Mutex::new(Vec::new()));
+
+    // lo_creat(mode) - create a large object with a new oid
+    conn.create_scalar_function("lo_creat", 1, FunctionFlags::SQLITE_UTF8, |ctx| {
+        if has_null(ctx) {
+            return Ok(None);
+        }
+        with_connection(ctx, |conn| create(conn, 0)).map(Some)
+    })?;
+
+    // lo_create(oid) - create a large object, with a new oid when given 0
+    conn.create_scalar_function("lo_create", 1, FunctionFlags::SQLITE_UTF8, |ctx| {
+        if has_null(ctx) {
+            return Ok(None);
+        }
+        let oid = ctx.get::<i64>(0)?;
+        with_connection(ctx, |conn| create(conn, oid)).map(Some)
+    })?;
+
+    // lo_open(oid, mode) - open a large object for reading, or for writing with INV_WRITE
+    let open_descriptors = descriptors.clone();
+    conn.create_scalar_function("lo_open", 2, FunctionFlags::SQLITE_UTF8, move |ctx| {
+        if has_null(ctx) {
+            return Ok(None);
+        }
+        let oid = ctx.get::<i64>(0)?;
+        let mode = ctx.get::<i64>(1)?;
+        with_connection(ctx, |conn| require_exists(conn, oid))?;
+
+        let descriptor = Descriptor { oid, offset: 0, writable: mode & INV_WRITE != 0 };
+        let mut table = open_descriptors.lock();
+        let fd = match table.iter().position(Option::is_none) {
+            Some(fd) => {
+                table[fd] = Some(descriptor);
+                fd
+            }
+            None => {
+                table.push(Some(descriptor));
+                table.len() - 1
+            }
+        };
+        Ok(Some(fd as i32))
+    })?;
+
+    // lo_close(fd) - close a descriptor
+    let close_descriptors = descriptors.clone();
+    conn.create_scalar_function("lo_close", 1, FunctionFlags::SQLITE_UTF8, move |ctx| {
+        if has_null(ctx) {
+            return Ok(None);
+        }
+        let fd = ctx.get::<i32>(0)?;
+        let mut table = close_descriptors.lock();
+        match usize::try_from(fd).ok().and_then(|i| table.get_mut(i)).and_then(Option::take) {
+            Some(_) => Ok(Some(0)),
+            None => Err(invalid_descriptor(fd)),
+        }
+    })?;
+
+    // loread(fd, length) - read from the descriptor's position, moving it past the data
+    let read_descriptors = descriptors.clone();
+    conn.create_scalar_function("loread", 2, FunctionFlags::SQLITE_UTF8, move |ctx| {
+        if has_null(ctx) {
+            return Ok(None);
+        }
+        let fd = ctx.get::<i32>(0)?;
+        let length = ctx.get::<i64>(1)?.max(0);
+        with_descriptor(&read_descriptors, fd, |descriptor| {
+            let data = with_connection(ctx, |conn| read(conn, descriptor.oid, descriptor.offset, length))?;
+            descriptor.offset += data.len() as i64;
+            Ok(Some(data))
+        })
+    })?;
+
+    // lowrite(fd, data) - write at the descriptor's position, returning the number of bytes
+    let write_descriptors = descriptors.clone();
+    conn.create_scalar_function("lowrite", 2, FunctionFlags::SQLITE_UTF8, move |ctx| {
+        if has_null(ctx) {
+            return Ok(None);
+        }
+        let fd = ctx.get::<i32>(0)?;
+        let data = bytea_arg(ctx, 1)?;
+        with_descriptor(&write_descriptors, fd, |descriptor| {
+            require_writable(fd, descriptor)?;
+            with_connection(ctx, |conn| write(conn, descriptor.oid, descriptor.offset, &data))?;
+            descriptor.offset += data.len() as i64;
+            Ok(Some(data.len() as i32))
+        })
+    })?;
+
+    // lo_lseek(fd, offset, whence) - move the descriptor's position, relative to the start
+    // (0), the current position (1) or the end (2)
+    for name in ["lo_lseek", "lo_lseek64"] {
+        let seek_descriptors = descriptors.clone();
+        conn.create_scalar_function(name, 3, FunctionFlags::SQLITE_UTF8, move |ctx| {
+            if has_null(ctx) {
+                return Ok(None);
+            }
+            let fd = ctx.get::<i32>(0)?;
+            let offset = ctx.get::<i64>(1)?;
+            let whence = ctx.get::<i32>(2)?;
+            with_descriptor(&seek_descriptors, fd, |descriptor| {
+                let base = match whence {
+                    0 => 0,
+                    1 => descriptor.offset,
+                    2 => with_connection(ctx, |conn| size(conn, descriptor.oid))?,
+                    _ => return Err(lo_error(format!("invalid whence setting: {whence}"))),
+                };
+                let position = base + offset;
+                if position < 0 {
+                    return Err(lo_error(format!("invalid seek offset: {position}")));
+                }
+                descriptor.offset = position;
+                Ok(Some(position))
+            })
+        })?;
+    }
+
+    // lo_tell(fd) - the descriptor's position
+    for name in ["lo_tell", "lo_tell64"] {
+        let tell_descriptors = descriptors.clone();
+        conn.create_scalar_function(name, 1, FunctionFlags::SQLITE_UTF8, move |ctx| {
+            if has_null(ctx) {
+                return Ok(None);
+            }
+            let fd = ctx.get::<i32>(0)?;
+            with_descriptor(&tell_descriptors, fd, |descriptor| Ok(Some(descriptor.offset)))
+        })?;
+    }
+
+    // lo_truncate(fd, length) - cut the object short or extend it with zeros
+    for name in ["lo_truncate", "lo_truncate64"] {
+        let truncate_descriptors = descriptors.clone();
+        conn.create_scalar_function(name, 2, FunctionFlags::SQLITE_UTF8, move |ctx| {
+            if has_null(ctx) {
+                return Ok(None);
+            }
+            let fd = ctx.get::<i32>(0)?;
+            let length = ctx.get::<i64>(1)?;
+            if length < 0 {
+                return Err(lo_error(format!("invalid large object truncation length: {length}")));
+            }
+            with_descriptor(&truncate_descriptors, fd, |descriptor| {
+                require_writable(fd, descriptor)?;
+                with_connection(ctx, |conn| truncate(conn, descriptor.oid, length))?;
+                Ok(Some(0))
+            })
+        })?;
+    }
+
+    // lo_unlink(oid) - delete a large object
+    conn.create_scalar_function("lo_unlink", 1, FunctionFlags::SQLITE_UTF8, |ctx| {
+        if has_null(ctx) {
+            return Ok(None);
+        }
+        let oid = ctx.get::<i64>(0)?;
+        with_connection(ctx, |conn| {
+            require_exists(conn, oid)?;
+            conn.execute("DELETE FROM __pgsqlite_largeobject WHERE loid = ?1", [oid])?;
+            conn.execute("DELETE FROM __pgsqlite_largeobject_metadata WHERE oid = ?1", [oid])?;
+            Ok(Some(1))
+        })
+    })?;
+
+    // lo_get(oid) - the whole content of a large object
+    conn.create_scalar_function("lo_get", 1, FunctionFlags::SQLITE_UTF8, |ctx| {
+        if has_null(ctx) {
+            return Ok(None);
+        }
+        let oid = ctx.get::<i64>(0)?;
+        with_connection(ctx, |conn| {
+            require_exists(conn, oid)?;
+            read(conn, oid, 0, i64::MAX).map(Some)
+        })
+    })?;
+
+    // lo_get(oid, offset, length) - part of the content of a large object
+    conn.create_scalar_function("lo_get", 3, FunctionFlags::SQLITE_UTF8, |ctx| {
+        if has_null(ctx) {
+            return Ok(None);
+        }
+        let oid = ctx.get::<i64>(0)?;
+        let offset = ctx.get::<i64>(1)?;
+        let length = ctx.get::<i64>(2)?;
+        if offset < 0 || length < 0 {
+            return Err(lo_error("invalid large object seek or length".to_string()));
+        }
+        with_connection(ctx, |conn| {
+            require_exists(conn, oid)?;
+            read(conn, oid, offset, length).map(Some)
+        })
+    })?;
+
+    // lo_put(oid, offset, data) - write data into a large object at offset
+    conn.create_scalar_function("lo_put", 3, FunctionFlags::SQLITE_UTF8, |ctx| {
+        if has_null(ctx) {
+            return Ok(Null);
+        }
+        let oid = ctx.get::<i64>(0)?;
+        let offset = ctx.get::<i64>(1)?;
+        let data = bytea_arg(ctx, 2)?;
+        if offset < 0 {
+            return Err(lo_error(format!("invalid seek offset: {offset}")));
+        }
+        with_connection(ctx, |conn| {
+            require_exists(conn, oid)?;
+            write(conn, oid, offset, &data)
+        })?;
+        Ok(Null)
+    })?;
+
+    // lo_from_bytea(oid, data) - create a large object holding data, with a new oid when given 0
+    conn.create_scalar_function("lo_from_bytea", 2, FunctionFlags::SQLITE_UTF8, |ctx| {
+        if has_null(ctx) {
+            return Ok(None);
+        }
+        let oid = ctx.get::<i64>(0)?;
+        let data = bytea_arg(ctx, 1)?;
+        with_connection(ctx, |conn| {
+            let oid = create(conn, oid)?;
+            write(conn, oid, 0, &data)?;
+            Ok(Some(oid))
+        })
+    })?;
+
+    Ok(())
+}
+
+/// Check if the query calls a function that changes large objects or descriptors, so it must
+/// not be run just to describe its result
+pub fn calls_large_object_functions(query: &str) -> bool {
+    let lower = query.to_lowercase();
+    (lower.contains("lo_") || lower.contains("lowrite") || lower.contains("loread"))
+        && LARGE_OBJECT_FUNCTIONS.iter().any(|name| {
+            lower.match_indices(name).any(|(at, _)| {
+                let before = lower[..at].chars().next_back();
+                let after = lower[at + name.len()..].trim_start().chars().next();
+                !before.is_some_and(|c| c.is_alphanumeric() || c == '_') && after == Some('(')
+            })
+        })
+}
+
+/// PostgreSQL's large object functions are strict
+fn has_null(ctx: &Context<'_>) -> bool {
+    (0..ctx.len()).any(|i| matches!(ctx.get_raw(i), ValueRef::Null))
+}
+
+/// Run `f` on the connection the function was called on
+fn with_connection<T>(ctx: &Context<'_>, f: impl FnOnce(&Connection) -> Result<T>) -> Result<T> {
+    // SAFETY: the connection is only used for statements on the large object tables while
+    // the calling statement runs, and is never closed from here
+    let conn = unsafe { ctx.get_connection()? };
+    f(&conn)
+}
+
+fn with_descriptor<T>(descriptors: &Descriptors, fd: i32, f: impl FnOnce(&mut Descriptor) -> Result<T>) -> Result<T> {
+    let mut table = descriptors.lock();
+    match usize::try_from(fd).ok().and_then(|i| table.get_mut(i)).and_then(Option::as_mut) {
+        Some(descriptor) => f(descriptor),
+        None => Err(invalid_descriptor(fd)),
+    }
+}
+
+fn lo_error(message: String) -> rusqlite::Error {
+    rusqlite::Error::UserFunctionError(message.into())
+}
+
+fn invalid_descriptor(fd: i32) -> rusqlite::Error {
+    lo_error(format!("invalid large-object descriptor: {fd}"))
+}
+
+fn require_writable(fd: i32, descriptor: &Descriptor) -> Result<()> {
+    if descriptor.writable {
+        Ok(())
+    } else {
+        Err(lo_error(format!("large object descriptor {fd} was not opened for writing")))
+    }
+}
+
+/// A bytea argument: a blob, or text in the hex format `\x...`
+fn bytea_arg(ctx: &Context<'_>, i: usize) -> Result<Vec<u8>> {
+    match ctx.get_raw(i) {
+        ValueRef::Blob(bytes) => Ok(bytes.to_vec()),
+        ValueRef::Text(text) => match text.strip_prefix(b"\\x") {
+            Some(digits) => hex::decode(digits)
+                .map_err(|_| lo_error("invalid hexadecimal data".to_string())),
+            None => Ok(text.to_vec()),
+        },
+        other => Err(rusqlite::Error::InvalidFunctionParameterType(i, other.data_type())),
+    }
+}
+
+fn require_exists(conn: &Connection, oid: i64) -> Result<()> {
+    let exists: bool = conn.query_row(
+        "SELECT EXISTS (SELECT 1 FROM __pgsqlite_largeobject_metadata WHERE oid = ?1)",
+        [oid],
+        |row| row.get(0),
+    )?;
+    if exists {
+        Ok(())
+    } else {
+        Err(lo_error(format!("large object {oid} does not exist")))
+    }
+}
+
+/// Create an empty large object, choosing the next free oid for 0
+fn create(conn: &Connection, oid: i64) -> Result<i64> {
+    let oid = if oid == 0 {
+        conn.query_row(
+            "SELECT COALESCE(MAX(oid) + 1, ?1) FROM __pgsqlite_largeobject_metadata",
+            [FIRST_OID],
+            |row| row.get(0),
+        )?
+    } else if require_exists(conn, oid).is_ok() {
+        return Err(lo_error(format!("large object {oid} already exists")));
+    } else {
+        oid
+    };
+    conn.execute("INSERT INTO __pgsqlite_largeobject_metadata (oid) VALUES (?1)", [oid])?;
+    Ok(oid)
+}
+
+/// The length of a large object: the end of its last page
+fn size(conn: &Connection, oid: i64) -> Result<i64> {
+    let size = conn.query_row(
+        "SELECT pageno * ?2 + length(data) FROM __pgsqlite_largeobject
+         WHERE loid = ?1 ORDER BY pageno DESC LIMIT 1",
+        params![oid, PAGE_SIZE],
+        |row| row.get(0),
+    ).optional()?;
+    Ok(size.unwrap_or(0))
+}
+
+/// Up to `length` bytes from `offset`, fewer past the end of the object
+fn read(conn: &Connection, oid: i64, offset: i64, length: i64) -> Result<Vec<u8>> {
+    let end = offset.saturating_add(length).min(size(conn, oid)?);
+    if offset >= end {
+        return Ok(Vec::new());
+    }
+
+    // Pages that were never written read as zeros
+    let mut data = vec![0u8; (end - offset) as usize];
+    let mut stmt = conn.prepare(
+        "SELECT pageno, data FROM __pgsqlite_largeobject WHERE loid = ?1 AND pageno BETWEEN ?2 AND ?3"
+    )?;
+    let pages = stmt.query_map(params![oid, offset / PAGE_SIZE, (end - 1) / PAGE_SIZE], |row| {
+        Ok((row.get::<_, i64>(0)?, row.get::<_, Vec<u8>>(1)?))
+    })?;
+    for page in pages {
+        let (pageno, page) = page?;
+        let page_start = pageno * PAGE_SIZE;
+        let from = offset.max(page_start);
+        let to = end.min(page_start + page.len() as i64);
+        if from < to {
+            data[(from - offset) as usize..(to - offset) as usize]
+                .copy_from_slice(&page[(from - page_start) as usize..(to - page_start) as usize]);
+        }
+    }
+    Ok(data)
+}
+
+/// Write `bytes` at `offset`, rewriting the pages it touches
+fn write(conn: &Connection, oid: i64, offset: i64, bytes: &[u8]) -> Result<()> {
+    if bytes.is_empty() {
+        return Ok(());
+    }
+    let end = offset + bytes.len() as i64;
+    for pageno in offset / PAGE_SIZE..=(end - 1) / PAGE_SIZE {
+        let page_start = pageno * PAGE_SIZE;
+        let mut page: Vec<u8> = conn.query_row(
+            "SELECT data FROM __pgsqlite_largeobject WHERE loid = ?1 AND pageno = ?2",
+            params![oid, pageno],
+            |row| row.get(0),
+        ).optional()?.unwrap_or_default();
+
+        let from = offset.max(page_start);
+        let to = end.min(page_start + PAGE_SIZE);
+        let page_end = (to - page_start) as usize;
+        if page.len() < page_end {
+            page.resize(page_end, 0);
+        }
+        page[(from - page_start) as usize..page_end]
+            .copy_from_slice(&bytes[(from - offset) as usize..(to - offset) as usize]);
+
+        conn.execute(
+            "INSERT OR REPLACE INTO __pgsqlite_largeobject (loid, pageno, data) VALUES (?1, ?2, ?3)",
+            params![oid, pageno, page],
+        )?;
+    }
+    Ok(())
+}
+
+/// Cut the object to `length` bytes, or extend it with zeros
+fn truncate(conn: &Connection, oid: i64, length: i64) -> Result<()> {
+    conn.execute(
+        "DELETE FROM __pgsqlite_largeobject WHERE loid = ?1 AND pageno * ?2 >= ?3",
+        params![oid, PAGE_SIZE, length],
+    )?;
+    if length > 0 {
+        let last_page = (length - 1) / PAGE_SIZE;
+        conn.execute(
+            "UPDATE __pgsqlite_largeobject SET data = substr(data, 1, ?3) WHERE loid = ?1 AND pageno = ?2",
+            params![oid, last_page, length - last_page * PAGE_SIZE],
+        )?;
+        if size(conn, oid)? < length {
+            write(conn, oid, length - 1, &[0])?;
+        }
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn setup() -> Connection {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "CREATE TABLE __pgsqlite_largeobject_metadata (oid INTEGER PRIMARY KEY, lomowner INTEGER NOT NULL DEFAULT 10);
+             CREATE TABLE __pgsqlite_largeobject (loid INTEGER NOT NULL, pageno INTEGER NOT NULL, data BLOB NOT NULL,
+                                                  PRIMARY KEY (loid, pageno));"
+        ).unwrap();
+        register_large_object_functions(&conn).unwrap();
+        conn
+    }
+
+    #[test]
+    fn test_descriptor_read_write() {
+        let conn = setup();
+        let oid: i64 = conn.query_row("SELECT lo_create(0)", [], |row| row.get(0)).unwrap();
+        assert_eq!(oid, FIRST_OID);
+
+        let fd: i32 = conn.query_row("SELECT lo_open(?1, 131072 | 262144)", [oid], |row| row.get(0)).unwrap();
+        let written: i32 = conn.query_row("SELECT lowrite(?1, X'48656C6C6F20776F726C64')", [fd], |row| row.get(0)).unwrap();
+        assert_eq!(written, 11);
+
+        // Overwrite in the middle, then read it all back
+        conn.query_row("SELECT lo_lseek(?1, 6, 0)", [fd], |row| row.get::<_, i64>(0)).unwrap();
+        conn.query_row("SELECT lowrite(?1, '\\x5745')", [fd], |row| row.get::<_, i32>(0)).unwrap();
+        assert_eq!(conn.query_row("SELECT lo_tell(?1)", [fd], |row| row.get::<_, i64>(0)).unwrap(), 8);
+        conn.query_row("SELECT lo_lseek(?1, 0, 0)", [fd], |row| row.get::<_, i64>(0)).unwrap();
+        let data: Vec<u8> = conn.query_row("SELECT loread(?1, 100)", [fd], |row| row.get(0)).unwrap();
+        assert_eq!(data, b"Hello WErld");
+
+        assert_eq!(conn.query_row("SELECT lo_close(?1)", [fd], |row| row.get::<_, i32>(0)).unwrap(), 0);
+        let err = conn.query_row("SELECT loread(?1, 1)", [fd], |row| row.get::<_, Vec<u8>>(0)).unwrap_err();
+        assert!(err.to_string().contains("invalid large-object descriptor"));
+
+        // A read-only descriptor can't write
+        let fd: i32 = conn.query_row("SELECT lo_open(?1, 262144)", [oid], |row| row.get(0)).unwrap();
+        let err = conn.query_row("SELECT lowrite(?1, X'00')", [fd], |row| row.get::<_, i32>(0)).unwrap_err();
+        assert!(err.to_string().contains("was not opened for writing"));
+    }
+
+    #[test]
+    fn test_pages_and_truncate() {
+        let conn = setup();
+        let data: Vec<u8> = (0..5000).map(|i| (i % 251) as u8).collect();
+        let oid: i64 = conn.query_row("SELECT lo_from_bytea(0, ?1)", [&data], |row| row.get(0)).unwrap();
+        let pages: i64 = conn.query_row("SELECT count(*) FROM __pgsqlite_largeobject WHERE loid = ?1", [oid], |row| row.get(0)).unwrap();
+        assert_eq!(pages, 3);
+
+        let all: Vec<u8> = conn.query_row("SELECT lo_get(?1)", [oid], |row| row.get(0)).unwrap();
+        assert_eq!(all, data);
+        let part: Vec<u8> = conn.query_row("SELECT lo_get(?1, 2040, 20)", [oid], |row| row.get(0)).unwrap();
+        assert_eq!(part, data[2040..2060]);
+
+        // Writing past the end leaves a hole of zeros
+        conn.query_row("SELECT lo_put(?1, 6000, X'FF')", [oid], |_| Ok(())).unwrap();
+        let tail: Vec<u8> = conn.query_row("SELECT lo_get(?1, 4998, 10)", [oid], |row| row.get(0)).unwrap();
+        assert_eq!(tail, [data[4998], data[4999], 0, 0, 0, 0, 0, 0, 0, 0]);
+
+        let fd: i32 = conn.query_row("SELECT lo_open(?1, 131072)", [oid], |row| row.get(0)).unwrap();
+        conn.query_row("SELECT lo_truncate(?1, 2050)", [fd], |row| row.get::<_, i32>(0)).unwrap();
+        let end: i64 = conn.query_row("SELECT lo_lseek64(?1, 0, 2)", [fd], |row| row.get(0)).unwrap();
+        assert_eq!(end, 2050);
+
+        assert_eq!(conn.query_row("SELECT lo_unlink(?1)", [oid], |row| row.get::<_, i32>(0)).unwrap(), 1);
+        let err = conn.query_row("SELECT lo_get(?1)", [oid], |row| row.get::<_, Vec<u8>>(0)).unwrap_err();
+        assert!(err.to_string().contains(&format!("large object {oid} does not exist")));
+    }
+
+    #[test]
+    fn test_calls_large_object_functions() {
+        assert!(calls_large_object_functions("SELECT lo_create(0)"));
+        assert!(calls_large_object_functions("SELECT LOWRITE ($1, $2)"));
+        assert!(!calls_large_object_functions("SELECT lo_get(12345)"));
+        assert!(!calls_large_object_functions("SELECT hello_create(1), lo_open FROM t"));
+    }
+}
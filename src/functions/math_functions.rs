@@ -1,6 +1,8 @@
 use rusqlite::{Connection, Result, functions::{FunctionFlags, Context}};
 use tracing::debug;
 use rust_decimal::Decimal;
+use serde_json::Value as JsonValue;
+use std::str::FromStr;
 
 /// Helper function to get a numeric value from context, handling both numeric and text inputs
 fn get_numeric_value(ctx: &Context<'_>, idx: usize) -> Result<f64> {
@@ -50,22 +52,176 @@ fn get_numeric_value(ctx: &Context<'_>, idx: usize) -> Result<f64> {
     }
 }
 
+/// Helper function to get an exact decimal from context for numeric-typed results. Floats are
+/// read through their shortest decimal form, so 2.675 stays 2.675 instead of 2.67499999...
+fn get_decimal_value(ctx: &Context<'_>, idx: usize) -> Result<Option<Decimal>> {
+    let text = match ctx.get_raw(idx) {
+        rusqlite::types::ValueRef::Null => return Ok(None),
+        rusqlite::types::ValueRef::Integer(i) => return Ok(Some(Decimal::from(i))),
+        rusqlite::types::ValueRef::Real(f) => f.to_string(),
+        rusqlite::types::ValueRef::Text(s) => {
+            std::str::from_utf8(s).map_err(|e| rusqlite::Error::UserFunctionError(Box::new(e)))?.trim().to_string()
+        }
+        rusqlite::types::ValueRef::Blob(b) => {
+            // Decimal values are stored as 16-byte blobs
+            let array: [u8; 16] = b.try_into().map_err(|_| rusqlite::Error::UserFunctionError(
+                format!("Invalid blob size for numeric function: {} bytes", b.len()).into()
+            ))?;
+            return Ok(Some(Decimal::deserialize(array)));
+        }
+    };
+    
+    Decimal::from_str(&text)
+        .or_else(|_| Decimal::from_scientific(&text))
+        .map(Some)
+        .map_err(|e| rusqlite::Error::UserFunctionError(format!("Failed to parse '{text}' as numeric: {e}").into()))
+}
+
+/// Whole-number results go back to SQLite as integers when they fit, so they stay exact
+fn integral_value(value: Decimal) -> rusqlite::types::Value {
+    match i64::try_from(value) {
+        Ok(i) => rusqlite::types::Value::Integer(i),
+        Err(_) => rusqlite::types::Value::Real(value.to_string().parse().unwrap_or(f64::NAN)),
+    }
+}
+
+/// Numeric values arrive as text or decimal blobs, integers and floats as themselves
+fn is_numeric_argument(ctx: &Context<'_>, idx: usize) -> bool {
+    matches!(ctx.get_raw(idx), rusqlite::types::ValueRef::Text(_) | rusqlite::types::ValueRef::Blob(_))
+}
+
+/// width_bucket(operand, low, high, count): the bucket of `count` equal-width buckets over
+/// [low, high) the operand falls in, 0 below the range and count + 1 above it.
+/// A range with low > high counts buckets downwards, as in PostgreSQL.
+fn width_bucket(operand: Decimal, low: Decimal, high: Decimal, count: i64) -> Result<i64> {
+    if count <= 0 {
+        return Err(rusqlite::Error::UserFunctionError("count must be greater than zero".into()));
+    }
+    if low == high {
+        return Err(rusqlite::Error::UserFunctionError("lower bound cannot equal upper bound".into()));
+    }
+    
+    // Multiply before dividing so bucket edges land exactly on whole buckets
+    let position = if low < high {
+        if operand < low {
+            return Ok(0);
+        }
+        if operand >= high {
+            return Ok(count + 1);
+        }
+        (operand - low) * Decimal::from(count) / (high - low)
+    } else {
+        if operand > low {
+            return Ok(0);
+        }
+        if operand <= high {
+            return Ok(count + 1);
+        }
+        (low - operand) * Decimal::from(count) / (low - high)
+    };
+    
+    i64::try_from(position.floor())
+        .map(|bucket| bucket + 1)
+        .map_err(|e| rusqlite::Error::UserFunctionError(Box::new(e)))
+}
+
+/// width_bucket(operand, thresholds): the number of thresholds less than or equal to the operand.
+/// Thresholds are a sorted array (stored as JSON) of numbers, or of text compared as strings.
+fn width_bucket_thresholds(ctx: &Context<'_>) -> Result<Option<i64>> {
+    let Some(thresholds) = ctx.get::<Option<String>>(1)? else {
+        return Ok(None);
+    };
+    let thresholds: Vec<JsonValue> = serde_json::from_str(&thresholds)
+        .map_err(|e| rusqlite::Error::UserFunctionError(format!("thresholds must be an array: {e}").into()))?;
+    if thresholds.iter().any(JsonValue::is_null) {
+        return Err(rusqlite::Error::UserFunctionError("thresholds array must not contain NULLs".into()));
+    }
+    
+    let numeric_thresholds: Option<Vec<Decimal>> = thresholds.iter()
+        .map(|t| match t {
+            JsonValue::Number(n) => Decimal::from_str(&n.to_string()).or_else(|_| Decimal::from_scientific(&n.to_string())).ok(),
+            JsonValue::String(s) => Decimal::from_str(s).ok(),
+            _ => None,
+        })
+        .collect();
+    
+    // PostgreSQL binary-searches the thresholds, so they are expected in ascending order
+    match (get_decimal_value(ctx, 0), numeric_thresholds) {
+        (Ok(None), _) => Ok(None),
+        (Ok(Some(operand)), Some(numeric)) => Ok(Some(numeric.partition_point(|t| *t <= operand) as i64)),
+        _ => {
+            let Some(operand) = ctx.get::<Option<String>>(0)? else {
+                return Ok(None);
+            };
+            let text: Vec<String> = thresholds.iter()
+                .map(|t| t.as_str().map(str::to_string).unwrap_or_else(|| t.to_string()))
+                .collect();
+            Ok(Some(text.partition_point(|t| t.as_str() <= operand.as_str()) as i64))
+        }
+    }
+}
+
+/// divide(a, b): the `/` operator. Two integers divide with truncation towards zero,
+/// numeric text divides exactly, anything else as float8; a zero divisor raises
+/// division_by_zero as in PostgreSQL instead of giving SQLite's NULL
+fn divide(ctx: &Context<'_>) -> Result<Option<rusqlite::types::Value>> {
+    use rusqlite::types::{Value, ValueRef};
+
+    match (ctx.get_raw(0), ctx.get_raw(1)) {
+        (ValueRef::Null, _) | (_, ValueRef::Null) => return Ok(None),
+        (ValueRef::Integer(dividend), ValueRef::Integer(divisor)) => {
+            if divisor == 0 {
+                return Err(rusqlite::Error::UserFunctionError("division by zero".into()));
+            }
+            return dividend.checked_div(divisor)
+                .map(|quotient| Some(Value::Integer(quotient)))
+                .ok_or_else(|| rusqlite::Error::UserFunctionError("bigint out of range".into()));
+        }
+        _ => {}
+    }
+
+    if is_numeric_argument(ctx, 0) || is_numeric_argument(ctx, 1) {
+        let (Some(dividend), Some(divisor)) = (get_decimal_value(ctx, 0)?, get_decimal_value(ctx, 1)?) else {
+            return Ok(None);
+        };
+        if divisor.is_zero() {
+            return Err(rusqlite::Error::UserFunctionError("division by zero".into()));
+        }
+        let quotient = dividend.checked_div(divisor)
+            .ok_or_else(|| rusqlite::Error::UserFunctionError("value out of range: overflow".into()))?;
+        return Ok(Some(Value::Text(quotient.normalize().to_string())));
+    }
+
+    let dividend = get_numeric_value(ctx, 0)?;
+    let divisor = get_numeric_value(ctx, 1)?;
+    if divisor == 0.0 {
+        return Err(rusqlite::Error::UserFunctionError("division by zero".into()));
+    }
+    Ok(Some(Value::Real(dividend / divisor)))
+}
+
 /// Register all PostgreSQL math functions
 pub fn register_math_functions(conn: &Connection) -> Result<()> {
     debug!("Registering math functions");
     
+    // Register divide, which the / operator is translated to
+    conn.create_scalar_function(
+        "divide",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        divide,
+    )?;
+    
     // Register trunc function (truncate towards zero)
     conn.create_scalar_function(
         "trunc",
         1,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let value = get_numeric_value(ctx, 0)?;
-            Ok(value.trunc())
-        },
+        |ctx| Ok(get_decimal_value(ctx, 0)?.map(|value| integral_value(value.trunc()))),
     )?;
     
-    // Register trunc function with precision
+    // Register trunc function with precision - numeric columns are rewritten to
+    // decimal_trunc, which keeps the scale as trunc(numeric, int) does
     conn.create_scalar_function(
         "trunc",
         2,
@@ -83,7 +239,8 @@ pub fn register_math_functions(conn: &Connection) -> Result<()> {
         },
     )?;
     
-    // Register round function with precision
+    // Register round function with precision - numeric columns are rewritten to
+    // decimal_round, which keeps the scale as round(numeric, int) does
     conn.create_scalar_function(
         "round",
         2,
@@ -101,15 +258,37 @@ pub fn register_math_functions(conn: &Connection) -> Result<()> {
         },
     )?;
     
+    // Register width_bucket over an equal-width range
+    conn.create_scalar_function(
+        "width_bucket",
+        4,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let (Some(operand), Some(low), Some(high)) = (get_decimal_value(ctx, 0)?, get_decimal_value(ctx, 1)?, get_decimal_value(ctx, 2)?) else {
+                return Ok(None);
+            };
+            let Some(count) = ctx.get::<Option<i64>>(3)? else {
+                return Ok(None);
+            };
+            
+            width_bucket(operand, low, high, count).map(Some)
+        },
+    )?;
+    
+    // Register width_bucket over an array of bucket thresholds
+    conn.create_scalar_function(
+        "width_bucket",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        width_bucket_thresholds,
+    )?;
+    
     // Register ceil function (ceiling)
     conn.create_scalar_function(
         "ceil",
         1,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let value = ctx.get::<f64>(0)?;
-            Ok(value.ceil())
-        },
+        |ctx| Ok(get_decimal_value(ctx, 0)?.map(|value| integral_value(value.ceil()))),
     )?;
     
     // Register ceiling function (alias for ceil)
@@ -117,10 +296,7 @@ pub fn register_math_functions(conn: &Connection) -> Result<()> {
         "ceiling",
         1,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let value = ctx.get::<f64>(0)?;
-            Ok(value.ceil())
-        },
+        |ctx| Ok(get_decimal_value(ctx, 0)?.map(|value| integral_value(value.ceil()))),
     )?;
     
     // Register floor function
@@ -128,10 +304,7 @@ pub fn register_math_functions(conn: &Connection) -> Result<()> {
         "floor",
         1,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
-        |ctx| {
-            let value = ctx.get::<f64>(0)?;
-            Ok(value.floor())
-        },
+        |ctx| Ok(get_decimal_value(ctx, 0)?.map(|value| integral_value(value.floor()))),
     )?;
     
     // Register sign function
@@ -162,20 +335,29 @@ pub fn register_math_functions(conn: &Connection) -> Result<()> {
         },
     )?;
     
-    // Register mod function (modulo)
+    // Register mod function (modulo) - integers stay integers, other values are float8;
+    // numeric columns are rewritten to decimal_mod
     conn.create_scalar_function(
         "mod",
         2,
         FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
         |ctx| {
-            let dividend = ctx.get::<f64>(0)?;
-            let divisor = ctx.get::<f64>(1)?;
+            if let (rusqlite::types::ValueRef::Integer(dividend), rusqlite::types::ValueRef::Integer(divisor)) = (ctx.get_raw(0), ctx.get_raw(1)) {
+                if divisor == 0 {
+                    return Err(rusqlite::Error::UserFunctionError("division by zero".into()));
+                }
+                // i64::MIN % -1 overflows, and its remainder is 0
+                return Ok(rusqlite::types::Value::Integer(dividend.checked_rem(divisor).unwrap_or(0)));
+            }
+            
+            let dividend = get_numeric_value(ctx, 0)?;
+            let divisor = get_numeric_value(ctx, 1)?;
             
             if divisor == 0.0 {
                 return Err(rusqlite::Error::UserFunctionError("division by zero".into()));
             }
             
-            Ok(dividend % divisor)
+            Ok(rusqlite::types::Value::Real(dividend % divisor))
         },
     )?;
     
@@ -463,6 +645,29 @@ mod tests {
         assert_eq!(result, 3.78);
     }
     
+    #[test]
+    fn test_width_bucket() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_math_functions(&conn).unwrap();
+        
+        let bucket = |sql: &str| conn.query_row(sql, [], |row| row.get::<_, i64>(0)).unwrap();
+        assert_eq!(bucket("SELECT width_bucket(42.5, 0, 100, 10)"), 5);
+        assert_eq!(bucket("SELECT width_bucket(40, 0, 100, 10)"), 5);
+        assert_eq!(bucket("SELECT width_bucket(-1, 0, 100, 10)"), 0);
+        assert_eq!(bucket("SELECT width_bucket(100, 0, 100, 10)"), 11);
+        // Exact arithmetic puts 0.3 on the edge of the second bucket
+        assert_eq!(bucket("SELECT width_bucket(0.3, 0, 0.9, 3)"), 2);
+        // Descending ranges count downwards
+        assert_eq!(bucket("SELECT width_bucket(80, 100, 0, 10)"), 3);
+        
+        assert_eq!(bucket("SELECT width_bucket(5, '[1, 3, 5, 7]')"), 3);
+        assert_eq!(bucket("SELECT width_bucket(0, '[1, 3, 5, 7]')"), 0);
+        assert_eq!(bucket("SELECT width_bucket('m', '[\"a\", \"k\", \"t\"]')"), 2);
+        
+        assert!(conn.query_row("SELECT width_bucket(5, 0, 100, 0)", [], |row| row.get::<_, i64>(0)).is_err());
+        assert!(conn.query_row("SELECT width_bucket(5, 10, 10, 5)", [], |row| row.get::<_, i64>(0)).is_err());
+    }
+    
     #[test]
     fn test_ceil_floor() {
         let conn = Connection::open_in_memory().unwrap();
@@ -481,6 +686,29 @@ mod tests {
             |row| row.get(0)
         ).unwrap();
         assert_eq!(result, 3.0);
+        
+        // Numeric text too long for a float stays exact
+        let result: i64 = conn.query_row("SELECT floor('9007199254740993.5')", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, 9007199254740993);
+        let result: i64 = conn.query_row("SELECT ceil(-0.5)", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, 0);
+    }
+    
+    #[test]
+    fn test_mod() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_math_functions(&conn).unwrap();
+        
+        let result: i64 = conn.query_row("SELECT mod(-7, 3)", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, -1);
+        
+        let result: f64 = conn.query_row("SELECT mod(7.5, 2)", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, 1.5);
+        let result: f64 = conn.query_row("SELECT mod('10.5', 3)", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, 1.5);
+        
+        assert!(conn.query_row("SELECT mod(1, 0)", [], |row| row.get::<_, i64>(0)).is_err());
+        assert!(conn.query_row("SELECT mod(1.5, 0)", [], |row| row.get::<_, f64>(0)).is_err());
     }
     
     #[test]
@@ -574,6 +802,26 @@ mod tests {
         assert_eq!(result, 2.0);
     }
     
+    #[test]
+    fn test_divide() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_math_functions(&conn).unwrap();
+        
+        let result: i64 = conn.query_row("SELECT divide(-7, 2)", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, -3);
+        let result: f64 = conn.query_row("SELECT divide(7.0, 2)", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, 3.5);
+        let result: String = conn.query_row("SELECT divide('1.5', 2)", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, "0.75");
+        let result: Option<i64> = conn.query_row("SELECT divide(1, NULLIF(0, 0))", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, None);
+        
+        for sql in ["SELECT divide(1, 0)", "SELECT divide(1.5, 0.0)", "SELECT divide('2.5', '0')"] {
+            let err = conn.query_row(sql, [], |row| row.get::<_, rusqlite::types::Value>(0)).unwrap_err();
+            assert!(err.to_string().contains("division by zero"), "{sql}: {err}");
+        }
+    }
+    
     #[test]
     fn test_angle_conversion() {
         let conn = Connection::open_in_memory().unwrap();
@@ -12,7 +12,16 @@ pub mod string_functions;
 pub mod math_functions;
 pub mod system_functions;
 pub mod fts_functions;
+pub mod text_search_config;
 pub mod comment_functions;
+pub mod range_functions;
+pub mod geometry_functions;
+pub mod large_object_functions;
+pub mod collation_functions;
+pub mod encoding_functions;
+pub mod digest_functions;
+pub mod bit_functions;
+pub mod xml_functions;
 
 use rusqlite::{Connection, Result};
 
@@ -31,5 +40,13 @@ pub fn register_all_functions(conn: &Connection) -> Result<()> {
     math_functions::register_math_functions(conn)?;
     system_functions::register_system_functions(conn)?;
     fts_functions::register_fts_functions(conn)?;
+    range_functions::register_range_functions(conn)?;
+    geometry_functions::register_geometry_functions(conn)?;
+    large_object_functions::register_large_object_functions(conn)?;
+    collation_functions::register_collations(conn)?;
+    encoding_functions::register_encoding_functions(conn)?;
+    digest_functions::register_digest_functions(conn)?;
+    bit_functions::register_bit_functions(conn)?;
+    xml_functions::register_xml_functions(conn)?;
     Ok(())
 }
\ No newline at end of file
@@ -0,0 +1,537 @@
+use rusqlite::{Connection, Result, functions::{Context, FunctionFlags}};
+use rusqlite::types::ValueRef;
+use chrono::{DateTime, NaiveDate, NaiveDateTime, TimeDelta};
+use std::cmp::Ordering;
+use tracing::debug;
+
+/// Range types with a constructor function of the same name
+pub const RANGE_TYPES: &[&str] = &["int4range", "int8range", "numrange", "daterange", "tsrange", "tstzrange"];
+
+/// Register functions operating on range values (int4range, tsrange, ...), which are
+/// stored as their text form such as `[2024-01-01 10:00,2024-01-01 12:00)`
+pub fn register_range_functions(conn: &Connection) -> Result<()> {
+    debug!("Registering range functions");
+
+    // range_overlaps(a, b) - the && operator on ranges
+    conn.create_scalar_function(
+        "range_overlaps",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            if matches!(ctx.get_raw(0), ValueRef::Null) || matches!(ctx.get_raw(1), ValueRef::Null) {
+                return Ok(None);
+            }
+            let a = ctx.get::<String>(0)?;
+            let b = ctx.get::<String>(1)?;
+            let a = Range::parse(&a).ok_or_else(|| malformed_range(&a))?;
+            let b = Range::parse(&b).ok_or_else(|| malformed_range(&b))?;
+            Ok(Some(a.overlaps(&b)))
+        },
+    )?;
+
+    // range_contains(range, range_or_element) - the @> operator, and <@ with swapped arguments
+    conn.create_scalar_function(
+        "range_contains",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            if matches!(ctx.get_raw(0), ValueRef::Null) || matches!(ctx.get_raw(1), ValueRef::Null) {
+                return Ok(None);
+            }
+            let a = ctx.get::<String>(0)?;
+            let a = Range::parse(&a).ok_or_else(|| malformed_range(&a))?;
+            let b = element_argument(ctx, 1, &a).unwrap_or_default();
+            if looks_like_range(&b) {
+                let b = Range::parse(&b).ok_or_else(|| malformed_range(&b))?;
+                Ok(Some(a.contains_range(&b)))
+            } else {
+                Ok(Some(a.contains_element(b.trim())))
+            }
+        },
+    )?;
+
+    // Constructors: int4range(lower, upper [, bounds]) and friends, plus a one-argument
+    // form that canonicalizes a range literal (used for '...'::int4range casts)
+    for &type_name in RANGE_TYPES {
+        for n_arg in 1..=3 {
+            conn.create_scalar_function(
+                type_name,
+                n_arg,
+                FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+                move |ctx| {
+                    let kind = RangeKind::from_name(type_name).expect("known range type");
+                    let result = if ctx.len() == 1 {
+                        match value_text(ctx, 0) {
+                            Some(text) => canonical_range(&text, type_name),
+                            None => return Ok(None),
+                        }
+                    } else {
+                        let bounds = if ctx.len() == 3 {
+                            value_text(ctx, 2).unwrap_or_else(|| "[)".to_string())
+                        } else {
+                            "[)".to_string()
+                        };
+                        let lower = bound_argument(ctx, 0, kind);
+                        let upper = bound_argument(ctx, 1, kind);
+                        construct_range(kind, type_name, lower.as_deref(), upper.as_deref(), &bounds)
+                    };
+                    result.map(Some).map_err(|e| rusqlite::Error::UserFunctionError(e.into()))
+                },
+            )?;
+        }
+    }
+
+    Ok(())
+}
+
+/// Canonical text of a range literal for the given range type: discrete types (int4range,
+/// int8range, daterange) use `[lower,upper)` bounds, and bound values are normalized
+pub fn canonical_range(text: &str, type_name: &str) -> std::result::Result<String, String> {
+    let kind = RangeKind::from_name(type_name)
+        .ok_or_else(|| format!("type \"{type_name}\" is not a range type"))?;
+    let range = Range::parse(text).ok_or_else(|| format!("malformed range literal: \"{text}\""))?;
+    if range.empty {
+        return Ok("empty".to_string());
+    }
+    let bounds = format!(
+        "{}{}",
+        if range.lower.inclusive { '[' } else { '(' },
+        if range.upper.inclusive { ']' } else { ')' },
+    );
+    construct_range(kind, type_name, range.lower.value.as_deref(), range.upper.value.as_deref(), &bounds)
+}
+
+fn malformed_range(text: &str) -> rusqlite::Error {
+    rusqlite::Error::UserFunctionError(format!("malformed range literal: \"{text}\"").into())
+}
+
+/// Whether a containment argument is a range rather than an element
+fn looks_like_range(text: &str) -> bool {
+    let text = text.trim();
+    text.starts_with('[') || text.starts_with('(') || text.eq_ignore_ascii_case("empty")
+}
+
+/// Text of an argument, None for NULL
+fn value_text(ctx: &Context<'_>, idx: usize) -> Option<String> {
+    match ctx.get_raw(idx) {
+        ValueRef::Null => None,
+        ValueRef::Integer(i) => Some(i.to_string()),
+        ValueRef::Real(f) => Some(f.to_string()),
+        ValueRef::Text(t) | ValueRef::Blob(t) => Some(String::from_utf8_lossy(t).into_owned()),
+    }
+}
+
+/// A constructor bound argument. Date and timestamp columns hold INTEGER days and
+/// microseconds since the epoch, which are turned back into their text form.
+fn bound_argument(ctx: &Context<'_>, idx: usize, kind: RangeKind) -> Option<String> {
+    match (ctx.get_raw(idx), kind) {
+        (ValueRef::Integer(days), RangeKind::Date) => TimeDelta::try_days(days)
+            .and_then(|delta| NaiveDate::from_ymd_opt(1970, 1, 1)?.checked_add_signed(delta))
+            .map(|date| date.format("%Y-%m-%d").to_string()),
+        (ValueRef::Integer(micros), RangeKind::Timestamp | RangeKind::TimestampTz) => {
+            DateTime::from_timestamp_micros(micros).map(|ts| format_timestamp(&ts.naive_utc()))
+        }
+        _ => value_text(ctx, idx),
+    }
+}
+
+/// A containment element. Date and timestamp columns hold INTEGER days and microseconds
+/// since the epoch, which are read as such when the range's bounds are dates or timestamps.
+fn element_argument(ctx: &Context<'_>, idx: usize, range: &Range) -> Option<String> {
+    let kind = [&range.lower.value, &range.upper.value]
+        .into_iter()
+        .flatten()
+        .find_map(|bound| {
+            if parse_date(bound).is_some() {
+                Some(RangeKind::Date)
+            } else {
+                parse_instant(bound).map(|_| RangeKind::Timestamp)
+            }
+        });
+    match kind {
+        Some(kind) => bound_argument(ctx, idx, kind),
+        None => value_text(ctx, idx),
+    }
+}
+
+/// Build the canonical text of a range from its bounds and flags such as "[)"
+fn construct_range(
+    kind: RangeKind,
+    type_name: &str,
+    lower: Option<&str>,
+    upper: Option<&str>,
+    bounds: &str,
+) -> std::result::Result<String, String> {
+    let (lower_inclusive, upper_inclusive) = match bounds {
+        "[)" => (true, false),
+        "[]" => (true, true),
+        "(]" => (false, true),
+        "()" => (false, false),
+        _ => return Err("invalid range bound flags".to_string()),
+    };
+    let normalize = |value: &str| {
+        kind.normalize(value)
+            .ok_or_else(|| format!("invalid input syntax for type {type_name}: \"{value}\""))
+    };
+    let mut lower = lower.map(&normalize).transpose()?;
+    let mut upper = upper.map(&normalize).transpose()?;
+    // Infinite bounds are always exclusive
+    let mut lower_inclusive = lower_inclusive && lower.is_some();
+    let mut upper_inclusive = upper_inclusive && upper.is_some();
+
+    if kind.is_discrete() {
+        if let Some(value) = lower.as_mut().filter(|_| !lower_inclusive) {
+            *value = kind.next(value).ok_or_else(|| format!("{type_name} out of range"))?;
+            lower_inclusive = true;
+        }
+        if let Some(value) = upper.as_mut().filter(|_| upper_inclusive) {
+            *value = kind.next(value).ok_or_else(|| format!("{type_name} out of range"))?;
+            upper_inclusive = false;
+        }
+    }
+
+    if let (Some(l), Some(u)) = (&lower, &upper) {
+        match compare_bounds(l, u) {
+            Ordering::Greater => {
+                return Err("range lower bound must be less than or equal to range upper bound".to_string());
+            }
+            Ordering::Equal if !(lower_inclusive && upper_inclusive) => return Ok("empty".to_string()),
+            _ => {}
+        }
+    }
+
+    Ok(format!(
+        "{}{},{}{}",
+        if lower_inclusive { '[' } else { '(' },
+        lower.as_deref().map(quote_bound).unwrap_or_default(),
+        upper.as_deref().map(quote_bound).unwrap_or_default(),
+        if upper_inclusive { ']' } else { ')' },
+    ))
+}
+
+/// Bound values with spaces or range punctuation are double-quoted, as PostgreSQL does
+fn quote_bound(value: &str) -> String {
+    if value.chars().any(|c| c.is_whitespace() || matches!(c, ',' | '"' | '(' | ')' | '[' | ']' | '\\')) {
+        format!("\"{}\"", value.replace('"', "\\\""))
+    } else {
+        value.to_string()
+    }
+}
+
+/// How the bounds of each range type are normalized
+#[derive(Debug, Clone, Copy)]
+enum RangeKind {
+    Integer,
+    Numeric,
+    Date,
+    Timestamp,
+    TimestampTz,
+}
+
+impl RangeKind {
+    fn from_name(name: &str) -> Option<Self> {
+        match name.to_ascii_lowercase().as_str() {
+            "int4range" | "int8range" => Some(RangeKind::Integer),
+            "numrange" => Some(RangeKind::Numeric),
+            "daterange" => Some(RangeKind::Date),
+            "tsrange" => Some(RangeKind::Timestamp),
+            "tstzrange" => Some(RangeKind::TimestampTz),
+            _ => None,
+        }
+    }
+
+    /// Discrete types have a canonical `[lower,upper)` form
+    fn is_discrete(self) -> bool {
+        matches!(self, RangeKind::Integer | RangeKind::Date)
+    }
+
+    fn normalize(self, value: &str) -> Option<String> {
+        let value = value.trim();
+        match self {
+            RangeKind::Integer => value.parse::<i64>().ok().map(|v| v.to_string()),
+            RangeKind::Numeric => value.parse::<f64>().ok().filter(|v| v.is_finite()).map(|_| value.to_string()),
+            RangeKind::Date => parse_date(value).map(|date| date.format("%Y-%m-%d").to_string()),
+            RangeKind::Timestamp => parse_timestamp(value).map(|ts| format_timestamp(&ts)),
+            RangeKind::TimestampTz => parse_instant(value).map(|ts| format!("{}+00", format_timestamp(&ts))),
+        }
+    }
+
+    /// The value following a normalized discrete bound
+    fn next(self, value: &str) -> Option<String> {
+        match self {
+            RangeKind::Integer => value.parse::<i64>().ok()?.checked_add(1).map(|v| v.to_string()),
+            RangeKind::Date => parse_date(value)?.succ_opt().map(|date| date.format("%Y-%m-%d").to_string()),
+            _ => Some(value.to_string()),
+        }
+    }
+}
+
+fn parse_date(value: &str) -> Option<NaiveDate> {
+    NaiveDate::parse_from_str(value, "%Y-%m-%d").ok()
+}
+
+/// A timestamp without time zone; a plain date is midnight
+fn parse_timestamp(value: &str) -> Option<NaiveDateTime> {
+    ["%Y-%m-%d %H:%M:%S%.f", "%Y-%m-%dT%H:%M:%S%.f", "%Y-%m-%d %H:%M", "%Y-%m-%dT%H:%M"]
+        .iter()
+        .find_map(|format| NaiveDateTime::parse_from_str(value, format).ok())
+        .or_else(|| parse_date(value).and_then(|date| date.and_hms_opt(0, 0, 0)))
+}
+
+/// A timestamp in UTC; values without an offset are taken as UTC
+fn parse_instant(value: &str) -> Option<NaiveDateTime> {
+    ["%Y-%m-%d %H:%M:%S%.f%#z", "%Y-%m-%dT%H:%M:%S%.f%#z", "%Y-%m-%d %H:%M%#z", "%Y-%m-%dT%H:%M%#z"]
+        .iter()
+        .find_map(|format| DateTime::parse_from_str(value, format).ok())
+        .map(|ts| ts.naive_utc())
+        .or_else(|| parse_timestamp(value))
+}
+
+fn format_timestamp(ts: &NaiveDateTime) -> String {
+    ts.format("%Y-%m-%d %H:%M:%S%.f").to_string()
+}
+
+/// One bound of a range; None is unbounded
+struct Bound {
+    value: Option<String>,
+    inclusive: bool,
+}
+
+/// A parsed range literal. `empty` contains no points and overlaps nothing.
+struct Range {
+    lower: Bound,
+    upper: Bound,
+    empty: bool,
+}
+
+impl Range {
+    fn parse(text: &str) -> Option<Range> {
+        let text = text.trim();
+        if text.eq_ignore_ascii_case("empty") {
+            return Some(Range {
+                lower: Bound { value: None, inclusive: false },
+                upper: Bound { value: None, inclusive: false },
+                empty: true,
+            });
+        }
+
+        let lower_inclusive = match text.chars().next()? {
+            '[' => true,
+            '(' => false,
+            _ => return None,
+        };
+        let upper_inclusive = match text.chars().last()? {
+            ']' => true,
+            ')' => false,
+            _ => return None,
+        };
+        let inner = text.get(1..text.len() - 1)?;
+
+        // Split on the comma outside double-quoted bounds
+        let mut in_quotes = false;
+        let comma = inner.char_indices().find(|&(_, c)| {
+            if c == '"' {
+                in_quotes = !in_quotes;
+            }
+            c == ',' && !in_quotes
+        })?.0;
+
+        let bound = |s: &str| {
+            let s = s.trim();
+            (!s.is_empty()).then(|| s.trim_matches('"').to_string())
+        };
+        let range = Range {
+            lower: Bound { value: bound(&inner[..comma]), inclusive: lower_inclusive },
+            upper: Bound { value: bound(&inner[comma + 1..]), inclusive: upper_inclusive },
+            empty: false,
+        };
+
+        // A range like [5,5) has no points
+        let empty = match (&range.lower.value, &range.upper.value) {
+            (Some(lower), Some(upper)) => match compare_bounds(lower, upper) {
+                Ordering::Greater => return None,
+                Ordering::Equal => !(lower_inclusive && upper_inclusive),
+                Ordering::Less => false,
+            },
+            _ => false,
+        };
+        Some(Range { empty, ..range })
+    }
+
+    fn overlaps(&self, other: &Range) -> bool {
+        !self.empty && !other.empty
+            && starts_before_end(&self.lower, &other.upper)
+            && starts_before_end(&other.lower, &self.upper)
+    }
+
+    /// The empty range is contained in every range
+    fn contains_range(&self, other: &Range) -> bool {
+        if other.empty {
+            return true;
+        }
+        if self.empty {
+            return false;
+        }
+        let lower_ok = match (&self.lower.value, &other.lower.value) {
+            (None, _) => true,
+            (Some(_), None) => false,
+            (Some(a), Some(b)) => match compare_bounds(a, b) {
+                Ordering::Less => true,
+                Ordering::Equal => self.lower.inclusive || !other.lower.inclusive,
+                Ordering::Greater => false,
+            },
+        };
+        let upper_ok = match (&self.upper.value, &other.upper.value) {
+            (None, _) => true,
+            (Some(_), None) => false,
+            (Some(a), Some(b)) => match compare_bounds(a, b) {
+                Ordering::Greater => true,
+                Ordering::Equal => self.upper.inclusive || !other.upper.inclusive,
+                Ordering::Less => false,
+            },
+        };
+        lower_ok && upper_ok
+    }
+
+    fn contains_element(&self, element: &str) -> bool {
+        let point = Bound { value: Some(element.to_string()), inclusive: true };
+        !self.empty
+            && starts_before_end(&self.lower, &point)
+            && starts_before_end(&point, &self.upper)
+    }
+}
+
+/// Whether a range starting at `lower` can share a point with one ending at `upper`
+fn starts_before_end(lower: &Bound, upper: &Bound) -> bool {
+    match (&lower.value, &upper.value) {
+        (Some(l), Some(u)) => match compare_bounds(l, u) {
+            Ordering::Less => true,
+            Ordering::Equal => lower.inclusive && upper.inclusive,
+            Ordering::Greater => false,
+        },
+        _ => true,
+    }
+}
+
+/// Numbers compare numerically, dates and timestamps chronologically, anything else as text
+fn compare_bounds(a: &str, b: &str) -> Ordering {
+    if let (Ok(x), Ok(y)) = (a.parse::<f64>(), b.parse::<f64>()) {
+        return x.partial_cmp(&y).unwrap_or(Ordering::Equal);
+    }
+    match (parse_instant(a.trim()), parse_instant(b.trim())) {
+        (Some(x), Some(y)) => x.cmp(&y),
+        _ => a.cmp(b),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn overlaps(conn: &Connection, a: &str, b: &str) -> Option<bool> {
+        conn.query_row("SELECT range_overlaps(?1, ?2)", [a, b], |row| row.get(0)).unwrap()
+    }
+
+    #[test]
+    fn test_range_overlaps() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_range_functions(&conn).unwrap();
+
+        assert_eq!(overlaps(&conn, "[1,5)", "[4,10)"), Some(true));
+        assert_eq!(overlaps(&conn, "[1,5)", "[5,10)"), Some(false));
+        assert_eq!(overlaps(&conn, "[1,5]", "[5,10)"), Some(true));
+        assert_eq!(overlaps(&conn, "[2,10)", "[9,100)"), Some(true));
+        assert_eq!(overlaps(&conn, "(,3)", "[2,)"), Some(true));
+        assert_eq!(overlaps(&conn, "empty", "(,)"), Some(false));
+        assert_eq!(
+            overlaps(&conn, "[\"2024-01-01 10:00\",\"2024-01-01 12:00\")", "[2024-01-01 12:00,2024-01-01 13:00)"),
+            Some(false)
+        );
+        assert_eq!(
+            overlaps(&conn, "[2024-01-01 10:00,2024-01-01 12:00)", "[2024-01-01 11:30,2024-01-01 13:00)"),
+            Some(true)
+        );
+
+        let null: Option<bool> = conn.query_row("SELECT range_overlaps(NULL, '[1,2)')", [], |row| row.get(0)).unwrap();
+        assert_eq!(null, None);
+        assert!(conn.query_row("SELECT range_overlaps('1,2', '[1,2)')", [], |row| row.get::<_, bool>(0)).is_err());
+    }
+
+    fn text(conn: &Connection, sql: &str) -> Option<String> {
+        conn.query_row(sql, [], |row| row.get(0)).unwrap()
+    }
+
+    #[test]
+    fn test_range_constructors() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_range_functions(&conn).unwrap();
+
+        assert_eq!(text(&conn, "SELECT int4range(1, 10)").as_deref(), Some("[1,10)"));
+        assert_eq!(text(&conn, "SELECT int4range(1, 10, '[]')").as_deref(), Some("[1,11)"));
+        assert_eq!(text(&conn, "SELECT int8range(1, 10, '()')").as_deref(), Some("[2,10)"));
+        assert_eq!(text(&conn, "SELECT int4range(NULL, 10, '[]')").as_deref(), Some("(,11)"));
+        assert_eq!(text(&conn, "SELECT int4range(5, 5)").as_deref(), Some("empty"));
+        assert_eq!(text(&conn, "SELECT numrange(1.5, 2.5, '(]')").as_deref(), Some("(1.5,2.5]"));
+        assert_eq!(text(&conn, "SELECT daterange('2024-01-01', '2024-01-31', '[]')").as_deref(), Some("[2024-01-01,2024-02-01)"));
+        assert_eq!(
+            text(&conn, "SELECT tsrange('2024-01-01 10:00', '2024-01-01 12:00', '[)')").as_deref(),
+            Some("[\"2024-01-01 10:00:00\",\"2024-01-01 12:00:00\")")
+        );
+        assert_eq!(
+            text(&conn, "SELECT tstzrange('2024-01-01 10:00:00+02', NULL)").as_deref(),
+            Some("[\"2024-01-01 08:00:00+00\",)")
+        );
+
+        // One argument canonicalizes a literal
+        assert_eq!(text(&conn, "SELECT int4range('(1,10]')").as_deref(), Some("[2,11)"));
+        assert_eq!(text(&conn, "SELECT int4range(NULL)"), None);
+
+        assert!(conn.query_row("SELECT int4range(10, 1)", [], |row| row.get::<_, String>(0)).is_err());
+        assert!(conn.query_row("SELECT int4range(1, 10, 'x')", [], |row| row.get::<_, String>(0)).is_err());
+        assert!(conn.query_row("SELECT int4range('a', 10)", [], |row| row.get::<_, String>(0)).is_err());
+    }
+
+    #[test]
+    fn test_range_contains() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_range_functions(&conn).unwrap();
+        let contains = |a: &str, b: &str| -> Option<bool> {
+            conn.query_row("SELECT range_contains(?1, ?2)", [a, b], |row| row.get(0)).unwrap()
+        };
+
+        assert_eq!(contains("[1,10)", "5"), Some(true));
+        assert_eq!(contains("[1,10)", "10"), Some(false));
+        assert_eq!(contains("[1,10]", "10"), Some(true));
+        assert_eq!(contains("(,10)", "-100"), Some(true));
+        assert_eq!(contains("[1,10)", "[2,5)"), Some(true));
+        assert_eq!(contains("[1,10)", "[2,10]"), Some(false));
+        assert_eq!(contains("[1,10)", "empty"), Some(true));
+        assert_eq!(contains("empty", "1"), Some(false));
+        assert_eq!(
+            contains("[\"2024-01-01 10:00:00\",\"2024-01-01 12:00:00\")", "2024-01-01 10:00"),
+            Some(true)
+        );
+        assert_eq!(
+            contains("[\"2024-01-01 10:00:00\",\"2024-01-01 12:00:00\")", "2024-01-01 12:00"),
+            Some(false)
+        );
+
+        let contains_int: bool = conn.query_row("SELECT range_contains('[1,10)', 3)", [], |row| row.get(0)).unwrap();
+        assert!(contains_int);
+        // Date and timestamp columns hold days and microseconds since the epoch
+        let contains_day = |day: i64| -> bool {
+            conn.query_row("SELECT range_contains('[2024-01-01,2024-01-08)', ?1)", [day], |row| row.get(0)).unwrap()
+        };
+        assert!(contains_day(19723)); // 2024-01-01
+        assert!(!contains_day(19730)); // 2024-01-08
+        let contains_instant: bool = conn.query_row(
+            "SELECT range_contains('[\"2024-01-01 08:00:00+00\",\"2024-01-01 10:00:00+00\")', ?1)",
+            [1_704_099_600_000_000_i64], // 2024-01-01 09:00:00 UTC
+            |row| row.get(0),
+        ).unwrap();
+        assert!(contains_instant);
+        assert_eq!(contains("[\"2024-01-01 08:00:00+00\",\"2024-01-01 10:00:00+00\")", "2024-01-01 11:30+02"), Some(true));
+        let null: Option<bool> = conn.query_row("SELECT range_contains('[1,10)', NULL)", [], |row| row.get(0)).unwrap();
+        assert_eq!(null, None);
+    }
+}
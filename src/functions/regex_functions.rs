@@ -1,5 +1,6 @@
-use rusqlite::{Connection, Result, functions::FunctionFlags};
-use regex::Regex;
+use rusqlite::{Connection, Error, Result, functions::{Context, FunctionFlags}};
+use regex::{Regex, RegexBuilder};
+use serde_json::Value as JsonValue;
 use tracing::{debug, trace};
 
 /// Register PostgreSQL-compatible regular expression functions
@@ -71,10 +72,185 @@ pub fn register_regex_functions(conn: &Connection) -> Result<()> {
         },
     )?;
     
+    // regexp_matches_json(text, pattern[, flags]) - backs the regexp_matches() set-returning
+    // function. Returns a JSON array holding one text[] per match, which the query
+    // translator expands into rows with json_each().
+    for n_args in [2, 3] {
+        conn.create_scalar_function(
+            "regexp_matches_json",
+            n_args,
+            FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+            regexp_matches_json,
+        )?;
+    }
+    
+    // substring_regex(text, pattern) - backs substring(text FROM pattern)
+    conn.create_scalar_function(
+        "substring_regex",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        substring_regex,
+    )?;
+    
+    // substring_similar(text, pattern, escape) - backs substring(text FROM pattern FOR escape)
+    // and substring(text SIMILAR pattern ESCAPE escape)
+    conn.create_scalar_function(
+        "substring_similar",
+        3,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        substring_similar,
+    )?;
+    
     debug!("Regex functions registered successfully");
     Ok(())
 }
 
+fn regexp_matches_json(ctx: &Context) -> Result<Option<String>> {
+    let text: Option<String> = ctx.get(0)?;
+    let pattern: Option<String> = ctx.get(1)?;
+    let flags: Option<String> = if ctx.len() > 2 { ctx.get(2)? } else { Some(String::new()) };
+
+    // Strict like PostgreSQL: any NULL argument produces no rows
+    let (Some(text), Some(pattern), Some(flags)) = (text, pattern, flags) else {
+        return Ok(None);
+    };
+
+    trace!("regexp_matches('{}', '{}', '{}')", text, pattern, flags);
+
+    let mut global = false;
+    let mut case_insensitive = false;
+    for flag in flags.chars() {
+        match flag {
+            'g' => global = true,
+            'i' => case_insensitive = true,
+            'c' => case_insensitive = false,
+            _ => return Err(Error::UserFunctionError(
+                format!("invalid regular expression option: \"{flag}\"").into()
+            )),
+        }
+    }
+
+    let re = RegexBuilder::new(&pattern)
+        .case_insensitive(case_insensitive)
+        .build()
+        .map_err(|e| Error::UserFunctionError(format!("invalid regular expression: {e}").into()))?;
+
+    let matches: Vec<JsonValue> = re.captures_iter(&text)
+        .take(if global { usize::MAX } else { 1 })
+        .map(|caps| {
+            // Without capture groups the whole match is the only element
+            if caps.len() == 1 {
+                return JsonValue::Array(vec![JsonValue::String(caps[0].to_string())]);
+            }
+            JsonValue::Array(caps.iter().skip(1)
+                .map(|group| group.map_or(JsonValue::Null, |m| JsonValue::String(m.as_str().to_string())))
+                .collect())
+        })
+        .collect();
+
+    Ok(Some(JsonValue::Array(matches).to_string()))
+}
+
+/// The first match of the pattern, or of its first parenthesized subexpression if it has one
+fn substring_regex(ctx: &Context) -> Result<Option<String>> {
+    let (Some(text), Some(pattern)) = (ctx.get::<Option<String>>(0)?, ctx.get::<Option<String>>(1)?) else {
+        return Ok(None);
+    };
+
+    trace!("substring('{}' FROM '{}')", text, pattern);
+
+    // PostgreSQL's `.` matches newlines unless the pattern asks otherwise
+    let re = RegexBuilder::new(&pattern)
+        .dot_matches_new_line(true)
+        .build()
+        .map_err(|e| Error::UserFunctionError(format!("invalid regular expression: {e}").into()))?;
+
+    Ok(re.captures(&text).and_then(|caps| {
+        let group = if caps.len() > 1 { caps.get(1) } else { caps.get(0) };
+        group.map(|m| m.as_str().to_string())
+    }))
+}
+
+/// The part of the text matching the section of a SIMILAR TO pattern between the
+/// escape-double-quote markers, or all of it when there are none. The pattern has to
+/// match the whole text.
+fn substring_similar(ctx: &Context) -> Result<Option<String>> {
+    let (Some(text), Some(pattern), Some(escape)) = (
+        ctx.get::<Option<String>>(0)?,
+        ctx.get::<Option<String>>(1)?,
+        ctx.get::<Option<String>>(2)?,
+    ) else {
+        return Ok(None);
+    };
+
+    trace!("substring('{}' SIMILAR '{}' ESCAPE '{}')", text, pattern, escape);
+
+    let mut escape_chars = escape.chars();
+    let escape = match (escape_chars.next(), escape_chars.next()) {
+        (escape, None) => escape,
+        _ => return Err(Error::UserFunctionError("invalid escape string".into())),
+    };
+    let re = Regex::new(&similar_to_regex(&pattern, escape).map_err(|e| Error::UserFunctionError(e.into()))?)
+        .map_err(|e| Error::UserFunctionError(format!("invalid regular expression: {e}").into()))?;
+
+    Ok(re.captures(&text).and_then(|caps| caps.get(1)).map(|m| m.as_str().to_string()))
+}
+
+/// Convert a SIMILAR TO pattern into an anchored regex whose first group is the part
+/// between the escape-double-quote markers. As in PostgreSQL the part before the first
+/// marker matches as little as possible.
+fn similar_to_regex(pattern: &str, escape: Option<char>) -> std::result::Result<String, String> {
+    let mut parts = vec![String::new()];
+    let mut in_bracket = false;
+    let mut chars = pattern.chars();
+    while let Some(c) = chars.next() {
+        let part = parts.last_mut().unwrap();
+        if Some(c) == escape {
+            match chars.next() {
+                Some('"') if !in_bracket => {
+                    if parts.len() == 3 {
+                        return Err("SQL regular expression may not contain more than two escape-double-quote separators".to_string());
+                    }
+                    parts.push(String::new());
+                }
+                Some(next) => part.push_str(&regex::escape(&next.to_string())),
+                None => return Err("invalid escape string".to_string()),
+            }
+        } else if in_bracket {
+            match c {
+                ']' => {
+                    in_bracket = false;
+                    part.push(c);
+                }
+                '\\' | '[' | '&' | '~' => {
+                    part.push('\\');
+                    part.push(c);
+                }
+                _ => part.push(c),
+            }
+        } else {
+            match c {
+                '%' => part.push_str(".*"),
+                '_' => part.push('.'),
+                '(' => part.push_str("(?:"),
+                '[' => {
+                    in_bracket = true;
+                    part.push(c);
+                }
+                '|' | '*' | '+' | '?' | ')' | '{' | '}' => part.push(c),
+                _ => part.push_str(&regex::escape(&c.to_string())),
+            }
+        }
+    }
+
+    Ok(match parts.as_slice() {
+        [whole] => format!("^(?s)({whole})$"),
+        [before, matched] => format!("^(?s)(?U:{before})({matched})$"),
+        [before, matched, after] => format!("^(?s)(?U:{before})({matched})(?:{after})$"),
+        _ => unreachable!(),
+    })
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -122,6 +298,80 @@ mod tests {
         assert!(result);
     }
     
+    #[test]
+    fn test_regexp_matches_json_function() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_regex_functions(&conn).unwrap();
+        
+        let result: String = conn
+            .query_row(r"SELECT regexp_matches_json('978-0134685991', '(\d{3})-(\d+)')", [], |row| row.get(0))
+            .unwrap();
+        assert_eq!(result, r#"[["978","0134685991"]]"#);
+        
+        // Only the first match without the g flag, every match with it
+        let result: String = conn
+            .query_row(r"SELECT regexp_matches_json('a1 b2 c3', '([a-z])(\d)')", [], |row| row.get(0))
+            .unwrap();
+        assert_eq!(result, r#"[["a","1"]]"#);
+        let result: String = conn
+            .query_row(r"SELECT regexp_matches_json('a1 b2 c3', '([a-z])(\d)', 'g')", [], |row| row.get(0))
+            .unwrap();
+        assert_eq!(result, r#"[["a","1"],["b","2"],["c","3"]]"#);
+        
+        // Whole match when there are no groups, case-insensitive flag, no match
+        let result: String = conn
+            .query_row("SELECT regexp_matches_json('Foo foo', 'foo', 'gi')", [], |row| row.get(0))
+            .unwrap();
+        assert_eq!(result, r#"[["Foo"],["foo"]]"#);
+        let result: String = conn
+            .query_row("SELECT regexp_matches_json('abc', 'x(y)')", [], |row| row.get(0))
+            .unwrap();
+        assert_eq!(result, "[]");
+        
+        let result: Option<String> = conn
+            .query_row("SELECT regexp_matches_json(NULL, 'x')", [], |row| row.get(0))
+            .unwrap();
+        assert_eq!(result, None);
+        
+        assert!(conn.query_row("SELECT regexp_matches_json('abc', 'a', 'q')", [], |row| row.get::<_, String>(0)).is_err());
+    }
+    
+    #[test]
+    fn test_substring_regex_function() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_regex_functions(&conn).unwrap();
+        
+        let query = |sql: &str| conn.query_row(sql, [], |row| row.get::<_, Option<String>>(0)).unwrap();
+        
+        // The whole match without groups, the first group with them
+        assert_eq!(query(r"SELECT substring_regex('order 1234 shipped', '\d+')"), Some("1234".to_string()));
+        assert_eq!(query(r"SELECT substring_regex('alice@example.com', '@(.*)\.(com|org)$')"), Some("example".to_string()));
+        assert_eq!(query("SELECT substring_regex('abc', 'x+')"), None);
+        assert_eq!(query("SELECT substring_regex(NULL, 'x+')"), None);
+        
+        assert!(conn.query_row("SELECT substring_regex('abc', '(')", [], |row| row.get::<_, String>(0)).is_err());
+    }
+    
+    #[test]
+    fn test_substring_similar_function() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_regex_functions(&conn).unwrap();
+        
+        let query = |sql: &str| conn.query_row(sql, [], |row| row.get::<_, Option<String>>(0)).unwrap();
+        
+        assert_eq!(query("SELECT substring_similar('foobar', '%#\"o_b#\"%', '#')"), Some("oob".to_string()));
+        // The pattern has to match the whole string
+        assert_eq!(query("SELECT substring_similar('foobar', '#\"o_b#\"%', '#')"), None);
+        // The part before the first marker is as short as possible
+        assert_eq!(query("SELECT substring_similar('a1b2c3', '%#\"[0-9]%#\"', '#')"), Some("1b2c3".to_string()));
+        assert_eq!(query("SELECT substring_similar('price: $5.00', 'price: #\"%#\"', '#')"), Some("$5.00".to_string()));
+        // Without markers the whole string is returned when it matches
+        assert_eq!(query("SELECT substring_similar('abc', 'a(b|x)c', '#')"), Some("abc".to_string()));
+        assert_eq!(query("SELECT substring_similar('abc', 'a_c', NULL)"), None);
+        
+        assert!(conn.query_row("SELECT substring_similar('abc', 'a', '##')", [], |row| row.get::<_, String>(0)).is_err());
+    }
+    
     #[test]
     fn test_invalid_regex() {
         let conn = Connection::open_in_memory().unwrap();
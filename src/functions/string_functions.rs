@@ -35,7 +35,15 @@ pub fn register_string_functions(conn: &Connection) -> Result<()> {
         "string_agg",
         2,
         FunctionFlags::SQLITE_UTF8,
-        StringAggregator,
+        StringAggregator { distinct: false },
+    )?;
+    
+    // string_agg(DISTINCT ...) - SQLite only allows DISTINCT on single-argument aggregates
+    conn.create_aggregate_function(
+        "string_agg_distinct",
+        2,
+        FunctionFlags::SQLITE_UTF8,
+        StringAggregator { distinct: true },
     )?;
     
     // Register translate function
@@ -225,13 +233,159 @@ pub fn register_string_functions(conn: &Connection) -> Result<()> {
         },
     )?;
     
+    // Register strpos function - the 1-based character position of the first occurrence
+    // of a substring, or 0. position(substring IN string) is translated to it.
+    conn.create_scalar_function(
+        "strpos",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let (Some(string), Some(substring)) = (ctx.get::<Option<String>>(0)?, ctx.get::<Option<String>>(1)?) else {
+                return Ok(None);
+            };
+            Ok(Some(string.find(&substring).map_or(0, |i| string[..i].chars().count() as i64 + 1)))
+        },
+    )?;
+    
+    // Register starts_with function
+    conn.create_scalar_function(
+        "starts_with",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let (Some(string), Some(prefix)) = (ctx.get::<Option<String>>(0)?, ctx.get::<Option<String>>(1)?) else {
+                return Ok(None);
+            };
+            Ok(Some(string.starts_with(&prefix)))
+        },
+    )?;
+    
+    // Register pg_like / pg_ilike, the targets of the ~~ and ~~* operators.
+    // Unlike SQLite's LIKE these honor PostgreSQL's default backslash escape,
+    // and pg_ilike folds case for all of Unicode rather than just ASCII.
+    for (name, case_insensitive) in [("pg_like", false), ("pg_ilike", true)] {
+        for n_args in [2, 3] {
+            conn.create_scalar_function(
+                name,
+                n_args,
+                FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+                move |ctx| {
+                    let text = ctx.get::<Option<String>>(0)?;
+                    let pattern = ctx.get::<Option<String>>(1)?;
+                    let escape = if ctx.len() > 2 {
+                        match ctx.get::<Option<String>>(2)? {
+                            Some(escape) => escape,
+                            None => return Ok(None),
+                        }
+                    } else {
+                        "\\".to_string()
+                    };
+
+                    match (text, pattern) {
+                        (Some(text), Some(pattern)) => {
+                            like_match(&text, &pattern, &escape, case_insensitive)
+                                .map(Some)
+                                .map_err(|e| rusqlite::Error::UserFunctionError(e.into()))
+                        }
+                        _ => Ok(None),
+                    }
+                },
+            )?;
+        }
+    }
+    
     debug!("Successfully registered string functions");
     Ok(())
 }
 
+/// A single element of a LIKE pattern
+#[derive(Debug, Clone, PartialEq)]
+enum LikeToken {
+    Literal(char),
+    AnyChar,
+    AnySequence,
+}
+
+/// Match `text` against a PostgreSQL LIKE pattern.
+///
+/// `_` matches any single character and `%` any sequence. `escape` must be
+/// empty (no escape character) or a single character; an escaped character
+/// is always matched literally. With `case_insensitive` both sides are
+/// lowercased using full Unicode case mapping, as ILIKE does.
+fn like_match(text: &str, pattern: &str, escape: &str, case_insensitive: bool) -> std::result::Result<bool, String> {
+    let mut escape_chars = escape.chars();
+    let escape = escape_chars.next();
+    if escape_chars.next().is_some() {
+        return Err("invalid escape string: must be empty or one character".to_string());
+    }
+
+    let fold = |c: char| -> Vec<char> {
+        if case_insensitive {
+            c.to_lowercase().collect()
+        } else {
+            vec![c]
+        }
+    };
+
+    let mut tokens = Vec::new();
+    let mut chars = pattern.chars();
+    while let Some(c) = chars.next() {
+        if Some(c) == escape {
+            match chars.next() {
+                Some(escaped) => tokens.extend(fold(escaped).into_iter().map(LikeToken::Literal)),
+                None => return Err("LIKE pattern must not end with escape character".to_string()),
+            }
+        } else if c == '%' {
+            // Consecutive % are equivalent to one
+            if tokens.last() != Some(&LikeToken::AnySequence) {
+                tokens.push(LikeToken::AnySequence);
+            }
+        } else if c == '_' {
+            tokens.push(LikeToken::AnyChar);
+        } else {
+            tokens.extend(fold(c).into_iter().map(LikeToken::Literal));
+        }
+    }
+
+    let text: Vec<char> = text.chars().flat_map(fold).collect();
+
+    // Greedy match that backtracks to the most recent % on mismatch
+    let (mut t, mut p) = (0, 0);
+    let mut backtrack: Option<(usize, usize)> = None;
+    while t < text.len() {
+        match tokens.get(p) {
+            Some(LikeToken::AnyChar) => {
+                t += 1;
+                p += 1;
+            }
+            Some(LikeToken::Literal(c)) if *c == text[t] => {
+                t += 1;
+                p += 1;
+            }
+            Some(LikeToken::AnySequence) => {
+                backtrack = Some((p, t));
+                p += 1;
+            }
+            _ => match backtrack {
+                Some((star, consumed)) => {
+                    backtrack = Some((star, consumed + 1));
+                    p = star + 1;
+                    t = consumed + 1;
+                }
+                None => return Ok(false),
+            },
+        }
+    }
+
+    Ok(tokens[p..].iter().all(|token| *token == LikeToken::AnySequence))
+}
+
 /// String aggregator for string_agg function
 #[derive(Debug)]
-struct StringAggregator;
+struct StringAggregator {
+    /// Drop repeated values, keeping the first occurrence in aggregation order
+    distinct: bool,
+}
 
 impl rusqlite::functions::Aggregate<(Vec<String>, Option<String>), Option<String>> for StringAggregator {
     fn init(&self, _ctx: &mut rusqlite::functions::Context<'_>) -> rusqlite::Result<(Vec<String>, Option<String>)> {
@@ -239,7 +393,10 @@ impl rusqlite::functions::Aggregate<(Vec<String>, Option<String>), Option<String
     }
     
     fn step(&self, ctx: &mut rusqlite::functions::Context<'_>, agg: &mut (Vec<String>, Option<String>)) -> rusqlite::Result<()> {
-        let value = ctx.get::<String>(0)?;
+        // NULL values are skipped, as in PostgreSQL
+        let Some(value) = ctx.get::<Option<String>>(0)? else {
+            return Ok(());
+        };
         agg.0.push(value);
         
         if agg.1.is_none() {
@@ -252,7 +409,11 @@ impl rusqlite::functions::Aggregate<(Vec<String>, Option<String>), Option<String
     
     fn finalize(&self, _ctx: &mut rusqlite::functions::Context<'_>, agg: Option<(Vec<String>, Option<String>)>) -> rusqlite::Result<Option<String>> {
         match agg {
-            Some((values, delimiter)) => {
+            Some((mut values, delimiter)) => {
+                if self.distinct {
+                    let mut seen = std::collections::HashSet::new();
+                    values.retain(|value| seen.insert(value.clone()));
+                }
                 if values.is_empty() {
                     Ok(None)
                 } else {
@@ -405,4 +566,83 @@ mod tests {
         ).unwrap();
         assert_eq!(result, "helloxxx");
     }
-}
\ No newline at end of file
+    
+    #[test]
+    fn test_strpos_starts_with() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_string_functions(&conn).unwrap();
+        
+        let result: i64 = conn.query_row("SELECT strpos('high', 'ig')", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, 2);
+        
+        // Character positions, not bytes
+        let result: i64 = conn.query_row("SELECT strpos('Crème brûlée', 'brû')", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, 7);
+        
+        let result: i64 = conn.query_row("SELECT strpos('high', 'x')", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, 0);
+        
+        let result: Option<i64> = conn.query_row("SELECT strpos(NULL, 'x')", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, None);
+        
+        let result: bool = conn.query_row("SELECT starts_with('The Hobbit', 'The')", [], |row| row.get(0)).unwrap();
+        assert!(result);
+        
+        let result: bool = conn.query_row("SELECT starts_with('The Hobbit', 'the')", [], |row| row.get(0)).unwrap();
+        assert!(!result);
+        
+        let result: Option<bool> = conn.query_row("SELECT starts_with('The Hobbit', NULL)", [], |row| row.get(0)).unwrap();
+        assert_eq!(result, None);
+    }
+    
+    #[test]
+    fn test_like_match_wildcards_and_escape() {
+        assert!(like_match("hello", "h%o", "\\", false).unwrap());
+        assert!(like_match("hello", "h_llo", "\\", false).unwrap());
+        assert!(!like_match("hello", "H%", "\\", false).unwrap());
+        assert!(like_match("", "%", "\\", false).unwrap());
+        assert!(!like_match("abc", "a_", "\\", false).unwrap());
+        assert!(like_match("a%c", "a\\%c", "\\", false).unwrap());
+        assert!(!like_match("abc", "a\\%c", "\\", false).unwrap());
+        assert!(like_match("50%", "50!%", "!", false).unwrap());
+        // An empty escape string makes the backslash an ordinary character
+        assert!(like_match("a\\b", "a\\_", "", false).unwrap());
+        assert!(like_match("mississippi", "%iss%ppi", "\\", false).unwrap());
+        assert!(like_match("abc", "abc\\", "\\", false).is_err());
+        assert!(like_match("abc", "abc", "ab", false).is_err());
+    }
+    
+    #[test]
+    fn test_ilike_unicode() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_string_functions(&conn).unwrap();
+        
+        let result: bool = conn.query_row(
+            "SELECT pg_ilike('École Normale', 'éCOLE%')",
+            [],
+            |row| row.get(0)
+        ).unwrap();
+        assert!(result);
+        
+        let result: bool = conn.query_row(
+            "SELECT pg_ilike('ÜBER Straße', 'über_straße')",
+            [],
+            |row| row.get(0)
+        ).unwrap();
+        assert!(result);
+        
+        let result: bool = conn.query_row(
+            "SELECT pg_like('ÜBER', 'über')",
+            [],
+            |row| row.get(0)
+        ).unwrap();
+        assert!(!result);
+        
+        let result: Option<bool> = conn.query_row(
+            "SELECT pg_like(NULL, 'a%')",
+            [],
+            |row| row.get(0)
+        ).unwrap();
+        assert_eq!(result, None);
+    }
+}
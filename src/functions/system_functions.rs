@@ -1,4 +1,6 @@
-use rusqlite::{Connection, Result, functions::FunctionFlags};
+use rusqlite::{Connection, Result, functions::FunctionFlags, types::Value};
+use parking_lot::Mutex;
+use std::sync::Arc;
 use tracing::debug;
 
 /// Register PostgreSQL system information functions
@@ -13,8 +15,8 @@ pub fn register_system_functions(conn: &Connection) -> Result<()> {
         |_ctx| {
             // Return a PostgreSQL-compatible version string
             // This format is what SQLAlchemy expects to parse
-            Ok(format!("PostgreSQL 16.0 (pgsqlite {}) on x86_64-pc-linux-gnu, compiled by rustc, 64-bit",
-                env!("CARGO_PKG_VERSION")))
+            Ok(format!("PostgreSQL {} (pgsqlite {}) on x86_64-pc-linux-gnu, compiled by rustc, 64-bit",
+                crate::PG_SERVER_VERSION, env!("CARGO_PKG_VERSION")))
         },
     )?;
     
@@ -90,6 +92,36 @@ pub fn register_system_functions(conn: &Connection) -> Result<()> {
         },
     )?;
     
+    // pg_cancel_backend(pid) - Cancels the statement the connection with that pid is running
+    conn.create_scalar_function(
+        "pg_cancel_backend",
+        1,
+        FunctionFlags::SQLITE_UTF8,
+        |ctx| {
+            let pid: i32 = ctx.get(0)?;
+            Ok(crate::session::activity::cancel(pid, None))
+        },
+    )?;
+    
+    // pg_terminate_backend(pid) - Closes the connection with that pid
+    conn.create_scalar_function(
+        "pg_terminate_backend",
+        1,
+        FunctionFlags::SQLITE_UTF8,
+        |ctx| {
+            let pid: i32 = ctx.get(0)?;
+            Ok(crate::session::activity::terminate(pid))
+        },
+    )?;
+    
+    // __pgsqlite_stat_activity() - The open connections as a JSON array, read by pg_stat_activity
+    conn.create_scalar_function(
+        "__pgsqlite_stat_activity",
+        0,
+        FunctionFlags::SQLITE_UTF8,
+        |_ctx| Ok(crate::session::activity::snapshot_json()),
+    )?;
+    
     // pg_is_in_recovery() - Returns whether server is in recovery mode
     conn.create_scalar_function(
         "pg_is_in_recovery",
@@ -114,6 +146,82 @@ pub fn register_system_functions(conn: &Connection) -> Result<()> {
         },
     )?;
     
+    // pg_relation_size(regclass) - Bytes used by the table or index itself
+    // pg_table_size(regclass) - Same, there is no TOAST storage in SQLite
+    // pg_indexes_size(regclass) - Bytes used by the indexes of the table
+    // pg_total_relation_size(regclass) - Table plus indexes
+    for (name, include_table, include_indexes) in [
+        ("pg_relation_size", true, false),
+        ("pg_table_size", true, false),
+        ("pg_indexes_size", false, true),
+        ("pg_total_relation_size", true, true),
+    ] {
+        conn.create_scalar_function(
+            name,
+            1,
+            FunctionFlags::SQLITE_UTF8,
+            move |ctx| {
+                let Some(relation) = relation_argument(ctx, 0)? else {
+                    return Ok(None);
+                };
+                // SAFETY: the connection is only used for read-only lookups while the
+                // calling statement runs, and is never closed from here
+                let conn = unsafe { ctx.get_connection()? };
+                relation_size(&conn, &relation, include_table, include_indexes).map(Some)
+            },
+        )?;
+    }
+    
+    // __pgsqlite_relpages(name) / __pgsqlite_reltuples(name) - pg_class size estimates
+    conn.create_scalar_function(
+        "__pgsqlite_relpages",
+        1,
+        FunctionFlags::SQLITE_UTF8,
+        |ctx| {
+            let name: String = ctx.get(0)?;
+            // SAFETY: see pg_relation_size above
+            let conn = unsafe { ctx.get_connection()? };
+            relation_statistics(&conn, &name).map(|(pages, _)| pages)
+        },
+    )?;
+    conn.create_scalar_function(
+        "__pgsqlite_reltuples",
+        1,
+        FunctionFlags::SQLITE_UTF8,
+        |ctx| {
+            let name: String = ctx.get(0)?;
+            // SAFETY: see pg_relation_size above
+            let conn = unsafe { ctx.get_connection()? };
+            relation_statistics(&conn, &name).map(|(_, tuples)| tuples)
+        },
+    )?;
+
+    // __pgsqlite_atttypid(pg_type, sqlite_type) / __pgsqlite_atttypmod(pg_type, sqlite_type, type_modifier)
+    // - pg_attribute's type columns from __pgsqlite_schema, as PgAttributeHandler reports them
+    conn.create_scalar_function(
+        "__pgsqlite_atttypid",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let pg_type: Option<String> = ctx.get(0)?;
+            let sqlite_type: Option<String> = ctx.get(1)?;
+            let (oid, _) = crate::catalog::pg_attribute::column_type(pg_type.as_deref(), sqlite_type.as_deref().unwrap_or("TEXT"), None);
+            Ok(oid)
+        },
+    )?;
+    conn.create_scalar_function(
+        "__pgsqlite_atttypmod",
+        3,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let pg_type: Option<String> = ctx.get(0)?;
+            let sqlite_type: Option<String> = ctx.get(1)?;
+            let type_modifier: Option<i32> = ctx.get(2)?;
+            let (_, atttypmod) = crate::catalog::pg_attribute::column_type(pg_type.as_deref(), sqlite_type.as_deref().unwrap_or("TEXT"), type_modifier);
+            Ok(atttypmod)
+        },
+    )?;
+
     // pg_postmaster_start_time() - Returns server start time
     conn.create_scalar_function(
         "pg_postmaster_start_time",
@@ -387,13 +495,11 @@ pub fn register_system_functions(conn: &Connection) -> Result<()> {
         2,
         FunctionFlags::SQLITE_UTF8,
         |ctx| {
-            let _object_oid: i64 = ctx.get(0)?;
-            let _catalog_name: String = ctx.get(1)?;
-            
-            // For SQLite functions, we can't easily access the connection
-            // So we return NULL for now - this will be handled by query interceptor
-            // or comment function processor
-            Ok(Option::<String>::None)
+            let Some(object_oid) = oid_argument(ctx, 0)? else {
+                return Ok(None);
+            };
+            let catalog_name: String = ctx.get(1)?;
+            lookup_comment(ctx, object_oid, &catalog_name, 0)
         },
     )?;
     
@@ -403,10 +509,14 @@ pub fn register_system_functions(conn: &Connection) -> Result<()> {
         1,
         FunctionFlags::SQLITE_UTF8,
         |ctx| {
-            let _object_oid: i64 = ctx.get(0)?;
-            // Use the two-parameter version with default catalog
-            // For now, return NULL - will be handled by query interceptor for real queries
-            Ok(Option::<String>::None)
+            let Some(object_oid) = oid_argument(ctx, 0)? else {
+                return Ok(None);
+            };
+            // Without a catalog, tables are the most likely target, then functions
+            match lookup_comment(ctx, object_oid, "pg_class", 0)? {
+                Some(comment) => Ok(Some(comment)),
+                None => lookup_comment(ctx, object_oid, "pg_proc", 0),
+            }
         },
     )?;
     
@@ -416,12 +526,10 @@ pub fn register_system_functions(conn: &Connection) -> Result<()> {
         2,
         FunctionFlags::SQLITE_UTF8,
         |ctx| {
-            let _table_oid: i64 = ctx.get(0)?;
-            let _column_number: i32 = ctx.get(1)?;
-            
-            // Query __pgsqlite_comments table for column comment
-            // For now, return NULL - will be handled by query interceptor
-            Ok(Option::<String>::None)
+            let (Some(table_oid), Some(column_number)) = (oid_argument(ctx, 0)?, oid_argument(ctx, 1)?) else {
+                return Ok(None);
+            };
+            lookup_comment(ctx, table_oid, "pg_class", column_number)
         },
     )?;
 
@@ -463,10 +571,253 @@ pub fn register_system_functions(conn: &Connection) -> Result<()> {
         },
     )?;
 
+    // __pgsqlite_system_value(v) / __pgsqlite_take_system_value(v) - INSERT ... OVERRIDING
+    // SYSTEM VALUE wraps the identity values it supplies in the first, and the triggers of a
+    // GENERATED ALWAYS identity column (see IdentityTriggers) only accept a value the second
+    // can take back out of the connection's list.
+    let system_values: Arc<Mutex<Vec<i64>>> = Arc::default();
+    {
+        let system_values = system_values.clone();
+        conn.create_scalar_function(
+            "__pgsqlite_system_value",
+            1,
+            FunctionFlags::SQLITE_UTF8,
+            move |ctx| {
+                let value: Value = ctx.get(0)?;
+                if let Some(id) = identity_value(&value) {
+                    system_values.lock().push(id);
+                }
+                Ok(value)
+            },
+        )?;
+    }
+    conn.create_scalar_function(
+        "__pgsqlite_take_system_value",
+        1,
+        FunctionFlags::SQLITE_UTF8,
+        move |ctx| {
+            let value: Value = ctx.get(0)?;
+            let mut system_values = system_values.lock();
+            let position = identity_value(&value)
+                .and_then(|id| system_values.iter().position(|&supplied| supplied == id));
+            Ok(position.map(|position| system_values.swap_remove(position)).is_some())
+        },
+    )?;
+
     debug!("System functions registered successfully");
     Ok(())
 }
 
+/// The integer an identity column stores for a value, None when it isn't one
+fn identity_value(value: &Value) -> Option<i64> {
+    match value {
+        Value::Integer(id) => Some(*id),
+        Value::Real(id) if id.fract() == 0.0 => Some(*id as i64),
+        Value::Text(id) => id.trim().parse().ok(),
+        _ => None,
+    }
+}
+
+/// Read an OID-like argument, which catalog views may hand over as text
+fn oid_argument(ctx: &rusqlite::functions::Context, idx: usize) -> Result<Option<i32>> {
+    Ok(match ctx.get_raw(idx) {
+        rusqlite::types::ValueRef::Integer(i) => i32::try_from(i).ok(),
+        rusqlite::types::ValueRef::Text(t) => std::str::from_utf8(t).ok().and_then(|t| t.trim().parse().ok()),
+        _ => None,
+    })
+}
+
+/// Read a regclass argument: a relation name, possibly schema-qualified or quoted, or an OID
+fn relation_argument(ctx: &rusqlite::functions::Context, idx: usize) -> Result<Option<RelationRef>> {
+    Ok(match ctx.get_raw(idx) {
+        rusqlite::types::ValueRef::Integer(oid) => Some(RelationRef::Oid(oid)),
+        rusqlite::types::ValueRef::Text(t) => {
+            let text = std::str::from_utf8(t).map_err(|e| rusqlite::Error::UserFunctionError(e.into()))?.trim();
+            match text.parse::<i64>() {
+                Ok(oid) => Some(RelationRef::Oid(oid)),
+                Err(_) => {
+                    let name = text.strip_prefix("public.").unwrap_or(text);
+                    Some(RelationRef::Name(name.trim_matches('"').to_string()))
+                }
+            }
+        }
+        _ => None,
+    })
+}
+
+enum RelationRef {
+    Name(String),
+    Oid(i64),
+}
+
+/// Size in bytes of a table and/or its indexes, from the pages the dbstat table reports
+fn relation_size(conn: &Connection, relation: &RelationRef, include_table: bool, include_indexes: bool) -> Result<i64> {
+    let (oid, name) = match relation {
+        RelationRef::Name(name) => (None, Some(name.as_str())),
+        RelationRef::Oid(oid) => (Some(*oid), None),
+    };
+    // pg_class OIDs are a hash of the name, see the catalog views
+    let lookup = conn.query_row(
+        "SELECT type, name, tbl_name FROM sqlite_master
+         WHERE type IN ('table', 'index')
+           AND CASE WHEN ?1 IS NULL
+                    THEN name = ?2
+                    ELSE ((unicode(substr(name, 1, 1)) * 1000000) +
+                          (unicode(substr(name || ' ', 2, 1)) * 10000) +
+                          (unicode(substr(name || '  ', 3, 1)) * 100) +
+                          (length(name) * 7)) % 1000000 + 16384 = ?1
+               END",
+        rusqlite::params![oid, name],
+        |row| Ok((row.get::<_, String>(0)?, row.get::<_, String>(1)?, row.get::<_, String>(2)?)),
+    );
+    let (kind, name, table_name) = match lookup {
+        Ok(found) => found,
+        Err(rusqlite::Error::QueryReturnedNoRows) => {
+            return Err(rusqlite::Error::UserFunctionError(match relation {
+                RelationRef::Name(name) => format!("relation \"{name}\" does not exist").into(),
+                RelationRef::Oid(oid) => format!("could not open relation with OID {oid}").into(),
+            }));
+        }
+        Err(e) => return Err(e),
+    };
+
+    // An index has no indexes of its own
+    let mut objects = Vec::new();
+    if include_table {
+        objects.push(name);
+    }
+    if include_indexes && kind == "table" {
+        let mut stmt = conn.prepare("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ?1")?;
+        let indexes = stmt.query_map([&table_name], |row| row.get::<_, String>(0))?;
+        for index in indexes {
+            objects.push(index?);
+        }
+    }
+
+    let mut size = 0i64;
+    for object in objects {
+        size += conn.query_row(
+            "SELECT COALESCE(SUM(pgsize), 0) FROM dbstat WHERE name = ?1 AND aggregate = TRUE",
+            [&object],
+            |row| row.get::<_, i64>(0),
+        )?;
+    }
+    Ok(size)
+}
+
+/// relpages and reltuples of a table or index.
+///
+/// The page count is read live from dbstat. The row count comes from sqlite_stat1, so like
+/// PostgreSQL's estimate it is refreshed by ANALYZE; a table that was never analyzed is
+/// counted. Views and unknown relations have no pages and an unknown (-1) row count.
+fn relation_statistics(conn: &Connection, name: &str) -> Result<(i64, f64)> {
+    let table_name = match conn.query_row(
+        "SELECT tbl_name FROM sqlite_master WHERE type IN ('table', 'index') AND name = ?1",
+        [name],
+        |row| row.get::<_, String>(0),
+    ) {
+        Ok(table_name) => table_name,
+        Err(rusqlite::Error::QueryReturnedNoRows) => return Ok((0, -1.0)),
+        Err(e) => return Err(e),
+    };
+
+    let pages = conn.query_row("SELECT count(*) FROM dbstat WHERE name = ?1", [name], |row| row.get::<_, i64>(0))?;
+
+    // Every sqlite_stat1 row of a table starts with its row count
+    let has_stats = conn.query_row(
+        "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_stat1')",
+        [],
+        |row| row.get::<_, bool>(0),
+    )?;
+    let analyzed = if has_stats {
+        conn.query_row("SELECT stat FROM sqlite_stat1 WHERE tbl = ?1 LIMIT 1", [&table_name], |row| row.get::<_, String>(0))
+            .ok()
+            .and_then(|stat| stat.split_whitespace().next()?.parse::<f64>().ok())
+    } else {
+        None
+    };
+    let tuples = match analyzed {
+        Some(tuples) => tuples,
+        None => conn.query_row(
+            &format!("SELECT count(*) FROM \"{}\"", table_name.replace('"', "\"\"")),
+            [],
+            |row| row.get::<_, i64>(0),
+        )? as f64,
+    };
+    Ok((pages, tuples))
+}
+
+/// Look up a comment stored by COMMENT ON in __pgsqlite_comments
+fn lookup_comment(
+    ctx: &rusqlite::functions::Context,
+    object_oid: i32,
+    catalog_name: &str,
+    subobject_id: i32,
+) -> Result<Option<String>> {
+    // SAFETY: the connection is only used for a read-only lookup while the
+    // calling statement runs, and is never closed from here
+    let conn = unsafe { ctx.get_connection()? };
+    let comment = conn.query_row(
+        "SELECT comment_text FROM __pgsqlite_comments
+         WHERE object_oid = ?1 AND catalog_name = ?2 AND subobject_id = ?3",
+        rusqlite::params![object_oid, catalog_name, subobject_id],
+        |row| row.get(0),
+    );
+    match comment {
+        Ok(comment) => Ok(comment),
+        Err(rusqlite::Error::QueryReturnedNoRows) => Ok(None),
+        // Connections that never ran the migrations have no comments table
+        Err(rusqlite::Error::SqliteFailure(_, Some(message))) if message.starts_with("no such table") => Ok(None),
+        Err(e) => Err(e),
+    }
+}
+
+/// Re-register the identity functions with the values from the session's startup message, and
+/// pg_backend_pid() with the session's own pid
+pub fn register_session_functions(conn: &Connection, user: &str, database: &str, pid: i32) -> Result<()> {
+    debug!("Registering session functions for user {} on database {}", user, database);
+
+    register_current_user(conn, user)?;
+
+    let session_user = user.to_string();
+    conn.create_scalar_function(
+        "session_user",
+        0,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        move |_ctx| Ok(session_user.clone()),
+    )?;
+
+    let current_database = database.to_string();
+    conn.create_scalar_function(
+        "current_database",
+        0,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        move |_ctx| Ok(current_database.clone()),
+    )?;
+
+    // Each session has its own backend pid, the one pg_stat_activity lists it under
+    conn.create_scalar_function(
+        "pg_backend_pid",
+        0,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        move |_ctx| Ok(pid),
+    )?;
+
+    Ok(())
+}
+
+/// Re-register current_user() with the role the session acts as, which SET ROLE changes and
+/// RESET ROLE restores to the login user
+pub fn register_current_user(conn: &Connection, role: &str) -> Result<()> {
+    let current_user = role.to_string();
+    conn.create_scalar_function(
+        "current_user",
+        0,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        move |_ctx| Ok(current_user.clone()),
+    )
+}
+
 /// Format size in bytes as human-readable string using PostgreSQL's algorithm
 /// Uses binary prefixes: 1 kB = 1024 bytes, 1 MB = 1024² bytes, etc.
 /// Based on PostgreSQL source code in src/backend/utils/adt/dbsize.c
@@ -555,6 +906,28 @@ mod tests {
         let user: String = conn.query_row("SELECT current_user()", [], |row| row.get(0)).unwrap();
         assert_eq!(user, "postgres");
     }
+
+    #[test]
+    fn test_session_functions() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_system_functions(&conn).unwrap();
+        register_session_functions(&conn, "alice", "inventory", 4242).unwrap();
+
+        let (current_user, session_user, database): (String, String, String) = conn.query_row(
+            "SELECT current_user(), session_user(), current_database()",
+            [],
+            |row| Ok((row.get(0)?, row.get(1)?, row.get(2)?)),
+        ).unwrap();
+        assert_eq!(current_user, "alice");
+        assert_eq!(session_user, "alice");
+        assert_eq!(database, "inventory");
+
+        let pid: i32 = conn.query_row("SELECT pg_backend_pid()", [], |row| row.get(0)).unwrap();
+        assert_eq!(pid, 4242);
+
+        let matched: i32 = conn.query_row("SELECT 1 WHERE current_user() = 'alice'", [], |row| row.get(0)).unwrap();
+        assert_eq!(matched, 1);
+    }
     
     #[test]
     fn test_pg_backend_pid() {
@@ -565,6 +938,20 @@ mod tests {
         assert!(pid > 0);
     }
     
+    #[test]
+    fn test_cancel_unknown_backend() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_system_functions(&conn).unwrap();
+
+        let (cancelled, terminated): (bool, bool) = conn.query_row(
+            "SELECT pg_cancel_backend(-1), pg_terminate_backend(-1)",
+            [],
+            |row| Ok((row.get(0)?, row.get(1)?)),
+        ).unwrap();
+        assert!(!cancelled);
+        assert!(!terminated);
+    }
+    
     #[test]
     fn test_pg_is_in_recovery() {
         let conn = Connection::open_in_memory().unwrap();
@@ -717,4 +1104,93 @@ mod tests {
         ).unwrap();
         assert_eq!(desc, None); // Should return NULL
     }
-}
\ No newline at end of file
+    
+    #[test]
+    fn test_description_lookup() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_system_functions(&conn).unwrap();
+        conn.execute(
+            "CREATE TABLE __pgsqlite_comments (object_oid INTEGER, catalog_name TEXT, subobject_id INTEGER, comment_text TEXT)",
+            [],
+        ).unwrap();
+        conn.execute(
+            "INSERT INTO __pgsqlite_comments VALUES (20000, 'pg_class', 0, 'Books'), (20000, 'pg_class', 2, 'The book''s title')",
+            [],
+        ).unwrap();
+        
+        let (table, table_deprecated, column, missing): (Option<String>, Option<String>, Option<String>, Option<String>) = conn.query_row(
+            "SELECT obj_description(20000, 'pg_class'), obj_description('20000'), col_description('20000', 2), col_description(20000, 1)",
+            [],
+            |row| Ok((row.get(0)?, row.get(1)?, row.get(2)?, row.get(3)?)),
+        ).unwrap();
+        assert_eq!(table.as_deref(), Some("Books"));
+        assert_eq!(table_deprecated.as_deref(), Some("Books"));
+        assert_eq!(column.as_deref(), Some("The book's title"));
+        assert_eq!(missing, None);
+    }
+    
+    #[test]
+    fn test_relation_size() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_system_functions(&conn).unwrap();
+        conn.execute_batch(
+            "CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT);
+             CREATE INDEX books_title_idx ON books (title);
+             WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 500)
+             INSERT INTO books (title) SELECT printf('Book number %d with a long title', i) FROM n;"
+        ).unwrap();
+        
+        let (table, indexes, total, index): (i64, i64, i64, i64) = conn.query_row(
+            "SELECT pg_relation_size('books'), pg_indexes_size('public.books'), pg_total_relation_size('books'),
+                    pg_relation_size('books_title_idx')",
+            [],
+            |row| Ok((row.get(0)?, row.get(1)?, row.get(2)?, row.get(3)?)),
+        ).unwrap();
+        assert!(table > 4096, "table size {table}");
+        assert_eq!(indexes, index);
+        assert_eq!(total, table + indexes);
+        
+        // pg_class OIDs work as well
+        let by_oid: i64 = conn.query_row(
+            "SELECT pg_relation_size(((unicode('b') * 1000000) + (unicode('o') * 10000) + (unicode('o') * 100) + 5 * 7) % 1000000 + 16384)",
+            [],
+            |row| row.get(0),
+        ).unwrap();
+        assert_eq!(by_oid, table);
+        
+        let err = conn.query_row("SELECT pg_relation_size('missing')", [], |row| row.get::<_, i64>(0)).unwrap_err();
+        assert!(err.to_string().contains("relation \"missing\" does not exist"), "{err}");
+    }
+    
+    #[test]
+    fn test_relation_statistics() {
+        let conn = Connection::open_in_memory().unwrap();
+        register_system_functions(&conn).unwrap();
+        conn.execute_batch(
+            "CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT);
+             CREATE INDEX books_title_idx ON books (title);
+             CREATE VIEW book_titles AS SELECT title FROM books;
+             WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 500)
+             INSERT INTO books (title) SELECT printf('Book number %d with a long title', i) FROM n;"
+        ).unwrap();
+        
+        let stats = |name: &str| conn.query_row(
+            "SELECT __pgsqlite_relpages(?1), __pgsqlite_reltuples(?1)",
+            [name],
+            |row| Ok((row.get::<_, i64>(0)?, row.get::<_, f64>(1)?)),
+        ).unwrap();
+        
+        // Not analyzed yet: the rows are counted
+        let (pages, tuples) = stats("books");
+        assert!(pages > 1, "{pages} pages");
+        assert_eq!(tuples, 500.0);
+        assert_eq!(stats("books_title_idx").1, 500.0);
+        assert_eq!(stats("book_titles"), (0, -1.0));
+        
+        // After ANALYZE the estimate only changes with the next ANALYZE
+        conn.execute_batch("ANALYZE; DELETE FROM books WHERE id > 100;").unwrap();
+        assert_eq!(stats("books").1, 500.0);
+        conn.execute_batch("ANALYZE").unwrap();
+        assert_eq!(stats("books").1, 100.0);
+    }
+}
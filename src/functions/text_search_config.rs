@@ -0,0 +1,337 @@
+/// A PostgreSQL text search configuration, which decides how the words of a document or query
+/// become lexemes
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum TextSearchConfig {
+    /// Lowercase words with English stop words removed and Porter stemming
+    English,
+    /// Lowercase words, nothing else
+    Simple,
+}
+
+/// The stop words of PostgreSQL's english dictionary
+const ENGLISH_STOP_WORDS: &[&str] = &[
+    "i", "me", "my", "myself", "we", "our", "ours", "ourselves", "you", "your", "yours",
+    "yourself", "yourselves", "he", "him", "his", "himself", "she", "her", "hers", "herself",
+    "it", "its", "itself", "they", "them", "their", "theirs", "themselves", "what", "which",
+    "who", "whom", "this", "that", "these", "those", "am", "is", "are", "was", "were", "be",
+    "been", "being", "have", "has", "had", "having", "do", "does", "did", "doing", "a", "an",
+    "the", "and", "but", "if", "or", "because", "as", "until", "while", "of", "at", "by", "for",
+    "with", "about", "against", "between", "into", "through", "during", "before", "after",
+    "above", "below", "to", "from", "up", "down", "in", "out", "on", "off", "over", "under",
+    "again", "further", "then", "once", "here", "there", "when", "where", "why", "how", "all",
+    "any", "both", "each", "few", "more", "most", "other", "some", "such", "no", "nor", "not",
+    "only", "own", "same", "so", "than", "too", "very", "s", "t", "can", "will", "just", "don",
+    "should", "now",
+];
+
+impl TextSearchConfig {
+    /// Look up a configuration by name, optionally schema qualified with pg_catalog
+    pub fn from_name(name: &str) -> Result<Self, String> {
+        let trimmed = name.trim();
+        let unqualified = trimmed.strip_prefix("pg_catalog.").unwrap_or(trimmed);
+        match unqualified.to_lowercase().as_str() {
+            "english" => Ok(Self::English),
+            "simple" => Ok(Self::Simple),
+            _ => Err(format!("text search configuration \"{trimmed}\" does not exist")),
+        }
+    }
+
+    pub fn name(&self) -> &'static str {
+        match self {
+            Self::English => "english",
+            Self::Simple => "simple",
+        }
+    }
+
+    /// The FTS5 tokenizer that splits text the same way
+    pub fn fts5_tokenizer(&self) -> &'static str {
+        match self {
+            Self::English => "porter unicode61",
+            Self::Simple => "unicode61",
+        }
+    }
+
+    /// The lexeme of a word, or None for a stop word
+    pub fn lexize(&self, word: &str) -> Option<String> {
+        let word = word.to_lowercase();
+        match self {
+            Self::Simple => Some(word),
+            Self::English if ENGLISH_STOP_WORDS.contains(&word.as_str()) => None,
+            Self::English => Some(porter_stem(&word)),
+        }
+    }
+}
+
+/// Martin Porter's stemming algorithm, the one FTS5's porter tokenizer uses. Words that aren't
+/// plain ASCII letters are left alone.
+pub fn porter_stem(word: &str) -> String {
+    if word.len() <= 2 || !word.bytes().all(|b| b.is_ascii_lowercase()) {
+        return word.to_string();
+    }
+    let mut stemmer = Stemmer { b: word.as_bytes().to_vec(), k: word.len() as isize - 1, j: 0 };
+    stemmer.step1ab();
+    if stemmer.k > 0 {
+        stemmer.step1c();
+        stemmer.step2();
+        stemmer.step3();
+        stemmer.step4();
+        stemmer.step5();
+    }
+    stemmer.b.truncate(stemmer.k as usize + 1);
+    String::from_utf8(stemmer.b).unwrap_or_default()
+}
+
+/// The word being stemmed: b[..=k] is the current stem and j marks the end of the stem before a
+/// suffix matched by `ends`
+struct Stemmer {
+    b: Vec<u8>,
+    k: isize,
+    j: isize,
+}
+
+impl Stemmer {
+    fn at(&self, i: isize) -> u8 {
+        self.b[i as usize]
+    }
+
+    fn is_consonant(&self, i: isize) -> bool {
+        match self.at(i) {
+            b'a' | b'e' | b'i' | b'o' | b'u' => false,
+            b'y' => i == 0 || !self.is_consonant(i - 1),
+            _ => true,
+        }
+    }
+
+    /// The number of vowel-consonant sequences in b[..=j]
+    fn measure(&self) -> usize {
+        let mut n = 0;
+        let mut i = 0;
+        loop {
+            if i > self.j {
+                return n;
+            }
+            if !self.is_consonant(i) {
+                break;
+            }
+            i += 1;
+        }
+        i += 1;
+        loop {
+            loop {
+                if i > self.j {
+                    return n;
+                }
+                if self.is_consonant(i) {
+                    break;
+                }
+                i += 1;
+            }
+            i += 1;
+            n += 1;
+            loop {
+                if i > self.j {
+                    return n;
+                }
+                if !self.is_consonant(i) {
+                    break;
+                }
+                i += 1;
+            }
+            i += 1;
+        }
+    }
+
+    fn vowel_in_stem(&self) -> bool {
+        (0..=self.j).any(|i| !self.is_consonant(i))
+    }
+
+    fn double_consonant(&self, i: isize) -> bool {
+        i >= 1 && self.at(i) == self.at(i - 1) && self.is_consonant(i)
+    }
+
+    /// consonant-vowel-consonant ending at i, where the last consonant isn't w, x or y
+    fn cvc(&self, i: isize) -> bool {
+        i >= 2
+            && self.is_consonant(i)
+            && !self.is_consonant(i - 1)
+            && self.is_consonant(i - 2)
+            && !matches!(self.at(i), b'w' | b'x' | b'y')
+    }
+
+    fn ends(&mut self, suffix: &str) -> bool {
+        let length = suffix.len() as isize;
+        if length > self.k + 1 || &self.b[(self.k - length + 1) as usize..=self.k as usize] != suffix.as_bytes() {
+            return false;
+        }
+        self.j = self.k - length;
+        true
+    }
+
+    fn set_to(&mut self, suffix: &str) {
+        self.b.truncate((self.j + 1) as usize);
+        self.b.extend_from_slice(suffix.as_bytes());
+        self.k = self.j + suffix.len() as isize;
+    }
+
+    fn replace(&mut self, suffix: &str) {
+        if self.measure() > 0 {
+            self.set_to(suffix);
+        }
+    }
+
+    /// Replace the first suffix that ends the word, if the stem before it has a measure above 0
+    fn replace_suffix(&mut self, rules: &[(&str, &str)]) {
+        for (suffix, replacement) in rules {
+            if self.ends(suffix) {
+                self.replace(replacement);
+                return;
+            }
+        }
+    }
+
+    /// Plurals and -ed or -ing
+    fn step1ab(&mut self) {
+        if self.at(self.k) == b's' {
+            if self.ends("sses") {
+                self.k -= 2;
+            } else if self.ends("ies") {
+                self.set_to("i");
+            } else if self.at(self.k - 1) != b's' {
+                self.k -= 1;
+            }
+        }
+        if self.ends("eed") {
+            if self.measure() > 0 {
+                self.k -= 1;
+            }
+        } else if (self.ends("ed") || self.ends("ing")) && self.vowel_in_stem() {
+            self.k = self.j;
+            if self.ends("at") {
+                self.set_to("ate");
+            } else if self.ends("bl") {
+                self.set_to("ble");
+            } else if self.ends("iz") {
+                self.set_to("ize");
+            } else if self.double_consonant(self.k) {
+                self.k -= 1;
+                if matches!(self.at(self.k), b'l' | b's' | b'z') {
+                    self.k += 1;
+                }
+            } else if self.measure() == 1 && self.cvc(self.k) {
+                self.set_to("e");
+            }
+        }
+    }
+
+    /// Terminal y to i when there is another vowel in the stem
+    fn step1c(&mut self) {
+        if self.ends("y") && self.vowel_in_stem() {
+            self.b[self.k as usize] = b'i';
+        }
+    }
+
+    /// Double suffixes to single ones
+    fn step2(&mut self) {
+        let rules: &[(&str, &str)] = match self.at(self.k - 1) {
+            b'a' => &[("ational", "ate"), ("tional", "tion")],
+            b'c' => &[("enci", "ence"), ("anci", "ance")],
+            b'e' => &[("izer", "ize")],
+            b'l' => &[("bli", "ble"), ("alli", "al"), ("entli", "ent"), ("eli", "e"), ("ousli", "ous")],
+            b'o' => &[("ization", "ize"), ("ation", "ate"), ("ator", "ate")],
+            b's' => &[("alism", "al"), ("iveness", "ive"), ("fulness", "ful"), ("ousness", "ous")],
+            b't' => &[("aliti", "al"), ("iviti", "ive"), ("biliti", "ble")],
+            b'g' => &[("logi", "log")],
+            _ => return,
+        };
+        self.replace_suffix(rules);
+    }
+
+    /// -ic-, -full, -ness etc.
+    fn step3(&mut self) {
+        let rules: &[(&str, &str)] = match self.at(self.k) {
+            b'e' => &[("icate", "ic"), ("ative", ""), ("alize", "al")],
+            b'i' => &[("iciti", "ic")],
+            b'l' => &[("ical", "ic"), ("ful", "")],
+            b's' => &[("ness", "")],
+            _ => return,
+        };
+        self.replace_suffix(rules);
+    }
+
+    /// -ant, -ence etc. in a stem with a measure above 1
+    fn step4(&mut self) {
+        let suffixes: &[&str] = match self.at(self.k - 1) {
+            b'a' => &["al"],
+            b'c' => &["ance", "ence"],
+            b'e' => &["er"],
+            b'i' => &["ic"],
+            b'l' => &["able", "ible"],
+            b'n' => &["ant", "ement", "ment", "ent"],
+            b'o' => {
+                if !(self.ends("ion") && self.j >= 0 && matches!(self.at(self.j), b's' | b't')) && !self.ends("ou") {
+                    return;
+                }
+                &[]
+            }
+            b's' => &["ism"],
+            b't' => &["ate", "iti"],
+            b'u' => &["ous"],
+            b'v' => &["ive"],
+            b'z' => &["ize"],
+            _ => return,
+        };
+        if !suffixes.is_empty() && !suffixes.iter().any(|suffix| self.ends(suffix)) {
+            return;
+        }
+        if self.measure() > 1 {
+            self.k = self.j;
+        }
+    }
+
+    /// Final -e and -ll
+    fn step5(&mut self) {
+        self.j = self.k;
+        if self.at(self.k) == b'e' {
+            let measure = self.measure();
+            if measure > 1 || measure == 1 && !self.cvc(self.k - 1) {
+                self.k -= 1;
+            }
+        }
+        if self.at(self.k) == b'l' && self.double_consonant(self.k) && self.measure() > 1 {
+            self.k -= 1;
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_porter_stem() {
+        for (word, stem) in [
+            ("caresses", "caress"), ("ponies", "poni"), ("cats", "cat"), ("running", "run"),
+            ("runs", "run"), ("agreed", "agre"), ("hopping", "hop"), ("filing", "file"),
+            ("happy", "happi"), ("relational", "relat"), ("generalization", "gener"),
+            ("database", "databas"), ("connected", "connect"), ("electrical", "electr"),
+            ("adjustment", "adjust"), ("controlling", "control"), ("is", "is"),
+        ] {
+            assert_eq!(porter_stem(word), stem, "stem of {word}");
+        }
+    }
+
+    #[test]
+    fn test_configs() {
+        assert_eq!(TextSearchConfig::from_name("pg_catalog.english"), Ok(TextSearchConfig::English));
+        assert_eq!(TextSearchConfig::from_name("Simple"), Ok(TextSearchConfig::Simple));
+        assert_eq!(
+            TextSearchConfig::from_name("klingon"),
+            Err("text search configuration \"klingon\" does not exist".to_string())
+        );
+
+        assert_eq!(TextSearchConfig::English.lexize("Running").as_deref(), Some("run"));
+        assert_eq!(TextSearchConfig::English.lexize("the"), None);
+        assert_eq!(TextSearchConfig::Simple.lexize("Running").as_deref(), Some("running"));
+        assert_eq!(TextSearchConfig::Simple.lexize("the").as_deref(), Some("the"));
+    }
+}
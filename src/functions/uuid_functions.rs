@@ -1,6 +1,6 @@
 use rusqlite::{Connection, Result};
 use rusqlite::functions::FunctionFlags;
-use crate::types::{UuidHandler, generate_uuid_v4};
+use crate::types::{UuidHandler, generate_uuid_v1, generate_uuid_v4};
 
 /// Register UUID-related functions in SQLite
 pub fn register_uuid_functions(conn: &Connection) -> Result<()> {
@@ -25,6 +25,16 @@ pub fn register_uuid_functions(conn: &Connection) -> Result<()> {
         },
     )?;
     
+    // uuid_generate_v1() - time-based UUID, as in uuid-ossp
+    conn.create_scalar_function(
+        "uuid_generate_v1",
+        0,
+        FunctionFlags::SQLITE_UTF8,
+        |_ctx| {
+            Ok(generate_uuid_v1())
+        },
+    )?;
+    
     // is_valid_uuid(text) - Check if a string is a valid UUID
     conn.create_scalar_function(
         "is_valid_uuid",
@@ -77,6 +87,11 @@ mod tests {
         assert!(UuidHandler::validate_uuid(&uuid2));
         assert_ne!(uuid, uuid2); // Should generate different UUIDs
         
+        // Test uuid_generate_v1
+        let uuid3: String = conn.query_row("SELECT uuid_generate_v1()", [], |row| row.get(0)).unwrap();
+        assert!(UuidHandler::validate_uuid(&uuid3));
+        assert_eq!(&uuid3[14..15], "1");
+        
         // Test is_valid_uuid
         let valid: bool = conn.query_row("SELECT is_valid_uuid(?)", ["550e8400-e29b-41d4-a716-446655440000"], |row| row.get(0)).unwrap();
         assert!(valid);
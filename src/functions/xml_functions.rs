@@ -0,0 +1,409 @@
+use rusqlite::{Connection, Result, functions::FunctionFlags};
+use roxmltree::{Document, Node, ParsingOptions};
+use tracing::debug;
+
+/// Element wrapping xml content, which may have several top-level nodes or none, so that
+/// it parses as a document
+const CONTENT_WRAPPER: &str = "pgsqlite-xml-content";
+
+/// Register functions for the xml type, which is stored as its text. Values are checked to
+/// be well-formed content, and xpath() evaluates the location paths of XPath 1.0: child,
+/// descendant (`//`), attribute (`@`), self and parent steps with name, `*`, `text()` and
+/// `node()` tests, filtered by position, `last()` and attribute or child value predicates.
+pub fn register_xml_functions(conn: &Connection) -> Result<()> {
+    debug!("Registering xml functions");
+
+    // xml(text) - check that text is well-formed xml content (used for '...'::xml casts and
+    // the checks on xml columns)
+    conn.create_scalar_function(
+        "xml",
+        1,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let Some(text) = ctx.get::<Option<String>>(0)? else {
+                return Ok(None);
+            };
+            if let Err(e) = parse_content(&text) {
+                return Err(rusqlite::Error::UserFunctionError(format!("invalid XML document: {e}").into()));
+            }
+            Ok(Some(text))
+        },
+    )?;
+
+    // xml_is_well_formed(text), xml_is_well_formed_content(text) and
+    // xml_is_well_formed_document(text) - check without raising an error
+    for (name, document) in [("xml_is_well_formed", false), ("xml_is_well_formed_content", false), ("xml_is_well_formed_document", true)] {
+        conn.create_scalar_function(
+            name,
+            1,
+            FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+            move |ctx| {
+                let Some(text) = ctx.get::<Option<String>>(0)? else {
+                    return Ok(None);
+                };
+                let well_formed = if document { parse_document(&text).is_ok() } else { parse_content(&text).is_ok() };
+                Ok(Some(well_formed))
+            },
+        )?;
+    }
+
+    // xpath(path, xml) - the text of the nodes the path selects, as a text[] (JSON array)
+    conn.create_scalar_function(
+        "xpath",
+        2,
+        FunctionFlags::SQLITE_UTF8 | FunctionFlags::SQLITE_DETERMINISTIC,
+        |ctx| {
+            let (Some(path), Some(text)) = (ctx.get::<Option<String>>(0)?, ctx.get::<Option<String>>(1)?) else {
+                return Ok(None);
+            };
+            let values = xpath(&path, &text).map_err(|e| rusqlite::Error::UserFunctionError(e.into()))?;
+            Ok(Some(serde_json::to_string(&values).unwrap_or_else(|_| "[]".to_string())))
+        },
+    )?;
+
+    Ok(())
+}
+
+fn parsing_options() -> ParsingOptions {
+    ParsingOptions { allow_dtd: true, ..ParsingOptions::default() }
+}
+
+fn parse_document(text: &str) -> std::result::Result<Document<'_>, roxmltree::Error> {
+    Document::parse_with_options(text, parsing_options())
+}
+
+/// Parse text as xml content: an optional XML declaration followed by any number of
+/// elements, text, comments and processing instructions. A document type declaration
+/// makes it a document, which is parsed as such.
+fn parse_content(text: &str) -> std::result::Result<(), roxmltree::Error> {
+    let mut body = text.trim_start();
+    if body.starts_with("<?xml")
+        && let Some(end) = body.find("?>") {
+        body = &body[end + 2..];
+    }
+    if body.trim_start().starts_with("<!DOCTYPE") {
+        return parse_document(text).map(|_| ());
+    }
+    let wrapped = format!("<{CONTENT_WRAPPER}>{body}</{CONTENT_WRAPPER}>");
+    parse_document(&wrapped).map(|_| ())
+}
+
+#[derive(Debug, Clone, PartialEq)]
+enum Axis {
+    Child,
+    Descendant,
+    Attribute,
+    SelfNode,
+    Parent,
+}
+
+#[derive(Debug, Clone, PartialEq)]
+enum NodeTest {
+    Name(String),
+    AnyName,
+    Text,
+    AnyNode,
+}
+
+#[derive(Debug, Clone, PartialEq)]
+enum Predicate {
+    Position(usize),
+    Last,
+    HasAttribute(String),
+    AttributeEquals(String, String),
+    HasChild(String),
+    ChildEquals(String, String),
+}
+
+#[derive(Debug, Clone, PartialEq)]
+struct Step {
+    axis: Axis,
+    test: NodeTest,
+    predicates: Vec<Predicate>,
+}
+
+/// A node selected by a path: a node of the tree, or an attribute of an element
+#[derive(Clone, Copy, PartialEq)]
+enum Item<'a, 'input> {
+    Node(Node<'a, 'input>),
+    Attribute(Node<'a, 'input>, &'a str, &'a str),
+}
+
+/// Evaluate `path` against the xml document `text`, returning the selected elements as
+/// their xml, and text nodes and attributes as their values
+fn xpath(path: &str, text: &str) -> std::result::Result<Vec<String>, String> {
+    let path = path.trim();
+    if path.is_empty() {
+        return Err("empty XPath expression".to_string());
+    }
+    let steps = parse_path(path).ok_or_else(|| "invalid XPath expression".to_string())?;
+    let doc = parse_document(text).map_err(|e| format!("could not parse XML document: {e}"))?;
+
+    let mut items = vec![Item::Node(doc.root())];
+    for step in &steps {
+        let mut selected: Vec<Item> = Vec::new();
+        for item in &items {
+            let Item::Node(node) = item else { continue };
+            for candidate in select(*node, step) {
+                if !selected.contains(&candidate) {
+                    selected.push(candidate);
+                }
+            }
+        }
+        items = selected;
+    }
+
+    Ok(items.into_iter().map(|item| match item {
+        Item::Attribute(_, _, value) => value.to_string(),
+        Item::Node(node) if node.is_text() => node.text().unwrap_or_default().to_string(),
+        Item::Node(node) => doc.input_text()[node.range()].to_string(),
+    }).collect())
+}
+
+/// The items a step selects from one context node, after its predicates
+fn select<'a, 'input>(node: Node<'a, 'input>, step: &Step) -> Vec<Item<'a, 'input>> {
+    let groups: Vec<Vec<Item>> = match step.axis {
+        Axis::Child => vec![node.children().filter(|child| matches(*child, &step.test)).map(Item::Node).collect()],
+        // //name is /descendant-or-self::node()/child::name, so positions count per parent
+        Axis::Descendant => node.descendants()
+            .map(|parent| parent.children().filter(|child| matches(*child, &step.test)).map(Item::Node).collect())
+            .collect(),
+        Axis::Attribute => vec![node.attributes()
+            .filter(|attr| match &step.test {
+                NodeTest::Name(name) => attr.name() == name,
+                NodeTest::AnyName | NodeTest::AnyNode => true,
+                NodeTest::Text => false,
+            })
+            .map(|attr| Item::Attribute(node, attr.name(), attr.value()))
+            .collect()],
+        Axis::SelfNode => vec![vec![Item::Node(node)]],
+        Axis::Parent => vec![node.parent().map(Item::Node).into_iter().collect()],
+    };
+
+    let mut selected = Vec::new();
+    for mut group in groups {
+        for predicate in &step.predicates {
+            let count = group.len();
+            group = group.into_iter().enumerate()
+                .filter(|(i, item)| matches_predicate(item, i + 1, count, predicate))
+                .map(|(_, item)| item)
+                .collect();
+        }
+        selected.extend(group);
+    }
+    selected
+}
+
+fn matches(node: Node, test: &NodeTest) -> bool {
+    match test {
+        NodeTest::Name(name) => node.is_element() && node.tag_name().name() == name,
+        NodeTest::AnyName => node.is_element(),
+        NodeTest::Text => node.is_text(),
+        NodeTest::AnyNode => true,
+    }
+}
+
+fn matches_predicate(item: &Item, position: usize, count: usize, predicate: &Predicate) -> bool {
+    let is_child = |child: &Node, name: &str| child.is_element() && child.tag_name().name() == name;
+    match (predicate, item) {
+        (Predicate::Position(n), _) => position == *n,
+        (Predicate::Last, _) => position == count,
+        // Attributes have neither attributes nor children
+        (_, Item::Attribute(..)) => false,
+        (Predicate::HasAttribute(name), Item::Node(node)) => node.attribute(name.as_str()).is_some(),
+        (Predicate::AttributeEquals(name, value), Item::Node(node)) => node.attribute(name.as_str()) == Some(value.as_str()),
+        (Predicate::HasChild(name), Item::Node(node)) => node.children().any(|child| is_child(&child, name)),
+        (Predicate::ChildEquals(name, value), Item::Node(node)) => node.children().any(|child| {
+            is_child(&child, name) && child.descendants().filter(|n| n.is_text()).filter_map(|n| n.text()).collect::<String>() == *value
+        }),
+    }
+}
+
+/// Parse a location path into its steps; None for anything outside the supported subset
+fn parse_path(path: &str) -> Option<Vec<Step>> {
+    let mut steps = Vec::new();
+    let mut rest = path;
+    // A relative path starts from the document node, like an absolute one
+    let mut axis = Axis::Child;
+    if let Some(after) = rest.strip_prefix("//") {
+        axis = Axis::Descendant;
+        rest = after;
+    } else if let Some(after) = rest.strip_prefix('/') {
+        rest = after;
+    }
+    if rest.is_empty() {
+        // "/" selects the document itself
+        return (axis == Axis::Child).then(|| vec![Step { axis: Axis::SelfNode, test: NodeTest::AnyNode, predicates: Vec::new() }]);
+    }
+
+    loop {
+        let end = step_end(rest)?;
+        let step = parse_step(rest[..end].trim(), axis)?;
+        steps.push(step);
+        rest = &rest[end..];
+        if rest.is_empty() {
+            return Some(steps);
+        }
+        if let Some(after) = rest.strip_prefix("//") {
+            axis = Axis::Descendant;
+            rest = after;
+        } else {
+            axis = Axis::Child;
+            rest = &rest[1..];
+        }
+        if rest.is_empty() {
+            return None;
+        }
+    }
+}
+
+/// Length of the step at the start of `path`, up to the next `/` outside predicates
+fn step_end(path: &str) -> Option<usize> {
+    let mut depth = 0;
+    let mut quote = None;
+    for (i, ch) in path.char_indices() {
+        match (ch, quote) {
+            ('\'' | '"', None) => quote = Some(ch),
+            (c, Some(q)) if c == q => quote = None,
+            (_, Some(_)) => {}
+            ('[', None) => depth += 1,
+            (']', None) => depth -= 1,
+            ('/', None) if depth == 0 => return Some(i),
+            _ => {}
+        }
+    }
+    (depth == 0 && quote.is_none()).then_some(path.len())
+}
+
+fn parse_step(step: &str, axis: Axis) -> Option<Step> {
+    let (test, predicates) = match step.find('[') {
+        Some(open) => (step[..open].trim(), &step[open..]),
+        None => (step, ""),
+    };
+
+    let (axis, test) = match test {
+        "." => (Axis::SelfNode, NodeTest::AnyNode),
+        ".." => (Axis::Parent, NodeTest::AnyNode),
+        _ if axis == Axis::Descendant && test.starts_with('@') => return None,
+        _ => match test.strip_prefix('@') {
+            Some(name) => (Axis::Attribute, parse_node_test(name.trim())?),
+            None => (axis, parse_node_test(test)?),
+        },
+    };
+
+    let mut parsed = Vec::new();
+    let mut rest = predicates.trim();
+    while !rest.is_empty() {
+        let close = predicate_end(rest)?;
+        parsed.push(parse_predicate(rest[1..close].trim())?);
+        rest = rest[close + 1..].trim_start();
+    }
+
+    Some(Step { axis, test, predicates: parsed })
+}
+
+/// Position of the `]` closing the predicate that `text` starts with
+fn predicate_end(text: &str) -> Option<usize> {
+    if !text.starts_with('[') {
+        return None;
+    }
+    let mut depth = 0;
+    let mut quote = None;
+    for (i, ch) in text.char_indices() {
+        match (ch, quote) {
+            ('\'' | '"', None) => quote = Some(ch),
+            (c, Some(q)) if c == q => quote = None,
+            (_, Some(_)) => {}
+            ('[', None) => depth += 1,
+            (']', None) => {
+                depth -= 1;
+                if depth == 0 {
+                    return Some(i);
+                }
+            }
+            _ => {}
+        }
+    }
+    None
+}
+
+fn parse_node_test(test: &str) -> Option<NodeTest> {
+    match test {
+        "*" => Some(NodeTest::AnyName),
+        "text()" => Some(NodeTest::Text),
+        "node()" => Some(NodeTest::AnyNode),
+        _ => {
+            // Prefixed names match on their local part
+            let name = test.rsplit(':').next().unwrap_or(test);
+            is_name(name).then(|| NodeTest::Name(name.to_string()))
+        }
+    }
+}
+
+fn parse_predicate(predicate: &str) -> Option<Predicate> {
+    if let Ok(position) = predicate.parse::<usize>() {
+        return (position > 0).then_some(Predicate::Position(position));
+    }
+    if predicate == "last()" {
+        return Some(Predicate::Last);
+    }
+    let (operand, value) = match predicate.split_once('=') {
+        Some((operand, value)) => {
+            let value = value.trim();
+            let unquoted = value.strip_prefix('\'').and_then(|v| v.strip_suffix('\''))
+                .or_else(|| value.strip_prefix('"').and_then(|v| v.strip_suffix('"')))?;
+            (operand.trim(), Some(unquoted.to_string()))
+        }
+        None => (predicate, None),
+    };
+    match (operand.strip_prefix('@'), value) {
+        (Some(name), Some(value)) if is_name(name) => Some(Predicate::AttributeEquals(name.to_string(), value)),
+        (Some(name), None) if is_name(name) => Some(Predicate::HasAttribute(name.to_string())),
+        (None, Some(value)) if is_name(operand) => Some(Predicate::ChildEquals(operand.to_string(), value)),
+        (None, None) if is_name(operand) => Some(Predicate::HasChild(operand.to_string())),
+        _ => None,
+    }
+}
+
+fn is_name(name: &str) -> bool {
+    let mut chars = name.chars();
+    chars.next().is_some_and(|c| c.is_alphabetic() || c == '_')
+        && chars.all(|c| c.is_alphanumeric() || matches!(c, '_' | '-' | '.'))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const CATALOG: &str = r#"<catalog><book id="1" lang="en"><title>Dune</title><price>9.99</price></book><book id="2"><title>Emma</title></book></catalog>"#;
+
+    #[test]
+    fn test_content_validation() {
+        assert!(parse_content("<a><b/></a>").is_ok());
+        assert!(parse_content("plain text").is_ok());
+        assert!(parse_content("<a/><b/>").is_ok());
+        assert!(parse_content(r#"<?xml version="1.0"?><a/>"#).is_ok());
+        assert!(parse_content("<a><b></a>").is_err());
+        assert!(parse_content("<a>&bogus;</a>").is_err());
+        assert!(parse_document("<a/><b/>").is_err());
+    }
+
+    #[test]
+    fn test_xpath() {
+        assert_eq!(xpath("/catalog/book/title/text()", CATALOG).unwrap(), vec!["Dune", "Emma"]);
+        assert_eq!(xpath("//title", CATALOG).unwrap(), vec!["<title>Dune</title>", "<title>Emma</title>"]);
+        assert_eq!(xpath("/catalog/book/@id", CATALOG).unwrap(), vec!["1", "2"]);
+        assert_eq!(xpath("//book[@lang='en']/price/text()", CATALOG).unwrap(), vec!["9.99"]);
+        assert_eq!(xpath("/catalog/book[2]/title/text()", CATALOG).unwrap(), vec!["Emma"]);
+        assert_eq!(xpath("//book[title='Emma']/@id", CATALOG).unwrap(), vec!["2"]);
+        assert_eq!(xpath("/catalog/book[last()]/title/../@id", CATALOG).unwrap(), vec!["2"]);
+        assert!(xpath("/catalog/missing", CATALOG).unwrap().is_empty());
+    }
+
+    #[test]
+    fn test_xpath_errors() {
+        assert_eq!(xpath("", CATALOG).unwrap_err(), "empty XPath expression");
+        assert_eq!(xpath("count(//book)", CATALOG).unwrap_err(), "invalid XPath expression");
+        assert!(xpath("/a", "<a>").unwrap_err().starts_with("could not parse XML document"));
+    }
+}
@@ -26,6 +26,11 @@ pub mod alloc_tracker;
 
 use thiserror::Error;
 
+/// The PostgreSQL version the server reports, in version(), server_version and SHOW
+pub const PG_SERVER_VERSION: &str = "16.0";
+/// PG_SERVER_VERSION as server_version_num reports it
+pub const PG_SERVER_VERSION_NUM: &str = "160000";
+
 #[derive(Error, Debug)]
 pub enum PgSqliteError {
     #[error("Protocol error: {0}")]
@@ -64,7 +69,7 @@ impl PgSqliteError {
         match self {
             PgSqliteError::Protocol(_) => "08P01", // protocol_violation
             PgSqliteError::SqlParse(_) => "42601", // syntax_error
-            PgSqliteError::Sqlite(_) => "58000", // system_error
+            PgSqliteError::Sqlite(_) => self.data_exception().map_or("58000", |(code, _)| code), // system_error
             PgSqliteError::TypeConversion(_) => "22P02", // invalid_text_representation
             PgSqliteError::NotSupported(_) => "0A000", // feature_not_supported
             PgSqliteError::AuthenticationFailed => "28000", // invalid_authorization_specification
@@ -75,11 +80,87 @@ impl PgSqliteError {
                 error::PgError::StringDataRightTruncation { .. } => "22001", // string_data_right_truncation
                 error::PgError::UniqueViolation { .. } => "23505", // unique_violation
                 error::PgError::ForeignKeyViolation { .. } => "23503", // foreign_key_violation
+                error::PgError::ExclusionViolation { .. } => "23P01", // exclusion_violation
                 error::PgError::SyntaxError { .. } => "42601", // syntax_error
                 error::PgError::Generic { code, .. } => code,
             },
         }
     }
+
+    /// SQLSTATE and message of the data exceptions raised inside SQLite. The divide() and
+    /// decimal_div() functions fail with "division by zero", the integer range triggers with
+    /// "<type> out of range", the char(n) triggers with "value too long for type character(n)",
+    /// and sum() with "integer overflow" when the total exceeds an i64. SQLite reports them
+    /// all as generic errors.
+    fn data_exception(&self) -> Option<(&'static str, String)> {
+        let message = match self {
+            PgSqliteError::Sqlite(rusqlite::Error::SqliteFailure(_, Some(msg))) => msg.clone(),
+            PgSqliteError::Sqlite(rusqlite::Error::UserFunctionError(e)) => e.to_string(),
+            _ => return None,
+        };
+        match message.as_str() {
+            "division by zero" => Some(("22012", message)), // division_by_zero
+            "interrupted" => Some(("57014", "canceling statement due to user request".to_string())), // query_canceled
+            "smallint out of range" | "integer out of range" | "bigint out of range" => Some(("22003", message)), // numeric_value_out_of_range
+            "integer overflow" => Some(("22003", "bigint out of range".to_string())),
+            m if m.starts_with("value too long for type character(") => Some(("22001", message)), // string_data_right_truncation
+            m if m.starts_with("cannot get array length of") => Some(("22023", message)), // invalid_parameter_value
+            m if m.starts_with("cannot insert a non-DEFAULT value into column") || m.ends_with("can only be updated to DEFAULT") => Some(("428C9", message)), // generated_always
+            m if m.starts_with("cannot INSERT into generated column ") => Some((
+                "428C9",
+                format!("cannot insert a non-DEFAULT value into column {}", &m["cannot INSERT into generated column ".len()..]),
+            )),
+            m if m.starts_with("cannot UPDATE generated column ") => Some((
+                "428C9",
+                format!("column {} can only be updated to DEFAULT", &m["cannot UPDATE generated column ".len()..]),
+            )),
+            m if m.starts_with("relation \"") && m.ends_with("\" does not exist") => Some(("42P01", message)), // undefined_table
+            m if m.starts_with("text search configuration ") && m.ends_with(" does not exist") => Some(("42704", message)), // undefined_object
+            m if m.starts_with("large object ") && m.ends_with(" does not exist") => Some(("42704", message)), // undefined_object
+            m if m.starts_with("invalid large-object descriptor: ") => Some(("42704", message)), // undefined_object
+            m if m.starts_with("large object ") && m.ends_with(" already exists") => Some(("42710", message)), // duplicate_object
+            m if m.starts_with("large object descriptor ") && m.ends_with(" was not opened for writing") => Some(("55000", message)), // object_not_in_prerequisite_state
+            "attempt to write a readonly database" => Some(("25006", "cannot execute this statement in a read-only transaction".to_string())), // read_only_sql_transaction
+            m if m.starts_with("invalid hexadecimal ") || m.starts_with("unrecognized encoding: ")
+                || m.ends_with(" while decoding base64 sequence") || m == "invalid base64 end sequence" => Some(("22023", message)), // invalid_parameter_value
+            "invalid input syntax for type bytea" => Some(("22P02", message)), // invalid_text_representation
+            "Length not in range" => Some(("22023", message)), // invalid_parameter_value
+            m if m.starts_with("Cannot use \"") && m.ends_with("\": No such hash algorithm") => Some(("39000", message)), // external_routine_invocation_exception
+            m if m.starts_with("invalid regular expression: ") || m.starts_with("SQL regular expression may not contain") => Some(("2201B", message)), // invalid_regular_expression
+            "invalid escape string" => Some(("22025", message)), // invalid_escape_sequence
+            m if m.starts_with("invalid escape string: ") || m == "LIKE pattern must not end with escape character" => Some(("22025", message)),
+            "ESCAPE expression must be a single character" => Some(("22025", "invalid escape string".to_string())),
+            m if m.ends_with(" is not a valid binary digit") => Some(("22P02", message)), // invalid_text_representation
+            m if m.starts_with("cannot ") && m.ends_with(" bit strings of different sizes") => Some(("22026", message)), // string_data_length_mismatch
+            m if m.starts_with("bit string length ") => Some(("22026", message)),
+            m if m.starts_with("bit string too long for type bit varying(") => Some(("22001", message)), // string_data_right_truncation
+            m if m.starts_with("bit index ") || (m.starts_with("index ") && m.contains(" out of valid range, ")) => Some(("2202E", message)), // array_subscript_error
+            "new bit must be 0 or 1" => Some(("22023", message)), // invalid_parameter_value
+            m if m.starts_with("invalid XML document") || m.starts_with("could not parse XML document") => Some(("2200M", message)), // invalid_xml_document
+            "invalid XPath expression" | "empty XPath expression" => Some(("22000", message)), // data_exception
+            m if m.starts_with(metadata::updatable_view::CHECK_OPTION_VIOLATION_PREFIX) => Some(("44000", message)), // with_check_option_violation
+            m if m.starts_with("date field value out of range: ") || m.starts_with("time field value out of range: ")
+                || m == "interval out of range" => Some(("22008", message)), // datetime_field_overflow
+            m if m.starts_with("malformed array literal") || m == "invalid input syntax for type json" => Some(("22P02", message)), // invalid_text_representation
+            _ => None,
+        }
+    }
+
+    /// Build the ErrorResponse sent to the client. Validation errors keep their own
+    /// SQLSTATE, constraint name and detail; everything else is reported under `context`.
+    pub fn to_error_response(&self, context: &str) -> protocol::ErrorResponse {
+        if let Some((code, message)) = self.data_exception() {
+            return protocol::ErrorResponse::new("ERROR".to_string(), code.to_string(), message);
+        }
+        match self {
+            PgSqliteError::Validation(pg_err) => pg_err.to_error_response(),
+            _ => protocol::ErrorResponse::new(
+                "ERROR".to_string(),
+                "42000".to_string(),
+                format!("{context}: {self}"),
+            ),
+        }
+    }
 }
 
 // Test helper to expose connection handler
@@ -114,6 +195,11 @@ pub async fn handle_test_connection_with_pool(
     // Wait for startup message
     let startup = match framed.next().await {
         Some(Ok(FrontendMessage::StartupMessage(msg))) => msg,
+        // A cancel request comes on a connection of its own, which is closed without a reply
+        Some(Ok(FrontendMessage::CancelRequest { process_id, secret_key })) => {
+            session::activity::cancel(process_id, Some(secret_key));
+            return Ok(());
+        }
         _ => return Err(anyhow::anyhow!("Expected startup message")),
     };
     
@@ -131,6 +217,11 @@ pub async fn handle_test_connection_with_pool(
     
     let session = Arc::new(SessionState::new(database, user));
     let session_id = session.id;
+
+    // pg_stat_activity shows the application_name the client connected with
+    if let Some(application_name) = startup.parameters.get("application_name") {
+        session::activity::set_application_name(session.pid, application_name);
+    }
     
     // Set the database handler for this session for proper lifecycle management
     session.set_db_handler(db_handler.clone()).await;
@@ -173,8 +264,8 @@ pub async fn handle_test_connection_with_pool(
     
     // Send backend key data
     framed.send(BackendMessage::BackendKeyData {
-        process_id: std::process::id() as i32,
-        secret_key: 12345,
+        process_id: session.pid,
+        secret_key: session.secret_key,
     }).await?;
     
     // Send ready for query
@@ -184,7 +275,22 @@ pub async fn handle_test_connection_with_pool(
     
     // Main message loop
     let result = async {
-        while let Some(msg) = framed.next().await {
+        loop {
+            let msg = tokio::select! {
+                msg = framed.next() => msg,
+                _ = session.terminated() => {
+                    let err = ErrorResponse::new(
+                        "FATAL".to_string(),
+                        "57P01".to_string(),
+                        "terminating connection due to administrator command".to_string(),
+                    );
+                    framed.send(BackendMessage::ErrorResponse(Box::new(err))).await?;
+                    break;
+                }
+            };
+            let Some(msg) = msg else {
+                break;
+            };
             let message = msg?;
             debug!("Received message: {:?}", message);
             println!("HANDLE_CONNECTION: Received message: {:?}", message);
@@ -203,11 +309,7 @@ pub async fn handle_test_connection_with_pool(
                                 session.set_transaction_status(TransactionStatus::InFailedTransaction).await;
                             }
                             
-                            let err = ErrorResponse::new(
-                                "ERROR".to_string(),
-                                "42000".to_string(),
-                                format!("Query execution failed: {e}"),
-                            );
+                            let err = e.to_error_response("Query execution failed");
                             framed.send(BackendMessage::ErrorResponse(Box::new(err))).await?;
                         }
                     }
@@ -251,11 +353,7 @@ pub async fn handle_test_connection_with_pool(
                     match ExtendedQueryHandler::handle_execute(&mut framed, &db_handler, &session, portal, max_rows).await {
                         Ok(()) => {},
                         Err(e) => {
-                            let err = ErrorResponse::new(
-                                "ERROR".to_string(),
-                                "42000".to_string(),
-                                format!("Execute failed: {e}"),
-                            );
+                            let err = e.to_error_response("Execute failed");
                             framed.send(BackendMessage::ErrorResponse(Box::new(err))).await?;
                         }
                     }
@@ -297,6 +395,9 @@ pub async fn handle_test_connection_with_pool(
                     framed.flush().await?;
                 }
                 FrontendMessage::Terminate => break,
+                FrontendMessage::CopyData(_) | FrontendMessage::CopyDone | FrontendMessage::CopyFail(_) => {
+                    // Left over from a COPY FROM STDIN that failed; PostgreSQL ignores these too
+                }
                 other => {
                     eprintln!("Unhandled message: {other:?}");
                     let err = ErrorResponse::new(
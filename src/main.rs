@@ -345,6 +345,12 @@ where
     // Wait for startup message
     let startup = match framed.next().await {
         Some(Ok(FrontendMessage::StartupMessage(msg))) => msg,
+        // A cancel request comes on a connection of its own, which is closed without a reply
+        Some(Ok(FrontendMessage::CancelRequest { process_id, secret_key })) => {
+            info!("Received cancel request for backend {} from {}", process_id, connection_info);
+            pgsqlite::session::activity::cancel(process_id, Some(secret_key));
+            return Ok(());
+        }
         Some(Ok(other)) => {
             error!("Expected startup message, got {:?}", other);
             return Err(anyhow::anyhow!("Protocol error: expected startup message"));
@@ -370,6 +376,11 @@ where
     let session = Arc::new(SessionState::new(database.clone(), user.clone()));
     let session_id = session.id;
 
+    // pg_stat_activity shows the application_name the client connected with
+    if let Some(application_name) = startup.parameters.get("application_name") {
+        pgsqlite::session::activity::set_application_name(session.pid, application_name);
+    }
+
     // Set the database handler for this session for proper lifecycle management
     session.set_db_handler(db_handler.clone()).await;
 
@@ -407,8 +418,8 @@ where
     // Send backend key data
     framed
         .send(BackendMessage::BackendKeyData {
-            process_id: std::process::id() as i32,
-            secret_key: rand::random::<i32>(),
+            process_id: session.pid,
+            secret_key: session.secret_key,
         })
         .await?;
 
@@ -422,7 +433,31 @@ where
     info!("Sent authentication and ready response to {}", connection_info);
 
     // Main message loop
-    while let Some(msg) = framed.next().await {
+    loop {
+        let msg = tokio::select! {
+            msg = framed.next() => msg,
+            _ = session.terminated() => {
+                info!("Terminating connection from {} at the request of pg_terminate_backend()", connection_info);
+
+                if session.in_transaction().await {
+                    if let Err(e) = db_handler.rollback_with_session(&session_id).await {
+                        error!("Failed to rollback transaction on termination: {}", e);
+                    }
+                    session.set_transaction_status(TransactionStatus::Idle).await;
+                }
+
+                let err = ErrorResponse::new(
+                    "FATAL".to_string(),
+                    "57P01".to_string(),
+                    "terminating connection due to administrator command".to_string(),
+                );
+                framed.send(BackendMessage::ErrorResponse(Box::new(err))).await?;
+                break;
+            }
+        };
+        let Some(msg) = msg else {
+            break;
+        };
         match msg? {
             FrontendMessage::Query(sql) => {
                 debug!("Received query from {}: {}", connection_info, sql);
@@ -461,11 +496,7 @@ where
                             session.set_transaction_status(TransactionStatus::InFailedTransaction).await;
                         }
                         
-                        let err = ErrorResponse::new(
-                            "ERROR".to_string(),
-                            "42000".to_string(),
-                            format!("Query execution failed: {e}"),
-                        );
+                        let err = e.to_error_response("Query execution failed");
                         framed.send(BackendMessage::ErrorResponse(Box::new(err))).await?;
                     }
                 }
@@ -574,11 +605,7 @@ where
                     Ok(()) => {}
                     Err(e) => {
                         error!("Execute error: {}", e);
-                        let err = ErrorResponse::new(
-                            "ERROR".to_string(),
-                            "42000".to_string(),
-                            format!("Execute failed: {e}"),
-                        );
+                        let err = e.to_error_response("Execute failed");
                         framed.send(BackendMessage::ErrorResponse(Box::new(err))).await?;
                         framed
                             .send(BackendMessage::ReadyForQuery {
@@ -653,6 +680,9 @@ where
                 
                 break;
             }
+            FrontendMessage::CopyData(_) | FrontendMessage::CopyDone | FrontendMessage::CopyFail(_) => {
+                // Left over from a COPY FROM STDIN that failed; PostgreSQL ignores these too
+            }
             other => {
                 info!("Received unhandled message from {}: {:?}", connection_info, other);
             }
@@ -0,0 +1,226 @@
+use rusqlite::Connection;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use crate::error::PgError;
+use crate::PgSqliteError;
+use crate::translator::sql_scan::split_top_level;
+
+static EXCLUDE_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^\s*(?:CONSTRAINT\s+("[^"]+"|\w+)\s+)?EXCLUDE\s+(?:USING\s+\w+\s*)?\((.*)\)\s*(.*?)\s*$"#).unwrap()
+});
+
+static ELEMENT_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^\s*("[^"]+"|\w+)\s+WITH\s+(\S+)\s*$"#).unwrap()
+});
+
+/// Prefix of the trigger error, mapped to SQLSTATE 23P01 by the ConstraintViolationMapper
+pub const EXCLUSION_VIOLATION_PREFIX: &str = "conflicting key value violates exclusion constraint \"";
+
+/// An `EXCLUDE [USING method] (column WITH operator, ...)` table constraint.
+///
+/// SQLite has no exclusion constraints, so they are enforced by BEFORE INSERT and UPDATE
+/// triggers that abort when an existing row matches the new one on every element.
+/// Columns compare with `=` and the other comparison operators, ranges with `&&`.
+#[derive(Debug, Clone)]
+pub struct ExclusionConstraint {
+    pub name: String,
+    /// (column, operator) pairs
+    pub elements: Vec<(String, String)>,
+}
+
+impl ExclusionConstraint {
+    /// Parse a table constraint definition, returning None if it is not an EXCLUDE constraint
+    pub fn parse(definition: &str, table_name: &str) -> Result<Option<Self>, PgSqliteError> {
+        let Some(caps) = EXCLUDE_PATTERN.captures(definition) else {
+            return Ok(None);
+        };
+
+        let trailing = &caps[3];
+        if !trailing.is_empty() {
+            return Err(Self::not_supported(format!("exclusion constraint clause \"{trailing}\" is not supported")));
+        }
+
+        let mut elements = Vec::new();
+        for element in split_top_level(&caps[2]) {
+            let element_caps = ELEMENT_PATTERN.captures(element)
+                .ok_or_else(|| Self::not_supported(format!("exclusion constraint element \"{}\" is not supported", element.trim())))?;
+            let column = element_caps[1].trim_matches('"').to_string();
+            let operator = element_caps[2].to_string();
+            if Self::condition("e", &column, &operator).is_none() {
+                return Err(Self::not_supported(format!("operator {operator} is not supported in exclusion constraints")));
+            }
+            elements.push((column, operator));
+        }
+        if elements.is_empty() {
+            return Err(Self::not_supported("exclusion constraint needs at least one element".to_string()));
+        }
+
+        // PostgreSQL's default name: table, columns, then "excl"
+        let name = match caps.get(1) {
+            Some(name) => name.as_str().trim_matches('"').to_string(),
+            None => {
+                let columns: Vec<&str> = elements.iter().map(|(column, _)| column.as_str()).collect();
+                format!("{}_{}_excl", table_name, columns.join("_"))
+            }
+        };
+
+        Ok(Some(ExclusionConstraint { name, elements }))
+    }
+
+    /// Create the triggers enforcing the constraint on `table_name`
+    pub fn create_triggers(&self, conn: &Connection, table_name: &str) -> Result<(), PgSqliteError> {
+        let columns: Vec<String> = self.elements.iter().map(|(column, _)| format!("\"{column}\"")).collect();
+        let conditions: Vec<String> = self.elements.iter()
+            .filter_map(|(column, operator)| Self::condition("e", column, operator))
+            .collect();
+        let conditions = conditions.join(" AND ");
+
+        // The error carries the new key and the first conflicting one, like PostgreSQL's detail
+        let key_text = |alias: &str| {
+            let values: Vec<String> = columns.iter().map(|column| format!("{alias}.{column}")).collect();
+            values.join(" || ', ' || ")
+        };
+        let column_list = self.elements.iter().map(|(column, _)| column.as_str()).collect::<Vec<_>>().join(", ");
+        let message = |filter: &str| format!(
+            "'{EXCLUSION_VIOLATION_PREFIX}{name}\"' || char(10) || 'Key ({column_list})=(' || {new_key} || \
+             ') conflicts with existing key ({column_list})=(' || \
+             (SELECT {existing_key} FROM \"{table_name}\" e WHERE {filter}{conditions} LIMIT 1) || ').'",
+            name = self.name.replace('\'', "''"),
+            new_key = key_text("NEW"),
+            existing_key = key_text("e"),
+        );
+
+        let insert_trigger_sql = format!(
+            r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_{name}_insert"
+            BEFORE INSERT ON "{table_name}"
+            FOR EACH ROW
+            WHEN EXISTS (SELECT 1 FROM "{table_name}" e WHERE {conditions})
+            BEGIN
+                SELECT RAISE(ABORT, {message});
+            END"#,
+            name = self.name,
+            message = message(""),
+        );
+        conn.execute(&insert_trigger_sql, [])
+            .map_err(|e| PgSqliteError::Protocol(format!("Failed to create exclusion INSERT trigger: {e}")))?;
+
+        // An updated row doesn't conflict with its own old version
+        let update_trigger_sql = format!(
+            r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_{name}_update"
+            BEFORE UPDATE OF {columns} ON "{table_name}"
+            FOR EACH ROW
+            WHEN EXISTS (SELECT 1 FROM "{table_name}" e WHERE e.rowid <> OLD.rowid AND {conditions})
+            BEGIN
+                SELECT RAISE(ABORT, {message});
+            END"#,
+            name = self.name,
+            columns = columns.join(", "),
+            message = message("e.rowid <> OLD.rowid AND "),
+        );
+        conn.execute(&update_trigger_sql, [])
+            .map_err(|e| PgSqliteError::Protocol(format!("Failed to create exclusion UPDATE trigger: {e}")))?;
+
+        debug!("Created exclusion constraint triggers for {}.{}", table_name, self.name);
+        Ok(())
+    }
+
+    /// Record the constraint in pg_constraint with contype 'x'
+    pub fn record_in_catalog(&self, conn: &Connection, table_name: &str) -> Result<(), rusqlite::Error> {
+        let mut column_numbers = Vec::new();
+        for (column, _) in &self.elements {
+            let cid: i64 = conn.query_row(
+                "SELECT cid FROM pragma_table_info(?1) WHERE name = ?2 COLLATE NOCASE",
+                [table_name, column],
+                |row| row.get(0),
+            )?;
+            column_numbers.push((cid + 1).to_string());
+        }
+        let operators: Vec<&str> = self.elements.iter().map(|(_, operator)| operator.as_str()).collect();
+        let definition = self.elements.iter()
+            .map(|(column, operator)| format!("{column} WITH {operator}"))
+            .collect::<Vec<_>>()
+            .join(", ");
+
+        conn.execute(
+            "INSERT OR IGNORE INTO pg_constraint (
+                oid, conname, contype, conrelid, conkey, conexclop, consrc, conislocal, convalidated
+            ) VALUES (?1, ?2, 'x', ?3, ?4, ?5, ?6, 1, 1)",
+            rusqlite::params![
+                crate::catalog::constraint_populator::generate_constraint_oid(&self.name, "x"),
+                self.name,
+                crate::catalog::constraint_populator::generate_table_oid(table_name),
+                column_numbers.join(","),
+                operators.join(","),
+                format!("EXCLUDE USING gist ({definition})"),
+            ],
+        )?;
+        Ok(())
+    }
+
+    /// SQL condition for an existing row `alias` conflicting with NEW on one element
+    fn condition(alias: &str, column: &str, operator: &str) -> Option<String> {
+        match operator {
+            "&&" => Some(format!("range_overlaps({alias}.\"{column}\", NEW.\"{column}\")")),
+            "=" | "<>" | "!=" | "<" | ">" | "<=" | ">=" => Some(format!("{alias}.\"{column}\" {operator} NEW.\"{column}\"")),
+            _ => None,
+        }
+    }
+
+    fn not_supported(message: String) -> PgSqliteError {
+        PgError::Generic {
+            code: "0A000".to_string(), // feature_not_supported
+            message,
+        }.into()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_exclusion_constraint() {
+        let constraint = ExclusionConstraint::parse("EXCLUDE USING gist (room WITH =, during WITH &&)", "reservations")
+            .unwrap()
+            .unwrap();
+        assert_eq!(constraint.name, "reservations_room_during_excl");
+        assert_eq!(constraint.elements, vec![
+            ("room".to_string(), "=".to_string()),
+            ("during".to_string(), "&&".to_string()),
+        ]);
+
+        let constraint = ExclusionConstraint::parse("CONSTRAINT no_double_booking EXCLUDE (room WITH =)", "reservations")
+            .unwrap()
+            .unwrap();
+        assert_eq!(constraint.name, "no_double_booking");
+
+        assert!(ExclusionConstraint::parse("UNIQUE (room)", "reservations").unwrap().is_none());
+        assert!(ExclusionConstraint::parse("EXCLUDE USING gist (during WITH -|-)", "reservations").is_err());
+        assert!(ExclusionConstraint::parse("EXCLUDE (room WITH =) WHERE (active)", "reservations").is_err());
+    }
+
+    #[test]
+    fn test_exclusion_triggers() {
+        let conn = Connection::open_in_memory().unwrap();
+        crate::functions::range_functions::register_range_functions(&conn).unwrap();
+        conn.execute("CREATE TABLE reservations (id INTEGER PRIMARY KEY, room INTEGER, during TEXT)", []).unwrap();
+        let constraint = ExclusionConstraint::parse("EXCLUDE USING gist (room WITH =, during WITH &&)", "reservations")
+            .unwrap()
+            .unwrap();
+        constraint.create_triggers(&conn, "reservations").unwrap();
+
+        conn.execute("INSERT INTO reservations (room, during) VALUES (1, '[10,12)')", []).unwrap();
+        conn.execute("INSERT INTO reservations (room, during) VALUES (1, '[12,14)')", []).unwrap();
+        conn.execute("INSERT INTO reservations (room, during) VALUES (2, '[10,12)')", []).unwrap();
+
+        let err = conn.execute("INSERT INTO reservations (room, during) VALUES (1, '[11,13)')", []).unwrap_err();
+        let message = err.to_string();
+        assert!(message.starts_with("conflicting key value violates exclusion constraint \"reservations_room_during_excl\""), "{message}");
+        assert!(message.contains("Key (room, during)=(1, [11,13)) conflicts with existing key (room, during)=(1, [10,12))"), "{message}");
+
+        // Moving a reservation within its own slot is not a conflict
+        conn.execute("UPDATE reservations SET during = '[10,11)' WHERE id = 1", []).unwrap();
+        assert!(conn.execute("UPDATE reservations SET during = '[13,15)' WHERE id = 1", []).is_err());
+    }
+}
@@ -3,10 +3,16 @@ use std::collections::HashMap;
 
 pub mod enum_metadata;
 pub mod enum_triggers;
+pub mod exclusion_constraints;
+pub mod nulls_not_distinct;
 pub mod object_resolver;
+pub mod updatable_view;
 pub use enum_metadata::{EnumMetadata, EnumType, EnumValue};
 pub use enum_triggers::EnumTriggers;
+pub use exclusion_constraints::ExclusionConstraint;
+pub use nulls_not_distinct::NullsNotDistinctUnique;
 pub use object_resolver::ObjectResolver;
+pub use updatable_view::UpdatableView;
 
 /// Represents a type mapping between PostgreSQL and SQLite
 #[derive(Debug, Clone)]
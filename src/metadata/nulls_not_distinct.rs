@@ -0,0 +1,240 @@
+use rusqlite::Connection;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use crate::error::PgError;
+use crate::PgSqliteError;
+
+static NULLS_OPTION_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\s+NULLS\s+(NOT\s+)?DISTINCT\b").unwrap()
+});
+
+static CREATE_UNIQUE_INDEX_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^\s*CREATE\s+UNIQUE\s+INDEX\s+(?:IF\s+NOT\s+EXISTS\s+)?("[^"]+"|\w+)\s+ON\s+(?:\w+\.)?("[^"]+"|\w+)\s*(?:USING\s+\w+\s*)?\((.*)\)\s*(.*?)\s*;?\s*$"#).unwrap()
+});
+
+static TABLE_CONSTRAINT_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^\s*(?:CONSTRAINT\s+("[^"]+"|\w+)\s+)?UNIQUE\s*\((.*)\)\s*$"#).unwrap()
+});
+
+static COLUMN_UNIQUE_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bUNIQUE\b").unwrap()
+});
+
+/// A unique index or constraint declared `NULLS NOT DISTINCT`.
+///
+/// SQLite's unique indexes let any number of rows share a key containing NULL, which is
+/// PostgreSQL's default `NULLS DISTINCT` behavior. The index is still created for the keys
+/// without NULLs, and BEFORE INSERT and UPDATE triggers reject a new key with a NULL that
+/// matches an existing one, comparing with `IS` so NULL equals NULL. The triggers raise
+/// SQLite's own unique failure message so the error maps to SQLSTATE 23505.
+#[derive(Debug, Clone, PartialEq)]
+pub struct NullsNotDistinctUnique {
+    pub name: String,
+    pub table_name: String,
+    pub columns: Vec<String>,
+}
+
+impl NullsNotDistinctUnique {
+    /// Remove the `NULLS [NOT] DISTINCT` option from a CREATE UNIQUE INDEX, returning the
+    /// statement SQLite can run and the constraint when it is NULLS NOT DISTINCT
+    pub fn from_create_index(query: &str) -> Result<(String, Option<Self>), PgSqliteError> {
+        let Some(caps) = CREATE_UNIQUE_INDEX_PATTERN.captures(query) else {
+            return Ok((query.to_string(), None));
+        };
+        let Some(option) = NULLS_OPTION_PATTERN.captures(&caps[4]) else {
+            return Ok((query.to_string(), None));
+        };
+        let sql = NULLS_OPTION_PATTERN.replace(query, "").to_string();
+        if option.get(1).is_none() {
+            // NULLS DISTINCT is what SQLite does anyway
+            return Ok((sql, None));
+        }
+        if !NULLS_OPTION_PATTERN.replace(&caps[4], "").trim().is_empty() {
+            return Err(Self::not_supported("NULLS NOT DISTINCT is not supported on partial indexes"));
+        }
+
+        let columns = Self::column_list(&caps[3])?;
+        let name = caps[1].trim_matches('"').to_string();
+        let table_name = caps[2].trim_matches('"').to_string();
+        Ok((sql, Some(Self { name, table_name, columns })))
+    }
+
+    /// Remove the `NULLS [NOT] DISTINCT` option from a CREATE TABLE element, returning the
+    /// definition SQLite can use and the constraint when it is NULLS NOT DISTINCT
+    pub fn from_table_element(definition: &str, table_name: &str) -> Result<(String, Option<Self>), PgSqliteError> {
+        let Some(option) = NULLS_OPTION_PATTERN.captures(definition) else {
+            return Ok((definition.to_string(), None));
+        };
+        let stripped = NULLS_OPTION_PATTERN.replace(definition, "").to_string();
+        if option.get(1).is_none() {
+            return Ok((stripped, None));
+        }
+
+        let (name, columns) = if let Some(caps) = TABLE_CONSTRAINT_PATTERN.captures(&stripped) {
+            (caps.get(1).map(|name| name.as_str().trim_matches('"').to_string()), Self::column_list(&caps[2])?)
+        } else if COLUMN_UNIQUE_PATTERN.is_match(&stripped) {
+            let column = stripped.split_whitespace().next().unwrap_or_default().trim_matches('"').to_string();
+            (None, vec![column])
+        } else {
+            return Ok((stripped, None));
+        };
+
+        // PostgreSQL's default name: table, columns, then "key"
+        let name = name.unwrap_or_else(|| format!("{}_{}_key", table_name, columns.join("_")));
+        Ok((stripped, Some(Self { name, table_name: table_name.to_string(), columns })))
+    }
+
+    /// Create the triggers enforcing the constraint
+    pub fn create_triggers(&self, conn: &Connection) -> Result<(), PgSqliteError> {
+        let table_name = &self.table_name;
+        let columns: Vec<String> = self.columns.iter().map(|column| format!("\"{column}\"")).collect();
+        let has_null = columns.iter()
+            .map(|column| format!("NEW.{column} IS NULL"))
+            .collect::<Vec<_>>()
+            .join(" OR ");
+        let matches = columns.iter()
+            .map(|column| format!("e.{column} IS NEW.{column}"))
+            .collect::<Vec<_>>()
+            .join(" AND ");
+        let failed = self.columns.iter()
+            .map(|column| format!("{table_name}.{column}"))
+            .collect::<Vec<_>>()
+            .join(", ");
+        let message = format!("UNIQUE constraint failed: {failed}").replace('\'', "''");
+
+        let insert_trigger_sql = format!(
+            r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_{name}_nnd_insert"
+            BEFORE INSERT ON "{table_name}"
+            FOR EACH ROW
+            WHEN ({has_null}) AND EXISTS (SELECT 1 FROM "{table_name}" e WHERE {matches})
+            BEGIN
+                SELECT RAISE(ABORT, '{message}');
+            END"#,
+            name = self.name,
+        );
+        conn.execute(&insert_trigger_sql, [])
+            .map_err(|e| PgSqliteError::Protocol(format!("Failed to create NULLS NOT DISTINCT INSERT trigger: {e}")))?;
+
+        // An updated row doesn't conflict with its own old version
+        let update_trigger_sql = format!(
+            r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_{name}_nnd_update"
+            BEFORE UPDATE OF {columns} ON "{table_name}"
+            FOR EACH ROW
+            WHEN ({has_null}) AND EXISTS (SELECT 1 FROM "{table_name}" e WHERE e.rowid <> OLD.rowid AND {matches})
+            BEGIN
+                SELECT RAISE(ABORT, '{message}');
+            END"#,
+            name = self.name,
+            columns = columns.join(", "),
+        );
+        conn.execute(&update_trigger_sql, [])
+            .map_err(|e| PgSqliteError::Protocol(format!("Failed to create NULLS NOT DISTINCT UPDATE trigger: {e}")))?;
+
+        debug!("Created NULLS NOT DISTINCT triggers for {}.{}", table_name, self.name);
+        Ok(())
+    }
+
+    /// Record the option in __pgsqlite_nulls_not_distinct
+    pub fn record(&self, conn: &Connection) -> Result<(), rusqlite::Error> {
+        conn.execute(
+            "INSERT OR REPLACE INTO __pgsqlite_nulls_not_distinct (name, table_name, columns) VALUES (?1, ?2, ?3)",
+            rusqlite::params![self.name, self.table_name, self.columns.join(",")],
+        )?;
+        Ok(())
+    }
+
+    /// Whether the named index or constraint was declared NULLS NOT DISTINCT
+    pub fn is_recorded(conn: &Connection, name: &str) -> bool {
+        conn.query_row(
+            "SELECT 1 FROM __pgsqlite_nulls_not_distinct WHERE name = ?1",
+            [name],
+            |_| Ok(()),
+        ).is_ok()
+    }
+
+    /// Forget the constraints of a dropped table
+    pub fn forget_table(conn: &Connection, table_name: &str) -> Result<(), rusqlite::Error> {
+        conn.execute("DELETE FROM __pgsqlite_nulls_not_distinct WHERE table_name = ?1", [table_name])?;
+        Ok(())
+    }
+
+    /// The plain column names of a key list; expressions can't be compared by the triggers
+    fn column_list(list: &str) -> Result<Vec<String>, PgSqliteError> {
+        list.split(',')
+            .map(|column| {
+                let column = column.trim().trim_matches('"');
+                if !column.is_empty() && column.chars().all(|c| c.is_alphanumeric() || c == '_') {
+                    Ok(column.to_string())
+                } else {
+                    Err(Self::not_supported("NULLS NOT DISTINCT is only supported on plain column keys"))
+                }
+            })
+            .collect()
+    }
+
+    fn not_supported(message: &str) -> PgSqliteError {
+        PgError::Generic {
+            code: "0A000".to_string(), // feature_not_supported
+            message: message.to_string(),
+        }.into()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_unique_index() {
+        let (sql, constraint) = NullsNotDistinctUnique::from_create_index(
+            "CREATE UNIQUE INDEX users_email_idx ON users (email, tenant) NULLS NOT DISTINCT"
+        ).unwrap();
+        assert_eq!(sql, "CREATE UNIQUE INDEX users_email_idx ON users (email, tenant)");
+        let constraint = constraint.unwrap();
+        assert_eq!(constraint.name, "users_email_idx");
+        assert_eq!(constraint.table_name, "users");
+        assert_eq!(constraint.columns, vec!["email", "tenant"]);
+
+        let (sql, constraint) = NullsNotDistinctUnique::from_create_index("CREATE UNIQUE INDEX i ON users (email) NULLS DISTINCT").unwrap();
+        assert_eq!(sql, "CREATE UNIQUE INDEX i ON users (email)");
+        assert!(constraint.is_none());
+        assert!(NullsNotDistinctUnique::from_create_index("CREATE UNIQUE INDEX i ON users (lower(email)) NULLS NOT DISTINCT").is_err());
+    }
+
+    #[test]
+    fn test_parse_table_elements() {
+        let (sql, constraint) = NullsNotDistinctUnique::from_table_element("email TEXT UNIQUE NULLS NOT DISTINCT", "users").unwrap();
+        assert_eq!(sql, "email TEXT UNIQUE");
+        assert_eq!(constraint.unwrap(), NullsNotDistinctUnique {
+            name: "users_email_key".to_string(),
+            table_name: "users".to_string(),
+            columns: vec!["email".to_string()],
+        });
+
+        let (sql, constraint) = NullsNotDistinctUnique::from_table_element("CONSTRAINT one_login UNIQUE NULLS NOT DISTINCT (tenant, login)", "users").unwrap();
+        assert_eq!(sql, "CONSTRAINT one_login UNIQUE (tenant, login)");
+        assert_eq!(constraint.unwrap().columns, vec!["tenant", "login"]);
+
+        let (sql, constraint) = NullsNotDistinctUnique::from_table_element("email TEXT UNIQUE NULLS DISTINCT", "users").unwrap();
+        assert_eq!(sql, "email TEXT UNIQUE");
+        assert!(constraint.is_none());
+    }
+
+    #[test]
+    fn test_nulls_not_distinct_triggers() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute("CREATE TABLE users (id INTEGER PRIMARY KEY, tenant INTEGER, email TEXT UNIQUE)", []).unwrap();
+        let (_, constraint) = NullsNotDistinctUnique::from_table_element("email TEXT UNIQUE NULLS NOT DISTINCT", "users").unwrap();
+        constraint.unwrap().create_triggers(&conn).unwrap();
+
+        conn.execute("INSERT INTO users (email) VALUES ('a@example.com')", []).unwrap();
+        conn.execute("INSERT INTO users (email) VALUES (NULL)", []).unwrap();
+        let err = conn.execute("INSERT INTO users (email) VALUES (NULL)", []).unwrap_err();
+        assert_eq!(err.to_string(), "UNIQUE constraint failed: users.email");
+
+        // The row holding the NULL can still be updated
+        conn.execute("UPDATE users SET tenant = 1, email = NULL WHERE id = 2", []).unwrap();
+        assert!(conn.execute("UPDATE users SET email = NULL WHERE id = 1", []).is_err());
+    }
+}
@@ -1,7 +1,5 @@
 use rusqlite::Connection;
 use crate::PgSqliteError;
-use std::collections::hash_map::DefaultHasher;
-use std::hash::{Hash, Hasher};
 use tracing::debug;
 
 /// Resolves database object names to their corresponding OIDs for comment storage
@@ -10,7 +8,7 @@ pub struct ObjectResolver;
 impl ObjectResolver {
     /// Resolve table name to OID using the same algorithm as pg_class view
     pub fn resolve_table_oid(table_name: &str) -> i32 {
-        generate_table_oid(table_name)
+        crate::catalog::constraint_populator::table_oid(table_name) as i32
     }
     
     /// Resolve column to (table_oid, column_number)
@@ -80,15 +78,6 @@ impl ObjectResolver {
     }
 }
 
-/// Generate a stable OID from table name using the same algorithm as pg_class view
-fn generate_table_oid(name: &str) -> i32 {
-    let mut hasher = DefaultHasher::new();
-    name.hash(&mut hasher);
-    // Keep it positive and in a reasonable range for OIDs
-    // Use same algorithm as existing catalog system
-    ((hasher.finish() & 0x7FFFFFFF) % 1000000 + 16384) as i32
-}
-
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -108,6 +97,16 @@ mod tests {
         // OIDs should be in expected range
         assert!(oid1 >= 16384);
         assert!(oid1 < 1016384);
+        
+        // Same OID the pg_class view reports for the table
+        let conn = Connection::open_in_memory().unwrap();
+        let view_oid: i32 = conn.query_row(
+            "SELECT ((unicode(substr(?1, 1, 1)) * 1000000) + (unicode(substr(?1 || ' ', 2, 1)) * 10000) +
+                     (unicode(substr(?1 || '  ', 3, 1)) * 100) + (length(?1) * 7)) % 1000000 + 16384",
+            ["users"],
+            |row| row.get(0),
+        ).unwrap();
+        assert_eq!(oid1, view_oid);
     }
     
     #[test]
@@ -0,0 +1,299 @@
+use rusqlite::Connection;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use sqlparser::ast::{Expr, GroupByExpr, SelectItem, SetExpr, Statement, TableFactor};
+use sqlparser::dialect::PostgreSqlDialect;
+use sqlparser::parser::Parser;
+use tracing::debug;
+use crate::error::PgError;
+use crate::PgSqliteError;
+
+static CREATE_VIEW_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^\s*CREATE\s+(?:(?:TEMP|TEMPORARY)\s+)?VIEW\s+(?:IF\s+NOT\s+EXISTS\s+)?(?:\w+\.)?("[^"]+"|\w+)\s*(?:\([^)]*\)\s*)?AS\s+(.*?)\s*;?\s*$"#).unwrap()
+});
+
+static CHECK_OPTION_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\s+WITH\s+(?:(?:LOCAL|CASCADED)\s+)?CHECK\s+OPTION\s*$").unwrap()
+});
+
+/// Prefix of the trigger error raised when a row written through a view falls outside it
+pub const CHECK_OPTION_VIOLATION_PREFIX: &str = "new row violates check option for view \"";
+
+/// A simple view over one table, which PostgreSQL makes automatically updatable.
+///
+/// SQLite views are read-only, so INSTEAD OF triggers carry inserts, updates and deletes
+/// through to the base table. A view selecting plain columns (or `*`) from a single table,
+/// without DISTINCT, GROUP BY, HAVING, LIMIT or set operations, qualifies. Rows are found
+/// by the table's primary key when the view shows it, otherwise by every column shown.
+/// Columns left out of an INSERT take the base table's default, as in PostgreSQL.
+///
+/// With `WITH [LOCAL | CASCADED] CHECK OPTION`, a written row has to satisfy the view's
+/// WHERE clause; the triggers look the row up again in the base table and abort if it is
+/// no longer visible through the view.
+#[derive(Debug, Clone, PartialEq)]
+pub struct UpdatableView {
+    pub name: String,
+    pub table_name: String,
+    /// Alias of the base table in the view definition, which the predicate may use
+    pub table_alias: Option<String>,
+    /// Base table columns shown by the view, in order; None for `SELECT *`
+    pub base_columns: Option<Vec<String>>,
+    pub predicate: Option<String>,
+    pub check_option: bool,
+}
+
+impl UpdatableView {
+    /// Remove the `WITH CHECK OPTION` clause from a CREATE VIEW, returning the statement
+    /// SQLite can run and the view when it is simple enough to be updatable
+    pub fn from_create_view(query: &str) -> Result<(String, Option<Self>), PgSqliteError> {
+        let Some(caps) = CREATE_VIEW_PATTERN.captures(query) else {
+            return Ok((query.to_string(), None));
+        };
+        let name = caps[1].trim_matches('"').to_string();
+        let body = caps.get(2).unwrap();
+        let (sql, body, check_option) = match CHECK_OPTION_PATTERN.find(body.as_str()) {
+            Some(option) => {
+                let sql = format!("{}{}", &query[..body.start() + option.start()], &query[body.end()..]);
+                (sql, &body.as_str()[..option.start()], true)
+            }
+            None => (query.to_string(), body.as_str(), false),
+        };
+
+        let view = Self::parse_body(body).map(|(table_name, table_alias, base_columns, predicate)| Self {
+            name,
+            table_name,
+            table_alias,
+            base_columns,
+            predicate,
+            check_option,
+        });
+        if check_option && view.is_none() {
+            return Err(PgError::Generic {
+                code: "0A000".to_string(), // feature_not_supported
+                message: "WITH CHECK OPTION is supported only on automatically updatable views".to_string(),
+            }.into());
+        }
+        Ok((sql, view))
+    }
+
+    /// Table, alias, columns and WHERE clause of a single-table SELECT of plain columns
+    #[allow(clippy::type_complexity)]
+    fn parse_body(body: &str) -> Option<(String, Option<String>, Option<Vec<String>>, Option<String>)> {
+        let statements = Parser::parse_sql(&PostgreSqlDialect {}, body).ok()?;
+        let [Statement::Query(parsed)] = statements.as_slice() else {
+            return None;
+        };
+        if parsed.with.is_some() || parsed.limit_clause.is_some() || parsed.fetch.is_some() {
+            return None;
+        }
+        let SetExpr::Select(select) = parsed.body.as_ref() else {
+            return None;
+        };
+        let no_grouping = matches!(&select.group_by, GroupByExpr::Expressions(exprs, _) if exprs.is_empty());
+        if select.distinct.is_some() || !no_grouping || select.having.is_some() {
+            return None;
+        }
+        let [from] = select.from.as_slice() else {
+            return None;
+        };
+        if !from.joins.is_empty() {
+            return None;
+        }
+        let TableFactor::Table { name, alias, .. } = &from.relation else {
+            return None;
+        };
+        let table_name = name.to_string().rsplit('.').next()?.trim_matches('"').to_string();
+        let table_alias = alias.as_ref().map(|alias| alias.name.value.clone());
+
+        let base_columns = match select.projection.as_slice() {
+            [SelectItem::Wildcard(_) | SelectItem::QualifiedWildcard(..)] => None,
+            items => Some(
+                items.iter()
+                    .map(|item| match item {
+                        SelectItem::UnnamedExpr(expr) | SelectItem::ExprWithAlias { expr, .. } => Self::column_name(expr),
+                        _ => None,
+                    })
+                    .collect::<Option<Vec<_>>>()?,
+            ),
+        };
+        let predicate = select.selection.as_ref().map(|expr| expr.to_string());
+        Some((table_name, table_alias, base_columns, predicate))
+    }
+
+    fn column_name(expr: &Expr) -> Option<String> {
+        match expr {
+            Expr::Identifier(ident) => Some(ident.value.clone()),
+            Expr::CompoundIdentifier(parts) => parts.last().map(|ident| ident.value.clone()),
+            _ => None,
+        }
+    }
+
+    /// Create the INSTEAD OF triggers writing through the view. Views over other views
+    /// are left read-only.
+    pub fn create_triggers(&self, conn: &Connection) -> Result<(), PgSqliteError> {
+        let name = &self.name;
+        let table_name = &self.table_name;
+        let is_table = conn.query_row(
+            "SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?1 COLLATE NOCASE",
+            [table_name],
+            |_| Ok(()),
+        ).is_ok();
+        if !is_table {
+            debug!("View {} is over {}, which isn't a table; leaving it read-only", name, table_name);
+            return Ok(());
+        }
+
+        let table_columns = Self::pragma_names(conn, &format!("SELECT name FROM pragma_table_info('{}')", table_name.replace('\'', "''")))?;
+        let view_columns = Self::pragma_names(conn, &format!("SELECT name FROM pragma_table_info('{}')", name.replace('\'', "''")))?;
+        let base_columns = self.base_columns.clone().unwrap_or(table_columns);
+        if base_columns.len() != view_columns.len() {
+            debug!("Columns of view {} don't line up with {}; leaving it read-only", name, table_name);
+            return Ok(());
+        }
+        let columns: Vec<(&str, &str)> = view_columns.iter().map(String::as_str)
+            .zip(base_columns.iter().map(String::as_str))
+            .collect();
+
+        // Identify rows by the primary key when the view shows all of it
+        let key_columns = Self::pragma_names(
+            conn,
+            &format!("SELECT name FROM pragma_table_info('{}') WHERE pk > 0 ORDER BY pk", table_name.replace('\'', "''")),
+        )?;
+        let key: Vec<(&str, &str)> = if !key_columns.is_empty()
+            && key_columns.iter().all(|k| columns.iter().any(|(_, base)| base.eq_ignore_ascii_case(k))) {
+            columns.iter().copied().filter(|(_, base)| key_columns.iter().any(|k| base.eq_ignore_ascii_case(k))).collect()
+        } else {
+            columns.clone()
+        };
+        let matches = |row: &str| key.iter()
+            .map(|(view, base)| format!("\"{base}\" IS {row}.\"{view}\""))
+            .collect::<Vec<_>>()
+            .join(" AND ");
+
+        let defaults: Vec<(String, Option<String>)> = conn
+            .prepare(&format!("SELECT name, dflt_value FROM pragma_table_info('{}')", table_name.replace('\'', "''")))?
+            .query_map([], |row| Ok((row.get(0)?, row.get(1)?)))?
+            .collect::<rusqlite::Result<_>>()?;
+        let insert_values = columns.iter()
+            .map(|(view, base)| {
+                match defaults.iter().find(|(column, _)| column.eq_ignore_ascii_case(base)).and_then(|(_, d)| d.as_ref()) {
+                    Some(default) => format!("coalesce(NEW.\"{view}\", {default})"),
+                    None => format!("NEW.\"{view}\""),
+                }
+            })
+            .collect::<Vec<_>>()
+            .join(", ");
+        let insert_columns = columns.iter().map(|(_, base)| format!("\"{base}\"")).collect::<Vec<_>>().join(", ");
+        let assignments = columns.iter()
+            .map(|(view, base)| format!("\"{base}\" = NEW.\"{view}\""))
+            .collect::<Vec<_>>()
+            .join(", ");
+
+        let check = |row_filter: &str| match (&self.predicate, self.check_option) {
+            (Some(predicate), true) => format!(
+                "SELECT RAISE(ABORT, '{}{}\"') WHERE NOT EXISTS (SELECT 1 FROM \"{table_name}\"{} WHERE {row_filter} AND ({predicate}));",
+                CHECK_OPTION_VIOLATION_PREFIX,
+                name.replace('\'', "''"),
+                self.table_alias.as_ref().map(|alias| format!(" AS \"{alias}\"")).unwrap_or_default(),
+            ),
+            _ => String::new(),
+        };
+
+        let triggers = [
+            ("insert", format!(
+                "INSERT INTO \"{table_name}\" ({insert_columns}) VALUES ({insert_values});\n{}",
+                check("rowid = last_insert_rowid()"),
+            )),
+            ("update", format!(
+                "UPDATE \"{table_name}\" SET {assignments} WHERE {};\n{}",
+                matches("OLD"),
+                check(&matches("NEW")),
+            )),
+            ("delete", format!("DELETE FROM \"{table_name}\" WHERE {};", matches("OLD"))),
+        ];
+        for (event, body) in triggers {
+            let trigger_sql = format!(
+                r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_view_{event}_{name}"
+                INSTEAD OF {} ON "{name}"
+                FOR EACH ROW
+                BEGIN
+                    {body}
+                END"#,
+                event.to_uppercase(),
+            );
+            conn.execute(&trigger_sql, [])
+                .map_err(|e| PgSqliteError::Protocol(format!("Failed to create {event} trigger for view {name}: {e}")))?;
+        }
+
+        debug!("Created updatable view triggers for {} over {}", name, table_name);
+        Ok(())
+    }
+
+    fn pragma_names(conn: &Connection, sql: &str) -> Result<Vec<String>, rusqlite::Error> {
+        conn.prepare(sql)?
+            .query_map([], |row| row.get(0))?
+            .collect()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_create_view() {
+        let (sql, view) = UpdatableView::from_create_view(
+            "CREATE VIEW available_books AS SELECT * FROM books WHERE is_available WITH CHECK OPTION"
+        ).unwrap();
+        assert_eq!(sql, "CREATE VIEW available_books AS SELECT * FROM books WHERE is_available");
+        assert_eq!(view.unwrap(), UpdatableView {
+            name: "available_books".to_string(),
+            table_name: "books".to_string(),
+            table_alias: None,
+            base_columns: None,
+            predicate: Some("is_available".to_string()),
+            check_option: true,
+        });
+
+        let (sql, view) = UpdatableView::from_create_view(
+            "CREATE VIEW cheap (book, cost) AS SELECT b.title, b.price AS p FROM books b WHERE b.price < 10 WITH LOCAL CHECK OPTION;"
+        ).unwrap();
+        assert_eq!(sql, "CREATE VIEW cheap (book, cost) AS SELECT b.title, b.price AS p FROM books b WHERE b.price < 10;");
+        let view = view.unwrap();
+        assert_eq!(view.table_alias.as_deref(), Some("b"));
+        assert_eq!(view.base_columns, Some(vec!["title".to_string(), "price".to_string()]));
+        assert_eq!(view.predicate.as_deref(), Some("b.price < 10"));
+
+        // Not updatable: read-only without the option, an error with it
+        let query = "CREATE VIEW counts AS SELECT author, count(*) FROM books GROUP BY author";
+        assert_eq!(UpdatableView::from_create_view(query).unwrap(), (query.to_string(), None));
+        let err = UpdatableView::from_create_view("CREATE VIEW v AS SELECT DISTINCT author FROM books WITH CHECK OPTION").unwrap_err();
+        assert_eq!(err.pg_error_code(), "0A000");
+    }
+
+    #[test]
+    fn test_writes_through_view() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, is_available INTEGER DEFAULT 1)", []).unwrap();
+        let (sql, view) = UpdatableView::from_create_view(
+            "CREATE VIEW available_books AS SELECT id, title, is_available FROM books WHERE is_available WITH CHECK OPTION"
+        ).unwrap();
+        conn.execute(&sql, []).unwrap();
+        view.unwrap().create_triggers(&conn).unwrap();
+
+        conn.execute("INSERT INTO available_books (title) VALUES ('Dune')", []).unwrap();
+        conn.execute("INSERT INTO available_books (title, is_available) VALUES ('Emma', 1)", []).unwrap();
+        let err = conn.execute("INSERT INTO available_books (title, is_available) VALUES ('Ulysses', 0)", []).unwrap_err();
+        assert_eq!(err.to_string(), "new row violates check option for view \"available_books\"");
+
+        conn.execute("UPDATE available_books SET title = 'Dune Messiah' WHERE id = 1", []).unwrap();
+        let err = conn.execute("UPDATE available_books SET is_available = 0 WHERE id = 2", []).unwrap_err();
+        assert!(err.to_string().starts_with(CHECK_OPTION_VIOLATION_PREFIX));
+        conn.execute("DELETE FROM available_books WHERE id = 2", []).unwrap();
+
+        let titles: Vec<String> = conn.prepare("SELECT title FROM books ORDER BY id").unwrap()
+            .query_map([], |row| row.get(0)).unwrap()
+            .collect::<rusqlite::Result<_>>().unwrap();
+        assert_eq!(titles, vec!["Dune Messiah"]);
+    }
+}
@@ -34,6 +34,20 @@ lazy_static! {
         register_v25_information_schema_triggers_support(&mut registry);
         register_v26_enhanced_pg_attribute_support(&mut registry);
         register_v27_fix_pg_proc_types(&mut registry);
+        register_v28_psql_describe_support(&mut registry);
+        register_v29_table_inheritance(&mut registry);
+        register_v30_pg_class_size_estimates(&mut registry);
+        register_v31_column_statistics(&mut registry);
+        register_v32_identity_columns(&mut registry);
+        register_v33_generated_columns(&mut registry);
+        register_v34_stat_activity(&mut registry);
+        register_v35_large_objects(&mut registry);
+        register_v36_nulls_not_distinct(&mut registry);
+        register_v37_serial_defaults(&mut registry);
+        register_v38_comment_oids(&mut registry);
+        register_v39_constraint_relation_oids(&mut registry);
+        register_v40_psql_describe_views(&mut registry);
+        register_v41_interval_text(&mut registry);
 
         registry
     };
@@ -535,8 +549,8 @@ fn populate_catalog_tables(conn: &rusqlite::Connection) -> anyhow::Result<()> {
     })?.collect::<Result<Vec<_>, rusqlite::Error>>()?;
     
     for (table_name, create_sql) in tables {
-        // Generate table OID (same as in pg_class view)
-        let table_oid = generate_table_oid(&table_name);
+        // Same table OID as the pg_class view
+        let table_oid = crate::catalog::constraint_populator::generate_table_oid(&table_name);
         
         // Parse CREATE TABLE statement to extract constraints
         if let Some(constraints) = parse_table_constraints(&table_name, &create_sql) {
@@ -590,8 +604,8 @@ fn populate_catalog_tables(conn: &rusqlite::Connection) -> anyhow::Result<()> {
     })?.collect::<Result<Vec<_>, _>>()?;
     
     for (index_name, table_name, create_sql) in indexes {
-        let index_oid = generate_table_oid(&index_name);
-        let table_oid = generate_table_oid(&table_name);
+        let index_oid = crate::catalog::constraint_populator::generate_table_oid(&index_name);
+        let table_oid = crate::catalog::constraint_populator::generate_table_oid(&table_name);
         
         // Parse index info
         let is_unique = create_sql.to_uppercase().contains("UNIQUE");
@@ -612,7 +626,7 @@ fn populate_catalog_tables(conn: &rusqlite::Connection) -> anyhow::Result<()> {
 }
 
 // Helper functions for parsing and OID generation
-fn generate_table_oid(name: &str) -> i32 {
+fn generate_object_oid(name: &str) -> i32 {
     use crate::utils::generate_oid_i32;
     generate_oid_i32(name)
 }
@@ -636,7 +650,7 @@ fn parse_table_constraints(table_name: &str, create_sql: &str) -> Option<Vec<Con
         for cap in pk_regex.captures_iter(create_sql) {
             if let Some(column_name) = cap.get(1) {
                 constraints.push(ConstraintInfo {
-                    oid: generate_table_oid(&format!("{table_name}_pkey")),
+                    oid: generate_object_oid(&format!("{table_name}_pkey")),
                     name: format!("{table_name}_pkey"),
                     contype: "p".to_string(),
                     columns: vec![column_name.as_str().to_string()],
@@ -655,7 +669,7 @@ fn parse_table_constraints(table_name: &str, create_sql: &str) -> Option<Vec<Con
                     .map(|s| s.trim().to_string())
                     .collect();
                 constraints.push(ConstraintInfo {
-                    oid: generate_table_oid(&format!("{table_name}_pkey")),
+                    oid: generate_object_oid(&format!("{table_name}_pkey")),
                     name: format!("{table_name}_pkey"),
                     contype: "p".to_string(),
                     columns,
@@ -670,7 +684,7 @@ fn parse_table_constraints(table_name: &str, create_sql: &str) -> Option<Vec<Con
         for cap in unique_regex.captures_iter(create_sql) {
             if let Some(column_name) = cap.get(1) {
                 constraints.push(ConstraintInfo {
-                    oid: generate_table_oid(&format!("{}_{}_key", table_name, column_name.as_str())),
+                    oid: generate_object_oid(&format!("{}_{}_key", table_name, column_name.as_str())),
                     name: format!("{}_{}_key", table_name, column_name.as_str()),
                     contype: "u".to_string(),
                     columns: vec![column_name.as_str().to_string()],
@@ -690,7 +704,7 @@ fn parse_table_constraints(table_name: &str, create_sql: &str) -> Option<Vec<Con
                     .collect();
                 let constraint_name = format!("{}_{}_key", table_name, columns.join("_"));
                 constraints.push(ConstraintInfo {
-                    oid: generate_table_oid(&constraint_name),
+                    oid: generate_object_oid(&constraint_name),
                     name: constraint_name,
                     contype: "u".to_string(),
                     columns,
@@ -706,7 +720,7 @@ fn parse_table_constraints(table_name: &str, create_sql: &str) -> Option<Vec<Con
             if let Some(check_expr) = cap.get(1) {
                 let constraint_name = format!("{}_check{}", table_name, i + 1);
                 constraints.push(ConstraintInfo {
-                    oid: generate_table_oid(&constraint_name),
+                    oid: generate_object_oid(&constraint_name),
                     name: constraint_name,
                     contype: "c".to_string(),
                     columns: vec![], // CHECK constraints don't have specific columns
@@ -722,7 +736,7 @@ fn parse_table_constraints(table_name: &str, create_sql: &str) -> Option<Vec<Con
             if let Some(column_name) = cap.get(1) {
                 let constraint_name = format!("{}_{}_not_null", table_name, column_name.as_str());
                 constraints.push(ConstraintInfo {
-                    oid: generate_table_oid(&constraint_name),
+                    oid: generate_object_oid(&constraint_name),
                     name: constraint_name,
                     contype: "c".to_string(),
                     columns: vec![column_name.as_str().to_string()],
@@ -758,7 +772,7 @@ fn parse_column_defaults(table_name: &str, create_sql: &str) -> Option<Vec<Defau
                 let column_num = get_column_number(create_sql, column_name.as_str()).unwrap_or(1);
                 
                 defaults.push(DefaultInfo {
-                    oid: generate_table_oid(&format!("{}_{}_default", table_name, column_name.as_str())),
+                    oid: generate_object_oid(&format!("{}_{}_default", table_name, column_name.as_str())),
                     column_num,
                     default_expr: default_value.as_str().trim().to_string(),
                 });
@@ -2859,4 +2873,1914 @@ fn register_v27_fix_pg_proc_types(registry: &mut BTreeMap<u32, Migration>) {
         ])),
         dependencies: vec![26],
     });
-}
\ No newline at end of file
+}
+/// Version 28: Live pg_attrdef and pg_index views for psql's \d
+fn register_v28_psql_describe_support(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(28, Migration {
+        version: 28,
+        name: "psql_describe_support",
+        description: "Derive pg_attrdef and pg_index from the live schema and report NOT NULL for primary key columns in pg_attribute",
+        up: MigrationAction::SqlBatch(&[
+            // The tables were only filled at CREATE TABLE time, so later indexes were missing
+            r#"DROP TABLE IF EXISTS pg_attrdef"#,
+            r#"DROP TABLE IF EXISTS pg_index"#,
+            r#"DROP VIEW IF EXISTS pg_attribute"#,
+
+            // Column defaults straight from SQLite, with SQLite-only spellings mapped back
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attrdef AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) || printf('%03d', p.cid + 1) as oid,
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as adrelid,
+                p.cid + 1 as adnum,
+                CASE
+                    WHEN p.dflt_value = 'datetime(''now'')' THEN 'now()'
+                    ELSE p.dflt_value
+                END as adbin,
+                CASE
+                    WHEN p.dflt_value = 'datetime(''now'')' THEN 'now()'
+                    ELSE p.dflt_value
+                END as adsrc
+            FROM sqlite_master m
+            JOIN pragma_table_info(m.name) p
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%'
+              AND p.dflt_value IS NOT NULL;
+            "#,
+
+            // One row per named index, with indkey listing the 1-based column numbers
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_index AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(il.name, 1, 1)) * 1000000) +
+                        (unicode(substr(il.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(il.name || '  ', 3, 1)) * 100) +
+                        (length(il.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as indexrelid,
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as indrelid,
+                (SELECT COUNT(*) FROM pragma_index_info(il.name)) as indnatts,
+                (SELECT COUNT(*) FROM pragma_index_info(il.name)) as indnkeyatts,
+                il."unique" as indisunique,
+                CASE WHEN il.origin = 'pk' THEN 1 ELSE 0 END as indisprimary,
+                0 as indisexclusion,
+                1 as indimmediate,
+                0 as indisclustered,
+                1 as indisvalid,
+                0 as indcheckxmin,
+                1 as indisready,
+                1 as indislive,
+                0 as indisreplident,
+                (SELECT group_concat(ii.cid + 1, ' ') FROM pragma_index_info(il.name) ii) as indkey,
+                '' as indcollation,
+                '' as indclass,
+                '' as indoption,
+                NULL as indexprs,
+                NULL as indpred
+            FROM sqlite_master m
+            JOIN pragma_index_list(m.name) il
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%'
+              AND il.name NOT LIKE 'sqlite_%';
+            "#,
+
+            // pg_attribute with PRIMARY KEY columns reported as NOT NULL, as PostgreSQL does
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attribute AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as attrelid,
+                p.cid + 1 as attnum,
+                p.name as attname,
+                CASE
+                    WHEN p.type LIKE '%INT%' THEN 23
+                    WHEN p.type = 'TEXT' THEN 25
+                    WHEN p.type = 'REAL' THEN 700
+                    WHEN p.type = 'BLOB' THEN 17
+                    WHEN p.type LIKE '%CHAR%' THEN 1043
+                    WHEN p.type = 'BOOLEAN' THEN 16
+                    WHEN p.type = 'DATE' THEN 1082
+                    WHEN p.type LIKE 'TIME%' THEN 1083
+                    WHEN p.type LIKE 'TIMESTAMP%' THEN 1114
+                    ELSE 25
+                END as atttypid,
+                -1 as attstattarget,
+                0 as attlen,
+                0 as attndims,
+                -1 as attcacheoff,
+                CASE WHEN p."notnull" = 1 OR p.pk > 0 THEN 't' ELSE 'f' END as attnotnull,
+                CASE WHEN p.dflt_value IS NOT NULL THEN 't' ELSE 'f' END as atthasdef,
+                'f' as atthasmissing,
+                CASE
+                    WHEN p.type LIKE '%INT%' AND p.pk = 1 THEN 'd'
+                    ELSE ''
+                END as attidentity,
+                '' as attgenerated,
+                'f' as attisdropped,
+                't' as attislocal,
+                0 as attinhcount,
+                0 as attcollation,
+                '' as attacl,
+                '' as attoptions,
+                '' as attfdwoptions,
+                '' as attmissingval
+            FROM pragma_table_info(m.name) p
+            JOIN sqlite_master m ON m.type = 'table'
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%';
+            "#,
+
+            // Update schema version
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '28', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ]),
+        down: Some(MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_attrdef"#,
+            r#"DROP VIEW IF EXISTS pg_index"#,
+            r#"DROP VIEW IF EXISTS pg_attribute"#,
+
+            r#"
+            CREATE TABLE IF NOT EXISTS pg_attrdef (
+                oid TEXT PRIMARY KEY,
+                adrelid TEXT NOT NULL,
+                adnum SMALLINT NOT NULL,
+                adbin TEXT,
+                adsrc TEXT
+            );
+            "#,
+
+            r#"
+            CREATE TABLE IF NOT EXISTS pg_index (
+                indexrelid TEXT PRIMARY KEY,
+                indrelid TEXT NOT NULL,
+                indnatts SMALLINT NOT NULL,
+                indnkeyatts SMALLINT NOT NULL,
+                indisunique BOOLEAN DEFAULT 0,
+                indisprimary BOOLEAN DEFAULT 0,
+                indisexclusion BOOLEAN DEFAULT 0,
+                indimmediate BOOLEAN DEFAULT 1,
+                indisclustered BOOLEAN DEFAULT 0,
+                indisvalid BOOLEAN DEFAULT 1,
+                indcheckxmin BOOLEAN DEFAULT 0,
+                indisready BOOLEAN DEFAULT 1,
+                indislive BOOLEAN DEFAULT 1,
+                indisreplident BOOLEAN DEFAULT 0,
+                indkey TEXT,
+                indcollation TEXT,
+                indclass TEXT,
+                indoption TEXT,
+                indexprs TEXT,
+                indpred TEXT
+            );
+            "#,
+
+            // Restore the version 26 pg_attribute view
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attribute AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as attrelid,
+                p.cid + 1 as attnum,
+                p.name as attname,
+                CASE
+                    WHEN p.type LIKE '%INT%' THEN 23
+                    WHEN p.type = 'TEXT' THEN 25
+                    WHEN p.type = 'REAL' THEN 700
+                    WHEN p.type = 'BLOB' THEN 17
+                    WHEN p.type LIKE '%CHAR%' THEN 1043
+                    WHEN p.type = 'BOOLEAN' THEN 16
+                    WHEN p.type = 'DATE' THEN 1082
+                    WHEN p.type LIKE 'TIME%' THEN 1083
+                    WHEN p.type LIKE 'TIMESTAMP%' THEN 1114
+                    ELSE 25
+                END as atttypid,
+                -1 as attstattarget,
+                0 as attlen,
+                0 as attndims,
+                -1 as attcacheoff,
+                CASE WHEN p."notnull" = 1 THEN 't' ELSE 'f' END as attnotnull,
+                CASE
+                    WHEN EXISTS (
+                        SELECT 1 FROM pg_attrdef def
+                        WHERE def.adrelid = CAST(
+                            (
+                                (unicode(substr(m.name, 1, 1)) * 1000000) +
+                                (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                                (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                                (length(m.name) * 7)
+                            ) % 1000000 + 16384
+                        AS TEXT)
+                        AND def.adnum = CAST(p.cid + 1 AS TEXT)
+                    ) THEN 't'
+                    ELSE 'f'
+                END as atthasdef,
+                'f' as atthasmissing,
+                CASE
+                    WHEN p.type LIKE '%INT%' AND p.pk = 1 THEN 'd'
+                    ELSE ''
+                END as attidentity,
+                '' as attgenerated,
+                'f' as attisdropped,
+                't' as attislocal,
+                0 as attinhcount,
+                0 as attcollation,
+                '' as attacl,
+                '' as attoptions,
+                '' as attfdwoptions,
+                '' as attmissingval
+            FROM pragma_table_info(m.name) p
+            JOIN sqlite_master m ON m.type = 'table'
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%';
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '27', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ])),
+        dependencies: vec![27],
+    });
+}
+
+/// Version 29: Parent tables of CREATE TABLE ... INHERITS
+fn register_v29_table_inheritance(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(29, Migration {
+        version: 29,
+        name: "table_inheritance",
+        description: "Record the parents of tables created with INHERITS and expose them through pg_inherits",
+        up: MigrationAction::SqlBatch(&[
+            r#"
+            CREATE TABLE IF NOT EXISTS __pgsqlite_inherits (
+                child_table TEXT NOT NULL,
+                parent_table TEXT NOT NULL,
+                seqno INTEGER NOT NULL,
+                PRIMARY KEY (child_table, parent_table)
+            );
+            "#,
+
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_inherits AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(i.child_table, 1, 1)) * 1000000) +
+                        (unicode(substr(i.child_table || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(i.child_table || '  ', 3, 1)) * 100) +
+                        (length(i.child_table) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as inhrelid,
+                CAST(
+                    (
+                        (unicode(substr(i.parent_table, 1, 1)) * 1000000) +
+                        (unicode(substr(i.parent_table || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(i.parent_table || '  ', 3, 1)) * 100) +
+                        (length(i.parent_table) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as inhparent,
+                i.seqno as inhseqno,
+                'f' as inhdetachpending
+            FROM __pgsqlite_inherits i;
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '29', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ]),
+        down: Some(MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_inherits"#,
+            r#"DROP TABLE IF EXISTS __pgsqlite_inherits"#,
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '28', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ])),
+        dependencies: vec![28],
+    });
+}
+
+fn register_v30_pg_class_size_estimates(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(30, Migration {
+        version: 30,
+        name: "pg_class_size_estimates",
+        description: "Report relpages and reltuples in pg_class from SQLite's page counts and ANALYZE statistics",
+        up: MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_class"#,
+
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_class AS
+            SELECT
+                -- Use SQLite built-in functions for consistent OID generation
+                CAST(
+                    (
+                        (unicode(substr(name, 1, 1)) * 1000000) +
+                        (unicode(substr(name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(name || '  ', 3, 1)) * 100) +
+                        (length(name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as oid,
+                name as relname,
+                2200 as relnamespace,  -- public schema
+                CASE
+                    WHEN type = 'table' THEN 'r'
+                    WHEN type = 'view' THEN 'v'
+                    WHEN type = 'index' THEN 'i'
+                END as relkind,
+                10 as relowner,
+                CASE WHEN type = 'index' THEN 403 ELSE 0 END as relam,
+                0 as relfilenode,
+                0 as reltablespace,
+                -- Page count from dbstat, row estimate as of the last ANALYZE
+                __pgsqlite_relpages(name) as relpages,
+                CAST(__pgsqlite_reltuples(name) AS REAL) as reltuples,
+                0 as relallvisible,
+                0 as reltoastrelid,
+                CASE WHEN type = 'table' THEN 't' ELSE 'f' END as relhasindex,
+                'f' as relisshared,
+                'p' as relpersistence,
+                'h' as relkind_full,
+                't' as relispopulated,
+                'v' as relreplident,
+                't' as relispartition,
+                0 as relrewrite,
+                0 as relfrozenxid,
+                0 as relminmxid,
+                NULL as relacl,
+                NULL as reloptions,
+                NULL as relpartbound
+            FROM sqlite_master
+            WHERE type IN ('table', 'view', 'index')
+              AND name NOT LIKE 'sqlite_%'
+              AND name NOT LIKE '__pgsqlite_%';
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '30', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ]),
+        down: Some(MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_class"#,
+
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_class AS
+            SELECT
+                -- Use SQLite built-in functions for consistent OID generation
+                CAST(
+                    (
+                        (unicode(substr(name, 1, 1)) * 1000000) +
+                        (unicode(substr(name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(name || '  ', 3, 1)) * 100) +
+                        (length(name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as oid,
+                name as relname,
+                2200 as relnamespace,  -- public schema
+                CASE
+                    WHEN type = 'table' THEN 'r'
+                    WHEN type = 'view' THEN 'v'
+                    WHEN type = 'index' THEN 'i'
+                END as relkind,
+                10 as relowner,
+                CASE WHEN type = 'index' THEN 403 ELSE 0 END as relam,
+                0 as relfilenode,
+                0 as reltablespace,
+                0 as relpages,
+                -1 as reltuples,
+                0 as relallvisible,
+                0 as reltoastrelid,
+                CASE WHEN type = 'table' THEN 't' ELSE 'f' END as relhasindex,
+                'f' as relisshared,
+                'p' as relpersistence,
+                'h' as relkind_full,
+                't' as relispopulated,
+                'v' as relreplident,
+                't' as relispartition,
+                0 as relrewrite,
+                0 as relfrozenxid,
+                0 as relminmxid,
+                NULL as relacl,
+                NULL as reloptions,
+                NULL as relpartbound
+            FROM sqlite_master
+            WHERE type IN ('table', 'view', 'index')
+              AND name NOT LIKE 'sqlite_%'
+              AND name NOT LIKE '__pgsqlite_%';
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '29', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ])),
+        dependencies: vec![29],
+    });
+}
+
+fn register_v31_column_statistics(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(31, Migration {
+        version: 31,
+        name: "column_statistics",
+        description: "Store the per-column statistics computed by ANALYZE for pg_stats",
+        up: MigrationAction::SqlBatch(&[
+            r#"
+            CREATE TABLE IF NOT EXISTS __pgsqlite_stats (
+                table_name TEXT NOT NULL,
+                column_name TEXT NOT NULL,
+                null_frac TEXT NOT NULL,
+                n_distinct TEXT NOT NULL,
+                most_common_vals TEXT,
+                most_common_freqs TEXT,
+                PRIMARY KEY (table_name, column_name)
+            );
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '31', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ]),
+        down: Some(MigrationAction::SqlBatch(&[
+            r#"DROP TABLE IF EXISTS __pgsqlite_stats"#,
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '30', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ])),
+        dependencies: vec![30],
+    });
+}
+
+/// Version 32: GENERATED { ALWAYS | BY DEFAULT } AS IDENTITY columns
+fn register_v32_identity_columns(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(32, Migration {
+        version: 32,
+        name: "identity_columns",
+        description: "Record identity columns and report their generation in pg_attribute.attidentity",
+        up: MigrationAction::SqlBatch(&[
+            r#"
+            CREATE TABLE IF NOT EXISTS __pgsqlite_identity_columns (
+                table_name TEXT NOT NULL,
+                column_name TEXT NOT NULL,
+                generation TEXT NOT NULL CHECK (generation IN ('a', 'd')),
+                PRIMARY KEY (table_name, column_name)
+            );
+            "#,
+
+            r#"DROP VIEW IF EXISTS pg_attribute"#,
+
+            // 'a' or 'd' from the recorded identity columns, other integer keys stay 'd'
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attribute AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as attrelid,
+                p.cid + 1 as attnum,
+                p.name as attname,
+                CASE
+                    WHEN p.type LIKE '%INT%' THEN 23
+                    WHEN p.type = 'TEXT' THEN 25
+                    WHEN p.type = 'REAL' THEN 700
+                    WHEN p.type = 'BLOB' THEN 17
+                    WHEN p.type LIKE '%CHAR%' THEN 1043
+                    WHEN p.type = 'BOOLEAN' THEN 16
+                    WHEN p.type = 'DATE' THEN 1082
+                    WHEN p.type LIKE 'TIME%' THEN 1083
+                    WHEN p.type LIKE 'TIMESTAMP%' THEN 1114
+                    ELSE 25
+                END as atttypid,
+                -1 as attstattarget,
+                0 as attlen,
+                0 as attndims,
+                -1 as attcacheoff,
+                CASE WHEN p."notnull" = 1 OR p.pk > 0 THEN 't' ELSE 'f' END as attnotnull,
+                CASE WHEN p.dflt_value IS NOT NULL THEN 't' ELSE 'f' END as atthasdef,
+                'f' as atthasmissing,
+                COALESCE(
+                    (SELECT ic.generation FROM __pgsqlite_identity_columns ic
+                     WHERE ic.table_name = m.name AND ic.column_name = p.name),
+                    CASE
+                        WHEN p.type LIKE '%INT%' AND p.pk = 1 THEN 'd'
+                        ELSE ''
+                    END
+                ) as attidentity,
+                '' as attgenerated,
+                'f' as attisdropped,
+                't' as attislocal,
+                0 as attinhcount,
+                0 as attcollation,
+                '' as attacl,
+                '' as attoptions,
+                '' as attfdwoptions,
+                '' as attmissingval
+            FROM pragma_table_info(m.name) p
+            JOIN sqlite_master m ON m.type = 'table'
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%';
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '32', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ]),
+        down: Some(MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_attribute"#,
+
+            // Restore the version 28 pg_attribute view
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attribute AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as attrelid,
+                p.cid + 1 as attnum,
+                p.name as attname,
+                CASE
+                    WHEN p.type LIKE '%INT%' THEN 23
+                    WHEN p.type = 'TEXT' THEN 25
+                    WHEN p.type = 'REAL' THEN 700
+                    WHEN p.type = 'BLOB' THEN 17
+                    WHEN p.type LIKE '%CHAR%' THEN 1043
+                    WHEN p.type = 'BOOLEAN' THEN 16
+                    WHEN p.type = 'DATE' THEN 1082
+                    WHEN p.type LIKE 'TIME%' THEN 1083
+                    WHEN p.type LIKE 'TIMESTAMP%' THEN 1114
+                    ELSE 25
+                END as atttypid,
+                -1 as attstattarget,
+                0 as attlen,
+                0 as attndims,
+                -1 as attcacheoff,
+                CASE WHEN p."notnull" = 1 OR p.pk > 0 THEN 't' ELSE 'f' END as attnotnull,
+                CASE WHEN p.dflt_value IS NOT NULL THEN 't' ELSE 'f' END as atthasdef,
+                'f' as atthasmissing,
+                CASE
+                    WHEN p.type LIKE '%INT%' AND p.pk = 1 THEN 'd'
+                    ELSE ''
+                END as attidentity,
+                '' as attgenerated,
+                'f' as attisdropped,
+                't' as attislocal,
+                0 as attinhcount,
+                0 as attcollation,
+                '' as attacl,
+                '' as attoptions,
+                '' as attfdwoptions,
+                '' as attmissingval
+            FROM pragma_table_info(m.name) p
+            JOIN sqlite_master m ON m.type = 'table'
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%';
+            "#,
+
+            r#"DROP TABLE IF EXISTS __pgsqlite_identity_columns"#,
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '31', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ])),
+        dependencies: vec![31],
+    });
+}
+
+/// Version 33: GENERATED ALWAYS AS (expression) columns
+fn register_v33_generated_columns(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(33, Migration {
+        version: 33,
+        name: "generated_columns",
+        description: "Record generated column expressions and include generated columns in pg_attribute and pg_attrdef",
+        up: MigrationAction::SqlBatch(&[
+            r#"
+            CREATE TABLE IF NOT EXISTS __pgsqlite_generated_columns (
+                table_name TEXT NOT NULL,
+                column_name TEXT NOT NULL,
+                expression TEXT NOT NULL,
+                generated TEXT NOT NULL CHECK (generated IN ('s', 'v')),
+                PRIMARY KEY (table_name, column_name)
+            );
+            "#,
+
+            r#"DROP VIEW IF EXISTS pg_attrdef"#,
+            r#"DROP VIEW IF EXISTS pg_attribute"#,
+
+            // pragma_table_info leaves generated columns out, pragma_table_xinfo marks them
+            // as hidden 2 (VIRTUAL) or 3 (STORED); the expression comes from the metadata
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attrdef AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) || printf('%03d', p.cid + 1) as oid,
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as adrelid,
+                p.cid + 1 as adnum,
+                CASE
+                    WHEN g.expression IS NOT NULL THEN g.expression
+                    WHEN p.dflt_value = 'datetime(''now'')' THEN 'now()'
+                    ELSE p.dflt_value
+                END as adbin,
+                CASE
+                    WHEN g.expression IS NOT NULL THEN g.expression
+                    WHEN p.dflt_value = 'datetime(''now'')' THEN 'now()'
+                    ELSE p.dflt_value
+                END as adsrc
+            FROM sqlite_master m
+            JOIN pragma_table_xinfo(m.name) p
+            LEFT JOIN __pgsqlite_generated_columns g ON g.table_name = m.name AND g.column_name = p.name
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%'
+              AND (p.dflt_value IS NOT NULL OR g.expression IS NOT NULL);
+            "#,
+
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attribute AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as attrelid,
+                p.cid + 1 as attnum,
+                p.name as attname,
+                CASE
+                    WHEN p.type LIKE '%INT%' THEN 23
+                    WHEN p.type = 'TEXT' THEN 25
+                    WHEN p.type = 'REAL' THEN 700
+                    WHEN p.type = 'BLOB' THEN 17
+                    WHEN p.type LIKE '%CHAR%' THEN 1043
+                    WHEN p.type = 'BOOLEAN' THEN 16
+                    WHEN p.type = 'DATE' THEN 1082
+                    WHEN p.type LIKE 'TIME%' THEN 1083
+                    WHEN p.type LIKE 'TIMESTAMP%' THEN 1114
+                    ELSE 25
+                END as atttypid,
+                -1 as attstattarget,
+                0 as attlen,
+                0 as attndims,
+                -1 as attcacheoff,
+                CASE WHEN p."notnull" = 1 OR p.pk > 0 THEN 't' ELSE 'f' END as attnotnull,
+                CASE WHEN p.dflt_value IS NOT NULL OR p.hidden IN (2, 3) THEN 't' ELSE 'f' END as atthasdef,
+                'f' as atthasmissing,
+                COALESCE(
+                    (SELECT ic.generation FROM __pgsqlite_identity_columns ic
+                     WHERE ic.table_name = m.name AND ic.column_name = p.name),
+                    CASE
+                        WHEN p.type LIKE '%INT%' AND p.pk = 1 THEN 'd'
+                        ELSE ''
+                    END
+                ) as attidentity,
+                CASE p.hidden WHEN 3 THEN 's' WHEN 2 THEN 'v' ELSE '' END as attgenerated,
+                'f' as attisdropped,
+                't' as attislocal,
+                0 as attinhcount,
+                0 as attcollation,
+                '' as attacl,
+                '' as attoptions,
+                '' as attfdwoptions,
+                '' as attmissingval
+            FROM pragma_table_xinfo(m.name) p
+            JOIN sqlite_master m ON m.type = 'table'
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%'
+              AND p.hidden IN (0, 2, 3);
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '33', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ]),
+        down: Some(MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_attrdef"#,
+            r#"DROP VIEW IF EXISTS pg_attribute"#,
+
+            // Restore the version 28 pg_attrdef and version 32 pg_attribute views
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attrdef AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) || printf('%03d', p.cid + 1) as oid,
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as adrelid,
+                p.cid + 1 as adnum,
+                CASE
+                    WHEN p.dflt_value = 'datetime(''now'')' THEN 'now()'
+                    ELSE p.dflt_value
+                END as adbin,
+                CASE
+                    WHEN p.dflt_value = 'datetime(''now'')' THEN 'now()'
+                    ELSE p.dflt_value
+                END as adsrc
+            FROM sqlite_master m
+            JOIN pragma_table_info(m.name) p
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%'
+              AND p.dflt_value IS NOT NULL;
+            "#,
+
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attribute AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as attrelid,
+                p.cid + 1 as attnum,
+                p.name as attname,
+                CASE
+                    WHEN p.type LIKE '%INT%' THEN 23
+                    WHEN p.type = 'TEXT' THEN 25
+                    WHEN p.type = 'REAL' THEN 700
+                    WHEN p.type = 'BLOB' THEN 17
+                    WHEN p.type LIKE '%CHAR%' THEN 1043
+                    WHEN p.type = 'BOOLEAN' THEN 16
+                    WHEN p.type = 'DATE' THEN 1082
+                    WHEN p.type LIKE 'TIME%' THEN 1083
+                    WHEN p.type LIKE 'TIMESTAMP%' THEN 1114
+                    ELSE 25
+                END as atttypid,
+                -1 as attstattarget,
+                0 as attlen,
+                0 as attndims,
+                -1 as attcacheoff,
+                CASE WHEN p."notnull" = 1 OR p.pk > 0 THEN 't' ELSE 'f' END as attnotnull,
+                CASE WHEN p.dflt_value IS NOT NULL THEN 't' ELSE 'f' END as atthasdef,
+                'f' as atthasmissing,
+                COALESCE(
+                    (SELECT ic.generation FROM __pgsqlite_identity_columns ic
+                     WHERE ic.table_name = m.name AND ic.column_name = p.name),
+                    CASE
+                        WHEN p.type LIKE '%INT%' AND p.pk = 1 THEN 'd'
+                        ELSE ''
+                    END
+                ) as attidentity,
+                '' as attgenerated,
+                'f' as attisdropped,
+                't' as attislocal,
+                0 as attinhcount,
+                0 as attcollation,
+                '' as attacl,
+                '' as attoptions,
+                '' as attfdwoptions,
+                '' as attmissingval
+            FROM pragma_table_info(m.name) p
+            JOIN sqlite_master m ON m.type = 'table'
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%';
+            "#,
+
+            r#"DROP TABLE IF EXISTS __pgsqlite_generated_columns"#,
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '32', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ])),
+        dependencies: vec![32],
+    });
+}
+
+/// Version 34: pg_stat_activity listing the open connections
+fn register_v34_stat_activity(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(34, Migration {
+        version: 34,
+        name: "stat_activity",
+        description: "List the server's open connections and their current queries in pg_stat_activity",
+        up: MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_stat_activity"#,
+
+            // __pgsqlite_stat_activity() returns the connections the server tracks as a JSON array
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_stat_activity AS
+            SELECT
+                1 as datid,
+                json_extract(b.value, '$.datname') as datname,
+                json_extract(b.value, '$.pid') as pid,
+                NULL as leader_pid,
+                10 as usesysid,
+                json_extract(b.value, '$.usename') as usename,
+                json_extract(b.value, '$.application_name') as application_name,
+                NULL as client_addr,
+                NULL as client_hostname,
+                NULL as client_port,
+                json_extract(b.value, '$.backend_start') as backend_start,
+                json_extract(b.value, '$.xact_start') as xact_start,
+                json_extract(b.value, '$.query_start') as query_start,
+                json_extract(b.value, '$.state_change') as state_change,
+                NULL as wait_event_type,
+                NULL as wait_event,
+                json_extract(b.value, '$.state') as state,
+                NULL as backend_xid,
+                NULL as backend_xmin,
+                NULL as query_id,
+                json_extract(b.value, '$.query') as query,
+                'client backend' as backend_type
+            FROM json_each(__pgsqlite_stat_activity()) b;
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '34', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ]),
+        down: Some(MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_stat_activity"#,
+
+            // Restore the static version 13 view
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_stat_activity AS
+            SELECT
+                1 as datid,
+                'main' as datname,
+                1 as pid,
+                NULL as leader_pid,
+                10 as usesysid,
+                'postgres' as usename,
+                'pgsqlite' as application_name,
+                NULL as client_addr,
+                NULL as client_hostname,
+                NULL as client_port,
+                datetime('now') as backend_start,
+                NULL as xact_start,
+                NULL as query_start,
+                datetime('now') as state_change,
+                NULL as wait_event_type,
+                NULL as wait_event,
+                'idle' as state,
+                NULL as backend_xid,
+                NULL as backend_xmin,
+                NULL as query_id,
+                '<IDLE>' as query,
+                'client backend' as backend_type;
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '33', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ])),
+        dependencies: vec![33],
+    });
+}
+
+/// Version 35: Large objects
+fn register_v35_large_objects(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(35, Migration {
+        version: 35,
+        name: "large_objects",
+        description: "Store large objects in pages for the lo_* functions, with pg_largeobject and pg_largeobject_metadata views",
+        up: MigrationAction::Sql(r#"
+            -- One row per large object
+            CREATE TABLE IF NOT EXISTS __pgsqlite_largeobject_metadata (
+                oid INTEGER PRIMARY KEY,
+                lomowner INTEGER NOT NULL DEFAULT 10
+            );
+
+            -- The data of each large object in 2048 byte pages
+            CREATE TABLE IF NOT EXISTS __pgsqlite_largeobject (
+                loid INTEGER NOT NULL,
+                pageno INTEGER NOT NULL,
+                data BLOB NOT NULL,
+                PRIMARY KEY (loid, pageno)
+            );
+
+            CREATE VIEW IF NOT EXISTS pg_largeobject_metadata AS
+            SELECT oid, lomowner, NULL as lomacl
+            FROM __pgsqlite_largeobject_metadata;
+
+            CREATE VIEW IF NOT EXISTS pg_largeobject AS
+            SELECT loid, pageno, data
+            FROM __pgsqlite_largeobject;
+
+            UPDATE __pgsqlite_metadata
+            SET value = '35', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+        "#),
+        down: Some(MigrationAction::Sql(r#"
+            DROP VIEW IF EXISTS pg_largeobject;
+            DROP VIEW IF EXISTS pg_largeobject_metadata;
+            DROP TABLE IF EXISTS __pgsqlite_largeobject;
+            DROP TABLE IF EXISTS __pgsqlite_largeobject_metadata;
+
+            UPDATE __pgsqlite_metadata
+            SET value = '34', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+        "#)),
+        dependencies: vec![34],
+    });
+}
+
+/// Version 36: UNIQUE NULLS NOT DISTINCT
+fn register_v36_nulls_not_distinct(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(36, Migration {
+        version: 36,
+        name: "nulls_not_distinct",
+        description: "Record unique indexes and constraints declared NULLS NOT DISTINCT, which are enforced by triggers",
+        up: MigrationAction::Sql(r#"
+            CREATE TABLE IF NOT EXISTS __pgsqlite_nulls_not_distinct (
+                name TEXT PRIMARY KEY,
+                table_name TEXT NOT NULL,
+                columns TEXT NOT NULL
+            );
+
+            UPDATE __pgsqlite_metadata
+            SET value = '36', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+        "#),
+        down: Some(MigrationAction::Sql(r#"
+            DROP TABLE IF EXISTS __pgsqlite_nulls_not_distinct;
+
+            UPDATE __pgsqlite_metadata
+            SET value = '35', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+        "#)),
+        dependencies: vec![35],
+    });
+}
+
+/// Version 37: SERIAL column defaults in pg_attribute and pg_attrdef
+fn register_v37_serial_defaults(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(37, Migration {
+        version: 37,
+        name: "serial_defaults",
+        description: "Report the nextval() default of SERIAL columns in pg_attrdef and pg_attribute.atthasdef",
+        up: MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_attrdef"#,
+            r#"DROP VIEW IF EXISTS pg_attribute"#,
+
+            // SERIAL columns become INTEGER PRIMARY KEY AUTOINCREMENT without a SQLite default,
+            // PostgreSQL reports the nextval() of their sequence as the default
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attrdef AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) || printf('%03d', p.cid + 1) as oid,
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as adrelid,
+                p.cid + 1 as adnum,
+                CASE
+                    WHEN g.expression IS NOT NULL THEN g.expression
+                    WHEN p.dflt_value IS NULL THEN 'nextval(''' || m.name || '_' || p.name || '_seq''::regclass)'
+                    WHEN p.dflt_value = 'datetime(''now'')' THEN 'now()'
+                    ELSE p.dflt_value
+                END as adbin,
+                CASE
+                    WHEN g.expression IS NOT NULL THEN g.expression
+                    WHEN p.dflt_value IS NULL THEN 'nextval(''' || m.name || '_' || p.name || '_seq''::regclass)'
+                    WHEN p.dflt_value = 'datetime(''now'')' THEN 'now()'
+                    ELSE p.dflt_value
+                END as adsrc
+            FROM sqlite_master m
+            JOIN pragma_table_xinfo(m.name) p
+            LEFT JOIN __pgsqlite_generated_columns g ON g.table_name = m.name AND g.column_name = p.name
+            LEFT JOIN __pgsqlite_schema s ON s.table_name = m.name AND s.column_name = p.name
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%'
+              AND (p.dflt_value IS NOT NULL OR g.expression IS NOT NULL
+                   OR upper(s.pg_type) IN ('SERIAL', 'SERIAL4', 'BIGSERIAL', 'SERIAL8', 'SMALLSERIAL', 'SERIAL2'));
+            "#,
+
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attribute AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as attrelid,
+                p.cid + 1 as attnum,
+                p.name as attname,
+                CASE
+                    WHEN p.type LIKE '%INT%' THEN 23
+                    WHEN p.type = 'TEXT' THEN 25
+                    WHEN p.type = 'REAL' THEN 700
+                    WHEN p.type = 'BLOB' THEN 17
+                    WHEN p.type LIKE '%CHAR%' THEN 1043
+                    WHEN p.type = 'BOOLEAN' THEN 16
+                    WHEN p.type = 'DATE' THEN 1082
+                    WHEN p.type LIKE 'TIME%' THEN 1083
+                    WHEN p.type LIKE 'TIMESTAMP%' THEN 1114
+                    ELSE 25
+                END as atttypid,
+                -1 as attstattarget,
+                0 as attlen,
+                0 as attndims,
+                -1 as attcacheoff,
+                CASE WHEN p."notnull" = 1 OR p.pk > 0 THEN 't' ELSE 'f' END as attnotnull,
+                CASE
+                    WHEN p.dflt_value IS NOT NULL OR p.hidden IN (2, 3) THEN 't'
+                    WHEN EXISTS (SELECT 1 FROM __pgsqlite_schema s
+                                 WHERE s.table_name = m.name AND s.column_name = p.name
+                                   AND upper(s.pg_type) IN ('SERIAL', 'SERIAL4', 'BIGSERIAL', 'SERIAL8', 'SMALLSERIAL', 'SERIAL2')) THEN 't'
+                    ELSE 'f'
+                END as atthasdef,
+                'f' as atthasmissing,
+                COALESCE(
+                    (SELECT ic.generation FROM __pgsqlite_identity_columns ic
+                     WHERE ic.table_name = m.name AND ic.column_name = p.name),
+                    CASE
+                        WHEN p.type LIKE '%INT%' AND p.pk = 1 THEN 'd'
+                        ELSE ''
+                    END
+                ) as attidentity,
+                CASE p.hidden WHEN 3 THEN 's' WHEN 2 THEN 'v' ELSE '' END as attgenerated,
+                'f' as attisdropped,
+                't' as attislocal,
+                0 as attinhcount,
+                0 as attcollation,
+                '' as attacl,
+                '' as attoptions,
+                '' as attfdwoptions,
+                '' as attmissingval
+            FROM pragma_table_xinfo(m.name) p
+            JOIN sqlite_master m ON m.type = 'table'
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%'
+              AND p.hidden IN (0, 2, 3);
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '37', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ]),
+        down: Some(MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_attrdef"#,
+            r#"DROP VIEW IF EXISTS pg_attribute"#,
+
+            // Restore the version 33 views
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attrdef AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) || printf('%03d', p.cid + 1) as oid,
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as adrelid,
+                p.cid + 1 as adnum,
+                CASE
+                    WHEN g.expression IS NOT NULL THEN g.expression
+                    WHEN p.dflt_value = 'datetime(''now'')' THEN 'now()'
+                    ELSE p.dflt_value
+                END as adbin,
+                CASE
+                    WHEN g.expression IS NOT NULL THEN g.expression
+                    WHEN p.dflt_value = 'datetime(''now'')' THEN 'now()'
+                    ELSE p.dflt_value
+                END as adsrc
+            FROM sqlite_master m
+            JOIN pragma_table_xinfo(m.name) p
+            LEFT JOIN __pgsqlite_generated_columns g ON g.table_name = m.name AND g.column_name = p.name
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%'
+              AND (p.dflt_value IS NOT NULL OR g.expression IS NOT NULL);
+            "#,
+
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attribute AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as attrelid,
+                p.cid + 1 as attnum,
+                p.name as attname,
+                CASE
+                    WHEN p.type LIKE '%INT%' THEN 23
+                    WHEN p.type = 'TEXT' THEN 25
+                    WHEN p.type = 'REAL' THEN 700
+                    WHEN p.type = 'BLOB' THEN 17
+                    WHEN p.type LIKE '%CHAR%' THEN 1043
+                    WHEN p.type = 'BOOLEAN' THEN 16
+                    WHEN p.type = 'DATE' THEN 1082
+                    WHEN p.type LIKE 'TIME%' THEN 1083
+                    WHEN p.type LIKE 'TIMESTAMP%' THEN 1114
+                    ELSE 25
+                END as atttypid,
+                -1 as attstattarget,
+                0 as attlen,
+                0 as attndims,
+                -1 as attcacheoff,
+                CASE WHEN p."notnull" = 1 OR p.pk > 0 THEN 't' ELSE 'f' END as attnotnull,
+                CASE WHEN p.dflt_value IS NOT NULL OR p.hidden IN (2, 3) THEN 't' ELSE 'f' END as atthasdef,
+                'f' as atthasmissing,
+                COALESCE(
+                    (SELECT ic.generation FROM __pgsqlite_identity_columns ic
+                     WHERE ic.table_name = m.name AND ic.column_name = p.name),
+                    CASE
+                        WHEN p.type LIKE '%INT%' AND p.pk = 1 THEN 'd'
+                        ELSE ''
+                    END
+                ) as attidentity,
+                CASE p.hidden WHEN 3 THEN 's' WHEN 2 THEN 'v' ELSE '' END as attgenerated,
+                'f' as attisdropped,
+                't' as attislocal,
+                0 as attinhcount,
+                0 as attcollation,
+                '' as attacl,
+                '' as attoptions,
+                '' as attfdwoptions,
+                '' as attmissingval
+            FROM pragma_table_xinfo(m.name) p
+            JOIN sqlite_master m ON m.type = 'table'
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%'
+              AND p.hidden IN (0, 2, 3);
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '36', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ])),
+        dependencies: vec![36],
+    });
+}
+
+/// Version 38: Comment OIDs matching the catalog
+fn register_v38_comment_oids(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(38, Migration {
+        version: 38,
+        name: "comment_oids",
+        description: "Key stored table and column comments by the OIDs the pg_class view reports",
+        up: MigrationAction::Combined {
+            pre_sql: None,
+            function: rewrite_comment_oids,
+            post_sql: Some(r#"
+                UPDATE __pgsqlite_metadata
+                SET value = '38', updated_at = strftime('%s', 'now')
+                WHERE key = 'schema_version';
+            "#),
+        },
+        down: None, // The old OIDs can't be told apart from the catalog's
+        dependencies: vec![37],
+    });
+}
+
+/// COMMENT ON used to key relations by a DefaultHasher hash of their name, which
+/// obj_description() and col_description() can't find from the pg_class OIDs
+fn rewrite_comment_oids(conn: &rusqlite::Connection) -> anyhow::Result<()> {
+    use std::collections::hash_map::DefaultHasher;
+    use std::hash::{Hash, Hasher};
+
+    let mut stmt = conn.prepare("
+        SELECT name FROM sqlite_master
+        WHERE type IN ('table', 'view', 'index')
+        AND name NOT LIKE 'sqlite_%'
+        AND name NOT LIKE '__pgsqlite_%'
+    ")?;
+    let names = stmt.query_map([], |row| row.get::<_, String>(0))?
+        .collect::<Result<Vec<_>, _>>()?;
+
+    // Map every old OID first, so one relation's new OID is never taken for another's old one
+    conn.execute("CREATE TEMP TABLE __pgsqlite_comment_oid_map (old_oid INTEGER PRIMARY KEY, new_oid INTEGER)", [])?;
+    for name in &names {
+        let mut hasher = DefaultHasher::new();
+        name.hash(&mut hasher);
+        let old_oid = (hasher.finish() & 0x7FFFFFFF) % 1000000 + 16384;
+        let new_oid = crate::catalog::constraint_populator::table_oid(name);
+        conn.execute(
+            "INSERT OR IGNORE INTO __pgsqlite_comment_oid_map (old_oid, new_oid) VALUES (?1, ?2)",
+            rusqlite::params![old_oid as i64, new_oid],
+        )?;
+    }
+
+    conn.execute("
+        UPDATE OR REPLACE __pgsqlite_comments
+        SET object_oid = (SELECT new_oid FROM __pgsqlite_comment_oid_map WHERE old_oid = object_oid),
+            updated_at = CURRENT_TIMESTAMP
+        WHERE catalog_name = 'pg_class'
+        AND object_oid IN (SELECT old_oid FROM __pgsqlite_comment_oid_map)
+    ", [])?;
+    conn.execute("DROP TABLE __pgsqlite_comment_oid_map", [])?;
+
+    Ok(())
+}
+
+fn register_v39_constraint_relation_oids(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(39, Migration {
+        version: 39,
+        name: "constraint_relation_oids",
+        description: "Point pg_constraint rows at the table OIDs the pg_class view reports",
+        up: MigrationAction::Combined {
+            pre_sql: None,
+            function: rewrite_constraint_relation_oids,
+            post_sql: Some(r#"
+                UPDATE __pgsqlite_metadata
+                SET value = '39', updated_at = strftime('%s', 'now')
+                WHERE key = 'schema_version';
+            "#),
+        },
+        down: None, // The old OIDs can't be told apart from the catalog's
+        dependencies: vec![38],
+    });
+}
+
+/// The pg_catalog_tables migration filled conrelid and confrelid with an OID of the
+/// table name that no pg_class row has, so joins on them found nothing
+fn rewrite_constraint_relation_oids(conn: &rusqlite::Connection) -> anyhow::Result<()> {
+    let mut stmt = conn.prepare("
+        SELECT name FROM sqlite_master
+        WHERE type = 'table'
+        AND name NOT LIKE 'sqlite_%'
+        AND name NOT LIKE '__pgsqlite_%'
+    ")?;
+    let names = stmt.query_map([], |row| row.get::<_, String>(0))?
+        .collect::<Result<Vec<_>, _>>()?;
+
+    // Map every old OID first, so one table's new OID is never taken for another's old one
+    conn.execute("CREATE TEMP TABLE __pgsqlite_relation_oid_map (old_oid TEXT PRIMARY KEY, new_oid TEXT)", [])?;
+    for name in &names {
+        conn.execute(
+            "INSERT OR IGNORE INTO __pgsqlite_relation_oid_map (old_oid, new_oid) VALUES (?1, ?2)",
+            rusqlite::params![
+                crate::utils::generate_oid_string(name),
+                crate::catalog::constraint_populator::generate_table_oid(name),
+            ],
+        )?;
+    }
+
+    for column in ["conrelid", "confrelid"] {
+        conn.execute(&format!("
+            UPDATE pg_constraint
+            SET {column} = (SELECT new_oid FROM __pgsqlite_relation_oid_map WHERE old_oid = {column})
+            WHERE {column} IN (SELECT old_oid FROM __pgsqlite_relation_oid_map)
+        "), [])?;
+    }
+    conn.execute("DROP TABLE __pgsqlite_relation_oid_map", [])?;
+
+    Ok(())
+}
+
+/// Version 40: The catalog columns psql's \d reads, for its queries that run on the views
+fn register_v40_psql_describe_views(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(40, Migration {
+        version: 40,
+        name: "psql_describe_views",
+        description: "Add the pg_class, pg_attribute and pg_type columns psql's \\d reads and a pg_collation view",
+        up: MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_class"#,
+            r#"DROP VIEW IF EXISTS pg_attribute"#,
+            r#"DROP VIEW IF EXISTS pg_type"#,
+
+            // The columns and values PgClassHandler reports, with the CHECK constraint count
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_class AS
+            SELECT
+                r.oid,
+                r.name as relname,
+                2200 as relnamespace,  -- public schema
+                r.oid + 1 as reltype,
+                0 as reloftype,
+                10 as relowner,
+                CASE WHEN r.type = 'index' THEN 403 ELSE 0 END as relam,
+                0 as relfilenode,
+                0 as reltablespace,
+                __pgsqlite_relpages(r.name) as relpages,
+                CAST(__pgsqlite_reltuples(r.name) AS REAL) as reltuples,
+                0 as relallvisible,
+                0 as reltoastrelid,
+                CASE WHEN r.type = 'table' AND EXISTS (SELECT 1 FROM pragma_index_list(r.name)) THEN 't' ELSE 'f' END as relhasindex,
+                'f' as relisshared,
+                'p' as relpersistence,
+                CASE r.type WHEN 'table' THEN 'r' WHEN 'view' THEN 'v' WHEN 'index' THEN 'i' END as relkind,
+                CASE
+                    WHEN r.type = 'index' THEN (SELECT COUNT(*) FROM pragma_index_info(r.name))
+                    ELSE (SELECT COUNT(*) FROM pragma_table_info(r.name))
+                END as relnatts,
+                (SELECT COUNT(*) FROM pg_constraint con WHERE con.conrelid = r.oid AND con.contype = 'c') as relchecks,
+                'f' as relhasrules,
+                'f' as relhastriggers,
+                CASE WHEN EXISTS (SELECT 1 FROM __pgsqlite_inherits i WHERE i.parent_table = r.name) THEN 't' ELSE 'f' END as relhassubclass,
+                'f' as relrowsecurity,
+                'f' as relforcerowsecurity,
+                't' as relispopulated,
+                'd' as relreplident,
+                'f' as relispartition,
+                0 as relrewrite,
+                0 as relfrozenxid,
+                0 as relminmxid,
+                NULL as relacl,
+                NULL as reloptions,
+                NULL as relpartbound,
+                'h' as relkind_full
+            FROM (
+                SELECT
+                    CAST(
+                        (
+                            (unicode(substr(name, 1, 1)) * 1000000) +
+                            (unicode(substr(name || ' ', 2, 1)) * 10000) +
+                            (unicode(substr(name || '  ', 3, 1)) * 100) +
+                            (length(name) * 7)
+                        ) % 1000000 + 16384
+                    AS TEXT) as oid,
+                    name,
+                    type
+                FROM sqlite_master
+                WHERE type IN ('table', 'view', 'index')
+                  AND name NOT LIKE 'sqlite_%'
+                  AND name NOT LIKE '__pgsqlite_%'
+            ) r;
+            "#,
+
+            // atttypid and atttypmod as PgAttributeHandler reports them, so format_type()
+            // prints the declared type, and the default collation for the string types
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attribute AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as attrelid,
+                p.cid + 1 as attnum,
+                p.name as attname,
+                COALESCE(e.type_oid, __pgsqlite_atttypid(s.pg_type, p.type)) as atttypid,
+                -1 as attstattarget,
+                0 as attlen,
+                0 as attndims,
+                -1 as attcacheoff,
+                CASE
+                    WHEN e.type_oid IS NOT NULL THEN -1
+                    ELSE __pgsqlite_atttypmod(s.pg_type, p.type, s.type_modifier)
+                END as atttypmod,
+                CASE WHEN p."notnull" = 1 OR p.pk > 0 THEN 't' ELSE 'f' END as attnotnull,
+                CASE
+                    WHEN p.dflt_value IS NOT NULL OR p.hidden IN (2, 3) THEN 't'
+                    WHEN upper(s.pg_type) IN ('SERIAL', 'SERIAL4', 'BIGSERIAL', 'SERIAL8', 'SMALLSERIAL', 'SERIAL2') THEN 't'
+                    ELSE 'f'
+                END as atthasdef,
+                'f' as atthasmissing,
+                COALESCE(
+                    (SELECT ic.generation FROM __pgsqlite_identity_columns ic
+                     WHERE ic.table_name = m.name AND ic.column_name = p.name),
+                    CASE
+                        WHEN p.type LIKE '%INT%' AND p.pk = 1 THEN 'd'
+                        ELSE ''
+                    END
+                ) as attidentity,
+                CASE p.hidden WHEN 3 THEN 's' WHEN 2 THEN 'v' ELSE '' END as attgenerated,
+                'f' as attisdropped,
+                't' as attislocal,
+                0 as attinhcount,
+                CASE
+                    WHEN e.type_oid IS NULL AND __pgsqlite_atttypid(s.pg_type, p.type) IN (25, 1042, 1043) THEN 100
+                    ELSE 0
+                END as attcollation,
+                '' as attacl,
+                '' as attoptions,
+                '' as attfdwoptions,
+                '' as attmissingval
+            FROM sqlite_master m
+            JOIN pragma_table_xinfo(m.name) p
+            LEFT JOIN __pgsqlite_schema s ON s.table_name = m.name AND s.column_name = p.name
+            LEFT JOIN __pgsqlite_enum_types e ON e.type_name = lower(s.pg_type)
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%'
+              AND p.hidden IN (0, 2, 3);
+            "#,
+
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_type AS
+            SELECT
+                oid,
+                typname,
+                typtype,
+                typelem,
+                typarray,
+                typbasetype,
+                typnamespace,
+                typcategory,
+                -- The string types collate with the database default
+                CASE WHEN oid IN (25, 1042, 1043) THEN 100 ELSE 0 END as typcollation
+            FROM (
+                -- Basic types with their array types and categories
+                SELECT 16 as oid, 'bool' as typname, 'b' as typtype, 0 as typelem, 1000 as typarray, 0 as typbasetype, 11 as typnamespace, 'B' as typcategory
+                UNION ALL SELECT 17, 'bytea', 'b', 0, 1001, 0, 11, 'U'
+                UNION ALL SELECT 20, 'int8', 'b', 0, 1016, 0, 11, 'N'
+                UNION ALL SELECT 21, 'int2', 'b', 0, 1005, 0, 11, 'N'
+                UNION ALL SELECT 23, 'int4', 'b', 0, 1007, 0, 11, 'N'
+                UNION ALL SELECT 25, 'text', 'b', 0, 1009, 0, 11, 'S'
+                UNION ALL SELECT 114, 'json', 'b', 0, 199, 0, 11, 'U'
+                UNION ALL SELECT 700, 'float4', 'b', 0, 1021, 0, 11, 'N'
+                UNION ALL SELECT 701, 'float8', 'b', 0, 1022, 0, 11, 'N'
+                UNION ALL SELECT 1042, 'char', 'b', 0, 1014, 0, 11, 'S'
+                UNION ALL SELECT 1043, 'varchar', 'b', 0, 1015, 0, 11, 'S'
+                UNION ALL SELECT 1082, 'date', 'b', 0, 1182, 0, 11, 'D'
+                UNION ALL SELECT 1083, 'time', 'b', 0, 1183, 0, 11, 'D'
+                UNION ALL SELECT 1114, 'timestamp', 'b', 0, 1115, 0, 11, 'D'
+                UNION ALL SELECT 1184, 'timestamptz', 'b', 0, 1185, 0, 11, 'D'
+                UNION ALL SELECT 1186, 'interval', 'b', 0, 1187, 0, 11, 'T'
+                UNION ALL SELECT 1266, 'timetz', 'b', 0, 1270, 0, 11, 'D'
+                UNION ALL SELECT 1560, 'bit', 'b', 0, 1561, 0, 11, 'V'
+                UNION ALL SELECT 1562, 'varbit', 'b', 0, 1563, 0, 11, 'V'
+                UNION ALL SELECT 1700, 'numeric', 'b', 0, 1231, 0, 11, 'N'
+                UNION ALL SELECT 2950, 'uuid', 'b', 0, 2951, 0, 11, 'U'
+                UNION ALL SELECT 3614, 'tsvector', 'b', 0, 3643, 0, 11, 'U'
+                UNION ALL SELECT 3615, 'tsquery', 'b', 0, 3645, 0, 11, 'U'
+                UNION ALL SELECT 3734, 'regconfig', 'b', 0, 3735, 0, 11, 'U'
+                UNION ALL SELECT 3802, 'jsonb', 'b', 0, 3807, 0, 11, 'U'
+                -- Array types (all have category 'A')
+                UNION ALL SELECT 1000, '_bool', 'b', 16, 0, 0, 11, 'A'
+                UNION ALL SELECT 1001, '_bytea', 'b', 17, 0, 0, 11, 'A'
+                UNION ALL SELECT 1005, '_int2', 'b', 21, 0, 0, 11, 'A'
+                UNION ALL SELECT 1007, '_int4', 'b', 23, 0, 0, 11, 'A'
+                UNION ALL SELECT 1009, '_text', 'b', 25, 0, 0, 11, 'A'
+                UNION ALL SELECT 1014, '_char', 'b', 1042, 0, 0, 11, 'A'
+                UNION ALL SELECT 1015, '_varchar', 'b', 1043, 0, 0, 11, 'A'
+                UNION ALL SELECT 1016, '_int8', 'b', 20, 0, 0, 11, 'A'
+                UNION ALL SELECT 1021, '_float4', 'b', 700, 0, 0, 11, 'A'
+                UNION ALL SELECT 1022, '_float8', 'b', 701, 0, 0, 11, 'A'
+                UNION ALL SELECT 1115, '_timestamp', 'b', 1114, 0, 0, 11, 'A'
+                UNION ALL SELECT 1182, '_date', 'b', 1082, 0, 0, 11, 'A'
+                UNION ALL SELECT 1183, '_time', 'b', 1083, 0, 0, 11, 'A'
+                UNION ALL SELECT 1185, '_timestamptz', 'b', 1184, 0, 0, 11, 'A'
+                UNION ALL SELECT 1187, '_interval', 'b', 1186, 0, 0, 11, 'A'
+                UNION ALL SELECT 1231, '_numeric', 'b', 1700, 0, 0, 11, 'A'
+                UNION ALL SELECT 1270, '_timetz', 'b', 1266, 0, 0, 11, 'A'
+                UNION ALL SELECT 1561, '_bit', 'b', 1560, 0, 0, 11, 'A'
+                UNION ALL SELECT 1563, '_varbit', 'b', 1562, 0, 0, 11, 'A'
+                UNION ALL SELECT 2951, '_uuid', 'b', 2950, 0, 0, 11, 'A'
+                UNION ALL SELECT 3643, '_tsvector', 'b', 3614, 0, 0, 11, 'A'
+                UNION ALL SELECT 3645, '_tsquery', 'b', 3615, 0, 0, 11, 'A'
+                UNION ALL SELECT 3735, '_regconfig', 'b', 3734, 0, 0, 11, 'A'
+                UNION ALL SELECT 3807, '_jsonb', 'b', 3802, 0, 0, 11, 'A'
+                UNION ALL SELECT 199, '_json', 'b', 114, 0, 0, 11, 'A'
+                -- ENUM types from __pgsqlite_enum_types (category 'E')
+                UNION ALL
+                SELECT 
+                    e.type_oid as oid,
+                    e.type_name as typname,
+                    'e' as typtype,
+                    0 as typelem,
+                    0 as typarray,  -- ENUMs don't have array types in our schema
+                    0 as typbasetype,
+                    e.namespace_oid as typnamespace,
+                    'E' as typcategory
+                FROM __pgsqlite_enum_types e
+            );
+            "#,
+
+            // The collations queries on pg_collation alone are answered with
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_collation AS
+            SELECT 100 as oid, 'default' as collname, 11 as collnamespace, 10 as collowner,
+                   'd' as collprovider, 't' as collisdeterministic, -1 as collencoding,
+                   NULL as collcollate, NULL as collctype, NULL as colliculocale,
+                   NULL as collicurules, NULL as collversion
+            UNION ALL SELECT 950, 'C', 11, 10, 'c', 't', -1, 'C', 'C', NULL, NULL, NULL
+            UNION ALL SELECT 951, 'POSIX', 11, 10, 'c', 't', -1, 'POSIX', 'POSIX', NULL, NULL, NULL;
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '40', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ]),
+        down: Some(MigrationAction::SqlBatch(&[
+            r#"DROP VIEW IF EXISTS pg_class"#,
+            r#"DROP VIEW IF EXISTS pg_attribute"#,
+            r#"DROP VIEW IF EXISTS pg_type"#,
+            r#"DROP VIEW IF EXISTS pg_collation"#,
+
+            // Restore the version 30, 37 and 10 views
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_class AS
+            SELECT
+                -- Use SQLite built-in functions for consistent OID generation
+                CAST(
+                    (
+                        (unicode(substr(name, 1, 1)) * 1000000) +
+                        (unicode(substr(name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(name || '  ', 3, 1)) * 100) +
+                        (length(name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as oid,
+                name as relname,
+                2200 as relnamespace,  -- public schema
+                CASE
+                    WHEN type = 'table' THEN 'r'
+                    WHEN type = 'view' THEN 'v'
+                    WHEN type = 'index' THEN 'i'
+                END as relkind,
+                10 as relowner,
+                CASE WHEN type = 'index' THEN 403 ELSE 0 END as relam,
+                0 as relfilenode,
+                0 as reltablespace,
+                -- Page count from dbstat, row estimate as of the last ANALYZE
+                __pgsqlite_relpages(name) as relpages,
+                CAST(__pgsqlite_reltuples(name) AS REAL) as reltuples,
+                0 as relallvisible,
+                0 as reltoastrelid,
+                CASE WHEN type = 'table' THEN 't' ELSE 'f' END as relhasindex,
+                'f' as relisshared,
+                'p' as relpersistence,
+                'h' as relkind_full,
+                't' as relispopulated,
+                'v' as relreplident,
+                't' as relispartition,
+                0 as relrewrite,
+                0 as relfrozenxid,
+                0 as relminmxid,
+                NULL as relacl,
+                NULL as reloptions,
+                NULL as relpartbound
+            FROM sqlite_master
+            WHERE type IN ('table', 'view', 'index')
+              AND name NOT LIKE 'sqlite_%'
+              AND name NOT LIKE '__pgsqlite_%';
+            "#,
+
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_attribute AS
+            SELECT
+                CAST(
+                    (
+                        (unicode(substr(m.name, 1, 1)) * 1000000) +
+                        (unicode(substr(m.name || ' ', 2, 1)) * 10000) +
+                        (unicode(substr(m.name || '  ', 3, 1)) * 100) +
+                        (length(m.name) * 7)
+                    ) % 1000000 + 16384
+                AS TEXT) as attrelid,
+                p.cid + 1 as attnum,
+                p.name as attname,
+                CASE
+                    WHEN p.type LIKE '%INT%' THEN 23
+                    WHEN p.type = 'TEXT' THEN 25
+                    WHEN p.type = 'REAL' THEN 700
+                    WHEN p.type = 'BLOB' THEN 17
+                    WHEN p.type LIKE '%CHAR%' THEN 1043
+                    WHEN p.type = 'BOOLEAN' THEN 16
+                    WHEN p.type = 'DATE' THEN 1082
+                    WHEN p.type LIKE 'TIME%' THEN 1083
+                    WHEN p.type LIKE 'TIMESTAMP%' THEN 1114
+                    ELSE 25
+                END as atttypid,
+                -1 as attstattarget,
+                0 as attlen,
+                0 as attndims,
+                -1 as attcacheoff,
+                CASE WHEN p."notnull" = 1 OR p.pk > 0 THEN 't' ELSE 'f' END as attnotnull,
+                CASE
+                    WHEN p.dflt_value IS NOT NULL OR p.hidden IN (2, 3) THEN 't'
+                    WHEN EXISTS (SELECT 1 FROM __pgsqlite_schema s
+                                 WHERE s.table_name = m.name AND s.column_name = p.name
+                                   AND upper(s.pg_type) IN ('SERIAL', 'SERIAL4', 'BIGSERIAL', 'SERIAL8', 'SMALLSERIAL', 'SERIAL2')) THEN 't'
+                    ELSE 'f'
+                END as atthasdef,
+                'f' as atthasmissing,
+                COALESCE(
+                    (SELECT ic.generation FROM __pgsqlite_identity_columns ic
+                     WHERE ic.table_name = m.name AND ic.column_name = p.name),
+                    CASE
+                        WHEN p.type LIKE '%INT%' AND p.pk = 1 THEN 'd'
+                        ELSE ''
+                    END
+                ) as attidentity,
+                CASE p.hidden WHEN 3 THEN 's' WHEN 2 THEN 'v' ELSE '' END as attgenerated,
+                'f' as attisdropped,
+                't' as attislocal,
+                0 as attinhcount,
+                0 as attcollation,
+                '' as attacl,
+                '' as attoptions,
+                '' as attfdwoptions,
+                '' as attmissingval
+            FROM pragma_table_xinfo(m.name) p
+            JOIN sqlite_master m ON m.type = 'table'
+            WHERE m.type = 'table'
+              AND m.name NOT LIKE 'sqlite_%'
+              AND m.name NOT LIKE '__pgsqlite_%'
+              AND p.hidden IN (0, 2, 3);
+            "#,
+
+            r#"
+            CREATE VIEW IF NOT EXISTS pg_type AS
+            SELECT 
+                oid,
+                typname,
+                typtype,
+                typelem,
+                typarray,
+                typbasetype,
+                typnamespace,
+                typcategory
+            FROM (
+                -- Basic types with their array types and categories
+                SELECT 16 as oid, 'bool' as typname, 'b' as typtype, 0 as typelem, 1000 as typarray, 0 as typbasetype, 11 as typnamespace, 'B' as typcategory
+                UNION ALL SELECT 17, 'bytea', 'b', 0, 1001, 0, 11, 'U'
+                UNION ALL SELECT 20, 'int8', 'b', 0, 1016, 0, 11, 'N'
+                UNION ALL SELECT 21, 'int2', 'b', 0, 1005, 0, 11, 'N'
+                UNION ALL SELECT 23, 'int4', 'b', 0, 1007, 0, 11, 'N'
+                UNION ALL SELECT 25, 'text', 'b', 0, 1009, 0, 11, 'S'
+                UNION ALL SELECT 114, 'json', 'b', 0, 199, 0, 11, 'U'
+                UNION ALL SELECT 700, 'float4', 'b', 0, 1021, 0, 11, 'N'
+                UNION ALL SELECT 701, 'float8', 'b', 0, 1022, 0, 11, 'N'
+                UNION ALL SELECT 1042, 'char', 'b', 0, 1014, 0, 11, 'S'
+                UNION ALL SELECT 1043, 'varchar', 'b', 0, 1015, 0, 11, 'S'
+                UNION ALL SELECT 1082, 'date', 'b', 0, 1182, 0, 11, 'D'
+                UNION ALL SELECT 1083, 'time', 'b', 0, 1183, 0, 11, 'D'
+                UNION ALL SELECT 1114, 'timestamp', 'b', 0, 1115, 0, 11, 'D'
+                UNION ALL SELECT 1184, 'timestamptz', 'b', 0, 1185, 0, 11, 'D'
+                UNION ALL SELECT 1186, 'interval', 'b', 0, 1187, 0, 11, 'T'
+                UNION ALL SELECT 1266, 'timetz', 'b', 0, 1270, 0, 11, 'D'
+                UNION ALL SELECT 1560, 'bit', 'b', 0, 1561, 0, 11, 'V'
+                UNION ALL SELECT 1562, 'varbit', 'b', 0, 1563, 0, 11, 'V'
+                UNION ALL SELECT 1700, 'numeric', 'b', 0, 1231, 0, 11, 'N'
+                UNION ALL SELECT 2950, 'uuid', 'b', 0, 2951, 0, 11, 'U'
+                UNION ALL SELECT 3614, 'tsvector', 'b', 0, 3643, 0, 11, 'U'
+                UNION ALL SELECT 3615, 'tsquery', 'b', 0, 3645, 0, 11, 'U'
+                UNION ALL SELECT 3734, 'regconfig', 'b', 0, 3735, 0, 11, 'U'
+                UNION ALL SELECT 3802, 'jsonb', 'b', 0, 3807, 0, 11, 'U'
+                -- Array types (all have category 'A')
+                UNION ALL SELECT 1000, '_bool', 'b', 16, 0, 0, 11, 'A'
+                UNION ALL SELECT 1001, '_bytea', 'b', 17, 0, 0, 11, 'A'
+                UNION ALL SELECT 1005, '_int2', 'b', 21, 0, 0, 11, 'A'
+                UNION ALL SELECT 1007, '_int4', 'b', 23, 0, 0, 11, 'A'
+                UNION ALL SELECT 1009, '_text', 'b', 25, 0, 0, 11, 'A'
+                UNION ALL SELECT 1014, '_char', 'b', 1042, 0, 0, 11, 'A'
+                UNION ALL SELECT 1015, '_varchar', 'b', 1043, 0, 0, 11, 'A'
+                UNION ALL SELECT 1016, '_int8', 'b', 20, 0, 0, 11, 'A'
+                UNION ALL SELECT 1021, '_float4', 'b', 700, 0, 0, 11, 'A'
+                UNION ALL SELECT 1022, '_float8', 'b', 701, 0, 0, 11, 'A'
+                UNION ALL SELECT 1115, '_timestamp', 'b', 1114, 0, 0, 11, 'A'
+                UNION ALL SELECT 1182, '_date', 'b', 1082, 0, 0, 11, 'A'
+                UNION ALL SELECT 1183, '_time', 'b', 1083, 0, 0, 11, 'A'
+                UNION ALL SELECT 1185, '_timestamptz', 'b', 1184, 0, 0, 11, 'A'
+                UNION ALL SELECT 1187, '_interval', 'b', 1186, 0, 0, 11, 'A'
+                UNION ALL SELECT 1231, '_numeric', 'b', 1700, 0, 0, 11, 'A'
+                UNION ALL SELECT 1270, '_timetz', 'b', 1266, 0, 0, 11, 'A'
+                UNION ALL SELECT 1561, '_bit', 'b', 1560, 0, 0, 11, 'A'
+                UNION ALL SELECT 1563, '_varbit', 'b', 1562, 0, 0, 11, 'A'
+                UNION ALL SELECT 2951, '_uuid', 'b', 2950, 0, 0, 11, 'A'
+                UNION ALL SELECT 3643, '_tsvector', 'b', 3614, 0, 0, 11, 'A'
+                UNION ALL SELECT 3645, '_tsquery', 'b', 3615, 0, 0, 11, 'A'
+                UNION ALL SELECT 3735, '_regconfig', 'b', 3734, 0, 0, 11, 'A'
+                UNION ALL SELECT 3807, '_jsonb', 'b', 3802, 0, 0, 11, 'A'
+                UNION ALL SELECT 199, '_json', 'b', 114, 0, 0, 11, 'A'
+                -- ENUM types from __pgsqlite_enum_types (category 'E')
+                UNION ALL
+                SELECT 
+                    e.type_oid as oid,
+                    e.type_name as typname,
+                    'e' as typtype,
+                    0 as typelem,
+                    0 as typarray,  -- ENUMs don't have array types in our schema
+                    0 as typbasetype,
+                    e.namespace_oid as typnamespace,
+                    'E' as typcategory
+                FROM __pgsqlite_enum_types e
+            );
+            "#,
+
+            r#"
+            UPDATE __pgsqlite_metadata
+            SET value = '39', updated_at = strftime('%s', 'now')
+            WHERE key = 'schema_version';
+            "#,
+        ])),
+        dependencies: vec![39],
+    });
+}
+
+/// Version 41: INTERVAL values stored as canonical interval text only
+fn register_v41_interval_text(registry: &mut BTreeMap<u32, Migration>) {
+    registry.insert(41, Migration {
+        version: 41,
+        name: "interval_text",
+        description: "Convert INTERVAL values stored as INTEGER microseconds to canonical interval text",
+        up: MigrationAction::Combined {
+            pre_sql: None,
+            function: interval_integers_to_text,
+            post_sql: Some(r#"
+                UPDATE __pgsqlite_metadata
+                SET value = '41', updated_at = strftime('%s', 'now')
+                WHERE key = 'schema_version';
+            "#),
+        },
+        down: Some(MigrationAction::Combined {
+            pre_sql: None,
+            function: interval_text_to_integers,
+            post_sql: Some(r#"
+                UPDATE __pgsqlite_metadata
+                SET value = '40', updated_at = strftime('%s', 'now')
+                WHERE key = 'schema_version';
+            "#),
+        }),
+        dependencies: vec![40],
+    });
+}
+
+/// Intervals without a month part used to be stored as INTEGER microseconds, so INTERVAL
+/// columns mixed TEXT and INTEGER values that compared and sorted apart
+fn interval_integers_to_text(conn: &rusqlite::Connection) -> anyhow::Result<()> {
+    use crate::types::datetime_utils::{format_interval, microseconds_to_interval};
+
+    rewrite_interval_values(conn, "integer", |value| {
+        let micros = match value {
+            rusqlite::types::Value::Integer(micros) => micros,
+            _ => return None,
+        };
+        let (months, days, micros) = microseconds_to_interval(micros);
+        Some(rusqlite::types::Value::Text(format_interval(months, days, micros)))
+    })
+}
+
+/// Back to the version 40 layout, where only intervals with a month part stay text
+fn interval_text_to_integers(conn: &rusqlite::Connection) -> anyhow::Result<()> {
+    use crate::types::datetime_utils::{interval_to_microseconds, parse_interval};
+
+    rewrite_interval_values(conn, "text", |value| {
+        let text = match value {
+            rusqlite::types::Value::Text(text) => text,
+            _ => return None,
+        };
+        match parse_interval(&text)? {
+            (0, days, micros) => interval_to_microseconds(0, days, micros).map(rusqlite::types::Value::Integer),
+            _ => None,
+        }
+    })
+}
+
+/// Rewrite the values of the given storage class in every INTERVAL column. Values the
+/// conversion rejects are left for the application to find
+fn rewrite_interval_values(
+    conn: &rusqlite::Connection,
+    storage_class: &str,
+    convert: fn(rusqlite::types::Value) -> Option<rusqlite::types::Value>,
+) -> anyhow::Result<()> {
+    let mut stmt = conn.prepare("
+        SELECT s.table_name, s.column_name
+        FROM __pgsqlite_schema s
+        JOIN sqlite_master m ON m.name = s.table_name AND m.type = 'table'
+        WHERE lower(s.pg_type) = 'interval'
+    ")?;
+    let columns = stmt.query_map([], |row| Ok((row.get::<_, String>(0)?, row.get::<_, String>(1)?)))?
+        .collect::<Result<Vec<_>, _>>()?;
+
+    for (table, column) in &columns {
+        let table = table.replace('"', "\"\"");
+        let column = column.replace('"', "\"\"");
+        let mut stmt = conn.prepare(&format!(
+            r#"SELECT DISTINCT "{column}" FROM "{table}" WHERE typeof("{column}") = ?1"#
+        ))?;
+        let values = stmt.query_map([storage_class], |row| row.get::<_, rusqlite::types::Value>(0))?
+            .collect::<Result<Vec<_>, _>>()?;
+
+        for value in values {
+            let Some(converted) = convert(value.clone()) else {
+                continue;
+            };
+            conn.execute(
+                &format!(r#"UPDATE "{table}" SET "{column}" = ?1 WHERE "{column}" = ?2 AND typeof("{column}") = ?3"#),
+                rusqlite::params![converted, value, storage_class],
+            )?;
+        }
+    }
+
+    Ok(())
+}
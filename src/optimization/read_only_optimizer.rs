@@ -366,7 +366,7 @@ impl ReadOnlyOptimizer {
                         }
                     }
                     rusqlite::types::ValueRef::Real(f) => {
-                        values.push(Some(f.to_string().into_bytes()));
+                        values.push(Some(crate::types::numeric_utils::format_float(f).into_bytes()));
                     }
                     rusqlite::types::ValueRef::Text(s) => {
                         values.push(Some(s.to_vec()));
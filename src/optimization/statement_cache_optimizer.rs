@@ -135,7 +135,7 @@ impl StatementCacheOptimizer {
                             }
                         },
                         rusqlite::types::ValueRef::Real(val) => {
-                            row_data.push(Some(val.to_string().into_bytes()));
+                            row_data.push(Some(crate::types::numeric_utils::format_float(val).into_bytes()));
                         },
                         rusqlite::types::ValueRef::Text(val) => {
                             row_data.push(Some(val.to_vec()));
@@ -180,7 +180,7 @@ impl StatementCacheOptimizer {
                         row_data.push(Some(val.to_string().into_bytes()));
                     },
                     rusqlite::types::ValueRef::Real(val) => {
-                        row_data.push(Some(val.to_string().into_bytes()));
+                        row_data.push(Some(crate::types::numeric_utils::format_float(val).into_bytes()));
                     },
                     rusqlite::types::ValueRef::Text(val) => {
                         row_data.push(Some(val.to_vec()));
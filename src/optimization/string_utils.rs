@@ -64,21 +64,15 @@ impl StringOptimizer {
         }
     }
 
-    /// Get an optimized command completion tag
-    pub fn get_command_tag(&self, command: &str, rows: u32) -> Cow<'static, str> {
-        if let Some(tag) = self.command_tags.get(&(command, rows)) {
+    /// Get an optimized command completion tag. INSERT tags carry the historical oid
+    /// field, which is always 0.
+    pub fn get_command_tag(&self, command: &str, rows: usize) -> Cow<'static, str> {
+        if let Some(tag) = u32::try_from(rows).ok().and_then(|rows| self.command_tags.get(&(command, rows))) {
             tag.clone()
-        } else if rows <= 1000 {
-            // For small row counts, create optimized strings
-            match command {
-                "INSERT" => Cow::Owned(format!("INSERT 0 {}", rows)),
-                "UPDATE" => Cow::Owned(format!("UPDATE {}", rows)),
-                "DELETE" => Cow::Owned(format!("DELETE {}", rows)),
-                "SELECT" => Cow::Owned(format!("SELECT {}", rows)),
-                _ => Cow::Owned(format!("{} {}", command, rows)),
-            }
+        } else if command == "INSERT" {
+            Cow::Owned(format!("INSERT 0 {rows}"))
         } else {
-            Cow::Owned(format!("{} {}", command, rows))
+            Cow::Owned(format!("{command} {rows}"))
         }
     }
 
@@ -137,7 +131,7 @@ impl StringOptimizer {
                 } else if *f == 1.0 {
                     Cow::Borrowed("1")
                 } else {
-                    Cow::Owned(f.to_string())
+                    Cow::Owned(crate::types::numeric_utils::format_float(*f))
                 }
             }
             rusqlite::types::Value::Text(s) => Cow::Borrowed(s),
@@ -251,6 +245,13 @@ mod tests {
 
         let tag = optimizer.get_command_tag("DELETE", 5);
         assert_eq!(tag, "DELETE 5");
+
+        // Large counts keep the oid field of INSERT tags
+        let tag = optimizer.get_command_tag("INSERT", 5000);
+        assert_eq!(tag, "INSERT 0 5000");
+
+        let tag = optimizer.get_command_tag("UPDATE", 5_000_000_000);
+        assert_eq!(tag, "UPDATE 5000000000");
     }
 
     #[test]
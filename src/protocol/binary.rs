@@ -672,10 +672,15 @@ impl BinaryEncoder {
     pub fn encode_interval(total_seconds: f64) -> Vec<u8> {
         // For simple intervals, encode as microseconds + 0 days + 0 months
         let micros = (total_seconds * 1_000_000.0).round() as i64;
+        Self::encode_interval_parts(0, 0, micros)
+    }
+
+    /// Encode INTERVAL from its months, days and microseconds fields
+    pub fn encode_interval_parts(months: i32, days: i32, micros: i64) -> Vec<u8> {
         let mut bytes = Vec::with_capacity(16);
         bytes.extend_from_slice(&micros.to_be_bytes());
-        bytes.extend_from_slice(&0i32.to_be_bytes()); // days
-        bytes.extend_from_slice(&0i32.to_be_bytes()); // months
+        bytes.extend_from_slice(&days.to_be_bytes());
+        bytes.extend_from_slice(&months.to_be_bytes());
         bytes
     }
 
@@ -985,6 +990,8 @@ impl BinaryEncoder {
                 match value {
                     rusqlite::types::Value::Real(f) => Some(Self::encode_float4(*f as f32)),
                     rusqlite::types::Value::Integer(i) => Some(Self::encode_float4(*i as f32)),
+                    // NaN is stored as text
+                    rusqlite::types::Value::Text(s) if s == "NaN" => Some(Self::encode_float4(f32::NAN)),
                     _ => None,
                 }
             }
@@ -993,6 +1000,7 @@ impl BinaryEncoder {
                 match value {
                     rusqlite::types::Value::Real(f) => Some(Self::encode_float8(*f)),
                     rusqlite::types::Value::Integer(i) => Some(Self::encode_float8(*i as f64)),
+                    rusqlite::types::Value::Text(s) if s == "NaN" => Some(Self::encode_float8(f64::NAN)),
                     _ => None,
                 }
             }
@@ -1040,10 +1048,17 @@ impl BinaryEncoder {
                 }
             }
             t if t == PgType::Interval.to_oid() => {
-                // INTERVAL - stored as total seconds
+                // INTERVAL - stored as canonical text; timestamp arithmetic yields INTEGER microseconds
                 match value {
                     rusqlite::types::Value::Real(f) => Some(Self::encode_interval(*f)),
-                    rusqlite::types::Value::Integer(i) => Some(Self::encode_interval(*i as f64)),
+                    rusqlite::types::Value::Integer(i) => {
+                        let (months, days, micros) = crate::types::datetime_utils::microseconds_to_interval(*i);
+                        Some(Self::encode_interval_parts(months, days, micros))
+                    }
+                    rusqlite::types::Value::Text(s) => {
+                        let (months, days, micros) = crate::types::datetime_utils::parse_stored_interval(s)?;
+                        Some(Self::encode_interval_parts(months, days, micros))
+                    }
                     _ => None,
                 }
             }
@@ -1434,6 +1449,19 @@ mod tests {
         let months = i32::from_be_bytes(encoded[12..16].try_into().unwrap());
         assert_eq!(days, 0);
         assert_eq!(months, 0);
+        
+        // Stored intervals carry their day and month fields
+        let encoded = BinaryEncoder::encode_value(&rusqlite::types::Value::Integer(95_400_000_000), PgType::Interval.to_oid(), true).unwrap();
+        assert_eq!(encoded, BinaryEncoder::encode_interval_parts(0, 1, 9_000_000_000));
+        
+        let stored = rusqlite::types::Value::Text("1 year 2 mons 3 days 04:05:06".to_string());
+        let encoded = BinaryEncoder::encode_value(&stored, PgType::Interval.to_oid(), true).unwrap();
+        assert_eq!(encoded, BinaryEncoder::encode_interval_parts(14, 3, 14_706_000_000));
+        
+        // 30 days stay days
+        let stored = rusqlite::types::Value::Text("30 days".to_string());
+        let encoded = BinaryEncoder::encode_value(&stored, PgType::Interval.to_oid(), true).unwrap();
+        assert_eq!(encoded, BinaryEncoder::encode_interval_parts(0, 30, 0));
     }
     
     #[test]
@@ -69,6 +69,10 @@ impl Encoder<BackendMessage> for PostgresCodec {
             BackendMessage::PortalSuspended => encode_portal_suspended(dst),
             BackendMessage::NoData => encode_no_data(dst),
             BackendMessage::ParameterDescription(oids) => encode_parameter_description(oids, dst),
+            BackendMessage::CopyInResponse { format, column_formats } => encode_copy_response(b'G', format, &column_formats, dst),
+            BackendMessage::CopyOutResponse { format, column_formats } => encode_copy_response(b'H', format, &column_formats, dst),
+            BackendMessage::CopyData(data) => encode_copy_data(&data, dst),
+            BackendMessage::CopyDone => encode_copy_done(dst),
         }
         Ok(())
     }
@@ -94,6 +98,13 @@ fn decode_startup_message(src: &mut BytesMut) -> io::Result<Option<FrontendMessa
     if protocol_version == 80877103 {
         return Ok(Some(FrontendMessage::SslRequest));
     }
+
+    // Check for cancel request (protocol version 80877102), sent on a new connection
+    if protocol_version == 80877102 && msg_buf.remaining() >= 8 {
+        let process_id = msg_buf.get_i32();
+        let secret_key = msg_buf.get_i32();
+        return Ok(Some(FrontendMessage::CancelRequest { process_id, secret_key }));
+    }
     
     let mut parameters = HashMap::new();
     
@@ -195,6 +206,12 @@ fn decode_normal_message(src: &mut BytesMut) -> io::Result<Option<FrontendMessag
             Ok(Some(FrontendMessage::Describe { typ, name }))
         }
         b'H' => Ok(Some(FrontendMessage::Flush)),
+        b'd' => Ok(Some(FrontendMessage::CopyData(msg_buf.to_vec()))),
+        b'c' => Ok(Some(FrontendMessage::CopyDone)),
+        b'f' => {
+            let message = read_cstring(&mut msg_buf)?;
+            Ok(Some(FrontendMessage::CopyFail(message)))
+        }
         _ => Err(io::Error::new(
             io::ErrorKind::InvalidData,
             format!("Unknown message type: {}", msg_type as char),
@@ -410,6 +427,31 @@ fn encode_parameter_description(oids: Vec<i32>, dst: &mut BytesMut) {
     update_message_length(dst, len_pos);
 }
 
+fn encode_copy_response(tag: u8, format: i8, column_formats: &[i16], dst: &mut BytesMut) {
+    dst.put_u8(tag);
+    let len_pos = dst.len();
+    dst.put_i32(0); // Placeholder
+
+    dst.put_i8(format);
+    dst.put_i16(column_formats.len() as i16);
+    for column_format in column_formats {
+        dst.put_i16(*column_format);
+    }
+
+    update_message_length(dst, len_pos);
+}
+
+fn encode_copy_data(data: &[u8], dst: &mut BytesMut) {
+    dst.put_u8(b'd');
+    dst.put_i32(data.len() as i32 + 4);
+    dst.put_slice(data);
+}
+
+fn encode_copy_done(dst: &mut BytesMut) {
+    dst.put_u8(b'c');
+    dst.put_i32(4); // Fixed length
+}
+
 // Helper functions
 fn read_cstring(buf: &mut &[u8]) -> io::Result<String> {
     let null_pos = buf.iter().position(|&b| b == 0)
@@ -3,6 +3,10 @@ use std::collections::HashMap;
 #[derive(Debug, Clone)]
 pub enum FrontendMessage {
     SslRequest,
+    CancelRequest {
+        process_id: i32,
+        secret_key: i32,
+    },
     StartupMessage(StartupMessage),
     Query(String),
     Parse {
@@ -32,6 +36,9 @@ pub enum FrontendMessage {
         name: String,
     },
     Flush,
+    CopyData(Vec<u8>),
+    CopyDone,
+    CopyFail(String),
 }
 
 #[derive(Debug, Clone)]
@@ -58,6 +65,10 @@ pub enum BackendMessage {
     PortalSuspended,
     NoData,
     ParameterDescription(Vec<i32>),
+    CopyInResponse { format: i8, column_formats: Vec<i16> },
+    CopyOutResponse { format: i8, column_formats: Vec<i16> },
+    CopyData(Vec<u8>),
+    CopyDone,
 }
 
 #[derive(Debug, Clone)]
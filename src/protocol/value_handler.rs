@@ -234,7 +234,7 @@ impl ValueHandler {
         let pg_data = if binary_format {
             self.convert_real_binary(real_val, pg_type_oid)
         } else {
-            real_val.to_string().into_bytes()
+            crate::types::numeric_utils::format_float(real_val).into_bytes()
         };
         
         Ok(Some(MappedValue::Memory(pg_data)))
@@ -0,0 +1,305 @@
+use crate::error::PgError;
+use crate::protocol::BackendMessage;
+use crate::session::{DbHandler, SessionState};
+use crate::translator::sql_scan::split_top_level;
+use std::sync::Arc;
+use crate::PgSqliteError;
+use rusqlite::{Connection, OptionalExtension};
+use tokio_util::codec::Framed;
+use futures::SinkExt;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+
+static ANALYZE_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?is)^\s*ANALY[SZ]E\b\s*(?:\([^()]*\)\s*)?(?:VERBOSE\b\s*)?(.*?)\s*;?\s*$").unwrap()
+});
+
+/// Number of most common values kept per column, PostgreSQL's default_statistics_target
+/// is much larger but clients only use the head of the list
+const MOST_COMMON_VALUES_LIMIT: i64 = 10;
+
+/// A table to analyze and the columns named for it (all columns if empty)
+#[derive(Debug, PartialEq)]
+pub(crate) struct AnalyzeTarget {
+    table: String,
+    columns: Vec<String>,
+}
+
+pub struct AnalyzeHandler;
+
+impl AnalyzeHandler {
+    /// Check if this is an ANALYZE command
+    pub fn is_analyze(query: &str) -> bool {
+        query.split_whitespace().next()
+            .map(|word| word.trim_end_matches(';'))
+            .is_some_and(|word| word.eq_ignore_ascii_case("ANALYZE") || word.eq_ignore_ascii_case("ANALYSE"))
+    }
+
+    /// Handle ANALYZE [ ( option [, ...] ) ] [ VERBOSE ] [ table [ ( column [, ...] ) ] [, ...] ]
+    ///
+    /// SQLite's ANALYZE refreshes sqlite_stat1, which pg_class.reltuples reads. Per-column
+    /// statistics (null fraction, distinct values, most common values) have no SQLite
+    /// counterpart, so they are computed here and kept in __pgsqlite_stats for pg_stats.
+    pub async fn handle_analyze<T>(
+        framed: &mut Framed<T, crate::protocol::PostgresCodec>,
+        db: &Arc<DbHandler>,
+        session: &Arc<SessionState>,
+        query: &str,
+    ) -> Result<(), PgSqliteError>
+    where
+        T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
+    {
+        let targets = Self::parse_targets(query)
+            .ok_or_else(|| PgError::SyntaxError {
+                message: "syntax error at or near \"ANALYZE\"".to_string(),
+                position: None,
+            })?;
+        debug!("ANALYZE {:?}", targets);
+
+        let result = db.with_session_connection(&session.id, |conn| {
+            Self::analyze(conn, targets)
+        }).await?;
+        result?;
+
+        framed.send(BackendMessage::CommandComplete {
+            tag: "ANALYZE".to_string()
+        }).await.map_err(PgSqliteError::Io)?;
+
+        Ok(())
+    }
+
+    fn parse_targets(query: &str) -> Option<Vec<AnalyzeTarget>> {
+        let caps = ANALYZE_PATTERN.captures(query)?;
+        Self::parse_table_list(&caps[1])
+    }
+
+    /// Parse `table [ ( column [, ...] ) ] [, ...]`, shared with VACUUM
+    pub(crate) fn parse_table_list(list: &str) -> Option<Vec<AnalyzeTarget>> {
+        let mut targets = Vec::new();
+        for reference in split_top_level(list) {
+            let reference = reference.trim();
+            if reference.is_empty() {
+                return None;
+            }
+            let (table, columns) = match reference.split_once('(') {
+                Some((table, columns)) => {
+                    let columns = columns.trim_end().strip_suffix(')')?;
+                    (table, columns.split(',').map(|column| column.trim().trim_matches('"').to_string()).collect())
+                }
+                None => (reference, Vec::new()),
+            };
+            targets.push(AnalyzeTarget { table: Self::table_name(table), columns });
+        }
+        Some(targets)
+    }
+
+    /// Refresh the statistics of the targets, all user tables if there are none
+    pub(crate) fn analyze(conn: &Connection, mut targets: Vec<AnalyzeTarget>) -> Result<Result<(), PgError>, rusqlite::Error> {
+        if targets.is_empty() {
+            let mut stmt = conn.prepare(
+                "SELECT name FROM sqlite_master WHERE type = 'table' \
+                 AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '__pgsqlite_%' ORDER BY name"
+            )?;
+            let tables = stmt.query_map([], |row| row.get::<_, String>(0))?.collect::<Result<Vec<_>, _>>()?;
+            targets = tables.into_iter().map(|table| AnalyzeTarget { table, columns: Vec::new() }).collect();
+        }
+
+        // Resolve every name before touching the statistics
+        let mut resolved = Vec::new();
+        for target in &targets {
+            let table: Option<String> = conn.query_row(
+                "SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?1 COLLATE NOCASE",
+                [&target.table],
+                |row| row.get(0),
+            ).optional()?;
+            let Some(table) = table else {
+                return Ok(Err(PgError::Generic {
+                    code: "42P01".to_string(), // undefined_table
+                    message: format!("relation \"{}\" does not exist", target.table),
+                }));
+            };
+
+            let mut stmt = conn.prepare("SELECT name FROM pragma_table_info(?1)")?;
+            let all_columns = stmt.query_map([&table], |row| row.get::<_, String>(0))?.collect::<Result<Vec<_>, _>>()?;
+            let mut columns = Vec::new();
+            for column in &target.columns {
+                match all_columns.iter().find(|name| name.eq_ignore_ascii_case(column)) {
+                    Some(name) => columns.push(name.clone()),
+                    None => return Ok(Err(PgError::Generic {
+                        code: "42703".to_string(), // undefined_column
+                        message: format!("column \"{column}\" of relation \"{table}\" does not exist"),
+                    })),
+                }
+            }
+            if columns.is_empty() {
+                columns = all_columns;
+            }
+            resolved.push((table, columns));
+        }
+
+        for (table, columns) in resolved {
+            conn.execute_batch(&format!("ANALYZE {}", Self::quote(&table)))?;
+            Self::store_column_statistics(conn, &table, &columns)?;
+        }
+        Ok(Ok(()))
+    }
+
+    /// Compute pg_stats values for the columns and replace the stored ones
+    fn store_column_statistics(conn: &Connection, table: &str, columns: &[String]) -> Result<(), rusqlite::Error> {
+        let quoted_table = Self::quote(table);
+        let total: i64 = conn.query_row(&format!("SELECT count(*) FROM {quoted_table}"), [], |row| row.get(0))?;
+
+        for column in columns {
+            conn.execute(
+                "DELETE FROM __pgsqlite_stats WHERE table_name = ?1 AND column_name = ?2",
+                [table, column],
+            )?;
+            // Like PostgreSQL, an empty table has no statistics
+            if total == 0 {
+                continue;
+            }
+
+            let quoted_column = Self::quote(column);
+            let (non_null, distinct): (i64, i64) = conn.query_row(
+                &format!("SELECT count({quoted_column}), count(DISTINCT {quoted_column}) FROM {quoted_table}"),
+                [],
+                |row| Ok((row.get(0)?, row.get(1)?)),
+            )?;
+            let total_f = total as f64;
+            let null_frac = (total - non_null) as f64 / total_f;
+            // A distinct count that grows with the table is stored as a negative fraction of the rows
+            let n_distinct = if distinct as f64 > 0.1 * total_f {
+                -(distinct as f64 / total_f)
+            } else {
+                distinct as f64
+            };
+
+            // Values that occur more than once, most frequent first
+            let mut stmt = conn.prepare(&format!(
+                "SELECT CAST({quoted_column} AS TEXT), count(*) FROM {quoted_table} WHERE {quoted_column} IS NOT NULL \
+                 GROUP BY {quoted_column} HAVING count(*) > 1 ORDER BY count(*) DESC, 1 LIMIT {MOST_COMMON_VALUES_LIMIT}"
+            ))?;
+            let common = stmt.query_map([], |row| Ok((row.get::<_, String>(0)?, row.get::<_, i64>(1)?)))?
+                .collect::<Result<Vec<_>, _>>()?;
+            let (most_common_vals, most_common_freqs) = if common.is_empty() {
+                (None, None)
+            } else {
+                let vals = common.iter().map(|(value, _)| Self::array_element(value)).collect::<Vec<_>>().join(",");
+                let freqs = common.iter().map(|(_, count)| Self::format_float(*count as f64 / total_f)).collect::<Vec<_>>().join(",");
+                (Some(format!("{{{vals}}}")), Some(format!("{{{freqs}}}")))
+            };
+
+            conn.execute(
+                "INSERT INTO __pgsqlite_stats (table_name, column_name, null_frac, n_distinct, most_common_vals, most_common_freqs) \
+                 VALUES (?1, ?2, ?3, ?4, ?5, ?6)",
+                rusqlite::params![
+                    table,
+                    column,
+                    Self::format_float(null_frac),
+                    Self::format_float(n_distinct),
+                    most_common_vals,
+                    most_common_freqs,
+                ],
+            )?;
+        }
+        Ok(())
+    }
+
+    /// float4 text output: at most 6 significant digits, no trailing zeros
+    fn format_float(value: f64) -> String {
+        let formatted = format!("{:.6}", value);
+        let formatted = formatted.trim_end_matches('0').trim_end_matches('.');
+        if formatted == "-0" { "0".to_string() } else { formatted.to_string() }
+    }
+
+    /// Quote an element of an array literal when PostgreSQL would
+    fn array_element(value: &str) -> String {
+        let needs_quotes = value.is_empty()
+            || value.eq_ignore_ascii_case("NULL")
+            || value.chars().any(|c| matches!(c, '{' | '}' | ',' | '"' | '\\') || c.is_whitespace());
+        if needs_quotes {
+            format!("\"{}\"", value.replace('\\', "\\\\").replace('"', "\\\""))
+        } else {
+            value.to_string()
+        }
+    }
+
+    fn quote(identifier: &str) -> String {
+        format!("\"{}\"", identifier.replace('"', "\"\""))
+    }
+
+    /// Strip a schema prefix and quotes from a table reference
+    fn table_name(reference: &str) -> String {
+        let reference = reference.trim();
+        let name = match reference.rsplit_once('.') {
+            Some((_, name)) if !name.ends_with('"') || reference.matches('"').count() % 4 == 0 => name,
+            _ => reference,
+        };
+        name.trim_matches('"').to_string()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_targets() {
+        assert_eq!(AnalyzeHandler::parse_targets("ANALYZE").unwrap(), vec![]);
+        assert_eq!(AnalyzeHandler::parse_targets("analyze verbose;").unwrap(), vec![]);
+        assert_eq!(
+            AnalyzeHandler::parse_targets("ANALYZE (VERBOSE, SKIP_LOCKED) public.books (title, \"Author\"), authors").unwrap(),
+            vec![
+                AnalyzeTarget { table: "books".to_string(), columns: vec!["title".to_string(), "Author".to_string()] },
+                AnalyzeTarget { table: "authors".to_string(), columns: vec![] },
+            ]
+        );
+        assert!(AnalyzeHandler::parse_targets("ANALYZE books,").is_none());
+        assert!(AnalyzeHandler::is_analyze("ANALYSE books"));
+        assert!(!AnalyzeHandler::is_analyze("SELECT 'ANALYZE'"));
+    }
+
+    #[test]
+    fn test_column_statistics() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "CREATE TABLE __pgsqlite_stats (table_name TEXT, column_name TEXT, null_frac TEXT, n_distinct TEXT,
+                                            most_common_vals TEXT, most_common_freqs TEXT,
+                                            PRIMARY KEY (table_name, column_name));
+             CREATE TABLE books (id INTEGER PRIMARY KEY, genre TEXT, note TEXT);
+             INSERT INTO books (genre, note) VALUES
+                ('scifi', NULL), ('scifi', NULL), ('scifi', 'x'), ('fantasy', NULL),
+                ('fantasy', 'y'), ('poetry', NULL), ('science fiction', NULL), ('science fiction', NULL),
+                ('scifi', NULL), ('scifi', NULL);"
+        ).unwrap();
+
+        let targets = AnalyzeHandler::parse_targets("ANALYZE books (genre, note)").unwrap();
+        AnalyzeHandler::analyze(&conn, targets).unwrap().unwrap();
+
+        let stats = |column: &str| conn.query_row(
+            "SELECT null_frac, n_distinct, most_common_vals, most_common_freqs FROM __pgsqlite_stats \
+             WHERE table_name = 'books' AND column_name = ?1",
+            [column],
+            |row| Ok((row.get::<_, String>(0)?, row.get::<_, String>(1)?, row.get::<_, Option<String>>(2)?, row.get::<_, Option<String>>(3)?)),
+        ).unwrap();
+        assert_eq!(stats("genre"), (
+            "0".to_string(),
+            "-0.4".to_string(),
+            Some("{scifi,fantasy,\"science fiction\"}".to_string()),
+            Some("{0.5,0.2,0.2}".to_string()),
+        ));
+        assert_eq!(stats("note"), ("0.8".to_string(), "-0.2".to_string(), None, None));
+
+        // Only the named columns are analyzed
+        let id_stats: i64 = conn.query_row("SELECT count(*) FROM __pgsqlite_stats WHERE column_name = 'id'", [], |row| row.get(0)).unwrap();
+        assert_eq!(id_stats, 0);
+        let analyzed: i64 = conn.query_row("SELECT count(*) FROM sqlite_stat1 WHERE tbl = 'books'", [], |row| row.get(0)).unwrap();
+        assert_eq!(analyzed, 1);
+
+        let err = AnalyzeHandler::analyze(&conn, AnalyzeHandler::parse_targets("ANALYZE books (missing)").unwrap()).unwrap().unwrap_err();
+        assert!(err.to_string().contains("column \"missing\" of relation \"books\" does not exist"));
+        let err = AnalyzeHandler::analyze(&conn, AnalyzeHandler::parse_targets("ANALYZE missing").unwrap()).unwrap().unwrap_err();
+        assert!(err.to_string().contains("relation \"missing\" does not exist"));
+    }
+}
@@ -1,3 +1,5 @@
+use crate::query::dollar_quote::{can_start_dollar_quote, dollar_quote_len};
+
 /// SQL comment stripping utilities
 /// 
 /// This module provides functionality to strip SQL comments from queries
@@ -8,12 +10,23 @@
 /// while preserving string literals and their contents.
 pub fn strip_sql_comments(query: &str) -> String {
     let mut result = String::with_capacity(query.len());
-    let mut chars = query.chars().peekable();
+    let mut chars = query.char_indices().peekable();
     let mut in_string = false;
     let mut string_delimiter = '\0';
     
-    while let Some(ch) = chars.next() {
+    while let Some((pos, ch)) = chars.next() {
         match ch {
+            // Dollar-quoted strings are copied whole, comment markers and all
+            '$' if !in_string && can_start_dollar_quote(query, pos) => {
+                match dollar_quote_len(&query[pos..]) {
+                    Some(len) => {
+                        result.push_str(&query[pos..pos + len]);
+                        while chars.next_if(|(next, _)| *next < pos + len).is_some() {}
+                    }
+                    None => result.push(ch),
+                }
+            }
+            
             // Handle string literals
             '\'' | '"' if !in_string => {
                 in_string = true;
@@ -22,10 +35,10 @@ pub fn strip_sql_comments(query: &str) -> String {
             }
             ch if ch == string_delimiter && in_string => {
                 // Check for escaped quotes
-                if chars.peek() == Some(&ch) {
+                if chars.peek().map(|(_, c)| *c) == Some(ch) {
                     // Escaped quote, consume both
                     result.push(ch);
-                    result.push(chars.next().unwrap());
+                    result.push(chars.next().unwrap().1);
                 } else {
                     // End of string
                     in_string = false;
@@ -35,21 +48,21 @@ pub fn strip_sql_comments(query: &str) -> String {
             }
             
             // Handle comments only outside of strings
-            '-' if !in_string && chars.peek() == Some(&'-') => {
+            '-' if !in_string && chars.peek().map(|(_, c)| *c) == Some('-') => {
                 // Single-line comment, skip to end of line
                 chars.next(); // consume second '-'
-                for c in chars.by_ref() {
+                for (_, c) in chars.by_ref() {
                     if c == '\n' {
                         result.push('\n'); // preserve line break
                         break;
                     }
                 }
             }
-            '/' if !in_string && chars.peek() == Some(&'*') => {
+            '/' if !in_string && chars.peek().map(|(_, c)| *c) == Some('*') => {
                 // Multi-line comment, skip until */
                 chars.next(); // consume '*'
                 let mut prev_char = '\0';
-                for c in chars.by_ref() {
+                for (_, c) in chars.by_ref() {
                     if prev_char == '*' && c == '/' {
                         break;
                     }
@@ -164,6 +177,14 @@ WHERE active = true
         );
     }
 
+    #[test]
+    fn test_preserve_dollar_quoted_strings() {
+        assert_eq!(
+            strip_sql_comments("SELECT $$-- kept /* too */$$, $fn$ 'a' -- b $fn$ -- dropped"),
+            "SELECT $$-- kept /* too */$$, $fn$ 'a' -- b $fn$ "
+        );
+    }
+
     #[test]
     fn test_comment_like_operators() {
         // Make sure we don't strip things that look like comments but aren't
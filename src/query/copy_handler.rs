@@ -0,0 +1,953 @@
+use crate::error::PgError;
+use crate::protocol::{BackendMessage, FrontendMessage};
+use crate::protocol::binary::BinaryEncoder;
+use crate::session::{DbHandler, SessionState};
+use crate::types::{DecimalHandler, PgType, SchemaTypeMapper, ValueConverter};
+use std::sync::Arc;
+use crate::PgSqliteError;
+use rusqlite::{Connection, OptionalExtension};
+use rusqlite::types::Value;
+use tokio_util::codec::Framed;
+use futures::{SinkExt, StreamExt};
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::create_table_as_handler::CreateTableAsHandler;
+
+static COPY_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r#"(?is)^\s*COPY\s+(?:\((.+)\)|((?:"[^"]+"|\w+)(?:\.(?:"[^"]+"|\w+))?)(?:\s*\(([^()]*)\))?)\s+(FROM|TO)\s+(STDIN|STDOUT|PROGRAM\s+'(?:[^']|'')*'|'(?:[^']|'')*')(.*?)\s*;?\s*$"#
+    ).unwrap()
+});
+
+/// The signature that starts binary COPY data
+const BINARY_SIGNATURE: &[u8] = b"PGCOPY\n\xff\r\n\0";
+
+/// Microseconds between the Unix epoch and PostgreSQL's 2000-01-01 epoch
+const PG_EPOCH_OFFSET_MICROS: i64 = 946_684_800_000_000;
+
+/// Days between the Unix epoch and PostgreSQL's 2000-01-01 epoch
+const PG_EPOCH_OFFSET_DAYS: i64 = 10_957;
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+enum CopyFormat {
+    Text,
+    Binary,
+}
+
+/// What a COPY statement reads or writes
+#[derive(Debug, PartialEq)]
+enum CopySource {
+    Table { name: String, columns: Vec<String> },
+    Query(String),
+}
+
+/// A parsed COPY ... FROM STDIN or COPY ... TO STDOUT statement
+#[derive(Debug, PartialEq)]
+struct CopyStatement {
+    source: CopySource,
+    from: bool,
+    format: CopyFormat,
+    delimiter: char,
+    null: String,
+}
+
+pub struct CopyHandler;
+
+impl CopyHandler {
+    /// Check if this is a COPY command
+    pub fn is_copy(query: &str) -> bool {
+        query.split_whitespace().next()
+            .is_some_and(|word| word.eq_ignore_ascii_case("COPY"))
+    }
+
+    /// Handle COPY table [(columns)] FROM STDIN and COPY {table [(columns)] | (query)} TO STDOUT
+    /// in the text and binary formats.
+    ///
+    /// COPY TO reads the stored values and sends them one row per CopyData message; the binary
+    /// format encodes them with the same per-type encoders as binary results, so numeric and
+    /// timestamp values round-trip exactly. COPY FROM collects the client's CopyData until
+    /// CopyDone and inserts every row under a savepoint, so a bad row leaves the table as it was.
+    pub async fn handle_copy<T>(
+        framed: &mut Framed<T, crate::protocol::PostgresCodec>,
+        db: &Arc<DbHandler>,
+        session: &Arc<SessionState>,
+        query: &str,
+    ) -> Result<(), PgSqliteError>
+    where
+        T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
+    {
+        let statement = Self::parse(query)?;
+        debug!("COPY {:?}", statement);
+
+        let rows = if statement.from {
+            Self::copy_from(framed, db, session, &statement).await?
+        } else {
+            Self::copy_to(framed, db, session, &statement).await?
+        };
+
+        framed.send(BackendMessage::CommandComplete {
+            tag: format!("COPY {rows}")
+        }).await.map_err(PgSqliteError::Io)?;
+
+        Ok(())
+    }
+
+    async fn copy_to<T>(
+        framed: &mut Framed<T, crate::protocol::PostgresCodec>,
+        db: &Arc<DbHandler>,
+        session: &Arc<SessionState>,
+        statement: &CopyStatement,
+    ) -> Result<usize, PgSqliteError>
+    where
+        T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
+    {
+        let schema_cache = db.get_schema_cache();
+        let (types, rows) = db.with_session_connection(&session.id, |conn| {
+            match &statement.source {
+                CopySource::Table { name, columns } => {
+                    let (table, columns) = match Self::table_columns(conn, name, columns, false)? {
+                        Ok(resolved) => resolved,
+                        Err(e) => return Ok(Err(e)),
+                    };
+                    let select = format!(
+                        "SELECT {} FROM {}",
+                        columns.iter().map(|(column, _)| Self::quote(column)).collect::<Vec<_>>().join(", "),
+                        Self::quote(&table)
+                    );
+                    let (_, rows) = Self::read_rows(conn, &select)?;
+                    Ok(Ok((columns.into_iter().map(|(_, oid)| oid).collect::<Vec<_>>(), rows)))
+                }
+                CopySource::Query(query) => {
+                    let translated = crate::query::process_query(query, conn, schema_cache)?;
+                    let (names, rows) = Self::read_rows(conn, &translated)?;
+                    let types = CreateTableAsHandler::query_column_types(conn, query, &names)
+                        .into_iter()
+                        .enumerate()
+                        .map(|(i, pg_type)| match pg_type {
+                            Some(pg_type) => SchemaTypeMapper::pg_type_string_to_oid_with_enum_check(&pg_type, conn),
+                            None => Self::type_of_values(&rows, i),
+                        })
+                        .collect();
+                    Ok(Ok((types, rows)))
+                }
+            }
+        }).await??;
+
+        let column_format = match statement.format {
+            CopyFormat::Text => 0,
+            CopyFormat::Binary => 1,
+        };
+        framed.send(BackendMessage::CopyOutResponse {
+            format: column_format as i8,
+            column_formats: vec![column_format; types.len()],
+        }).await.map_err(PgSqliteError::Io)?;
+
+        if statement.format == CopyFormat::Binary {
+            // Signature, no flags and an empty header extension
+            let mut header = BINARY_SIGNATURE.to_vec();
+            header.extend_from_slice(&0i32.to_be_bytes());
+            header.extend_from_slice(&0i32.to_be_bytes());
+            framed.send(BackendMessage::CopyData(header)).await.map_err(PgSqliteError::Io)?;
+        }
+        for row in &rows {
+            let data = match statement.format {
+                CopyFormat::Text => Self::encode_text_row(row, &types, statement.delimiter, &statement.null),
+                CopyFormat::Binary => Self::encode_binary_row(row, &types),
+            };
+            framed.send(BackendMessage::CopyData(data)).await.map_err(PgSqliteError::Io)?;
+        }
+        if statement.format == CopyFormat::Binary {
+            framed.send(BackendMessage::CopyData((-1i16).to_be_bytes().to_vec())).await.map_err(PgSqliteError::Io)?;
+        }
+        framed.send(BackendMessage::CopyDone).await.map_err(PgSqliteError::Io)?;
+
+        Ok(rows.len())
+    }
+
+    async fn copy_from<T>(
+        framed: &mut Framed<T, crate::protocol::PostgresCodec>,
+        db: &Arc<DbHandler>,
+        session: &Arc<SessionState>,
+        statement: &CopyStatement,
+    ) -> Result<usize, PgSqliteError>
+    where
+        T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
+    {
+        let CopySource::Table { name, columns } = &statement.source else {
+            return Err(PgError::SyntaxError {
+                message: "syntax error at or near \"FROM\"".to_string(),
+                position: None,
+            }.into());
+        };
+        let (table, columns) = db.with_session_connection(&session.id, |conn| {
+            Self::table_columns(conn, name, columns, true)
+        }).await??;
+
+        let column_format = match statement.format {
+            CopyFormat::Text => 0,
+            CopyFormat::Binary => 1,
+        };
+        framed.send(BackendMessage::CopyInResponse {
+            format: column_format as i8,
+            column_formats: vec![column_format; columns.len()],
+        }).await.map_err(PgSqliteError::Io)?;
+        framed.flush().await.map_err(PgSqliteError::Io)?;
+
+        // Collect the data; Sync and Flush are ignored until the client is done
+        let mut data = Vec::new();
+        loop {
+            match framed.next().await {
+                Some(Ok(FrontendMessage::CopyData(chunk))) => data.extend_from_slice(&chunk),
+                Some(Ok(FrontendMessage::CopyDone)) => break,
+                Some(Ok(FrontendMessage::CopyFail(message))) => {
+                    return Err(PgError::Generic {
+                        code: "57014".to_string(), // query_canceled
+                        message: format!("COPY from stdin failed: {message}"),
+                    }.into());
+                }
+                Some(Ok(FrontendMessage::Sync | FrontendMessage::Flush)) => {}
+                Some(Ok(other)) => {
+                    return Err(PgError::Generic {
+                        code: "08P01".to_string(), // protocol_violation
+                        message: format!("unexpected message during COPY from stdin: {other:?}"),
+                    }.into());
+                }
+                Some(Err(e)) => return Err(PgSqliteError::Io(e)),
+                None => {
+                    return Err(PgError::Generic {
+                        code: "08P01".to_string(), // protocol_violation
+                        message: "unexpected EOF on client connection with an open transaction".to_string(),
+                    }.into());
+                }
+            }
+        }
+        debug!("COPY FROM STDIN received {} bytes for {}", data.len(), table);
+
+        let types: Vec<i32> = columns.iter().map(|(_, oid)| *oid).collect();
+        let rows = match statement.format {
+            CopyFormat::Text => Self::decode_text_rows(&data, &columns, statement.delimiter, &statement.null)?,
+            CopyFormat::Binary => Self::decode_binary_rows(&data, &types)?,
+        };
+
+        let names: Vec<String> = columns.into_iter().map(|(column, _)| column).collect();
+        let inserted = db.with_session_connection(&session.id, |conn| {
+            Self::insert_rows(conn, &table, &names, &rows)
+        }).await?;
+
+        Ok(inserted)
+    }
+
+    fn parse(query: &str) -> Result<CopyStatement, PgError> {
+        let caps = COPY_PATTERN.captures(query)
+            .ok_or_else(|| PgError::SyntaxError {
+                message: "syntax error at or near \"COPY\"".to_string(),
+                position: None,
+            })?;
+
+        let from = caps[4].eq_ignore_ascii_case("FROM");
+        let target = &caps[5];
+        let expected = if from { "STDIN" } else { "STDOUT" };
+        if !target.eq_ignore_ascii_case(expected) {
+            if target.eq_ignore_ascii_case("STDIN") || target.eq_ignore_ascii_case("STDOUT") {
+                return Err(PgError::SyntaxError {
+                    message: format!("syntax error at or near \"{target}\""),
+                    position: None,
+                });
+            }
+            return Err(PgError::Generic {
+                code: "0A000".to_string(), // feature_not_supported
+                message: format!(
+                    "COPY {} a file or program is not supported, use COPY ... {} {expected} instead",
+                    if from { "from" } else { "to" },
+                    if from { "FROM" } else { "TO" }
+                ),
+            });
+        }
+
+        let source = match caps.get(1) {
+            Some(query) => CopySource::Query(query.as_str().trim().to_string()),
+            None => CopySource::Table {
+                name: Self::table_name(&caps[2]),
+                columns: caps.get(3)
+                    .map(|m| m.as_str().split(',').map(|c| Self::unquote(c.trim())).filter(|c| !c.is_empty()).collect())
+                    .unwrap_or_default(),
+            },
+        };
+
+        let mut statement = CopyStatement {
+            source,
+            from,
+            format: CopyFormat::Text,
+            delimiter: '\t',
+            null: "\\N".to_string(),
+        };
+        for (option, value) in Self::parse_options(&caps[6])? {
+            match (option.as_str(), value) {
+                ("format", Some(format)) => {
+                    statement.format = match format.to_lowercase().as_str() {
+                        "text" => CopyFormat::Text,
+                        "binary" => CopyFormat::Binary,
+                        "csv" => return Err(PgError::Generic {
+                            code: "0A000".to_string(), // feature_not_supported
+                            message: "COPY format \"csv\" is not supported".to_string(),
+                        }),
+                        _ => return Err(PgError::Generic {
+                            code: "22023".to_string(), // invalid_parameter_value
+                            message: format!("COPY format \"{format}\" not recognized"),
+                        }),
+                    };
+                }
+                ("binary", None) => statement.format = CopyFormat::Binary,
+                ("csv", None) => return Err(PgError::Generic {
+                    code: "0A000".to_string(), // feature_not_supported
+                    message: "COPY format \"csv\" is not supported".to_string(),
+                }),
+                ("delimiter", Some(delimiter)) => {
+                    let mut chars = delimiter.chars();
+                    statement.delimiter = match (chars.next(), chars.next()) {
+                        (Some(c), None) if c != '\\' && c != '\n' && c != '\r' => c,
+                        _ => return Err(PgError::Generic {
+                            code: "22023".to_string(), // invalid_parameter_value
+                            message: "COPY delimiter must be a single one-byte character".to_string(),
+                        }),
+                    };
+                }
+                ("null", Some(null)) => statement.null = null,
+                ("encoding", Some(encoding)) if encoding.eq_ignore_ascii_case("utf8") || encoding.eq_ignore_ascii_case("utf-8") => {}
+                ("freeze", _) => {}
+                _ => return Err(PgError::Generic {
+                    code: "42601".to_string(), // syntax_error
+                    message: format!("option \"{option}\" not recognized"),
+                }),
+            }
+        }
+        if statement.format == CopyFormat::Binary && (statement.delimiter != '\t' || statement.null != "\\N") {
+            return Err(PgError::Generic {
+                code: "42601".to_string(), // syntax_error
+                message: "cannot specify DELIMITER or NULL in BINARY mode".to_string(),
+            });
+        }
+        Ok(statement)
+    }
+
+    /// The options after the target: `[WITH] (name [value], ...)` or the older
+    /// `[WITH] BINARY | DELIMITER [AS] 'c' | NULL [AS] 'str'` words
+    fn parse_options(options: &str) -> Result<Vec<(String, Option<String>)>, PgError> {
+        let mut options = options.trim();
+        if options.len() >= 4 && options[..4].eq_ignore_ascii_case("WITH") {
+            options = options[4..].trim_start();
+        }
+        let syntax_error = || PgError::SyntaxError {
+            message: format!("syntax error at or near \"{}\"", options.split_whitespace().next().unwrap_or("")),
+            position: None,
+        };
+
+        let mut words = Vec::new();
+        let list = match options.strip_prefix('(') {
+            Some(inner) => inner.strip_suffix(')').ok_or_else(syntax_error)?,
+            None => options,
+        };
+        let mut chars = list.chars().peekable();
+        while let Some(&c) = chars.peek() {
+            if c.is_whitespace() || c == ',' {
+                chars.next();
+                if c == ',' {
+                    words.push(None);
+                }
+            } else if c == '\'' {
+                chars.next();
+                let mut literal = String::new();
+                loop {
+                    match chars.next() {
+                        Some('\'') if chars.peek() == Some(&'\'') => {
+                            chars.next();
+                            literal.push('\'');
+                        }
+                        Some('\'') => break,
+                        Some(c) => literal.push(c),
+                        None => return Err(syntax_error()),
+                    }
+                }
+                words.push(Some((literal, true)));
+            } else {
+                let mut word = String::new();
+                while let Some(&c) = chars.peek() {
+                    if c.is_whitespace() || c == ',' || c == '\'' {
+                        break;
+                    }
+                    word.push(c);
+                    chars.next();
+                }
+                words.push(Some((word, false)));
+            }
+        }
+
+        // Pair each option name with the value that follows it
+        let mut parsed = Vec::new();
+        let mut words = words.into_iter().peekable();
+        while let Some(word) = words.next() {
+            let Some((name, false)) = word else {
+                if word.is_none() {
+                    continue;
+                }
+                return Err(syntax_error());
+            };
+            let name = name.to_lowercase();
+            if matches!(words.peek(), Some(Some((word, false))) if word.eq_ignore_ascii_case("AS")) {
+                words.next();
+            }
+            let value = match words.peek() {
+                Some(Some((value, quoted))) if *quoted || !matches!(name.as_str(), "binary" | "csv" | "freeze") => {
+                    let value = value.clone();
+                    words.next();
+                    Some(value)
+                }
+                _ => None,
+            };
+            parsed.push((name, value));
+        }
+        Ok(parsed)
+    }
+
+    /// The table's stored name and the columns COPY uses, all of them in table order unless
+    /// listed, each with the oid of its PostgreSQL type
+    #[allow(clippy::type_complexity)]
+    fn table_columns(
+        conn: &Connection,
+        table: &str,
+        listed: &[String],
+        from: bool,
+    ) -> Result<Result<(String, Vec<(String, i32)>), PgError>, rusqlite::Error> {
+        let found: Option<(String, String)> = conn.query_row(
+            "SELECT name, type FROM sqlite_master WHERE type IN ('table', 'view') AND name = ?1 COLLATE NOCASE \
+             UNION ALL SELECT name, type FROM sqlite_temp_master WHERE type IN ('table', 'view') AND name = ?1 COLLATE NOCASE",
+            [table],
+            |row| Ok((row.get(0)?, row.get(1)?)),
+        ).optional()?;
+        let Some((table, kind)) = found else {
+            return Ok(Err(PgError::Generic {
+                code: "42P01".to_string(), // undefined_table
+                message: format!("relation \"{table}\" does not exist"),
+            }));
+        };
+        if kind == "view" {
+            return Ok(Err(PgError::Generic {
+                code: "42809".to_string(), // wrong_object_type
+                message: format!("cannot copy {} view \"{table}\"", if from { "to" } else { "from" }),
+            }));
+        }
+
+        let declared: Vec<(String, String)> = conn
+            .prepare(&format!("PRAGMA table_info({})", Self::quote(&table)))?
+            .query_map([], |row| Ok((row.get(1)?, row.get(2)?)))?
+            .collect::<Result<_, _>>()?;
+        let selected: Vec<&(String, String)> = if listed.is_empty() {
+            declared.iter().collect()
+        } else {
+            let mut selected = Vec::new();
+            for column in listed {
+                match declared.iter().find(|(name, _)| name.eq_ignore_ascii_case(column)) {
+                    Some(found) => selected.push(found),
+                    None => return Ok(Err(PgError::Generic {
+                        code: "42703".to_string(), // undefined_column
+                        message: format!("column \"{column}\" of relation \"{table}\" does not exist"),
+                    })),
+                }
+            }
+            selected
+        };
+
+        let mut columns = Vec::new();
+        for (column, declared_type) in selected {
+            let pg_type: Option<String> = conn.query_row(
+                "SELECT pg_type FROM __pgsqlite_schema WHERE table_name = ?1 AND column_name = ?2",
+                [&table, column],
+                |row| row.get(0),
+            ).optional().unwrap_or(None);
+            let pg_type = pg_type.unwrap_or_else(|| declared_type.clone());
+            columns.push((column.clone(), SchemaTypeMapper::pg_type_string_to_oid_with_enum_check(&pg_type, conn)));
+        }
+        Ok(Ok((table, columns)))
+    }
+
+    fn read_rows(conn: &Connection, query: &str) -> Result<(Vec<String>, Vec<Vec<Value>>), rusqlite::Error> {
+        let mut stmt = conn.prepare(query)?;
+        let names: Vec<String> = stmt.column_names().into_iter().map(String::from).collect();
+        let count = names.len();
+        let rows = stmt
+            .query_map([], |row| (0..count).map(|i| row.get::<_, Value>(i)).collect())?
+            .collect::<Result<_, _>>()?;
+        Ok((names, rows))
+    }
+
+    /// The type of a query column no type could be inferred for, from the values it holds
+    fn type_of_values(rows: &[Vec<Value>], column: usize) -> i32 {
+        let pg_type = rows.iter().find_map(|row| match &row[column] {
+            Value::Null => None,
+            Value::Integer(_) => Some(PgType::Int8),
+            Value::Real(_) => Some(PgType::Float8),
+            Value::Text(_) => Some(PgType::Text),
+            Value::Blob(_) => Some(PgType::Bytea),
+        });
+        pg_type.unwrap_or(PgType::Text).to_oid()
+    }
+
+    /// A stored value in PostgreSQL's text representation
+    fn value_to_text(value: &Value, type_oid: i32) -> Option<String> {
+        let stored = match value {
+            Value::Null => return None,
+            Value::Integer(i) if type_oid == PgType::Bool.to_oid() => {
+                return Some(if *i != 0 { "t" } else { "f" }.to_string());
+            }
+            Value::Blob(bytes) => return Some(format!("\\x{}", hex::encode(bytes))),
+            Value::Integer(i) => i.to_string(),
+            Value::Real(f) => crate::types::numeric_utils::format_float(*f),
+            Value::Text(s) => s.clone(),
+        };
+        Some(match PgType::from_oid(type_oid) {
+            Some(pg_type) => ValueConverter::sqlite_to_pg(&stored, pg_type).unwrap_or(stored),
+            None => stored,
+        })
+    }
+
+    /// One line of text format COPY data
+    fn encode_text_row(row: &[Value], types: &[i32], delimiter: char, null: &str) -> Vec<u8> {
+        let mut line = String::new();
+        for (i, value) in row.iter().enumerate() {
+            if i > 0 {
+                line.push(delimiter);
+            }
+            match Self::value_to_text(value, types[i]) {
+                None => line.push_str(null),
+                Some(text) => {
+                    for c in text.chars() {
+                        match c {
+                            '\\' => line.push_str("\\\\"),
+                            '\n' => line.push_str("\\n"),
+                            '\r' => line.push_str("\\r"),
+                            '\t' => line.push_str("\\t"),
+                            '\x08' => line.push_str("\\b"),
+                            '\x0c' => line.push_str("\\f"),
+                            '\x0b' => line.push_str("\\v"),
+                            c if c == delimiter => {
+                                line.push('\\');
+                                line.push(c);
+                            }
+                            c => line.push(c),
+                        }
+                    }
+                }
+            }
+        }
+        line.push('\n');
+        line.into_bytes()
+    }
+
+    /// One tuple of binary format COPY data: the field count, then each field's length and bytes
+    fn encode_binary_row(row: &[Value], types: &[i32]) -> Vec<u8> {
+        let mut tuple = Vec::new();
+        tuple.extend_from_slice(&(row.len() as i16).to_be_bytes());
+        for (i, value) in row.iter().enumerate() {
+            if matches!(value, Value::Null) {
+                tuple.extend_from_slice(&(-1i32).to_be_bytes());
+                continue;
+            }
+            // Types without a binary encoder send their text form, which is what text-like
+            // types look like in binary
+            let bytes = BinaryEncoder::encode_value(value, types[i], true)
+                .or_else(|| Self::value_to_text(value, types[i]).map(String::into_bytes))
+                .unwrap_or_default();
+            tuple.extend_from_slice(&(bytes.len() as i32).to_be_bytes());
+            tuple.extend_from_slice(&bytes);
+        }
+        tuple
+    }
+
+    fn decode_text_rows(data: &[u8], columns: &[(String, i32)], delimiter: char, null: &str) -> Result<Vec<Vec<Value>>, PgError> {
+        let data = std::str::from_utf8(data).map_err(|_| PgError::Generic {
+            code: "22021".to_string(), // character_not_in_repertoire
+            message: "invalid byte sequence for encoding \"UTF8\"".to_string(),
+        })?;
+
+        let mut rows = Vec::new();
+        for line in data.split('\n') {
+            let line = line.strip_suffix('\r').unwrap_or(line);
+            if line == "\\." {
+                break;
+            }
+            if line.is_empty() && columns.len() != 1 {
+                // The end of the data, or an empty line that can't be a row
+                continue;
+            }
+
+            let fields = Self::split_text_line(line, delimiter);
+            if fields.len() > columns.len() {
+                return Err(PgError::Generic {
+                    code: "22P04".to_string(), // bad_copy_file_format
+                    message: "extra data after last expected column".to_string(),
+                });
+            }
+            if fields.len() < columns.len() {
+                return Err(PgError::Generic {
+                    code: "22P04".to_string(), // bad_copy_file_format
+                    message: format!("missing data for column \"{}\"", columns[fields.len()].0),
+                });
+            }
+
+            let mut row = Vec::with_capacity(columns.len());
+            for (raw, (_, type_oid)) in fields.into_iter().zip(columns) {
+                if raw == null {
+                    row.push(Value::Null);
+                } else {
+                    row.push(Self::text_to_value(&Self::unescape_text(raw)?, *type_oid)?);
+                }
+            }
+            rows.push(row);
+        }
+        // A trailing newline leaves one empty line behind, which isn't a row
+        if columns.len() == 1 && data.ends_with('\n') && rows.last().is_some_and(|row| matches!(&row[0], Value::Text(s) if s.is_empty())) {
+            rows.pop();
+        }
+        Ok(rows)
+    }
+
+    /// Split a line on the delimiter, leaving escaped delimiters inside their field
+    fn split_text_line(line: &str, delimiter: char) -> Vec<&str> {
+        let mut fields = Vec::new();
+        let mut start = 0;
+        let mut escaped = false;
+        for (i, c) in line.char_indices() {
+            if escaped {
+                escaped = false;
+            } else if c == '\\' {
+                escaped = true;
+            } else if c == delimiter {
+                fields.push(&line[start..i]);
+                start = i + c.len_utf8();
+            }
+        }
+        fields.push(&line[start..]);
+        fields
+    }
+
+    /// Resolve the backslash escapes of a text format field
+    fn unescape_text(raw: &str) -> Result<String, PgError> {
+        if !raw.contains('\\') {
+            return Ok(raw.to_string());
+        }
+        let bytes = raw.as_bytes();
+        let mut out = Vec::with_capacity(bytes.len());
+        let mut i = 0;
+        while i < bytes.len() {
+            if bytes[i] != b'\\' || i + 1 == bytes.len() {
+                out.push(bytes[i]);
+                i += 1;
+                continue;
+            }
+            i += 1;
+            match bytes[i] {
+                b'b' => out.push(0x08),
+                b'f' => out.push(0x0c),
+                b'n' => out.push(b'\n'),
+                b'r' => out.push(b'\r'),
+                b't' => out.push(b'\t'),
+                b'v' => out.push(0x0b),
+                b'0'..=b'7' => {
+                    let digits = bytes[i..].iter().take(3).take_while(|b| (b'0'..=b'7').contains(b)).count();
+                    let value = u32::from_str_radix(&raw[i..i + digits], 8).unwrap_or(0);
+                    out.push(value as u8);
+                    i += digits - 1;
+                }
+                b'x' if bytes.get(i + 1).is_some_and(u8::is_ascii_hexdigit) => {
+                    let digits = bytes[i + 1..].iter().take(2).take_while(|b| b.is_ascii_hexdigit()).count();
+                    out.push(u8::from_str_radix(&raw[i + 1..i + 1 + digits], 16).unwrap_or(0));
+                    i += digits;
+                }
+                other => out.push(other),
+            }
+            i += 1;
+        }
+        String::from_utf8(out).map_err(|_| PgError::Generic {
+            code: "22021".to_string(), // character_not_in_repertoire
+            message: "invalid byte sequence for encoding \"UTF8\"".to_string(),
+        })
+    }
+
+    /// A value in PostgreSQL's text representation in the form SQLite stores it
+    fn text_to_value(text: &str, type_oid: i32) -> Result<Value, PgError> {
+        let pg_type = PgType::from_oid(type_oid);
+        let invalid = || PgError::Generic {
+            code: "22P02".to_string(), // invalid_text_representation
+            message: format!(
+                "invalid input syntax for type {}: \"{text}\"",
+                pg_type.map(|t| t.name()).unwrap_or("text")
+            ),
+        };
+        Ok(match pg_type {
+            Some(PgType::Bool) => match text.trim().to_lowercase().as_str() {
+                "t" | "true" | "y" | "yes" | "on" | "1" => Value::Integer(1),
+                "f" | "false" | "n" | "no" | "off" | "0" => Value::Integer(0),
+                _ => return Err(invalid()),
+            },
+            Some(PgType::Int2 | PgType::Int4 | PgType::Int8) => {
+                Value::Integer(text.trim().parse().map_err(|_| invalid())?)
+            }
+            Some(PgType::Float4 | PgType::Float8) => {
+                Value::Real(text.trim().parse().map_err(|_| invalid())?)
+            }
+            Some(PgType::Bytea) => match text.strip_prefix("\\x") {
+                Some(digits) => Value::Blob(hex::decode(digits).map_err(|_| invalid())?),
+                None => Value::Blob(text.as_bytes().to_vec()),
+            },
+            Some(PgType::Numeric) => {
+                DecimalHandler::validate_numeric_string(text).map_err(|_| invalid())?;
+                Value::Text(text.to_string())
+            }
+            Some(pg_type @ (PgType::Date | PgType::Time | PgType::Timetz | PgType::Timestamp
+                | PgType::Timestamptz | PgType::Interval)) => {
+                // Stored as INTEGER days or microseconds, or canonical text for intervals with months
+                let stored = ValueConverter::pg_to_sqlite(text, pg_type).map_err(|_| invalid())?;
+                match stored.parse::<i64>() {
+                    Ok(i) => Value::Integer(i),
+                    Err(_) => Value::Text(stored),
+                }
+            }
+            Some(pg_type) => Value::Text(ValueConverter::pg_to_sqlite(text, pg_type).map_err(|_| invalid())?),
+            None => Value::Text(text.to_string()),
+        })
+    }
+
+    fn decode_binary_rows(data: &[u8], types: &[i32]) -> Result<Vec<Vec<Value>>, PgError> {
+        let bad_format = |message: &str| PgError::Generic {
+            code: "22P04".to_string(), // bad_copy_file_format
+            message: message.to_string(),
+        };
+        let mut data = data.strip_prefix(BINARY_SIGNATURE)
+            .ok_or_else(|| bad_format("COPY file signature not recognized"))?;
+
+        let flags = Self::take_i32(&mut data)?;
+        if flags & (1 << 16) != 0 {
+            return Err(bad_format("invalid COPY file header (WITH OIDS)"));
+        }
+        if flags & !0xffff & !(1 << 16) != 0 {
+            return Err(bad_format("unrecognized critical flags in COPY file header"));
+        }
+        let extension = Self::take_i32(&mut data)?;
+        Self::take(&mut data, usize::try_from(extension).map_err(|_| bad_format("invalid COPY file header (wrong length)"))?)?;
+
+        let mut rows = Vec::new();
+        loop {
+            let count = i16::from_be_bytes(Self::take(&mut data, 2)?.try_into().unwrap());
+            if count == -1 {
+                break;
+            }
+            if count as usize != types.len() {
+                return Err(bad_format(&format!("row field count is {count}, expected {}", types.len())));
+            }
+            let mut row = Vec::with_capacity(types.len());
+            for type_oid in types {
+                let length = Self::take_i32(&mut data)?;
+                if length == -1 {
+                    row.push(Value::Null);
+                    continue;
+                }
+                let length = usize::try_from(length).map_err(|_| bad_format("invalid field size"))?;
+                row.push(Self::binary_to_value(Self::take(&mut data, length)?, *type_oid)?);
+            }
+            rows.push(row);
+        }
+        Ok(rows)
+    }
+
+    fn take<'a>(data: &mut &'a [u8], length: usize) -> Result<&'a [u8], PgError> {
+        if data.len() < length {
+            return Err(PgError::Generic {
+                code: "22P04".to_string(), // bad_copy_file_format
+                message: "unexpected EOF in COPY data".to_string(),
+            });
+        }
+        let (taken, rest) = data.split_at(length);
+        *data = rest;
+        Ok(taken)
+    }
+
+    fn take_i32(data: &mut &[u8]) -> Result<i32, PgError> {
+        Ok(i32::from_be_bytes(Self::take(data, 4)?.try_into().unwrap()))
+    }
+
+    /// A value in PostgreSQL's binary representation in the form SQLite stores it
+    fn binary_to_value(bytes: &[u8], type_oid: i32) -> Result<Value, PgError> {
+        let pg_type = PgType::from_oid(type_oid);
+        let invalid = || PgError::Generic {
+            code: "22P03".to_string(), // invalid_binary_representation
+            message: format!("incorrect binary data format for type {}", pg_type.map(|t| t.name()).unwrap_or("unknown")),
+        };
+        let int = |bytes: &[u8]| -> Result<i64, PgError> {
+            Ok(match bytes.len() {
+                2 => i16::from_be_bytes(bytes.try_into().unwrap()) as i64,
+                4 => i32::from_be_bytes(bytes.try_into().unwrap()) as i64,
+                8 => i64::from_be_bytes(bytes.try_into().unwrap()),
+                _ => return Err(invalid()),
+            })
+        };
+        Ok(match pg_type {
+            Some(PgType::Bool) if bytes.len() == 1 => Value::Integer((bytes[0] != 0) as i64),
+            Some(PgType::Int2 | PgType::Int4 | PgType::Int8) => Value::Integer(int(bytes)?),
+            Some(PgType::Float4) if bytes.len() == 4 => Value::Real(f32::from_be_bytes(bytes.try_into().unwrap()) as f64),
+            Some(PgType::Float8) if bytes.len() == 8 => Value::Real(f64::from_be_bytes(bytes.try_into().unwrap())),
+            Some(PgType::Numeric) => Value::Text(DecimalHandler::decode_numeric(bytes).map_err(|_| invalid())?.to_string()),
+            Some(PgType::Bytea) => Value::Blob(bytes.to_vec()),
+            Some(PgType::Date) if bytes.len() == 4 => Value::Integer(int(bytes)? + PG_EPOCH_OFFSET_DAYS),
+            Some(PgType::Time) if bytes.len() == 8 => Value::Integer(int(bytes)?),
+            Some(PgType::Timestamp | PgType::Timestamptz) if bytes.len() == 8 => {
+                Value::Integer(int(bytes)? + PG_EPOCH_OFFSET_MICROS)
+            }
+            Some(PgType::Interval) if bytes.len() == 16 => {
+                let micros = int(&bytes[..8])?;
+                let days = int(&bytes[8..12])?;
+                let months = int(&bytes[12..])?;
+                Value::Text(crate::types::datetime_utils::format_interval(months as i32, days as i32, micros))
+            }
+            Some(PgType::Uuid) if bytes.len() == 16 => {
+                Value::Text(uuid::Uuid::from_slice(bytes).map_err(|_| invalid())?.to_string())
+            }
+            Some(PgType::Jsonb) => match bytes.split_first() {
+                Some((1, json)) => Value::Text(String::from_utf8(json.to_vec()).map_err(|_| invalid())?),
+                _ => return Err(invalid()),
+            },
+            Some(PgType::Bool | PgType::Float4 | PgType::Float8 | PgType::Date | PgType::Time
+                | PgType::Timestamp | PgType::Timestamptz | PgType::Interval | PgType::Uuid) => return Err(invalid()),
+            // Text-like types send their text in binary too
+            _ => match std::str::from_utf8(bytes) {
+                Ok(text) => Self::text_to_value(text, type_oid)?,
+                Err(_) => return Err(PgError::Generic {
+                    code: "0A000".to_string(), // feature_not_supported
+                    message: format!(
+                        "binary COPY is not supported for type {}",
+                        SchemaTypeMapper::pg_oid_to_type_name(type_oid)
+                    ),
+                }),
+            },
+        })
+    }
+
+    /// Insert the rows under a savepoint, so a failing row inserts none of them
+    fn insert_rows(conn: &Connection, table: &str, columns: &[String], rows: &[Vec<Value>]) -> Result<usize, rusqlite::Error> {
+        let insert = format!(
+            "INSERT INTO {} ({}) VALUES ({})",
+            Self::quote(table),
+            columns.iter().map(|column| Self::quote(column)).collect::<Vec<_>>().join(", "),
+            (1..=columns.len()).map(|i| format!("?{i}")).collect::<Vec<_>>().join(", ")
+        );
+        conn.execute_batch("SAVEPOINT __pgsqlite_copy")?;
+        let result = conn.prepare(&insert).and_then(|mut stmt| {
+            for row in rows {
+                stmt.execute(rusqlite::params_from_iter(row))?;
+            }
+            Ok(rows.len())
+        });
+        match result {
+            Ok(count) => {
+                conn.execute_batch("RELEASE __pgsqlite_copy")?;
+                Ok(count)
+            }
+            Err(e) => {
+                if let Err(rollback_err) = conn.execute_batch("ROLLBACK TO __pgsqlite_copy; RELEASE __pgsqlite_copy") {
+                    debug!("Failed to roll back COPY: {}", rollback_err);
+                }
+                Err(e)
+            }
+        }
+    }
+
+    /// Strip a schema prefix and quotes from a table name
+    fn table_name(reference: &str) -> String {
+        let name = match reference.strip_suffix('"').and_then(|r| r.rfind('"')) {
+            Some(start) => &reference[start..],
+            None => reference.rsplit('.').next().unwrap_or(reference),
+        };
+        Self::unquote(name)
+    }
+
+    fn unquote(identifier: &str) -> String {
+        match identifier.strip_prefix('"').and_then(|s| s.strip_suffix('"')) {
+            Some(inner) => inner.replace("\"\"", "\""),
+            None => identifier.to_string(),
+        }
+    }
+
+    fn quote(identifier: &str) -> String {
+        format!("\"{}\"", identifier.replace('"', "\"\""))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_copy() {
+        let statement = CopyHandler::parse("COPY items (id, \"Name\") FROM STDIN WITH (FORMAT binary)").unwrap();
+        assert_eq!(statement.source, CopySource::Table {
+            name: "items".to_string(),
+            columns: vec!["id".to_string(), "Name".to_string()],
+        });
+        assert!(statement.from);
+        assert_eq!(statement.format, CopyFormat::Binary);
+
+        let statement = CopyHandler::parse("copy (SELECT id FROM items WHERE (id > 1)) to stdout;").unwrap();
+        assert_eq!(statement.source, CopySource::Query("SELECT id FROM items WHERE (id > 1)".to_string()));
+        assert!(!statement.from);
+        assert_eq!(statement.format, CopyFormat::Text);
+
+        let statement = CopyHandler::parse("COPY public.items TO STDOUT WITH DELIMITER AS '|' NULL 'none'").unwrap();
+        assert_eq!(statement.delimiter, '|');
+        assert_eq!(statement.null, "none");
+        assert_eq!(CopyHandler::parse("COPY items TO STDOUT BINARY").unwrap().format, CopyFormat::Binary);
+
+        assert!(CopyHandler::parse("COPY items FROM '/tmp/items.dat'").is_err());
+        assert!(CopyHandler::parse("COPY items TO STDOUT (FORMAT csv)").is_err());
+        assert!(CopyHandler::parse("COPY items TO STDOUT (COLOR red)").is_err());
+    }
+
+    #[test]
+    fn test_text_format() {
+        let types = [PgType::Int4.to_oid(), PgType::Text.to_oid(), PgType::Bool.to_oid()];
+        let row = vec![Value::Integer(7), Value::Text("tab\there\\ \nnew".to_string()), Value::Null];
+        let line = CopyHandler::encode_text_row(&row, &types, '\t', "\\N");
+        assert_eq!(line, b"7\ttab\\there\\\\ \\nnew\t\\N\n");
+
+        let columns: Vec<(String, i32)> = ["id", "note", "done"].iter().map(|c| c.to_string()).zip(types).collect();
+        let rows = CopyHandler::decode_text_rows(&line, &columns, '\t', "\\N").unwrap();
+        assert_eq!(rows, vec![row]);
+
+        let rows = CopyHandler::decode_text_rows(b"1\t\\101\\x42\tt\r\n\\.\n", &columns, '\t', "\\N").unwrap();
+        assert_eq!(rows, vec![vec![Value::Integer(1), Value::Text("AB".to_string()), Value::Integer(1)]]);
+
+        assert!(CopyHandler::decode_text_rows(b"1\tx\n", &columns, '\t', "\\N").is_err());
+        assert!(CopyHandler::decode_text_rows(b"1\tx\tt\textra\n", &columns, '\t', "\\N").is_err());
+        assert!(CopyHandler::decode_text_rows(b"one\tx\tt\n", &columns, '\t', "\\N").is_err());
+    }
+
+    #[test]
+    fn test_binary_format() {
+        let types = [PgType::Int8.to_oid(), PgType::Float8.to_oid(), PgType::Timestamp.to_oid(), PgType::Bytea.to_oid()];
+        let rows = vec![
+            vec![Value::Integer(1), Value::Real(2.5), Value::Integer(1_700_000_000_123_456), Value::Blob(vec![0, 255])],
+            vec![Value::Integer(-9), Value::Null, Value::Null, Value::Null],
+        ];
+
+        let mut data = BINARY_SIGNATURE.to_vec();
+        data.extend_from_slice(&[0; 8]);
+        for row in &rows {
+            data.extend(CopyHandler::encode_binary_row(row, &types));
+        }
+        data.extend_from_slice(&(-1i16).to_be_bytes());
+        assert_eq!(CopyHandler::decode_binary_rows(&data, &types).unwrap(), rows);
+
+        assert!(CopyHandler::decode_binary_rows(b"PGCOPY\n", &types).is_err());
+        assert!(CopyHandler::decode_binary_rows(&data[..data.len() - 3], &types).is_err());
+        assert!(CopyHandler::decode_binary_rows(&data, &types[..3]).is_err());
+    }
+}
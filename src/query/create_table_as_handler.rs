@@ -0,0 +1,454 @@
+use crate::error::PgError;
+use crate::protocol::BackendMessage;
+use crate::session::{DbHandler, SessionState};
+use crate::translator::PgTypeofTranslator;
+use crate::types::TypeMapper;
+use std::sync::Arc;
+use crate::PgSqliteError;
+use rusqlite::{Connection, OptionalExtension};
+use sqlparser::ast::{Expr, FunctionArg, FunctionArgExpr, FunctionArguments, SelectItem, SetExpr, Statement, Value};
+use sqlparser::dialect::PostgreSqlDialect;
+use sqlparser::parser::Parser;
+use tokio_util::codec::Framed;
+use futures::SinkExt;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+
+static CREATE_TABLE_AS_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r#"(?is)^\s*CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:(TEMP|TEMPORARY)\s+|UNLOGGED\s+)?TABLE\s+(IF\s+NOT\s+EXISTS\s+)?((?:"[^"]+"|\w+)(?:\.(?:"[^"]+"|\w+))?)\s*(?:\(([^()]*)\)\s*)?AS\s+((?:SELECT\b|WITH\b|VALUES\b|\().*?)(?:\s+WITH\s+(NO\s+)?DATA)?\s*;?\s*$"#
+    ).unwrap()
+});
+
+static SELECT_INTO_TARGET_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^INTO\s+(?:(TEMP|TEMPORARY)\s+|UNLOGGED\s+)?(?:TABLE\s+)?((?:"[^"]+"|\w+)(?:\.(?:"[^"]+"|\w+))?)"#).unwrap()
+});
+
+/// A parsed CREATE TABLE ... AS or SELECT ... INTO statement
+#[derive(Debug, PartialEq)]
+struct CreateTableAs {
+    table: String,
+    temporary: bool,
+    if_not_exists: bool,
+    columns: Vec<String>,
+    query: String,
+    with_data: bool,
+}
+
+pub struct CreateTableAsHandler;
+
+impl CreateTableAsHandler {
+    /// Check if this is CREATE TABLE ... AS or SELECT ... INTO
+    pub fn is_create_table_as(query: &str) -> bool {
+        let Some(first_word) = query.split_whitespace().next() else {
+            return false;
+        };
+        if first_word.eq_ignore_ascii_case("CREATE") {
+            CREATE_TABLE_AS_PATTERN.is_match(query)
+        } else if first_word.eq_ignore_ascii_case("SELECT") || first_word.eq_ignore_ascii_case("WITH") {
+            query.as_bytes().windows(4).any(|w| w.eq_ignore_ascii_case(b"INTO")) && Self::parse(query).is_some()
+        } else {
+            false
+        }
+    }
+
+    /// Handle CREATE [TEMP] TABLE [IF NOT EXISTS] name [(columns)] AS query [WITH [NO] DATA]
+    /// and its older spelling SELECT ... INTO [TEMP] [TABLE] name FROM ...
+    ///
+    /// The defining query is translated like any other and run by SQLite's own CREATE TABLE
+    /// AS. SQLite only keeps type affinities for the new columns, so their PostgreSQL types
+    /// are inferred from the select list and stored in __pgsqlite_schema, letting later
+    /// SELECTs encode them like the source columns.
+    pub async fn handle_create_table_as<T>(
+        framed: &mut Framed<T, crate::protocol::PostgresCodec>,
+        db: &Arc<DbHandler>,
+        session: &Arc<SessionState>,
+        query: &str,
+    ) -> Result<(), PgSqliteError>
+    where
+        T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
+    {
+        let statement = Self::parse(query)
+            .ok_or_else(|| PgError::SyntaxError {
+                message: "syntax error at or near \"AS\"".to_string(),
+                position: None,
+            })?;
+        debug!("CREATE TABLE AS: {:?}", statement);
+
+        let schema_cache = db.get_schema_cache();
+        let rows = db.with_session_connection(&session.id, |conn| {
+            Self::create_table(conn, &statement, schema_cache)
+        }).await??;
+        crate::query::executor::invalidate_all_schema_cache();
+
+        // PostgreSQL reports the rows written, or CREATE TABLE AS when IF NOT EXISTS skipped it
+        let tag = match rows {
+            Some(rows) => format!("SELECT {rows}"),
+            None => "CREATE TABLE AS".to_string(),
+        };
+        framed.send(BackendMessage::CommandComplete { tag }).await
+            .map_err(PgSqliteError::Io)?;
+
+        Ok(())
+    }
+
+    fn parse(query: &str) -> Option<CreateTableAs> {
+        if let Some(caps) = CREATE_TABLE_AS_PATTERN.captures(query) {
+            let columns = caps.get(4)
+                .map(|m| m.as_str().split(',').map(|c| Self::unquote(c.trim())).filter(|c| !c.is_empty()).collect())
+                .unwrap_or_default();
+            return Some(CreateTableAs {
+                table: Self::table_name(&caps[3]),
+                temporary: caps.get(1).is_some(),
+                if_not_exists: caps.get(2).is_some(),
+                columns,
+                query: caps[5].trim().to_string(),
+                with_data: caps.get(6).is_none(),
+            });
+        }
+
+        // SELECT INTO: the INTO clause is cut out of the query
+        let query = query.trim().trim_end_matches(';').trim_end();
+        let into = Self::find_top_level_into(query)?;
+        let caps = SELECT_INTO_TARGET_PATTERN.captures(&query[into..])?;
+        let end = into + caps.get(0)?.end();
+        Some(CreateTableAs {
+            table: Self::table_name(&caps[2]),
+            temporary: caps.get(1).is_some(),
+            if_not_exists: false,
+            columns: Vec::new(),
+            query: format!("{} {}", query[..into].trim_end(), query[end..].trim_start()).trim_end().to_string(),
+            with_data: true,
+        })
+    }
+
+    /// Find the INTO keyword outside of strings, quoted identifiers and parentheses
+    fn find_top_level_into(query: &str) -> Option<usize> {
+        let bytes = query.as_bytes();
+        let mut depth = 0;
+        let mut i = 0;
+        while i < bytes.len() {
+            match bytes[i] {
+                quote @ (b'\'' | b'"') => {
+                    i += 1;
+                    while i < bytes.len() && bytes[i] != quote {
+                        i += 1;
+                    }
+                }
+                b'(' => depth += 1,
+                b')' => depth -= 1,
+                _ if depth == 0
+                    && bytes[i..].len() > 4
+                    && bytes[i..i + 4].eq_ignore_ascii_case(b"INTO")
+                    && bytes[i + 4].is_ascii_whitespace()
+                    && (i == 0 || !(bytes[i - 1].is_ascii_alphanumeric() || bytes[i - 1] == b'_')) => return Some(i),
+                _ => {}
+            }
+            i += 1;
+        }
+        None
+    }
+
+    /// Create and fill the table; None when IF NOT EXISTS found it already there
+    fn create_table(
+        conn: &Connection,
+        statement: &CreateTableAs,
+        schema_cache: &crate::cache::SchemaCache,
+    ) -> Result<Result<Option<i64>, PgError>, rusqlite::Error> {
+        let existing: Option<String> = conn.query_row(
+            "SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?1 COLLATE NOCASE \
+             UNION ALL SELECT name FROM sqlite_temp_master WHERE type = 'table' AND name = ?1 COLLATE NOCASE",
+            [&statement.table],
+            |row| row.get(0),
+        ).optional()?;
+        if existing.is_some() {
+            if statement.if_not_exists {
+                debug!("relation \"{}\" already exists, skipping", statement.table);
+                return Ok(Ok(None));
+            }
+            return Ok(Err(PgError::Generic {
+                code: "42P07".to_string(), // duplicate_table
+                message: format!("relation \"{}\" already exists", statement.table),
+            }));
+        }
+
+        let mut body = crate::query::process_query(&statement.query, conn, schema_cache)?;
+        if !statement.columns.is_empty() {
+            let columns: Vec<String> = statement.columns.iter().map(|c| Self::quote(c)).collect();
+            body = format!("WITH __pgsqlite_ctas({}) AS ({body}) SELECT * FROM __pgsqlite_ctas", columns.join(", "));
+        }
+        if !statement.with_data {
+            body = format!("SELECT * FROM ({body}) LIMIT 0");
+        }
+        let create = format!(
+            "CREATE {}TABLE {} AS {body}",
+            if statement.temporary { "TEMP " } else { "" },
+            Self::quote(&statement.table)
+        );
+        debug!("Executing: {}", create);
+        conn.execute(&create, [])?;
+
+        Self::store_column_types(conn, statement)?;
+        if let Err(e) = crate::catalog::constraint_populator::populate_constraints_for_table(conn, &statement.table) {
+            debug!("Failed to populate constraints for table {}: {}", statement.table, e);
+        }
+
+        let rows = conn.query_row(&format!("SELECT count(*) FROM {}", Self::quote(&statement.table)), [], |row| row.get(0))?;
+        Ok(Ok(Some(rows)))
+    }
+
+    /// Record the PostgreSQL type of each new column in __pgsqlite_schema
+    fn store_column_types(conn: &Connection, statement: &CreateTableAs) -> Result<(), rusqlite::Error> {
+        let columns: Vec<(String, String)> = conn
+            .prepare(&format!("PRAGMA table_info({})", Self::quote(&statement.table)))?
+            .query_map([], |row| Ok((row.get(1)?, row.get(2)?)))?
+            .collect::<Result<_, _>>()?;
+
+        let names: Vec<String> = columns.iter().map(|(name, _)| name.clone()).collect();
+        let inferred = Self::query_column_types(conn, &statement.query, &names);
+
+        conn.execute(
+            "CREATE TABLE IF NOT EXISTS __pgsqlite_schema (
+                table_name TEXT NOT NULL,
+                column_name TEXT NOT NULL,
+                pg_type TEXT NOT NULL,
+                sqlite_type TEXT NOT NULL,
+                PRIMARY KEY (table_name, column_name)
+            )",
+            [],
+        )?;
+        let mapper = TypeMapper::new();
+        for (i, (column, declared)) in columns.iter().enumerate() {
+            let pg_type = inferred[i].clone()
+                .unwrap_or_else(|| Self::type_from_affinity(declared).to_string());
+            debug!("CREATE TABLE AS column {}.{} -> {}", statement.table, column, pg_type);
+            conn.execute(
+                "INSERT OR REPLACE INTO __pgsqlite_schema (table_name, column_name, pg_type, sqlite_type) VALUES (?1, ?2, ?3, ?4)",
+                [&statement.table, column, &pg_type, &mapper.pg_to_sqlite_for_create_table(&pg_type)],
+            )?;
+        }
+        Ok(())
+    }
+
+    /// The PostgreSQL type of each result column of a query, where the select list or the
+    /// source columns tell it. Columns selected through * keep the type of the source column
+    /// of that name.
+    pub(crate) fn query_column_types(conn: &Connection, query: &str, columns: &[String]) -> Vec<Option<String>> {
+        let sources = PgTypeofTranslator::extract_table_refs(query);
+        let (inferred, has_wildcard) = Self::infer_select_types(conn, query, &sources);
+        columns.iter().enumerate().map(|(i, column)| {
+            inferred.get(i).cloned().flatten()
+                .or_else(|| has_wildcard.then(|| Self::source_column_type(conn, &sources, None, column)).flatten())
+        }).collect()
+    }
+
+    /// The PostgreSQL type of each select list item, where it can be told from the
+    /// expression; the flag reports a `*` in the list, which stops positional matching
+    fn infer_select_types(conn: &Connection, query: &str, sources: &[(String, Option<String>)]) -> (Vec<Option<String>>, bool) {
+        let Ok(statements) = Parser::parse_sql(&PostgreSqlDialect {}, query) else {
+            return (Vec::new(), false);
+        };
+        let [Statement::Query(parsed)] = statements.as_slice() else {
+            return (Vec::new(), false);
+        };
+        let mut body = parsed.body.as_ref();
+        loop {
+            match body {
+                SetExpr::SetOperation { left, .. } => body = left.as_ref(),
+                SetExpr::Query(query) => body = query.body.as_ref(),
+                _ => break,
+            }
+        }
+        let SetExpr::Select(select) = body else {
+            return (Vec::new(), false);
+        };
+
+        let mut types = Vec::new();
+        for item in &select.projection {
+            match item {
+                SelectItem::UnnamedExpr(expr) | SelectItem::ExprWithAlias { expr, .. } => {
+                    types.push(Self::expr_type(conn, expr, sources));
+                }
+                SelectItem::Wildcard(_) | SelectItem::QualifiedWildcard(..) => return (Vec::new(), true),
+            }
+        }
+        (types, false)
+    }
+
+    fn expr_type(conn: &Connection, expr: &Expr, sources: &[(String, Option<String>)]) -> Option<String> {
+        match expr {
+            Expr::Identifier(ident) => Self::source_column_type(conn, sources, None, &ident.value),
+            Expr::CompoundIdentifier(parts) if parts.len() >= 2 => {
+                let qualifier = &parts[parts.len() - 2].value;
+                Self::source_column_type(conn, sources, Some(qualifier), &parts[parts.len() - 1].value)
+            }
+            Expr::Nested(inner) => Self::expr_type(conn, inner, sources),
+            Expr::Cast { data_type, .. } => Some(data_type.to_string().to_uppercase()),
+            Expr::Value(value) => match &value.value {
+                Value::Number(n, _) => Some(match n.parse::<i64>() {
+                    Ok(i) if i32::try_from(i).is_ok() => "INTEGER",
+                    Ok(_) => "BIGINT",
+                    Err(_) => "NUMERIC",
+                }.to_string()),
+                Value::SingleQuotedString(_) => Some("TEXT".to_string()),
+                Value::Boolean(_) => Some("BOOLEAN".to_string()),
+                _ => None,
+            },
+            Expr::Function(func) => {
+                let argument = match &func.args {
+                    FunctionArguments::List(list) => match list.args.first() {
+                        Some(FunctionArg::Unnamed(FunctionArgExpr::Expr(arg))) => Self::expr_type(conn, arg, sources),
+                        _ => None,
+                    },
+                    _ => None,
+                };
+                let argument = argument.map(|t| t.to_uppercase());
+                match func.name.to_string().to_lowercase().as_str() {
+                    "count" => Some("BIGINT".to_string()),
+                    "sum" => Some(match argument.as_deref() {
+                        Some("SMALLINT" | "INT2" | "INTEGER" | "INT" | "INT4" | "SERIAL") => "BIGINT",
+                        Some("REAL" | "FLOAT4") => "REAL",
+                        Some("DOUBLE PRECISION" | "FLOAT8" | "FLOAT") => "DOUBLE PRECISION",
+                        _ => "NUMERIC",
+                    }.to_string()),
+                    "avg" => Some(match argument.as_deref() {
+                        Some("REAL" | "FLOAT4" | "DOUBLE PRECISION" | "FLOAT8" | "FLOAT") => "DOUBLE PRECISION",
+                        _ => "NUMERIC",
+                    }.to_string()),
+                    "min" | "max" => argument,
+                    _ => None,
+                }
+            }
+            _ => None,
+        }
+    }
+
+    /// Look a column up in the tables the query reads from, optionally by table name or alias
+    fn source_column_type(
+        conn: &Connection,
+        sources: &[(String, Option<String>)],
+        qualifier: Option<&str>,
+        column: &str,
+    ) -> Option<String> {
+        sources.iter()
+            .filter(|(table, alias)| qualifier.is_none_or(|q| {
+                table.eq_ignore_ascii_case(q) || alias.as_deref().is_some_and(|a| a.eq_ignore_ascii_case(q))
+            }))
+            .find_map(|(table, _)| {
+                conn.query_row(
+                    "SELECT pg_type FROM __pgsqlite_schema WHERE table_name = ?1 AND column_name = ?2",
+                    [table, column],
+                    |row| row.get::<_, String>(0),
+                ).ok()
+            })
+            // The copied values carry no sequence
+            .map(|pg_type| match pg_type.to_uppercase().as_str() {
+                "SERIAL" | "SERIAL4" => "INTEGER".to_string(),
+                "BIGSERIAL" | "SERIAL8" => "BIGINT".to_string(),
+                "SMALLSERIAL" | "SERIAL2" => "SMALLINT".to_string(),
+                _ => pg_type,
+            })
+    }
+
+    /// Fall back on the affinity SQLite gave the column
+    fn type_from_affinity(declared: &str) -> &'static str {
+        match declared.to_uppercase().as_str() {
+            "INT" | "INTEGER" => "INTEGER",
+            "REAL" => "DOUBLE PRECISION",
+            "NUM" | "NUMERIC" => "NUMERIC",
+            "BLOB" => "BYTEA",
+            _ => "TEXT",
+        }
+    }
+
+    /// Strip a schema prefix and quotes from a table name
+    fn table_name(reference: &str) -> String {
+        let name = match reference.strip_suffix('"').and_then(|r| r.rfind('"')) {
+            Some(start) => &reference[start..],
+            None => reference.rsplit('.').next().unwrap_or(reference),
+        };
+        Self::unquote(name)
+    }
+
+    fn unquote(identifier: &str) -> String {
+        match identifier.strip_prefix('"').and_then(|s| s.strip_suffix('"')) {
+            Some(inner) => inner.replace("\"\"", "\""),
+            None => identifier.to_string(),
+        }
+    }
+
+    fn quote(identifier: &str) -> String {
+        format!("\"{}\"", identifier.replace('"', "\"\""))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_create_table_as() {
+        let statement = CreateTableAsHandler::parse(
+            "CREATE TEMP TABLE IF NOT EXISTS top_books (title, sold) AS SELECT title, sum(qty) FROM sales GROUP BY title WITH NO DATA;"
+        ).unwrap();
+        assert_eq!(statement, CreateTableAs {
+            table: "top_books".to_string(),
+            temporary: true,
+            if_not_exists: true,
+            columns: vec!["title".to_string(), "sold".to_string()],
+            query: "SELECT title, sum(qty) FROM sales GROUP BY title".to_string(),
+            with_data: false,
+        });
+
+        let statement = CreateTableAsHandler::parse("CREATE TABLE public.\"Recent\" AS WITH r AS (SELECT 1) SELECT * FROM r").unwrap();
+        assert_eq!(statement.table, "Recent");
+        assert_eq!(statement.query, "WITH r AS (SELECT 1) SELECT * FROM r");
+        assert!(statement.with_data);
+
+        assert!(!CreateTableAsHandler::is_create_table_as("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT)"));
+        assert!(!CreateTableAsHandler::is_create_table_as(
+            "CREATE TABLE t (id INTEGER GENERATED ALWAYS AS IDENTITY, total INTEGER GENERATED ALWAYS AS (id * 2) STORED)"
+        ));
+    }
+
+    #[test]
+    fn test_parse_select_into() {
+        let statement = CreateTableAsHandler::parse(
+            "SELECT title, count(*) AS n INTO TEMP TABLE counts FROM sales WHERE note <> 'into x' GROUP BY title"
+        ).unwrap();
+        assert_eq!(statement.table, "counts");
+        assert!(statement.temporary);
+        assert_eq!(statement.query, "SELECT title, count(*) AS n FROM sales WHERE note <> 'into x' GROUP BY title");
+
+        // INTO inside strings or subqueries is not a SELECT INTO
+        assert!(!CreateTableAsHandler::is_create_table_as("SELECT 'select into x' FROM t"));
+        assert!(!CreateTableAsHandler::is_create_table_as("SELECT (SELECT 1) AS into_count FROM t"));
+        assert!(!CreateTableAsHandler::is_create_table_as("INSERT INTO t SELECT 1"));
+    }
+
+    #[test]
+    fn test_infer_column_types() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT);
+             INSERT INTO __pgsqlite_schema VALUES ('sales', 'title', 'VARCHAR(100)', 'TEXT');
+             INSERT INTO __pgsqlite_schema VALUES ('sales', 'qty', 'INTEGER', 'INTEGER');
+             INSERT INTO __pgsqlite_schema VALUES ('sales', 'price', 'NUMERIC(10,2)', 'DECIMAL');
+             INSERT INTO __pgsqlite_schema VALUES ('sales', 'sold_on', 'DATE', 'INTEGER');"
+        ).unwrap();
+
+        let query = "SELECT s.title, count(*), sum(qty), sum(price), avg(qty), max(sold_on), qty::text, 1 FROM sales s GROUP BY s.title";
+        let sources = PgTypeofTranslator::extract_table_refs(query);
+        let (types, has_wildcard) = CreateTableAsHandler::infer_select_types(&conn, query, &sources);
+        assert!(!has_wildcard);
+        let types: Vec<Option<&str>> = types.iter().map(Option::as_deref).collect();
+        assert_eq!(types, vec![
+            Some("VARCHAR(100)"), Some("BIGINT"), Some("BIGINT"), Some("NUMERIC"),
+            Some("NUMERIC"), Some("DATE"), Some("TEXT"), Some("INTEGER"),
+        ]);
+
+        let (_, has_wildcard) = CreateTableAsHandler::infer_select_types(&conn, "SELECT * FROM sales", &sources);
+        assert!(has_wildcard);
+    }
+}
@@ -0,0 +1,120 @@
+//! DO anonymous code blocks
+//!
+//! Migrations often wrap plain statements in `DO $$ BEGIN ... END $$`. pgsqlite has no
+//! PL/pgSQL interpreter, so a block whose body is just a sequence of SQL statements is run
+//! statement by statement; control flow, variables and exception handlers are rejected.
+
+use crate::error::PgError;
+use crate::query::split_statements;
+use crate::PgSqliteError;
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+/// DO [LANGUAGE lang] 'body' [LANGUAGE lang]; dollar quotes are already rewritten as
+/// standard strings when this runs
+static DO_BLOCK_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?is)^\s*DO\s+(?:LANGUAGE\s+(\w+)\s+)?'((?:[^']|'')*)'\s*(?:LANGUAGE\s+(\w+)\s*)?;?\s*$").unwrap()
+});
+
+static PLPGSQL_BLOCK_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?is)^\s*BEGIN\b(.*)\bEND\s*;?\s*$").unwrap()
+});
+
+/// First words of PL/pgSQL statements that need an interpreter
+const PLPGSQL_KEYWORDS: &[&str] = &[
+    "DECLARE", "BEGIN", "IF", "ELSIF", "ELSE", "CASE", "LOOP", "WHILE", "FOR", "FOREACH",
+    "EXIT", "CONTINUE", "RETURN", "RAISE", "PERFORM", "EXECUTE", "GET", "ASSERT", "EXCEPTION",
+    "OPEN", "FETCH", "CLOSE", "CALL",
+];
+
+/// Check if the query is a DO block
+pub fn is_do_block(query: &str) -> bool {
+    query.trim_start().get(..3).is_some_and(|s| s.eq_ignore_ascii_case("DO ") || s.eq_ignore_ascii_case("DO\n"))
+}
+
+/// The SQL statements making up the body of a DO block
+pub fn do_block_statements(query: &str) -> Result<Vec<String>, PgSqliteError> {
+    let caps = DO_BLOCK_REGEX.captures(query).ok_or_else(|| PgError::SyntaxError {
+        message: "syntax error at or near \"DO\"".to_string(),
+        position: None,
+    })?;
+
+    let language = caps.get(1).or(caps.get(3)).map_or("plpgsql", |m| m.as_str()).to_lowercase();
+    let body = caps[2].replace("''", "'");
+
+    let body = match language.as_str() {
+        "plpgsql" => match PLPGSQL_BLOCK_REGEX.captures(&body) {
+            Some(block) => block[1].to_string(),
+            None => return Err(unsupported("DO blocks must consist of a BEGIN ... END block")),
+        },
+        "sql" => body,
+        other => return Err(PgError::Generic {
+            code: "42704".to_string(), // undefined_object
+            message: format!("language \"{other}\" does not exist"),
+        }.into()),
+    };
+
+    let statements: Vec<String> = split_statements(&body).into_iter()
+        // NULL; is PL/pgSQL's empty statement
+        .filter(|stmt| !stmt.eq_ignore_ascii_case("NULL"))
+        .map(str::to_string)
+        .collect();
+
+    for statement in &statements {
+        let first_word = statement.split(|c: char| !c.is_alphanumeric() && c != '_').next().unwrap_or("");
+        if PLPGSQL_KEYWORDS.iter().any(|k| k.eq_ignore_ascii_case(first_word)) || statement.contains(":=") {
+            return Err(unsupported(&format!(
+                "PL/pgSQL statement is not supported in DO blocks: {}",
+                statement.split_whitespace().take(4).collect::<Vec<_>>().join(" ")
+            )));
+        }
+    }
+
+    Ok(statements)
+}
+
+fn unsupported(message: &str) -> PgSqliteError {
+    PgError::Generic {
+        code: "0A000".to_string(), // feature_not_supported
+        message: message.to_string(),
+    }.into()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_do_block_statements() {
+        assert!(is_do_block("DO 'BEGIN END'"));
+        assert!(!is_do_block("DOUBLE"));
+
+        assert_eq!(
+            do_block_statements("DO 'BEGIN INSERT INTO t VALUES (''a;b''); NULL; UPDATE t SET n = 1; END'").unwrap(),
+            vec!["INSERT INTO t VALUES ('a;b')", "UPDATE t SET n = 1"]
+        );
+        assert_eq!(
+            do_block_statements("DO LANGUAGE sql 'DELETE FROM t; DELETE FROM u'").unwrap(),
+            vec!["DELETE FROM t", "DELETE FROM u"]
+        );
+        assert_eq!(
+            do_block_statements("DO ' BEGIN END; ' LANGUAGE plpgsql;").unwrap(),
+            Vec::<String>::new()
+        );
+    }
+
+    #[test]
+    fn test_do_block_rejects_plpgsql() {
+        for query in [
+            "DO 'BEGIN IF true THEN DELETE FROM t; END IF; END'",
+            "DO 'DECLARE n int; BEGIN n := 1; END'",
+            "DO 'BEGIN PERFORM 1; END'",
+        ] {
+            let err = do_block_statements(query).unwrap_err();
+            assert_eq!(err.pg_error_code(), "0A000", "{query}");
+        }
+
+        let err = do_block_statements("DO LANGUAGE plperl 'print 1'").unwrap_err();
+        assert!(err.to_string().contains("language \"plperl\" does not exist"));
+    }
+}
@@ -0,0 +1,110 @@
+//! Dollar-quoted string literals
+//!
+//! PostgreSQL accepts `$$text$$` and `$tag$text$tag$` as string constants whose body needs
+//! no escaping and may hold quotes, semicolons, comment markers and other dollar quotes
+//! with a different tag. SQLite only knows single-quoted strings, so they are rewritten.
+
+use std::borrow::Cow;
+
+/// Length of the `$tag$` opening a dollar-quoted string at the start of `text`, if one does
+pub fn dollar_quote_tag(text: &str) -> Option<usize> {
+    let bytes = text.as_bytes();
+    if bytes.first() != Some(&b'$') {
+        return None;
+    }
+    // $1 is a parameter placeholder, and tags can't start with a digit
+    if bytes.get(1).is_some_and(|b| b.is_ascii_digit()) {
+        return None;
+    }
+    let tag_len = bytes[1..].iter().take_while(|b| is_word_byte(**b)).count();
+    if bytes.get(tag_len + 1) != Some(&b'$') {
+        return None;
+    }
+    Some(tag_len + 2)
+}
+
+/// Byte length of the dollar-quoted string at the start of `text`, closing tag included.
+/// None when `text` doesn't start with one or it is never closed.
+pub fn dollar_quote_len(text: &str) -> Option<usize> {
+    let tag_len = dollar_quote_tag(text)?;
+    let tag = &text[..tag_len];
+    let body_len = text[tag_len..].find(tag)?;
+    Some(tag_len + body_len + tag_len)
+}
+
+/// Whether a dollar quote may start at byte `pos`: `$` inside an identifier such as
+/// `price$usd` is part of the name
+pub fn can_start_dollar_quote(query: &str, pos: usize) -> bool {
+    pos == 0 || !is_word_byte(query.as_bytes()[pos - 1])
+}
+
+/// Rewrite dollar-quoted strings as standard single-quoted strings
+pub fn convert_dollar_quotes(query: &str) -> Cow<'_, str> {
+    if !query.contains('$') {
+        return Cow::Borrowed(query);
+    }
+
+    let bytes = query.as_bytes();
+    let mut result = String::with_capacity(query.len());
+    let mut copied = 0;
+    let mut i = 0;
+
+    while i < bytes.len() {
+        match bytes[i] {
+            quote @ (b'\'' | b'"') => {
+                // Doubled quotes escape themselves, so skipping pair by pair is enough
+                i += 1;
+                while i < bytes.len() && bytes[i] != quote {
+                    i += 1;
+                }
+            }
+            b'$' if can_start_dollar_quote(query, i) => {
+                if let Some(len) = dollar_quote_len(&query[i..]) {
+                    let tag_len = dollar_quote_tag(&query[i..]).unwrap_or(0);
+                    let body = &query[i + tag_len..i + len - tag_len];
+                    result.push_str(&query[copied..i]);
+                    result.push('\'');
+                    result.push_str(&body.replace('\'', "''"));
+                    result.push('\'');
+                    i += len;
+                    copied = i;
+                    continue;
+                }
+            }
+            _ => {}
+        }
+        i += 1;
+    }
+
+    if copied == 0 {
+        return Cow::Borrowed(query);
+    }
+    result.push_str(&query[copied..]);
+    Cow::Owned(result)
+}
+
+fn is_word_byte(b: u8) -> bool {
+    b.is_ascii_alphanumeric() || b == b'_'
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_convert_dollar_quotes() {
+        assert_eq!(
+            convert_dollar_quotes("SELECT $$it's; -- not a comment$$"),
+            "SELECT 'it''s; -- not a comment'"
+        );
+        assert_eq!(
+            convert_dollar_quotes("SELECT $body$ SELECT $$inner;$$; $body$, $a$x$a$"),
+            "SELECT ' SELECT $$inner;$$; ', 'x'"
+        );
+
+        // Parameters, identifiers, quoted dollars and unterminated quotes stay as they are
+        let query = "SELECT price$usd, '$$', \"$x$\" FROM t WHERE id = $1 OR note = $2";
+        assert_eq!(convert_dollar_quotes(query), query);
+        assert_eq!(convert_dollar_quotes("SELECT $$open"), "SELECT $$open");
+    }
+}
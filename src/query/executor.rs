@@ -31,10 +31,16 @@ static SET_CONFIG_PATTERN: Lazy<Regex> = Lazy::new(|| {
 });
 
 fn preprocess_query(query: &str) -> String {
-    if PG_SHOW_ALL_SETTINGS_PATTERN.is_match(query) {
-        PG_SHOW_ALL_SETTINGS_PATTERN.replace_all(query, "pg_settings").to_string()
+    // SQLite has no dollar-quoted strings
+    let query = crate::query::convert_dollar_quotes(query);
+    // ... nor the TABLE name shorthand for SELECT * FROM name
+    if crate::translator::TableStatementTranslator::needs_translation(&query) {
+        return crate::translator::TableStatementTranslator::translate_query(&query);
+    }
+    if PG_SHOW_ALL_SETTINGS_PATTERN.is_match(&query) {
+        PG_SHOW_ALL_SETTINGS_PATTERN.replace_all(&query, "pg_settings").to_string()
     } else {
-        query.to_string()
+        query.into_owned()
     }
 }
 
@@ -149,7 +155,7 @@ async fn get_table_schema_info(table_name: &str, db: &Arc<DbHandler>, session_id
 
 /// Create a command complete tag with optimized Cow<str> for minimal allocations
 fn create_command_tag(operation: &str, rows_affected: usize) -> Cow<'static, str> {
-    global_string_optimizer().get_command_tag(operation, rows_affected as u32)
+    global_string_optimizer().get_command_tag(operation, rows_affected)
 }
 
 pub struct QueryExecutor;
@@ -189,6 +195,7 @@ impl QueryExecutor {
     {
         println!("EXECUTOR: execute_query called with: '{}'", query);
         // Executing query
+        let _activity = session.start_query(query);
         
         // Strip SQL comments first to avoid parsing issues
         let cleaned_query = crate::query::strip_sql_comments(query);
@@ -199,16 +206,6 @@ impl QueryExecutor {
             return Err(PgSqliteError::Protocol(error_message!("Empty query").into_owned()));
         }
         
-        // Handle PostgreSQL DEALLOCATE commands (used for prepared statement cleanup)
-        let query_upper = query_to_execute.to_uppercase();
-        if query_upper.starts_with("DEALLOCATE") {
-            debug!("DEALLOCATE command - treating as successful no-op (SQLite manages prepared statements automatically)");
-            // Send CommandComplete for successful DEALLOCATE
-            let msg = BackendMessage::CommandComplete { tag: error_message!("DEALLOCATE").into_owned() };
-            framed.send(msg).await.map_err(PgSqliteError::Io)?;
-            return Ok(());
-        }
-        
         // debug!("Executing query: {}", query_to_execute);
         
         // Check for Python-style parameters and provide helpful error
@@ -226,11 +223,8 @@ impl QueryExecutor {
         // Check if query contains multiple statements
         let trimmed = query_to_execute.trim();
         if trimmed.contains(';') {
-            // Split by semicolon and execute each statement
-            let statements: Vec<&str> = trimmed.split(';')
-                .map(|s| s.trim())
-                .filter(|s| !s.is_empty())
-                .collect();
+            // Split on semicolons outside literals and trigger bodies
+            let statements = crate::query::split_statements(trimmed);
             
             // Handle empty query case (just semicolon) - SQLAlchemy uses ";" for ping
             if statements.is_empty() {
@@ -243,18 +237,67 @@ impl QueryExecutor {
             
             if statements.len() > 1 {
                 debug!("Query contains {} statements", statements.len());
-                for (i, stmt) in statements.iter().enumerate() {
-                    debug!("Executing statement {}: {}", i + 1, stmt);
-                    Self::execute_single_statement(framed, db, session, stmt, query_router).await?;
-                }
-                return Ok(());
+                return Self::execute_batch(framed, db, session, &statements, query_router).await;
             }
+            
+            return Self::execute_single_statement(framed, db, session, statements[0], query_router).await;
         }
-        
         // Single statement execution
         Self::execute_single_statement(framed, db, session, query_to_execute, query_router).await
     }
     
+    /// Execute the statements of a simple-query batch in order, each with its own
+    /// CommandComplete. As in PostgreSQL, a batch sent outside a transaction block runs as
+    /// one implicit transaction unless it begins or ends transactions itself, so a failing
+    /// statement rolls back the ones before it; the remaining statements are skipped.
+    /// Batches containing VACUUM, which can't run inside a transaction, run unwrapped.
+    async fn execute_batch<T>(
+        framed: &mut Framed<T, crate::protocol::PostgresCodec>,
+        db: &Arc<DbHandler>,
+        session: &Arc<SessionState>,
+        statements: &[&str],
+        query_router: Option<&Arc<QueryRouter>>,
+    ) -> Result<(), PgSqliteError>
+    where
+        T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin + Send,
+    {
+        use crate::protocol::TransactionStatus;
+        use crate::query::{QueryTypeDetector, QueryType};
+        
+        let implicit_transaction = session.get_transaction_status().await == TransactionStatus::Idle
+            && !statements.iter().any(|stmt| matches!(
+                QueryTypeDetector::detect_query_type(stmt),
+                QueryType::Begin | QueryType::Commit | QueryType::Rollback
+            ) || crate::query::VacuumHandler::is_vacuum(stmt));
+        if implicit_transaction {
+            db.begin_with_session(&session.id).await?;
+            // Nested batches (DO blocks) see the open transaction
+            *session.transaction_status.write().await = TransactionStatus::InTransaction;
+        }
+        
+        let mut result = Ok(());
+        for (i, stmt) in statements.iter().enumerate() {
+            debug!("Executing statement {}: {}", i + 1, stmt);
+            result = Self::execute_single_statement(framed, db, session, stmt, query_router).await;
+            if result.is_err() {
+                break;
+            }
+        }
+        
+        if implicit_transaction {
+            *session.transaction_status.write().await = TransactionStatus::Idle;
+            match result {
+                Ok(()) => db.commit_with_session(&session.id).await?,
+                Err(_) => {
+                    if let Err(rollback_err) = db.rollback_with_session(&session.id).await {
+                        tracing::warn!("Failed to roll back implicit batch transaction: {}", rollback_err);
+                    }
+                }
+            }
+        }
+        result
+    }
+    
     async fn execute_single_statement<T>(
         framed: &mut Framed<T, crate::protocol::PostgresCodec>,
         db: &Arc<DbHandler>,
@@ -281,6 +324,51 @@ impl QueryExecutor {
         let query = preprocess_query(query);
         let query: &str = query.as_str();
 
+        // DO blocks run their statements with the results discarded, reporting just DO
+        if crate::query::do_block::is_do_block(query) {
+            let statements = crate::query::do_block::do_block_statements(query)?;
+            let statements: Vec<&str> = statements.iter().map(String::as_str).collect();
+            debug!("Executing DO block with {} statements", statements.len());
+
+            let mut discard = Framed::new(
+                tokio::io::join(tokio::io::empty(), tokio::io::sink()),
+                crate::protocol::PostgresCodec::new(),
+            );
+            // Boxed as a trait object: DO blocks can nest, which makes the future recursive
+            let batch: std::pin::Pin<Box<dyn std::future::Future<Output = Result<(), PgSqliteError>> + Send + '_>> =
+                Box::pin(Self::execute_batch(&mut discard, db, session, &statements, query_router));
+            batch.await?;
+
+            framed.send(BackendMessage::CommandComplete { tag: "DO".to_string() }).await
+                .map_err(PgSqliteError::Io)?;
+            return Ok(());
+        }
+
+        // CREATE TABLE ... AS and SELECT ... INTO translate their defining query themselves
+        if crate::query::CreateTableAsHandler::is_create_table_as(query) {
+            return crate::query::CreateTableAsHandler::handle_create_table_as(framed, db, session, query).await;
+        }
+
+        // PREPARE, EXECUTE and DEALLOCATE manage prepared statements from SQL
+        if crate::query::PrepareHandler::is_prepare(query) {
+            return crate::query::PrepareHandler::handle_prepare(framed, session, query).await;
+        }
+        if crate::query::PrepareHandler::is_deallocate(query) {
+            return crate::query::PrepareHandler::handle_deallocate(framed, session, query).await;
+        }
+        if crate::query::PrepareHandler::is_execute(query) {
+            let statement = crate::query::PrepareHandler::bind_execute(session, query).await?;
+            // Boxed as a trait object: the prepared statement runs through this same function
+            let execute: std::pin::Pin<Box<dyn std::future::Future<Output = Result<(), PgSqliteError>> + Send + '_>> =
+                Box::pin(Self::execute_single_statement(framed, db, session, &statement, query_router));
+            return execute.await;
+        }
+
+        // COPY ... FROM STDIN and COPY ... TO STDOUT run the copy sub-protocol
+        if crate::query::CopyHandler::is_copy(query) {
+            return crate::query::CopyHandler::handle_copy(framed, db, session, query).await;
+        }
+
         // Handle set_config() function calls
         if let Some(caps) = SET_CONFIG_PATTERN.captures(query) {
             let param_name = caps[1].to_string();
@@ -293,6 +381,9 @@ impl QueryExecutor {
             let mut params = session.parameters.write().await;
             params.insert(param_name.to_uppercase(), param_value.clone());
             drop(params);
+            if param_name.eq_ignore_ascii_case("application_name") {
+                crate::session::activity::set_application_name(session.pid, &param_value);
+            }
 
             // Send synthetic response: RowDescription + DataRow + CommandComplete
             let field = FieldDescription {
@@ -318,6 +409,11 @@ impl QueryExecutor {
             return Ok(());
         }
 
+        // PostgreSQL rejects SELECT DISTINCT ordered by columns it doesn't select
+        crate::validator::DistinctOrderByValidator::validate(query)?;
+        // ... and negative OFFSETs, which SQLite treats as zero
+        crate::validator::OffsetValidator::validate(query)?;
+
         // Ultra-fast path: Skip all translation if query is simple enough
         let is_ultra_simple = crate::query::simple_query_detector::is_ultra_simple_query(query);
         // Checking if query is ultra-simple
@@ -636,6 +732,34 @@ impl QueryExecutor {
             }
         }
         
+        // Range operators and casts go first: the cast and array translators would otherwise
+        // claim '...'::int4range and the @>, <@ and && operators
+        let range_translated = if crate::translator::RangeTranslator::needs_translation(query) {
+            let translated = db.with_session_connection(&session.id, |conn| {
+                Ok(crate::translator::RangeTranslator::translate_query(query, conn))
+            }).await?;
+            Some(translated)
+        } else {
+            None
+        };
+        let query = range_translated.as_deref().unwrap_or(query);
+        
+        // Likewise '...'::point casts and the <-> distance operator
+        let point_translated = crate::translator::PointTranslator::needs_translation(query)
+            .then(|| crate::translator::PointTranslator::translate_query(query));
+        let query = point_translated.as_deref().unwrap_or(query);
+        
+        // And B'...' literals, ::bit(n) casts and the bitwise operators on bit strings
+        let bit_translated = if crate::translator::BitStringTranslator::needs_translation(query) {
+            let translated = db.with_session_connection(&session.id, |conn| {
+                Ok(crate::translator::BitStringTranslator::translate_query(query, conn))
+            }).await?;
+            Some(translated)
+        } else {
+            None
+        };
+        let query = bit_translated.as_deref().unwrap_or(query);
+        
         // Analyze query once to determine which translators are needed
         let translation_flags = crate::translator::QueryAnalyzer::analyze(query);
         debug!("Query analysis flags: {:?}", translation_flags);
@@ -902,6 +1026,22 @@ impl QueryExecutor {
             debug!("Total translation metadata after merge: {} hints", translation_metadata.column_mappings.len());
         }
         
+        // Analyze window functions (including named WINDOW definitions) for type metadata
+        if translation_flags.contains(crate::translator::TranslationFlags::WINDOW) {
+            let window_metadata = crate::translator::WindowFunctionAnalyzer::analyze_query(&translated_query);
+            debug!("WindowFunctionAnalyzer found {} hints", window_metadata.column_mappings.len());
+            translation_metadata.merge(window_metadata);
+        }
+        
+        // Analyze CASE expressions for their unified result type
+        if crate::translator::CaseExpressionAnalyzer::needs_analysis(&translated_query) {
+            let case_metadata = db.with_session_connection(&session.id, |conn| {
+                Ok(crate::translator::CaseExpressionAnalyzer::analyze_query(&translated_query, conn))
+            }).await?;
+            debug!("CaseExpressionAnalyzer found {} hints", case_metadata.column_mappings.len());
+            translation_metadata.merge(case_metadata);
+        }
+        
         let query_to_execute = translated_query.as_str();
         
         // Simple query routing using optimized detection
@@ -924,9 +1064,14 @@ impl QueryExecutor {
             QueryType::Begin | QueryType::Commit | QueryType::Rollback => {
                 Self::execute_transaction(framed, db, session, query_to_execute, query_router).await
             }
+            QueryType::Truncate => {
+                crate::query::TruncateHandler::handle_truncate(framed, db, session, query_to_execute).await
+            }
             _ => {
                 // Check if it's a SET command
-                if crate::query::SetHandler::is_set_command(query_to_execute) {
+                if crate::query::SetHandler::is_set_constraints(query_to_execute) {
+                    crate::query::SetHandler::handle_set_constraints(framed, db, session, query_to_execute).await
+                } else if crate::query::SetHandler::is_set_command(query_to_execute) {
                     crate::query::SetHandler::handle_set_command(framed, session, query_to_execute).await
                 } else if query_to_execute.trim().to_uppercase().starts_with("GRANT") {
                     // Handle GRANT commands
@@ -944,6 +1089,10 @@ impl QueryExecutor {
                     }).await
                         .map_err(PgSqliteError::Io)?;
                     Ok(())
+                } else if crate::query::AnalyzeHandler::is_analyze(query_to_execute) {
+                    crate::query::AnalyzeHandler::handle_analyze(framed, db, session, query_to_execute).await
+                } else if crate::query::VacuumHandler::is_vacuum(query_to_execute) {
+                    crate::query::VacuumHandler::handle_vacuum(framed, db, session, query_to_execute).await
                 } else if query_to_execute.trim().to_uppercase().starts_with("FLUSH") {
                     // Handle FLUSH commands
                     info!("FLUSH command received - SQLite doesn't have caching layers like PostgreSQL, succeeding with no-op");
@@ -1187,6 +1336,14 @@ impl QueryExecutor {
                 }
             }
             
+            // SELECTs without FROM (SELECT 1, now(), ...) and VALUES lists are typed from their expressions
+            let fromless_types = if table_name.is_none() {
+                crate::types::SchemaTypeMapper::infer_fromless_select_types(query)
+                    .or_else(|| crate::translator::ValuesTranslator::infer_result_types(query))
+            } else {
+                None
+            };
+            
             // Build field descriptions with proper type inference
             let fields: Vec<FieldDescription> = response.columns.iter()
                 .enumerate()
@@ -1195,6 +1352,8 @@ impl QueryExecutor {
                     let type_oid = if let Some(pg_type) = schema_types.get(name) {
                         // Use basic type OID mapping (enum checking would require async which isn't allowed in closure)
                         crate::types::SchemaTypeMapper::pg_type_string_to_oid(pg_type)
+                    } else if let Some(expr_oid) = fromless_types.as_ref().and_then(|types| types.get(i).copied().flatten()) {
+                        expr_oid
                     } else if let Some(aggregate_oid) = crate::types::SchemaTypeMapper::get_aggregate_return_type_with_query(name, None, None, Some(query)) {
                         // Second priority: Check for aggregate functions
                         aggregate_oid
@@ -1207,11 +1366,20 @@ impl QueryExecutor {
                         debug!("  Expression type: {:?}", hint.expression_type);
                         debug!("  Source column: {:?}", hint.source_column);
                         
-                        // Check if we pre-fetched the source type
-                        if let Some(source_type) = hint_source_types.get(name) {
+                        // An explicit cast determines the result type, whatever its source column was.
+                        // Text is also the fallback for unknown (e.g. enum) types, so leave those to the source.
+                        if hint.expression_type == Some(crate::translator::ExpressionType::TypeCast)
+                            && let Some(suggested_type) = &hint.suggested_type
+                            && *suggested_type != PgType::Text {
+                            suggested_type.to_oid()
+                        } else if let Some(source_type) = hint_source_types.get(name) {
                             debug!("Found source column type for '{}' -> '{}': {}", name, hint.source_column.as_ref().unwrap_or(&"<none>".to_string()), source_type);
-                            // For arithmetic on numeric columns, preserve the type
-                            if hint.expression_type == Some(crate::translator::ExpressionType::ArithmeticOnFloat) {
+                            let source_oid = crate::types::SchemaTypeMapper::pg_type_string_to_oid(source_type);
+                            // sum() and avg() over a column follow the column's type,
+                            // arithmetic on numeric columns preserves it
+                            if let Some(aggregate_type) = PgType::from_oid(source_oid).and_then(|t| hint.aggregate_type(t)) {
+                                aggregate_type.to_oid()
+                            } else if hint.expression_type == Some(crate::translator::ExpressionType::ArithmeticOnFloat) {
                                 if source_type.contains("NUMERIC") || source_type.contains("DECIMAL") {
                                     // For NUMERIC/DECIMAL types, arithmetic returns NUMERIC
                                     PgType::Numeric.to_oid()
@@ -1930,7 +2098,7 @@ impl QueryExecutor {
             return Ok(());
         }
         
-        let (translated_query, type_mappings, enum_columns, array_columns) = if matches!(QueryTypeDetector::detect_query_type(query), QueryType::Create) && query.trim_start()[6..].trim_start().to_uppercase().starts_with("TABLE") {
+        let (translated_query, type_mappings, enum_columns, array_columns, exclusion_constraints, nulls_not_distinct, updatable_view) = if matches!(QueryTypeDetector::detect_query_type(query), QueryType::Create) && query.trim_start()[6..].trim_start().to_uppercase().starts_with("TABLE") {
             // Use CREATE TABLE translator with connection for ENUM support
             db.with_session_connection(&session.id, |conn| {
                 let result = CreateTableTranslator::translate_with_connection_full(query, Some(conn))
@@ -1939,16 +2107,20 @@ impl QueryExecutor {
                         Some(format!("CREATE TABLE translation failed: {e}"))
                     ))?;
                 
-                Ok((result.sql, result.type_mappings, result.enum_columns, result.array_columns))
+                Ok((result.sql, result.type_mappings, result.enum_columns, result.array_columns, result.exclusion_constraints, result.nulls_not_distinct, None))
             }).await?
         } else {
+            // CREATE VIEW ... WITH CHECK OPTION and CREATE UNIQUE INDEX ... NULLS [NOT] DISTINCT
+            // lose the options SQLite doesn't know
+            let (query, updatable_view) = crate::metadata::UpdatableView::from_create_view(query)?;
+            let (query, unique_index) = crate::metadata::NullsNotDistinctUnique::from_create_index(&query)?;
             // For other DDL, check for JSON/JSONB types
             let translated = if query.to_lowercase().contains("json") || query.to_lowercase().contains("jsonb") {
-                JsonTranslator::translate_statement(query)?
+                JsonTranslator::translate_statement(&query)?
             } else {
-                query.to_string()
+                query
             };
-            (translated, std::collections::HashMap::new(), Vec::new(), Vec::new())
+            (translated, std::collections::HashMap::new(), Vec::new(), Vec::new(), Vec::new(), unique_index.into_iter().collect(), updatable_view)
         };
         
         // Check if this is a DROP TABLE command and extract table name
@@ -1977,6 +2149,9 @@ impl QueryExecutor {
 
             db.with_session_connection_mut(&session.id, |conn| {
                 use crate::metadata::EnumTriggers;
+                if let Err(e) = crate::metadata::NullsNotDistinctUnique::forget_table(conn, &table_name) {
+                    debug!("Failed to forget NULLS NOT DISTINCT keys of {}: {}", table_name, e);
+                }
                 EnumTriggers::clean_enum_usage_for_table(conn, &table_name)
                     .map_err(|e| rusqlite::Error::SqliteFailure(
                         rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
@@ -2158,17 +2333,76 @@ impl QueryExecutor {
         // Populate PostgreSQL catalog tables with constraint information for ALL CREATE TABLE statements
         if let Some(table_name) = extract_table_name_from_create(query) {
             db.with_session_connection(&session.id, |conn| {
-                // Populate pg_constraint, pg_attrdef, and pg_index tables
+                // Populate pg_constraint and pg_depend (pg_attrdef and pg_index are live views)
                 if let Err(e) = crate::catalog::constraint_populator::populate_constraints_for_table(conn, &table_name) {
                     // Log the error but don't fail the CREATE TABLE operation
                     debug!("Failed to populate constraints for table {}: {}", table_name, e);
                 } else {
                     debug!("Successfully populated constraint catalog tables for table: {}", table_name);
                 }
+                // Enforce the int2/int4/int8 ranges, char(n) padding, bit(n) lengths, timestamp(p) precision and xml well-formedness, record INHERITS parents, identity and generated columns
+                if let Err(e) = crate::validator::IntegerRangeTriggers::create_for_table(conn, &table_name)
+                    .and_then(|_| crate::validator::FixedCharTriggers::create_for_table(conn, &table_name))
+                    .and_then(|_| crate::validator::BitStringTriggers::create_for_table(conn, &table_name))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_datetime_precisions(conn, &table_name, query))
+                    .and_then(|_| crate::validator::DatetimePrecisionTriggers::create_for_table(conn, &table_name))
+                    .and_then(|_| crate::validator::XmlTriggers::create_for_table(conn, &table_name))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_inheritance(conn, &table_name, query))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_identity_columns(conn, &table_name, query))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_generated_columns(conn, &table_name, query))
+                    .and_then(|_| crate::validator::IdentityTriggers::create_for_table(conn, &table_name)) {
+                    debug!("Failed to create column type triggers or record column metadata for table {}: {}", table_name, e);
+                }
                 Ok(())
             }).await?;
         }
         
+        // Enforce EXCLUDE constraints with triggers and record them in pg_constraint
+        if !exclusion_constraints.is_empty()
+            && let Some(table_name) = extract_table_name_from_create(query) {
+            db.with_session_connection(&session.id, |conn| {
+                for constraint in &exclusion_constraints {
+                    constraint.create_triggers(conn, &table_name)
+                        .map_err(|e| rusqlite::Error::SqliteFailure(
+                            rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                            Some(format!("Failed to create exclusion constraint triggers: {e}"))
+                        ))?;
+                    if let Err(e) = constraint.record_in_catalog(conn, &table_name) {
+                        debug!("Failed to record exclusion constraint {} in pg_constraint: {}", constraint.name, e);
+                    }
+                }
+                Ok(())
+            }).await?;
+        }
+        
+        // Enforce NULLS NOT DISTINCT unique keys with triggers and record the option
+        if !nulls_not_distinct.is_empty() {
+            db.with_session_connection(&session.id, |conn| {
+                for constraint in &nulls_not_distinct {
+                    constraint.create_triggers(conn)
+                        .map_err(|e| rusqlite::Error::SqliteFailure(
+                            rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                            Some(format!("Failed to create NULLS NOT DISTINCT triggers: {e}"))
+                        ))?;
+                    if let Err(e) = constraint.record(conn) {
+                        debug!("Failed to record NULLS NOT DISTINCT for {}: {}", constraint.name, e);
+                    }
+                }
+                Ok(())
+            }).await?;
+        }
+        
+        // Write through simple views with INSTEAD OF triggers, enforcing WITH CHECK OPTION
+        if let Some(view) = updatable_view {
+            db.with_session_connection(&session.id, |conn| {
+                view.create_triggers(conn)
+                    .map_err(|e| rusqlite::Error::SqliteFailure(
+                        rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                        Some(format!("Failed to create updatable view triggers: {e}"))
+                    ))
+            }).await?;
+        }
+        
         // Handle cache invalidation for ALTER operations
         if matches!(QueryTypeDetector::detect_query_type(query), QueryType::Alter) {
             // For ALTER operations, we invalidate all schema cache since determining
@@ -2242,6 +2476,7 @@ impl QueryExecutor {
         
         match QueryTypeDetector::detect_query_type(query) {
             QueryType::Begin => {
+                let mode = crate::query::TransactionMode::parse(query)?;
                 // Check if we're already in a transaction
                 if current_status == TransactionStatus::InTransaction {
                     // PostgreSQL behavior: warn but don't fail
@@ -2261,8 +2496,8 @@ impl QueryExecutor {
                     framed.send(BackendMessage::CommandComplete { tag: "BEGIN".to_string() }).await
                         .map_err(PgSqliteError::Io)?;
                 } else {
-                    tracing::debug!("Executing BEGIN command");
-                    db.begin_with_session(&session.id).await?;
+                    tracing::debug!("Executing BEGIN command with {:?}", mode);
+                    db.begin_with_mode(&session.id, &mode).await?;
                     tracing::debug!("BEGIN executed successfully");
                     // Update transaction status to InTransaction
                     *session.transaction_status.write().await = TransactionStatus::InTransaction;
@@ -2279,7 +2514,13 @@ impl QueryExecutor {
                     ));
                 }
                 tracing::debug!("Executing COMMIT command");
-                db.commit_with_session(&session.id).await?;
+                if let Err(e) = db.commit_with_session(&session.id).await {
+                    if matches!(e, PgSqliteError::Validation(_)) {
+                        // A deferred constraint failed and the transaction was rolled back
+                        *session.transaction_status.write().await = TransactionStatus::Idle;
+                    }
+                    return Err(e);
+                }
                 tracing::debug!("COMMIT executed successfully");
                 
                 // Update transaction status to Idle
@@ -2313,6 +2554,8 @@ impl QueryExecutor {
     where
         T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin + Send,
     {
+        use crate::query::{QueryTypeDetector, QueryType};
+        
         // Try to execute as a simple statement
         if let Some(router) = query_router {
             router.execute_query(query, session).await.map_err(|e| PgSqliteError::Protocol(e.to_string()))?;
@@ -2321,7 +2564,11 @@ impl QueryExecutor {
             db.execute_with_session_cached(query, &session.id, cached_conn.as_ref()).await?;
         }
         
-        framed.send(BackendMessage::CommandComplete { tag: "OK".to_string() }).await
+        let tag = match QueryTypeDetector::detect_query_type(query) {
+            QueryType::Comment => "COMMENT",
+            _ => "OK",
+        };
+        framed.send(BackendMessage::CommandComplete { tag: tag.to_string() }).await
             .map_err(PgSqliteError::Io)?;
         
         Ok(())
@@ -2413,6 +2660,7 @@ impl QueryExecutor {
         let timetz_oid = PgType::Timetz.to_oid();
         let timestamp_oid = PgType::Timestamp.to_oid();
         let timestamptz_oid = PgType::Timestamptz.to_oid();
+        let interval_oid = PgType::Interval.to_oid();
         
         let needs_conversion = type_oids.iter().any(|&oid| {
             oid == bool_oid || 
@@ -2421,6 +2669,7 @@ impl QueryExecutor {
             oid == timetz_oid ||
             oid == timestamp_oid ||
             oid == timestamptz_oid ||
+            oid == interval_oid ||
             PgType::from_oid(oid).is_some_and(|t| t.is_array())
         });
         
@@ -2500,6 +2749,13 @@ impl QueryExecutor {
                         } else {
                             Some(data) // Keep original if not valid UTF-8
                         }
+                    } else if type_oid == interval_oid {
+                        // Convert stored microseconds or interval text to canonical interval format
+                        use crate::types::datetime_utils::{format_interval, parse_stored_interval};
+                        match std::str::from_utf8(&data).ok().and_then(parse_stored_interval) {
+                            Some((months, days, micros)) => Some(format_interval(months, days, micros).into_bytes()),
+                            None => Some(data), // Keep original if not a valid interval
+                        }
                     } else {
                         Some(data)
                     }
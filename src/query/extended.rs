@@ -14,6 +14,18 @@ use tracing::{info, warn, debug};
 use std::sync::Arc;
 use byteorder::{BigEndian, ByteOrder};
 use chrono::{NaiveDate, NaiveTime, NaiveDateTime, Timelike};
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+/// A `::type` or `::type(n[, m])` cast. The letter after `::` keeps IPv6 addresses like ::1 out.
+static POSTFIX_CAST_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"::[a-zA-Z]\w*(?:\(\s*\d+\s*(?:,\s*\d+\s*)?\))?").unwrap()
+});
+
+/// `CAST(expr AS type)`, capturing the type name without its modifier
+static CAST_SYNTAX_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?is)^\s*CAST\s*\(.+\s+AS\s+([a-z_][a-z0-9_]*(?:\s+[a-z_][a-z0-9_]*)*)\s*(?:\([\d\s,]*\))?\s*\)").unwrap()
+});
 
 /// Efficient case-insensitive query type detection
 #[inline]
@@ -91,6 +103,12 @@ impl ExtendedQueryHandler {
         T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
     {
         info!("PARSE: Starting parse for statement '{}', query: {}", name, query);
+        // TABLE name is shorthand for SELECT * FROM name, which is what gets prepared
+        let query = if crate::translator::TableStatementTranslator::needs_translation(&query) {
+            crate::translator::TableStatementTranslator::translate_query(&query)
+        } else {
+            query
+        };
         // Fast path: Check if we already have this prepared statement
         // This avoids re-parsing the same query multiple times
         if !name.is_empty() {
@@ -140,12 +158,18 @@ impl ExtendedQueryHandler {
         
         // Strip SQL comments first to avoid parsing issues
         let mut cleaned_query = crate::query::strip_sql_comments(&query);
+        cleaned_query = crate::query::convert_dollar_quotes(&cleaned_query).into_owned();
         
         // Check if query is empty after comment stripping
         if cleaned_query.trim().is_empty() {
             return Err(PgSqliteError::Protocol("Empty query".to_string()));
         }
         
+        // PostgreSQL rejects SELECT DISTINCT ordered by columns it doesn't select
+        crate::validator::DistinctOrderByValidator::validate(&cleaned_query)?;
+        // ... and negative OFFSETs, which SQLite treats as zero
+        crate::validator::OffsetValidator::validate(&cleaned_query)?;
+        
         // Removed verbose debug logging for parsing
         
         // Extract cast type information BEFORE any query translation
@@ -252,6 +276,23 @@ impl ExtendedQueryHandler {
             }
         }
         
+        // COPY runs the copy sub-protocol during Execute and returns no rows
+        if crate::query::CopyHandler::is_copy(&cleaned_query) {
+            let stmt = PreparedStatement {
+                query: cleaned_query.clone(),
+                translated_query: None,
+                param_types: vec![],
+                param_formats: vec![],
+                field_descriptions: vec![],
+                translation_metadata: None,
+            };
+            session.prepared_statements.write().await.insert(name.clone(), stmt);
+
+            framed.send(BackendMessage::ParseComplete).await
+                .map_err(PgSqliteError::Io)?;
+            return Ok(());
+        }
+
         // Check if this is a SET command - handle it specially
         if crate::query::SetHandler::is_set_command(&cleaned_query) {
             // For SET commands, we need to create a special prepared statement
@@ -438,9 +479,17 @@ impl ExtendedQueryHandler {
         // Translate catalog functions (remove pg_catalog prefix)
         #[cfg(not(feature = "unified_processor"))] // Skip when using unified processor
         {
-            use crate::translator::{CatalogFunctionTranslator, PgTableIsVisibleTranslator};
+            use crate::translator::{CatalogFunctionTranslator, PgTableIsVisibleTranslator, OnlyTranslator, DistinctFromTranslator, TsMatchTranslator, CollateTranslator, RowComparisonTranslator, SubstringTranslator, PositionTranslator, MakeIntervalTranslator};
             translated_for_analysis = CatalogFunctionTranslator::translate(&translated_for_analysis);
             translated_for_analysis = PgTableIsVisibleTranslator::translate(&translated_for_analysis);
+            translated_for_analysis = OnlyTranslator::translate_query(&translated_for_analysis);
+            translated_for_analysis = DistinctFromTranslator::translate_query(&translated_for_analysis);
+            translated_for_analysis = TsMatchTranslator::translate_query(&translated_for_analysis);
+            translated_for_analysis = CollateTranslator::translate_query(&translated_for_analysis);
+            translated_for_analysis = RowComparisonTranslator::translate_query(&translated_for_analysis);
+            translated_for_analysis = SubstringTranslator::translate_query(&translated_for_analysis);
+            translated_for_analysis = PositionTranslator::translate_query(&translated_for_analysis);
+            translated_for_analysis = MakeIntervalTranslator::translate_query(&translated_for_analysis);
         }
         
         // Translate array operators with metadata
@@ -463,6 +512,21 @@ impl ExtendedQueryHandler {
             }
         }
         
+        // Translate unnest() functions to json_each() equivalents
+        #[cfg(not(feature = "unified_processor"))] // Skip when using unified processor
+        if crate::translator::UnnestTranslator::contains_unnest(&translated_for_analysis) {
+            use crate::translator::UnnestTranslator;
+            match UnnestTranslator::translate_with_metadata(&translated_for_analysis) {
+                Ok((translated, metadata)) => {
+                    translated_for_analysis = translated;
+                    translation_metadata.merge(metadata);
+                }
+                Err(_) => {
+                    // Continue with original query
+                }
+            }
+        }
+
         // Translate json_each()/jsonb_each() functions for PostgreSQL compatibility
         #[cfg(not(feature = "unified_processor"))] // Skip when using unified processor
         {
@@ -508,12 +572,29 @@ impl ExtendedQueryHandler {
             debug!("Found {} arithmetic type hints", translation_metadata.column_mappings.len());
         }
         
+        // Analyze window functions for type metadata
+        #[cfg(not(feature = "unified_processor"))] // Skip when using unified processor
+        if crate::translator::WindowFunctionAnalyzer::needs_analysis(&translated_for_analysis) {
+            let window_metadata = crate::translator::WindowFunctionAnalyzer::analyze_query(&translated_for_analysis);
+            translation_metadata.merge(window_metadata);
+        }
+        
+        // Analyze CASE expressions for their unified result type
+        #[cfg(not(feature = "unified_processor"))] // Skip when using unified processor
+        if crate::translator::CaseExpressionAnalyzer::needs_analysis(&translated_for_analysis) {
+            let case_metadata = db.with_session_connection(&session.id, |conn| {
+                Ok(crate::translator::CaseExpressionAnalyzer::analyze_query(&translated_for_analysis, conn))
+            }).await?;
+            translation_metadata.merge(case_metadata);
+        }
+        
         // For now, we'll just analyze the query to get field descriptions
         // In a real implementation, we'd parse the SQL and validate it
         info!("PARSE: Analyzing query '{}' for field descriptions", translated_for_analysis);
         info!("PARSE: Original query: {}", cleaned_query);
         info!("PARSE: Is simple param select: {}", is_simple_param_select);
-        let field_descriptions = if query_starts_with_ignore_case(&cleaned_query, "SELECT") {
+        let field_descriptions = if query_starts_with_ignore_case(&cleaned_query, "SELECT")
+            || query_starts_with_ignore_case(&cleaned_query, "VALUES") {
             // Don't try to get field descriptions if this is a catalog query
             // These queries are handled specially and don't need real field info
             if cleaned_query.contains("pg_catalog") || cleaned_query.contains("pg_type") ||
@@ -542,14 +623,18 @@ impl ExtendedQueryHandler {
                 info!("Detected column casts: {:?}", cast_info);
                 
                 // Remove PostgreSQL-style type casts before executing
-                // Be careful not to match IPv6 addresses like ::1
-                let cast_regex = regex::Regex::new(r"::[a-zA-Z]\w*").unwrap();
-                test_query = cast_regex.replace_all(&test_query, "").to_string();
+                test_query = POSTFIX_CAST_REGEX.replace_all(&test_query, "").to_string();
                 
                 // Add LIMIT 1 to avoid processing too much data, but only if there's no existing LIMIT
-                if !test_query.to_uppercase().contains(" LIMIT ") {
+                // FETCH FIRST and OFFSET ... ROWS are turned into a LIMIT later on
+                if !test_query.to_uppercase().contains(" LIMIT ")
+                    && !crate::translator::FetchFirstTranslator::needs_translation(&test_query) {
                     test_query = format!("{test_query} LIMIT 1");
                 }
+                // Large object functions write as they run, so only look at the columns of those queries
+                if crate::functions::large_object_functions::calls_large_object_functions(&test_query) {
+                    test_query = format!("SELECT * FROM ({}) WHERE 0", test_query.trim_end().trim_end_matches(';'));
+                }
                 let cached_conn = Self::get_or_cache_connection(session, db).await;
                 let test_response = db.query_with_session_cached(&test_query, &session.id, cached_conn.as_ref()).await;
                 
@@ -666,6 +751,14 @@ impl ExtendedQueryHandler {
                             std::collections::HashMap::new()
                         };
 
+                        // SELECTs without FROM (SELECT 1, now(), ...) and VALUES lists are typed from their expressions
+                        let fromless_types = if table_name.is_none() {
+                            crate::types::SchemaTypeMapper::infer_fromless_select_types(&cleaned_query)
+                                .or_else(|| crate::translator::ValuesTranslator::infer_result_types(&cleaned_query))
+                        } else {
+                            None
+                        };
+
                         for (i, col_name) in response.columns.iter().enumerate() {
                             info!("PARSE: Processing column {}: '{}'", i, col_name);
                             let inferred_type = {
@@ -725,6 +818,16 @@ impl ExtendedQueryHandler {
                                         debug!("Arithmetic expression '{}' detected with ArithmeticOnFloat hint, returning FLOAT8", col_name);
                                         // For arithmetic on REAL/FLOAT columns, always return FLOAT8
                                         PgType::Float8.to_oid()
+                                    } else if let Some(aggregate_type) = match (&hint.source_column, &table_name) {
+                                        // sum() and avg() over a column follow the column's type
+                                        (Some(source_col), Some(table)) if matches!(hint.expression_type, Some(crate::translator::ExpressionType::Sum | crate::translator::ExpressionType::Avg)) => {
+                                            db.get_schema_type_with_session(&session.id, table, source_col).await.ok().flatten()
+                                                .and_then(|source_type| PgType::from_oid(crate::types::SchemaTypeMapper::pg_type_string_to_oid(&source_type)))
+                                                .and_then(|source_type| hint.aggregate_type(source_type))
+                                        }
+                                        _ => None,
+                                    } {
+                                        aggregate_type.to_oid()
                                     } else if let Some(suggested_type) = &hint.suggested_type {
                                         debug!("Using type hint from translation metadata for '{}': {:?}", col_name, suggested_type);
                                         suggested_type.to_oid()
@@ -781,6 +884,12 @@ impl ExtendedQueryHandler {
                                 continue;
                             }
                             
+                            if let Some(expr_oid) = fromless_types.as_ref().and_then(|types| types.get(i).copied().flatten()) {
+                                info!("PARSE: Column '{}': typed from FROM-less SELECT expression -> OID {}", col_name, expr_oid);
+                                inferred_types.push(expr_oid);
+                                continue;
+                            }
+                            
                             // Third priority: Check schema table for stored type mappings
                             if let Some(pg_type) = schema_types.get(col_name) {
                                 // Use basic type OID mapping (enum checking would require async which isn't allowed in closure)
@@ -1284,6 +1393,7 @@ impl ExtendedQueryHandler {
              portal_obj.statement_name.clone(),
              portal_obj.inferred_param_types.clone())
         };
+        let _activity = session.start_query(&query);
         
         // Special logging for orders queries
         if query.contains("orders") && query.contains("customer_id") {
@@ -1518,7 +1628,8 @@ impl ExtendedQueryHandler {
                                     t if t == PgType::Float4.to_oid() => {
                                         if bytes.len() == 4 {
                                             let val = f32::from_be_bytes([bytes[0], bytes[1], bytes[2], bytes[3]]);
-                                            Some(val.to_string().into_bytes())
+                                            let text = if val.is_finite() { val.to_string() } else { crate::types::numeric_utils::format_float(val as f64) };
+                                            Some(text.into_bytes())
                                         } else {
                                             Some(bytes.clone())
                                         }
@@ -1529,7 +1640,7 @@ impl ExtendedQueryHandler {
                                                 bytes[0], bytes[1], bytes[2], bytes[3],
                                                 bytes[4], bytes[5], bytes[6], bytes[7]
                                             ]);
-                                            Some(val.to_string().into_bytes())
+                                            Some(crate::types::numeric_utils::format_float(val).into_bytes())
                                         } else {
                                             Some(bytes.clone())
                                         }
@@ -1885,7 +1996,11 @@ impl ExtendedQueryHandler {
         }
         
         // Execute based on query type
-        if query_starts_with_ignore_case(&final_query, "SELECT") {
+        if crate::query::CreateTableAsHandler::is_create_table_as(&final_query) {
+            crate::query::CreateTableAsHandler::handle_create_table_as(framed, db, session, &final_query).await?;
+        } else if crate::query::CopyHandler::is_copy(&final_query) {
+            crate::query::CopyHandler::handle_copy(framed, db, session, &final_query).await?;
+        } else if query_starts_with_ignore_case(&final_query, "SELECT") || query_starts_with_ignore_case(&final_query, "VALUES") {
             Self::execute_select(framed, db, session, &portal, &final_query, max_rows).await?;
         } else if query_starts_with_ignore_case(&final_query, "INSERT") 
             || query_starts_with_ignore_case(&final_query, "UPDATE") 
@@ -1901,6 +2016,14 @@ impl ExtendedQueryHandler {
             || query_starts_with_ignore_case(&final_query, "END")
             || query_starts_with_ignore_case(&final_query, "ROLLBACK") {
             Self::execute_transaction(framed, db, session, &final_query).await?;
+        } else if crate::query::TruncateHandler::is_truncate(&final_query) {
+            crate::query::TruncateHandler::handle_truncate(framed, db, session, &final_query).await?;
+        } else if crate::query::AnalyzeHandler::is_analyze(&final_query) {
+            crate::query::AnalyzeHandler::handle_analyze(framed, db, session, &final_query).await?;
+        } else if crate::query::VacuumHandler::is_vacuum(&final_query) {
+            crate::query::VacuumHandler::handle_vacuum(framed, db, session, &final_query).await?;
+        } else if crate::query::SetHandler::is_set_constraints(&final_query) {
+            crate::query::SetHandler::handle_set_constraints(framed, db, session, &final_query).await?;
         } else if crate::query::SetHandler::is_set_command(&final_query) {
             // Check if we should skip row description
             let skip_row_desc = {
@@ -2597,7 +2720,17 @@ impl ExtendedQueryHandler {
                 None => rusqlite_params.push(rusqlite::types::Value::Null),
             }
         }
-        
+
+        // Text parameters compared with date, timestamp or numeric columns are cast to the
+        // column type, as PostgreSQL does; bound as text they would never equal the stored integers
+        if rusqlite_params.iter().any(|param| matches!(param, rusqlite::types::Value::Text(_)))
+            && db.with_session_connection(&session.id, |conn| {
+                crate::translator::DateComparisonTranslator::coerce_parameters(query, conn, &mut rusqlite_params);
+                Ok(())
+            }).await.is_err() {
+            return Ok(None);
+        }
+
         // Get result formats and statement name from portal
         let (result_formats, statement_name) = {
             let portals = session.portals.read().await;
@@ -2687,7 +2820,7 @@ impl ExtendedQueryHandler {
             match v {
                 rusqlite::types::Value::Null => None,
                 rusqlite::types::Value::Integer(i) => Some(i.to_string().into_bytes()),
-                rusqlite::types::Value::Real(f) => Some(f.to_string().into_bytes()),
+                rusqlite::types::Value::Real(f) => Some(crate::types::numeric_utils::format_float(*f).into_bytes()),
                 rusqlite::types::Value::Text(s) => Some(s.clone().into_bytes()),
                 rusqlite::types::Value::Blob(b) => Some(b.clone()),
             }
@@ -2725,8 +2858,8 @@ impl ExtendedQueryHandler {
                 t if t == PgType::Int8.to_oid() => Ok(rusqlite::types::Value::Integer(text.parse::<i64>().map_err(|_| PgSqliteError::Protocol("Invalid int8".to_string()))?)), // INT8
                 t if t == PgType::Int4.to_oid() => Ok(rusqlite::types::Value::Integer(text.parse::<i64>().map_err(|_| PgSqliteError::Protocol("Invalid int4".to_string()))?)), // INT4
                 t if t == PgType::Int2.to_oid() => Ok(rusqlite::types::Value::Integer(text.parse::<i64>().map_err(|_| PgSqliteError::Protocol("Invalid int2".to_string()))?)), // INT2
-                t if t == PgType::Float4.to_oid() => Ok(rusqlite::types::Value::Real(text.parse::<f64>().map_err(|_| PgSqliteError::Protocol("Invalid float4".to_string()))?)), // FLOAT4
-                t if t == PgType::Float8.to_oid() => Ok(rusqlite::types::Value::Real(text.parse::<f64>().map_err(|_| PgSqliteError::Protocol("Invalid float8".to_string()))?)), // FLOAT8
+                t if t == PgType::Float4.to_oid() => Ok(crate::types::numeric_utils::float_to_sqlite_value(text.parse::<f64>().map_err(|_| PgSqliteError::Protocol("Invalid float4".to_string()))?)), // FLOAT4
+                t if t == PgType::Float8.to_oid() => Ok(crate::types::numeric_utils::float_to_sqlite_value(text.parse::<f64>().map_err(|_| PgSqliteError::Protocol("Invalid float8".to_string()))?)), // FLOAT8
                 t if t == PgType::Date.to_oid() => {
                     // DATE - convert to days since epoch
                     match crate::types::ValueConverter::convert_date_to_unix(text) {
@@ -2748,6 +2881,13 @@ impl ExtendedQueryHandler {
                         Err(e) => Err(PgSqliteError::Protocol(format!("Invalid timestamp: {e}")))
                     }
                 }
+                t if t == PgType::Interval.to_oid() => {
+                    // INTERVAL - canonical interval text
+                    match crate::types::ValueConverter::convert_interval_to_text(text) {
+                        Ok(stored) => Ok(rusqlite::types::Value::Text(stored)),
+                        Err(e) => Err(PgSqliteError::Protocol(format!("Invalid interval: {e}")))
+                    }
+                }
                 _ => Ok(rusqlite::types::Value::Text(text.to_string())), // Default to TEXT
             }
         } else {
@@ -3115,6 +3255,22 @@ impl ExtendedQueryHandler {
                                     format!("X'{}'", hex::encode(bytes))
                                 }
                             }
+                            t if t == PgType::Interval.to_oid() => {
+                                // interval - int8 microseconds, int4 days, int4 months
+                                if bytes.len() == 16 {
+                                    let micros = i64::from_be_bytes([
+                                        bytes[0], bytes[1], bytes[2], bytes[3],
+                                        bytes[4], bytes[5], bytes[6], bytes[7]
+                                    ]);
+                                    let days = i32::from_be_bytes([bytes[8], bytes[9], bytes[10], bytes[11]]);
+                                    let months = i32::from_be_bytes([bytes[12], bytes[13], bytes[14], bytes[15]]);
+                                    info!("Decoded binary interval parameter {}: {} months, {} days, {} microseconds", i + 1, months, days, micros);
+                                    // Stored as canonical interval text
+                                    format!("'{}'", crate::types::datetime_utils::format_interval(months, days, micros))
+                                } else {
+                                    format!("X'{}'", hex::encode(bytes))
+                                }
+                            }
                             0 => {
                                 // No type specified - try to infer from byte pattern
                                 if bytes.len() == 1 && (bytes[0] == 0 || bytes[0] == 1) {
@@ -3158,9 +3314,16 @@ impl ExtendedQueryHandler {
                             Ok(s) => {
                                 // Check parameter type to determine handling
                                 match param_type {
-                                    t if t == PgType::Int4.to_oid() || t == PgType::Int8.to_oid() || t == PgType::Int2.to_oid() || 
-                                         t == PgType::Float4.to_oid() || t == PgType::Float8.to_oid() => {
-                                        // Integer and float types - use as-is if valid number
+                                    t if t == PgType::Float4.to_oid() || t == PgType::Float8.to_oid() => {
+                                        // Float types - Infinity and NaN need their stored form
+                                        match crate::types::numeric_utils::special_float_literal(&s) {
+                                            Some(literal) => literal.to_string(),
+                                            None if s.parse::<f64>().is_ok() => s,
+                                            None => format!("'{}'", s.replace('\'', "''")),
+                                        }
+                                    }
+                                    t if t == PgType::Int4.to_oid() || t == PgType::Int8.to_oid() || t == PgType::Int2.to_oid() => {
+                                        // Integer types - use as-is if valid number
                                         if s.parse::<i64>().is_ok() || s.parse::<f64>().is_ok() {
                                             s
                                         } else {
@@ -3221,9 +3384,19 @@ impl ExtendedQueryHandler {
                                             }
                                         }
                                     }
+                                    t if t == PgType::Interval.to_oid() => {
+                                        // INTERVAL - canonical interval text
+                                        match crate::types::ValueConverter::convert_interval_to_text(&s) {
+                                            Ok(stored) => format!("'{stored}'"),
+                                            Err(e) => {
+                                                // Invalid INTERVAL parameter
+                                                return Err(PgSqliteError::InvalidParameter(format!("Invalid INTERVAL value: {e}")));
+                                            }
+                                        }
+                                    }
                                     _ => {
-                                        // For other types, check if it's a plain number
-                                        if s.parse::<i64>().is_ok() || s.parse::<f64>().is_ok() {
+                                        // For other types, check if it's a plain number (f64 also parses "inf" and "NaN")
+                                        if s.parse::<i64>().is_ok() || s.parse::<f64>().is_ok_and(f64::is_finite) {
                                             s // Use as-is for numeric values
                                         } else {
                                             // Quote string values
@@ -4105,6 +4278,16 @@ impl ExtendedQueryHandler {
                                     Some(bytes.clone())
                                 }
                             }
+                            t if t == PgType::Interval.to_oid() => {
+                                // interval - microseconds as int8, days as int4, months as int4
+                                let parsed = std::str::from_utf8(bytes).ok()
+                                    .and_then(crate::types::datetime_utils::parse_stored_interval);
+                                match parsed {
+                                    Some((months, days, micros)) => Some(crate::protocol::binary::BinaryEncoder::encode_interval_parts(months, days, micros)),
+                                    // If parsing fails, keep as text
+                                    None => Some(bytes.clone()),
+                                }
+                            }
                             // Numeric type - use proper binary encoding when requested
                             t if t == PgType::Numeric.to_oid() => {
                                 if let Ok(s) = String::from_utf8(bytes.clone()) {
@@ -4347,6 +4530,15 @@ impl ExtendedQueryHandler {
                                     Some(bytes.clone())
                                 }
                             }
+                            // Interval type - convert stored microseconds or text to canonical format
+                            t if t == PgType::Interval.to_oid() => {
+                                use crate::types::datetime_utils::{format_interval, parse_stored_interval};
+                                match std::str::from_utf8(bytes).ok().and_then(parse_stored_interval) {
+                                    Some((months, days, micros)) => Some(format_interval(months, days, micros).into_bytes()),
+                                    // Invalid interval, keep as-is
+                                    None => Some(bytes.clone()),
+                                }
+                            }
                             // NOTE: Array type handling removed for text format too
                             // Arrays are returned as JSON strings with TEXT type
                             t if t == PgType::Text.to_oid() => {
@@ -5476,14 +5668,14 @@ impl ExtendedQueryHandler {
         // Handle CREATE TABLE translation
         if query_starts_with_ignore_case(query, "CREATE TABLE") {
             // Use translator with connection for ENUM support
-            let (sqlite_sql, type_mappings, enum_columns, array_columns) = db.with_session_connection(&session.id, |conn| {
+            let (sqlite_sql, type_mappings, enum_columns, array_columns, exclusion_constraints, nulls_not_distinct) = db.with_session_connection(&session.id, |conn| {
                 let result = crate::translator::CreateTableTranslator::translate_with_connection_full(query, Some(conn))
                     .map_err(|e| rusqlite::Error::SqliteFailure(
                         rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
                         Some(format!("CREATE TABLE translation failed: {e}"))
                     ))?;
                 
-                Ok((result.sql, result.type_mappings, result.enum_columns, result.array_columns))
+                Ok((result.sql, result.type_mappings, result.enum_columns, result.array_columns, result.exclusion_constraints, result.nulls_not_distinct))
             }).await
             .map_err(|e| PgSqliteError::Protocol(format!("Failed to translate CREATE TABLE: {e}")))?;
             
@@ -5618,7 +5810,7 @@ impl ExtendedQueryHandler {
             // Populate PostgreSQL catalog tables with constraint information
             if let Some(table_name) = extract_table_name_from_create(query) {
                 db.with_session_connection(&session.id, |conn| {
-                    // Populate pg_constraint, pg_attrdef, and pg_index tables
+                    // Populate pg_constraint and pg_depend (pg_attrdef and pg_index are live views)
                     info!("Extended: About to populate constraints for table: {}", table_name);
                     if let Err(e) = crate::catalog::constraint_populator::populate_constraints_for_table(conn, &table_name) {
                         // Log the error but don't fail the CREATE TABLE operation
@@ -5626,10 +5818,43 @@ impl ExtendedQueryHandler {
                     } else {
                         debug!("Successfully populated constraint catalog tables for table: {}", table_name);
                     }
+                    // Enforce the int2/int4/int8 ranges, char(n) padding, bit(n) lengths, timestamp(p) precision and xml well-formedness, record INHERITS parents, identity and generated columns
+                    if let Err(e) = crate::validator::IntegerRangeTriggers::create_for_table(conn, &table_name)
+                        .and_then(|_| crate::validator::FixedCharTriggers::create_for_table(conn, &table_name))
+                        .and_then(|_| crate::validator::BitStringTriggers::create_for_table(conn, &table_name))
+                        .and_then(|_| crate::translator::CreateTableTranslator::record_datetime_precisions(conn, &table_name, query))
+                        .and_then(|_| crate::validator::DatetimePrecisionTriggers::create_for_table(conn, &table_name))
+                        .and_then(|_| crate::validator::XmlTriggers::create_for_table(conn, &table_name))
+                        .and_then(|_| crate::translator::CreateTableTranslator::record_inheritance(conn, &table_name, query))
+                        .and_then(|_| crate::translator::CreateTableTranslator::record_identity_columns(conn, &table_name, query))
+                        .and_then(|_| crate::translator::CreateTableTranslator::record_generated_columns(conn, &table_name, query))
+                        .and_then(|_| crate::validator::IdentityTriggers::create_for_table(conn, &table_name)) {
+                        warn!("Failed to create column type triggers or record column metadata for table {}: {}", table_name, e);
+                    }
+                    Ok(())
+                }).await?;
+            }
+
+            // Enforce EXCLUDE constraints with triggers and record them in pg_constraint
+            if !exclusion_constraints.is_empty()
+                && let Some(table_name) = extract_table_name_from_create(query) {
+                db.with_session_connection(&session.id, |conn| {
+                    for constraint in &exclusion_constraints {
+                        constraint.create_triggers(conn, &table_name)
+                            .map_err(|e| rusqlite::Error::SqliteFailure(
+                                rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                                Some(format!("Failed to create exclusion constraint triggers: {e}"))
+                            ))?;
+                        if let Err(e) = constraint.record_in_catalog(conn, &table_name) {
+                            warn!("Failed to record exclusion constraint {} in pg_constraint: {}", constraint.name, e);
+                        }
+                    }
                     Ok(())
                 }).await?;
             }
 
+            Self::enforce_nulls_not_distinct(db, session, &nulls_not_distinct).await?;
+
             // Send CommandComplete and return
             framed.send(BackendMessage::CommandComplete { tag: "CREATE TABLE".to_string() }).await
                 .map_err(PgSqliteError::Io)?;
@@ -5638,17 +5863,32 @@ impl ExtendedQueryHandler {
         };
         
         // Handle other DDL with potential translation
+        let (view_query, updatable_view) = crate::metadata::UpdatableView::from_create_view(query)?;
+        let (unique_index_query, unique_index) = crate::metadata::NullsNotDistinctUnique::from_create_index(&view_query)?;
         let translated_query = if query.to_lowercase().contains("json") || query.to_lowercase().contains("jsonb") {
-            JsonTranslator::translate_statement(query)?
+            JsonTranslator::translate_statement(&view_query)?
         } else if query_starts_with_ignore_case(query, "CREATE INDEX") {
             // Translate CREATE INDEX with operator classes
             crate::translator::CreateIndexTranslator::translate(query)
         } else {
-            query.to_string()
+            unique_index_query
         };
         
         let cached_conn = Self::get_or_cache_connection(session, db).await;
         db.execute_with_session_cached(&translated_query, &session.id, cached_conn.as_ref()).await?;
+        if let Some(unique_index) = unique_index {
+            Self::enforce_nulls_not_distinct(db, session, &[unique_index]).await?;
+        }
+        if let Some(view) = updatable_view {
+            // Write through simple views with INSTEAD OF triggers, enforcing WITH CHECK OPTION
+            db.with_session_connection(&session.id, |conn| {
+                view.create_triggers(conn)
+                    .map_err(|e| rusqlite::Error::SqliteFailure(
+                        rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                        Some(format!("Failed to create updatable view triggers: {e}"))
+                    ))
+            }).await?;
+        }
         
         let tag = if query_starts_with_ignore_case(query, "CREATE TABLE") {
             "CREATE TABLE".to_string()
@@ -5666,6 +5906,31 @@ impl ExtendedQueryHandler {
         Ok(())
     }
     
+    /// Create the triggers for NULLS NOT DISTINCT unique keys and record the option
+    async fn enforce_nulls_not_distinct(
+        db: &Arc<DbHandler>,
+        session: &Arc<SessionState>,
+        constraints: &[crate::metadata::NullsNotDistinctUnique],
+    ) -> Result<(), PgSqliteError> {
+        if constraints.is_empty() {
+            return Ok(());
+        }
+        db.with_session_connection(&session.id, |conn| {
+            for constraint in constraints {
+                constraint.create_triggers(conn)
+                    .map_err(|e| rusqlite::Error::SqliteFailure(
+                        rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                        Some(format!("Failed to create NULLS NOT DISTINCT triggers: {e}"))
+                    ))?;
+                if let Err(e) = constraint.record(conn) {
+                    warn!("Failed to record NULLS NOT DISTINCT for {}: {}", constraint.name, e);
+                }
+            }
+            Ok(())
+        }).await?;
+        Ok(())
+    }
+    
     async fn execute_transaction<T>(
         framed: &mut Framed<T, crate::protocol::PostgresCodec>,
         db: &Arc<DbHandler>,
@@ -5675,18 +5940,40 @@ impl ExtendedQueryHandler {
     where
         T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
     {
+        use crate::protocol::TransactionStatus;
+        
         if query_starts_with_ignore_case(query, "BEGIN")
             || query_starts_with_ignore_case(query, "START") {
-            db.begin_with_session(&session.id).await?;
+            let mode = crate::query::TransactionMode::parse(query)?;
+            if session.get_transaction_status().await == TransactionStatus::Idle {
+                db.begin_with_mode(&session.id, &mode).await?;
+                *session.transaction_status.write().await = TransactionStatus::InTransaction;
+            } else {
+                // PostgreSQL warns about a nested BEGIN and keeps the open transaction
+                framed.send(BackendMessage::NoticeResponse(crate::protocol::messages::NoticeResponse {
+                    severity: "WARNING".to_string(),
+                    code: "25001".to_string(), // active_sql_transaction
+                    message: "there is already a transaction in progress".to_string(),
+                    detail: None,
+                    hint: None,
+                    position: None,
+                    where_: None,
+                })).await.map_err(PgSqliteError::Io)?;
+            }
             framed.send(BackendMessage::CommandComplete { tag: "BEGIN".to_string() }).await
                 .map_err(PgSqliteError::Io)?;
         } else if query_starts_with_ignore_case(query, "COMMIT")
             || query_starts_with_ignore_case(query, "END") {
-            db.commit_with_session(&session.id).await?;
+            let result = db.commit_with_session(&session.id).await;
+            if result.is_ok() || matches!(result, Err(PgSqliteError::Validation(_))) {
+                *session.transaction_status.write().await = TransactionStatus::Idle;
+            }
+            result?;
             framed.send(BackendMessage::CommandComplete { tag: "COMMIT".to_string() }).await
                 .map_err(PgSqliteError::Io)?;
         } else if query_starts_with_ignore_case(query, "ROLLBACK") {
             db.rollback_with_session(&session.id).await?;
+            *session.transaction_status.write().await = TransactionStatus::Idle;
             framed.send(BackendMessage::CommandComplete { tag: "ROLLBACK".to_string() }).await
                 .map_err(PgSqliteError::Io)?;
         }
@@ -5751,8 +6038,13 @@ impl ExtendedQueryHandler {
                     t if t == PgType::Int4range.to_oid() => PgType::Text.to_oid(), // INT4RANGE -> TEXT
                     t if t == PgType::Int8range.to_oid() => PgType::Text.to_oid(), // INT8RANGE -> TEXT
                     t if t == PgType::Numrange.to_oid() => PgType::Text.to_oid(), // NUMRANGE -> TEXT
+                    t if t == PgType::Tsrange.to_oid() => PgType::Text.to_oid(), // TSRANGE -> TEXT
+                    t if t == PgType::Tstzrange.to_oid() => PgType::Text.to_oid(), // TSTZRANGE -> TEXT
+                    t if t == PgType::Daterange.to_oid() => PgType::Text.to_oid(), // DATERANGE -> TEXT
+                    t if t == PgType::Point.to_oid() => PgType::Text.to_oid(), // POINT -> TEXT
                     t if t == PgType::Bit.to_oid() => PgType::Text.to_oid(), // BIT -> TEXT
                     t if t == PgType::Varbit.to_oid() => PgType::Text.to_oid(), // VARBIT -> TEXT
+                    t if t == PgType::Xml.to_oid() => PgType::Text.to_oid(), // XML -> TEXT
                     _ => col_info.pg_oid, // Use original OID for supported types
                 };
                 
@@ -5794,7 +6086,7 @@ impl ExtendedQueryHandler {
             "time" => PgType::Time.to_oid(),
             "timestamp" => PgType::Timestamp.to_oid(),
             "timestamptz" | "timestamp with time zone" => PgType::Timestamptz.to_oid(),
-            "interval" => 1186, // Interval type not in PgType enum yet
+            "interval" => PgType::Interval.to_oid(),
             "numeric" | "decimal" => PgType::Numeric.to_oid(),
             "uuid" => PgType::Uuid.to_oid(),
             "json" => PgType::Json.to_oid(),
@@ -5803,12 +6095,17 @@ impl ExtendedQueryHandler {
             "int4range" => PgType::Int4range.to_oid(),
             "int8range" => PgType::Int8range.to_oid(),
             "numrange" => PgType::Numrange.to_oid(),
+            "tsrange" => PgType::Tsrange.to_oid(),
+            "tstzrange" => PgType::Tstzrange.to_oid(),
+            "daterange" => PgType::Daterange.to_oid(),
+            "point" => PgType::Point.to_oid(),
             "cidr" => PgType::Cidr.to_oid(),
             "inet" => PgType::Inet.to_oid(),
             "macaddr" => PgType::Macaddr.to_oid(),
             "macaddr8" => PgType::Macaddr8.to_oid(),
             "bit" => PgType::Bit.to_oid(),
             "varbit" | "bit varying" => PgType::Varbit.to_oid(),
+            "xml" => PgType::Xml.to_oid(),
             _ => {
                 info!("Unknown PostgreSQL type '{}', defaulting to text", type_name);
                 PgType::Text.to_oid() // Default to text
@@ -5863,9 +6160,46 @@ impl ExtendedQueryHandler {
             // Try to find which column this parameter is compared against
             // Look for patterns like "column = $n" or "column < $n" etc.
             
+            let param_escaped = regex::escape(&param);
+            
+            // Aggregate comparisons, typically in HAVING (e.g. "count(*) > $n"). Left as text,
+            // SQLite would compare the integer aggregate against a string and never match.
+            let aggregate_patterns = [
+                format!(r"\b(count|sum|avg|min|max)\s*\(\s*(?:distinct\s+)?([^()]*?)\s*\)\s*(?:=|<>|!=|<=|>=|<|>)\s*{param_escaped}\b"),
+                format!(r"{param_escaped}\s*(?:=|<>|!=|<=|>=|<|>)\s*(count|sum|avg|min|max)\s*\(\s*(?:distinct\s+)?([^()]*?)\s*\)"),
+            ];
+            for pattern in &aggregate_patterns {
+                let regex = regex::Regex::new(pattern).unwrap();
+                if let Some(captures) = regex.captures(&query_lower) {
+                    let function = &captures[1];
+                    let argument = captures[2].rsplit('.').next().unwrap_or_default();
+                    let argument_type = if argument.chars().all(|c| c.is_alphanumeric() || c == '_') && !argument.is_empty() {
+                        Self::lookup_column_type_oid(db, session, &table_name, argument).await
+                    } else {
+                        None
+                    };
+                    let oid = match (function, argument_type) {
+                        ("count", _) => Some(PgType::Int8.to_oid()),
+                        ("sum", Some(t)) if t == PgType::Int2.to_oid() || t == PgType::Int4.to_oid() || t == PgType::Int8.to_oid() => Some(PgType::Int8.to_oid()),
+                        ("sum", Some(t)) if t == PgType::Float4.to_oid() || t == PgType::Float8.to_oid() => Some(PgType::Float8.to_oid()),
+                        ("sum", _) | ("avg", _) => Some(PgType::Numeric.to_oid()),
+                        (_, argument_type) => argument_type,
+                    };
+                    if let Some(oid) = oid {
+                        param_types.push(oid);
+                        info!("Found type for parameter {} from aggregate {}({}): OID {}", i, function, argument, oid);
+                        found_type = true;
+                        break;
+                    }
+                }
+            }
+            
+            if found_type {
+                continue;
+            }
+            
             // Look for the parameter in the query and find the column it's compared to
             // Use simpler string matching instead of complex regex
-            let param_escaped = regex::escape(&param);
             let patterns = vec![
                 format!(r"(\w+)\s*=\s*{}", param_escaped),
                 format!(r"(\w+)\s*<\s*{}", param_escaped),
@@ -5928,6 +6262,21 @@ impl ExtendedQueryHandler {
         Ok(param_types)
     }
     
+    /// Look up the PostgreSQL type OID of a table column, from the schema metadata or,
+    /// failing that, from the SQLite declared type
+    async fn lookup_column_type_oid(db: &Arc<DbHandler>, session: &Arc<SessionState>, table_name: &str, column: &str) -> Option<i32> {
+        if let Ok(Some(pg_type)) = db.get_schema_type_with_session(&session.id, table_name, column).await {
+            return Some(crate::types::SchemaTypeMapper::pg_type_string_to_oid(&pg_type));
+        }
+        let response = db.query(&format!("PRAGMA table_info({table_name})")).await.ok()?;
+        response.rows.iter().find_map(|row| {
+            let name = String::from_utf8(row.get(1)?.clone()?).ok()?;
+            let sqlite_type = String::from_utf8(row.get(2)?.clone()?).ok()?;
+            name.eq_ignore_ascii_case(column)
+                .then(|| crate::types::SchemaTypeMapper::sqlite_type_to_pg_oid(&sqlite_type))
+        })
+    }
+    
     /// Analyze a SELECT query to find explicit type casts on columns
     /// Returns a map of column index to cast type
     fn analyze_column_casts(query: &str) -> std::collections::HashMap<usize, String> {
@@ -6017,12 +6366,13 @@ impl ExtendedQueryHandler {
         cast_map
     }
     
-    /// Extract cast type from an expression like "column::text"
+    /// Extract cast type from an expression like "column::text" or "CAST(column AS numeric(10,2))".
+    /// Type modifiers are dropped since they don't change the type OID.
     fn extract_cast_from_expression(expr: &str) -> Option<String> {
         if let Some(cast_pos) = expr.find("::") {
             let cast_type = &expr[cast_pos + 2..];
-            // Extract just the type name (before any whitespace or AS alias)
-            let type_end = cast_type.find(|c: char| c.is_whitespace() || c == ')')
+            // Extract just the type name (before any whitespace, modifier or AS alias)
+            let type_end = cast_type.find(|c: char| c.is_whitespace() || c == '(' || c == ')')
                 .unwrap_or(cast_type.len());
             let type_name = cast_type[..type_end].trim().to_lowercase();
             
@@ -6032,7 +6382,7 @@ impl ExtendedQueryHandler {
                 None
             }
         } else {
-            None
+            CAST_SYNTAX_REGEX.captures(expr).map(|caps| caps[1].to_lowercase())
         }
     }
     
@@ -6061,12 +6411,17 @@ impl ExtendedQueryHandler {
             "int4range" => PgType::Int4range.to_oid(),
             "int8range" => PgType::Int8range.to_oid(),
             "numrange" => PgType::Numrange.to_oid(),
+            "tsrange" => PgType::Tsrange.to_oid(),
+            "tstzrange" => PgType::Tstzrange.to_oid(),
+            "daterange" => PgType::Daterange.to_oid(),
+            "point" => PgType::Point.to_oid(),
             "cidr" => PgType::Cidr.to_oid(),
             "inet" => PgType::Inet.to_oid(),
             "macaddr" => PgType::Macaddr.to_oid(),
             "macaddr8" => PgType::Macaddr8.to_oid(),
             "bit" => PgType::Bit.to_oid(),
             "varbit" | "bit varying" => PgType::Varbit.to_oid(),
+            "xml" => PgType::Xml.to_oid(),
             _ => PgType::Text.to_oid(), // Default to text for unknown types
         }
     }
@@ -6223,6 +6578,12 @@ fn extract_table_name_from_select(query: &str) -> Option<String> {
     if let Some(from_pos) = find_keyword_position(query, " from ") {
         let after_from = &query[from_pos + 6..].trim();
         
+        // FROM ONLY t reads t
+        let after_from = match after_from.get(..5) {
+            Some(keyword) if keyword.eq_ignore_ascii_case("only ") => after_from[5..].trim_start(),
+            _ => *after_from,
+        };
+        
         // Find the end of table name (space, where, order by, etc.)
         let table_end = after_from.find(|c: char| {
             c.is_whitespace() || c == ',' || c == ';' || c == '('
@@ -40,7 +40,7 @@ impl ExtendedFastPath {
         T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
     {
         // Convert parameters to rusqlite values with caching, using original types for proper conversion
-        let rusqlite_params = match Self::convert_parameters_cached(query, bound_values, param_formats, param_types, original_types) {
+        let mut rusqlite_params = match Self::convert_parameters_cached(query, bound_values, param_formats, param_types, original_types) {
             Ok(params) => {
                 params
             },
@@ -49,6 +49,16 @@ impl ExtendedFastPath {
                 return Ok(false); // Fall back to normal path
             }
         };
+
+        // Text parameters compared with date, timestamp or numeric columns are cast to the
+        // column type, as the normal path does once they are substituted
+        if rusqlite_params.iter().any(|param| matches!(param, rusqlite::types::Value::Text(_)))
+            && db.with_session_connection(&session.id, |conn| {
+                crate::translator::DateComparisonTranslator::coerce_parameters(query, conn, &mut rusqlite_params);
+                Ok(())
+            }).await.is_err() {
+            return Ok(false);
+        }
         
         // Execute based on query type
         match query_type {
@@ -219,7 +229,7 @@ impl ExtendedFastPath {
                 t if t == PgType::Float4.to_oid() || t == PgType::Float8.to_oid() => {
                     // FLOAT4, FLOAT8
                     text.parse::<f64>()
-                        .map(rusqlite::types::Value::Real)
+                        .map(crate::types::numeric_utils::float_to_sqlite_value)
                         .map_err(|_| PgSqliteError::Protocol(format!("Invalid float: {text}")))
                 }
                 t if t == PgType::Numeric.to_oid() => {
@@ -262,10 +272,17 @@ impl ExtendedFastPath {
                         Err(e) => Err(PgSqliteError::Protocol(format!("Invalid timestamp: {e}")))
                     }
                 }
-                t if t == PgType::Timestamptz.to_oid() || t == PgType::Timetz.to_oid() || t == PgType::Interval.to_oid() => {
+                t if t == PgType::Interval.to_oid() => {
+                    // INTERVAL - canonical interval text
+                    match crate::types::ValueConverter::convert_interval_to_text(text) {
+                        Ok(stored) => Ok(rusqlite::types::Value::Text(stored)),
+                        Err(e) => Err(PgSqliteError::Protocol(format!("Invalid interval: {e}")))
+                    }
+                }
+                t if t == PgType::Timestamptz.to_oid() || t == PgType::Timetz.to_oid() => {
                     // Other datetime types - convert to INTEGER (microseconds)
                     // For now, store as text until we implement proper conversion
-                    // TODO: Implement proper conversion for TIMESTAMPTZ, TIMETZ, INTERVAL
+                    // TODO: Implement proper conversion for TIMESTAMPTZ, TIMETZ
                     Ok(rusqlite::types::Value::Text(text.to_string()))
                 }
                 t if t == PgType::Money.to_oid() || t == PgType::Macaddr.to_oid() || t == PgType::Macaddr8.to_oid() ||
@@ -381,7 +398,7 @@ impl ExtendedFastPath {
                     if bytes.len() == 4 {
                         let bits = u32::from_be_bytes([bytes[0], bytes[1], bytes[2], bytes[3]]);
                         let val = f32::from_bits(bits) as f64;
-                        Ok(rusqlite::types::Value::Real(val))
+                        Ok(crate::types::numeric_utils::float_to_sqlite_value(val))
                     } else {
                         Err(PgSqliteError::Protocol("Invalid FLOAT4 binary format".to_string()))
                     }
@@ -394,7 +411,7 @@ impl ExtendedFastPath {
                             bytes[4], bytes[5], bytes[6], bytes[7]
                         ]);
                         let val = f64::from_bits(bits);
-                        Ok(rusqlite::types::Value::Real(val))
+                        Ok(crate::types::numeric_utils::float_to_sqlite_value(val))
                     } else {
                         Err(PgSqliteError::Protocol("Invalid FLOAT8 binary format".to_string()))
                     }
@@ -481,11 +498,10 @@ impl ExtendedFastPath {
                             bytes[4], bytes[5], bytes[6], bytes[7]
                         ]);
                         let days = i32::from_be_bytes([bytes[8], bytes[9], bytes[10], bytes[11]]);
-                        let _months = i32::from_be_bytes([bytes[12], bytes[13], bytes[14], bytes[15]]);
+                        let months = i32::from_be_bytes([bytes[12], bytes[13], bytes[14], bytes[15]]);
                         
-                        // Convert to total microseconds (simple intervals only)
-                        let total_micros = micros + (days as i64 * 86400 * 1_000_000);
-                        Ok(rusqlite::types::Value::Integer(total_micros))
+                        // Months have no fixed length, so the parts are kept in the canonical text form
+                        Ok(rusqlite::types::Value::Text(crate::types::datetime_utils::format_interval(months, days, micros)))
                     } else {
                         Err(PgSqliteError::Protocol("Invalid INTERVAL binary format".to_string()))
                     }
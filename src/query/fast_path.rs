@@ -95,7 +95,18 @@ pub fn can_use_fast_path_enhanced(query: &str) -> Option<FastPathQuery> {
        query.contains("LIMIT") ||
        query.contains("ORDER BY") ||
        query.contains("GROUP BY") ||
-       query.contains("HAVING") {
+       query.contains("HAVING") ||
+       crate::translator::DivisionTranslator::needs_translation(query) ||
+       crate::translator::OnlyTranslator::needs_translation(query) ||
+       crate::translator::DistinctFromTranslator::needs_translation(query) ||
+       crate::translator::InsertDefaultTranslator::needs_translation(query) ||
+       crate::translator::IdentityInsertTranslator::needs_translation(query) ||
+       crate::translator::TsMatchTranslator::needs_translation(query) ||
+       crate::translator::CollateTranslator::needs_translation(query) ||
+       crate::translator::RowComparisonTranslator::needs_translation(query) ||
+       crate::translator::SubstringTranslator::needs_translation(query) ||
+       crate::translator::PositionTranslator::needs_translation(query) ||
+       crate::translator::MakeIntervalTranslator::needs_translation(query) {
         return None;
     }
     
@@ -451,7 +462,7 @@ pub fn query_fast_path(
                                     );
                                     values.push(Some(formatted.into_bytes()));
                                 } else {
-                                    values.push(Some(f.to_string().into_bytes()));
+                                    values.push(Some(crate::types::numeric_utils::format_float(f).into_bytes()));
                                 }
                             },
                             ValueRef::Text(s) => values.push(Some(s.to_vec())),
@@ -699,7 +710,7 @@ fn execute_fast_select_with_params(
                         );
                         values.push(Some(formatted.into_bytes()));
                     } else {
-                        values.push(Some(f.to_string().into_bytes()));
+                        values.push(Some(crate::types::numeric_utils::format_float(f).into_bytes()));
                     }
                 },
                 ValueRef::Text(s) => values.push(Some(s.to_vec())),
@@ -817,7 +828,7 @@ fn execute_fast_select(
                         );
                         values.push(Some(formatted.into_bytes()));
                     } else {
-                        values.push(Some(f.to_string().into_bytes()));
+                        values.push(Some(crate::types::numeric_utils::format_float(f).into_bytes()));
                     }
                 },
                 ValueRef::Text(s) => values.push(Some(s.to_vec())),
@@ -921,6 +932,10 @@ mod tests {
         assert!(can_use_fast_path_enhanced("SELECT * FROM users ORDER BY name").is_none());
         assert!(can_use_fast_path_enhanced("SELECT * FROM users LIMIT 10").is_none());
         assert!(can_use_fast_path_enhanced("SELECT COUNT(*) FROM users GROUP BY status").is_none());
+
+        // Only a real division operator needs the division translation
+        assert!(can_use_fast_path_enhanced("SELECT * FROM files WHERE path = '/tmp/a.txt'").is_some());
+        assert!(can_use_fast_path_enhanced("SELECT price / 2 FROM products WHERE id = 5").is_none());
     }
     
     #[test]
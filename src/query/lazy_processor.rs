@@ -12,6 +12,7 @@ pub struct LazyQueryProcessor<'a> {
     needs_cast_translation: bool,
     needs_decimal_rewrite: Option<bool>,
     needs_regex_translation: bool,
+    needs_like_translation: bool,
     needs_schema_translation: bool,
     needs_numeric_cast_translation: bool,
     needs_array_translation: bool,
@@ -20,6 +21,31 @@ pub struct LazyQueryProcessor<'a> {
     needs_datetime_translation: bool,
     needs_pg_table_is_visible_translation: bool,
     needs_session_identifier_translation: bool,
+    needs_pg_typeof_translation: bool,
+    needs_regexp_matches_translation: bool,
+    needs_range_predicate_translation: bool,
+    needs_row_to_json_translation: bool,
+    needs_distinct_aggregate_translation: bool,
+    needs_date_comparison_translation: bool,
+    needs_values_translation: bool,
+    needs_tablesample_translation: bool,
+    needs_fetch_first_translation: bool,
+    needs_lateral_translation: bool,
+    needs_range_translation: bool,
+    needs_point_translation: bool,
+    needs_bit_string_translation: bool,
+    needs_division_translation: bool,
+    needs_only_translation: bool,
+    needs_group_by_translation: bool,
+    needs_distinct_from_translation: bool,
+    needs_insert_default_translation: bool,
+    needs_identity_override_translation: bool,
+    needs_ts_match_translation: bool,
+    needs_collate_translation: bool,
+    needs_row_comparison_translation: bool,
+    needs_substring_translation: bool,
+    needs_position_translation: bool,
+    needs_make_interval_translation: bool,
 }
 
 impl<'a> LazyQueryProcessor<'a> {
@@ -27,13 +53,42 @@ impl<'a> LazyQueryProcessor<'a> {
     pub fn new(query: &'a str) -> Self {
         // Fast path for simple queries - check if query contains any special characters
         // that might require translation
-        let quick_check = query.contains("::") || query.contains(" ~ ") || query.contains("pg_catalog") ||
+        let quick_check = query.contains("::") || query.contains(" ~ ") || query.contains("~~") || query.contains("pg_catalog") ||
                          query.contains("PG_CATALOG") || query.contains("[") || query.contains("ANY(") ||
+                         query.contains("ARRAY(") || query.contains("array(") ||
                          query.contains("ALL(") || query.contains("@>") || query.contains("<@") ||
                          query.contains("&&") || query.contains("DELETE") || query.contains("UPDATE") ||
                          query.contains("AT TIME ZONE") || query.contains("pg_table_is_visible") ||
                          query.contains("current_user") || query.contains("session_user") ||
-                         query.contains("CURRENT_USER") || query.contains("SESSION_USER");
+                         query.contains("CURRENT_USER") || query.contains("SESSION_USER") ||
+                         query.contains("ILIKE") || query.contains("ilike") ||
+                         query.contains("ESCAPE") || query.contains("escape") ||
+                         query.contains("pg_typeof") || query.contains("PG_TYPEOF") ||
+                         query.contains("regexp_matches") || query.contains("REGEXP_MATCHES") ||
+                         query.contains("OVERLAPS") || query.contains("overlaps") ||
+                         query.contains("SYMMETRIC") || query.contains("symmetric") ||
+                         query.contains("to_json") || query.contains("TO_JSON") ||
+                         query.contains("_agg") || query.contains("_AGG") ||
+                         (query.contains('\'') && crate::translator::DateComparisonTranslator::needs_translation(query)) ||
+                         crate::translator::ValuesTranslator::needs_translation(query) ||
+                         query.contains("TABLESAMPLE") || query.contains("tablesample") ||
+                         crate::translator::FetchFirstTranslator::needs_translation(query) ||
+                         crate::translator::LateralJoinTranslator::needs_translation(query) ||
+                         crate::translator::RangeTranslator::needs_translation(query) ||
+                         crate::translator::PointTranslator::needs_translation(query) ||
+                         crate::translator::BitStringTranslator::needs_translation(query) ||
+                         crate::translator::DivisionTranslator::needs_translation(query) ||
+                         crate::translator::OnlyTranslator::needs_translation(query) ||
+                         crate::translator::GroupByTranslator::needs_translation(query) ||
+                         crate::translator::DistinctFromTranslator::needs_translation(query) ||
+                         crate::translator::InsertDefaultTranslator::needs_translation(query) ||
+                         crate::translator::IdentityInsertTranslator::needs_translation(query) ||
+                         crate::translator::TsMatchTranslator::needs_translation(query) ||
+                         crate::translator::CollateTranslator::needs_translation(query) ||
+                         crate::translator::RowComparisonTranslator::needs_translation(query) ||
+                         crate::translator::SubstringTranslator::needs_translation(query) ||
+                         crate::translator::PositionTranslator::needs_translation(query) ||
+                         crate::translator::MakeIntervalTranslator::needs_translation(query);
         
         if !quick_check {
             // Fast path - no translation needed
@@ -43,6 +98,7 @@ impl<'a> LazyQueryProcessor<'a> {
                 needs_cast_translation: false,
                 needs_decimal_rewrite: None,
                 needs_regex_translation: false,
+                needs_like_translation: false,
                 needs_schema_translation: false,
                 needs_numeric_cast_translation: false,
                 needs_array_translation: false,
@@ -51,6 +107,31 @@ impl<'a> LazyQueryProcessor<'a> {
                 needs_datetime_translation: false,
                 needs_pg_table_is_visible_translation: false,
                 needs_session_identifier_translation: false,
+                needs_pg_typeof_translation: false,
+                needs_regexp_matches_translation: false,
+                needs_range_predicate_translation: false,
+                needs_row_to_json_translation: false,
+                needs_distinct_aggregate_translation: false,
+                needs_date_comparison_translation: false,
+                needs_values_translation: false,
+                needs_tablesample_translation: false,
+                needs_fetch_first_translation: false,
+                needs_lateral_translation: false,
+                needs_range_translation: false,
+                needs_point_translation: false,
+                needs_bit_string_translation: false,
+                needs_division_translation: false,
+                needs_only_translation: false,
+                needs_group_by_translation: false,
+                needs_distinct_from_translation: false,
+                needs_insert_default_translation: false,
+                needs_identity_override_translation: false,
+                needs_ts_match_translation: false,
+                needs_collate_translation: false,
+                needs_row_comparison_translation: false,
+                needs_substring_translation: false,
+                needs_position_translation: false,
+                needs_make_interval_translation: false,
             };
         }
         
@@ -62,15 +143,42 @@ impl<'a> LazyQueryProcessor<'a> {
             needs_decimal_rewrite: None,
             needs_regex_translation: query.contains(" ~ ") || query.contains(" !~ ") || 
                                      query.contains(" ~* ") || query.contains(" !~* "),
+            needs_like_translation: crate::translator::LikeTranslator::needs_translation(query),
             needs_schema_translation: query.contains("pg_catalog.") || query.contains("PG_CATALOG."),
             needs_numeric_cast_translation: crate::translator::NumericCastTranslator::needs_translation(query),
-            needs_array_translation: query.contains("[") || query.contains("ANY(") || query.contains("ALL(") ||
+            needs_array_translation: query.contains("[") || query.contains("ARRAY(") || query.contains("array(") ||
+                                    query.contains("ANY(") || query.contains("ALL(") ||
                                     query.contains("@>") || query.contains("<@") || query.contains("&&"),
             needs_delete_using_translation: BatchDeleteTranslator::contains_batch_delete(query),
             needs_batch_update_translation: BatchUpdateTranslator::contains_batch_update(query),
             needs_datetime_translation: crate::translator::DateTimeTranslator::needs_translation(query),
             needs_pg_table_is_visible_translation: query.contains("pg_table_is_visible"),
             needs_session_identifier_translation: crate::translator::SessionIdentifierTranslator::needs_translation(query),
+            needs_pg_typeof_translation: crate::translator::PgTypeofTranslator::needs_translation(query),
+            needs_regexp_matches_translation: crate::translator::RegexpMatchesTranslator::needs_translation(query),
+            needs_range_predicate_translation: crate::translator::RangePredicateTranslator::needs_translation(query),
+            needs_row_to_json_translation: crate::translator::RowToJsonTranslator::needs_row_reference_translation(query),
+            needs_distinct_aggregate_translation: crate::translator::DistinctAggregateTranslator::needs_translation(query),
+            needs_date_comparison_translation: crate::translator::DateComparisonTranslator::needs_translation(query),
+            needs_values_translation: crate::translator::ValuesTranslator::needs_translation(query),
+            needs_tablesample_translation: crate::translator::TablesampleTranslator::needs_translation(query),
+            needs_fetch_first_translation: crate::translator::FetchFirstTranslator::needs_translation(query),
+            needs_lateral_translation: crate::translator::LateralJoinTranslator::needs_translation(query),
+            needs_range_translation: crate::translator::RangeTranslator::needs_translation(query),
+            needs_point_translation: crate::translator::PointTranslator::needs_translation(query),
+            needs_bit_string_translation: crate::translator::BitStringTranslator::needs_translation(query),
+            needs_division_translation: crate::translator::DivisionTranslator::needs_translation(query),
+            needs_only_translation: crate::translator::OnlyTranslator::needs_translation(query),
+            needs_group_by_translation: crate::translator::GroupByTranslator::needs_translation(query),
+            needs_distinct_from_translation: crate::translator::DistinctFromTranslator::needs_translation(query),
+            needs_insert_default_translation: crate::translator::InsertDefaultTranslator::needs_translation(query),
+            needs_identity_override_translation: crate::translator::IdentityInsertTranslator::needs_translation(query),
+            needs_ts_match_translation: crate::translator::TsMatchTranslator::needs_translation(query),
+            needs_collate_translation: crate::translator::CollateTranslator::needs_translation(query),
+            needs_row_comparison_translation: crate::translator::RowComparisonTranslator::needs_translation(query),
+            needs_substring_translation: crate::translator::SubstringTranslator::needs_translation(query),
+            needs_position_translation: crate::translator::PositionTranslator::needs_translation(query),
+            needs_make_interval_translation: crate::translator::MakeIntervalTranslator::needs_translation(query),
         }
     }
     
@@ -89,6 +197,10 @@ impl<'a> LazyQueryProcessor<'a> {
             return true;
         }
         
+        if self.needs_like_translation {
+            return true;
+        }
+        
         if self.needs_schema_translation {
             return true;
         }
@@ -120,6 +232,42 @@ impl<'a> LazyQueryProcessor<'a> {
         if self.needs_session_identifier_translation {
             return true;
         }
+
+        if self.needs_pg_typeof_translation {
+            return true;
+        }
+
+        if self.needs_regexp_matches_translation {
+            return true;
+        }
+
+        if self.needs_range_predicate_translation {
+            return true;
+        }
+
+        if self.needs_row_to_json_translation {
+            return true;
+        }
+
+        if self.needs_distinct_aggregate_translation {
+            return true;
+        }
+
+        if self.needs_date_comparison_translation {
+            return true;
+        }
+
+        if self.needs_values_translation || self.needs_tablesample_translation || self.needs_fetch_first_translation ||
+           self.needs_lateral_translation ||
+           self.needs_range_translation || self.needs_point_translation || self.needs_bit_string_translation ||
+           self.needs_division_translation ||
+           self.needs_only_translation || self.needs_group_by_translation || self.needs_distinct_from_translation ||
+           self.needs_insert_default_translation || self.needs_identity_override_translation ||
+           self.needs_ts_match_translation || self.needs_collate_translation ||
+           self.needs_row_comparison_translation || self.needs_substring_translation ||
+           self.needs_position_translation || self.needs_make_interval_translation {
+            return true;
+        }
         
         // Check decimal rewrite need if not already determined
         if let Some(needs_decimal) = self.needs_decimal_rewrite {
@@ -152,11 +300,23 @@ impl<'a> LazyQueryProcessor<'a> {
         }
         
         // Fast path - if no translation is needed, return original query directly
-        if !self.needs_cast_translation && !self.needs_regex_translation &&
+        if !self.needs_cast_translation && !self.needs_regex_translation && !self.needs_like_translation &&
            !self.needs_schema_translation && !self.needs_numeric_cast_translation &&
            !self.needs_array_translation && !self.needs_delete_using_translation &&
            !self.needs_batch_update_translation && !self.needs_datetime_translation &&
-           !self.needs_pg_table_is_visible_translation && !self.needs_session_identifier_translation {
+           !self.needs_pg_table_is_visible_translation && !self.needs_session_identifier_translation &&
+           !self.needs_pg_typeof_translation && !self.needs_regexp_matches_translation &&
+           !self.needs_range_predicate_translation && !self.needs_row_to_json_translation &&
+           !self.needs_distinct_aggregate_translation && !self.needs_date_comparison_translation &&
+           !self.needs_values_translation && !self.needs_tablesample_translation &&
+           !self.needs_fetch_first_translation && !self.needs_lateral_translation && !self.needs_range_translation &&
+           !self.needs_point_translation && !self.needs_bit_string_translation &&
+           !self.needs_division_translation &&
+           !self.needs_only_translation && !self.needs_group_by_translation && !self.needs_distinct_from_translation &&
+           !self.needs_insert_default_translation && !self.needs_identity_override_translation &&
+           !self.needs_ts_match_translation && !self.needs_collate_translation &&
+           !self.needs_row_comparison_translation && !self.needs_substring_translation &&
+           !self.needs_position_translation && !self.needs_make_interval_translation {
             // Check if this is an INSERT that might need decimal rewrite
             if matches!(QueryTypeDetector::detect_query_type(self.original_query), QueryType::Insert) {
                 if let Some(table_name) = extract_insert_table_name(self.original_query)
@@ -179,6 +339,95 @@ impl<'a> LazyQueryProcessor<'a> {
             current_query = Cow::Owned(translated);
         }
 
+        // Step 1.2: ONLY in front of table references is dropped, there are no child tables
+        if self.needs_only_translation {
+            tracing::debug!("Before ONLY translation: {}", current_query);
+            let translated = crate::translator::OnlyTranslator::translate_query(&current_query);
+            tracing::debug!("After ONLY translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+
+        // Step 1.25: GROUP BY positions and output column aliases become their expressions,
+        // before later steps rewrite the select list
+        if self.needs_group_by_translation {
+            tracing::debug!("Before GROUP BY translation: {}", current_query);
+            let translated = crate::translator::GroupByTranslator::translate_query(&current_query, conn);
+            tracing::debug!("After GROUP BY translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+
+        // Step 1.3: IS [NOT] DISTINCT FROM becomes SQLite's NULL-safe IS NOT / IS
+        if self.needs_distinct_from_translation {
+            tracing::debug!("Before IS DISTINCT FROM translation: {}", current_query);
+            let translated = crate::translator::DistinctFromTranslator::translate_query(&current_query);
+            tracing::debug!("After IS DISTINCT FROM translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+
+        // Step 1.4: DEFAULT in INSERT ... VALUES becomes the column's default expression
+        if self.needs_insert_default_translation {
+            tracing::debug!("Before INSERT DEFAULT translation: {}", current_query);
+            let translated = crate::translator::InsertDefaultTranslator::translate_query(&current_query, conn);
+            tracing::debug!("After INSERT DEFAULT translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+
+        // Step 1.45: OVERRIDING SYSTEM/USER VALUE marks or drops the identity column values
+        if self.needs_identity_override_translation {
+            tracing::debug!("Before OVERRIDING translation: {}", current_query);
+            let translated = crate::translator::IdentityInsertTranslator::translate_query(&current_query, conn);
+            tracing::debug!("After OVERRIDING translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+
+        // Step 1.46: the @@ match operator becomes a pgsqlite_ts_match() call
+        if self.needs_ts_match_translation {
+            tracing::debug!("Before @@ translation: {}", current_query);
+            let translated = crate::translator::TsMatchTranslator::translate_query(&current_query);
+            tracing::debug!("After @@ translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+
+        // Step 1.47: PostgreSQL collation names become SQLite collations
+        if self.needs_collate_translation {
+            tracing::debug!("Before COLLATE translation: {}", current_query);
+            let translated = crate::translator::CollateTranslator::translate_query(&current_query);
+            tracing::debug!("After COLLATE translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+
+        // Step 1.48: row comparisons become chained scalar comparisons
+        if self.needs_row_comparison_translation {
+            tracing::debug!("Before row comparison translation: {}", current_query);
+            let translated = crate::translator::RowComparisonTranslator::translate_query(&current_query);
+            tracing::debug!("After row comparison translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+
+        // Step 1.49: substring(... FROM ... FOR ...) becomes substr() or a pattern function
+        if self.needs_substring_translation {
+            tracing::debug!("Before substring translation: {}", current_query);
+            let translated = crate::translator::SubstringTranslator::translate_query(&current_query);
+            tracing::debug!("After substring translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+
+        // Step 1.495: position(a IN b) becomes strpos(b, a)
+        if self.needs_position_translation {
+            tracing::debug!("Before position translation: {}", current_query);
+            let translated = crate::translator::PositionTranslator::translate_query(&current_query);
+            tracing::debug!("After position translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+
+        // Step 1.496: make_interval(days => 5) becomes a positional call
+        if self.needs_make_interval_translation {
+            tracing::debug!("Before make_interval translation: {}", current_query);
+            let translated = crate::translator::MakeIntervalTranslator::translate_query(&current_query);
+            tracing::debug!("After make_interval translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+
         // Step 1.5: Session identifier translation if needed (add parentheses to current_user, session_user)
         if self.needs_session_identifier_translation {
             tracing::debug!("Before session identifier translation: {}", current_query);
@@ -195,6 +444,129 @@ impl<'a> LazyQueryProcessor<'a> {
             current_query = Cow::Owned(translated);
         }
         
+        // Step 2.5: pg_typeof() resolution must see its argument before casts are rewritten
+        if self.needs_pg_typeof_translation {
+            tracing::debug!("Before pg_typeof translation: {}", current_query);
+            let translated = crate::translator::PgTypeofTranslator::translate_query(&current_query, conn);
+            tracing::debug!("After pg_typeof translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
+        // Step 2.6: regexp_matches() in SELECT lists becomes a json_each join
+        if self.needs_regexp_matches_translation {
+            tracing::debug!("Before regexp_matches translation: {}", current_query);
+            match crate::translator::RegexpMatchesTranslator::translate_query(&current_query) {
+                Ok(translated) => {
+                    tracing::debug!("After regexp_matches translation: {}", translated);
+                    current_query = Cow::Owned(translated);
+                }
+                Err(e) => {
+                    tracing::warn!("Failed to translate regexp_matches: {}", e);
+                }
+            }
+        }
+        
+        // Step 2.64: Range operators and '...'::int4range casts become range function calls
+        if self.needs_range_translation {
+            tracing::debug!("Before range translation: {}", current_query);
+            let translated = crate::translator::RangeTranslator::translate_query(&current_query, conn);
+            tracing::debug!("After range translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
+        // Step 2.645: Point casts and the <-> distance operator become point function calls
+        if self.needs_point_translation {
+            tracing::debug!("Before point translation: {}", current_query);
+            let translated = crate::translator::PointTranslator::translate_query(&current_query);
+            tracing::debug!("After point translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
+        // Step 2.646: Bit string literals, casts and bitwise operators become bit function calls
+        if self.needs_bit_string_translation {
+            tracing::debug!("Before bit string translation: {}", current_query);
+            let translated = crate::translator::BitStringTranslator::translate_query(&current_query, conn);
+            tracing::debug!("After bit string translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
+        // Step 2.65: Date and timestamp literals compared with such columns become their stored integers
+        // (before BETWEEN SYMMETRIC wraps its bounds in min/max)
+        if self.needs_date_comparison_translation {
+            tracing::debug!("Before date comparison translation: {}", current_query);
+            let translated = crate::translator::DateComparisonTranslator::translate_query(&current_query, conn);
+            tracing::debug!("After date comparison translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
+        // Step 2.66: VALUES derived tables get their column aliases as SELECT aliases
+        if self.needs_values_translation {
+            tracing::debug!("Before VALUES alias translation: {}", current_query);
+            let translated = crate::translator::ValuesTranslator::translate_query(&current_query);
+            tracing::debug!("After VALUES alias translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
+        // Step 2.67: TABLESAMPLE becomes a subquery keeping a fraction of the rows
+        if self.needs_tablesample_translation {
+            tracing::debug!("Before TABLESAMPLE translation: {}", current_query);
+            let translated = crate::translator::TablesampleTranslator::translate_query(&current_query)
+                .map_err(|e| rusqlite::Error::SqliteFailure(
+                    rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                    Some(e.to_string())
+                ))?;
+            tracing::debug!("After TABLESAMPLE translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
+        // Step 2.68: FETCH FIRST/NEXT, OFFSET ... ROWS and LIMIT ALL become LIMIT/OFFSET
+        if self.needs_fetch_first_translation {
+            tracing::debug!("Before FETCH FIRST translation: {}", current_query);
+            let translated = crate::translator::FetchFirstTranslator::translate_query(&current_query)
+                .map_err(|e| rusqlite::Error::SqliteFailure(
+                    rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                    Some(e.to_string())
+                ))?;
+            tracing::debug!("After FETCH FIRST translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
+        // Step 2.69: LATERAL subqueries become joins on the rows they pick for each outer row
+        if self.needs_lateral_translation {
+            tracing::debug!("Before LATERAL translation: {}", current_query);
+            let translated = crate::translator::LateralJoinTranslator::translate_query(&current_query);
+            tracing::debug!("After LATERAL translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
+        // Step 2.7: OVERLAPS and BETWEEN SYMMETRIC become plain comparisons
+        if self.needs_range_predicate_translation {
+            tracing::debug!("Before range predicate translation: {}", current_query);
+            let translated = crate::translator::RangePredicateTranslator::translate_query(&current_query);
+            tracing::debug!("After range predicate translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
+        // Step 2.8: Whole-row row_to_json()/to_json() expand into json_object() over the table's columns
+        if self.needs_row_to_json_translation {
+            tracing::debug!("Before row_to_json translation: {}", current_query);
+            let translated = crate::translator::RowToJsonTranslator::translate_row_references(&current_query, conn);
+            tracing::debug!("After row_to_json translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
+        // Step 2.9: DISTINCT inside array_agg/string_agg uses the deduplicating aggregates
+        if self.needs_distinct_aggregate_translation {
+            tracing::debug!("Before DISTINCT aggregate translation: {}", current_query);
+            let translated = crate::translator::DistinctAggregateTranslator::translate_query(&current_query)
+                .map_err(|e| rusqlite::Error::SqliteFailure(
+                    rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                    Some(e.to_string())
+                ))?;
+            tracing::debug!("After DISTINCT aggregate translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
         // Step 3: Numeric cast translation MUST come before general cast translation
         // to ensure CAST(x AS NUMERIC(p,s)) is handled properly
         if self.needs_numeric_cast_translation {
@@ -253,6 +625,20 @@ impl<'a> LazyQueryProcessor<'a> {
             }
         }
         
+        // Step 5.5: LIKE operator translation (~~, !~~, ~~*, !~~*, ILIKE) if needed
+        if self.needs_like_translation {
+            tracing::debug!("Before LIKE translation: {}", current_query);
+            match crate::translator::LikeTranslator::translate_query(&current_query) {
+                Ok(translated) => {
+                    tracing::debug!("After LIKE translation: {}", translated);
+                    current_query = Cow::Owned(translated);
+                }
+                Err(e) => {
+                    tracing::warn!("Failed to translate LIKE operators: {}", e);
+                }
+            }
+        }
+        
         // Step 6: Array translation if needed
         if self.needs_array_translation {
             tracing::debug!("Before array translation: {}", current_query);
@@ -349,6 +735,14 @@ impl<'a> LazyQueryProcessor<'a> {
             self.needs_decimal_rewrite = Some(false);
         }
         
+        // Step 11: Division semantics, after decimal rewriting has taken the NUMERIC operands
+        if self.needs_division_translation {
+            tracing::debug!("Before division translation: {}", current_query);
+            let translated = crate::translator::DivisionTranslator::translate_query(&current_query);
+            tracing::debug!("After division translation: {}", translated);
+            current_query = Cow::Owned(translated);
+        }
+        
         // Store the processed query
         self.translated_query = Some(current_query);
         
@@ -6,8 +6,18 @@ pub mod fast_path;
 pub mod extended_fast_path;
 pub mod query_type_detection;
 pub mod comment_stripper;
+pub mod statement_splitter;
+pub mod dollar_quote;
+pub mod do_block;
 pub mod lazy_processor;
 pub mod set_handler;
+pub mod truncate_handler;
+pub mod analyze_handler;
+pub mod vacuum_handler;
+pub mod create_table_as_handler;
+pub mod copy_handler;
+pub mod prepare_handler;
+pub mod transaction_mode;
 pub mod simple_query_detector;
 pub mod parameter_parser;
 pub mod query_processor;
@@ -27,8 +37,17 @@ pub use fast_path::{
 };
 pub use query_type_detection::{QueryTypeDetector, QueryType};
 pub use comment_stripper::strip_sql_comments;
+pub use statement_splitter::split_statements;
+pub use dollar_quote::convert_dollar_quotes;
 pub use lazy_processor::LazyQueryProcessor;
 pub use set_handler::SetHandler;
+pub use truncate_handler::TruncateHandler;
+pub use analyze_handler::AnalyzeHandler;
+pub use vacuum_handler::VacuumHandler;
+pub use create_table_as_handler::CreateTableAsHandler;
+pub use copy_handler::CopyHandler;
+pub use prepare_handler::PrepareHandler;
+pub use transaction_mode::{TransactionMode, IsolationLevel};
 pub use query_processor::process_query;
 pub use parameter_parser::ParameterParser;
 pub use pattern_optimizer::{QueryPatternOptimizer, QueryPattern, OptimizationHints, QueryComplexity, ResultSize};
\ No newline at end of file
@@ -0,0 +1,221 @@
+use crate::error::PgError;
+use crate::protocol::BackendMessage;
+use crate::session::{PreparedStatement, SessionState};
+use crate::types::SchemaTypeMapper;
+use crate::translator::sql_scan::split_top_level;
+use super::parameter_parser::ParameterParser;
+use std::sync::Arc;
+use crate::PgSqliteError;
+use tokio_util::codec::Framed;
+use futures::SinkExt;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+
+static PREPARE_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^\s*PREPARE\s+("(?:[^"]|"")+"|\w+)(?:\s*\((.*?)\))?\s+AS\s+(.+?)\s*;?\s*$"#).unwrap()
+});
+
+static EXECUTE_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^\s*EXECUTE\s+("(?:[^"]|"")+"|\w+)\s*(?:\((.*)\))?\s*;?\s*$"#).unwrap()
+});
+
+static DEALLOCATE_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^\s*DEALLOCATE\s+(?:PREPARE\s+)?("(?:[^"]|"")+"|\w+)\s*;?\s*$"#).unwrap()
+});
+
+pub struct PrepareHandler;
+
+impl PrepareHandler {
+    /// Check if this is a PREPARE command (PREPARE TRANSACTION is two-phase commit, not this)
+    pub fn is_prepare(query: &str) -> bool {
+        let mut words = query.split_whitespace();
+        words.next().is_some_and(|word| word.eq_ignore_ascii_case("PREPARE"))
+            && !words.next().is_some_and(|word| word.eq_ignore_ascii_case("TRANSACTION"))
+    }
+
+    /// Check if this is an EXECUTE command
+    pub fn is_execute(query: &str) -> bool {
+        query.split_whitespace().next().is_some_and(|word| word.eq_ignore_ascii_case("EXECUTE"))
+    }
+
+    /// Check if this is a DEALLOCATE command
+    pub fn is_deallocate(query: &str) -> bool {
+        query.split_whitespace().next().is_some_and(|word| word.eq_ignore_ascii_case("DEALLOCATE"))
+    }
+
+    /// Handle PREPARE name [(type [, ...])] AS statement
+    ///
+    /// The statement is kept under its name with the other prepared statements of the session,
+    /// as PostgreSQL shares one namespace between SQL and protocol-level prepared statements.
+    pub async fn handle_prepare<T>(
+        framed: &mut Framed<T, crate::protocol::PostgresCodec>,
+        session: &Arc<SessionState>,
+        query: &str,
+    ) -> Result<(), PgSqliteError>
+    where
+        T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
+    {
+        let caps = PREPARE_PATTERN.captures(query)
+            .ok_or_else(|| PgError::SyntaxError {
+                message: "syntax error at or near \"PREPARE\"".to_string(),
+                position: None,
+            })?;
+        let name = Self::statement_name(&caps[1]);
+        let param_types: Vec<i32> = caps.get(2)
+            .map(|types| split_top_level(types.as_str()))
+            .unwrap_or_default()
+            .iter()
+            .map(|pg_type| SchemaTypeMapper::pg_type_string_to_oid(pg_type))
+            .collect();
+        let statement = caps[3].to_string();
+        debug!("PREPARE {} ({:?}) AS {}", name, param_types, statement);
+
+        let mut statements = session.prepared_statements.write().await;
+        if statements.contains_key(&name) {
+            return Err(PgError::Generic {
+                code: "42P05".to_string(), // duplicate_prepared_statement
+                message: format!("prepared statement \"{name}\" already exists"),
+            }.into());
+        }
+        statements.insert(name, PreparedStatement {
+            query: statement,
+            translated_query: None,
+            param_formats: vec![0; param_types.len()],
+            param_types,
+            field_descriptions: vec![],
+            translation_metadata: None,
+        });
+        drop(statements);
+
+        framed.send(BackendMessage::CommandComplete {
+            tag: "PREPARE".to_string()
+        }).await.map_err(PgSqliteError::Io)?;
+
+        Ok(())
+    }
+
+    /// Handle DEALLOCATE [PREPARE] { name | ALL }
+    pub async fn handle_deallocate<T>(
+        framed: &mut Framed<T, crate::protocol::PostgresCodec>,
+        session: &Arc<SessionState>,
+        query: &str,
+    ) -> Result<(), PgSqliteError>
+    where
+        T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
+    {
+        let caps = DEALLOCATE_PATTERN.captures(query)
+            .ok_or_else(|| PgError::SyntaxError {
+                message: "syntax error at or near \"DEALLOCATE\"".to_string(),
+                position: None,
+            })?;
+
+        let tag = if caps[1].eq_ignore_ascii_case("ALL") {
+            session.prepared_statements.write().await.clear();
+            "DEALLOCATE ALL"
+        } else {
+            let name = Self::statement_name(&caps[1]);
+            if session.prepared_statements.write().await.remove(&name).is_none() {
+                return Err(Self::unknown_statement(&name).into());
+            }
+            "DEALLOCATE"
+        };
+        debug!("{} {}", tag, &caps[1]);
+
+        framed.send(BackendMessage::CommandComplete {
+            tag: tag.to_string()
+        }).await.map_err(PgSqliteError::Io)?;
+
+        Ok(())
+    }
+
+    /// The statement EXECUTE name [(argument [, ...])] runs: the prepared statement with each
+    /// argument in place of its parameter, cast to the parameter's declared type
+    pub async fn bind_execute(session: &Arc<SessionState>, query: &str) -> Result<String, PgSqliteError> {
+        let caps = EXECUTE_PATTERN.captures(query)
+            .ok_or_else(|| PgError::SyntaxError {
+                message: "syntax error at or near \"EXECUTE\"".to_string(),
+                position: None,
+            })?;
+        let name = Self::statement_name(&caps[1]);
+        let args = caps.get(2).map(|args| split_top_level(args.as_str())).unwrap_or_default();
+
+        let statements = session.prepared_statements.read().await;
+        let statement = statements.get(&name).ok_or_else(|| Self::unknown_statement(&name))?;
+
+        let expected = ParameterParser::find_parameters(&statement.query).into_iter().max()
+            .unwrap_or(0)
+            .max(statement.param_types.len());
+        if args.len() != expected {
+            return Err(PgError::Generic {
+                code: "42601".to_string(), // syntax_error
+                message: format!("wrong number of parameters for prepared statement \"{name}\""),
+            }.into());
+        }
+
+        let values: Vec<String> = args.iter().enumerate().map(|(i, arg)| {
+            match statement.param_types.get(i) {
+                Some(&oid) if oid != 0 => format!("({arg})::{}", SchemaTypeMapper::pg_oid_to_type_name(oid)),
+                _ => format!("({arg})"),
+            }
+        }).collect();
+        let bound = ParameterParser::substitute_parameters(&statement.query, &values)
+            .map_err(PgSqliteError::Protocol)?;
+        debug!("EXECUTE {} runs: {}", name, bound);
+
+        Ok(bound)
+    }
+
+    fn unknown_statement(name: &str) -> PgError {
+        PgError::Generic {
+            code: "26000".to_string(), // invalid_sql_statement_name
+            message: format!("prepared statement \"{name}\" does not exist"),
+        }
+    }
+
+    /// Unquoted names fold to lowercase like other identifiers
+    fn statement_name(name: &str) -> String {
+        match name.strip_prefix('"').and_then(|n| n.strip_suffix('"')) {
+            Some(quoted) => quoted.replace("\"\"", "\""),
+            None => name.to_lowercase(),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_is_prepare_commands() {
+        assert!(PrepareHandler::is_prepare("PREPARE q (int) AS SELECT $1"));
+        assert!(!PrepareHandler::is_prepare("PREPARE TRANSACTION 'tx1'"));
+        assert!(PrepareHandler::is_execute("execute q(1)"));
+        assert!(PrepareHandler::is_deallocate("DEALLOCATE ALL"));
+        assert!(!PrepareHandler::is_execute("SELECT 'EXECUTE'"));
+    }
+
+    #[test]
+    fn test_prepare_pattern() {
+        let caps = PREPARE_PATTERN.captures("PREPARE Find (numeric(10,2), text) AS SELECT * FROM t WHERE a = $1 AND b = $2;").unwrap();
+        assert_eq!(PrepareHandler::statement_name(&caps[1]), "find");
+        assert_eq!(split_top_level(&caps[2]), vec!["numeric(10,2)", "text"]);
+        assert_eq!(&caps[3], "SELECT * FROM t WHERE a = $1 AND b = $2");
+
+        let caps = PREPARE_PATTERN.captures("PREPARE \"Find\" AS SELECT (1)").unwrap();
+        assert_eq!(PrepareHandler::statement_name(&caps[1]), "Find");
+        assert!(caps.get(2).is_none());
+        assert_eq!(&caps[3], "SELECT (1)");
+    }
+
+    #[test]
+    fn test_split_arguments() {
+        let caps = EXECUTE_PATTERN.captures("EXECUTE q (1, 'a, b', lower('X'), NULL)").unwrap();
+        assert_eq!(
+            split_top_level(&caps[2]),
+            vec!["1", "'a, b'", "lower('X')", "NULL"]
+        );
+        assert!(EXECUTE_PATTERN.captures("EXECUTE q").unwrap().get(2).is_none());
+        assert!(split_top_level("").is_empty());
+    }
+}
@@ -23,6 +23,7 @@ impl QueryTypeDetector {
                 b"UPDATE" | b"update" | b"Update" => return QueryType::Update,
                 b"DELETE" | b"delete" | b"Delete" => return QueryType::Delete,
                 b"CREATE" | b"create" | b"Create" => return QueryType::Create,
+                b"VALUES" | b"values" | b"Values" => return QueryType::Select,
                 _ => {}
             }
         }
@@ -76,7 +77,8 @@ impl QueryTypeDetector {
         
         // Fall back to eq_ignore_ascii_case for less common or mixed case patterns
         if (trimmed.len() >= 4 && trimmed[..4].eq_ignore_ascii_case("WITH")) ||
-           (trimmed.len() >= 6 && trimmed[..6].eq_ignore_ascii_case("SELECT")) {
+           (trimmed.len() >= 6 && trimmed[..6].eq_ignore_ascii_case("SELECT")) ||
+           (trimmed.len() >= 6 && trimmed[..6].eq_ignore_ascii_case("VALUES")) {
             QueryType::Select
         } else if trimmed.len() >= 6 && trimmed[..6].eq_ignore_ascii_case("INSERT") {
             QueryType::Insert
@@ -219,6 +221,7 @@ mod tests {
         assert_eq!(QueryTypeDetector::detect_query_type("select * from users"), QueryType::Select);
         assert_eq!(QueryTypeDetector::detect_query_type("Select * From users"), QueryType::Select);
         assert_eq!(QueryTypeDetector::detect_query_type("SeLeCt * from users"), QueryType::Select);
+        assert_eq!(QueryTypeDetector::detect_query_type("VALUES (1, 'a')"), QueryType::Select);
         
         assert_eq!(QueryTypeDetector::detect_query_type("INSERT INTO table VALUES (1)"), QueryType::Insert);
         assert_eq!(QueryTypeDetector::detect_query_type("insert into table values (1)"), QueryType::Insert);
@@ -1,5 +1,7 @@
+use crate::error::PgError;
 use crate::protocol::BackendMessage;
-use crate::session::SessionState;
+use crate::session::{DbHandler, SessionState};
+use crate::validator::ConstraintViolationMapper;
 use std::sync::Arc;
 use crate::PgSqliteError;
 use tokio_util::codec::Framed;
@@ -16,6 +18,18 @@ static SET_PARAMETER_PATTERN: Lazy<Regex> = Lazy::new(|| {
     Regex::new(r"(?i)^\s*SET\s+(\w+)(?:\s*=\s*|\s+TO\s+)(.+)$").unwrap()
 });
 
+static SET_ROLE_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*SET\s+(?:(?:SESSION|LOCAL)\s+)?ROLE(?:\s*=\s*|\s+TO\s+|\s+)(.+?)\s*;?\s*$").unwrap()
+});
+
+static RESET_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*RESET\s+(\w+)\s*;?\s*$").unwrap()
+});
+
+static SET_CONSTRAINTS_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*SET\s+CONSTRAINTS\s+(.+?)\s+(DEFERRED|IMMEDIATE)\s*;?\s*$").unwrap()
+});
+
 static SHOW_PARAMETER_PATTERN: Lazy<Regex> = Lazy::new(|| {
     Regex::new(r"(?i)^\s*SHOW\s+(.+?)\s*$").unwrap()
 });
@@ -27,7 +41,53 @@ impl SetHandler {
     pub fn is_set_command(query: &str) -> bool {
         let trimmed = query.trim();
         let upper = trimmed.to_uppercase();
-        upper.starts_with("SET ") || upper.starts_with("SHOW ")
+        upper.starts_with("SET ") || upper.starts_with("SHOW ") || upper.starts_with("RESET ")
+    }
+
+    /// Check if this is a SET CONSTRAINTS command
+    pub fn is_set_constraints(query: &str) -> bool {
+        SET_CONSTRAINTS_PATTERN.is_match(query.trim())
+    }
+
+    /// Handle SET CONSTRAINTS { ALL | name [, ...] } { DEFERRED | IMMEDIATE }
+    ///
+    /// SQLite can only defer foreign keys as a whole, via `PRAGMA defer_foreign_keys`,
+    /// so named constraints are treated like ALL. The pragma resets at the end of
+    /// every transaction, matching PostgreSQL's transaction-scoped behavior.
+    pub async fn handle_set_constraints<T>(
+        framed: &mut Framed<T, crate::protocol::PostgresCodec>,
+        db: &Arc<DbHandler>,
+        session: &Arc<SessionState>,
+        query: &str,
+    ) -> Result<(), PgSqliteError>
+    where
+        T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
+    {
+        let caps = SET_CONSTRAINTS_PATTERN.captures(query.trim())
+            .ok_or_else(|| PgSqliteError::Protocol(format!("Unrecognized SET CONSTRAINTS command: {query}")))?;
+        let deferred = caps[2].eq_ignore_ascii_case("DEFERRED");
+        debug!("SET CONSTRAINTS {} {}", caps[1].trim(), if deferred { "DEFERRED" } else { "IMMEDIATE" });
+
+        let violation = db.with_session_connection(&session.id, |conn| {
+            if deferred {
+                conn.execute_batch("PRAGMA defer_foreign_keys = ON")?;
+                Ok(None)
+            } else {
+                // Switching back to IMMEDIATE checks the constraints deferred so far
+                conn.execute_batch("PRAGMA defer_foreign_keys = OFF")?;
+                Ok(ConstraintViolationMapper::pending_foreign_key_violation(conn))
+            }
+        }).await?;
+
+        if let Some(pg_err) = violation {
+            return Err(PgSqliteError::Validation(pg_err));
+        }
+
+        framed.send(BackendMessage::CommandComplete {
+            tag: "SET CONSTRAINTS".to_string()
+        }).await.map_err(PgSqliteError::Io)?;
+
+        Ok(())
     }
 
     /// Handle SET and SHOW commands
@@ -80,6 +140,50 @@ impl SetHandler {
             return Ok(());
         }
         
+        // Handle SET ROLE, which changes what current_user reports
+        if let Some(caps) = SET_ROLE_PATTERN.captures(trimmed) {
+            let role = caps[1].trim();
+            let role = if role.eq_ignore_ascii_case("NONE") {
+                None
+            } else if let Some(quoted) = role.strip_prefix('"').and_then(|r| r.strip_suffix('"')) {
+                Some(quoted.replace("\"\"", "\""))
+            } else if let Some(literal) = role.strip_prefix('\'').and_then(|r| r.strip_suffix('\'')) {
+                Some(literal.replace("''", "'"))
+            } else {
+                Some(role.to_lowercase())
+            };
+            Self::set_role(session, role.as_deref()).await?;
+
+            framed.send(BackendMessage::CommandComplete {
+                tag: "SET".to_string()
+            }).await.map_err(PgSqliteError::Io)?;
+
+            return Ok(());
+        }
+
+        // Handle RESET parameter, RESET ROLE and RESET ALL
+        if let Some(caps) = RESET_PATTERN.captures(trimmed) {
+            let param_name = caps[1].to_uppercase();
+            if param_name == "ROLE" || param_name == "ALL" {
+                Self::set_role(session, None).await?;
+            }
+            if param_name != "ROLE" {
+                let mut params = session.parameters.write().await;
+                if param_name == "ALL" {
+                    // Parameters set with SET are stored uppercased
+                    params.retain(|name, _| name.to_uppercase() != *name);
+                } else {
+                    params.remove(&param_name);
+                }
+            }
+
+            framed.send(BackendMessage::CommandComplete {
+                tag: "RESET".to_string()
+            }).await.map_err(PgSqliteError::Io)?;
+
+            return Ok(());
+        }
+
         // Handle general SET parameter
         if let Some(caps) = SET_PARAMETER_PATTERN.captures(trimmed) {
             let param_name = caps[1].to_uppercase();
@@ -89,6 +193,9 @@ impl SetHandler {
             let mut params = session.parameters.write().await;
             params.insert(param_name.clone(), param_value.to_string());
             drop(params);
+            if param_name == "APPLICATION_NAME" {
+                crate::session::activity::set_application_name(session.pid, param_value);
+            }
             
             framed.send(BackendMessage::CommandComplete { 
                 tag: "SET".to_string() 
@@ -107,8 +214,8 @@ impl SetHandler {
                 "TRANSACTION ISOLATION LEVEL" => "read committed".to_string(),
                 "DEFAULT_TRANSACTION_ISOLATION" => "read committed".to_string(), 
                 "TRANSACTION_ISOLATION" => "read committed".to_string(),
-                "SERVER_VERSION" => "16.0".to_string(),
-                "SERVER_VERSION_NUM" => "160000".to_string(),
+                "SERVER_VERSION" => crate::PG_SERVER_VERSION.to_string(),
+                "SERVER_VERSION_NUM" => crate::PG_SERVER_VERSION_NUM.to_string(),
                 "IS_SUPERUSER" => "on".to_string(),
                 "SESSION_AUTHORIZATION" => "postgres".to_string(),
                 "STANDARD_CONFORMING_STRINGS" => "on".to_string(),
@@ -119,7 +226,7 @@ impl SetHandler {
                     let params = session.parameters.read().await;
                     params.get(&param_name)
                         .map(|v| v.to_string())
-                        .unwrap_or_else(|| "unset".to_string())
+                        .unwrap_or_else(|| if param_name == "ROLE" { "none" } else { "unset" }.to_string())
                 }
             };
             info!("Parameter {} = {}", param_name, value);
@@ -155,6 +262,35 @@ impl SetHandler {
         Err(PgSqliteError::Protocol(format!("Unrecognized SET command: {query}")))
     }
     
+    /// Act as `role`, or as the login user again when it's None. pgsqlite has a single user,
+    /// so the role only changes what current_user reports; it has to be listed in pg_roles
+    /// unless permissive roles are enabled.
+    async fn set_role(session: &Arc<SessionState>, role: Option<&str>) -> Result<(), PgSqliteError> {
+        if let Some(role) = role
+            && role != session.user
+            && !crate::catalog::pg_roles::PgRolesHandler::role_exists(role)
+            && !crate::config::CONFIG.permissive_roles
+        {
+            return Err(PgError::Generic {
+                code: "22023".to_string(), // invalid_parameter_value
+                message: format!("role \"{role}\" does not exist"),
+            }.into());
+        }
+        info!("Setting role to: {}", role.unwrap_or("none"));
+
+        if let Some(db) = session.get_db_handler().await {
+            let current_user = role.unwrap_or(&session.user).to_string();
+            db.with_session_connection(&session.id, |conn| {
+                crate::functions::system_functions::register_current_user(conn, &current_user)
+            }).await?;
+        }
+
+        let mut params = session.parameters.write().await;
+        params.insert("ROLE".to_string(), role.unwrap_or("none").to_string());
+
+        Ok(())
+    }
+
     /// Set the session timezone
     async fn set_timezone(session: &Arc<SessionState>, timezone: &str) -> Result<(), PgSqliteError> {
         // Validate timezone (basic validation)
@@ -201,10 +337,34 @@ mod tests {
         assert!(SetHandler::is_set_command("SHOW TimeZone"));
         assert!(SetHandler::is_set_command("show timezone"));
         
+        assert!(SetHandler::is_set_command("RESET ROLE"));
+        
         assert!(!SetHandler::is_set_command("SELECT * FROM users"));
         assert!(!SetHandler::is_set_command("INSERT INTO test VALUES (1)"));
     }
     
+    #[test]
+    fn test_is_set_constraints() {
+        assert!(SetHandler::is_set_constraints("SET CONSTRAINTS ALL DEFERRED"));
+        assert!(SetHandler::is_set_constraints("set constraints all immediate;"));
+        assert!(SetHandler::is_set_constraints("SET CONSTRAINTS books_author_id_fkey, authors_book_id_fkey DEFERRED"));
+        assert!(!SetHandler::is_set_constraints("SET search_path TO public"));
+        assert!(!SetHandler::is_set_constraints("SET CONSTRAINTS ALL"));
+    }
+
+    #[test]
+    fn test_set_role_pattern() {
+        let caps = SET_ROLE_PATTERN.captures("SET ROLE tenant_x").unwrap();
+        assert_eq!(&caps[1], "tenant_x");
+        let caps = SET_ROLE_PATTERN.captures("set session role to 'Tenant X';").unwrap();
+        assert_eq!(&caps[1], "'Tenant X'");
+        let caps = SET_ROLE_PATTERN.captures("SET LOCAL ROLE = \"Admin\"").unwrap();
+        assert_eq!(&caps[1], "\"Admin\"");
+        assert!(!SET_ROLE_PATTERN.is_match("SET role_name TO x"));
+        assert!(RESET_PATTERN.is_match("RESET ROLE;"));
+        assert!(RESET_PATTERN.is_match("reset all"));
+    }
+
     #[test]
     fn test_set_timezone_pattern() {
         let query = "SET TIME ZONE 'America/New_York'";
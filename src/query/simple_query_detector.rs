@@ -7,9 +7,13 @@ pub fn contains_non_deterministic_functions(query: &str) -> bool {
     let query_lower = query.to_lowercase();
     query_lower.contains("gen_random_uuid") ||
     query_lower.contains("uuid_generate_v4") ||
+    query_lower.contains("uuid_generate_v1") ||
+    query_lower.contains("gen_random_bytes") ||
     query_lower.contains("random()") ||
     query_lower.contains("now()") ||
     query_lower.contains("current_timestamp") ||
+    query_lower.contains("transaction_timestamp") ||
+    query_lower.contains("clock_timestamp") ||
     query_lower.contains("current_date") ||
     query_lower.contains("current_time")
 }
@@ -57,12 +61,37 @@ pub fn is_ultra_simple_query(query: &str) -> bool {
        query.contains("AT TIME ZONE") ||
        query.contains("||") || // String concatenation
        query.contains("~") || // Pattern matching
+       query.contains('&') || // Bitwise operators, which may apply to bit strings
+       query.contains('|') ||
+       query.contains('#') ||
+       query.contains("<<") ||
+       query.contains(">>") ||
        query.contains("->") || // JSON operators
        query.contains("@") || // Array/range operators
+       crate::translator::DivisionTranslator::needs_translation(query) || // Division needs PostgreSQL semantics
+       query.contains("ONLY") || // FROM ONLY / UPDATE ONLY
+       query.contains("only") ||
+       query.contains("DISTINCT FROM") || // NULL-safe comparison
+       query.contains("distinct from") ||
+       query.contains("DEFAULT") || // DEFAULT as an INSERT value
+       query.contains("default") ||
+       query.contains("OVERRIDING") || // OVERRIDING SYSTEM VALUE for identity columns
+       query.contains("overriding") ||
        query.contains("DECIMAL") || // May need rewriting
        query.contains("NUMERIC") ||
        query.contains("unnest") || // unnest function calls need translation
-       query.contains("UNNEST") {
+       query.contains("UNNEST") ||
+       query.contains("COLLATE") || // Collation names need translation
+       query.contains("collate") ||
+       query.contains("ROW(") || // ROW constructors
+       query.contains("row(") ||
+       query.contains("SUBSTRING") || // substring(... FROM ... FOR ...)
+       query.contains("substring") ||
+       query.contains("ESCAPE") || // LIKE ... ESCAPE ''
+       query.contains("escape") ||
+       query.contains("POSITION") || // position(... IN ...)
+       query.contains("position") ||
+       query.contains("=>") { // Named function arguments
         return false;
     }
     
@@ -71,13 +100,16 @@ pub fn is_ultra_simple_query(query: &str) -> bool {
         return false;
     }
     
-    // Additional check for INSERT statements with datetime or array patterns
-    if query.to_uppercase().starts_with("INSERT") {
+    // Additional check for INSERT statements with datetime, array or special float patterns
+    let upper = query.to_uppercase();
+    if upper.starts_with("INSERT") {
         // Exclude if it contains date/time patterns that need conversion
         if (query.contains("'") && query.contains('-')) || // Date patterns like '2024-01-01'
            (query.contains("'") && query.contains(':')) ||  // Time patterns like '14:30:00'
            query.contains('{') ||                           // Array patterns like '{1,2,3}'
-           query.contains("ARRAY[") {                       // Array constructor like ARRAY[1,2,3]
+           query.contains("ARRAY[") ||                      // Array constructor like ARRAY[1,2,3]
+           (query.contains("'") && (upper.contains("INF") || upper.contains("NAN"))) || // 'Infinity' and 'NaN' floats
+           upper.contains("B'") {                            // Bit string literals like B'0101'
             debug!("INSERT query detected with special patterns - NOT ultra-simple: {}", query);
             return false;
         }
@@ -204,6 +236,69 @@ pub fn is_fast_path_simple_query(query: &str) -> bool {
         return false;
     }
     
+    // Check for division, which truncates integers and rejects zero divisors
+    if crate::translator::DivisionTranslator::needs_translation(query) {
+        return false;
+    }
+    
+    // Check for ONLY in front of the table name
+    if memchr::memmem::find(query_bytes, b"ONLY").is_some() ||
+       memchr::memmem::find(query_bytes, b"only").is_some() {
+        return false;
+    }
+    
+    // Check for IS [NOT] DISTINCT FROM
+    if memchr::memmem::find(query_bytes, b"DISTINCT").is_some() ||
+       memchr::memmem::find(query_bytes, b"distinct").is_some() {
+        return false;
+    }
+    
+    // Check for DEFAULT as an INSERT value
+    if memchr::memmem::find(query_bytes, b"DEFAULT").is_some() ||
+       memchr::memmem::find(query_bytes, b"default").is_some() {
+        return false;
+    }
+    
+    // Check for OVERRIDING SYSTEM/USER VALUE
+    if memchr::memmem::find(query_bytes, b"OVERRIDING").is_some() ||
+       memchr::memmem::find(query_bytes, b"overriding").is_some() {
+        return false;
+    }
+    
+    // Check for the @@ text search match operator
+    if memchr::memmem::find(query_bytes, b"@@").is_some() {
+        return false;
+    }
+    
+    // Check for COLLATE clauses, whose collation names may need translating
+    if memchr::memmem::find(query_bytes, b"COLLATE").is_some() ||
+       memchr::memmem::find(query_bytes, b"collate").is_some() {
+        return false;
+    }
+    
+    // Check for ROW constructors
+    if memchr::memmem::find(query_bytes, b"ROW(").is_some() ||
+       memchr::memmem::find(query_bytes, b"row(").is_some() {
+        return false;
+    }
+    
+    // Check for substring(), whose FROM/FOR forms need translating
+    if memchr::memmem::find(query_bytes, b"SUBSTRING").is_some() ||
+       memchr::memmem::find(query_bytes, b"substring").is_some() {
+        return false;
+    }
+    
+    // Check for position(), whose IN form needs translating
+    if memchr::memmem::find(query_bytes, b"POSITION").is_some() ||
+       memchr::memmem::find(query_bytes, b"position").is_some() {
+        return false;
+    }
+    
+    // Check for named function arguments, which SQLite doesn't have
+    if memchr::memmem::find(query_bytes, b"=>").is_some() {
+        return false;
+    }
+    
     // Check for regex operators
     if memchr::memmem::find(query_bytes, b" ~ ").is_some() ||
        memchr::memmem::find(query_bytes, b" !~ ").is_some() ||
@@ -212,6 +307,25 @@ pub fn is_fast_path_simple_query(query: &str) -> bool {
         return false;
     }
     
+    // Check for bit string literals and the bitwise operators
+    if memchr::memchr3(b'&', b'|', b'#', query_bytes).is_some() ||
+       memchr::memchr(b'~', query_bytes).is_some() ||
+       memchr::memmem::find(query_bytes, b"<<").is_some() ||
+       memchr::memmem::find(query_bytes, b">>").is_some() ||
+       memchr::memmem::find(query_bytes, b"B'").is_some() ||
+       memchr::memmem::find(query_bytes, b"b'").is_some() {
+        return false;
+    }
+    
+    // Check for FETCH FIRST, OFFSET ... ROWS and LIMIT ALL pagination
+    if (memchr::memmem::find(query_bytes, b"ALL").is_some() ||
+        memchr::memmem::find(query_bytes, b"all").is_some() ||
+        memchr::memmem::find(query_bytes, b"ROW").is_some() ||
+        memchr::memmem::find(query_bytes, b"row").is_some())
+        && crate::translator::FetchFirstTranslator::needs_translation(query) {
+        return false;
+    }
+
     // Check for schema prefixes
     if memchr::memmem::find(query_bytes, b"pg_catalog").is_some() ||
        memchr::memmem::find(query_bytes, b"PG_CATALOG").is_some() {
@@ -281,6 +395,14 @@ pub fn is_fast_path_simple_query(query: &str) -> bool {
         }
     }
     
+    // Check for date literals compared with columns (date columns store day numbers)
+    if first_char != b'I'
+        && memchr::memchr(b'\'', query_bytes).is_some()
+        && memchr::memchr(b'-', query_bytes).is_some()
+        && crate::translator::DateComparisonTranslator::needs_translation(query) {
+        return false;
+    }
+    
     // Check for JOIN, UNION, subqueries, etc
     if memchr::memmem::find(query_bytes, b"JOIN").is_some() ||
        memchr::memmem::find(query_bytes, b"UNION").is_some() ||
@@ -391,6 +513,9 @@ mod tests {
         // Simple batch INSERTs that should pass ultra-simple test
         assert!(is_ultra_simple_query("INSERT INTO users (id, name) VALUES (1, 'test'), (2, 'test2')"));
         assert!(is_ultra_simple_query("INSERT INTO products (id, price) VALUES (1, 99.99), (2, 149.99), (3, 199.99)"));
+        assert!(!is_ultra_simple_query("INSERT INTO readings (id, value) VALUES (1, 'Infinity')"));
+        assert!(!is_ultra_simple_query("INSERT INTO readings (id, value) VALUES (2, 'NaN')"));
+        assert!(!is_ultra_simple_query("INSERT INTO features (id, flags) VALUES (1, B'0101')"));
         
         // Batch INSERTs with datetime values should NOT pass
         assert!(!is_ultra_simple_query("INSERT INTO orders (id, date) VALUES (1, '2024-01-01'), (2, '2024-01-02')"));
@@ -447,6 +572,7 @@ mod tests {
         assert!(!is_fast_path_simple_query("INSERT INTO logs (created) VALUES ('2024-01-01')"));
         assert!(!is_fast_path_simple_query("INSERT INTO logs (time) VALUES ('14:30:00')"));
         assert!(!is_fast_path_simple_query("SELECT * FROM unnest(ARRAY[1,2,3])"));
+        assert!(!is_fast_path_simple_query("SELECT id FROM features WHERE flags & $1 = $1"));
         
         // Complex RETURNING clauses should NOT use fast path
         assert!(!is_fast_path_simple_query("INSERT INTO users (name) VALUES ('test') RETURNING id::text"));
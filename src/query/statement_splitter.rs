@@ -0,0 +1,112 @@
+//! SQL statement splitting for simple-query batches
+//!
+//! A simple Query message may carry several statements separated by semicolons.
+//! Semicolons inside string literals, quoted identifiers, dollar-quoted strings and
+//! trigger bodies (BEGIN ... END) do not end a statement.
+
+use crate::query::dollar_quote::{can_start_dollar_quote, dollar_quote_len};
+
+/// Split a query into its statements, dropping empty ones
+pub fn split_statements(query: &str) -> Vec<&str> {
+    let bytes = query.as_bytes();
+    let mut statements = Vec::new();
+    let mut start = 0;
+    let mut i = 0;
+    let mut in_trigger_body = false;
+    let mut case_depth = 0;
+
+    while i < bytes.len() {
+        match bytes[i] {
+            quote @ (b'\'' | b'"') => {
+                // Doubled quotes escape themselves, so skipping pair by pair is enough
+                i += 1;
+                while i < bytes.len() && bytes[i] != quote {
+                    i += 1;
+                }
+            }
+            b'$' if can_start_dollar_quote(query, i) => {
+                if let Some(len) = dollar_quote_len(&query[i..]) {
+                    i += len - 1;
+                }
+            }
+            b';' if !in_trigger_body => {
+                let statement = query[start..i].trim();
+                if !statement.is_empty() {
+                    statements.push(statement);
+                }
+                start = i + 1;
+            }
+            c if c.is_ascii_alphabetic() && (i == 0 || !is_word_byte(bytes[i - 1])) => {
+                let end = i + bytes[i..].iter().take_while(|b| is_word_byte(**b)).count();
+                let word = &query[i..end];
+                if word.eq_ignore_ascii_case("BEGIN") && is_create_trigger(&query[start..i]) {
+                    in_trigger_body = true;
+                } else if word.eq_ignore_ascii_case("CASE") {
+                    case_depth += 1;
+                } else if word.eq_ignore_ascii_case("END") {
+                    // END closes a CASE expression before it closes a trigger body
+                    if case_depth > 0 {
+                        case_depth -= 1;
+                    } else {
+                        in_trigger_body = false;
+                    }
+                }
+                i = end - 1;
+            }
+            _ => {}
+        }
+        i += 1;
+    }
+
+    let statement = query[start..].trim();
+    if !statement.is_empty() {
+        statements.push(statement);
+    }
+    statements
+}
+
+fn is_word_byte(b: u8) -> bool {
+    b.is_ascii_alphanumeric() || b == b'_'
+}
+
+/// Check whether the statement text so far is a CREATE [TEMP] TRIGGER header
+fn is_create_trigger(statement: &str) -> bool {
+    let mut words = statement.split_whitespace();
+    words.next().is_some_and(|w| w.eq_ignore_ascii_case("CREATE"))
+        && words.take(2).any(|w| w.eq_ignore_ascii_case("TRIGGER"))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_split_statements() {
+        assert_eq!(
+            split_statements("CREATE TABLE t (id INTEGER); INSERT INTO t VALUES (1);SELECT * FROM t;"),
+            vec!["CREATE TABLE t (id INTEGER)", "INSERT INTO t VALUES (1)", "SELECT * FROM t"]
+        );
+        assert_eq!(split_statements(" ; ;"), Vec::<&str>::new());
+    }
+
+    #[test]
+    fn test_split_keeps_quoted_semicolons() {
+        assert_eq!(
+            split_statements("INSERT INTO t VALUES ('a;b', 'it''s;'); SELECT \"odd;name\" FROM t"),
+            vec!["INSERT INTO t VALUES ('a;b', 'it''s;')", "SELECT \"odd;name\" FROM t"]
+        );
+        assert_eq!(
+            split_statements("SELECT $$a;b$$, $tag$c;$$;d$tag$, $1; SELECT 2"),
+            vec!["SELECT $$a;b$$, $tag$c;$$;d$tag$, $1", "SELECT 2"]
+        );
+    }
+
+    #[test]
+    fn test_split_keeps_trigger_bodies() {
+        assert_eq!(
+            split_statements("CREATE TRIGGER trg AFTER INSERT ON t BEGIN UPDATE t SET n = CASE WHEN n > 0 THEN 1 END; SELECT 1; END; SELECT 2"),
+            vec!["CREATE TRIGGER trg AFTER INSERT ON t BEGIN UPDATE t SET n = CASE WHEN n > 0 THEN 1 END; SELECT 1; END", "SELECT 2"]
+        );
+        assert_eq!(split_statements("BEGIN; SELECT 1; END"), vec!["BEGIN", "SELECT 1", "END"]);
+    }
+}
@@ -0,0 +1,128 @@
+use crate::error::PgError;
+use crate::PgSqliteError;
+
+/// PostgreSQL isolation levels. SQLite transactions are always serializable, so the level
+/// only decides how the SQLite transaction is started.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum IsolationLevel {
+    ReadUncommitted,
+    ReadCommitted,
+    RepeatableRead,
+    Serializable,
+}
+
+/// The modes of a `BEGIN` or `START TRANSACTION` statement
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default)]
+pub struct TransactionMode {
+    pub isolation: Option<IsolationLevel>,
+    pub read_only: bool,
+    pub deferrable: bool,
+}
+
+impl TransactionMode {
+    /// Parse `BEGIN [WORK | TRANSACTION] [mode [, ...]]` or `START TRANSACTION [mode [, ...]]`,
+    /// where a mode is `ISOLATION LEVEL level`, `READ WRITE`, `READ ONLY` or `[NOT] DEFERRABLE`
+    pub fn parse(query: &str) -> Result<Self, PgSqliteError> {
+        let query = query.trim().trim_end_matches(';').replace(',', " ");
+        let words: Vec<String> = query.split_whitespace().map(str::to_uppercase).collect();
+        let mut words = words.iter().map(String::as_str).peekable();
+
+        match words.next() {
+            Some("BEGIN") => {
+                words.next_if(|word| matches!(*word, "WORK" | "TRANSACTION"));
+            }
+            Some("START") if words.next() == Some("TRANSACTION") => {}
+            _ => return Err(Self::syntax_error(&query)),
+        }
+
+        let mut mode = Self::default();
+        while let Some(word) = words.next() {
+            match word {
+                "ISOLATION" => {
+                    Self::expect(&mut words, word, "LEVEL")?;
+                    mode.isolation = Some(match words.next() {
+                        Some("SERIALIZABLE") => IsolationLevel::Serializable,
+                        Some("REPEATABLE") if words.next_if_eq(&"READ").is_some() => IsolationLevel::RepeatableRead,
+                        Some("READ") if words.next_if_eq(&"COMMITTED").is_some() => IsolationLevel::ReadCommitted,
+                        Some("READ") if words.next_if_eq(&"UNCOMMITTED").is_some() => IsolationLevel::ReadUncommitted,
+                        next => return Err(Self::syntax_error(next.unwrap_or(word))),
+                    });
+                }
+                "READ" => match words.next() {
+                    Some("ONLY") => mode.read_only = true,
+                    Some("WRITE") => mode.read_only = false,
+                    next => return Err(Self::syntax_error(next.unwrap_or(word))),
+                },
+                "NOT" => {
+                    Self::expect(&mut words, word, "DEFERRABLE")?;
+                    mode.deferrable = false;
+                }
+                "DEFERRABLE" => mode.deferrable = true,
+                _ => return Err(Self::syntax_error(word)),
+            }
+        }
+        Ok(mode)
+    }
+
+    /// Take the word that must follow `after`
+    fn expect<'a>(words: &mut impl Iterator<Item = &'a str>, after: &str, expected: &str) -> Result<(), PgSqliteError> {
+        match words.next() {
+            Some(next) if next == expected => Ok(()),
+            next => Err(Self::syntax_error(next.unwrap_or(after))),
+        }
+    }
+
+    /// The SQLite statement starting the transaction. A read-write SERIALIZABLE transaction
+    /// takes the write lock up front, so it can't fail halfway through when another
+    /// connection writes first; the other levels start a deferred transaction, whose
+    /// snapshot is taken at its first read like PostgreSQL's REPEATABLE READ.
+    pub fn sqlite_begin(&self) -> &'static str {
+        match (self.isolation, self.read_only) {
+            (Some(IsolationLevel::Serializable), false) => "BEGIN IMMEDIATE",
+            _ => "BEGIN DEFERRED",
+        }
+    }
+
+    fn syntax_error(near: &str) -> PgSqliteError {
+        PgError::Generic {
+            code: "42601".to_string(), // syntax_error
+            message: format!("syntax error at or near \"{near}\""),
+        }.into()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_begin_spellings() {
+        for query in ["BEGIN", "begin work", "BEGIN TRANSACTION;", "START TRANSACTION"] {
+            assert_eq!(TransactionMode::parse(query).unwrap(), TransactionMode::default(), "{query}");
+        }
+        assert!(TransactionMode::parse("START").is_err());
+        assert!(TransactionMode::parse("BEGIN EXCLUSIVE").is_err());
+    }
+
+    #[test]
+    fn test_transaction_modes() {
+        let mode = TransactionMode::parse("BEGIN ISOLATION LEVEL SERIALIZABLE, READ ONLY, DEFERRABLE").unwrap();
+        assert_eq!(mode, TransactionMode { isolation: Some(IsolationLevel::Serializable), read_only: true, deferrable: true });
+        assert_eq!(mode.sqlite_begin(), "BEGIN DEFERRED");
+
+        let mode = TransactionMode::parse("START TRANSACTION ISOLATION LEVEL REPEATABLE READ READ WRITE").unwrap();
+        assert_eq!(mode.isolation, Some(IsolationLevel::RepeatableRead));
+        assert!(!mode.read_only);
+
+        let mode = TransactionMode::parse("begin transaction deferrable read only").unwrap();
+        assert!(mode.read_only && mode.deferrable);
+
+        let mode = TransactionMode::parse("BEGIN ISOLATION LEVEL SERIALIZABLE").unwrap();
+        assert_eq!(mode.sqlite_begin(), "BEGIN IMMEDIATE");
+        assert_eq!(TransactionMode::parse("BEGIN ISOLATION LEVEL READ COMMITTED NOT DEFERRABLE").unwrap().isolation, Some(IsolationLevel::ReadCommitted));
+
+        let err = TransactionMode::parse("BEGIN ISOLATION LEVEL SOMETIMES").unwrap_err();
+        assert_eq!(err.pg_error_code(), "42601");
+        assert!(TransactionMode::parse("BEGIN READ SOMETHING").is_err());
+    }
+}
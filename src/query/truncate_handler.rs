@@ -0,0 +1,219 @@
+use crate::error::PgError;
+use crate::protocol::BackendMessage;
+use crate::session::{DbHandler, SessionState};
+use std::sync::Arc;
+use crate::PgSqliteError;
+use rusqlite::{Connection, OptionalExtension};
+use tokio_util::codec::Framed;
+use futures::SinkExt;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+
+static TRUNCATE_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?is)^\s*TRUNCATE\s+(?:TABLE\s+)?(.+?)(?:\s+(RESTART|CONTINUE)\s+IDENTITY)?(?:\s+(CASCADE|RESTRICT))?\s*;?\s*$").unwrap()
+});
+
+pub struct TruncateHandler;
+
+impl TruncateHandler {
+    /// Check if this is a TRUNCATE command
+    pub fn is_truncate(query: &str) -> bool {
+        query.split_whitespace().next().is_some_and(|word| word.eq_ignore_ascii_case("TRUNCATE"))
+    }
+
+    /// Handle TRUNCATE [TABLE] [ONLY] name [*] [, ...] [RESTART | CONTINUE IDENTITY] [CASCADE | RESTRICT]
+    ///
+    /// SQLite has no TRUNCATE, so the tables are emptied with DELETE, referencing tables
+    /// first so immediate foreign keys hold after each statement. RESTART IDENTITY resets
+    /// the AUTOINCREMENT counters kept in sqlite_sequence.
+    pub async fn handle_truncate<T>(
+        framed: &mut Framed<T, crate::protocol::PostgresCodec>,
+        db: &Arc<DbHandler>,
+        session: &Arc<SessionState>,
+        query: &str,
+    ) -> Result<(), PgSqliteError>
+    where
+        T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
+    {
+        let caps = TRUNCATE_PATTERN.captures(query)
+            .ok_or_else(|| PgError::SyntaxError {
+                message: "syntax error at or near \"TRUNCATE\"".to_string(),
+                position: None,
+            })?;
+        let tables: Vec<String> = caps[1].split(',').map(Self::table_name).collect();
+        let restart_identity = caps.get(2).is_some_and(|m| m.as_str().eq_ignore_ascii_case("RESTART"));
+        let cascade = caps.get(3).is_some_and(|m| m.as_str().eq_ignore_ascii_case("CASCADE"));
+        debug!("TRUNCATE {:?} restart_identity={} cascade={}", tables, restart_identity, cascade);
+
+        let result = db.with_session_connection(&session.id, |conn| {
+            Self::truncate_tables(conn, &tables, restart_identity, cascade)
+        }).await?;
+        result?;
+
+        framed.send(BackendMessage::CommandComplete {
+            tag: "TRUNCATE TABLE".to_string()
+        }).await.map_err(PgSqliteError::Io)?;
+
+        Ok(())
+    }
+
+    fn truncate_tables(
+        conn: &Connection,
+        tables: &[String],
+        restart_identity: bool,
+        cascade: bool,
+    ) -> Result<Result<(), PgError>, rusqlite::Error> {
+        let mut targets: Vec<String> = Vec::new();
+        for table in tables {
+            let existing: Option<String> = conn.query_row(
+                "SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?1 COLLATE NOCASE",
+                [table],
+                |row| row.get(0),
+            ).optional()?;
+            match existing {
+                Some(name) if !targets.contains(&name) => targets.push(name),
+                Some(_) => {}
+                None => return Ok(Err(PgError::Generic {
+                    code: "42P01".to_string(), // undefined_table
+                    message: format!("relation \"{table}\" does not exist"),
+                })),
+            }
+        }
+
+        // Tables referencing the targets are emptied along with them (CASCADE) or block the
+        // TRUNCATE (RESTRICT)
+        let mut i = 0;
+        while i < targets.len() {
+            for referencing in Self::referencing_tables(conn, &targets[i])? {
+                if targets.contains(&referencing) {
+                    continue;
+                }
+                if !cascade {
+                    return Ok(Err(PgError::Generic {
+                        code: "0A000".to_string(), // feature_not_supported
+                        message: format!(
+                            "cannot truncate a table referenced in a foreign key constraint: table \"{}\" references \"{}\"",
+                            referencing, targets[i]
+                        ),
+                    }));
+                }
+                debug!("truncate cascades to table \"{}\"", referencing);
+                targets.push(referencing);
+            }
+            i += 1;
+        }
+
+        conn.execute_batch("SAVEPOINT pgsqlite_truncate")?;
+        let result = Self::delete_rows(conn, targets, restart_identity);
+        match result {
+            Ok(()) => conn.execute_batch("RELEASE pgsqlite_truncate")?,
+            Err(_) => conn.execute_batch("ROLLBACK TO pgsqlite_truncate; RELEASE pgsqlite_truncate")?,
+        }
+        result.map(Ok)
+    }
+
+    /// Empty the tables, each one only once no other remaining table references it
+    fn delete_rows(conn: &Connection, mut remaining: Vec<String>, restart_identity: bool) -> Result<(), rusqlite::Error> {
+        let has_sequences = conn.query_row(
+            "SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_sequence'",
+            [],
+            |_| Ok(()),
+        ).optional()?.is_some();
+
+        while !remaining.is_empty() {
+            let mut next = 0;
+            for (i, table) in remaining.iter().enumerate() {
+                let referenced = Self::referencing_tables(conn, table)?
+                    .iter()
+                    .any(|other| other != table && remaining.contains(other));
+                if !referenced {
+                    next = i;
+                    break;
+                }
+            }
+            // A reference cycle falls back to the first table
+            let table = remaining.remove(next);
+
+            conn.execute(&format!("DELETE FROM \"{}\"", table.replace('"', "\"\"")), [])?;
+            if restart_identity && has_sequences {
+                conn.execute("DELETE FROM sqlite_sequence WHERE name = ?1", [&table])?;
+            }
+        }
+        Ok(())
+    }
+
+    /// Tables with a foreign key to `table`
+    fn referencing_tables(conn: &Connection, table: &str) -> Result<Vec<String>, rusqlite::Error> {
+        let mut stmt = conn.prepare(
+            "SELECT DISTINCT m.name FROM sqlite_master m, pragma_foreign_key_list(m.name) f \
+             WHERE m.type = 'table' AND f.\"table\" = ?1 COLLATE NOCASE"
+        )?;
+        let rows = stmt.query_map([table], |row| row.get(0))?;
+        rows.collect()
+    }
+
+    /// Strip ONLY, the inheritance `*`, a schema prefix and quotes from a table reference
+    fn table_name(reference: &str) -> String {
+        let reference = reference.trim();
+        let reference = match reference.get(..5) {
+            Some(prefix) if prefix.eq_ignore_ascii_case("ONLY ") => reference[5..].trim_start(),
+            _ => reference,
+        };
+        let reference = reference.trim_end_matches('*').trim_end();
+        let name = match reference.rsplit_once('.') {
+            Some((_, name)) if !name.ends_with('"') || reference.matches('"').count() % 4 == 0 => name,
+            _ => reference,
+        };
+        name.trim_matches('"').to_string()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn setup() -> Connection {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "PRAGMA foreign_keys = ON;
+             CREATE TABLE authors (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);
+             CREATE TABLE books (id INTEGER PRIMARY KEY AUTOINCREMENT, author_id INTEGER REFERENCES authors(id));
+             INSERT INTO authors (name) VALUES ('a'), ('b');
+             INSERT INTO books (author_id) VALUES (1), (2);"
+        ).unwrap();
+        conn
+    }
+
+    fn count(conn: &Connection, table: &str) -> i64 {
+        conn.query_row(&format!("SELECT COUNT(*) FROM {table}"), [], |row| row.get(0)).unwrap()
+    }
+
+    #[test]
+    fn test_truncate_restrict_and_cascade() {
+        let conn = setup();
+
+        let err = TruncateHandler::truncate_tables(&conn, &["authors".to_string()], false, false).unwrap().unwrap_err();
+        assert!(err.to_string().contains("table \"books\" references \"authors\""));
+        assert_eq!(count(&conn, "authors"), 2);
+
+        // Listing the referencing table as well is enough
+        TruncateHandler::truncate_tables(&conn, &["authors".to_string(), "books".to_string()], false, false).unwrap().unwrap();
+        assert_eq!(count(&conn, "books"), 0);
+
+        conn.execute_batch("INSERT INTO authors (name) VALUES ('c'); INSERT INTO books (author_id) VALUES (3);").unwrap();
+        TruncateHandler::truncate_tables(&conn, &["authors".to_string()], true, true).unwrap().unwrap();
+        assert_eq!(count(&conn, "authors"), 0);
+        assert_eq!(count(&conn, "books"), 0);
+
+        conn.execute("INSERT INTO authors (name) VALUES ('d')", []).unwrap();
+        let id: i64 = conn.query_row("SELECT id FROM authors", [], |row| row.get(0)).unwrap();
+        assert_eq!(id, 1);
+    }
+
+    #[test]
+    fn test_table_name() {
+        assert_eq!(TruncateHandler::table_name(" ONLY public.books *"), "books");
+        assert_eq!(TruncateHandler::table_name("\"Order Items\""), "Order Items");
+    }
+}
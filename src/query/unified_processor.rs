@@ -9,7 +9,7 @@ use crate::cache::SchemaCache;
 use crate::query::{QueryTypeDetector, QueryType};
 
 bitflags! {
-    struct TranslationFlags: u32 {
+    struct TranslationFlags: u64 {
         const CAST = 0x1;
         const REGEX = 0x2;
         const SCHEMA = 0x4;
@@ -21,6 +21,32 @@ bitflags! {
         const BATCH_UPDATE = 0x100;
         const SESSION_IDENTIFIER = 0x200;
         const CREATE_TABLE = 0x400;
+        const LIKE = 0x800;
+        const PG_TYPEOF = 0x1000;
+        const REGEXP_MATCHES = 0x2000;
+        const RANGE_PREDICATE = 0x4000;
+        const ROW_TO_JSON = 0x8000;
+        const DISTINCT_AGGREGATE = 0x10000;
+        const DATE_COMPARISON = 0x20000;
+        const VALUES_ALIASES = 0x40000;
+        const TABLESAMPLE = 0x80000;
+        const FETCH_FIRST = 0x100000;
+        const RANGE = 0x200000;
+        const POINT = 0x400000;
+        const DIVISION = 0x800000;
+        const ONLY = 0x1000000;
+        const DISTINCT_FROM = 0x2000000;
+        const INSERT_DEFAULT = 0x4000000;
+        const IDENTITY_OVERRIDE = 0x8000000;
+        const TS_MATCH = 0x10000000;
+        const COLLATE = 0x20000000;
+        const ROW_COMPARISON = 0x40000000;
+        const SUBSTRING = 0x80000000;
+        const POSITION = 0x100000000;
+        const BIT_STRING = 0x200000000;
+        const GROUP_BY = 0x400000000;
+        const LATERAL = 0x800000000;
+        const MAKE_INTERVAL = 0x1000000000;
     }
 }
 
@@ -78,12 +104,154 @@ impl<'a> UnifiedProcessor<'a> {
             complexity = ComplexityLevel::Moderate;
         }
         
+        if memchr::memmem::find(query_bytes, b"~~").is_some() ||
+           has_ilike(query_bytes) ||
+           has_empty_escape(query_bytes) {
+            translations.insert(TranslationFlags::LIKE);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
         if memchr::memmem::find(query_bytes, b"pg_catalog").is_some() ||
            memchr::memmem::find(query_bytes, b"PG_CATALOG").is_some() {
             translations.insert(TranslationFlags::SCHEMA);
             complexity = ComplexityLevel::Moderate;
         }
         
+        if memchr::memmem::find(query_bytes, b"pg_typeof").is_some() ||
+           memchr::memmem::find(query_bytes, b"PG_TYPEOF").is_some() {
+            translations.insert(TranslationFlags::PG_TYPEOF);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if memchr::memmem::find(query_bytes, b"regexp_matches").is_some() ||
+           memchr::memmem::find(query_bytes, b"REGEXP_MATCHES").is_some() {
+            translations.insert(TranslationFlags::REGEXP_MATCHES);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if memchr::memmem::find(query_bytes, b"OVERLAPS").is_some() ||
+           memchr::memmem::find(query_bytes, b"overlaps").is_some() ||
+           memchr::memmem::find(query_bytes, b"SYMMETRIC").is_some() ||
+           memchr::memmem::find(query_bytes, b"symmetric").is_some() {
+            translations.insert(TranslationFlags::RANGE_PREDICATE);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if memchr::memmem::find(query_bytes, b"to_json").is_some() ||
+           memchr::memmem::find(query_bytes, b"TO_JSON").is_some() {
+            translations.insert(TranslationFlags::ROW_TO_JSON);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if (memchr::memmem::find(query_bytes, b"_agg").is_some() ||
+            memchr::memmem::find(query_bytes, b"_AGG").is_some())
+            && crate::translator::DistinctAggregateTranslator::needs_translation(query) {
+            translations.insert(TranslationFlags::DISTINCT_AGGREGATE);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if memchr::memchr(b'\'', query_bytes).is_some()
+            && crate::translator::DateComparisonTranslator::needs_translation(query) {
+            translations.insert(TranslationFlags::DATE_COMPARISON);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_values_derived_table(query_bytes) {
+            translations.insert(TranslationFlags::VALUES_ALIASES);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if memchr::memmem::find(query_bytes, b"TABLESAMPLE").is_some() ||
+           memchr::memmem::find(query_bytes, b"tablesample").is_some() {
+            translations.insert(TranslationFlags::TABLESAMPLE);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_fetch_first(query_bytes) {
+            translations.insert(TranslationFlags::FETCH_FIRST);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_lateral(query_bytes) {
+            translations.insert(TranslationFlags::LATERAL);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_range_operator(query_bytes) {
+            translations.insert(TranslationFlags::RANGE);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_point_operator(query_bytes) {
+            translations.insert(TranslationFlags::POINT);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_bit_string(query_bytes) {
+            translations.insert(TranslationFlags::BIT_STRING);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_division(query_bytes) {
+            translations.insert(TranslationFlags::DIVISION);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_only(query_bytes) {
+            translations.insert(TranslationFlags::ONLY);
+            complexity = ComplexityLevel::Moderate;
+        }
+
+        if has_group_by(query_bytes) {
+            translations.insert(TranslationFlags::GROUP_BY);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_distinct_from(query_bytes) {
+            translations.insert(TranslationFlags::DISTINCT_FROM);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_insert_default(query_bytes) {
+            translations.insert(TranslationFlags::INSERT_DEFAULT);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_identity_override(query_bytes) {
+            translations.insert(TranslationFlags::IDENTITY_OVERRIDE);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_ts_match(query_bytes) {
+            translations.insert(TranslationFlags::TS_MATCH);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_collate(query_bytes) {
+            translations.insert(TranslationFlags::COLLATE);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_row_comparison(query_bytes) {
+            translations.insert(TranslationFlags::ROW_COMPARISON);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_substring(query_bytes) {
+            translations.insert(TranslationFlags::SUBSTRING);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_position(query_bytes) {
+            translations.insert(TranslationFlags::POSITION);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
+        if has_make_interval(query_bytes) {
+            translations.insert(TranslationFlags::MAKE_INTERVAL);
+            complexity = ComplexityLevel::Moderate;
+        }
+        
         if (memchr::memmem::find(query_bytes, b"::NUMERIC").is_some() ||
            memchr::memmem::find(query_bytes, b"::numeric").is_some() ||
            memchr::memmem::find(query_bytes, b"CAST(").is_some() ||
@@ -94,6 +262,8 @@ impl<'a> UnifiedProcessor<'a> {
             }
         
         if memchr::memchr(b'[', query_bytes).is_some() ||
+           memchr::memmem::find(query_bytes, b"ARRAY(").is_some() ||
+           memchr::memmem::find(query_bytes, b"array(").is_some() ||
            memchr::memmem::find(query_bytes, b"ANY(").is_some() ||
            memchr::memmem::find(query_bytes, b"ALL(").is_some() ||
            memchr::memmem::find(query_bytes, b" @> ").is_some() ||
@@ -252,7 +422,22 @@ fn has_any_special_pattern_fast(bytes: &[u8]) -> bool {
     memchr::memmem::find(bytes, b"NOW()").is_some() ||
     memchr::memmem::find(bytes, b"CURRENT_").is_some() ||
     memchr::memmem::find(bytes, b" ~ ").is_some() ||
+    memchr::memmem::find(bytes, b"~~").is_some() ||
+    has_ilike(bytes) ||
+    has_empty_escape(bytes) ||
     memchr::memmem::find(bytes, b"pg_catalog").is_some() ||
+    memchr::memmem::find(bytes, b"pg_typeof").is_some() ||
+    memchr::memmem::find(bytes, b"PG_TYPEOF").is_some() ||
+    memchr::memmem::find(bytes, b"regexp_matches").is_some() ||
+    memchr::memmem::find(bytes, b"REGEXP_MATCHES").is_some() ||
+    memchr::memmem::find(bytes, b"OVERLAPS").is_some() ||
+    memchr::memmem::find(bytes, b"overlaps").is_some() ||
+    memchr::memmem::find(bytes, b"SYMMETRIC").is_some() ||
+    memchr::memmem::find(bytes, b"symmetric").is_some() ||
+    memchr::memmem::find(bytes, b"to_json").is_some() ||
+    memchr::memmem::find(bytes, b"TO_JSON").is_some() ||
+    memchr::memmem::find(bytes, b"_agg").is_some() ||
+    memchr::memmem::find(bytes, b"_AGG").is_some() ||
     memchr::memmem::find(bytes, b"PG_CATALOG").is_some() ||
     memchr::memmem::find(bytes, b"CAST(").is_some() ||
     memchr::memmem::find(bytes, b"cast(").is_some() ||
@@ -272,7 +457,182 @@ fn has_any_special_pattern_fast(bytes: &[u8]) -> bool {
     memchr::memmem::find(bytes, b"current_user").is_some() ||
     memchr::memmem::find(bytes, b"CURRENT_USER").is_some() ||
     memchr::memmem::find(bytes, b"session_user").is_some() ||
-    memchr::memmem::find(bytes, b"SESSION_USER").is_some()
+    memchr::memmem::find(bytes, b"SESSION_USER").is_some() ||
+    memchr::memmem::find(bytes, b"TABLESAMPLE").is_some() ||
+    memchr::memmem::find(bytes, b"tablesample").is_some() ||
+    has_date_comparison(bytes) ||
+    has_values_derived_table(bytes) ||
+    has_fetch_first(bytes) ||
+    has_lateral(bytes) ||
+    has_range_operator(bytes) ||
+    has_point_operator(bytes) ||
+    has_bit_string(bytes) ||
+    has_division(bytes) ||
+    has_only(bytes) ||
+    has_group_by(bytes) ||
+    has_distinct_from(bytes) ||
+    has_insert_default(bytes) ||
+    has_identity_override(bytes) ||
+    has_ts_match(bytes) ||
+    has_collate(bytes) ||
+    has_row_comparison(bytes) ||
+    has_substring(bytes) ||
+    has_position(bytes) ||
+    has_make_interval(bytes)
+}
+
+/// Check for DEFAULT used as a value in INSERT ... VALUES
+#[inline(always)]
+fn has_insert_default(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"DEFAULT").is_some() || memchr::memmem::find(bytes, b"default").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::InsertDefaultTranslator::needs_translation)
+}
+
+/// Check for INSERT ... OVERRIDING { SYSTEM | USER } VALUE
+#[inline(always)]
+fn has_identity_override(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"OVERRIDING").is_some() || memchr::memmem::find(bytes, b"overriding").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::IdentityInsertTranslator::needs_translation)
+}
+
+/// Check for the @@ text search match operator
+#[inline(always)]
+fn has_ts_match(bytes: &[u8]) -> bool {
+    memchr::memmem::find(bytes, b"@@").is_some()
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::TsMatchTranslator::needs_translation)
+}
+
+/// Check for a COLLATE clause naming a PostgreSQL collation
+#[inline(always)]
+fn has_collate(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"COLLATE").is_some() || memchr::memmem::find(bytes, b"collate").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::CollateTranslator::needs_translation)
+}
+
+/// Check for a ROW constructor or a comparison of parenthesized lists
+#[inline(always)]
+fn has_row_comparison(bytes: &[u8]) -> bool {
+    memchr::memchr(b'(', bytes).is_some()
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::RowComparisonTranslator::needs_translation)
+}
+
+/// Check for the ILIKE operator
+#[inline(always)]
+fn has_ilike(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"ILIKE").is_some() || memchr::memmem::find(bytes, b"ilike").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::LikeTranslator::needs_translation)
+}
+
+/// Check for LIKE ... ESCAPE '', which has to be dropped for SQLite
+#[inline(always)]
+fn has_empty_escape(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"ESCAPE").is_some() || memchr::memmem::find(bytes, b"escape").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::LikeTranslator::needs_translation)
+}
+
+/// Check for substring() called with FROM, FOR or SIMILAR
+#[inline(always)]
+fn has_substring(bytes: &[u8]) -> bool {
+    memchr::memchr(b'(', bytes).is_some()
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::SubstringTranslator::needs_translation)
+}
+
+/// Check for position() called with IN
+#[inline(always)]
+fn has_position(bytes: &[u8]) -> bool {
+    memchr::memchr(b'(', bytes).is_some()
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::PositionTranslator::needs_translation)
+}
+
+/// Check for make_interval() called with named arguments
+#[inline(always)]
+fn has_make_interval(bytes: &[u8]) -> bool {
+    memchr::memmem::find(bytes, b"=>").is_some()
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::MakeIntervalTranslator::needs_translation)
+}
+
+/// Check for IS [NOT] DISTINCT FROM
+#[inline(always)]
+fn has_distinct_from(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"DISTINCT").is_some() || memchr::memmem::find(bytes, b"distinct").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::DistinctFromTranslator::needs_translation)
+}
+
+/// Check for ONLY in front of a table reference
+#[inline(always)]
+fn has_only(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"ONLY").is_some() || memchr::memmem::find(bytes, b"only").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::OnlyTranslator::needs_translation)
+}
+
+/// Check for a LATERAL subquery in a join
+#[inline(always)]
+fn has_lateral(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"LATERAL").is_some() || memchr::memmem::find(bytes, b"lateral").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::LateralJoinTranslator::needs_translation)
+}
+
+/// Check for a GROUP BY clause, whose positions and aliases are resolved
+#[inline(always)]
+fn has_group_by(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"GROUP").is_some() || memchr::memmem::find(bytes, b"group").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::GroupByTranslator::needs_translation)
+}
+
+/// Check for the / operator, which needs PostgreSQL's division semantics
+#[inline(always)]
+fn has_division(bytes: &[u8]) -> bool {
+    memchr::memchr(b'/', bytes).is_some()
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::DivisionTranslator::needs_translation)
+}
+
+/// Check for the <-> distance operator and casts to point
+#[inline(always)]
+fn has_point_operator(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"<->").is_some() || memchr::memmem::find(bytes, b"::").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::PointTranslator::needs_translation)
+}
+
+/// Check for bit string literals and casts, and the operators applying to bit strings
+#[inline(always)]
+fn has_bit_string(bytes: &[u8]) -> bool {
+    (memchr::memchr3(b'&', b'|', b'#', bytes).is_some() || memchr::memchr3(b'~', b'<', b'>', bytes).is_some() ||
+     memchr::memmem::find(bytes, b"B'").is_some() || memchr::memmem::find(bytes, b"b'").is_some() ||
+     memchr::memmem::find(bytes, b"::").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::BitStringTranslator::needs_translation)
+}
+
+/// Check for the range operators and casts to range types
+#[inline(always)]
+fn has_range_operator(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"@>").is_some() || memchr::memmem::find(bytes, b"<@").is_some() ||
+     memchr::memmem::find(bytes, b"&&").is_some() || memchr::memmem::find(bytes, b"range").is_some() ||
+     memchr::memmem::find(bytes, b"RANGE").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::RangeTranslator::needs_translation)
+}
+
+/// Check for FETCH FIRST/NEXT, OFFSET ... ROWS or LIMIT ALL pagination
+#[inline(always)]
+fn has_fetch_first(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"FETCH").is_some() || memchr::memmem::find(bytes, b"fetch").is_some() ||
+     memchr::memmem::find(bytes, b"ROW").is_some() || memchr::memmem::find(bytes, b"row").is_some() ||
+     memchr::memmem::find(bytes, b"ALL").is_some() || memchr::memmem::find(bytes, b"all").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::FetchFirstTranslator::needs_translation)
+}
+
+/// Check for a VALUES list used as a derived table, which may carry column aliases
+#[inline(always)]
+fn has_values_derived_table(bytes: &[u8]) -> bool {
+    (memchr::memmem::find(bytes, b"VALUES").is_some() || memchr::memmem::find(bytes, b"values").is_some())
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::ValuesTranslator::needs_translation)
+}
+
+/// Check for a date literal compared with something (BETWEEN bounds, comparison operators)
+#[inline(always)]
+fn has_date_comparison(bytes: &[u8]) -> bool {
+    memchr::memchr(b'\'', bytes).is_some()
+        && memchr::memchr(b'-', bytes).is_some()
+        && std::str::from_utf8(bytes).is_ok_and(crate::translator::DateComparisonTranslator::needs_translation)
 }
 
 /// Check INSERT-specific patterns
@@ -391,12 +751,174 @@ fn process_complex_query<'a>(
         result = Cow::Owned(translated);
     }
 
+    // 1.2. ONLY in front of table references (there are no child tables to exclude)
+    if processor.needs_translation(TranslationFlags::ONLY) {
+        let translated = crate::translator::OnlyTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.25. GROUP BY positions and output column aliases (before the select list is rewritten)
+    if processor.needs_translation(TranslationFlags::GROUP_BY) {
+        let translated = crate::translator::GroupByTranslator::translate_query(&result, conn);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.3. IS [NOT] DISTINCT FROM (SQLite's IS / IS NOT are NULL-safe)
+    if processor.needs_translation(TranslationFlags::DISTINCT_FROM) {
+        let translated = crate::translator::DistinctFromTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.4. DEFAULT in INSERT ... VALUES (SQLite only fills in defaults for omitted columns)
+    if processor.needs_translation(TranslationFlags::INSERT_DEFAULT) {
+        let translated = crate::translator::InsertDefaultTranslator::translate_query(&result, conn);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.45. OVERRIDING SYSTEM/USER VALUE for identity columns
+    if processor.needs_translation(TranslationFlags::IDENTITY_OVERRIDE) {
+        let translated = crate::translator::IdentityInsertTranslator::translate_query(&result, conn);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.46. The @@ text search match operator
+    if processor.needs_translation(TranslationFlags::TS_MATCH) {
+        let translated = crate::translator::TsMatchTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.47. PostgreSQL collation names
+    if processor.needs_translation(TranslationFlags::COLLATE) {
+        let translated = crate::translator::CollateTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.48. Row constructors and row comparisons
+    if processor.needs_translation(TranslationFlags::ROW_COMPARISON) {
+        let translated = crate::translator::RowComparisonTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.49. The FROM/FOR/SIMILAR forms of substring()
+    if processor.needs_translation(TranslationFlags::SUBSTRING) {
+        let translated = crate::translator::SubstringTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.495. position(substring IN string)
+    if processor.needs_translation(TranslationFlags::POSITION) {
+        let translated = crate::translator::PositionTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.496. make_interval() with named arguments
+    if processor.needs_translation(TranslationFlags::MAKE_INTERVAL) {
+        let translated = crate::translator::MakeIntervalTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
     // 1.5. Session identifier translation (add parentheses to current_user, session_user)
     if processor.needs_translation(TranslationFlags::SESSION_IDENTIFIER) {
         let translated = crate::translator::SessionIdentifierTranslator::translate_query(&result);
         result = Cow::Owned(translated);
     }
 
+    // 1.6. pg_typeof() resolution (before casts rewrite its argument)
+    if processor.needs_translation(TranslationFlags::PG_TYPEOF) {
+        let translated = crate::translator::PgTypeofTranslator::translate_query(&result, conn);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.7. regexp_matches() expansion into a json_each join
+    if processor.needs_translation(TranslationFlags::REGEXP_MATCHES) {
+        match crate::translator::RegexpMatchesTranslator::translate_query(&result) {
+            Ok(translated) => {
+                result = Cow::Owned(translated);
+            }
+            Err(e) => {
+                tracing::warn!("Failed to translate regexp_matches: {}", e);
+            }
+        }
+    }
+
+    // 1.74. Range operators and '...'::int4range casts (before the cast and array translators)
+    if processor.needs_translation(TranslationFlags::RANGE) {
+        let translated = crate::translator::RangeTranslator::translate_query(&result, conn);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.745. Point casts and the <-> distance operator
+    if processor.needs_translation(TranslationFlags::POINT) {
+        let translated = crate::translator::PointTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.746. Bit string literals, casts and bitwise operators
+    if processor.needs_translation(TranslationFlags::BIT_STRING) {
+        let translated = crate::translator::BitStringTranslator::translate_query(&result, conn);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.75. Date and timestamp literals compared with such columns (before BETWEEN SYMMETRIC rewrites the bounds)
+    if processor.needs_translation(TranslationFlags::DATE_COMPARISON) {
+        let translated = crate::translator::DateComparisonTranslator::translate_query(&result, conn);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.76. Column aliases of VALUES derived tables
+    if processor.needs_translation(TranslationFlags::VALUES_ALIASES) {
+        let translated = crate::translator::ValuesTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.77. TABLESAMPLE becomes a filtered subquery (rejects unknown sampling methods)
+    if processor.needs_translation(TranslationFlags::TABLESAMPLE) {
+        let translated = crate::translator::TablesampleTranslator::translate_query(&result)
+            .map_err(|e| rusqlite::Error::SqliteFailure(
+                rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                Some(e.to_string())
+            ))?;
+        result = Cow::Owned(translated);
+    }
+
+    // 1.78. FETCH FIRST/NEXT, OFFSET ... ROWS and LIMIT ALL become LIMIT/OFFSET
+    if processor.needs_translation(TranslationFlags::FETCH_FIRST) {
+        let translated = crate::translator::FetchFirstTranslator::translate_query(&result)
+            .map_err(|e| rusqlite::Error::SqliteFailure(
+                rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                Some(e.to_string())
+            ))?;
+        result = Cow::Owned(translated);
+    }
+
+    // 1.79. LATERAL subqueries become joins on the rows they pick for each outer row
+    if processor.needs_translation(TranslationFlags::LATERAL) {
+        let translated = crate::translator::LateralJoinTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.8. OVERLAPS and BETWEEN SYMMETRIC predicates
+    if processor.needs_translation(TranslationFlags::RANGE_PREDICATE) {
+        let translated = crate::translator::RangePredicateTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.9. Whole-row row_to_json()/to_json() expansion over the table's columns
+    if processor.needs_translation(TranslationFlags::ROW_TO_JSON) {
+        let translated = crate::translator::RowToJsonTranslator::translate_row_references(&result, conn);
+        result = Cow::Owned(translated);
+    }
+
+    // 1.10. DISTINCT inside array_agg/string_agg (rejects ORDER BY outside the arguments)
+    if processor.needs_translation(TranslationFlags::DISTINCT_AGGREGATE) {
+        let translated = crate::translator::DistinctAggregateTranslator::translate_query(&result)
+            .map_err(|e| rusqlite::Error::SqliteFailure(
+                rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+                Some(e.to_string())
+            ))?;
+        result = Cow::Owned(translated);
+    }
+
     // Note: CREATE TABLE translation is now handled directly in execute_with_session
     // to ensure proper metadata storage
 
@@ -437,6 +959,18 @@ fn process_complex_query<'a>(
         }
     }
     
+    // 4.5. LIKE operator translation
+    if processor.needs_translation(TranslationFlags::LIKE) {
+        match crate::translator::LikeTranslator::translate_query(&result) {
+            Ok(translated) => {
+                result = Cow::Owned(translated);
+            }
+            Err(e) => {
+                tracing::warn!("Failed to translate LIKE operators: {}", e);
+            }
+        }
+    }
+    
     // 5. Array translation
     if processor.needs_translation(TranslationFlags::ARRAY) {
         match crate::translator::ArrayTranslator::translate_array_operators(&result) {
@@ -512,6 +1046,12 @@ fn process_complex_query<'a>(
         }
     }
     
+    // 10. Division semantics (after decimal rewriting, which handles NUMERIC operands)
+    if processor.needs_translation(TranslationFlags::DIVISION) {
+        let translated = crate::translator::DivisionTranslator::translate_query(&result);
+        result = Cow::Owned(translated);
+    }
+    
     Ok(result)
 }
 
@@ -0,0 +1,128 @@
+use crate::error::PgError;
+use crate::protocol::{BackendMessage, TransactionStatus};
+use crate::session::{DbHandler, SessionState};
+use std::sync::Arc;
+use crate::PgSqliteError;
+use tokio_util::codec::Framed;
+use futures::SinkExt;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::analyze_handler::{AnalyzeHandler, AnalyzeTarget};
+
+static VACUUM_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?is)^\s*VACUUM\b\s*(?:\(([^()]*)\)\s*)?((?:(?:FULL|FREEZE|VERBOSE|ANALY[SZ]E)\b\s*)*)(.*?)\s*;?\s*$").unwrap()
+});
+
+/// What a VACUUM statement asks for
+#[derive(Debug, PartialEq)]
+struct VacuumCommand {
+    analyze: bool,
+    targets: Vec<AnalyzeTarget>,
+}
+
+pub struct VacuumHandler;
+
+impl VacuumHandler {
+    /// Check if this is a VACUUM command
+    pub fn is_vacuum(query: &str) -> bool {
+        query.split_whitespace().next()
+            .map(|word| word.trim_end_matches(';'))
+            .is_some_and(|word| word.eq_ignore_ascii_case("VACUUM"))
+    }
+
+    /// Handle VACUUM [ ( option [, ...] ) ] [ FULL ] [ FREEZE ] [ VERBOSE ] [ ANALYZE ] [ table [, ...] ]
+    ///
+    /// SQLite's VACUUM always rebuilds the whole database file, so plain VACUUM and VACUUM FULL
+    /// both map to it whatever tables are named. With ANALYZE the named tables (or all tables)
+    /// then get the same statistics refresh as the ANALYZE command.
+    pub async fn handle_vacuum<T>(
+        framed: &mut Framed<T, crate::protocol::PostgresCodec>,
+        db: &Arc<DbHandler>,
+        session: &Arc<SessionState>,
+        query: &str,
+    ) -> Result<(), PgSqliteError>
+    where
+        T: tokio::io::AsyncRead + tokio::io::AsyncWrite + Unpin,
+    {
+        let command = Self::parse(query)
+            .ok_or_else(|| PgError::SyntaxError {
+                message: "syntax error at or near \"VACUUM\"".to_string(),
+                position: None,
+            })?;
+        debug!("VACUUM {:?}", command);
+
+        // Neither PostgreSQL nor SQLite can vacuum inside a transaction
+        if *session.transaction_status.read().await != TransactionStatus::Idle {
+            return Err(PgError::Generic {
+                code: "25001".to_string(), // active_sql_transaction
+                message: "VACUUM cannot run inside a transaction block".to_string(),
+            }.into());
+        }
+
+        let result = db.with_session_connection(&session.id, |conn| {
+            conn.execute_batch("VACUUM")?;
+            if command.analyze {
+                AnalyzeHandler::analyze(conn, command.targets)
+            } else {
+                Ok(Ok(()))
+            }
+        }).await?;
+        result?;
+
+        framed.send(BackendMessage::CommandComplete {
+            tag: "VACUUM".to_string()
+        }).await.map_err(PgSqliteError::Io)?;
+
+        Ok(())
+    }
+
+    fn parse(query: &str) -> Option<VacuumCommand> {
+        let caps = VACUUM_PATTERN.captures(query)?;
+
+        let keywords = caps[2].to_uppercase();
+        let mut analyze = keywords.contains("ANALYZE") || keywords.contains("ANALYSE");
+        if let Some(options) = caps.get(1) {
+            for option in options.as_str().split(',') {
+                let mut words = option.split_whitespace();
+                let name = words.next()?.to_uppercase();
+                if name == "ANALYZE" || name == "ANALYSE" {
+                    analyze = !words.next().is_some_and(|value| {
+                        matches!(value.to_lowercase().as_str(), "false" | "off" | "0")
+                    });
+                }
+            }
+        }
+
+        let targets = AnalyzeHandler::parse_table_list(&caps[3])?;
+        Some(VacuumCommand { analyze, targets })
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_vacuum() {
+        let command = VacuumHandler::parse("VACUUM").unwrap();
+        assert!(!command.analyze);
+        assert!(command.targets.is_empty());
+
+        let command = VacuumHandler::parse("vacuum full;").unwrap();
+        assert!(!command.analyze);
+        assert!(command.targets.is_empty());
+
+        let command = VacuumHandler::parse("VACUUM FULL VERBOSE ANALYZE books (genre), authors").unwrap();
+        assert!(command.analyze);
+        assert_eq!(command.targets, vec![
+            AnalyzeTarget { table: "books".to_string(), columns: vec!["genre".to_string()] },
+            AnalyzeTarget { table: "authors".to_string(), columns: vec![] },
+        ]);
+
+        assert!(VacuumHandler::parse("VACUUM (VERBOSE, ANALYZE) books").unwrap().analyze);
+        assert!(!VacuumHandler::parse("VACUUM (ANALYZE false) books").unwrap().analyze);
+        assert!(VacuumHandler::is_vacuum("VACUUM;"));
+        assert!(!VacuumHandler::is_vacuum("SELECT 'VACUUM'"));
+    }
+}
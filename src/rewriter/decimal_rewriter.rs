@@ -930,7 +930,9 @@ impl<'a> DecimalQueryRewriter<'a> {
     /// Check if function is a math function that needs decimal handling
     fn is_math_function(&self, name: &ObjectName) -> bool {
         let func_name = name.to_string().to_uppercase();
-        matches!(func_name.as_str(), "ROUND" | "ABS")
+        matches!(func_name.as_str(),
+            "ROUND" | "ABS" | "TRUNC" | "CEIL" | "CEILING" | "FLOOR" | "MOD" | "POWER" | "POW" | "SQRT"
+        )
     }
     
     /// Check if function is a math function that returns float (not decimal)
@@ -948,10 +950,11 @@ impl<'a> DecimalQueryRewriter<'a> {
         let func_name = func.name.to_string().to_uppercase();
         
         match func_name.as_str() {
-            "ROUND" => {
-                func.name = ObjectName(vec![ObjectNamePart::Identifier(Ident::new("decimal_round"))]);
-                // PostgreSQL ROUND() has an optional second argument (scale) that defaults to 0
-                // decimal_round() always requires 2 arguments, so add default if missing
+            "ROUND" | "TRUNC" => {
+                let decimal_name = if func_name == "ROUND" { "decimal_round" } else { "decimal_trunc" };
+                func.name = ObjectName(vec![ObjectNamePart::Identifier(Ident::new(decimal_name))]);
+                // PostgreSQL ROUND() and TRUNC() have an optional second argument (scale) that defaults to 0
+                // decimal_round() and decimal_trunc() always require 2 arguments, so add default if missing
                 if let FunctionArguments::List(ref mut list) = func.args
                     && list.args.len() == 1 {
                         list.args.push(FunctionArg::Unnamed(FunctionArgExpr::Expr(
@@ -968,6 +971,21 @@ impl<'a> DecimalQueryRewriter<'a> {
             "ABS" => {
                 func.name = ObjectName(vec![ObjectNamePart::Identifier(Ident::new("decimal_abs"))]);
             }
+            "CEIL" | "CEILING" => {
+                func.name = ObjectName(vec![ObjectNamePart::Identifier(Ident::new("decimal_ceil"))]);
+            }
+            "FLOOR" => {
+                func.name = ObjectName(vec![ObjectNamePart::Identifier(Ident::new("decimal_floor"))]);
+            }
+            "MOD" => {
+                func.name = ObjectName(vec![ObjectNamePart::Identifier(Ident::new("decimal_mod"))]);
+            }
+            "POWER" | "POW" => {
+                func.name = ObjectName(vec![ObjectNamePart::Identifier(Ident::new("decimal_power"))]);
+            }
+            "SQRT" => {
+                func.name = ObjectName(vec![ObjectNamePart::Identifier(Ident::new("decimal_sqrt"))]);
+            }
             _ => return Err(format!("Unsupported math function: {func_name}")),
         }
         
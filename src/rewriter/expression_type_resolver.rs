@@ -508,7 +508,7 @@ impl<'a> ExpressionTypeResolver<'a> {
                 }
             }
             // Math functions that preserve type
-            "ABS" | "CEIL" | "FLOOR" | "ROUND" => {
+            "ABS" | "CEIL" | "CEILING" | "FLOOR" | "ROUND" | "TRUNC" | "MOD" => {
                 if let FunctionArguments::List(list) = &func.args {
                     if !list.args.is_empty() {
                         if let FunctionArg::Unnamed(FunctionArgExpr::Expr(expr)) = &list.args[0] {
@@ -523,16 +523,29 @@ impl<'a> ExpressionTypeResolver<'a> {
                     PgType::Float8
                 }
             }
+            // Math functions with a numeric variant for numeric input, float otherwise
+            "SQRT" | "POWER" | "POW" => {
+                match &func.args {
+                    FunctionArguments::List(list) => match list.args.first() {
+                        Some(FunctionArg::Unnamed(FunctionArgExpr::Expr(expr)))
+                            if self.resolve_expr_type(expr, context) == PgType::Numeric => PgType::Numeric,
+                        _ => PgType::Float8,
+                    },
+                    _ => PgType::Float8,
+                }
+            }
             // Math functions that always return float
-            "SQRT" | "POWER" | "POW" | "EXP" | "LN" | "LOG" |
+            "EXP" | "LN" | "LOG" |
             "SIN" | "COS" | "TAN" | "ASIN" | "ACOS" | "ATAN" | "ATAN2" |
-            "RADIANS" | "DEGREES" | "PI" | "RANDOM" | "SIGN" | "TRUNC" | "MOD" => PgType::Float8,
+            "RADIANS" | "DEGREES" | "PI" | "RANDOM" | "SIGN" => PgType::Float8,
             // String functions
             "LENGTH" | "CHAR_LENGTH" => PgType::Int4,
             "LOWER" | "UPPER" | "TRIM" | "SUBSTR" => PgType::Text,
             // Our decimal functions
             "DECIMAL_ADD" | "DECIMAL_SUB" | "DECIMAL_MUL" | "DECIMAL_DIV" => PgType::Numeric,
             "DECIMAL_FROM_TEXT" => PgType::Numeric,
+            "DECIMAL_ROUND" | "DECIMAL_ABS" | "DECIMAL_TRUNC" | "DECIMAL_CEIL" | "DECIMAL_FLOOR" |
+            "DECIMAL_MOD" | "DECIMAL_POWER" | "DECIMAL_SQRT" => PgType::Numeric,
             "DECIMAL_TO_TEXT" => PgType::Text,
             // Date/Time functions (SQLite built-ins)
             "DATE" => PgType::Date,
@@ -547,7 +560,7 @@ impl<'a> ExpressionTypeResolver<'a> {
             "AGE" => PgType::Interval,
             // Array functions
             "ARRAY_AGG" => PgType::TextArray, // Generic array aggregate
-            "ARRAY_LENGTH" | "ARRAY_UPPER" | "ARRAY_LOWER" | "ARRAY_NDIMS" => PgType::Int4,
+            "ARRAY_LENGTH" | "ARRAY_UPPER" | "ARRAY_LOWER" | "ARRAY_NDIMS" | "CARDINALITY" => PgType::Int4,
             "ARRAY_APPEND" | "ARRAY_PREPEND" | "ARRAY_CAT" => PgType::TextArray,
             "ARRAY_REMOVE" | "ARRAY_REPLACE" => PgType::TextArray,
             "ARRAY_SLICE" => PgType::TextArray,
@@ -0,0 +1,252 @@
+// Per-connection activity behind the pg_stat_activity view, and the handles used to cancel
+// or terminate a connection by pid
+use std::collections::{BTreeMap, HashMap};
+use std::sync::Arc;
+use std::sync::atomic::{AtomicI32, Ordering};
+use chrono::Utc;
+use once_cell::sync::Lazy;
+use parking_lot::RwLock;
+use rusqlite::InterruptHandle;
+use tokio::sync::Notify;
+use crate::protocol::TransactionStatus;
+use super::SessionState;
+
+// Backend pids handed out to sessions, starting at the server's own process id
+static NEXT_PID: Lazy<AtomicI32> = Lazy::new(|| AtomicI32::new(std::process::id() as i32));
+
+// The activity of every open session, keyed by pid
+static BACKENDS: Lazy<RwLock<BTreeMap<i32, BackendActivity>>> = Lazy::new(|| RwLock::new(BTreeMap::new()));
+
+// How to reach every open session from another connection, keyed by pid
+static CONTROLS: Lazy<RwLock<HashMap<i32, BackendControl>>> = Lazy::new(|| RwLock::new(HashMap::new()));
+
+/// What CancelRequest, pg_cancel_backend() and pg_terminate_backend() act on
+struct BackendControl {
+    /// The secret sent in BackendKeyData, which a CancelRequest must repeat
+    secret_key: i32,
+    /// Interrupts the statement running on the session's connection
+    interrupt: Option<InterruptHandle>,
+    /// Wakes the session's message loop to close the connection
+    terminate: Arc<Notify>,
+}
+
+/// What one connection is doing, as pg_stat_activity reports it
+#[derive(Debug, Clone)]
+pub struct BackendActivity {
+    pub pid: i32,
+    pub datname: String,
+    pub usename: String,
+    pub application_name: String,
+    pub backend_start: String,
+    pub xact_start: Option<String>,
+    pub query_start: Option<String>,
+    pub state_change: String,
+    pub state: String,
+    /// The running statement, or the last one when the connection is idle
+    pub query: String,
+}
+
+fn timestamp() -> String {
+    Utc::now().format("%Y-%m-%d %H:%M:%S%.6f").to_string()
+}
+
+/// Allocate the pid of a new session
+pub fn next_pid() -> i32 {
+    NEXT_PID.fetch_add(1, Ordering::Relaxed)
+}
+
+/// Start tracking a session that just connected
+pub fn register(pid: i32, datname: &str, usename: &str, secret_key: i32, terminate: Arc<Notify>) {
+    CONTROLS.write().insert(pid, BackendControl { secret_key, interrupt: None, terminate });
+    let now = timestamp();
+    BACKENDS.write().insert(pid, BackendActivity {
+        pid,
+        datname: datname.to_string(),
+        usename: usename.to_string(),
+        application_name: String::new(),
+        backend_start: now.clone(),
+        xact_start: None,
+        query_start: None,
+        state_change: now,
+        state: "idle".to_string(),
+        query: String::new(),
+    });
+}
+
+/// Stop tracking a session that disconnected
+pub fn unregister(pid: i32) {
+    CONTROLS.write().remove(&pid);
+    BACKENDS.write().remove(&pid);
+}
+
+/// Remember how to interrupt the statements of the session's connection
+pub fn set_interrupt_handle(pid: i32, interrupt: InterruptHandle) {
+    if let Some(control) = CONTROLS.write().get_mut(&pid) {
+        control.interrupt = Some(interrupt);
+    }
+}
+
+/// Cancel the statement the session is running, if any. A CancelRequest passes the secret key
+/// from BackendKeyData, which has to match. Returns whether the session exists.
+pub fn cancel(pid: i32, secret_key: Option<i32>) -> bool {
+    let controls = CONTROLS.read();
+    let Some(control) = controls.get(&pid) else {
+        return false;
+    };
+    if secret_key.is_some_and(|key| key != control.secret_key) {
+        return false;
+    }
+    interrupt_if_active(pid, control);
+    true
+}
+
+/// Close the session's connection, cancelling the statement it is running. Returns whether
+/// the session exists.
+pub fn terminate(pid: i32) -> bool {
+    let controls = CONTROLS.read();
+    let Some(control) = controls.get(&pid) else {
+        return false;
+    };
+    control.terminate.notify_one();
+    interrupt_if_active(pid, control);
+    true
+}
+
+fn interrupt_if_active(pid: i32, control: &BackendControl) {
+    let active = BACKENDS.read().get(&pid).is_some_and(|backend| backend.state == "active");
+    if active && let Some(interrupt) = &control.interrupt {
+        interrupt.interrupt();
+    }
+}
+
+pub fn set_application_name(pid: i32, application_name: &str) {
+    if let Some(backend) = BACKENDS.write().get_mut(&pid) {
+        backend.application_name = application_name.to_string();
+    }
+}
+
+/// Mark the session active running `query`
+pub fn query_started(pid: i32, query: &str) {
+    if let Some(backend) = BACKENDS.write().get_mut(&pid) {
+        let now = timestamp();
+        // Outside a transaction block the statement is its own transaction
+        if backend.xact_start.is_none() {
+            backend.xact_start = Some(now.clone());
+        }
+        backend.query_start = Some(now.clone());
+        backend.state_change = now;
+        backend.state = "active".to_string();
+        backend.query = query.to_string();
+    }
+}
+
+/// Mark the session idle again once its statement is done
+pub fn query_finished(pid: i32, status: TransactionStatus) {
+    if let Some(backend) = BACKENDS.write().get_mut(&pid) {
+        let state = match status {
+            TransactionStatus::Idle => {
+                backend.xact_start = None;
+                "idle"
+            }
+            TransactionStatus::InTransaction => "idle in transaction",
+            TransactionStatus::InFailedTransaction => "idle in transaction (aborted)",
+        };
+        backend.state_change = timestamp();
+        backend.state = state.to_string();
+    }
+}
+
+/// The activity of all open sessions, ordered by pid
+pub fn snapshot() -> Vec<BackendActivity> {
+    BACKENDS.read().values().cloned().collect()
+}
+
+/// The snapshot as a JSON array, which the pg_stat_activity view reads with json_each()
+pub fn snapshot_json() -> String {
+    let backends: Vec<serde_json::Value> = snapshot().into_iter().map(|backend| serde_json::json!({
+        "pid": backend.pid,
+        "datname": backend.datname,
+        "usename": backend.usename,
+        "application_name": backend.application_name,
+        "backend_start": backend.backend_start,
+        "xact_start": backend.xact_start,
+        "query_start": backend.query_start,
+        "state_change": backend.state_change,
+        "state": backend.state,
+        "query": backend.query,
+    })).collect();
+    serde_json::Value::Array(backends).to_string()
+}
+
+/// Reports a statement as active while it runs and the session as idle when dropped
+pub struct QueryActivity<'a> {
+    session: &'a SessionState,
+}
+
+impl<'a> QueryActivity<'a> {
+    pub fn start(session: &'a SessionState, query: &str) -> Self {
+        query_started(session.pid, query);
+        QueryActivity { session }
+    }
+}
+
+impl Drop for QueryActivity<'_> {
+    fn drop(&mut self) {
+        let status = self.session.transaction_status.try_read()
+            .map(|status| *status)
+            .unwrap_or(TransactionStatus::Idle);
+        query_finished(self.session.pid, status);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_backend_activity() {
+        let pid = next_pid();
+        register(pid, "inventory", "alice", 99, Arc::new(Notify::new()));
+        set_application_name(pid, "reports");
+        query_started(pid, "SELECT 1");
+
+        let backend = snapshot().into_iter().find(|backend| backend.pid == pid).unwrap();
+        assert_eq!(backend.datname, "inventory");
+        assert_eq!(backend.usename, "alice");
+        assert_eq!(backend.application_name, "reports");
+        assert_eq!(backend.state, "active");
+        assert_eq!(backend.query, "SELECT 1");
+        assert!(backend.xact_start.is_some());
+
+        query_finished(pid, TransactionStatus::Idle);
+        let backend = snapshot().into_iter().find(|backend| backend.pid == pid).unwrap();
+        assert_eq!(backend.state, "idle");
+        assert_eq!(backend.query, "SELECT 1");
+        assert!(backend.xact_start.is_none());
+
+        unregister(pid);
+        assert!(snapshot().iter().all(|backend| backend.pid != pid));
+    }
+
+    #[test]
+    fn test_cancel_and_terminate() {
+        let pid = next_pid();
+        let terminated = Arc::new(Notify::new());
+        register(pid, "inventory", "alice", 99, terminated.clone());
+
+        // The secret key has to match, and unknown pids are reported
+        assert!(!cancel(pid, Some(98)));
+        assert!(cancel(pid, Some(99)));
+        assert!(cancel(pid, None));
+        assert!(!cancel(-1, None));
+
+        // Terminating leaves a permit for the session's message loop
+        assert!(terminate(pid));
+        let notified = terminated.notified();
+        tokio::pin!(notified);
+        assert!(notified.as_mut().enable());
+
+        unregister(pid);
+        assert!(!terminate(pid));
+    }
+}
@@ -61,7 +61,8 @@ impl ConnectionManager {
              PRAGMA synchronous = {};
              PRAGMA cache_size = {};
              PRAGMA temp_store = MEMORY;
-             PRAGMA mmap_size = {};",
+             PRAGMA mmap_size = {};
+             PRAGMA foreign_keys = ON;",
             self.config.pragma_journal_mode,
             self.config.pragma_synchronous,
             self.config.pragma_cache_size,
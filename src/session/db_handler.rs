@@ -9,7 +9,7 @@ use crate::error_message;
 use crate::query::{QueryTypeDetector, QueryType, process_query, executor::extract_table_name_from_create};
 use crate::config::Config;
 use crate::migration::MigrationRunner;
-use crate::validator::StringConstraintValidator;
+use crate::validator::{StringConstraintValidator, ConstraintViolationMapper};
 use crate::session::ConnectionManager;
 use crate::ddl::CommentDdlHandler;
 use crate::PgSqliteError;
@@ -332,7 +332,8 @@ impl DbHandler {
              PRAGMA synchronous = {};
              PRAGMA cache_size = {};
              PRAGMA temp_store = MEMORY;
-             PRAGMA mmap_size = {};",
+             PRAGMA mmap_size = {};
+             PRAGMA foreign_keys = ON;",
             config.pragma_journal_mode,
             config.pragma_synchronous,
             config.pragma_cache_size,
@@ -479,6 +480,7 @@ impl DbHandler {
         self.validate_sql_security(query)?;
         debug!("execute_with_params called with query: {}", query);
         debug!("execute_with_params params count: {}", params.len());
+        let mut violation = None;
         let result = self.connection_manager.execute_with_session(session_id, |conn| {
             // Process query with fast path optimization
             let processed_query = process_query(query, conn, &self.schema_cache)?;
@@ -536,7 +538,7 @@ impl DbHandler {
                             row_data.push(match value {
                                 Some(rusqlite::types::Value::Text(s)) => Some(s.into_bytes()),
                                 Some(rusqlite::types::Value::Integer(i)) => Some(i.to_string().into_bytes()),
-                                Some(rusqlite::types::Value::Real(f)) => Some(f.to_string().into_bytes()),
+                                Some(rusqlite::types::Value::Real(f)) => Some(crate::types::numeric_utils::format_float(f).into_bytes()),
                                 Some(rusqlite::types::Value::Blob(b)) => Some(b),
                                 Some(rusqlite::types::Value::Null) | None => None,
                             });
@@ -553,7 +555,9 @@ impl DbHandler {
                     }
                 }
                 _ => {
-                    let rows_affected = stmt.execute(rusqlite::params_from_iter(values.iter()))?;
+                    let rows_affected = stmt.execute(rusqlite::params_from_iter(values.iter())).inspect_err(|e| {
+                        violation = ConstraintViolationMapper::map_error_with_params(conn, query, &values, e);
+                    })?;
                     DbResponse {
                         columns: vec![],
                         rows: vec![],
@@ -574,7 +578,8 @@ impl DbHandler {
             }
             
             Ok(result)
-        })?;
+        });
+        let result = Self::with_constraint_violation(result, violation)?;
         
         // After the closure completes, check if we need WAL refresh
         let query_type = QueryTypeDetector::detect_query_type(query);
@@ -784,7 +789,7 @@ impl DbHandler {
                                         row_data.push(match value {
                                             Some(rusqlite::types::Value::Text(s)) => Some(s.into_bytes()),
                                             Some(rusqlite::types::Value::Integer(i)) => Some(i.to_string().into_bytes()),
-                                            Some(rusqlite::types::Value::Real(f)) => Some(f.to_string().into_bytes()),
+                                            Some(rusqlite::types::Value::Real(f)) => Some(crate::types::numeric_utils::format_float(f).into_bytes()),
                                             Some(rusqlite::types::Value::Blob(b)) => Some(b),
                                             Some(rusqlite::types::Value::Null) | None => None,
                                         });
@@ -902,7 +907,7 @@ impl DbHandler {
                     row_data.push(match value {
                         Some(rusqlite::types::Value::Text(s)) => Some(s.into_bytes()),
                         Some(rusqlite::types::Value::Integer(i)) => Some(i.to_string().into_bytes()),
-                        Some(rusqlite::types::Value::Real(f)) => Some(f.to_string().into_bytes()),
+                        Some(rusqlite::types::Value::Real(f)) => Some(crate::types::numeric_utils::format_float(f).into_bytes()),
                         Some(rusqlite::types::Value::Blob(b)) => Some(b),
                         Some(rusqlite::types::Value::Null) | None => None,
                     });
@@ -933,9 +938,12 @@ impl DbHandler {
         
         // Handle special system function queries
         if lower_query.trim() == "select current_user()" {
+            let user: String = self.with_session_connection(session_id, |conn| {
+                conn.query_row("SELECT current_user()", [], |row| row.get(0))
+            }).await?;
             return Ok(DbResponse {
                 columns: vec!["current_user".to_string()],
-                rows: vec![vec![Some("postgres".to_string().into_bytes())]],
+                rows: vec![vec![Some(user.into_bytes())]],
                 rows_affected: 1,
             });
         }
@@ -1249,7 +1257,7 @@ impl DbHandler {
                             row_data.push(match value {
                                 Some(rusqlite::types::Value::Text(s)) => Some(s.into_bytes()),
                                 Some(rusqlite::types::Value::Integer(i)) => Some(i.to_string().into_bytes()),
-                                Some(rusqlite::types::Value::Real(f)) => Some(f.to_string().into_bytes()),
+                                Some(rusqlite::types::Value::Real(f)) => Some(crate::types::numeric_utils::format_float(f).into_bytes()),
                                 Some(rusqlite::types::Value::Blob(b)) => Some(b),
                                 Some(rusqlite::types::Value::Null) | None => None,
                             });
@@ -1280,9 +1288,12 @@ impl DbHandler {
 
         // Handle special system function queries
         if lower_query.trim() == "select current_user()" {
+            let user: String = self.with_session_connection(session_id, |conn| {
+                conn.query_row("SELECT current_user()", [], |row| row.get(0))
+            }).await?;
             return Ok(DbResponse {
                 columns: vec!["current_user".to_string()],
-                rows: vec![vec![Some("postgres".to_string().into_bytes())]],
+                rows: vec![vec![Some(user.into_bytes())]],
                 rows_affected: 1,
             });
         }
@@ -1460,7 +1471,7 @@ impl DbHandler {
                                                 row_data.push(match value {
                                                     Some(rusqlite::types::Value::Text(s)) => Some(s.into_bytes()),
                                                     Some(rusqlite::types::Value::Integer(i)) => Some(i.to_string().into_bytes()),
-                                                    Some(rusqlite::types::Value::Real(f)) => Some(f.to_string().into_bytes()),
+                                                    Some(rusqlite::types::Value::Real(f)) => Some(crate::types::numeric_utils::format_float(f).into_bytes()),
                                                     Some(rusqlite::types::Value::Blob(b)) => Some(b),
                                                     Some(rusqlite::types::Value::Null) | None => None,
                                                 });
@@ -1729,7 +1740,7 @@ impl DbHandler {
                     row_data.push(match value {
                         Some(rusqlite::types::Value::Text(s)) => Some(s.into_bytes()),
                         Some(rusqlite::types::Value::Integer(i)) => Some(i.to_string().into_bytes()),
-                        Some(rusqlite::types::Value::Real(f)) => Some(f.to_string().into_bytes()),
+                        Some(rusqlite::types::Value::Real(f)) => Some(crate::types::numeric_utils::format_float(f).into_bytes()),
                         Some(rusqlite::types::Value::Blob(b)) => Some(b),
                         Some(rusqlite::types::Value::Null) | None => None,
                     });
@@ -1822,7 +1833,9 @@ impl DbHandler {
                     (processed, std::collections::HashMap::new())
                 };
 
-            let rows_affected = conn.execute(&processed_query, [])?;
+            let rows_affected = conn.execute(&processed_query, []).inspect_err(|e| {
+                violation = ConstraintViolationMapper::map_error(conn, query, e);
+            })?;
 
             // Handle CREATE TABLE metadata storage and constraints
             if query.trim_start().to_uppercase().starts_with("CREATE TABLE")
@@ -1920,6 +1933,18 @@ impl DbHandler {
                 } else {
                     info!("Successfully populated constraint catalog tables for table: {}", table_name);
                 }
+                if let Err(e) = crate::validator::IntegerRangeTriggers::create_for_table(&conn, &table_name)
+                    .and_then(|_| crate::validator::FixedCharTriggers::create_for_table(&conn, &table_name))
+                    .and_then(|_| crate::validator::BitStringTriggers::create_for_table(&conn, &table_name))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_datetime_precisions(&conn, &table_name, query))
+                    .and_then(|_| crate::validator::DatetimePrecisionTriggers::create_for_table(&conn, &table_name))
+                    .and_then(|_| crate::validator::XmlTriggers::create_for_table(&conn, &table_name))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_inheritance(&conn, &table_name, query))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_identity_columns(&conn, &table_name, query))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_generated_columns(&conn, &table_name, query))
+                    .and_then(|_| crate::validator::IdentityTriggers::create_for_table(&conn, &table_name)) {
+                    error!("Failed to create column type triggers or record column metadata for table {}: {}", table_name, e);
+                }
             }
 
             Ok(DbResponse {
@@ -1938,13 +1963,20 @@ impl DbHandler {
         cached_conn: Option<&Arc<parking_lot::Mutex<rusqlite::Connection>>>
     ) -> Result<DbResponse, PgSqliteError> {
         eprintln!("🗂️ execute_with_session_cached called, cached_conn: {}", cached_conn.is_some());
+        // COMMENT ON is handled outside SQLite, on the session connection
+        if CommentDdlHandler::is_comment_ddl(query) {
+            return self.execute_with_session(query, session_id).await;
+        }
         match cached_conn {
             Some(conn) => {
-                self.connection_manager.execute_with_cached_connection(conn, |conn| {
+                let mut violation = None;
+                let result = self.connection_manager.execute_with_cached_connection(conn, |conn| {
                     // Process query with fast path optimization
                     let processed_query = process_query(query, conn, &self.schema_cache)?;
 
-                    let rows_affected = conn.execute(&processed_query, [])?;
+                    let rows_affected = conn.execute(&processed_query, []).inspect_err(|e| {
+                        violation = ConstraintViolationMapper::map_error(conn, query, e);
+                    })?;
 
                     // Handle CREATE TABLE metadata storage
                     if query.trim_start().to_uppercase().starts_with("CREATE TABLE")
@@ -1966,6 +1998,18 @@ impl DbHandler {
                                 } else {
                                     debug!("Successfully populated constraint catalog tables for table: {}", table_name);
                                 }
+                                if let Err(e) = crate::validator::IntegerRangeTriggers::create_for_table(conn, &table_name)
+                                    .and_then(|_| crate::validator::FixedCharTriggers::create_for_table(conn, &table_name))
+                                    .and_then(|_| crate::validator::BitStringTriggers::create_for_table(conn, &table_name))
+                                    .and_then(|_| crate::translator::CreateTableTranslator::record_datetime_precisions(conn, &table_name, query))
+                                    .and_then(|_| crate::validator::DatetimePrecisionTriggers::create_for_table(conn, &table_name))
+                                    .and_then(|_| crate::validator::XmlTriggers::create_for_table(conn, &table_name))
+                                    .and_then(|_| crate::translator::CreateTableTranslator::record_inheritance(conn, &table_name, query))
+                                    .and_then(|_| crate::translator::CreateTableTranslator::record_identity_columns(conn, &table_name, query))
+                                    .and_then(|_| crate::translator::CreateTableTranslator::record_generated_columns(conn, &table_name, query))
+                                    .and_then(|_| crate::validator::IdentityTriggers::create_for_table(conn, &table_name)) {
+                                    debug!("Failed to create column type triggers or record column metadata for table {}: {}", table_name, e);
+                                }
                             }
                         }
 
@@ -1974,7 +2018,8 @@ impl DbHandler {
                         rows: vec![],
                         rows_affected,
                     })
-                })
+                });
+                Self::with_constraint_violation(result, violation)
             }
             None => {
                 // Fall back to regular lookup
@@ -2009,7 +2054,8 @@ impl DbHandler {
             });
         }
 
-        self.connection_manager.execute_with_session(session_id, |conn| {
+        let mut violation = None;
+        let result = self.connection_manager.execute_with_session(session_id, |conn| {
             // Check if this is a CREATE TABLE statement that needs special handling
             let (processed_query, type_mappings, _array_columns, _enum_columns) =
                 if query.trim_start().to_uppercase().starts_with("CREATE TABLE") {
@@ -2035,7 +2081,9 @@ impl DbHandler {
                     (processed, std::collections::HashMap::new(), Vec::new(), Vec::new())
                 };
 
-            let rows_affected = conn.execute(&processed_query, [])?;
+            let rows_affected = conn.execute(&processed_query, []).inspect_err(|e| {
+                violation = ConstraintViolationMapper::map_error(conn, query, e);
+            })?;
 
             // Handle CREATE TABLE metadata storage and constraints
             if query.trim_start().to_uppercase().starts_with("CREATE TABLE")
@@ -2131,6 +2179,18 @@ impl DbHandler {
                 } else {
                     debug!("Successfully populated constraint catalog tables for table: {}", table_name);
                 }
+                if let Err(e) = crate::validator::IntegerRangeTriggers::create_for_table(conn, &table_name)
+                    .and_then(|_| crate::validator::FixedCharTriggers::create_for_table(conn, &table_name))
+                    .and_then(|_| crate::validator::BitStringTriggers::create_for_table(conn, &table_name))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_datetime_precisions(conn, &table_name, query))
+                    .and_then(|_| crate::validator::DatetimePrecisionTriggers::create_for_table(conn, &table_name))
+                    .and_then(|_| crate::validator::XmlTriggers::create_for_table(conn, &table_name))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_inheritance(conn, &table_name, query))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_identity_columns(conn, &table_name, query))
+                    .and_then(|_| crate::translator::CreateTableTranslator::record_generated_columns(conn, &table_name, query))
+                    .and_then(|_| crate::validator::IdentityTriggers::create_for_table(conn, &table_name)) {
+                    debug!("Failed to create column type triggers or record column metadata for table {}: {}", table_name, e);
+                }
             }
 
             Ok(DbResponse {
@@ -2138,23 +2198,60 @@ impl DbHandler {
                 rows: vec![],
                 rows_affected,
             })
-        })
+        });
+        Self::with_constraint_violation(result, violation)
+    }
+
+    /// Replace a raw SQLite constraint failure with the mapped PostgreSQL violation, if any
+    fn with_constraint_violation<T>(
+        result: Result<T, PgSqliteError>,
+        violation: Option<crate::error::PgError>,
+    ) -> Result<T, PgSqliteError> {
+        match (result, violation) {
+            (Err(PgSqliteError::Sqlite(_)), Some(pg_err)) => Err(PgSqliteError::Validation(pg_err)),
+            (result, _) => result,
+        }
     }
     
     /// Transaction control methods
     pub async fn begin_with_session(&self, session_id: &Uuid) -> Result<(), PgSqliteError> {
+        self.begin_with_mode(session_id, &crate::query::TransactionMode::default()).await
+    }
+    
+    /// Start a transaction with the modes of a BEGIN statement. A READ ONLY transaction
+    /// turns on SQLite's query_only pragma until it ends, so its writes fail.
+    pub async fn begin_with_mode(&self, session_id: &Uuid, mode: &crate::query::TransactionMode) -> Result<(), PgSqliteError> {
         self.connection_manager.execute_with_session(session_id, |conn| {
-            conn.execute("BEGIN", [])?;
+            conn.execute(mode.sqlite_begin(), [])?;
+            if mode.read_only {
+                conn.pragma_update(None, "query_only", true)?;
+            }
+            // now() returns the transaction start from here on
+            crate::functions::datetime_functions::start_transaction_clock(conn)?;
             Ok(())
         })
     }
     
     pub async fn commit(&self, session_id: &Uuid) -> Result<(), PgSqliteError> {
         // Execute the commit on the current session
-        self.connection_manager.execute_with_session(session_id, |conn| {
-            conn.execute("COMMIT", [])?;
+        let mut violation = None;
+        let result = self.connection_manager.execute_with_session(session_id, |conn| {
+            crate::functions::datetime_functions::stop_transaction_clock(conn)?;
+            conn.pragma_update(None, "query_only", false)?;
+            conn.execute("COMMIT", []).inspect_err(|e| {
+                if let rusqlite::Error::SqliteFailure(err, _) = e
+                    && err.code == rusqlite::ErrorCode::ConstraintViolation {
+                    // Deferred foreign keys are checked here. SQLite keeps the transaction
+                    // open after a failed COMMIT, PostgreSQL rolls it back.
+                    violation = ConstraintViolationMapper::pending_foreign_key_violation(conn);
+                    if let Err(rollback_err) = conn.execute("ROLLBACK", []) {
+                        warn!("Failed to roll back after deferred constraint violation: {}", rollback_err);
+                    }
+                }
+            })?;
             Ok(())
-        })?;
+        });
+        Self::with_constraint_violation(result, violation)?;
         
         // Force all other connections to refresh their WAL view (WAL mode only)
         // This ensures committed data is visible to all other sessions
@@ -2169,6 +2266,8 @@ impl DbHandler {
     
     pub async fn rollback(&self, session_id: &Uuid) -> Result<(), PgSqliteError> {
         self.connection_manager.execute_with_session(session_id, |conn| {
+            crate::functions::datetime_functions::stop_transaction_clock(conn)?;
+            conn.pragma_update(None, "query_only", false)?;
             match conn.execute("ROLLBACK", []) {
                 Ok(_) => Ok(()),
                 Err(rusqlite::Error::SqliteFailure(_, Some(msg))) 
@@ -2400,7 +2499,7 @@ impl DbHandler {
                                         Some(int_value.to_string().into_bytes())
                                     }
                                 }
-                                rusqlite::types::ValueRef::Real(f) => Some(f.to_string().into_bytes()),
+                                rusqlite::types::ValueRef::Real(f) => Some(crate::types::numeric_utils::format_float(f).into_bytes()),
                                 rusqlite::types::ValueRef::Text(s) => Some(s.to_vec()),
                                 rusqlite::types::ValueRef::Blob(b) => Some(b.to_vec()),
                             };
@@ -2493,7 +2592,7 @@ impl DbHandler {
                                             Some(int_value.to_string().into_bytes())
                                         }
                                     }
-                                    rusqlite::types::ValueRef::Real(f) => Some(f.to_string().into_bytes()),
+                                    rusqlite::types::ValueRef::Real(f) => Some(crate::types::numeric_utils::format_float(f).into_bytes()),
                                     rusqlite::types::ValueRef::Text(s) => Some(s.to_vec()),
                                     rusqlite::types::ValueRef::Blob(b) => Some(b.to_vec()),
                                 };
@@ -7,6 +7,7 @@ pub mod query_router;
 pub mod portal_manager;
 pub mod connection_manager;
 pub mod thread_local_cache;
+pub mod activity;
 
 pub use state::{SessionState, PreparedStatement, Portal, GLOBAL_QUERY_CACHE};
 pub use pool::{SqlitePool, PooledConnection};
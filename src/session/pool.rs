@@ -177,7 +177,8 @@ impl SqlitePool {
              PRAGMA synchronous=NORMAL;
              PRAGMA cache_size=-64000;
              PRAGMA temp_store=MEMORY;
-             PRAGMA mmap_size=268435456;"
+             PRAGMA mmap_size=268435456;
+             PRAGMA foreign_keys=ON;"
         )?;
         
         Ok(conn)
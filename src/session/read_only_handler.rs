@@ -81,7 +81,7 @@ impl ReadOnlyDbHandler {
                 let value = match row.get::<_, rusqlite::types::Value>(i)? {
                     rusqlite::types::Value::Null => None,
                     rusqlite::types::Value::Integer(i) => Some(i.to_string().into_bytes()),
-                    rusqlite::types::Value::Real(f) => Some(f.to_string().into_bytes()),
+                    rusqlite::types::Value::Real(f) => Some(crate::types::numeric_utils::format_float(f).into_bytes()),
                     rusqlite::types::Value::Text(s) => Some(s.into_bytes()),
                     rusqlite::types::Value::Blob(b) => Some(b),
                 };
@@ -128,7 +128,7 @@ impl ReadOnlyDbHandler {
                 let value = match row.get::<_, rusqlite::types::Value>(i)? {
                     rusqlite::types::Value::Null => None,
                     rusqlite::types::Value::Integer(i) => Some(i.to_string().into_bytes()),
-                    rusqlite::types::Value::Real(f) => Some(f.to_string().into_bytes()),
+                    rusqlite::types::Value::Real(f) => Some(crate::types::numeric_utils::format_float(f).into_bytes()),
                     rusqlite::types::Value::Text(s) => Some(s.into_bytes()),
                     rusqlite::types::Value::Blob(b) => Some(b),
                 };
@@ -1,5 +1,5 @@
 use std::collections::HashMap;
-use tokio::sync::{RwLock, Mutex};
+use tokio::sync::{RwLock, Mutex, Notify};
 use crate::protocol::TransactionStatus;
 use crate::cache::QueryCache;
 use crate::config::CONFIG;
@@ -20,6 +20,9 @@ static ACTIVE_SESSION_COUNT: AtomicUsize = AtomicUsize::new(0);
 
 pub struct SessionState {
     pub id: uuid::Uuid,
+    pub pid: i32, // Backend pid reported in BackendKeyData and pg_stat_activity
+    pub secret_key: i32, // Secret reported in BackendKeyData, checked by CancelRequest
+    pub terminate: Arc<Notify>, // Notified by pg_terminate_backend()
     pub database: String,
     pub user: String,
     pub parameters: RwLock<HashMap<String, String>>,
@@ -55,7 +58,7 @@ pub struct Portal {
 impl SessionState {
     pub fn new(database: String, user: String) -> Self {
         let mut parameters = HashMap::new();
-        parameters.insert("server_version".to_string(), "16.0".to_string());
+        parameters.insert("server_version".to_string(), crate::PG_SERVER_VERSION.to_string());
         parameters.insert("server_encoding".to_string(), "UTF8".to_string());
         parameters.insert("client_encoding".to_string(), "UTF8".to_string());
         parameters.insert("DateStyle".to_string(), "ISO, MDY".to_string());
@@ -65,9 +68,17 @@ impl SessionState {
         
         // Increment active session count
         ACTIVE_SESSION_COUNT.fetch_add(1, Ordering::Relaxed);
+
+        let pid = super::activity::next_pid();
+        let secret_key = rand::random::<i32>();
+        let terminate = Arc::new(Notify::new());
+        super::activity::register(pid, &database, &user, secret_key, terminate.clone());
         
         SessionState {
             id: uuid::Uuid::new_v4(),
+            pid,
+            secret_key,
+            terminate,
             database,
             user,
             parameters: RwLock::new(parameters),
@@ -87,6 +98,16 @@ impl SessionState {
         Self::new("test".to_string(), "test".to_string())
     }
 
+    /// Report `query` as running in pg_stat_activity until the returned guard is dropped
+    pub fn start_query(&self, query: &str) -> super::activity::QueryActivity<'_> {
+        super::activity::QueryActivity::start(self, query)
+    }
+
+    /// Wait until pg_terminate_backend() is called for this session
+    pub async fn terminated(&self) {
+        self.terminate.notified().await
+    }
+
     /// Check if the session is currently in a transaction
     pub async fn in_transaction(&self) -> bool {
         matches!(
@@ -126,6 +147,10 @@ impl SessionState {
     pub async fn initialize_connection(&self) -> Result<(), crate::PgSqliteError> {
         if let Some(ref db_handler) = *self.db_handler.lock().await {
             db_handler.create_session_connection(self.id).await?;
+            db_handler.with_session_connection(&self.id, |conn| {
+                super::activity::set_interrupt_handle(self.pid, conn.get_interrupt_handle());
+                crate::functions::system_functions::register_session_functions(conn, &self.user, &self.database, self.pid)
+            }).await?;
         }
         Ok(())
     }
@@ -160,5 +185,8 @@ impl Drop for SessionState {
         
         // Decrement active session count when session is destroyed
         ACTIVE_SESSION_COUNT.fetch_sub(1, Ordering::Relaxed);
+
+        // The connection no longer shows up in pg_stat_activity
+        super::activity::unregister(self.pid);
     }
 }
\ No newline at end of file
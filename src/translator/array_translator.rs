@@ -4,6 +4,7 @@ use crate::types::PgType;
 use regex::Regex;
 use once_cell::sync::Lazy;
 use tracing::debug;
+use super::sql_scan::{in_string_literal, matching_paren, split_top_level};
 
 /// Regex patterns for array operators
 static ARRAY_CONTAINS_REGEX: Lazy<Regex> = Lazy::new(|| {
@@ -15,11 +16,26 @@ static ARRAY_CONTAINED_REGEX: Lazy<Regex> = Lazy::new(|| {
 });
 
 static ARRAY_OVERLAP_REGEX: Lazy<Regex> = Lazy::new(|| {
-    Regex::new(r#"(\b\w+(?:\.\w+)*)\s*&&\s*(\b\w+(?:\.\w+)*|'[^']+'|"[^"]+"|'\[[^\]]+\]')"#).unwrap()
+    Regex::new(r#"(\b\w+(?:\.\w+)*|'\[[^']*\]')\s*&&\s*(\b\w+(?:\.\w+)*|'[^']+'|"[^"]+"|'\[[^\]]+\]')"#).unwrap()
 });
 
-static ARRAY_LITERAL_REGEX: Lazy<Regex> = Lazy::new(|| {
-    Regex::new(r"ARRAY\[([^\]]*)\]").unwrap()
+/// ARRAY[...] and ARRAY(subquery) constructors
+static ARRAY_CONSTRUCTOR_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bARRAY\s*([\[(])").unwrap()
+});
+
+static SUBQUERY_SELECT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?is)^SELECT\s+(?:DISTINCT\s+)?").unwrap()
+});
+
+/// Name of the select list item of an ARRAY(subquery): its alias or column name
+static SUBQUERY_COLUMN_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^(?:.+\s+AS\s+("?\w+"?)|(?:\w+\.)*("?\w+"?))$"#).unwrap()
+});
+
+/// Right operand of && in parentheses or a function call, e.g. a translated ARRAY(subquery)
+static ARRAY_OVERLAP_CALL_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(\b\w+(?:\.\w+)*|'[^']*')\s*&&\s*((?:\w+\s*)?)\(").unwrap()
 });
 
 static ARRAY_SUBSCRIPT_REGEX: Lazy<Regex> = Lazy::new(|| {
@@ -57,6 +73,7 @@ static ARRAY_FUNCTION_ALIAS_REGEXES: Lazy<Vec<(&'static str, Regex)>> = Lazy::ne
         ("array_lower", Regex::new(r"(?i)array_lower\s*\([^)]+\)\s+(?:AS\s+)?(\w+)").unwrap()),
         ("array_ndims", Regex::new(r"(?i)array_ndims\s*\([^)]+\)\s+(?:AS\s+)?(\w+)").unwrap()),
         ("array_position", Regex::new(r"(?i)array_position\s*\([^)]+\)\s+(?:AS\s+)?(\w+)").unwrap()),
+        ("cardinality", Regex::new(r"(?i)cardinality\s*\([^)]+\)\s+(?:AS\s+)?(\w+)").unwrap()),
         ("json_array_length", Regex::new(r"(?i)json_array_length\s*\([^)]+\)\s+(?:AS\s+)?(\w+)").unwrap()),
         // Array functions that return booleans
         ("array_contains", Regex::new(r"(?i)array_contains\s*\([^)]+\)\s+(?:AS\s+)?(\w+)").unwrap()),
@@ -86,8 +103,8 @@ impl ArrayTranslator {
             return true;
         }
         
-        // ARRAY[...] literal syntax - check both cases without full string conversion
-        if sql.contains("ARRAY[") || sql.contains("array[") {
+        // ARRAY[...] literal and ARRAY(subquery) syntax - check both cases without full string conversion
+        if sql.contains("ARRAY[") || sql.contains("array[") || sql.contains("ARRAY(") || sql.contains("array(") {
             return true;
         }
         
@@ -110,7 +127,7 @@ impl ArrayTranslator {
         const ARRAY_FUNCTIONS: &[&str] = &[
             "array_append", "array_prepend", "array_cat", "array_remove",
             "array_replace", "array_slice", "string_to_array", "array_positions",
-            "array_upper", "array_lower", "array_ndims", "array_position",
+            "array_upper", "array_lower", "array_ndims", "array_position", "cardinality",
             "array_contains", "array_contained", "array_overlap", "json_array_length"
         ];
         
@@ -192,63 +209,142 @@ impl ArrayTranslator {
         Ok((result, metadata))
     }
     
-    /// Translate ARRAY[...] literals to JSON format: ARRAY[1,2,3] -> '[1,2,3]'
+    /// Translate ARRAY constructors. ARRAY[...] of constants becomes a JSON literal,
+    /// ARRAY[1,2,3] -> '[1,2,3]', and json_array() builds it when an element is an
+    /// expression. ARRAY(subquery) aggregates the subquery's column with json_group_array().
     fn translate_array_literals(sql: &str) -> Result<String, PgSqliteError> {
         let mut result = sql.to_string();
-        
-        while let Some(captures) = ARRAY_LITERAL_REGEX.captures(&result) {
-            let array_contents = &captures[1];
-            
-            // Parse the array contents and convert to JSON format
-            let json_array = Self::convert_array_contents_to_json(array_contents)?;
-            
-            result = result.replace(&captures[0], &json_array);
+        let starts: Vec<_> = ARRAY_CONSTRUCTOR_REGEX.captures_iter(sql)
+            .filter(|caps| !in_string_literal(sql, caps.get(0).unwrap().start()))
+            .map(|caps| (caps.get(0).unwrap().start(), caps.get(1).unwrap().start()))
+            .collect();
+
+        // Inner constructors come later in the text, so going backwards translates them first
+        for (start, open) in starts.into_iter().rev() {
+            let close = matching_paren(&result, open)
+                .ok_or_else(|| PgSqliteError::Protocol("Unterminated ARRAY constructor".to_string()))?;
+            let contents = &result[open + 1..close];
+
+            let replacement = if result.as_bytes()[open] == b'(' {
+                Self::convert_array_subquery(contents)
+            } else {
+                let elements = split_top_level(contents);
+                if elements.iter().all(|element| Self::json_constant(element).is_some()) {
+                    Self::convert_array_contents_to_json(contents)?
+                } else if Self::is_any_all_argument(&result[..start]) {
+                    // x = ANY(ARRAY[$1, $2]) becomes an IN list later on
+                    continue;
+                } else {
+                    let elements: Vec<String> = elements.iter()
+                        .map(|element| match Self::nested_json_array(element) {
+                            Some(_) => format!("json({element})"),
+                            None => element.to_string(),
+                        })
+                        .collect();
+                    format!("json_array({})", elements.join(", "))
+                }
+            };
+            result.replace_range(start..=close, &replacement);
         }
-        
+
         Ok(result)
     }
-    
-    /// Convert PostgreSQL array contents to JSON format
+
+    /// Convert PostgreSQL array contents of constants to a JSON literal
     fn convert_array_contents_to_json(contents: &str) -> Result<String, PgSqliteError> {
-        let trimmed = contents.trim();
-        
-        // Handle empty arrays
-        if trimmed.is_empty() {
-            return Ok("'[]'".to_string());
+        let json_elements = split_top_level(contents).iter()
+            .map(|element| Self::json_constant(element)
+                .ok_or_else(|| PgSqliteError::Protocol(format!("Not a constant array element: {element}"))))
+            .collect::<Result<Vec<_>, _>>()?;
+        Ok(format!("'[{}]'", json_elements.join(",").replace('\'', "''")))
+    }
+
+    /// JSON text of a constant array element, None for expressions
+    fn json_constant(element: &str) -> Option<String> {
+        if let Some(nested) = Self::nested_json_array(element) {
+            return Some(nested);
         }
-        
-        // Split by commas and process each element
-        let elements: Vec<&str> = trimmed.split(',').collect();
-        let mut json_elements = Vec::new();
-        
-        for element in elements {
-            let trimmed_element = element.trim();
-            
-            // Check if it's a string literal (quoted)
-            if (trimmed_element.starts_with('\'') && trimmed_element.ends_with('\'')) ||
-               (trimmed_element.starts_with('"') && trimmed_element.ends_with('"')) {
-                // It's a quoted string - extract the content and properly escape for JSON
-                let content = &trimmed_element[1..trimmed_element.len()-1];
-                json_elements.push(format!("\"{}\"", content.replace("\"", "\\\"")));
-            } else {
-                // It's a number, boolean, or unquoted value
-                // For PostgreSQL compatibility, treat unquoted values as strings
-                if trimmed_element.parse::<i64>().is_ok() || 
-                   trimmed_element.parse::<f64>().is_ok() ||
-                   trimmed_element == "true" || 
-                   trimmed_element == "false" ||
-                   trimmed_element == "null" {
-                    // Valid JSON values can be added directly
-                    json_elements.push(trimmed_element.to_string());
-                } else {
-                    // Treat as string and quote it
-                    json_elements.push(format!("\"{trimmed_element}\""));
-                }
+        // ARRAY[[1,2],[3,4]] spells the inner arrays without the keyword
+        if let Some(inner) = element.strip_prefix('[').and_then(|e| e.strip_suffix(']')) {
+            let elements = split_top_level(inner).iter()
+                .map(|element| Self::json_constant(element))
+                .collect::<Option<Vec<_>>>()?;
+            return Some(format!("[{}]", elements.join(",")));
+        }
+        if element.len() >= 2 && element.starts_with('\'') && element.ends_with('\'') {
+            let content = element[1..element.len() - 1].replace("''", "'");
+            return Some(serde_json::Value::String(content).to_string());
+        }
+        if element.len() >= 2 && element.starts_with('"') && element.ends_with('"') {
+            return Some(serde_json::Value::String(element[1..element.len() - 1].to_string()).to_string());
+        }
+        if element.parse::<i64>().is_ok() || element.parse::<f64>().is_ok() {
+            return Some(element.to_string());
+        }
+        match element.to_lowercase().as_str() {
+            lower @ ("true" | "false" | "null") => Some(lower.to_string()),
+            _ => None,
+        }
+    }
+
+    /// A nested ARRAY[...] that has already become a JSON literal
+    fn nested_json_array(element: &str) -> Option<String> {
+        let json = element.strip_prefix("'[")?.strip_suffix("]'")?;
+        let json = format!("[{}]", json.replace("''", "'"));
+        serde_json::from_str::<serde_json::Value>(&json).ok()?.is_array().then_some(json)
+    }
+
+    /// ARRAY(SELECT x FROM ...) -> (SELECT json_group_array(x) FROM (SELECT x FROM ...))
+    fn convert_array_subquery(subquery: &str) -> String {
+        let subquery = subquery.trim();
+        let projection_start = SUBQUERY_SELECT_REGEX.find(subquery).map(|m| m.end()).unwrap_or(0);
+        let from = Self::find_top_level_from(subquery, projection_start).unwrap_or(subquery.len());
+        let projection = subquery[projection_start..from].trim();
+
+        match SUBQUERY_COLUMN_REGEX.captures(projection) {
+            Some(caps) => {
+                let column = caps.get(1).or(caps.get(2)).unwrap().as_str();
+                format!("(SELECT json_group_array({column}) FROM ({subquery}))")
             }
+            // Name the expression so the outer query can refer to it
+            None => format!(
+                "(SELECT json_group_array(__pgsqlite_element) FROM ({} AS __pgsqlite_element {}))",
+                subquery[..from].trim_end(),
+                &subquery[from..]
+            ),
         }
-        
-        Ok(format!("'[{}]'", json_elements.join(",")))
     }
+
+    /// Position of the FROM keyword of the outermost SELECT
+    fn find_top_level_from(query: &str, from: usize) -> Option<usize> {
+        let bytes = query.as_bytes();
+        let mut depth = 0;
+        let mut in_quote = false;
+        for i in from..bytes.len() {
+            match bytes[i] {
+                b'\'' => in_quote = !in_quote,
+                b'(' if !in_quote => depth += 1,
+                b')' if !in_quote => depth -= 1,
+                _ if !in_quote && depth == 0
+                    && query[i..].len() >= 5
+                    && query[i..i + 4].eq_ignore_ascii_case("FROM")
+                    && bytes[i - 1].is_ascii_whitespace()
+                    && bytes[i + 4].is_ascii_whitespace() => return Some(i),
+                _ => {}
+            }
+        }
+        None
+    }
+
+    /// Whether the text before an ARRAY constructor ends with `ANY (` or `ALL (`
+    fn is_any_all_argument(before: &str) -> bool {
+        let Some(before) = before.trim_end().strip_suffix('(') else {
+            return false;
+        };
+        let before = before.trim_end().to_uppercase();
+        before.ends_with("ANY") || before.ends_with("ALL")
+    }
+
     
     /// Translate array subscript access: array[1] -> json_extract(array, '$[0]')
     fn translate_array_subscript(sql: &str) -> Result<String, PgSqliteError> {
@@ -431,6 +527,17 @@ impl ArrayTranslator {
     fn translate_overlap_operator(sql: &str) -> Result<String, PgSqliteError> {
         let mut result = sql.to_string();
         
+        // Subqueries, e.g. from ARRAY(SELECT ...), and calls need their parentheses matched
+        while let Some(captures) = ARRAY_OVERLAP_CALL_REGEX.captures(&result) {
+            let whole = captures.get(0).unwrap();
+            let operand_start = captures.get(2).unwrap().start();
+            let Some(close) = matching_paren(&result, whole.end() - 1) else {
+                break;
+            };
+            let replacement = format!("array_overlap({}, {})", &captures[1], &result[operand_start..=close]);
+            result.replace_range(whole.start()..=close, &replacement);
+        }
+        
         while let Some(captures) = ARRAY_OVERLAP_REGEX.captures(&result) {
             let array1 = &captures[1];
             let array2 = captures[2].trim();
@@ -461,7 +568,7 @@ impl ArrayTranslator {
                     // Use type-aware resolution
                     if Self::is_likely_array_concatenation(&left_operand, &right_operand) {
                         let original_text = chars[left_start..right_end].iter().collect::<String>();
-                        let replacement = format!("array_cat({left_operand}, {right_operand})");
+                        let replacement = Self::concat_call(&left_operand, &right_operand);
                         replacements.push((original_text, replacement));
                     }
                 }
@@ -531,8 +638,11 @@ impl ArrayTranslator {
             }
         } else if chars[end - 1] == '\'' {
             // String literal - find opening quote
-            while start > 0 && chars[start] != '\'' {
+            while start > 0 {
                 start -= 1;
+                if chars[start] == '\'' {
+                    break;
+                }
             }
         } else {
             // Identifier - find word boundary
@@ -582,21 +692,12 @@ impl ArrayTranslator {
                 }
             }
         } else if chars[start] == '\'' {
-            // String literal or array literal
+            // String literal, including array literals '{...}' and '[...]'
             end = start + 1;
-            if chars[start + 1] == '[' {
-                // Array literal '{...}'
-                while end < chars.len() && !(chars[end - 1] == '}' && chars[end] == '\'') {
-                    end += 1;
-                }
-                end += 1; // Include closing quote
-            } else {
-                // Regular string literal
-                while end < chars.len() && chars[end] != '\'' {
-                    end += 1;
-                }
-                end += 1; // Include closing quote
+            while end < chars.len() && chars[end] != '\'' {
+                end += 1;
             }
+            end += 1; // Include closing quote
         } else {
             // Identifier - find word boundary
             while end < chars.len() && (chars[end].is_alphanumeric() || chars[end] == '_' || chars[end] == '.') {
@@ -635,6 +736,57 @@ impl ArrayTranslator {
         false
     }
     
+    /// The call for `left || right`: a scalar literal on either side is an element appended
+    /// to or prepended onto the other operand, as in PostgreSQL, otherwise two arrays are
+    /// concatenated (array_cat also appends or prepends an operand that turns out not to be
+    /// an array, such as a text column)
+    fn concat_call(left: &str, right: &str) -> String {
+        if Self::is_scalar_literal(right) && !Self::is_scalar_literal(left) {
+            format!("array_append({left}, {right})")
+        } else if Self::is_scalar_literal(left) && !Self::is_scalar_literal(right) {
+            format!("array_prepend({left}, {right})")
+        } else {
+            format!("array_cat({left}, {right})")
+        }
+    }
+    
+    /// Check if an operand is a number, boolean or quoted string that isn't an array literal
+    fn is_scalar_literal(s: &str) -> bool {
+        if s.len() >= 2 && s.starts_with('\'') && s.ends_with('\'') {
+            return !Self::is_array_literal(s);
+        }
+        s.parse::<f64>().is_ok() || s.eq_ignore_ascii_case("true") || s.eq_ignore_ascii_case("false")
+    }
+    
+    /// Check if an operand is a (possibly qualified) column name
+    fn is_column_reference(s: &str) -> bool {
+        s.starts_with(|c: char| c.is_alphabetic() || c == '_')
+            && s.chars().all(|c| c.is_alphanumeric() || c == '_' || c == '.')
+            && !s.eq_ignore_ascii_case("true") && !s.eq_ignore_ascii_case("false")
+    }
+    
+    /// Array type of an array literal ('[1,2]' or '{1,2}') from its elements: integer,
+    /// numeric and boolean elements give those arrays, anything else a text array
+    fn literal_array_type(s: &str) -> Option<PgType> {
+        if !Self::is_array_literal(s) {
+            return None;
+        }
+        let inner = &s[2..s.len() - 2];
+        let elements: Vec<&str> = inner.split(',').map(|e| e.trim()).filter(|e| !e.is_empty()).collect();
+        if elements.is_empty() {
+            return Some(PgType::TextArray);
+        }
+        Some(if elements.iter().all(|e| e.parse::<i32>().is_ok()) {
+            PgType::Int4Array
+        } else if elements.iter().all(|e| e.parse::<f64>().is_ok()) {
+            PgType::NumericArray
+        } else if elements.iter().all(|e| e.eq_ignore_ascii_case("true") || e.eq_ignore_ascii_case("false")) {
+            PgType::BoolArray
+        } else {
+            PgType::TextArray
+        })
+    }
+    
     /// Check if a string looks like an array literal
     fn is_array_literal(s: &str) -> bool {
         // PostgreSQL array literal: '{...}' with comma-separated values
@@ -708,27 +860,31 @@ impl ArrayTranslator {
             // Use type-aware resolution to determine if this should be array concatenation
             if Self::is_likely_array_concatenation(operand1, operand2) {
                 let original = captures[0].to_string();
-                let replacement = format!("array_cat({operand1}, {operand2})");
+                let replacement = Self::concat_call(operand1, operand2);
                 
-                replacements.push((original, replacement, operand1.to_string()));
+                replacements.push((original, replacement, operand1.to_string(), operand2.to_string()));
             }
         }
         
-        // Simplified alias detection - for now, skip the complex metadata tracking
-        // as the core functionality is working
-        let alias_map: std::collections::HashMap<String, String> = std::collections::HashMap::new();
-        
         // Apply replacements
         let mut final_result = result;
-        for (original, replacement, array1) in replacements {
+        for (original, replacement, operand1, operand2) in replacements {
             final_result = final_result.replace(&original, &replacement);
             
-            // Check if this expression had an alias
-            if let Some(alias) = alias_map.get(&original) {
+            // An aliased concatenation is typed as the array it extends: the array column's
+            // type, or one inferred from an array literal's elements
+            let alias_regex = Regex::new(&format!(r"(?i){}\s+AS\s+(\w+)", regex::escape(&replacement))).unwrap();
+            if let Some(alias) = alias_regex.captures(&final_result).map(|caps| caps[1].to_string()) {
                 debug!("Found alias '{}' for array concat expression", alias);
-                metadata.add_hint(alias.clone(), ColumnTypeHint {
-                    source_column: Some(array1),
-                    suggested_type: Some(PgType::Text), // Return as TEXT (JSON array)
+                let source_column = [&operand1, &operand2].into_iter()
+                    .find(|operand| Self::is_column_reference(operand))
+                    .map(|operand| operand.rsplit('.').next().unwrap_or(operand).to_string());
+                let suggested_type = [&operand1, &operand2].into_iter()
+                    .find_map(|operand| Self::literal_array_type(operand))
+                    .unwrap_or(PgType::TextArray);
+                metadata.add_hint(alias, ColumnTypeHint {
+                    source_column,
+                    suggested_type: Some(suggested_type),
                     datetime_subtype: None,
                     is_expression: true,
                     expression_type: Some(ExpressionType::Other),
@@ -754,6 +910,11 @@ impl ArrayTranslator {
                 let alias = captures[1].to_string();
                 debug!("Found array function {} with alias: {}", func_name, alias);
                 
+                // Concatenations already carry their array type
+                if metadata.get_hint(&alias).is_some() {
+                    continue;
+                }
+                
                 // Determine return type based on function name
                 let suggested_type = match *func_name {
                     // Functions that return arrays (stored as JSON TEXT)
@@ -763,7 +924,7 @@ impl ArrayTranslator {
                     
                     // Functions that return integers
                     "array_length" | "array_upper" | "array_lower" | "array_ndims" |
-                    "array_position" | "json_array_length" => PgType::Int4,
+                    "array_position" | "cardinality" | "json_array_length" => PgType::Int4,
                     
                     // Functions that return booleans
                     "array_contains" | "array_contained" | "array_overlap" => PgType::Bool,
@@ -885,11 +1046,8 @@ mod tests {
         // Test ARRAY[] || ARRAY[] syntax
         let sql = "SELECT ARRAY[1,2] || ARRAY[3,4] AS result";
         let result = ArrayTranslator::translate_array_operators(sql).unwrap();
-        // With the new implementation, ARRAY[...] is translated to JSON format first
-        // The concatenation might not be detected as array concatenation due to the translation order
-        assert!(result.contains("[1,2]"));
-        assert!(result.contains("[3,4]"));
-        assert!(result.contains("||")); // The || operator should still be present
+        // ARRAY[...] is translated to JSON format first, then concatenated
+        assert_eq!(result, "SELECT array_cat('[1,2]', '[3,4]') AS result");
         
         // Test mixed ARRAY[] and literal syntax
         let sql2 = "SELECT ARRAY[1,2] || '{3,4}' AS result";
@@ -937,6 +1095,33 @@ mod tests {
         assert_eq!(result3, sql3); // Should remain unchanged
     }
     
+    #[test]
+    fn test_concat_element_append_prepend() {
+        assert_eq!(
+            ArrayTranslator::translate_array_operators("SELECT tags || 'new' AS t FROM products").unwrap(),
+            "SELECT array_append(tags, 'new') AS t FROM products"
+        );
+        assert_eq!(
+            ArrayTranslator::translate_array_operators("SELECT 0 || numbers FROM products").unwrap(),
+            "SELECT array_prepend(0, numbers) FROM products"
+        );
+        assert_eq!(
+            ArrayTranslator::translate_array_operators("SELECT '{a}' || tags FROM products").unwrap(),
+            "SELECT array_cat('{a}', tags) FROM products"
+        );
+        
+        // Aliased concatenations are typed from the array column or literal they extend
+        let (result, metadata) = ArrayTranslator::translate_with_metadata(
+            "SELECT numbers || 4 AS appended, ARRAY[1.5] || prices AS merged FROM products"
+        ).unwrap();
+        assert_eq!(result, "SELECT array_append(numbers, 4) AS appended, array_cat('[1.5]', prices) AS merged FROM products");
+        let hint = metadata.get_hint("appended").unwrap();
+        assert_eq!(hint.source_column.as_deref(), Some("numbers"));
+        let hint = metadata.get_hint("merged").unwrap();
+        assert_eq!(hint.source_column.as_deref(), Some("prices"));
+        assert_eq!(hint.suggested_type, Some(PgType::NumericArray));
+    }
+    
     #[test]
     fn test_is_likely_array_concatenation() {
         // ARRAY[] syntax should always be array concatenation
@@ -996,6 +1181,37 @@ mod tests {
         assert_eq!(result, "INSERT INTO products (tags) VALUES ('[\"new\",\"product\"]')");
     }
     
+    #[test]
+    fn test_array_constructors() {
+        // Quotes and commas inside the elements, nested arrays
+        let sql = "SELECT ARRAY['it''s', 'a,b'], ARRAY[[1,2],[3,4]]";
+        let result = ArrayTranslator::translate_array_operators(sql).unwrap();
+        assert_eq!(result, "SELECT '[\"it''s\",\"a,b\"]', '[[1,2],[3,4]]'");
+
+        // Expressions are collected by json_array()
+        let sql = "SELECT array[title, 'draft'] FROM books";
+        let result = ArrayTranslator::translate_array_operators(sql).unwrap();
+        assert_eq!(result, "SELECT json_array(title, 'draft') FROM books");
+
+        // ARRAY(subquery) aggregates the subquery's column
+        let sql = "SELECT ARRAY(SELECT g.name FROM genres g ORDER BY g.id)";
+        let result = ArrayTranslator::translate_array_operators(sql).unwrap();
+        assert_eq!(result, "SELECT (SELECT json_group_array(name) FROM (SELECT g.name FROM genres g ORDER BY g.id))");
+
+        let sql = "SELECT ARRAY(SELECT upper(name) FROM genres)";
+        let result = ArrayTranslator::translate_array_operators(sql).unwrap();
+        assert_eq!(result, "SELECT (SELECT json_group_array(__pgsqlite_element) FROM (SELECT upper(name) AS __pgsqlite_element FROM genres))");
+
+        // Both forms feed the overlap operator
+        let sql = "SELECT id FROM books WHERE tags && ARRAY(SELECT name FROM genres)";
+        let result = ArrayTranslator::translate_array_operators(sql).unwrap();
+        assert_eq!(result, "SELECT id FROM books WHERE array_overlap(tags, (SELECT json_group_array(name) FROM (SELECT name FROM genres)))");
+
+        let sql = "SELECT id FROM books WHERE tags && ARRAY[$1, $2]";
+        let result = ArrayTranslator::translate_array_operators(sql).unwrap();
+        assert_eq!(result, "SELECT id FROM books WHERE array_overlap(tags, json_array($1, $2))");
+    }
+
     #[test]
     fn test_array_literal_with_concatenation() {
         // Test ARRAY[...] || ARRAY[...] - this should work with existing concat logic
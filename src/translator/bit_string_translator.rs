@@ -0,0 +1,400 @@
+use rusqlite::Connection;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::{in_string_literal, matching_paren, opening_paren, quoted_end};
+use crate::translator::PgTypeofTranslator;
+use crate::validator::BitStringTriggers;
+
+static BIT_LITERAL_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bB'([^']*)'").unwrap()
+});
+
+static BIT_CAST_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)::\s*(bit\s+varying|varbit|bit)\b(?:\s*\(\s*(\d+)\s*\))?").unwrap()
+});
+
+static UPDATE_TABLE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*UPDATE\s+(\w+)").unwrap()
+});
+
+/// Functions returning a bit string, whose calls are bit operands
+const BIT_FUNCTIONS: &[&str] = &["varbit", "bit", "bitand", "bitor", "bitxor", "bitnot", "bitshiftleft", "bitshiftright", "set_bit"];
+
+/// Bit strings are stored as their text of 0s and 1s, so `B'0101'` literals become
+/// varbit('0101'), `::bit(n)` and `::bit varying(n)` casts become bit() and varbit() calls,
+/// and the bitwise operators `&`, `|`, `#`, `~`, `<<` and `>>` become bitand(), bitor(),
+/// bitxor(), bitnot(), bitshiftleft() and bitshiftright(). Only operators with a bit
+/// operand (a bit column, a literal, a cast or one of those calls) are rewritten; integer
+/// arithmetic and the operators spelled the same for other types are left alone.
+pub struct BitStringTranslator;
+
+impl BitStringTranslator {
+    /// Check if the query has a bit string literal or cast, or an operator that might apply to bits
+    pub fn needs_translation(query: &str) -> bool {
+        BIT_LITERAL_REGEX.is_match(query)
+            || (query.contains("::") && BIT_CAST_REGEX.is_match(query))
+            || Self::has_operator(query)
+    }
+
+    /// Translate bit string literals, casts and operators
+    pub fn translate_query(query: &str, conn: &Connection) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let mut result = Self::translate_literals(query);
+        result = Self::translate_casts(&result);
+        if Self::has_operator(&result) {
+            let bit_columns = Self::bit_columns(&result, conn);
+            // An operator is only known to apply to bits once the operators nested in its
+            // operands are rewritten, so repeat until nothing changes
+            loop {
+                let next = Self::translate_operators(&result, &bit_columns);
+                if next == result {
+                    break;
+                }
+                result = next;
+            }
+        }
+
+        if result != query {
+            debug!("Translated bit string operators: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// B'0101' becomes varbit('0101'), which checks the digits
+    fn translate_literals(query: &str) -> String {
+        let mut result = String::with_capacity(query.len());
+        let mut last = 0;
+        for caps in BIT_LITERAL_REGEX.captures_iter(query) {
+            let whole = caps.get(0).unwrap();
+            if in_string_literal(query, whole.start()) {
+                continue;
+            }
+            result.push_str(&query[last..whole.start()]);
+            result.push_str(&format!("varbit('{}')", &caps[1]));
+            last = whole.end();
+        }
+        result.push_str(&query[last..]);
+        result
+    }
+
+    /// expr::bit(n) becomes bit(expr, n), expr::bit varying(n) varbit(expr, n)
+    fn translate_casts(query: &str) -> String {
+        let mut result = query.to_string();
+        loop {
+            let Some((range, replacement)) = BIT_CAST_REGEX.captures_iter(&result).find_map(|caps| {
+                let whole = caps.get(0)?;
+                if in_string_literal(&result, whole.start()) {
+                    return None;
+                }
+                let bytes = result.as_bytes();
+                let start = Self::primary_start(bytes, Self::skip_space_back(bytes, whole.start()))?;
+                let operand = &result[start..whole.start()];
+                let varying = !caps[1].eq_ignore_ascii_case("bit");
+                let replacement = match (varying, caps.get(2)) {
+                    (false, length) => format!("bit({operand}, {})", length.map_or("1", |m| m.as_str())),
+                    (true, Some(length)) => format!("varbit({operand}, {})", length.as_str()),
+                    (true, None) => format!("varbit({operand})"),
+                };
+                Some((start..whole.end(), replacement))
+            }) else {
+                return result;
+            };
+            result.replace_range(range, &replacement);
+        }
+    }
+
+    /// One left-to-right pass rewriting the operators that have a bit operand
+    fn translate_operators(query: &str, bit_columns: &[String]) -> String {
+        let mut result = query.to_string();
+        let mut i = 0;
+        while i < result.len() {
+            let bytes = result.as_bytes();
+            let Some((operator, len)) = Self::operator_at(bytes, i) else {
+                i = Self::next_position(bytes, i);
+                continue;
+            };
+            let is_bit = |operand: &str| Self::is_bit_operand(operand, bit_columns);
+
+            if operator == "bitnot" {
+                let Some(end) = Self::primary_end(bytes, Self::skip_space(bytes, i + 1)) else {
+                    i += 1;
+                    continue;
+                };
+                let operand = result[i + 1..end].trim().to_string();
+                if is_bit(&operand) {
+                    result.replace_range(i..end, &format!("bitnot({operand})"));
+                }
+                i += 1;
+                continue;
+            }
+
+            let left_start = Self::primary_start(bytes, Self::skip_space_back(bytes, i));
+            let right_end = Self::right_operand_end(bytes, i + len);
+            let (Some(start), Some(end)) = (left_start, right_end) else {
+                i += len;
+                continue;
+            };
+            let left = result[start..i].trim().to_string();
+            let right = result[i + len..end].trim().to_string();
+            let applies = match operator {
+                "bitshiftleft" | "bitshiftright" => is_bit(&left),
+                _ => is_bit(&left) || is_bit(&right),
+            };
+            if applies {
+                result.replace_range(start..end, &format!("{operator}({left}, {right})"));
+                // The call is the left operand of any operator following it
+                i = start;
+            } else {
+                i += len;
+            }
+        }
+        result
+    }
+
+    /// The bitwise operator at `i`, as the function it becomes, and its length. The
+    /// neighbouring bytes rule out ||, &&, #>, ->>, <<= and the other operators sharing
+    /// a character with them, and a ~ after an operand is the regex match operator.
+    fn operator_at(bytes: &[u8], i: usize) -> Option<(&'static str, usize)> {
+        let prev = if i > 0 { bytes[i - 1] } else { b' ' };
+        let next = |n: usize| bytes.get(i + n).copied().unwrap_or(b' ');
+        let is_operator_byte = |b: u8| b"&|#~<>=!@-+*/%^?".contains(&b);
+        match bytes[i] {
+            b'&' if !is_operator_byte(prev) && !is_operator_byte(next(1)) => Some(("bitand", 1)),
+            b'|' if !is_operator_byte(prev) && !is_operator_byte(next(1)) => Some(("bitor", 1)),
+            b'#' if !is_operator_byte(prev) && !is_operator_byte(next(1)) => Some(("bitxor", 1)),
+            b'<' if next(1) == b'<' && !is_operator_byte(prev) && !is_operator_byte(next(2)) => Some(("bitshiftleft", 2)),
+            b'>' if next(1) == b'>' && !is_operator_byte(prev) && !is_operator_byte(next(2)) => Some(("bitshiftright", 2)),
+            b'~' if !is_operator_byte(next(1)) && !matches!(prev, b'!' | b'~' | b'@') => {
+                let before = Self::skip_space_back(bytes, i);
+                let after_operand = before > 0
+                    && (Self::is_word_byte(bytes[before - 1]) || matches!(bytes[before - 1], b')' | b'\'' | b'"' | b']'));
+                (!after_operand).then_some(("bitnot", 1))
+            }
+            _ => None,
+        }
+    }
+
+    /// Position after the byte at `i`, skipping over string literals, quoted identifiers
+    /// and comments
+    fn next_position(bytes: &[u8], i: usize) -> usize {
+        match bytes[i] {
+            b'\'' | b'"' => quoted_end(bytes, i),
+            b'-' if bytes.get(i + 1) == Some(&b'-') => {
+                bytes[i..].iter().position(|&b| b == b'\n').map_or(bytes.len(), |n| i + n)
+            }
+            b'/' if bytes.get(i + 1) == Some(&b'*') => {
+                memchr::memmem::find(&bytes[i + 2..], b"*/").map_or(bytes.len(), |end| i + 2 + end + 2)
+            }
+            _ => i + 1,
+        }
+    }
+
+    /// Whether the query has a byte of a bitwise operator outside string literals
+    fn has_operator(query: &str) -> bool {
+        let bytes = query.as_bytes();
+        let mut i = 0;
+        while i < bytes.len() {
+            if Self::operator_at(bytes, i).is_some() {
+                return true;
+            }
+            i = Self::next_position(bytes, i);
+        }
+        false
+    }
+
+    /// A bit column, or a call returning a bit string, optionally in parentheses
+    fn is_bit_operand(operand: &str, bit_columns: &[String]) -> bool {
+        let mut operand = operand.trim();
+        while operand.starts_with('(')
+            && matching_paren(operand, 0) == Some(operand.len() - 1) {
+            operand = operand[1..operand.len() - 1].trim();
+        }
+        if operand.ends_with(')') {
+            let name = operand[..operand.find('(').unwrap_or(0)].trim();
+            return BIT_FUNCTIONS.iter().any(|f| f.eq_ignore_ascii_case(name));
+        }
+        let column = operand.rsplit('.').next().unwrap_or(operand).trim_matches('"');
+        !column.is_empty() && bit_columns.iter().any(|c| c.eq_ignore_ascii_case(column))
+    }
+
+    /// Look up the bit columns of the tables the query reads from or updates
+    fn bit_columns(query: &str, conn: &Connection) -> Vec<String> {
+        let mut tables: Vec<String> = PgTypeofTranslator::extract_table_refs(query)
+            .into_iter()
+            .map(|(table, _)| table)
+            .collect();
+        if let Some(caps) = UPDATE_TABLE_REGEX.captures(query) {
+            tables.push(caps[1].to_string());
+        }
+
+        let mut columns = Vec::new();
+        for table in tables {
+            let table_columns: Vec<(String, String)> = conn.prepare(
+                "SELECT column_name, pg_type FROM __pgsqlite_schema WHERE table_name = ?1"
+            )
+                .and_then(|mut stmt| {
+                    let columns = stmt.query_map([&table], |row| Ok((row.get(0)?, row.get(1)?)))?.collect();
+                    columns
+                })
+                .unwrap_or_default();
+            columns.extend(table_columns.into_iter()
+                .filter(|(_, pg_type)| BitStringTriggers::bit_typmod(pg_type).is_some())
+                .map(|(column, _)| column));
+        }
+        columns
+    }
+
+    /// Start of the literal, column, function call or parenthesized expression whose last
+    /// byte is at `end - 1`
+    fn primary_start(bytes: &[u8], end: usize) -> Option<usize> {
+        if end == 0 {
+            return None;
+        }
+        match bytes[end - 1] {
+            b')' => {
+                let open = opening_paren(bytes, end - 1)?;
+                // A function name right before the parenthesis belongs to the operand
+                let mut name = open;
+                while name > 0 && Self::is_word_byte(bytes[name - 1]) {
+                    name -= 1;
+                }
+                Some(name)
+            }
+            quote @ (b'\'' | b'"') => {
+                let mut open = end - 1;
+                loop {
+                    open = bytes[..open].iter().rposition(|&b| b == quote)?;
+                    // A doubled quote is an escaped one inside the literal
+                    if open > 0 && bytes[open - 1] == quote {
+                        open -= 1;
+                    } else {
+                        break;
+                    }
+                }
+                Some(open)
+            }
+            b if Self::is_word_byte(b) => {
+                let mut start = end;
+                while start > 0 && (Self::is_word_byte(bytes[start - 1]) || bytes[start - 1] == b'.') {
+                    start -= 1;
+                }
+                Some(start)
+            }
+            _ => None,
+        }
+    }
+
+    /// End of the operand starting at `start`: an optional sign and a primary expression
+    fn right_operand_end(bytes: &[u8], start: usize) -> Option<usize> {
+        let mut i = Self::skip_space(bytes, start);
+        if i < bytes.len() && bytes[i] == b'-' {
+            i = Self::skip_space(bytes, i + 1);
+        }
+        Self::primary_end(bytes, i)
+    }
+
+    /// End of the literal, column, function call or parenthesized expression at `start`
+    fn primary_end(bytes: &[u8], start: usize) -> Option<usize> {
+        match *bytes.get(start)? {
+            b'(' => Some(matching_paren(bytes, start)? + 1),
+            b'\'' | b'"' => Some(quoted_end(bytes, start)),
+            b if Self::is_word_byte(b) => {
+                let mut end = start;
+                while end < bytes.len() && (Self::is_word_byte(bytes[end]) || bytes[end] == b'.') {
+                    end += 1;
+                }
+                let after = Self::skip_space(bytes, end);
+                if bytes.get(after) == Some(&b'(') {
+                    end = matching_paren(bytes, after)? + 1;
+                }
+                Some(end)
+            }
+            _ => None,
+        }
+    }
+
+    fn skip_space(bytes: &[u8], mut i: usize) -> usize {
+        while i < bytes.len() && bytes[i].is_ascii_whitespace() {
+            i += 1;
+        }
+        i
+    }
+
+    fn skip_space_back(bytes: &[u8], mut i: usize) -> usize {
+        while i > 0 && bytes[i - 1].is_ascii_whitespace() {
+            i -= 1;
+        }
+        i
+    }
+
+    fn is_word_byte(b: u8) -> bool {
+        b.is_ascii_alphanumeric() || b == b'_' || b == b'$'
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn setup() -> Connection {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute("CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT)", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('features', 'flags', 'BIT(8)', 'TEXT')", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('features', 'mask', 'BIT VARYING(8)', 'TEXT')", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('features', 'id', 'INTEGER', 'INTEGER')", []).unwrap();
+        conn
+    }
+
+    #[test]
+    fn test_literals_and_casts() {
+        let conn = setup();
+
+        assert_eq!(
+            BitStringTranslator::translate_query("INSERT INTO features (flags) VALUES (B'00000101')", &conn),
+            "INSERT INTO features (flags) VALUES (varbit('00000101'))"
+        );
+        assert_eq!(
+            BitStringTranslator::translate_query("SELECT 5::bit(4), '101'::bit varying(2), id::bit FROM features", &conn),
+            "SELECT bit(5, 4), varbit('101', 2), bit(id, 1) FROM features"
+        );
+        // Text that merely looks like a literal is left alone
+        let query = "SELECT 'b''1''' FROM features";
+        assert_eq!(BitStringTranslator::translate_query(query, &conn), query);
+    }
+
+    #[test]
+    fn test_operators() {
+        let conn = setup();
+
+        assert_eq!(
+            BitStringTranslator::translate_query("SELECT id FROM features WHERE flags & B'00000001' = B'00000001'", &conn),
+            "SELECT id FROM features WHERE bitand(flags, varbit('00000001')) = varbit('00000001')"
+        );
+        assert_eq!(
+            BitStringTranslator::translate_query("SELECT f.flags | f.mask # B'11110000', ~flags, flags << 2, flags >> 1 FROM features f", &conn),
+            "SELECT bitxor(bitor(f.flags, f.mask), varbit('11110000')), bitnot(flags), bitshiftleft(flags, 2), bitshiftright(flags, 1) FROM features f"
+        );
+        assert_eq!(
+            BitStringTranslator::translate_query("UPDATE features SET flags = set_bit(flags, 0, 1) | (mask & flags)", &conn),
+            "UPDATE features SET flags = bitor(set_bit(flags, 0, 1), (bitand(mask, flags)))"
+        );
+    }
+
+    #[test]
+    fn test_other_operators_untouched() {
+        let conn = setup();
+
+        for query in [
+            "SELECT id & 1, id | 2, id << 1 FROM features",
+            "SELECT 'a' || 'b', data #> '{a}', data ->> 'k' FROM features",
+            "SELECT id FROM features WHERE name ~ 'x' AND tags && ARRAY['a']",
+        ] {
+            assert_eq!(BitStringTranslator::translate_query(query, &conn), query);
+        }
+        assert!(!BitStringTranslator::needs_translation("SELECT 'a' || 'b' FROM t"));
+    }
+}
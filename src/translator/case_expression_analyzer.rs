@@ -0,0 +1,167 @@
+use rusqlite::Connection;
+use sqlparser::ast::{BinaryOperator, Expr, SelectItem, SetExpr, Statement, TableFactor, TableWithJoins, UnaryOperator};
+use sqlparser::dialect::PostgreSqlDialect;
+use sqlparser::parser::Parser;
+use super::{TranslationMetadata, ColumnTypeHint, ExpressionType};
+use crate::types::{PgType, SchemaTypeMapper};
+use tracing::debug;
+
+/// Analyzes aliased CASE and COALESCE expressions in the projection list to generate result
+/// type metadata.
+///
+/// PostgreSQL gives a CASE the common type of its THEN/ELSE results, so
+/// `CASE WHEN ... THEN price ELSE 0 END` is numeric when price is numeric, and resolves
+/// `COALESCE(a, b)` the same way over its arguments. SQLite returns whatever value the taken
+/// branch produced, which leaves nothing to infer the column type from (or only a NULL);
+/// this analyzer resolves the branch types against the schema instead.
+pub struct CaseExpressionAnalyzer;
+
+impl CaseExpressionAnalyzer {
+    /// Quick check for CASE or COALESCE usage
+    pub fn needs_analysis(query: &str) -> bool {
+        let query_upper = query.to_uppercase();
+        (query_upper.contains("CASE") && query_upper.contains("END")) || query_upper.contains("COALESCE")
+    }
+
+    /// Analyze query and extract metadata for aliased CASE and COALESCE columns
+    pub fn analyze_query(query: &str, conn: &Connection) -> TranslationMetadata {
+        let mut metadata = TranslationMetadata::new();
+
+        let statements = match Parser::parse_sql(&PostgreSqlDialect {}, query) {
+            Ok(statements) => statements,
+            Err(e) => {
+                debug!("CASE analysis skipped, failed to parse query: {}", e);
+                return metadata;
+            }
+        };
+
+        for statement in &statements {
+            if let Statement::Query(query) = statement {
+                Self::analyze_set_expr(&query.body, conn, &mut metadata);
+            }
+        }
+
+        metadata
+    }
+
+    fn analyze_set_expr(set_expr: &SetExpr, conn: &Connection, metadata: &mut TranslationMetadata) {
+        match set_expr {
+            SetExpr::Select(select) => {
+                let tables = Self::table_references(&select.from);
+                for item in &select.projection {
+                    if let SelectItem::ExprWithAlias { expr, alias } = item
+                        && (matches!(expr, Expr::Case { .. }) || Self::is_coalesce(expr))
+                        && let Some(pg_type) = Self::expr_type(expr, conn, &tables).and_then(PgType::from_oid) {
+                            debug!("CASE/COALESCE expression aliased as '{}' -> {:?}", alias.value, pg_type);
+                            metadata.add_hint(alias.value.clone(), ColumnTypeHint::expression(None, pg_type, ExpressionType::Other));
+                        }
+                }
+            }
+            SetExpr::Query(query) => Self::analyze_set_expr(&query.body, conn, metadata),
+            SetExpr::SetOperation { left, .. } => Self::analyze_set_expr(left, conn, metadata),
+            _ => {}
+        }
+    }
+
+    fn is_coalesce(expr: &Expr) -> bool {
+        matches!(expr, Expr::Function(function) if function.name.to_string().eq_ignore_ascii_case("coalesce"))
+    }
+
+    /// (alias or name, table name) for every table in the FROM clause
+    fn table_references(from: &[TableWithJoins]) -> Vec<(String, String)> {
+        from.iter()
+            .flat_map(|table| std::iter::once(&table.relation).chain(table.joins.iter().map(|join| &join.relation)))
+            .filter_map(|relation| match relation {
+                TableFactor::Table { name, alias, .. } => {
+                    let table_name = name.to_string().rsplit('.').next()?.trim_matches('"').to_string();
+                    let reference = alias.as_ref().map_or_else(|| table_name.clone(), |alias| alias.name.value.clone());
+                    Some((reference, table_name))
+                }
+                _ => None,
+            })
+            .collect()
+    }
+
+    /// Type of a CASE result or COALESCE argument: columns come from the schema, constants and function calls
+    /// are typed like those of a SELECT without FROM
+    fn expr_type(expr: &Expr, conn: &Connection, tables: &[(String, String)]) -> Option<i32> {
+        match expr {
+            Expr::Identifier(ident) => tables.iter()
+                .find_map(|(_, table)| SchemaTypeMapper::get_type_from_schema(conn, table, &ident.value)),
+            Expr::CompoundIdentifier(parts) if parts.len() >= 2 => {
+                let qualifier = &parts[parts.len() - 2].value;
+                let (_, table) = tables.iter().find(|(reference, _)| reference == qualifier)?;
+                SchemaTypeMapper::get_type_from_schema(conn, table, &parts[parts.len() - 1].value)
+            }
+            Expr::Nested(inner) | Expr::UnaryOp { op: UnaryOperator::Minus | UnaryOperator::Plus, expr: inner } => {
+                Self::expr_type(inner, conn, tables)
+            }
+            Expr::BinaryOp {
+                left,
+                op: BinaryOperator::Plus | BinaryOperator::Minus | BinaryOperator::Multiply |
+                    BinaryOperator::Divide | BinaryOperator::Modulo,
+                right,
+            } => SchemaTypeMapper::promote_arithmetic_type(
+                Self::expr_type(left, conn, tables)?,
+                Self::expr_type(right, conn, tables)?,
+            ),
+            Expr::Case { .. } => SchemaTypeMapper::infer_case_type(expr, &|result| Self::expr_type(result, conn, tables)),
+            _ if Self::is_coalesce(expr) => {
+                SchemaTypeMapper::infer_coalesce_type(expr, &|argument| Self::expr_type(argument, conn, tables))
+            }
+            _ => SchemaTypeMapper::infer_literal_expr_type(expr),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_case_result_types() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute("CREATE TABLE products (id INTEGER PRIMARY KEY, name TEXT, price DECIMAL, stock INTEGER)", []).unwrap();
+        conn.execute("CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT)", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('products', 'price', 'NUMERIC(10,2)', 'DECIMAL')", []).unwrap();
+
+        let metadata = CaseExpressionAnalyzer::analyze_query(
+            "SELECT id, \
+                    CASE WHEN stock > 0 THEN price ELSE 0 END AS sale_price, \
+                    CASE p.stock WHEN 0 THEN 'none' ELSE p.name END AS label, \
+                    CASE WHEN stock > 10 THEN stock * 2 END AS doubled, \
+                    CASE WHEN stock > 0 THEN 'yes' ELSE NULL END AS in_stock \
+             FROM products p",
+            &conn,
+        );
+        assert_eq!(metadata.get_hint("sale_price").unwrap().suggested_type, Some(PgType::Numeric));
+        assert_eq!(metadata.get_hint("label").unwrap().suggested_type, Some(PgType::Text));
+        assert_eq!(metadata.get_hint("doubled").unwrap().suggested_type, Some(PgType::Int4));
+        assert_eq!(metadata.get_hint("in_stock").unwrap().suggested_type, Some(PgType::Text));
+        assert!(metadata.get_hint("id").is_none());
+    }
+
+    #[test]
+    fn test_coalesce_result_types() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute("CREATE TABLE accounts (id INTEGER PRIMARY KEY, nickname TEXT, credit DECIMAL, visits INTEGER, total BIGINT)", []).unwrap();
+        conn.execute("CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT)", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('accounts', 'credit', 'NUMERIC(10,2)', 'DECIMAL')", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('accounts', 'total', 'BIGINT', 'INTEGER')", []).unwrap();
+
+        let metadata = CaseExpressionAnalyzer::analyze_query(
+            "SELECT COALESCE(credit, 0) AS credit_or_zero, \
+                    coalesce(a.visits, a.total) AS count, \
+                    COALESCE(nickname, 'anonymous') AS display, \
+                    COALESCE(NULL, visits) AS maybe_visits, \
+                    COALESCE(id, 1) + 1 AS next_id \
+             FROM accounts a",
+            &conn,
+        );
+        assert_eq!(metadata.get_hint("credit_or_zero").unwrap().suggested_type, Some(PgType::Numeric));
+        assert_eq!(metadata.get_hint("count").unwrap().suggested_type, Some(PgType::Int8));
+        assert_eq!(metadata.get_hint("display").unwrap().suggested_type, Some(PgType::Text));
+        assert_eq!(metadata.get_hint("maybe_visits").unwrap().suggested_type, Some(PgType::Int4));
+        assert!(metadata.get_hint("next_id").is_none());
+    }
+}
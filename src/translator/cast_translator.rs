@@ -1,6 +1,7 @@
 use crate::metadata::EnumMetadata;
 use rusqlite::Connection;
 use super::{SimdCastSearch, TranslationMetadata, ColumnTypeHint, ExpressionType};
+use super::sql_scan::matching_paren;
 use crate::types::PgType;
 use regex::Regex;
 use once_cell::sync::Lazy;
@@ -17,6 +18,11 @@ static SELECT_CAST_PATTERN: Lazy<Regex> = Lazy::new(|| {
     Regex::new(r"(?i)(?:([^,\s]+)::\s*([a-zA-Z0-9_]+(?:\([0-9,]+\))?)|CAST\s*\(\s*([^)]+)\s+AS\s+([a-zA-Z0-9_]+(?:\([0-9,]+\))?)\s*\))(?:\s+AS\s+([a-zA-Z_][a-zA-Z0-9_]*))?").unwrap()
 });
 
+// Regex for types carrying a length/precision modifier, e.g. numeric(10,2) or varchar(20)
+static TYPMOD_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^(NUMERIC|DECIMAL|VARCHAR|CHARACTER\s+VARYING)\s*\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\)$").unwrap()
+});
+
 /// Translates PostgreSQL cast syntax to SQLite-compatible syntax
 pub struct CastTranslator;
 
@@ -205,7 +211,16 @@ impl CastTranslator {
             }
             
             // Check if this is an ENUM type cast
-            let translated_cast = if let Some(conn) = conn {
+            let output = Self::is_select_output(&result, expr_start, cast_pos + 2 + type_end);
+            let translated_cast = if let Some(reg_cast) = Self::translate_reg_cast(expr, type_name, output) {
+                reg_cast
+            } else if let Some(special_float) = Self::translate_special_float_cast(expr, type_name) {
+                special_float
+            } else if let Some(xml_cast) = Self::translate_xml_cast(expr, type_name) {
+                xml_cast
+            } else if let Some(typmod_cast) = Self::translate_typmod_cast(expr, type_name) {
+                typmod_cast
+            } else if let Some(conn) = conn {
                 if Self::is_enum_type(conn, type_name) {
                     // For ENUM types, we validate the value
                     Self::translate_enum_cast(expr, type_name, conn)
@@ -411,7 +426,54 @@ impl CastTranslator {
         
         in_single_quote || in_double_quote
     }
-    
+
+    /// Check if `start..end` is a whole column of the outermost SELECT list, where the
+    /// value is sent to the client rather than compared or passed to a function
+    fn is_select_output(query: &str, start: usize, end: usize) -> bool {
+        let after = query[end..].trim_start();
+        let next_word: String = after.chars().take_while(|c| c.is_ascii_alphabetic()).collect();
+        if !(after.is_empty() || after.starts_with([',', ';'])
+            || ["AS", "FROM", "UNION"].iter().any(|word| next_word.eq_ignore_ascii_case(word))) {
+            return false;
+        }
+
+        let before = query[..start].trim_end();
+        let last_word = before.rsplit(|c: char| !(c.is_ascii_alphanumeric() || c == '_')).next().unwrap_or("");
+        if !(before.ends_with(',') || last_word.eq_ignore_ascii_case("SELECT") || last_word.eq_ignore_ascii_case("DISTINCT")) {
+            return false;
+        }
+
+        // The last clause keyword outside parentheses and quotes must be SELECT
+        let bytes = before.as_bytes();
+        let mut depth = 0;
+        let mut quote = None;
+        let mut clause = String::new();
+        let mut i = 0;
+        while i < bytes.len() {
+            match (quote, bytes[i]) {
+                (Some(q), c) if c == q => quote = None,
+                (Some(_), _) => {}
+                (None, c @ (b'\'' | b'"')) => quote = Some(c),
+                (None, b'(') => depth += 1,
+                (None, b')') => depth -= 1,
+                (None, c) if depth == 0 && c.is_ascii_alphabetic()
+                    && (i == 0 || !(bytes[i - 1].is_ascii_alphanumeric() || bytes[i - 1] == b'_')) => {
+                    let len = bytes[i..].iter().take_while(|b| b.is_ascii_alphanumeric() || **b == b'_').count();
+                    let word = before[i..i + len].to_ascii_uppercase();
+                    if matches!(word.as_str(), "SELECT" | "FROM" | "WHERE" | "GROUP" | "HAVING" | "ORDER" | "LIMIT"
+                        | "VALUES" | "SET" | "RETURNING" | "ON" | "INTO") {
+                        clause = word;
+                    }
+                    i += len;
+                    continue;
+                }
+                _ => {}
+            }
+            i += 1;
+        }
+        clause == "SELECT"
+    }
+
     /// Find the start of an expression before :: cast
     fn find_expression_start(before: &str) -> usize {
         let bytes = before.as_bytes();
@@ -488,7 +550,7 @@ impl CastTranslator {
                 } else if let Some(next_char) = after.as_bytes().get(len) {
                     // There's a character after the pattern - make sure it's a word boundary
                     if !next_char.is_ascii_alphanumeric() && *next_char != b'_' {
-                        return len;
+                        return len + Self::type_modifier_len(&after[len..]);
                     }
                 }
             }
@@ -528,7 +590,7 @@ impl CastTranslator {
                         if after.contains("RETURNING") {
                             eprintln!("DEBUG: Type '{}' followed by non-alphanumeric '{}', returning {}", type_name, *next_char as char, len);
                         }
-                        return len;
+                        return len + Self::type_modifier_len(&after[len..]);
                     }
                 }
             }
@@ -570,6 +632,84 @@ impl CastTranslator {
         after.len()
     }
     
+    /// Length of a type modifier such as "(10,2)" at the start of `after`, or 0 if there is none
+    fn type_modifier_len(after: &str) -> usize {
+        if !after.starts_with('(') {
+            return 0;
+        }
+        match after.find(')') {
+            Some(close) if after[1..close].chars().all(|c| c.is_ascii_digit() || c == ',' || c == ' ') => close + 1,
+            _ => 0,
+        }
+    }
+    
+    /// Translate a cast to a type with a modifier, honoring it the way PostgreSQL does:
+    /// numeric(p,s) rounds to the scale and checks the precision, varchar(n) truncates
+    fn translate_typmod_cast(expr: &str, type_name: &str) -> Option<String> {
+        let caps = TYPMOD_PATTERN.captures(type_name.trim())?;
+        let base_type = caps[1].to_uppercase();
+        let length = &caps[2];
+        
+        let expr = if expr.trim_start().to_uppercase().starts_with("SELECT") {
+            format!("({expr})")
+        } else {
+            expr.to_string()
+        };
+        
+        if base_type == "NUMERIC" || base_type == "DECIMAL" {
+            let scale = caps.get(3).map(|m| m.as_str()).unwrap_or("0");
+            Some(format!("numeric_cast({expr}, {length}, {scale})"))
+        } else if caps.get(3).is_none() {
+            Some(format!("substr(CAST({expr} AS TEXT), 1, {length})"))
+        } else {
+            None
+        }
+    }
+    
+    /// Translate 'Infinity', '-Infinity' or 'NaN' cast to a float or numeric type to the value
+    /// stored for it; SQLite would cast those strings to 0
+    fn translate_special_float_cast(expr: &str, type_name: &str) -> Option<String> {
+        let base_type = type_name.split('(').next().unwrap_or(type_name).trim().to_lowercase();
+        if !matches!(base_type.as_str(), "float4" | "float8" | "real" | "float" | "double precision" | "numeric" | "decimal") {
+            return None;
+        }
+        let literal = expr.trim().strip_prefix('\'')?.strip_suffix('\'')?;
+        crate::types::numeric_utils::special_float_literal(literal).map(str::to_string)
+    }
+    
+    /// Translate a cast to xml to an xml() call, which checks that the text is well-formed
+    fn translate_xml_cast(expr: &str, type_name: &str) -> Option<String> {
+        matches!(type_name.trim().to_lowercase().as_str(), "xml" | "pg_catalog.xml").then(|| format!("xml({expr})"))
+    }
+    
+    /// Translate casts involving the OID alias types: to regclass (table name to OID) and
+    /// regtype (type name to OID), and from either back to text, which yields the name.
+    /// A cast that is a column of the result is printed as the name, as PostgreSQL does.
+    fn translate_reg_cast(expr: &str, type_name: &str, output: bool) -> Option<String> {
+        match type_name.trim().to_lowercase().as_str() {
+            "regclass" | "pg_catalog.regclass" if output => Some(format!("regclass_name(regclass({expr}))")),
+            "regclass" | "pg_catalog.regclass" => Some(format!("regclass({expr})")),
+            "regtype" | "pg_catalog.regtype" if output => Some(format!("regtype_name(regtype({expr}))")),
+            "regtype" | "pg_catalog.regtype" => Some(format!("regtype({expr})")),
+            "text" | "varchar" | "name" => {
+                let trimmed = expr.trim();
+                let lower = trimmed.to_lowercase();
+                let output_function = if lower.starts_with("regclass(") {
+                    "regclass_name"
+                } else if lower.starts_with("regtype(") {
+                    "regtype_name"
+                } else {
+                    return None;
+                };
+                // Only when the whole expression is the one regclass()/regtype() call
+                let open = trimmed.find('(')?;
+                let close = matching_paren(trimmed, open)?;
+                (close == trimmed.len() - 1).then(|| format!("{output_function}({trimmed})"))
+            }
+            _ => None,
+        }
+    }
+    
     /// Check if a type name is an ENUM type
     fn is_enum_type(conn: &Connection, type_name: &str) -> bool {
         EnumMetadata::get_enum_type(conn, type_name)
@@ -695,6 +835,12 @@ impl CastTranslator {
                 None => break,
             };
             
+            // Skip function names ending in "cast(", such as numeric_cast(
+            if result[..cast_start].chars().next_back().is_some_and(|c| c.is_ascii_alphanumeric() || c == '_') {
+                search_from = cast_start + 5;
+                continue;
+            }
+            
             // Find matching closing parenthesis
             let mut paren_count = 1;
             let mut i = cast_start + 5; // Skip "CAST("
@@ -725,7 +871,16 @@ impl CastTranslator {
             let type_name = result[as_position + 4..cast_end].trim();
             
             // Check if this is an ENUM type cast
-            let translated = if let Some(conn) = conn {
+            let output = Self::is_select_output(&result, cast_start, cast_end + 1);
+            let translated = if let Some(reg_cast) = Self::translate_reg_cast(expr, type_name, output) {
+                reg_cast
+            } else if let Some(special_float) = Self::translate_special_float_cast(expr, type_name) {
+                special_float
+            } else if let Some(xml_cast) = Self::translate_xml_cast(expr, type_name) {
+                xml_cast
+            } else if let Some(typmod_cast) = Self::translate_typmod_cast(expr, type_name) {
+                typmod_cast
+            } else if let Some(conn) = conn {
                 if Self::is_enum_type(conn, type_name) {
                     // For ENUM types, use the enum cast translator
                     Self::translate_enum_cast(expr, type_name, conn)
@@ -0,0 +1,94 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::in_string_literal;
+use crate::functions::collation_functions::LOCALE_COLLATION;
+
+/// `COLLATE name`, optionally schema-qualified and quoted
+static COLLATE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?i)\bCOLLATE\s+((?:"[^"]+"|\w+)\s*\.\s*)?("[^"]+"|\w+)"#).unwrap()
+});
+
+/// Translates PostgreSQL collation names to the collations SQLite has.
+///
+/// "C" and "POSIX" compare bytes like SQLite's BINARY, case-insensitive collations
+/// (`case_insensitive`, `*_ci`, ICU `ks-level1`/`ks-level2`) become NOCASE, and locale
+/// names such as "en_US" or "de-DE-x-icu" use the accent-aware pg_locale collation.
+pub struct CollateTranslator;
+
+impl CollateTranslator {
+    /// Check if the query has a COLLATE clause naming a collation SQLite doesn't know
+    pub fn needs_translation(query: &str) -> bool {
+        COLLATE_REGEX.captures_iter(query).any(|caps| {
+            caps.get(1).is_some() || !Self::sqlite_collation(&caps[2]).eq_ignore_ascii_case(&caps[2])
+        })
+    }
+
+    /// Replace the collation names of COLLATE clauses
+    pub fn translate_query(query: &str) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let mut result = query.to_string();
+        let matches: Vec<_> = COLLATE_REGEX.captures_iter(query)
+            .filter(|caps| !in_string_literal(query, caps.get(0).unwrap().start()))
+            .map(|caps| (caps.get(0).unwrap().range(), format!("COLLATE {}", Self::sqlite_collation(&caps[2]))))
+            .collect();
+
+        // Work from the last match backwards so earlier offsets stay valid
+        for (range, replacement) in matches.into_iter().rev() {
+            result.replace_range(range, &replacement);
+        }
+
+        if result != query {
+            debug!("Translated COLLATE: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// The SQLite collation standing in for a PostgreSQL collation name
+    pub fn sqlite_collation(name: &str) -> String {
+        let name = name.trim_matches('"');
+        let lower = name.to_lowercase();
+        match lower.as_str() {
+            "c" | "posix" | "ucs_basic" | "default" | "binary" => "BINARY".to_string(),
+            "nocase" | "rtrim" => name.to_uppercase(),
+            _ if lower == "case_insensitive" || lower == "ci" || lower.ends_with("_ci")
+                || lower.contains("ks-level1") || lower.contains("ks-level2") => "NOCASE".to_string(),
+            _ if lower == LOCALE_COLLATION => lower,
+            _ => LOCALE_COLLATION.to_string(),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_collate_translation() {
+        let cases = [
+            (r#"SELECT name FROM t ORDER BY name COLLATE "en_US""#, "SELECT name FROM t ORDER BY name COLLATE pg_locale"),
+            (r#"SELECT * FROM t WHERE name = 'x' COLLATE "C""#, "SELECT * FROM t WHERE name = 'x' COLLATE BINARY"),
+            (r#"SELECT * FROM t WHERE name = 'x' COLLATE pg_catalog."POSIX""#, "SELECT * FROM t WHERE name = 'x' COLLATE BINARY"),
+            ("SELECT * FROM t WHERE name = 'x' COLLATE case_insensitive", "SELECT * FROM t WHERE name = 'x' COLLATE NOCASE"),
+            (r#"SELECT * FROM t ORDER BY name COLLATE "und-u-ks-level2""#, "SELECT * FROM t ORDER BY name COLLATE NOCASE"),
+            (r#"SELECT * FROM t ORDER BY name COLLATE "de-DE-x-icu" DESC"#, "SELECT * FROM t ORDER BY name COLLATE pg_locale DESC"),
+        ];
+        for (input, expected) in cases {
+            assert_eq!(CollateTranslator::translate_query(input), expected, "input: {input}");
+        }
+    }
+
+    #[test]
+    fn test_sqlite_collations_unchanged() {
+        assert!(!CollateTranslator::needs_translation("SELECT * FROM t ORDER BY name COLLATE NOCASE"));
+        assert!(!CollateTranslator::needs_translation("SELECT * FROM t ORDER BY name COLLATE binary"));
+        assert!(!CollateTranslator::needs_translation("SELECT name FROM t"));
+        assert_eq!(
+            CollateTranslator::translate_query(r#"SELECT 'COLLATE "C"' FROM t ORDER BY a COLLATE "C""#),
+            r#"SELECT 'COLLATE "C"' FROM t ORDER BY a COLLATE BINARY"#
+        );
+    }
+}
@@ -1,10 +1,13 @@
 use regex::Regex;
 use std::collections::HashMap;
-use crate::metadata::{TypeMapping, EnumMetadata};
+use crate::metadata::{TypeMapping, EnumMetadata, ExclusionConstraint, NullsNotDistinctUnique};
 use crate::types::TypeMapper;
+use crate::validator::FixedCharTriggers;
+use crate::translator::CastTranslator;
 use crate::PgSqliteError;
 use rusqlite::Connection;
 use once_cell::sync::Lazy;
+use crate::translator::sql_scan::{matching_paren, split_top_level};
 
 // Pre-compiled regex patterns
 static CREATE_TABLE_REGEX: Lazy<Result<Regex, regex::Error>> = Lazy::new(|| {
@@ -12,12 +15,41 @@ static CREATE_TABLE_REGEX: Lazy<Result<Regex, regex::Error>> = Lazy::new(|| {
     Regex::new(r#"(?is)CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(?:"([^"]+)"|(\w+))\s*\((.*)\)"#)
 });
 
+/// Trailing `INHERITS (parent, ...)` after the column list
+static INHERITS_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?is)\)\s*INHERITS\s*\(([^()]*)\)\s*;?\s*$").unwrap()
+});
+
+/// `GENERATED { ALWAYS | BY DEFAULT } AS IDENTITY` in a column definition
+static IDENTITY_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bGENERATED\s+(ALWAYS|BY\s+DEFAULT)\s+AS\s+IDENTITY\b").unwrap()
+});
+
+/// `GENERATED ALWAYS AS (` opening the expression of a generated column
+static GENERATED_COLUMN_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bGENERATED\s+ALWAYS\s+AS\s*\(").unwrap()
+});
+
+/// Stands in for a generated column's clause while the rest of the definition is translated
+const GENERATED_PLACEHOLDER: &str = "__pgsqlite_generated__";
+
+/// Fractional seconds precision of a time or timestamp column, `TIMESTAMP(3)`, `TIME (0) WITH TIME ZONE`
+static DATETIME_PRECISION_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?i)^(\s*(?:"[^"]+"|\S+)\s+(?:TIMESTAMPTZ|TIMESTAMP|TIMETZ|TIME))\s*\(\s*(\d+)\s*\)"#).unwrap()
+});
+
+static DEFAULT_CALL_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bDEFAULT\s+([A-Za-z_][\w.]*)\s*\(").unwrap()
+});
+
 #[derive(Debug)]
 pub struct CreateTableResult {
     pub sql: String,
     pub type_mappings: HashMap<String, TypeMapping>,
     pub enum_columns: Vec<(String, String)>, // (column_name, enum_type)
     pub array_columns: Vec<(String, String, i32)>, // (column_name, element_type, dimensions)
+    pub exclusion_constraints: Vec<ExclusionConstraint>, // enforced by triggers after creation
+    pub nulls_not_distinct: Vec<NullsNotDistinctUnique>, // enforced by triggers after creation
 }
 
 /// Context for tracking columns during translation
@@ -25,6 +57,8 @@ pub struct CreateTableResult {
 pub struct CreateTableContext {
     enum_columns: Vec<(String, String)>,
     array_columns: Vec<(String, String, i32)>,
+    exclusion_constraints: Vec<ExclusionConstraint>,
+    nulls_not_distinct: Vec<NullsNotDistinctUnique>,
 }
 
 pub struct CreateTableTranslator;
@@ -55,10 +89,14 @@ impl CreateTableTranslator {
         // Create context for tracking columns
         let mut context = CreateTableContext::default();
 
+        // INHERITS comes after the column list, which the regex below would swallow
+        let parents = Self::inherited_tables(pg_sql);
+        let pg_sql = INHERITS_REGEX.replace(pg_sql, ")");
+
         // Basic regex to match CREATE TABLE - use DOTALL flag to match newlines
         let regex = CREATE_TABLE_REGEX.as_ref()
             .map_err(|e| PgSqliteError::Protocol(format!("Regex compilation error: {}", e)))?;
-        if let Some(captures) = regex.captures(pg_sql) {
+        if let Some(captures) = regex.captures(&pg_sql) {
             // Handle both quoted and unquoted table names
             let (table_name, table_name_for_output) = if let Some(quoted) = captures.get(1) {
                 // quoted name - preserve quotes in output
@@ -74,9 +112,21 @@ impl CreateTableTranslator {
                 .ok_or_else(|| PgSqliteError::Protocol("Could not extract column definitions".to_string()))?
                 .as_str();
 
+            // The parent columns come first, as in PostgreSQL
+            let columns_str = if parents.is_empty() {
+                columns_str.to_string()
+            } else {
+                let conn = conn.ok_or_else(|| PgSqliteError::Protocol("INHERITS requires a database connection".to_string()))?;
+                let mut definitions = Self::inherited_column_definitions(conn, &parents, columns_str)?;
+                if !columns_str.trim().is_empty() {
+                    definitions.push(columns_str.to_string());
+                }
+                definitions.join(", ")
+            };
+
             // Parse columns
             let sqlite_columns = Self::parse_and_translate_columns(
-                columns_str,
+                &columns_str,
                 table_name,
                 &mut type_mapping,
                 &mut context,
@@ -94,6 +144,8 @@ impl CreateTableTranslator {
                 type_mappings: type_mapping,
                 enum_columns: context.enum_columns,
                 array_columns: context.array_columns,
+                exclusion_constraints: context.exclusion_constraints,
+                nulls_not_distinct: context.nulls_not_distinct,
             })
         } else {
             // Not a CREATE TABLE statement, return as-is
@@ -102,10 +154,161 @@ impl CreateTableTranslator {
                 type_mappings: type_mapping,
                 enum_columns: Vec::new(),
                 array_columns: Vec::new(),
+                exclusion_constraints: Vec::new(),
+                nulls_not_distinct: Vec::new(),
             })
         }
     }
     
+    /// Parent tables named by the INHERITS clause of a CREATE TABLE, in order
+    pub fn inherited_tables(pg_sql: &str) -> Vec<String> {
+        INHERITS_REGEX.captures(pg_sql)
+            .map(|caps| caps[1].split(',')
+                .map(|parent| {
+                    let parent = parent.trim();
+                    let parent = parent.strip_prefix("public.").unwrap_or(parent);
+                    parent.trim_matches('"').to_string()
+                })
+                .filter(|parent| !parent.is_empty())
+                .collect())
+            .unwrap_or_default()
+    }
+
+    /// Record the parents of a table created with INHERITS in __pgsqlite_inherits
+    pub fn record_inheritance(conn: &Connection, table_name: &str, pg_sql: &str) -> rusqlite::Result<()> {
+        for (seqno, parent) in Self::inherited_tables(pg_sql).iter().enumerate() {
+            conn.execute(
+                "INSERT OR REPLACE INTO __pgsqlite_inherits (child_table, parent_table, seqno) VALUES (?1, ?2, ?3)",
+                rusqlite::params![table_name, parent, seqno as i64 + 1],
+            )?;
+        }
+        Ok(())
+    }
+
+    /// Identity columns of a CREATE TABLE with their pg_attribute.attidentity code,
+    /// 'a' for GENERATED ALWAYS and 'd' for GENERATED BY DEFAULT
+    pub fn identity_columns(pg_sql: &str) -> Vec<(String, &'static str)> {
+        let Some(caps) = CREATE_TABLE_REGEX.as_ref().ok().and_then(|regex| regex.captures(pg_sql)) else {
+            return Vec::new();
+        };
+        Self::split_definitions(&caps[3]).iter()
+            .filter_map(|definition| {
+                let generation = IDENTITY_REGEX.captures(definition)?;
+                let column = definition.split_whitespace().next()?.trim_matches('"').to_string();
+                let code = if generation[1].eq_ignore_ascii_case("ALWAYS") { "a" } else { "d" };
+                Some((column, code))
+            })
+            .collect()
+    }
+
+    /// Record the identity columns of a new table in __pgsqlite_identity_columns
+    pub fn record_identity_columns(conn: &Connection, table_name: &str, pg_sql: &str) -> rusqlite::Result<()> {
+        let columns = Self::identity_columns(pg_sql);
+        if columns.is_empty() {
+            return Ok(());
+        }
+        conn.execute("DELETE FROM __pgsqlite_identity_columns WHERE table_name = ?1", [table_name])?;
+        for (column, generation) in columns {
+            conn.execute(
+                "INSERT INTO __pgsqlite_identity_columns (table_name, column_name, generation) VALUES (?1, ?2, ?3)",
+                rusqlite::params![table_name, column, generation],
+            )?;
+        }
+        Ok(())
+    }
+
+    /// Time and timestamp columns of a CREATE TABLE declared with a fractional seconds
+    /// precision, which PostgreSQL caps at 6 digits
+    pub fn datetime_precisions(pg_sql: &str) -> Vec<(String, i32)> {
+        let Some(caps) = CREATE_TABLE_REGEX.as_ref().ok().and_then(|regex| regex.captures(pg_sql)) else {
+            return Vec::new();
+        };
+        Self::split_definitions(&caps[3]).iter()
+            .filter_map(|definition| {
+                let precision = DATETIME_PRECISION_REGEX.captures(definition)?[2].parse::<i32>().ok()?;
+                let column = definition.split_whitespace().next()?.trim_matches('"').to_string();
+                Some((column, precision.min(6)))
+            })
+            .collect()
+    }
+
+    /// Record the precision of time and timestamp columns as their type modifier in __pgsqlite_schema
+    pub fn record_datetime_precisions(conn: &Connection, table_name: &str, pg_sql: &str) -> rusqlite::Result<()> {
+        for (column, precision) in Self::datetime_precisions(pg_sql) {
+            conn.execute(
+                "UPDATE __pgsqlite_schema SET type_modifier = ?3 WHERE table_name = ?1 AND column_name = ?2",
+                rusqlite::params![table_name, column, precision],
+            )?;
+        }
+        Ok(())
+    }
+
+    /// The non-empty column definitions of a CREATE TABLE column list
+    fn split_definitions(columns_str: &str) -> Vec<&str> {
+        split_top_level(columns_str).into_iter().filter(|definition| !definition.is_empty()).collect()
+    }
+
+    /// Column definitions copied from the parent tables. Columns that an earlier parent or
+    /// the child itself defines are merged into that definition, as PostgreSQL does.
+    fn inherited_column_definitions(
+        conn: &Connection,
+        parents: &[String],
+        child_columns: &str,
+    ) -> Result<Vec<String>, PgSqliteError> {
+        let mut seen: std::collections::HashSet<String> = Self::split_definitions(child_columns)
+            .iter()
+            .filter_map(|definition| definition.split_whitespace().next())
+            .map(|name| name.trim_matches('"').to_lowercase())
+            .collect();
+        let mut definitions = Vec::new();
+
+        for parent in parents {
+            let mut stmt = conn.prepare(
+                "SELECT p.name, COALESCE(s.pg_type, p.type), p.\"notnull\", p.dflt_value
+                 FROM pragma_table_info(?1) p
+                 LEFT JOIN __pgsqlite_schema s ON s.table_name = ?1 AND s.column_name = p.name
+                 ORDER BY p.cid"
+            ).map_err(|e| PgSqliteError::Protocol(e.to_string()))?;
+            let columns = stmt.query_map([parent], |row| Ok((
+                row.get::<_, String>(0)?,
+                row.get::<_, String>(1)?,
+                row.get::<_, bool>(2)?,
+                row.get::<_, Option<String>>(3)?,
+            )))
+            .and_then(|rows| rows.collect::<rusqlite::Result<Vec<_>>>())
+            .map_err(|e| PgSqliteError::Protocol(e.to_string()))?;
+            if columns.is_empty() {
+                return Err(PgSqliteError::Protocol(format!("relation \"{parent}\" does not exist")));
+            }
+
+            for (name, pg_type, not_null, default) in columns {
+                if !seen.insert(name.to_lowercase()) {
+                    continue;
+                }
+                // The child shares the parent's values, not its sequence
+                let pg_type = match pg_type.to_uppercase().as_str() {
+                    "SERIAL" | "SERIAL4" => "INTEGER".to_string(),
+                    "BIGSERIAL" | "SERIAL8" => "BIGINT".to_string(),
+                    "SMALLSERIAL" | "SERIAL2" => "SMALLINT".to_string(),
+                    _ => pg_type,
+                };
+                // Plain names stay unquoted, the column parser keys the type mappings by them
+                let plain = name.chars().all(|c| c.is_ascii_alphanumeric() || c == '_')
+                    && !name.starts_with(|c: char| c.is_ascii_digit());
+                let mut definition = if plain { format!("{name} {pg_type}") } else { format!("\"{name}\" {pg_type}") };
+                if not_null {
+                    definition.push_str(" NOT NULL");
+                }
+                if let Some(default) = default {
+                    definition.push_str(&format!(" DEFAULT {default}"));
+                }
+                definitions.push(definition);
+            }
+        }
+
+        Ok(definitions)
+    }
+
     fn parse_and_translate_columns(
         columns_str: &str,
         table_name: &str,
@@ -160,6 +363,16 @@ impl CreateTableTranslator {
                 continue;
             }
             
+            // SQLite unique keys always treat NULLs as distinct; NULLS NOT DISTINCT adds triggers
+            let (column_def, nulls_not_distinct) = NullsNotDistinctUnique::from_table_element(&column_def, table_name)?;
+            context.nulls_not_distinct.extend(nulls_not_distinct);
+
+            // EXCLUDE constraints have no SQLite equivalent; they become triggers
+            if let Some(constraint) = ExclusionConstraint::parse(&column_def, table_name)? {
+                context.exclusion_constraints.push(constraint);
+                continue;
+            }
+            
             let translated = Self::translate_column_definition(
                 &column_def,
                 table_name,
@@ -178,7 +391,7 @@ impl CreateTableTranslator {
         let parts: Vec<&str> = column_def.split_whitespace().collect();
         if parts.len() >= 2 {
             let pg_type = parts[1].to_uppercase();
-            if pg_type == "SERIAL" || pg_type == "BIGSERIAL" {
+            if pg_type == "SERIAL" || pg_type == "BIGSERIAL" || pg_type == "SMALLSERIAL" {
                 return Some(parts[0].to_string());
             }
         }
@@ -217,6 +430,16 @@ impl CreateTableTranslator {
             return Ok(column_def.to_string());
         }
         
+        // The generation expression is set aside so splitting on whitespace can't mangle it
+        let generated = Self::generated_clause(column_def);
+        let column_def = match &generated {
+            Some((range, _, _)) => format!("{}{}{}", &column_def[..range.start], GENERATED_PLACEHOLDER, &column_def[range.end..]),
+            None => column_def.to_string(),
+        };
+
+        // The precision of TIMESTAMP(3) is recorded after creation, the column has the plain type
+        let column_def = DATETIME_PRECISION_REGEX.replace(&column_def, "$1").to_string();
+
         // Parse column name and type
         let parts: Vec<&str> = column_def.split_whitespace().collect();
         if parts.is_empty() {
@@ -366,10 +589,17 @@ impl CreateTableTranslator {
         // Reconstruct the column definition with SQLite type
         let mut result = format!("{column_name} {sqlite_type}");
 
+        // char(n) compares ignoring trailing spaces; the padding itself is done by triggers
+        if !is_array && FixedCharTriggers::char_length(&pg_type).is_some()
+            && !parts[type_end_idx..].iter().any(|part| part.eq_ignore_ascii_case("COLLATE")) {
+            result.push_str(" COLLATE RTRIM");
+        }
+
         // Add any remaining parts (constraints, defaults, etc.)
         let mut remaining_parts = Vec::new();
         let mut skip_next = false;
         let mut skip_count = 0;
+        let mut identity_at = None;
         for (i, part) in parts[type_end_idx..].iter().enumerate() {
             if skip_count > 0 {
                 skip_count -= 1;
@@ -381,7 +611,7 @@ impl CreateTableTranslator {
             }
 
             // Special handling for SERIAL - skip PRIMARY KEY as it's included in the type translation
-            if (pg_type.to_uppercase() == "SERIAL" || pg_type.to_uppercase() == "BIGSERIAL")
+            if matches!(pg_type.to_uppercase().as_str(), "SERIAL" | "BIGSERIAL" | "SMALLSERIAL")
                 && part.to_uppercase() == "PRIMARY" {
                     // Skip "PRIMARY" and check if next is "KEY"
                     if let Some(next_part) = parts.get(type_end_idx + i + 1)
@@ -391,43 +621,50 @@ impl CreateTableTranslator {
                     continue;
                 }
 
-            // Special handling for GENERATED BY DEFAULT AS IDENTITY
-            if part.to_uppercase() == "GENERATED" {
-                // Check for "GENERATED BY DEFAULT AS IDENTITY" sequence
+            // GENERATED { ALWAYS | BY DEFAULT } AS IDENTITY [ ( sequence options ) ] becomes an
+            // AUTOINCREMENT key, ALWAYS is enforced afterwards by IdentityTriggers
+            if part.eq_ignore_ascii_case("GENERATED") {
                 let remaining_upper: Vec<String> = parts[type_end_idx + i..]
                     .iter().map(|s| s.to_uppercase()).collect();
+                let identity_idx = match remaining_upper.get(1).map(String::as_str) {
+                    Some("ALWAYS") => 3,
+                    Some("BY") if remaining_upper.get(2).is_some_and(|word| word == "DEFAULT") => 4,
+                    _ => 0,
+                };
 
-                if remaining_upper.len() >= 5
-                    && remaining_upper[0] == "GENERATED"
-                    && remaining_upper[1] == "BY"
-                    && remaining_upper[2] == "DEFAULT"
-                    && remaining_upper[3] == "AS"
-                    && remaining_upper[4] == "IDENTITY" {
-
-                    // Check if PRIMARY KEY follows after IDENTITY
-                    let primary_key_follows = remaining_upper.len() >= 7
-                        && remaining_upper[5] == "PRIMARY"
-                        && remaining_upper[6] == "KEY";
-
-                    if primary_key_follows {
-                        // Skip GENERATED BY DEFAULT AS IDENTITY and replace PRIMARY KEY with PRIMARY KEY AUTOINCREMENT
-                        skip_count = 6; // Skip the next 6 parts (BY DEFAULT AS IDENTITY PRIMARY KEY)
-                        remaining_parts.push("PRIMARY");
-                        remaining_parts.push("KEY");
-                        remaining_parts.push("AUTOINCREMENT");
-                    } else {
-                        // No PRIMARY KEY follows, so add it ourselves
-                        skip_count = 4; // Skip the next 4 parts (BY DEFAULT AS IDENTITY)
-                        remaining_parts.push("PRIMARY");
-                        remaining_parts.push("KEY");
-                        remaining_parts.push("AUTOINCREMENT");
+                if identity_idx > 0
+                    && remaining_upper.get(identity_idx - 1).is_some_and(|word| word == "AS")
+                    && remaining_upper.get(identity_idx).is_some_and(|word| word == "IDENTITY" || word.starts_with("IDENTITY(")) {
+                    // Skip the sequence options, SQLite's AUTOINCREMENT has none
+                    let mut end = identity_idx;
+                    let mut depth = remaining_upper[end].matches('(').count() as i32 - remaining_upper[end].matches(')').count() as i32;
+                    if depth == 0 && remaining_upper.get(end + 1).is_some_and(|word| word.starts_with('(')) {
+                        end += 1;
+                        depth = remaining_upper[end].matches('(').count() as i32 - remaining_upper[end].matches(')').count() as i32;
+                    }
+                    while depth > 0 && end + 1 < remaining_upper.len() {
+                        end += 1;
+                        depth += remaining_upper[end].matches('(').count() as i32 - remaining_upper[end].matches(')').count() as i32;
                     }
+                    skip_count = end;
+                    identity_at = Some(remaining_parts.len());
                     continue;
                 }
             }
 
             remaining_parts.push(*part);
         }
+
+        // The identity column becomes the AUTOINCREMENT key, using its own PRIMARY KEY if it has one
+        if let Some(at) = identity_at {
+            match remaining_parts.windows(2)
+                .position(|pair| pair[0].eq_ignore_ascii_case("PRIMARY") && pair[1].eq_ignore_ascii_case("KEY")) {
+                Some(primary_key) => remaining_parts.insert(primary_key + 2, "AUTOINCREMENT"),
+                None => {
+                    remaining_parts.splice(at..at, ["PRIMARY", "KEY", "AUTOINCREMENT"]);
+                }
+            }
+        }
         
         // Join remaining parts and apply datetime translation if needed
         if !remaining_parts.is_empty() {
@@ -453,11 +690,90 @@ impl CreateTableTranslator {
             };
             
             result.push(' ');
-            result.push_str(&translated_clause);
+            result.push_str(&Self::parenthesize_default_call(&translated_clause));
+        }
+
+        if let Some((_, expression, storage)) = generated {
+            let expression = if CastTranslator::needs_translation(&expression) {
+                CastTranslator::translate_query(&expression, conn)
+            } else {
+                expression
+            };
+            result = result.replace(GENERATED_PLACEHOLDER, &format!("GENERATED ALWAYS AS ({expression}) {storage}"));
         }
         
         Ok(result)
     }
+
+    /// The `GENERATED ALWAYS AS (expression) [STORED | VIRTUAL]` clause of a column definition:
+    /// its byte range, the expression and the storage. Without a keyword the column is VIRTUAL,
+    /// which is the default of both SQLite and PostgreSQL 18.
+    fn generated_clause(column_def: &str) -> Option<(std::ops::Range<usize>, String, &'static str)> {
+        let open = GENERATED_COLUMN_REGEX.find(column_def)?;
+        let close = matching_paren(column_def, open.end() - 1)?;
+        let expression = column_def[open.end()..close].trim().to_string();
+
+        let after = &column_def[close + 1..];
+        let keyword_start = close + 1 + after.len() - after.trim_start().len();
+        let keyword = after.split_whitespace().next().unwrap_or("");
+        let (storage, end) = if keyword.eq_ignore_ascii_case("STORED") {
+            ("STORED", keyword_start + keyword.len())
+        } else if keyword.eq_ignore_ascii_case("VIRTUAL") {
+            ("VIRTUAL", keyword_start + keyword.len())
+        } else {
+            ("VIRTUAL", close + 1)
+        };
+        Some((open.start()..end, expression, storage))
+    }
+
+    /// Generated columns of a CREATE TABLE: (column, PostgreSQL expression, pg_attribute.attgenerated
+    /// code), 's' for STORED and 'v' for VIRTUAL
+    pub fn generated_columns(pg_sql: &str) -> Vec<(String, String, &'static str)> {
+        let Some(caps) = CREATE_TABLE_REGEX.as_ref().ok().and_then(|regex| regex.captures(pg_sql)) else {
+            return Vec::new();
+        };
+        Self::split_definitions(&caps[3]).iter()
+            .filter_map(|definition| {
+                let (_, expression, storage) = Self::generated_clause(definition)?;
+                let column = definition.split_whitespace().next()?.trim_matches('"').to_string();
+                Some((column, expression, if storage == "STORED" { "s" } else { "v" }))
+            })
+            .collect()
+    }
+
+    /// Record the generated columns of a new table in __pgsqlite_generated_columns
+    pub fn record_generated_columns(conn: &Connection, table_name: &str, pg_sql: &str) -> rusqlite::Result<()> {
+        let columns = Self::generated_columns(pg_sql);
+        if columns.is_empty() {
+            return Ok(());
+        }
+        conn.execute("DELETE FROM __pgsqlite_generated_columns WHERE table_name = ?1", [table_name])?;
+        for (column, expression, generated) in columns {
+            conn.execute(
+                "INSERT INTO __pgsqlite_generated_columns (table_name, column_name, expression, generated) VALUES (?1, ?2, ?3, ?4)",
+                rusqlite::params![table_name, column, expression, generated],
+            )?;
+        }
+        Ok(())
+    }
+
+    /// SQLite only accepts a function call as a column default inside parentheses,
+    /// so `DEFAULT gen_random_uuid()` becomes `DEFAULT (gen_random_uuid())`
+    fn parenthesize_default_call(clause: &str) -> String {
+        let Some(caps) = DEFAULT_CALL_REGEX.captures(clause) else {
+            return clause.to_string();
+        };
+        let (Some(whole), Some(name)) = (caps.get(0), caps.get(1)) else {
+            return clause.to_string();
+        };
+        let call_start = name.start();
+        let open_paren = whole.end() - 1;
+
+        match matching_paren(clause, open_paren) {
+            Some(close) => format!("{}({}){}", &clause[..call_start], &clause[call_start..close + 1], &clause[close + 1..]),
+            None => clause.to_string(),
+        }
+    }
     
     fn is_multiword_type_start(type_str: &str) -> bool {
         let start_patterns = [
@@ -615,7 +931,11 @@ mod tests {
             code CHAR(10)
         )";
         
-        let (_translated, mappings) = CreateTableTranslator::translate(sql).unwrap();
+        let (translated, mappings) = CreateTableTranslator::translate(sql).unwrap();
+        
+        // Only the fixed-length column ignores trailing spaces
+        assert!(translated.contains("code TEXT COLLATE RTRIM"));
+        assert_eq!(translated.matches("COLLATE").count(), 1);
         
         // Check that types were mapped correctly
         assert!(mappings.contains_key("users.name"));
@@ -662,6 +982,22 @@ mod tests {
         assert_eq!(mappings["test.col3"].type_modifier, Some(5));
     }
     
+    #[test]
+    fn test_exclude_constraint_is_collected() {
+        let sql = "CREATE TABLE reservations (
+            id SERIAL PRIMARY KEY,
+            room INTEGER,
+            during TSRANGE,
+            EXCLUDE USING gist (room WITH =, during WITH &&)
+        )";
+        
+        let result = CreateTableTranslator::translate_with_connection_full(sql, None).unwrap();
+        
+        assert!(!result.sql.to_uppercase().contains("EXCLUDE"));
+        assert_eq!(result.exclusion_constraints.len(), 1);
+        assert_eq!(result.exclusion_constraints[0].name, "reservations_room_during_excl");
+    }
+    
     #[test]
     fn test_parse_array_type() {
         // Test simple array types
@@ -738,12 +1074,23 @@ mod tests {
         println!("Translated SQL: {}", result.sql);
 
         // Check that NOW() was translated to datetime('now')
-        assert!(result.sql.contains("DEFAULT datetime('now')"),
-                "Expected 'DEFAULT datetime('now')' but got: {}", result.sql);
+        assert!(result.sql.contains("DEFAULT (datetime('now'))"),
+                "Expected 'DEFAULT (datetime('now'))' but got: {}", result.sql);
         assert!(!result.sql.contains("DEFAULT now()"),
                 "Found 'DEFAULT now()' which should have been translated: {}", result.sql);
     }
 
+    #[test]
+    fn test_translate_default_function_call() {
+        let sql = "CREATE TABLE books (id UUID DEFAULT gen_random_uuid() PRIMARY KEY, status TEXT DEFAULT 'draft(1)')";
+
+        let result = CreateTableTranslator::translate_with_connection_full(sql, None).unwrap();
+
+        assert!(result.sql.contains("DEFAULT (gen_random_uuid()) PRIMARY KEY"),
+                "Expected a parenthesized default but got: {}", result.sql);
+        assert!(result.sql.contains("DEFAULT 'draft(1)'"), "Literal default changed: {}", result.sql);
+    }
+
     #[test]
     fn test_translate_identity() {
         let sql = r#"CREATE TABLE "django_migrations" ("id" bigint NOT NULL PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY, "app" varchar(255) NOT NULL, "name" varchar(255) NOT NULL, "applied" timestamp with time zone NOT NULL)"#;
@@ -758,5 +1105,76 @@ mod tests {
                 "Expected 'PRIMARY KEY AUTOINCREMENT' but got: {}", result.sql);
         assert!(!result.sql.contains("GENERATED BY DEFAULT AS IDENTITY"),
                "Translation should not contain original IDENTITY syntax: {}", result.sql);
+        assert_eq!(result.sql.matches("PRIMARY KEY").count(), 1, "{}", result.sql);
+        assert_eq!(CreateTableTranslator::identity_columns(sql), vec![("id".to_string(), "d")]);
+
+        let sql = "CREATE TABLE orders (id bigint GENERATED ALWAYS AS IDENTITY (START WITH 10 INCREMENT BY 5), note text)";
+        let result = CreateTableTranslator::translate_with_connection_full(sql, None).unwrap();
+        assert!(result.sql.contains("PRIMARY KEY AUTOINCREMENT, note"), "{}", result.sql);
+        assert!(!result.sql.contains("START") && !result.sql.contains("IDENTITY"), "{}", result.sql);
+        assert_eq!(CreateTableTranslator::identity_columns(sql), vec![("id".to_string(), "a")]);
     }
-}
\ No newline at end of file
+
+    #[test]
+    fn test_translate_generated_columns() {
+        let sql = "CREATE TABLE books (id SERIAL PRIMARY KEY, title text NOT NULL, subtitle text, \
+                   search_text text GENERATED ALWAYS AS (title || '  ' || coalesce(subtitle, '')) STORED, \
+                   price numeric, whole_price integer GENERATED ALWAYS AS (price::integer))";
+        let result = CreateTableTranslator::translate_with_connection_full(sql, None).unwrap();
+        assert!(result.sql.contains("search_text TEXT GENERATED ALWAYS AS (title || '  ' || coalesce(subtitle, '')) STORED"), "{}", result.sql);
+        assert!(result.sql.contains("GENERATED ALWAYS AS (") && result.sql.contains(") VIRTUAL"), "{}", result.sql);
+        assert!(!result.sql.contains("::"), "{}", result.sql);
+
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute(&result.sql, []).unwrap();
+        conn.execute("INSERT INTO books (title, subtitle, price) VALUES ('Dune', NULL, 9.8)", []).unwrap();
+        let search_text: String = conn.query_row("SELECT search_text FROM books", [], |row| row.get(0)).unwrap();
+        assert_eq!(search_text, "Dune  ");
+
+        assert_eq!(CreateTableTranslator::generated_columns(sql), vec![
+            ("search_text".to_string(), "title || '  ' || coalesce(subtitle, '')".to_string(), "s"),
+            ("whole_price".to_string(), "price::integer".to_string(), "v"),
+        ]);
+    }
+
+    #[test]
+    fn test_translate_inherits() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT);
+             INSERT INTO __pgsqlite_schema VALUES ('cities', 'id', 'SERIAL', 'INTEGER'), ('cities', 'name', 'VARCHAR(50)', 'TEXT');
+             CREATE TABLE cities (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, population REAL DEFAULT 0);"
+        ).unwrap();
+
+        let sql = "CREATE TABLE capitals (state CHAR(2), population REAL) INHERITS (public.cities)";
+        assert_eq!(CreateTableTranslator::inherited_tables(sql), vec!["cities"]);
+
+        let result = CreateTableTranslator::translate_with_connection_full(sql, Some(&conn)).unwrap();
+        assert!(!result.sql.contains("INHERITS"), "INHERITS left in: {}", result.sql);
+        let id = result.sql.find("id INTEGER").expect(&result.sql);
+        let name = result.sql.find("name TEXT").expect(&result.sql);
+        let state = result.sql.find("state").expect(&result.sql);
+        assert!(id < name && name < state, "Parent columns should come first: {}", result.sql);
+        assert!(!result.sql.contains("AUTOINCREMENT"), "The child doesn't share the parent's key: {}", result.sql);
+        // The child's own definition wins over the inherited one
+        assert_eq!(result.sql.matches("population").count(), 1, "{}", result.sql);
+        assert_eq!(result.type_mappings["capitals.name"].pg_type, "VARCHAR(50)");
+
+        let missing = CreateTableTranslator::translate_with_connection_full("CREATE TABLE t (a INT) INHERITS (nope)", Some(&conn));
+        assert!(missing.is_err());
+    }
+
+    #[test]
+    fn test_translate_datetime_precision() {
+        let sql = "CREATE TABLE events (id INTEGER, happened_at TIMESTAMP(3), starts time (0) with time zone, logged_at TIMESTAMP)";
+        assert_eq!(CreateTableTranslator::datetime_precisions(sql), vec![
+            ("happened_at".to_string(), 3),
+            ("starts".to_string(), 0),
+        ]);
+
+        let result = CreateTableTranslator::translate_with_connection_full(sql, None).unwrap();
+        assert!(!result.sql.contains("(3)") && !result.sql.contains("(0)"), "{}", result.sql);
+        assert_eq!(result.type_mappings["events.happened_at"].pg_type, "TIMESTAMP");
+        assert_eq!(result.type_mappings["events.starts"].pg_type, "TIME WITH TIME ZONE");
+    }
+}
@@ -0,0 +1,392 @@
+use rusqlite::Connection;
+use rusqlite::types::Value;
+use regex::{Captures, Regex};
+use rust_decimal::Decimal;
+use std::str::FromStr;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use crate::translator::PgTypeofTranslator;
+use crate::types::ValueConverter;
+
+static DATE_COMPARISON_HINT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)(?:[=<>]|\bBETWEEN|\bSYMMETRIC|\bAND)\s*'\d{4}-\d{1,2}-\d{1,2}(?:[ T][^']*)?'|'\d{4}-\d{1,2}-\d{1,2}(?:[ T][^']*)?'(?:::\w+)?\s*(?:[=<>]|!=)").unwrap()
+});
+
+static BETWEEN_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\b((?:\w+\.)?\w+)(\s+(?:NOT\s+)?BETWEEN\s+(?:SYMMETRIC\s+|ASYMMETRIC\s+)?)('[^']*'(?:::\w+)?|\w+\s*\([^()]*\)|[\w.]+)(\s+AND\s+)('[^']*'(?:::\w+)?|\w+\s*\([^()]*\)|[\w.]+)").unwrap()
+});
+
+static COMPARISON_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\b((?:\w+\.)?\w+)(\s*(?:=|<>|!=|<=|>=|<|>)\s*)('[^']*'(?:::\w+)?)").unwrap()
+});
+
+static REVERSED_COMPARISON_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)('[^']*'(?:::\w+)?)(\s*(?:=|<>|!=|<=|>=|<|>)\s*)((?:\w+\.)?\w+)\b").unwrap()
+});
+
+static PARAMETER_COMPARISON_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\b((?:\w+\.)?\w+)\s*(?:=|<>|!=|<=|>=|<|>)\s*\$(\d+)\b").unwrap()
+});
+
+static REVERSED_PARAMETER_COMPARISON_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\$(\d+)\s*(?:=|<>|!=|<=|>=|<|>)\s*((?:\w+\.)?\w+)\b").unwrap()
+});
+
+static BETWEEN_PARAMETERS_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\b((?:\w+\.)?\w+)\s+(?:NOT\s+)?BETWEEN\s+(?:SYMMETRIC\s+|ASYMMETRIC\s+)?\$(\d+)\s+AND\s+\$(\d+)\b").unwrap()
+});
+
+static DATE_LITERAL_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"^'([^']*)'(?:::(\w+))?$").unwrap()
+});
+
+static WHERE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bWHERE\b").unwrap()
+});
+
+static UPDATE_TABLE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*UPDATE\s+(\w+)").unwrap()
+});
+
+/// (table, alias, date, timestamp and numeric columns with their lowercased types) for each
+/// table the query reads from
+type TypedColumns = Vec<(String, Option<String>, Vec<(String, String)>)>;
+
+/// Date columns are stored as INTEGER days since epoch and timestamp columns as INTEGER
+/// microseconds, so comparing one against a quoted literal in SQLite would compare an
+/// integer with text. This translator casts the literals compared with those columns (in
+/// BETWEEN bounds and comparison operators) to the column's storage value, as PostgreSQL
+/// casts text to the column type, whether they are plain quoted text or typed with a
+/// date/timestamp cast. Text compared with numeric columns becomes a number the same way,
+/// and text parameters bound for such comparisons are cast by `coerce_parameters`.
+pub struct DateComparisonTranslator;
+
+impl DateComparisonTranslator {
+    /// Check if the query compares something with a date-looking literal
+    pub fn needs_translation(query: &str) -> bool {
+        DATE_COMPARISON_HINT_REGEX.is_match(query)
+    }
+
+    /// Translate date literals compared with date columns into day numbers
+    pub fn translate_query(query: &str, conn: &Connection) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let Some(start) = Self::comparison_start(query) else {
+            return query.to_string();
+        };
+
+        let columns = Self::typed_columns(query, conn);
+        if columns.iter().all(|(_, _, table_columns)| table_columns.is_empty()) {
+            return query.to_string();
+        }
+
+        let (head, tail) = query.split_at(start);
+        let tail = BETWEEN_REGEX.replace_all(tail, |caps: &Captures| {
+            let Some(pg_type) = Self::column_type(&caps[1], &columns) else {
+                return caps[0].to_string();
+            };
+            format!(
+                "{}{}{}{}{}",
+                &caps[1],
+                &caps[2],
+                Self::literal_value(&caps[3], pg_type).unwrap_or_else(|| caps[3].to_string()),
+                &caps[4],
+                Self::literal_value(&caps[5], pg_type).unwrap_or_else(|| caps[5].to_string()),
+            )
+        });
+        let tail = COMPARISON_REGEX.replace_all(&tail, |caps: &Captures| {
+            match Self::column_type(&caps[1], &columns).and_then(|pg_type| Self::literal_value(&caps[3], pg_type)) {
+                Some(value) => format!("{}{}{}", &caps[1], &caps[2], value),
+                None => caps[0].to_string(),
+            }
+        });
+        let tail = REVERSED_COMPARISON_REGEX.replace_all(&tail, |caps: &Captures| {
+            match Self::column_type(&caps[3], &columns).and_then(|pg_type| Self::literal_value(&caps[1], pg_type)) {
+                Some(value) => format!("{}{}{}", value, &caps[2], &caps[3]),
+                None => caps[0].to_string(),
+            }
+        });
+
+        let result = format!("{head}{tail}");
+        if result != query {
+            debug!("Translated date comparisons: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// Cast the text parameters compared with date, timestamp and numeric columns (in
+    /// comparison operators and BETWEEN bounds) to the column's storage value, as
+    /// `translate_query` does for literals once parameters are substituted
+    pub fn coerce_parameters(query: &str, conn: &Connection, params: &mut [Value]) {
+        let Some(start) = Self::comparison_start(query) else {
+            return;
+        };
+        let tail = &query[start..];
+
+        let mut compared: Vec<(&str, &str)> = Vec::new();
+        for caps in PARAMETER_COMPARISON_REGEX.captures_iter(tail) {
+            compared.push((caps.get(1).unwrap().as_str(), caps.get(2).unwrap().as_str()));
+        }
+        for caps in REVERSED_PARAMETER_COMPARISON_REGEX.captures_iter(tail) {
+            compared.push((caps.get(2).unwrap().as_str(), caps.get(1).unwrap().as_str()));
+        }
+        for caps in BETWEEN_PARAMETERS_REGEX.captures_iter(tail) {
+            compared.push((caps.get(1).unwrap().as_str(), caps.get(2).unwrap().as_str()));
+            compared.push((caps.get(1).unwrap().as_str(), caps.get(3).unwrap().as_str()));
+        }
+        let compared: Vec<(&str, usize)> = compared.into_iter()
+            .filter_map(|(reference, number)| Some((reference, number.parse::<usize>().ok()?.checked_sub(1)?)))
+            .filter(|(_, index)| matches!(params.get(*index), Some(Value::Text(_))))
+            .collect();
+        if compared.is_empty() {
+            return;
+        }
+
+        let columns = Self::typed_columns(query, conn);
+        for (reference, index) in compared {
+            if let Some(pg_type) = Self::column_type(reference, &columns)
+                && let Some(Value::Text(text)) = params.get(index)
+                && let Some(value) = Self::coerce_text(text, pg_type) {
+                    debug!("Cast text parameter ${} compared with {} to {}", index + 1, reference, value);
+                    params[index] = match value.parse::<i64>() {
+                        Ok(integer) => Value::Integer(integer),
+                        Err(_) => value.parse::<f64>().map(Value::Real).unwrap_or(Value::Text(value)),
+                    };
+                }
+        }
+    }
+
+    /// Where the compared part of the query starts: outside SELECT, only the WHERE clause
+    /// compares; SET and VALUES lists are left to the INSERT/UPDATE value conversion
+    fn comparison_start(query: &str) -> Option<usize> {
+        if query.trim_start().get(..6).is_some_and(|s| s.eq_ignore_ascii_case("SELECT")) {
+            Some(0)
+        } else {
+            WHERE_REGEX.find(query).map(|m| m.end())
+        }
+    }
+
+    /// Storage value for a quoted literal compared with a column of the given type, plain or
+    /// cast to a date/timestamp type; other casts are left alone
+    fn literal_value(literal: &str, pg_type: &str) -> Option<String> {
+        let caps = DATE_LITERAL_REGEX.captures(literal)?;
+        let cast_allowed = match caps.get(2).map(|cast| cast.as_str().to_lowercase()) {
+            None => true,
+            Some(cast) if pg_type == "date" => cast == "date",
+            Some(cast) => matches!(cast.as_str(), "date" | "timestamp" | "timestamptz"),
+        };
+        if !cast_allowed {
+            return None;
+        }
+        Self::coerce_text(&caps[1], pg_type)
+    }
+
+    /// Cast a text value to the storage value of a date, timestamp or numeric column, given
+    /// the column's PostgreSQL type; None for other types or text that isn't a valid value.
+    /// A date compared with a timestamp column means midnight of that day, as in PostgreSQL.
+    pub fn coerce_text(value: &str, pg_type: &str) -> Option<String> {
+        // Drop a precision, as in timestamp(3) with time zone
+        let pg_type = pg_type.to_lowercase();
+        let base_type = match pg_type.split_once('(') {
+            Some((head, rest)) => format!("{}{}", head.trim_end(), rest.split_once(')').map_or("", |(_, tail)| tail)),
+            None => pg_type,
+        };
+        match base_type.trim() {
+            "date" => ValueConverter::convert_date_to_unix(value).ok(),
+            "timestamp" | "timestamp without time zone" => ValueConverter::convert_timestamp_to_unix(value).ok()
+                .or_else(|| Self::midnight_micros(value)),
+            "timestamptz" | "timestamp with time zone" => ValueConverter::convert_timestamptz_to_unix(value).ok()
+                .or_else(|| Self::midnight_micros(value)),
+            "smallint" | "integer" | "bigint" | "int" | "int2" | "int4" | "int8" => {
+                value.trim().parse::<i64>().ok().map(|integer| integer.to_string())
+            }
+            "real" | "double precision" | "float4" | "float8" => {
+                value.trim().parse::<f64>().ok().filter(|float| float.is_finite()).map(|float| float.to_string())
+            }
+            "numeric" | "decimal" => {
+                let value = value.trim();
+                Decimal::from_str(value).or_else(|_| Decimal::from_scientific(value)).ok()
+                    .map(|decimal| decimal.normalize().to_string())
+            }
+            _ => None,
+        }
+    }
+
+    /// Microseconds since epoch for midnight of a date-only value
+    fn midnight_micros(value: &str) -> Option<String> {
+        let days: i64 = ValueConverter::convert_date_to_unix(value).ok()?.parse().ok()?;
+        Some((days * 86_400_000_000).to_string())
+    }
+
+    /// The lowercased type of a (possibly qualified) column reference naming a date,
+    /// timestamp or numeric column
+    fn column_type<'a>(reference: &str, columns: &'a TypedColumns) -> Option<&'a str> {
+        let (qualifier, column) = match reference.rsplit_once('.') {
+            Some((qualifier, column)) => (Some(qualifier), column),
+            None => (None, reference),
+        };
+
+        columns.iter()
+            .filter(|(table, alias, _)| {
+                qualifier.is_none_or(|q| table.eq_ignore_ascii_case(q) || alias.as_deref().is_some_and(|a| a.eq_ignore_ascii_case(q)))
+            })
+            .flat_map(|(_, _, table_columns)| table_columns)
+            .find(|(c, _)| c.eq_ignore_ascii_case(column))
+            .map(|(_, pg_type)| pg_type.as_str())
+    }
+
+    /// Look up the date, timestamp and numeric columns of the tables the query reads from or
+    /// updates
+    fn typed_columns(query: &str, conn: &Connection) -> TypedColumns {
+        let mut tables = PgTypeofTranslator::extract_table_refs(query);
+        if let Some(caps) = UPDATE_TABLE_REGEX.captures(query) {
+            tables.push((caps[1].to_string(), None));
+        }
+
+        tables.into_iter()
+            .map(|(table, alias)| {
+                let columns: Vec<(String, String)> = conn.prepare(
+                    "SELECT column_name, lower(pg_type) FROM __pgsqlite_schema WHERE table_name = ?1 \
+                     AND (lower(pg_type) IN ('date', 'smallint', 'integer', 'bigint', 'int', 'int2', 'int4', 'int8', \
+                     'real', 'double precision', 'float4', 'float8') \
+                     OR lower(pg_type) LIKE 'timestamp%' OR lower(pg_type) LIKE 'numeric%' OR lower(pg_type) LIKE 'decimal%')"
+                )
+                    .and_then(|mut stmt| {
+                        let columns = stmt.query_map([&table], |row| Ok((row.get(0)?, row.get(1)?)))?.collect();
+                        columns
+                    })
+                    .unwrap_or_default();
+                (table, alias, columns)
+            })
+            .collect()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn setup() -> Connection {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, published DATE)", []).unwrap();
+        conn.execute("CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT)", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('books', 'published', 'DATE', 'INTEGER')", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('books', 'title', 'TEXT', 'TEXT')", []).unwrap();
+        conn.execute("CREATE TABLE events (id INTEGER PRIMARY KEY, starts_at INTEGER, logged_at INTEGER)", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('events', 'starts_at', 'TIMESTAMP', 'INTEGER')", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('events', 'logged_at', 'TIMESTAMP WITH TIME ZONE', 'INTEGER')", []).unwrap();
+        conn
+    }
+
+    #[test]
+    fn test_between_date_literals() {
+        let conn = setup();
+
+        assert_eq!(
+            DateComparisonTranslator::translate_query(
+                "SELECT id FROM books WHERE published BETWEEN '1970-01-01' AND '1970-01-31'::date", &conn
+            ),
+            "SELECT id FROM books WHERE published BETWEEN 0 AND 30"
+        );
+        assert_eq!(
+            DateComparisonTranslator::translate_query(
+                "SELECT id FROM books b WHERE b.published NOT BETWEEN '1969-12-31' AND CURRENT_DATE", &conn
+            ),
+            "SELECT id FROM books b WHERE b.published NOT BETWEEN -1 AND CURRENT_DATE"
+        );
+
+        // Bounds already converted from ::date keep their conversion
+        assert_eq!(
+            DateComparisonTranslator::translate_query(
+                "SELECT id FROM books WHERE published BETWEEN pg_date_from_text('1970-01-01') AND '1970-01-02'", &conn
+            ),
+            "SELECT id FROM books WHERE published BETWEEN pg_date_from_text('1970-01-01') AND 1"
+        );
+    }
+
+    #[test]
+    fn test_comparison_date_literals() {
+        let conn = setup();
+
+        assert_eq!(
+            DateComparisonTranslator::translate_query(
+                "SELECT id FROM books WHERE published >= '1970-01-02' AND '1970-01-11' > published", &conn
+            ),
+            "SELECT id FROM books WHERE published >= 1 AND 10 > published"
+        );
+
+        // Text columns, non-date casts and UPDATE assignments keep their literals
+        let query = "SELECT id FROM books WHERE title = '1970-01-01' OR published = '1970-01-01'::text";
+        assert_eq!(DateComparisonTranslator::translate_query(query, &conn), query);
+        assert_eq!(
+            DateComparisonTranslator::translate_query(
+                "UPDATE books SET published = '1970-01-05' WHERE published < '1970-01-03'", &conn
+            ),
+            "UPDATE books SET published = '1970-01-05' WHERE published < 2"
+        );
+    }
+
+    #[test]
+    fn test_comparison_timestamp_literals() {
+        let conn = setup();
+
+        assert_eq!(
+            DateComparisonTranslator::translate_query(
+                "SELECT id FROM events WHERE starts_at >= '1970-01-01 00:00:01' AND starts_at < '1970-01-02'::timestamp", &conn
+            ),
+            "SELECT id FROM events WHERE starts_at >= 1000000 AND starts_at < 86400000000"
+        );
+        assert_eq!(
+            DateComparisonTranslator::translate_query(
+                "SELECT id FROM events e WHERE e.logged_at BETWEEN '1970-01-01 01:00:00+01:00' AND '1970-01-01T00:00:02.5'", &conn
+            ),
+            "SELECT id FROM events e WHERE e.logged_at BETWEEN 0 AND 2500000"
+        );
+
+        // Values that aren't timestamps are left for SQLite to compare
+        let query = "SELECT id FROM events WHERE starts_at = '1970-01-01 noon'";
+        assert_eq!(DateComparisonTranslator::translate_query(query, &conn), query);
+
+        assert_eq!(DateComparisonTranslator::coerce_text("2024-01-15", "timestamp(3) with time zone").as_deref(), Some("1705276800000000"));
+        assert_eq!(DateComparisonTranslator::coerce_text("2024-01-15", "DATE").as_deref(), Some("19737"));
+    }
+
+    #[test]
+    fn test_coerce_numeric_text() {
+        assert_eq!(DateComparisonTranslator::coerce_text("1.50", "numeric(10,2)").as_deref(), Some("1.5"));
+        assert_eq!(DateComparisonTranslator::coerce_text(" 12 ", "DECIMAL").as_deref(), Some("12"));
+        assert_eq!(DateComparisonTranslator::coerce_text("42", "integer").as_deref(), Some("42"));
+        assert_eq!(DateComparisonTranslator::coerce_text("0.25", "double precision").as_deref(), Some("0.25"));
+        assert_eq!(DateComparisonTranslator::coerce_text("4.2", "bigint"), None);
+        assert_eq!(DateComparisonTranslator::coerce_text("cheap", "numeric"), None);
+    }
+
+    #[test]
+    fn test_coerce_parameters() {
+        let conn = setup();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('books', 'price', 'NUMERIC(10,2)', 'DECIMAL')", []).unwrap();
+
+        let mut params = vec![
+            Value::Text("1970-01-02".to_string()),
+            Value::Text("9.90".to_string()),
+            Value::Text("1970-01-11".to_string()),
+            Value::Text("Dune".to_string()),
+        ];
+        DateComparisonTranslator::coerce_parameters(
+            "SELECT id FROM books b WHERE b.published BETWEEN $1 AND $3 AND $2 > price AND title = $4", &conn, &mut params
+        );
+        assert_eq!(params, vec![
+            Value::Integer(1),
+            Value::Real(9.9),
+            Value::Integer(10),
+            Value::Text("Dune".to_string()),
+        ]);
+
+        // UPDATE assignments keep their text
+        let mut params = vec![Value::Text("1970-01-05".to_string()), Value::Text("1970-01-03".to_string())];
+        DateComparisonTranslator::coerce_parameters("UPDATE books SET published = $1 WHERE published < $2", &conn, &mut params);
+        assert_eq!(params, vec![Value::Text("1970-01-05".to_string()), Value::Integer(2)]);
+    }
+}
@@ -0,0 +1,140 @@
+use crate::PgSqliteError;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::{matching_paren, split_top_level};
+
+static DISTINCT_AGGREGATE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\b(array_agg|string_agg)\s*\(\s*DISTINCT\s+").unwrap()
+});
+
+static ORDER_BY_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s+ORDER\s+BY\s+").unwrap()
+});
+
+static SORT_OPTIONS_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)(?:\s+(?:ASC|DESC))?(?:\s+NULLS\s+(?:FIRST|LAST))?\s*$").unwrap()
+});
+
+/// Rewrites array_agg(DISTINCT ...) and string_agg(DISTINCT ...) into the deduplicating
+/// array_agg_distinct/string_agg_distinct aggregates. SQLite only allows DISTINCT on
+/// single-argument aggregates, so string_agg's separator argument rules out the native form.
+pub struct DistinctAggregateTranslator;
+
+impl DistinctAggregateTranslator {
+    /// Check if the query uses DISTINCT inside array_agg or string_agg
+    pub fn needs_translation(query: &str) -> bool {
+        DISTINCT_AGGREGATE_REGEX.is_match(query)
+    }
+
+    /// Translate DISTINCT aggregates, keeping any ORDER BY inside the call
+    pub fn translate_query(query: &str) -> Result<String, PgSqliteError> {
+        if !Self::needs_translation(query) {
+            return Ok(query.to_string());
+        }
+
+        let mut result = query.to_string();
+
+        // Replace from the end so earlier match positions stay valid
+        let matches: Vec<_> = DISTINCT_AGGREGATE_REGEX.captures_iter(query)
+            .map(|caps| (caps.get(0).unwrap(), caps[1].to_lowercase()))
+            .collect();
+        for (m, function) in matches.into_iter().rev() {
+            let open = m.start() + m.as_str().find('(').unwrap();
+            let Some(close) = matching_paren(query, open) else {
+                continue;
+            };
+
+            let inner = &query[m.end()..close];
+            let (arguments, order_by) = match Self::find_top_level_order_by(inner) {
+                Some((start, end)) => (inner[..start].trim(), Some(inner[end..].trim())),
+                None => (inner.trim(), None),
+            };
+            let args = split_top_level(arguments);
+
+            let order_by = match order_by {
+                Some(order_by) => {
+                    Self::check_order_by_in_arguments(order_by, &args)?;
+                    order_by.to_string()
+                }
+                // PostgreSQL deduplicates by sorting, so the values come out in ascending order
+                None => args.first().map(|arg| arg.to_string()).unwrap_or_default(),
+            };
+
+            let replacement = format!("{function}_distinct({arguments} ORDER BY {order_by})");
+            debug!("Translated DISTINCT {}: {} -> {}", function, &query[m.start()..close + 1], replacement);
+            result.replace_range(m.start()..close + 1, &replacement);
+        }
+
+        Ok(result)
+    }
+
+    /// PostgreSQL rejects DISTINCT aggregates sorted by anything other than their arguments
+    fn check_order_by_in_arguments(order_by: &str, args: &[&str]) -> Result<(), PgSqliteError> {
+        for item in split_top_level(order_by) {
+            let expr = SORT_OPTIONS_REGEX.replace(item, "");
+            if !args.iter().any(|arg| Self::normalize(arg) == Self::normalize(&expr)) {
+                return Err(PgSqliteError::Validation(
+                    "in an aggregate with DISTINCT, ORDER BY expressions must appear in argument list".to_string()
+                ));
+            }
+        }
+        Ok(())
+    }
+
+    fn normalize(expr: &str) -> String {
+        expr.split_whitespace().collect::<String>().to_lowercase()
+    }
+
+    /// Locate the ORDER BY keyword outside nested parentheses and string literals
+    fn find_top_level_order_by(inner: &str) -> Option<(usize, usize)> {
+        let mut depth = 0;
+        let mut in_string = false;
+
+        for (i, ch) in inner.char_indices() {
+            match ch {
+                '\'' => in_string = !in_string,
+                '(' if !in_string => depth += 1,
+                ')' if !in_string => depth -= 1,
+                c if c.is_whitespace() && !in_string && depth == 0 => {
+                    if let Some(m) = ORDER_BY_REGEX.find(&inner[i..]) {
+                        return Some((i, i + m.end()));
+                    }
+                }
+                _ => {}
+            }
+        }
+
+        None
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_distinct_aggregate_translation() {
+        assert_eq!(
+            DistinctAggregateTranslator::translate_query("SELECT array_agg(DISTINCT tag) FROM tags").unwrap(),
+            "SELECT array_agg_distinct(tag ORDER BY tag) FROM tags"
+        );
+        assert_eq!(
+            DistinctAggregateTranslator::translate_query(
+                "SELECT string_agg(DISTINCT nationality, ', ' ORDER BY nationality DESC), ARRAY_AGG(DISTINCT lower(tag)) FROM authors"
+            ).unwrap(),
+            "SELECT string_agg_distinct(nationality, ', ' ORDER BY nationality DESC), array_agg_distinct(lower(tag) ORDER BY lower(tag)) FROM authors"
+        );
+
+        let query = "SELECT array_agg(tag ORDER BY tag), count(DISTINCT tag) FROM tags";
+        assert_eq!(DistinctAggregateTranslator::translate_query(query).unwrap(), query);
+    }
+
+    #[test]
+    fn test_distinct_order_by_must_use_arguments() {
+        let err = DistinctAggregateTranslator::translate_query(
+            "SELECT array_agg(DISTINCT tag ORDER BY id) FROM tags"
+        ).unwrap_err();
+        assert!(err.to_string().contains("ORDER BY expressions must appear in argument list"));
+    }
+}
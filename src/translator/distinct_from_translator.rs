@@ -0,0 +1,70 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::in_string_literal;
+
+/// `IS [NOT] DISTINCT FROM`
+static DISTINCT_FROM_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bIS\s+(NOT\s+)?DISTINCT\s+FROM\b").unwrap()
+});
+
+/// Translates PostgreSQL's NULL-safe comparisons to SQLite's `IS` operators.
+///
+/// `a IS DISTINCT FROM b` is true when the values differ or exactly one of them is NULL,
+/// which is what SQLite's `a IS NOT b` does; `IS NOT DISTINCT FROM` becomes `IS`.
+pub struct DistinctFromTranslator;
+
+impl DistinctFromTranslator {
+    /// Check if the query uses IS [NOT] DISTINCT FROM
+    pub fn needs_translation(query: &str) -> bool {
+        DISTINCT_FROM_REGEX.is_match(query)
+    }
+
+    /// Replace IS [NOT] DISTINCT FROM with IS NOT / IS
+    pub fn translate_query(query: &str) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let mut result = query.to_string();
+        let matches: Vec<_> = DISTINCT_FROM_REGEX.captures_iter(query)
+            .filter(|caps| !in_string_literal(query, caps.get(0).unwrap().start()))
+            .map(|caps| {
+                let replacement = if caps.get(1).is_some() { "IS" } else { "IS NOT" };
+                (caps.get(0).unwrap().range(), replacement)
+            })
+            .collect();
+
+        // Work from the last match backwards so earlier offsets stay valid
+        for (range, replacement) in matches.into_iter().rev() {
+            result.replace_range(range, replacement);
+        }
+
+        if result != query {
+            debug!("Translated IS DISTINCT FROM: {} -> {}", query, result);
+        }
+        result
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_distinct_from_translation() {
+        let cases = [
+            ("SELECT * FROM t WHERE a IS DISTINCT FROM b", "SELECT * FROM t WHERE a IS NOT b"),
+            ("SELECT * FROM t WHERE a is not distinct from $1", "SELECT * FROM t WHERE a IS $1"),
+            ("UPDATE t SET a = 1 WHERE a IS DISTINCT FROM 1 OR b IS NOT DISTINCT FROM NULL",
+             "UPDATE t SET a = 1 WHERE a IS NOT 1 OR b IS NULL"),
+            ("SELECT 'IS DISTINCT FROM' FROM t WHERE a IS\n  DISTINCT FROM b", "SELECT 'IS DISTINCT FROM' FROM t WHERE a IS NOT b"),
+        ];
+        for (query, expected) in cases {
+            assert_eq!(DistinctFromTranslator::translate_query(query), expected);
+        }
+
+        let query = "SELECT DISTINCT a FROM t WHERE a IS NOT NULL";
+        assert_eq!(DistinctFromTranslator::translate_query(query), query);
+    }
+}
@@ -0,0 +1,342 @@
+use tracing::debug;
+use super::sql_scan::{matching_paren, opening_paren, quoted_end};
+
+/// Words that end an operand scan instead of naming a function, as in `WHERE (a + b) / 2`
+const KEYWORDS: &[&str] = &[
+    "all", "and", "any", "as", "between", "by", "case", "distinct", "else", "exists", "from",
+    "having", "in", "is", "like", "not", "on", "or", "returning", "select", "set", "then",
+    "values", "when", "where",
+];
+
+/// PostgreSQL divides integers with truncation and raises division_by_zero, where SQLite
+/// returns NULL for a zero divisor. Each `a / b` becomes `divide(a, b)`, which keeps
+/// integer division for two integers, divides exactly for numeric text, and raises on a
+/// zero divisor. `a / NULLIF(b, 0)` still yields NULL. This runs after the decimal
+/// rewriter, so divisions of NUMERIC columns already went to decimal_div().
+pub struct DivisionTranslator;
+
+impl DivisionTranslator {
+    /// Check if a DML query has a `/` operator outside string literals and comments
+    pub fn needs_translation(query: &str) -> bool {
+        if !query.contains('/') {
+            return false;
+        }
+        let first_word = query.split_whitespace().next().unwrap_or("").trim_start_matches('(');
+        ["SELECT", "WITH", "VALUES", "INSERT", "UPDATE", "DELETE"]
+            .iter()
+            .any(|keyword| first_word.eq_ignore_ascii_case(keyword))
+            && Self::find_division(query.as_bytes(), 0).is_some()
+    }
+
+    /// Rewrite each division operator, left to right, into a divide() call
+    pub fn translate_query(query: &str) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let mut result = query.to_string();
+        let mut pos = 0;
+        while let Some(slash) = Self::find_division(result.as_bytes(), pos) {
+            let bytes = result.as_bytes();
+            match (Self::left_operand_start(bytes, slash), Self::right_operand_end(bytes, slash + 1)) {
+                (Some(start), Some(end)) => {
+                    let rewritten = format!(
+                        "{}divide({}, {}){}",
+                        &result[..start],
+                        result[start..slash].trim_end(),
+                        result[slash + 1..end].trim(),
+                        &result[end..]
+                    );
+                    result = rewritten;
+                    // Divisions in the left operand were already handled, so the scan
+                    // resumes inside the call for the ones in the right operand
+                    pos = start;
+                }
+                _ => pos = slash + 1,
+            }
+        }
+
+        if result != query {
+            debug!("Translated division: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// The next `/` used as the division operator, outside strings, quoted identifiers
+    /// and comments, and not part of the |/ and ||/ root operators
+    fn find_division(bytes: &[u8], from: usize) -> Option<usize> {
+        let mut i = from;
+        while i < bytes.len() {
+            match bytes[i] {
+                b'\'' | b'"' => i = quoted_end(bytes, i),
+                b'-' if bytes.get(i + 1) == Some(&b'-') => {
+                    while i < bytes.len() && bytes[i] != b'\n' {
+                        i += 1;
+                    }
+                }
+                b'/' if bytes.get(i + 1) == Some(&b'*') => {
+                    i = memchr::memmem::find(&bytes[i + 2..], b"*/").map_or(bytes.len(), |end| i + 2 + end + 2);
+                }
+                b'/' if i > 0 && bytes[i - 1] == b'|' => i += 1,
+                b'/' => return Some(i),
+                _ => i += 1,
+            }
+        }
+        None
+    }
+
+    /// Start of the operand ending before `end`, taking in `*` and `%` to the left, which
+    /// share the precedence of `/` and associate left: `a * b / c` is `(a * b) / c`
+    fn left_operand_start(bytes: &[u8], end: usize) -> Option<usize> {
+        let mut start = Self::cast_operand_start(bytes, end)?;
+        loop {
+            let before = Self::skip_space_back(bytes, start);
+            if before > 0 && matches!(bytes[before - 1], b'*' | b'%')
+                && let Some(operand) = Self::cast_operand_start(bytes, before - 1) {
+                start = operand;
+            } else {
+                return Some(start);
+            }
+        }
+    }
+
+    /// Start of a primary expression with any `::type` casts applied to it
+    fn cast_operand_start(bytes: &[u8], end: usize) -> Option<usize> {
+        let mut start = Self::primary_start(bytes, Self::skip_space_back(bytes, end))?;
+        loop {
+            let before = Self::skip_space_back(bytes, start);
+            if before >= 2 && &bytes[before - 2..before] == b"::" {
+                start = Self::primary_start(bytes, Self::skip_space_back(bytes, before - 2))?;
+            } else {
+                return Some(start);
+            }
+        }
+    }
+
+    /// Start of the literal, column, function call, subscript or parenthesized expression
+    /// whose last byte is at `end - 1`
+    fn primary_start(bytes: &[u8], end: usize) -> Option<usize> {
+        if end == 0 {
+            return None;
+        }
+        match bytes[end - 1] {
+            b')' => {
+                let open = opening_paren(bytes, end - 1)?;
+                // A function name right before the parenthesis belongs to the operand
+                let mut name = open;
+                while name > 0 && (Self::is_word_byte(bytes[name - 1]) || bytes[name - 1] == b'.') {
+                    name -= 1;
+                }
+                let word = std::str::from_utf8(&bytes[name..open]).ok()?;
+                if name < open && !Self::is_keyword(word) { Some(name) } else { Some(open) }
+            }
+            b']' => {
+                let open = opening_paren(bytes, end - 1)?;
+                Self::primary_start(bytes, open)
+            }
+            quote @ (b'\'' | b'"') => {
+                let mut open = end - 1;
+                loop {
+                    open = bytes[..open].iter().rposition(|&b| b == quote)?;
+                    // A doubled quote is an escaped one inside the literal
+                    if open > 0 && bytes[open - 1] == quote {
+                        open -= 1;
+                    } else {
+                        break;
+                    }
+                }
+                if quote == b'"' && open > 0 && bytes[open - 1] == b'.' {
+                    return Self::primary_start(bytes, open - 1);
+                }
+                Some(open)
+            }
+            b if Self::is_word_byte(b) => {
+                let mut start = end;
+                while start > 0 && (Self::is_word_byte(bytes[start - 1]) || bytes[start - 1] == b'.') {
+                    start -= 1;
+                }
+                let word = std::str::from_utf8(&bytes[start..end]).ok()?;
+                if word.eq_ignore_ascii_case("end") {
+                    return Self::matching_case(bytes, start);
+                }
+                if Self::is_keyword(word) { None } else { Some(start) }
+            }
+            _ => None,
+        }
+    }
+
+    /// End of the operand starting at `start`: an optional sign, a primary expression and
+    /// any `::type` casts
+    fn right_operand_end(bytes: &[u8], start: usize) -> Option<usize> {
+        let mut i = Self::skip_space(bytes, start);
+        if i < bytes.len() && matches!(bytes[i], b'-' | b'+') {
+            i = Self::skip_space(bytes, i + 1);
+        }
+        let mut end = Self::primary_end(bytes, i)?;
+        loop {
+            let after = Self::skip_space(bytes, end);
+            if bytes[after..].starts_with(b"::") {
+                end = Self::primary_end(bytes, Self::skip_space(bytes, after + 2))?;
+            } else {
+                return Some(end);
+            }
+        }
+    }
+
+    /// End of the literal, column, function call, CASE or parenthesized expression at `start`
+    fn primary_end(bytes: &[u8], start: usize) -> Option<usize> {
+        let mut end = match *bytes.get(start)? {
+            b'(' => matching_paren(bytes, start)? + 1,
+            quote @ (b'\'' | b'"') => {
+                let end = quoted_end(bytes, start);
+                if quote == b'"' && bytes.get(end) == Some(&b'.') {
+                    return Self::primary_end(bytes, end + 1);
+                }
+                end
+            }
+            b if Self::is_word_byte(b) => {
+                let mut end = start;
+                while end < bytes.len() && (Self::is_word_byte(bytes[end]) || bytes[end] == b'.') {
+                    end += 1;
+                }
+                let word = std::str::from_utf8(&bytes[start..end]).ok()?;
+                if word.eq_ignore_ascii_case("case") {
+                    return Self::matching_end(bytes, start);
+                }
+                if Self::is_keyword(word) {
+                    return None;
+                }
+                let after = Self::skip_space(bytes, end);
+                if bytes.get(after) == Some(&b'(') {
+                    end = matching_paren(bytes, after)? + 1;
+                }
+                end
+            }
+            _ => return None,
+        };
+        // Array subscripts
+        while bytes.get(end) == Some(&b'[') {
+            end = matching_paren(bytes, end)? + 1;
+        }
+        Some(end)
+    }
+
+    /// Start of the CASE matching the END at `end_start`
+    fn matching_case(bytes: &[u8], end_start: usize) -> Option<usize> {
+        let words = Self::words(&bytes[..end_start]);
+        let mut depth = 1;
+        for (start, word) in words.into_iter().rev() {
+            if word.eq_ignore_ascii_case("end") {
+                depth += 1;
+            } else if word.eq_ignore_ascii_case("case") {
+                depth -= 1;
+                if depth == 0 {
+                    return Some(start);
+                }
+            }
+        }
+        None
+    }
+
+    /// Index just past the END matching the CASE at `case_start`
+    fn matching_end(bytes: &[u8], case_start: usize) -> Option<usize> {
+        let mut depth = 0;
+        for (start, word) in Self::words(&bytes[case_start..]) {
+            if word.eq_ignore_ascii_case("case") {
+                depth += 1;
+            } else if word.eq_ignore_ascii_case("end") {
+                depth -= 1;
+                if depth == 0 {
+                    return Some(case_start + start + word.len());
+                }
+            }
+        }
+        None
+    }
+
+    /// The words outside quotes, with their offsets
+    fn words(bytes: &[u8]) -> Vec<(usize, &str)> {
+        let mut words = Vec::new();
+        let mut i = 0;
+        while i < bytes.len() {
+            match bytes[i] {
+                b'\'' | b'"' => i = quoted_end(bytes, i),
+                b if Self::is_word_byte(b) => {
+                    let start = i;
+                    while i < bytes.len() && Self::is_word_byte(bytes[i]) {
+                        i += 1;
+                    }
+                    if let Ok(word) = std::str::from_utf8(&bytes[start..i]) {
+                        words.push((start, word));
+                    }
+                }
+                _ => i += 1,
+            }
+        }
+        words
+    }
+
+    fn skip_space(bytes: &[u8], mut i: usize) -> usize {
+        while i < bytes.len() && bytes[i].is_ascii_whitespace() {
+            i += 1;
+        }
+        i
+    }
+
+    fn skip_space_back(bytes: &[u8], mut i: usize) -> usize {
+        while i > 0 && bytes[i - 1].is_ascii_whitespace() {
+            i -= 1;
+        }
+        i
+    }
+
+    /// Identifier, number and parameter bytes; non-ASCII bytes belong to identifiers
+    fn is_word_byte(b: u8) -> bool {
+        b.is_ascii_alphanumeric() || b == b'_' || b == b'$' || b >= 0x80
+    }
+
+    fn is_keyword(word: &str) -> bool {
+        KEYWORDS.iter().any(|keyword| keyword.eq_ignore_ascii_case(word))
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_division_operands() {
+        assert_eq!(DivisionTranslator::translate_query("SELECT 7 / 2"), "SELECT divide(7, 2)");
+        assert_eq!(
+            DivisionTranslator::translate_query("SELECT a * b / c, t.x/ -2 FROM t"),
+            "SELECT divide(a * b, c), divide(t.x, -2) FROM t"
+        );
+        assert_eq!(
+            DivisionTranslator::translate_query("SELECT a / b / c + 1 FROM t WHERE (a + b) / 2 > 1"),
+            "SELECT divide(divide(a, b), c) + 1 FROM t WHERE divide((a + b), 2) > 1"
+        );
+        assert_eq!(
+            DivisionTranslator::translate_query("SELECT sum(x) / NULLIF(count(*), 0) FROM t"),
+            "SELECT divide(sum(x), NULLIF(count(*), 0)) FROM t"
+        );
+        assert_eq!(
+            DivisionTranslator::translate_query("UPDATE t SET r = CASE WHEN n > 0 THEN s / n END / 2"),
+            "UPDATE t SET r = divide(CASE WHEN n > 0 THEN divide(s, n) END, 2)"
+        );
+        assert_eq!(
+            DivisionTranslator::translate_query("SELECT CAST(a AS REAL) / x::numeric FROM t"),
+            "SELECT divide(CAST(a AS REAL), x::numeric) FROM t"
+        );
+    }
+
+    #[test]
+    fn test_non_division_slashes() {
+        let query = "SELECT '2024/01/01', \"a/b\" FROM t /* a / b */ WHERE |/ x > 1";
+        assert_eq!(DivisionTranslator::translate_query(query), query);
+        assert!(!DivisionTranslator::needs_translation("CREATE TABLE t (a INTEGER CHECK (a / 2 > 0))"));
+        assert!(!DivisionTranslator::needs_translation("SELECT 1"));
+        assert!(!DivisionTranslator::needs_translation(query));
+        assert!(!DivisionTranslator::needs_translation("SELECT * FROM files WHERE path = '/tmp/a' -- a / b"));
+        assert!(DivisionTranslator::needs_translation("SELECT a / b FROM t"));
+    }
+}
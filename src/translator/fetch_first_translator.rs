@@ -0,0 +1,238 @@
+use crate::error::PgError;
+use crate::PgSqliteError;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::{in_string_literal, statement_start, top_level_matches};
+
+/// `[OFFSET n {ROW|ROWS}] FETCH {FIRST|NEXT} [count] {ROW|ROWS} {ONLY|WITH TIES}`
+static FETCH_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)(?:\bOFFSET\s+(\S+?)\s*(?:\bROWS?\b)?\s*)?\bFETCH\s+(?:FIRST|NEXT)\s+(?:(\S+?)\s+)?ROWS?\s+(ONLY|WITH\s+TIES)\b").unwrap()
+});
+
+/// `OFFSET n {ROW|ROWS}` without a FETCH clause
+static OFFSET_ROWS_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bOFFSET\s+(\S+?)\s+ROWS?\b").unwrap()
+});
+
+/// `LIMIT ALL`, PostgreSQL's spelling of no limit
+static LIMIT_ALL_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bLIMIT\s+ALL\b").unwrap()
+});
+
+static LIMIT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bLIMIT\b").unwrap()
+});
+
+static ORDER_BY_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bORDER\s+BY\b").unwrap()
+});
+
+static FROM_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bFROM\b").unwrap()
+});
+
+static WHERE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bWHERE\b").unwrap()
+});
+
+/// Clauses after FROM that the WITH TIES rewrite can't carry into its boundary subquery
+static UNSUPPORTED_TIES_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\b(?:GROUP\s+BY|HAVING|WINDOW|UNION|INTERSECT|EXCEPT|DISTINCT)\b").unwrap()
+});
+
+/// Translates the SQL-standard FETCH FIRST/NEXT and OFFSET ... ROWS pagination clauses
+/// into SQLite's LIMIT/OFFSET, and `LIMIT ALL` into SQLite's `LIMIT -1`.
+///
+/// WITH TIES also returns the rows tied with the last one on the ORDER BY key. For a single
+/// sort key over a plain FROM/WHERE, rows are kept while their key doesn't sort after the
+/// key of the last row in the page, which a subquery looks up.
+pub struct FetchFirstTranslator;
+
+impl FetchFirstTranslator {
+    /// Check if the query uses FETCH FIRST/NEXT, OFFSET ... ROWS or LIMIT ALL
+    pub fn needs_translation(query: &str) -> bool {
+        FETCH_REGEX.is_match(query) || OFFSET_ROWS_REGEX.is_match(query) || LIMIT_ALL_REGEX.is_match(query)
+    }
+
+    /// Translate FETCH, OFFSET ... ROWS and LIMIT ALL clauses into LIMIT/OFFSET
+    pub fn translate_query(query: &str) -> Result<String, PgSqliteError> {
+        if !Self::needs_translation(query) {
+            return Ok(query.to_string());
+        }
+
+        let mut result = query.to_string();
+
+        let limit_alls: Vec<_> = LIMIT_ALL_REGEX.find_iter(query)
+            .filter(|m| !in_string_literal(query, m.start()))
+            .map(|m| m.range())
+            .collect();
+        for range in limit_alls.into_iter().rev() {
+            result.replace_range(range, "LIMIT -1");
+        }
+
+        // Work from the last clause backwards so earlier offsets stay valid
+        let clauses: Vec<_> = FETCH_REGEX.captures_iter(&result)
+            .filter(|caps| !in_string_literal(&result, caps.get(0).unwrap().start()))
+            .map(|caps| {
+                let whole = caps.get(0).unwrap();
+                (
+                    whole.range(),
+                    caps.get(1).map(|m| m.as_str().to_string()),
+                    caps.get(2).map_or_else(|| "1".to_string(), |m| m.as_str().to_string()),
+                    caps[3].to_uppercase().starts_with("WITH"),
+                )
+            })
+            .collect();
+
+        for (range, offset, count, with_ties) in clauses.into_iter().rev() {
+            if with_ties {
+                result = Self::translate_with_ties(&result, range, offset.as_deref(), &count)?;
+                continue;
+            }
+            let replacement = match offset {
+                Some(offset) => format!("LIMIT {count} OFFSET {offset}"),
+                None => format!("LIMIT {count}"),
+            };
+            result.replace_range(range, &replacement);
+        }
+
+        // OFFSET ... ROWS alone: SQLite needs a LIMIT before any OFFSET, -1 meaning none
+        let offsets: Vec<_> = OFFSET_ROWS_REGEX.captures_iter(&result)
+            .filter(|caps| !in_string_literal(&result, caps.get(0).unwrap().start()))
+            .map(|caps| (caps.get(0).unwrap().range(), caps[1].to_string()))
+            .collect();
+        for (range, offset) in offsets.into_iter().rev() {
+            let statement = &result[statement_start(&result, range.start)..range.start];
+            let has_limit = top_level_matches(statement, &LIMIT_REGEX).last().is_some();
+            let replacement = if has_limit {
+                format!("OFFSET {offset}")
+            } else {
+                format!("LIMIT -1 OFFSET {offset}")
+            };
+            result.replace_range(range, &replacement);
+        }
+
+        if result != query {
+            debug!("Translated FETCH/OFFSET clauses: {} -> {}", query, result);
+        }
+        Ok(result)
+    }
+
+    /// `SELECT ... FROM f [WHERE w] ORDER BY k FETCH FIRST n ROWS WITH TIES` becomes
+    /// `SELECT ... FROM f WHERE (w) AND k <= coalesce((SELECT k FROM f [WHERE w] ORDER BY k
+    /// LIMIT 1 OFFSET n - 1), k) ORDER BY k`, with >= for a descending key
+    fn translate_with_ties(
+        query: &str,
+        fetch: std::ops::Range<usize>,
+        offset: Option<&str>,
+        count: &str,
+    ) -> Result<String, PgSqliteError> {
+        let start = statement_start(query, fetch.start);
+        let statement = &query[start..fetch.start];
+
+        let order_by = top_level_matches(statement, &ORDER_BY_REGEX).last().ok_or_else(|| PgError::Generic {
+            code: "42P20".to_string(), // windowing_error
+            message: "WITH TIES cannot be specified without ORDER BY clause".to_string(),
+        })?;
+        let from = top_level_matches(&statement[..order_by.start], &FROM_REGEX).last()
+            .filter(|_| !UNSUPPORTED_TIES_REGEX.is_match(statement));
+        let sort_key = statement[order_by.end..].trim();
+        let (Some(from), false) = (from, sort_key.contains(',')) else {
+            return Err(PgError::Generic {
+                code: "0A000".to_string(), // feature_not_supported
+                message: "FETCH ... WITH TIES is only supported with a single ORDER BY key over a plain FROM/WHERE".to_string(),
+            }.into());
+        };
+
+        let count: i64 = count.parse().map_err(|_| Self::invalid_count(count))?;
+        if count < 1 {
+            return Err(Self::invalid_count(&count.to_string()));
+        }
+        let skip: i64 = match offset {
+            Some(offset) => offset.parse().map_err(|_| Self::invalid_count(offset))?,
+            None => 0,
+        };
+
+        let mut key_words: Vec<&str> = sort_key.split_whitespace().collect();
+        let descending = match key_words.last().map(|w| w.to_uppercase()) {
+            Some(w) if w == "DESC" => { key_words.pop(); true }
+            Some(w) if w == "ASC" => { key_words.pop(); false }
+            _ => false,
+        };
+        let key = key_words.join(" ");
+        let comparison = if descending { ">=" } else { "<=" };
+
+        let source = statement[from.end..order_by.start].trim();
+        let filtered_source = match top_level_matches(source, &WHERE_REGEX).last() {
+            Some(w) => format!("{} WHERE ({})", source[..w.start].trim_end(), source[w.end..].trim()),
+            None => format!("{source} WHERE 1"),
+        };
+        let boundary = format!("(SELECT {key} FROM {source} ORDER BY {sort_key} LIMIT 1 OFFSET {})", skip + count - 1);
+        let paging = if skip > 0 { format!(" LIMIT -1 OFFSET {skip}") } else { String::new() };
+
+        Ok(format!(
+            "{}FROM {} AND {key} {comparison} coalesce({boundary}, {key}) ORDER BY {sort_key}{paging}{}",
+            &query[..start + from.start],
+            filtered_source,
+            &query[fetch.end..],
+        ))
+    }
+
+    fn invalid_count(count: &str) -> PgSqliteError {
+        PgError::Generic {
+            code: "2201W".to_string(), // invalid_row_count_in_limit_clause
+            message: format!("row count in FETCH FIRST ... WITH TIES must be a positive integer, got {count}"),
+        }.into()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_fetch_first_translation() {
+        let cases = [
+            ("SELECT * FROM t ORDER BY id OFFSET 40 ROWS FETCH FIRST 20 ROWS ONLY", "SELECT * FROM t ORDER BY id LIMIT 20 OFFSET 40"),
+            ("SELECT * FROM t ORDER BY id FETCH NEXT 5 ROWS ONLY", "SELECT * FROM t ORDER BY id LIMIT 5"),
+            ("SELECT * FROM t FETCH FIRST ROW ONLY", "SELECT * FROM t LIMIT 1"),
+            ("SELECT * FROM t ORDER BY id OFFSET 10 ROWS", "SELECT * FROM t ORDER BY id LIMIT -1 OFFSET 10"),
+            ("SELECT * FROM t ORDER BY id LIMIT 5 OFFSET 10 ROWS", "SELECT * FROM t ORDER BY id LIMIT 5 OFFSET 10"),
+            (
+                "SELECT * FROM (SELECT id FROM t ORDER BY id FETCH FIRST 3 ROWS ONLY) s OFFSET 1 ROW",
+                "SELECT * FROM (SELECT id FROM t ORDER BY id LIMIT 3) s LIMIT -1 OFFSET 1",
+            ),
+            ("SELECT * FROM t ORDER BY id LIMIT ALL", "SELECT * FROM t ORDER BY id LIMIT -1"),
+            ("SELECT * FROM t ORDER BY id limit all offset 5", "SELECT * FROM t ORDER BY id LIMIT -1 offset 5"),
+            ("SELECT * FROM t ORDER BY id LIMIT ALL OFFSET 5 ROWS", "SELECT * FROM t ORDER BY id LIMIT -1 OFFSET 5"),
+        ];
+        for (query, expected) in cases {
+            assert_eq!(FetchFirstTranslator::translate_query(query).unwrap(), expected);
+        }
+
+        let query = "SELECT 'FETCH FIRST 1 ROWS ONLY' FROM t LIMIT 2";
+        assert_eq!(FetchFirstTranslator::translate_query(query).unwrap(), query);
+    }
+
+    #[test]
+    fn test_fetch_with_ties_translation() {
+        assert_eq!(
+            FetchFirstTranslator::translate_query(
+                "SELECT name, score FROM players WHERE active = 1 OR vip = 1 ORDER BY score DESC FETCH FIRST 3 ROWS WITH TIES"
+            ).unwrap(),
+            "SELECT name, score FROM players WHERE (active = 1 OR vip = 1) AND score >= coalesce(\
+             (SELECT score FROM players WHERE active = 1 OR vip = 1 ORDER BY score DESC LIMIT 1 OFFSET 2), score) \
+             ORDER BY score DESC"
+        );
+        assert_eq!(
+            FetchFirstTranslator::translate_query("SELECT * FROM p ORDER BY s OFFSET 2 ROWS FETCH NEXT 2 ROWS WITH TIES").unwrap(),
+            "SELECT * FROM p WHERE 1 AND s <= coalesce((SELECT s FROM p ORDER BY s LIMIT 1 OFFSET 3), s) ORDER BY s LIMIT -1 OFFSET 2"
+        );
+
+        let err = FetchFirstTranslator::translate_query("SELECT * FROM p FETCH FIRST 2 ROWS WITH TIES").unwrap_err();
+        assert_eq!(err.pg_error_code(), "42P20");
+        let err = FetchFirstTranslator::translate_query("SELECT * FROM p ORDER BY a, b FETCH FIRST 2 ROWS WITH TIES").unwrap_err();
+        assert_eq!(err.pg_error_code(), "0A000");
+    }
+}
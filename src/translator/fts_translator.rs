@@ -1,6 +1,8 @@
 use lazy_static::lazy_static;
 use regex::Regex;
 use rusqlite::Connection;
+use crate::functions::fts_functions::{lexize_tsquery, lexize_words, websearch_to_tsquery};
+use crate::functions::text_search_config::TextSearchConfig;
 
 lazy_static! {
     // Match FTS operators: @@ for match, @> and <@ for contains
@@ -88,7 +90,11 @@ impl FtsTranslator {
             let modified_query = query.replace("tsvector", "TEXT");
             translated_queries.push(modified_query);
             
-            // Create FTS5 shadow tables for each tsvector column
+            // Create FTS5 shadow tables for each tsvector column, tokenized like the default
+            // configuration
+            let config = TextSearchConfig::English;
+            let tokenizer = config.fts5_tokenizer();
+            let config_name = config.name();
             for column_name in &fts_columns {
                 let fts_table_name = format!("__pgsqlite_fts_{table_name}_{column_name}");
                 
@@ -97,7 +103,7 @@ impl FtsTranslator {
                         content,
                         weights UNINDEXED,
                         lexemes UNINDEXED,
-                        tokenize = 'porter unicode61'
+                        tokenize = '{tokenizer}'
                     )"
                 );
                 translated_queries.push(fts_create);
@@ -106,14 +112,14 @@ impl FtsTranslator {
                 let metadata_insert = format!(
                     "INSERT INTO __pgsqlite_fts_metadata 
                      (table_name, column_name, fts_table_name, config_name, tokenizer)
-                     VALUES ('{table_name}', '{column_name}', '{fts_table_name}', 'english', 'porter unicode61')"
+                     VALUES ('{table_name}', '{column_name}', '{fts_table_name}', '{config_name}', '{tokenizer}')"
                 );
                 translated_queries.push(metadata_insert);
                 
                 // Update schema table
                 let schema_update = format!(
                     "UPDATE __pgsqlite_schema 
-                     SET fts_table_name = '{fts_table_name}', fts_config = 'english'
+                     SET fts_table_name = '{fts_table_name}', fts_config = '{config_name}'
                      WHERE table_name = '{table_name}' AND column_name = '{column_name}'"
                 );
                 translated_queries.push(schema_update);
@@ -208,6 +214,12 @@ impl FtsTranslator {
                     let config = tsvector_match.get(1).map(|m| m.as_str()).unwrap_or("english");
                     let text_content = tsvector_match.get(2).unwrap().as_str();
                     
+                    // Leave unknown configurations to to_tsvector(), which reports them
+                    let Ok(config) = TextSearchConfig::from_name(config) else {
+                        continue;
+                    };
+                    let config = config.name();
+                    
                     if i < columns.len() {
                         let column_name = &columns[i];
                         
@@ -347,15 +359,19 @@ impl FtsTranslator {
         
         for caps in TO_TSQUERY_REGEX.captures_iter(query) {
             let function_name = caps.get(1).unwrap().as_str();
-            let _config = caps.get(2).map(|m| m.as_str());
             let query_text = caps.get(3).unwrap().as_str();
             
+            // Leave unknown configurations to the tsquery functions, which report them
+            let Ok(config) = TextSearchConfig::from_name(caps.get(2).map_or("english", |m| m.as_str())) else {
+                continue;
+            };
+            
             // Convert PostgreSQL query syntax to FTS5
             let fts5_query = match function_name {
-                "to_tsquery" => self.convert_tsquery_to_fts5(query_text)?,
-                "plainto_tsquery" => self.convert_plain_to_fts5(query_text)?,
-                "phraseto_tsquery" => self.convert_phrase_to_fts5(query_text)?,
-                "websearch_to_tsquery" => self.convert_websearch_to_fts5(query_text)?,
+                "to_tsquery" => self.convert_tsquery_to_fts5(config, query_text)?,
+                "plainto_tsquery" => self.convert_plain_to_fts5(config, query_text)?,
+                "phraseto_tsquery" => self.convert_phrase_to_fts5(config, query_text)?,
+                "websearch_to_tsquery" => self.convert_websearch_to_fts5(config, query_text)?,
                 _ => query_text.to_string(),
             };
             
@@ -368,12 +384,12 @@ impl FtsTranslator {
     }
     
     /// Convert PostgreSQL tsquery syntax to FTS5 MATCH syntax
-    fn convert_tsquery_to_fts5(&self, query: &str) -> anyhow::Result<String> {
+    fn convert_tsquery_to_fts5(&self, config: TextSearchConfig, query: &str) -> anyhow::Result<String> {
         // Remove quotes if present
         let query = query.trim_matches('\'').trim_matches('"');
         
         // Convert operators with proper spacing
-        let result = query
+        let result = lexize_tsquery(config, query)
             .replace(" & ", " AND ")
             .replace("&", " AND ")
             .replace(" | ", " OR ")
@@ -385,23 +401,21 @@ impl FtsTranslator {
     }
     
     /// Convert plain text to FTS5 query (all terms with AND)
-    fn convert_plain_to_fts5(&self, query: &str) -> anyhow::Result<String> {
+    fn convert_plain_to_fts5(&self, config: TextSearchConfig, query: &str) -> anyhow::Result<String> {
         let query = query.trim_matches('\'').trim_matches('"');
-        let terms: Vec<&str> = query.split_whitespace().collect();
-        Ok(terms.join(" AND "))
+        Ok(lexize_words(config, query).join(" AND "))
     }
     
     /// Convert phrase query to FTS5 (exact phrase match)
-    fn convert_phrase_to_fts5(&self, query: &str) -> anyhow::Result<String> {
+    fn convert_phrase_to_fts5(&self, config: TextSearchConfig, query: &str) -> anyhow::Result<String> {
         let query = query.trim_matches('\'').trim_matches('"');
-        Ok(format!("\"{query}\""))
+        Ok(format!("\"{}\"", lexize_words(config, query).join(" ")))
     }
     
     /// Convert web search syntax to FTS5
-    fn convert_websearch_to_fts5(&self, query: &str) -> anyhow::Result<String> {
+    fn convert_websearch_to_fts5(&self, config: TextSearchConfig, query: &str) -> anyhow::Result<String> {
         let query = query.trim_matches('\'').trim_matches('"');
-        // Simple implementation - could be enhanced
-        Ok(query.to_string())
+        Ok(websearch_to_tsquery(config, query))
     }
     
     /// Extract table name from FROM clause in SELECT query
@@ -460,19 +474,19 @@ mod tests {
         let translator = FtsTranslator::new();
         
         assert_eq!(
-            translator.convert_tsquery_to_fts5("'cat & dog'").unwrap(),
+            translator.convert_tsquery_to_fts5(TextSearchConfig::English, "'cat & dog'").unwrap(),
             "cat AND dog"
         );
         assert_eq!(
-            translator.convert_tsquery_to_fts5("'cat | dog'").unwrap(),
+            translator.convert_tsquery_to_fts5(TextSearchConfig::English, "'cat | dog'").unwrap(),
             "cat OR dog"
         );
         assert_eq!(
-            translator.convert_tsquery_to_fts5("'!cat'").unwrap(),
+            translator.convert_tsquery_to_fts5(TextSearchConfig::English, "'!cat'").unwrap(),
             "NOT cat"
         );
         assert_eq!(
-            translator.convert_tsquery_to_fts5("'cat:*'").unwrap(),
+            translator.convert_tsquery_to_fts5(TextSearchConfig::English, "'cat:*'").unwrap(),
             "cat*"
         );
     }
@@ -482,7 +496,7 @@ mod tests {
         let translator = FtsTranslator::new();
         
         assert_eq!(
-            translator.convert_plain_to_fts5("'quick brown fox'").unwrap(),
+            translator.convert_plain_to_fts5(TextSearchConfig::English, "'quick brown fox'").unwrap(),
             "quick AND brown AND fox"
         );
     }
@@ -492,7 +506,7 @@ mod tests {
         let translator = FtsTranslator::new();
         
         assert_eq!(
-            translator.convert_phrase_to_fts5("'quick brown fox'").unwrap(),
+            translator.convert_phrase_to_fts5(TextSearchConfig::English, "'quick brown fox'").unwrap(),
             "\"quick brown fox\""
         );
     }
@@ -0,0 +1,261 @@
+use rusqlite::Connection;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::{in_string_literal, statement_start, top_level_matches, top_level_ranges};
+
+static GROUP_BY_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bGROUP\s+BY\b").unwrap()
+});
+
+static SELECT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bSELECT\b").unwrap()
+});
+
+static FROM_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bFROM\b").unwrap()
+});
+
+/// Clauses that end a GROUP BY list
+static CLAUSE_END_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\b(?:HAVING|WINDOW|ORDER\s+BY|LIMIT|OFFSET|FETCH|UNION|INTERSECT|EXCEPT|FOR)\b").unwrap()
+});
+
+/// `expression AS alias` in a select list
+static ALIAS_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^(.*?)\s+AS\s+("[^"]+"|\w+)$"#).unwrap()
+});
+
+static SELECT_QUANTIFIER_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*(?:ALL|DISTINCT)\b").unwrap()
+});
+
+static DISTINCT_ON_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*DISTINCT\s+ON\b").unwrap()
+});
+
+static TABLE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?i)\b(?:FROM|JOIN)\s+(?:\w+\.)?("[^"]+"|\w+)"#).unwrap()
+});
+
+static IDENTIFIER_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"^(?:"[^"]+"|[A-Za-z_]\w*)$"#).unwrap()
+});
+
+/// Resolves GROUP BY items naming select-list positions or output column aliases.
+///
+/// `GROUP BY 2` and `GROUP BY total` are replaced with the select-list expression they
+/// refer to, so the grouping keeps following the expression whatever later translations
+/// do to the select list. As in PostgreSQL, a name that is both an input column and an
+/// output alias groups by the input column, and only explicit `AS` aliases are resolved.
+/// Positions out of range are left for SQLite to reject.
+pub struct GroupByTranslator;
+
+impl GroupByTranslator {
+    /// Check if the query has a GROUP BY clause
+    pub fn needs_translation(query: &str) -> bool {
+        GROUP_BY_REGEX.is_match(query)
+    }
+
+    /// Replace GROUP BY positions and output column aliases with their expressions
+    pub fn translate_query(query: &str, conn: &Connection) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let mut result = query.to_string();
+        let group_bys: Vec<_> = GROUP_BY_REGEX.find_iter(query)
+            .filter(|m| !in_string_literal(query, m.start()))
+            .map(|m| m.range())
+            .collect();
+
+        // Work from the last clause backwards so earlier offsets stay valid
+        for group_by in group_bys.into_iter().rev() {
+            if let Some((range, list)) = Self::resolve_group_by(&result, group_by, conn) {
+                result.replace_range(range, &list);
+            }
+        }
+
+        if result != query {
+            debug!("Resolved GROUP BY positions and aliases: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// The range of the GROUP BY list after `group_by` and its resolved text, if any item
+    /// names a position or an alias
+    fn resolve_group_by(
+        query: &str,
+        group_by: std::ops::Range<usize>,
+        conn: &Connection,
+    ) -> Option<(std::ops::Range<usize>, String)> {
+        let start = statement_start(query, group_by.start);
+        let statement = &query[start..group_by.start];
+        let select = top_level_matches(statement, &SELECT_REGEX).last()?;
+        let from = top_level_matches(&statement[select.end..], &FROM_REGEX).next()?;
+        let mut select_list = &statement[select.end..select.end + from.start];
+        if DISTINCT_ON_REGEX.is_match(select_list) {
+            return None;
+        }
+        if let Some(quantifier) = SELECT_QUANTIFIER_REGEX.find(select_list) {
+            select_list = &select_list[quantifier.end()..];
+        }
+
+        let outputs: Vec<(&str, Option<&str>)> = Self::list_items(select_list)
+            .into_iter()
+            .map(|(_, item)| match ALIAS_REGEX.captures(item) {
+                Some(caps) => (caps.get(1).unwrap().as_str().trim(), Some(caps.get(2).unwrap().as_str())),
+                None => (item, None),
+            })
+            .collect();
+        let has_star = outputs.iter().any(|(expr, _)| *expr == "*" || expr.ends_with(".*"));
+        let tables: Vec<String> = TABLE_REGEX.captures_iter(&statement[select.end + from.start..])
+            .map(|caps| caps[1].trim_matches('"').to_string())
+            .collect();
+
+        // The list runs to the next clause, the end of the (sub)query or the statement
+        let rest = &query[group_by.end..];
+        let mut end = Self::list_end(rest);
+        if let Some(clause) = top_level_matches(&rest[..end], &CLAUSE_END_REGEX).next() {
+            end = clause.start;
+        }
+        let list = &rest[..end];
+
+        let mut resolved = list.to_string();
+        let mut changed = false;
+        for (offset, item) in Self::list_items(list).into_iter().rev() {
+            let expr = if let Ok(position) = item.parse::<usize>() {
+                // A constant integer would itself be read as a position
+                (!has_star && position >= 1)
+                    .then(|| outputs.get(position - 1).map(|(expr, _)| *expr))
+                    .flatten()
+                    .filter(|expr| !expr.chars().all(|c| c.is_ascii_digit()))
+            } else if IDENTIFIER_REGEX.is_match(item) {
+                outputs.iter()
+                    .find(|(_, alias)| alias.is_some_and(|alias| Self::same_name(alias, item)))
+                    .map(|(expr, _)| *expr)
+                    .filter(|_| !Self::is_input_column(conn, &tables, item))
+            } else {
+                None
+            };
+            if let Some(expr) = expr {
+                resolved.replace_range(offset..offset + item.len(), expr);
+                changed = true;
+            }
+        }
+
+        changed.then(|| (group_by.end..group_by.end + end, resolved))
+    }
+
+    /// Whether two identifiers name the same column; unquoted names are case-insensitive
+    fn same_name(a: &str, b: &str) -> bool {
+        let fold = |name: &str| match name.strip_prefix('"').and_then(|n| n.strip_suffix('"')) {
+            Some(quoted) => quoted.to_string(),
+            None => name.to_lowercase(),
+        };
+        fold(a) == fold(b)
+    }
+
+    fn is_input_column(conn: &Connection, tables: &[String], name: &str) -> bool {
+        let name = name.trim_matches('"');
+        tables.iter().any(|table| {
+            conn.query_row(
+                "SELECT 1 FROM pragma_table_info(?1) WHERE name = ?2 COLLATE NOCASE",
+                [table.as_str(), name],
+                |_| Ok(()),
+            ).is_ok()
+        })
+    }
+
+    /// Comma-separated items outside parentheses and string literals, trimmed, with their
+    /// offsets in `text`
+    fn list_items(text: &str) -> Vec<(usize, &str)> {
+        top_level_ranges(text).into_iter()
+            .filter_map(|range| {
+                let raw = &text[range.clone()];
+                let trimmed = raw.trim();
+                (!trimmed.is_empty()).then(|| (range.start + raw.len() - raw.trim_start().len(), trimmed))
+            })
+            .collect()
+    }
+
+    /// End of the GROUP BY list: the parenthesis closing its subquery, a semicolon or the end
+    fn list_end(rest: &str) -> usize {
+        let mut depth = 0;
+        let mut in_string = false;
+        for (i, ch) in rest.char_indices() {
+            match ch {
+                '\'' => in_string = !in_string,
+                '(' if !in_string => depth += 1,
+                ')' if !in_string && depth == 0 => return i,
+                ')' if !in_string => depth -= 1,
+                ';' if !in_string && depth == 0 => return i,
+                _ => {}
+            }
+        }
+        rest.len()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn conn() -> Connection {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute("CREATE TABLE orders (id INTEGER, region TEXT, amount REAL, created_at TEXT)", []).unwrap();
+        conn
+    }
+
+    #[test]
+    fn test_group_by_positions() {
+        let conn = conn();
+        let cases = [
+            (
+                "SELECT region, count(*) FROM orders GROUP BY 1",
+                "SELECT region, count(*) FROM orders GROUP BY region",
+            ),
+            (
+                "SELECT substr(created_at, 1, 7) AS month, region, sum(amount) FROM orders GROUP BY 1, 2 ORDER BY 1",
+                "SELECT substr(created_at, 1, 7) AS month, region, sum(amount) FROM orders GROUP BY substr(created_at, 1, 7), region ORDER BY 1",
+            ),
+            (
+                "SELECT * FROM (SELECT DISTINCT region, count(*) AS n FROM orders GROUP BY 1) s",
+                "SELECT * FROM (SELECT DISTINCT region, count(*) AS n FROM orders GROUP BY region) s",
+            ),
+        ];
+        for (query, expected) in cases {
+            assert_eq!(GroupByTranslator::translate_query(query, &conn), expected);
+        }
+
+        // Constant integers, stars and positions out of range are left alone
+        for query in [
+            "SELECT 5, count(*) FROM orders GROUP BY 1",
+            "SELECT * FROM orders GROUP BY 2",
+            "SELECT region, count(*) FROM orders GROUP BY 3",
+            "SELECT region FROM orders GROUP BY region",
+        ] {
+            assert_eq!(GroupByTranslator::translate_query(query, &conn), query);
+        }
+    }
+
+    #[test]
+    fn test_group_by_aliases() {
+        let conn = conn();
+        assert_eq!(
+            GroupByTranslator::translate_query(
+                "SELECT CASE WHEN amount > 100 THEN 'large' ELSE 'small' END AS size, count(*) FROM orders GROUP BY size HAVING count(*) > 1",
+                &conn,
+            ),
+            "SELECT CASE WHEN amount > 100 THEN 'large' ELSE 'small' END AS size, count(*) FROM orders GROUP BY CASE WHEN amount > 100 THEN 'large' ELSE 'small' END HAVING count(*) > 1"
+        );
+        assert_eq!(
+            GroupByTranslator::translate_query("SELECT CAST(amount AS INTEGER) AS \"Bucket\", count(*) FROM orders GROUP BY \"Bucket\";", &conn),
+            "SELECT CAST(amount AS INTEGER) AS \"Bucket\", count(*) FROM orders GROUP BY CAST(amount AS INTEGER);"
+        );
+
+        // An input column of the same name wins over the output alias
+        let query = "SELECT upper(region) AS region, count(*) FROM orders GROUP BY region";
+        assert_eq!(GroupByTranslator::translate_query(query, &conn), query);
+    }
+}
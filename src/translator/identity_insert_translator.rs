@@ -0,0 +1,135 @@
+use rusqlite::Connection;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::insert_default_translator::{InsertDefaultTranslator, ValueRewrite, INSERT_VALUES_REGEX};
+
+static OVERRIDING_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bOVERRIDING\s+(SYSTEM|USER)\s+VALUE\s+").unwrap()
+});
+
+/// Translates `INSERT ... OVERRIDING { SYSTEM | USER } VALUE`, which SQLite doesn't have.
+///
+/// With SYSTEM VALUE the values given for GENERATED ALWAYS identity columns are wrapped in
+/// `__pgsqlite_system_value()`, which lets them past the column's IdentityTriggers. With
+/// USER VALUE the values given for identity columns are ignored, as in PostgreSQL, by
+/// replacing them with NULL so the key is generated.
+pub struct IdentityInsertTranslator;
+
+impl IdentityInsertTranslator {
+    /// Check if the query is an INSERT with an OVERRIDING clause
+    pub fn needs_translation(query: &str) -> bool {
+        (query.contains("OVERRIDING") || query.contains("overriding") || query.contains("Overriding"))
+            && INSERT_VALUES_REGEX.find(query).is_some_and(|insert| OVERRIDING_REGEX.is_match(insert.as_str()))
+    }
+
+    /// Remove the OVERRIDING clause and mark or drop the identity values accordingly
+    pub fn translate_query(query: &str, conn: &Connection) -> String {
+        let Some(insert) = INSERT_VALUES_REGEX.find(query) else {
+            return query.to_string();
+        };
+        let Some(clause) = OVERRIDING_REGEX.captures(insert.as_str()) else {
+            return query.to_string();
+        };
+        let system_value = clause[1].eq_ignore_ascii_case("SYSTEM");
+        let clause = clause.get(0).unwrap();
+        let (start, end) = (insert.start() + clause.start(), insert.start() + clause.end());
+        let query_without_clause = format!("{}{}", &query[..start], &query[end..]);
+
+        let Some(caps) = INSERT_VALUES_REGEX.captures(&query_without_clause) else {
+            return query_without_clause;
+        };
+        let table = caps[1].rsplit('.').next().unwrap_or(&caps[1]).trim_matches('"').to_string();
+
+        // SYSTEM VALUE only matters for ALWAYS columns, BY DEFAULT ones accept any value
+        let identity_columns = match Self::identity_columns(conn, &table) {
+            Ok(columns) => columns.into_iter()
+                .filter(|(_, generation)| !system_value || generation == "a")
+                .map(|(column, _)| column)
+                .collect::<Vec<_>>(),
+            Err(e) => {
+                debug!("Could not read the identity columns of {}: {}", table, e);
+                return query_without_clause;
+            }
+        };
+        if identity_columns.is_empty() {
+            return query_without_clause;
+        }
+
+        // Which value positions hold an identity column: the listed columns, or all columns in
+        // order. DEFAULT has been translated already, so the rows no longer hold generated columns.
+        let columns: Vec<String> = match caps.get(2) {
+            Some(columns) => columns.as_str().split(',')
+                .map(|column| column.trim().trim_matches('"').to_string())
+                .collect(),
+            None => match InsertDefaultTranslator::column_defaults(conn, &table) {
+                Ok(columns) => columns.into_iter()
+                    .filter(|(_, _, generated)| !generated)
+                    .map(|(column, _, _)| column)
+                    .collect(),
+                Err(_) => return query_without_clause,
+            },
+        };
+        let is_identity: Vec<bool> = columns.iter()
+            .map(|column| identity_columns.iter().any(|identity| identity.eq_ignore_ascii_case(column)))
+            .collect();
+
+        let values_start = caps.get(0).unwrap().end();
+        let rows = InsertDefaultTranslator::rewrite_rows(&query_without_clause[values_start..], |i, value| {
+            if !is_identity.get(i).copied().unwrap_or(false)
+                || value.eq_ignore_ascii_case("DEFAULT") || value.eq_ignore_ascii_case("NULL") {
+                return ValueRewrite::Keep;
+            }
+            ValueRewrite::Replace(if system_value { format!("__pgsqlite_system_value({value})") } else { "NULL".to_string() })
+        });
+        let result = format!("{}{}", &query_without_clause[..values_start], rows);
+
+        debug!("Translated OVERRIDING clause: {} -> {}", query, result);
+        result
+    }
+
+    /// (column, generation) of the identity columns recorded for the table
+    fn identity_columns(conn: &Connection, table: &str) -> rusqlite::Result<Vec<(String, String)>> {
+        let mut stmt = conn.prepare(
+            "SELECT column_name, generation FROM __pgsqlite_identity_columns WHERE table_name = ?1"
+        )?;
+        let rows = stmt.query_map([table], |row| Ok((row.get(0)?, row.get(1)?)))?;
+        rows.collect()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_overriding_translation() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "CREATE TABLE __pgsqlite_identity_columns (table_name TEXT, column_name TEXT, generation TEXT);
+             INSERT INTO __pgsqlite_identity_columns VALUES ('orders', 'id', 'a'), ('notes', 'id', 'd');
+             CREATE TABLE orders (id INTEGER PRIMARY KEY AUTOINCREMENT, item TEXT);
+             CREATE TABLE notes (id INTEGER PRIMARY KEY AUTOINCREMENT, body TEXT);"
+        ).unwrap();
+
+        assert_eq!(
+            IdentityInsertTranslator::translate_query(
+                "INSERT INTO orders (id, item) OVERRIDING SYSTEM VALUE VALUES (10, 'a'), (DEFAULT, 'b') RETURNING id",
+                &conn,
+            ),
+            "INSERT INTO orders (id, item) VALUES (__pgsqlite_system_value(10), 'a'), (DEFAULT, 'b') RETURNING id"
+        );
+        assert_eq!(
+            IdentityInsertTranslator::translate_query("INSERT INTO orders OVERRIDING USER VALUE VALUES (7, 'c')", &conn),
+            "INSERT INTO orders VALUES (NULL, 'c')"
+        );
+        // BY DEFAULT columns already take explicit values
+        assert_eq!(
+            IdentityInsertTranslator::translate_query("INSERT INTO notes (id, body) OVERRIDING SYSTEM VALUE VALUES (3, 'x')", &conn),
+            "INSERT INTO notes (id, body) VALUES (3, 'x')"
+        );
+
+        assert!(IdentityInsertTranslator::needs_translation("insert into orders (id) overriding system value values (1)"));
+        assert!(!IdentityInsertTranslator::needs_translation("INSERT INTO orders (item) VALUES ('OVERRIDING SYSTEM VALUE x')"));
+    }
+}
@@ -0,0 +1,211 @@
+use rusqlite::Connection;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::{matching_paren, split_top_level};
+
+/// `INSERT INTO t [(columns)] [OVERRIDING ... VALUE] VALUES`, capturing the table and the column list
+pub(super) static INSERT_VALUES_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^\s*INSERT\s+INTO\s+((?:"[^"]+"|\w+)(?:\.(?:"[^"]+"|\w+))?)\s*(?:\(([^)]*)\))?\s*(?:OVERRIDING\s+(?:SYSTEM|USER)\s+VALUE\s+)?VALUES\b"#).unwrap()
+});
+
+static DEFAULT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bDEFAULT\b").unwrap()
+});
+
+/// What to do with one value of an INSERT row
+pub(super) enum ValueRewrite {
+    Keep,
+    Replace(String),
+    Omit,
+}
+
+/// Replaces the DEFAULT keyword in the rows of `INSERT ... VALUES` with the column's default.
+///
+/// SQLite only fills in defaults for columns left out of the column list, so
+/// `VALUES ('X', DEFAULT)` is a syntax error there. Each DEFAULT is replaced with the default
+/// expression SQLite stored for that column (a literal, CURRENT_TIMESTAMP or a parenthesized
+/// expression such as `(gen_random_uuid())`), or NULL when the column has none. NULL is also
+/// what lets an INTEGER PRIMARY KEY pick the next rowid. SQLite rejects any value for a
+/// generated column, so a DEFAULT there is removed along with the column. `INSERT INTO t
+/// DEFAULT VALUES` is native SQLite and is left alone.
+pub struct InsertDefaultTranslator;
+
+impl InsertDefaultTranslator {
+    /// Check if the query is an INSERT ... VALUES using DEFAULT for a value
+    pub fn needs_translation(query: &str) -> bool {
+        if !(query.contains("DEFAULT") || query.contains("default") || query.contains("Default")) {
+            return false;
+        }
+        INSERT_VALUES_REGEX.find(query)
+            .is_some_and(|values| DEFAULT_REGEX.is_match(&query[values.end()..]))
+    }
+
+    /// Replace the DEFAULT values with the defaults from the table definition
+    pub fn translate_query(query: &str, conn: &Connection) -> String {
+        let Some(caps) = INSERT_VALUES_REGEX.captures(query) else {
+            return query.to_string();
+        };
+        let table = caps[1].rsplit('.').next().unwrap_or(&caps[1]).trim_matches('"').to_string();
+
+        let defaults = match Self::column_defaults(conn, &table) {
+            Ok(defaults) if !defaults.is_empty() => defaults,
+            Ok(_) => return query.to_string(),
+            Err(e) => {
+                debug!("Could not read the defaults of {}: {}", table, e);
+                return query.to_string();
+            }
+        };
+
+        // The default of each value position: the listed columns, or all columns in order.
+        // Generated columns have none, a DEFAULT there is dropped since SQLite computes them.
+        let default_of = |(_, default, generated): &(String, Option<String>, bool)| {
+            (!generated).then(|| default.clone().unwrap_or_else(|| "NULL".to_string()))
+        };
+        let listed: Option<Vec<&str>> = caps.get(2).map(|columns| columns.as_str().split(',').collect());
+        let positions: Vec<Option<String>> = match &listed {
+            Some(columns) => columns.iter()
+                .map(|column| {
+                    let column = column.trim().trim_matches('"');
+                    defaults.iter()
+                        .find(|(name, _, _)| name.eq_ignore_ascii_case(column))
+                        .map_or_else(|| Some("NULL".to_string()), default_of)
+                })
+                .collect(),
+            None => defaults.iter().map(default_of).collect(),
+        };
+
+        let omitted = std::cell::RefCell::new(vec![false; positions.len()]);
+        let values_start = caps.get(0).unwrap().end();
+        let rows = Self::rewrite_rows(&query[values_start..], |i, value| {
+            if !value.eq_ignore_ascii_case("DEFAULT") {
+                return ValueRewrite::Keep;
+            }
+            match positions.get(i) {
+                Some(Some(default)) => ValueRewrite::Replace(default.clone()),
+                Some(None) => {
+                    omitted.borrow_mut()[i] = true;
+                    ValueRewrite::Omit
+                }
+                None => ValueRewrite::Keep,
+            }
+        });
+
+        // Generated columns whose value was dropped leave the column list too
+        let omitted = omitted.into_inner();
+        let prefix = match (caps.get(2), &listed) {
+            (Some(list), Some(columns)) if omitted.contains(&true) => {
+                let kept: Vec<&str> = columns.iter().enumerate()
+                    .filter(|(i, _)| !omitted[*i])
+                    .map(|(_, column)| column.trim())
+                    .collect();
+                format!("{}{}{}", &query[..list.start()], kept.join(", "), &query[list.end()..values_start])
+            }
+            _ => query[..values_start].to_string(),
+        };
+        let result = format!("{prefix}{rows}");
+
+        debug!("Replaced DEFAULT values: {} -> {}", query, result);
+        result
+    }
+
+    /// Rewrite the row tuples that follow VALUES until something other than a row follows
+    /// (ON CONFLICT, RETURNING, ...). `rewrite` gets the position and trimmed text of each
+    /// value, rows where every value is kept stay as written.
+    pub(super) fn rewrite_rows(rest: &str, rewrite: impl Fn(usize, &str) -> ValueRewrite) -> String {
+        let mut result = String::with_capacity(rest.len());
+        let mut pos = 0;
+        loop {
+            let skipped = rest[pos..].len() - rest[pos..].trim_start_matches(|c: char| c.is_whitespace() || c == ',').len();
+            result.push_str(&rest[pos..pos + skipped]);
+            pos += skipped;
+            if !rest[pos..].starts_with('(') {
+                break;
+            }
+            let Some(end) = matching_paren(&rest[pos..], 0) else {
+                break;
+            };
+            let values = split_top_level(&rest[pos + 1..pos + end]);
+            let rewrites: Vec<ValueRewrite> = values.iter().enumerate()
+                .map(|(i, value)| rewrite(i, value.trim()))
+                .collect();
+            if rewrites.iter().any(|rewrite| !matches!(rewrite, ValueRewrite::Keep)) {
+                let values: Vec<String> = values.iter().zip(rewrites)
+                    .filter_map(|(value, rewrite)| match rewrite {
+                        ValueRewrite::Keep => Some(value.trim().to_string()),
+                        ValueRewrite::Replace(replacement) => Some(replacement),
+                        ValueRewrite::Omit => None,
+                    })
+                    .collect();
+                result.push('(');
+                result.push_str(&values.join(", "));
+                result.push(')');
+            } else {
+                result.push_str(&rest[pos..=pos + end]);
+            }
+            pos += end + 1;
+        }
+        result.push_str(&rest[pos..]);
+        result
+    }
+
+    /// (column, SQLite default expression, generated) in column order. pragma_table_xinfo
+    /// includes the generated columns (hidden 2 and 3) that pragma_table_info leaves out.
+    pub(super) fn column_defaults(conn: &Connection, table: &str) -> rusqlite::Result<Vec<(String, Option<String>, bool)>> {
+        let mut stmt = conn.prepare(
+            "SELECT name, dflt_value, hidden IN (2, 3) FROM pragma_table_xinfo(?1) WHERE hidden <> 1 ORDER BY cid"
+        )?;
+        let rows = stmt.query_map([table], |row| Ok((row.get(0)?, row.get(1)?, row.get(2)?)))?;
+        rows.collect()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_default_values_translation() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute(
+            "CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, status TEXT DEFAULT 'draft', \
+             added TEXT DEFAULT CURRENT_TIMESTAMP, code TEXT DEFAULT (lower(hex(randomblob(4)))))",
+            [],
+        ).unwrap();
+
+        assert_eq!(
+            InsertDefaultTranslator::translate_query("INSERT INTO books (title, status) VALUES ('X', DEFAULT)", &conn),
+            "INSERT INTO books (title, status) VALUES ('X', 'draft')"
+        );
+        assert_eq!(
+            InsertDefaultTranslator::translate_query(
+                "INSERT INTO books (id, title, code) VALUES (DEFAULT, 'default, kept', default), (7, 'Y', 'abc') RETURNING id",
+                &conn,
+            ),
+            "INSERT INTO books (id, title, code) VALUES (NULL, 'default, kept', (lower(hex(randomblob(4))))), (7, 'Y', 'abc') RETURNING id"
+        );
+        assert_eq!(
+            InsertDefaultTranslator::translate_query("INSERT INTO books VALUES (1, 'Z', DEFAULT, DEFAULT, 'c')", &conn),
+            "INSERT INTO books VALUES (1, 'Z', 'draft', CURRENT_TIMESTAMP, 'c')"
+        );
+
+        // SQLite computes generated columns itself and takes no value for them
+        conn.execute(
+            "CREATE TABLE people (id INTEGER PRIMARY KEY, first TEXT, last TEXT, \
+             full_name TEXT GENERATED ALWAYS AS (first || ' ' || last) STORED, age INTEGER DEFAULT 0)",
+            [],
+        ).unwrap();
+        assert_eq!(
+            InsertDefaultTranslator::translate_query("INSERT INTO people (first, last, full_name) VALUES ('Ada', 'Lovelace', DEFAULT)", &conn),
+            "INSERT INTO people (first, last) VALUES ('Ada', 'Lovelace')"
+        );
+        assert_eq!(
+            InsertDefaultTranslator::translate_query("INSERT INTO people VALUES (DEFAULT, 'Alan', 'Turing', DEFAULT, DEFAULT)", &conn),
+            "INSERT INTO people VALUES (NULL, 'Alan', 'Turing', 0)"
+        );
+
+        assert!(InsertDefaultTranslator::needs_translation("insert into books (title) values (default)"));
+        assert!(!InsertDefaultTranslator::needs_translation("INSERT INTO books DEFAULT VALUES"));
+        assert!(!InsertDefaultTranslator::needs_translation("CREATE TABLE t (status TEXT DEFAULT 'x')"));
+    }
+}
@@ -28,6 +28,16 @@ static INSERT_SELECT_NO_COLUMNS_PATTERN: Lazy<Regex> = Lazy::new(|| {
     Regex::new(r"(?si)INSERT\s+INTO\s+(\w+)\s+SELECT\s+(.+)").unwrap()
 });
 
+// Pattern to match a NUMERIC(p,s) or DECIMAL(p,s) column type
+static NUMERIC_TYPMOD_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*(?:NUMERIC|DECIMAL)\s*\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\)\s*$").unwrap()
+});
+
+// Pattern to match a quoted Infinity or NaN float literal
+static SPECIAL_FLOAT_PATTERN: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)'\s*[+-]?(?:inf|infinity|nan)\s*'").unwrap()
+});
+
 impl InsertTranslator {
     /// Check if the query is an INSERT that might need datetime, array, or VALUES translation
     pub fn needs_translation(query: &str) -> bool {
@@ -52,11 +62,14 @@ impl InsertTranslator {
                                    query.contains("CURRENT_TIME") ||
                                    query.contains("CURRENT_TIMESTAMP");
         
+        // Infinity and NaN float literals need their stored form
+        let has_special_float = SPECIAL_FLOAT_PATTERN.is_match(query);
+        
         // Also check for SQLAlchemy VALUES pattern
         let has_sqlalchemy_values = query.contains("FROM (VALUES") && query.contains(") AS ") && 
                                    (query.contains("imp_sen") || query.contains("(p0, p1"));
         
-        is_insert && (has_datetime_or_array || has_sqlalchemy_values)
+        is_insert && (has_datetime_or_array || has_special_float || has_sqlalchemy_values)
     }
     
     /// Translate INSERT statement to convert datetime values to INTEGER format
@@ -183,7 +196,8 @@ impl InsertTranslator {
                 eprintln!("   Columns: {columns:?}");
                 eprintln!("   Select clause: {select_clause}");
                 // Convert VALUES pattern to UNION ALL
-                Self::convert_sqlalchemy_values_to_union(select_clause, &columns, &column_types)?
+                let union = Self::convert_sqlalchemy_values_to_union(select_clause, &columns, &column_types)?;
+                format!("SELECT {union}")
             } else {
                 // Translate the SELECT clause normally
                 let converted_select = Self::translate_select_clause(
@@ -192,14 +206,15 @@ impl InsertTranslator {
                     &column_types
                 )?;
                 eprintln!("   Converted SELECT: {converted_select}");
-                converted_select
+                // Coerce the selected values to the column types
+                Self::coerce_select(&converted_select, &columns, &column_types)
             };
             
             eprintln!("   Final SELECT: {final_select}");
             
             // Reconstruct the INSERT query
             Ok(format!(
-                "INSERT INTO {table_name} ({columns_str}) SELECT {final_select}"
+                "INSERT INTO {table_name} ({columns_str}) {final_select}"
             ))
         } else if let Some(caps) = INSERT_SELECT_NO_COLUMNS_PATTERN.captures(query) {
             // Handle INSERT INTO table SELECT ... (without column list)
@@ -217,9 +232,10 @@ impl InsertTranslator {
                 &column_types
             )?;
             
-            // Reconstruct the INSERT query  
+            // Reconstruct the INSERT query, coercing the selected values to the column types
+            let select = Self::coerce_select(&converted_select, &columns_refs, &column_types);
             Ok(format!(
-                "INSERT INTO {table_name} SELECT {converted_select}"
+                "INSERT INTO {table_name} {select}"
             ))
         } else {
             // Not a recognized INSERT pattern, return as-is
@@ -494,7 +510,34 @@ impl InsertTranslator {
                     Err(e) => Err(format!("Invalid timestamp value '{unquoted}': {e}. Expected format: YYYY-MM-DD HH:MM:SS[.ffffff]"))
                 }
             }
-            "timestamptz" | "timetz" | "interval" => {
+            "interval" => {
+                // INTERVAL '1 day' is stored like the bare literal; other expressions pass through
+                let literal = match value.get(..8) {
+                    Some(keyword) if keyword.eq_ignore_ascii_case("INTERVAL") => value[8..].trim_start(),
+                    _ => value,
+                };
+                if !literal.starts_with('\'') || !literal.ends_with('\'') || literal.len() < 2 {
+                    return Ok(value.to_string());
+                }
+                let unquoted = &literal[1..literal.len() - 1];
+                match ValueConverter::convert_interval_to_text(unquoted) {
+                    Ok(stored) => Ok(format!("'{stored}'")),
+                    Err(e) => Err(format!("Invalid interval value '{unquoted}': {e}. Expected format: N years N mons N days HH:MM:SS"))
+                }
+            }
+            "real" | "float4" | "float8" | "float" | "double precision" | "numeric" | "decimal" => {
+                match crate::types::numeric_utils::special_float_literal(unquoted) {
+                    Some(literal) if value.starts_with('\'') => Ok(literal.to_string()),
+                    _ => Ok(value.to_string()),
+                }
+            }
+            // Range literals are stored in their canonical form, so '[2024-01-01,2024-01-05]'
+            // becomes '[2024-01-01,2024-01-06)' as in PostgreSQL
+            range_type if crate::functions::range_functions::RANGE_TYPES.contains(&range_type) && value.starts_with('\'') => {
+                crate::functions::range_functions::canonical_range(&unquoted.replace("''", "'"), range_type)
+                    .map(|canonical| format!("'{}'", canonical.replace('\'', "''")))
+            }
+            "timestamptz" | "timetz" => {
                 // TODO: Implement these conversions
                 // For now, keep as quoted strings
                 Ok(value.to_string())
@@ -687,6 +730,11 @@ impl InsertTranslator {
         // Parse the SELECT clause to extract individual expressions
         let expressions = Self::parse_select_expressions(select_clause)?;
         
+        // A star can't be matched to the columns by position; coerce_select still applies
+        if expressions.iter().any(|expr| expr == "*" || expr.ends_with(".*")) {
+            return Ok(select_clause.to_string());
+        }
+        
         if expressions.len() != columns.len() {
             return Err(format!(
                 "Column count mismatch in SELECT: {} columns but {} expressions", 
@@ -707,7 +755,66 @@ impl InsertTranslator {
             converted_expressions.push(converted_expr);
         }
         
-        Ok(converted_expressions.join(", "))
+        // Keep the FROM clause and everything after it
+        let rest = select_clause.find(" FROM ").map_or("", |from_pos| &select_clause[from_pos..]);
+        Ok(format!("{}{}", converted_expressions.join(", "), rest))
+    }
+    
+    /// Build the SELECT of an INSERT ... SELECT so that the selected values are stored the way
+    /// literal values would be: numeric(p,s) rounded to its scale and date/time text converted
+    /// to the INTEGER representation. The original SELECT becomes a CTE with positional column
+    /// names, so this works for any select list, stars included.
+    fn coerce_select(
+        select_clause: &str,
+        columns: &[&str],
+        column_types: &std::collections::HashMap<String, String>
+    ) -> String {
+        let upper = select_clause.to_uppercase();
+        // A trailing ON CONFLICT or RETURNING belongs to the INSERT, not to the SELECT
+        if upper.contains("ON CONFLICT") || upper.contains(" RETURNING ") {
+            return format!("SELECT {select_clause}");
+        }
+        
+        let coerced: Vec<Option<String>> = columns.iter().enumerate()
+            .map(|(i, column)| {
+                let pg_type = column_types.get(&column.to_lowercase())?;
+                Self::coerce_expression(&format!("c{}", i + 1), pg_type)
+            })
+            .collect();
+        if coerced.iter().all(Option::is_none) {
+            return format!("SELECT {select_clause}");
+        }
+        
+        let names: Vec<String> = (1..=columns.len()).map(|i| format!("c{i}")).collect();
+        let expressions: Vec<String> = coerced.into_iter().zip(&names)
+            .map(|(coerced, name)| coerced.unwrap_or_else(|| name.clone()))
+            .collect();
+        debug!("Coercing INSERT ... SELECT values: {}", expressions.join(", "));
+        
+        format!(
+            "WITH __pgsqlite_insert_src({}) AS (SELECT {}) SELECT {} FROM __pgsqlite_insert_src",
+            names.join(", "),
+            select_clause,
+            expressions.join(", ")
+        )
+    }
+    
+    /// Expression converting `column` to the storage representation of `pg_type`, or None
+    /// when values of that type are stored as they come
+    fn coerce_expression(column: &str, pg_type: &str) -> Option<String> {
+        if let Some(caps) = NUMERIC_TYPMOD_PATTERN.captures(pg_type) {
+            let scale = caps.get(2).map_or("0", |m| m.as_str());
+            return Some(format!("numeric_cast({column}, {}, {scale})", &caps[1]));
+        }
+        
+        let convert = match pg_type.to_lowercase().as_str() {
+            "date" => "pg_date_from_text",
+            "time" | "time without time zone" => "pg_time_from_text",
+            "timestamp" | "timestamp without time zone" => "pg_timestamp_from_text",
+            _ => return None,
+        };
+        // Values already in the INTEGER representation (and NULLs) pass through
+        Some(format!("CASE WHEN typeof({column}) = 'text' THEN {convert}({column}) ELSE {column} END"))
     }
     
     /// Check if this is the SQLAlchemy VALUES pattern with column aliases
@@ -1046,8 +1153,14 @@ impl InsertTranslator {
                     Err(e) => Err(format!("Invalid timestamp value '{literal}': {e}"))
                 }
             }
+            "interval" => {
+                match ValueConverter::convert_interval_to_text(literal) {
+                    Ok(stored) => Ok(format!("'{stored}'")),
+                    Err(e) => Err(format!("Invalid interval value '{literal}': {e}"))
+                }
+            }
             _ => {
-                // For other types (timestamptz, timetz, interval), keep as quoted string for now
+                // For other types (timestamptz, timetz), keep as quoted string for now
                 Ok(format!("'{literal}'"))
             }
         }
@@ -1069,13 +1182,70 @@ mod tests {
         let result = InsertTranslator::convert_value("'2024-01-15'", "date").unwrap();
         assert_eq!(result, "19737"); // Days since epoch
     }
-    
+
+    #[test]
+    fn test_convert_interval_value() {
+        assert_eq!(InsertTranslator::convert_value("'1 day 02:30:00'", "interval").unwrap(), "'1 day 02:30:00'");
+        assert_eq!(InsertTranslator::convert_value("'1 year 2 months'", "interval").unwrap(), "'1 year 2 mons'");
+        assert_eq!(InsertTranslator::convert_value("'30 days'", "interval").unwrap(), "'30 days'");
+        assert_eq!(InsertTranslator::convert_value("INTERVAL '1 day'", "interval").unwrap(), "'1 day'");
+        assert_eq!(InsertTranslator::convert_value("make_interval(0, 1)", "interval").unwrap(), "make_interval(0, 1)");
+        assert!(InsertTranslator::convert_value("'soon'", "interval").is_err());
+    }
+
     #[test]
     fn test_needs_translation() {
         assert!(InsertTranslator::needs_translation("INSERT INTO test (date_col) VALUES ('2024-01-15')"));
         assert!(InsertTranslator::needs_translation("INSERT INTO test (time_col) VALUES ('14:30:00')"));
         assert!(InsertTranslator::needs_translation("INSERT INTO test (arr_col) VALUES ('{1,2,3}')"));
     }
+
+    #[test]
+    fn test_convert_special_float_value() {
+        assert!(InsertTranslator::needs_translation("INSERT INTO test (reading) VALUES ('Infinity')"));
+        assert!(InsertTranslator::needs_translation("INSERT INTO test (reading) VALUES ('NaN')"));
+        assert_eq!(InsertTranslator::convert_value("'Infinity'", "float8").unwrap(), "9e999");
+        assert_eq!(InsertTranslator::convert_value("'-Infinity'", "DOUBLE PRECISION").unwrap(), "-9e999");
+        assert_eq!(InsertTranslator::convert_value("'NaN'", "real").unwrap(), "'NaN'");
+        assert_eq!(InsertTranslator::convert_value("1.5", "float8").unwrap(), "1.5");
+        assert_eq!(InsertTranslator::convert_value("'NaN'", "text").unwrap(), "'NaN'");
+    }
+    
+    #[test]
+    fn test_convert_range_value() {
+        assert_eq!(InsertTranslator::convert_value("'[2024-01-01,2024-01-05]'", "daterange").unwrap(), "'[2024-01-01,2024-01-06)'");
+        assert_eq!(InsertTranslator::convert_value("'(1,5]'", "INT4RANGE").unwrap(), "'[2,6)'");
+        assert_eq!(InsertTranslator::convert_value("'empty'", "daterange").unwrap(), "'empty'");
+        assert!(InsertTranslator::convert_value("'[2024-01-05,2024-01-01)'", "daterange").is_err());
+    }
+    
+    #[test]
+    fn test_coerce_select() {
+        let mut column_types = std::collections::HashMap::new();
+        column_types.insert("price".to_string(), "NUMERIC(10,2)".to_string());
+        column_types.insert("published".to_string(), "DATE".to_string());
+        column_types.insert("title".to_string(), "TEXT".to_string());
+        
+        assert_eq!(
+            InsertTranslator::coerce_select("* FROM books", &["title", "price", "published"], &column_types),
+            "WITH __pgsqlite_insert_src(c1, c2, c3) AS (SELECT * FROM books) SELECT c1, numeric_cast(c2, 10, 2), \
+             CASE WHEN typeof(c3) = 'text' THEN pg_date_from_text(c3) ELSE c3 END FROM __pgsqlite_insert_src"
+        );
+        assert_eq!(
+            InsertTranslator::coerce_select("title FROM books", &["title"], &column_types),
+            "SELECT title FROM books"
+        );
+    }
+    
+    #[test]
+    fn test_translate_select_clause_keeps_from() {
+        let mut column_types = std::collections::HashMap::new();
+        column_types.insert("published".to_string(), "date".to_string());
+        assert_eq!(
+            InsertTranslator::translate_select_clause("id, '2024-01-15' FROM books", &["id", "published"], &column_types).unwrap(),
+            "id, 19737 FROM books"
+        );
+    }
     
     #[test]
     fn test_regex_matches_multiline_insert() {
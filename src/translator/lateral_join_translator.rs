@@ -0,0 +1,233 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use sqlparser::ast::{GroupByExpr, SetExpr, Statement, TableFactor};
+use sqlparser::dialect::PostgreSqlDialect;
+use sqlparser::parser::Parser;
+use tracing::debug;
+use super::sql_scan::{in_string_literal, matching_paren};
+
+/// `[LEFT [OUTER] | INNER | CROSS] JOIN LATERAL (` or `, LATERAL (`
+static LATERAL_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)(?:\b(LEFT\s+(?:OUTER\s+)?|INNER\s+|CROSS\s+)?JOIN|,)\s*LATERAL\s*\(").unwrap()
+});
+
+/// The alias after the subquery, which PostgreSQL requires
+static ALIAS_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*(?:AS\s+)?(\w+)").unwrap()
+});
+
+static ON_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*ON\b").unwrap()
+});
+
+/// Keywords that end a join condition
+static CLAUSE_END_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^(?:LEFT|RIGHT|FULL|INNER|CROSS|NATURAL|JOIN|WHERE|GROUP|HAVING|WINDOW|ORDER|LIMIT|OFFSET|UNION|INTERSECT|EXCEPT)\b").unwrap()
+});
+
+static SELECT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?is)^\s*SELECT\s+(.*)$").unwrap()
+});
+
+/// Aggregates collapse the subquery's rows, so there is no row to join back to
+static AGGREGATE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\b(?:count|sum|avg|min|max|array_agg|string_agg|group_concat|json_agg|jsonb_agg|json_group_array|bool_and|bool_or|every)\s*\(").unwrap()
+});
+
+/// Column carrying the rowid of the subquery's table through the join
+const ROWID_COLUMN: &str = "__pgsqlite_lateral_rowid";
+
+/// Translates LATERAL subqueries in joins, which SQLite lacks.
+///
+/// A lateral subquery over a single table, typically a top-N per group like
+/// `LEFT JOIN LATERAL (SELECT ... WHERE b.author_id = a.id ORDER BY rating DESC LIMIT 1) b ON true`,
+/// becomes a join against the table's rows whose rowid is among those the correlated
+/// subquery picks for the current outer row. SQLite evaluates that subquery in the join
+/// condition, where outer columns are in scope, and a LEFT JOIN still keeps outer rows
+/// for which it picks nothing. Subqueries with aggregates, grouping, DISTINCT or joins are
+/// left as they are.
+pub struct LateralJoinTranslator;
+
+impl LateralJoinTranslator {
+    /// Check if the query joins a LATERAL subquery
+    pub fn needs_translation(query: &str) -> bool {
+        query.to_uppercase().contains("LATERAL") && LATERAL_REGEX.is_match(query)
+    }
+
+    /// Rewrite each supported LATERAL join into a join filtered on rowid
+    pub fn translate_query(query: &str) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let mut result = query.to_string();
+        let joins: Vec<_> = LATERAL_REGEX.captures_iter(query)
+            .filter(|caps| !in_string_literal(query, caps.get(0).unwrap().start()))
+            .map(|caps| (caps.get(0).unwrap().range(), caps.get(1).map(|m| m.as_str().to_uppercase())))
+            .collect();
+
+        // Work from the last join backwards so earlier offsets stay valid
+        for (range, join_type) in joins.into_iter().rev() {
+            let open = range.end - 1;
+            let Some(close) = matching_paren(&result, open) else {
+                continue;
+            };
+            let subquery = &result[open + 1..close];
+
+            let Some(alias) = ALIAS_REGEX.captures(&result[close + 1..]) else {
+                continue;
+            };
+            let alias_end = close + 1 + alias.get(0).unwrap().end();
+            let alias = alias[1].to_string();
+            // A column alias list, or a keyword where the alias should be, isn't supported
+            if alias.eq_ignore_ascii_case("ON") || result[alias_end..].trim_start().starts_with('(') {
+                continue;
+            }
+
+            let Some((projection, relation)) = Self::single_table_parts(subquery) else {
+                debug!("LATERAL subquery left untranslated: {}", subquery);
+                continue;
+            };
+            let Some(body) = SELECT_REGEX.captures(subquery).map(|caps| caps[1].to_string()) else {
+                continue;
+            };
+
+            let is_left = join_type.as_deref().is_some_and(|join| join.starts_with("LEFT"));
+            let is_inner = join_type.as_deref().is_some_and(|join| join.starts_with("INNER"))
+                || (join_type.is_none() && !result[range.start..].starts_with(','));
+            let mut end = alias_end;
+            let mut condition = None;
+            if let Some(on) = ON_REGEX.find(&result[alias_end..]) {
+                let start = alias_end + on.end();
+                let on_condition = result[start..Self::clause_end(&result, start)].trim_end();
+                end = start + on_condition.len();
+                let on_condition = on_condition.trim();
+                if !on_condition.eq_ignore_ascii_case("true") && on_condition != "1" {
+                    condition = Some(on_condition.to_string());
+                }
+            } else if is_left || is_inner {
+                // LEFT and INNER joins need their ON clause
+                continue;
+            }
+
+            // CROSS joins and the comma form become inner joins on the picked rows
+            let mut replacement = format!(
+                "{}JOIN (SELECT rowid AS {ROWID_COLUMN}, {projection} FROM {relation}) AS {alias} ON {alias}.{ROWID_COLUMN} IN \
+                 (SELECT {ROWID_COLUMN} FROM (SELECT rowid AS {ROWID_COLUMN}, {body}))",
+                if is_left { "LEFT " } else if join_type.is_none() && result[range.start..].starts_with(',') { " " } else { "" },
+            );
+            if let Some(condition) = condition {
+                replacement.push_str(&format!(" AND ({condition})"));
+            }
+
+            debug!("Translated LATERAL join: {} -> {}", &result[range.start..end], replacement);
+            result.replace_range(range.start..end, &replacement);
+        }
+
+        result
+    }
+
+    /// Projection and table of a lateral subquery that selects plain rows from one table
+    fn single_table_parts(subquery: &str) -> Option<(String, String)> {
+        let statements = Parser::parse_sql(&PostgreSqlDialect {}, subquery).ok()?;
+        let [Statement::Query(parsed)] = statements.as_slice() else {
+            return None;
+        };
+        let SetExpr::Select(select) = parsed.body.as_ref() else {
+            return None;
+        };
+        let no_grouping = matches!(&select.group_by, GroupByExpr::Expressions(exprs, _) if exprs.is_empty());
+        if parsed.with.is_some() || select.distinct.is_some() || !no_grouping || select.having.is_some() {
+            return None;
+        }
+        let [table] = select.from.as_slice() else {
+            return None;
+        };
+        if !table.joins.is_empty() || !matches!(table.relation, TableFactor::Table { .. }) {
+            return None;
+        }
+
+        let projection = select.projection.iter()
+            .map(ToString::to_string)
+            .collect::<Vec<_>>()
+            .join(", ");
+        if AGGREGATE_REGEX.is_match(&projection) {
+            return None;
+        }
+        Some((projection, table.relation.to_string()))
+    }
+
+    /// End of a join condition starting at `start`: the next clause keyword, closing
+    /// parenthesis or semicolon at the same nesting level
+    fn clause_end(query: &str, start: usize) -> usize {
+        let mut depth = 0;
+        let mut in_string = false;
+        let mut previous = ' ';
+        for (i, c) in query[start..].char_indices() {
+            let position = start + i;
+            match c {
+                '\'' => in_string = !in_string,
+                _ if in_string => {}
+                '(' => depth += 1,
+                ')' if depth == 0 => return position,
+                ')' => depth -= 1,
+                ';' if depth == 0 => return position,
+                _ if depth == 0 && !(previous.is_alphanumeric() || previous == '_' || previous == '.')
+                    && CLAUSE_END_REGEX.is_match(&query[position..]) => return position,
+                _ => {}
+            }
+            previous = c;
+        }
+        query.len()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_left_join_lateral_top_n() {
+        assert_eq!(
+            LateralJoinTranslator::translate_query(
+                "SELECT a.name, b.title FROM authors a LEFT JOIN LATERAL (SELECT title, average_rating FROM books \
+                 WHERE books.author_id = a.id ORDER BY average_rating DESC LIMIT 1) b ON true ORDER BY a.id"
+            ),
+            "SELECT a.name, b.title FROM authors a LEFT JOIN (SELECT rowid AS __pgsqlite_lateral_rowid, title, average_rating \
+             FROM books) AS b ON b.__pgsqlite_lateral_rowid IN (SELECT __pgsqlite_lateral_rowid FROM (SELECT rowid AS \
+             __pgsqlite_lateral_rowid, title, average_rating FROM books WHERE books.author_id = a.id ORDER BY average_rating \
+             DESC LIMIT 1)) ORDER BY a.id"
+        );
+    }
+
+    #[test]
+    fn test_cross_and_conditional_lateral_joins() {
+        assert_eq!(
+            LateralJoinTranslator::translate_query(
+                "SELECT u.id, o.total FROM users u CROSS JOIN LATERAL (SELECT total FROM orders o2 WHERE o2.user_id = u.id LIMIT 2) o"
+            ),
+            "SELECT u.id, o.total FROM users u JOIN (SELECT rowid AS __pgsqlite_lateral_rowid, total FROM orders AS o2) AS o \
+             ON o.__pgsqlite_lateral_rowid IN (SELECT __pgsqlite_lateral_rowid FROM (SELECT rowid AS __pgsqlite_lateral_rowid, \
+             total FROM orders o2 WHERE o2.user_id = u.id LIMIT 2))"
+        );
+        assert_eq!(
+            LateralJoinTranslator::translate_query(
+                "SELECT * FROM t JOIN LATERAL (SELECT v FROM s WHERE s.k = t.k) x ON x.v > 1 WHERE t.k > 0"
+            ),
+            "SELECT * FROM t JOIN (SELECT rowid AS __pgsqlite_lateral_rowid, v FROM s) AS x ON x.__pgsqlite_lateral_rowid IN \
+             (SELECT __pgsqlite_lateral_rowid FROM (SELECT rowid AS __pgsqlite_lateral_rowid, v FROM s WHERE s.k = t.k)) \
+             AND (x.v > 1) WHERE t.k > 0"
+        );
+    }
+
+    #[test]
+    fn test_unsupported_lateral_subqueries() {
+        for query in [
+            "SELECT * FROM a LEFT JOIN LATERAL (SELECT count(*) AS n FROM b WHERE b.a_id = a.id) c ON true",
+            "SELECT * FROM a LEFT JOIN LATERAL (SELECT b.x FROM b JOIN d ON d.id = b.d_id WHERE b.a_id = a.id) c ON true",
+            "SELECT 'JOIN LATERAL (SELECT 1) x ON true'",
+        ] {
+            assert_eq!(LateralJoinTranslator::translate_query(query), query);
+        }
+    }
+}
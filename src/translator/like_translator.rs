@@ -0,0 +1,306 @@
+use sqlparser::ast::{Expr, BinaryOperator, UnaryOperator, Function, FunctionArg, FunctionArgExpr, FunctionArguments, FunctionArgumentList, ObjectName, ObjectNamePart, Ident, Statement, Query, SetExpr, Select, SelectItem, Value};
+use sqlparser::dialect::PostgreSqlDialect;
+use sqlparser::parser::Parser;
+use crate::PgSqliteError;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::{debug, trace};
+
+/// An empty ESCAPE clause, which turns escaping off in PostgreSQL but is an error in SQLite
+static EMPTY_ESCAPE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bESCAPE\s*''(?:[^']|$)").unwrap()
+});
+
+/// The ILIKE operator, as a word of its own
+static ILIKE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bILIKE\b").unwrap()
+});
+
+/// Translates PostgreSQL pattern operators (~~, !~~, ~~*, !~~*) and ILIKE to
+/// the pg_like / pg_ilike SQLite functions. LIKE stays SQLite's LIKE, whose ESCAPE
+/// clause works the same way, except that an empty escape string is dropped.
+pub struct LikeTranslator;
+
+impl LikeTranslator {
+    /// Translate a query containing LIKE operator forms to SQLite-compatible syntax
+    pub fn translate_query(query: &str) -> Result<String, PgSqliteError> {
+        if !Self::needs_translation(query) {
+            return Ok(query.to_string());
+        }
+
+        debug!("Translating LIKE operators in query: {}", query);
+
+        let dialect = PostgreSqlDialect {};
+        let mut statements = Parser::parse_sql(&dialect, query)?;
+
+        for statement in &mut statements {
+            Self::translate_statement(statement);
+        }
+
+        let result = statements.iter()
+            .map(|s| s.to_string())
+            .collect::<Vec<_>>()
+            .join("; ");
+
+        debug!("Translated query: {}", result);
+        Ok(result)
+    }
+
+    /// Quick check if query contains ~~ operators, the ILIKE operator or an empty ESCAPE
+    pub fn needs_translation(query: &str) -> bool {
+        query.contains("~~") || Self::has_ilike(query) || EMPTY_ESCAPE_REGEX.is_match(query)
+    }
+
+    /// Whether ILIKE appears as an operator, not as part of a name or inside a string literal
+    fn has_ilike(query: &str) -> bool {
+        ILIKE_REGEX.find_iter(query)
+            .any(|m| query[..m.start()].matches('\'').count() % 2 == 0)
+    }
+
+    /// Translate a statement
+    fn translate_statement(statement: &mut Statement) {
+        match statement {
+            Statement::Query(query) => Self::translate_query_box(query),
+            Statement::Update { selection: Some(selection), .. } => Self::translate_expression(selection),
+            Statement::Delete(delete) => {
+                if let Some(selection) = &mut delete.selection {
+                    Self::translate_expression(selection);
+                }
+            }
+            Statement::Insert(insert) => {
+                if let Some(source) = &mut insert.source {
+                    Self::translate_query_box(source);
+                }
+            }
+            _ => {}
+        }
+    }
+
+    /// Translate a boxed query
+    fn translate_query_box(query: &mut Box<Query>) {
+        Self::translate_set_expr(&mut query.body);
+    }
+
+    /// Translate the body of a query, including both sides of set operations
+    fn translate_set_expr(set_expr: &mut SetExpr) {
+        match set_expr {
+            SetExpr::Select(select) => Self::translate_select(select),
+            SetExpr::Query(query) => Self::translate_query_box(query),
+            SetExpr::SetOperation { left, right, .. } => {
+                Self::translate_set_expr(left);
+                Self::translate_set_expr(right);
+            }
+            _ => {}
+        }
+    }
+
+    /// Translate a SELECT statement
+    fn translate_select(select: &mut Box<Select>) {
+        if let Some(selection) = &mut select.selection {
+            Self::translate_expression(selection);
+        }
+
+        for projection in &mut select.projection {
+            match projection {
+                SelectItem::UnnamedExpr(expr) |
+                SelectItem::ExprWithAlias { expr, .. } => Self::translate_expression(expr),
+                _ => {}
+            }
+        }
+
+        if let Some(having) = &mut select.having {
+            Self::translate_expression(having);
+        }
+    }
+
+    /// Translate an expression, converting pattern operators to pg_like / pg_ilike calls
+    fn translate_expression(expr: &mut Expr) {
+        match expr {
+            Expr::BinaryOp { left, op, right } => {
+                Self::translate_expression(left);
+                Self::translate_expression(right);
+
+                let (function, negated) = match op {
+                    BinaryOperator::PGLikeMatch => ("pg_like", false),
+                    BinaryOperator::PGNotLikeMatch => ("pg_like", true),
+                    BinaryOperator::PGILikeMatch => ("pg_ilike", false),
+                    BinaryOperator::PGNotILikeMatch => ("pg_ilike", true),
+                    _ => return,
+                };
+                trace!("Translating {} operator", op);
+                *expr = Self::create_like_function(function, left.as_ref().clone(), right.as_ref().clone(), None, negated);
+            }
+            Expr::ILike { negated, any: false, expr: inner, pattern, escape_char } => {
+                Self::translate_expression(inner);
+                Self::translate_expression(pattern);
+
+                trace!("Translating ILIKE expression");
+                // Escape characters print as quoted literals; keep only their content
+                let escape = escape_char.as_ref().map(|escape| {
+                    let escape = escape.to_string();
+                    let escape = escape.strip_prefix('\'').and_then(|e| e.strip_suffix('\'')).unwrap_or(&escape).to_string();
+                    Expr::Value(Value::SingleQuotedString(escape).into())
+                });
+                *expr = Self::create_like_function("pg_ilike", inner.as_ref().clone(), pattern.as_ref().clone(), escape, *negated);
+            }
+            Expr::Like { expr: inner, pattern, escape_char, .. } => {
+                Self::translate_expression(inner);
+                Self::translate_expression(pattern);
+
+                // Without an escape character SQLite's LIKE matches backslashes literally
+                if escape_char.as_ref().is_some_and(|escape| matches!(escape.to_string().as_str(), "" | "''")) {
+                    *escape_char = None;
+                }
+            }
+            Expr::Nested(nested) => Self::translate_expression(nested),
+            Expr::UnaryOp { expr: inner, .. } => Self::translate_expression(inner),
+            Expr::Cast { expr: inner, .. } => Self::translate_expression(inner),
+            Expr::IsNull(inner) | Expr::IsNotNull(inner) |
+            Expr::IsTrue(inner) | Expr::IsFalse(inner) => Self::translate_expression(inner),
+            Expr::Case { operand, conditions, else_result, .. } => {
+                if let Some(op) = operand {
+                    Self::translate_expression(op);
+                }
+                for condition in conditions {
+                    Self::translate_expression(&mut condition.condition);
+                    Self::translate_expression(&mut condition.result);
+                }
+                if let Some(else_expr) = else_result {
+                    Self::translate_expression(else_expr);
+                }
+            }
+            Expr::InList { expr: inner, list, .. } => {
+                Self::translate_expression(inner);
+                for item in list {
+                    Self::translate_expression(item);
+                }
+            }
+            Expr::InSubquery { expr: inner, subquery, .. } => {
+                Self::translate_expression(inner);
+                Self::translate_set_expr(subquery);
+            }
+            Expr::Exists { subquery, .. } | Expr::Subquery(subquery) => Self::translate_query_box(subquery),
+            Expr::Between { expr: inner, low, high, .. } => {
+                Self::translate_expression(inner);
+                Self::translate_expression(low);
+                Self::translate_expression(high);
+            }
+            Expr::Function(func) => {
+                if let FunctionArguments::List(arg_list) = &mut func.args {
+                    for arg in &mut arg_list.args {
+                        if let FunctionArg::Unnamed(FunctionArgExpr::Expr(e)) |
+                               FunctionArg::Named { arg: FunctionArgExpr::Expr(e), .. } = arg {
+                            Self::translate_expression(e);
+                        }
+                    }
+                }
+            }
+            _ => {}
+        }
+    }
+
+    /// Create a pg_like / pg_ilike function call, optionally wrapped in NOT
+    fn create_like_function(name: &str, text: Expr, pattern: Expr, escape: Option<Expr>, negate: bool) -> Expr {
+        let mut args = vec![
+            FunctionArg::Unnamed(FunctionArgExpr::Expr(text)),
+            FunctionArg::Unnamed(FunctionArgExpr::Expr(pattern)),
+        ];
+        if let Some(escape) = escape {
+            args.push(FunctionArg::Unnamed(FunctionArgExpr::Expr(escape)));
+        }
+
+        let call = Expr::Function(Function {
+            name: ObjectName(vec![ObjectNamePart::Identifier(Ident::new(name))]),
+            args: FunctionArguments::List(FunctionArgumentList {
+                args,
+                duplicate_treatment: None,
+                clauses: vec![],
+            }),
+            filter: None,
+            null_treatment: None,
+            over: None,
+            within_group: vec![],
+            parameters: FunctionArguments::None,
+            uses_odbc_syntax: false,
+        });
+
+        if negate {
+            Expr::UnaryOp {
+                op: UnaryOperator::Not,
+                expr: Box::new(call),
+            }
+        } else {
+            call
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_like_operator() {
+        let result = LikeTranslator::translate_query("SELECT * FROM users WHERE name ~~ 'J%'").unwrap();
+        assert_eq!(result, "SELECT * FROM users WHERE pg_like(name, 'J%')");
+    }
+
+    #[test]
+    fn test_not_like_operator() {
+        let result = LikeTranslator::translate_query("SELECT * FROM users WHERE name !~~ 'J%'").unwrap();
+        assert_eq!(result, "SELECT * FROM users WHERE NOT pg_like(name, 'J%')");
+    }
+
+    #[test]
+    fn test_ilike_operators() {
+        let result = LikeTranslator::translate_query("SELECT * FROM users WHERE name ~~* 'j%'").unwrap();
+        assert_eq!(result, "SELECT * FROM users WHERE pg_ilike(name, 'j%')");
+
+        let result = LikeTranslator::translate_query("SELECT * FROM users WHERE name !~~* 'j%'").unwrap();
+        assert_eq!(result, "SELECT * FROM users WHERE NOT pg_ilike(name, 'j%')");
+    }
+
+    #[test]
+    fn test_ilike_keyword_with_escape() {
+        let result = LikeTranslator::translate_query("SELECT * FROM t WHERE code NOT ILIKE 'a!_%' ESCAPE '!'").unwrap();
+        assert_eq!(result, "SELECT * FROM t WHERE NOT pg_ilike(code, 'a!_%', '!')");
+    }
+
+    #[test]
+    fn test_like_escape() {
+        // SQLite's LIKE supports ESCAPE, so the clause is kept
+        let query = r"SELECT * FROM t WHERE code LIKE '100\%%' ESCAPE '\'";
+        assert_eq!(LikeTranslator::translate_query(query).unwrap(), query);
+
+        let result = LikeTranslator::translate_query(r"SELECT * FROM t WHERE code NOT LIKE 'a\_%' ESCAPE ''").unwrap();
+        assert_eq!(result, r"SELECT * FROM t WHERE code NOT LIKE 'a\_%'");
+
+        let result = LikeTranslator::translate_query("SELECT * FROM t WHERE code ILIKE '50#%' ESCAPE '#' AND name LIKE 'x#_%' ESCAPE '#'").unwrap();
+        assert_eq!(result, "SELECT * FROM t WHERE pg_ilike(code, '50#%', '#') AND name LIKE 'x#_%' ESCAPE '#'");
+    }
+
+    #[test]
+    fn test_update_and_union() {
+        let result = LikeTranslator::translate_query("UPDATE t SET flag = 1 WHERE name ~~ 'x%'").unwrap();
+        assert!(result.contains("WHERE pg_like(name, 'x%')"));
+
+        let result = LikeTranslator::translate_query("SELECT a FROM t WHERE a ~~ 'x' UNION SELECT a FROM u WHERE a !~~* 'y'").unwrap();
+        assert!(result.contains("pg_like(a, 'x')"));
+        assert!(result.contains("NOT pg_ilike(a, 'y')"));
+    }
+
+    #[test]
+    fn test_no_like_operators() {
+        let query = "SELECT * FROM users WHERE name LIKE 'J%'";
+        assert_eq!(LikeTranslator::translate_query(query).unwrap(), query);
+
+        for query in [
+            "SELECT similike_score FROM users",
+            "SELECT * FROM users WHERE note = 'try ilike here'",
+            "INSERT INTO notes (body) VALUES ('a ILIKE b')",
+        ] {
+            assert!(!LikeTranslator::needs_translation(query), "{query}");
+        }
+        assert!(LikeTranslator::needs_translation("SELECT * FROM users WHERE name ilike 'j%'"));
+    }
+}
@@ -0,0 +1,117 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::{in_string_literal, matching_paren, split_top_level};
+
+/// The start of a make_interval() call
+static MAKE_INTERVAL_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bMAKE_INTERVAL\s*\(").unwrap()
+});
+
+/// make_interval()'s parameters in order, each defaulting to 0
+const FIELDS: [&str; 7] = ["years", "months", "weeks", "days", "hours", "mins", "secs"];
+
+/// Translates `make_interval(days => 5, hours => 2)` to `make_interval(0, 0, 0, 5, 2, 0, 0)`.
+///
+/// SQLite has no named arguments, so the fields named with `=>` are put at their
+/// position and the ones left out get their default of 0.
+pub struct MakeIntervalTranslator;
+
+impl MakeIntervalTranslator {
+    /// Check if the query calls make_interval() with named arguments
+    pub fn needs_translation(query: &str) -> bool {
+        query.contains("=>") && MAKE_INTERVAL_REGEX.is_match(query) && Self::next_call(query).is_some()
+    }
+
+    /// Rewrite each make_interval() call with named arguments as a positional one
+    pub fn translate_query(query: &str) -> String {
+        if !query.contains("=>") || !MAKE_INTERVAL_REGEX.is_match(query) {
+            return query.to_string();
+        }
+
+        // Calls nested in the arguments are found again on the next pass
+        let mut result = query.to_string();
+        while let Some((range, replacement)) = Self::next_call(&result) {
+            result.replace_range(range, &replacement);
+        }
+
+        if result != query {
+            debug!("Translated make_interval: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// Find the first make_interval() call naming an argument, with its translation
+    fn next_call(sql: &str) -> Option<(std::ops::Range<usize>, String)> {
+        for m in MAKE_INTERVAL_REGEX.find_iter(sql) {
+            if in_string_literal(sql, m.start()) {
+                continue;
+            }
+            let open = m.end() - 1;
+            let Some(close) = matching_paren(sql, open) else {
+                continue;
+            };
+            if let Some(args) = Self::positional_arguments(&sql[open + 1..close]) {
+                return Some((m.start()..close + 1, format!("make_interval({})", args.join(", "))));
+            }
+        }
+        None
+    }
+
+    /// The arguments in parameter order, if some are named. Positional arguments come
+    /// first as in PostgreSQL; unknown or repeated names leave the call alone.
+    fn positional_arguments(args: &str) -> Option<Vec<String>> {
+        let mut values: [Option<String>; 7] = Default::default();
+        let mut named = false;
+        for (i, arg) in split_top_level(args).into_iter().enumerate() {
+            match arg.split_once("=>") {
+                Some((name, value)) => {
+                    let index = FIELDS.iter().position(|field| field.eq_ignore_ascii_case(name.trim()))?;
+                    if values[index].is_some() {
+                        return None;
+                    }
+                    values[index] = Some(value.trim().to_string());
+                    named = true;
+                }
+                None if !named && i < FIELDS.len() => values[i] = Some(arg.trim().to_string()),
+                None => return None,
+            }
+        }
+        named.then(|| values.into_iter().map(|value| value.unwrap_or_else(|| "0".to_string())).collect())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_named_arguments() {
+        assert_eq!(
+            MakeIntervalTranslator::translate_query("SELECT make_interval(days => 5)"),
+            "SELECT make_interval(0, 0, 0, 5, 0, 0, 0)"
+        );
+        assert_eq!(
+            MakeIntervalTranslator::translate_query("SELECT created_at + MAKE_INTERVAL(hours => $1, secs=>1.5) FROM jobs"),
+            "SELECT created_at + make_interval(0, 0, 0, 0, $1, 0, 1.5) FROM jobs"
+        );
+        assert_eq!(
+            MakeIntervalTranslator::translate_query("SELECT make_interval(1, 2, days => abs(-3))"),
+            "SELECT make_interval(1, 2, 0, abs(-3), 0, 0, 0)"
+        );
+    }
+
+    #[test]
+    fn test_other_calls_unchanged() {
+        for query in [
+            "SELECT make_interval(1, 2, 3)",
+            "SELECT make_interval(days => 1, days => 2)",
+            "SELECT make_interval(fortnights => 1)",
+            "SELECT make_interval(days => 1, 2)",
+            "SELECT 'make_interval(days => 5)'",
+        ] {
+            assert!(!MakeIntervalTranslator::needs_translation(query), "{query}");
+            assert_eq!(MakeIntervalTranslator::translate_query(query), query);
+        }
+    }
+}
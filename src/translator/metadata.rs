@@ -51,6 +51,10 @@ pub enum ExpressionType {
     StringConcatenation,
     /// Type cast expression
     TypeCast,
+    /// sum() of a column, typed from the column
+    Sum,
+    /// avg() of a column, typed from the column
+    Avg,
     /// Other/unknown expression type
     Other,
 }
@@ -134,6 +138,36 @@ impl ColumnTypeHint {
         }
     }
     
+    /// Create a hint for sum() or avg() over a column. NUMERIC stands in when the
+    /// argument isn't a plain column whose type can be looked up.
+    pub fn aggregate(source: Option<String>, expr_type: ExpressionType) -> Self {
+        Self {
+            source_column: source,
+            suggested_type: Some(PgType::Numeric),
+            datetime_subtype: None,
+            is_expression: true,
+            expression_type: Some(expr_type),
+        }
+    }
+    
+    /// The result type of this hint's sum() or avg() over a column of `source_type`,
+    /// as PostgreSQL types it: sum(int4) is int8, sum(int8) numeric, avg() of any
+    /// integer numeric, and both keep float8 for floats
+    pub fn aggregate_type(&self, source_type: PgType) -> Option<PgType> {
+        let sum = match self.expression_type? {
+            ExpressionType::Sum => true,
+            ExpressionType::Avg => false,
+            _ => return None,
+        };
+        Some(match source_type {
+            PgType::Int2 | PgType::Int4 if sum => PgType::Int8,
+            PgType::Int2 | PgType::Int4 | PgType::Int8 | PgType::Numeric => PgType::Numeric,
+            PgType::Float4 if sum => PgType::Float4,
+            PgType::Float4 | PgType::Float8 => PgType::Float8,
+            other => other,
+        })
+    }
+    
     /// Create a hint for arithmetic on datetime
     pub fn datetime_arithmetic(source: String, pg_type: PgType, datetime_subtype: DateTimeSubtype) -> Self {
         Self {
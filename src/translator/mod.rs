@@ -10,13 +10,17 @@ mod simd_search;
 mod datetime_translator;
 mod metadata;
 mod arithmetic_analyzer;
+mod window_function_analyzer;
+mod case_expression_analyzer;
 mod insert_translator;
 mod regex_translator;
+mod like_translator;
 mod schema_prefix_translator;
 mod numeric_format_translator;
 mod numeric_cast_translator;
 mod array_translator;
 mod array_agg_translator;
+mod distinct_aggregate_translator;
 mod unnest_translator;
 mod json_each_translator;
 mod row_to_json_translator;
@@ -28,6 +32,31 @@ mod function_parentheses_translator;
 mod catalog_function_translator;
 mod pg_table_is_visible_translator;
 mod session_identifier_translator;
+mod pg_typeof_translator;
+mod regexp_matches_translator;
+mod range_predicate_translator;
+mod date_comparison_translator;
+mod values_translator;
+mod tablesample_translator;
+mod table_statement_translator;
+mod fetch_first_translator;
+mod lateral_join_translator;
+mod range_translator;
+mod point_translator;
+mod bit_string_translator;
+mod division_translator;
+mod only_translator;
+mod group_by_translator;
+mod insert_default_translator;
+mod distinct_from_translator;
+mod identity_insert_translator;
+mod ts_match_translator;
+mod collate_translator;
+mod row_comparison_translator;
+mod substring_translator;
+mod position_translator;
+mod make_interval_translator;
+pub mod sql_scan;
 
 pub use json_translator::JsonTranslator;
 pub use returning_translator::ReturningTranslator;
@@ -38,14 +67,18 @@ pub use cast_translator::CastTranslator;
 pub use simd_search::SimdCastSearch;
 pub use datetime_translator::DateTimeTranslator;
 pub use arithmetic_analyzer::ArithmeticAnalyzer;
+pub use window_function_analyzer::WindowFunctionAnalyzer;
+pub use case_expression_analyzer::CaseExpressionAnalyzer;
 pub use metadata::{TranslationMetadata, ColumnTypeHint, ExpressionType, DateTimeSubtype};
 pub use insert_translator::InsertTranslator;
 pub use regex_translator::RegexTranslator;
+pub use like_translator::LikeTranslator;
 pub use schema_prefix_translator::SchemaPrefixTranslator;
 pub use numeric_format_translator::NumericFormatTranslator;
 pub use numeric_cast_translator::NumericCastTranslator;
 pub use array_translator::ArrayTranslator;
 pub use array_agg_translator::ArrayAggTranslator;
+pub use distinct_aggregate_translator::DistinctAggregateTranslator;
 pub use unnest_translator::UnnestTranslator;
 pub use json_each_translator::JsonEachTranslator;
 pub use row_to_json_translator::RowToJsonTranslator;
@@ -56,4 +89,28 @@ pub use query_analyzer::{QueryAnalyzer, TranslationFlags};
 pub use function_parentheses_translator::FunctionParenthesesTranslator;
 pub use catalog_function_translator::CatalogFunctionTranslator;
 pub use pg_table_is_visible_translator::PgTableIsVisibleTranslator;
-pub use session_identifier_translator::SessionIdentifierTranslator;
\ No newline at end of file
+pub use session_identifier_translator::SessionIdentifierTranslator;
+pub use pg_typeof_translator::PgTypeofTranslator;
+pub use regexp_matches_translator::RegexpMatchesTranslator;
+pub use range_predicate_translator::RangePredicateTranslator;
+pub use date_comparison_translator::DateComparisonTranslator;
+pub use values_translator::ValuesTranslator;
+pub use tablesample_translator::TablesampleTranslator;
+pub use table_statement_translator::TableStatementTranslator;
+pub use fetch_first_translator::FetchFirstTranslator;
+pub use lateral_join_translator::LateralJoinTranslator;
+pub use range_translator::RangeTranslator;
+pub use point_translator::PointTranslator;
+pub use bit_string_translator::BitStringTranslator;
+pub use division_translator::DivisionTranslator;
+pub use only_translator::OnlyTranslator;
+pub use group_by_translator::GroupByTranslator;
+pub use insert_default_translator::InsertDefaultTranslator;
+pub use distinct_from_translator::DistinctFromTranslator;
+pub use identity_insert_translator::IdentityInsertTranslator;
+pub use ts_match_translator::TsMatchTranslator;
+pub use collate_translator::CollateTranslator;
+pub use row_comparison_translator::RowComparisonTranslator;
+pub use substring_translator::SubstringTranslator;
+pub use position_translator::PositionTranslator;
+pub use make_interval_translator::MakeIntervalTranslator;
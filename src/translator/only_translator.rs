@@ -0,0 +1,74 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::in_string_literal;
+
+/// `FROM ONLY t`, `JOIN ONLY t` and `UPDATE ONLY t`
+static ONLY_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\b(FROM|JOIN|UPDATE)\s+ONLY\b\s*").unwrap()
+});
+
+/// Removes the ONLY keyword in front of table references.
+///
+/// ONLY keeps PostgreSQL from scanning the tables that inherit from the named one. SQLite
+/// has no inheritance (INHERITS copies the parent's columns into an independent table), so
+/// a table never has rows of its children and ONLY changes nothing.
+pub struct OnlyTranslator;
+
+impl OnlyTranslator {
+    /// Check if the query names a table with ONLY
+    pub fn needs_translation(query: &str) -> bool {
+        ONLY_REGEX.is_match(query)
+    }
+
+    /// Drop ONLY from the table references of the query
+    pub fn translate_query(query: &str) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let mut result = query.to_string();
+        let matches: Vec<_> = ONLY_REGEX.captures_iter(query)
+            .filter(|caps| !in_string_literal(query, caps.get(0).unwrap().start()))
+            .map(|caps| (caps.get(0).unwrap().range(), format!("{} ", &caps[1])))
+            .collect();
+
+        // Work from the last match backwards so earlier offsets stay valid
+        for (range, replacement) in matches.into_iter().rev() {
+            result.replace_range(range, &replacement);
+        }
+
+        if result != query {
+            debug!("Removed ONLY from table references: {} -> {}", query, result);
+        }
+        result
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_only_translation() {
+        let cases = [
+            ("SELECT * FROM ONLY books", "SELECT * FROM books"),
+            ("select b.id from only public.books b join only authors a on a.id = b.author_id",
+             "select b.id from public.books b join authors a on a.id = b.author_id"),
+            ("UPDATE ONLY books SET title = 'x' WHERE id = 1", "UPDATE books SET title = 'x' WHERE id = 1"),
+            ("DELETE FROM ONLY books WHERE id = 1", "DELETE FROM books WHERE id = 1"),
+        ];
+        for (query, expected) in cases {
+            assert_eq!(OnlyTranslator::translate_query(query), expected);
+        }
+
+        // Not table references
+        for query in [
+            "SELECT * FROM books ORDER BY id FETCH FIRST 1 ROWS ONLY",
+            "SELECT * FROM only_books",
+            "SELECT 'FROM ONLY x' FROM books",
+        ] {
+            assert_eq!(OnlyTranslator::translate_query(query), query);
+        }
+    }
+}
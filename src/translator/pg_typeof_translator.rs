@@ -0,0 +1,150 @@
+use rusqlite::Connection;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use crate::catalog::system_functions::SystemFunctions;
+use crate::types::SchemaTypeMapper;
+use super::sql_scan::matching_paren;
+
+static PG_TYPEOF_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bpg_typeof\s*\(").unwrap()
+});
+
+static TABLE_REF_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\b(?:FROM|JOIN)\s+(\w+)(?:\s+(?:AS\s+)?(\w+))?").unwrap()
+});
+
+static COLUMN_REF_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"^(?:(\w+)\.)?([A-Za-z_]\w*)$").unwrap()
+});
+
+/// Replaces pg_typeof(expr) with the PostgreSQL type name of the expression
+pub struct PgTypeofTranslator;
+
+impl PgTypeofTranslator {
+    /// Check if the query calls pg_typeof
+    pub fn needs_translation(query: &str) -> bool {
+        query.to_lowercase().contains("pg_typeof")
+    }
+
+    /// Translate pg_typeof calls into type name literals
+    pub fn translate_query(query: &str, conn: &Connection) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let tables = Self::extract_table_refs(query);
+        let mut result = query.to_string();
+
+        // Replace from the end so earlier match positions stay valid
+        let matches: Vec<_> = PG_TYPEOF_REGEX.find_iter(query).collect();
+        for m in matches.into_iter().rev() {
+            let Some(close) = matching_paren(query, m.end() - 1) else {
+                continue;
+            };
+
+            let argument = query[m.end()..close].trim();
+            let type_name = Self::infer_type_name(argument, &tables, conn);
+            result.replace_range(m.start()..close + 1, &format!("'{type_name}'"));
+        }
+
+        result
+    }
+
+    /// Determine the PostgreSQL type name for a pg_typeof argument
+    fn infer_type_name(argument: &str, tables: &[(String, Option<String>)], conn: &Connection) -> String {
+        // Untyped string literals are reported as unknown, as in PostgreSQL
+        if argument.len() >= 2 && argument.starts_with('\'') && argument.ends_with('\'') && !argument[1..argument.len() - 1].contains('\'') {
+            return "unknown".to_string();
+        }
+
+        if let Some(oid) = Self::resolve_column_type(argument, tables, conn) {
+            return SystemFunctions::format_type_name(oid, None);
+        }
+
+        if let Some(types) = SchemaTypeMapper::infer_fromless_select_types(&format!("SELECT {argument}"))
+            && let Some(Some(oid)) = types.first() {
+                return SystemFunctions::format_type_name(*oid, None);
+            }
+
+        "text".to_string()
+    }
+
+    /// Look up a (possibly qualified) column reference in the tables the query reads from
+    fn resolve_column_type(argument: &str, tables: &[(String, Option<String>)], conn: &Connection) -> Option<i32> {
+        let caps = COLUMN_REF_REGEX.captures(argument)?;
+        let qualifier = caps.get(1).map(|m| m.as_str());
+        let column = caps.get(2)?.as_str();
+
+        if matches!(column.to_lowercase().as_str(), "true" | "false" | "null") {
+            return None;
+        }
+
+        tables.iter()
+            .filter(|(table, alias)| match qualifier {
+                Some(q) => table.eq_ignore_ascii_case(q) || alias.as_deref().is_some_and(|a| a.eq_ignore_ascii_case(q)),
+                None => true,
+            })
+            .find_map(|(table, _)| {
+                // Skip tables that don't have the column so unqualified references pick the right one
+                Self::column_exists(conn, table, column)
+                    .then(|| SchemaTypeMapper::get_type_from_schema(conn, table, column))
+                    .flatten()
+            })
+    }
+
+    fn column_exists(conn: &Connection, table: &str, column: &str) -> bool {
+        conn.query_row(
+            "SELECT COUNT(*) > 0 FROM pragma_table_info(?1) WHERE name = ?2",
+            [table, column],
+            |row| row.get(0),
+        ).unwrap_or(false)
+    }
+
+    /// Collect (table, alias) pairs from FROM and JOIN clauses
+    pub(crate) fn extract_table_refs(query: &str) -> Vec<(String, Option<String>)> {
+        const KEYWORDS: &[&str] = &[
+            "where", "join", "inner", "left", "right", "full", "cross", "on", "group",
+            "order", "limit", "offset", "having", "union", "natural", "using",
+        ];
+
+        TABLE_REF_REGEX.captures_iter(query)
+            .map(|caps| {
+                let table = caps[1].to_string();
+                let alias = caps.get(2)
+                    .map(|m| m.as_str())
+                    .filter(|a| !KEYWORDS.contains(&a.to_lowercase().as_str()))
+                    .map(str::to_string);
+                (table, alias)
+            })
+            .collect()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_pg_typeof_translation() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute("CREATE TABLE items (id INTEGER PRIMARY KEY, price DECIMAL, label TEXT)", []).unwrap();
+        conn.execute("CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT)", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('items', 'price', 'NUMERIC(10,2)', 'DECIMAL')", []).unwrap();
+
+        assert_eq!(
+            PgTypeofTranslator::translate_query("SELECT pg_typeof(price), pg_typeof(i.label) FROM items i", &conn),
+            "SELECT 'numeric', 'text' FROM items i"
+        );
+        assert_eq!(
+            PgTypeofTranslator::translate_query("SELECT pg_typeof(42), pg_typeof(3000000000), pg_typeof(1.5)", &conn),
+            "SELECT 'integer', 'bigint', 'numeric'"
+        );
+        assert_eq!(
+            PgTypeofTranslator::translate_query("SELECT pg_typeof('a'), pg_typeof('a'::uuid), pg_typeof(now())", &conn),
+            "SELECT 'unknown', 'uuid', 'timestamp with time zone'"
+        );
+
+        let query = "SELECT id FROM items";
+        assert_eq!(PgTypeofTranslator::translate_query(query, &conn), query);
+    }
+}
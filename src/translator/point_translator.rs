@@ -0,0 +1,77 @@
+use regex::{Captures, Regex};
+use once_cell::sync::Lazy;
+use tracing::debug;
+
+static POINT_CAST_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)('(?:[^']|'')*')\s*::\s*point\b").unwrap()
+});
+
+/// A function call, a string literal or a column reference on either side of `<->`;
+/// string literals not next to the operator are matched whole so that text inside them
+/// (like to_tsquery('a <-> b')) is left alone
+static DISTANCE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    const OPERAND: &str = r"(?:\w+\s*\((?:'(?:[^']|'')*'|[^()'])*\)|'(?:[^']|'')*'|\w+(?:\.\w+)?)";
+    Regex::new(&format!(r"({OPERAND})\s*<->\s*({OPERAND})|'(?:[^']|'')*'")).unwrap()
+});
+
+/// Points are stored as their `(x,y)` text, so the `<->` distance operator becomes a call
+/// to point_distance() and `'(1,2)'::point` a call to point(), which normalizes the text.
+/// ORDER BY location <-> point(...) thus sorts by distance, without index acceleration.
+pub struct PointTranslator;
+
+impl PointTranslator {
+    /// Check if the query uses the distance operator or casts a literal to point
+    pub fn needs_translation(query: &str) -> bool {
+        query.contains("<->") || (query.contains("::") && POINT_CAST_REGEX.is_match(query))
+    }
+
+    /// Translate point casts and the distance operator
+    pub fn translate_query(query: &str) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let result = POINT_CAST_REGEX.replace_all(query, "point($1)");
+        let result = DISTANCE_REGEX.replace_all(&result, |caps: &Captures| {
+            match (caps.get(1), caps.get(2)) {
+                (Some(left), Some(right)) => format!("point_distance({}, {})", left.as_str(), right.as_str()),
+                _ => caps[0].to_string(),
+            }
+        });
+
+        if result != query {
+            debug!("Translated point operators: {} -> {}", query, result);
+        }
+        result.into_owned()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_distance_operator() {
+        assert_eq!(
+            PointTranslator::translate_query("SELECT name FROM stores ORDER BY location <-> point(3, 4) LIMIT 2"),
+            "SELECT name FROM stores ORDER BY point_distance(location, point(3, 4)) LIMIT 2"
+        );
+        assert_eq!(
+            PointTranslator::translate_query("SELECT s.location <-> '(1,1)'::point FROM stores s"),
+            "SELECT point_distance(s.location, point('(1,1)')) FROM stores s"
+        );
+    }
+
+    #[test]
+    fn test_point_casts_and_strings() {
+        assert_eq!(
+            PointTranslator::translate_query("INSERT INTO stores (location) VALUES ('(1.5, 2)'::POINT)"),
+            "INSERT INTO stores (location) VALUES (point('(1.5, 2)'))"
+        );
+
+        // The tsquery followed-by operator inside a string is not a distance
+        let query = "SELECT to_tsquery('quick <-> fox')";
+        assert_eq!(PointTranslator::translate_query(query), query);
+        assert!(!PointTranslator::needs_translation("SELECT '1'::int4"));
+    }
+}
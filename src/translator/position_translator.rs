@@ -0,0 +1,126 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::{in_string_literal, matching_paren};
+
+/// The start of a position() call
+static POSITION_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bPOSITION\s*\(").unwrap()
+});
+
+/// Translates `position(substring IN string)` to `strpos(string, substring)`.
+///
+/// SQLite can't parse the IN keyword inside a call, and strpos() is the same function
+/// with its arguments the other way round.
+pub struct PositionTranslator;
+
+impl PositionTranslator {
+    /// Check if the query calls position() with the IN keyword
+    pub fn needs_translation(query: &str) -> bool {
+        POSITION_REGEX.is_match(query) && Self::next_call(query).is_some()
+    }
+
+    /// Rewrite each `position(a IN b)` as `strpos(b, a)`
+    pub fn translate_query(query: &str) -> String {
+        if !POSITION_REGEX.is_match(query) {
+            return query.to_string();
+        }
+
+        // Calls nested in the arguments are found again on the next pass
+        let mut result = query.to_string();
+        while let Some((range, replacement)) = Self::next_call(&result) {
+            result.replace_range(range, &replacement);
+        }
+
+        if result != query {
+            debug!("Translated position: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// Find the first position() call using IN, with its translation
+    fn next_call(sql: &str) -> Option<(std::ops::Range<usize>, String)> {
+        for m in POSITION_REGEX.find_iter(sql) {
+            if in_string_literal(sql, m.start()) {
+                continue;
+            }
+            let open = m.end() - 1;
+            let Some(close) = matching_paren(sql, open) else {
+                continue;
+            };
+            let args = &sql[open + 1..close];
+            let Some(keyword) = Self::find_in_keyword(args) else {
+                continue;
+            };
+            let substring = args[..keyword].trim();
+            let string = args[keyword + 2..].trim();
+            return Some((m.start()..close + 1, format!("strpos({string}, {substring})")));
+        }
+        None
+    }
+
+    /// The position of the IN keyword outside parentheses and quotes
+    fn find_in_keyword(args: &str) -> Option<usize> {
+        let bytes = args.as_bytes();
+        let is_word = |b: u8| b.is_ascii_alphanumeric() || b == b'_' || b == b'$';
+        let mut depth = 0;
+        let mut quote = None;
+        for (i, &c) in bytes.iter().enumerate() {
+            match (quote, c) {
+                (Some(q), c) if c == q => quote = None,
+                (Some(_), _) => {}
+                (None, b'\'' | b'"') => quote = Some(c),
+                (None, b'(') => depth += 1,
+                (None, b')') => depth -= 1,
+                (None, b'i' | b'I') if depth == 0
+                    && bytes.get(i + 1).is_some_and(|n| n.eq_ignore_ascii_case(&b'n'))
+                    && (i == 0 || !is_word(bytes[i - 1]))
+                    && bytes.get(i + 2).is_none_or(|&n| !is_word(n)) => return Some(i),
+                _ => {}
+            }
+        }
+        None
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_position_in() {
+        assert_eq!(
+            PositionTranslator::translate_query("SELECT position('x' in title) FROM posts"),
+            "SELECT strpos(title, 'x') FROM posts"
+        );
+        assert_eq!(
+            PositionTranslator::translate_query("SELECT * FROM users WHERE POSITION('@' IN email) > 0"),
+            "SELECT * FROM users WHERE strpos(email, '@') > 0"
+        );
+        assert_eq!(
+            PositionTranslator::translate_query("SELECT position($1 IN lower(title || ' in ' || body)) AS pos FROM posts"),
+            "SELECT strpos(lower(title || ' in ' || body), $1) AS pos FROM posts"
+        );
+    }
+
+    #[test]
+    fn test_nested_calls() {
+        assert_eq!(
+            PositionTranslator::translate_query("SELECT position('b' IN substr(name, position('a' IN name))) FROM t"),
+            "SELECT strpos(substr(name, strpos(name, 'a')), 'b') FROM t"
+        );
+    }
+
+    #[test]
+    fn test_other_calls_unchanged() {
+        for query in [
+            "SELECT array_position(tags, 'x') FROM posts",
+            "SELECT position FROM players WHERE position IN ('GK', 'DF')",
+            "SELECT 'position(a IN b)' FROM t",
+            "SELECT position(index_name) FROM t",
+        ] {
+            assert!(!PositionTranslator::needs_translation(query), "{query}");
+            assert_eq!(PositionTranslator::translate_query(query), query);
+        }
+    }
+}
@@ -19,6 +19,7 @@ bitflags! {
         const JSON_EACH = 1 << 11;
         const ROW_TO_JSON = 1 << 12;
         const ARITHMETIC = 1 << 13;
+        const WINDOW = 1 << 14;
     }
 }
 
@@ -74,6 +75,11 @@ impl QueryAnalyzer {
             if query.contains('{') || query.contains("ARRAY[") || query.contains("array[") {
                 flags |= TranslationFlags::INSERT_DATETIME; // InsertTranslator handles arrays too
             }
+            
+            // Check for Infinity and NaN float literals
+            if query_lower.contains("inf") || query_lower.contains("nan") {
+                flags |= TranslationFlags::INSERT_DATETIME;
+            }
         }
         
         // Check for datetime functions (not in INSERT)
@@ -87,6 +93,12 @@ impl QueryAnalyzer {
             flags |= TranslationFlags::DATETIME;
         }
         
+        // INSERT ... SELECT values are coerced to the target column types; checked after the
+        // datetime functions so that the SELECT still gets those translated
+        if (query_lower.starts_with("insert") || query_lower.contains("insert into")) && query_lower.contains("select ") {
+            flags |= TranslationFlags::INSERT_DATETIME;
+        }
+        
         // Check for JSON operations
         if query.contains("->") || query.contains("->>") || query.contains("#>") ||
            query.contains("#>>") || query.contains("@>") || query.contains("<@") ||
@@ -149,6 +161,11 @@ impl QueryAnalyzer {
             }
         }
         
+        // Check for window functions (OVER w / OVER (...))
+        if query_lower.starts_with("select") && crate::translator::WindowFunctionAnalyzer::needs_analysis(query) {
+            flags |= TranslationFlags::WINDOW;
+        }
+        
         flags
     }
 }
@@ -205,6 +222,12 @@ mod tests {
         assert!(!flags.contains(TranslationFlags::DATETIME));
     }
     
+    #[test]
+    fn test_insert_select_detection() {
+        let flags = QueryAnalyzer::analyze("INSERT INTO archive SELECT * FROM books WHERE status = 'archived'");
+        assert!(flags.contains(TranslationFlags::INSERT_DATETIME));
+    }
+    
     #[test]
     fn test_json_detection() {
         let flags = QueryAnalyzer::analyze("SELECT data->>'name' FROM users");
@@ -225,6 +248,15 @@ mod tests {
         assert!(flags.contains(TranslationFlags::ARRAY));
     }
     
+    #[test]
+    fn test_window_detection() {
+        let flags = QueryAnalyzer::analyze("SELECT rank() OVER w AS r FROM books WINDOW w AS (ORDER BY price)");
+        assert!(flags.contains(TranslationFlags::WINDOW));
+        
+        let flags = QueryAnalyzer::analyze("SELECT title FROM books ORDER BY price");
+        assert!(!flags.contains(TranslationFlags::WINDOW));
+    }
+    
     #[test]
     fn test_insert_array_detection() {
         let flags = QueryAnalyzer::analyze("INSERT INTO test_arrays (int_array) VALUES ('{1,2,3}')");
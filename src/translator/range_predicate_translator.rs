@@ -0,0 +1,240 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::{in_string_literal, matching_paren, opening_paren, split_top_level};
+
+static OVERLAPS_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bOVERLAPS\b").unwrap()
+});
+
+static BETWEEN_SYMMETRY_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bBETWEEN\s+(SYMMETRIC|ASYMMETRIC)\b").unwrap()
+});
+
+/// Keywords that end an operand of BETWEEN SYMMETRIC
+const OPERAND_TERMINATORS: &[&str] = &[
+    "AND", "OR", "WHERE", "GROUP", "ORDER", "LIMIT", "OFFSET", "HAVING",
+    "UNION", "EXCEPT", "INTERSECT", "THEN", "WHEN", "ELSE", "END", "AS",
+];
+
+/// Translates the OVERLAPS and BETWEEN SYMMETRIC predicates, which SQLite lacks,
+/// into equivalent comparisons
+pub struct RangePredicateTranslator;
+
+impl RangePredicateTranslator {
+    /// Check if the query uses OVERLAPS or BETWEEN [A]SYMMETRIC
+    pub fn needs_translation(query: &str) -> bool {
+        let upper = query.to_uppercase();
+        upper.contains("OVERLAPS") || upper.contains("SYMMETRIC")
+    }
+
+    /// Translate OVERLAPS and BETWEEN SYMMETRIC predicates
+    pub fn translate_query(query: &str) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let result = Self::translate_between_symmetric(query);
+        let result = Self::translate_overlaps(&result);
+
+        if result != query {
+            debug!("Translated range predicates: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// (s1, e1) OVERLAPS (s2, e2) becomes a comparison of the normalized periods.
+    /// Like PostgreSQL, each period's endpoints are swapped when the end comes first,
+    /// and periods starting at the same instant always overlap.
+    fn translate_overlaps(query: &str) -> String {
+        let mut result = query.to_string();
+
+        // Work from the last predicate backwards so earlier offsets stay valid
+        let positions: Vec<_> = OVERLAPS_REGEX.find_iter(query)
+            .filter(|m| !in_string_literal(query, m.start()))
+            .map(|m| (m.start(), m.end()))
+            .collect();
+
+        for (start, end) in positions.into_iter().rev() {
+            let Some(left_start) = Self::tuple_start_before(&result, start) else { continue };
+            let Some(right_end) = Self::tuple_end_after(&result, end) else { continue };
+
+            let left_close = result[..start].trim_end().len() - 1;
+            let right_open = end + (result[end..].len() - result[end..].trim_start().len());
+            let (Some((s1, e1)), Some((s2, e2))) = (
+                Self::split_pair(&result[left_start + 1..left_close]),
+                Self::split_pair(&result[right_open + 1..right_end]),
+            ) else { continue };
+
+            let (s1, e1) = Self::ordered_period(&s1, &e1);
+            let (s2, e2) = Self::ordered_period(&s2, &e2);
+            let replacement = format!("(({s1}) = ({s2}) OR (({s1}) < ({e2}) AND ({s2}) < ({e1})))");
+
+            result.replace_range(left_start..right_end + 1, &replacement);
+        }
+
+        result
+    }
+
+    /// Order a period's endpoints, leaving a NULL end in place as PostgreSQL does
+    fn ordered_period(start: &str, end: &str) -> (String, String) {
+        (
+            format!("CASE WHEN {end} < {start} THEN {end} ELSE {start} END"),
+            format!("CASE WHEN {end} < {start} THEN {start} ELSE {end} END"),
+        )
+    }
+
+    /// x BETWEEN SYMMETRIC a AND b becomes x BETWEEN min(a, b) AND max(a, b)
+    fn translate_between_symmetric(query: &str) -> String {
+        let mut result = query.to_string();
+
+        let positions: Vec<_> = BETWEEN_SYMMETRY_REGEX.captures_iter(query)
+            .filter_map(|caps| {
+                let whole = caps.get(0)?;
+                let symmetric = caps[1].eq_ignore_ascii_case("SYMMETRIC");
+                (!in_string_literal(query, whole.start())).then_some((whole.start(), whole.end(), symmetric))
+            })
+            .collect();
+
+        for (start, end, symmetric) in positions.into_iter().rev() {
+            let low_end = Self::operand_end(&result, end);
+            if !result[low_end..].to_uppercase().starts_with("AND") {
+                continue;
+            }
+            let high_start = low_end + 3;
+            let high_end = Self::operand_end(&result, high_start);
+
+            let low = result[end..low_end].trim().to_string();
+            let high = result[high_start..high_end].trim().to_string();
+
+            // BETWEEN ASYMMETRIC is the default behaviour; only the keyword goes
+            let replacement = if symmetric {
+                format!("BETWEEN min({low}, {high}) AND max({low}, {high})")
+            } else {
+                format!("BETWEEN {low} AND {high}")
+            };
+            let suffix = if high_end < result.len() { " " } else { "" };
+            result.replace_range(start..high_end, &format!("{replacement}{suffix}"));
+        }
+
+        result
+    }
+
+    /// Find where a BETWEEN operand starting at `start` ends: at a top-level keyword,
+    /// comma, closing parenthesis, semicolon or the end of the query
+    fn operand_end(query: &str, start: usize) -> usize {
+        let bytes = query.as_bytes();
+        let mut depth = 0i32;
+        let mut in_string = false;
+        let mut i = start;
+
+        while i < bytes.len() {
+            let ch = bytes[i];
+            if in_string {
+                if ch == b'\'' {
+                    in_string = false;
+                }
+                i += 1;
+                continue;
+            }
+
+            match ch {
+                b'\'' => in_string = true,
+                b'(' => depth += 1,
+                b')' if depth == 0 => return i,
+                b')' => depth -= 1,
+                b',' | b';' if depth == 0 => return i,
+                c if depth == 0 && c.is_ascii_alphabetic() && (i == 0 || !Self::is_word_byte(bytes[i - 1])) => {
+                    let word_end = bytes[i..].iter()
+                        .position(|b| !Self::is_word_byte(*b))
+                        .map_or(bytes.len(), |p| i + p);
+                    let word = &query[i..word_end];
+                    // The operand has to start before its terminator can appear
+                    if i > start && !query[start..i].trim().is_empty()
+                        && OPERAND_TERMINATORS.iter().any(|k| k.eq_ignore_ascii_case(word)) {
+                        return i;
+                    }
+                    i = word_end;
+                    continue;
+                }
+                _ => {}
+            }
+            i += 1;
+        }
+
+        bytes.len()
+    }
+
+    fn is_word_byte(b: u8) -> bool {
+        b.is_ascii_alphanumeric() || b == b'_'
+    }
+
+    /// Find the opening parenthesis of the tuple that ends just before `pos`
+    fn tuple_start_before(query: &str, pos: usize) -> Option<usize> {
+        let before = query[..pos].trim_end();
+        if !before.ends_with(')') {
+            return None;
+        }
+
+        opening_paren(before, before.len() - 1)
+    }
+
+    /// Find the closing parenthesis of the tuple that starts just after `pos`
+    fn tuple_end_after(query: &str, pos: usize) -> Option<usize> {
+        let offset = pos + (query[pos..].len() - query[pos..].trim_start().len());
+        if !query[offset..].starts_with('(') {
+            return None;
+        }
+
+        matching_paren(query, offset)
+    }
+
+    /// Split "a, b" at its single top-level comma
+    fn split_pair(content: &str) -> Option<(String, String)> {
+        match split_top_level(content)[..] {
+            [first, second] => Some((first.to_string(), second.to_string())),
+            _ => None,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_overlaps_translation() {
+        let result = RangePredicateTranslator::translate_query(
+            "SELECT id FROM bookings WHERE (start_date, end_date) OVERLAPS (pg_date_from_text('2024-01-10'), pg_date_from_text('2024-01-20'))"
+        );
+        assert_eq!(
+            result,
+            "SELECT id FROM bookings WHERE ((CASE WHEN end_date < start_date THEN end_date ELSE start_date END) = \
+             (CASE WHEN pg_date_from_text('2024-01-20') < pg_date_from_text('2024-01-10') THEN pg_date_from_text('2024-01-20') ELSE pg_date_from_text('2024-01-10') END) OR \
+             ((CASE WHEN end_date < start_date THEN end_date ELSE start_date END) < \
+             (CASE WHEN pg_date_from_text('2024-01-20') < pg_date_from_text('2024-01-10') THEN pg_date_from_text('2024-01-10') ELSE pg_date_from_text('2024-01-20') END) AND \
+             (CASE WHEN pg_date_from_text('2024-01-20') < pg_date_from_text('2024-01-10') THEN pg_date_from_text('2024-01-20') ELSE pg_date_from_text('2024-01-10') END) < \
+             (CASE WHEN end_date < start_date THEN start_date ELSE end_date END)))"
+        );
+
+        // Not a predicate outside string literals
+        let query = "SELECT 'periods OVERLAPS here' FROM t";
+        assert_eq!(RangePredicateTranslator::translate_query(query), query);
+    }
+
+    #[test]
+    fn test_between_symmetric_translation() {
+        assert_eq!(
+            RangePredicateTranslator::translate_query("SELECT id FROM t WHERE x BETWEEN SYMMETRIC 10 AND 1 ORDER BY id"),
+            "SELECT id FROM t WHERE x BETWEEN min(10, 1) AND max(10, 1) ORDER BY id"
+        );
+        assert_eq!(
+            RangePredicateTranslator::translate_query("SELECT x NOT BETWEEN SYMMETRIC abs(y) AND 5 AND z = 1 FROM t"),
+            "SELECT x NOT BETWEEN min(abs(y), 5) AND max(abs(y), 5) AND z = 1 FROM t"
+        );
+        assert_eq!(
+            RangePredicateTranslator::translate_query("SELECT * FROM t WHERE x BETWEEN ASYMMETRIC 1 AND 10"),
+            "SELECT * FROM t WHERE x BETWEEN 1 AND 10"
+        );
+    }
+}
@@ -0,0 +1,183 @@
+use rusqlite::Connection;
+use regex::{Captures, Regex};
+use once_cell::sync::Lazy;
+use tracing::debug;
+use crate::functions::range_functions::RANGE_TYPES;
+use crate::translator::PgTypeofTranslator;
+
+static RANGE_CAST_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)('(?:[^']|'')*')\s*::\s*(int4range|int8range|numrange|daterange|tsrange|tstzrange)\b").unwrap()
+});
+
+/// A function call, a string literal with an optional cast, a number or a column reference
+const OPERAND: &str = r"(?:\w+\s*\((?:'(?:[^']|'')*'|[^()'])*\)|'(?:[^']|'')*'(?:\s*::\s*\w+(?:\s+with(?:out)?\s+time\s+zone)?)?|-?\d+(?:\.\d+)?|\w+(?:\.\w+)?)";
+
+static RANGE_OPERATOR_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(&format!(r"(?i)({OPERAND})\s*(@>|<@|&&)\s*({OPERAND})")).unwrap()
+});
+
+static FUNCTION_NAME_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"^(\w+)\s*\(").unwrap()
+});
+
+static CAST_LITERAL_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"^('(?:[^']|'')*')\s*::").unwrap()
+});
+
+static UPDATE_TABLE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*UPDATE\s+(\w+)").unwrap()
+});
+
+/// Range values are stored as their canonical text, so the range operators become calls
+/// to the range functions: `a && b` to range_overlaps(a, b), `a @> b` to
+/// range_contains(a, b) and `a <@ b` to range_contains(b, a). Only operators with a range
+/// operand (a range column, a range constructor or a `'...'::int4range` cast) are rewritten;
+/// the array and JSON operators spelled the same are left to their translators.
+pub struct RangeTranslator;
+
+impl RangeTranslator {
+    /// Check if the query uses a range operator or casts a literal to a range type
+    pub fn needs_translation(query: &str) -> bool {
+        query.contains("@>") || query.contains("<@") || query.contains("&&")
+            || (query.contains("::") && RANGE_CAST_REGEX.is_match(query))
+    }
+
+    /// Translate range casts and operators
+    pub fn translate_query(query: &str, conn: &Connection) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        // '[1,10]'::int4range becomes int4range('[1,10]'), which yields the canonical text
+        let result = RANGE_CAST_REGEX.replace_all(query, |caps: &Captures| {
+            format!("{}({})", caps[2].to_lowercase(), &caps[1])
+        });
+
+        let range_columns = if result.contains("@>") || result.contains("<@") || result.contains("&&") {
+            Self::range_columns(&result, conn)
+        } else {
+            Vec::new()
+        };
+        let result = RANGE_OPERATOR_REGEX.replace_all(&result, |caps: &Captures| {
+            let (left, operator, right) = (&caps[1], &caps[2], &caps[3]);
+            let is_range = |operand: &str| Self::is_range_operand(operand, &range_columns);
+            match operator {
+                "&&" if is_range(left) || is_range(right) => {
+                    format!("range_overlaps({}, {})", Self::element(left), Self::element(right))
+                }
+                "@>" if is_range(left) => format!("range_contains({}, {})", left, Self::element(right)),
+                "<@" if is_range(right) => format!("range_contains({}, {})", right, Self::element(left)),
+                _ => caps[0].to_string(),
+            }
+        });
+
+        if result != query {
+            debug!("Translated range operators: {} -> {}", query, result);
+        }
+        result.into_owned()
+    }
+
+    /// Range constructor calls and columns declared with a range type
+    fn is_range_operand(operand: &str, range_columns: &[String]) -> bool {
+        if let Some(caps) = FUNCTION_NAME_REGEX.captures(operand) {
+            return RANGE_TYPES.iter().any(|name| name.eq_ignore_ascii_case(&caps[1]));
+        }
+        if operand.starts_with('\'') || operand.starts_with('-') || operand.starts_with(|c: char| c.is_ascii_digit()) {
+            return false;
+        }
+        let column = operand.rsplit('.').next().unwrap_or(operand);
+        range_columns.iter().any(|c| c.eq_ignore_ascii_case(column))
+    }
+
+    /// Literals lose their casts (like '2024-01-01 10:00'::timestamp): the range functions
+    /// compare bounds and elements from their text
+    fn element(operand: &str) -> &str {
+        match CAST_LITERAL_REGEX.captures(operand) {
+            Some(caps) => caps.get(1).map_or(operand, |m| m.as_str()),
+            None => operand,
+        }
+    }
+
+    /// Look up the range columns of the tables the query reads from or updates
+    fn range_columns(query: &str, conn: &Connection) -> Vec<String> {
+        let mut tables: Vec<String> = PgTypeofTranslator::extract_table_refs(query)
+            .into_iter()
+            .map(|(table, _)| table)
+            .collect();
+        if let Some(caps) = UPDATE_TABLE_REGEX.captures(query) {
+            tables.push(caps[1].to_string());
+        }
+
+        let mut columns = Vec::new();
+        for table in tables {
+            let table_columns: Vec<String> = conn.prepare(
+                "SELECT column_name FROM __pgsqlite_schema WHERE table_name = ?1 \
+                 AND lower(pg_type) IN ('int4range', 'int8range', 'numrange', 'daterange', 'tsrange', 'tstzrange')"
+            )
+                .and_then(|mut stmt| {
+                    let columns = stmt.query_map([&table], |row| row.get(0))?.collect();
+                    columns
+                })
+                .unwrap_or_default();
+            columns.extend(table_columns);
+        }
+        columns
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn setup() -> Connection {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute("CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT)", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('bookings', 'during', 'TSRANGE', 'TEXT')", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('bookings', 'tags', 'TEXT[]', 'TEXT')", []).unwrap();
+        conn
+    }
+
+    #[test]
+    fn test_range_operators() {
+        let conn = setup();
+
+        assert_eq!(
+            RangeTranslator::translate_query(
+                "SELECT id FROM bookings WHERE during @> '2024-01-01 11:00'::timestamp", &conn
+            ),
+            "SELECT id FROM bookings WHERE range_contains(during, '2024-01-01 11:00')"
+        );
+        assert_eq!(
+            RangeTranslator::translate_query(
+                "SELECT id FROM bookings b WHERE b.during && tsrange('2024-01-01 09:00', '2024-01-01 10:30')", &conn
+            ),
+            "SELECT id FROM bookings b WHERE range_overlaps(b.during, tsrange('2024-01-01 09:00', '2024-01-01 10:30'))"
+        );
+        assert_eq!(
+            RangeTranslator::translate_query("SELECT 5 <@ int4range(1, 10), '[2,4)'::int4range <@ '[1,10)'::int4range", &conn),
+            "SELECT range_contains(int4range(1, 10), 5), range_contains(int4range('[1,10)'), int4range('[2,4)'))"
+        );
+    }
+
+    #[test]
+    fn test_other_operators_untouched() {
+        let conn = setup();
+
+        // Array and JSON containment are left to their translators
+        let query = "SELECT id FROM bookings WHERE tags @> '{a}' AND data @> '{\"k\": 1}'";
+        assert_eq!(RangeTranslator::translate_query(query, &conn), query);
+        let query = "SELECT id FROM bookings WHERE tags && ARRAY['a']";
+        assert_eq!(RangeTranslator::translate_query(query, &conn), query);
+    }
+
+    #[test]
+    fn test_range_casts() {
+        let conn = setup();
+
+        assert_eq!(
+            RangeTranslator::translate_query("INSERT INTO bookings (during) VALUES ('[2024-01-01,2024-01-02]'::TSRANGE)", &conn),
+            "INSERT INTO bookings (during) VALUES (tsrange('[2024-01-01,2024-01-02]'))"
+        );
+        assert!(!RangeTranslator::needs_translation("SELECT '1'::int4"));
+    }
+}
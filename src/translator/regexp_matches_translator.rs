@@ -0,0 +1,167 @@
+use sqlparser::ast::{Expr, FunctionArg, FunctionArgExpr, FunctionArguments, Ident, ObjectName, ObjectNamePart, Query, Select, SelectItem, SetExpr, Statement, TableWithJoins};
+use sqlparser::dialect::PostgreSqlDialect;
+use sqlparser::parser::Parser;
+use crate::PgSqliteError;
+use tracing::debug;
+
+/// Translates regexp_matches() in SELECT lists into a join against
+/// json_each(regexp_matches_json(...)), so each match becomes its own row
+pub struct RegexpMatchesTranslator;
+
+impl RegexpMatchesTranslator {
+    /// Quick check if the query calls regexp_matches
+    pub fn needs_translation(query: &str) -> bool {
+        query.to_lowercase().contains("regexp_matches")
+    }
+
+    /// Translate regexp_matches calls in the query's SELECT lists
+    pub fn translate_query(query: &str) -> Result<String, PgSqliteError> {
+        if !Self::needs_translation(query) {
+            return Ok(query.to_string());
+        }
+
+        debug!("Translating regexp_matches in query: {}", query);
+
+        let dialect = PostgreSqlDialect {};
+        let mut statements = Parser::parse_sql(&dialect, query)?;
+
+        for statement in &mut statements {
+            if let Statement::Query(query) = statement {
+                Self::translate_query_box(query);
+            }
+        }
+
+        let result = statements.iter()
+            .map(|s| s.to_string())
+            .collect::<Vec<_>>()
+            .join("; ");
+
+        debug!("Translated query: {}", result);
+        Ok(result)
+    }
+
+    fn translate_query_box(query: &mut Box<Query>) {
+        Self::translate_set_expr(&mut query.body);
+    }
+
+    fn translate_set_expr(set_expr: &mut SetExpr) {
+        match set_expr {
+            SetExpr::Select(select) => Self::translate_select(select),
+            SetExpr::Query(query) => Self::translate_query_box(query),
+            SetExpr::SetOperation { left, right, .. } => {
+                Self::translate_set_expr(left);
+                Self::translate_set_expr(right);
+            }
+            _ => {}
+        }
+    }
+
+    /// Replace each regexp_matches call in the projection with the value column of
+    /// a json_each source appended to FROM
+    fn translate_select(select: &mut Box<Select>) {
+        let mut sources = Vec::new();
+
+        for projection in &mut select.projection {
+            match projection {
+                SelectItem::UnnamedExpr(expr) => {
+                    let is_call = Self::is_regexp_matches(expr);
+                    Self::replace_calls(expr, &mut sources);
+                    // Keep PostgreSQL's default column name for a bare call
+                    if is_call {
+                        *projection = SelectItem::ExprWithAlias {
+                            expr: expr.clone(),
+                            alias: Ident::new("regexp_matches"),
+                        };
+                    }
+                }
+                SelectItem::ExprWithAlias { expr, .. } => Self::replace_calls(expr, &mut sources),
+                _ => {}
+            }
+        }
+
+        for (alias, call) in sources {
+            if let Some(source) = Self::json_each_source(&call, &alias) {
+                select.from.push(source);
+            }
+        }
+    }
+
+    fn is_regexp_matches(expr: &Expr) -> bool {
+        matches!(expr, Expr::Function(func) if func.name.to_string().eq_ignore_ascii_case("regexp_matches"))
+    }
+
+    /// Swap regexp_matches calls for alias.value, collecting the regexp_matches_json call for each
+    fn replace_calls(expr: &mut Expr, sources: &mut Vec<(String, Expr)>) {
+        if Self::is_regexp_matches(expr) {
+            let alias = format!("__pgsqlite_matches{}", sources.len());
+            let mut call = expr.clone();
+            if let Expr::Function(func) = &mut call {
+                func.name = ObjectName(vec![ObjectNamePart::Identifier(Ident::new("regexp_matches_json"))]);
+            }
+            *expr = Expr::CompoundIdentifier(vec![Ident::new(&alias), Ident::new("value")]);
+            sources.push((alias, call));
+            return;
+        }
+
+        match expr {
+            Expr::Nested(inner) => Self::replace_calls(inner, sources),
+            Expr::Cast { expr: inner, .. } => Self::replace_calls(inner, sources),
+            Expr::Function(func) => {
+                if let FunctionArguments::List(arg_list) = &mut func.args {
+                    for arg in &mut arg_list.args {
+                        if let FunctionArg::Unnamed(FunctionArgExpr::Expr(e)) = arg {
+                            Self::replace_calls(e, sources);
+                        }
+                    }
+                }
+            }
+            _ => {}
+        }
+    }
+
+    /// Build the `json_each(call) AS alias` FROM item by parsing it
+    fn json_each_source(call: &Expr, alias: &str) -> Option<TableWithJoins> {
+        let sql = format!("SELECT 1 FROM json_each({call}) AS {alias}");
+        let mut statements = Parser::parse_sql(&PostgreSqlDialect {}, &sql).ok()?;
+        match statements.pop()? {
+            Statement::Query(query) => match *query.body {
+                SetExpr::Select(mut select) => select.from.pop(),
+                _ => None,
+            },
+            _ => None,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_regexp_matches_in_select_list() {
+        let result = RegexpMatchesTranslator::translate_query(
+            r"SELECT id, regexp_matches(isbn, '(\d{3})(\d+)') FROM books WHERE id > 1"
+        ).unwrap();
+        assert_eq!(
+            result,
+            r"SELECT id, __pgsqlite_matches0.value AS regexp_matches FROM books, json_each(regexp_matches_json(isbn, '(\d{3})(\d+)')) AS __pgsqlite_matches0 WHERE id > 1"
+        );
+    }
+
+    #[test]
+    fn test_regexp_matches_with_alias_and_flags() {
+        let result = RegexpMatchesTranslator::translate_query(
+            "SELECT regexp_matches('a1 b2', '([a-z])(\\d)', 'g') AS parts"
+        ).unwrap();
+        assert_eq!(
+            result,
+            "SELECT __pgsqlite_matches0.value AS parts FROM json_each(regexp_matches_json('a1 b2', '([a-z])(\\d)', 'g')) AS __pgsqlite_matches0"
+        );
+    }
+
+    #[test]
+    fn test_query_without_regexp_matches() {
+        let query = "SELECT regexp_replace(name, 'a', 'b') FROM t";
+        assert_eq!(RegexpMatchesTranslator::translate_query(query).unwrap(), query);
+    }
+}
@@ -0,0 +1,249 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::{in_string_literal, matching_paren, split_top_level};
+
+/// The ROW keyword of a row constructor
+static ROW_KEYWORD_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bROW\s*\(").unwrap()
+});
+
+/// A parenthesized list compared with another one
+static ROW_COMPARISON_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\)\s*(?:=|<>|!=|<=|>=|<|>)\s*(?:ROW\s*)?\(").unwrap()
+});
+
+/// Comparison operators, longest first so `<=` isn't read as `<`
+const OPERATORS: &[&str] = &["<>", "!=", "<=", ">=", "=", "<", ">"];
+
+/// Keywords a row constructor can follow, unlike the name of a called function
+const PRECEDING_KEYWORDS: &[&str] = &["WHERE", "AND", "OR", "NOT", "ON", "WHEN", "THEN", "ELSE", "HAVING", "SELECT", "RETURNING"];
+
+/// Translates row constructors and row comparisons.
+///
+/// `(a, b) = (1, 'x')` becomes `(a = 1 AND b = 'x')` and `ROW(a, b) < ROW(c, d)` the
+/// lexicographic `(a < c OR (a = c AND b < d))`, so each pair is a plain comparison the
+/// other translators understand. The expansion keeps PostgreSQL's NULL semantics: the
+/// result is decided by the first pair that is unequal or NULL. Row constructors that
+/// aren't compared lose the ROW keyword and stay SQLite row values.
+pub struct RowComparisonTranslator;
+
+impl RowComparisonTranslator {
+    /// Check if the query has a ROW constructor or compares parenthesized lists
+    pub fn needs_translation(query: &str) -> bool {
+        ROW_KEYWORD_REGEX.is_match(query) || ROW_COMPARISON_REGEX.is_match(query)
+    }
+
+    /// Expand row comparisons and drop the ROW keyword
+    pub fn translate_query(query: &str) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let mut result = query.to_string();
+        let mut from = 0;
+        while let Some((range, replacement)) = Self::next_comparison(&result, from) {
+            from = range.start + replacement.len();
+            result.replace_range(range, &replacement);
+        }
+
+        let matches: Vec<_> = ROW_KEYWORD_REGEX.find_iter(&result)
+            .filter(|m| !in_string_literal(&result, m.start()))
+            .map(|m| m.range())
+            .collect();
+        for range in matches.into_iter().rev() {
+            result.replace_range(range, "(");
+        }
+
+        if result != query {
+            debug!("Translated row comparison: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// Find the first row comparison starting at or after `from`, with its expansion
+    fn next_comparison(sql: &str, from: usize) -> Option<(std::ops::Range<usize>, String)> {
+        for (open, _) in sql.char_indices().skip_while(|(i, _)| *i < from).filter(|(_, c)| *c == '(') {
+            if in_string_literal(sql, open) {
+                continue;
+            }
+            let Some(start) = Self::row_start(sql, open) else {
+                continue;
+            };
+            let Some(close) = matching_paren(sql, open) else {
+                continue;
+            };
+            let left = Self::split_items(&sql[open + 1..close]);
+            if left.len() < 2 || Self::is_subquery(&sql[open + 1..close]) {
+                continue;
+            }
+
+            let rest = &sql[close + 1..];
+            let after_space = rest.len() - rest.trim_start().len();
+            let Some(operator) = OPERATORS.iter().find(|op| rest[after_space..].starts_with(**op)) else {
+                continue;
+            };
+            let right_start = close + 1 + after_space + operator.len();
+            let rest = &sql[right_start..];
+            let mut right_open = right_start + rest.len() - rest.trim_start().len();
+            if sql[right_open..].len() >= 3 && sql[right_open..right_open + 3].eq_ignore_ascii_case("ROW") {
+                let after_row = &sql[right_open + 3..];
+                right_open += 3 + after_row.len() - after_row.trim_start().len();
+            }
+            if !sql[right_open..].starts_with('(') {
+                continue;
+            }
+            let Some(right_close) = matching_paren(sql, right_open) else {
+                continue;
+            };
+            let right = Self::split_items(&sql[right_open + 1..right_close]);
+            if right.len() != left.len() || Self::is_subquery(&sql[right_open + 1..right_close]) {
+                continue;
+            }
+
+            return Some((start..right_close + 1, Self::expand(operator, &left, &right)));
+        }
+        None
+    }
+
+    /// Where the row constructor opening at `open` starts, if it is one and not a function call
+    fn row_start(sql: &str, open: usize) -> Option<usize> {
+        let before = sql[..open].trim_end();
+        let word_start = before.rfind(|c: char| !(c.is_alphanumeric() || c == '_')).map_or(0, |i| i + 1);
+        let word = &before[word_start..];
+        if word.eq_ignore_ascii_case("ROW") {
+            return Some(word_start);
+        }
+        if word.is_empty() && !before.ends_with(['"', ']']) {
+            return Some(open);
+        }
+        PRECEDING_KEYWORDS.iter().any(|keyword| word.eq_ignore_ascii_case(keyword)).then_some(open)
+    }
+
+    /// Subqueries returning a row are compared by SQLite's own row values
+    fn is_subquery(list: &str) -> bool {
+        list.trim_start().get(..6).is_some_and(|word| word.eq_ignore_ascii_case("SELECT"))
+    }
+
+    /// The chained comparison equivalent to `(left) operator (right)`
+    fn expand(operator: &str, left: &[String], right: &[String]) -> String {
+        let pair = |i: usize, op: &str| format!("{} {} {}", left[i], op, right[i]);
+        let expanded = match operator {
+            "=" => (0..left.len()).map(|i| pair(i, "=")).collect::<Vec<_>>().join(" AND "),
+            "<>" | "!=" => (0..left.len()).map(|i| pair(i, "<>")).collect::<Vec<_>>().join(" OR "),
+            _ => {
+                // Equal on every earlier pair and less (or greater) on this one; the last
+                // pair takes the operator itself so <= and >= include equal rows
+                let strict = &operator[..1];
+                let chain = (0..left.len()).map(|i| {
+                    let op = if i == left.len() - 1 { operator } else { strict };
+                    let mut terms: Vec<String> = (0..i).map(|j| pair(j, "=")).collect();
+                    terms.push(pair(i, op));
+                    if terms.len() > 1 { format!("({})", terms.join(" AND ")) } else { terms.remove(0) }
+                }).collect::<Vec<_>>().join(" OR ");
+                if left.len() == 1 {
+                    chain
+                } else {
+                    // The redundant bound on the first column gives the planner a single range
+                    // scan of an index on the columns, in index order, instead of a
+                    // MULTI-INDEX OR over each branch that then has to be sorted for ORDER BY
+                    format!("{} AND ({chain})", pair(0, &format!("{strict}=")))
+                }
+            }
+        };
+        format!("({expanded})")
+    }
+
+    /// Split a list on the commas outside parentheses and quotes, parenthesizing compound items
+    fn split_items(list: &str) -> Vec<String> {
+        split_top_level(list).into_iter().map(|item| {
+            let simple = !item.contains(char::is_whitespace) || (item.starts_with('\'') && item.ends_with('\'') && item.len() > 1);
+            if simple { item.to_string() } else { format!("({item})") }
+        }).collect()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_row_equality() {
+        assert_eq!(
+            RowComparisonTranslator::translate_query("SELECT * FROM posts WHERE (author_id, status) = (1, 'published')"),
+            "SELECT * FROM posts WHERE (author_id = 1 AND status = 'published')"
+        );
+        assert_eq!(
+            RowComparisonTranslator::translate_query("SELECT * FROM t WHERE ROW(a, b) <> ROW(1, 2)"),
+            "SELECT * FROM t WHERE (a <> 1 OR b <> 2)"
+        );
+    }
+
+    #[test]
+    fn test_row_ordering() {
+        assert_eq!(
+            RowComparisonTranslator::translate_query(
+                "SELECT id FROM posts WHERE (created_at, id) < ('2024-01-01', 10) ORDER BY created_at DESC, id DESC"
+            ),
+            "SELECT id FROM posts WHERE (created_at <= '2024-01-01' AND (created_at < '2024-01-01' OR (created_at = '2024-01-01' AND id < 10))) ORDER BY created_at DESC, id DESC"
+        );
+        assert_eq!(
+            RowComparisonTranslator::translate_query("SELECT * FROM t WHERE ROW(a, b, c) >= ROW(1, 2, 3)"),
+            "SELECT * FROM t WHERE (a >= 1 AND (a > 1 OR (a = 1 AND b > 2) OR (a = 1 AND b = 2 AND c >= 3)))"
+        );
+    }
+
+    #[test]
+    fn test_other_lists_unchanged() {
+        for query in [
+            "SELECT coalesce(a, b) = 1 FROM t",
+            "INSERT INTO t (a, b) VALUES (1, 2)",
+            "SELECT * FROM t WHERE a IN (1, 2)",
+            "SELECT '(1, 2) = (1, 2)'",
+            "SELECT * FROM t WHERE (a, b) = (SELECT x, y FROM u LIMIT 1)",
+        ] {
+            assert_eq!(RowComparisonTranslator::translate_query(query), query);
+        }
+        // A row constructor that isn't compared is a SQLite row value
+        assert_eq!(
+            RowComparisonTranslator::translate_query("SELECT * FROM t WHERE ROW(a, b) IN (SELECT x, y FROM u)"),
+            "SELECT * FROM t WHERE (a, b) IN (SELECT x, y FROM u)"
+        );
+    }
+
+    #[test]
+    fn test_keyset_predicate_uses_composite_index() {
+        let conn = rusqlite::Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "CREATE TABLE events (pk INTEGER PRIMARY KEY, created_at TEXT, id INTEGER, seq INTEGER, body TEXT);
+             CREATE INDEX events_keyset_idx ON events (created_at, id, seq);
+             WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 50000)
+             INSERT INTO events (created_at, id, seq, body)
+             SELECT date('2024-01-01', '+' || (i % 365) || ' days'), i % 1000, i, 'event ' || i FROM n;
+             ANALYZE;"
+        ).unwrap();
+
+        for (query, params) in [
+            ("SELECT pk FROM events WHERE (created_at, id) < ($1, $2) ORDER BY created_at DESC, id DESC LIMIT 20", 2),
+            ("SELECT pk FROM events WHERE ROW(created_at, id, seq) >= ROW($1, $2, $3) ORDER BY created_at, id, seq LIMIT 20", 3),
+        ] {
+            let mut translated = RowComparisonTranslator::translate_query(query);
+            for i in 1..=params {
+                translated = translated.replace(&format!("${i}"), &format!("?{i}"));
+            }
+            let values = || rusqlite::params_from_iter(["2024-06-01", "500", "0"].into_iter().take(params));
+            let plan: Vec<String> = conn.prepare(&format!("EXPLAIN QUERY PLAN {translated}")).unwrap()
+                .query_map(values(), |row| row.get::<_, String>(3)).unwrap()
+                .collect::<Result<_, _>>().unwrap();
+            let plan = plan.join("\n");
+            assert!(plan.contains("INDEX events_keyset_idx (created_at"), "{translated}: {plan}");
+            assert!(!plan.contains("MULTI-INDEX OR"), "{translated}: {plan}");
+            assert!(!plan.contains("TEMP B-TREE"), "{translated}: {plan}");
+
+            let rows: Vec<i64> = conn.prepare(&translated).unwrap()
+                .query_map(values(), |row| row.get(0)).unwrap()
+                .collect::<Result<_, _>>().unwrap();
+            assert_eq!(rows.len(), 20);
+        }
+    }
+}
@@ -1,6 +1,14 @@
-use crate::types::PgType;
+use rusqlite::Connection;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use crate::types::{PgType, SchemaTypeMapper};
+use crate::translator::PgTypeofTranslator;
 use crate::translator::metadata::{TranslationMetadata, ColumnTypeHint};
 
+static ROW_REFERENCE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\b(row_to_json|to_jsonb?)\s*\(\s*(\w+)\s*(?:,\s*(\w+)\s*)?\)").unwrap()
+});
+
 /// Translator for PostgreSQL row_to_json() function calls
 pub struct RowToJsonTranslator;
 
@@ -74,10 +82,10 @@ impl RowToJsonTranslator {
                     
                     let json_construction = format!("json_object({})", json_fields.join(", "));
                     
-                    // Replace the row_to_json call with json_object
+                    // Replace the row_to_json call with json_object, keeping PostgreSQL's column name
                     let result = query.replace(
                         &format!("row_to_json({alias})"),
-                        &json_construction
+                        &format!("{json_construction} AS row_to_json")
                     );
                     
                     // Add metadata hint for the result type
@@ -97,6 +105,85 @@ impl RowToJsonTranslator {
         None
     }
     
+    /// Check if the query may pass a whole row to row_to_json, to_json or to_jsonb
+    pub fn needs_row_reference_translation(query: &str) -> bool {
+        query.to_lowercase().contains("to_json")
+    }
+    
+    /// Expand row_to_json(t), to_json(t) and to_jsonb(t), where t names a table
+    /// in FROM or JOIN, into a json_object() over the table's columns. Values are
+    /// encoded by their declared types so numbers stay unquoted and timestamps
+    /// come out as ISO 8601 strings.
+    pub fn translate_row_references(query: &str, conn: &Connection) -> String {
+        if !Self::needs_row_reference_translation(query) {
+            return query.to_string();
+        }
+        
+        let tables = PgTypeofTranslator::extract_table_refs(query);
+        let mut result = query.to_string();
+        
+        // Replace from the end so earlier match positions stay valid
+        let matches: Vec<_> = ROW_REFERENCE_REGEX.captures_iter(query).collect();
+        for caps in matches.into_iter().rev() {
+            let whole = caps.get(0).unwrap();
+            let function = caps[1].to_lowercase();
+            let reference = &caps[2];
+            let pretty = caps.get(3).map(|m| m.as_str());
+            if pretty.is_some() && function != "row_to_json" {
+                continue;
+            }
+            
+            let Some(table) = tables.iter()
+                .find(|(table, alias)| match alias {
+                    Some(alias) => alias.eq_ignore_ascii_case(reference),
+                    None => table.eq_ignore_ascii_case(reference),
+                })
+                .map(|(table, _)| table.as_str()) else { continue };
+            
+            let columns = Self::table_columns(conn, table);
+            if columns.is_empty() {
+                continue;
+            }
+            
+            let fields: Vec<String> = columns.iter()
+                .map(|column| {
+                    let column_ref = format!("{reference}.\"{column}\"");
+                    let value = match SchemaTypeMapper::get_type_from_schema(conn, table, column).and_then(PgType::from_oid) {
+                        None | Some(PgType::Text | PgType::Varchar | PgType::Char | PgType::Uuid) => column_ref,
+                        Some(pg_type) => format!("json(pgsqlite_json_typed_value({column_ref}, {}))", pg_type.to_oid()),
+                    };
+                    format!("'{}', {value}", column.replace('\'', "''"))
+                })
+                .collect();
+            
+            let json_construction = format!("json_object({})", fields.join(", "));
+            let mut replacement = match pretty {
+                Some(pretty) => format!("row_to_json({json_construction}, {pretty})"),
+                None => json_construction,
+            };
+            
+            // Keep PostgreSQL's column name for an unaliased call in the select list
+            let rest = query[whole.end()..].trim_start();
+            if rest.starts_with(',') || rest.get(..4).is_some_and(|w| w.eq_ignore_ascii_case("from")) {
+                replacement.push_str(&format!(" AS {function}"));
+            }
+            
+            result.replace_range(whole.start()..whole.end(), &replacement);
+        }
+        
+        result
+    }
+    
+    /// Column names of a table in declaration order
+    fn table_columns(conn: &Connection, table: &str) -> Vec<String> {
+        let Ok(mut stmt) = conn.prepare("SELECT name FROM pragma_table_info(?1) ORDER BY cid") else {
+            return Vec::new();
+        };
+        stmt.query_map([table], |row| row.get(0))
+            .map(|rows| rows.filter_map(Result::ok).collect())
+            .unwrap_or_default()
+    }
+    
     /// Extract column names from a SELECT clause
     fn extract_columns_from_select(select_clause: &str) -> Option<Vec<String>> {
         // Simple extraction of column names from SELECT clause
@@ -159,6 +246,29 @@ mod tests {
         assert_eq!(columns, Some(vec!["user_name".to_string(), "age".to_string()]));
     }
     
+    #[test]
+    fn test_translate_row_references() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute("CREATE TABLE items (id INTEGER PRIMARY KEY, label TEXT, created INTEGER)", []).unwrap();
+        conn.execute("CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT)", []).unwrap();
+        conn.execute("INSERT INTO __pgsqlite_schema VALUES ('items', 'created', 'TIMESTAMP', 'INTEGER')", []).unwrap();
+        
+        assert_eq!(
+            RowToJsonTranslator::translate_row_references("SELECT row_to_json(i) FROM items i WHERE i.id = 1", &conn),
+            "SELECT json_object('id', json(pgsqlite_json_typed_value(i.\"id\", 23)), 'label', i.\"label\", \
+             'created', json(pgsqlite_json_typed_value(i.\"created\", 1114))) AS row_to_json FROM items i WHERE i.id = 1"
+        );
+        assert_eq!(
+            RowToJsonTranslator::translate_row_references("SELECT id, row_to_json(items, true) AS doc FROM items", &conn),
+            "SELECT id, row_to_json(json_object('id', json(pgsqlite_json_typed_value(items.\"id\", 23)), 'label', items.\"label\", \
+             'created', json(pgsqlite_json_typed_value(items.\"created\", 1114))), true) AS doc FROM items"
+        );
+        
+        // Arguments that aren't table references are left to the scalar functions
+        let query = "SELECT to_json(label) FROM items";
+        assert_eq!(RowToJsonTranslator::translate_row_references(query, &conn), query);
+    }
+    
     #[test]
     fn test_translate_subquery_pattern() {
         let query = "SELECT row_to_json(t) FROM (SELECT name, age FROM users WHERE id = 1) t";
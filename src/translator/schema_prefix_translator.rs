@@ -16,7 +16,7 @@ impl SchemaPrefixTranslator {
         let catalog_tables = [
             "pg_class", "pg_namespace", "pg_attribute", "pg_type", 
             "pg_constraint", "pg_index", "pg_attrdef", "pg_am",
-            "pg_enum", "pg_range"
+            "pg_enum", "pg_range", "pg_collation", "pg_inherits"
         ];
         
         for table in &catalog_tables {
@@ -31,7 +31,8 @@ impl SchemaPrefixTranslator {
             "pg_table_is_visible", "pg_get_userbyid", "pg_get_constraintdef",
             "format_type", "pg_get_expr", "pg_get_indexdef", "version",
             "current_database", "current_schema", "current_user", "session_user",
-            "pg_backend_pid", "pg_is_in_recovery", "current_schemas"
+            "pg_backend_pid", "pg_is_in_recovery", "current_schemas",
+            "pg_cancel_backend", "pg_terminate_backend", "pg_partition_ancestors"
         ];
         
         for func in &catalog_functions {
@@ -0,0 +1,216 @@
+//! Helpers for scanning SQL text without parsing it: matching parentheses, splitting on
+//! top-level commas, finding matches outside nested queries and telling whether a
+//! position falls inside a string literal.
+//!
+//! The translators rewrite queries with regular expressions and then use these to
+//! find the extent of what they matched, so quoted text is never mistaken for SQL.
+
+use regex::Regex;
+use std::ops::Range;
+
+/// The position of the parenthesis or bracket closing the one at `open`, skipping
+/// string literals and quoted identifiers
+pub fn matching_paren<S: AsRef<[u8]> + ?Sized>(sql: &S, open: usize) -> Option<usize> {
+    let bytes = sql.as_ref();
+    let mut depth = 0;
+    let mut i = open;
+    while i < bytes.len() {
+        match bytes[i] {
+            b'\'' | b'"' => {
+                i = quoted_end(bytes, i);
+                continue;
+            }
+            b'(' | b'[' => depth += 1,
+            b')' | b']' => {
+                depth -= 1;
+                if depth == 0 {
+                    return Some(i);
+                }
+            }
+            _ => {}
+        }
+        i += 1;
+    }
+    None
+}
+
+/// The position of the parenthesis or bracket opening the one at `close`, skipping
+/// string literals and quoted identifiers
+pub fn opening_paren<S: AsRef<[u8]> + ?Sized>(sql: &S, close: usize) -> Option<usize> {
+    let bytes = sql.as_ref();
+    let mut depth = 0;
+    let mut quote = None;
+    for i in (0..=close).rev() {
+        match (quote, bytes[i]) {
+            (Some(q), b) if b == q => quote = None,
+            (Some(_), _) => {}
+            (None, b @ (b'\'' | b'"')) => quote = Some(b),
+            (None, b')' | b']') => depth += 1,
+            (None, b'(' | b'[') => {
+                depth -= 1;
+                if depth == 0 {
+                    return Some(i);
+                }
+            }
+            _ => {}
+        }
+    }
+    None
+}
+
+/// Index just past the string literal or quoted identifier opening at `start`, where a
+/// doubled quote stands for the quote itself
+pub fn quoted_end<S: AsRef<[u8]> + ?Sized>(sql: &S, start: usize) -> usize {
+    let bytes = sql.as_ref();
+    let quote = bytes[start];
+    let mut i = start + 1;
+    while i < bytes.len() {
+        if bytes[i] == quote {
+            if bytes.get(i + 1) == Some(&quote) {
+                i += 2;
+                continue;
+            }
+            return i + 1;
+        }
+        i += 1;
+    }
+    bytes.len()
+}
+
+/// The ranges of the items of a comma-separated list, split at the commas outside
+/// parentheses, brackets and quotes. The ranges are not trimmed; a blank list has no items.
+pub fn top_level_ranges(list: &str) -> Vec<Range<usize>> {
+    let mut items = Vec::new();
+    let mut depth = 0;
+    let mut quote = None;
+    let mut start = 0;
+    for (i, c) in list.char_indices() {
+        match (quote, c) {
+            (Some(q), c) if c == q => quote = None,
+            (Some(_), _) => {}
+            (None, '\'' | '"') => quote = Some(c),
+            (None, '(' | '[') => depth += 1,
+            (None, ')' | ']') => depth -= 1,
+            (None, ',') if depth == 0 => {
+                items.push(start..i);
+                start = i + 1;
+            }
+            _ => {}
+        }
+    }
+    if !list[start..].trim().is_empty() || !items.is_empty() {
+        items.push(start..list.len());
+    }
+    items
+}
+
+/// The trimmed items of a comma-separated list, split at the commas outside
+/// parentheses, brackets and quotes
+pub fn split_top_level(list: &str) -> Vec<&str> {
+    top_level_ranges(list).into_iter().map(|range| list[range].trim()).collect()
+}
+
+/// The parenthesis nesting depth at the end of `text`, ignoring string literals
+pub fn paren_depth(text: &str) -> i32 {
+    let mut depth = 0;
+    let mut in_string = false;
+    for c in text.chars() {
+        match c {
+            '\'' => in_string = !in_string,
+            '(' if !in_string => depth += 1,
+            ')' if !in_string => depth -= 1,
+            _ => {}
+        }
+    }
+    depth
+}
+
+/// Matches of `regex` in `text` outside parentheses and string literals
+pub fn top_level_matches<'t>(text: &'t str, regex: &'t Regex) -> impl Iterator<Item = Range<usize>> + 't {
+    regex.find_iter(text)
+        .filter(|m| !in_string_literal(text, m.start()) && paren_depth(&text[..m.start()]) == 0)
+        .map(|m| m.range())
+}
+
+/// Start of the (sub)query containing `pos`: just after the innermost unclosed parenthesis
+pub fn statement_start(sql: &str, pos: usize) -> usize {
+    let mut depth = 0;
+    for (i, c) in sql[..pos].char_indices().rev() {
+        match c {
+            ')' => depth += 1,
+            '(' if depth == 0 => return i + 1,
+            '(' => depth -= 1,
+            _ => {}
+        }
+    }
+    0
+}
+
+/// Whether `pos` falls inside a single-quoted string literal
+pub fn in_string_literal(sql: &str, pos: usize) -> bool {
+    sql[..pos].matches('\'').count() % 2 == 1
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_matching_paren() {
+        let sql = "f(a, g(b), 'x)', \")\") + 1";
+        assert_eq!(matching_paren(sql, 1), Some(sql.len() - 5));
+        assert_eq!(matching_paren(sql, 6), Some(8));
+        assert_eq!(matching_paren("f(a", 1), None);
+        assert_eq!(matching_paren("ARRAY[1, (2)]", 5), Some(12));
+        assert_eq!(quoted_end("'it''s' || x", 0), 7);
+        assert_eq!(quoted_end("\"open", 0), 5);
+    }
+
+    #[test]
+    fn test_opening_paren() {
+        let sql = "f(a, g(b), 'x(', \"(\") + 1";
+        assert_eq!(opening_paren(sql, sql.len() - 5), Some(1));
+        assert_eq!(opening_paren(sql, 8), Some(6));
+        assert_eq!(opening_paren("a[(b)]", 5), Some(1));
+        assert_eq!(opening_paren("a)", 1), None);
+    }
+
+    #[test]
+    fn test_split_top_level() {
+        assert_eq!(
+            split_top_level("a, f(b, c), 'd,e', \"f,g\", ARRAY[1, 2]"),
+            vec!["a", "f(b, c)", "'d,e'", "\"f,g\"", "ARRAY[1, 2]"]
+        );
+        assert_eq!(split_top_level("a,"), vec!["a", ""]);
+        assert!(split_top_level("  ").is_empty());
+        assert_eq!(top_level_ranges("a, b"), vec![0..1, 2..4]);
+    }
+
+    #[test]
+    fn test_paren_depth() {
+        assert_eq!(paren_depth("f(a, '(', g(b"), 2);
+        assert_eq!(paren_depth("f(a)"), 0);
+    }
+
+    #[test]
+    fn test_top_level_matches() {
+        let regex = Regex::new(r"(?i)\bORDER\s+BY\b").unwrap();
+        let sql = "SELECT * FROM (SELECT a FROM t ORDER BY a) s WHERE b = 'ORDER BY' ORDER BY b";
+        let matches: Vec<_> = top_level_matches(sql, &regex).collect();
+        assert_eq!(matches.len(), 1);
+        assert_eq!(&sql[matches[0].end..], " b");
+    }
+
+    #[test]
+    fn test_statement_start() {
+        let sql = "SELECT * FROM (SELECT a FROM t ORDER BY a) s WHERE b = 1";
+        assert_eq!(statement_start(sql, sql.find("a FROM").unwrap()), 15);
+        assert_eq!(statement_start(sql, sql.len()), 0);
+    }
+
+    #[test]
+    fn test_in_string_literal() {
+        assert!(in_string_literal("SELECT 'a, b' FROM t", 10));
+        assert!(!in_string_literal("SELECT 'it''s' FROM t", 15));
+    }
+}
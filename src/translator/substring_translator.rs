@@ -0,0 +1,212 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use super::sql_scan::{in_string_literal, matching_paren};
+
+/// The start of a substring() call
+static SUBSTRING_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bSUBSTRING\s*\(").unwrap()
+});
+
+/// Keywords separating the arguments of the SQL-standard substring() forms
+const KEYWORDS: &[&str] = &["FROM", "FOR", "SIMILAR", "ESCAPE"];
+
+/// Translates the SQL-standard forms of substring().
+///
+/// `substring(s FROM start FOR count)` and its `FROM`-only and `FOR`-only variants become
+/// SQLite's `substr()`. When the `FROM` argument is a string literal it is a pattern, as in
+/// PostgreSQL: `substring(s FROM 'regex')` calls `substring_regex()`, which returns the
+/// first match or its first capture group, and `substring(s FROM pattern FOR escape)` or
+/// `substring(s SIMILAR pattern ESCAPE escape)` calls `substring_similar()`, which returns
+/// the part of a SIMILAR TO pattern between the escape-double-quote markers.
+pub struct SubstringTranslator;
+
+impl SubstringTranslator {
+    /// Check if the query calls substring() with FROM, FOR or SIMILAR
+    pub fn needs_translation(query: &str) -> bool {
+        SUBSTRING_REGEX.is_match(query) && Self::next_call(query).is_some()
+    }
+
+    /// Rewrite each keyword form of substring() as a function call SQLite understands
+    pub fn translate_query(query: &str) -> String {
+        if !SUBSTRING_REGEX.is_match(query) {
+            return query.to_string();
+        }
+
+        // Each rewrite removes one keyword form, and calls nested in its arguments are
+        // found again on the next pass
+        let mut result = query.to_string();
+        while let Some((range, replacement)) = Self::next_call(&result) {
+            result.replace_range(range, &replacement);
+        }
+
+        if result != query {
+            debug!("Translated substring: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// Find the first substring() call using keywords, with its translation
+    fn next_call(sql: &str) -> Option<(std::ops::Range<usize>, String)> {
+        for m in SUBSTRING_REGEX.find_iter(sql) {
+            if in_string_literal(sql, m.start()) {
+                continue;
+            }
+            let open = m.end() - 1;
+            let Some(close) = matching_paren(sql, open) else {
+                continue;
+            };
+            let Some(replacement) = Self::translate_arguments(&sql[open + 1..close]) else {
+                continue;
+            };
+            return Some((m.start()..close + 1, replacement));
+        }
+        None
+    }
+
+    /// The call equivalent to `substring(<args>)`, if the arguments use keywords
+    fn translate_arguments(args: &str) -> Option<String> {
+        let (string, clauses) = Self::split_keywords(args);
+        if clauses.is_empty() {
+            return None;
+        }
+        let clause = |keyword: &str| clauses.iter().find(|(k, _)| *k == keyword).map(|(_, expr)| *expr);
+
+        match (clause("FROM"), clause("FOR"), clause("SIMILAR"), clause("ESCAPE")) {
+            (None, None, Some(pattern), Some(escape)) if clauses.len() == 2 => {
+                Some(format!("substring_similar({string}, {pattern}, {escape})"))
+            }
+            (Some(pattern), escape, None, None) if Self::is_text_literal(pattern) => match escape {
+                Some(escape) if clauses.len() == 2 => Some(format!("substring_similar({string}, {pattern}, {escape})")),
+                None if clauses.len() == 1 => Some(format!("substring_regex({string}, {pattern})")),
+                _ => None,
+            },
+            (Some(start), Some(count), None, None) if clauses.len() == 2 => Some(format!("substr({string}, {start}, {count})")),
+            (Some(start), None, None, None) if clauses.len() == 1 => Some(format!("substr({string}, {start})")),
+            (None, Some(count), None, None) if clauses.len() == 1 => Some(format!("substr({string}, 1, {count})")),
+            _ => None,
+        }
+    }
+
+    /// Split arguments on the keywords outside parentheses and quotes
+    fn split_keywords(args: &str) -> (&str, Vec<(&'static str, &str)>) {
+        let bytes = args.as_bytes();
+        let mut depth = 0;
+        let mut quote = None;
+        let mut pieces = Vec::new();
+        let mut keyword = None;
+        let mut start = 0;
+        let mut i = 0;
+        while i < bytes.len() {
+            let c = bytes[i];
+            match (quote, c) {
+                (Some(q), c) if c == q => quote = None,
+                (Some(_), _) => {}
+                (None, b'\'' | b'"') => quote = Some(c),
+                (None, b'(') => depth += 1,
+                (None, b')') => depth -= 1,
+                (None, c) if depth == 0 && c.is_ascii_alphabetic()
+                    && (i == 0 || !(bytes[i - 1].is_ascii_alphanumeric() || bytes[i - 1] == b'_' || bytes[i - 1] == b'$')) => {
+                    let end = bytes[i..].iter().position(|b| !(b.is_ascii_alphanumeric() || *b == b'_')).map_or(bytes.len(), |n| i + n);
+                    if let Some(found) = KEYWORDS.iter().find(|k| args[i..end].eq_ignore_ascii_case(k)) {
+                        pieces.push((keyword, args[start..i].trim()));
+                        keyword = Some(*found);
+                        start = end;
+                    }
+                    i = end;
+                    continue;
+                }
+                _ => {}
+            }
+            i += 1;
+        }
+        pieces.push((keyword, args[start..].trim()));
+
+        let string = pieces.remove(0).1;
+        let clauses = pieces.into_iter().map(|(k, expr)| (k.unwrap(), expr)).collect();
+        (string, clauses)
+    }
+
+    /// A quoted string, optionally cast to a text type, which PostgreSQL reads as a pattern
+    fn is_text_literal(expr: &str) -> bool {
+        let literal = match expr.rsplit_once("::") {
+            Some((literal, cast)) if ["text", "varchar", "character varying"].iter().any(|t| cast.trim().eq_ignore_ascii_case(t)) => literal.trim_end(),
+            _ => expr,
+        };
+        literal.len() >= 2 && literal.starts_with('\'') && literal.ends_with('\'')
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_positional_forms() {
+        assert_eq!(
+            SubstringTranslator::translate_query("SELECT substring(name FROM 2 FOR 3) FROM users"),
+            "SELECT substr(name, 2, 3) FROM users"
+        );
+        assert_eq!(
+            SubstringTranslator::translate_query("SELECT SUBSTRING(name from 4) AS rest FROM users"),
+            "SELECT substr(name, 4) AS rest FROM users"
+        );
+        assert_eq!(
+            SubstringTranslator::translate_query("SELECT substring(name FOR 1) FROM users"),
+            "SELECT substr(name, 1, 1) FROM users"
+        );
+        assert_eq!(
+            SubstringTranslator::translate_query("SELECT substring(name FOR $2 FROM $1) FROM users"),
+            "SELECT substr(name, $1, $2) FROM users"
+        );
+        assert_eq!(
+            SubstringTranslator::translate_query("SELECT substring(lower(name) FROM position('@' in email) + 1) FROM users"),
+            "SELECT substr(lower(name), position('@' in email) + 1) FROM users"
+        );
+    }
+
+    #[test]
+    fn test_pattern_forms() {
+        assert_eq!(
+            SubstringTranslator::translate_query(r"SELECT substring(sku FROM '\d+') FROM items"),
+            r"SELECT substring_regex(sku, '\d+') FROM items"
+        );
+        assert_eq!(
+            SubstringTranslator::translate_query("SELECT substring(email from '@(.*)$'::text) FROM users"),
+            "SELECT substring_regex(email, '@(.*)$'::text) FROM users"
+        );
+        assert_eq!(
+            SubstringTranslator::translate_query("SELECT substring('foobar' FROM '%#\"o_b#\"%' FOR '#')"),
+            "SELECT substring_similar('foobar', '%#\"o_b#\"%', '#')"
+        );
+        assert_eq!(
+            SubstringTranslator::translate_query("SELECT substring('foobar' SIMILAR '%#\"o_b#\"%' ESCAPE '#')"),
+            "SELECT substring_similar('foobar', '%#\"o_b#\"%', '#')"
+        );
+    }
+
+    #[test]
+    fn test_nested_calls() {
+        assert_eq!(
+            SubstringTranslator::translate_query("SELECT substring(substring(code FROM 2) FROM 1 FOR 3) FROM t"),
+            "SELECT substr(substr(code, 2), 1, 3) FROM t"
+        );
+        assert_eq!(
+            SubstringTranslator::translate_query("SELECT substring((SELECT name FROM users LIMIT 1) FROM 2)"),
+            "SELECT substr((SELECT name FROM users LIMIT 1), 2)"
+        );
+    }
+
+    #[test]
+    fn test_other_calls_unchanged() {
+        for query in [
+            "SELECT substring(name, 2, 3) FROM users",
+            "SELECT substr(name, 1) FROM users",
+            "SELECT 'substring(x FROM 2)' FROM t",
+            "SELECT my_substring(name) FROM users",
+        ] {
+            assert!(!SubstringTranslator::needs_translation(query), "{query}");
+            assert_eq!(SubstringTranslator::translate_query(query), query);
+        }
+    }
+}
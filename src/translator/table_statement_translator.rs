@@ -0,0 +1,49 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+
+/// `TABLE [ONLY] name [*] [ORDER BY ...] [LIMIT ...] ...`
+static TABLE_STATEMENT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?is)^\s*TABLE\s+((?:ONLY\s+)?(?:(?:"[^"]+"|\w+)\.)*(?:"[^"]+"|\w+))(?:\s*\*)?(\s.*|;.*)?$"#).unwrap()
+});
+
+/// Translates the `TABLE name` statement, PostgreSQL's shorthand for
+/// `SELECT * FROM name`, which SQLite doesn't know. Trailing clauses such as
+/// ORDER BY, LIMIT and OFFSET are kept as they are.
+pub struct TableStatementTranslator;
+
+impl TableStatementTranslator {
+    /// Check if the statement is a TABLE statement
+    pub fn needs_translation(query: &str) -> bool {
+        TABLE_STATEMENT_REGEX.is_match(query)
+    }
+
+    /// Expand a TABLE statement into the equivalent SELECT
+    pub fn translate_query(query: &str) -> String {
+        match TABLE_STATEMENT_REGEX.captures(query) {
+            Some(caps) => format!("SELECT * FROM {}{}", &caps[1], caps.get(2).map_or("", |m| m.as_str())),
+            None => query.to_string(),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_table_statement() {
+        assert_eq!(TableStatementTranslator::translate_query("TABLE books"), "SELECT * FROM books");
+        assert_eq!(
+            TableStatementTranslator::translate_query("table genres ORDER BY name LIMIT 5;"),
+            "SELECT * FROM genres ORDER BY name LIMIT 5;"
+        );
+        assert_eq!(
+            TableStatementTranslator::translate_query("TABLE ONLY public.\"Order Items\"* OFFSET 2"),
+            "SELECT * FROM ONLY public.\"Order Items\" OFFSET 2"
+        );
+
+        for query in ["CREATE TABLE books (id INTEGER)", "SELECT * FROM books", "TABLESAMPLE", "TABLE"] {
+            assert!(!TableStatementTranslator::needs_translation(query), "{query}");
+        }
+    }
+}
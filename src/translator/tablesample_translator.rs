@@ -0,0 +1,122 @@
+use crate::error::PgError;
+use crate::PgSqliteError;
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+
+/// `table [[AS] alias] TABLESAMPLE method (percentage) [REPEATABLE (seed)]`
+static TABLESAMPLE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?i)(?:\bFROM|\bJOIN|,)\s+("[^"]+"|[\w.]+)(?:\s+(?:AS\s+)?(\w+))?\s+TABLESAMPLE\s+(\w+)\s*\(\s*([^()]*?)\s*\)(?:\s+REPEATABLE\s*\(\s*([^()]*?)\s*\))?"#).unwrap()
+});
+
+/// Sampling resolution: rows are kept when their bucket in 0..SAMPLE_BUCKETS falls
+/// below the percentage scaled to it
+const SAMPLE_BUCKETS: i64 = 1_000_000;
+
+/// Translates the TABLESAMPLE clause, which SQLite lacks, into a filtered subquery.
+///
+/// BERNOULLI keeps each row with the given probability. SYSTEM samples pages in
+/// PostgreSQL; SQLite gives no access to them, so it is approximated row by row as well.
+/// With REPEATABLE the choice hashes the rowid with the seed instead of calling random(),
+/// so the same seed returns the same rows while the table is unchanged.
+pub struct TablesampleTranslator;
+
+impl TablesampleTranslator {
+    /// Check if the query has a TABLESAMPLE clause
+    pub fn needs_translation(query: &str) -> bool {
+        query.to_uppercase().contains("TABLESAMPLE")
+    }
+
+    /// Replace each sampled table with a subquery keeping a fraction of its rows
+    pub fn translate_query(query: &str) -> Result<String, PgSqliteError> {
+        if !Self::needs_translation(query) {
+            return Ok(query.to_string());
+        }
+
+        let mut result = query.to_string();
+        let matches: Vec<_> = TABLESAMPLE_REGEX.captures_iter(query).collect();
+
+        // Work from the last clause backwards so earlier offsets stay valid
+        for caps in matches.into_iter().rev() {
+            let whole = caps.get(0).unwrap();
+            let clause = caps.get(1).unwrap().start()..whole.end();
+            let table = &caps[1];
+            let alias = caps.get(2).map_or_else(|| table.rsplit('.').next().unwrap_or(table), |m| m.as_str());
+
+            let method = caps[3].to_lowercase();
+            if method != "bernoulli" && method != "system" {
+                return Err(PgError::Generic {
+                    code: "42704".to_string(), // undefined_object
+                    message: format!("tablesample method {method} does not exist"),
+                }.into());
+            }
+
+            let percentage: f64 = caps[4].parse().map_err(|_| Self::invalid_argument(&caps[4]))?;
+            if !(0.0..=100.0).contains(&percentage) {
+                return Err(PgError::Generic {
+                    code: "2202H".to_string(), // invalid_tablesample_argument
+                    message: "sample percentage must be between 0 and 100".to_string(),
+                }.into());
+            }
+            let threshold = (percentage / 100.0 * SAMPLE_BUCKETS as f64).round() as i64;
+
+            let bucket = match caps.get(5) {
+                Some(seed) => {
+                    let seed: f64 = seed.as_str().parse().map_err(|_| Self::invalid_argument(seed.as_str()))?;
+                    // Knuth's multiplicative hash of the rowid offset by the seed, both taken
+                    // modulo 2^32. The multiplier 2654435761 is split into 40503 * 65536 + 31153
+                    // so no product leaves the INTEGER range, where SQLite would switch to REAL.
+                    let key = format!("(((rowid & 4294967295) + {}) & 4294967295)", (seed as i64).rem_euclid(1 << 32));
+                    format!("(({key} * 31153 + (({key} * 40503) & 65535) * 65536) & 4294967295) % {SAMPLE_BUCKETS}")
+                }
+                None => format!("abs(random() % {SAMPLE_BUCKETS})"),
+            };
+
+            let replacement = format!("(SELECT * FROM {table} WHERE {bucket} < {threshold}) AS {alias}");
+            debug!("Translated TABLESAMPLE: {} -> {}", &query[clause.clone()], replacement);
+            result.replace_range(clause, &replacement);
+        }
+
+        Ok(result)
+    }
+
+    fn invalid_argument(argument: &str) -> PgSqliteError {
+        PgError::Generic {
+            code: "2202H".to_string(), // invalid_tablesample_argument
+            message: format!("invalid TABLESAMPLE argument: {argument}"),
+        }.into()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_tablesample_translation() {
+        assert_eq!(
+            TablesampleTranslator::translate_query("SELECT * FROM books TABLESAMPLE BERNOULLI(10) WHERE year > 2000").unwrap(),
+            "SELECT * FROM (SELECT * FROM books WHERE abs(random() % 1000000) < 100000) AS books WHERE year > 2000"
+        );
+        assert_eq!(
+            TablesampleTranslator::translate_query("SELECT b.id FROM main.books AS b TABLESAMPLE SYSTEM (2.5) REPEATABLE (42)").unwrap(),
+            "SELECT b.id FROM (SELECT * FROM main.books WHERE (((((rowid & 4294967295) + 42) & 4294967295) * 31153 \
+             + (((((rowid & 4294967295) + 42) & 4294967295) * 40503) & 65535) * 65536) & 4294967295) % 1000000 < 25000) AS b"
+        );
+        // Negative and large seeds are reduced modulo 2^32 before they reach the query
+        assert!(
+            TablesampleTranslator::translate_query("SELECT * FROM books TABLESAMPLE BERNOULLI (50) REPEATABLE (-1)").unwrap()
+                .contains("((rowid & 4294967295) + 4294967295) & 4294967295")
+        );
+    }
+
+    #[test]
+    fn test_tablesample_errors() {
+        let err = TablesampleTranslator::translate_query("SELECT * FROM books TABLESAMPLE system_rows(10)").unwrap_err();
+        assert_eq!(err.pg_error_code(), "42704");
+        assert!(err.to_string().contains("tablesample method system_rows does not exist"));
+
+        let err = TablesampleTranslator::translate_query("SELECT * FROM books TABLESAMPLE BERNOULLI(150)").unwrap_err();
+        assert_eq!(err.pg_error_code(), "2202H");
+    }
+}
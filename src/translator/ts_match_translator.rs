@@ -0,0 +1,256 @@
+use tracing::debug;
+use super::sql_scan::{in_string_literal, matching_paren, opening_paren};
+
+/// The functions whose result is a tsquery
+const TSQUERY_FUNCTIONS: &[&str] = &["to_tsquery", "plainto_tsquery", "phraseto_tsquery", "websearch_to_tsquery"];
+
+/// What an operand of `@@` is, as far as the query text tells
+#[derive(Debug, Clone, Copy, PartialEq)]
+enum OperandKind {
+    /// A string literal or parameter, which PostgreSQL casts to the type the other side needs
+    Text,
+    /// A tsquery function call or a value cast to tsquery
+    Query,
+    /// Anything else: columns, to_tsvector() and other calls
+    Other,
+}
+
+struct Operand {
+    start: usize,
+    end: usize,
+    /// The operand without a cast to tsquery or text
+    expression: String,
+    kind: OperandKind,
+}
+
+/// Translates the full text search match operator `@@`, which SQLite doesn't have, to
+/// `pgsqlite_ts_match(tsvector, tsquery)`.
+///
+/// Either side may hold the tsvector, so `to_tsquery('cat') @@ to_tsvector(body)` works like
+/// `to_tsvector(body) @@ to_tsquery('cat')`. A text literal or parameter matched against a
+/// tsvector is converted with pgsqlite_text_to_tsquery(), which follows to_tsquery() when
+/// the text has tsquery operators and plainto_tsquery() otherwise; text on the vector side
+/// goes through to_tsvector().
+pub struct TsMatchTranslator;
+
+impl TsMatchTranslator {
+    /// Check if the query uses the @@ operator outside of string literals
+    pub fn needs_translation(query: &str) -> bool {
+        query.contains("@@") && Self::find_operator(query, 0).is_some()
+    }
+
+    /// Replace every `a @@ b` with a pgsqlite_ts_match() call
+    pub fn translate_query(query: &str) -> String {
+        let mut result = query.to_string();
+        let mut from = 0;
+        while let Some(at) = Self::find_operator(&result, from) {
+            let (Some(left), Some(right)) = (Self::operand_before(&result, at), Self::operand_after(&result, at + 2)) else {
+                from = at + 2;
+                continue;
+            };
+
+            // The tsvector goes first, whichever side it was written on
+            let (vector, tsquery) = if left.kind != OperandKind::Other && right.kind == OperandKind::Other {
+                (&right, &left)
+            } else {
+                (&left, &right)
+            };
+            let vector = match vector.kind {
+                OperandKind::Text => format!("to_tsvector({})", vector.expression),
+                _ => vector.expression.clone(),
+            };
+            let tsquery = match tsquery.kind {
+                OperandKind::Text => format!("pgsqlite_text_to_tsquery({})", tsquery.expression),
+                _ => tsquery.expression.clone(),
+            };
+
+            let replacement = format!("pgsqlite_ts_match({vector}, {tsquery})");
+            result.replace_range(left.start..right.end, &replacement);
+            from = left.start + replacement.len();
+        }
+
+        if result != query {
+            debug!("Translated @@ operator: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// The position of the next @@ at or after `from` that isn't inside a string literal
+    fn find_operator(query: &str, from: usize) -> Option<usize> {
+        let bytes = query.as_bytes();
+        let mut in_string = in_string_literal(query, from);
+        let mut i = from;
+        while i + 1 < bytes.len() {
+            match bytes[i] {
+                b'\'' => in_string = !in_string,
+                b'@' if !in_string && bytes[i + 1] == b'@' => return Some(i),
+                _ => {}
+            }
+            i += 1;
+        }
+        None
+    }
+
+    /// The operand ending right before `end`, skipping whitespace
+    fn operand_before(query: &str, end: usize) -> Option<Operand> {
+        let bytes = query.as_bytes();
+        let end = query[..end].trim_end().len();
+        let mut start = end;
+        if start == 0 {
+            return None;
+        }
+
+        let mut kind = OperandKind::Other;
+        match bytes[start - 1] {
+            b'\'' => {
+                // Back to the opening quote, stepping over doubled quotes
+                start -= 1;
+                loop {
+                    let quote = query[..start].rfind('\'')?;
+                    if quote > 0 && bytes[quote - 1] == b'\'' {
+                        start = quote - 1;
+                    } else {
+                        start = quote;
+                        break;
+                    }
+                }
+                kind = OperandKind::Text;
+            }
+            b')' => {
+                start = opening_paren(query, start - 1)?;
+                start = Self::identifier_start(query, start);
+            }
+            _ => {
+                start = Self::identifier_start(query, start);
+                if start == end {
+                    return None;
+                }
+                if bytes[start] == b'$' {
+                    kind = OperandKind::Text;
+                }
+            }
+        }
+
+        // A cast written after the operand: look at what it casts
+        let mut expression = query[start..end].to_string();
+        if let Some(base_end) = query[..start].strip_suffix("::").map(str::len)
+            && let Some(base) = Self::operand_before(query, base_end)
+        {
+            let cast_type = expression.to_lowercase();
+            (expression, kind) = match cast_type.as_str() {
+                "tsquery" if base.kind == OperandKind::Text => (base.expression, OperandKind::Text),
+                "tsquery" => (query[base.start..end].to_string(), OperandKind::Query),
+                "text" | "varchar" => (base.expression, base.kind),
+                _ => (query[base.start..end].to_string(), OperandKind::Other),
+            };
+            start = base.start;
+        } else if kind == OperandKind::Other && Self::is_tsquery_call(&expression) {
+            kind = OperandKind::Query;
+        }
+        Some(Operand { start, end, expression, kind })
+    }
+
+    /// The operand starting after `start`, skipping whitespace
+    fn operand_after(query: &str, start: usize) -> Option<Operand> {
+        let bytes = query.as_bytes();
+        let start = start + (query[start..].len() - query[start..].trim_start().len());
+        let mut end = start;
+
+        let mut kind = OperandKind::Other;
+        match bytes.get(start).copied()? {
+            b'\'' => {
+                // Up to the closing quote, stepping over doubled quotes
+                end += 1;
+                loop {
+                    end += query[end..].find('\'')? + 1;
+                    if bytes.get(end) == Some(&b'\'') {
+                        end += 1;
+                    } else {
+                        break;
+                    }
+                }
+                kind = OperandKind::Text;
+            }
+            _ => {
+                while end < bytes.len() && (bytes[end].is_ascii_alphanumeric() || matches!(bytes[end], b'_' | b'.' | b'$' | b'"')) {
+                    end += 1;
+                }
+                if bytes[start] == b'$' && end > start {
+                    kind = OperandKind::Text;
+                }
+                // A function call or parenthesized expression
+                let call_start = end + (query[end..].len() - query[end..].trim_start().len());
+                if bytes.get(call_start) == Some(&b'(') && (end > start || call_start == start) {
+                    end = matching_paren(query, call_start)? + 1;
+                }
+                if end == start {
+                    return None;
+                }
+            }
+        }
+
+        let mut expression = query[start..end].to_string();
+        if query[end..].starts_with("::") {
+            let type_start = end + 2;
+            let type_end = type_start + query[type_start..]
+                .find(|c: char| !c.is_ascii_alphanumeric() && c != '_')
+                .unwrap_or(query.len() - type_start);
+            let cast_type = query[type_start..type_end].to_lowercase();
+            (expression, kind) = match cast_type.as_str() {
+                "tsquery" if kind == OperandKind::Text => (expression, OperandKind::Text),
+                "tsquery" => (query[start..type_end].to_string(), OperandKind::Query),
+                "text" | "varchar" => (expression, kind),
+                _ => (query[start..type_end].to_string(), OperandKind::Other),
+            };
+            end = type_end;
+        } else if kind == OperandKind::Other && Self::is_tsquery_call(&expression) {
+            kind = OperandKind::Query;
+        }
+        Some(Operand { start, end, expression, kind })
+    }
+
+    /// Where the identifier, column reference or parameter ending at `end` starts
+    fn identifier_start(query: &str, end: usize) -> usize {
+        let bytes = query.as_bytes();
+        let mut start = end;
+        while start > 0 && (bytes[start - 1].is_ascii_alphanumeric() || matches!(bytes[start - 1], b'_' | b'.' | b'$' | b'"')) {
+            start -= 1;
+        }
+        start
+    }
+
+    fn is_tsquery_call(expression: &str) -> bool {
+        let name = expression.split('(').next().unwrap_or("").trim().to_lowercase();
+        let name = name.rsplit('.').next().unwrap_or(&name);
+        expression.contains('(') && TSQUERY_FUNCTIONS.contains(&name)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_ts_match_translation() {
+        let cases = [
+            ("SELECT id FROM docs WHERE to_tsvector('english', body) @@ to_tsquery('english', 'cat & dog')",
+             "SELECT id FROM docs WHERE pgsqlite_ts_match(to_tsvector('english', body), to_tsquery('english', 'cat & dog'))"),
+            ("SELECT id FROM docs WHERE to_tsvector(body) @@ 'cat & dog' AND id > 1",
+             "SELECT id FROM docs WHERE pgsqlite_ts_match(to_tsvector(body), pgsqlite_text_to_tsquery('cat & dog')) AND id > 1"),
+            ("SELECT id FROM docs WHERE 'cat''s toy' @@ search_vector",
+             "SELECT id FROM docs WHERE pgsqlite_ts_match(search_vector, pgsqlite_text_to_tsquery('cat''s toy'))"),
+            ("SELECT id FROM docs WHERE plainto_tsquery($1) @@ d.search_vector",
+             "SELECT id FROM docs WHERE pgsqlite_ts_match(d.search_vector, plainto_tsquery($1))"),
+            ("SELECT id FROM docs WHERE search_vector @@ $1::tsquery ORDER BY id",
+             "SELECT id FROM docs WHERE pgsqlite_ts_match(search_vector, pgsqlite_text_to_tsquery($1)) ORDER BY id"),
+            ("SELECT 'a fat cat' @@ 'cat', '@@' FROM docs",
+             "SELECT pgsqlite_ts_match(to_tsvector('a fat cat'), pgsqlite_text_to_tsquery('cat')), '@@' FROM docs"),
+        ];
+        for (query, expected) in cases {
+            assert_eq!(TsMatchTranslator::translate_query(query), expected);
+        }
+
+        assert!(TsMatchTranslator::needs_translation("SELECT 1 WHERE body@@'cat'"));
+        assert!(!TsMatchTranslator::needs_translation("SELECT 'a @@ b' FROM docs"));
+    }
+}
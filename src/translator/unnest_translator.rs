@@ -12,11 +12,11 @@ static UNNEST_REGEX: Lazy<Regex> = Lazy::new(|| {
 
 
 static UNNEST_FROM_CLAUSE_REGEX: Lazy<Regex> = Lazy::new(|| {
-    Regex::new(r"(?i)\bFROM\s+unnest\s*\(\s*([^)]+)\s*\)(?:\s+(?:AS\s+)?(\w+))?").unwrap()
+    Regex::new(r"(?i)\bFROM\s+unnest\s*\(\s*([^)]+)\s*\)(?:\s+(?:AS\s+)?(\w+)(?:\s*\(\s*(\w+)\s*\))?)?").unwrap()
 });
 
 static UNNEST_WITH_ORDINALITY_REGEX: Lazy<Regex> = Lazy::new(|| {
-    Regex::new(r"(?i)\bFROM\s+unnest\s*\(\s*([^)]+)\s*\)\s+WITH\s+ORDINALITY(?:\s+(?:AS\s+)?(\w+))?").unwrap()
+    Regex::new(r"(?i)\bFROM\s+unnest\s*\(\s*([^)]+)\s*\)\s+WITH\s+ORDINALITY(?:\s+(?:AS\s+)?(\w+)(?:\s*\(\s*(\w+)(?:\s*,\s*(\w+))?\s*\))?)?").unwrap()
 });
 
 /// Translates PostgreSQL unnest() function calls to SQLite json_each() equivalents
@@ -64,6 +64,18 @@ impl UnnestTranslator {
         let mut result = sql.to_string();
         let mut metadata = TranslationMetadata::new();
         
+        // The ordinality column is bigint, whatever it ends up being called
+        for captures in UNNEST_WITH_ORDINALITY_REGEX.captures_iter(sql) {
+            let ordinality = captures.get(4).map(|m| m.as_str()).unwrap_or("ordinality");
+            metadata.add_hint(ordinality.to_string(), ColumnTypeHint {
+                source_column: None,
+                suggested_type: Some(PgType::Int8),
+                datetime_subtype: None,
+                is_expression: true,
+                expression_type: Some(ExpressionType::Other),
+            });
+        }
+        
         // Translate unnest calls
         result = Self::translate_from_clause_with_ordinality(&result)?;
         result = Self::translate_from_clause(&result)?;
@@ -75,7 +87,8 @@ impl UnnestTranslator {
         Ok((result, metadata))
     }
     
-    /// Translate FROM unnest(array) AS alias to FROM json_each(array) AS alias
+    /// Translate FROM unnest(array) AS alias to FROM json_each(array) AS alias, or to a
+    /// subquery renaming the value column for FROM unnest(array) AS alias(column)
     fn translate_from_clause(sql: &str) -> Result<String, PgSqliteError> {
         let mut result = sql.to_string();
         
@@ -86,7 +99,13 @@ impl UnnestTranslator {
             let alias = captures.get(2).map(|m| m.as_str()).unwrap_or("unnest_table");
             
             // Convert unnest(array) to json_each(array) with proper column selection
-            let replacement = format!("FROM json_each({array_expr}) AS {alias}");
+            let replacement = match captures.get(3) {
+                Some(column) => format!(
+                    "FROM (SELECT value AS {} FROM json_each({array_expr})) AS {alias}",
+                    column.as_str()
+                ),
+                None => format!("FROM json_each({array_expr}) AS {alias}"),
+            };
             
             replacements.push((captures[0].to_string(), replacement));
         }
@@ -100,7 +119,8 @@ impl UnnestTranslator {
         Ok(result)
     }
     
-    /// Translate FROM unnest(array) WITH ORDINALITY AS alias to CTE with ROW_NUMBER
+    /// Translate FROM unnest(array) WITH ORDINALITY [AS alias[(column[, ordinality])]] to a
+    /// subquery numbering the elements from 1
     fn translate_from_clause_with_ordinality(sql: &str) -> Result<String, PgSqliteError> {
         let mut result = sql.to_string();
         
@@ -109,11 +129,14 @@ impl UnnestTranslator {
         for captures in UNNEST_WITH_ORDINALITY_REGEX.captures_iter(&result) {
             let array_expr = captures[1].trim();
             let alias = captures.get(2).map(|m| m.as_str()).unwrap_or("unnest_table");
+            let value = captures.get(3).map(|m| format!("value AS {}", m.as_str()))
+                .unwrap_or_else(|| "value".to_string());
+            let ordinality = captures.get(4).map(|m| m.as_str()).unwrap_or("ordinality");
             
-            // Convert unnest(array) WITH ORDINALITY to a CTE that includes row numbers
-            // PostgreSQL's WITH ORDINALITY returns (value, ordinality) columns
+            // Convert unnest(array) WITH ORDINALITY to a subquery that includes the positions
+            // PostgreSQL's WITH ORDINALITY returns (value, ordinality) columns unless renamed
             let replacement = format!(
-                "FROM (SELECT value, (key + 1) AS ordinality FROM json_each({array_expr})) AS {alias}"
+                "FROM (SELECT {value}, (key + 1) AS {ordinality} FROM json_each({array_expr})) AS {alias}"
             );
             
             replacements.push((captures[0].to_string(), replacement));
@@ -269,4 +292,18 @@ mod tests {
         assert!(result.contains("json_each"));
         assert!(!result.contains("unnest"));
     }
-}
\ No newline at end of file
+    
+    #[test]
+    fn test_unnest_with_ordinality_column_aliases() {
+        let sql = "SELECT * FROM unnest('[\"a\", \"b\"]') WITH ORDINALITY AS t(tag, pos)";
+        let (result, metadata) = UnnestTranslator::translate_with_metadata(sql).unwrap();
+        assert_eq!(
+            result,
+            "SELECT * FROM (SELECT value AS tag, (key + 1) AS pos FROM json_each('[\"a\", \"b\"]')) AS t"
+        );
+        assert_eq!(metadata.get_hint("pos").and_then(|h| h.suggested_type), Some(PgType::Int8));
+        
+        let result = UnnestTranslator::translate_unnest("SELECT tag FROM unnest(tags) AS t(tag)").unwrap();
+        assert_eq!(result, "SELECT tag FROM (SELECT value AS tag FROM json_each(tags)) AS t");
+    }
+}
@@ -0,0 +1,135 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use tracing::debug;
+use crate::types::SchemaTypeMapper;
+use super::sql_scan::{in_string_literal, matching_paren, split_top_level};
+
+static DERIVED_VALUES_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\(\s*VALUES\s*\(").unwrap()
+});
+
+/// `[AS] alias(col, ...)` following a derived table
+static COLUMN_ALIASES_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"(?i)^\s*(?:AS\s+)?("[^"]+"|\w+)\s*\(((?:\s*(?:"[^"]+"|\w+)\s*,)*\s*(?:"[^"]+"|\w+)\s*)\)"#).unwrap()
+});
+
+static VALUES_STATEMENT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*VALUES\s*\(").unwrap()
+});
+
+static SELECT_ALL_FROM_VALUES_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)^\s*SELECT\s+(?:\w+\.)?\*\s+FROM\s+\(\s*VALUES\s*\(").unwrap()
+});
+
+/// Words that can follow a derived table and an opening parenthesis without being its alias
+const NON_ALIAS_KEYWORDS: &[&str] = &["WHERE", "ON", "USING", "JOIN", "AND", "OR", "IN", "NOT", "EXISTS"];
+
+/// Handles VALUES lists used as statements and as derived tables. SQLite runs both, but
+/// doesn't accept the column aliases of `(VALUES ...) AS t(a, b)` and names the columns
+/// column1, column2, ... instead.
+pub struct ValuesTranslator;
+
+impl ValuesTranslator {
+    /// Check if the query has a VALUES list in parentheses, i.e. a derived table
+    pub fn needs_translation(query: &str) -> bool {
+        DERIVED_VALUES_REGEX.is_match(query)
+    }
+
+    /// Rewrite `(VALUES ...) AS t(a, b)` as `(SELECT column1 AS a, column2 AS b FROM (VALUES ...)) AS t`
+    pub fn translate_query(query: &str) -> String {
+        if !Self::needs_translation(query) {
+            return query.to_string();
+        }
+
+        let mut result = query.to_string();
+
+        // Work from the last derived table backwards so earlier offsets stay valid
+        let matches: Vec<_> = DERIVED_VALUES_REGEX.find_iter(query)
+            .filter(|m| !in_string_literal(query, m.start()))
+            .map(|m| (m.start(), m.end()))
+            .collect();
+
+        for (start, end) in matches.into_iter().rev() {
+            let Some(close) = matching_paren(&result, start) else { continue };
+            let Some(caps) = COLUMN_ALIASES_REGEX.captures(&result[close + 1..]) else { continue };
+            let alias = &caps[1];
+            if NON_ALIAS_KEYWORDS.iter().any(|k| k.eq_ignore_ascii_case(alias)) {
+                continue;
+            }
+            let aliases: Vec<&str> = caps[2].split(',').map(str::trim).collect();
+
+            let values_list = &result[start..=close];
+            // The match ends with the parenthesis opening the first row
+            let column_count = Self::first_row(&result[end - 1..])
+                .map_or(aliases.len(), |row| split_top_level(row).len())
+                .max(aliases.len());
+            let columns: Vec<String> = (1..=column_count)
+                .map(|i| match aliases.get(i - 1) {
+                    Some(name) => format!("column{i} AS {name}"),
+                    None => format!("column{i}"),
+                })
+                .collect();
+
+            let replacement = format!("(SELECT {} FROM {}) AS {}", columns.join(", "), values_list, alias);
+            let aliases_end = close + 1 + caps.get(0).map_or(0, |m| m.end());
+            result.replace_range(start..aliases_end, &replacement);
+        }
+
+        if result != query {
+            debug!("Translated VALUES column aliases: {} -> {}", query, result);
+        }
+        result
+    }
+
+    /// Result column types of a VALUES statement or a `SELECT * FROM (VALUES ...)`, inferred
+    /// from the literals of the first row
+    pub fn infer_result_types(query: &str) -> Option<Vec<Option<i32>>> {
+        // Both patterns end with the parenthesis opening the first row
+        let m = VALUES_STATEMENT_REGEX.find(query)
+            .or_else(|| SELECT_ALL_FROM_VALUES_REGEX.find(query))?;
+        let row = Self::first_row(&query[m.end() - 1..])?;
+        SchemaTypeMapper::infer_fromless_select_types(&format!("SELECT {row}"))
+    }
+
+    /// The contents of the row whose opening parenthesis starts `text`
+    fn first_row(text: &str) -> Option<&str> {
+        let close = matching_paren(text, 0)?;
+        Some(&text[1..close])
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::types::PgType;
+
+    #[test]
+    fn test_values_column_aliases() {
+        assert_eq!(
+            ValuesTranslator::translate_query("SELECT * FROM (VALUES (1, 'a'), (2, 'b')) AS t(id, name) ORDER BY id"),
+            "SELECT * FROM (SELECT column1 AS id, column2 AS name FROM (VALUES (1, 'a'), (2, 'b'))) AS t ORDER BY id"
+        );
+        // Columns without an alias keep their generated name
+        assert_eq!(
+            ValuesTranslator::translate_query("SELECT v.code FROM items JOIN (VALUES ('x', f(1, 2), 3)) v (code) ON v.code = items.code"),
+            "SELECT v.code FROM items JOIN (SELECT column1 AS code, column2, column3 FROM (VALUES ('x', f(1, 2), 3))) AS v ON v.code = items.code"
+        );
+
+        // No column aliases, or not a derived table
+        for query in [
+            "SELECT * FROM (VALUES (1), (2)) AS t",
+            "SELECT * FROM t WHERE id IN (VALUES (1)) AND (x = 1)",
+            "INSERT INTO t (a) VALUES (1)",
+        ] {
+            assert_eq!(ValuesTranslator::translate_query(query), query);
+        }
+    }
+
+    #[test]
+    fn test_values_result_types() {
+        let expected = Some(vec![Some(PgType::Int4.to_oid()), Some(PgType::Text.to_oid()), Some(PgType::Bool.to_oid())]);
+        assert_eq!(ValuesTranslator::infer_result_types("VALUES (1, 'a,b', true), (2, 'c', false)"), expected);
+        assert_eq!(ValuesTranslator::infer_result_types("SELECT * FROM (VALUES (1, 'a', true)) AS t(id, name, flag)"), expected);
+        assert_eq!(ValuesTranslator::infer_result_types("SELECT id FROM (VALUES (1)) AS t(id)"), None);
+    }
+}
@@ -0,0 +1,173 @@
+use sqlparser::ast::{Expr, FunctionArg, FunctionArgExpr, FunctionArguments, SelectItem, SetExpr, Statement};
+use sqlparser::dialect::PostgreSqlDialect;
+use sqlparser::parser::Parser;
+use super::{TranslationMetadata, ColumnTypeHint, ExpressionType};
+use crate::types::PgType;
+use tracing::debug;
+
+/// Analyzes window function calls (`fn(...) OVER w` / `fn(...) OVER (...)`) in the
+/// projection list to generate result type metadata.
+///
+/// SQLite executes window functions and `WINDOW w AS (...)` clauses natively, so the
+/// query text is passed through untouched; this analyzer only provides the PostgreSQL
+/// result types that cannot be recovered from the SQLite result set.
+pub struct WindowFunctionAnalyzer;
+
+impl WindowFunctionAnalyzer {
+    /// Quick check for window function usage
+    pub fn needs_analysis(query: &str) -> bool {
+        let query_lower = query.to_lowercase();
+        query_lower.contains(" over ") || query_lower.contains(" over(") || query_lower.contains(")over")
+    }
+
+    /// Analyze query and extract metadata for aliased window function columns
+    pub fn analyze_query(query: &str) -> TranslationMetadata {
+        let mut metadata = TranslationMetadata::new();
+
+        let statements = match Parser::parse_sql(&PostgreSqlDialect {}, query) {
+            Ok(statements) => statements,
+            Err(e) => {
+                debug!("Window function analysis skipped, failed to parse query: {}", e);
+                return metadata;
+            }
+        };
+
+        for statement in &statements {
+            if let Statement::Query(query) = statement {
+                Self::analyze_set_expr(&query.body, &mut metadata);
+            }
+        }
+
+        metadata
+    }
+
+    fn analyze_set_expr(set_expr: &SetExpr, metadata: &mut TranslationMetadata) {
+        match set_expr {
+            SetExpr::Select(select) => {
+                for item in &select.projection {
+                    if let SelectItem::ExprWithAlias { expr: Expr::Function(func), alias } = item
+                        && func.over.is_some() {
+                            let func_name = func.name.to_string().to_lowercase();
+                            let source_column = Self::first_column_argument(&func.args);
+                            if let Some(hint) = Self::hint_for_window_function(&func_name, source_column) {
+                                debug!("Window function '{}' aliased as '{}' -> {:?}", func_name, alias.value, hint.suggested_type);
+                                metadata.add_hint(alias.value.clone(), hint);
+                            }
+                        }
+                }
+            }
+            SetExpr::Query(query) => Self::analyze_set_expr(&query.body, metadata),
+            SetExpr::SetOperation { left, .. } => Self::analyze_set_expr(left, metadata),
+            _ => {}
+        }
+    }
+
+    /// Extract the column referenced by the first argument, if it is a plain column
+    fn first_column_argument(args: &FunctionArguments) -> Option<String> {
+        if let FunctionArguments::List(list) = args
+            && let Some(FunctionArg::Unnamed(FunctionArgExpr::Expr(expr))) = list.args.first() {
+                return match expr {
+                    Expr::Identifier(ident) => Some(ident.value.clone()),
+                    Expr::CompoundIdentifier(parts) => parts.last().map(|ident| ident.value.clone()),
+                    _ => None,
+                };
+            }
+        None
+    }
+
+    /// Map a window function to its PostgreSQL result type
+    fn hint_for_window_function(func_name: &str, source_column: Option<String>) -> Option<ColumnTypeHint> {
+        match func_name {
+            // Ranking functions always return bigint
+            "row_number" | "rank" | "dense_rank" | "count" => {
+                Some(ColumnTypeHint::expression(None, PgType::Int8, ExpressionType::Other))
+            }
+            "ntile" => Some(ColumnTypeHint::expression(None, PgType::Int4, ExpressionType::Other)),
+            // Distribution functions return double precision
+            "percent_rank" | "cume_dist" => {
+                Some(ColumnTypeHint::expression(None, PgType::Float8, ExpressionType::Other))
+            }
+            // sum() and avg() are typed from their argument column once its type is known
+            "sum" => Some(ColumnTypeHint::aggregate(source_column, ExpressionType::Sum)),
+            "avg" => Some(ColumnTypeHint::aggregate(source_column, ExpressionType::Avg)),
+            // Value functions return the type of their argument column
+            "lag" | "lead" | "first_value" | "last_value" | "nth_value" | "min" | "max" => {
+                source_column.map(|source| ColumnTypeHint {
+                    source_column: Some(source),
+                    suggested_type: None,
+                    datetime_subtype: None,
+                    is_expression: false,
+                    expression_type: None,
+                })
+            }
+            _ => None,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_needs_analysis() {
+        assert!(WindowFunctionAnalyzer::needs_analysis("SELECT row_number() OVER w AS rn FROM books WINDOW w AS (ORDER BY price)"));
+        assert!(WindowFunctionAnalyzer::needs_analysis("SELECT rank() OVER(ORDER BY price) AS r FROM books"));
+        assert!(!WindowFunctionAnalyzer::needs_analysis("SELECT price FROM books ORDER BY price"));
+    }
+
+    #[test]
+    fn test_named_window_ranking_functions() {
+        let query = "SELECT title, row_number() OVER w AS rn, rank() OVER w AS rnk \
+                     FROM books WINDOW w AS (PARTITION BY author_id ORDER BY price)";
+        let metadata = WindowFunctionAnalyzer::analyze_query(query);
+
+        assert_eq!(metadata.get_hint("rn").unwrap().suggested_type, Some(PgType::Int8));
+        assert_eq!(metadata.get_hint("rnk").unwrap().suggested_type, Some(PgType::Int8));
+        assert!(metadata.get_hint("title").is_none());
+    }
+
+    #[test]
+    fn test_multiple_named_windows() {
+        let query = "SELECT lag(price) OVER w1 AS prev_price, percent_rank() OVER w2 AS pct \
+                     FROM books \
+                     WINDOW w1 AS (PARTITION BY author_id ORDER BY price), w2 AS (ORDER BY price)";
+        let metadata = WindowFunctionAnalyzer::analyze_query(query);
+
+        let prev = metadata.get_hint("prev_price").unwrap();
+        assert_eq!(prev.source_column.as_deref(), Some("price"));
+        assert_eq!(prev.suggested_type, None);
+        assert_eq!(metadata.get_hint("pct").unwrap().suggested_type, Some(PgType::Float8));
+    }
+
+    #[test]
+    fn test_inline_window_spec() {
+        let query = "SELECT sum(b.price) OVER (PARTITION BY b.author_id) AS total FROM books b";
+        let metadata = WindowFunctionAnalyzer::analyze_query(query);
+
+        let total = metadata.get_hint("total").unwrap();
+        assert_eq!(total.source_column.as_deref(), Some("price"));
+        assert_eq!(total.suggested_type, Some(PgType::Numeric));
+    }
+
+    #[test]
+    fn test_sum_avg_typed_from_column() {
+        let query = "SELECT sum(qty) OVER w AS total_qty, avg(qty) OVER w AS avg_qty, sum(weight) OVER w AS total_weight \
+                     FROM items WINDOW w AS (ORDER BY id)";
+        let metadata = WindowFunctionAnalyzer::analyze_query(query);
+
+        let total_qty = metadata.get_hint("total_qty").unwrap();
+        assert_eq!(total_qty.aggregate_type(PgType::Int4), Some(PgType::Int8));
+        assert_eq!(total_qty.aggregate_type(PgType::Int8), Some(PgType::Numeric));
+        assert_eq!(metadata.get_hint("avg_qty").unwrap().aggregate_type(PgType::Int4), Some(PgType::Numeric));
+        let total_weight = metadata.get_hint("total_weight").unwrap();
+        assert_eq!(total_weight.aggregate_type(PgType::Float8), Some(PgType::Float8));
+        assert_eq!(total_weight.aggregate_type(PgType::Numeric), Some(PgType::Numeric));
+    }
+
+    #[test]
+    fn test_plain_aggregates_are_ignored() {
+        let metadata = WindowFunctionAnalyzer::analyze_query("SELECT count(*) AS n FROM books");
+        assert!(metadata.get_hint("n").is_none());
+    }
+}
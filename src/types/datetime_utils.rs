@@ -268,6 +268,226 @@ pub fn format_microseconds_to_timestamp_buf(micros: i64, buf: &mut [u8]) -> usiz
     date_len + 1 + time_len
 }
 
+/// Number of days in the given month
+fn days_in_month(year: i32, month: u32) -> i64 {
+    let (next_year, next_month) = if month == 12 { (year + 1, 1) } else { (year, month + 1) };
+    match (NaiveDate::from_ymd_opt(year, month, 1), NaiveDate::from_ymd_opt(next_year, next_month, 1)) {
+        (Some(first), Some(next)) => (next - first).num_days(),
+        _ => 30,
+    }
+}
+
+/// Symbolic difference `end - start` as PostgreSQL's age() computes it: field by field,
+/// borrowing days from the month of the earlier timestamp rather than counting total days.
+/// Returns the interval as (months, days, microseconds).
+pub fn timestamp_age(end: &NaiveDateTime, start: &NaiveDateTime) -> (i32, i32, i64) {
+    let negative = end < start;
+    let (later, earlier) = if negative { (start, end) } else { (end, start) };
+    
+    let mut micros = (later.nanosecond() / 1000) as i64 - (earlier.nanosecond() / 1000) as i64;
+    let mut seconds = later.second() as i64 - earlier.second() as i64;
+    let mut minutes = later.minute() as i64 - earlier.minute() as i64;
+    let mut hours = later.hour() as i64 - earlier.hour() as i64;
+    let mut days = later.day() as i64 - earlier.day() as i64;
+    let mut months = later.month() as i64 - earlier.month() as i64;
+    let mut years = (later.year() - earlier.year()) as i64;
+    
+    while micros < 0 {
+        micros += 1_000_000;
+        seconds -= 1;
+    }
+    while seconds < 0 {
+        seconds += 60;
+        minutes -= 1;
+    }
+    while minutes < 0 {
+        minutes += 60;
+        hours -= 1;
+    }
+    while hours < 0 {
+        hours += 24;
+        days -= 1;
+    }
+    while days < 0 {
+        days += days_in_month(earlier.year(), earlier.month());
+        months -= 1;
+    }
+    while months < 0 {
+        months += 12;
+        years -= 1;
+    }
+    
+    let sign = if negative { -1 } else { 1 };
+    let total_months = (years * 12 + months) as i32 * sign;
+    let total_micros = ((hours * 60 + minutes) * 60 + seconds) * 1_000_000 + micros;
+    (total_months, days as i32 * sign, total_micros * sign as i64)
+}
+
+/// Format an interval in PostgreSQL's default output style,
+/// e.g. "1 year 2 mons 3 days 04:05:06"
+pub fn format_interval(months: i32, days: i32, micros: i64) -> String {
+    let mut parts = Vec::new();
+    let mut is_before = false;
+    
+    let years = months / 12;
+    let mons = months % 12;
+    for (value, unit) in [(years as i64, "year"), (mons as i64, "mon"), (days as i64, "day")] {
+        if value != 0 {
+            let sign = if is_before && value > 0 { "+" } else { "" };
+            let plural = if value.abs() == 1 { "" } else { "s" };
+            parts.push(format!("{sign}{value} {unit}{plural}"));
+            is_before |= value < 0;
+        }
+    }
+    
+    if micros != 0 || parts.is_empty() {
+        let sign = if micros < 0 { "-" } else if is_before { "+" } else { "" };
+        let abs = micros.unsigned_abs();
+        let hours = abs / 3_600_000_000;
+        let minutes = (abs / 60_000_000) % 60;
+        let seconds = (abs / 1_000_000) % 60;
+        let fraction = abs % 1_000_000;
+        
+        let mut time = format!("{sign}{hours:02}:{minutes:02}:{seconds:02}");
+        if fraction != 0 {
+            let digits = format!("{fraction:06}");
+            time.push('.');
+            time.push_str(digits.trim_end_matches('0'));
+        }
+        parts.push(time);
+    }
+    
+    parts.join(" ")
+}
+
+/// Parse a PostgreSQL interval literal such as "1 year 2 mons 3 days 04:05:06",
+/// "-1 days +01:00:00", "@ 2 hours ago" or "90 minutes" into (months, days, microseconds).
+/// Fractional months spill into days (30 days per month) and fractional days into time.
+pub fn parse_interval(interval_str: &str) -> Option<(i32, i32, i64)> {
+    const MICROS_PER_DAY: f64 = 86_400_000_000.0;
+
+    let mut months = 0f64;
+    let mut days = 0f64;
+    let mut micros = 0f64;
+    let mut negate = false;
+    let mut seen_field = false;
+
+    let tokens: Vec<&str> = interval_str.split_whitespace().collect();
+    let mut i = 0;
+    while i < tokens.len() {
+        let token = tokens[i];
+        i += 1;
+
+        match token.to_lowercase().as_str() {
+            "@" => continue,
+            "ago" => {
+                negate = true;
+                continue;
+            }
+            _ => {}
+        }
+
+        // Time component: [+-]HH:MM[:SS[.ffffff]]
+        if token.contains(':') {
+            let (sign, rest) = match token.as_bytes()[0] {
+                b'-' => (-1.0, &token[1..]),
+                b'+' => (1.0, &token[1..]),
+                _ => (1.0, token),
+            };
+            let fields: Vec<&str> = rest.split(':').collect();
+            if fields.len() > 3 || fields.iter().any(|f| f.is_empty()) {
+                return None;
+            }
+            let hours = fields[0].parse::<u64>().ok()? as f64;
+            let minutes = fields[1].parse::<u64>().ok()? as f64;
+            let seconds = match fields.get(2) {
+                Some(s) => s.parse::<f64>().ok().filter(|s| *s >= 0.0)?,
+                None => 0.0,
+            };
+            micros += sign * ((hours * 3600.0 + minutes * 60.0 + seconds) * 1_000_000.0);
+            seen_field = true;
+            continue;
+        }
+
+        let value = token.parse::<f64>().ok()?;
+        let unit = match tokens.get(i) {
+            Some(unit) if unit.parse::<f64>().is_err() && !unit.contains(':') && !unit.eq_ignore_ascii_case("ago") => {
+                i += 1;
+                unit.to_lowercase()
+            }
+            // A bare number counts as seconds
+            _ => "second".to_string(),
+        };
+
+        match unit.as_str() {
+            "millennium" | "millennia" | "millenniums" => months += value * 12_000.0,
+            "century" | "centuries" => months += value * 1_200.0,
+            "decade" | "decades" => months += value * 120.0,
+            "year" | "years" | "yr" | "yrs" | "y" => months += value * 12.0,
+            "mon" | "mons" | "month" | "months" => months += value,
+            "week" | "weeks" | "w" => days += value * 7.0,
+            "day" | "days" | "d" => days += value,
+            "hour" | "hours" | "hr" | "hrs" | "h" => micros += value * 3_600_000_000.0,
+            "minute" | "minutes" | "min" | "mins" | "m" => micros += value * 60_000_000.0,
+            "second" | "seconds" | "sec" | "secs" | "s" => micros += value * 1_000_000.0,
+            "millisecond" | "milliseconds" | "ms" => micros += value * 1_000.0,
+            "microsecond" | "microseconds" | "us" => micros += value,
+            _ => return None,
+        }
+        seen_field = true;
+    }
+
+    if !seen_field {
+        return None;
+    }
+
+    // Cascade fractional parts down, as PostgreSQL does
+    let whole_months = months.trunc();
+    days += (months - whole_months) * 30.0;
+    let whole_days = days.trunc();
+    micros += (days - whole_days) * MICROS_PER_DAY;
+
+    let sign = if negate { -1.0 } else { 1.0 };
+    Some((
+        (whole_months * sign) as i32,
+        (whole_days * sign) as i32,
+        (micros * sign).round() as i64,
+    ))
+}
+
+/// The microseconds an interval spans, counting a month as 30 days and a day as 24 hours,
+/// the lengths PostgreSQL compares intervals by; None on overflow
+pub fn interval_to_microseconds(months: i32, days: i32, micros: i64) -> Option<i64> {
+    const MICROS_PER_DAY: i64 = 86_400_000_000;
+
+    (months as i64 * 30 + days as i64)
+        .checked_mul(MICROS_PER_DAY)
+        .and_then(|day_micros| day_micros.checked_add(micros))
+}
+
+/// Split INTEGER microseconds, as timestamp arithmetic produces them, into
+/// (months, days, microseconds). A month has no fixed length, so none is inferred
+pub fn microseconds_to_interval(total_micros: i64) -> (i32, i32, i64) {
+    const MICROS_PER_DAY: i64 = 86_400_000_000;
+
+    (0, (total_micros / MICROS_PER_DAY) as i32, total_micros % MICROS_PER_DAY)
+}
+
+/// The canonical text an interval is stored as, which keeps its months, days and time
+/// apart; None for text that isn't an interval
+pub fn canonical_interval(value: &str) -> Option<String> {
+    parse_stored_interval(value).map(|(months, days, micros)| format_interval(months, days, micros))
+}
+
+/// Decode an interval as pgsqlite stores it, canonical interval text, or the INTEGER
+/// microseconds timestamp arithmetic produces
+pub fn parse_stored_interval(value: &str) -> Option<(i32, i32, i64)> {
+    if let Ok(total_micros) = value.trim().parse::<i64>() {
+        return Some(microseconds_to_interval(total_micros));
+    }
+    parse_interval(value)
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -323,4 +543,72 @@ mod tests {
         // Test parsing
         assert_eq!(parse_timestamp_to_microseconds("1970-01-01 00:00:00"), Some(0));
     }
-}
\ No newline at end of file
+    
+    #[test]
+    fn test_timestamp_age() {
+        let ts = |s: &str| NaiveDateTime::parse_from_str(s, "%Y-%m-%d %H:%M:%S").unwrap();
+        
+        // Across a year boundary
+        assert_eq!(timestamp_age(&ts("2024-01-15 00:00:00"), &ts("2023-12-20 00:00:00")), (0, 26, 0));
+        assert_eq!(timestamp_age(&ts("2024-03-01 00:00:00"), &ts("2022-11-30 12:00:00")), (15, 0, 12 * 3_600_000_000));
+        
+        // Days are borrowed from the earlier month
+        assert_eq!(timestamp_age(&ts("2023-03-01 00:00:00"), &ts("2023-01-31 00:00:00")), (1, 1, 0));
+        
+        // Reversed arguments give the negated interval
+        assert_eq!(timestamp_age(&ts("2000-01-01 00:00:00"), &ts("2001-02-03 00:00:00")), (-13, -2, 0));
+    }
+    
+    #[test]
+    fn test_format_interval() {
+        assert_eq!(format_interval(14, 3, 14_706_000_000), "1 year 2 mons 3 days 04:05:06");
+        assert_eq!(format_interval(1, 1, 0), "1 mon 1 day");
+        assert_eq!(format_interval(0, 0, 0), "00:00:00");
+        assert_eq!(format_interval(0, 0, 1_500_000), "00:00:01.5");
+        assert_eq!(format_interval(-13, -2, 0), "-1 years -1 mons -2 days");
+        assert_eq!(format_interval(0, -1, 3_600_000_000), "-1 days +01:00:00");
+    }
+
+    #[test]
+    fn test_parse_interval() {
+        assert_eq!(parse_interval("1 year 2 mons 3 days 04:05:06"), Some((14, 3, 14_706_000_000)));
+        assert_eq!(parse_interval("-1 days +01:00:00"), Some((0, -1, 3_600_000_000)));
+        assert_eq!(parse_interval("@ 2 hours ago"), Some((0, 0, -7_200_000_000)));
+        assert_eq!(parse_interval("90 minutes"), Some((0, 0, 5_400_000_000)));
+        assert_eq!(parse_interval("1.5 months"), Some((1, 15, 0)));
+        assert_eq!(parse_interval("2 weeks"), Some((0, 14, 0)));
+        assert_eq!(parse_interval("00:00:01.5"), Some((0, 0, 1_500_000)));
+        assert_eq!(parse_interval("1 fortnight"), None);
+        assert_eq!(parse_interval(""), None);
+
+        // Formatting and parsing round-trip
+        for (months, days, micros) in [(14, 3, 14_706_000_000), (-13, -2, 0), (0, -1, 3_600_000_000), (0, 0, 1_500_000)] {
+            assert_eq!(parse_interval(&format_interval(months, days, micros)), Some((months, days, micros)));
+        }
+    }
+
+    #[test]
+    fn test_parse_stored_interval() {
+        assert_eq!(parse_stored_interval("95400000000"), Some((0, 1, 9_000_000_000)));
+        assert_eq!(parse_stored_interval("1 mon 1 day"), Some((1, 1, 0)));
+    }
+
+    #[test]
+    fn test_interval_microseconds() {
+        assert_eq!(interval_to_microseconds(14, 3, 14_706_000_000), Some(36_561_906_000_000));
+        assert_eq!(interval_to_microseconds(i32::MAX, i32::MAX, 0), None);
+        // Whole days are split off, but 30 days stay days
+        assert_eq!(microseconds_to_interval(2_592_000_000_000), (0, 30, 0));
+        assert_eq!(microseconds_to_interval(-90_000_000_000), (0, -1, -3_600_000_000));
+    }
+
+    #[test]
+    fn test_canonical_interval() {
+        assert_eq!(canonical_interval("30 days").as_deref(), Some("30 days"));
+        assert_eq!(canonical_interval("1 month").as_deref(), Some("1 mon"));
+        assert_eq!(canonical_interval("365 days").as_deref(), Some("365 days"));
+        assert_eq!(canonical_interval("1 year 2 months 3 days 4 hours 5 minutes 6 seconds").as_deref(), Some("1 year 2 mons 3 days 04:05:06"));
+        assert_eq!(canonical_interval("95400000000").as_deref(), Some("1 day 02:30:00"));
+        assert_eq!(canonical_interval("soon"), None);
+    }
+}
@@ -12,7 +12,7 @@ pub mod numeric_utils;
 pub mod type_resolution;
 
 pub use type_mapper::{TypeMapper, PgType};
-pub use uuid::{UuidHandler, generate_uuid_v4};
+pub use uuid::{UuidHandler, generate_uuid_v1, generate_uuid_v4};
 pub use sqlite_type_info::{get_pg_type_oid_from_sqlite, sqlite_type_to_pg_oid, infer_pg_type_from_text};
 pub use schema_type_mapper::SchemaTypeMapper;
 pub use query_context_analyzer::QueryContextAnalyzer;
@@ -11,6 +11,11 @@ static NUMERIC_CONSTRAINT_CACHE: once_cell::sync::Lazy<NumericConstraintCache> =
 
 /// Format a numeric value according to its scale constraint
 pub fn format_numeric_with_scale(value: f64, table_name: &str, column_name: &str, conn: &Connection) -> String {
+    // Infinity has no digits to round
+    if !value.is_finite() {
+        return format_float(value);
+    }
+
     // Try to get constraints from cache
     {
         let cache = NUMERIC_CONSTRAINT_CACHE.read().unwrap();
@@ -58,6 +63,41 @@ pub fn format_numeric_with_scale(value: f64, table_name: &str, column_name: &str
     value.to_string()
 }
 
+/// Format a float as PostgreSQL does, spelling out Infinity, -Infinity and NaN
+pub fn format_float(value: f64) -> String {
+    if value.is_nan() {
+        "NaN".to_string()
+    } else if value == f64::INFINITY {
+        "Infinity".to_string()
+    } else if value == f64::NEG_INFINITY {
+        "-Infinity".to_string()
+    } else {
+        value.to_string()
+    }
+}
+
+/// The SQLite expression storing one of PostgreSQL's special float inputs.
+///
+/// SQLite reads 9e999 as infinity. It has no NaN (binding one stores NULL), so NaN is kept
+/// as text, which sorts after every number and equals itself, as NaN does in PostgreSQL.
+pub fn special_float_literal(text: &str) -> Option<&'static str> {
+    match text.trim().to_ascii_lowercase().as_str() {
+        "infinity" | "+infinity" | "inf" | "+inf" => Some("9e999"),
+        "-infinity" | "-inf" => Some("-9e999"),
+        "nan" => Some("'NaN'"),
+        _ => None,
+    }
+}
+
+/// The SQLite value to bind for a float parameter, keeping NaN as text
+pub fn float_to_sqlite_value(value: f64) -> rusqlite::types::Value {
+    if value.is_nan() {
+        rusqlite::types::Value::Text("NaN".to_string())
+    } else {
+        rusqlite::types::Value::Real(value)
+    }
+}
+
 /// Clear the numeric constraint cache for a specific table
 pub fn invalidate_numeric_cache(table_name: &str) {
     let mut cache = NUMERIC_CONSTRAINT_CACHE.write().unwrap();
@@ -68,4 +108,32 @@ pub fn invalidate_numeric_cache(table_name: &str) {
 pub fn clear_numeric_cache() {
     let mut cache = NUMERIC_CONSTRAINT_CACHE.write().unwrap();
     cache.clear();
-}
\ No newline at end of file
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_format_float() {
+        assert_eq!(format_float(1.5), "1.5");
+        assert_eq!(format_float(f64::INFINITY), "Infinity");
+        assert_eq!(format_float(f64::NEG_INFINITY), "-Infinity");
+        assert_eq!(format_float(f64::NAN), "NaN");
+    }
+
+    #[test]
+    fn test_special_float_literal() {
+        assert_eq!(special_float_literal("Infinity"), Some("9e999"));
+        assert_eq!(special_float_literal("-inf"), Some("-9e999"));
+        assert_eq!(special_float_literal("NaN"), Some("'NaN'"));
+        assert_eq!(special_float_literal("1.5"), None);
+        assert_eq!(special_float_literal("infinite"), None);
+    }
+
+    #[test]
+    fn test_float_to_sqlite_value() {
+        assert_eq!(float_to_sqlite_value(2.0), rusqlite::types::Value::Real(2.0));
+        assert_eq!(float_to_sqlite_value(f64::NAN), rusqlite::types::Value::Text("NaN".to_string()));
+    }
+}
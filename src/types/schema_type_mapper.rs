@@ -2,6 +2,9 @@ use rusqlite::Connection;
 use crate::types::PgType;
 use crate::metadata::EnumMetadata;
 use regex;
+use sqlparser::ast::{BinaryOperator, Expr, FunctionArg, FunctionArgExpr, FunctionArguments, SelectItem, SetExpr, Statement, UnaryOperator, Value};
+use sqlparser::dialect::PostgreSqlDialect;
+use sqlparser::parser::Parser;
 
 /// Maps between PostgreSQL and SQLite types using actual schema information
 pub struct SchemaTypeMapper;
@@ -146,6 +149,7 @@ impl SchemaTypeMapper {
             "BIGINT" | "INT8" => PgType::Int8.to_oid(),
             "SERIAL" => PgType::Int4.to_oid(), // Serial is int4 with sequence
             "BIGSERIAL" => PgType::Int8.to_oid(), // Bigserial is int8 with sequence
+            "SMALLSERIAL" => PgType::Int2.to_oid(), // Smallserial is int2 with sequence
             
             // Floating point
             "REAL" | "FLOAT4" => PgType::Float4.to_oid(),
@@ -154,7 +158,7 @@ impl SchemaTypeMapper {
             
             // Text types
             "VARCHAR" | "CHARACTER VARYING" => PgType::Varchar.to_oid(),
-            "CHAR" | "CHARACTER" => PgType::Char.to_oid(),
+            "CHAR" | "CHARACTER" | "BPCHAR" => PgType::Char.to_oid(),
             "TEXT" => PgType::Text.to_oid(),
             
             // Binary
@@ -190,6 +194,12 @@ impl SchemaTypeMapper {
             "INT4RANGE" => PgType::Int4range.to_oid(),
             "INT8RANGE" => PgType::Int8range.to_oid(),
             "NUMRANGE" => PgType::Numrange.to_oid(),
+            "TSRANGE" => PgType::Tsrange.to_oid(),
+            "TSTZRANGE" => PgType::Tstzrange.to_oid(),
+            "DATERANGE" => PgType::Daterange.to_oid(),
+            
+            // Geometric types
+            "POINT" => PgType::Point.to_oid(),
             
             // Network types
             "CIDR" => PgType::Cidr.to_oid(),
@@ -201,6 +211,8 @@ impl SchemaTypeMapper {
             "BIT VARYING" | "VARBIT" => PgType::Varbit.to_oid(),
             "BIT" => PgType::Bit.to_oid(),
             
+            "XML" => PgType::Xml.to_oid(),
+            
             // Default - might be an ENUM type, return a special marker
             _ => {
                 // For unknown types, we'll return TEXT but the caller should check
@@ -341,12 +353,59 @@ impl SchemaTypeMapper {
         if !function_name.contains('(') && !function_name.contains(' ') {
             // If we have the query, try to find what function produces this alias
             if let Some(q) = query {
+                // regexp_matches() yields text[] under its default name or an alias
+                if q.to_lowercase().contains("regexp_matches") {
+                    let pattern = format!(r"(?i)regexp_matches\s*\(.*\)\s+(?:AS\s+)?{}\b", regex::escape(function_name));
+                    if function_name.eq_ignore_ascii_case("regexp_matches")
+                        || regex::Regex::new(&pattern).is_ok_and(|re| re.is_match(q)) {
+                        return Some(PgType::TextArray.to_oid());
+                    }
+                }
+                
+                // Whole-row JSON conversions keep their function name as the column name
+                if matches!(function_name.to_lowercase().as_str(), "row_to_json" | "to_json" | "to_jsonb" | "array_to_json")
+                    && q.to_lowercase().contains(&function_name.to_lowercase()) {
+                    return Self::get_aggregate_return_type_with_query(&format!("{upper}()"), conn, table_name, None);
+                }
+                
+                // OVERLAPS and BETWEEN predicates are boolean
+                let predicate_pattern = format!(
+                    r"(?i)(?:\bOVERLAPS\s*\(.*?\)|\bBETWEEN\s+(?:SYMMETRIC\s+|ASYMMETRIC\s+)?\S+\s+AND\s+\S+)\s+AS\s+{}\b",
+                    regex::escape(function_name)
+                );
+                if regex::Regex::new(&predicate_pattern).is_ok_and(|re| re.is_match(q)) {
+                    return Some(PgType::Bool.to_oid());
+                }
+                
                 // Look for patterns like "sum(...) AS function_name" or "avg(...) AS function_name"
                 // This handles both simple aggregates and aggregate expressions
                 let pattern = format!(r"(?i)([\w_]+)\s*\([^)]+\)\s+(?:AS\s+)?{}\b", regex::escape(function_name));
                 if let Ok(re) = regex::Regex::new(&pattern)
                     && let Some(captures) = re.captures(q) {
                         let actual_function = captures[1].to_uppercase();
+                        // age() and make_interval() produce an interval whatever their arguments are
+                        if actual_function == "AGE" || actual_function == "MAKE_INTERVAL" {
+                            return Some(PgType::Interval.to_oid());
+                        }
+                        // Large object functions are typed by name alone
+                        if matches!(actual_function.as_str(), "LOREAD" | "LO_GET" | "LO_CREAT" | "LO_CREATE" | "LO_FROM_BYTEA" |
+                                   "LO_OPEN" | "LO_CLOSE" | "LOWRITE" | "LO_LSEEK" | "LO_LSEEK64" | "LO_TELL" |
+                                   "LO_TELL64" | "LO_TRUNCATE" | "LO_UNLINK") {
+                            return Self::get_aggregate_return_type_with_query(&format!("{actual_function}()"), conn, table_name, None);
+                        }
+                        // Numeric math functions are typed from their argument list
+                        if matches!(actual_function.as_str(), "ROUND" | "TRUNC" | "WIDTH_BUCKET") {
+                            return Self::get_aggregate_return_type_with_query(&captures[0], conn, table_name, None);
+                        }
+                        if matches!(actual_function.as_str(), "ROW_TO_JSON" | "TO_JSON" | "TO_JSONB" | "ARRAY_TO_JSON" | "JSON_STRIP_NULLS" |
+                                   "JSONB_STRIP_NULLS" | "JSONB_PRETTY" | "ENCODE" | "DECODE" |
+                                   "MD5" | "DIGEST" | "HMAC" | "GEN_RANDOM_BYTES" | "SHA224" | "SHA256" | "SHA384" | "SHA512" |
+                                   "STRPOS" | "POSITION" | "STARTS_WITH" | "VARBIT" | "BIT" | "BITAND" | "BITOR" |
+                                   "BITXOR" | "BITNOT" | "BITSHIFTLEFT" | "BITSHIFTRIGHT" | "GET_BIT" | "SET_BIT" |
+                                   "XML" | "XPATH" | "XML_IS_WELL_FORMED" | "XML_IS_WELL_FORMED_CONTENT" | "XML_IS_WELL_FORMED_DOCUMENT" |
+                                   "MAKE_DATE" | "MAKE_TIME" | "MAKE_TIMESTAMP") {
+                            return Self::get_aggregate_return_type_with_query(&format!("{actual_function}()"), conn, table_name, None);
+                        }
                         // Check if this is an aggregate function
                         if matches!(actual_function.as_str(), "SUM" | "AVG" | "MAX" | "MIN" | "COUNT" | 
                                    "ARRAY_AGG" | "JSON_AGG" | "JSONB_AGG" | "STRING_AGG" |
@@ -380,6 +439,23 @@ impl SchemaTypeMapper {
             return Some(PgType::Int8.to_oid()); // bigint
         }
         
+        // width_bucket() returns the bucket number
+        if upper.starts_with("WIDTH_BUCKET(") {
+            return Some(PgType::Int4.to_oid()); // int4
+        }
+        
+        // round(value, scale) and trunc(value, scale) keep the scale on numeric columns, which
+        // are rewritten to decimal_round and decimal_trunc; other values are rounded as float8
+        if (upper.starts_with("ROUND(") || upper.starts_with("TRUNC(")) && upper.contains(',') {
+            let argument = function_name[6..function_name.find(',').unwrap_or(6)].trim();
+            let column = argument.rsplit('.').next().unwrap_or(argument);
+            if let (Some(conn), Some(table)) = (conn, table_name)
+                && Self::get_type_from_schema(conn, table, column) == Some(PgType::Numeric.to_oid()) {
+                    return Some(PgType::Numeric.to_oid()); // numeric
+                }
+            return Some(PgType::Float8.to_oid()); // float8
+        }
+        
         // Decimal arithmetic functions that return numeric
         if upper.starts_with("DECIMAL_ADD(") || upper.starts_with("DECIMAL_SUB(") || 
            upper.starts_with("DECIMAL_MUL(") || upper.starts_with("DECIMAL_DIV(") ||
@@ -387,6 +463,12 @@ impl SchemaTypeMapper {
             return Some(PgType::Numeric.to_oid()); // numeric
         }
         
+        // Decimal math functions the rewriter substitutes for numeric arguments
+        if ["DECIMAL_ROUND(", "DECIMAL_ABS(", "DECIMAL_TRUNC(", "DECIMAL_CEIL(", "DECIMAL_FLOOR(",
+            "DECIMAL_MOD(", "DECIMAL_POWER(", "DECIMAL_SQRT("].iter().any(|f| upper.starts_with(f)) {
+            return Some(PgType::Numeric.to_oid()); // numeric
+        }
+        
         // JSON functions that return integers
         if upper.starts_with("JSON_ARRAY_LENGTH(") {
             return Some(PgType::Int4.to_oid()); // int4
@@ -398,10 +480,103 @@ impl SchemaTypeMapper {
             return Some(PgType::Text.to_oid()); // text
         }
         
-        // JSON aggregate functions that return text (for compatibility)
-        if upper.starts_with("JSON_AGG(") || upper.starts_with("JSON_OBJECT_AGG(") ||
-           upper.starts_with("JSONB_AGG(") || upper.starts_with("JSONB_OBJECT_AGG(") ||
-           upper.starts_with("ROW_TO_JSON(") {
+        // JSON aggregate functions return json or jsonb like PostgreSQL
+        if upper.starts_with("JSON_AGG(") || upper.starts_with("JSON_OBJECT_AGG(") {
+            return Some(PgType::Json.to_oid()); // json
+        }
+        if upper.starts_with("JSONB_AGG(") || upper.starts_with("JSONB_OBJECT_AGG(") {
+            return Some(PgType::Jsonb.to_oid()); // jsonb
+        }
+        
+        // row_to_json, to_json and array_to_json return json, to_jsonb returns jsonb
+        if upper.starts_with("ROW_TO_JSON(") || upper.starts_with("TO_JSON(") || upper.starts_with("ARRAY_TO_JSON(") {
+            return Some(PgType::Json.to_oid()); // json
+        }
+        if upper.starts_with("JSON_STRIP_NULLS(") {
+            return Some(PgType::Json.to_oid()); // json
+        }
+        if upper.starts_with("JSONB_STRIP_NULLS(") {
+            return Some(PgType::Jsonb.to_oid()); // jsonb
+        }
+        if upper.starts_with("JSONB_PRETTY(") {
+            return Some(PgType::Text.to_oid()); // text
+        }
+        if upper.starts_with("TO_JSONB(") {
+            return Some(PgType::Jsonb.to_oid()); // jsonb
+        }
+        
+        // Object size functions return bigint byte counts
+        if ["PG_RELATION_SIZE(", "PG_TABLE_SIZE(", "PG_INDEXES_SIZE(", "PG_TOTAL_RELATION_SIZE(",
+            "PG_DATABASE_SIZE("].iter().any(|f| upper.starts_with(f)) {
+            return Some(PgType::Int8.to_oid()); // int8
+        }
+        
+        if upper.starts_with("REGEXP_MATCHES(") {
+            return Some(PgType::TextArray.to_oid()); // text[]
+        }
+        
+        // Large object data comes back as bytea, oids, descriptors and results as integers
+        if upper.starts_with("LOREAD(") || upper.starts_with("LO_GET(") {
+            return Some(PgType::Bytea.to_oid()); // bytea
+        }
+        if upper.starts_with("LO_LSEEK64(") || upper.starts_with("LO_TELL64(") {
+            return Some(PgType::Int8.to_oid()); // int8
+        }
+        if ["LO_CREAT(", "LO_CREATE(", "LO_FROM_BYTEA(", "LO_OPEN(", "LO_CLOSE(", "LOWRITE(", "LO_LSEEK(",
+            "LO_TELL(", "LO_TRUNCATE(", "LO_UNLINK("].iter().any(|f| upper.starts_with(f)) {
+            return Some(PgType::Int4.to_oid()); // int4
+        }
+        
+        // decode() returns bytea, encode() its text form
+        if upper.starts_with("DECODE(") {
+            return Some(PgType::Bytea.to_oid()); // bytea
+        }
+        if upper.starts_with("ENCODE(") {
+            return Some(PgType::Text.to_oid()); // text
+        }
+        
+        // Hashes are bytea, except md5() which returns its hex digits
+        if ["DIGEST(", "HMAC(", "GEN_RANDOM_BYTES(", "SHA224(", "SHA256(", "SHA384(", "SHA512("].iter().any(|f| upper.starts_with(f)) {
+            return Some(PgType::Bytea.to_oid()); // bytea
+        }
+        if upper.starts_with("MD5(") {
+            return Some(PgType::Text.to_oid()); // text
+        }
+        
+        // position(substring IN string) is translated to strpos()
+        if upper.starts_with("STRPOS(") || upper.starts_with("POSITION(") {
+            return Some(PgType::Int4.to_oid()); // int4
+        }
+        if upper.starts_with("STARTS_WITH(") {
+            return Some(PgType::Bool.to_oid()); // bool
+        }
+        
+        // Bit string literals, casts and operators are translated to these functions
+        if upper.starts_with("VARBIT(") {
+            return Some(PgType::Varbit.to_oid()); // varbit
+        }
+        if upper.starts_with("BIT(") || upper.starts_with("BITAND(") || upper.starts_with("BITOR(") ||
+           upper.starts_with("BITXOR(") || upper.starts_with("BITNOT(") || upper.starts_with("BITSHIFTLEFT(") ||
+           upper.starts_with("BITSHIFTRIGHT(") || upper.starts_with("SET_BIT(") {
+            return Some(PgType::Bit.to_oid()); // bit
+        }
+        if upper.starts_with("GET_BIT(") {
+            return Some(PgType::Int4.to_oid()); // int4
+        }
+        
+        // '...'::xml is translated to xml()
+        if upper.starts_with("XML(") {
+            return Some(PgType::Xml.to_oid()); // xml
+        }
+        if upper.starts_with("XPATH(") {
+            return Some(PgType::TextArray.to_oid()); // text[]
+        }
+        if upper.starts_with("XML_IS_WELL_FORMED") {
+            return Some(PgType::Bool.to_oid()); // bool
+        }
+        
+        // pg_typeof() is replaced by its type name during translation
+        if upper.starts_with("PG_TYPEOF(") {
             return Some(PgType::Text.to_oid()); // text
         }
         
@@ -411,7 +586,8 @@ impl SchemaTypeMapper {
         }
         
         // Timestamp functions that return INTEGER microseconds (stored as timestamp type)
-        if upper == "NOW()" || upper == "CURRENT_TIMESTAMP" || upper == "CURRENT_TIMESTAMP()" {
+        if upper == "NOW()" || upper == "CURRENT_TIMESTAMP" || upper == "CURRENT_TIMESTAMP()" ||
+           upper == "TRANSACTION_TIMESTAMP()" || upper == "CLOCK_TIMESTAMP()" {
             return Some(PgType::Timestamptz.to_oid()); // timestamptz (formatted timestamp string)
         }
         
@@ -424,6 +600,14 @@ impl SchemaTypeMapper {
             return Some(PgType::Date.to_oid()); // date (INTEGER days since epoch)
         }
         
+        if upper.starts_with("MAKE_TIMESTAMP(") {
+            return Some(PgType::Timestamp.to_oid()); // timestamp (INTEGER microseconds since epoch)
+        }
+        
+        if upper.starts_with("MAKE_INTERVAL(") {
+            return Some(PgType::Interval.to_oid()); // interval (canonical text)
+        }
+        
         if upper == "EPOCH()" {
             return Some(PgType::Timestamp.to_oid()); // epoch as timestamp
         }
@@ -432,6 +616,11 @@ impl SchemaTypeMapper {
             return Some(PgType::Interval.to_oid()); // interval (INTEGER microseconds)
         }
         
+        // UUID generators
+        if upper == "GEN_RANDOM_UUID()" || upper == "UUID_GENERATE_V4()" || upper == "UUID_GENERATE_V1()" {
+            return Some(PgType::Uuid.to_oid());
+        }
+        
         // Time functions that return INTEGER microseconds (stored as time type)
         if upper == "CURRENT_TIME" || upper == "CURRENT_TIME()" || upper.starts_with("MAKE_TIME(") {
             return Some(PgType::Time.to_oid()); // time (INTEGER microseconds since midnight)
@@ -483,7 +672,8 @@ impl SchemaTypeMapper {
         }
         
         if upper.starts_with("ARRAY_LENGTH(") || upper.starts_with("ARRAY_UPPER(") || 
-           upper.starts_with("ARRAY_LOWER(") || upper.starts_with("ARRAY_NDIMS(") {
+           upper.starts_with("ARRAY_LOWER(") || upper.starts_with("ARRAY_NDIMS(") ||
+           upper.starts_with("CARDINALITY(") {
             return Some(PgType::Int4.to_oid()); // int4
         }
         
@@ -518,4 +708,263 @@ impl SchemaTypeMapper {
             None
         }
     }
-}
\ No newline at end of file
+
+    /// Infer the result column types of a SELECT without a FROM clause, such as
+    /// `SELECT 1, now(), gen_random_uuid()`. Returns None for any other query, and
+    /// None for an individual column whose type can't be determined from its expression.
+    pub fn infer_fromless_select_types(query: &str) -> Option<Vec<Option<i32>>> {
+        // Cheap pre-check before parsing
+        if query.to_uppercase().contains("FROM") {
+            return None;
+        }
+        
+        let statements = Parser::parse_sql(&PostgreSqlDialect {}, query).ok()?;
+        let [Statement::Query(parsed)] = statements.as_slice() else {
+            return None;
+        };
+        let SetExpr::Select(select) = parsed.body.as_ref() else {
+            return None;
+        };
+        if !select.from.is_empty() {
+            return None;
+        }
+        
+        Some(select.projection.iter()
+            .map(|item| match item {
+                SelectItem::UnnamedExpr(expr) | SelectItem::ExprWithAlias { expr, .. } => Self::infer_literal_expr_type(expr),
+                _ => None,
+            })
+            .collect())
+    }
+    
+    /// Infer the type of a constant expression or function call
+    pub(crate) fn infer_literal_expr_type(expr: &Expr) -> Option<i32> {
+        match expr {
+            Expr::Value(value) => match &value.value {
+                Value::Number(n, _) => {
+                    let oid = match n.parse::<i64>() {
+                        Ok(i) if i >= i32::MIN as i64 && i <= i32::MAX as i64 => PgType::Int4.to_oid(),
+                        Ok(_) => PgType::Int8.to_oid(),
+                        Err(_) => PgType::Numeric.to_oid(),
+                    };
+                    Some(oid)
+                }
+                Value::SingleQuotedString(_) | Value::DoubleQuotedString(_) | Value::Null => Some(PgType::Text.to_oid()),
+                Value::Boolean(_) => Some(PgType::Bool.to_oid()),
+                _ => None,
+            },
+            Expr::UnaryOp { op: UnaryOperator::Minus | UnaryOperator::Plus, expr } => Self::infer_literal_expr_type(expr),
+            Expr::UnaryOp { op: UnaryOperator::Not, .. } => Some(PgType::Bool.to_oid()),
+            Expr::BinaryOp {
+                op: BinaryOperator::Eq | BinaryOperator::NotEq | BinaryOperator::Lt | BinaryOperator::LtEq |
+                    BinaryOperator::Gt | BinaryOperator::GtEq | BinaryOperator::And | BinaryOperator::Or,
+                ..
+            } => Some(PgType::Bool.to_oid()),
+            Expr::Between { .. } => Some(PgType::Bool.to_oid()),
+            Expr::BinaryOp {
+                left,
+                op: BinaryOperator::Plus | BinaryOperator::Minus | BinaryOperator::Multiply |
+                    BinaryOperator::Divide | BinaryOperator::Modulo,
+                right,
+            } => Self::promote_arithmetic_type(Self::infer_literal_expr_type(left)?, Self::infer_literal_expr_type(right)?),
+            Expr::Nested(inner) => Self::infer_literal_expr_type(inner),
+            Expr::Cast { data_type, .. } => Some(Self::pg_type_string_to_oid(&data_type.to_string())),
+            Expr::Function(function) if function.name.to_string().eq_ignore_ascii_case("coalesce") => {
+                Self::infer_coalesce_type(expr, &Self::infer_literal_expr_type)
+            }
+            Expr::Function(_) => Self::get_aggregate_return_type_with_query(&expr.to_string(), None, None, None),
+            Expr::Case { .. } => Self::infer_case_type(expr, &Self::infer_literal_expr_type),
+            // ARRAY[...] is an array of its first typed element
+            Expr::Array(array) => {
+                let element = array.elem.iter()
+                    .filter(|elem| !matches!(elem, Expr::Value(v) if v.value == Value::Null))
+                    .find_map(Self::infer_literal_expr_type)
+                    .unwrap_or(PgType::Text.to_oid());
+                PgType::from_oid(element).and_then(|t| t.array_type()).map(|t| t.to_oid())
+            }
+            _ => None,
+        }
+    }
+    
+    /// Result type of a CASE expression: the common type of its THEN and ELSE results, each
+    /// typed by `resolve`. String literals and NULL (including a missing ELSE) are untyped and
+    /// take the type of the other results, as in PostgreSQL. Returns None if any other result
+    /// can't be typed.
+    pub fn infer_case_type(expr: &Expr, resolve: &dyn Fn(&Expr) -> Option<i32>) -> Option<i32> {
+        let Expr::Case { conditions, else_result, .. } = expr else {
+            return None;
+        };
+        
+        Self::infer_common_type(conditions.iter().map(|when| &when.result).chain(else_result.as_deref()), resolve)
+    }
+    
+    /// Result type of a COALESCE call: the common type of its arguments, resolved like the
+    /// results of a CASE. It doesn't depend on which argument the value came from, so a
+    /// COALESCE that returns NULL for every row still reports a stable type.
+    pub fn infer_coalesce_type(expr: &Expr, resolve: &dyn Fn(&Expr) -> Option<i32>) -> Option<i32> {
+        let Expr::Function(function) = expr else {
+            return None;
+        };
+        let FunctionArguments::List(list) = &function.args else {
+            return None;
+        };
+        
+        let mut arguments = Vec::new();
+        for arg in &list.args {
+            let FunctionArg::Unnamed(FunctionArgExpr::Expr(argument)) = arg else {
+                return None;
+            };
+            arguments.push(argument);
+        }
+        Self::infer_common_type(arguments.into_iter(), resolve)
+    }
+    
+    /// Common type of a set of results, skipping untyped string literals and NULLs
+    fn infer_common_type<'a>(results: impl Iterator<Item = &'a Expr>, resolve: &dyn Fn(&Expr) -> Option<i32>) -> Option<i32> {
+        let mut types = Vec::new();
+        for result in results {
+            let untyped = matches!(result, Expr::Value(v) if matches!(v.value, Value::SingleQuotedString(_) | Value::Null));
+            if !untyped {
+                types.push(resolve(result)?);
+            }
+        }
+        Some(Self::unify_types(&types))
+    }
+    
+    /// Common type of CASE or COALESCE results: identical types stay, numeric types promote
+    /// like arithmetic (integer and numeric give numeric), dates and timestamps widen to
+    /// timestamp and then timestamptz, anything else falls back to text
+    fn unify_types(types: &[i32]) -> i32 {
+        let Some((&first, rest)) = types.split_first() else {
+            return PgType::Text.to_oid();
+        };
+        rest.iter()
+            .try_fold(first, |unified, &oid| {
+                if unified == oid {
+                    Some(oid)
+                } else {
+                    Self::promote_arithmetic_type(unified, oid).or_else(|| Self::promote_datetime_type(unified, oid))
+                }
+            })
+            .unwrap_or(PgType::Text.to_oid())
+    }
+    
+    /// Common type of two datetime types, following PostgreSQL's implicit casts
+    /// date -> timestamp -> timestamptz
+    fn promote_datetime_type(left: i32, right: i32) -> Option<i32> {
+        let rank = |oid: i32| match PgType::from_oid(oid)? {
+            PgType::Date => Some(1),
+            PgType::Timestamp => Some(2),
+            PgType::Timestamptz => Some(3),
+            _ => None,
+        };
+        Some(if rank(left)? >= rank(right)? { left } else { right })
+    }
+    
+    /// Result type of an arithmetic operator: integers widen to the larger operand, so
+    /// int2 + int2 stays int2 while int2 + int4 is int4; floats win over numeric
+    pub(crate) fn promote_arithmetic_type(left: i32, right: i32) -> Option<i32> {
+        let rank = |oid: i32| match PgType::from_oid(oid)? {
+            PgType::Int2 => Some(1),
+            PgType::Int4 => Some(2),
+            PgType::Int8 => Some(3),
+            PgType::Numeric => Some(4),
+            PgType::Float4 => Some(5),
+            PgType::Float8 => Some(6),
+            _ => None,
+        };
+        let promoted = match (rank(left)?, rank(right)?) {
+            // real + real stays real, but real mixed with anything else is double precision
+            (5, 5) => PgType::Float4.to_oid(),
+            (5, _) | (_, 5) => PgType::Float8.to_oid(),
+            (l, r) if l >= r => left,
+            _ => right,
+        };
+        Some(promoted)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_infer_fromless_arithmetic_types() {
+        let types = SchemaTypeMapper::infer_fromless_select_types(
+            "SELECT 2::int2 + 3::int2, CAST(2 AS SMALLINT) * 3, 1 + 3000000000, 7 / 2.0, 1.5::real + 1, -(2::int2 - 1::int2)"
+        ).unwrap();
+        assert_eq!(types, vec![
+            Some(PgType::Int2.to_oid()),
+            Some(PgType::Int4.to_oid()),
+            Some(PgType::Int8.to_oid()),
+            Some(PgType::Numeric.to_oid()),
+            Some(PgType::Float8.to_oid()),
+            Some(PgType::Int2.to_oid()),
+        ]);
+    }
+
+    #[test]
+    fn test_infer_fromless_select_types() {
+        let types = SchemaTypeMapper::infer_fromless_select_types("SELECT 1, now(), gen_random_uuid()").unwrap();
+        assert_eq!(types, vec![
+            Some(PgType::Int4.to_oid()),
+            Some(PgType::Timestamptz.to_oid()),
+            Some(PgType::Uuid.to_oid()),
+        ]);
+
+        let types = SchemaTypeMapper::infer_fromless_select_types("SELECT 3000000000 AS big, -1.5, 'x', true, CAST('1' AS BIGINT), some_udf()").unwrap();
+        assert_eq!(types, vec![
+            Some(PgType::Int8.to_oid()),
+            Some(PgType::Numeric.to_oid()),
+            Some(PgType::Text.to_oid()),
+            Some(PgType::Bool.to_oid()),
+            Some(PgType::Int8.to_oid()),
+            None,
+        ]);
+
+        let types = SchemaTypeMapper::infer_fromless_select_types("SELECT 2 BETWEEN 1 AND 3, 1 < 2 AND 2 < 3").unwrap();
+        assert_eq!(types, vec![Some(PgType::Bool.to_oid()), Some(PgType::Bool.to_oid())]);
+
+        let types = SchemaTypeMapper::infer_fromless_select_types("SELECT ARRAY[NULL, 1, 2], ARRAY['a'], ARRAY[]").unwrap();
+        assert_eq!(types, vec![
+            Some(PgType::Int4Array.to_oid()),
+            Some(PgType::TextArray.to_oid()),
+            Some(PgType::TextArray.to_oid()),
+        ]);
+
+        assert_eq!(SchemaTypeMapper::infer_fromless_select_types("SELECT id FROM users"), None);
+    }
+
+    #[test]
+    fn test_infer_fromless_case_types() {
+        let types = SchemaTypeMapper::infer_fromless_select_types(
+            "SELECT CASE WHEN 1 > 0 THEN 1.5 ELSE 0 END, CASE 2 WHEN 1 THEN 'a' WHEN 2 THEN 'b' END, \
+             CASE WHEN 1 > 0 THEN 1 END, CASE WHEN 1 > 0 THEN '7' ELSE 3000000000 END, \
+             CASE WHEN 1 > 0 THEN true ELSE 1 END, CASE WHEN 1 > 0 THEN some_udf() ELSE 1 END"
+        ).unwrap();
+        assert_eq!(types, vec![
+            Some(PgType::Numeric.to_oid()),
+            Some(PgType::Text.to_oid()),
+            Some(PgType::Int4.to_oid()),
+            Some(PgType::Int8.to_oid()),
+            Some(PgType::Text.to_oid()),
+            None,
+        ]);
+    }
+    
+    #[test]
+    fn test_infer_fromless_coalesce_types() {
+        let types = SchemaTypeMapper::infer_fromless_select_types(
+            "SELECT COALESCE(NULL, 1), coalesce(NULL, NULL), COALESCE(1, 2.5, NULL), COALESCE('x', 'y'), \
+             COALESCE(CAST('2024-01-01' AS DATE), '2024-01-02'::timestamp), COALESCE(1, true)"
+        ).unwrap();
+        assert_eq!(types, vec![
+            Some(PgType::Int4.to_oid()),
+            Some(PgType::Text.to_oid()),
+            Some(PgType::Numeric.to_oid()),
+            Some(PgType::Text.to_oid()),
+            Some(PgType::Timestamp.to_oid()),
+            Some(PgType::Text.to_oid()),
+        ]);
+    }
+}
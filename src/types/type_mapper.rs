@@ -27,12 +27,17 @@ pub enum PgType {
     Int4range = 3904,
     Int8range = 3926,
     Numrange = 3906,
+    Tsrange = 3908,
+    Tstzrange = 3910,
+    Daterange = 3912,
     Cidr = 650,
     Inet = 869,
     Macaddr = 829,
     Macaddr8 = 774,
     Bit = 1560,
     Varbit = 1562,
+    Point = 600,
+    Xml = 142,
     Unknown = 705,
     // Full-text search types
     Tsvector = 3614,
@@ -63,12 +68,17 @@ pub enum PgType {
     Int4rangeArray = 3905,
     Int8rangeArray = 3927,
     NumrangeArray = 3907,
+    TsrangeArray = 3909,
+    TstzrangeArray = 3911,
+    DaterangeArray = 3913,
     CidrArray = 651,
     InetArray = 1041,
     MacaddrArray = 1040,
     Macaddr8Array = 775,
     BitArray = 1561,
     VarbitArray = 1563,
+    PointArray = 1017,
+    XmlArray = 143,
 }
 
 impl PgType {
@@ -98,12 +108,17 @@ impl PgType {
             3904 => Some(PgType::Int4range),
             3926 => Some(PgType::Int8range),
             3906 => Some(PgType::Numrange),
+            3908 => Some(PgType::Tsrange),
+            3910 => Some(PgType::Tstzrange),
+            3912 => Some(PgType::Daterange),
             650 => Some(PgType::Cidr),
             869 => Some(PgType::Inet),
             829 => Some(PgType::Macaddr),
             774 => Some(PgType::Macaddr8),
             1560 => Some(PgType::Bit),
             1562 => Some(PgType::Varbit),
+            600 => Some(PgType::Point),
+            142 => Some(PgType::Xml),
             705 => Some(PgType::Unknown),
             // Full-text search types
             3614 => Some(PgType::Tsvector),
@@ -134,12 +149,17 @@ impl PgType {
             3905 => Some(PgType::Int4rangeArray),
             3927 => Some(PgType::Int8rangeArray),
             3907 => Some(PgType::NumrangeArray),
+            3909 => Some(PgType::TsrangeArray),
+            3911 => Some(PgType::TstzrangeArray),
+            3913 => Some(PgType::DaterangeArray),
             651 => Some(PgType::CidrArray),
             1041 => Some(PgType::InetArray),
             1040 => Some(PgType::MacaddrArray),
             775 => Some(PgType::Macaddr8Array),
             1561 => Some(PgType::BitArray),
             1563 => Some(PgType::VarbitArray),
+            1017 => Some(PgType::PointArray),
+            143 => Some(PgType::XmlArray),
             _ => None,
         }
     }
@@ -174,12 +194,17 @@ impl PgType {
             PgType::Int4range => "int4range",
             PgType::Int8range => "int8range",
             PgType::Numrange => "numrange",
+            PgType::Tsrange => "tsrange",
+            PgType::Tstzrange => "tstzrange",
+            PgType::Daterange => "daterange",
             PgType::Cidr => "cidr",
             PgType::Inet => "inet",
             PgType::Macaddr => "macaddr",
             PgType::Macaddr8 => "macaddr8",
             PgType::Bit => "bit",
             PgType::Varbit => "varbit",
+            PgType::Point => "point",
+            PgType::Xml => "xml",
             PgType::Unknown => "unknown",
             // Full-text search types
             PgType::Tsvector => "tsvector",
@@ -210,12 +235,17 @@ impl PgType {
             PgType::Int4rangeArray => "_int4range",
             PgType::Int8rangeArray => "_int8range",
             PgType::NumrangeArray => "_numrange",
+            PgType::TsrangeArray => "_tsrange",
+            PgType::TstzrangeArray => "_tstzrange",
+            PgType::DaterangeArray => "_daterange",
             PgType::CidrArray => "_cidr",
             PgType::InetArray => "_inet",
             PgType::MacaddrArray => "_macaddr",
             PgType::Macaddr8Array => "_macaddr8",
             PgType::BitArray => "_bit",
             PgType::VarbitArray => "_varbit",
+            PgType::PointArray => "_point",
+            PgType::XmlArray => "_xml",
         }
     }
 
@@ -228,8 +258,9 @@ impl PgType {
             PgType::DateArray | PgType::TimeArray | PgType::TimestampArray | PgType::TimestamptzArray |
             PgType::TimetzArray | PgType::IntervalArray | PgType::NumericArray | PgType::ByteaArray |
             PgType::MoneyArray | PgType::Int4rangeArray | PgType::Int8rangeArray | PgType::NumrangeArray |
+            PgType::TsrangeArray | PgType::TstzrangeArray | PgType::DaterangeArray |
             PgType::CidrArray | PgType::InetArray | PgType::MacaddrArray | PgType::Macaddr8Array |
-            PgType::BitArray | PgType::VarbitArray
+            PgType::BitArray | PgType::VarbitArray | PgType::PointArray | PgType::XmlArray
         )
     }
 
@@ -269,12 +300,17 @@ impl PgType {
             PgType::Int4rangeArray => Some(PgType::Int4range),
             PgType::Int8rangeArray => Some(PgType::Int8range),
             PgType::NumrangeArray => Some(PgType::Numrange),
+            PgType::TsrangeArray => Some(PgType::Tsrange),
+            PgType::TstzrangeArray => Some(PgType::Tstzrange),
+            PgType::DaterangeArray => Some(PgType::Daterange),
             PgType::CidrArray => Some(PgType::Cidr),
             PgType::InetArray => Some(PgType::Inet),
             PgType::MacaddrArray => Some(PgType::Macaddr),
             PgType::Macaddr8Array => Some(PgType::Macaddr8),
             PgType::BitArray => Some(PgType::Bit),
             PgType::VarbitArray => Some(PgType::Varbit),
+            PgType::PointArray => Some(PgType::Point),
+            PgType::XmlArray => Some(PgType::Xml),
             _ => None,
         }
     }
@@ -306,12 +342,17 @@ impl PgType {
             PgType::Int4range => Some(PgType::Int4rangeArray),
             PgType::Int8range => Some(PgType::Int8rangeArray),
             PgType::Numrange => Some(PgType::NumrangeArray),
+            PgType::Tsrange => Some(PgType::TsrangeArray),
+            PgType::Tstzrange => Some(PgType::TstzrangeArray),
+            PgType::Daterange => Some(PgType::DaterangeArray),
             PgType::Cidr => Some(PgType::CidrArray),
             PgType::Inet => Some(PgType::InetArray),
             PgType::Macaddr => Some(PgType::MacaddrArray),
             PgType::Macaddr8 => Some(PgType::Macaddr8Array),
             PgType::Bit => Some(PgType::BitArray),
             PgType::Varbit => Some(PgType::VarbitArray),
+            PgType::Point => Some(PgType::PointArray),
+            PgType::Xml => Some(PgType::XmlArray),
             _ => None,
         }
     }
@@ -360,6 +401,7 @@ impl TypeMapper {
         // Additional mappings from PRD
         mapper.pg_to_sqlite.insert("serial".to_string(), "INTEGER".to_string());
         mapper.pg_to_sqlite.insert("bigserial".to_string(), "INTEGER".to_string());
+        mapper.pg_to_sqlite.insert("smallserial".to_string(), "INTEGER".to_string());
         mapper.pg_to_sqlite.insert("character varying".to_string(), "TEXT".to_string());
         mapper.pg_to_sqlite.insert("character".to_string(), "TEXT".to_string());
         mapper.pg_to_sqlite.insert("timestamp with time zone".to_string(), "INTEGER".to_string());
@@ -375,13 +417,18 @@ impl TypeMapper {
         mapper.pg_to_sqlite.insert("int4range".to_string(), "TEXT".to_string());
         mapper.pg_to_sqlite.insert("int8range".to_string(), "TEXT".to_string());
         mapper.pg_to_sqlite.insert("numrange".to_string(), "TEXT".to_string());
+        mapper.pg_to_sqlite.insert("tsrange".to_string(), "TEXT".to_string());
+        mapper.pg_to_sqlite.insert("tstzrange".to_string(), "TEXT".to_string());
+        mapper.pg_to_sqlite.insert("daterange".to_string(), "TEXT".to_string());
         mapper.pg_to_sqlite.insert("cidr".to_string(), "TEXT".to_string());
         mapper.pg_to_sqlite.insert("inet".to_string(), "TEXT".to_string());
         mapper.pg_to_sqlite.insert("macaddr".to_string(), "TEXT".to_string());
+        mapper.pg_to_sqlite.insert("point".to_string(), "TEXT".to_string());
         mapper.pg_to_sqlite.insert("macaddr8".to_string(), "TEXT".to_string());
         mapper.pg_to_sqlite.insert("bit".to_string(), "TEXT".to_string());
         mapper.pg_to_sqlite.insert("bit varying".to_string(), "TEXT".to_string());
         mapper.pg_to_sqlite.insert("varbit".to_string(), "TEXT".to_string());
+        mapper.pg_to_sqlite.insert("xml".to_string(), "TEXT".to_string());
         
         // Full-text search types
         mapper.pg_to_sqlite.insert("tsvector".to_string(), "TEXT".to_string());
@@ -464,6 +511,7 @@ impl TypeMapper {
         match normalized_type.to_uppercase().as_str() {
             "SERIAL" => "INTEGER PRIMARY KEY AUTOINCREMENT".to_string(),
             "BIGSERIAL" => "INTEGER PRIMARY KEY AUTOINCREMENT".to_string(),
+            "SMALLSERIAL" => "INTEGER PRIMARY KEY AUTOINCREMENT".to_string(),
             _ => {
                 // Check for parametric types first
                 if let Some(base_type) = self.extract_base_type(&normalized_type) {
@@ -710,6 +758,7 @@ mod tests {
         assert_eq!(mapper.pg_to_sqlite_for_create_table("SERIAL"), "INTEGER PRIMARY KEY AUTOINCREMENT");
         assert_eq!(mapper.pg_to_sqlite_for_create_table("serial"), "INTEGER PRIMARY KEY AUTOINCREMENT");
         assert_eq!(mapper.pg_to_sqlite_for_create_table("BIGSERIAL"), "INTEGER PRIMARY KEY AUTOINCREMENT");
+        assert_eq!(mapper.pg_to_sqlite_for_create_table("smallserial"), "INTEGER PRIMARY KEY AUTOINCREMENT");
         assert_eq!(mapper.pg_to_sqlite_for_create_table("bigserial"), "INTEGER PRIMARY KEY AUTOINCREMENT");
     }
     
@@ -161,10 +161,13 @@ impl TypePropagator {
     /// Determine the result type of a function call
     pub fn function_type(name: &str, args: &[(PgType, Option<DateTimeSubtype>)]) -> (PgType, Option<DateTimeSubtype>) {
         match name.to_lowercase().as_str() {
-            "now" | "current_timestamp" => (PgType::Timestamptz, Some(DateTimeSubtype::TimestampTz)),
+            "now" | "current_timestamp" | "transaction_timestamp" | "clock_timestamp" => (PgType::Timestamptz, Some(DateTimeSubtype::TimestampTz)),
             "current_date" => (PgType::Date, Some(DateTimeSubtype::Date)),
             "current_time" => (PgType::Timetz, Some(DateTimeSubtype::TimeTz)),
-            "age" => (PgType::Interval, Some(DateTimeSubtype::Interval)),
+            "age" | "make_interval" => (PgType::Interval, Some(DateTimeSubtype::Interval)),
+            "make_date" => (PgType::Date, Some(DateTimeSubtype::Date)),
+            "make_time" => (PgType::Time, Some(DateTimeSubtype::Time)),
+            "make_timestamp" => (PgType::Timestamp, Some(DateTimeSubtype::Timestamp)),
             "extract" | "date_part" => (PgType::Float8, None),
             "date_trunc" => {
                 // date_trunc preserves the input timestamp type
@@ -1,3 +1,6 @@
+use std::sync::atomic::{AtomicU64, Ordering};
+use std::time::{SystemTime, UNIX_EPOCH};
+use once_cell::sync::Lazy;
 use crate::PgSqliteError;
 
 /// UUID utilities for PostgreSQL compatibility
@@ -61,6 +64,43 @@ pub fn generate_uuid_v4() -> String {
     uuid::Uuid::new_v4().to_string()
 }
 
+/// 100-nanosecond intervals between the start of the Gregorian calendar, which v1
+/// timestamps count from, and the Unix epoch
+const GREGORIAN_OFFSET: u64 = 0x01b2_1dd2_1381_4000;
+
+/// The timestamp of the last v1 UUID, so UUIDs generated within one clock tick still differ
+static LAST_V1_TIMESTAMP: AtomicU64 = AtomicU64::new(0);
+
+/// The clock sequence and node of v1 UUIDs. There's no portable way to read the MAC
+/// address, so the node is random with the multicast bit set, as RFC 4122 allows.
+static V1_CLOCK_SEQ_AND_NODE: Lazy<(u16, [u8; 6])> = Lazy::new(|| {
+    let mut node = rand::random::<[u8; 6]>();
+    node[0] |= 0x01;
+    (rand::random::<u16>() & 0x3fff, node)
+});
+
+/// SQLite function for UUID generation (v1, time-based)
+pub fn generate_uuid_v1() -> String {
+    let now = SystemTime::now().duration_since(UNIX_EPOCH).unwrap_or_default();
+    let now = (now.as_nanos() / 100) as u64 + GREGORIAN_OFFSET;
+    let timestamp = match LAST_V1_TIMESTAMP.fetch_update(Ordering::SeqCst, Ordering::SeqCst, |last| Some(now.max(last + 1))) {
+        Ok(last) | Err(last) => now.max(last + 1),
+    };
+
+    let (clock_seq, node) = *V1_CLOCK_SEQ_AND_NODE;
+    let mut clock_seq_and_node = [0u8; 8];
+    clock_seq_and_node[0] = (clock_seq >> 8) as u8 | 0x80; // RFC 4122 variant
+    clock_seq_and_node[1] = clock_seq as u8;
+    clock_seq_and_node[2..].copy_from_slice(&node);
+
+    uuid::Uuid::from_fields(
+        timestamp as u32,
+        (timestamp >> 32) as u16,
+        (timestamp >> 48) as u16 & 0x0fff | 0x1000, // version 1
+        &clock_seq_and_node,
+    ).to_string()
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -106,4 +146,25 @@ mod tests {
         assert_eq!(&uuid1[14..15], "4");
         assert_eq!(&uuid2[14..15], "4");
     }
+    
+    #[test]
+    fn test_generate_uuid_v1() {
+        let uuid1 = uuid::Uuid::parse_str(&generate_uuid_v1()).unwrap();
+        let uuid2 = uuid::Uuid::parse_str(&generate_uuid_v1()).unwrap();
+        assert_ne!(uuid1, uuid2);
+        assert_eq!(uuid1.get_version_num(), 1);
+        assert_eq!(uuid1.get_variant(), uuid::Variant::RFC4122);
+        
+        // The embedded timestamp is the current time and keeps increasing
+        let timestamp = |uuid: &uuid::Uuid| {
+            let (low, mid, high, _) = uuid.as_fields();
+            ((high & 0x0fff) as u64) << 48 | (mid as u64) << 32 | low as u64
+        };
+        assert!(timestamp(&uuid2) > timestamp(&uuid1));
+        let now = (SystemTime::now().duration_since(UNIX_EPOCH).unwrap().as_nanos() / 100) as u64 + GREGORIAN_OFFSET;
+        assert!(now.abs_diff(timestamp(&uuid1)) < 10_000_000);
+        
+        // The node is the same for every UUID of the process
+        assert_eq!(uuid1.as_bytes()[8..], uuid2.as_bytes()[8..]);
+    }
 }
\ No newline at end of file
@@ -1,7 +1,6 @@
 use crate::types::type_mapper::PgType;
 use std::net::{Ipv4Addr, Ipv6Addr};
 use regex::Regex;
-use chrono::{NaiveTime, Timelike};
 use crate::types::datetime_utils;
 use once_cell::sync::Lazy;
 
@@ -22,10 +21,6 @@ static TIMESTAMPTZ_REGEX: Lazy<Regex> = Lazy::new(|| {
     Regex::new(r"^(.+?)([-+]\d{2}:?\d{2})$").unwrap()
 });
 
-static INTERVAL_REGEX: Lazy<Regex> = Lazy::new(|| {
-    Regex::new(r"(?:(\d+)\s+days?\s*)?(?:(\d{2}):(\d{2}):(\d{2})(?:\.(\d+))?)?").unwrap()
-});
-
 static TIMEZONE_OFFSET_REGEX: Lazy<Regex> = Lazy::new(|| {
     Regex::new(r"^([-+])(\d{2}):?(\d{2})$").unwrap()
 });
@@ -38,6 +33,9 @@ impl ValueConverter {
         match pg_type {
             PgType::Money => Self::convert_money(value),
             PgType::Int4range | PgType::Int8range | PgType::Numrange => Self::convert_range(value),
+            PgType::Tsrange | PgType::Tstzrange | PgType::Daterange => {
+                crate::functions::range_functions::canonical_range(value, pg_type.name())
+            }
             PgType::Cidr => Self::convert_cidr(value),
             PgType::Inet => Self::convert_inet(value),
             PgType::Macaddr => Self::convert_macaddr(value),
@@ -48,7 +46,7 @@ impl ValueConverter {
             PgType::Timetz => Self::convert_timetz_to_seconds(value),
             PgType::Timestamp => Self::convert_timestamp_to_unix(value),
             PgType::Timestamptz => Self::convert_timestamptz_to_unix(value),
-            PgType::Interval => Self::convert_interval_to_seconds(value),
+            PgType::Interval => Self::convert_interval_to_text(value),
             _ => Ok(value.to_string()), // Pass through other types
         }
     }
@@ -57,7 +55,8 @@ impl ValueConverter {
     pub fn sqlite_to_pg(value: &str, pg_type: PgType) -> Result<String, String> {
         match pg_type {
             PgType::Money => Ok(value.to_string()), // Money is stored as-is
-            PgType::Int4range | PgType::Int8range | PgType::Numrange => Ok(value.to_string()), // Ranges stored as-is
+            PgType::Int4range | PgType::Int8range | PgType::Numrange |
+            PgType::Tsrange | PgType::Tstzrange | PgType::Daterange => Ok(value.to_string()), // Ranges stored as-is
             PgType::Cidr => Ok(value.to_string()), // CIDR stored as-is
             PgType::Inet => Ok(value.to_string()), // INET stored as-is
             PgType::Macaddr => Ok(value.to_string()), // MAC addresses stored as-is
@@ -306,7 +305,7 @@ impl ValueConverter {
     }
     
     /// Convert PostgreSQL TIMESTAMPTZ to microseconds since epoch in UTC (stored as INTEGER)
-    fn convert_timestamptz_to_unix(value: &str) -> Result<String, String> {
+    pub fn convert_timestamptz_to_unix(value: &str) -> Result<String, String> {
         // Try parsing with timezone offset
         let (datetime_str, offset_seconds) = if let Some(caps) = TIMESTAMPTZ_REGEX.captures(value.trim()) {
             let dt_str = caps.get(1).unwrap().as_str();
@@ -341,76 +340,21 @@ impl ValueConverter {
         Ok(format!("{timestamp_str}+00:00"))
     }
     
-    /// Convert PostgreSQL INTERVAL to microseconds (stored as INTEGER)
-    fn convert_interval_to_seconds(value: &str) -> Result<String, String> {
-        // Simple interval parsing for common formats
-        // Full PostgreSQL interval parsing is complex, this handles basic cases
-        let trimmed = value.trim();
-        
-        // Handle simple numeric intervals (e.g., "3600000000" microseconds)
-        if let Ok(micros) = trimmed.parse::<i64>() {
-            return Ok(micros.to_string());
-        }
-        
-        // Handle HH:MM:SS format
-        if let Ok(time) = NaiveTime::parse_from_str(trimmed, "%H:%M:%S%.f")
-            .or_else(|_| NaiveTime::parse_from_str(trimmed, "%H:%M:%S")) {
-            let micros = time.num_seconds_from_midnight() as i64 * 1_000_000 
-                + (time.nanosecond() / 1000) as i64;
-            return Ok(micros.to_string());
-        }
-        
-        // Handle verbose format (e.g., "1 day 02:30:00")
-        if let Some(caps) = INTERVAL_REGEX.captures(trimmed) {
-            let days = caps.get(1).map(|m| m.as_str().parse::<i64>().unwrap_or(0)).unwrap_or(0);
-            let hours = caps.get(2).map(|m| m.as_str().parse::<i64>().unwrap_or(0)).unwrap_or(0);
-            let minutes = caps.get(3).map(|m| m.as_str().parse::<i64>().unwrap_or(0)).unwrap_or(0);
-            let seconds = caps.get(4).map(|m| m.as_str().parse::<i64>().unwrap_or(0)).unwrap_or(0);
-            let fraction = caps.get(5).map(|m| {
-                let fraction_str = m.as_str();
-                // Parse the fractional part and convert to microseconds
-                
-                if fraction_str.len() <= 6 {
-                    // Pad with zeros if needed
-                    let padded = format!("{fraction_str:0<6}");
-                    padded.parse::<i64>().unwrap_or(0)
-                } else {
-                    // Truncate to 6 digits
-                    fraction_str[..6].parse::<i64>().unwrap_or(0)
-                }
-            }).unwrap_or(0);
-            
-            let total_micros = (days * 86400 + hours * 3600 + minutes * 60 + seconds) * 1_000_000 + fraction;
-            return Ok(total_micros.to_string());
-        }
-        
-        Err(format!("Unsupported interval format: {value}"))
+    /// Convert PostgreSQL INTERVAL to the canonical interval text it is stored as, so
+    /// months, days and time read back exactly as written
+    pub fn convert_interval_to_text(value: &str) -> Result<String, String> {
+        // Plain numbers are microseconds, e.g. "3600000000"
+        datetime_utils::canonical_interval(value)
+            .ok_or_else(|| format!("Unsupported interval format: {value}"))
     }
     
-    /// Convert microseconds to PostgreSQL INTERVAL
+    /// Convert a stored interval, or microseconds from timestamp arithmetic, to PostgreSQL's
+    /// canonical INTERVAL text
     fn convert_seconds_to_interval(value: &str) -> Result<String, String> {
-        let total_micros = value.parse::<i64>()
-            .map_err(|e| format!("Invalid microseconds value: {value} ({e})"))?;
-        
-        let days = total_micros / (86400 * 1_000_000);
-        let remaining_micros = total_micros % (86400 * 1_000_000);
-        let hours = remaining_micros / (3600 * 1_000_000);
-        let minutes = (remaining_micros % (3600 * 1_000_000)) / (60 * 1_000_000);
-        let seconds = (remaining_micros % (60 * 1_000_000)) / 1_000_000;
-        let microseconds = remaining_micros % 1_000_000;
-        
-        let mut parts = Vec::new();
-        if days > 0 {
-            parts.push(format!("{} day{}", days, if days == 1 { "" } else { "s" }));
-        }
+        let (months, days, micros) = datetime_utils::parse_stored_interval(value)
+            .ok_or_else(|| format!("Invalid interval value: {value}"))?;
         
-        if microseconds > 0 {
-            parts.push(format!("{hours:02}:{minutes:02}:{seconds:02}.{microseconds:06}"));
-        } else {
-            parts.push(format!("{hours:02}:{minutes:02}:{seconds:02}"));
-        }
-        
-        Ok(parts.join(" "))
+        Ok(datetime_utils::format_interval(months, days, micros))
     }
     
     /// Parse timezone offset string (±HH:MM or ±HHMM) to seconds
@@ -521,17 +465,25 @@ mod tests {
     #[test]
     fn test_interval_conversion() {
         // Test simple microseconds
-        assert_eq!(ValueConverter::convert_interval_to_seconds("3600000000").unwrap(), "3600000000");
+        assert_eq!(ValueConverter::convert_interval_to_text("3600000000").unwrap(), "01:00:00");
         
         // Test HH:MM:SS format
-        assert_eq!(ValueConverter::convert_interval_to_seconds("01:30:00").unwrap(), "5400000000"); // 1.5 hours in microseconds
+        assert_eq!(ValueConverter::convert_interval_to_text("01:30:00").unwrap(), "01:30:00");
         
         // Test verbose format
-        let result = ValueConverter::convert_interval_to_seconds("1 day 02:30:00").unwrap();
-        assert_eq!(result, "95400000000"); // (86400 + 9000) seconds * 1_000_000 microseconds
+        assert_eq!(ValueConverter::convert_interval_to_text("1 day 2 hours 30 minutes").unwrap(), "1 day 02:30:00");
+        assert!(ValueConverter::convert_interval_to_text("soon").is_err());
         
         // Test microseconds to interval
         assert_eq!(ValueConverter::convert_seconds_to_interval("95400000000").unwrap(), "1 day 02:30:00");
-        assert_eq!(ValueConverter::convert_seconds_to_interval("5400500000").unwrap(), "01:30:00.500000");
+        assert_eq!(ValueConverter::convert_seconds_to_interval("5400500000").unwrap(), "01:30:00.5");
+        assert_eq!(ValueConverter::convert_seconds_to_interval("-90000000000").unwrap(), "-1 days -01:00:00");
+        
+        // Months and days are kept apart, however many days there are
+        for interval in ["1 year 2 mons 3 days 04:05:06", "30 days", "1 mon 15 days", "365 days"] {
+            let stored = ValueConverter::convert_interval_to_text(interval).unwrap();
+            assert_eq!(stored, interval);
+            assert_eq!(ValueConverter::convert_seconds_to_interval(&stored).unwrap(), interval);
+        }
     }
 }
\ No newline at end of file
@@ -0,0 +1,101 @@
+use rusqlite::{Connection, Result};
+use tracing::debug;
+
+/// Validates the values of bit(n) and bit varying(n) columns.
+///
+/// Bit strings are stored as their text of 0s and 1s. BEFORE INSERT and UPDATE triggers pass
+/// each value to bit_typmod_check(), which raises PostgreSQL's errors for characters other
+/// than 0 and 1, for a bit(n) value that isn't exactly n bits long and for a bit varying(n)
+/// value longer than n bits.
+pub struct BitStringTriggers;
+
+impl BitStringTriggers {
+    /// Length limit of a bit or bit varying type and whether it is varying; a bare bit is
+    /// bit(1) and a bare bit varying has no limit
+    pub fn bit_typmod(pg_type: &str) -> Option<(Option<usize>, bool)> {
+        let pg_type = pg_type.trim().to_uppercase();
+        let (base, length) = match pg_type.split_once('(') {
+            Some((base, rest)) => (base.trim(), Some(rest.trim_end_matches(')').trim().parse().ok()?)),
+            None => (pg_type.as_str(), None),
+        };
+        let base = base.split_whitespace().collect::<Vec<_>>().join(" ");
+        match base.as_str() {
+            "BIT" => Some((Some(length.unwrap_or(1)), false)),
+            "VARBIT" | "BIT VARYING" => Some((length, true)),
+            _ => None,
+        }
+    }
+
+    /// Create the validation triggers for the bit columns recorded in __pgsqlite_schema
+    pub fn create_for_table(conn: &Connection, table_name: &str) -> Result<()> {
+        let mut stmt = conn.prepare("SELECT column_name, pg_type FROM __pgsqlite_schema WHERE table_name = ?1")?;
+        let columns = stmt.query_map([table_name], |row| Ok((row.get::<_, String>(0)?, row.get::<_, String>(1)?)))?
+            .collect::<Result<Vec<_>>>()?;
+        let checks: Vec<(String, String)> = columns.into_iter()
+            .filter_map(|(column, pg_type)| {
+                let check = match Self::bit_typmod(&pg_type)? {
+                    (Some(length), varying) => format!("bit_typmod_check(NEW.\"{column}\", {length}, {})", varying as i32),
+                    (None, _) => format!("varbit(NEW.\"{column}\")"),
+                };
+                Some((column, check))
+            })
+            .collect();
+        if checks.is_empty() {
+            return Ok(());
+        }
+
+        let calls = checks.iter().map(|(_, check)| check.as_str()).collect::<Vec<_>>().join(", ");
+        let column_list = checks.iter().map(|(column, _)| format!("\"{column}\"")).collect::<Vec<_>>().join(", ");
+
+        for (event, target) in [("insert", "INSERT".to_string()), ("update", format!("UPDATE OF {column_list}"))] {
+            let trigger_sql = format!(
+                r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_bit_check_{event}_{table_name}"
+                BEFORE {target} ON "{table_name}"
+                FOR EACH ROW
+                BEGIN
+                    SELECT {calls};
+                END"#
+            );
+            conn.execute(&trigger_sql, [])?;
+        }
+
+        debug!("Created bit string triggers for {} columns of {}", checks.len(), table_name);
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_bit_typmod() {
+        assert_eq!(BitStringTriggers::bit_typmod("BIT(8)"), Some((Some(8), false)));
+        assert_eq!(BitStringTriggers::bit_typmod("bit"), Some((Some(1), false)));
+        assert_eq!(BitStringTriggers::bit_typmod("BIT VARYING(16)"), Some((Some(16), true)));
+        assert_eq!(BitStringTriggers::bit_typmod("varbit"), Some((None, true)));
+        assert_eq!(BitStringTriggers::bit_typmod("BIGINT"), None);
+        assert_eq!(BitStringTriggers::bit_typmod("VARCHAR(8)"), None);
+    }
+
+    #[test]
+    fn test_check_triggers() {
+        let conn = Connection::open_in_memory().unwrap();
+        crate::functions::bit_functions::register_bit_functions(&conn).unwrap();
+        conn.execute_batch(
+            "CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT);
+             INSERT INTO __pgsqlite_schema VALUES ('features', 'flags', 'BIT(4)', 'TEXT'), ('features', 'mask', 'BIT VARYING(6)', 'TEXT');
+             CREATE TABLE features (flags TEXT, mask TEXT);"
+        ).unwrap();
+        BitStringTriggers::create_for_table(&conn, "features").unwrap();
+
+        conn.execute("INSERT INTO features VALUES ('0101', '11'), (NULL, NULL)", []).unwrap();
+
+        let err = conn.execute("INSERT INTO features VALUES ('010', '1')", []).unwrap_err();
+        assert!(err.to_string().contains("bit string length 3 does not match type bit(4)"));
+        let err = conn.execute("UPDATE features SET mask = '1111111'", []).unwrap_err();
+        assert!(err.to_string().contains("bit string too long for type bit varying(6)"));
+        let err = conn.execute("UPDATE features SET flags = '0120'", []).unwrap_err();
+        assert!(err.to_string().contains("\"2\" is not a valid binary digit"));
+    }
+}
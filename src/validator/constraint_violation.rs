@@ -0,0 +1,631 @@
+use rusqlite::Connection;
+use rusqlite::types::Value as SqliteValue;
+use sqlparser::ast::{Expr, FromTable, SetExpr, Statement, TableFactor, TableObject, Value};
+use sqlparser::dialect::PostgreSqlDialect;
+use sqlparser::parser::Parser;
+use tracing::debug;
+use crate::error::PgError;
+use crate::metadata::exclusion_constraints::EXCLUSION_VIOLATION_PREFIX;
+
+/// Maps SQLite constraint failures to PostgreSQL constraint violation errors.
+///
+/// SQLite only reports the table and columns involved (`UNIQUE constraint failed: users.email`),
+/// while PostgreSQL clients and ORMs parse the constraint name and the offending key out of the
+/// error. The constraint name is resolved from the table's index metadata using the same naming
+/// convention as the pg_constraint catalog, and the key value is recovered from the failed statement
+/// and, for `$n` placeholders, the values bound to it.
+pub struct ConstraintViolationMapper;
+
+/// A foreign key as reported by `PRAGMA foreign_key_list`
+struct ForeignKey {
+    id: i64,
+    parent_table: String,
+    from: Vec<String>,
+    to: Vec<String>,
+    on_delete: String,
+}
+
+impl ConstraintViolationMapper {
+    /// Translate a SQLite error raised while executing `query`, if it is a constraint violation
+    pub fn map_error(conn: &Connection, query: &str, err: &rusqlite::Error) -> Option<PgError> {
+        Self::map_error_with_params(conn, query, &[], err)
+    }
+
+    /// Translate a SQLite error raised while executing `query` with bound parameters, if it is
+    /// a constraint violation
+    pub fn map_error_with_params(conn: &Connection, query: &str, params: &[SqliteValue], err: &rusqlite::Error) -> Option<PgError> {
+        match err {
+            rusqlite::Error::SqliteFailure(sqlite_err, Some(msg))
+                if sqlite_err.code == rusqlite::ErrorCode::ConstraintViolation =>
+            {
+                if let Some(target) = msg.strip_prefix("UNIQUE constraint failed: ") {
+                    return Some(Self::unique_violation(conn, query, params, target));
+                }
+                if msg.contains("FOREIGN KEY constraint failed") {
+                    return Some(Self::foreign_key_violation(conn, query, params).unwrap_or_else(Self::unknown_foreign_key_violation));
+                }
+                if let Some(rest) = msg.strip_prefix(EXCLUSION_VIOLATION_PREFIX) {
+                    // Raised by the exclusion constraint triggers: name"\ndetail
+                    let (constraint_name, detail) = rest.split_once('\n').unwrap_or((rest, ""));
+                    return Some(PgError::ExclusionViolation {
+                        constraint_name: constraint_name.trim_end_matches('"').to_string(),
+                        detail: detail.to_string(),
+                    });
+                }
+                None
+            }
+            _ => None,
+        }
+    }
+
+    /// Find the first foreign key violation pending on the connection.
+    ///
+    /// Deferred constraints are only checked at COMMIT (or `SET CONSTRAINTS ... IMMEDIATE`),
+    /// where SQLite reports nothing but `FOREIGN KEY constraint failed`. `PRAGMA foreign_key_check`
+    /// identifies the offending row so the error can name the constraint and key.
+    pub fn pending_foreign_key_violation(conn: &Connection) -> Option<PgError> {
+        let (table_name, rowid, fk_id): (String, Option<i64>, i64) = conn
+            .query_row("PRAGMA foreign_key_check", [], |row| {
+                Ok((row.get(0)?, row.get(1)?, row.get(3)?))
+            })
+            .ok()?;
+
+        let fk = match Self::foreign_keys(conn, &table_name).into_iter().find(|fk| fk.id == fk_id) {
+            Some(fk) => fk,
+            None => return Some(Self::unknown_foreign_key_violation()),
+        };
+
+        // WITHOUT ROWID tables report a NULL rowid, so the key cannot be read back
+        let values = rowid.and_then(|rowid| {
+            let select_list: Vec<String> = fk.from.iter().map(|c| format!("CAST(\"{c}\" AS TEXT)")).collect();
+            let sql = format!("SELECT {} FROM \"{}\" WHERE rowid = ?1", select_list.join(", "), table_name);
+            conn.query_row(&sql, [rowid], |row| {
+                (0..fk.from.len()).map(|i| row.get::<_, Option<String>>(i).map(|v| v.unwrap_or_else(|| "NULL".to_string()))).collect::<rusqlite::Result<Vec<_>>>()
+            }).ok()
+        });
+
+        Some(Self::missing_parent_error(&table_name, &fk, values))
+    }
+
+    /// Build a 23503 error for an immediate `FOREIGN KEY constraint failed`.
+    ///
+    /// INSERT and UPDATE statements are checked for keys missing from the referenced table,
+    /// DELETE statements for rows that are still referenced.
+    fn foreign_key_violation(conn: &Connection, query: &str, params: &[SqliteValue]) -> Option<PgError> {
+        let statements = Parser::parse_sql(&PostgreSqlDialect {}, query).ok()?;
+        match statements.first()? {
+            Statement::Insert(insert) => {
+                let table_name = match &insert.table {
+                    TableObject::TableName(name) => Self::bare_name(&name.to_string()),
+                    _ => return None,
+                };
+                let target_columns: Vec<String> = if insert.columns.is_empty() {
+                    Self::table_columns(conn, &table_name)
+                } else {
+                    insert.columns.iter().map(|c| c.value.clone()).collect()
+                };
+                let rows = match insert.source.as_ref()?.body.as_ref() {
+                    SetExpr::Values(values) => &values.rows,
+                    _ => return None,
+                };
+
+                for fk in Self::foreign_keys(conn, &table_name) {
+                    let positions = match fk.from.iter()
+                        .map(|col| target_columns.iter().position(|c| c.eq_ignore_ascii_case(col)))
+                        .collect::<Option<Vec<usize>>>() {
+                        Some(positions) => positions,
+                        None => continue,
+                    };
+                    for row in rows {
+                        let key: Option<Vec<String>> = positions.iter().map(|&i| row.get(i).and_then(|expr| Self::literal_value(expr, params))).collect();
+                        if let Some(key) = key
+                            && !Self::key_exists(conn, &fk.parent_table, &fk.to, &key) {
+                            return Some(Self::missing_parent_error(&table_name, &fk, Some(key)));
+                        }
+                    }
+                }
+                None
+            }
+            Statement::Update { table, assignments, .. } => {
+                let table_name = match &table.relation {
+                    TableFactor::Table { name, .. } => Self::bare_name(&name.to_string()),
+                    _ => return None,
+                };
+                for fk in Self::foreign_keys(conn, &table_name) {
+                    let key: Option<Vec<String>> = fk.from.iter()
+                        .map(|col| {
+                            assignments.iter()
+                                .find(|a| Self::bare_name(&a.target.to_string()).eq_ignore_ascii_case(col))
+                                .and_then(|a| Self::literal_value(&a.value, params))
+                        })
+                        .collect();
+                    if let Some(key) = key
+                        && !Self::key_exists(conn, &fk.parent_table, &fk.to, &key) {
+                        return Some(Self::missing_parent_error(&table_name, &fk, Some(key)));
+                    }
+                }
+                None
+            }
+            Statement::Delete(delete) => {
+                let tables = match &delete.from {
+                    FromTable::WithFromKeyword(tables) | FromTable::WithoutKeyword(tables) => tables,
+                };
+                let table_name = match &tables.first()?.relation {
+                    TableFactor::Table { name, .. } => Self::bare_name(&name.to_string()),
+                    _ => return None,
+                };
+                let filter = delete.selection.as_ref().map(|expr| format!(" WHERE {expr}")).unwrap_or_default();
+                Self::still_referenced_error(conn, &table_name, &filter)
+            }
+            _ => None,
+        }
+    }
+
+    /// Find a row about to be deleted from `table_name` that another table still references
+    fn still_referenced_error(conn: &Connection, table_name: &str, filter: &str) -> Option<PgError> {
+        let tables: Vec<String> = conn
+            .prepare("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '__pgsqlite_%'")
+            .and_then(|mut stmt| {
+                stmt.query_map([], |row| row.get::<_, String>(0))
+                    .map(|rows| rows.filter_map(|r| r.ok()).collect::<Vec<_>>())
+            })
+            .unwrap_or_default();
+
+        for referencing_table in tables {
+            for fk in Self::foreign_keys(conn, &referencing_table) {
+                // Cascading actions never raise a violation
+                if !fk.parent_table.eq_ignore_ascii_case(table_name)
+                    || matches!(fk.on_delete.as_str(), "CASCADE" | "SET NULL" | "SET DEFAULT") {
+                    continue;
+                }
+
+                let select_list: Vec<String> = fk.to.iter().map(|c| format!("CAST(\"{c}\" AS TEXT)")).collect();
+                let sql = format!("SELECT {} FROM \"{}\"{}", select_list.join(", "), table_name, filter);
+                let keys: Vec<Vec<String>> = match conn.prepare(&sql) {
+                    Ok(mut stmt) => stmt
+                        .query_map([], |row| (0..fk.to.len()).map(|i| row.get::<_, Option<String>>(i).map(|v| v.unwrap_or_default())).collect::<rusqlite::Result<Vec<_>>>())
+                        .map(|rows| rows.filter_map(|r| r.ok()).collect())
+                        .unwrap_or_default(),
+                    Err(e) => {
+                        debug!("Could not select deleted keys from {}: {}", table_name, e);
+                        continue;
+                    }
+                };
+
+                if let Some(key) = keys.iter().find(|key| Self::key_exists(conn, &referencing_table, &fk.from, key)) {
+                    return Some(PgError::ForeignKeyViolation {
+                        table_name: table_name.to_string(),
+                        constraint_name: Self::foreign_key_name(&referencing_table, &fk),
+                        referencing_table: Some(referencing_table.clone()),
+                        detail: format!(
+                            "Key ({})=({}) is still referenced from table \"{}\".",
+                            fk.to.join(", "), key.join(", "), referencing_table
+                        ),
+                    });
+                }
+            }
+        }
+        None
+    }
+
+    /// Error for a referencing row whose key has no match in the referenced table
+    fn missing_parent_error(table_name: &str, fk: &ForeignKey, key: Option<Vec<String>>) -> PgError {
+        let detail = match key {
+            Some(values) => format!(
+                "Key ({})=({}) is not present in table \"{}\".",
+                fk.from.join(", "), values.join(", "), fk.parent_table
+            ),
+            None => format!("Key ({}) is not present in table \"{}\".", fk.from.join(", "), fk.parent_table),
+        };
+        debug!("Foreign key violation on {} -> {}: {}", table_name, fk.parent_table, detail);
+
+        PgError::ForeignKeyViolation {
+            table_name: table_name.to_string(),
+            constraint_name: Self::foreign_key_name(table_name, fk),
+            referencing_table: None,
+            detail,
+        }
+    }
+
+    /// Fallback when the offending key cannot be identified
+    fn unknown_foreign_key_violation() -> PgError {
+        PgError::Generic {
+            code: "23503".to_string(),
+            message: "foreign key constraint violation".to_string(),
+        }
+    }
+
+    /// Constraint name following the pg_constraint catalog convention
+    fn foreign_key_name(table_name: &str, fk: &ForeignKey) -> String {
+        format!("{}_{}_fkey", table_name, fk.from.join("_"))
+    }
+
+    /// Foreign keys declared on a table, with implicit references resolved to the parent's primary key
+    fn foreign_keys(conn: &Connection, table_name: &str) -> Vec<ForeignKey> {
+        // PRAGMA foreign_key_list: id, seq, table, from, to, on_update, on_delete, match
+        let entries: Vec<(i64, String, String, Option<String>, String)> = conn
+            .prepare(&format!("PRAGMA foreign_key_list(\"{table_name}\")"))
+            .and_then(|mut stmt| {
+                stmt.query_map([], |row| Ok((row.get(0)?, row.get(2)?, row.get(3)?, row.get(4)?, row.get(6)?)))
+                    .map(|rows| rows.filter_map(|r| r.ok()).collect::<Vec<_>>())
+            })
+            .unwrap_or_default();
+
+        let mut foreign_keys: Vec<ForeignKey> = Vec::new();
+        for (id, parent_table, from, to, on_delete) in entries {
+            let index = match foreign_keys.iter().position(|fk| fk.id == id) {
+                Some(index) => index,
+                None => {
+                    foreign_keys.push(ForeignKey { id, parent_table, from: Vec::new(), to: Vec::new(), on_delete });
+                    foreign_keys.len() - 1
+                }
+            };
+            foreign_keys[index].from.push(from);
+            if let Some(to) = to {
+                foreign_keys[index].to.push(to);
+            }
+        }
+
+        for fk in &mut foreign_keys {
+            if fk.to.len() != fk.from.len() {
+                fk.to = Self::primary_key_columns(conn, &fk.parent_table);
+            }
+        }
+        foreign_keys
+    }
+
+    /// Strip schema qualification and quotes from an identifier
+    fn bare_name(name: &str) -> String {
+        name.rsplit('.').next().unwrap_or(name).trim_matches('"').to_string()
+    }
+
+    /// Build a 23505 error for a `UNIQUE constraint failed: ...` message
+    fn unique_violation(conn: &Connection, query: &str, params: &[SqliteValue], target: &str) -> PgError {
+        let (table_name, columns) = match Self::parse_failed_columns(target) {
+            Some(parsed) => parsed,
+            None => {
+                // Expression indexes are reported by name: "index 'idx_name'"
+                let index_name = target.trim_start_matches("index ").trim_matches('\'').to_string();
+                return PgError::UniqueViolation {
+                    constraint_name: index_name,
+                    detail: "Key already exists.".to_string(),
+                };
+            }
+        };
+
+        let constraint_name = Self::unique_constraint_name(conn, &table_name, &columns);
+        let detail = match Self::find_conflicting_key(conn, query, params, &table_name, &columns) {
+            Some(values) => format!("Key ({})=({}) already exists.", columns.join(", "), values.join(", ")),
+            None => format!("Key ({}) already exists.", columns.join(", ")),
+        };
+        debug!("Mapped unique violation on {}.{:?} to constraint {}", table_name, columns, constraint_name);
+
+        PgError::UniqueViolation { constraint_name, detail }
+    }
+
+    /// Parse `table.col1, table.col2` into the table name and column list
+    fn parse_failed_columns(target: &str) -> Option<(String, Vec<String>)> {
+        let mut table_name = None;
+        let mut columns = Vec::new();
+        for part in target.split(',') {
+            let (table, column) = part.trim().rsplit_once('.')?;
+            table_name.get_or_insert_with(|| table.to_string());
+            columns.push(column.to_string());
+        }
+        table_name.map(|table| (table, columns))
+    }
+
+    /// Resolve the PostgreSQL constraint name for a unique key on the given columns
+    fn unique_constraint_name(conn: &Connection, table_name: &str, columns: &[String]) -> String {
+        for (index_name, origin, index_columns) in Self::unique_indexes(conn, table_name) {
+            if index_columns.len() == columns.len()
+                && index_columns.iter().zip(columns).all(|(a, b)| a.eq_ignore_ascii_case(b)) {
+                return match origin.as_str() {
+                    "pk" => format!("{table_name}_pkey"),
+                    // CREATE UNIQUE INDEX keeps its own name as the constraint name
+                    "u" => index_name,
+                    _ => format!("{}_{}_key", table_name, columns.join("_")),
+                };
+            }
+        }
+
+        // INTEGER PRIMARY KEY columns alias the rowid and have no backing index
+        if Self::primary_key_columns(conn, table_name).iter().map(|c| c.to_lowercase()).eq(columns.iter().map(|c| c.to_lowercase())) {
+            return format!("{table_name}_pkey");
+        }
+
+        format!("{}_{}_key", table_name, columns.join("_"))
+    }
+
+    /// List unique indexes of a table as (name, origin, columns)
+    fn unique_indexes(conn: &Connection, table_name: &str) -> Vec<(String, String, Vec<String>)> {
+        let indexes: Vec<(String, String)> = match conn.prepare(&format!("PRAGMA index_list(\"{table_name}\")")) {
+            Ok(mut stmt) => stmt
+                .query_map([], |row| Ok((row.get::<_, String>(1)?, row.get::<_, i64>(2)?, row.get::<_, String>(3)?)))
+                .map(|rows| rows.filter_map(|r| r.ok()).filter(|(_, unique, _)| *unique != 0).map(|(name, _, origin)| (name, origin)).collect())
+                .unwrap_or_default(),
+            Err(_) => return Vec::new(),
+        };
+
+        indexes
+            .into_iter()
+            .map(|(name, origin)| {
+                let columns = conn
+                    .prepare(&format!("PRAGMA index_info(\"{name}\")"))
+                    .and_then(|mut stmt| {
+                        stmt.query_map([], |row| row.get::<_, Option<String>>(2))
+                            .map(|rows| rows.filter_map(|r| r.ok().flatten()).collect::<Vec<_>>())
+                    })
+                    .unwrap_or_default();
+                (name, origin, columns)
+            })
+            .collect()
+    }
+
+    /// Primary key columns of a table in key order
+    fn primary_key_columns(conn: &Connection, table_name: &str) -> Vec<String> {
+        let mut pk_columns: Vec<(i64, String)> = conn
+            .prepare(&format!("PRAGMA table_info(\"{table_name}\")"))
+            .and_then(|mut stmt| {
+                stmt.query_map([], |row| Ok((row.get::<_, i64>(5)?, row.get::<_, String>(1)?)))
+                    .map(|rows| rows.filter_map(|r| r.ok()).filter(|(pk, _)| *pk > 0).collect::<Vec<_>>())
+            })
+            .unwrap_or_default();
+        pk_columns.sort_by_key(|(pk, _)| *pk);
+        pk_columns.into_iter().map(|(_, name)| name).collect()
+    }
+
+    /// All column names of a table in declaration order
+    fn table_columns(conn: &Connection, table_name: &str) -> Vec<String> {
+        conn.prepare(&format!("PRAGMA table_info(\"{table_name}\")"))
+            .and_then(|mut stmt| {
+                stmt.query_map([], |row| row.get::<_, String>(1))
+                    .map(|rows| rows.filter_map(|r| r.ok()).collect::<Vec<_>>())
+            })
+            .unwrap_or_default()
+    }
+
+    /// Recover the key values that caused the violation from the failed INSERT or UPDATE
+    fn find_conflicting_key(conn: &Connection, query: &str, params: &[SqliteValue], table_name: &str, columns: &[String]) -> Option<Vec<String>> {
+        let statements = Parser::parse_sql(&PostgreSqlDialect {}, query).ok()?;
+        match statements.first()? {
+            Statement::Insert(insert) => {
+                let target_columns: Vec<String> = if insert.columns.is_empty() {
+                    Self::table_columns(conn, table_name)
+                } else {
+                    insert.columns.iter().map(|c| c.value.clone()).collect()
+                };
+                let positions = columns
+                    .iter()
+                    .map(|col| target_columns.iter().position(|c| c.eq_ignore_ascii_case(col)))
+                    .collect::<Option<Vec<usize>>>()?;
+
+                let rows = match insert.source.as_ref()?.body.as_ref() {
+                    SetExpr::Values(values) => &values.rows,
+                    _ => return None,
+                };
+                let keys: Vec<Vec<String>> = rows
+                    .iter()
+                    .filter_map(|row| positions.iter().map(|&i| row.get(i).and_then(|expr| Self::literal_value(expr, params))).collect())
+                    .collect();
+
+                // Prefer the row whose key is already stored, then a key repeated within the statement
+                keys.iter()
+                    .find(|key| Self::key_exists(conn, table_name, columns, key))
+                    .or_else(|| keys.iter().enumerate().find(|(i, key)| keys[..*i].contains(*key)).map(|(_, key)| key))
+                    .or_else(|| keys.first())
+                    .cloned()
+            }
+            Statement::Update { assignments, .. } => columns
+                .iter()
+                .map(|col| {
+                    assignments
+                        .iter()
+                        .find(|a| Self::bare_name(&a.target.to_string()).eq_ignore_ascii_case(col))
+                        .and_then(|a| Self::literal_value(&a.value, params))
+                })
+                .collect(),
+            _ => None,
+        }
+    }
+
+    /// Check whether a key is already present in the table
+    fn key_exists(conn: &Connection, table_name: &str, columns: &[String], key: &[String]) -> bool {
+        let conditions: Vec<String> = columns
+            .iter()
+            .enumerate()
+            .map(|(i, col)| format!("\"{}\" = ?{}", col, i + 1))
+            .collect();
+        let sql = format!("SELECT 1 FROM \"{}\" WHERE {} LIMIT 1", table_name, conditions.join(" AND "));
+        conn.query_row(&sql, rusqlite::params_from_iter(key.iter()), |_| Ok(()))
+            .is_ok()
+    }
+
+    /// Render a literal, or the value bound to a `$n` placeholder, the way PostgreSQL prints
+    /// it in a key detail
+    fn literal_value(expr: &Expr, params: &[SqliteValue]) -> Option<String> {
+        match expr {
+            Expr::Value(value) => match &value.value {
+                Value::SingleQuotedString(s) => Some(s.clone()),
+                Value::Number(n, _) => Some(n.clone()),
+                Value::Boolean(b) => Some(if *b { "t" } else { "f" }.to_string()),
+                Value::Placeholder(placeholder) => {
+                    let index = placeholder.strip_prefix('$')?.parse::<usize>().ok()?.checked_sub(1)?;
+                    match params.get(index)? {
+                        SqliteValue::Text(s) => Some(s.clone()),
+                        SqliteValue::Integer(i) => Some(i.to_string()),
+                        SqliteValue::Real(f) => Some(crate::types::numeric_utils::format_float(*f)),
+                        SqliteValue::Null | SqliteValue::Blob(_) => None,
+                    }
+                }
+                Value::Null => None,
+                other => Some(other.to_string()),
+            },
+            Expr::Cast { expr, .. } | Expr::Nested(expr) => Self::literal_value(expr, params),
+            Expr::UnaryOp { .. } => Some(expr.to_string()),
+            _ => None,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn unique_error(target: &str) -> rusqlite::Error {
+        rusqlite::Error::SqliteFailure(
+            rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_CONSTRAINT_UNIQUE),
+            Some(format!("UNIQUE constraint failed: {target}")),
+        )
+    }
+
+    fn setup() -> Connection {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "CREATE TABLE books (id INTEGER PRIMARY KEY, isbn_13 TEXT UNIQUE, title TEXT);
+             CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);
+             CREATE UNIQUE INDEX users_email_idx ON users (email);
+             INSERT INTO books (id, isbn_13, title) VALUES (1, '9780000000001', 'First');
+             INSERT INTO users (id, email) VALUES (1, 'a@example.com');"
+        ).unwrap();
+        conn
+    }
+
+    #[test]
+    fn test_unique_column_constraint() {
+        let conn = setup();
+        let query = "INSERT INTO books (id, isbn_13, title) VALUES (2, '9780000000001', 'Second')";
+        match ConstraintViolationMapper::map_error(&conn, query, &unique_error("books.isbn_13")) {
+            Some(PgError::UniqueViolation { constraint_name, detail }) => {
+                assert_eq!(constraint_name, "books_isbn_13_key");
+                assert_eq!(detail, "Key (isbn_13)=(9780000000001) already exists.");
+            }
+            other => panic!("Expected unique violation, got {other:?}"),
+        }
+    }
+
+    #[test]
+    fn test_unique_index_and_multi_row_insert() {
+        let conn = setup();
+        let query = "INSERT INTO users (id, email) VALUES (2, 'b@example.com'), (3, 'a@example.com')";
+        match ConstraintViolationMapper::map_error(&conn, query, &unique_error("users.email")) {
+            Some(PgError::UniqueViolation { constraint_name, detail }) => {
+                assert_eq!(constraint_name, "users_email_idx");
+                assert_eq!(detail, "Key (email)=(a@example.com) already exists.");
+            }
+            other => panic!("Expected unique violation, got {other:?}"),
+        }
+    }
+
+    #[test]
+    fn test_bound_parameters() {
+        let conn = setup();
+        let params = [SqliteValue::Integer(2), SqliteValue::Text("9780000000001".to_string())];
+        let query = "INSERT INTO books (id, isbn_13, title) VALUES ($1, $2, 'Second')";
+        match ConstraintViolationMapper::map_error_with_params(&conn, query, &params, &unique_error("books.isbn_13")) {
+            Some(PgError::UniqueViolation { detail, .. }) => {
+                assert_eq!(detail, "Key (isbn_13)=(9780000000001) already exists.");
+            }
+            other => panic!("Expected unique violation, got {other:?}"),
+        }
+
+        let update = "UPDATE users SET id = $2 WHERE email = $1";
+        let params = [SqliteValue::Text("b@example.com".to_string()), SqliteValue::Integer(1)];
+        match ConstraintViolationMapper::map_error_with_params(&conn, update, &params, &unique_error("users.id")) {
+            Some(PgError::UniqueViolation { detail, .. }) => {
+                assert_eq!(detail, "Key (id)=(1) already exists.");
+            }
+            other => panic!("Expected unique violation, got {other:?}"),
+        }
+    }
+
+    #[test]
+    fn test_primary_key_and_update() {
+        let conn = setup();
+        let insert = "INSERT INTO users VALUES (1, 'c@example.com')";
+        match ConstraintViolationMapper::map_error(&conn, insert, &unique_error("users.id")) {
+            Some(PgError::UniqueViolation { constraint_name, detail }) => {
+                assert_eq!(constraint_name, "users_pkey");
+                assert_eq!(detail, "Key (id)=(1) already exists.");
+            }
+            other => panic!("Expected unique violation, got {other:?}"),
+        }
+
+        let update = "UPDATE books SET isbn_13 = '9780000000001' WHERE id = 2";
+        match ConstraintViolationMapper::map_error(&conn, update, &unique_error("books.isbn_13")) {
+            Some(PgError::UniqueViolation { detail, .. }) => {
+                assert_eq!(detail, "Key (isbn_13)=(9780000000001) already exists.");
+            }
+            other => panic!("Expected unique violation, got {other:?}"),
+        }
+    }
+
+    fn setup_foreign_keys() -> Connection {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "PRAGMA foreign_keys = ON;
+             CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT);
+             CREATE TABLE books (id INTEGER PRIMARY KEY, author_id INTEGER REFERENCES authors(id));
+             INSERT INTO authors (id, name) VALUES (1, 'Le Guin');
+             INSERT INTO books (id, author_id) VALUES (1, 1);"
+        ).unwrap();
+        conn
+    }
+
+    #[test]
+    fn test_foreign_key_insert_orphan() {
+        let conn = setup_foreign_keys();
+        let query = "INSERT INTO books (id, author_id) VALUES (2, 99)";
+        let err = conn.execute(query, []).unwrap_err();
+        match ConstraintViolationMapper::map_error(&conn, query, &err) {
+            Some(PgError::ForeignKeyViolation { table_name, constraint_name, referencing_table, detail }) => {
+                assert_eq!(table_name, "books");
+                assert_eq!(constraint_name, "books_author_id_fkey");
+                assert_eq!(referencing_table, None);
+                assert_eq!(detail, "Key (author_id)=(99) is not present in table \"authors\".");
+            }
+            other => panic!("Expected foreign key violation, got {other:?}"),
+        }
+    }
+
+    #[test]
+    fn test_foreign_key_delete_parent() {
+        let conn = setup_foreign_keys();
+        let query = "DELETE FROM authors WHERE id = 1";
+        let err = conn.execute(query, []).unwrap_err();
+        match ConstraintViolationMapper::map_error(&conn, query, &err) {
+            Some(PgError::ForeignKeyViolation { table_name, constraint_name, referencing_table, detail }) => {
+                assert_eq!(table_name, "authors");
+                assert_eq!(constraint_name, "books_author_id_fkey");
+                assert_eq!(referencing_table.as_deref(), Some("books"));
+                assert_eq!(detail, "Key (id)=(1) is still referenced from table \"books\".");
+            }
+            other => panic!("Expected foreign key violation, got {other:?}"),
+        }
+    }
+
+    #[test]
+    fn test_pending_foreign_key_violation() {
+        let conn = setup_foreign_keys();
+        conn.execute_batch("BEGIN; PRAGMA defer_foreign_keys = ON; INSERT INTO books (id, author_id) VALUES (3, 5);").unwrap();
+        match ConstraintViolationMapper::pending_foreign_key_violation(&conn) {
+            Some(PgError::ForeignKeyViolation { constraint_name, detail, .. }) => {
+                assert_eq!(constraint_name, "books_author_id_fkey");
+                assert_eq!(detail, "Key (author_id)=(5) is not present in table \"authors\".");
+            }
+            other => panic!("Expected foreign key violation, got {other:?}"),
+        }
+        conn.execute_batch("ROLLBACK").unwrap();
+        assert!(ConstraintViolationMapper::pending_foreign_key_violation(&conn).is_none());
+    }
+
+    #[test]
+    fn test_non_constraint_errors_are_ignored() {
+        let conn = setup();
+        let err = rusqlite::Error::SqliteFailure(
+            rusqlite::ffi::Error::new(rusqlite::ffi::SQLITE_ERROR),
+            Some("no such table: missing".to_string()),
+        );
+        assert!(ConstraintViolationMapper::map_error(&conn, "SELECT * FROM missing", &err).is_none());
+    }
+}
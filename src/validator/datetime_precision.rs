@@ -0,0 +1,116 @@
+use rusqlite::{Connection, Result};
+use tracing::debug;
+
+/// Cuts the fractional seconds of time(p) and timestamp(p) columns to their precision.
+///
+/// The precision is kept as the column's type_modifier in __pgsqlite_schema. Values are
+/// stored as microseconds, or as text when they weren't converted; an AFTER trigger floors
+/// the microseconds to 10^(6-p) and drops the fraction digits after the p-th from text.
+pub struct DatetimePrecisionTriggers;
+
+impl DatetimePrecisionTriggers {
+    /// Whether the type is a time or timestamp type, with or without time zone
+    pub fn is_datetime_type(pg_type: &str) -> bool {
+        matches!(
+            pg_type.trim().to_uppercase().as_str(),
+            "TIME" | "TIMETZ" | "TIMESTAMP" | "TIMESTAMPTZ"
+                | "TIME WITH TIME ZONE" | "TIME WITHOUT TIME ZONE"
+                | "TIMESTAMP WITH TIME ZONE" | "TIMESTAMP WITHOUT TIME ZONE"
+        )
+    }
+
+    /// The value of `column` cut to `precision` fraction digits
+    fn truncated(column: &str, precision: i32) -> String {
+        let unit = 10_i64.pow((6 - precision) as u32);
+        let fraction = format!("substr({column}, instr({column}, '.') + 1)");
+        let rest = format!("ltrim({fraction}, '0123456789')");
+        let text = if precision == 0 {
+            format!("substr({column}, 1, instr({column}, '.') - 1) || {rest}")
+        } else {
+            // Trailing zeros go too; the dot stops the trim at the seconds
+            format!(
+                "rtrim(rtrim(substr({column}, 1, instr({column}, '.')) || substr({fraction}, 1, min({precision}, length({fraction}) - length({rest}))), '0'), '.') || {rest}"
+            )
+        };
+        format!(
+            "CASE WHEN typeof({column}) = 'integer' THEN {column} - (({column} % {unit}) + {unit}) % {unit} \
+             WHEN typeof({column}) = 'text' AND instr({column}, '.') > 0 THEN {text} \
+             ELSE {column} END"
+        )
+    }
+
+    /// Create the truncation triggers for the time and timestamp columns with a precision below 6
+    pub fn create_for_table(conn: &Connection, table_name: &str) -> Result<()> {
+        let mut stmt = conn.prepare(
+            "SELECT column_name, pg_type, type_modifier FROM __pgsqlite_schema WHERE table_name = ?1 AND type_modifier IS NOT NULL"
+        )?;
+        let columns = stmt.query_map([table_name], |row| Ok((row.get::<_, String>(0)?, row.get::<_, String>(1)?, row.get::<_, i32>(2)?)))?
+            .collect::<Result<Vec<_>>>()?;
+        let columns: Vec<(String, i32)> = columns.into_iter()
+            .filter(|(_, pg_type, precision)| Self::is_datetime_type(pg_type) && (0..6).contains(precision))
+            .map(|(column, _, precision)| (column, precision))
+            .collect();
+        if columns.is_empty() {
+            return Ok(());
+        }
+
+        let when_untruncated = columns.iter()
+            .map(|(column, precision)| format!("NEW.\"{column}\" IS NOT ({})", Self::truncated(&format!("NEW.\"{column}\""), *precision)))
+            .collect::<Vec<_>>()
+            .join(" OR ");
+        let assignments = columns.iter()
+            .map(|(column, precision)| format!("\"{column}\" = {}", Self::truncated(&format!("NEW.\"{column}\""), *precision)))
+            .collect::<Vec<_>>()
+            .join(", ");
+        let column_list = columns.iter().map(|(column, _)| format!("\"{column}\"")).collect::<Vec<_>>().join(", ");
+
+        for (event, target) in [("insert", "INSERT".to_string()), ("update", format!("UPDATE OF {column_list}"))] {
+            // The trigger doesn't fire itself again, recursive triggers being off
+            let truncate_sql = format!(
+                r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_datetime_precision_{event}_{table_name}"
+                AFTER {target} ON "{table_name}"
+                FOR EACH ROW
+                WHEN {when_untruncated}
+                BEGIN
+                    UPDATE "{table_name}" SET {assignments} WHERE rowid = NEW.rowid;
+                END"#
+            );
+            conn.execute(&truncate_sql, [])?;
+        }
+
+        debug!("Created datetime precision triggers for {} columns of {}", columns.len(), table_name);
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_precision_triggers() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT, type_modifier INTEGER);
+             INSERT INTO __pgsqlite_schema VALUES ('events', 'happened_at', 'TIMESTAMP', 'INTEGER', 3),
+                 ('events', 'starts', 'TIME', 'INTEGER', 0), ('events', 'logged_at', 'TIMESTAMPTZ', 'INTEGER', NULL);
+             CREATE TABLE events (happened_at INTEGER, starts INTEGER, logged_at INTEGER);"
+        ).unwrap();
+        DatetimePrecisionTriggers::create_for_table(&conn, "events").unwrap();
+
+        // 2024-01-15 10:30:45.123456, 08:15:30.987654 and one microsecond before the epoch
+        conn.execute("INSERT INTO events VALUES (1705314645123456, 29730987654, 1705314645123456), (-1, NULL, NULL)", []).unwrap();
+        let rows: Vec<(i64, Option<i64>, Option<i64>)> = conn.prepare("SELECT * FROM events ORDER BY rowid").unwrap()
+            .query_map([], |row| Ok((row.get(0)?, row.get(1)?, row.get(2)?))).unwrap().collect::<Result<_>>().unwrap();
+        assert_eq!(rows, vec![(1705314645123000, Some(29730000000), Some(1705314645123456)), (-1000, None, None)]);
+
+        conn.execute("UPDATE events SET happened_at = '2024-01-15 10:30:45.99999+02', starts = '08:15:30.5' WHERE rowid = 1", []).unwrap();
+        conn.execute("UPDATE events SET happened_at = '2024-01-15 10:30:45.1', starts = '08:15:30' WHERE rowid = 2", []).unwrap();
+        let rows: Vec<(String, String)> = conn.prepare("SELECT happened_at, starts FROM events ORDER BY rowid").unwrap()
+            .query_map([], |row| Ok((row.get(0)?, row.get(1)?))).unwrap().collect::<Result<_>>().unwrap();
+        assert_eq!(rows, vec![
+            ("2024-01-15 10:30:45.999+02".to_string(), "08:15:30".to_string()),
+            ("2024-01-15 10:30:45.1".to_string(), "08:15:30".to_string()),
+        ]);
+    }
+}
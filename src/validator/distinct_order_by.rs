@@ -0,0 +1,126 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use sqlparser::ast::{Distinct, Expr, OrderByKind, SelectItem, SetExpr, Statement};
+use sqlparser::dialect::PostgreSqlDialect;
+use sqlparser::parser::Parser;
+use crate::error::PgError;
+use crate::PgSqliteError;
+
+static SELECT_DISTINCT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bSELECT\s+DISTINCT\b").unwrap()
+});
+
+static ORDER_BY_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bORDER\s+BY\b").unwrap()
+});
+
+/// Rejects `SELECT DISTINCT` queries ordered by expressions that aren't selected.
+///
+/// PostgreSQL raises 42P10 for them because a distinct row may stand for several input
+/// rows with different sort values. SQLite accepts the query and sorts by whichever input
+/// row it kept, so ported queries would quietly return rows in an arbitrary order.
+pub struct DistinctOrderByValidator;
+
+impl DistinctOrderByValidator {
+    /// Check if the query may be a SELECT DISTINCT with an ORDER BY
+    pub fn needs_validation(query: &str) -> bool {
+        SELECT_DISTINCT_REGEX.is_match(query) && ORDER_BY_REGEX.is_match(query)
+    }
+
+    /// Fail with PostgreSQL's error when an ORDER BY expression isn't in the select list.
+    /// Queries the parser doesn't understand are left for SQLite to judge.
+    pub fn validate(query: &str) -> Result<(), PgSqliteError> {
+        if !Self::needs_validation(query) {
+            return Ok(());
+        }
+        let Ok(statements) = Parser::parse_sql(&PostgreSqlDialect {}, query) else {
+            return Ok(());
+        };
+        let [Statement::Query(parsed)] = statements.as_slice() else {
+            return Ok(());
+        };
+        let SetExpr::Select(select) = parsed.body.as_ref() else {
+            return Ok(());
+        };
+        // DISTINCT ON follows a different rule, about the leading ORDER BY keys
+        if !matches!(select.distinct, Some(Distinct::Distinct)) {
+            return Ok(());
+        }
+        let Some(OrderByKind::Expressions(order_exprs)) = parsed.order_by.as_ref().map(|order_by| &order_by.kind) else {
+            return Ok(());
+        };
+
+        for order_expr in order_exprs {
+            if !Self::is_selected(&order_expr.expr, &select.projection) {
+                return Err(PgError::Generic {
+                    code: "42P10".to_string(), // invalid_column_reference
+                    message: "for SELECT DISTINCT, ORDER BY expressions must appear in select list".to_string(),
+                }.into());
+            }
+        }
+        Ok(())
+    }
+
+    /// Whether an ORDER BY expression names an output column, by position, alias or expression
+    fn is_selected(expr: &Expr, projection: &[SelectItem]) -> bool {
+        let sort_key = expr.to_string();
+        if sort_key.parse::<usize>().is_ok() {
+            return true;
+        }
+        let column = Self::column_name(expr);
+
+        projection.iter().any(|item| match item {
+            SelectItem::UnnamedExpr(selected) => {
+                selected.to_string().eq_ignore_ascii_case(&sort_key)
+                    || column.is_some_and(|column| Self::column_name(selected).is_some_and(|name| name.eq_ignore_ascii_case(column)))
+            }
+            SelectItem::ExprWithAlias { expr: selected, alias } => {
+                selected.to_string().eq_ignore_ascii_case(&sort_key)
+                    || column.is_some_and(|column| alias.value.eq_ignore_ascii_case(column))
+            }
+            // Every column of the tables is selected
+            SelectItem::Wildcard(_) | SelectItem::QualifiedWildcard(..) => column.is_some(),
+        })
+    }
+
+    /// The column a plain or qualified column reference names
+    fn column_name(expr: &Expr) -> Option<&str> {
+        match expr {
+            Expr::Identifier(ident) => Some(&ident.value),
+            Expr::CompoundIdentifier(parts) => parts.last().map(|ident| ident.value.as_str()),
+            _ => None,
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_order_by_not_selected() {
+        for query in [
+            "SELECT DISTINCT author FROM posts ORDER BY created_at",
+            "SELECT DISTINCT lower(author) FROM posts ORDER BY author",
+            "SELECT DISTINCT author AS a FROM posts ORDER BY author, title DESC",
+        ] {
+            let err = DistinctOrderByValidator::validate(query).unwrap_err();
+            assert_eq!(err.pg_error_code(), "42P10", "{query}");
+        }
+    }
+
+    #[test]
+    fn test_order_by_selected() {
+        for query in [
+            "SELECT DISTINCT author FROM posts ORDER BY author DESC",
+            "SELECT DISTINCT p.author FROM posts p ORDER BY author",
+            "SELECT DISTINCT author AS a, title FROM posts ORDER BY a, 2",
+            "SELECT DISTINCT lower(author) FROM posts ORDER BY LOWER(author)",
+            "SELECT DISTINCT * FROM posts ORDER BY created_at",
+            "SELECT DISTINCT ON (author) author, title FROM posts ORDER BY author, created_at",
+            "SELECT author FROM posts ORDER BY created_at",
+        ] {
+            assert!(DistinctOrderByValidator::validate(query).is_ok(), "{query}");
+        }
+    }
+}
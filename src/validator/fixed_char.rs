@@ -0,0 +1,124 @@
+use rusqlite::{Connection, Result};
+use tracing::debug;
+
+/// Gives char(n)/bpchar columns PostgreSQL's blank-padded semantics.
+///
+/// The columns are created with SQLite's RTRIM collation so that comparisons, grouping and
+/// unique indexes ignore trailing spaces. Values are stored padded to n characters: a BEFORE
+/// trigger rejects values whose non-space characters don't fit, and an AFTER trigger pads
+/// short values and cuts trailing spaces beyond n.
+pub struct FixedCharTriggers;
+
+impl FixedCharTriggers {
+    /// Length of a char(n), character(n) or bpchar(n) type; a bare char is char(1)
+    pub fn char_length(pg_type: &str) -> Option<usize> {
+        let pg_type = pg_type.trim().to_uppercase();
+        let (base, length) = match pg_type.split_once('(') {
+            Some((base, rest)) => (base.trim(), rest.trim_end_matches(')').trim().parse().ok()?),
+            None => (pg_type.as_str(), 1),
+        };
+        match base {
+            "CHAR" | "CHARACTER" | "BPCHAR" if length > 0 => Some(length),
+            _ => None,
+        }
+    }
+
+    /// Create the padding triggers for the char(n) columns recorded in __pgsqlite_schema
+    pub fn create_for_table(conn: &Connection, table_name: &str) -> Result<()> {
+        let mut stmt = conn.prepare("SELECT column_name, pg_type FROM __pgsqlite_schema WHERE table_name = ?1")?;
+        let columns = stmt.query_map([table_name], |row| Ok((row.get::<_, String>(0)?, row.get::<_, String>(1)?)))?
+            .collect::<Result<Vec<_>>>()?;
+        let columns: Vec<(String, usize)> = columns.into_iter()
+            .filter_map(|(column, pg_type)| Some((column, Self::char_length(&pg_type)?)))
+            .collect();
+        if columns.is_empty() {
+            return Ok(());
+        }
+
+        let too_long = |column: &str, length: usize| format!("length(rtrim(NEW.\"{column}\", ' ')) > {length}");
+        let when_too_long = columns.iter().map(|(column, length)| too_long(column, *length)).collect::<Vec<_>>().join(" OR ");
+        let errors: String = columns.iter()
+            .map(|(column, length)| format!(
+                "WHEN {} THEN RAISE(ABORT, 'value too long for type character({length})') ",
+                too_long(column, *length)
+            ))
+            .collect();
+        let when_unpadded = columns.iter()
+            .map(|(column, length)| format!("length(NEW.\"{column}\") <> {length}"))
+            .collect::<Vec<_>>()
+            .join(" OR ");
+        let assignments = columns.iter()
+            .map(|(column, length)| format!("\"{column}\" = substr(NEW.\"{column}\" || '{}', 1, {length})", " ".repeat(*length)))
+            .collect::<Vec<_>>()
+            .join(", ");
+        let column_list = columns.iter().map(|(column, _)| format!("\"{column}\"")).collect::<Vec<_>>().join(", ");
+
+        for (event, target) in [("insert", "INSERT".to_string()), ("update", format!("UPDATE OF {column_list}"))] {
+            let check_sql = format!(
+                r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_char_length_{event}_{table_name}"
+                BEFORE {target} ON "{table_name}"
+                FOR EACH ROW
+                WHEN {when_too_long}
+                BEGIN
+                    SELECT CASE {errors}END;
+                END"#
+            );
+            conn.execute(&check_sql, [])?;
+
+            // The trigger doesn't fire itself again, recursive triggers being off
+            let pad_sql = format!(
+                r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_char_pad_{event}_{table_name}"
+                AFTER {target} ON "{table_name}"
+                FOR EACH ROW
+                WHEN {when_unpadded}
+                BEGIN
+                    UPDATE "{table_name}" SET {assignments} WHERE rowid = NEW.rowid;
+                END"#
+            );
+            conn.execute(&pad_sql, [])?;
+        }
+
+        debug!("Created char padding triggers for {} columns of {}", columns.len(), table_name);
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_char_length() {
+        assert_eq!(FixedCharTriggers::char_length("CHAR(2)"), Some(2));
+        assert_eq!(FixedCharTriggers::char_length("character (10)"), Some(10));
+        assert_eq!(FixedCharTriggers::char_length("bpchar(3)"), Some(3));
+        assert_eq!(FixedCharTriggers::char_length("CHAR"), Some(1));
+        assert_eq!(FixedCharTriggers::char_length("VARCHAR(2)"), None);
+        assert_eq!(FixedCharTriggers::char_length("CHARACTER VARYING(2)"), None);
+    }
+
+    #[test]
+    fn test_padding_triggers() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT);
+             INSERT INTO __pgsqlite_schema VALUES ('countries', 'code', 'CHAR(3)', 'TEXT'), ('countries', 'name', 'TEXT', 'TEXT');
+             CREATE TABLE countries (code TEXT COLLATE RTRIM, name TEXT);"
+        ).unwrap();
+        FixedCharTriggers::create_for_table(&conn, "countries").unwrap();
+
+        conn.execute("INSERT INTO countries VALUES ('US', 'United States'), ('FRA   ', 'France'), (NULL, 'Nowhere')", []).unwrap();
+        let codes: Vec<Option<String>> = conn.prepare("SELECT code FROM countries ORDER BY rowid").unwrap()
+            .query_map([], |row| row.get(0)).unwrap().collect::<Result<_>>().unwrap();
+        assert_eq!(codes, vec![Some("US ".to_string()), Some("FRA".to_string()), None]);
+
+        let name: String = conn.query_row("SELECT name FROM countries WHERE code = 'US'", [], |row| row.get(0)).unwrap();
+        assert_eq!(name, "United States");
+
+        let err = conn.execute("UPDATE countries SET code = 'USA1' WHERE name = 'France'", []).unwrap_err();
+        assert_eq!(err.to_string(), "value too long for type character(3)");
+        conn.execute("UPDATE countries SET code = 'D' WHERE name = 'France'", []).unwrap();
+        let code: String = conn.query_row("SELECT code FROM countries WHERE name = 'France'", [], |row| row.get(0)).unwrap();
+        assert_eq!(code, "D  ");
+    }
+}
@@ -0,0 +1,81 @@
+use rusqlite::{Connection, Result};
+use tracing::debug;
+
+/// Enforces the GENERATED ALWAYS identity columns of a table.
+///
+/// Identity columns are INTEGER PRIMARY KEY AUTOINCREMENT columns in SQLite, which always accept
+/// an explicit value. In a BEFORE INSERT trigger the key is -1 while SQLite still has to assign
+/// it, so any other value was supplied by the statement and is rejected, unless OVERRIDING
+/// SYSTEM VALUE marked it with __pgsqlite_system_value(). Updates may only keep the value.
+/// Both errors are reported as SQLSTATE 428C9.
+pub struct IdentityTriggers;
+
+impl IdentityTriggers {
+    /// Create the triggers for the GENERATED ALWAYS columns recorded in __pgsqlite_identity_columns
+    pub fn create_for_table(conn: &Connection, table_name: &str) -> Result<()> {
+        let mut stmt = conn.prepare(
+            "SELECT column_name FROM __pgsqlite_identity_columns WHERE table_name = ?1 AND generation = 'a'"
+        )?;
+        let columns = stmt.query_map([table_name], |row| row.get::<_, String>(0))?
+            .collect::<Result<Vec<_>>>()?;
+
+        for column in &columns {
+            let message_column = column.replace('\'', "''");
+            conn.execute(&format!(
+                r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_identity_insert_{table_name}_{column}"
+                BEFORE INSERT ON "{table_name}"
+                FOR EACH ROW
+                WHEN NEW."{column}" <> -1 AND NOT __pgsqlite_take_system_value(NEW."{column}")
+                BEGIN
+                    SELECT RAISE(ABORT, 'cannot insert a non-DEFAULT value into column "{message_column}"');
+                END"#
+            ), [])?;
+            conn.execute(&format!(
+                r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_identity_update_{table_name}_{column}"
+                BEFORE UPDATE OF "{column}" ON "{table_name}"
+                FOR EACH ROW
+                WHEN NEW."{column}" IS NOT OLD."{column}"
+                BEGIN
+                    SELECT RAISE(ABORT, 'column "{message_column}" can only be updated to DEFAULT');
+                END"#
+            ), [])?;
+        }
+
+        if !columns.is_empty() {
+            debug!("Created identity triggers for {} columns of {}", columns.len(), table_name);
+        }
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_identity_triggers() {
+        let conn = Connection::open_in_memory().unwrap();
+        crate::functions::system_functions::register_system_functions(&conn).unwrap();
+        conn.execute_batch(
+            "CREATE TABLE __pgsqlite_identity_columns (table_name TEXT, column_name TEXT, generation TEXT);
+             INSERT INTO __pgsqlite_identity_columns VALUES ('items', 'id', 'a');
+             CREATE TABLE items (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);"
+        ).unwrap();
+        IdentityTriggers::create_for_table(&conn, "items").unwrap();
+
+        conn.execute("INSERT INTO items (name) VALUES ('a')", []).unwrap();
+        conn.execute("INSERT INTO items (id, name) VALUES (NULL, 'b')", []).unwrap();
+        conn.execute("INSERT INTO items (id, name) VALUES (__pgsqlite_system_value(10), 'c')", []).unwrap();
+        conn.execute("UPDATE items SET name = 'd' WHERE id = 10", []).unwrap();
+
+        let err = conn.execute("INSERT INTO items (id, name) VALUES (5, 'e')", []).unwrap_err();
+        assert!(err.to_string().contains("cannot insert a non-DEFAULT value into column \"id\""), "{err}");
+        let err = conn.execute("UPDATE items SET id = 20 WHERE id = 10", []).unwrap_err();
+        assert!(err.to_string().contains("can only be updated to DEFAULT"), "{err}");
+
+        let ids: Vec<i64> = conn.prepare("SELECT id FROM items ORDER BY id").unwrap()
+            .query_map([], |row| row.get(0)).unwrap()
+            .collect::<Result<_>>().unwrap();
+        assert_eq!(ids, vec![1, 2, 10]);
+    }
+}
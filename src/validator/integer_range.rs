@@ -0,0 +1,96 @@
+use rusqlite::{Connection, Result};
+use tracing::debug;
+
+/// Enforces the int2/int4/int8 ranges of a table's integer columns.
+///
+/// SQLite stores any 64-bit integer in an INTEGER column and silently turns larger values into
+/// REAL, so BEFORE INSERT and UPDATE triggers abort with PostgreSQL's `<type> out of range`
+/// message, which is reported as SQLSTATE 22003. The WHEN clause keeps the trigger body from
+/// running for rows that are in range.
+pub struct IntegerRangeTriggers;
+
+impl IntegerRangeTriggers {
+    /// Create the range triggers for the integer columns recorded in __pgsqlite_schema
+    pub fn create_for_table(conn: &Connection, table_name: &str) -> Result<()> {
+        let mut stmt = conn.prepare("SELECT column_name, pg_type FROM __pgsqlite_schema WHERE table_name = ?1")?;
+        let columns = stmt.query_map([table_name], |row| Ok((row.get::<_, String>(0)?, row.get::<_, String>(1)?)))?
+            .collect::<Result<Vec<_>>>()?;
+
+        let checks: Vec<(String, &str)> = columns.iter()
+            .filter_map(|(column, pg_type)| {
+                let type_name = Self::integer_type_name(pg_type)?;
+                Some((Self::out_of_range_condition(&format!("NEW.\"{column}\""), type_name), type_name))
+            })
+            .collect();
+        if checks.is_empty() {
+            return Ok(());
+        }
+
+        let when = checks.iter().map(|(condition, _)| condition.as_str()).collect::<Vec<_>>().join(" OR ");
+        let cases: String = checks.iter()
+            .map(|(condition, type_name)| format!("WHEN {condition} THEN RAISE(ABORT, '{type_name} out of range') "))
+            .collect();
+
+        for event in ["INSERT", "UPDATE"] {
+            let trigger_sql = format!(
+                r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_int_range_{event}_{table_name}"
+                BEFORE {event} ON "{table_name}"
+                FOR EACH ROW
+                WHEN {when}
+                BEGIN
+                    SELECT CASE {cases}END;
+                END"#,
+                event = event.to_lowercase(),
+            );
+            conn.execute(&trigger_sql, [])?;
+        }
+
+        debug!("Created integer range triggers for {} columns of {}", checks.len(), table_name);
+        Ok(())
+    }
+
+    /// The PostgreSQL name used in the error for an integer column type, None for other types
+    fn integer_type_name(pg_type: &str) -> Option<&'static str> {
+        match pg_type.trim().to_lowercase().as_str() {
+            "int2" | "smallint" | "smallserial" | "serial2" => Some("smallint"),
+            "int" | "int4" | "integer" | "serial" | "serial4" => Some("integer"),
+            "int8" | "bigint" | "bigserial" | "serial8" => Some("bigint"),
+            _ => None,
+        }
+    }
+
+    /// SQL condition that is true when `value` is outside the range of the type. Adding 0
+    /// converts numeric text, and values beyond an i64 have already become REAL.
+    fn out_of_range_condition(value: &str, type_name: &str) -> String {
+        match type_name {
+            "smallint" => format!("({value} + 0 < -32768 OR {value} + 0 > 32767)"),
+            "integer" => format!("({value} + 0 < -2147483648 OR {value} + 0 > 2147483647)"),
+            _ => format!("({value} + 0 < -9223372036854775808.0 OR {value} + 0 >= 9223372036854775808.0)"),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_range_triggers() {
+        let conn = Connection::open_in_memory().unwrap();
+        conn.execute_batch(
+            "CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT);
+             INSERT INTO __pgsqlite_schema VALUES ('counters', 'small', 'SMALLINT', 'INTEGER'), ('counters', 'n', 'int4', 'INTEGER'),
+                                                  ('counters', 'big', 'BIGINT', 'INTEGER'), ('counters', 'label', 'TEXT', 'TEXT');
+             CREATE TABLE counters (small INTEGER, n INTEGER, big INTEGER, label TEXT);"
+        ).unwrap();
+        IntegerRangeTriggers::create_for_table(&conn, "counters").unwrap();
+
+        conn.execute("INSERT INTO counters VALUES (-32768, 2147483647, 9223372036854775807, '99999999999')", []).unwrap();
+        let error = |sql: &str| conn.execute(sql, []).unwrap_err().to_string();
+        assert_eq!(error("INSERT INTO counters (small) VALUES (32768)"), "smallint out of range");
+        assert_eq!(error("INSERT INTO counters (n) VALUES ('2147483648')"), "integer out of range");
+        assert_eq!(error("INSERT INTO counters (big) VALUES (9223372036854775807 + 1)"), "bigint out of range");
+        assert_eq!(error("UPDATE counters SET n = n + 1"), "integer out of range");
+        conn.execute("UPDATE counters SET n = n - 1, small = NULL", []).unwrap();
+    }
+}
@@ -3,9 +3,27 @@ pub mod numeric_constraints;
 pub mod numeric_triggers;
 pub mod insert_validator;
 pub mod numeric_validator;
+pub mod constraint_violation;
+pub mod integer_range;
+pub mod fixed_char;
+pub mod bit_string;
+pub mod datetime_precision;
+pub mod xml_content;
+pub mod identity;
+pub mod distinct_order_by;
+pub mod offset;
 
 pub use string_constraints::{StringConstraintValidator, StringConstraint};
 pub use numeric_constraints::{NumericConstraintValidator, NumericConstraint};
 pub use numeric_triggers::NumericTriggers;
 pub use insert_validator::{InsertValidator, UpdateValidator};
-pub use numeric_validator::NumericValidator;
\ No newline at end of file
+pub use numeric_validator::NumericValidator;
+pub use constraint_violation::ConstraintViolationMapper;
+pub use integer_range::IntegerRangeTriggers;
+pub use fixed_char::FixedCharTriggers;
+pub use bit_string::BitStringTriggers;
+pub use datetime_precision::DatetimePrecisionTriggers;
+pub use xml_content::XmlTriggers;
+pub use identity::IdentityTriggers;
+pub use distinct_order_by::DistinctOrderByValidator;
+pub use offset::OffsetValidator;
\ No newline at end of file
@@ -55,8 +55,8 @@ impl NumericValidator {
     
     /// Validate a numeric value against constraints
     pub fn validate_value(value: &str, precision: i32, scale: i32) -> Result<(), PgError> {
-        // Handle NULL/empty
-        if value.is_empty() || value.eq_ignore_ascii_case("null") {
+        // Handle NULL/empty, and DEFAULT which takes the column's default
+        if value.is_empty() || value.eq_ignore_ascii_case("null") || value.trim().eq_ignore_ascii_case("default") {
             return Ok(());
         }
         
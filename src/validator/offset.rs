@@ -0,0 +1,58 @@
+use regex::Regex;
+use once_cell::sync::Lazy;
+use crate::error::PgError;
+use crate::PgSqliteError;
+
+/// `OFFSET -n`, optionally parenthesized, in either the LIMIT/OFFSET or OFFSET ... ROWS form
+static NEGATIVE_OFFSET_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)\bOFFSET\s+(?:\(\s*)?-\s*(\d+(?:\.\d*)?)").unwrap()
+});
+
+/// Rejects queries with a negative OFFSET.
+///
+/// PostgreSQL raises 2201X for them, while SQLite treats a negative offset as zero, so a
+/// query builder that miscomputes a page would quietly get the first page back instead.
+pub struct OffsetValidator;
+
+impl OffsetValidator {
+    /// Fail with PostgreSQL's error when a literal OFFSET is negative
+    pub fn validate(query: &str) -> Result<(), PgSqliteError> {
+        let negative = NEGATIVE_OFFSET_REGEX.captures_iter(query)
+            .filter(|caps| query[..caps.get(0).unwrap().start()].matches('\'').count() % 2 == 0)
+            .any(|caps| caps[1].parse::<f64>().is_ok_and(|offset| offset > 0.0));
+        if negative {
+            return Err(PgError::Generic {
+                code: "2201X".to_string(), // invalid_row_count_in_result_offset_clause
+                message: "OFFSET must not be negative".to_string(),
+            }.into());
+        }
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_negative_offset() {
+        for query in [
+            "SELECT * FROM items ORDER BY id LIMIT 10 OFFSET -10",
+            "SELECT * FROM items ORDER BY id offset - 1",
+            "SELECT * FROM items ORDER BY id OFFSET (-5) ROWS FETCH FIRST 5 ROWS ONLY",
+            "SELECT * FROM (SELECT id FROM items LIMIT ALL OFFSET -1) s",
+        ] {
+            let err = OffsetValidator::validate(query).unwrap_err();
+            assert_eq!(err.pg_error_code(), "2201X", "{query}");
+        }
+
+        for query in [
+            "SELECT * FROM items ORDER BY id LIMIT 10 OFFSET 10",
+            "SELECT * FROM items ORDER BY id LIMIT 10 OFFSET -0",
+            "SELECT * FROM items WHERE note = 'OFFSET -1'",
+            "SELECT * FROM items ORDER BY id OFFSET $1",
+        ] {
+            assert!(OffsetValidator::validate(query).is_ok(), "{query}");
+        }
+    }
+}
@@ -0,0 +1,64 @@
+use rusqlite::{Connection, Result};
+use tracing::debug;
+
+/// Validates the values of xml columns.
+///
+/// Xml values are stored as their text. BEFORE INSERT and UPDATE triggers pass each value
+/// to xml(), which raises an invalid XML document error for text that isn't well-formed.
+pub struct XmlTriggers;
+
+impl XmlTriggers {
+    /// Create the validation triggers for the xml columns recorded in __pgsqlite_schema
+    pub fn create_for_table(conn: &Connection, table_name: &str) -> Result<()> {
+        let mut stmt = conn.prepare(
+            "SELECT column_name FROM __pgsqlite_schema WHERE table_name = ?1 AND upper(trim(pg_type)) = 'XML'"
+        )?;
+        let columns = stmt.query_map([table_name], |row| row.get::<_, String>(0))?
+            .collect::<Result<Vec<_>>>()?;
+        if columns.is_empty() {
+            return Ok(());
+        }
+
+        let calls = columns.iter().map(|column| format!("xml(NEW.\"{column}\")")).collect::<Vec<_>>().join(", ");
+        let column_list = columns.iter().map(|column| format!("\"{column}\"")).collect::<Vec<_>>().join(", ");
+
+        for (event, target) in [("insert", "INSERT".to_string()), ("update", format!("UPDATE OF {column_list}"))] {
+            let trigger_sql = format!(
+                r#"CREATE TRIGGER IF NOT EXISTS "__pgsqlite_xml_check_{event}_{table_name}"
+                BEFORE {target} ON "{table_name}"
+                FOR EACH ROW
+                BEGIN
+                    SELECT {calls};
+                END"#
+            );
+            conn.execute(&trigger_sql, [])?;
+        }
+
+        debug!("Created xml triggers for {} columns of {}", columns.len(), table_name);
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_check_triggers() {
+        let conn = Connection::open_in_memory().unwrap();
+        crate::functions::xml_functions::register_xml_functions(&conn).unwrap();
+        conn.execute_batch(
+            "CREATE TABLE __pgsqlite_schema (table_name TEXT, column_name TEXT, pg_type TEXT, sqlite_type TEXT);
+             INSERT INTO __pgsqlite_schema VALUES ('documents', 'body', 'XML', 'TEXT'), ('documents', 'title', 'TEXT', 'TEXT');
+             CREATE TABLE documents (title TEXT, body TEXT);"
+        ).unwrap();
+        XmlTriggers::create_for_table(&conn, "documents").unwrap();
+
+        conn.execute("INSERT INTO documents VALUES ('<a', '<note><to>Tove</to></note>'), ('none', NULL)", []).unwrap();
+
+        let err = conn.execute("INSERT INTO documents VALUES ('bad', '<note><to>Tove</note>')", []).unwrap_err();
+        assert!(err.to_string().contains("invalid XML document"));
+        let err = conn.execute("UPDATE documents SET body = '<a>'", []).unwrap_err();
+        assert!(err.to_string().contains("invalid XML document"));
+    }
+}
@@ -0,0 +1,55 @@
+mod common;
+use common::*;
+use chrono::{Datelike, Utc};
+
+/// Test age(end, start) on dates and timestamps across year boundaries
+#[tokio::test]
+async fn test_age_two_arguments() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT NOT NULL, birth_date DATE)").await?;
+            db.execute("INSERT INTO authors (id, name, birth_date) VALUES
+                (1, 'December', '2023-12-20'),
+                (2, 'Midyear', '1990-06-15'),
+                (3, 'Leap Day', '2000-02-29')").await?;
+
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    let ages = simple_values(client, "SELECT age('2024-01-15', birth_date) AS author_age FROM authors ORDER BY id").await;
+    assert_eq!(ages, vec!["26 days", "33 years 7 mons", "23 years 10 mons 15 days"]);
+
+    let ages = simple_values(client, "SELECT age('2001-04-10', '1957-06-13')").await;
+    assert_eq!(ages, vec!["43 years 9 mons 27 days"]);
+
+    let ages = simple_values(client, "SELECT age('2024-01-01 01:00:00', '2023-12-31 23:00:00')").await;
+    assert_eq!(ages, vec!["02:00:00"]);
+
+    // Reversed arguments produce a negative interval
+    let ages = simple_values(client, "SELECT age('2023-12-31', '2024-02-01')").await;
+    assert_eq!(ages, vec!["-1 mons -1 days"]);
+}
+
+/// Test age(timestamp) relative to the current date
+#[tokio::test]
+async fn test_age_single_argument() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let today = Utc::now().date_naive();
+    let birth = today.with_day(1).unwrap()
+        .with_year(today.year() - 30).unwrap();
+    let expected = if today.day() == 1 {
+        "30 years".to_string()
+    } else if today.day() == 2 {
+        "30 years 1 day".to_string()
+    } else {
+        format!("30 years {} days", today.day() - 1)
+    };
+
+    let ages = simple_values(client, &format!("SELECT age('{}')", birth.format("%Y-%m-%d"))).await;
+    assert_eq!(ages, vec![expected]);
+}
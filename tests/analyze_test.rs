@@ -0,0 +1,40 @@
+mod common;
+use common::*;
+
+/// Test that ANALYZE refreshes reltuples and the column statistics shown by pg_stats
+#[tokio::test]
+async fn test_analyze_refreshes_statistics() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, genre TEXT, note TEXT)").await?;
+            db.execute(
+                "INSERT INTO books (id, genre, note) VALUES \
+                 (1, 'scifi', NULL), (2, 'scifi', NULL), (3, 'scifi', 'signed'), \
+                 (4, 'fantasy', NULL), (5, 'fantasy', 'first edition')"
+            ).await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute("ANALYZE books").await.unwrap();
+
+    let reltuples = rows(&client.simple_query("SELECT reltuples FROM pg_class WHERE relname = 'books'").await.unwrap());
+    assert_eq!(reltuples[0][0].as_deref().unwrap().parse::<f64>().unwrap(), 5.0);
+
+    let stats = rows(&client.simple_query(
+        "SELECT attname, null_frac, n_distinct, most_common_vals, most_common_freqs FROM pg_stats WHERE tablename = 'books'"
+    ).await.unwrap());
+    let genre = stats.iter().find(|row| row[0].as_deref() == Some("genre")).expect("no statistics for genre");
+    assert_eq!(genre[1..], some(&["0", "-0.4", "{scifi,fantasy}", "{0.6,0.4}"])[..]);
+    let note = stats.iter().find(|row| row[0].as_deref() == Some("note")).expect("no statistics for note");
+    assert_eq!(note[1].as_deref(), Some("0.6"));
+
+    // Column lists, the British spelling and a database-wide ANALYZE are all accepted
+    client.batch_execute("ANALYZE books (genre, note)").await.unwrap();
+    client.batch_execute("ANALYSE VERBOSE books").await.unwrap();
+    client.batch_execute("ANALYZE").await.unwrap();
+
+    assert!(client.batch_execute("ANALYZE missing_table").await.is_err());
+    assert!(client.batch_execute("ANALYZE books (missing_column)").await.is_err());
+}
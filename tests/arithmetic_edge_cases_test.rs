@@ -38,11 +38,9 @@ async fn test_division_by_zero() {
         }
     });
     
-    // Test division by zero - SQLite returns NULL
-    let rows = client.query("SELECT numerator / denominator AS result FROM test_div WHERE id = 1", &[]).await.unwrap();
-    assert_eq!(rows.len(), 1);
-    let result: Option<f64> = rows[0].get(0);
-    assert!(result.is_none()); // SQLite returns NULL for division by zero
+    // Test division by zero - PostgreSQL raises division_by_zero
+    let err = client.query("SELECT numerator / denominator AS result FROM test_div WHERE id = 1", &[]).await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::DIVISION_BY_ZERO));
     
     // Test normal division
     let rows = client.query("SELECT numerator / denominator AS result FROM test_div WHERE id = 2", &[]).await.unwrap();
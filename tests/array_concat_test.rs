@@ -223,4 +223,33 @@ async fn test_array_syntax_concatenation() {
     assert_eq!(result.len(), 1);
     let greeting: String = result[0].get(0);
     assert_eq!(greeting, "hello world");
-}
\ No newline at end of file
+}
+
+/// Test || between two arrays and between an array and a single element, with the
+/// result typed as the array it extends
+#[tokio::test]
+async fn test_array_concatenation_values() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE concat_values (id INTEGER PRIMARY KEY, tags TEXT[], numbers INTEGER[])").await?;
+            db.execute("INSERT INTO concat_values (id, tags, numbers) VALUES (1, '{a,b}', '{1,2,3}')").await?;
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    for (query, expected) in [
+        ("SELECT numbers || ARRAY[4,5] AS merged FROM concat_values WHERE id = 1", "{1,2,3,4,5}"),
+        ("SELECT tags || '{c,d}' AS merged FROM concat_values WHERE id = 1", "{a,b,c,d}"),
+        ("SELECT tags || 'c' AS appended FROM concat_values WHERE id = 1", "{a,b,c}"),
+        ("SELECT numbers || 4 AS appended FROM concat_values WHERE id = 1", "{1,2,3,4}"),
+        ("SELECT 0 || numbers AS prepended FROM concat_values WHERE id = 1", "{0,1,2,3}"),
+        ("SELECT 'z' || tags AS prepended FROM concat_values WHERE id = 1", "{z,a,b}"),
+    ] {
+        assert_eq!(first_value(client, query).await.as_deref(), Some(expected), "{query}");
+    }
+
+    // Text concatenation is unaffected
+    assert_eq!(first_value(client, "SELECT 'tag' || 's' AS word").await.as_deref(), Some("tags"));
+}
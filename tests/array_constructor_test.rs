@@ -0,0 +1,40 @@
+mod common;
+use common::*;
+
+/// Test ARRAY[...] and ARRAY(subquery) constructors feeding the overlap operator
+#[tokio::test]
+async fn test_array_constructors_with_overlap() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, tags TEXT)").await?;
+            db.execute("CREATE TABLE genres (id INTEGER PRIMARY KEY, name TEXT, featured INTEGER)").await?;
+            db.execute(
+                r#"INSERT INTO books (id, title, tags) VALUES
+                (1, 'Dune', '["scifi", "classic"]'),
+                (2, 'Emma', '["romance", "classic"]'),
+                (3, 'Neuromancer', '["scifi", "cyberpunk"]'),
+                (4, 'Odyssey', '["poetry"]')"#
+            ).await?;
+            db.execute("INSERT INTO genres (id, name, featured) VALUES (1, 'cyberpunk', 1), (2, 'romance', 1), (3, 'poetry', 0)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    // Literal constructor
+    let rows = client.query("SELECT id FROM books WHERE tags && ARRAY['classic', 'poetry'] ORDER BY id", &[]).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![1, 2, 4]);
+
+    // Subquery constructor
+    let rows = client.query(
+        "SELECT id FROM books WHERE tags && ARRAY(SELECT name FROM genres WHERE featured = 1) ORDER BY id",
+        &[],
+    ).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![2, 3]);
+
+    // The subquery's values come back as one array
+    let names = first_value(client, "SELECT ARRAY(SELECT name FROM genres ORDER BY id) AS names").await.unwrap();
+    assert!(names.contains("cyberpunk") && names.contains("romance") && names.contains("poetry"), "{names}");
+}
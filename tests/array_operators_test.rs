@@ -369,4 +369,96 @@ async fn test_unnest_with_ordinality() {
     assert_eq!(val2, "d");
     
     server.abort();
-}
\ No newline at end of file
+}
+#[tokio::test]
+async fn test_array_search_and_edit_functions() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE posts (id INTEGER PRIMARY KEY, tags TEXT[])").await?;
+            db.execute("INSERT INTO posts (id, tags) VALUES (1, '{old,rust,\"new tag\",old}'), (2, '{go}')").await?;
+            Ok(())
+        })
+    }).await;
+    
+    let client = &server.client;
+    
+    let row = client.query_one("SELECT array_remove(tags, 'old') FROM posts WHERE id = 1", &[]).await.unwrap();
+    let removed: String = row.get(0);
+    assert_eq!(removed, r#"["rust","new tag"]"#);
+    
+    let row = client.query_one("SELECT array_replace(tags, 'old', 'legacy') FROM posts WHERE id = 1", &[]).await.unwrap();
+    let replaced: String = row.get(0);
+    assert_eq!(replaced, r#"["legacy","rust","new tag","legacy"]"#);
+    
+    let rows = client.query("SELECT id, array_position(tags, 'rust') FROM posts ORDER BY id", &[]).await.unwrap();
+    let positions: Vec<Option<i32>> = rows.iter().map(|row| row.get(1)).collect();
+    assert_eq!(positions, vec![Some(2), None]);
+    
+    let row = client.query_one("SELECT array_position(tags, 'old', 2) FROM posts WHERE id = 1", &[]).await.unwrap();
+    let position: i32 = row.get(0);
+    assert_eq!(position, 4);
+    
+    let row = client.query_one("SELECT array_positions(tags, 'old') FROM posts WHERE id = 1", &[]).await.unwrap();
+    let positions: String = row.get(0);
+    assert_eq!(positions, "[1,4]");
+    
+    server.abort();
+}
+
+#[tokio::test]
+async fn test_cardinality_and_ndims() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE grids (id INTEGER PRIMARY KEY, cells INTEGER[][], tags TEXT[])").await?;
+            db.execute("INSERT INTO grids (id, cells, tags) VALUES (1, '{{1,2,3},{4,5,6}}', '{a,b}'), (2, '{}', '{}')").await?;
+            Ok(())
+        })
+    }).await;
+    
+    let client = &server.client;
+    
+    let rows = client.query("SELECT cardinality(cells) AS n FROM grids ORDER BY id", &[]).await.unwrap();
+    let counts: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(counts, vec![6, 0]);
+    
+    let row = client.query_one("SELECT array_ndims(tags) FROM grids WHERE id = 1", &[]).await.unwrap();
+    let ndims: i32 = row.get(0);
+    assert_eq!(ndims, 1);
+    
+    server.abort();
+}
+
+#[tokio::test]
+async fn test_string_array_round_trip() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE posts (id INTEGER PRIMARY KEY, tags TEXT[])").await?;
+            db.execute("INSERT INTO posts (id, tags) VALUES (1, '{rust,NULL,sqlite}')").await?;
+            Ok(())
+        })
+    }).await;
+    
+    let client = &server.client;
+    
+    let row = client.query_one("SELECT string_to_array('a,b,,c', ',', '') AS parts", &[]).await.unwrap();
+    let parts: String = row.get(0);
+    assert_eq!(parts, r#"["a","b",null,"c"]"#);
+    
+    let row = client.query_one(
+        "SELECT array_to_string(string_to_array('a,b,c', ','), ', ') AS joined",
+        &[]
+    ).await.unwrap();
+    let joined: String = row.get(0);
+    assert_eq!(joined, "a, b, c");
+    
+    let row = client.query_one(
+        "SELECT array_to_string(tags, ', '), array_to_string(tags, ', ', '-') FROM posts WHERE id = 1",
+        &[]
+    ).await.unwrap();
+    let skipped: String = row.get(0);
+    let rendered: String = row.get(1);
+    assert_eq!(skipped, "rust, sqlite");
+    assert_eq!(rendered, "rust, -, sqlite");
+    
+    server.abort();
+}
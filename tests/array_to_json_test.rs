@@ -0,0 +1,52 @@
+mod common;
+use common::*;
+use tokio_postgres::error::SqlState;
+use tokio_postgres::types::Type;
+
+/// Test converting a text[] column to a JSON array
+#[tokio::test]
+async fn test_array_to_json() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE posts (id INTEGER PRIMARY KEY, tags TEXT[])").await?;
+            db.execute("INSERT INTO posts (id, tags) VALUES (1, '{rust,\"sql lite\"}'), (2, '{}'), (3, NULL)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let stmt = client.prepare("SELECT array_to_json(tags) FROM posts ORDER BY id").await.unwrap();
+    assert_eq!(stmt.columns()[0].name(), "array_to_json");
+    assert_eq!(stmt.columns()[0].type_(), &Type::JSON);
+
+    let rows = client.query(&stmt, &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, JsonText>(0).0, r#"["rust","sql lite"]"#);
+    assert_eq!(rows[1].get::<_, JsonText>(0).0, "[]");
+    assert!(rows[2].get::<_, Option<JsonText>>(0).is_none());
+
+    let rows = client.query("SELECT json_array_length(array_to_json(tags)) AS tag_count FROM posts WHERE id = 1", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i32>(0), 2);
+}
+
+/// Test counting JSON array elements and rejecting non-arrays
+#[tokio::test]
+async fn test_json_array_length() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE events (id INTEGER PRIMARY KEY, payload JSONB)").await?;
+            db.execute(r#"INSERT INTO events (id, payload) VALUES (1, '[{"a": 1}, 2, "three"]'), (2, '{"a": [1, 2]}')"#).await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let count = first_value(client, "SELECT jsonb_array_length(payload) FROM events WHERE id = 1").await;
+    assert_eq!(count.as_deref(), Some("3"));
+
+    let err = client.simple_query("SELECT jsonb_array_length(payload) FROM events WHERE id = 2").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::INVALID_PARAMETER_VALUE));
+    assert!(err.to_string().contains("cannot get array length of a non-array"), "unexpected error: {err}");
+
+    let err = client.simple_query("SELECT json_array_length('7')").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::INVALID_PARAMETER_VALUE));
+}
@@ -0,0 +1,63 @@
+mod common;
+use common::*;
+
+/// Test the bitwise operators on bit columns and B'...' literals
+#[tokio::test]
+async fn test_bitwise_operators() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE features (id INTEGER PRIMARY KEY, flags BIT(8), mask BIT VARYING(8));
+         INSERT INTO features (id, flags, mask) VALUES (1, B'00000101', B'0011'), (2, B'11110000', B'1'), (3, NULL, NULL);"
+    ).await.unwrap();
+
+    // Feature-flag filtering with a mask
+    let results = client.simple_query(
+        "SELECT id FROM features WHERE flags & B'00000100' = B'00000100' ORDER BY id"
+    ).await.unwrap();
+    assert_eq!(rows(&results), vec![some(&["1"])]);
+
+    let results = client.simple_query(
+        "SELECT flags | B'00001111', flags # B'11111111', ~flags, flags << 2, flags >> 1 FROM features WHERE id = 1"
+    ).await.unwrap();
+    assert_eq!(rows(&results), vec![some(&["00001111", "11111010", "11111010", "00010100", "00000010"])]);
+
+    // Integer bitwise arithmetic is unaffected
+    let results = client.simple_query("SELECT id & 2, id | 4, id << 3 FROM features WHERE id = 3").await.unwrap();
+    assert_eq!(rows(&results), vec![some(&["2", "7", "24"])]);
+
+    // Operands of different lengths are an error
+    let err = client.simple_query("SELECT flags & mask FROM features WHERE id = 1").await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::STRING_DATA_LENGTH_MISMATCH));
+}
+
+/// Test get_bit, set_bit, length and the bit(n) length check
+#[tokio::test]
+async fn test_bit_functions_and_length_check() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE permissions (id INTEGER PRIMARY KEY, bits BIT(4));
+         INSERT INTO permissions (id, bits) VALUES (1, B'1010');"
+    ).await.unwrap();
+
+    let results = client.simple_query(
+        "SELECT get_bit(bits, 0), get_bit(bits, 1), length(bits) FROM permissions"
+    ).await.unwrap();
+    assert_eq!(rows(&results), vec![some(&["1", "0", "4"])]);
+
+    client.execute("UPDATE permissions SET bits = set_bit(bits, 3, 1) WHERE id = 1", &[]).await.unwrap();
+    let results = client.simple_query("SELECT bits FROM permissions").await.unwrap();
+    assert_eq!(rows(&results), vec![some(&["1011"])]);
+
+    // A bit(4) column rejects values of any other length
+    let err = client.execute("INSERT INTO permissions (id, bits) VALUES (2, B'101')", &[]).await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::STRING_DATA_LENGTH_MISMATCH));
+    let err = client.execute("INSERT INTO permissions (id, bits) VALUES (2, B'1021')", &[]).await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::INVALID_TEXT_REPRESENTATION));
+
+    let results = client.simple_query("SELECT 5::bit(4), B'101'::bit varying(2)").await.unwrap();
+    assert_eq!(rows(&results), vec![some(&["0101", "10"])]);
+}
@@ -0,0 +1,97 @@
+mod common;
+use common::first_value;
+use std::sync::Arc;
+use std::time::Duration;
+use pgsqlite::session::DbHandler;
+use tokio::net::TcpListener;
+use tokio_postgres::error::SqlState;
+use tokio_postgres::{Client, NoTls};
+
+/// Runs until it is cancelled
+const LONG_QUERY: &str = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 1000000000) SELECT count(*) FROM c";
+
+async fn connect(port: u16) -> Client {
+    let config = format!("host=localhost port={port} dbname=test user=testuser");
+    let (client, connection) = tokio_postgres::connect(&config, NoTls).await.unwrap();
+    tokio::spawn(async move {
+        if let Err(e) = connection.await {
+            eprintln!("Connection error: {e}");
+        }
+    });
+    client
+}
+
+/// Wait until pg_stat_activity shows the backend running a statement
+async fn wait_until_active(monitor: &Client, pid: i32) {
+    for _ in 0..100 {
+        let state = first_value(monitor, &format!("SELECT state FROM pg_stat_activity WHERE pid = {pid}")).await;
+        if state.as_deref() == Some("active") {
+            return;
+        }
+        tokio::time::sleep(Duration::from_millis(20)).await;
+    }
+    panic!("backend {pid} never became active");
+}
+
+/// Test that the pid from pg_backend_pid() is the one BackendKeyData reports, so it works for
+/// CancelRequest, pg_cancel_backend() and pg_terminate_backend()
+#[tokio::test(flavor = "multi_thread", worker_threads = 4)]
+async fn test_cancel_and_terminate_backend() {
+    let listener = TcpListener::bind("127.0.0.1:0").await.unwrap();
+    let port = listener.local_addr().unwrap().port();
+    let db_path = format!("/tmp/pgsqlite_cancel_backend_{}.db", uuid::Uuid::new_v4().simple());
+    let db_handler = Arc::new(DbHandler::new(&db_path).unwrap());
+
+    // Serve every connection: cancel requests arrive on connections of their own
+    let server_handle = tokio::spawn(async move {
+        loop {
+            let (stream, addr) = listener.accept().await.unwrap();
+            let db_handler = db_handler.clone();
+            tokio::spawn(async move {
+                if let Err(e) = pgsqlite::handle_test_connection_with_pool(stream, addr, db_handler).await {
+                    eprintln!("Connection handling error: {e}");
+                }
+            });
+        }
+    });
+
+    let worker = Arc::new(connect(port).await);
+    let monitor = connect(port).await;
+
+    let pid: i32 = first_value(&worker, "SELECT pg_backend_pid()").await.unwrap().parse().unwrap();
+    let listed = first_value(&monitor, &format!("SELECT count(*) FROM pg_stat_activity WHERE pid = {pid}")).await;
+    assert_eq!(listed.as_deref(), Some("1"));
+
+    // The client's cancel token holds the pid and secret key from BackendKeyData
+    let running = tokio::spawn({
+        let worker = worker.clone();
+        async move { worker.simple_query(LONG_QUERY).await.map(|_| ()) }
+    });
+    wait_until_active(&monitor, pid).await;
+    worker.cancel_token().cancel_query(NoTls).await.unwrap();
+    let err = running.await.unwrap().unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::QUERY_CANCELED));
+
+    // The connection keeps working after a cancel
+    assert_eq!(first_value(&worker, "SELECT pg_backend_pid()").await, Some(pid.to_string()));
+
+    // pg_cancel_backend() takes the same pid
+    let running = tokio::spawn({
+        let worker = worker.clone();
+        async move { worker.simple_query(LONG_QUERY).await.map(|_| ()) }
+    });
+    wait_until_active(&monitor, pid).await;
+    monitor.simple_query(&format!("SELECT pg_cancel_backend({pid})")).await.unwrap();
+    let err = running.await.unwrap().unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::QUERY_CANCELED));
+
+    // pg_terminate_backend() closes the connection
+    monitor.simple_query(&format!("SELECT pg_terminate_backend({pid})")).await.unwrap();
+    tokio::time::sleep(Duration::from_millis(200)).await;
+    assert!(worker.simple_query("SELECT 1").await.is_err());
+    let listed = first_value(&monitor, &format!("SELECT count(*) FROM pg_stat_activity WHERE pid = {pid}")).await;
+    assert_eq!(listed.as_deref(), Some("0"));
+
+    server_handle.abort();
+    let _ = std::fs::remove_file(&db_path);
+}
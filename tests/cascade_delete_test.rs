@@ -0,0 +1,63 @@
+mod common;
+use common::*;
+
+/// Test that deleting an author cascades through books to reviews and inventory
+#[tokio::test]
+async fn test_on_delete_cascade_multi_level() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT NOT NULL)").await?;
+            db.execute("CREATE TABLE books (
+                id INTEGER PRIMARY KEY,
+                title TEXT NOT NULL,
+                author_id INTEGER NOT NULL,
+                CONSTRAINT fk_books_author FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE CASCADE
+            )").await?;
+            db.execute("CREATE TABLE reviews (
+                id INTEGER PRIMARY KEY,
+                book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+                rating INTEGER NOT NULL
+            )").await?;
+            db.execute("CREATE TABLE inventory (
+                id INTEGER PRIMARY KEY,
+                book_id INTEGER NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+                quantity INTEGER NOT NULL
+            )").await?;
+
+            db.execute("INSERT INTO authors (id, name) VALUES (1, 'Ursula K. Le Guin'), (2, 'Octavia E. Butler')").await?;
+            db.execute("INSERT INTO books (id, title, author_id) VALUES
+                (1, 'A Wizard of Earthsea', 1),
+                (2, 'The Left Hand of Darkness', 1),
+                (3, 'Kindred', 2)").await?;
+            db.execute("INSERT INTO reviews (id, book_id, rating) VALUES (1, 1, 5), (2, 2, 4), (3, 3, 5)").await?;
+            db.execute("INSERT INTO inventory (id, book_id, quantity) VALUES (1, 1, 10), (2, 2, 3), (3, 3, 7)").await?;
+
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    let deleted = client.execute("DELETE FROM authors WHERE id = 1", &[]).await.unwrap();
+    assert_eq!(deleted, 1);
+
+    for (table, expected) in [("authors", 1i64), ("books", 1), ("reviews", 1), ("inventory", 1)] {
+        let rows = client.query(&format!("SELECT COUNT(*) FROM {table}"), &[]).await.unwrap();
+        assert_eq!(rows[0].get::<_, i64>(0), expected, "unexpected row count in {table}");
+    }
+
+    // Only the other author's rows remain
+    let rows = client.query(
+        "SELECT b.title FROM books b JOIN reviews r ON r.book_id = b.id JOIN inventory i ON i.book_id = b.id",
+        &[]
+    ).await.unwrap();
+    assert_eq!(rows.len(), 1);
+    assert_eq!(rows[0].get::<_, String>(0), "Kindred");
+
+    // Deleting a single book cascades to its reviews and inventory
+    client.execute("DELETE FROM books WHERE id = 3", &[]).await.unwrap();
+    for table in ["reviews", "inventory"] {
+        let rows = client.query(&format!("SELECT COUNT(*) FROM {table}"), &[]).await.unwrap();
+        assert_eq!(rows[0].get::<_, i64>(0), 0, "unexpected row count in {table}");
+    }
+}
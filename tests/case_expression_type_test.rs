@@ -0,0 +1,41 @@
+mod common;
+use common::*;
+use tokio_postgres::types::Type;
+
+/// Test that a CASE column gets the common type of its branches
+#[tokio::test]
+async fn test_case_result_type_unification() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE products (id INTEGER PRIMARY KEY, name TEXT, price NUMERIC(10,2), stock INTEGER)").await?;
+            db.execute("INSERT INTO products (id, name, price, stock) VALUES (1, 'Lamp', 19.99, 3), (2, 'Desk', 149.50, 0)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    // numeric column + integer literal -> numeric
+    let stmt = client.prepare(
+        "SELECT id, CASE WHEN stock > 0 THEN price ELSE 0 END AS sale_price FROM products ORDER BY id"
+    ).await.unwrap();
+    assert_eq!(stmt.columns()[1].type_(), &Type::NUMERIC);
+    let rows = client.query(&stmt, &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, rust_decimal::Decimal>(1), rust_decimal::Decimal::new(1999, 2));
+    assert_eq!(rows[1].get::<_, rust_decimal::Decimal>(1), rust_decimal::Decimal::ZERO);
+
+    // Simple CASE with text branches and no ELSE -> text
+    let stmt = client.prepare(
+        "SELECT CASE stock WHEN 0 THEN 'sold out' WHEN 3 THEN name END AS label FROM products ORDER BY id"
+    ).await.unwrap();
+    assert_eq!(stmt.columns()[0].type_(), &Type::TEXT);
+    let rows = client.query(&stmt, &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, String>(0), "Lamp");
+    assert_eq!(rows[1].get::<_, String>(0), "sold out");
+
+    // Integer branches with an implicit NULL ELSE stay integer
+    let stmt = client.prepare("SELECT CASE WHEN stock > 0 THEN stock * 2 END AS doubled FROM products ORDER BY id").await.unwrap();
+    assert_eq!(stmt.columns()[0].type_(), &Type::INT4);
+    let rows = client.query(&stmt, &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, Option<i32>>(0), Some(6));
+    assert_eq!(rows[1].get::<_, Option<i32>>(0), None);
+}
@@ -0,0 +1,64 @@
+mod common;
+use common::*;
+
+use tokio_postgres::error::SqlState;
+use tokio_postgres::types::Type;
+
+/// Test that char(n) values come back blank-padded and compare ignoring trailing spaces
+#[tokio::test]
+async fn test_char_padding_and_comparison() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE countries (id INTEGER PRIMARY KEY, code CHAR(3), name TEXT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "INSERT INTO countries (id, code, name) VALUES (1, 'US', 'United States');
+         INSERT INTO countries (id, code, name) VALUES (2, 'FRA  ', 'France');"
+    ).await.unwrap();
+
+    let stmt = client.prepare("SELECT code FROM countries ORDER BY id").await.unwrap();
+    assert_eq!(stmt.columns()[0].type_(), &Type::BPCHAR);
+    let codes: Vec<String> = client.query(&stmt, &[]).await.unwrap().iter().map(|row| row.get(0)).collect();
+    assert_eq!(codes, vec!["US ", "FRA"]);
+
+    // Trailing spaces don't matter when comparing
+    for query in [
+        "SELECT name FROM countries WHERE code = 'US'",
+        "SELECT name FROM countries WHERE code = 'US   '",
+        "SELECT name FROM countries WHERE 'US' = code",
+    ] {
+        let rows = client.query(query, &[]).await.unwrap();
+        assert_eq!(rows.len(), 1, "{query}");
+        assert_eq!(rows[0].get::<_, String>(0), "United States");
+    }
+
+    client.batch_execute("UPDATE countries SET code = 'D' WHERE id = 2").await.unwrap();
+    let row = client.query_one("SELECT code FROM countries WHERE id = 2", &[]).await.unwrap();
+    assert_eq!(row.get::<_, String>(0), "D  ");
+}
+
+/// Test that values whose non-space characters don't fit are rejected
+#[tokio::test]
+async fn test_char_too_long() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE countries (id INTEGER PRIMARY KEY, code CHAR(2))").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let err = client.batch_execute("INSERT INTO countries (id, code) VALUES (1, 'USA')").await.unwrap_err();
+    let db_err = err.as_db_error().unwrap();
+    assert_eq!(db_err.code(), &SqlState::STRING_DATA_RIGHT_TRUNCATION);
+    assert_eq!(db_err.message(), "value too long for type character(2)");
+
+    // Extra trailing spaces are cut instead
+    client.batch_execute("INSERT INTO countries (id, code) VALUES (2, 'UK    ')").await.unwrap();
+    let row = client.query_one("SELECT code FROM countries WHERE id = 2", &[]).await.unwrap();
+    assert_eq!(row.get::<_, String>(0), "UK");
+}
@@ -0,0 +1,39 @@
+mod common;
+use common::*;
+use tokio_postgres::types::Type;
+
+#[tokio::test]
+async fn test_coalesce_result_types() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE balances (id INTEGER PRIMARY KEY, credit NUMERIC(10,2), bonus NUMERIC(10,2), visits INTEGER, total BIGINT, nickname TEXT)").await?;
+            db.execute("INSERT INTO balances (id, credit, bonus, visits, total, nickname) VALUES (1, 12.50, NULL, 3, 40, 'ann'), (2, NULL, NULL, NULL, NULL, NULL)").await?;
+            Ok(())
+        })
+    }).await;
+    
+    let client = &server.client;
+    
+    let statement = client.prepare(
+        "SELECT COALESCE(credit, bonus, 0) AS amount, COALESCE(visits, total) AS hits, COALESCE(nickname, 'anonymous') AS name \
+         FROM balances ORDER BY id"
+    ).await.unwrap();
+    let types: Vec<&Type> = statement.columns().iter().map(|column| column.type_()).collect();
+    assert_eq!(types, vec![&Type::NUMERIC, &Type::INT8, &Type::TEXT]);
+    
+    // A row where every argument is NULL reports the same types
+    let rows = client.query(
+        "SELECT COALESCE(visits, total) AS hits, COALESCE(credit, bonus) AS amount, COALESCE(nickname, 'anonymous') AS name \
+         FROM balances ORDER BY id",
+        &[]
+    ).await.unwrap();
+    assert_eq!(rows[0].columns()[0].type_(), &Type::INT8);
+    assert_eq!(rows[0].columns()[1].type_(), &Type::NUMERIC);
+    
+    let hits: Vec<Option<i64>> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(hits, vec![Some(3), None]);
+    let names: Vec<String> = rows.iter().map(|row| row.get(2)).collect();
+    assert_eq!(names, vec!["ann", "anonymous"]);
+    
+    server.abort();
+}
@@ -0,0 +1,41 @@
+mod common;
+use common::*;
+/// Test sorting accented strings by locale and by byte value
+#[tokio::test]
+async fn test_order_by_collate() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT);
+         INSERT INTO people (name) VALUES ('zebra'), ('Émile'), ('eagle'), ('apple'), ('Eve'), ('éclair');"
+    ).await.unwrap();
+
+    assert_eq!(simple_values(client, "SELECT name FROM people ORDER BY name COLLATE \"en_US\"").await, vec!["apple", "eagle", "éclair", "Émile", "Eve", "zebra"]);
+
+    assert_eq!(simple_values(client, "SELECT name FROM people ORDER BY name COLLATE \"C\"").await, vec!["Eve", "apple", "eagle", "zebra", "Émile", "éclair"]);
+
+    // The extended protocol translates collation names too
+    let rows = client.query("SELECT name FROM people ORDER BY name COLLATE \"de-DE-x-icu\" DESC LIMIT 2", &[]).await.unwrap();
+    let names: Vec<String> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(names, vec!["zebra", "Eve"]);
+}
+
+/// Test comparisons under case-sensitive and case-insensitive collations
+#[tokio::test]
+async fn test_compare_with_collate() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE tags (id INTEGER PRIMARY KEY, label TEXT);
+         INSERT INTO tags (label) VALUES ('Rust'), ('rust'), ('RUST'), ('go');"
+    ).await.unwrap();
+
+    assert_eq!(
+        simple_values(client, "SELECT count(*) FROM tags WHERE label = 'rust' COLLATE case_insensitive").await,
+        vec!["3"]
+    );
+
+    assert_eq!(simple_values(client, "SELECT count(*) FROM tags WHERE label = 'rust' COLLATE \"C\"").await, vec!["1"]);
+}
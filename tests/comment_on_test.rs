@@ -0,0 +1,76 @@
+mod common;
+use common::*;
+
+/// Test that COMMENT ON TABLE/COLUMN comments are stored and show up in pg_description
+#[tokio::test]
+async fn test_comment_on_table_and_column() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, isbn TEXT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.simple_query("COMMENT ON TABLE books IS 'Catalog of books'").await.unwrap();
+    client.simple_query("COMMENT ON COLUMN books.title IS 'The book''s title'").await.unwrap();
+    // Schema-qualified, quoted names as emitted by ORMs
+    client.simple_query(r#"COMMENT ON COLUMN public."books"."isbn" IS 'ISBN-13'"#).await.unwrap();
+
+    assert_eq!(
+        column(&client.simple_query("SELECT objsubid, description FROM pg_description WHERE objsubid = 0").await.unwrap(), "description"),
+        vec!["Catalog of books"]
+    );
+    assert_eq!(
+        simple_values(client, "SELECT description FROM pg_description WHERE objsubid = 2").await,
+        vec!["The book's title"]
+    );
+    assert_eq!(
+        simple_values(client, "SELECT description FROM pg_description WHERE objsubid = 3").await,
+        vec!["ISBN-13"]
+    );
+
+    // Replacing and removing comments
+    client.simple_query("COMMENT ON TABLE books IS 'All books'").await.unwrap();
+    client.simple_query("COMMENT ON COLUMN books.isbn IS NULL").await.unwrap();
+    assert_eq!(
+        simple_values(client, "SELECT description FROM pg_description WHERE objsubid = 0").await,
+        vec!["All books"]
+    );
+    assert!(simple_values(client, "SELECT description FROM pg_description WHERE objsubid = 3").await.is_empty());
+
+    // Unknown objects are rejected
+    assert!(client.simple_query("COMMENT ON TABLE missing IS 'x'").await.is_err());
+    assert!(client.simple_query("COMMENT ON COLUMN books.missing IS 'x'").await.is_err());
+}
+
+/// Test that obj_description and col_description find comments by the OIDs pg_class reports
+#[tokio::test]
+async fn test_description_functions() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, isbn TEXT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.simple_query("COMMENT ON TABLE books IS 'Catalog of books'").await.unwrap();
+    client.simple_query("COMMENT ON COLUMN books.title IS 'The book''s title'").await.unwrap();
+
+    let oid = first_value(client, "SELECT oid FROM pg_class WHERE relname = 'books'").await
+        .expect("books should be in pg_class");
+
+    assert_eq!(
+        simple_values(client, &format!("SELECT col_description({oid}, 2) AS description")).await,
+        vec!["The book's title"]
+    );
+    assert_eq!(
+        simple_values(client, &format!("SELECT obj_description({oid}, 'pg_class') AS description")).await,
+        vec!["Catalog of books"]
+    );
+
+    // Columns without a comment give NULL
+    let results = client.simple_query(&format!("SELECT col_description({oid}, 3) AS description")).await.unwrap();
+    assert_eq!(rows(&results), vec![vec![None]]);
+}
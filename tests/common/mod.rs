@@ -1,5 +1,6 @@
 use tokio::net::TcpListener;
-use tokio_postgres::{Client, NoTls};
+use tokio_postgres::{Client, NoTls, SimpleQueryMessage};
+use tokio_postgres::types::{FromSql, Type};
 use std::sync::Arc;
 use uuid::Uuid;
 
@@ -43,6 +44,7 @@ pub async fn setup_test_server() -> TestServer {
 }
 
 /// Setup a test server with custom initialization
+#[allow(dead_code)]
 pub async fn setup_test_server_with_init<F, Fut>(init: F) -> TestServer 
 where
     F: FnOnce(Arc<pgsqlite::session::DbHandler>) -> Fut + Send + 'static,
@@ -101,4 +103,73 @@ where
         server_handle,
         db_path,
     }
-}
\ No newline at end of file
+}
+
+/// The rows of simple query results, each column as text and NULL as None
+#[allow(dead_code)]
+pub fn rows(results: &[SimpleQueryMessage]) -> Vec<Vec<Option<String>>> {
+    results.iter()
+        .filter_map(|msg| match msg {
+            SimpleQueryMessage::Row(row) => Some((0..row.len()).map(|i| row.get(i).map(str::to_string)).collect()),
+            _ => None,
+        })
+        .collect()
+}
+
+/// One column of simple query results by name, skipping NULLs
+#[allow(dead_code)]
+pub fn column(results: &[SimpleQueryMessage], name: &str) -> Vec<String> {
+    results.iter()
+        .filter_map(|msg| match msg {
+            SimpleQueryMessage::Row(row) => row.get(name).map(str::to_string),
+            _ => None,
+        })
+        .collect()
+}
+
+/// Run a simple query and return its first column, skipping NULLs
+#[allow(dead_code)]
+pub async fn simple_values(client: &Client, query: &str) -> Vec<String> {
+    client.simple_query(query).await.unwrap()
+        .iter()
+        .filter_map(|msg| match msg {
+            SimpleQueryMessage::Row(row) => row.get(0).map(str::to_string),
+            _ => None,
+        })
+        .collect()
+}
+
+/// Run a simple query and return the first column of its first row, None for NULL
+/// or no rows
+#[allow(dead_code)]
+pub async fn first_value(client: &Client, query: &str) -> Option<String> {
+    client.simple_query(query).await.unwrap()
+        .iter()
+        .find_map(|msg| match msg {
+            SimpleQueryMessage::Row(row) => Some(row.get(0).map(str::to_string)),
+            _ => None,
+        })
+        .flatten()
+}
+
+/// Expected non-NULL row values
+#[allow(dead_code)]
+pub fn some(values: &[&str]) -> Vec<Option<String>> {
+    values.iter().map(|v| Some(v.to_string())).collect()
+}
+
+/// json/jsonb column value as its JSON text
+#[allow(dead_code)]
+pub struct JsonText(pub String);
+
+impl<'a> FromSql<'a> for JsonText {
+    fn from_sql(ty: &Type, raw: &'a [u8]) -> Result<Self, Box<dyn std::error::Error + Sync + Send>> {
+        // jsonb carries a leading version byte
+        let raw = if *ty == Type::JSONB { &raw[1..] } else { raw };
+        Ok(JsonText(std::str::from_utf8(raw)?.to_string()))
+    }
+
+    fn accepts(ty: &Type) -> bool {
+        *ty == Type::JSON || *ty == Type::JSONB
+    }
+}
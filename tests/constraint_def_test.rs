@@ -0,0 +1,54 @@
+mod common;
+use common::*;
+
+async fn setup_library() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("PRAGMA foreign_keys = ON").await?;
+            db.execute("CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT)").await?;
+            db.execute("CREATE TABLE books (
+                id INTEGER PRIMARY KEY,
+                author_id INTEGER REFERENCES authors(id) ON DELETE CASCADE,
+                title TEXT CHECK (length(title) > 0),
+                status TEXT DEFAULT 'draft'
+            )").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+/// Test that pg_get_constraintdef rebuilds CHECK, FOREIGN KEY and PRIMARY KEY clauses
+#[tokio::test]
+async fn test_pg_get_constraintdef() {
+    let server = setup_library().await;
+    let client = &server.client;
+
+    let results = client.simple_query(
+        "SELECT conname, pg_get_constraintdef(oid) AS def FROM pg_constraint \
+         WHERE conname IN ('books_check1', 'books_author_id_fkey', 'books_pkey') ORDER BY conname"
+    ).await.unwrap();
+    assert_eq!(column(&results, "conname"), vec!["books_author_id_fkey", "books_check1", "books_pkey"]);
+    assert_eq!(column(&results, "def"), vec![
+        "FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE CASCADE",
+        "CHECK ((length(title) > 0))",
+        "PRIMARY KEY (id)",
+    ]);
+}
+
+/// Test that pg_get_expr shows column defaults with PostgreSQL's typed literals
+#[tokio::test]
+async fn test_pg_get_expr_column_default() {
+    let server = setup_library().await;
+    let client = &server.client;
+
+    let results = client.simple_query(
+        "SELECT a.attname, pg_get_expr(d.adbin, d.adrelid) AS def \
+         FROM pg_class c \
+         JOIN pg_attribute a ON a.attrelid = c.oid \
+         JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum \
+         WHERE c.relname = 'books' AND a.attnum > 0"
+    ).await.unwrap();
+    assert_eq!(column(&results, "attname"), vec!["status"]);
+    assert_eq!(column(&results, "def"), vec!["'draft'::text"]);
+}
@@ -0,0 +1,97 @@
+mod common;
+use common::*;
+use bytes::Bytes;
+use futures::{SinkExt, TryStreamExt};
+use rust_decimal::Decimal;
+use std::str::FromStr;
+
+async fn copy_out(client: &tokio_postgres::Client, query: &str) -> Vec<u8> {
+    let stream = client.copy_out(query).await.unwrap();
+    let chunks: Vec<Bytes> = stream.try_collect().await.unwrap();
+    chunks.concat()
+}
+
+async fn copy_in(client: &tokio_postgres::Client, query: &str, data: Vec<u8>) -> u64 {
+    let sink = client.copy_in(query).await.unwrap();
+    futures::pin_mut!(sink);
+    sink.send(Bytes::from(data)).await.unwrap();
+    sink.finish().await.unwrap()
+}
+
+/// Test exporting a table with binary COPY and importing the data into another table
+#[tokio::test]
+async fn test_binary_copy_round_trip() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    for table in ["items", "items_copy"] {
+        client.batch_execute(&format!(
+            "CREATE TABLE {table} (id INTEGER PRIMARY KEY, price NUMERIC(10,2), added TIMESTAMP, \
+             name TEXT, flag BOOLEAN, payload BYTEA)"
+        )).await.unwrap();
+    }
+    client.batch_execute(
+        "INSERT INTO items VALUES \
+         (1, 19.99, '2024-03-01 12:34:56.789012', 'first', true, '\\x00ff10'), \
+         (2, -0.50, '1999-12-31 23:59:59', NULL, false, NULL), \
+         (3, NULL, NULL, 'tab\tand\\backslash', NULL, '\\x01')"
+    ).await.unwrap();
+
+    let data = copy_out(client, "COPY items TO STDOUT WITH (FORMAT binary)").await;
+    assert!(data.starts_with(b"PGCOPY\n\xff\r\n\0"));
+    assert!(data.ends_with(&(-1i16).to_be_bytes()));
+
+    let copied = copy_in(client, "COPY items_copy FROM STDIN WITH (FORMAT binary)", data).await;
+    assert_eq!(copied, 3);
+
+    let rows = client.query(
+        "SELECT id, price, added, name, flag, payload FROM items_copy ORDER BY id", &[]
+    ).await.unwrap();
+    assert_eq!(rows.len(), 3);
+
+    assert_eq!(rows[0].get::<_, i32>(0), 1);
+    assert_eq!(rows[0].get::<_, Option<Decimal>>(1), Some(Decimal::from_str("19.99").unwrap()));
+    let added = chrono::NaiveDateTime::parse_from_str("2024-03-01 12:34:56.789012", "%Y-%m-%d %H:%M:%S%.f").unwrap();
+    assert_eq!(rows[0].get::<_, Option<chrono::NaiveDateTime>>(2), Some(added));
+    assert_eq!(rows[0].get::<_, Option<&str>>(3), Some("first"));
+    assert_eq!(rows[0].get::<_, Option<bool>>(4), Some(true));
+    assert_eq!(rows[0].get::<_, Option<Vec<u8>>>(5), Some(vec![0x00, 0xff, 0x10]));
+
+    assert_eq!(rows[1].get::<_, Option<Decimal>>(1), Some(Decimal::from_str("-0.50").unwrap()));
+    assert_eq!(rows[1].get::<_, Option<&str>>(3), None);
+    assert_eq!(rows[1].get::<_, Option<bool>>(4), Some(false));
+    assert_eq!(rows[1].get::<_, Option<Vec<u8>>>(5), None);
+
+    assert_eq!(rows[2].get::<_, Option<Decimal>>(1), None);
+    assert_eq!(rows[2].get::<_, Option<chrono::NaiveDateTime>>(2), None);
+    assert_eq!(rows[2].get::<_, Option<&str>>(3), Some("tab\tand\\backslash"));
+    assert_eq!(rows[2].get::<_, Option<Vec<u8>>>(5), Some(vec![0x01]));
+
+    // Exporting the copy gives the same bytes again
+    let original = copy_out(client, "COPY items TO STDOUT (FORMAT binary)").await;
+    let again = copy_out(client, "COPY items_copy TO STDOUT (FORMAT binary)").await;
+    assert_eq!(original, again);
+}
+
+/// Test the text format and a column list
+#[tokio::test]
+async fn test_text_copy() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute("CREATE TABLE notes (id INTEGER, body TEXT, done BOOLEAN)").await.unwrap();
+    let copied = copy_in(client, "COPY notes (id, body) FROM STDIN", b"1\tline one\\nline two\n2\t\\N\n".to_vec()).await;
+    assert_eq!(copied, 2);
+
+    let data = copy_out(client, "COPY (SELECT id, body FROM notes ORDER BY id) TO STDOUT").await;
+    assert_eq!(data, b"1\tline one\\nline two\n2\t\\N\n");
+
+    // A bad row leaves the table as it was
+    let sink = client.copy_in("COPY notes FROM STDIN").await.unwrap();
+    futures::pin_mut!(sink);
+    sink.send(Bytes::from_static(b"3\tok\tt\nfour\tbad\tf\n")).await.unwrap();
+    let err = sink.finish().await.unwrap_err();
+    assert!(err.to_string().contains("invalid input syntax"), "unexpected error: {err}");
+    let count = client.query_one("SELECT count(*) FROM notes", &[]).await.unwrap();
+    assert_eq!(count.get::<_, i64>(0), 2);
+}
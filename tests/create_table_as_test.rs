@@ -0,0 +1,68 @@
+mod common;
+use common::*;
+
+/// Test that a table created from an aggregate query keeps PostgreSQL column types
+#[tokio::test]
+async fn test_create_table_as_aggregate() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE sales (id INTEGER PRIMARY KEY, title VARCHAR(100), qty INTEGER, price NUMERIC(10,2), sold_on DATE)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "INSERT INTO sales (id, title, qty, price, sold_on) VALUES (1, 'Dune', 2, 9.50, '2024-03-01');
+         INSERT INTO sales (id, title, qty, price, sold_on) VALUES (2, 'Dune', 3, 9.50, '2024-03-05');
+         INSERT INTO sales (id, title, qty, price, sold_on) VALUES (3, 'Emma', 1, 12.00, '2024-02-10');"
+    ).await.unwrap();
+
+    let messages = client.simple_query(
+        "CREATE TABLE top_books AS SELECT title, count(*) AS orders, sum(qty) AS copies, max(sold_on) AS last_sold FROM sales GROUP BY title"
+    ).await.unwrap();
+    let rows = messages.iter().find_map(|msg| match msg {
+        tokio_postgres::SimpleQueryMessage::CommandComplete(rows) => Some(*rows),
+        _ => None,
+    });
+    assert_eq!(rows, Some(2));
+
+    // The new columns decode with the inferred types
+    let rows = client.query("SELECT title, orders, copies, last_sold FROM top_books ORDER BY title", &[]).await.unwrap();
+    assert_eq!(rows.len(), 2);
+    assert_eq!(rows[0].get::<_, String>(0), "Dune");
+    assert_eq!(rows[0].get::<_, i64>(1), 2);
+    assert_eq!(rows[0].get::<_, i64>(2), 5);
+    assert_eq!(rows[0].get::<_, chrono::NaiveDate>(3), chrono::NaiveDate::from_ymd_opt(2024, 3, 5).unwrap());
+    assert_eq!(rows[1].get::<_, i64>(2), 1);
+}
+
+/// Test SELECT ... INTO and WITH NO DATA
+#[tokio::test]
+async fn test_select_into_and_no_data() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT)").await?;
+            db.execute("INSERT INTO books (id, title) VALUES (1, 'Dune'), (2, 'Emma')").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute("SELECT id, title INTO recent_books FROM books WHERE id = 1").await.unwrap();
+    let rows = client.query("SELECT id, title FROM recent_books", &[]).await.unwrap();
+    assert_eq!(rows.len(), 1);
+    assert_eq!(rows[0].get::<_, i32>(0), 1);
+    assert_eq!(rows[0].get::<_, String>(1), "Dune");
+
+    client.batch_execute("CREATE TABLE empty_books (book_id, book_title) AS SELECT id, title FROM books WITH NO DATA").await.unwrap();
+    let rows = client.query("SELECT book_id, book_title FROM empty_books", &[]).await.unwrap();
+    assert!(rows.is_empty());
+    client.batch_execute("INSERT INTO empty_books (book_id, book_title) VALUES (7, 'Persuasion')").await.unwrap();
+    let rows = client.query("SELECT book_id FROM empty_books", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i32>(0), 7);
+
+    // The table exists now
+    assert!(client.batch_execute("SELECT id INTO recent_books FROM books").await.is_err());
+    client.batch_execute("CREATE TABLE IF NOT EXISTS recent_books AS SELECT id FROM books").await.unwrap();
+}
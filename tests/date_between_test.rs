@@ -0,0 +1,76 @@
+mod common;
+use common::*;
+
+async fn setup_books() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, publication_date DATE)").await?;
+            db.execute("INSERT INTO books (id, title, publication_date) VALUES
+                (1, 'Pride and Prejudice', '1813-01-28'),
+                (2, 'Castle Rackrent', '1800-01-01'),
+                (3, 'Wieland', '1798-09-14'),
+                (4, 'Kim', '1901-10-01'),
+                (5, 'Lord Jim', '1900-12-31'),
+                (6, 'Middlemarch', '1871-12-01'),
+                (7, 'Ulysses', '1922-02-02')").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+/// Test BETWEEN on a date column with text, ::date and mixed bounds, including the boundary dates
+#[tokio::test]
+async fn test_date_between_literal_formats() {
+    let server = setup_books().await;
+    let client = &server.client;
+
+    for query in [
+        "SELECT id FROM books WHERE publication_date BETWEEN '1800-01-01' AND '1900-12-31' ORDER BY id",
+        "SELECT id FROM books WHERE publication_date BETWEEN '1800-01-01'::date AND '1900-12-31'::date ORDER BY id",
+        "SELECT id FROM books WHERE publication_date BETWEEN '1800-01-01'::date AND '1900-12-31' ORDER BY id",
+        "SELECT id FROM books b WHERE b.publication_date BETWEEN '1800-01-01' AND '1900-12-31'::date ORDER BY id",
+        "SELECT id FROM books WHERE publication_date BETWEEN SYMMETRIC '1900-12-31' AND '1800-01-01' ORDER BY id",
+    ] {
+        let results = client.simple_query(query).await.unwrap();
+        assert_eq!(column(&results, "id"), vec!["1", "2", "5", "6"], "{query}");
+    }
+
+    let results = client.simple_query(
+        "SELECT id FROM books WHERE publication_date NOT BETWEEN '1800-01-01' AND '1900-12-31'::date ORDER BY id"
+    ).await.unwrap();
+    assert_eq!(column(&results, "id"), vec!["3", "4", "7"]);
+
+    let rows = client.query(
+        "SELECT id FROM books WHERE publication_date BETWEEN '1800-01-01' AND '1900-12-31'::date ORDER BY id",
+        &[],
+    ).await.unwrap();
+    let extended: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(extended, vec![1, 2, 5, 6]);
+}
+
+/// Test comparison operators between a date column and text or ::date literals, on either side
+#[tokio::test]
+async fn test_date_comparison_literal_formats() {
+    let server = setup_books().await;
+    let client = &server.client;
+
+    let results = client.simple_query(
+        "SELECT id FROM books WHERE publication_date >= '1900-12-31' ORDER BY id"
+    ).await.unwrap();
+    assert_eq!(column(&results, "id"), vec!["4", "5", "7"]);
+
+    let results = client.simple_query(
+        "SELECT id FROM books WHERE publication_date < '1800-01-01'::date ORDER BY id"
+    ).await.unwrap();
+    assert_eq!(column(&results, "id"), vec!["3"]);
+
+    let results = client.simple_query(
+        "SELECT id FROM books WHERE '1813-01-28' = publication_date"
+    ).await.unwrap();
+    assert_eq!(column(&results, "id"), vec!["1"]);
+
+    client.simple_query("DELETE FROM books WHERE publication_date > '1901-01-01'").await.unwrap();
+    let results = client.simple_query("SELECT id FROM books ORDER BY id").await.unwrap();
+    assert_eq!(column(&results, "id"), vec!["1", "2", "3", "5", "6"]);
+}
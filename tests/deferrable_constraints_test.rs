@@ -0,0 +1,118 @@
+mod common;
+use common::*;
+
+/// Test mutually-referential rows inserted within one transaction using
+/// DEFERRABLE INITIALLY DEFERRED foreign keys
+#[tokio::test]
+async fn test_initially_deferred_circular_references() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE authors (
+                id INTEGER PRIMARY KEY,
+                name TEXT NOT NULL,
+                featured_book_id INTEGER REFERENCES books(id) DEFERRABLE INITIALLY DEFERRED
+            )").await?;
+
+            db.execute("CREATE TABLE books (
+                id INTEGER PRIMARY KEY,
+                title TEXT NOT NULL,
+                author_id INTEGER NOT NULL REFERENCES authors(id) DEFERRABLE INITIALLY DEFERRED
+            )").await?;
+
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    client.batch_execute(
+        "BEGIN;
+         INSERT INTO authors (id, name, featured_book_id) VALUES (1, 'Ursula', 10);
+         INSERT INTO books (id, title, author_id) VALUES (10, 'The Dispossessed', 1);
+         COMMIT;"
+    ).await.unwrap();
+
+    let rows = client.query(
+        "SELECT a.name, b.title FROM authors a JOIN books b ON b.id = a.featured_book_id",
+        &[]
+    ).await.unwrap();
+    assert_eq!(rows.len(), 1);
+    assert_eq!(rows[0].get::<_, String>("name"), "Ursula");
+    assert_eq!(rows[0].get::<_, String>("title"), "The Dispossessed");
+}
+
+/// Test that a deferred violation is reported with SQLSTATE 23503 at COMMIT
+#[tokio::test]
+async fn test_deferred_violation_fails_at_commit() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT NOT NULL)").await?;
+            db.execute("CREATE TABLE books (
+                id INTEGER PRIMARY KEY,
+                author_id INTEGER NOT NULL REFERENCES authors(id) DEFERRABLE INITIALLY DEFERRED
+            )").await?;
+
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    client.batch_execute("BEGIN").await.unwrap();
+    // Accepted while the transaction is open
+    client.batch_execute("INSERT INTO books (id, author_id) VALUES (1, 42)").await.unwrap();
+
+    let err = client.batch_execute("COMMIT").await.unwrap_err();
+    let db_err = err.as_db_error().expect("Expected a database error");
+    assert_eq!(db_err.code(), &tokio_postgres::error::SqlState::FOREIGN_KEY_VIOLATION);
+    assert_eq!(db_err.constraint(), Some("books_author_id_fkey"));
+    assert_eq!(db_err.detail(), Some("Key (author_id)=(42) is not present in table \"authors\"."));
+
+    // The failed COMMIT rolled the transaction back
+    let rows = client.query("SELECT COUNT(*) FROM books", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i64>(0), 0);
+}
+
+/// Test SET CONSTRAINTS ALL DEFERRED / IMMEDIATE on regular foreign keys
+#[tokio::test]
+async fn test_set_constraints() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT NOT NULL)").await?;
+            db.execute("CREATE TABLE books (
+                id INTEGER PRIMARY KEY,
+                author_id INTEGER NOT NULL REFERENCES authors(id)
+            )").await?;
+
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    // Child before parent succeeds once constraints are deferred
+    client.batch_execute(
+        "BEGIN;
+         SET CONSTRAINTS ALL DEFERRED;
+         INSERT INTO books (id, author_id) VALUES (1, 7);
+         INSERT INTO authors (id, name) VALUES (7, 'Octavia');
+         COMMIT;"
+    ).await.unwrap();
+
+    let rows = client.query("SELECT COUNT(*) FROM books", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i64>(0), 1);
+
+    // Switching back to IMMEDIATE checks the pending rows right away
+    client.batch_execute("BEGIN").await.unwrap();
+    client.batch_execute("SET CONSTRAINTS ALL DEFERRED").await.unwrap();
+    client.batch_execute("INSERT INTO books (id, author_id) VALUES (2, 99)").await.unwrap();
+
+    let err = client.batch_execute("SET CONSTRAINTS ALL IMMEDIATE").await.unwrap_err();
+    let db_err = err.as_db_error().expect("Expected a database error");
+    assert_eq!(db_err.code(), &tokio_postgres::error::SqlState::FOREIGN_KEY_VIOLATION);
+
+    client.batch_execute("ROLLBACK").await.unwrap();
+
+    let rows = client.query("SELECT COUNT(*) FROM books", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i64>(0), 1);
+}
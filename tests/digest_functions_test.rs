@@ -0,0 +1,57 @@
+mod common;
+use common::*;
+
+/// Test md5() on text columns and parameters
+#[tokio::test]
+async fn test_md5() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);
+         INSERT INTO users VALUES (1, 'alice@example.com');"
+    ).await.unwrap();
+
+    let row = client.query_one("SELECT md5(email) AS etag FROM users WHERE id = 1", &[]).await.unwrap();
+    let etag: String = row.get("etag");
+    assert_eq!(etag, "c160f8cc69a4f0bf2b0362752353d060");
+
+    let row = client.query_one("SELECT md5($1) AS hash", &[&"password"]).await.unwrap();
+    let hash: String = row.get("hash");
+    assert_eq!(hash, "5f4dcc3b5aa765d61d8327deb882cf99");
+}
+
+/// Test digest() and hmac() with the supported algorithms
+#[tokio::test]
+async fn test_digest_and_hmac() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    for (algorithm, expected) in [
+        ("md5", "900150983cd24fb0d6963f7d28e17f72"),
+        ("sha1", "a9993e364706816aba3e25717850c26c9cd0d89d"),
+        ("sha256", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"),
+        ("sha512", "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"),
+    ] {
+        let row = client.query_one(
+            &format!("SELECT encode(digest('abc', '{algorithm}'), 'hex') AS hex, digest('abc', '{algorithm}') AS raw"),
+            &[],
+        ).await.unwrap();
+        let hex: String = row.get("hex");
+        let raw: Vec<u8> = row.get("raw");
+        assert_eq!(hex, expected, "{algorithm}");
+        assert_eq!(raw.len(), expected.len() / 2, "{algorithm}");
+    }
+
+    let row = client.query_one(
+        "SELECT encode(hmac('The quick brown fox jumps over the lazy dog', 'key', 'sha256'), 'hex') AS signature",
+        &[],
+    ).await.unwrap();
+    let signature: String = row.get("signature");
+    assert_eq!(signature, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8");
+
+    let err = client.simple_query("SELECT digest('abc', 'md4')").await.unwrap_err();
+    let db_error = err.as_db_error().unwrap();
+    assert_eq!(db_error.code().code(), "39000");
+    assert_eq!(db_error.message(), "Cannot use \"md4\": No such hash algorithm");
+}
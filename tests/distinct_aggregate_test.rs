@@ -0,0 +1,81 @@
+mod common;
+use common::*;
+
+async fn setup_authors() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT, nationality TEXT, tag TEXT)").await?;
+            db.execute("INSERT INTO authors (id, name, nationality, tag) VALUES
+                (1, 'Austen', 'British', 'classic'),
+                (2, 'Tolstoy', 'Russian', 'classic'),
+                (3, 'Orwell', 'British', 'dystopia'),
+                (4, 'Chekhov', 'Russian', 'drama'),
+                (5, 'Herbert', 'American', 'scifi'),
+                (6, 'Huxley', 'British', 'dystopia'),
+                (7, 'Anonymous', NULL, 'classic')").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+/// Split an array value into its elements, whether sent as JSON or PostgreSQL array text
+fn elements(array: &str) -> Vec<String> {
+    array.trim_matches(|c| matches!(c, '[' | ']' | '{' | '}'))
+        .split(',')
+        .map(|element| element.trim().trim_matches('"').to_string())
+        .collect()
+}
+
+/// Test that DISTINCT deduplicates inside string_agg, sorted by default or by the ORDER BY given
+#[tokio::test]
+async fn test_string_agg_distinct() {
+    let server = setup_authors().await;
+    let client = &server.client;
+
+    let results = client.simple_query(
+        "SELECT string_agg(DISTINCT nationality, ',') AS nationalities FROM authors"
+    ).await.unwrap();
+    assert_eq!(column(&results, "nationalities"), vec!["American,British,Russian"]);
+
+    let results = client.simple_query(
+        "SELECT tag, string_agg(DISTINCT nationality, ', ' ORDER BY nationality DESC) AS nationalities \
+         FROM authors GROUP BY tag ORDER BY tag"
+    ).await.unwrap();
+    assert_eq!(column(&results, "tag"), vec!["classic", "drama", "dystopia", "scifi"]);
+    assert_eq!(column(&results, "nationalities"), vec!["Russian, British", "Russian", "British", "American"]);
+}
+
+/// Test that DISTINCT deduplicates inside array_agg and combines with ORDER BY
+#[tokio::test]
+async fn test_array_agg_distinct() {
+    let server = setup_authors().await;
+    let client = &server.client;
+
+    let results = client.simple_query("SELECT array_agg(DISTINCT tag) AS tags FROM authors").await.unwrap();
+    assert_eq!(
+        column(&results, "tags").iter().map(|tags| elements(tags)).collect::<Vec<_>>(),
+        vec![vec!["classic", "drama", "dystopia", "scifi"]]
+    );
+
+    let results = client.simple_query(
+        "SELECT array_agg(DISTINCT tag ORDER BY tag DESC) AS tags FROM authors WHERE nationality = 'British'"
+    ).await.unwrap();
+    assert_eq!(
+        column(&results, "tags").iter().map(|tags| elements(tags)).collect::<Vec<_>>(),
+        vec![vec!["dystopia", "classic"]]
+    );
+}
+
+/// Test that DISTINCT aggregates can only be ordered by their arguments, as in PostgreSQL
+#[tokio::test]
+async fn test_distinct_aggregate_order_by_must_match_arguments() {
+    let server = setup_authors().await;
+    let client = &server.client;
+
+    let err = client.simple_query("SELECT array_agg(DISTINCT tag ORDER BY id) FROM authors").await.unwrap_err();
+    assert!(err.to_string().contains("ORDER BY expressions must appear in argument list"), "{err}");
+
+    let err = client.simple_query("SELECT string_agg(DISTINCT tag, ',' ORDER BY name) FROM authors").await.unwrap_err();
+    assert!(err.to_string().contains("ORDER BY expressions must appear in argument list"), "{err}");
+}
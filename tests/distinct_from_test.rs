@@ -0,0 +1,38 @@
+mod common;
+use common::*;
+
+/// Test the NULL-safe IS DISTINCT FROM / IS NOT DISTINCT FROM comparisons
+#[tokio::test]
+async fn test_is_distinct_from() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE pairs (id INTEGER PRIMARY KEY, a INTEGER, b INTEGER)").await?;
+            db.execute("INSERT INTO pairs (id, a, b) VALUES (1, NULL, NULL), (2, NULL, 5), (3, 5, 5), (4, 5, 6)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let ids: Vec<i32> = client.query("SELECT id FROM pairs WHERE a IS DISTINCT FROM b ORDER BY id", &[]).await.unwrap().iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![2, 4]);
+
+    let ids: Vec<i32> = client.query("SELECT id FROM pairs WHERE a IS NOT DISTINCT FROM b ORDER BY id", &[]).await.unwrap().iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![1, 3]);
+
+    // NULL vs NULL, NULL vs value and value vs value as expressions
+    let messages = client.simple_query(
+        "SELECT CASE WHEN NULL IS DISTINCT FROM NULL THEN 1 ELSE 0 END, \
+                CASE WHEN NULL IS DISTINCT FROM 1 THEN 1 ELSE 0 END, \
+                CASE WHEN 1 IS DISTINCT FROM 1 THEN 1 ELSE 0 END, \
+                CASE WHEN 1 is not distinct from 2 THEN 1 ELSE 0 END"
+    ).await.unwrap();
+    assert_eq!(rows(&messages), vec![some(&["0", "1", "0", "0"])]);
+
+    // Parameters on either side
+    let ids: Vec<i32> = client.query("SELECT id FROM pairs WHERE b IS NOT DISTINCT FROM $1 ORDER BY id", &[&5i32]).await.unwrap().iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![2, 3]);
+
+    // Typical upsert guard: only touch rows whose value actually changes
+    let updated = client.execute("UPDATE pairs SET b = 5 WHERE b IS DISTINCT FROM 5", &[]).await.unwrap();
+    assert_eq!(updated, 2);
+}
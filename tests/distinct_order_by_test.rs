@@ -0,0 +1,43 @@
+mod common;
+use common::*;
+use tokio_postgres::error::SqlState;
+
+/// Test that SELECT DISTINCT ordered by an unselected column fails like PostgreSQL
+#[tokio::test]
+async fn test_distinct_order_by_unselected_column() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE posts (id INTEGER PRIMARY KEY, author TEXT, created_at TEXT);
+         INSERT INTO posts (author, created_at) VALUES ('bob', '2024-01-03'), ('alice', '2024-01-02'), ('bob', '2024-01-01');"
+    ).await.unwrap();
+
+    let err = client.simple_query("SELECT DISTINCT author FROM posts ORDER BY created_at").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::INVALID_COLUMN_REFERENCE), "{err}");
+
+    let err = client.query("SELECT DISTINCT author FROM posts WHERE id > $1 ORDER BY created_at", &[&0i32]).await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::INVALID_COLUMN_REFERENCE), "{err}");
+}
+
+/// Test that ordering by selected columns, aliases and positions still works
+#[tokio::test]
+async fn test_distinct_order_by_selected_column() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE posts (id INTEGER PRIMARY KEY, author TEXT, created_at TEXT);
+         INSERT INTO posts (author, created_at) VALUES ('bob', '2024-01-03'), ('alice', '2024-01-02'), ('bob', '2024-01-01');"
+    ).await.unwrap();
+
+    for query in [
+        "SELECT DISTINCT author FROM posts ORDER BY author",
+        "SELECT DISTINCT author AS name FROM posts ORDER BY name",
+        "SELECT DISTINCT author FROM posts ORDER BY 1",
+    ] {
+        let rows = client.query(query, &[]).await.unwrap();
+        let authors: Vec<String> = rows.iter().map(|row| row.get(0)).collect();
+        assert_eq!(authors, vec!["alice", "bob"], "{query}");
+    }
+}
@@ -0,0 +1,50 @@
+mod common;
+use common::*;
+
+/// Test that integer division truncates and numeric/float division keeps the fraction
+#[tokio::test]
+async fn test_integer_and_numeric_division() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE orders (id INTEGER PRIMARY KEY, qty INTEGER, boxes INTEGER, weight DOUBLE PRECISION)").await?;
+            db.execute("INSERT INTO orders (id, qty, boxes, weight) VALUES (1, 7, 2, 7.5), (2, -7, 2, 3.0)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    assert_eq!(first_value(client, "SELECT 7 / 2").await.as_deref(), Some("3"));
+    assert_eq!(first_value(client, "SELECT -7 / 2").await.as_deref(), Some("-3"));
+    assert_eq!(first_value(client, "SELECT 7.0 / 2").await.as_deref(), Some("3.5"));
+    assert_eq!(first_value(client, "SELECT (1 + 2) * 10 / 4").await.as_deref(), Some("7"));
+    assert_eq!(first_value(client, "SELECT '7 / 2'").await.as_deref(), Some("7 / 2"));
+
+    assert_eq!(first_value(client, "SELECT qty / boxes FROM orders WHERE id = 1").await.as_deref(), Some("3"));
+    assert_eq!(first_value(client, "SELECT qty / boxes FROM orders WHERE id = 2").await.as_deref(), Some("-3"));
+
+    let rows = client.query("SELECT weight / boxes AS per_box FROM orders WHERE id = 1", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, f64>(0), 3.75);
+}
+
+/// Test that dividing by zero fails with SQLSTATE 22012 unless guarded with NULLIF
+#[tokio::test]
+async fn test_division_by_zero() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE stats (id INTEGER PRIMARY KEY, hits INTEGER, visits INTEGER)").await?;
+            db.execute("INSERT INTO stats (id, hits, visits) VALUES (1, 5, 0), (2, 6, 3)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    for query in ["SELECT 1 / 0", "SELECT 1.5 / 0", "SELECT hits / visits FROM stats WHERE id = 1"] {
+        let err = client.simple_query(query).await.unwrap_err();
+        assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::DIVISION_BY_ZERO), "{query}");
+    }
+
+    // The usual guard turns a zero divisor into NULL
+    assert_eq!(first_value(client, "SELECT hits / NULLIF(visits, 0) FROM stats WHERE id = 1").await, None);
+    assert_eq!(first_value(client, "SELECT hits / NULLIF(visits, 0) FROM stats WHERE id = 2").await.as_deref(), Some("2"));
+    assert_eq!(first_value(client, "SELECT 10 / NULL").await, None);
+}
@@ -0,0 +1,56 @@
+mod common;
+use common::*;
+use tokio_postgres::error::SqlState;
+
+/// Test a DO block made of plain statements, as migration tools emit them
+#[tokio::test]
+async fn test_do_block_plain_statements() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.simple_query(
+        "DO $$
+         BEGIN
+             INSERT INTO notes (id, body) VALUES (1, 'a');
+             INSERT INTO notes (id, body) VALUES (2, 'b;c');
+         END
+         $$;"
+    ).await.unwrap();
+
+    client.simple_query("DO LANGUAGE sql $$UPDATE notes SET body = body || '!' WHERE id = 1$$").await.unwrap();
+
+    let rows = client.query("SELECT body FROM notes ORDER BY id", &[]).await.unwrap();
+    let bodies: Vec<&str> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(bodies, vec!["a!", "b;c"]);
+}
+
+/// Test that PL/pgSQL control flow is rejected before anything runs
+#[tokio::test]
+async fn test_do_block_control_flow_unsupported() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let err = client.simple_query(
+        "DO $$
+         BEGIN
+             INSERT INTO notes (id, body) VALUES (1, 'a');
+             IF NOT EXISTS (SELECT 1 FROM notes WHERE id = 2) THEN
+                 INSERT INTO notes (id, body) VALUES (2, 'b');
+             END IF;
+         END $$"
+    ).await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::FEATURE_NOT_SUPPORTED), "{err}");
+
+    let rows = client.query("SELECT COUNT(*) FROM notes", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i64>(0), 0);
+}
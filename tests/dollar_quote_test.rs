@@ -0,0 +1,31 @@
+mod common;
+use common::*;
+/// Test dollar-quoted bodies holding semicolons, quotes, comment markers and nested tags,
+/// in a batch that has to be split around them
+#[tokio::test]
+async fn test_dollar_quoted_literals() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE snippets (id INTEGER PRIMARY KEY, body TEXT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.simple_query(
+        "INSERT INTO snippets (id, body) VALUES (1, $$SELECT 1; SELECT 'two';$$);
+         INSERT INTO snippets (id, body) VALUES (2, $fn$BEGIN RETURN $$a;b$$; END -- done$fn$);
+         INSERT INTO snippets (id, body) VALUES (3, $$$$)"
+    ).await.unwrap();
+
+    let results = client.simple_query("SELECT id, body FROM snippets ORDER BY id").await.unwrap();
+    assert_eq!(column(&results, "body"), vec![
+        "SELECT 1; SELECT 'two';",
+        "BEGIN RETURN $$a;b$$; END -- done",
+        "",
+    ]);
+
+    // Extended protocol, next to a real parameter
+    let rows = client.query("SELECT $1::text || $x$;it's$x$ AS joined", &[&"a"]).await.unwrap();
+    assert_eq!(rows[0].get::<_, &str>(0), "a;it's");
+}
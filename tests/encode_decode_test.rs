@@ -0,0 +1,59 @@
+mod common;
+use common::*;
+
+/// Test round-tripping binary data through hex and base64
+#[tokio::test]
+async fn test_encode_decode_round_trip() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.execute("CREATE TABLE tokens (id INTEGER PRIMARY KEY, secret BYTEA)", &[]).await.unwrap();
+    let secret: Vec<u8> = vec![0x00, 0x01, 0x7f, 0x80, 0xde, 0xad, 0xbe, 0xef, 0xff];
+    client.execute("INSERT INTO tokens (id, secret) VALUES (1, $1)", &[&secret]).await.unwrap();
+
+    let row = client.query_one(
+        "SELECT encode(secret, 'hex') AS hex, encode(secret, 'base64') AS b64 FROM tokens WHERE id = 1",
+        &[],
+    ).await.unwrap();
+    let hex: String = row.get("hex");
+    let b64: String = row.get("b64");
+    assert_eq!(hex, "00017f80deadbeefff");
+    assert_eq!(b64, "AAF/gN6tvu//");
+
+    let row = client.query_one("SELECT decode($1, 'hex') AS from_hex, decode($2, 'base64') AS from_b64", &[&hex, &b64]).await.unwrap();
+    let from_hex: Vec<u8> = row.get("from_hex");
+    let from_b64: Vec<u8> = row.get("from_b64");
+    assert_eq!(from_hex, secret);
+    assert_eq!(from_b64, secret);
+
+    // Decoded values can be stored and compared as bytea
+    client.execute("INSERT INTO tokens (id, secret) VALUES (2, decode('aGVsbG8gd29ybGQ=', 'base64'))", &[]).await.unwrap();
+    let row = client.query_one("SELECT encode(secret, 'escape') AS text FROM tokens WHERE id = 2", &[]).await.unwrap();
+    let text: String = row.get("text");
+    assert_eq!(text, "hello world");
+}
+
+/// Test NULL inputs and malformed data
+#[tokio::test]
+async fn test_encode_decode_nulls_and_errors() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let row = client.query_one("SELECT encode(NULL, 'hex') AS encoded, decode(NULL, 'base64') AS decoded", &[]).await.unwrap();
+    let encoded: Option<String> = row.get("encoded");
+    let decoded: Option<Vec<u8>> = row.get("decoded");
+    assert!(encoded.is_none());
+    assert!(decoded.is_none());
+
+    for (query, message) in [
+        ("SELECT decode('not base64!', 'base64')", "invalid symbol"),
+        ("SELECT decode('aGVsbG8', 'base64')", "invalid base64 end sequence"),
+        ("SELECT decode('abc', 'hex')", "invalid hexadecimal data"),
+        ("SELECT encode(decode('00', 'hex'), 'rot13')", "unrecognized encoding"),
+    ] {
+        let err = client.simple_query(query).await.unwrap_err();
+        let db_error = err.as_db_error().unwrap();
+        assert_eq!(db_error.code().code(), "22023", "{query}");
+        assert!(db_error.message().contains(message), "{query}: {}", db_error.message());
+    }
+}
@@ -0,0 +1,44 @@
+mod common;
+use common::*;
+
+/// Test that an EXCLUDE constraint rejects overlapping reservations of the same room
+#[tokio::test]
+async fn test_exclusion_constraint_prevents_overlaps() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE reservations (
+            id SERIAL PRIMARY KEY,
+            room INTEGER NOT NULL,
+            during TSRANGE NOT NULL,
+            EXCLUDE USING gist (room WITH =, during WITH &&)
+        )"
+    ).await.unwrap();
+
+    client.batch_execute(
+        "INSERT INTO reservations (room, during) VALUES (101, '[2024-01-01 10:00,2024-01-01 12:00)');
+         INSERT INTO reservations (room, during) VALUES (101, '[2024-01-01 12:00,2024-01-01 13:00)');
+         INSERT INTO reservations (room, during) VALUES (102, '[2024-01-01 10:00,2024-01-01 12:00)');"
+    ).await.unwrap();
+
+    let err = client.simple_query(
+        "INSERT INTO reservations (room, during) VALUES (101, '[2024-01-01 11:00,2024-01-01 11:30)')"
+    ).await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::EXCLUSION_VIOLATION));
+    let db_error = err.as_db_error().unwrap();
+    assert_eq!(db_error.constraint(), Some("reservations_room_during_excl"));
+    assert!(db_error.detail().unwrap().contains("conflicts with existing key"), "{db_error:?}");
+
+    // Updating into an occupied slot is rejected as well
+    let err = client.simple_query(
+        "UPDATE reservations SET room = 101 WHERE room = 102"
+    ).await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::EXCLUSION_VIOLATION));
+
+    assert_eq!(first_value(client, "SELECT COUNT(*) FROM reservations").await.as_deref(), Some("3"));
+    assert_eq!(
+        simple_values(client, "SELECT conname FROM pg_constraint WHERE contype = 'x'").await,
+        vec!["reservations_room_during_excl"]
+    );
+}
@@ -0,0 +1,86 @@
+mod common;
+use common::*;
+
+async fn ids(client: &tokio_postgres::Client, query: &str) -> Vec<i32> {
+    client.query(query, &[]).await.unwrap().iter().map(|row| row.get(0)).collect()
+}
+
+/// Test that LIMIT/OFFSET and OFFSET ... ROWS FETCH FIRST ... ROWS ONLY return the same pages
+#[tokio::test]
+async fn test_fetch_first_pagination() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE items (id INTEGER PRIMARY KEY, score INTEGER)").await?;
+            let rows: Vec<String> = (1..=100).map(|i| format!("({i}, {})", i / 10)).collect();
+            db.execute(&format!("INSERT INTO items (id, score) VALUES {}", rows.join(", "))).await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let limit_page = ids(client, "SELECT id FROM items ORDER BY id LIMIT 20 OFFSET 40").await;
+    assert_eq!(limit_page, (41..=60).collect::<Vec<_>>());
+    assert_eq!(ids(client, "SELECT id FROM items ORDER BY id OFFSET 40 ROWS FETCH FIRST 20 ROWS ONLY").await, limit_page);
+    assert_eq!(ids(client, "SELECT id FROM items ORDER BY id OFFSET 40 ROWS FETCH NEXT 20 ROWS ONLY").await, limit_page);
+
+    assert_eq!(ids(client, "SELECT id FROM items ORDER BY id FETCH FIRST ROW ONLY").await, vec![1]);
+    assert_eq!(ids(client, "SELECT id FROM items ORDER BY id OFFSET 97 ROWS").await, vec![98, 99, 100]);
+
+    // Simple protocol takes the same path
+    let results = client.simple_query("SELECT id FROM items ORDER BY id OFFSET 40 ROWS FETCH FIRST 20 ROWS ONLY").await.unwrap();
+    assert_eq!(rows(&results).len(), 20);
+}
+
+/// Test that WITH TIES extends the page with the rows tied with its last row
+#[tokio::test]
+async fn test_fetch_first_with_ties() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE players (id INTEGER PRIMARY KEY, score INTEGER)").await?;
+            db.execute("INSERT INTO players (id, score) VALUES (1, 90), (2, 80), (3, 80), (4, 80), (5, 70)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let mut top = ids(client, "SELECT id FROM players ORDER BY score DESC FETCH FIRST 2 ROWS WITH TIES").await;
+    top.sort();
+    assert_eq!(top, vec![1, 2, 3, 4]);
+
+    let only = ids(client, "SELECT id FROM players ORDER BY score DESC FETCH FIRST 2 ROWS ONLY").await;
+    assert_eq!(only.len(), 2);
+
+    // Fewer rows than requested: everything matching is returned
+    let mut all = ids(client, "SELECT id FROM players WHERE score < 85 ORDER BY score FETCH FIRST 10 ROWS WITH TIES").await;
+    all.sort();
+    assert_eq!(all, vec![2, 3, 4, 5]);
+}
+
+/// Test that LIMIT ALL returns every row and a negative OFFSET is rejected
+#[tokio::test]
+async fn test_limit_all_and_negative_offset() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE items (id INTEGER PRIMARY KEY)").await?;
+            let rows: Vec<String> = (1..=10).map(|i| format!("({i})")).collect();
+            db.execute(&format!("INSERT INTO items (id) VALUES {}", rows.join(", "))).await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    assert_eq!(ids(client, "SELECT id FROM items ORDER BY id LIMIT ALL").await, (1..=10).collect::<Vec<_>>());
+    assert_eq!(ids(client, "SELECT id FROM items ORDER BY id LIMIT ALL OFFSET 7").await, vec![8, 9, 10]);
+    let results = client.simple_query("SELECT id FROM items limit all").await.unwrap();
+    assert_eq!(rows(&results).len(), 10);
+
+    for query in [
+        "SELECT id FROM items ORDER BY id LIMIT 5 OFFSET -5",
+        "SELECT id FROM items ORDER BY id OFFSET -1 ROWS FETCH FIRST 2 ROWS ONLY",
+    ] {
+        let err = client.query(query, &[]).await.unwrap_err();
+        assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::INVALID_ROW_COUNT_IN_RESULT_OFFSET_CLAUSE), "{query}");
+        let err = client.simple_query(query).await.unwrap_err();
+        assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::INVALID_ROW_COUNT_IN_RESULT_OFFSET_CLAUSE), "{query}");
+    }
+}
@@ -0,0 +1,66 @@
+mod common;
+use common::*;
+
+/// Test inserting Infinity, -Infinity and NaN literals and ordering a column holding them
+#[tokio::test]
+async fn test_special_float_literals() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE readings (id INTEGER PRIMARY KEY, value DOUBLE PRECISION);
+         INSERT INTO readings (id, value) VALUES (1, 1.5), (2, 'Infinity'), (3, '-Infinity'), (4, 'NaN'), (5, -2);"
+    ).await.unwrap();
+
+    // -Infinity sorts first and NaN sorts after every other value, as in PostgreSQL
+    assert_eq!(simple_values(client, "SELECT value FROM readings ORDER BY value").await, vec![
+        "-Infinity",
+        "-2",
+        "1.5",
+        "Infinity",
+        "NaN",
+    ]);
+
+    // Binary results decode to the special values
+    let rows = client.query("SELECT id, value FROM readings ORDER BY value DESC", &[]).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![4, 2, 1, 5, 3]);
+    let values: Vec<f64> = rows.iter().map(|row| row.get(1)).collect();
+    assert!(values[0].is_nan());
+    assert_eq!(values[1], f64::INFINITY);
+    assert_eq!(values[4], f64::NEG_INFINITY);
+
+    // NaN equals NaN and is greater than any number
+    assert_eq!(simple_values(client, "SELECT id FROM readings WHERE value = 'NaN'").await, vec!["4"]);
+    assert_eq!(simple_values(client, "SELECT id FROM readings WHERE value > 1000 ORDER BY id").await, vec!["2", "4"]);
+}
+
+/// Test casting the special values and passing them as parameters
+#[tokio::test]
+async fn test_special_float_casts_and_parameters() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    assert_eq!(simple_values(client, "SELECT 'Infinity'::float8").await, vec!["Infinity"]);
+    assert_eq!(simple_values(client, "SELECT CAST('-infinity' AS DOUBLE PRECISION)").await, vec!["-Infinity"]);
+    assert_eq!(simple_values(client, "SELECT 'NaN'::float8").await, vec!["NaN"]);
+
+    client.execute("CREATE TABLE measurements (id INTEGER PRIMARY KEY, value DOUBLE PRECISION)", &[]).await.unwrap();
+    for (id, value) in [(1, f64::INFINITY), (2, f64::NAN), (3, 0.5), (4, f64::NEG_INFINITY)] {
+        client.execute("INSERT INTO measurements (id, value) VALUES ($1, $2)", &[&id, &value]).await.unwrap();
+    }
+
+    let rows = client.query("SELECT value FROM measurements ORDER BY id", &[]).await.unwrap();
+    let values: Vec<Option<f64>> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(values[0], Some(f64::INFINITY));
+    assert!(values[1].is_some_and(f64::is_nan));
+    assert_eq!(values[2], Some(0.5));
+    assert_eq!(values[3], Some(f64::NEG_INFINITY));
+
+    assert_eq!(simple_values(client, "SELECT id FROM measurements ORDER BY value").await, vec![
+        "4",
+        "3",
+        "1",
+        "2",
+    ]);
+}
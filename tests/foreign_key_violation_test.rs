@@ -0,0 +1,65 @@
+mod common;
+use common::*;
+
+async fn setup_library() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT NOT NULL)").await?;
+            db.execute("CREATE TABLE books (
+                id INTEGER PRIMARY KEY,
+                title TEXT NOT NULL,
+                author_id INTEGER NOT NULL REFERENCES authors(id)
+            )").await?;
+
+            db.execute("INSERT INTO authors (id, name) VALUES (1, 'Ursula K. Le Guin')").await?;
+            db.execute("INSERT INTO books (id, title, author_id) VALUES (1, 'The Lathe of Heaven', 1)").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+/// Test inserting a book whose author does not exist
+#[tokio::test]
+async fn test_insert_orphan_reports_foreign_key_violation() {
+    let server = setup_library().await;
+    let client = &server.client;
+
+    let err = client.execute(
+        "INSERT INTO books (id, title, author_id) VALUES (2, 'Orphan', 999)",
+        &[]
+    ).await.unwrap_err();
+
+    let db_err = err.as_db_error().expect("Expected a database error");
+    assert_eq!(db_err.code(), &tokio_postgres::error::SqlState::FOREIGN_KEY_VIOLATION);
+    assert_eq!(
+        db_err.message(),
+        "insert or update on table \"books\" violates foreign key constraint \"books_author_id_fkey\""
+    );
+    assert_eq!(db_err.constraint(), Some("books_author_id_fkey"));
+    assert_eq!(db_err.detail(), Some("Key (author_id)=(999) is not present in table \"authors\"."));
+
+    let rows = client.query("SELECT COUNT(*) FROM books", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i64>(0), 1);
+}
+
+/// Test deleting an author that books still reference
+#[tokio::test]
+async fn test_delete_parent_reports_foreign_key_violation() {
+    let server = setup_library().await;
+    let client = &server.client;
+
+    let err = client.batch_execute("DELETE FROM authors WHERE id = 1").await.unwrap_err();
+
+    let db_err = err.as_db_error().expect("Expected a database error");
+    assert_eq!(db_err.code(), &tokio_postgres::error::SqlState::FOREIGN_KEY_VIOLATION);
+    assert_eq!(
+        db_err.message(),
+        "update or delete on table \"authors\" violates foreign key constraint \"books_author_id_fkey\" on table \"books\""
+    );
+    assert_eq!(db_err.constraint(), Some("books_author_id_fkey"));
+    assert_eq!(db_err.detail(), Some("Key (id)=(1) is still referenced from table \"books\"."));
+
+    let rows = client.query("SELECT COUNT(*) FROM authors", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i64>(0), 1);
+}
@@ -0,0 +1,33 @@
+mod common;
+use common::*;
+
+async fn format_type(client: &tokio_postgres::Client, args: &str) -> Option<String> {
+    first_value(client, &format!("SELECT pg_catalog.format_type({args})")).await
+}
+
+/// Test formatting types with length, precision and scale modifiers
+#[tokio::test]
+async fn test_format_type_modifiers() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    assert_eq!(format_type(client, "1043, 504").await.as_deref(), Some("character varying(500)"));
+    assert_eq!(format_type(client, "1043, -1").await.as_deref(), Some("character varying"));
+    assert_eq!(format_type(client, "1042, 14").await.as_deref(), Some("character(10)"));
+    assert_eq!(format_type(client, "1700, 655366").await.as_deref(), Some("numeric(10,2)"));
+    assert_eq!(format_type(client, "1184, 3").await.as_deref(), Some("timestamp(3) with time zone"));
+    assert_eq!(format_type(client, "2950, -1").await.as_deref(), Some("uuid"));
+    assert_eq!(format_type(client, "23, NULL").await.as_deref(), Some("integer"));
+}
+
+/// Test that array types format their element type followed by []
+#[tokio::test]
+async fn test_format_type_arrays() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    assert_eq!(format_type(client, "1009, -1").await.as_deref(), Some("text[]"));
+    assert_eq!(format_type(client, "1007, -1").await.as_deref(), Some("integer[]"));
+    assert_eq!(format_type(client, "1015, 36").await.as_deref(), Some("character varying(32)[]"));
+    assert_eq!(format_type(client, "1231, 327686").await.as_deref(), Some("numeric(5,2)[]"));
+}
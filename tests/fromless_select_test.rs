@@ -0,0 +1,41 @@
+mod common;
+use common::*;
+use tokio_postgres::types::Type;
+
+/// Test that each expression of a FROM-less SELECT is typed from the expression
+#[tokio::test]
+async fn test_fromless_select_types() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let stmt = client.prepare("SELECT 1, now(), gen_random_uuid()").await.unwrap();
+    let types: Vec<&Type> = stmt.columns().iter().map(|c| c.type_()).collect();
+    assert_eq!(types, vec![&Type::INT4, &Type::TIMESTAMPTZ, &Type::UUID]);
+
+    let rows = client.query(&stmt, &[]).await.unwrap();
+    assert_eq!(rows.len(), 1);
+    assert_eq!(rows[0].get::<_, i32>(0), 1);
+
+    let now: chrono::DateTime<chrono::Utc> = rows[0].get(1);
+    assert!((chrono::Utc::now() - now).num_seconds().abs() < 60);
+
+    let uuid: String = client.query_one("SELECT gen_random_uuid()::text", &[]).await.unwrap().get(0);
+    assert_eq!(uuid.len(), 36);
+}
+
+/// Test FROM-less SELECT typing through the simple query protocol
+#[tokio::test]
+async fn test_fromless_select_simple_protocol() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let results = client.simple_query("SELECT 1 AS one, 3000000000 AS big, 'pgsqlite' AS name, gen_random_uuid() AS id").await.unwrap();
+
+    assert_eq!(column(&results, "one"), vec!["1"]);
+    assert_eq!(column(&results, "big"), vec!["3000000000"]);
+    assert_eq!(column(&results, "name"), vec!["pgsqlite"]);
+    assert_eq!(column(&results, "id").iter().map(String::len).collect::<Vec<_>>(), vec![36]);
+
+    // Health checks
+    assert_eq!(simple_values(client, "SELECT 1").await, vec!["1"]);
+}
@@ -83,13 +83,14 @@ async fn test_fts_functions_registration() {
     
     // Test ts_rank function
     let result = db.query_with_session(
-        "SELECT ts_rank('dummy', 'dummy')",
+        "SELECT ts_rank(to_tsvector('english', 'hello world'), to_tsquery('english', 'hello'))",
         &session_id
     ).await.unwrap();
     
     let text = String::from_utf8_lossy(result.rows[0][0].as_ref().unwrap());
     // ts_rank returns a float, but we get it as text
-    assert_eq!(text, "0.1");
+    let rank: f64 = text.parse().unwrap();
+    assert!((rank - 0.0607927).abs() < 1e-6);
     
     // Clean up session
     db.remove_session_connection(&session_id);
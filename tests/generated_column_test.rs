@@ -0,0 +1,49 @@
+mod common;
+use common::*;
+
+/// Test GENERATED ALWAYS AS (expression) STORED columns
+#[tokio::test]
+async fn test_generated_columns() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE books (
+            id SERIAL PRIMARY KEY,
+            title TEXT NOT NULL,
+            subtitle TEXT,
+            search_text TEXT GENERATED ALWAYS AS (lower(title || ' ' || coalesce(subtitle, ''))) STORED
+        )"
+    ).await.unwrap();
+
+    client.batch_execute("INSERT INTO books (title, subtitle) VALUES ('Dune', 'Messiah'), ('Emma', NULL)").await.unwrap();
+    client.batch_execute("INSERT INTO books (title, subtitle, search_text) VALUES ('Ulysses', 'A Novel', DEFAULT)").await.unwrap();
+
+    let rows = client.query("SELECT title, search_text FROM books ORDER BY id", &[]).await.unwrap();
+    let books: Vec<(String, String)> = rows.iter().map(|row| (row.get(0), row.get(1))).collect();
+    assert_eq!(books, vec![
+        ("Dune".to_string(), "dune messiah".to_string()),
+        ("Emma".to_string(), "emma ".to_string()),
+        ("Ulysses".to_string(), "ulysses a novel".to_string()),
+    ]);
+
+    // The value follows the columns it is derived from
+    client.batch_execute("UPDATE books SET subtitle = 'Children of Dune' WHERE title = 'Dune'").await.unwrap();
+    let rows = client.query("SELECT title FROM books WHERE search_text LIKE '%children%'", &[]).await.unwrap();
+    assert_eq!(rows.len(), 1);
+    assert_eq!(rows[0].get::<_, String>(0), "Dune");
+
+    // Generated columns can't be written directly
+    let err = client.batch_execute("INSERT INTO books (title, search_text) VALUES ('Odyssey', 'odyssey')").await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::GENERATED_ALWAYS));
+    let err = client.batch_execute("UPDATE books SET search_text = 'x'").await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::GENERATED_ALWAYS));
+
+    // pg_attribute lists the column as a stored generated column
+    let generated = first_value(
+        client,
+        "SELECT a.attgenerated FROM pg_attribute a JOIN pg_class c ON c.oid = a.attrelid \
+         WHERE c.relname = 'books' AND a.attname = 'search_text'"
+    ).await;
+    assert_eq!(generated.as_deref(), Some("s"));
+}
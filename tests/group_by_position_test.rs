@@ -0,0 +1,52 @@
+mod common;
+use common::*;
+
+async fn rows(client: &tokio_postgres::Client, query: &str) -> Vec<(String, i64)> {
+    client.query(query, &[]).await.unwrap().iter().map(|row| (row.get(0), row.get(1))).collect()
+}
+
+/// Test GROUP BY select-list positions and output column aliases
+#[tokio::test]
+async fn test_group_by_position_and_alias() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE orders (id INTEGER PRIMARY KEY, region TEXT, amount INTEGER)").await?;
+            db.execute("INSERT INTO orders (id, region, amount) VALUES (1, 'north', 50), (2, 'north', 250), (3, 'south', 120), (4, 'south', 180), (5, 'west', 20)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let expected = vec![("north".to_string(), 2), ("south".to_string(), 2), ("west".to_string(), 1)];
+    assert_eq!(rows(client, "SELECT region, count(*) FROM orders GROUP BY 1 ORDER BY 1").await, expected);
+    assert_eq!(rows(client, "SELECT region, count(*) FROM orders GROUP BY region ORDER BY region").await, expected);
+
+    // A computed alias, also used by HAVING and ORDER BY
+    assert_eq!(
+        rows(
+            client,
+            "SELECT CASE WHEN amount >= 100 THEN 'large' ELSE 'small' END AS size, count(*) AS n \
+             FROM orders GROUP BY size HAVING count(*) > 1 ORDER BY size",
+        ).await,
+        vec![("large".to_string(), 3), ("small".to_string(), 2)]
+    );
+    assert_eq!(
+        rows(client, "SELECT upper(region) AS area, sum(amount) FROM orders GROUP BY area ORDER BY area").await,
+        vec![("NORTH".to_string(), 300), ("SOUTH".to_string(), 300), ("WEST".to_string(), 20)]
+    );
+
+    // A position naming a computed column, mixed with an alias
+    assert_eq!(
+        rows(client, "SELECT region || ':' || (amount / 100) AS band, count(*) FROM orders GROUP BY 1 ORDER BY band").await,
+        vec![
+            ("north:0".to_string(), 1), ("north:2".to_string(), 1),
+            ("south:1".to_string(), 2), ("west:0".to_string(), 1),
+        ]
+    );
+
+    // An output alias shadowing an input column groups by the input column, as in PostgreSQL
+    assert_eq!(
+        rows(client, "SELECT upper(region) AS region, count(*) FROM orders GROUP BY region ORDER BY 1").await,
+        vec![("NORTH".to_string(), 2), ("SOUTH".to_string(), 2), ("WEST".to_string(), 1)]
+    );
+}
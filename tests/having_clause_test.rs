@@ -0,0 +1,49 @@
+mod common;
+use common::*;
+
+async fn authors(client: &tokio_postgres::Client, query: &str, params: &[&(dyn tokio_postgres::types::ToSql + Sync)]) -> Vec<(String, i64)> {
+    client.query(query, params).await.unwrap().iter().map(|row| (row.get(0), row.get(1))).collect()
+}
+
+/// Test HAVING with aggregate and non-aggregate conditions, literal and parameterized
+#[tokio::test]
+async fn test_having_conditions() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, author TEXT, pages INTEGER)").await?;
+            db.execute("INSERT INTO books (id, author, pages) VALUES (1, 'austen', 300), (2, 'austen', 250), (3, 'austen', 400), (4, 'bronte', 500), (5, 'bronte', 350), (6, 'eliot', 800), (7, NULL, 100), (8, NULL, 120)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    assert_eq!(
+        authors(client, "SELECT author, count(*) FROM books GROUP BY author HAVING count(*) > 1 ORDER BY author", &[]).await,
+        vec![("austen".to_string(), 3), ("bronte".to_string(), 2)]
+    );
+
+    // A non-aggregate condition on a grouping column
+    let rows = client.query(
+        "SELECT author, count(*) FROM books GROUP BY author HAVING author IS NOT NULL ORDER BY author", &[]
+    ).await.unwrap();
+    let names: Vec<String> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(names, vec!["austen", "bronte", "eliot"]);
+
+    // Aggregate comparisons against parameters are typed by the aggregate, so an int8
+    // argument binds and compares numerically
+    assert_eq!(
+        authors(client, "SELECT author, count(*) FROM books GROUP BY author HAVING count(*) > $1 ORDER BY author", &[&1i64]).await,
+        vec![("austen".to_string(), 3), ("bronte".to_string(), 2)]
+    );
+    assert_eq!(
+        authors(client, "SELECT author, sum(pages) FROM books GROUP BY author HAVING sum(pages) >= $1 AND author <> $2 ORDER BY author", &[&800i64, &"eliot"]).await,
+        vec![("austen".to_string(), 950), ("bronte".to_string(), 850)]
+    );
+    assert_eq!(
+        authors(client, "SELECT author, count(*) FROM books GROUP BY author HAVING $1 <= count(*) AND author IS NOT NULL ORDER BY author", &[&3i64]).await,
+        vec![("austen".to_string(), 3)]
+    );
+
+    let statement = client.prepare("SELECT author FROM books GROUP BY author HAVING count(*) > $1").await.unwrap();
+    assert_eq!(statement.params()[0], tokio_postgres::types::Type::INT8);
+}
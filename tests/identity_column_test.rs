@@ -0,0 +1,54 @@
+mod common;
+use common::*;
+
+/// Test GENERATED BY DEFAULT and GENERATED ALWAYS identity columns
+#[tokio::test]
+async fn test_identity_columns() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE notes (id bigint GENERATED BY DEFAULT AS IDENTITY, body TEXT);
+         CREATE TABLE orders (id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY, item TEXT)"
+    ).await.unwrap();
+
+    // BY DEFAULT generates a key but accepts an explicit one
+    client.batch_execute("INSERT INTO notes (body) VALUES ('first')").await.unwrap();
+    client.batch_execute("INSERT INTO notes (id, body) VALUES (10, 'explicit')").await.unwrap();
+    client.batch_execute("INSERT INTO notes (id, body) VALUES (DEFAULT, 'next')").await.unwrap();
+    let rows = client.query("SELECT id FROM notes ORDER BY id", &[]).await.unwrap();
+    let ids: Vec<i64> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![1, 10, 11]);
+
+    // ALWAYS generates the key and rejects an explicit one
+    client.batch_execute("INSERT INTO orders (item) VALUES ('book')").await.unwrap();
+    client.batch_execute("INSERT INTO orders (id, item) VALUES (DEFAULT, 'pen')").await.unwrap();
+    let err = client.batch_execute("INSERT INTO orders (id, item) VALUES (5, 'lamp')").await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::GENERATED_ALWAYS));
+    let err = client.execute("INSERT INTO orders (id, item) VALUES ($1, 'lamp')", &[&6i64]).await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::GENERATED_ALWAYS));
+    let err = client.batch_execute("UPDATE orders SET id = 7 WHERE item = 'book'").await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::GENERATED_ALWAYS));
+
+    // ...unless the statement overrides the system value
+    client.batch_execute("INSERT INTO orders (id, item) OVERRIDING SYSTEM VALUE VALUES (100, 'lamp')").await.unwrap();
+    // OVERRIDING USER VALUE ignores the given key
+    client.batch_execute("INSERT INTO orders (id, item) OVERRIDING USER VALUE VALUES (5, 'desk')").await.unwrap();
+    let rows = client.query("SELECT id, item FROM orders ORDER BY id", &[]).await.unwrap();
+    let orders: Vec<(i64, String)> = rows.iter().map(|row| (row.get(0), row.get(1))).collect();
+    assert_eq!(orders, vec![
+        (1, "book".to_string()),
+        (2, "pen".to_string()),
+        (100, "lamp".to_string()),
+        (101, "desk".to_string()),
+    ]);
+
+    // pg_attribute reports the kind of identity
+    for (table, expected) in [("notes", "d"), ("orders", "a")] {
+        let identity = first_value(client, &format!(
+            "SELECT a.attidentity FROM pg_attribute a JOIN pg_class c ON c.oid = a.attrelid \
+             WHERE c.relname = '{table}' AND a.attname = 'id'"
+        )).await;
+        assert_eq!(identity.as_deref(), Some(expected), "attidentity of {table}.id");
+    }
+}
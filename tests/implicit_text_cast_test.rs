@@ -0,0 +1,83 @@
+mod common;
+use common::*;
+use tokio_postgres::types::Type;
+
+async fn setup_orders() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE orders (id INTEGER PRIMARY KEY, price NUMERIC(10,2), quantity INTEGER, ordered_on DATE, shipped_at TIMESTAMP)").await?;
+            db.execute("INSERT INTO orders (id, price, quantity, ordered_on, shipped_at) VALUES
+                (1, 12.99, 3, '2024-01-15', '2024-01-16 09:30:00'),
+                (2, 5.50, 10, '2024-01-20', '2024-01-22 14:00:00'),
+                (3, 120.00, 1, '2024-02-01', '2024-02-01 18:45:00')").await?;
+            Ok(())
+        })
+    }).await
+}
+
+async fn ids(client: &tokio_postgres::Client, query: &str, text_params: &[&str]) -> Vec<i32> {
+    let statement = client.prepare_typed(query, &vec![Type::TEXT; text_params.len()]).await.unwrap();
+    let params: Vec<&(dyn tokio_postgres::types::ToSql + Sync)> = text_params.iter()
+        .map(|param| param as &(dyn tokio_postgres::types::ToSql + Sync))
+        .collect();
+    client.query(&statement, &params).await.unwrap().iter().map(|row| row.get(0)).collect()
+}
+
+/// Test numeric columns compared with text literals and text parameters
+#[tokio::test]
+async fn test_numeric_compared_with_text() {
+    let server = setup_orders().await;
+    let client = &server.client;
+
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE price = '12.99'", &[]).await, vec![1]);
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE price > '10' ORDER BY id", &[]).await, vec![1, 3]);
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE '3' = quantity", &[]).await, vec![1]);
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE price = '5.50'", &[]).await, vec![2]);
+
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE price = $1", &["12.99"]).await, vec![1]);
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE price < $1 ORDER BY id", &["100"]).await, vec![1, 2]);
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE quantity >= $1 ORDER BY id", &["3"]).await, vec![1, 2]);
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE price = $1", &["5.50"]).await, vec![2]);
+    assert_eq!(
+        ids(client, "SELECT id FROM orders WHERE price >= $1 AND quantity < $2 ORDER BY id", &["5.5", "10"]).await,
+        vec![1, 3]
+    );
+    assert_eq!(
+        ids(client, "SELECT id FROM orders WHERE price BETWEEN $1 AND $2 ORDER BY id", &["5.50", "13"]).await,
+        vec![1, 2]
+    );
+}
+
+/// Test date and timestamp columns compared with text literals and text parameters
+#[tokio::test]
+async fn test_dates_compared_with_text() {
+    let server = setup_orders().await;
+    let client = &server.client;
+
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE ordered_on = '2024-01-20'", &[]).await, vec![2]);
+    assert_eq!(
+        ids(client, "SELECT id FROM orders WHERE shipped_at >= '2024-01-16 12:00:00' ORDER BY id", &[]).await,
+        vec![2, 3]
+    );
+    // A date compared with a timestamp column means midnight of that day
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE shipped_at < '2024-02-01' ORDER BY id", &[]).await, vec![1, 2]);
+    assert_eq!(
+        ids(client, "SELECT id FROM orders WHERE shipped_at BETWEEN '2024-01-16' AND '2024-01-31T23:59:59'::timestamp ORDER BY id", &[]).await,
+        vec![1, 2]
+    );
+
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE ordered_on = $1", &["2024-02-01"]).await, vec![3]);
+    assert_eq!(ids(client, "SELECT id FROM orders WHERE shipped_at = $1", &["2024-01-22 14:00:00"]).await, vec![2]);
+    assert_eq!(
+        ids(client, "SELECT id FROM orders WHERE ordered_on >= $1 AND ordered_on < $2 ORDER BY id", &["2024-01-16", "2024-02-01"]).await,
+        vec![2]
+    );
+    assert_eq!(
+        ids(client, "SELECT id FROM orders WHERE shipped_at > $1 ORDER BY id", &["2024-01-20"]).await,
+        vec![2, 3]
+    );
+    assert_eq!(
+        ids(client, "SELECT id FROM orders WHERE shipped_at >= $1 AND price < $2 ORDER BY id", &["2024-01-16", "100"]).await,
+        vec![1, 2]
+    );
+}
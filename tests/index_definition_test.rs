@@ -0,0 +1,40 @@
+mod common;
+use common::*;
+
+async fn index_definition(client: &tokio_postgres::Client, index_name: &str, column: i32) -> Option<String> {
+    let query = format!(
+        "SELECT pg_get_indexdef(i.indexrelid, {column}, false) FROM pg_index i \
+         JOIN pg_class c ON c.oid = i.indexrelid WHERE c.relname = '{index_name}'"
+    );
+    first_value(client, &query).await
+}
+
+/// Test reconstructing CREATE INDEX for multi-column, expression and partial indexes
+#[tokio::test]
+async fn test_pg_get_indexdef() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE people (id INTEGER PRIMARY KEY, last_name TEXT, first_name TEXT, email TEXT, age INTEGER);
+         CREATE UNIQUE INDEX people_name_idx ON people (last_name, first_name DESC);
+         CREATE INDEX people_email_lower_idx ON people (lower(email));
+         CREATE INDEX people_age_idx ON people ((age + 1)) WHERE age > 18;"
+    ).await.unwrap();
+
+    assert_eq!(
+        index_definition(client, "people_name_idx", 0).await.as_deref(),
+        Some("CREATE UNIQUE INDEX people_name_idx ON public.people USING btree (last_name, first_name DESC)")
+    );
+    assert_eq!(
+        index_definition(client, "people_email_lower_idx", 0).await.as_deref(),
+        Some("CREATE INDEX people_email_lower_idx ON public.people USING btree (lower(email))")
+    );
+    assert_eq!(
+        index_definition(client, "people_age_idx", 0).await.as_deref(),
+        Some("CREATE INDEX people_age_idx ON public.people USING btree ((age + 1)) WHERE (age > 18)")
+    );
+
+    // A column number gives just that key
+    assert_eq!(index_definition(client, "people_name_idx", 2).await.as_deref(), Some("first_name DESC"));
+}
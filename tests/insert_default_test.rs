@@ -0,0 +1,43 @@
+mod common;
+use common::*;
+
+/// Test DEFAULT as a value and INSERT ... DEFAULT VALUES
+#[tokio::test]
+async fn test_insert_with_default_keyword() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE books (
+            id SERIAL PRIMARY KEY,
+            title TEXT DEFAULT 'Untitled',
+            status TEXT DEFAULT 'draft',
+            copies INTEGER DEFAULT 1,
+            code UUID DEFAULT gen_random_uuid()
+        )"
+    ).await.unwrap();
+
+    client.batch_execute("INSERT INTO books (title, status) VALUES ('Dune', DEFAULT)").await.unwrap();
+    client.batch_execute("INSERT INTO books (title, copies, code) VALUES ('Emma', default, DEFAULT), ('Ulysses', 3, DEFAULT)").await.unwrap();
+    client.batch_execute("INSERT INTO books DEFAULT VALUES").await.unwrap();
+
+    let rows = client.query("SELECT id, title, status, copies FROM books ORDER BY id", &[]).await.unwrap();
+    let books: Vec<(i32, String, String, i32)> = rows.iter()
+        .map(|row| (row.get(0), row.get(1), row.get(2), row.get(3)))
+        .collect();
+    assert_eq!(books, vec![
+        (1, "Dune".to_string(), "draft".to_string(), 1),
+        (2, "Emma".to_string(), "draft".to_string(), 1),
+        (3, "Ulysses".to_string(), "draft".to_string(), 3),
+        (4, "Untitled".to_string(), "draft".to_string(), 1),
+    ]);
+
+    // Every row got its own generated code
+    let rows = client.query("SELECT count(DISTINCT code) FROM books WHERE code IS NOT NULL", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i64>(0), 4);
+
+    // The extended protocol takes the same path
+    client.execute("INSERT INTO books (id, title, status) VALUES (DEFAULT, $1, DEFAULT)", &[&"Persuasion"]).await.unwrap();
+    let row = client.query_one("SELECT status FROM books WHERE title = 'Persuasion'", &[]).await.unwrap();
+    assert_eq!(row.get::<_, String>(0), "draft");
+}
@@ -0,0 +1,47 @@
+mod common;
+use common::*;
+
+/// Test that INSERT ... SELECT stores values the same way a literal INSERT does
+#[tokio::test]
+async fn test_insert_select_coerces_to_column_types() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE staging (id INTEGER PRIMARY KEY, price TEXT, published TEXT, status TEXT);
+         CREATE TABLE books (id INTEGER PRIMARY KEY, price NUMERIC(10,2), published DATE, status TEXT);
+         CREATE TABLE archive (id INTEGER PRIMARY KEY, price NUMERIC(10,2), published DATE, status TEXT);"
+    ).await.unwrap();
+    client.batch_execute(
+        "INSERT INTO staging (id, price, published, status) VALUES (1, '19.999', '2024-01-15', 'archived'), (2, '5', '2023-06-30', 'active');
+         INSERT INTO books (id, price, published, status) VALUES (3, 12.5, '2022-03-01', 'archived');"
+    ).await.unwrap();
+
+    // Text values become numeric(10,2) and dates; typed values copy unchanged
+    client.simple_query("INSERT INTO archive SELECT * FROM staging WHERE status = 'archived'").await.unwrap();
+    client.simple_query("INSERT INTO archive SELECT * FROM books WHERE status = 'archived'").await.unwrap();
+    // A column-list subset
+    client.simple_query("INSERT INTO archive (id, published) SELECT id + 10, published FROM staging WHERE id = 2").await.unwrap();
+    // The literal form the copies must match
+    client.simple_query("INSERT INTO archive (id, price, published, status) VALUES (20, 20.00, '2024-01-15', 'archived')").await.unwrap();
+
+    let archived = rows(&client.simple_query("SELECT id, price, published FROM archive ORDER BY id").await.unwrap());
+    let expected = [
+        ["1", "20.00", "2024-01-15"],
+        ["3", "12.50", "2022-03-01"],
+    ];
+    for (row, expected) in archived.iter().zip(expected) {
+        assert_eq!(row.iter().map(|v| v.as_deref().unwrap()).collect::<Vec<_>>(), expected);
+    }
+    assert_eq!(archived[2][0].as_deref(), Some("12"));
+    assert_eq!(archived[2][1], None);
+    assert_eq!(archived[2][2].as_deref(), Some("2023-06-30"));
+    assert_eq!(archived[3][1..], archived[0][1..]);
+
+    // Copied and literal rows share one storage representation
+    assert_eq!(simple_values(client, "SELECT DISTINCT typeof(published) FROM archive").await, vec!["integer"]);
+    assert_eq!(
+        simple_values(client, "SELECT COUNT(*) FROM archive WHERE price IS NOT NULL AND typeof(price) <> 'real' AND typeof(price) <> 'integer'").await,
+        vec!["0"]
+    );
+}
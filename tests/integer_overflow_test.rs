@@ -0,0 +1,56 @@
+mod common;
+use common::*;
+
+use tokio_postgres::error::SqlState;
+
+/// Test that values beyond the int2/int4/int8 ranges are rejected with SQLSTATE 22003
+#[tokio::test]
+async fn test_integer_column_ranges() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE counters (id INTEGER PRIMARY KEY, small SMALLINT, hits INTEGER, total BIGINT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute("INSERT INTO counters (id, small, hits, total) VALUES (1, 32767, 2147483647, 9223372036854775807)").await.unwrap();
+
+    for (query, message) in [
+        ("INSERT INTO counters (id, hits) VALUES (2, 2147483648)", "integer out of range"),
+        ("INSERT INTO counters (id, small) VALUES (2, -40000)", "smallint out of range"),
+        ("INSERT INTO counters (id, total) VALUES (2, 9223372036854775808)", "bigint out of range"),
+        // Arithmetic that overflows the column type
+        ("UPDATE counters SET hits = hits + 1 WHERE id = 1", "integer out of range"),
+    ] {
+        let err = client.batch_execute(query).await.unwrap_err();
+        let db_err = err.as_db_error().expect(query);
+        assert_eq!(db_err.code(), &SqlState::NUMERIC_VALUE_OUT_OF_RANGE, "{query}");
+        assert_eq!(db_err.message(), message, "{query}");
+    }
+
+    // The failed statements left the row alone
+    let row = client.query_one("SELECT hits, total FROM counters WHERE id = 1", &[]).await.unwrap();
+    assert_eq!(row.get::<_, i32>(0), 2147483647);
+    assert_eq!(row.get::<_, i64>(1), 9223372036854775807);
+
+    client.batch_execute("UPDATE counters SET hits = hits - 1 WHERE id = 1").await.unwrap();
+}
+
+/// Test that an aggregate overflowing a 64-bit integer fails instead of wrapping
+#[tokio::test]
+async fn test_overflowing_sum() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE ledger (id INTEGER PRIMARY KEY, amount BIGINT)").await?;
+            db.execute("INSERT INTO ledger (id, amount) VALUES (1, 9223372036854775807), (2, 1)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let err = client.simple_query("SELECT sum(amount) FROM ledger").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::NUMERIC_VALUE_OUT_OF_RANGE));
+
+    assert_eq!(simple_values(client, "SELECT sum(amount) FROM ledger WHERE id = 2").await, vec!["1"]);
+}
@@ -0,0 +1,143 @@
+mod common;
+use common::*;
+use bytes::BytesMut;
+use tokio_postgres::types::{FromSql, IsNull, ToSql, Type, to_sql_checked};
+
+/// INTERVAL in PostgreSQL's binary layout: microseconds, days, months
+#[derive(Debug, PartialEq)]
+struct Interval {
+    months: i32,
+    days: i32,
+    micros: i64,
+}
+
+impl<'a> FromSql<'a> for Interval {
+    fn from_sql(_ty: &Type, raw: &'a [u8]) -> Result<Self, Box<dyn std::error::Error + Sync + Send>> {
+        if raw.len() != 16 {
+            return Err(format!("Invalid interval length: {}", raw.len()).into());
+        }
+        Ok(Interval {
+            micros: i64::from_be_bytes(raw[0..8].try_into()?),
+            days: i32::from_be_bytes(raw[8..12].try_into()?),
+            months: i32::from_be_bytes(raw[12..16].try_into()?),
+        })
+    }
+
+    fn accepts(ty: &Type) -> bool {
+        *ty == Type::INTERVAL
+    }
+}
+
+impl ToSql for Interval {
+    fn to_sql(&self, _ty: &Type, out: &mut BytesMut) -> Result<IsNull, Box<dyn std::error::Error + Sync + Send>> {
+        out.extend_from_slice(&self.micros.to_be_bytes());
+        out.extend_from_slice(&self.days.to_be_bytes());
+        out.extend_from_slice(&self.months.to_be_bytes());
+        Ok(IsNull::No)
+    }
+
+    fn accepts(ty: &Type) -> bool {
+        *ty == Type::INTERVAL
+    }
+
+    to_sql_checked!();
+}
+
+async fn setup_durations() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE durations (id INTEGER PRIMARY KEY, span INTERVAL)").await?;
+            db.execute("INSERT INTO durations (id, span) VALUES
+                (1, '1 year 2 mons 3 days 04:05:06'),
+                (2, '1 day 02:30:00'),
+                (3, '90 minutes'),
+                (4, '2 weeks'),
+                (5, '00:00:00.25')").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+/// Test that intervals come back in PostgreSQL's canonical text form
+#[tokio::test]
+async fn test_interval_text_output() {
+    let server = setup_durations().await;
+    let client = &server.client;
+
+    assert_eq!(simple_values(client, "SELECT span FROM durations ORDER BY id").await, vec![
+        "1 year 2 mons 3 days 04:05:06",
+        "1 day 02:30:00",
+        "01:30:00",
+        "14 days",
+        "00:00:00.25",
+    ]);
+}
+
+/// Test that intervals are described with the interval OID and decode from binary
+#[tokio::test]
+async fn test_interval_binary_output() {
+    let server = setup_durations().await;
+    let client = &server.client;
+
+    let stmt = client.prepare("SELECT span FROM durations ORDER BY id").await.unwrap();
+    assert_eq!(stmt.columns()[0].type_(), &Type::INTERVAL);
+
+    let rows = client.query(&stmt, &[]).await.unwrap();
+    let spans: Vec<Interval> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(spans, vec![
+        Interval { months: 14, days: 3, micros: 14_706_000_000 },
+        Interval { months: 0, days: 1, micros: 9_000_000_000 },
+        Interval { months: 0, days: 0, micros: 5_400_000_000 },
+        Interval { months: 0, days: 14, micros: 0 },
+        Interval { months: 0, days: 0, micros: 250_000 },
+    ]);
+}
+
+/// Test that binary interval parameters round-trip through storage
+#[tokio::test]
+async fn test_interval_parameter_round_trip() {
+    let server = setup_durations().await;
+    let client = &server.client;
+
+    let values = [
+        (10, Interval { months: 1, days: 2, micros: 3_000_000 }),
+        (11, Interval { months: 0, days: 0, micros: 45_296_789_000 }),
+        (12, Interval { months: -13, days: 0, micros: 0 }),
+    ];
+    for (id, span) in &values {
+        client.execute("INSERT INTO durations (id, span) VALUES ($1, $2::interval)", &[id, span]).await.unwrap();
+    }
+
+    let rows = client.query("SELECT span FROM durations WHERE id >= 10 ORDER BY id", &[]).await.unwrap();
+    let spans: Vec<Interval> = rows.iter().map(|row| row.get(0)).collect();
+    let expected: Vec<Interval> = values.into_iter().map(|(_, span)| span).collect();
+    assert_eq!(spans, expected);
+
+    assert_eq!(simple_values(client, "SELECT span FROM durations WHERE id >= 10 ORDER BY id").await, vec!["1 mon 2 days 00:00:03", "12:34:56.789", "-1 years -1 mons"]);
+}
+
+/// Test that intervals with and without a month part share one storage form, and that
+/// days never fold into months
+#[tokio::test]
+async fn test_interval_storage_form() {
+    let server = setup_durations().await;
+    let client = &server.client;
+
+    assert_eq!(simple_values(client, "SELECT count(*) AS stored FROM durations WHERE typeof(span) = 'text'").await, vec!["5"]);
+
+    client.execute("INSERT INTO durations (id, span) VALUES (20, '30 days'), (21, '45 days'), (22, '365 days'), (23, '1 mon')", &[]).await.unwrap();
+    client.execute("INSERT INTO durations (id, span) VALUES ($1, $2)", &[&24i32, &Interval { months: 0, days: 30, micros: 0 }]).await.unwrap();
+
+    assert_eq!(simple_values(client, "SELECT span FROM durations WHERE id >= 20 ORDER BY id").await, vec!["30 days", "45 days", "365 days", "1 mon", "30 days"]);
+
+    let rows = client.query("SELECT span FROM durations WHERE id >= 20 ORDER BY id", &[]).await.unwrap();
+    let spans: Vec<Interval> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(spans, vec![
+        Interval { months: 0, days: 30, micros: 0 },
+        Interval { months: 0, days: 45, micros: 0 },
+        Interval { months: 0, days: 365, micros: 0 },
+        Interval { months: 1, days: 0, micros: 0 },
+        Interval { months: 0, days: 30, micros: 0 },
+    ]);
+}
@@ -1,3 +1,5 @@
+mod common;
+use common::JsonText;
 use tokio::net::TcpListener;
 use tokio_postgres::NoTls;
 
@@ -59,7 +61,7 @@ async fn test_json_agg_integration() {
     ).await.unwrap();
     
     assert_eq!(rows.len(), 1);
-    let json_result: String = rows[0].get("names");
+    let json_result: String = rows[0].get::<_, JsonText>("names").0;
     
     // Parse and verify it's a valid JSON array
     let parsed: serde_json::Value = serde_json::from_str(&json_result).unwrap();
@@ -86,7 +88,7 @@ async fn test_json_agg_integration() {
     ).await.unwrap();
     
     assert_eq!(rows.len(), 1);
-    let json_result: String = rows[0].get("salaries");
+    let json_result: String = rows[0].get::<_, JsonText>("salaries").0;
     let parsed: serde_json::Value = serde_json::from_str(&json_result).unwrap();
     
     match parsed {
@@ -108,7 +110,7 @@ async fn test_json_agg_integration() {
     ).await.unwrap();
     
     assert_eq!(rows.len(), 1);
-    let json_result: String = rows[0].get("names");
+    let json_result: String = rows[0].get::<_, JsonText>("names").0;
     let parsed: serde_json::Value = serde_json::from_str(&json_result).unwrap();
     
     match parsed {
@@ -130,7 +132,7 @@ async fn test_json_agg_integration() {
     ).await.unwrap();
     
     assert_eq!(rows.len(), 1);
-    let json_result: String = rows[0].get("names");
+    let json_result: String = rows[0].get::<_, JsonText>("names").0;
     assert_eq!(json_result, "[]");
     
     // Test with GROUP BY
@@ -144,7 +146,7 @@ async fn test_json_agg_integration() {
     // Check Engineering department
     let eng_row = &rows[0];
     let dept: String = eng_row.get("department");
-    let names: String = eng_row.get("names");
+    let names: String = eng_row.get::<_, JsonText>("names").0;
     assert_eq!(dept, "Engineering");
     
     let parsed: serde_json::Value = serde_json::from_str(&names).unwrap();
@@ -213,7 +215,7 @@ async fn test_json_agg_with_nulls() {
     ).await.unwrap();
     
     assert_eq!(rows.len(), 1);
-    let json_result: String = rows[0].get("value_list");
+    let json_result: String = rows[0].get::<_, JsonText>("value_list").0;
     let parsed: serde_json::Value = serde_json::from_str(&json_result).unwrap();
     
     match parsed {
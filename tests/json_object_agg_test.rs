@@ -1,3 +1,5 @@
+mod common;
+use common::JsonText;
 use tokio::net::TcpListener;
 use tokio_postgres::NoTls;
 
@@ -38,7 +40,7 @@ async fn test_json_object_agg_basic() {
     ).await.unwrap();
     
     assert_eq!(rows.len(), 1);
-    let result: String = rows[0].get("result");
+    let result: String = rows[0].get::<_, JsonText>("result").0;
     
     // Parse the result JSON to verify it contains the expected key-value pairs
     let json: serde_json::Value = serde_json::from_str(&result).unwrap();
@@ -86,7 +88,7 @@ async fn test_jsonb_object_agg_basic() {
     ).await.unwrap();
     
     assert_eq!(rows.len(), 1);
-    let result: String = rows[0].get("result");
+    let result: String = rows[0].get::<_, JsonText>("result").0;
     
     // Parse the result JSON to verify it contains the expected key-value pairs
     let json: serde_json::Value = serde_json::from_str(&result).unwrap();
@@ -153,7 +155,7 @@ async fn test_json_object_agg_with_table() {
     ).await.unwrap();
     
     assert_eq!(rows.len(), 1);
-    let result: String = rows[0].get("result");
+    let result: String = rows[0].get::<_, JsonText>("result").0;
     
     // Parse the result JSON to verify it contains the expected key-value pairs
     let json: serde_json::Value = serde_json::from_str(&result).unwrap();
@@ -201,7 +203,7 @@ async fn test_json_object_agg_empty_result() {
     ).await.unwrap();
     
     assert_eq!(rows.len(), 1);
-    let result: String = rows[0].get("result");
+    let result: String = rows[0].get::<_, JsonText>("result").0;
     
     // Should return empty object for no rows
     assert_eq!(result, "{}");
@@ -246,7 +248,7 @@ async fn test_json_object_agg_mixed_types() {
     ).await.unwrap();
     
     assert_eq!(rows.len(), 1);
-    let result: String = rows[0].get("result");
+    let result: String = rows[0].get::<_, JsonText>("result").0;
     
     // Parse the result JSON to verify it contains the expected key-value pairs
     let json: serde_json::Value = serde_json::from_str(&result).unwrap();
@@ -295,7 +297,7 @@ async fn test_json_object_agg_duplicate_keys() {
     ).await.unwrap();
     
     assert_eq!(rows.len(), 1);
-    let result: String = rows[0].get("result");
+    let result: String = rows[0].get::<_, JsonText>("result").0;
     
     // Parse the result JSON to verify duplicate key handling
     let json: serde_json::Value = serde_json::from_str(&result).unwrap();
@@ -0,0 +1,70 @@
+mod common;
+use common::*;
+use tokio_postgres::types::Type;
+
+async fn setup_reviews() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT)").await?;
+            db.execute("CREATE TABLE reviews (id INTEGER PRIMARY KEY, book_id INTEGER, reviewer TEXT, rating INTEGER)").await?;
+            db.execute("INSERT INTO books (id, title) VALUES (1, 'Dune'), (2, 'Emma')").await?;
+            db.execute("INSERT INTO reviews (id, book_id, reviewer, rating) VALUES
+                (1, 1, 'ana', 4),
+                (2, 1, 'ben', 5),
+                (3, 2, 'cy', 3),
+                (4, 1, 'dee', 2)").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+fn json_rows(results: &[tokio_postgres::SimpleQueryMessage], name: &str) -> Vec<serde_json::Value> {
+    column(results, name).iter().map(|v| serde_json::from_str(v).unwrap()).collect()
+}
+
+/// Test aggregating the reviews of each book into an ordered JSON array
+#[tokio::test]
+async fn test_jsonb_agg_reviews_per_book() {
+    let server = setup_reviews().await;
+    let client = &server.client;
+
+    let query = "SELECT b.title, jsonb_agg(json_build_object('reviewer', r.reviewer, 'rating', r.rating) ORDER BY r.rating DESC) AS reviews \
+                 FROM books b JOIN reviews r ON r.book_id = b.id GROUP BY b.title ORDER BY b.title";
+
+    let stmt = client.prepare(query).await.unwrap();
+    assert_eq!(stmt.columns()[1].type_(), &Type::JSONB);
+
+    let results = client.simple_query(query).await.unwrap();
+    assert_eq!(json_rows(&results, "reviews"), vec![
+        serde_json::json!([
+            {"reviewer": "ben", "rating": 5},
+            {"reviewer": "ana", "rating": 4},
+            {"reviewer": "dee", "rating": 2},
+        ]),
+        serde_json::json!([{"reviewer": "cy", "rating": 3}]),
+    ]);
+}
+
+/// Test json_object_agg building an object per book and rejecting NULL keys
+#[tokio::test]
+async fn test_json_object_agg_reviews() {
+    let server = setup_reviews().await;
+    let client = &server.client;
+
+    let query = "SELECT book_id, json_object_agg(reviewer, rating ORDER BY reviewer) AS ratings \
+                 FROM reviews GROUP BY book_id ORDER BY book_id";
+
+    let stmt = client.prepare(query).await.unwrap();
+    assert_eq!(stmt.columns()[1].type_(), &Type::JSON);
+
+    let results = client.simple_query(query).await.unwrap();
+    assert_eq!(json_rows(&results, "ratings"), vec![
+        serde_json::json!({"ana": 4, "ben": 5, "dee": 2}),
+        serde_json::json!({"cy": 3}),
+    ]);
+
+    client.simple_query("INSERT INTO reviews (id, book_id, reviewer, rating) VALUES (5, 2, NULL, 1)").await.unwrap();
+    let err = client.simple_query("SELECT json_object_agg(reviewer, rating) FROM reviews").await.unwrap_err();
+    assert!(err.to_string().contains("null value not allowed for object key"), "{err}");
+}
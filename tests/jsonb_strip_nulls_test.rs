@@ -0,0 +1,38 @@
+mod common;
+use common::*;
+use tokio_postgres::types::Type;
+
+#[tokio::test]
+async fn test_jsonb_strip_nulls_and_pretty() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE profiles (id INTEGER PRIMARY KEY, data JSONB)").await?;
+            db.execute(r#"INSERT INTO profiles (id, data) VALUES (1, '{"name":"Ann","bio":null,"address":{"city":"Oslo","zip":null},"tags":["a",null]}')"#).await?;
+            Ok(())
+        })
+    }).await;
+    
+    let client = &server.client;
+    
+    let statement = client.prepare(
+        "SELECT jsonb_strip_nulls(data) AS cleaned, jsonb_pretty(data) AS pretty FROM profiles WHERE id = 1"
+    ).await.unwrap();
+    assert_eq!(statement.columns()[0].type_(), &Type::JSONB);
+    assert_eq!(statement.columns()[1].type_(), &Type::TEXT);
+    
+    let cleaned = first_value(client, "SELECT jsonb_strip_nulls(data) AS cleaned FROM profiles WHERE id = 1")
+        .await
+        .expect("Expected to find a row");
+    let cleaned: serde_json::Value = serde_json::from_str(&cleaned).unwrap();
+    assert_eq!(cleaned, serde_json::json!({
+        "name": "Ann",
+        "address": {"city": "Oslo"},
+        "tags": ["a", null]
+    }));
+    
+    let row = client.query_one(r#"SELECT jsonb_pretty('{"a":{"b":1}}') AS pretty"#, &[]).await.unwrap();
+    let pretty: String = row.get(0);
+    assert_eq!(pretty, "{\n    \"a\": {\n        \"b\": 1\n    }\n}");
+    
+    server.abort();
+}
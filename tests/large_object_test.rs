@@ -0,0 +1,48 @@
+mod common;
+use common::*;
+
+/// Test writing bytes to a large object through a descriptor and reading them back
+#[tokio::test]
+async fn test_large_object_write_and_read() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let oid = first_value(client, "SELECT lo_create(0)").await.unwrap();
+
+    // Descriptors are opened for reading and writing with INV_READ | INV_WRITE
+    client.batch_execute("BEGIN").await.unwrap();
+    let fd = first_value(client, &format!("SELECT lo_open({oid}, 393216)")).await.unwrap();
+    let written = first_value(client, &format!("SELECT lowrite({fd}, '\\x48656c6c6f2c20776f726c64')")).await;
+    assert_eq!(written.as_deref(), Some("12"));
+    assert_eq!(first_value(client, &format!("SELECT lo_tell({fd})")).await.as_deref(), Some("12"));
+
+    // Seek back to the start (SEEK_SET) and read it all
+    assert_eq!(first_value(client, &format!("SELECT lo_lseek({fd}, 0, 0)")).await.as_deref(), Some("0"));
+    let read = first_value(client, &format!("SELECT loread({fd}, 100)")).await;
+    assert_eq!(read.as_deref(), Some("\\x48656c6c6f2c20776f726c64"));
+
+    // Overwrite part of it relative to the end (SEEK_END)
+    assert_eq!(first_value(client, &format!("SELECT lo_lseek({fd}, -5, 2)")).await.as_deref(), Some("7"));
+    first_value(client, &format!("SELECT lowrite({fd}, '\\x5745524c44')")).await;
+    assert_eq!(first_value(client, &format!("SELECT lo_close({fd})")).await.as_deref(), Some("0"));
+    client.batch_execute("COMMIT").await.unwrap();
+
+    // The convenience functions read and write whole objects
+    let whole = first_value(client, &format!("SELECT lo_get({oid})")).await;
+    assert_eq!(whole.as_deref(), Some("\\x48656c6c6f2c20574f524c44"));
+    let part = first_value(client, &format!("SELECT lo_get({oid}, 7, 5)")).await;
+    assert_eq!(part.as_deref(), Some("\\x574f524c44"));
+
+    let copy = first_value(client, "SELECT lo_from_bytea(0, '\\x010203')").await.unwrap();
+    assert_ne!(copy, oid);
+    assert_eq!(first_value(client, &format!("SELECT lo_get({copy})")).await.as_deref(), Some("\\x010203"));
+
+    let listed = first_value(client, "SELECT count(*) FROM pg_largeobject_metadata").await;
+    assert_eq!(listed.as_deref(), Some("2"));
+
+    // An unlinked object is gone
+    assert_eq!(first_value(client, &format!("SELECT lo_unlink({oid})")).await.as_deref(), Some("1"));
+    let err = client.simple_query(&format!("SELECT lo_get({oid})")).await.unwrap_err();
+    assert!(err.to_string().contains("does not exist"), "unexpected error: {err}");
+    assert_eq!(first_value(client, "SELECT count(*) FROM pg_largeobject_metadata").await.as_deref(), Some("1"));
+}
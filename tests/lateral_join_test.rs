@@ -0,0 +1,47 @@
+mod common;
+use common::*;
+
+#[tokio::test]
+async fn test_left_join_lateral_top_book_per_author() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT NOT NULL)").await?;
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, author_id INTEGER NOT NULL, title TEXT NOT NULL, average_rating DOUBLE PRECISION)").await?;
+            db.execute("INSERT INTO authors (id, name) VALUES (1, 'Le Guin'), (2, 'Herbert'), (3, 'Unpublished')").await?;
+            db.execute("INSERT INTO books (id, author_id, title, average_rating) VALUES \
+                (1, 1, 'The Lathe of Heaven', 4.0), (2, 1, 'The Dispossessed', 4.2), (3, 1, 'Earthsea', 4.1), \
+                (4, 2, 'Dune', 4.3), (5, 2, 'Dune Messiah', 3.9)").await?;
+            Ok(())
+        })
+    }).await;
+    
+    let client = &server.client;
+    
+    let query = "SELECT a.name, b.title, b.average_rating FROM authors a \
+                 LEFT JOIN LATERAL (SELECT title, average_rating FROM books \
+                 WHERE books.author_id = a.id ORDER BY average_rating DESC LIMIT 1) b ON true \
+                 ORDER BY a.id";
+    let top: Vec<(String, Option<String>, Option<f64>)> = client.query(query, &[]).await.unwrap().iter()
+        .map(|row| (row.get(0), row.get(1), row.get(2)))
+        .collect();
+    assert_eq!(top, vec![
+        ("Le Guin".to_string(), Some("The Dispossessed".to_string()), Some(4.2)),
+        ("Herbert".to_string(), Some("Dune".to_string()), Some(4.3)),
+        ("Unpublished".to_string(), None, None),
+    ]);
+    
+    // Top two per author, dropping authors without books
+    let results = client.simple_query(
+        "SELECT a.name, b.title FROM authors a \
+         CROSS JOIN LATERAL (SELECT title FROM books bk WHERE bk.author_id = a.id ORDER BY bk.average_rating DESC LIMIT 2) b \
+         ORDER BY a.id, b.title"
+    ).await.unwrap();
+    assert_eq!(rows(&results), vec![
+        some(&["Le Guin", "Earthsea"]),
+        some(&["Le Guin", "The Dispossessed"]),
+        some(&["Herbert", "Dune"]),
+        some(&["Herbert", "Dune Messiah"]),
+    ]);
+    
+    server.abort();
+}
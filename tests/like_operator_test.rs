@@ -0,0 +1,83 @@
+mod common;
+use common::*;
+
+async fn setup_words() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE words (id INTEGER PRIMARY KEY, word TEXT NOT NULL)").await?;
+            db.execute("INSERT INTO words (id, word) VALUES
+                (1, 'Apple'),
+                (2, 'apricot'),
+                (3, 'banana'),
+                (4, '100% juice'),
+                (5, 'ÉCLAIR'),
+                (6, 'a_b')").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+async fn matching_ids(client: &tokio_postgres::Client, condition: &str) -> Vec<i32> {
+    let rows = client.query(&format!("SELECT id FROM words WHERE {condition} ORDER BY id"), &[]).await.unwrap();
+    rows.iter().map(|row| row.get::<_, i32>(0)).collect()
+}
+
+/// Test ~~ and !~~ as case-sensitive LIKE / NOT LIKE
+#[tokio::test]
+async fn test_like_operators() {
+    let server = setup_words().await;
+    let client = &server.client;
+
+    assert_eq!(matching_ids(client, "word ~~ 'a%'").await, vec![2, 6]);
+    assert_eq!(matching_ids(client, "word ~~ 'A____'").await, vec![1]);
+    assert_eq!(matching_ids(client, "word !~~ '%a%'").await, vec![1, 4, 5]);
+}
+
+/// Test ~~* and !~~* as Unicode-aware ILIKE / NOT ILIKE
+#[tokio::test]
+async fn test_ilike_operators() {
+    let server = setup_words().await;
+    let client = &server.client;
+
+    assert_eq!(matching_ids(client, "word ~~* 'a%'").await, vec![1, 2, 6]);
+    assert_eq!(matching_ids(client, "word ~~* 'éclair'").await, vec![5]);
+    assert_eq!(matching_ids(client, "word !~~* 'A%'").await, vec![3, 4, 5]);
+    assert_eq!(matching_ids(client, "word ILIKE 'É%'").await, vec![5]);
+}
+
+/// Test escaped wildcards, both with the default backslash and an explicit ESCAPE
+#[tokio::test]
+async fn test_like_escape_semantics() {
+    let server = setup_words().await;
+    let client = &server.client;
+
+    assert_eq!(matching_ids(client, r"word ~~ '%\%%'").await, vec![4]);
+    assert_eq!(matching_ids(client, r"word ~~ 'a\_b'").await, vec![6]);
+    assert_eq!(matching_ids(client, "word ILIKE 'A!_%' ESCAPE '!'").await, vec![6]);
+
+    // Parameters are bound through the extended protocol
+    let rows = client.query("SELECT id FROM words WHERE word !~~ $1 ORDER BY id", &[&"%a%"]).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get::<_, i32>(0)).collect();
+    assert_eq!(ids, vec![1, 4, 5]);
+}
+
+/// Test LIKE ... ESCAPE matching literal wildcards
+#[tokio::test]
+async fn test_like_with_escape_clause() {
+    let server = setup_words().await;
+    let client = &server.client;
+
+    assert_eq!(matching_ids(client, r"word LIKE '100\%%' ESCAPE '\'").await, vec![4]);
+    assert_eq!(matching_ids(client, "word LIKE '%#%%' ESCAPE '#'").await, vec![4]);
+    assert_eq!(matching_ids(client, "word NOT LIKE '%!_%' ESCAPE '!'").await, vec![1, 2, 3, 4, 5]);
+    // An empty escape string turns escaping off
+    assert_eq!(matching_ids(client, r"word LIKE 'a\_b' ESCAPE ''").await, Vec::<i32>::new());
+
+    let rows = client.query(r"SELECT id FROM words WHERE word LIKE $1 ESCAPE '\' ORDER BY id", &[&r"%\%%"]).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get::<_, i32>(0)).collect();
+    assert_eq!(ids, vec![4]);
+
+    let err = client.simple_query("SELECT id FROM words WHERE word LIKE 'a' ESCAPE '##'").await.unwrap_err();
+    assert_eq!(err.as_db_error().unwrap().code().code(), "22025");
+}
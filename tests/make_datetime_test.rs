@@ -0,0 +1,45 @@
+mod common;
+use common::*;
+use chrono::NaiveDate;
+use tokio_postgres::error::SqlState;
+
+/// Test building dates, timestamps and intervals from their parts, as report queries do
+#[tokio::test]
+async fn test_make_date_timestamp_interval() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    assert_eq!(simple_values(client, "SELECT make_date(2024, 1, 15) AS day").await, vec!["2024-01-15"]);
+    assert_eq!(
+        simple_values(client, "SELECT make_timestamp(2024, 1, 15, 10, 30, 45) AS at").await,
+        vec!["2024-01-15 10:30:45"]
+    );
+    assert_eq!(simple_values(client, "SELECT make_interval(days => 5) AS span").await, vec!["5 days"]);
+    assert_eq!(
+        simple_values(client, "SELECT make_interval(years => 1, weeks => 2, hours => 3, secs => 1.5) AS span").await,
+        vec!["1 year 14 days 03:00:01.5"]
+    );
+
+    let row = client.query_one("SELECT make_date(2024, 2, 29) AS day", &[]).await.unwrap();
+    assert_eq!(row.get::<_, NaiveDate>(0), NaiveDate::from_ymd_opt(2024, 2, 29).unwrap());
+
+    server.abort();
+}
+
+/// Test that a month or day outside the calendar raises datetime_field_overflow
+#[tokio::test]
+async fn test_make_date_out_of_range() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    for query in ["SELECT make_date(2024, 2, 30)", "SELECT make_timestamp(2024, 13, 1, 0, 0, 0)"] {
+        let err = client.simple_query(query).await.unwrap_err();
+        assert_eq!(err.code(), Some(&SqlState::DATETIME_FIELD_OVERFLOW), "{query}: {err:?}");
+    }
+
+    let err = client.query("SELECT make_date(2023, 2, 29)", &[]).await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::DATETIME_FIELD_OVERFLOW), "{err:?}");
+    assert!(err.as_db_error().unwrap().message().contains("date field value out of range: 2023-02-29"), "{err:?}");
+
+    server.abort();
+}
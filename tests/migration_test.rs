@@ -23,7 +23,7 @@ fn test_fresh_database_migration() {
     
     // Should apply all migrations
     assert_eq!(applied.len(), MIGRATIONS.len());
-    assert_eq!(applied, vec![1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27]);
+    assert_eq!(applied, vec![1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41]);
     
     // Verify schema version
     let conn = runner.into_connection();
@@ -32,7 +32,7 @@ fn test_fresh_database_migration() {
         [],
         |row| row.get(0)
     ).unwrap();
-    assert_eq!(version, "27");
+    assert_eq!(version, "41");
     
     // Now check should pass
     let runner2 = MigrationRunner::new(conn);
@@ -68,7 +68,7 @@ fn test_idempotent_migrations() {
     let conn = Connection::open(&db_path).unwrap();
     let mut runner = MigrationRunner::new(conn);
     let applied = runner.run_pending_migrations().unwrap();
-    assert_eq!(applied.len(), 27);
+    assert_eq!(applied.len(), 41);
     drop(runner);
     
     // Second run - should apply nothing
@@ -109,8 +109,8 @@ fn test_existing_schema_detection() {
     let mut runner = MigrationRunner::new(conn);
     let applied = runner.run_pending_migrations().unwrap();
     
-    // Should recognize existing schema as version 1 and only apply versions 2-27
-    assert_eq!(applied.len(), 26);
+    // Should recognize existing schema as version 1 and only apply versions 2-41
+    assert_eq!(applied.len(), 40);
     assert_eq!(applied[0], 2);
     assert_eq!(applied[1], 3);
     assert_eq!(applied[2], 4);
@@ -123,6 +123,20 @@ fn test_existing_schema_detection() {
     assert_eq!(applied[9], 11);
     assert_eq!(applied[10], 12);
     assert_eq!(applied[25], 27);
+    assert_eq!(applied[26], 28);
+    assert_eq!(applied[27], 29);
+    assert_eq!(applied[28], 30);
+    assert_eq!(applied[29], 31);
+    assert_eq!(applied[30], 32);
+    assert_eq!(applied[31], 33);
+    assert_eq!(applied[32], 34);
+    assert_eq!(applied[33], 35);
+    assert_eq!(applied[34], 36);
+    assert_eq!(applied[35], 37);
+    assert_eq!(applied[36], 38);
+    assert_eq!(applied[37], 39);
+    assert_eq!(applied[38], 40);
+    assert_eq!(applied[39], 41);
     
     // Verify final version
     let conn = runner.into_connection();
@@ -131,7 +145,7 @@ fn test_existing_schema_detection() {
         [],
         |row| row.get(0)
     ).unwrap();
-    assert_eq!(version, "27");
+    assert_eq!(version, "41");
     
     // Now check should pass
     let runner2 = MigrationRunner::new(conn);
@@ -156,7 +170,7 @@ fn test_migration_history() {
     .unwrap()
     .collect::<Result<Vec<_>, _>>().unwrap();
     
-    assert_eq!(migrations.len(), 27);
+    assert_eq!(migrations.len(), 41);
     assert_eq!(migrations[0], (1, "initial_schema".to_string(), "completed".to_string()));
     assert_eq!(migrations[1], (2, "enum_type_support".to_string(), "completed".to_string()));
     assert_eq!(migrations[2], (3, "datetime_timezone_support".to_string(), "completed".to_string()));
@@ -169,6 +183,20 @@ fn test_migration_history() {
     assert_eq!(migrations[9], (10, "typcategory_support".to_string(), "completed".to_string()));
     assert_eq!(migrations[10], (11, "fix_catalog_views".to_string(), "completed".to_string()));
     assert_eq!(migrations[25], (26, "enhanced_pg_attribute_support".to_string(), "completed".to_string()));
+    assert_eq!(migrations[27], (28, "psql_describe_support".to_string(), "completed".to_string()));
+    assert_eq!(migrations[28], (29, "table_inheritance".to_string(), "completed".to_string()));
+    assert_eq!(migrations[29], (30, "pg_class_size_estimates".to_string(), "completed".to_string()));
+    assert_eq!(migrations[30], (31, "column_statistics".to_string(), "completed".to_string()));
+    assert_eq!(migrations[31], (32, "identity_columns".to_string(), "completed".to_string()));
+    assert_eq!(migrations[32], (33, "generated_columns".to_string(), "completed".to_string()));
+    assert_eq!(migrations[33], (34, "stat_activity".to_string(), "completed".to_string()));
+    assert_eq!(migrations[34], (35, "large_objects".to_string(), "completed".to_string()));
+    assert_eq!(migrations[35], (36, "nulls_not_distinct".to_string(), "completed".to_string()));
+    assert_eq!(migrations[36], (37, "serial_defaults".to_string(), "completed".to_string()));
+    assert_eq!(migrations[37], (38, "comment_oids".to_string(), "completed".to_string()));
+    assert_eq!(migrations[38], (39, "constraint_relation_oids".to_string(), "completed".to_string()));
+    assert_eq!(migrations[39], (40, "psql_describe_views".to_string(), "completed".to_string()));
+    assert_eq!(migrations[40], (41, "interval_text".to_string(), "completed".to_string()));
 }
 
 #[test] 
@@ -219,4 +247,117 @@ fn test_check_up_to_date_database() {
     
     // Check should pass for up-to-date database
     assert!(runner.check_schema_version().is_ok());
-}
\ No newline at end of file
+}
+#[test]
+fn test_comment_oids_follow_pg_class() {
+    use std::collections::hash_map::DefaultHasher;
+    use std::hash::{Hash, Hasher};
+
+    let temp_dir = TempDir::new().unwrap();
+    let db_path = temp_dir.path().join("test.db");
+    
+    let conn = Connection::open(&db_path).unwrap();
+    let mut runner = MigrationRunner::new(conn);
+    runner.run_pending_migrations().unwrap();
+    let conn = runner.into_connection();
+    
+    // A comment stored under the OID COMMENT ON used before version 38
+    let mut hasher = DefaultHasher::new();
+    "books".hash(&mut hasher);
+    let old_oid = ((hasher.finish() & 0x7FFFFFFF) % 1000000 + 16384) as i64;
+    conn.execute_batch("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT)").unwrap();
+    conn.execute(
+        "INSERT INTO __pgsqlite_comments (object_oid, catalog_name, subobject_id, comment_text) VALUES (?1, 'pg_class', 2, 'Title')",
+        [old_oid],
+    ).unwrap();
+    conn.execute("UPDATE __pgsqlite_metadata SET value = '37' WHERE key = 'schema_version'", []).unwrap();
+    
+    let mut runner = MigrationRunner::new(conn);
+    assert_eq!(runner.run_pending_migrations().unwrap(), vec![38, 39, 40, 41]);
+    let conn = runner.into_connection();
+    
+    // The comment now sits under the OID the pg_class view computes for the table
+    let (oid, comment): (i64, String) = conn.query_row(
+        "SELECT object_oid, comment_text FROM __pgsqlite_comments WHERE catalog_name = 'pg_class' AND subobject_id = 2",
+        [],
+        |row| Ok((row.get(0)?, row.get(1)?)),
+    ).unwrap();
+    let pg_class_oid: i64 = conn.query_row(
+        "SELECT ((unicode(substr(name, 1, 1)) * 1000000) + (unicode(substr(name || ' ', 2, 1)) * 10000) +
+                 (unicode(substr(name || '  ', 3, 1)) * 100) + (length(name) * 7)) % 1000000 + 16384
+         FROM sqlite_master WHERE name = 'books'",
+        [],
+        |row| row.get(0),
+    ).unwrap();
+    assert_ne!(oid, old_oid);
+    assert_eq!(oid, pg_class_oid);
+    assert_eq!(comment, "Title");
+}
+
+#[test]
+fn test_constraint_relation_oids_follow_pg_class() {
+    let temp_dir = TempDir::new().unwrap();
+    let db_path = temp_dir.path().join("test.db");
+    
+    let conn = Connection::open(&db_path).unwrap();
+    let mut runner = MigrationRunner::new(conn);
+    runner.run_pending_migrations().unwrap();
+    let conn = runner.into_connection();
+    
+    // A constraint as the pg_catalog_tables migration recorded it before version 39
+    let old_oid = pgsqlite::utils::generate_oid_string("books");
+    conn.execute_batch("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT)").unwrap();
+    conn.execute(
+        "INSERT INTO pg_constraint (oid, conname, contype, conrelid, conkey) VALUES ('90001', 'books_pkey', 'p', ?1, '1')",
+        [&old_oid],
+    ).unwrap();
+    conn.execute("UPDATE __pgsqlite_metadata SET value = '38' WHERE key = 'schema_version'", []).unwrap();
+    
+    let mut runner = MigrationRunner::new(conn);
+    assert_eq!(runner.run_pending_migrations().unwrap(), vec![39, 40, 41]);
+    let conn = runner.into_connection();
+    
+    // The constraint now joins to the OID the pg_class view computes for the table
+    let conrelid: String = conn.query_row(
+        "SELECT conrelid FROM pg_constraint WHERE conname = 'books_pkey'",
+        [],
+        |row| row.get(0),
+    ).unwrap();
+    let pg_class_oid: i64 = conn.query_row(
+        "SELECT ((unicode(substr(name, 1, 1)) * 1000000) + (unicode(substr(name || ' ', 2, 1)) * 10000) +
+                 (unicode(substr(name || '  ', 3, 1)) * 100) + (length(name) * 7)) % 1000000 + 16384
+         FROM sqlite_master WHERE name = 'books'",
+        [],
+        |row| row.get(0),
+    ).unwrap();
+    assert_ne!(conrelid, old_oid);
+    assert_eq!(conrelid, pg_class_oid.to_string());
+}
+
+#[test]
+fn test_interval_microseconds_become_text() {
+    let temp_dir = TempDir::new().unwrap();
+    let db_path = temp_dir.path().join("test.db");
+    
+    let conn = Connection::open(&db_path).unwrap();
+    let mut runner = MigrationRunner::new(conn);
+    runner.run_pending_migrations().unwrap();
+    let conn = runner.into_connection();
+    
+    // Before version 41 only intervals with a month part were stored as text
+    conn.execute_batch("
+        CREATE TABLE durations (id INTEGER PRIMARY KEY, span INTEGER);
+        INSERT INTO __pgsqlite_schema (table_name, column_name, pg_type, sqlite_type) VALUES ('durations', 'span', 'INTERVAL', 'INTEGER');
+        INSERT INTO durations (id, span) VALUES (1, '1 year 2 mons 3 days 04:05:06'), (2, 5400000000), (3, '1 mon');
+        UPDATE __pgsqlite_metadata SET value = '40' WHERE key = 'schema_version';
+    ").unwrap();
+    
+    let mut runner = MigrationRunner::new(conn);
+    assert_eq!(runner.run_pending_migrations().unwrap(), vec![41]);
+    let conn = runner.into_connection();
+    
+    let spans: Vec<String> = conn.prepare("SELECT span FROM durations ORDER BY id").unwrap()
+        .query_map([], |row| row.get(0)).unwrap()
+        .collect::<Result<_, _>>().unwrap();
+    assert_eq!(spans, vec!["1 year 2 mons 3 days 04:05:06", "01:30:00", "1 mon"]);
+}
@@ -0,0 +1,77 @@
+mod common;
+use common::*;
+use tokio_postgres::SimpleQueryMessage;
+
+fn command_completes(results: &[SimpleQueryMessage]) -> Vec<u64> {
+    results.iter()
+        .filter_map(|msg| match msg {
+            SimpleQueryMessage::CommandComplete(rows) => Some(*rows),
+            _ => None,
+        })
+        .collect()
+}
+
+/// Test a three-statement batch in one Query message, with semicolons inside literals
+#[tokio::test]
+async fn test_multi_statement_batch() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let results = client.simple_query(
+        "CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);
+         CREATE INDEX notes_body_idx ON notes (body);
+         INSERT INTO notes (id, body) VALUES (1, 'first; still first'), (2, $$second;$$);"
+    ).await.unwrap();
+    assert_eq!(command_completes(&results), vec![0, 0, 2]);
+
+    assert_eq!(simple_values(client, "SELECT body FROM notes ORDER BY id").await, vec!["first; still first", "second;"]);
+}
+
+/// Test that a failing statement aborts the rest of the batch and rolls back the
+/// implicit transaction, unless the batch manages its own transaction
+#[tokio::test]
+async fn test_multi_statement_batch_failure() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let err = client.simple_query(
+        "INSERT INTO notes (id, body) VALUES (1, 'kept?');
+         INSERT INTO notes (id, body) VALUES (1, 'duplicate');
+         INSERT INTO notes (id, body) VALUES (3, 'never run')"
+    ).await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::UNIQUE_VIOLATION), "{err}");
+
+    let rows = client.query("SELECT COUNT(*) FROM notes", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i64>(0), 0);
+
+    // The connection is usable again and statements after a COMMIT stay committed
+    let err = client.simple_query(
+        "BEGIN; INSERT INTO notes (id, body) VALUES (1, 'kept'); COMMIT;
+         INSERT INTO notes (id, body) VALUES (1, 'duplicate')"
+    ).await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::UNIQUE_VIOLATION), "{err}");
+
+    let rows = client.query("SELECT body FROM notes", &[]).await.unwrap();
+    assert_eq!(rows.len(), 1);
+    assert_eq!(rows[0].get::<_, &str>(0), "kept");
+}
+
+/// Test that a batch containing VACUUM runs without the implicit transaction
+#[tokio::test]
+async fn test_multi_statement_batch_with_vacuum() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let results = client.simple_query(
+        "CREATE TABLE logs (id INTEGER PRIMARY KEY, line TEXT); VACUUM;"
+    ).await.unwrap();
+    assert_eq!(command_completes(&results), vec![0, 0]);
+
+    client.batch_execute("INSERT INTO logs (id, line) VALUES (1, 'a'); VACUUM ANALYZE logs").await.unwrap();
+    assert_eq!(simple_values(client, "SELECT line FROM logs").await, vec!["a"]);
+}
@@ -0,0 +1,58 @@
+mod common;
+use common::*;
+use tokio_postgres::error::SqlState;
+
+/// Test that a UNIQUE NULLS NOT DISTINCT column rejects a second NULL
+#[tokio::test]
+async fn test_unique_nulls_not_distinct_column() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE accounts (id INTEGER PRIMARY KEY, email TEXT UNIQUE NULLS NOT DISTINCT, nickname TEXT UNIQUE)"
+    ).await.unwrap();
+
+    client.execute("INSERT INTO accounts (id, email, nickname) VALUES (1, NULL, NULL)", &[]).await.unwrap();
+    // Plain UNIQUE still treats NULLs as distinct
+    client.execute("INSERT INTO accounts (id, email, nickname) VALUES (2, 'b@example.com', NULL)", &[]).await.unwrap();
+
+    let err = client.execute("INSERT INTO accounts (id, email, nickname) VALUES (3, NULL, 'c')", &[]).await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::UNIQUE_VIOLATION));
+
+    // Non-NULL duplicates are rejected as usual
+    let err = client.execute("INSERT INTO accounts (id, email) VALUES (4, 'b@example.com')", &[]).await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::UNIQUE_VIOLATION));
+
+    let count = client.query_one("SELECT count(*) FROM accounts", &[]).await.unwrap();
+    assert_eq!(count.get::<_, i64>(0), 2);
+}
+
+/// Test a multi-column NULLS NOT DISTINCT unique index and its definition
+#[tokio::test]
+async fn test_unique_index_nulls_not_distinct() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE logins (id INTEGER PRIMARY KEY, tenant INTEGER, login TEXT);
+         CREATE UNIQUE INDEX logins_tenant_login_idx ON logins (tenant, login) NULLS NOT DISTINCT;"
+    ).await.unwrap();
+
+    client.simple_query("INSERT INTO logins VALUES (1, NULL, 'ann'), (2, 1, 'ann')").await.unwrap();
+    let err = client.simple_query("INSERT INTO logins VALUES (3, NULL, 'ann')").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::UNIQUE_VIOLATION));
+
+    // Moving a row onto an existing key with a NULL fails too
+    let err = client.simple_query("UPDATE logins SET tenant = NULL WHERE id = 2").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::UNIQUE_VIOLATION));
+
+    let definition = first_value(
+        client,
+        "SELECT pg_get_indexdef(i.indexrelid) FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid \
+         WHERE c.relname = 'logins_tenant_login_idx'"
+    ).await;
+    assert_eq!(
+        definition.as_deref(),
+        Some("CREATE UNIQUE INDEX logins_tenant_login_idx ON public.logins USING btree (tenant, login) NULLS NOT DISTINCT")
+    );
+}
@@ -373,16 +373,14 @@ async fn test_numeric_cast_operations() {
     ).await.unwrap();
     assert_eq!(row.get::<_, String>(0), "456.79");
     
-    // Test CAST with precision overflow
-    // Note: Our implementation validates literals at INSERT time, not computed values
-    // This is a limitation compared to full PostgreSQL behavior
-    client.execute(
+    // Test CAST with precision overflow: 99999999.999 rounds to 100000000.00,
+    // which no longer fits NUMERIC(10,2)
+    let result = client.execute(
         "INSERT INTO cast_test (id, numeric_val) 
          SELECT 5, CAST(text_val AS NUMERIC(10,2)) FROM cast_test WHERE id = 4",
         &[]
-    ).await.unwrap();
-    
-    // The value gets stored but may be truncated/rounded by SQLite
+    ).await;
+    assert!(result.is_err(), "Expected numeric field overflow");
     
     // Verify successful cast
     let row = client.query_one(
@@ -0,0 +1,106 @@
+mod common;
+use common::*;
+use tokio_postgres::types::Type;
+
+async fn setup_products() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE products (id INTEGER PRIMARY KEY, price NUMERIC(10,2), rate NUMERIC(10,4))").await?;
+            db.execute("INSERT INTO products (id, price, rate) VALUES
+                (1, 0.50, 2.6750),
+                (2, 9.99, 1.0050),
+                (3, 10.00, -0.1250),
+                (4, 42.25, 0.1249),
+                (5, 99.99, -2.5000),
+                (6, 150.00, 7.0000)").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+/// Test histogram buckets over an equal-width range and over explicit thresholds
+#[tokio::test]
+async fn test_width_bucket() {
+    let server = setup_products().await;
+    let client = &server.client;
+
+    let results = client.simple_query(
+        "SELECT id, width_bucket(price, 0, 100, 10) AS bucket FROM products ORDER BY id"
+    ).await.unwrap();
+    assert_eq!(column(&results, "bucket"), vec!["1", "1", "2", "5", "10", "11"]);
+
+    let results = client.simple_query(
+        "SELECT id, width_bucket(price, ARRAY[10, 50, 100]) AS bucket FROM products ORDER BY id"
+    ).await.unwrap();
+    assert_eq!(column(&results, "bucket"), vec!["0", "0", "1", "1", "2", "3"]);
+
+    let stmt = client.prepare("SELECT width_bucket(price, 0, 100, 10) AS bucket FROM products").await.unwrap();
+    assert_eq!(stmt.columns()[0].type_(), &Type::INT4);
+
+    let err = client.simple_query("SELECT width_bucket(price, 0, 100, 0) FROM products").await.unwrap_err();
+    assert!(err.to_string().contains("count must be greater than zero"), "{err}");
+}
+
+/// Test that round(numeric, scale) rounds halves away from zero and keeps the scale on numeric columns
+#[tokio::test]
+async fn test_round_numeric_scale() {
+    let server = setup_products().await;
+    let client = &server.client;
+
+    let results = client.simple_query("SELECT id, round(rate, 2) AS rounded FROM products ORDER BY id").await.unwrap();
+    assert_eq!(column(&results, "rounded"), vec!["2.68", "1.01", "-0.13", "0.12", "-2.50", "7.00"]);
+
+    // Values that aren't numeric columns are rounded as float8
+    let results = client.simple_query("SELECT round(2.5, 0) AS a, round(-2.5, 0) AS b, round(1250, -2) AS c").await.unwrap();
+    let float = |name: &str| column(&results, name)[0].parse::<f64>().unwrap();
+    assert_eq!(float("a"), 3.0);
+    assert_eq!(float("b"), -3.0);
+    assert_eq!(float("c"), 1300.0);
+
+    let stmt = client.prepare("SELECT round(rate, 2) AS rounded FROM products").await.unwrap();
+    assert_eq!(stmt.columns()[0].type_(), &Type::NUMERIC);
+}
+
+/// Test that trunc, ceil, floor, mod, power and sqrt work on the exact values of numeric
+/// columns, where float math would leave round-off behind
+#[tokio::test]
+async fn test_numeric_math_precision() {
+    let server = setup_products().await;
+    let client = &server.client;
+
+    let results = client.simple_query(
+        "SELECT id, trunc(rate, 2) AS truncated, ceil(price) AS ceiling, floor(price) AS floored \
+         FROM products ORDER BY id"
+    ).await.unwrap();
+    assert_eq!(column(&results, "truncated"), vec!["2.67", "1.00", "-0.12", "0.12", "-2.50", "7.00"]);
+    assert_eq!(column(&results, "ceiling"), vec!["1", "10", "10", "43", "100", "150"]);
+    assert_eq!(column(&results, "floored"), vec!["0", "9", "10", "42", "99", "150"]);
+
+    // In float8, 9.99 % 3 is 0.9900000000000002 and 9.99 ^ 2 is 99.80010000000001
+    let results = client.simple_query(
+        "SELECT mod(price, 3) AS remainder, power(price, 2) AS squared FROM products WHERE id = 2"
+    ).await.unwrap();
+    assert_eq!(column(&results, "remainder"), vec!["0.99"]);
+    assert_eq!(column(&results, "squared"), vec!["99.8001"]);
+
+    let results = client.simple_query("SELECT sqrt(price) AS root FROM products WHERE id = 3").await.unwrap();
+    let root = column(&results, "root");
+    assert!(root[0].starts_with("3.16227766016837933199889354"), "{root:?}");
+
+    let stmt = client.prepare("SELECT trunc(rate, 2) AS truncated FROM products").await.unwrap();
+    assert_eq!(stmt.columns()[0].type_(), &Type::NUMERIC);
+
+    // Values that aren't numeric columns use float8 math
+    let results = client.simple_query(
+        "SELECT trunc(3.789, 2) AS truncated, mod(7.5, 2) AS remainder, power(1.5, 2) AS squared, sqrt(2.25) AS root"
+    ).await.unwrap();
+    let float = |name: &str| column(&results, name)[0].parse::<f64>().unwrap();
+    assert_eq!(float("truncated"), 3.78);
+    assert_eq!(float("remainder"), 1.5);
+    assert_eq!(float("squared"), 2.25);
+    assert_eq!(float("root"), 1.5);
+
+    let err = client.simple_query("SELECT mod(price, 0) FROM products").await.unwrap_err();
+    assert!(err.to_string().contains("division by zero"), "{err}");
+}
@@ -0,0 +1,44 @@
+mod common;
+use common::*;
+
+/// Test the NOT NULL flags and defaults an ORM migrator reads back: a SERIAL key has
+/// its sequence's nextval() as default, like a NOT NULL column with a DEFAULT has its value
+#[tokio::test]
+async fn test_pg_attribute_not_null_and_defaults() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (
+                id SERIAL PRIMARY KEY,
+                title TEXT NOT NULL DEFAULT 'Untitled',
+                subtitle TEXT
+            )").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let results = client.simple_query(
+        "SELECT attname, attnotnull, atthasdef, adsrc FROM pg_attribute WHERE attrelid = 'books'::regclass"
+    ).await.unwrap();
+    assert_eq!(rows(&results), vec![
+        some(&["id", "t", "t", "nextval('books_id_seq'::regclass)"]),
+        some(&["title", "t", "t", "'Untitled'"]),
+        vec![Some("subtitle".to_string()), Some("f".to_string()), Some("f".to_string()), None],
+    ]);
+
+    let results = client.simple_query(
+        "SELECT a.attname, a.attnotnull, a.atthasdef, pg_get_expr(d.adbin, d.adrelid) AS default_expr \
+         FROM pg_class c \
+         JOIN pg_attribute a ON a.attrelid = c.oid \
+         LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum \
+         WHERE c.relname = 'books' AND a.attnum > 0 \
+         ORDER BY a.attnum"
+    ).await.unwrap();
+    assert_eq!(rows(&results), vec![
+        some(&["id", "t", "t", "nextval('books_id_seq'::regclass)"]),
+        some(&["title", "t", "t", "'Untitled'::text"]),
+        vec![Some("subtitle".to_string()), Some("f".to_string()), Some("f".to_string()), None],
+    ]);
+
+    server.abort();
+}
@@ -0,0 +1,48 @@
+mod common;
+use common::*;
+
+fn first_row(messages: &[tokio_postgres::SimpleQueryMessage]) -> Vec<String> {
+    let row = rows(messages).into_iter().next().expect("no rows returned");
+    row.into_iter().map(Option::unwrap_or_default).collect()
+}
+
+/// Test that pg_class reports row and page estimates and the right relkind
+#[tokio::test]
+async fn test_pg_class_reltuples_and_relpages() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT, total INTEGER)").await?;
+            db.execute("CREATE INDEX orders_customer_idx ON orders (customer)").await?;
+            db.execute("CREATE VIEW big_orders AS SELECT * FROM orders WHERE total > 100").await?;
+            db.execute(
+                "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 250) \
+                 INSERT INTO orders (customer, total) SELECT 'customer ' || i, i FROM n"
+            ).await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let row = first_row(&client.simple_query(
+        "SELECT reltuples, relpages, relkind FROM pg_class WHERE relname = 'orders'"
+    ).await.unwrap());
+    assert_eq!(row[0].parse::<f64>().unwrap(), 250.0);
+    assert!(row[1].parse::<i32>().unwrap() > 0, "relpages {}", row[1]);
+    assert_eq!(row[2], "r");
+
+    let row = first_row(&client.simple_query("SELECT relkind FROM pg_class WHERE relname = 'orders_customer_idx'").await.unwrap());
+    assert_eq!(row[0], "i");
+    let row = first_row(&client.simple_query("SELECT relkind, reltuples FROM pg_class WHERE relname = 'big_orders'").await.unwrap());
+    assert_eq!(row[0], "v");
+    assert_eq!(row[1].parse::<f64>().unwrap(), -1.0);
+
+    // The estimate follows ANALYZE
+    client.batch_execute("ANALYZE orders").await.unwrap();
+    client.batch_execute("DELETE FROM orders WHERE id > 50").await.unwrap();
+    let row = first_row(&client.simple_query("SELECT reltuples FROM pg_class WHERE relname = 'orders'").await.unwrap());
+    assert_eq!(row[0].parse::<f64>().unwrap(), 250.0);
+
+    client.batch_execute("ANALYZE orders").await.unwrap();
+    let row = first_row(&client.simple_query("SELECT reltuples FROM pg_class WHERE relname = 'orders'").await.unwrap());
+    assert_eq!(row[0].parse::<f64>().unwrap(), 50.0);
+}
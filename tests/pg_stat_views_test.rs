@@ -7,34 +7,42 @@ async fn test_pg_stat_activity_view() {
     // Create a temporary file database for the test
     let temp_file = format!("/tmp/test_pg_stat_activity_{}.db", uuid::Uuid::new_v4());
     let db = Arc::new(DbHandler::new(&temp_file).expect("Failed to create database"));
-    let _session = Arc::new(SessionState::new("test".to_string(), "test".to_string()));
+    let session = Arc::new(SessionState::new("test".to_string(), "test".to_string()));
 
     // Test will run migrations automatically via DbHandler::new
 
-    // Test pg_stat_activity view basic structure
-    let result = db.query("SELECT datid, datname, pid, usename, application_name, state, backend_type FROM pg_stat_activity").await;
+    // Test pg_stat_activity view basic structure; other tests' sessions are listed too
+    let result = db.query(&format!(
+        "SELECT datid, datname, pid, usename, application_name, state, backend_type FROM pg_stat_activity WHERE pid = {}",
+        session.pid
+    )).await;
     assert!(result.is_ok(), "Failed to query pg_stat_activity: {:?}", result);
 
     let response = result.unwrap();
     assert_eq!(response.columns.len(), 7, "pg_stat_activity should have 7 columns in this query");
-    assert_eq!(response.rows.len(), 1, "pg_stat_activity should return 1 row");
+    assert_eq!(response.rows.len(), 1, "pg_stat_activity should list the session");
 
     // Verify column values
     if let Some(first_row) = response.rows.first() {
         // datid should be 1
         assert_eq!(first_row[0], Some(b"1".to_vec()));
-        // datname should be 'main'
-        assert_eq!(first_row[1], Some(b"main".to_vec()));
-        // usename should be 'postgres'
-        assert_eq!(first_row[3], Some(b"postgres".to_vec()));
-        // application_name should be 'pgsqlite'
-        assert_eq!(first_row[4], Some(b"pgsqlite".to_vec()));
+        // datname and usename come from the session
+        assert_eq!(first_row[1], Some(b"test".to_vec()));
+        assert_eq!(first_row[3], Some(b"test".to_vec()));
+        // application_name wasn't set
+        assert_eq!(first_row[4], Some(b"".to_vec()));
         // state should be 'idle'
         assert_eq!(first_row[5], Some(b"idle".to_vec()));
         // backend_type should be 'client backend'
         assert_eq!(first_row[6], Some(b"client backend".to_vec()));
     }
 
+    // A closed session is no longer listed
+    let pid = session.pid;
+    drop(session);
+    let response = db.query(&format!("SELECT pid FROM pg_stat_activity WHERE pid = {pid}")).await.unwrap();
+    assert!(response.rows.is_empty());
+
     // Clean up
     std::fs::remove_file(&temp_file).ok();
 }
@@ -0,0 +1,47 @@
+mod common;
+use common::*;
+
+async fn setup_products() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE products (
+                id UUID PRIMARY KEY,
+                price NUMERIC(10,2),
+                name TEXT,
+                attributes JSONB,
+                created_at TIMESTAMPTZ
+            )").await?;
+            db.execute("INSERT INTO products (id, price, name, attributes, created_at) VALUES
+                ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11', 19.99, 'Widget', '{\"color\": \"red\"}', '2024-01-15 10:30:00+00')").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+/// Test pg_typeof on column references, resolved from column metadata
+#[tokio::test]
+async fn test_pg_typeof_columns() {
+    let server = setup_products().await;
+    let client = &server.client;
+
+    let row = client.query_one(
+        "SELECT pg_typeof(price), pg_typeof(id), pg_typeof(p.name), pg_typeof(attributes), pg_typeof(created_at) FROM products p",
+        &[],
+    ).await.unwrap();
+    assert_eq!(row.get::<_, String>(0), "numeric");
+    assert_eq!(row.get::<_, String>(1), "uuid");
+    assert_eq!(row.get::<_, String>(2), "text");
+    assert_eq!(row.get::<_, String>(3), "jsonb");
+    assert_eq!(row.get::<_, String>(4), "timestamp with time zone");
+}
+
+/// Test pg_typeof on literals and expressions through the simple protocol
+#[tokio::test]
+async fn test_pg_typeof_literals() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let results = client.simple_query("SELECT pg_typeof(42), pg_typeof(1.5), pg_typeof('abc'), pg_typeof('abc'::text), pg_typeof(true)").await.unwrap();
+    assert_eq!(rows(&results), vec![some(&["integer", "numeric", "unknown", "text", "boolean"])]);
+}
@@ -0,0 +1,38 @@
+mod common;
+use common::*;
+
+/// Test that ORDER BY location <-> point(...) returns the nearest stores first
+#[tokio::test]
+async fn test_order_by_distance() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE stores (id INTEGER PRIMARY KEY, name TEXT, location POINT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "INSERT INTO stores (id, name, location) VALUES (1, 'downtown', point(0, 0));
+         INSERT INTO stores (id, name, location) VALUES (2, 'harbor', '(9,9)');
+         INSERT INTO stores (id, name, location) VALUES (3, 'airport', '( 3.5 , -4 )'::point);
+         INSERT INTO stores (id, name, location) VALUES (4, 'mall', point(6, 5));"
+    ).await.unwrap();
+
+    assert_eq!(simple_values(client, "SELECT location FROM stores WHERE id = 3").await, vec!["(3.5,-4)"]);
+
+    assert_eq!(
+        simple_values(client, "SELECT name FROM stores ORDER BY location <-> point(5, 5)").await,
+        vec!["mall", "harbor", "downtown", "airport"]
+    );
+    assert_eq!(
+        simple_values(client, "SELECT name FROM stores ORDER BY location <-> '(0,-1)'::point LIMIT 2").await,
+        vec!["downtown", "airport"]
+    );
+
+    // The distance compares like any other value
+    assert_eq!(simple_values(client, "SELECT name FROM stores WHERE id = 1 AND point(0, 0) <-> point(3, 4) = 5").await, vec!["downtown"]);
+    let rows = client.query("SELECT id FROM stores WHERE location <-> point(0, 0) < 6 ORDER BY id", &[]).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![1, 3]);
+}
@@ -0,0 +1,69 @@
+mod common;
+use common::*;
+use tokio_postgres::SimpleQueryMessage;
+use tokio_postgres::error::SqlState;
+
+/// Test preparing a parameterized SELECT, executing it with different arguments and
+/// deallocating it
+#[tokio::test]
+async fn test_prepare_execute_deallocate() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE products (id INTEGER PRIMARY KEY, name TEXT, price NUMERIC(10,2), active BOOLEAN);
+         INSERT INTO products VALUES (1, 'lamp', 19.99, true), (2, 'desk', 120.00, true), (3, 'chair', 45.50, false);"
+    ).await.unwrap();
+
+    client.simple_query(
+        "PREPARE by_price (numeric, boolean) AS SELECT id, name, price, active FROM products WHERE price < $1 AND active = $2 ORDER BY id"
+    ).await.unwrap();
+
+    let result = client.simple_query("EXECUTE by_price (100, true)").await.unwrap();
+    assert_eq!(rows(&result), vec![some(&["1", "lamp", "19.99", "t"])]);
+
+    // The result set is typed like the prepared query's
+    let SimpleQueryMessage::RowDescription(columns) = &result[0] else {
+        panic!("expected a RowDescription, got {:?}", result[0]);
+    };
+    assert_eq!(columns.len(), 4);
+    assert_eq!(columns[1].name(), "name");
+
+    let result = client.simple_query("EXECUTE by_price(200, false)").await.unwrap();
+    let found = rows(&result);
+    assert_eq!(found.len(), 1);
+    assert_eq!(found[0][1].as_deref(), Some("chair"));
+    assert_eq!(found[0][3].as_deref(), Some("f"));
+
+    // Preparing the same name twice fails
+    let err = client.simple_query("PREPARE by_price AS SELECT 1").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::DUPLICATE_PSTATEMENT));
+
+    // Arguments have to match the parameters
+    let err = client.simple_query("EXECUTE by_price (100)").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::SYNTAX_ERROR));
+
+    client.simple_query("DEALLOCATE by_price").await.unwrap();
+    let err = client.simple_query("EXECUTE by_price (100, true)").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::INVALID_SQL_STATEMENT_NAME));
+    assert!(err.to_string().contains("prepared statement \"by_price\" does not exist"), "unexpected error: {err}");
+}
+
+/// Test prepared statements that modify data and DEALLOCATE ALL
+#[tokio::test]
+async fn test_prepare_insert_and_deallocate_all() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)").await.unwrap();
+    client.simple_query("PREPARE add_note (int, text) AS INSERT INTO notes VALUES ($1, $2)").await.unwrap();
+    client.simple_query("EXECUTE add_note (1, 'first')").await.unwrap();
+    client.simple_query("EXECUTE add_note (2, 'it''s second')").await.unwrap();
+
+    let result = client.simple_query("SELECT body FROM notes ORDER BY id").await.unwrap();
+    assert_eq!(rows(&result), vec![some(&["first"]), some(&["it's second"])]);
+
+    client.simple_query("DEALLOCATE ALL").await.unwrap();
+    let err = client.simple_query("EXECUTE add_note (3, 'third')").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::INVALID_SQL_STATEMENT_NAME));
+}
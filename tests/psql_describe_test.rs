@@ -0,0 +1,129 @@
+mod common;
+use common::*;
+
+/// Replay the catalog queries psql 16 sends for `\d books`: the table lookup, its columns
+/// with defaults, its indexes, check constraints, foreign keys and the tables referencing it,
+/// then the parents of a child table
+#[tokio::test]
+async fn test_psql_describe_table() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT NOT NULL)").await?;
+            db.execute("CREATE TABLE books (
+                id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                title TEXT NOT NULL,
+                status VARCHAR(20) DEFAULT 'draft',
+                pages INTEGER DEFAULT 0 CHECK (pages >= 0),
+                author_id INTEGER REFERENCES authors(id),
+                isbn TEXT
+            )").await?;
+            db.execute("CREATE TABLE reviews (id INTEGER PRIMARY KEY, book_id UUID REFERENCES books(id), body TEXT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.simple_query("CREATE UNIQUE INDEX books_isbn_idx ON books (isbn)").await.unwrap();
+    client.batch_execute("CREATE TABLE ebooks (url TEXT) INHERITS (books)").await.unwrap();
+
+    let results = client.simple_query(
+        "SELECT c.oid,\n  n.nspname,\n  c.relname\n\
+         FROM pg_catalog.pg_class c\n     LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace\n\
+         WHERE c.relname OPERATOR(pg_catalog.~) '^(books)$' COLLATE pg_catalog.default\n  \
+         AND pg_catalog.pg_table_is_visible(c.oid)\n\
+         ORDER BY 2, 3;"
+    ).await.unwrap();
+    let table = rows(&results);
+    assert_eq!(table.len(), 1);
+    assert_eq!(table[0][1..], some(&["public", "books"])[..]);
+    let oid = table[0][0].clone().expect("books has an oid");
+
+    let results = client.simple_query(&format!(
+        "SELECT a.attname,\n  pg_catalog.format_type(a.atttypid, a.atttypmod),\n  \
+         (SELECT pg_catalog.pg_get_expr(d.adbin, d.adrelid, true)\n   FROM pg_catalog.pg_attrdef d\n   \
+         WHERE d.adrelid = a.attrelid AND d.adnum = a.attnum AND a.atthasdef),\n  \
+         a.attnotnull,\n  \
+         (SELECT c.collname FROM pg_catalog.pg_collation c, pg_catalog.pg_type t\n   \
+         WHERE c.oid = a.attcollation AND t.oid = a.atttypid AND a.attcollation <> t.typcollation) AS attcollation,\n  \
+         a.attidentity,\n  a.attgenerated\n\
+         FROM pg_catalog.pg_attribute a\n\
+         WHERE a.attrelid = '{oid}' AND a.attnum > 0 AND NOT a.attisdropped\n\
+         ORDER BY a.attnum;"
+    )).await.unwrap();
+    assert_eq!(rows(&results), vec![
+        vec![Some("id".to_string()), Some("uuid".to_string()), Some("gen_random_uuid()".to_string()), Some("t".to_string()), None, Some(String::new()), Some(String::new())],
+        vec![Some("title".to_string()), Some("text".to_string()), None, Some("t".to_string()), None, Some(String::new()), Some(String::new())],
+        vec![Some("status".to_string()), Some("character varying(20)".to_string()), Some("'draft'::character varying".to_string()), Some("f".to_string()), None, Some(String::new()), Some(String::new())],
+        vec![Some("pages".to_string()), Some("integer".to_string()), Some("0".to_string()), Some("f".to_string()), None, Some(String::new()), Some(String::new())],
+        vec![Some("author_id".to_string()), Some("integer".to_string()), None, Some("f".to_string()), None, Some(String::new()), Some(String::new())],
+        vec![Some("isbn".to_string()), Some("text".to_string()), None, Some("f".to_string()), None, Some(String::new()), Some(String::new())],
+    ]);
+
+    let results = client.simple_query(&format!(
+        "SELECT c2.relname, i.indisprimary, i.indisunique, i.indisclustered, i.indisvalid, \
+         pg_catalog.pg_get_indexdef(i.indexrelid, 0, true),\n  \
+         pg_catalog.pg_get_constraintdef(con.oid, true), contype, condeferrable, condeferred, i.indisreplident, c2.reltablespace\n\
+         FROM pg_catalog.pg_class c, pg_catalog.pg_class c2, pg_catalog.pg_index i\n  \
+         LEFT JOIN pg_catalog.pg_constraint con ON (conrelid = i.indrelid AND conindid = i.indexrelid AND contype IN ('p','u','x'))\n\
+         WHERE c.oid = '{oid}' AND c.oid = i.indrelid AND i.indexrelid = c2.oid\n\
+         ORDER BY i.indisprimary DESC, c2.relname;"
+    )).await.unwrap();
+    let indexes: Vec<_> = rows(&results).into_iter().map(|row| vec![row[0].clone(), row[5].clone()]).collect();
+    assert!(indexes.contains(&some(&["books_isbn_idx", "CREATE UNIQUE INDEX books_isbn_idx ON public.books USING btree (isbn)"])), "{indexes:?}");
+
+    let results = client.simple_query(&format!(
+        "SELECT r.conname, pg_catalog.pg_get_constraintdef(r.oid, true)\n\
+         FROM pg_catalog.pg_constraint r\n\
+         WHERE r.conrelid = '{oid}' AND r.contype = 'c'\n\
+         ORDER BY 1;"
+    )).await.unwrap();
+    assert_eq!(rows(&results), vec![some(&["books_check1", "CHECK ((pages >= 0))"])]);
+
+    let results = client.simple_query(&format!(
+        "SELECT true as sametable, conname,\n  \
+         pg_catalog.pg_get_constraintdef(r.oid, true) as condef,\n  \
+         conrelid::pg_catalog.regclass AS ontable\n\
+         FROM pg_catalog.pg_constraint r\n\
+         WHERE r.conrelid = '{oid}'\n    AND r.contype = 'f'\n     AND conparentid = 0\n\
+         ORDER BY conname"
+    )).await.unwrap();
+    assert_eq!(rows(&results), vec![
+        some(&["t", "books_author_id_fkey", "FOREIGN KEY (author_id) REFERENCES authors(id)", "books"]),
+    ]);
+
+    let results = client.simple_query(&format!(
+        "SELECT conname, conrelid::pg_catalog.regclass AS ontable,\n       \
+         pg_catalog.pg_get_constraintdef(oid, true) AS condef\n  \
+         FROM pg_catalog.pg_constraint c\n \
+         WHERE confrelid IN (SELECT pg_catalog.pg_partition_ancestors('{oid}')\n                     \
+         UNION ALL VALUES ('{oid}'::pg_catalog.regclass))\n       \
+         AND contype = 'f' AND conparentid = 0\n\
+         ORDER BY conname;"
+    )).await.unwrap();
+    assert_eq!(rows(&results), vec![
+        some(&["reviews_book_id_fkey", "reviews", "FOREIGN KEY (book_id) REFERENCES books(id)"]),
+    ]);
+
+    // \d ebooks lists the tables it inherits from
+    let results = client.simple_query(
+        "SELECT c.oid,\n  n.nspname,\n  c.relname\n\
+         FROM pg_catalog.pg_class c\n     LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace\n\
+         WHERE c.relname OPERATOR(pg_catalog.~) '^(ebooks)$' COLLATE pg_catalog.default\n  \
+         AND pg_catalog.pg_table_is_visible(c.oid)\n\
+         ORDER BY 2, 3;"
+    ).await.unwrap();
+    let ebooks_oid = rows(&results)[0][0].clone().expect("ebooks has an oid");
+    let results = client.simple_query(&format!(
+        "SELECT c.oid::pg_catalog.regclass\n\
+         FROM pg_catalog.pg_class c, pg_catalog.pg_inherits i\n\
+         WHERE c.oid = i.inhparent AND i.inhrelid = '{ebooks_oid}'\n  \
+         AND c.relkind != 'p' AND c.relkind != 'I'\n\
+         ORDER BY inhseqno;"
+    )).await.unwrap();
+    assert_eq!(rows(&results), vec![some(&["books"])]);
+
+    // The reconstructed defaults are the ones SQLite applies
+    client.simple_query("INSERT INTO books (title) VALUES ('Dune')").await.unwrap();
+    let results = client.simple_query("SELECT length(id) AS id_length, status, pages FROM books").await.unwrap();
+    assert_eq!(rows(&results), vec![some(&["36", "draft", "0"])]);
+}
@@ -0,0 +1,66 @@
+mod common;
+use common::*;
+
+async fn setup_bookings() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE bookings (id INTEGER PRIMARY KEY, room TEXT, start_date DATE, end_date DATE)").await?;
+            db.execute("INSERT INTO bookings (id, room, start_date, end_date) VALUES
+                (1, 'A', '2024-01-01', '2024-01-05'),
+                (2, 'A', '2024-01-08', '2024-01-12'),
+                (3, 'B', '2024-01-15', '2024-01-25'),
+                (4, 'B', '2024-01-20', '2024-01-10'),
+                (5, 'C', '2024-01-20', '2024-01-20')").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+/// Test OVERLAPS against a date range, including reversed and zero-length periods
+#[tokio::test]
+async fn test_overlaps_date_ranges() {
+    let server = setup_bookings().await;
+    let client = &server.client;
+
+    let rows = client.query(
+        "SELECT id FROM bookings WHERE (start_date, end_date) OVERLAPS ('2024-01-10'::date, '2024-01-20'::date) ORDER BY id",
+        &[],
+    ).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    // Booking 1 ends before the range, booking 5 is an instant at the range's open end
+    assert_eq!(ids, vec![2, 3, 4]);
+
+    // Adjacent periods share only an endpoint and do not overlap
+    let rows = client.query(
+        "SELECT id FROM bookings WHERE (start_date, end_date) OVERLAPS ('2024-01-05'::date, '2024-01-08'::date) ORDER BY id",
+        &[],
+    ).await.unwrap();
+    assert!(rows.is_empty());
+
+    let rows = client.query(
+        "SELECT id, (start_date, end_date) OVERLAPS ('2024-01-01'::date, '2024-01-09'::date) AS busy FROM bookings WHERE room = 'A' ORDER BY id",
+        &[],
+    ).await.unwrap();
+    let busy: Vec<(i32, bool)> = rows.iter().map(|row| (row.get(0), row.get(1))).collect();
+    assert_eq!(busy, vec![(1, true), (2, true)]);
+}
+
+/// Test BETWEEN SYMMETRIC with bounds in either order
+#[tokio::test]
+async fn test_between_symmetric() {
+    let server = setup_bookings().await;
+    let client = &server.client;
+
+    let rows = client.query("SELECT id FROM bookings WHERE id BETWEEN SYMMETRIC 4 AND 2 ORDER BY id", &[]).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![2, 3, 4]);
+
+    // Plain BETWEEN with reversed bounds matches nothing
+    let rows = client.query("SELECT id FROM bookings WHERE id BETWEEN 4 AND 2", &[]).await.unwrap();
+    assert!(rows.is_empty());
+
+    let rows = client.query("SELECT id FROM bookings WHERE id NOT BETWEEN SYMMETRIC 5 AND 2 ORDER BY id", &[]).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![1]);
+}
@@ -0,0 +1,120 @@
+mod common;
+use common::*;
+
+async fn ids(client: &tokio_postgres::Client, query: &str) -> Vec<i32> {
+    client.query(query, &[]).await.unwrap().iter().map(|row| row.get(0)).collect()
+}
+
+/// Test the range constructors and their canonical text output
+#[tokio::test]
+async fn test_range_constructors() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    assert_eq!(simple_values(client, "SELECT int4range(1, 10, '[]')").await, vec!["[1,11)"]);
+    assert_eq!(simple_values(client, "SELECT '(1,10]'::int4range").await, vec!["[2,11)"]);
+    assert_eq!(simple_values(client, "SELECT daterange('2024-01-01', '2024-01-31', '[]')").await, vec!["[2024-01-01,2024-02-01)"]);
+    assert_eq!(
+        simple_values(client, "SELECT tsrange('2024-01-01 10:00', '2024-01-01 12:00', '[)')").await,
+        vec!["[\"2024-01-01 10:00:00\",\"2024-01-01 12:00:00\")"]
+    );
+}
+
+/// Test the @>, <@ and && operators on range columns and constructed ranges
+#[tokio::test]
+async fn test_range_containment_and_overlap() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE bookings (id INTEGER PRIMARY KEY, seats INT4RANGE, during TSRANGE)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "INSERT INTO bookings (id, seats, during) VALUES (1, int4range(1, 10), tsrange('2024-01-01 10:00', '2024-01-01 12:00'));
+         INSERT INTO bookings (id, seats, during) VALUES (2, '[5,20]'::int4range, tsrange('2024-01-01 12:00', '2024-01-01 14:00'));
+         INSERT INTO bookings (id, seats, during) VALUES (3, '[30,40)'::int4range, tsrange('2024-01-02 09:00', NULL));"
+    ).await.unwrap();
+
+    // Casts store the canonical text
+    assert_eq!(simple_values(client, "SELECT seats FROM bookings WHERE id = 2").await, vec!["[5,21)"]);
+
+    // Element containment
+    assert_eq!(ids(client, "SELECT id FROM bookings WHERE seats @> 7 ORDER BY id").await, vec![1, 2]);
+    assert_eq!(ids(client, "SELECT id FROM bookings WHERE 10 <@ seats ORDER BY id").await, vec![2]);
+    assert_eq!(
+        ids(client, "SELECT id FROM bookings WHERE during @> '2024-01-01 12:00'::timestamp ORDER BY id").await,
+        vec![2]
+    );
+    assert_eq!(ids(client, "SELECT id FROM bookings WHERE during @> '2030-06-01 00:00' ORDER BY id").await, vec![3]);
+
+    // Range containment
+    assert_eq!(ids(client, "SELECT id FROM bookings WHERE seats @> int4range(6, 9) ORDER BY id").await, vec![1, 2]);
+    assert_eq!(ids(client, "SELECT id FROM bookings WHERE '[31,35)'::int4range <@ seats").await, vec![3]);
+
+    // Overlap
+    assert_eq!(
+        ids(client, "SELECT id FROM bookings WHERE during && tsrange('2024-01-01 11:00', '2024-01-01 12:30') ORDER BY id").await,
+        vec![1, 2]
+    );
+    assert_eq!(
+        ids(client, "SELECT id FROM bookings WHERE during && tsrange('2024-01-01 14:00', '2024-01-02 09:00')").await,
+        Vec::<i32>::new()
+    );
+    assert_eq!(ids(client, "SELECT id FROM bookings WHERE seats && '[15,35)' ORDER BY id").await, vec![2, 3]);
+}
+
+/// Test daterange and tstzrange availability windows: canonical date bounds, whether a
+/// date or instant falls within a window, and whether two windows overlap
+#[tokio::test]
+async fn test_date_and_timestamptz_ranges() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE availability (id INTEGER PRIMARY KEY, starts DATE, ends DATE, days DATERANGE, slot TSTZRANGE)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "INSERT INTO availability (id, starts, ends, days, slot) VALUES (1, '2024-03-01', '2024-03-10', '[2024-03-01,2024-03-10]', tstzrange('2024-03-01 09:00:00+00', '2024-03-01 17:00:00+00', '[)'));
+         INSERT INTO availability (id, starts, ends, days, slot) VALUES (2, '2024-03-10', '2024-03-20', daterange('2024-03-10', '2024-03-20', '(]'), tstzrange('2024-03-01 17:00:00+00', NULL));"
+    ).await.unwrap();
+
+    // Discrete date ranges are stored half-open, as PostgreSQL canonicalizes them
+    assert_eq!(
+        simple_values(client, "SELECT days FROM availability ORDER BY id").await,
+        vec!["[2024-03-01,2024-03-11)", "[2024-03-11,2024-03-21)"]
+    );
+    assert_eq!(simple_values(client, "SELECT daterange('2024-03-01', '2024-03-05', '()')").await, vec!["[2024-03-02,2024-03-05)"]);
+
+    // Whether a date falls within a window
+    assert_eq!(ids(client, "SELECT id FROM availability WHERE days @> '2024-03-10'::date ORDER BY id").await, vec![1]);
+    assert_eq!(ids(client, "SELECT id FROM availability WHERE days @> '2024-03-11'::date ORDER BY id").await, vec![2]);
+    assert_eq!(ids(client, "SELECT id FROM availability WHERE '2024-04-01'::date <@ days").await, Vec::<i32>::new());
+    // ... including dates read from date columns
+    assert_eq!(ids(client, "SELECT id FROM availability WHERE days @> starts ORDER BY id").await, vec![1]);
+    assert_eq!(ids(client, "SELECT id FROM availability WHERE daterange(starts, ends, '[]') @> ends ORDER BY id").await, vec![1, 2]);
+
+    // Whether an instant falls within a slot, whatever its offset
+    assert_eq!(
+        ids(client, "SELECT id FROM availability WHERE slot @> '2024-03-01 12:00:00+02'::timestamptz ORDER BY id").await,
+        vec![1]
+    );
+    assert_eq!(ids(client, "SELECT id FROM availability WHERE slot @> '2024-06-01 00:00:00+00' ORDER BY id").await, vec![2]);
+
+    // Whether two windows overlap
+    assert_eq!(
+        ids(client, "SELECT id FROM availability WHERE days && daterange('2024-03-08', '2024-03-12') ORDER BY id").await,
+        vec![1, 2]
+    );
+    assert_eq!(
+        ids(client, "SELECT id FROM availability WHERE days && daterange('2024-03-11', '2024-03-15') ORDER BY id").await,
+        vec![2]
+    );
+    assert_eq!(
+        ids(client, "SELECT id FROM availability WHERE slot && tstzrange('2024-03-01 16:00:00+00', '2024-03-01 18:00:00+00') ORDER BY id").await,
+        vec![1, 2]
+    );
+}
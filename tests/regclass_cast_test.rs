@@ -0,0 +1,71 @@
+mod common;
+use common::*;
+use tokio_postgres::error::SqlState;
+
+/// Test casting a table name to regclass, matching pg_class, and the OID back to the name
+#[tokio::test]
+async fn test_regclass_cast_round_trip() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let results = client.simple_query("SELECT 'books'::regclass::oid AS oid").await.unwrap();
+    let oid = column(&results, "oid");
+    let results = client.simple_query("SELECT oid FROM pg_class WHERE relname = 'books'").await.unwrap();
+    assert_eq!(column(&results, "oid"), oid);
+
+    let results = client.simple_query(&format!("SELECT {}::regclass::text AS name", oid[0])).await.unwrap();
+    assert_eq!(column(&results, "name"), vec!["books"]);
+
+    let results = client.simple_query(
+        "SELECT 'books'::regclass::text AS plain, 'public.books'::regclass::text AS qualified"
+    ).await.unwrap();
+    assert_eq!(column(&results, "plain"), vec!["books"]);
+    assert_eq!(column(&results, "qualified"), vec!["books"]);
+}
+
+/// Test that a selected regclass prints as the relation name and an unknown one is an error
+#[tokio::test]
+async fn test_regclass_output_and_unknown_relation() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let results = client.simple_query(
+        "SELECT 'books'::regclass AS plain, CAST('Books' AS regclass) AS folded"
+    ).await.unwrap();
+    assert_eq!(column(&results, "plain"), vec!["books"]);
+    assert_eq!(column(&results, "folded"), vec!["books"]);
+
+    for query in ["SELECT 'no_such_table'::regclass", "SELECT '\"Books\"'::regclass::oid"] {
+        let err = client.simple_query(query).await.unwrap_err();
+        assert_eq!(err.code(), Some(&SqlState::UNDEFINED_TABLE), "{query}: {err:?}");
+    }
+    let err = client.simple_query("SELECT 'no_such_table'::regclass").await.unwrap_err();
+    assert!(err.as_db_error().unwrap().message().contains("relation \"no_such_table\" does not exist"), "{err:?}");
+}
+
+/// Test casting type names to regtype and back to their canonical names
+#[tokio::test]
+async fn test_regtype_cast() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let results = client.simple_query(
+        "SELECT 'integer'::regtype::oid AS int_oid, 'text'::regtype::oid AS text_oid, 'int4'::regtype::text AS name"
+    ).await.unwrap();
+    assert_eq!(column(&results, "int_oid"), vec!["23"]);
+    assert_eq!(column(&results, "text_oid"), vec!["25"]);
+    assert_eq!(column(&results, "name"), vec!["integer"]);
+
+    let err = client.simple_query("SELECT 'no_such_type'::regtype::oid").await.unwrap_err();
+    assert!(err.to_string().contains("does not exist"), "{err}");
+}
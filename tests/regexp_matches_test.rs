@@ -0,0 +1,73 @@
+mod common;
+use common::*;
+
+async fn setup_books() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, isbn TEXT, title TEXT)").await?;
+            db.execute("INSERT INTO books (id, isbn, title) VALUES
+                (1, '9780134685991', 'Effective Java'),
+                (2, 'unknown', 'Draft'),
+                (3, '9781492052593', 'Programming Rust')").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+/// Test regexp_matches without the g flag: one row per matching input, none for no match
+#[tokio::test]
+async fn test_regexp_matches_single() {
+    let server = setup_books().await;
+    let client = &server.client;
+
+    let rows = client.query(
+        r"SELECT id, regexp_matches(isbn, '(\d{3})(\d+)') FROM books ORDER BY id",
+        &[],
+    ).await.unwrap();
+    let matches: Vec<(i32, Vec<String>)> = rows.iter().map(|row| (row.get(0), row.get(1))).collect();
+    assert_eq!(matches, vec![
+        (1, vec!["978".to_string(), "0134685991".to_string()]),
+        (3, vec!["978".to_string(), "1492052593".to_string()]),
+    ]);
+
+    assert_eq!(
+        simple_values(client, r"SELECT regexp_matches(isbn, '(\d{3})(\d+)') AS parts FROM books WHERE id = 1").await,
+        vec!["{978,0134685991}"]
+    );
+}
+
+/// Test regexp_matches with the g flag and case-insensitive matching
+#[tokio::test]
+async fn test_regexp_matches_global() {
+    let server = setup_books().await;
+    let client = &server.client;
+
+    let rows = client.query(
+        "SELECT regexp_matches(title, '([a-z])([a-z]*)', 'g') FROM books WHERE id = 1",
+        &[],
+    ).await.unwrap();
+    let words: Vec<Vec<String>> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(words, vec![
+        vec!["f".to_string(), "fective".to_string()],
+        vec!["a".to_string(), "va".to_string()],
+    ]);
+
+    let rows = client.query(
+        "SELECT regexp_matches(title, '([a-z])([a-z]*)', 'gi') FROM books WHERE id = 1",
+        &[],
+    ).await.unwrap();
+    let words: Vec<Vec<String>> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(words, vec![
+        vec!["E".to_string(), "ffective".to_string()],
+        vec!["J".to_string(), "ava".to_string()],
+    ]);
+
+    // Without capture groups each row holds the whole match
+    let rows = client.query("SELECT regexp_matches('a1b22c333', '\\d+', 'g')", &[]).await.unwrap();
+    let numbers: Vec<Vec<String>> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(numbers, vec![vec!["1".to_string()], vec!["22".to_string()], vec!["333".to_string()]]);
+
+    let rows = client.query("SELECT regexp_matches('abc', 'x', 'g')", &[]).await.unwrap();
+    assert!(rows.is_empty());
+}
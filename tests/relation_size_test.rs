@@ -0,0 +1,36 @@
+mod common;
+use common::*;
+
+/// Test pg_relation_size, pg_total_relation_size and pg_size_pretty on a populated table
+#[tokio::test]
+async fn test_relation_size() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT)").await?;
+            db.execute("CREATE INDEX books_title_idx ON books (title)").await?;
+            let values: Vec<String> = (1..=1000)
+                .map(|i| format!("('Book number {i} with a reasonably long title')"))
+                .collect();
+            db.execute(&format!("INSERT INTO books (title) VALUES {}", values.join(", "))).await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let row = client.query_one(
+        "SELECT pg_relation_size('books'), pg_total_relation_size('books'), pg_indexes_size('books')",
+        &[],
+    ).await.unwrap();
+    let table: i64 = row.get(0);
+    let total: i64 = row.get(1);
+    let indexes: i64 = row.get(2);
+    assert!(table > 0);
+    assert!(indexes > 0);
+    assert_eq!(total, table + indexes);
+
+    let pretty = first_value(client, "SELECT pg_size_pretty(pg_total_relation_size('books'))").await.unwrap();
+    assert!(pretty.ends_with(" kB") || pretty.ends_with(" bytes"), "unexpected size: {pretty}");
+    assert!(!pretty.starts_with('0'), "unexpected size: {pretty}");
+
+    assert!(client.simple_query("SELECT pg_relation_size('missing')").await.is_err());
+}
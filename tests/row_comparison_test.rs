@@ -0,0 +1,57 @@
+mod common;
+use common::*;
+/// Test multi-column equality with row constructors
+#[tokio::test]
+async fn test_row_equality() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE posts (id INTEGER PRIMARY KEY, author_id INTEGER, status TEXT, score INTEGER);
+         INSERT INTO posts VALUES (1, 1, 'published', 5), (2, 1, 'draft', 3), (3, 2, 'published', 5), (4, 1, 'published', NULL);"
+    ).await.unwrap();
+
+    assert_eq!(
+        simple_values(client, "SELECT id FROM posts WHERE (author_id, status) = (1, 'published') ORDER BY id").await,
+        vec!["1", "4"]
+    );
+
+    assert_eq!(
+        simple_values(client, "SELECT id FROM posts WHERE ROW(author_id, status) <> ROW(1, 'published') ORDER BY id").await,
+        vec!["2", "3"]
+    );
+
+    // A NULL in the row makes the comparison unknown, so the row isn't returned
+    assert_eq!(
+        simple_values(client, "SELECT id FROM posts WHERE (author_id, score) = (1, 5) OR (author_id, score) <> (1, 5) ORDER BY id").await,
+        vec!["1", "2", "3"]
+    );
+}
+
+/// Test keyset pagination with a less-than row comparison
+#[tokio::test]
+async fn test_keyset_pagination() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE events (id INTEGER PRIMARY KEY, day INTEGER, title TEXT);
+         INSERT INTO events VALUES (1, 10, 'a'), (2, 10, 'b'), (3, 11, 'c'), (4, 12, 'd'), (5, 12, 'e'), (6, 13, 'f');"
+    ).await.unwrap();
+
+    // Page through newest first, two at a time, continuing after the last (day, id) seen
+    let page = |day: i64, id: i64| format!(
+        "SELECT id FROM events WHERE (day, id) < ({day}, {id}) ORDER BY day DESC, id DESC LIMIT 2"
+    );
+    assert_eq!(simple_values(client, "SELECT id FROM events ORDER BY day DESC, id DESC LIMIT 2").await, vec!["6", "5"]);
+    assert_eq!(simple_values(client, &page(12, 5)).await, vec!["4", "3"]);
+    assert_eq!(simple_values(client, &page(11, 3)).await, vec!["2", "1"]);
+
+    // The same through the extended protocol with parameters
+    let rows = client.query(
+        "SELECT id FROM events WHERE ROW(day, id) <= ROW($1, $2) ORDER BY day DESC, id DESC LIMIT 2",
+        &[&12i32, &4i32],
+    ).await.unwrap();
+    let found: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(found, vec![4, 3]);
+}
@@ -1,7 +1,10 @@
+mod common;
+use common::{JsonText, first_value};
 use tokio_postgres::{Client, NoTls};
 use tokio::net::TcpListener;
 use std::time::Duration;
 use tokio::time::timeout;
+use tokio_postgres::types::Type;
 
 async fn setup_test_db() -> Result<(u16, Client), Box<dyn std::error::Error + Send + Sync>> {
     // Start test server
@@ -69,7 +72,7 @@ async fn test_row_to_json_basic_subquery() {
     ).await.expect("Failed to execute query");
     
     assert_eq!(rows.len(), 1);
-    let json_result: String = rows[0].get(0);
+    let json_result: String = rows[0].get::<_, JsonText>(0).0;
     
     // The result should be a JSON object with name and age
     assert!(json_result.contains("\"name\":\"Alice\"") || json_result.contains("\"name\": \"Alice\""));
@@ -103,7 +106,7 @@ async fn test_row_to_json_multiple_columns() {
     ).await.expect("Failed to execute query");
     
     assert_eq!(rows.len(), 1);
-    let json_result: String = rows[0].get(0);
+    let json_result: String = rows[0].get::<_, JsonText>(0).0;
     
     println!("Multi-column row to JSON result: {json_result}");
     
@@ -141,7 +144,7 @@ async fn test_row_to_json_with_aliases() {
     ).await.expect("Failed to execute query");
     
     assert_eq!(rows.len(), 1);
-    let json_result: String = rows[0].get(0);
+    let json_result: String = rows[0].get::<_, JsonText>(0).0;
     
     // Verify aliases are used in the JSON
     assert!(json_result.contains("\"fname\":\"John\"") || json_result.contains("\"fname\": \"John\""));
@@ -165,7 +168,7 @@ async fn test_row_to_json_simple_function_call() {
     ).await.expect("Failed to execute query");
     
     assert_eq!(rows.len(), 1);
-    let json_result: String = rows[0].get(0);
+    let json_result: String = rows[0].get::<_, JsonText>(0).0;
     
     // For simple values, the SQLite function should handle it
     println!("Simple row to JSON result: {json_result}");
@@ -200,8 +203,8 @@ async fn test_row_to_json_multiple_rows() {
     
     assert_eq!(rows.len(), 2);
     
-    let json_result1: String = rows[0].get(0);
-    let json_result2: String = rows[1].get(0);
+    let json_result1: String = rows[0].get::<_, JsonText>(0).0;
+    let json_result2: String = rows[1].get::<_, JsonText>(0).0;
     
     // Verify both rows are correctly converted
     assert!(json_result1.contains("\"name\":\"Apple\"") || json_result1.contains("\"name\": \"Apple\""));
@@ -212,4 +215,48 @@ async fn test_row_to_json_multiple_rows() {
     
     println!("Multiple rows - Row 1: {json_result1}");
     println!("Multiple rows - Row 2: {json_result2}");
-}
\ No newline at end of file
+}
+#[tokio::test]
+async fn test_row_to_json_table_row() {
+    let (_port, client) = timeout(Duration::from_secs(10), setup_test_db()).await
+        .expect("Timeout setting up test DB")
+        .expect("Failed to set up test DB");
+    
+    client.execute(
+        "CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, price NUMERIC(10,2), in_print BOOLEAN, published_at TIMESTAMP, released DATE, notes TEXT)",
+        &[]
+    ).await.expect("Failed to create table");
+    
+    client.execute(
+        "INSERT INTO books (id, title, price, in_print, published_at, released, notes) VALUES (1, 'Dune', 9.99, true, '2024-01-15 10:30:00', '1965-08-01', NULL)",
+        &[]
+    ).await.expect("Failed to insert data");
+    
+    let expected = r#"{"id":1,"title":"Dune","price":9.99,"in_print":true,"published_at":"2024-01-15T10:30:00","released":"1965-08-01","notes":null}"#;
+    
+    // Whole-row reference through the table alias, to_json and the bare table name
+    for query in [
+        "SELECT row_to_json(b) FROM books b WHERE b.id = 1",
+        "SELECT to_json(b) FROM books AS b WHERE b.id = 1",
+        "SELECT row_to_json(books) FROM books WHERE id = 1",
+    ] {
+        let json_result = first_value(&client, query).await.expect("Expected to find a row");
+        assert_eq!(json_result, expected, "Unexpected JSON for: {query}");
+    }
+    
+    // Column name and type follow PostgreSQL
+    let stmt = client.prepare("SELECT row_to_json(b) FROM books b").await.expect("Failed to prepare");
+    assert_eq!(stmt.columns()[0].name(), "row_to_json");
+    assert_eq!(stmt.columns()[0].type_(), &Type::JSON);
+    
+    let rows = client.query(&stmt, &[]).await.expect("Failed to execute query");
+    let json_result: String = rows[0].get::<_, JsonText>(0).0;
+    let parsed: serde_json::Value = serde_json::from_str(&json_result).unwrap();
+    assert_eq!(parsed["title"], "Dune");
+    assert_eq!(parsed["price"], 9.99);
+    
+    // Pretty output puts each top-level field on its own line
+    let json_result = first_value(&client, "SELECT row_to_json(b, true) AS doc FROM books b").await
+        .expect("Expected to find a row");
+    assert!(json_result.starts_with("{\"id\":1,\n \"title\":\"Dune\",\n \"price\":9.99,"), "Unexpected pretty JSON: {json_result}");
+}
@@ -0,0 +1,97 @@
+mod common;
+use common::*;
+use tokio_postgres::SimpleQueryMessage;
+
+fn command_complete(messages: &[SimpleQueryMessage]) -> Option<u64> {
+    messages.iter().find_map(|msg| match msg {
+        SimpleQueryMessage::CommandComplete(rows) => Some(*rows),
+        _ => None,
+    })
+}
+
+/// Test the counts of multi-row UPDATE and DELETE
+#[tokio::test]
+async fn test_update_delete_rows_affected() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE tasks (id INTEGER PRIMARY KEY, owner TEXT, done BOOLEAN);
+         INSERT INTO tasks VALUES (1, 'ann', false), (2, 'ann', false), (3, 'bob', false), (4, 'ann', true);"
+    ).await.unwrap();
+
+    let result = client.simple_query("UPDATE tasks SET done = true WHERE owner = 'ann'").await.unwrap();
+    assert_eq!(command_complete(&result), Some(3));
+
+    let updated = client.execute("UPDATE tasks SET owner = $1 WHERE owner = $2", &[&"carl", &"bob"]).await.unwrap();
+    assert_eq!(updated, 1);
+
+    let updated = client.execute("UPDATE tasks SET done = false WHERE id > 100", &[]).await.unwrap();
+    assert_eq!(updated, 0);
+
+    let deleted = client.execute("DELETE FROM tasks WHERE done = $1", &[&true]).await.unwrap();
+    assert_eq!(deleted, 3);
+
+    let result = client.simple_query("DELETE FROM tasks").await.unwrap();
+    assert_eq!(command_complete(&result), Some(1));
+}
+
+/// Test the counts of multi-row INSERT and INSERT ... SELECT
+#[tokio::test]
+async fn test_bulk_insert_rows_affected() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);
+         CREATE TABLE archive (id INTEGER PRIMARY KEY, name TEXT);"
+    ).await.unwrap();
+
+    let values: Vec<String> = (1..=1500).map(|i| format!("({i}, 'item {i}')")).collect();
+    let result = client.simple_query(&format!("INSERT INTO items (id, name) VALUES {}", values.join(", "))).await.unwrap();
+    assert_eq!(command_complete(&result), Some(1500));
+
+    let inserted = client.execute("INSERT INTO items (id, name) VALUES ($1, $2), ($3, $4)", &[&2001i32, &"a", &2002i32, &"b"]).await.unwrap();
+    assert_eq!(inserted, 2);
+
+    let inserted = client.execute("INSERT INTO archive (id, name) SELECT id, name FROM items WHERE id <= 10", &[]).await.unwrap();
+    assert_eq!(inserted, 10);
+
+    let result = client.simple_query("INSERT INTO archive (id, name) SELECT id, name FROM items WHERE id > 2000").await.unwrap();
+    assert_eq!(command_complete(&result), Some(2));
+}
+
+/// Test that upserts count both inserted and updated rows, but not skipped ones
+#[tokio::test]
+async fn test_upsert_rows_affected() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE settings (key TEXT PRIMARY KEY, value TEXT);
+         INSERT INTO settings VALUES ('theme', 'light'), ('lang', 'en');"
+    ).await.unwrap();
+
+    let result = client.simple_query(
+        "INSERT INTO settings VALUES ('theme', 'dark'), ('tz', 'UTC'), ('lang', 'fr') \
+         ON CONFLICT (key) DO UPDATE SET value = excluded.value"
+    ).await.unwrap();
+    assert_eq!(command_complete(&result), Some(3));
+
+    let affected = client.execute(
+        "INSERT INTO settings VALUES ($1, $2), ($3, $4) ON CONFLICT (key) DO NOTHING",
+        &[&"theme", &"blue", &"font", &"mono"],
+    ).await.unwrap();
+    assert_eq!(affected, 1);
+
+    // A conflicting row filtered out by the DO UPDATE condition isn't counted
+    let affected = client.execute(
+        "INSERT INTO settings VALUES ('tz', 'UTC'), ('lang', 'de') \
+         ON CONFLICT (key) DO UPDATE SET value = excluded.value WHERE settings.value <> excluded.value",
+        &[],
+    ).await.unwrap();
+    assert_eq!(affected, 1);
+
+    let value = first_value(client, "SELECT value FROM settings WHERE key = 'lang'").await;
+    assert_eq!(value.as_deref(), Some("de"));
+}
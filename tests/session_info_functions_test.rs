@@ -0,0 +1,47 @@
+mod common;
+use common::*;
+
+/// Test that identity functions report the user and database from the startup message
+#[tokio::test]
+async fn test_session_info_functions() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let row = client.query_one("SELECT current_user, session_user, current_database(), version()", &[]).await.unwrap();
+    assert_eq!(row.get::<_, String>(0), "testuser");
+    assert_eq!(row.get::<_, String>(1), "testuser");
+    assert_eq!(row.get::<_, String>(2), "test");
+    let version = row.get::<_, String>(3);
+    assert!(version.starts_with(&format!("PostgreSQL {} ", pgsqlite::PG_SERVER_VERSION)), "{version}");
+
+    assert_eq!(simple_values(client, "SELECT current_user").await, vec!["testuser"]);
+}
+
+/// Test identity functions used as filters
+#[tokio::test]
+async fn test_session_info_functions_in_where() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE audit_log (id INTEGER PRIMARY KEY, user_name TEXT, db_name TEXT)").await?;
+            db.execute("INSERT INTO audit_log (id, user_name, db_name) VALUES
+                (1, 'testuser', 'test'),
+                (2, 'postgres', 'test'),
+                (3, 'testuser', 'other')").await?;
+
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    let rows = client.query(
+        "SELECT id FROM audit_log WHERE user_name = current_user AND db_name = current_database() ORDER BY id",
+        &[],
+    ).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![1]);
+
+    let rows = client.query("SELECT id FROM audit_log WHERE user_name = session_user ORDER BY id", &[]).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![1, 3]);
+}
@@ -0,0 +1,45 @@
+mod common;
+use common::*;
+use tokio_postgres::error::SqlState;
+
+/// Test that SET ROLE changes current_user and RESET ROLE restores the login user
+#[tokio::test]
+async fn test_set_and_reset_role() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    assert_eq!(first_value(client, "SELECT current_user").await.as_deref(), Some("testuser"));
+    assert_eq!(first_value(client, "SHOW role").await.as_deref(), Some("none"));
+
+    // postgres is listed in pg_roles
+    client.simple_query("SET ROLE postgres").await.unwrap();
+    assert_eq!(first_value(client, "SELECT current_user").await.as_deref(), Some("postgres"));
+    assert_eq!(first_value(client, "SELECT session_user").await.as_deref(), Some("testuser"));
+    assert_eq!(first_value(client, "SHOW role").await.as_deref(), Some("postgres"));
+
+    // The extended protocol sees the role too
+    let row = client.query_one("SELECT current_user", &[]).await.unwrap();
+    assert_eq!(row.get::<_, String>(0), "postgres");
+
+    client.simple_query("RESET ROLE").await.unwrap();
+    assert_eq!(first_value(client, "SELECT current_user").await.as_deref(), Some("testuser"));
+    assert_eq!(first_value(client, "SHOW role").await.as_deref(), Some("none"));
+
+    client.simple_query("SET SESSION ROLE TO 'pgsqlite_user'").await.unwrap();
+    assert_eq!(first_value(client, "SELECT current_user").await.as_deref(), Some("pgsqlite_user"));
+    client.simple_query("SET ROLE NONE").await.unwrap();
+    assert_eq!(first_value(client, "SELECT current_user").await.as_deref(), Some("testuser"));
+}
+
+/// Test that a role missing from pg_roles is rejected and leaves the current role alone
+#[tokio::test]
+async fn test_set_unknown_role() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.simple_query("SET ROLE postgres").await.unwrap();
+    let err = client.simple_query("SET ROLE tenant_x").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::INVALID_PARAMETER_VALUE));
+    assert!(err.to_string().contains("role \"tenant_x\" does not exist"), "unexpected error: {err}");
+    assert_eq!(first_value(client, "SELECT current_user").await.as_deref(), Some("postgres"));
+}
@@ -0,0 +1,49 @@
+mod common;
+use common::*;
+
+use tokio_postgres::error::SqlState;
+use tokio_postgres::types::Type;
+
+/// Test that smallint columns report int2 and reject values outside -32768..32767
+#[tokio::test]
+async fn test_smallint_columns() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE shelves (id SMALLSERIAL PRIMARY KEY, label TEXT, slots SMALLINT, spare int2)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute("INSERT INTO shelves (label, slots, spare) VALUES ('top', 32767, -32768)").await.unwrap();
+
+    let stmt = client.prepare("SELECT id, slots, spare FROM shelves").await.unwrap();
+    assert!(stmt.columns().iter().all(|column| column.type_() == &Type::INT2));
+    let row = client.query_one(&stmt, &[]).await.unwrap();
+    assert_eq!(row.get::<_, i16>(0), 1);
+    assert_eq!(row.get::<_, i16>(1), 32767);
+    assert_eq!(row.get::<_, i16>(2), -32768);
+
+    let err = client.batch_execute("INSERT INTO shelves (label, slots) VALUES ('bottom', 32768)").await.unwrap_err();
+    let db_err = err.as_db_error().unwrap();
+    assert_eq!(db_err.code(), &SqlState::NUMERIC_VALUE_OUT_OF_RANGE);
+    assert_eq!(db_err.message(), "smallint out of range");
+
+    // Arithmetic on the column is fine as long as the stored result fits
+    client.batch_execute("UPDATE shelves SET spare = spare + 1").await.unwrap();
+    assert!(client.batch_execute("UPDATE shelves SET slots = slots + 1").await.is_err());
+}
+
+/// Test that int2 arithmetic keeps the int2 type and mixing widths promotes
+#[tokio::test]
+async fn test_smallint_arithmetic_types() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let stmt = client.prepare("SELECT 2::int2 + 3::int2, 2::int2 * 1000").await.unwrap();
+    assert_eq!(stmt.columns()[0].type_(), &Type::INT2);
+    assert_eq!(stmt.columns()[1].type_(), &Type::INT4);
+    let row = client.query_one(&stmt, &[]).await.unwrap();
+    assert_eq!(row.get::<_, i16>(0), 5);
+    assert_eq!(row.get::<_, i32>(1), 2000);
+}
@@ -0,0 +1,87 @@
+mod common;
+use common::rows;
+use std::sync::Arc;
+use pgsqlite::session::DbHandler;
+use tokio::net::TcpListener;
+use tokio_postgres::{Client, NoTls};
+
+async fn connect(port: u16, application_name: &str) -> Client {
+    let config = format!("host=localhost port={port} dbname=inventory user=testuser application_name={application_name}");
+    let (client, connection) = tokio_postgres::connect(&config, NoTls).await.unwrap();
+    tokio::spawn(async move {
+        if let Err(e) = connection.await {
+            eprintln!("Connection error: {e}");
+        }
+    });
+    client
+}
+
+async fn query_rows(client: &Client, query: &str) -> Vec<Vec<String>> {
+    rows(&client.simple_query(query).await.unwrap()).into_iter()
+        .map(|row| row.into_iter().map(Option::unwrap_or_default).collect())
+        .collect()
+}
+
+/// Test that pg_stat_activity lists every open connection with its current or last query
+#[tokio::test]
+async fn test_pg_stat_activity_connections() {
+    let listener = TcpListener::bind("127.0.0.1:0").await.unwrap();
+    let port = listener.local_addr().unwrap().port();
+    let db_path = format!("/tmp/pgsqlite_stat_activity_{}.db", uuid::Uuid::new_v4().simple());
+    let db_handler = Arc::new(DbHandler::new(&db_path).unwrap());
+
+    // Serve every connection, unlike the single-connection test server
+    let server_handle = tokio::spawn(async move {
+        loop {
+            let (stream, addr) = listener.accept().await.unwrap();
+            let db_handler = db_handler.clone();
+            tokio::spawn(async move {
+                if let Err(e) = pgsqlite::handle_test_connection_with_pool(stream, addr, db_handler).await {
+                    eprintln!("Connection handling error: {e}");
+                }
+            });
+        }
+    });
+
+    let worker = connect(port, "worker").await;
+    let monitor = connect(port, "monitor").await;
+
+    worker.batch_execute("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)").await.unwrap();
+    worker.simple_query("SELECT count(*) FROM items").await.unwrap();
+
+    let worker_pid = query_rows(&worker, "SELECT pg_backend_pid()").await[0][0].clone();
+    let monitor_pid = query_rows(&monitor, "SELECT pg_backend_pid()").await[0][0].clone();
+    assert_ne!(worker_pid, monitor_pid);
+
+    let activity = query_rows(
+        &monitor,
+        "SELECT pid, usename, application_name, state, query FROM pg_stat_activity WHERE datname = 'inventory' ORDER BY pid",
+    ).await;
+    assert_eq!(activity.len(), 2, "both connections are listed: {activity:?}");
+
+    let worker_row = activity.iter().find(|row| row[0] == worker_pid).unwrap();
+    assert_eq!(worker_row[1..], ["testuser", "worker", "idle", "SELECT pg_backend_pid()"]);
+
+    // The monitoring connection is running the query that reads the view
+    let monitor_row = activity.iter().find(|row| row[0] == monitor_pid).unwrap();
+    assert_eq!(monitor_row[1..4], ["testuser", "monitor", "active"]);
+    assert!(monitor_row[4].contains("FROM pg_stat_activity"));
+
+    // Filtering by state
+    let active = query_rows(&worker, "SELECT pid, application_name FROM pg_stat_activity WHERE state = 'active'").await;
+    assert_eq!(active, vec![vec![worker_pid.clone(), "worker".to_string()]]);
+
+    // The last statement stays listed while the connection is idle
+    worker.simple_query("SELECT name FROM items WHERE id = 1").await.unwrap();
+    let query = query_rows(&monitor, &format!("SELECT state, query FROM pg_stat_activity WHERE pid = {worker_pid}")).await;
+    assert_eq!(query, vec![vec!["idle".to_string(), "SELECT name FROM items WHERE id = 1".to_string()]]);
+
+    // A closed connection disappears
+    drop(worker);
+    tokio::time::sleep(tokio::time::Duration::from_millis(100)).await;
+    let remaining = query_rows(&monitor, "SELECT pid FROM pg_stat_activity WHERE datname = 'inventory'").await;
+    assert_eq!(remaining, vec![vec![monitor_pid]]);
+
+    server_handle.abort();
+    let _ = std::fs::remove_file(&db_path);
+}
@@ -0,0 +1,58 @@
+mod common;
+use common::*;
+
+async fn setup_books(client: &tokio_postgres::Client) {
+    client.batch_execute(
+        "CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT);
+         INSERT INTO books VALUES (1, 'The Hobbit'), (2, 'Dune'), (3, 'Then There Were None'), (4, NULL);"
+    ).await.unwrap();
+}
+
+/// Test strpos() and position(... IN ...) for found and not-found substrings
+#[tokio::test]
+async fn test_strpos_and_position() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+    setup_books(client).await;
+
+    let rows = client.query(
+        "SELECT strpos(title, 'e') AS first_e, position('bb' IN title) AS bb FROM books ORDER BY id",
+        &[],
+    ).await.unwrap();
+    let first_e: Vec<Option<i32>> = rows.iter().map(|row| row.get("first_e")).collect();
+    let bb: Vec<Option<i32>> = rows.iter().map(|row| row.get("bb")).collect();
+    assert_eq!(first_e, vec![Some(3), Some(4), Some(3), None]);
+    assert_eq!(bb, vec![Some(7), Some(0), Some(0), None]);
+
+    // Search terms are usually parameters
+    let rows = client.query(
+        "SELECT id FROM books WHERE position($1 IN lower(title)) > 0 ORDER BY id",
+        &[&"the"],
+    ).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![1, 3]);
+
+    // Positions count characters, not bytes
+    let row = client.query_one("SELECT strpos('Crème brûlée', 'brû') AS pos", &[]).await.unwrap();
+    let pos: i32 = row.get("pos");
+    assert_eq!(pos, 7);
+}
+
+/// Test starts_with() as a prefix filter and a boolean column
+#[tokio::test]
+async fn test_starts_with() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+    setup_books(client).await;
+
+    let rows = client.query("SELECT id FROM books WHERE starts_with(title, 'The') ORDER BY id", &[]).await.unwrap();
+    let ids: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(ids, vec![1, 3]);
+
+    let rows = client.query(
+        "SELECT starts_with(title, 'The ') AS is_the FROM books ORDER BY id",
+        &[],
+    ).await.unwrap();
+    let is_the: Vec<Option<bool>> = rows.iter().map(|row| row.get("is_the")).collect();
+    assert_eq!(is_the, vec![Some(true), Some(false), Some(false), None]);
+}
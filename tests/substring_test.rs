@@ -0,0 +1,74 @@
+mod common;
+use common::*;
+
+/// Test substring(s FROM start FOR count) and its FROM-only and FOR-only variants
+#[tokio::test]
+async fn test_substring_positional_forms() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE products (id INTEGER PRIMARY KEY, sku TEXT);
+         INSERT INTO products VALUES (1, 'ABC-12345-XL');"
+    ).await.unwrap();
+
+    let row = client.query_one(
+        "SELECT substring(sku FROM 5 FOR 5) AS code, substring(sku FROM 11) AS size, substring(sku FOR 3) AS prefix FROM products WHERE id = 1",
+        &[],
+    ).await.unwrap();
+    let code: String = row.get("code");
+    let size: String = row.get("size");
+    let prefix: String = row.get("prefix");
+    assert_eq!(code, "12345");
+    assert_eq!(size, "XL");
+    assert_eq!(prefix, "ABC");
+
+    // Positions can be parameters, and the comma form still works
+    let row = client.query_one(
+        "SELECT substring(sku FROM $1::int4 FOR $2::int4) AS code, substring(sku, 1, 3) AS prefix FROM products WHERE id = 1",
+        &[&5i32, &5i32],
+    ).await.unwrap();
+    let code: String = row.get("code");
+    let prefix: String = row.get("prefix");
+    assert_eq!(code, "12345");
+    assert_eq!(prefix, "ABC");
+
+    let value = first_value(client, "SELECT SUBSTRING('PostgreSQL' FROM 5 FOR 3)").await;
+    assert_eq!(value.as_deref(), Some("gre"));
+}
+
+/// Test the regex and SQL regular expression forms of substring()
+#[tokio::test]
+async fn test_substring_pattern_forms() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);
+         INSERT INTO users VALUES (1, 'alice@example.com'), (2, 'bob');"
+    ).await.unwrap();
+
+    // The first capture group when the pattern has one, otherwise the whole match
+    let rows = client.query(
+        "SELECT substring(email FROM '@(.*)$') AS domain, substring(email FROM '[a-z]+') AS word FROM users ORDER BY id",
+        &[],
+    ).await.unwrap();
+    let domain: Option<String> = rows[0].get("domain");
+    let word: Option<String> = rows[0].get("word");
+    assert_eq!(domain.as_deref(), Some("example.com"));
+    assert_eq!(word.as_deref(), Some("alice"));
+    let domain: Option<String> = rows[1].get("domain");
+    assert!(domain.is_none());
+
+    let row = client.query_one(
+        "SELECT substring('foobar' FROM '%#\"o_b#\"%' FOR '#') AS middle, substring('foobar' SIMILAR '#\"o_b#\"%' ESCAPE '#') AS anchored",
+        &[],
+    ).await.unwrap();
+    let middle: Option<String> = row.get("middle");
+    let anchored: Option<String> = row.get("anchored");
+    assert_eq!(middle.as_deref(), Some("oob"));
+    assert!(anchored.is_none());
+
+    let err = client.simple_query("SELECT substring('abc' FROM '(')").await.unwrap_err();
+    assert_eq!(err.as_db_error().unwrap().code().code(), "2201B");
+}
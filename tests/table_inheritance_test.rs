@@ -0,0 +1,58 @@
+mod common;
+use common::*;
+
+/// Test that ONLY in front of a table name is accepted
+#[tokio::test]
+async fn test_only_keyword() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT)").await?;
+            db.execute("INSERT INTO books (id, title) VALUES (1, 'Dune'), (2, 'Emma'), (3, 'Ulysses')").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let rows = client.query("SELECT * FROM ONLY books ORDER BY id", &[]).await.unwrap();
+    assert_eq!(rows.len(), 3);
+    assert_eq!(rows[0].get::<_, String>(1), "Dune");
+
+    let rows = client.query("SELECT title FROM only books WHERE id = $1", &[&2i32]).await.unwrap();
+    assert_eq!(rows[0].get::<_, String>(0), "Emma");
+
+    client.execute("UPDATE ONLY books SET title = 'Persuasion' WHERE id = 2", &[]).await.unwrap();
+    client.execute("DELETE FROM ONLY books WHERE id = 3", &[]).await.unwrap();
+    let rows = client.query("SELECT title FROM books ORDER BY id", &[]).await.unwrap();
+    let titles: Vec<String> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(titles, vec!["Dune", "Persuasion"]);
+}
+
+/// Test that a table created with INHERITS gets the parent's columns
+#[tokio::test]
+async fn test_inherits_copies_parent_columns() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE cities (name VARCHAR(50) NOT NULL, population INTEGER DEFAULT 0)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute("CREATE TABLE capitals (state CHAR(2)) INHERITS (cities)").await.unwrap();
+    client.batch_execute("INSERT INTO capitals (name, state) VALUES ('Sacramento', 'CA')").await.unwrap();
+
+    let rows = client.query("SELECT name, population, state FROM capitals", &[]).await.unwrap();
+    assert_eq!(rows.len(), 1);
+    assert_eq!(rows[0].get::<_, String>(0), "Sacramento");
+    assert_eq!(rows[0].get::<_, i32>(1), 0);
+    assert_eq!(rows[0].get::<_, String>(2), "CA");
+
+    // NOT NULL comes along with the column
+    assert!(client.batch_execute("INSERT INTO capitals (state) VALUES ('NV')").await.is_err());
+
+    // The parent keeps its own rows
+    let rows = client.query("SELECT count(*) FROM ONLY cities", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i64>(0), 0);
+
+    assert_eq!(simple_values(client, "SELECT inhseqno FROM pg_inherits").await, vec!["1"]);
+}
@@ -0,0 +1,36 @@
+mod common;
+use common::*;
+
+#[tokio::test]
+async fn test_table_statement_shorthand() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE genres (id INTEGER PRIMARY KEY, name TEXT NOT NULL)").await?;
+            db.execute("INSERT INTO genres (id, name) VALUES (1, 'Mystery'), (2, 'Fantasy'), (3, 'Biography'), \
+                (4, 'Poetry'), (5, 'Horror'), (6, 'Romance'), (7, 'Drama')").await?;
+            Ok(())
+        })
+    }).await;
+    
+    let client = &server.client;
+    
+    let expected: Vec<(i32, String)> = client.query("SELECT * FROM genres ORDER BY name LIMIT 5", &[]).await.unwrap()
+        .iter()
+        .map(|row| (row.get(0), row.get(1)))
+        .collect();
+    assert_eq!(expected.len(), 5);
+    assert_eq!(expected[0], (3, "Biography".to_string()));
+    
+    // Extended protocol
+    let rows: Vec<(i32, String)> = client.query("TABLE genres ORDER BY name LIMIT 5", &[]).await.unwrap()
+        .iter()
+        .map(|row| (row.get(0), row.get(1)))
+        .collect();
+    assert_eq!(rows, expected);
+    
+    // Simple protocol
+    let messages = client.simple_query("TABLE genres ORDER BY name LIMIT 5").await.unwrap();
+    assert_eq!(column(&messages, "name"), expected.iter().map(|(_, name)| name.clone()).collect::<Vec<_>>());
+    
+    server.abort();
+}
@@ -0,0 +1,61 @@
+mod common;
+use common::*;
+
+async fn sample_ids(client: &tokio_postgres::Client, query: &str) -> Vec<i32> {
+    client.query(query, &[]).await.unwrap().iter().map(|row| row.get(0)).collect()
+}
+
+/// Test that TABLESAMPLE keeps roughly the requested fraction of rows
+#[tokio::test]
+async fn test_tablesample_fraction() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE events (id INTEGER PRIMARY KEY, kind TEXT)").await?;
+            let rows: Vec<String> = (1..=1000).map(|i| format!("({i}, 'k{}')", i % 3)).collect();
+            db.execute(&format!("INSERT INTO events (id, kind) VALUES {}", rows.join(", "))).await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    // 20% of 1000 rows: 200 expected, the bounds are about eight standard deviations wide
+    let ids = sample_ids(client, "SELECT id FROM events TABLESAMPLE BERNOULLI(20)").await;
+    assert!((100..=300).contains(&ids.len()), "sampled {} rows", ids.len());
+
+    let ids = sample_ids(client, "SELECT e.id FROM events e TABLESAMPLE SYSTEM (20) WHERE e.kind = 'k1' ORDER BY e.id").await;
+    assert!((20..=120).contains(&ids.len()), "sampled {} rows", ids.len());
+    assert!(ids.windows(2).all(|w| w[0] < w[1]));
+
+    assert!(sample_ids(client, "SELECT id FROM events TABLESAMPLE BERNOULLI(0)").await.is_empty());
+    assert_eq!(sample_ids(client, "SELECT id FROM events TABLESAMPLE BERNOULLI(100)").await.len(), 1000);
+
+    // The same seed picks the same rows
+    let query = "SELECT id FROM events TABLESAMPLE BERNOULLI(20) REPEATABLE(7) ORDER BY id";
+    let first = sample_ids(client, query).await;
+    assert!((100..=300).contains(&first.len()), "sampled {} rows", first.len());
+    assert_eq!(sample_ids(client, query).await, first);
+
+    let err = client.simple_query("SELECT id FROM events TABLESAMPLE system_rows(10)").await.unwrap_err();
+    assert!(err.to_string().contains("tablesample method system_rows does not exist"), "{err}");
+}
+
+/// Test that a seeded sample keeps the requested fraction of rows with negative rowids
+#[tokio::test]
+async fn test_tablesample_repeatable_negative_rowids() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE ledger (id INTEGER PRIMARY KEY, amount INTEGER)").await?;
+            let rows: Vec<String> = (-1000..=-1).map(|i| format!("({i}, {})", -i)).collect();
+            db.execute(&format!("INSERT INTO ledger (id, amount) VALUES {}", rows.join(", "))).await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let query = "SELECT id FROM ledger TABLESAMPLE BERNOULLI(20) REPEATABLE(7) ORDER BY id";
+    let ids = sample_ids(client, query).await;
+    assert!((100..=300).contains(&ids.len()), "sampled {} rows", ids.len());
+    assert_eq!(sample_ids(client, query).await, ids);
+
+    server.abort();
+}
@@ -0,0 +1,57 @@
+mod common;
+use common::*;
+
+async fn lexemes(client: &tokio_postgres::Client, config: &str, text: &str) -> Vec<String> {
+    let tsvector = first_value(client, &format!("SELECT to_tsvector('{config}', '{text}')")).await.unwrap();
+    let tsvector: serde_json::Value = serde_json::from_str(&tsvector).unwrap();
+    tsvector["lexemes"].as_object().unwrap().keys().cloned().collect()
+}
+
+/// Test that the english configuration stems words and drops stop words while simple doesn't
+#[tokio::test]
+async fn test_stemmed_and_simple_configs() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE posts (id INTEGER PRIMARY KEY, body TEXT)").await?;
+            db.execute("INSERT INTO posts (id, body) VALUES (1, 'The runners were running races')").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    assert_eq!(lexemes(client, "english", "The runners were running races").await, vec!["race", "run", "runner"]);
+    assert_eq!(lexemes(client, "pg_catalog.english", "Connected connections").await, vec!["connect"]);
+    assert_eq!(
+        lexemes(client, "simple", "The runners were running races").await,
+        vec!["races", "runners", "running", "the", "were"]
+    );
+
+    // A stemmed query matches other forms of the word only with english
+    let rank: f64 = first_value(client,
+        "SELECT ts_rank(to_tsvector('english', body), to_tsquery('english', 'runs & race')) FROM posts"
+    ).await.unwrap().parse().unwrap();
+    assert!(rank > 0.0, "unexpected rank {rank}");
+    let rank: f64 = first_value(client,
+        "SELECT ts_rank(to_tsvector('simple', body), to_tsquery('simple', 'runs & race')) FROM posts"
+    ).await.unwrap().parse().unwrap();
+    assert_eq!(rank, 0.0);
+
+    // Without a configuration the default one is english
+    assert_eq!(first_value(client, "SELECT plainto_tsquery('The running races')").await.as_deref(), Some("run AND race"));
+}
+
+/// Test that an unknown configuration is an error
+#[tokio::test]
+async fn test_unknown_config() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    for query in [
+        "SELECT to_tsvector('klingon', 'Qapla')",
+        "SELECT to_tsquery('klingon', 'Qapla')",
+    ] {
+        let err = client.simple_query(query).await.unwrap_err();
+        assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::UNDEFINED_OBJECT), "{query}: {err}");
+        assert!(err.to_string().contains("text search configuration \"klingon\" does not exist"), "{query}: {err}");
+    }
+}
@@ -0,0 +1,59 @@
+mod common;
+use common::*;
+use chrono::{NaiveDate, NaiveDateTime, NaiveTime};
+
+fn timestamp(micros: u32) -> NaiveDateTime {
+    NaiveDate::from_ymd_opt(2024, 1, 15).unwrap().and_hms_micro_opt(10, 30, 45, micros).unwrap()
+}
+
+/// Test that timestamp(3) and time(0) columns keep only the declared fraction digits,
+/// whether the value comes as a literal, a parameter or an update
+#[tokio::test]
+async fn test_timestamp_precision_truncates() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE events (
+                id INTEGER PRIMARY KEY,
+                happened_at TIMESTAMP(3),
+                starts TIME(0),
+                logged_at TIMESTAMP
+            )").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.simple_query(
+        "INSERT INTO events (id, happened_at, starts, logged_at) \
+         VALUES (1, '2024-01-15 10:30:45.123456', '08:15:30.987654', '2024-01-15 10:30:45.123456')"
+    ).await.unwrap();
+    client.execute(
+        "INSERT INTO events (id, happened_at) VALUES (2, $1)",
+        &[&timestamp(999_999)],
+    ).await.unwrap();
+
+    let row = client.query_one("SELECT happened_at, starts, logged_at FROM events WHERE id = 1", &[]).await.unwrap();
+    assert_eq!(row.get::<_, NaiveDateTime>(0), timestamp(123_000));
+    assert_eq!(row.get::<_, NaiveTime>(1), NaiveTime::from_hms_opt(8, 15, 30).unwrap());
+    assert_eq!(row.get::<_, NaiveDateTime>(2), timestamp(123_456));
+
+    let row = client.query_one("SELECT happened_at FROM events WHERE id = 2", &[]).await.unwrap();
+    assert_eq!(row.get::<_, NaiveDateTime>(0), timestamp(999_000));
+
+    client.execute("UPDATE events SET happened_at = $1 WHERE id = 1", &[&timestamp(456_789)]).await.unwrap();
+    let row = client.query_one("SELECT happened_at FROM events WHERE id = 1", &[]).await.unwrap();
+    assert_eq!(row.get::<_, NaiveDateTime>(0), timestamp(456_000));
+
+    // The precision is the column's type modifier
+    let results = client.simple_query(
+        "SELECT attname, atttypmod FROM pg_attribute WHERE attrelid = 'events'::regclass"
+    ).await.unwrap();
+    assert_eq!(rows(&results), vec![
+        some(&["id", "-1"]),
+        some(&["happened_at", "3"]),
+        some(&["starts", "0"]),
+        some(&["logged_at", "-1"]),
+    ]);
+
+    server.abort();
+}
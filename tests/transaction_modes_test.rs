@@ -0,0 +1,73 @@
+mod common;
+use common::*;
+use tokio_postgres::error::SqlState;
+
+async fn count(client: &tokio_postgres::Client) -> String {
+    first_value(client, "SELECT count(*) FROM items").await.unwrap()
+}
+
+/// Test the BEGIN and START TRANSACTION spellings with their modes
+#[tokio::test]
+async fn test_begin_forms() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.execute("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)", &[]).await.unwrap();
+
+    for begin in [
+        "BEGIN",
+        "BEGIN WORK",
+        "BEGIN TRANSACTION",
+        "START TRANSACTION",
+        "BEGIN ISOLATION LEVEL SERIALIZABLE",
+        "START TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ WRITE",
+        "BEGIN TRANSACTION ISOLATION LEVEL READ COMMITTED NOT DEFERRABLE",
+    ] {
+        client.simple_query(begin).await.unwrap_or_else(|e| panic!("{begin}: {e}"));
+        client.execute("INSERT INTO items (name) VALUES ('x')", &[]).await.unwrap();
+        client.simple_query("COMMIT").await.unwrap();
+    }
+    assert_eq!(count(client).await, "7");
+
+    let err = client.simple_query("BEGIN ISOLATION LEVEL SOMETIMES").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::SYNTAX_ERROR), "{err}");
+}
+
+/// Test that a READ ONLY transaction reads but rejects writes
+#[tokio::test]
+async fn test_read_only_transaction() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);
+         INSERT INTO items (name) VALUES ('a');"
+    ).await.unwrap();
+
+    client.simple_query("BEGIN ISOLATION LEVEL SERIALIZABLE, READ ONLY, DEFERRABLE").await.unwrap();
+    assert_eq!(count(client).await, "1");
+    let err = client.simple_query("INSERT INTO items (name) VALUES ('b')").await.unwrap_err();
+    assert_eq!(err.code(), Some(&SqlState::READ_ONLY_SQL_TRANSACTION), "{err}");
+    client.simple_query("ROLLBACK").await.unwrap();
+
+    // Writes work again once the read-only transaction is over
+    client.execute("INSERT INTO items (name) VALUES ('c')", &[]).await.unwrap();
+    assert_eq!(count(client).await, "2");
+}
+
+/// Test that BEGIN inside a transaction only warns and keeps the transaction
+#[tokio::test]
+async fn test_nested_begin_warns() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.execute("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)", &[]).await.unwrap();
+
+    client.simple_query("BEGIN").await.unwrap();
+    client.execute("INSERT INTO items (name) VALUES ('a')", &[]).await.unwrap();
+    client.simple_query("START TRANSACTION READ ONLY").await.unwrap();
+    // The outer transaction is still read-write and still open
+    client.execute("INSERT INTO items (name) VALUES ('b')", &[]).await.unwrap();
+    client.simple_query("ROLLBACK").await.unwrap();
+    assert_eq!(count(client).await, "0");
+}
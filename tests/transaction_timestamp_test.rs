@@ -0,0 +1,30 @@
+mod common;
+use common::*;
+
+/// Test that now() is the transaction start time while clock_timestamp() keeps moving
+#[tokio::test]
+async fn test_now_is_stable_within_transaction() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute("BEGIN").await.unwrap();
+    let first_now = first_value(client, "SELECT now()").await.unwrap();
+    let first_clock = first_value(client, "SELECT clock_timestamp()").await.unwrap();
+    tokio::time::sleep(std::time::Duration::from_millis(20)).await;
+    let second_now = first_value(client, "SELECT now()").await.unwrap();
+    let second_clock = first_value(client, "SELECT clock_timestamp()").await.unwrap();
+    let transaction = first_value(client, "SELECT transaction_timestamp()").await.unwrap();
+    client.batch_execute("COMMIT").await.unwrap();
+
+    assert_eq!(first_now, second_now);
+    assert_eq!(transaction, first_now);
+    assert_ne!(first_clock, second_clock);
+    assert!(second_clock > second_now, "{second_clock} should be after {second_now}");
+
+    // The next transaction gets a new timestamp
+    tokio::time::sleep(std::time::Duration::from_millis(20)).await;
+    client.batch_execute("BEGIN").await.unwrap();
+    let next_now = first_value(client, "SELECT now()").await.unwrap();
+    client.batch_execute("COMMIT").await.unwrap();
+    assert!(next_now > first_now, "{next_now} should be after {first_now}");
+}
@@ -0,0 +1,41 @@
+mod common;
+use common::*;
+
+/// Test that TRUNCATE empties the tables and RESTART IDENTITY resets SERIAL counters
+#[tokio::test]
+async fn test_truncate_restart_identity_cascade() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.execute("CREATE TABLE authors (id SERIAL PRIMARY KEY, name TEXT)", &[]).await.unwrap();
+    client.execute("CREATE TABLE books (id SERIAL PRIMARY KEY, author_id INTEGER REFERENCES authors(id), title TEXT)", &[]).await.unwrap();
+    client.execute("INSERT INTO authors (name) VALUES ('Le Guin'), ('Herbert')", &[]).await.unwrap();
+    client.execute("INSERT INTO books (author_id, title) VALUES (1, 'Earthsea'), (2, 'Dune')", &[]).await.unwrap();
+
+    // RESTRICT is the default: books still references authors
+    let err = client.simple_query("TRUNCATE authors").await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::FEATURE_NOT_SUPPORTED));
+    let row = client.query_one("SELECT COUNT(*) FROM authors", &[]).await.unwrap();
+    assert_eq!(row.get::<_, i64>(0), 2);
+
+    // CONTINUE IDENTITY keeps the counters
+    client.simple_query("TRUNCATE TABLE books, authors CONTINUE IDENTITY").await.unwrap();
+    client.execute("INSERT INTO authors (name) VALUES ('Butler')", &[]).await.unwrap();
+    let row = client.query_one("SELECT id FROM authors", &[]).await.unwrap();
+    assert_eq!(row.get::<_, i32>(0), 3);
+    client.execute("INSERT INTO books (author_id, title) VALUES (3, 'Kindred')", &[]).await.unwrap();
+
+    // CASCADE pulls in books, RESTART IDENTITY starts both tables over at 1
+    client.execute("TRUNCATE authors RESTART IDENTITY CASCADE", &[]).await.unwrap();
+    let row = client.query_one("SELECT COUNT(*) FROM books", &[]).await.unwrap();
+    assert_eq!(row.get::<_, i64>(0), 0);
+
+    client.execute("INSERT INTO authors (name) VALUES ('Jemisin')", &[]).await.unwrap();
+    client.execute("INSERT INTO books (author_id, title) VALUES (1, 'The Fifth Season')", &[]).await.unwrap();
+    let row = client.query_one("SELECT a.id, b.id FROM authors a JOIN books b ON b.author_id = a.id", &[]).await.unwrap();
+    assert_eq!(row.get::<_, i32>(0), 1);
+    assert_eq!(row.get::<_, i32>(1), 1);
+
+    let err = client.simple_query("TRUNCATE missing").await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::UNDEFINED_TABLE));
+}
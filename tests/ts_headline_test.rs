@@ -0,0 +1,38 @@
+mod common;
+use common::*;
+
+/// Test highlighting the matched terms of a document with ts_headline
+#[tokio::test]
+async fn test_ts_headline() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE posts (id INTEGER PRIMARY KEY, content TEXT)").await?;
+            db.execute("INSERT INTO posts (id, content) VALUES
+                (1, 'SQLite is a small, fast database engine. PostgreSQL clients can talk to it through a proxy.')").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    assert_eq!(
+        first_value(client, "SELECT ts_headline(content, to_tsquery('english', 'database | proxy')) FROM posts WHERE id = 1").await.as_deref(),
+        Some("SQLite is a small, fast <b>database</b> engine. PostgreSQL clients can talk to it through a <b>proxy</b>.")
+    );
+
+    assert_eq!(
+        first_value(client,
+            "SELECT ts_headline('english', content, to_tsquery('english', 'clients'), 'StartSel=[, StopSel=], MaxWords=5, MinWords=2') \
+             FROM posts WHERE id = 1"
+        ).await.as_deref(),
+        Some("[clients] can talk to it")
+    );
+
+    // Without a match the headline is the beginning of the document
+    assert_eq!(
+        first_value(client, "SELECT ts_headline(content, to_tsquery('english', 'mysql'), 'MaxWords=4, MinWords=3') FROM posts").await.as_deref(),
+        Some("SQLite is a")
+    );
+
+    let err = client.simple_query("SELECT ts_headline(content, to_tsquery('english', 'fast'), 'MaxWords=3, MinWords=3') FROM posts").await.unwrap_err();
+    assert!(err.to_string().contains("MinWords should be less than MaxWords"), "unexpected error: {err}");
+}
@@ -0,0 +1,58 @@
+mod common;
+use common::*;
+
+/// Test @@ with plain text, in either operand order
+#[tokio::test]
+async fn test_match_text_operand() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE posts (id INTEGER PRIMARY KEY, body TEXT)").await?;
+            db.execute("INSERT INTO posts (id, body) VALUES
+                (1, 'The cat sat on the mat'),
+                (2, 'Dogs are running in the park'),
+                (3, 'A cat and a dog')").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    // Text with operators follows to_tsquery, other text plainto_tsquery
+    assert_eq!(simple_values(client, "SELECT id FROM posts WHERE to_tsvector('english', body) @@ 'cat & dog' ORDER BY id").await, vec!["3"]);
+    assert_eq!(simple_values(client, "SELECT id FROM posts WHERE to_tsvector('english', body) @@ 'cat | park' ORDER BY id").await, vec!["1", "2", "3"]);
+    assert_eq!(simple_values(client, "SELECT id FROM posts WHERE to_tsvector('english', body) @@ 'running dogs' ORDER BY id").await, vec!["2"]);
+
+    // The tsquery may come first
+    assert_eq!(simple_values(client, "SELECT id FROM posts WHERE 'cat' @@ to_tsvector('english', body) ORDER BY id").await, vec!["1", "3"]);
+    assert_eq!(
+        simple_values(client, "SELECT id FROM posts WHERE to_tsquery('english', 'cat & !dog') @@ to_tsvector('english', body) ORDER BY id").await,
+        vec!["1"]
+    );
+    assert_eq!(
+        simple_values(client, "SELECT id FROM posts WHERE to_tsvector('english', body) @@ plainto_tsquery('english', 'the dog') ORDER BY id").await,
+        vec!["2", "3"]
+    );
+
+    // A parameter is text as well
+    let rows = client.query(
+        "SELECT id FROM posts WHERE to_tsvector('english', body) @@ $1 ORDER BY id", &[&"park | mat"]
+    ).await.unwrap();
+    let found: Vec<i32> = rows.iter().map(|row| row.get(0)).collect();
+    assert_eq!(found, vec![1, 2]);
+}
+
+/// Test @@ against a stored tsvector column
+#[tokio::test]
+async fn test_match_tsvector_column() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE articles (id INTEGER PRIMARY KEY, search tsvector);
+         INSERT INTO articles (id, search) VALUES (1, to_tsvector('english', 'Connecting the databases'));
+         INSERT INTO articles (id, search) VALUES (2, to_tsvector('english', 'Cooking with friends'))"
+    ).await.unwrap();
+
+    assert_eq!(simple_values(client, "SELECT id FROM articles WHERE search @@ 'database & connection'").await, vec!["1"]);
+    assert_eq!(simple_values(client, "SELECT id FROM articles WHERE 'cook:*' @@ search").await, vec!["2"]);
+    assert!(simple_values(client, "SELECT id FROM articles WHERE search @@ 'friends & database'").await.is_empty());
+}
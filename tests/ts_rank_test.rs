@@ -0,0 +1,60 @@
+mod common;
+use common::*;
+
+/// Test ordering documents by ts_rank and ts_rank_cd
+#[tokio::test]
+async fn test_order_by_rank() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE docs (id INTEGER PRIMARY KEY, title TEXT, body TEXT)").await?;
+            db.execute("INSERT INTO docs (id, title, body) VALUES
+                (1, 'Dogs', 'a dog chased the ball'),
+                (2, 'Cats', 'the cat sat on the mat with another cat'),
+                (3, 'Pets', 'a cat and a dog')").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let ids = simple_values(client,
+        "SELECT id FROM docs ORDER BY ts_rank(to_tsvector('english', body), to_tsquery('english', 'cat')) DESC, id"
+    ).await;
+    assert_eq!(ids, vec!["2", "3", "1"]);
+
+    let ids = simple_values(client,
+        "SELECT id FROM docs WHERE ts_rank_cd(to_tsvector('english', body), to_tsquery('english', 'cat & dog')) > 0 \
+         ORDER BY ts_rank_cd(to_tsvector('english', body), to_tsquery('english', 'cat | dog')) DESC, id"
+    ).await;
+    assert_eq!(ids, vec!["3"]);
+
+    let rank = simple_values(client, "SELECT ts_rank(to_tsvector('english', body), to_tsquery('english', 'ball')) FROM docs WHERE id = 1").await;
+    let rank: f64 = rank[0].parse().unwrap();
+    assert!((rank - 0.0607927).abs() < 1e-6, "unexpected rank {rank}");
+}
+
+/// Test that lexemes weighted with setweight rank above unweighted ones
+#[tokio::test]
+async fn test_setweight_ranking() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE articles (id INTEGER PRIMARY KEY, title TEXT, body TEXT)").await?;
+            db.execute("INSERT INTO articles (id, title, body) VALUES
+                (1, 'Pets', 'dog food and dog toys for every dog'),
+                (2, 'Dog training', 'teach new tricks'),
+                (3, 'Cats', 'nothing about dogs here')").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    // A title match weighs more than several matches in the body
+    let ids = simple_values(client,
+        "SELECT id FROM articles ORDER BY \
+         ts_rank(setweight(to_tsvector('english', title), 'A'), to_tsquery('english', 'dog')) + \
+         ts_rank(to_tsvector('english', body), to_tsquery('english', 'dog')) DESC, id"
+    ).await;
+    assert_eq!(ids, vec!["2", "1", "3"]);
+
+    let err = client.simple_query("SELECT setweight(to_tsvector('english', title), 'E') FROM articles").await.unwrap_err();
+    assert!(err.to_string().contains("unrecognized weight"), "unexpected error: {err}");
+}
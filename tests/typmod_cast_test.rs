@@ -0,0 +1,69 @@
+mod common;
+use common::*;
+use rust_decimal::Decimal;
+use std::str::FromStr;
+
+async fn setup_measurements() -> TestServer {
+    setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE measurements (
+                id INTEGER PRIMARY KEY,
+                reading DOUBLE PRECISION,
+                label TEXT
+            )").await?;
+            db.execute("INSERT INTO measurements (id, reading, label) VALUES
+                (1, 1234.56789, 'temperature'),
+                (2, 2.345, 'hum'),
+                (3, -0.005, 'pressure')").await?;
+
+            Ok(())
+        })
+    }).await
+}
+
+/// Test that numeric(p,s) casts of high-precision floats round to the scale
+#[tokio::test]
+async fn test_float_to_numeric_rounding() {
+    let server = setup_measurements().await;
+    let client = &server.client;
+
+    let expected = ["1234.57", "2.35", "-0.01"];
+
+    // Simple query protocol, CAST syntax
+    let amounts = simple_values(client, "SELECT CAST(reading AS numeric(10,2)) AS amount FROM measurements ORDER BY id").await;
+    assert_eq!(amounts, expected);
+
+    // Extended query protocol, :: syntax, decoded as NUMERIC
+    let stmt = client.prepare("SELECT reading::numeric(10,2) AS amount FROM measurements ORDER BY id").await.unwrap();
+    assert_eq!(stmt.columns()[0].type_(), &tokio_postgres::types::Type::NUMERIC);
+
+    let rows = client.query(&stmt, &[]).await.unwrap();
+    for (row, expected) in rows.iter().zip(expected) {
+        assert_eq!(row.get::<_, Decimal>("amount"), Decimal::from_str(expected).unwrap());
+    }
+
+    // Values that round past the precision overflow, as in PostgreSQL
+    let result = client.simple_query("SELECT CAST(99999.999 AS numeric(7,2))").await;
+    assert!(result.is_err());
+}
+
+/// Test that varchar(n) casts truncate to n characters
+#[tokio::test]
+async fn test_varchar_cast_truncates() {
+    let server = setup_measurements().await;
+    let client = &server.client;
+
+    let rows = client.query(
+        "SELECT label::varchar(4) AS short, CAST(label AS VARCHAR(20)) AS full_label FROM measurements ORDER BY id",
+        &[]
+    ).await.unwrap();
+
+    let labels: Vec<(String, String)> = rows.iter()
+        .map(|row| (row.get("short"), row.get("full_label")))
+        .collect();
+    assert_eq!(labels, vec![
+        ("temp".to_string(), "temperature".to_string()),
+        ("hum".to_string(), "hum".to_string()),
+        ("pres".to_string(), "pressure".to_string()),
+    ]);
+}
@@ -0,0 +1,90 @@
+mod common;
+use common::*;
+
+/// Test that duplicate keys report the constraint name and conflicting value
+#[tokio::test]
+async fn test_unique_violation_includes_key_detail() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (
+                id INTEGER PRIMARY KEY,
+                isbn_13 VARCHAR(13) UNIQUE,
+                title TEXT NOT NULL
+            )").await?;
+
+            db.execute("INSERT INTO books (id, isbn_13, title) VALUES (1, '9780306406157', 'First')").await?;
+
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    // Simple query protocol
+    let err = client.batch_execute(
+        "INSERT INTO books (id, isbn_13, title) VALUES (2, '9780306406157', 'Second')"
+    ).await.unwrap_err();
+
+    let db_err = err.as_db_error().expect("Expected a database error");
+    assert_eq!(db_err.code(), &tokio_postgres::error::SqlState::UNIQUE_VIOLATION);
+    assert_eq!(db_err.message(), "duplicate key value violates unique constraint \"books_isbn_13_key\"");
+    assert_eq!(db_err.constraint(), Some("books_isbn_13_key"));
+    assert_eq!(db_err.detail(), Some("Key (isbn_13)=(9780306406157) already exists."));
+
+    // Extended query protocol, primary key conflict
+    let err = client.execute(
+        "INSERT INTO books (id, isbn_13, title) VALUES (1, '9780306406158', 'Third')",
+        &[]
+    ).await.unwrap_err();
+
+    let db_err = err.as_db_error().expect("Expected a database error");
+    assert_eq!(db_err.code(), &tokio_postgres::error::SqlState::UNIQUE_VIOLATION);
+    assert_eq!(db_err.constraint(), Some("books_pkey"));
+    assert_eq!(db_err.detail(), Some("Key (id)=(1) already exists."));
+
+    // Extended query protocol with bound parameters
+    let err = client.execute(
+        "INSERT INTO books (id, isbn_13, title) VALUES ($1, $2, $3)",
+        &[&4i32, &"9780306406157", &"Fourth"]
+    ).await.unwrap_err();
+
+    let db_err = err.as_db_error().expect("Expected a database error");
+    assert_eq!(db_err.code(), &tokio_postgres::error::SqlState::UNIQUE_VIOLATION);
+    assert_eq!(db_err.constraint(), Some("books_isbn_13_key"));
+    assert_eq!(db_err.detail(), Some("Key (isbn_13)=(9780306406157) already exists."));
+
+    // The connection stays usable after the error
+    let rows = client.query("SELECT COUNT(*) FROM books", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i64>(0), 1);
+}
+
+/// Test that a CREATE UNIQUE INDEX name is used as the constraint name
+#[tokio::test]
+async fn test_unique_index_violation_on_update() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL)").await?;
+            db.execute("CREATE UNIQUE INDEX users_email_idx ON users (email)").await?;
+            db.execute("INSERT INTO users (id, email) VALUES (1, 'a@example.com'), (2, 'b@example.com')").await?;
+
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    let err = client.batch_execute(
+        "UPDATE users SET email = 'a@example.com' WHERE id = 2"
+    ).await.unwrap_err();
+
+    let db_err = err.as_db_error().expect("Expected a database error");
+    assert_eq!(db_err.code(), &tokio_postgres::error::SqlState::UNIQUE_VIOLATION);
+    assert_eq!(db_err.constraint(), Some("users_email_idx"));
+    assert_eq!(db_err.detail(), Some("Key (email)=(a@example.com) already exists."));
+
+    let err = client.execute("UPDATE users SET email = $1 WHERE id = $2", &[&"b@example.com", &1i32]).await.unwrap_err();
+
+    let db_err = err.as_db_error().expect("Expected a database error");
+    assert_eq!(db_err.code(), &tokio_postgres::error::SqlState::UNIQUE_VIOLATION);
+    assert_eq!(db_err.detail(), Some("Key (email)=(b@example.com) already exists."));
+}
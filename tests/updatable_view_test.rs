@@ -0,0 +1,46 @@
+mod common;
+use common::*;
+
+async fn titles(client: &tokio_postgres::Client, query: &str) -> Vec<String> {
+    client.query(query, &[]).await.unwrap().iter().map(|row| row.get(0)).collect()
+}
+
+/// Test inserts, updates and deletes through a simple view and its WITH CHECK OPTION
+#[tokio::test]
+async fn test_view_with_check_option() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT NOT NULL, is_available BOOLEAN NOT NULL DEFAULT true)").await?;
+            db.execute("INSERT INTO books (id, title, is_available) VALUES (1, 'Dune', true), (2, 'Emma', false)").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE VIEW available_books AS SELECT id, title, is_available FROM books WHERE is_available WITH CHECK OPTION"
+    ).await.unwrap();
+    assert_eq!(titles(client, "SELECT title FROM available_books ORDER BY id").await, vec!["Dune"]);
+
+    // A row visible through the view can be inserted; the base table default applies
+    client.execute("INSERT INTO available_books (id, title) VALUES (3, 'Persuasion')", &[]).await.unwrap();
+    client.execute("INSERT INTO available_books (id, title, is_available) VALUES (4, 'Middlemarch', true)", &[]).await.unwrap();
+    assert_eq!(titles(client, "SELECT title FROM available_books ORDER BY id").await, vec!["Dune", "Persuasion", "Middlemarch"]);
+
+    // A row the view wouldn't show is rejected
+    let err = client.execute("INSERT INTO available_books (id, title, is_available) VALUES (5, 'Ulysses', false)", &[]).await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::WITH_CHECK_OPTION_VIOLATION));
+    assert_eq!(err.as_db_error().unwrap().message(), "new row violates check option for view \"available_books\"");
+    let err = client.simple_query("UPDATE available_books SET is_available = false WHERE id = 1").await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::WITH_CHECK_OPTION_VIOLATION));
+
+    // Updates and deletes keeping rows visible go through to the table
+    client.execute("UPDATE available_books SET title = 'Dune Messiah' WHERE id = 1", &[]).await.unwrap();
+    client.execute("DELETE FROM available_books WHERE id = 4", &[]).await.unwrap();
+    assert_eq!(titles(client, "SELECT title FROM books ORDER BY id").await, vec!["Dune Messiah", "Emma", "Persuasion"]);
+
+    // Without the option, a simple view is still updatable
+    client.batch_execute("CREATE VIEW book_titles AS SELECT id, title FROM books").await.unwrap();
+    client.execute("UPDATE book_titles SET title = 'Emma.' WHERE id = 2", &[]).await.unwrap();
+    assert_eq!(titles(client, "SELECT title FROM books WHERE id = 2").await, vec!["Emma."]);
+}
@@ -0,0 +1,50 @@
+mod common;
+use common::*;
+
+/// Test uuid_generate_v4() and uuid_generate_v1() as calls and column defaults
+#[tokio::test]
+async fn test_uuid_ossp_generators() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let result = client.simple_query("SELECT uuid_generate_v4(), uuid_generate_v1()").await.unwrap();
+    let row = &rows(&result)[0];
+    let v4 = uuid::Uuid::parse_str(row[0].as_deref().unwrap()).unwrap();
+    let v1 = uuid::Uuid::parse_str(row[1].as_deref().unwrap()).unwrap();
+    assert_eq!(v4.get_version_num(), 4);
+    assert_eq!(v1.get_version_num(), 1);
+
+    client.batch_execute(
+        "CREATE TABLE orders (id UUID PRIMARY KEY DEFAULT uuid_generate_v4(), tracking_id UUID DEFAULT uuid_generate_v1(), note TEXT);
+         INSERT INTO orders (note) VALUES ('a'), ('b'), ('c');"
+    ).await.unwrap();
+
+    let ids = simple_values(client, "SELECT id FROM orders").await;
+    assert_eq!(ids.len(), 3);
+    assert!(ids.iter().all(|id| uuid::Uuid::parse_str(id).unwrap().get_version_num() == 4));
+    let distinct = simple_values(client, "SELECT COUNT(DISTINCT tracking_id) FROM orders").await;
+    assert_eq!(distinct, vec!["3"]);
+}
+
+/// Test that gen_random_bytes() returns the requested number of bytes
+#[tokio::test]
+async fn test_gen_random_bytes() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    for count in [1, 16, 32, 1024] {
+        let row = client.query_one(&format!("SELECT gen_random_bytes({count}) AS bytes"), &[]).await.unwrap();
+        let bytes: Vec<u8> = row.get("bytes");
+        assert_eq!(bytes.len(), count);
+    }
+
+    let row = client.query_one(
+        "SELECT encode(gen_random_bytes(16), 'hex') AS token",
+        &[],
+    ).await.unwrap();
+    let token: String = row.get("token");
+    assert_eq!(token.len(), 32);
+
+    let err = client.simple_query("SELECT gen_random_bytes(2048)").await.unwrap_err();
+    assert_eq!(err.as_db_error().unwrap().code().code(), "22023");
+}
@@ -0,0 +1,43 @@
+mod common;
+use common::*;
+
+/// Test VACUUM, VACUUM FULL and VACUUM ANALYZE outside a transaction
+#[tokio::test]
+async fn test_vacuum_outside_transaction() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (id INTEGER PRIMARY KEY, genre TEXT)").await?;
+            db.execute("INSERT INTO books (id, genre) VALUES (1, 'scifi'), (2, 'scifi'), (3, 'fantasy')").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    client.batch_execute("VACUUM").await.unwrap();
+    client.batch_execute("VACUUM FULL books").await.unwrap();
+    client.batch_execute("VACUUM ANALYZE books").await.unwrap();
+
+    // VACUUM ANALYZE leaves statistics behind for pg_stats
+    let most_common_vals = first_value(
+        client,
+        "SELECT most_common_vals FROM pg_stats WHERE tablename = 'books' AND attname = 'genre'"
+    ).await;
+    assert_eq!(most_common_vals.as_deref(), Some("{scifi}"));
+
+    let rows = client.query("SELECT count(*) FROM books", &[]).await.unwrap();
+    assert_eq!(rows[0].get::<_, i64>(0), 3);
+}
+
+/// Test that VACUUM is rejected inside a transaction block
+#[tokio::test]
+async fn test_vacuum_inside_transaction() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute("BEGIN").await.unwrap();
+    let err = client.batch_execute("VACUUM").await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::ACTIVE_SQL_TRANSACTION));
+    client.batch_execute("ROLLBACK").await.unwrap();
+
+    client.batch_execute("VACUUM").await.unwrap();
+}
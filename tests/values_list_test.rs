@@ -0,0 +1,58 @@
+mod common;
+use common::*;
+use tokio_postgres::types::Type;
+
+/// Test VALUES as a statement of its own: columns are column1, column2, ... typed from the first row
+#[tokio::test]
+async fn test_standalone_values() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let rows = client.query("VALUES (1, 'one'), (2, 'two')", &[]).await.unwrap();
+    assert_eq!(rows.len(), 2);
+    let columns = rows[0].columns();
+    assert_eq!(columns[0].name(), "column1");
+    assert_eq!(columns[0].type_(), &Type::INT4);
+    assert_eq!(columns[1].name(), "column2");
+    assert_eq!(columns[1].type_(), &Type::TEXT);
+    assert_eq!(rows[1].get::<_, i32>(0), 2);
+    assert_eq!(rows[1].get::<_, &str>(1), "two");
+
+    let results = client.simple_query("VALUES (1, 'one'), (2, 'two')").await.unwrap();
+    assert_eq!(column(&results, "column1"), vec!["1", "2"]);
+    assert_eq!(column(&results, "column2"), vec!["one", "two"]);
+}
+
+/// Test a VALUES derived table whose columns are named by the alias list
+#[tokio::test]
+async fn test_values_derived_table_aliases() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE orders (id INTEGER PRIMARY KEY, status_code TEXT)").await?;
+            db.execute("INSERT INTO orders VALUES (1, 'S'), (2, 'P'), (3, 'S')").await?;
+            Ok(())
+        })
+    }).await;
+    let client = &server.client;
+
+    let rows = client.query(
+        "SELECT * FROM (VALUES (1, 'a'), (2, 'b')) AS t(id, name) ORDER BY id DESC",
+        &[]
+    ).await.unwrap();
+    let columns = rows[0].columns();
+    assert_eq!(columns[0].name(), "id");
+    assert_eq!(columns[0].type_(), &Type::INT4);
+    assert_eq!(columns[1].name(), "name");
+    assert_eq!(columns[1].type_(), &Type::TEXT);
+    let values: Vec<(i32, &str)> = rows.iter().map(|row| (row.get(0), row.get(1))).collect();
+    assert_eq!(values, vec![(2, "b"), (1, "a")]);
+
+    // Bulk mapping through a join on the aliased columns
+    let rows = client.query(
+        "SELECT o.id, m.label FROM orders o JOIN (VALUES ('S', 'shipped'), ('P', 'pending')) m(code, label) \
+         ON m.code = o.status_code ORDER BY o.id",
+        &[]
+    ).await.unwrap();
+    let labels: Vec<&str> = rows.iter().map(|row| row.get(1)).collect();
+    assert_eq!(labels, vec!["shipped", "pending", "shipped"]);
+}
@@ -0,0 +1,106 @@
+mod common;
+use common::*;
+
+/// Test named WINDOW definitions shared across several window function calls
+#[tokio::test]
+async fn test_named_window_clause() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (
+                id INTEGER PRIMARY KEY,
+                author_id INTEGER NOT NULL,
+                title TEXT NOT NULL,
+                price DOUBLE PRECISION NOT NULL
+            )").await?;
+
+            db.execute("INSERT INTO books (id, author_id, title, price) VALUES
+                (1, 1, 'Alpha', 10.0),
+                (2, 1, 'Beta', 20.0),
+                (3, 1, 'Gamma', 15.0),
+                (4, 2, 'Delta', 5.0),
+                (5, 2, 'Epsilon', 7.5)").await?;
+
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    let rows = client.query(
+        "SELECT title, row_number() OVER w AS rn, first_value(title) OVER w AS cheapest \
+         FROM books \
+         WINDOW w AS (PARTITION BY author_id ORDER BY price) \
+         ORDER BY author_id, rn",
+        &[]
+    ).await.unwrap();
+
+    assert_eq!(rows.len(), 5);
+
+    let expected = [
+        ("Alpha", 1i64, "Alpha"),
+        ("Gamma", 2, "Alpha"),
+        ("Beta", 3, "Alpha"),
+        ("Delta", 1, "Delta"),
+        ("Epsilon", 2, "Delta"),
+    ];
+    for (row, (title, rn, cheapest)) in rows.iter().zip(expected.iter()) {
+        assert_eq!(row.get::<_, String>("title"), *title);
+        // row_number() must be typed as bigint for this to decode
+        assert_eq!(row.get::<_, i64>("rn"), *rn);
+        assert_eq!(row.get::<_, String>("cheapest"), *cheapest);
+    }
+}
+
+/// Test several named windows in one query through the simple query protocol
+#[tokio::test]
+async fn test_multiple_named_windows() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE books (
+                id INTEGER PRIMARY KEY,
+                author_id INTEGER NOT NULL,
+                price DOUBLE PRECISION NOT NULL
+            )").await?;
+
+            db.execute("INSERT INTO books (id, author_id, price) VALUES
+                (1, 1, 10.0), (2, 1, 20.0), (3, 2, 5.0)").await?;
+
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    let messages = client.simple_query(
+        "SELECT id, rank() OVER by_author AS author_rank, rank() OVER overall AS overall_rank \
+         FROM books \
+         WINDOW by_author AS (PARTITION BY author_id ORDER BY price DESC), overall AS (ORDER BY price DESC) \
+         ORDER BY id"
+    ).await.unwrap();
+
+    assert_eq!(column(&messages, "author_rank"), vec!["2", "1", "1"]);
+    assert_eq!(column(&messages, "overall_rank"), vec!["2", "1", "3"]);
+}
+
+/// Test that running sums are typed from their column: bigint over integers, float8 over floats
+#[tokio::test]
+async fn test_window_sum_types() {
+    let server = setup_test_server_with_init(|db| {
+        Box::pin(async move {
+            db.execute("CREATE TABLE items (id INTEGER PRIMARY KEY, qty INTEGER NOT NULL, weight DOUBLE PRECISION NOT NULL)").await?;
+            db.execute("INSERT INTO items (id, qty, weight) VALUES (1, 2, 1.5), (2, 3, 0.25), (3, 5, 2.0)").await?;
+            Ok(())
+        })
+    }).await;
+
+    let client = &server.client;
+
+    let rows = client.query(
+        "SELECT id, sum(qty) OVER w AS total_qty, sum(weight) OVER w AS total_weight \
+         FROM items WINDOW w AS (ORDER BY id) ORDER BY id",
+        &[]
+    ).await.unwrap();
+
+    let totals: Vec<(i64, f64)> = rows.iter().map(|row| (row.get("total_qty"), row.get("total_weight"))).collect();
+    assert_eq!(totals, vec![(2, 1.5), (5, 1.75), (10, 3.75)]);
+}
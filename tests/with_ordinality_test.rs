@@ -0,0 +1,34 @@
+mod common;
+use common::*;
+use tokio_postgres::SimpleQueryMessage;
+
+/// Test unnesting tags WITH ORDINALITY under renamed columns
+#[tokio::test]
+async fn test_unnest_with_ordinality_column_aliases() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    let result = client.simple_query(
+        "SELECT * FROM unnest('[\"red\", \"green\", \"blue\"]') WITH ORDINALITY AS t(tag, pos) ORDER BY pos"
+    ).await.unwrap();
+
+    let SimpleQueryMessage::RowDescription(columns) = &result[0] else {
+        panic!("expected a RowDescription, got {:?}", result[0]);
+    };
+    let names: Vec<&str> = columns.iter().map(|c| c.name()).collect();
+    assert_eq!(names, vec!["tag", "pos"]);
+
+    assert_eq!(rows(&result), vec![
+        some(&["red", "1"]),
+        some(&["green", "2"]),
+        some(&["blue", "3"]),
+    ]);
+
+    // Positions come back as bigint
+    let rows = client.query(
+        "SELECT tag, pos FROM unnest('[\"a\", \"b\"]') WITH ORDINALITY AS t(tag, pos) WHERE pos > 1", &[]
+    ).await.unwrap();
+    assert_eq!(rows.len(), 1);
+    assert_eq!(rows[0].get::<_, &str>(0), "b");
+    assert_eq!(rows[0].get::<_, i64>(1), 2);
+}
@@ -0,0 +1,61 @@
+mod common;
+use common::*;
+
+/// Test that xml columns accept well-formed values and reject malformed ones
+#[tokio::test]
+async fn test_xml_column_validation() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE orders (id INTEGER PRIMARY KEY, payload XML);
+         INSERT INTO orders (id, payload) VALUES (1, '<order><item sku=\"A1\">Widget</item></order>');
+         INSERT INTO orders (id, payload) VALUES (2, NULL);"
+    ).await.unwrap();
+
+    let results = client.simple_query("SELECT payload FROM orders ORDER BY id").await.unwrap();
+    assert_eq!(rows(&results), vec![
+        some(&["<order><item sku=\"A1\">Widget</item></order>"]),
+        vec![None],
+    ]);
+
+    // Unclosed and mismatched tags are rejected, on INSERT and UPDATE alike
+    let err = client.execute("INSERT INTO orders (id, payload) VALUES (3, '<order><item></order>')", &[]).await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::INVALID_XML_DOCUMENT));
+    let err = client.execute("UPDATE orders SET payload = '<order>' WHERE id = 1", &[]).await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::INVALID_XML_DOCUMENT));
+    let err = client.simple_query("SELECT '<a><b></a>'::xml").await.unwrap_err();
+    assert_eq!(err.code(), Some(&tokio_postgres::error::SqlState::INVALID_XML_DOCUMENT));
+
+    assert_eq!(simple_values(client, "SELECT count(*) FROM orders").await, vec!["2"]);
+
+    // The column is described with the xml type
+    let stmt = client.prepare("SELECT payload FROM orders").await.unwrap();
+    assert_eq!(stmt.columns()[0].type_().oid(), 142);
+}
+
+/// Test xpath() selecting elements, text and attributes
+#[tokio::test]
+async fn test_xpath() {
+    let server = setup_test_server().await;
+    let client = &server.client;
+
+    client.batch_execute(
+        "CREATE TABLE catalogs (id INTEGER PRIMARY KEY, doc XML);
+         INSERT INTO catalogs (id, doc) VALUES (1, '<catalog><book id=\"7\"><title>Dune</title></book><book id=\"9\"><title>Emma</title></book></catalog>');"
+    ).await.unwrap();
+
+    let row = client.query_one("SELECT xpath('/catalog/book/title/text()', doc) AS titles FROM catalogs", &[]).await.unwrap();
+    let titles: Vec<String> = row.get("titles");
+    assert_eq!(titles, vec!["Dune", "Emma"]);
+
+    let row = client.query_one("SELECT xpath('//book[@id=''9'']/title', doc) AS titles FROM catalogs", &[]).await.unwrap();
+    let titles: Vec<String> = row.get("titles");
+    assert_eq!(titles, vec!["<title>Emma</title>"]);
+
+    assert_eq!(simple_values(client, "SELECT xpath('/catalog/book/@id', doc) FROM catalogs").await, vec!["{7,9}"]);
+
+    let row = client.query_one("SELECT xpath('/catalog/magazine', doc) AS magazines FROM catalogs", &[]).await.unwrap();
+    let magazines: Vec<String> = row.get("magazines");
+    assert!(magazines.is_empty());
+}